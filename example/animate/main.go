@@ -4,11 +4,7 @@ import (
 	"context"
 	"fmt"
 	"image"
-	"image/color/palette"
-	"image/draw"
-	"image/gif"
 	"log"
-	"math"
 	"os"
 	"path/filepath"
 	"time"
@@ -17,39 +13,6 @@ import (
 	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
 )
 
-// lerpPoint returns the linear interpolation between a and b by t in [0,1].
-func lerpPoint(a, b r3.Point, t float64) r3.Point {
-	return r3.Point{
-		X: a.X + (b.X-a.X)*t,
-		Y: a.Y + (b.Y-a.Y)*t,
-		Z: a.Z + (b.Z-a.Z)*t,
-	}
-}
-
-// buildLinearDolly returns a phys.CameraFunc that produces a calibrated camera
-// whose LookFrom linearly interpolates from p0 to p1 as u goes from 0 to 1.
-// The function is pure and has no side effects.
-func buildLinearDolly(
-	intr phys.CameraIntrinsics,
-	p0 r3.Point,
-	p1 r3.Point,
-	lookAt r3.Point,
-	vup r3.Vec,
-) phys.CameraFunc {
-	return func(u float64) phys.Camera {
-		uWrapped := u - math.Floor(u) // wrap into [0,1)
-		lookFrom := lerpPoint(p0, p1, uWrapped)
-		return phys.CalibratedCamera{
-			Intrinsics: intr,
-			Extrinsics: phys.CameraExtrinsics{
-				LookFrom: lookFrom,
-				LookAt:   lookAt,
-				VUp:      vup,
-			},
-		}
-	}
-}
-
 // newScene constructs a minimal scene with a lit checkerboard ground plane,
 // origin axes, and a reference sphere. The function returns a validated
 // phys.Scene using the provided render dimensions.
@@ -108,23 +71,17 @@ func newScene(dx, dy int) phys.Scene {
 	return scene
 }
 
-// palettize converts an RGBA frame to a Paletted frame suitable for GIF.
-// The function uses Floyd–Steinberg dithering and the WebSafe palette.
-func palettize(src *image.RGBA) *image.Paletted {
-	dst := image.NewPaletted(src.Bounds(), palette.WebSafe)
-	draw.FloydSteinberg.Draw(dst, dst.Rect, src, image.Point{})
-	return dst
-}
-
-// main sets up an AnimatedCamera for a linear dolly and renders a short sequence.
-// Each frame is saved as a before/after PNG and also appended to an animation GIF.
+// main sets up an AnimatedCamera for a linear dolly and renders a short
+// sequence, writing each frame into a phys.Encoder. outPath's extension
+// picks the format: .gif (paletted, Floyd-Steinberg dithered), .png (true
+// color APNG), or .mp4 (shells out to ffmpeg).
 func main() {
 	// Render settings.
 	const (
 		dx      = 1440 / 5
 		dy      = 1080 / 5
 		nFrames = 60
-		fps     = 60 // frames per second in the output GIF
+		fps     = 60 // frames per second in the output animation
 	)
 
 	// Choose intrinsics (dimensions do not need to match dx,dy for this demo).
@@ -136,8 +93,15 @@ func main() {
 	lookAt := r3.Point{X: 0, Y: 0, Z: 25 * float64(phys.MM)}
 	vup := r3.Vec{X: 0, Y: 0, Z: -1}
 
-	build := buildLinearDolly(intr, p0, p1, lookAt, vup)
-	ac := phys.NewAnimatedCamera(build, 0, 2*time.Second) // 2 s cycle (helpers use this)
+	timeline := phys.NewCameraTimeline([]phys.CameraTimelineKeyframe{
+		{T: 0, LookFrom: p0, LookAt: lookAt, VUp: vup, Intrinsics: intr},
+		{T: 2 * time.Second, LookFrom: p1, LookAt: lookAt, VUp: vup, Intrinsics: intr},
+	}, 2*time.Second, phys.InterpolationEaseInOut)
+	if err := timeline.Validate(); err != nil {
+		log.Fatalf("invalid camera timeline: %v", err)
+	}
+	ac := timeline.Animate()                   // 2 s cycle (helpers use this)
+	ac.ShutterDuration = 10 * time.Millisecond // blurs the dolly's motion within each frame
 
 	// Prepare scene and output directory.
 	scene := newScene(dx, dy)
@@ -145,15 +109,14 @@ func main() {
 		log.Fatalf("failed to create out directory: %v", err)
 	}
 
-	// Prepare GIF container (side-by-side width).
-	delayCS := int(math.Round(100.0 / float64(fps))) // delay in 1/100 s
-	anim := &gif.GIF{
-		Image:     make([]*image.Paletted, 0, nFrames),
-		Delay:     make([]int, 0, nFrames),
-		LoopCount: 0, // loop forever
+	outPath := "./out/animation.gif"
+	enc, err := phys.NewEncoder(outPath, fps)
+	if err != nil {
+		log.Fatalf("failed to create encoder for %s: %v", outPath, err)
 	}
 
 	ctx := context.Background()
+	frames := make([]*image.RGBA, 0, nFrames)
 	for i := 0; i < nFrames; i++ {
 		u := float64(i) / float64(nFrames) // sample [0,1)
 		scene.Camera = []phys.Camera{ac.WithU(u)}
@@ -162,30 +125,23 @@ func main() {
 		if err != nil {
 			log.Fatalf("render failed at frame %d: %v", i, err)
 		}
-
-		// Append to GIF.
-		anim.Image = append(anim.Image, palettize(artifact.Image))
-		anim.Delay = append(anim.Delay, delayCS)
+		if err := enc.WriteFrame(artifact.Image); err != nil {
+			log.Fatalf("WriteFrame(%d): %v", i, err)
+		}
+		frames = append(frames, artifact.Image)
 
 		fmt.Printf("rendered frame %d/%d\n", i+1, nFrames)
 	}
 
-	// Make animation loop by mirroring frames.
+	// Make the animation loop by mirroring frames back to the start.
 	for i := nFrames - 1; i >= 0; i-- {
-		anim.Image = append(anim.Image, anim.Image[i])
-		anim.Delay = append(anim.Delay, delayCS)
-	}
-
-	// Write animated GIF.
-	outPath := "./out/animation.gif"
-	f, err := os.Create(outPath)
-	if err != nil {
-		log.Fatalf("failed to create %s: %v", outPath, err)
+		if err := enc.WriteFrame(frames[i]); err != nil {
+			log.Fatalf("WriteFrame (mirror %d): %v", i, err)
+		}
 	}
-	defer f.Close()
 
-	if err := gif.EncodeAll(f, anim); err != nil {
-		log.Fatalf("gif encode failed: %v", err)
+	if err := enc.Close(); err != nil {
+		log.Fatalf("encoder Close failed: %v", err)
 	}
-	fmt.Printf("✅ wrote %s (%d frames at ~%d fps)\n", filepath.ToSlash(outPath), len(anim.Image), fps)
+	fmt.Printf("✅ wrote %s (%d frames at ~%d fps)\n", filepath.ToSlash(outPath), 2*nFrames, fps)
 }
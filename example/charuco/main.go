@@ -16,6 +16,14 @@ import (
 	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
 )
 
+// scenePath is the hot-reloaded scene description driving this demo. Edit
+// it and save -- phys.WatchScene picks up the change on its next poll and
+// the orbit loop below re-renders with whatever Node/Light/RenderOptions
+// it now describes. See scene.json for the starting point; Camera is
+// always overridden per frame below, so its entry there only exists to
+// satisfy Scene.Validate for other tools that load the file directly.
+const scenePath = "scene.json"
+
 func main() {
 	// All window/GL calls on main OS thread.
 	runtime.LockOSThread()
@@ -34,203 +42,21 @@ func main() {
 	}
 	defer win.Close()
 
-	// -------- Scene constants --------
-	var colormap = []phys.Spectrum{
-		{X: 0.5, Y: 0.5, Z: 0.5},
-
-		{X: 227.0 / 255.0, Y: 26.0 / 255.0, Z: 28.0 / 255.0},   // dark red
-		{X: 251.0 / 255.0, Y: 154.0 / 255.0, Z: 153.0 / 255.0}, // light red
-
-		{X: 51.0 / 255.0, Y: 160.0 / 255.0, Z: 44.0 / 255.0},   // dark green
-		{X: 178.0 / 255.0, Y: 223.0 / 255.0, Z: 138.0 / 255.0}, // light green
-
-		{X: 31.0 / 255.0, Y: 120.0 / 255.0, Z: 180.0 / 255.0},  // dark blue
-		{X: 166.0 / 255.0, Y: 206.0 / 255.0, Z: 227.0 / 255.0}, // light blue
-
-		{X: 1, Y: 127.0 / 255.0, Z: 0.0 / 255.0},               // dark orange
-		{X: 253.0 / 255.0, Y: 191.0 / 255.0, Z: 111.0 / 255.0}, // light orange
-	}
-	// 203, 136, 206
-	P := phys.Spectrum{X: 203.0 / 255.0, Y: 136.0 / 255.0, Z: 206.0 / 255.0} // purple
-
-	// Box dimensions
-	const W = phys.MM * 150
-	const H = phys.MM * 150
-	const D = phys.MM * 150
-
-	colorX := phys.Spectrum{X: 1, Y: 0, Z: 157.0 / 255.0}
-	colorY := phys.Spectrum{X: 157 / 255.0, Y: 1, Z: 0}
-	colorZ := phys.Spectrum{X: 0 / 255.0, Y: 57.0 / 255.0, Z: 1}
-
-	// Base scene (camera updated per frame)
-	scene := phys.Scene{
-		RenderOptions: phys.RenderOptions{
-			Seed:         0,
-			RaysPerPixel: 1,    // keep realtime-ish; increase for quality
-			MaxRayDepth:  6,    // modest recursion
-			Dx:           winW, // match window size
-			Dy:           winH,
-		},
-		Light: []phys.Light{
-			phys.PointLight{
-				Position: r3.Point{X: float64(500 * phys.MM), Y: float64(500 * phys.MM), Z: float64(500 * phys.MM)},
-				RadiantIntensity: r3.Vec{
-					X: 0.3, Y: 0.3, Z: 0.3,
-				},
-			},
-		},
-		Camera: []phys.Camera{}, // set each frame
-		Node: []phys.Node{
-			node("Origin", phys.Emitter{Texture: phys.TextureUniform{Color: colormap[0]}}, 0, 0, 0),
-			node("P1", phys.Emitter{Texture: phys.TextureUniform{Color: P}}, -float64(W)/2, -float64(H)/2, float64(D)),
-			node("P2", phys.Emitter{Texture: phys.TextureUniform{Color: P}}, float64(W)/2, -float64(H)/2, float64(D)),
-			node("P3", phys.Emitter{Texture: phys.TextureUniform{Color: P}}, float64(W)/2, float64(H)/2, float64(D)),
-			node("P4", phys.Emitter{Texture: phys.TextureUniform{Color: P}}, -float64(W)/2, float64(H)/2, float64(D)),
-
-			node("P5", phys.Emitter{Texture: phys.TextureUniform{Color: P}}, -float64(W)/2, -float64(H)/2, 0),
-			node("P6", phys.Emitter{Texture: phys.TextureUniform{Color: P}}, float64(W)/2, -float64(H)/2, 0),
-			node("P7", phys.Emitter{Texture: phys.TextureUniform{Color: P}}, float64(W)/2, float64(H)/2, 0),
-			node("P8", phys.Emitter{Texture: phys.TextureUniform{Color: P}}, -float64(W)/2, float64(H)/2, 0),
-
-			// Tiny axes at origin
-			phys.PropAxes(r3.Point{X: 0, Y: 0, Z: 0}, 0.5*phys.MM, 20*phys.MM, "")[0],
-			phys.PropAxes(r3.Point{X: 0, Y: 0, Z: 0}, 0.5*phys.MM, 20*phys.MM, "")[1],
-			phys.PropAxes(r3.Point{X: 0, Y: 0, Z: 0}, 0.5*phys.MM, 20*phys.MM, "")[2],
-
-			// 12 rods outlining the box edges
-			{
-				Name:     "AxisX1Top",
-				Material: phys.Emitter{Texture: phys.TextureUniform{Color: colorX}},
-				Shape: phys.Cylinder{
-					Origin:    r3.Point{X: -float64(W) / 2, Y: -float64(H) / 2, Z: float64(D)},
-					Direction: r3.Vec{X: 1, Y: 0, Z: 0},
-					Radius:    1 * phys.MM,
-					Height:    W,
-				},
-			},
-			{
-				Name:     "AxisX2Top",
-				Material: phys.Emitter{Texture: phys.TextureUniform{Color: colorX}},
-				Shape: phys.Cylinder{
-					Origin:    r3.Point{X: -float64(W) / 2, Y: float64(H) / 2, Z: float64(D)},
-					Direction: r3.Vec{X: 1, Y: 0, Z: 0},
-					Radius:    1 * phys.MM,
-					Height:    W,
-				},
-			},
-			{
-				Name:     "AxisY1Top",
-				Material: phys.Emitter{Texture: phys.TextureUniform{Color: colorY}},
-				Shape: phys.Cylinder{
-					Origin:    r3.Point{X: -float64(W) / 2, Y: -float64(H) / 2, Z: float64(D)},
-					Direction: r3.Vec{X: 0, Y: 1, Z: 0},
-					Radius:    1 * phys.MM,
-					Height:    H,
-				},
-			},
-			{
-				Name:     "AxisY2Top",
-				Material: phys.Emitter{Texture: phys.TextureUniform{Color: colorY}},
-				Shape: phys.Cylinder{
-					Origin:    r3.Point{X: float64(W) / 2, Y: -float64(H) / 2, Z: float64(D)},
-					Direction: r3.Vec{X: 0, Y: 1, Z: 0},
-					Radius:    1 * phys.MM,
-					Height:    H,
-				},
-			},
-			{
-				Name:     "AxisX1Bottom",
-				Material: phys.Emitter{Texture: phys.TextureUniform{Color: colorX}},
-				Shape: phys.Cylinder{
-					Origin:    r3.Point{X: -float64(W) / 2, Y: -float64(H) / 2, Z: 0},
-					Direction: r3.Vec{X: 1, Y: 0, Z: 0},
-					Radius:    1 * phys.MM,
-					Height:    W,
-				},
-			},
-			{
-				Name:     "AxisX2Bottom",
-				Material: phys.Emitter{Texture: phys.TextureUniform{Color: colorX}},
-				Shape: phys.Cylinder{
-					Origin:    r3.Point{X: -float64(W) / 2, Y: float64(H) / 2, Z: 0},
-					Direction: r3.Vec{X: 1, Y: 0, Z: 0},
-					Radius:    1 * phys.MM,
-					Height:    W,
-				},
-			},
-			{
-				Name:     "AxisZ1Top",
-				Material: phys.Emitter{Texture: phys.TextureUniform{Color: colorZ}},
-				Shape: phys.Cylinder{
-					Origin:    r3.Point{X: -float64(W) / 2, Y: float64(H) / 2, Z: 0},
-					Direction: r3.Vec{X: 0, Y: 0, Z: 1},
-					Radius:    1 * phys.MM,
-					Height:    D,
-				},
-			},
-			{
-				Name:     "AxisZ2Top",
-				Material: phys.Emitter{Texture: phys.TextureUniform{Color: colorZ}},
-				Shape: phys.Cylinder{
-					Origin:    r3.Point{X: float64(W) / 2, Y: float64(H) / 2, Z: 0},
-					Direction: r3.Vec{X: 0, Y: 0, Z: 1},
-					Radius:    1 * phys.MM,
-					Height:    D,
-				},
-			},
-			{
-				Name:     "AxisZ1Bottom",
-				Material: phys.Emitter{Texture: phys.TextureUniform{Color: colorZ}},
-				Shape: phys.Cylinder{
-					Origin:    r3.Point{X: -float64(W) / 2, Y: -float64(H) / 2, Z: 0},
-					Direction: r3.Vec{X: 0, Y: 0, Z: 1},
-					Radius:    1 * phys.MM,
-					Height:    D,
-				},
-			},
-			{
-				Name:     "AxisZ2Bottom",
-				Material: phys.Emitter{Texture: phys.TextureUniform{Color: colorZ}},
-				Shape: phys.Cylinder{
-					Origin:    r3.Point{X: float64(W) / 2, Y: -float64(H) / 2, Z: 0},
-					Direction: r3.Vec{X: 0, Y: 0, Z: 1},
-					Radius:    1 * phys.MM,
-					Height:    D,
-				},
-			},
-			{
-				Name:     "AxisY1Bottom",
-				Material: phys.Emitter{Texture: phys.TextureUniform{Color: colorY}},
-				Shape: phys.Cylinder{
-					Origin:    r3.Point{X: -float64(W) / 2, Y: -float64(H) / 2, Z: 0},
-					Direction: r3.Vec{X: 0, Y: 1, Z: 0},
-					Radius:    1 * phys.MM,
-					Height:    H,
-				},
-			},
-			{
-				Name:     "AxisY2Bottom",
-				Material: phys.Emitter{Texture: phys.TextureUniform{Color: colorY}},
-				Shape: phys.Cylinder{
-					Origin:    r3.Point{X: float64(W) / 2, Y: -float64(H) / 2, Z: 0},
-					Direction: r3.Vec{X: 0, Y: 1, Z: 0},
-					Radius:    1 * phys.MM,
-					Height:    H,
-				},
-			},
-
-			{
-				Name:     "CharucoSquare150MM",
-				Material: phys.Emitter{Texture: phys.MustNewTextureImage("./asset/Square150MM.png", "", "")},
-				Shape: phys.Quad{
-					Center: r3.Point{X: 0, Y: 0, Z: 0},
-					Width:  150 * phys.MM,
-					Height: 150 * phys.MM,
-					Normal: r3.Vec{X: 0, Y: 0, Z: 1},
-				},
-			},
-		},
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sceneCh, sceneErrCh := phys.WatchScene(ctx, scenePath)
+
+	// Block for the first successfully parsed scene; a missing or broken
+	// scene.json at startup is fatal, same as phys.LoadScene failing
+	// outright would have been before this demo grew hot-reload.
+	var scene *phys.Scene
+	select {
+	case scene = <-sceneCh:
+	case err := <-sceneErrCh:
+		log.Fatalf("loading %s: %v", scenePath, err)
 	}
+	scene.RenderOptions.Dx = winW // match window size regardless of what scene.json says
+	scene.RenderOptions.Dy = winH
 
 	fmt.Printf("bbox: %v\n", scene.Node[0].Shape.Bounds())
 
@@ -243,11 +69,12 @@ func main() {
 	const orbitRadius = 400 * phys.MM // distance in XY plane
 	const orbitHeight = 320 * phys.MM // Z height
 
-	// Frame cache (filled once during first orbit)
+	// Frame cache (filled once during first orbit); reset whenever a new
+	// scene arrives on sceneCh so a hot-reloaded scene.json edit shows up
+	// in subsequent frames instead of replaying stale cached ones.
 	cache := make([]*image.RGBA, framesPerOrbit)
 
 	// -------- Main loop --------
-	ctx := context.Background()
 	frameIndex := 0
 	firstOrbitDone := false
 
@@ -260,6 +87,21 @@ func main() {
 		frameStart := time.Now()
 		win.PollEvents()
 
+		// Pick up a hot-reloaded scene.json without blocking; an update
+		// invalidates the frame cache so the change is actually seen.
+		select {
+		case s := <-sceneCh:
+			s.RenderOptions.Dx = winW
+			s.RenderOptions.Dy = winH
+			scene = s
+			cache = make([]*image.RGBA, framesPerOrbit)
+			firstOrbitDone = false
+			log.Println("scene.json reloaded")
+		case err := <-sceneErrCh:
+			log.Printf("%s: %v (keeping previous scene)", scenePath, err)
+		default:
+		}
+
 		idx := frameIndex % framesPerOrbit
 		var img image.Image
 
@@ -274,7 +116,7 @@ func main() {
 				Y: float64(orbitRadius) * math.Sin(theta),
 				Z: float64(orbitHeight),
 			}
-			lookAt := r3.Point{X: 0, Y: 0, Z: float64(D) / 2}
+			lookAt := r3.Point{X: 0, Y: 0, Z: 75 * phys.MM}
 			vup := r3.Vec{X: 0, Y: 0, Z: -1}
 
 			scene.Camera = []phys.Camera{
@@ -289,7 +131,7 @@ func main() {
 			}
 
 			// Render this frame
-			res, err := phys.Render(ctx, &scene)
+			res, err := phys.Render(ctx, scene)
 			if err != nil {
 				fmt.Println("render error:", err)
 				return
@@ -339,16 +181,6 @@ func main() {
 	}
 }
 
-func node(name string, mat phys.Material, x, y, z float64) phys.Node {
-	// Create a sphere with a radius of 4mm.
-	radius := 4 * phys.MM
-	return phys.Node{
-		Name:     name,
-		Shape:    phys.Sphere{Center: r3.Point{X: x, Y: y, Z: z}, Radius: radius},
-		Material: mat,
-	}
-}
-
 // cloneRGBA makes a deep *image.RGBA copy of an image.Image.
 func cloneRGBA(src image.Image) *image.RGBA {
 	r := src.Bounds()
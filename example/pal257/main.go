@@ -9,16 +9,53 @@
 //   - While paused, click+drag adjusts the frame *relative* to pointer movement
 //     (smooth, no jumps). When resuming play, playback continues from the
 //     scrubbed frame (no jump back to the old animation position).
+//
+// Scene source:
+//   - By default the program renders the built-in newScene geometry.
+//   - Pass -scene path/to/file.scene.json to render a declarative
+//     phys.Scene file instead; see phys.LoadScene for the file format.
+//   - Dropping a file whose name ends in ".scene.json" onto the window
+//     hot-reloads that scene: playback resets to the start of the orbit
+//     and continues with the new geometry, without recompiling.
+//
+// Camera path:
+//   - The camera follows cfg.timeline, a phys.Timeline (default:
+//     buildOrbitTimeline, a Catmull-Rom ring reproducing the old fixed
+//     circular orbit). Library callers of run() can set cfg.timeline to
+//     any keyframe list without recompiling the orbit math.
+//   - A thin scrub bar along the bottom of the window shows cached
+//     (green) vs uncached (gray) frames, with a white marker at showIdx.
+//   - Left/Right arrow keys jump to the previous/next keyframe's frame,
+//     pausing playback there.
+//
+// Render backend:
+//   - scene.RenderOptions.Backend selects the phys.Renderer: the default
+//     "cpu" path tracer, or "gl" for phys's compute-shader backend,
+//     which requires the window's GL context (win) to implement
+//     phys.GLDevice. See newRenderer.
+//
+// Export:
+//   - Pass -export out.mp4 (or out.png for an APNG) to render the
+//     timeline headlessly, with no window, and exit; see runExport and
+//     phys.NewEncoder. -frames from:to exports a sub-range (default:
+//     the whole timeline).
+//   - While the window is open, pressing "E" exports the currently
+//     cached frame range to a timestamped ./out/export_*.png once
+//     frameCache is full; see exportRange.
 package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"image"
+	"image/color"
 	"image/draw"
 	"log"
 	"math"
 	"runtime"
+	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -62,28 +99,80 @@ type config struct {
 
 	propAxesRadius phys.Distance
 	propAxesLength phys.Distance
+
+	// sceneFile, when non-empty, is loaded with phys.LoadScene instead of
+	// building the hard-coded newScene geometry. Set via the -scene flag.
+	sceneFile string
+
+	// script, when non-nil, is ticked once per frame with the current
+	// frame index, elapsed time, and pause state, and may mutate the
+	// scene in place (move nodes, swap materials, replace the camera).
+	// See phys.SceneScript. Unset by default; library callers of run()
+	// can set it to script orbits or props without recompiling Go code.
+	script phys.SceneScript
+
+	// timeline, when non-empty, replaces buildOrbitTimeline as the
+	// camera path run() samples at showIdx. See phys.Timeline.
+	timeline phys.Timeline
+
+	// exportPath, when non-empty, makes main render the timeline
+	// headlessly (no window) via runExport and exit, instead of calling
+	// run(). Set with the -export flag.
+	exportPath string
+	// exportFrames restricts -export to a "from:to" frame sub-range; the
+	// whole timeline if empty. Set with the -frames flag.
+	exportFrames string
+}
+
+// buildOrbitTimeline is the phys.Timeline equivalent of the fixed
+// circular orbit this program used to hard-code: the camera circles
+// cfg.orbitLookAt at radius cfg.orbitRadius and height cfg.orbitLookFromZ,
+// taking cfg.orbitPeriod to complete one revolution. samples keyframes
+// are spread evenly around the circle (including both endpoints, so the
+// loop closes exactly) and blended with Catmull-Rom interpolation.
+func buildOrbitTimeline(cfg config) phys.Timeline {
+	const samples = 16
+	tl := phys.Timeline{Interpolation: phys.InterpolationCatmullRom}
+	for i := 0; i <= samples; i++ {
+		theta := 2 * math.Pi * float64(i) / samples
+		lookFrom := r3.Point{
+			X: float64(cfg.orbitRadius) * math.Cos(theta),
+			Y: float64(cfg.orbitRadius) * math.Sin(theta),
+			Z: float64(cfg.orbitLookFromZ),
+		}
+		tl.Keyframes = append(tl.Keyframes, phys.TimelineKeyframe{
+			Time:       time.Duration(float64(cfg.orbitPeriod) * float64(i) / samples),
+			Extrinsics: phys.CameraExtrinsics{LookFrom: lookFrom, LookAt: cfg.orbitLookAt, VUp: r3.Vec{Z: -1}},
+		})
+	}
+	return tl
 }
 
-type orbitCamera struct {
-	lookAt     r3.Point
-	lookFromZ  phys.Distance
-	vup        r3.Vec
-	intrinsics phys.CameraIntrinsics
-	radius     phys.Distance
-	frameSpan  int
+// keyframeFrame returns timeline's keyframe i converted to a frame index
+// at fps frames/second.
+func keyframeFrame(timeline phys.Timeline, fps, i int) int {
+	return int(math.Round(timeline.Keyframes[i].Time.Seconds() * float64(fps)))
 }
 
-func (o orbitCamera) at(i int) phys.Camera {
-	theta := 2 * math.Pi * (float64(i) / float64(max(1, o.frameSpan)))
-	lookFrom := r3.Point{
-		X: float64(o.radius) * math.Cos(theta),
-		Y: float64(o.radius) * math.Sin(theta),
-		Z: float64(o.lookFromZ),
+// nearestKeyframeFrame returns the frame index of the keyframe strictly
+// before (step<0) or after (step>0) fromFrame, for stepping playback with
+// the arrow keys. ok is false if there is no such keyframe (already at
+// the first/last one).
+func nearestKeyframeFrame(timeline phys.Timeline, fps, fromFrame, step int) (frame int, ok bool) {
+	if step < 0 {
+		for i := len(timeline.Keyframes) - 1; i >= 0; i-- {
+			if f := keyframeFrame(timeline, fps, i); f < fromFrame {
+				return f, true
+			}
+		}
+		return 0, false
+	}
+	for i := 0; i < len(timeline.Keyframes); i++ {
+		if f := keyframeFrame(timeline, fps, i); f > fromFrame {
+			return f, true
+		}
 	}
-	return phys.NewCalibratedCamera(
-		o.intrinsics,
-		phys.CameraExtrinsics{LookFrom: lookFrom, LookAt: o.lookAt, VUp: o.vup},
-	)
+	return 0, false
 }
 
 type frameCache struct{ buf []*image.RGBA }
@@ -105,6 +194,67 @@ func (fc *frameCache) full() bool {
 	return true
 }
 
+// InvalidateFrom clears cached frames [i, len) so the next request for
+// any of them falls through to a fresh render. Arcball mode calls this
+// on every view change: the timeline-indexed frames it otherwise reuses
+// no longer correspond to what the camera is pointed at.
+func (fc *frameCache) InvalidateFrom(i int) {
+	if i < 0 {
+		i = 0
+	}
+	for ; i < len(fc.buf); i++ {
+		fc.buf[i] = nil
+	}
+}
+
+// GLFW key and mouse-button codes. instrument does not export named
+// constants for either, so these are the raw codes GLFW (and therefore
+// most desktop window backends) assigns them.
+const (
+	glfwKeyRight = 262
+	glfwKeyLeft  = 263
+	glfwKeyE     = 69
+	glfwKeyA     = 65
+
+	glfwMouseButtonLeft  = 0
+	glfwMouseButtonRight = 1
+)
+
+// scrubBarHeight is the height, in pixels, of the cached/uncached
+// playback overlay drawScrubBar paints along the bottom of the window.
+const scrubBarHeight = 6
+
+// drawScrubBar paints a thin bar along the bottom scrubBarHeight rows of
+// img: green where cache has that frame rendered, gray where it doesn't,
+// with a white marker at showIdx. It overwrites every pixel in the bar
+// each call, so it is safe to call on img even when img is itself the
+// *image.RGBA stored in cache (the common case once cache is full).
+func drawScrubBar(img *image.RGBA, cache *frameCache, totalFrames, showIdx int) {
+	bounds := img.Bounds()
+	y0 := bounds.Max.Y - scrubBarHeight
+	width := bounds.Dx()
+	if y0 < bounds.Min.Y || width <= 0 || totalFrames <= 0 {
+		return
+	}
+	cachedColor := color.RGBA{R: 40, G: 200, B: 80, A: 255}
+	uncachedColor := color.RGBA{R: 90, G: 90, B: 90, A: 255}
+	markerColor := color.RGBA{R: 255, G: 255, B: 255, A: 255}
+
+	for x := 0; x < width; x++ {
+		c := uncachedColor
+		if cache.has(x * totalFrames / width) {
+			c = cachedColor
+		}
+		for y := y0; y < bounds.Max.Y; y++ {
+			img.SetRGBA(bounds.Min.X+x, y, c)
+		}
+	}
+	markerX := showIdx * width / totalFrames
+	for y := y0; y < bounds.Max.Y; y++ {
+		img.SetRGBA(bounds.Min.X+markerX, y, markerColor)
+	}
+}
+
 // uiState holds interaction state for pause/play + scrubbing.
 type uiState struct {
 	mu sync.RWMutex
@@ -187,6 +337,307 @@ func (s *uiState) takeResume() (idx int, ok bool) {
 	return 0, false
 }
 
+// loadInitialScene builds the scene run() starts with: cfg.sceneFile
+// loaded through phys.LoadScene if set, otherwise the built-in newScene
+// geometry.
+func loadInitialScene(cfg config) (phys.Scene, error) {
+	if cfg.sceneFile == "" {
+		return newScene(cfg), nil
+	}
+	scene, err := phys.LoadScene(cfg.sceneFile)
+	if err != nil {
+		return phys.Scene{}, fmt.Errorf("-scene %s: %w", cfg.sceneFile, err)
+	}
+	return *scene, nil
+}
+
+// newRenderer builds the phys.Renderer opts.Backend selects. For the
+// "gl" backend, win (the window gl.New returned) must itself implement
+// phys.GLDevice, so the compute dispatch shares the same GL context the
+// window displays with instead of opening a second one.
+func newRenderer(opts phys.RenderOptions, win any) (phys.Renderer, error) {
+	var device phys.GLDevice
+	if opts.Backend == "gl" {
+		d, ok := win.(phys.GLDevice)
+		if !ok {
+			return nil, fmt.Errorf("backend %q requires the window gl.New returned to implement phys.GLDevice", opts.Backend)
+		}
+		device = d
+	}
+	return phys.NewRenderer(opts, device)
+}
+
+// parseFrameRange parses a "from:to" -frames flag value.
+func parseFrameRange(s string) (from, to int, err error) {
+	parts := strings.SplitN(s, ":", 2)
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("want \"from:to\", got %q", s)
+	}
+	if from, err = strconv.Atoi(parts[0]); err != nil {
+		return 0, 0, fmt.Errorf("bad from: %w", err)
+	}
+	if to, err = strconv.Atoi(parts[1]); err != nil {
+		return 0, 0, fmt.Errorf("bad to: %w", err)
+	}
+	if to <= from {
+		return 0, 0, fmt.Errorf("to (%d) must be greater than from (%d)", to, from)
+	}
+	return from, to, nil
+}
+
+// exportRange renders frames [from, to) of scene at fps and writes them,
+// in order, to an Encoder opened at path. Frames cache already has are
+// reused; the rest are synthesized with phys.Render across a worker pool
+// sized by runtime.NumCPU(), so a long export doesn't serialize behind a
+// single goroutine (the caller's UI goroutine, for the in-window export
+// shortcut, or main itself for headless -export).
+func exportRange(ctx context.Context, scene phys.Scene, cameraAt func(frame int) phys.Camera, cache *frameCache, path string, fps, from, to int) error {
+	if to <= from {
+		return fmt.Errorf("export: empty range [%d, %d)", from, to)
+	}
+	enc, err := phys.NewEncoder(path, fps)
+	if err != nil {
+		return fmt.Errorf("export: %w", err)
+	}
+
+	n := to - from
+	imgs := make([]*image.RGBA, n)
+	errs := make([]error, n)
+	work := make(chan int)
+	var wg sync.WaitGroup
+	workers := runtime.NumCPU()
+	if workers < 1 {
+		workers = 1
+	}
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range work {
+				if cache.has(i) {
+					imgs[i-from] = cache.get(i)
+					continue
+				}
+				sceneCopy := scene
+				sceneCopy.Camera = []phys.Camera{cameraAt(i)}
+				res, err := phys.Render(ctx, &sceneCopy)
+				if err != nil {
+					errs[i-from] = fmt.Errorf("frame %d: %w", i, err)
+					continue
+				}
+				imgs[i-from] = res.Image
+			}
+		}()
+	}
+	for i := from; i < to; i++ {
+		work <- i
+	}
+	close(work)
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			enc.Close()
+			return fmt.Errorf("export: %w", err)
+		}
+	}
+	for _, img := range imgs {
+		if err := enc.WriteFrame(img); err != nil {
+			enc.Close()
+			return fmt.Errorf("export: write frame: %w", err)
+		}
+	}
+	return enc.Close()
+}
+
+// runExport implements -export: it renders cfg.exportFrames (or the
+// whole timeline if unset) headlessly, with no window, and writes the
+// result to cfg.exportPath.
+func runExport(cfg config) error {
+	scene, err := loadInitialScene(cfg)
+	if err != nil {
+		return err
+	}
+	timeline := cfg.timeline
+	if len(timeline.Keyframes) == 0 {
+		timeline = buildOrbitTimeline(cfg)
+	}
+	framesPerOrbit := timeline.DurationFrames(cfg.renderFPS)
+
+	from, to := 0, framesPerOrbit
+	if cfg.exportFrames != "" {
+		if from, to, err = parseFrameRange(cfg.exportFrames); err != nil {
+			return fmt.Errorf("-frames %q: %w", cfg.exportFrames, err)
+		}
+	}
+
+	frameDur := time.Second / time.Duration(cfg.renderFPS)
+	cameraAt := func(frame int) phys.Camera {
+		return phys.NewCalibratedCamera(phys.IntrinsicsFireflyDLGeneric6mm, timeline.At(time.Duration(frame)*frameDur))
+	}
+	cache := newFrameCache(0) // headless: nothing is ever pre-rendered
+	if err := exportRange(context.Background(), scene, cameraAt, cache, cfg.exportPath, cfg.renderFPS, from, to); err != nil {
+		return err
+	}
+	log.Printf("export: wrote %s (%d frames)", cfg.exportPath, to-from)
+	return nil
+}
+
+// isSceneDropFile reports whether name looks like a declarative scene file
+// dropped onto the window, i.e. a ".scene.json" suffix.
+func isSceneDropFile(name string) bool {
+	return strings.HasSuffix(strings.ToLower(name), ".scene.json")
+}
+
+// sceneDropState hands a dropped scene file path from the event-handler
+// goroutine to the render loop in run(), which does the actual
+// phys.LoadScene call; loading touches disk and must not run inside the
+// event handler goroutine.
+type sceneDropState struct {
+	mu   sync.Mutex
+	path string
+	has  bool
+}
+
+func (s *sceneDropState) request(path string) {
+	s.mu.Lock()
+	s.path, s.has = path, true
+	s.mu.Unlock()
+}
+
+func (s *sceneDropState) take() (path string, ok bool) {
+	s.mu.Lock()
+	path, ok = s.path, s.has
+	s.has = false
+	s.mu.Unlock()
+	return
+}
+
+// scriptSwapState hands a replacement phys.SceneScript to the render loop
+// in run(), which invalidates frameCache and resets the script's
+// frame/elapsed counters before ticking the new script. Loading the
+// script's own source (if any) is the caller's responsibility; this
+// module has no embedded scripting engine to parse one from disk.
+type scriptSwapState struct {
+	mu     sync.Mutex
+	script phys.SceneScript
+	has    bool
+}
+
+func (s *scriptSwapState) request(script phys.SceneScript) {
+	s.mu.Lock()
+	s.script, s.has = script, true
+	s.mu.Unlock()
+}
+
+func (s *scriptSwapState) take() (script phys.SceneScript, ok bool) {
+	s.mu.Lock()
+	script, ok = s.script, s.has
+	s.has = false
+	s.mu.Unlock()
+	return
+}
+
+// exportTriggerState hands an "E was pressed" signal from the
+// event-handler goroutine to the render loop in run(), which has scene
+// and cache in scope to actually perform the export; exporting (like
+// loading a dropped scene) must not run inside the event handler
+// goroutine.
+type exportTriggerState struct {
+	mu  sync.Mutex
+	has bool
+}
+
+func (s *exportTriggerState) request() { s.mu.Lock(); s.has = true; s.mu.Unlock() }
+
+func (s *exportTriggerState) take() (ok bool) {
+	s.mu.Lock()
+	ok, s.has = s.has, false
+	s.mu.Unlock()
+	return
+}
+
+// arcballState tracks whether arcball mode is on and, while a drag is in
+// progress, which mouse button started it, so the event-handler
+// goroutine can dispatch PointerMove to ArcballController.Rotate or
+// PanTo. Unlike sceneDropState/scriptSwapState/exportTriggerState, this
+// is mutated directly by the event handler rather than handed off to the
+// render loop: rotating/panning/dollying an ArcballController is pure
+// in-memory math, not disk I/O or a renderer swap.
+type arcballState struct {
+	mu       sync.Mutex
+	enabled  bool
+	button   int // valid only while dragging
+	dragging bool
+}
+
+func (s *arcballState) toggle() (enabled bool) {
+	s.mu.Lock()
+	s.enabled = !s.enabled
+	s.dragging = false
+	enabled = s.enabled
+	s.mu.Unlock()
+	return
+}
+
+func (s *arcballState) isEnabled() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.enabled
+}
+
+func (s *arcballState) beginDrag(button int) {
+	s.mu.Lock()
+	s.button, s.dragging = button, true
+	s.mu.Unlock()
+}
+
+func (s *arcballState) endDrag() { s.mu.Lock(); s.dragging = false; s.mu.Unlock() }
+
+// draggingButton reports the button passed to beginDrag, if a drag
+// (started while enabled) is still in progress.
+func (s *arcballState) draggingButton() (button int, ok bool) {
+	s.mu.Lock()
+	button, ok = s.button, s.dragging
+	s.mu.Unlock()
+	return
+}
+
+// normalizePointer maps a pointer position in window pixel coordinates
+// to [-1, 1] on both axes, the range ArcballController expects.
+func normalizePointer(x, y float64, w, h int) (nx, ny float64) {
+	if w <= 0 || h <= 0 {
+		return 0, 0
+	}
+	return 2*x/float64(w) - 1, 2*y/float64(h) - 1
+}
+
+// arcballConvergeTTL is how long the arcball view must sit still before
+// the render loop queues a full-quality convergence pass; see
+// arcballDirtyState and phys.ProgressiveQueue.
+const arcballConvergeTTL = 400 * time.Millisecond
+
+// arcballDirtyState records when the arcball view last changed. version
+// increments on every markDirty, so the render loop can tell "the view
+// changed since I last checked" apart from "still the same drag" with a
+// single integer comparison instead of comparing two time.Time values.
+type arcballDirtyState struct {
+	mu      sync.Mutex
+	version int
+}
+
+func (s *arcballDirtyState) markDirty() {
+	s.mu.Lock()
+	s.version++
+	s.mu.Unlock()
+}
+
+func (s *arcballDirtyState) load() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.version
+}
+
 func newScene(cfg config) phys.Scene {
 	scene := phys.Scene{
 		RenderOptions: phys.RenderOptions{
@@ -302,16 +753,27 @@ func main() {
 		propAxesRadius: 0.1 * phys.MM,
 		propAxesLength: 50 * phys.MM,
 	}
+	flag.StringVar(&cfg.sceneFile, "scene", "", "path to a .scene.json file (phys.LoadScene format) to render instead of the built-in geometry")
+	flag.StringVar(&cfg.exportPath, "export", "", "render the timeline headlessly to this file (.png for APNG, .mp4 via ffmpeg) and exit without opening a window")
+	flag.StringVar(&cfg.exportFrames, "frames", "", "with -export, the frame range to render as \"from:to\" (default: the whole timeline)")
+	flag.Parse()
+	if cfg.exportPath != "" {
+		if err := runExport(cfg); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
 	if err := run(cfg); err != nil {
 		log.Fatal(err)
 	}
 }
 
 func run(cfg config) error {
-	framesPerOrbit := int(math.Round(cfg.orbitPeriod.Seconds() * float64(cfg.renderFPS)))
-	if framesPerOrbit < 1 {
-		framesPerOrbit = 1
+	timeline := cfg.timeline
+	if len(timeline.Keyframes) == 0 {
+		timeline = buildOrbitTimeline(cfg)
 	}
+	framesPerOrbit := timeline.DurationFrames(cfg.renderFPS)
 
 	// Event bus + subscriber
 	bus := instrument.NewBus(64)
@@ -320,8 +782,22 @@ func run(cfg config) error {
 	defer bus.Unsubscribe(events)
 
 	var ui uiState
+	var sceneDrop sceneDropState
+	var scriptSwap scriptSwapState
+	var exportTrigger exportTriggerState
 	const dragThreshold = 1.0 // pixels to consider a drag
 
+	// Arcball mode: toggled with "A", rebinds PointerDown/Move/Up/Cancel
+	// and Wheel (below) away from scrubbing to orbit/pan/dolly. arcball
+	// is mutated by the event handler and sampled by the render loop, the
+	// same split uiState uses; arcballDirty and progressiveQueue drive
+	// the render loop's progressive re-render once it picks up the view
+	// change (see the render loop below).
+	var arc arcballState
+	arcball := phys.NewArcballController(timeline.At(0))
+	var arcballDirty arcballDirtyState
+	progressiveQueue := phys.NewProgressiveQueue()
+
 	// Event handler goroutine
 	go func() {
 		for e := range events {
@@ -340,6 +816,16 @@ func run(cfg config) error {
 				d := e.Data.PointerDown
 				log.Printf("ui.down    from=%s x=%.1f y=%.1f w=%d h=%d btn=%d mods=%d clicks=%d",
 					e.From, d.X, d.Y, d.W, d.H, d.Button, d.Mods, d.Clicks)
+				if arc.isEnabled() {
+					arc.beginDrag(int(d.Button))
+					nx, ny := normalizePointer(d.X, d.Y, d.W, d.H)
+					if int(d.Button) == glfwMouseButtonRight {
+						arcball.BeginPan(nx, ny)
+					} else {
+						arcball.BeginRotate(nx, ny)
+					}
+					break
+				}
 				ui.setMouseDown(d.X, d.Y)
 				// If paused, initialize relative-drag baseline to current view
 				if paused, _, _, _ := ui.get(); paused {
@@ -350,6 +836,16 @@ func run(cfg config) error {
 
 			case instrument.PointerMove:
 				d := e.Data.PointerMove
+				if button, dragging := arc.draggingButton(); arc.isEnabled() && dragging {
+					nx, ny := normalizePointer(d.X, d.Y, d.W, d.H)
+					if button == glfwMouseButtonRight {
+						arcball.PanTo(nx, ny)
+					} else {
+						arcball.Rotate(nx, ny)
+					}
+					arcballDirty.markDirty()
+					break
+				}
 				downX, downY := ui.getDown()
 				if dx, dy := d.X-downX, d.Y-downY; (dx*dx + dy*dy) >= (dragThreshold * dragThreshold) {
 					ui.setDragging()
@@ -369,6 +865,11 @@ func run(cfg config) error {
 				d := e.Data.PointerUp
 				log.Printf("ui.up      from=%s x=%.1f y=%.1f w=%d h=%d btn=%d mods=%d clicks=%d",
 					e.From, d.X, d.Y, d.W, d.H, d.Button, d.Mods, d.Clicks)
+				if arc.isEnabled() {
+					arc.endDrag()
+					arcball.EndDrag()
+					break
+				}
 
 				// Click (down+up without drag) toggles pause/play.
 				paused, mouseDown, dragging, scrub := ui.get()
@@ -390,12 +891,34 @@ func run(cfg config) error {
 			case instrument.PointerCancel:
 				d := e.Data.PointerCancel
 				log.Printf("ui.cancel  from=%s x=%.1f y=%.1f w=%d h=%d reason=%q", e.From, d.X, d.Y, d.W, d.H, d.Reason)
+				arc.endDrag()
+				arcball.EndDrag()
 				ui.setMouseUp()
 
 			// ---------- Keyboard ----------
 			case instrument.KeyDown:
 				d := e.Data.KeyDown
 				log.Printf("key.down   from=%s key=%d scancode=%d mods=%d repeat=%v", e.From, d.Key, d.Scancode, d.Mods, d.Repeat)
+				step := 0
+				switch int(d.Key) {
+				case glfwKeyLeft:
+					step = -1
+				case glfwKeyRight:
+					step = 1
+				case glfwKeyE:
+					exportTrigger.request()
+				case glfwKeyA:
+					enabled := arc.toggle()
+					arcball.EndDrag()
+					log.Printf("arcball: enabled=%v", enabled)
+				}
+				if step != 0 {
+					if frame, ok := nearestKeyframeFrame(timeline, cfg.renderFPS, ui.loadDisplayed(), step); ok {
+						ui.setPaused(true)
+						ui.setScrub(frame, 0)
+						log.Printf("timeline: stepped to keyframe frame %d", frame)
+					}
+				}
 			case instrument.KeyUp:
 				d := e.Data.KeyUp
 				log.Printf("key.up     from=%s key=%d scancode=%d mods=%d", e.From, d.Key, d.Scancode, d.Mods)
@@ -404,9 +927,19 @@ func run(cfg config) error {
 			case instrument.Wheel:
 				d := e.Data.Wheel
 				log.Printf("wheel      from=%s dx=%.2f dy=%.2f", e.From, d.OffX, d.OffY)
+				if arc.isEnabled() {
+					arcball.Dolly(d.OffY)
+					arcballDirty.markDirty()
+				}
 			case instrument.Dropped:
 				d := e.Data.Dropped
 				log.Printf("drop       from=%s files=%v", e.From, d.Names)
+				for _, name := range d.Names {
+					if isSceneDropFile(name) {
+						sceneDrop.request(name)
+						break
+					}
+				}
 			}
 		}
 	}()
@@ -425,23 +958,27 @@ func run(cfg config) error {
 	}
 	defer win.Close()
 
-	scene := newScene(cfg)
+	scene, err := loadInitialScene(cfg)
+	if err != nil {
+		return err
+	}
+	renderer, err := newRenderer(scene.RenderOptions, win)
+	if err != nil {
+		return fmt.Errorf("renderer: %w", err)
+	}
+	// A closure, not `defer renderer.Release()`: the scene-drop handler
+	// below can replace renderer, and a bare defer would capture today's
+	// value and leak whatever renderer is active when run() returns.
+	defer func() { renderer.Release() }()
 	cache := newFrameCache(framesPerOrbit)
+	arcballCache := newFrameCache(1) // holds only the current arcball view, at index 0
+	var lastArcballVersion int
 
 	ctx := context.Background()
 	frameDur := time.Second / time.Duration(cfg.renderFPS)
 	ticker := time.NewTicker(frameDur)
 	defer ticker.Stop()
 
-	orbit := orbitCamera{
-		intrinsics: phys.IntrinsicsFireflyDLGeneric6mm,
-		lookAt:     cfg.orbitLookAt,
-		vup:        r3.Vec{Z: -1},
-		radius:     cfg.orbitRadius,
-		lookFromZ:  cfg.orbitLookFromZ,
-		frameSpan:  framesPerOrbit,
-	}
-
 	fmt.Printf("bbox: %v\n", scene.Node[0].Shape.Bounds())
 
 	var firstSaved bool
@@ -449,6 +986,10 @@ func run(cfg config) error {
 	lastFPS := time.Now()
 	var frame int
 
+	sceneScript := cfg.script
+	scriptStart := time.Now()
+	var scriptTick int
+
 	for {
 		select {
 		case <-ticker.C:
@@ -461,6 +1002,51 @@ func run(cfg config) error {
 		}
 		win.PollEvents()
 
+		if path, ok := sceneDrop.take(); ok {
+			loaded, err := phys.LoadScene(path)
+			if err != nil {
+				log.Printf("scene drop: %v", err)
+			} else if next, err := newRenderer(loaded.RenderOptions, win); err != nil {
+				log.Printf("scene drop: renderer: %v", err)
+			} else {
+				renderer.Release()
+				renderer = next
+				scene = *loaded
+				cache = newFrameCache(framesPerOrbit)
+				frame = 0
+				ui.setScrub(0, 0)
+				ui.setPaused(false)
+				firstSaved = true // don't re-save a "first frame" PNG for the reloaded scene
+				log.Printf("scene drop: reloaded %s (%d nodes)", path, len(scene.Node))
+			}
+		}
+
+		if script, ok := scriptSwap.take(); ok {
+			sceneScript = script
+			cache = newFrameCache(framesPerOrbit)
+			scriptStart = time.Now()
+			scriptTick = 0
+			log.Printf("scene script: reloaded")
+		}
+
+		if exportTrigger.take() {
+			if !cache.full() {
+				log.Printf("export: cache is not full yet, ignoring")
+			} else {
+				path := time.Now().Format("./out/export_20060102_150405.png")
+				go func(scene phys.Scene, cache *frameCache, total int) {
+					cameraAt := func(frame int) phys.Camera {
+						return phys.NewCalibratedCamera(phys.IntrinsicsFireflyDLGeneric6mm, timeline.At(time.Duration(frame)*frameDur))
+					}
+					if err := exportRange(ctx, scene, cameraAt, cache, path, cfg.renderFPS, 0, total); err != nil {
+						log.Printf("export: %v", err)
+					} else {
+						log.Printf("export: wrote %s (%d frames)", path, total)
+					}
+				}(scene, cache, framesPerOrbit)
+			}
+		}
+
 		paused, _, _, scrub := ui.get()
 
 		// If we just switched from pause->play, align animation start to scrub index.
@@ -478,27 +1064,81 @@ func run(cfg config) error {
 			showIdx = clampInt(scrub, 0, framesPerOrbit-1)
 		}
 
-		var img image.Image
-		if cache.has(showIdx) {
-			img = cache.get(showIdx)
+		var img *image.RGBA
+		if arc.isEnabled() {
+			// Arcball mode owns the camera outright: no timeline sampling,
+			// no SceneScript tick (scripts are keyed to frame index, which
+			// has no meaning for a freely orbited view), and a dedicated
+			// single-slot cache instead of the timeline-indexed one.
+			scene.Camera = []phys.Camera{phys.NewCalibratedCamera(phys.IntrinsicsFireflyDLGeneric6mm, arcball.Extrinsics())}
+			if ver := arcballDirty.load(); ver != lastArcballVersion {
+				lastArcballVersion = ver
+				arcballCache.InvalidateFrom(0)
+				now := time.Now()
+				// An immediate low-sample preview outranks the delayed,
+				// full-quality convergence pass so dragging stays
+				// responsive; the convergence pass fires once the view
+				// has sat still for arcballConvergeTTL.
+				progressiveQueue.Push(phys.RenderRequest{RaysPerPixel: 1, ReadyAt: now, Priority: 10})
+				progressiveQueue.Push(phys.RenderRequest{RaysPerPixel: scene.RenderOptions.RaysPerPixel, ReadyAt: now.Add(arcballConvergeTTL), Priority: 0})
+			}
+			raysPerPixel := scene.RenderOptions.RaysPerPixel
+			renderNow := true
+			if req, ok := progressiveQueue.TryPop(); ok {
+				raysPerPixel = req.RaysPerPixel
+			} else if arcballCache.has(0) {
+				img, renderNow = arcballCache.get(0), false
+			}
+			if renderNow {
+				sceneCopy := scene
+				sceneCopy.RenderOptions.RaysPerPixel = raysPerPixel
+				res, err := renderer.Render(ctx, &sceneCopy)
+				if err != nil {
+					return fmt.Errorf("render: %w", err)
+				}
+				img = res.Image
+				arcballCache.put(0, cloneRGBA(img))
+			}
 		} else {
-			scene.Camera = []phys.Camera{orbit.at(showIdx)}
-			res, err := phys.Render(ctx, &scene)
-			if err != nil {
-				return fmt.Errorf("render: %w", err)
+			elapsed := time.Duration(showIdx) * frameDur
+			scene.Camera = []phys.Camera{phys.NewCalibratedCamera(phys.IntrinsicsFireflyDLGeneric6mm, timeline.At(elapsed))}
+			if sceneScript != nil {
+				state := phys.SceneScriptState{Frame: scriptTick, Elapsed: time.Since(scriptStart), Paused: paused}
+				if err := sceneScript.Tick(&scene, state); err != nil {
+					log.Printf("scene script: tick %d: %v", scriptTick, err)
+				}
+				scriptTick++
 			}
-			img = res.Image
-			cache.put(showIdx, cloneRGBA(img))
-			if !firstSaved {
-				path := time.Now().Format("./out/out_20060102_150405.png")
-				if err := phys.SavePNG(path, res.Image); err != nil {
-					return fmt.Errorf("save first frame: %w", err)
+
+			// A SceneScript can make the scene's contents a function of wall
+			// time rather than frame index alone, so frameCache -- which is
+			// keyed purely by index -- cannot be trusted while one is active;
+			// bypass it entirely rather than serve a stale mutation.
+			if sceneScript == nil && cache.has(showIdx) {
+				img = cache.get(showIdx)
+			} else {
+				res, err := renderer.Render(ctx, &scene)
+				if err != nil {
+					return fmt.Errorf("render: %w", err)
+				}
+				img = res.Image
+				if sceneScript == nil {
+					cache.put(showIdx, cloneRGBA(img))
+				}
+				if !firstSaved {
+					path := time.Now().Format("./out/out_20060102_150405.png")
+					if err := phys.SavePNG(path, res.Image); err != nil {
+						return fmt.Errorf("save first frame: %w", err)
+					}
+					log.Printf("saved first frame --> %s\n", path)
+					firstSaved = true
 				}
-				log.Printf("saved first frame --> %s\n", path)
-				firstSaved = true
 			}
 		}
 
+		if !arc.isEnabled() {
+			drawScrubBar(img, cache, framesPerOrbit, showIdx)
+		}
 		win.Draw(img)
 		ui.storeDisplayed(showIdx)
 
@@ -562,13 +1202,6 @@ func cloneRGBA(src image.Image) *image.RGBA {
 	return dst
 }
 
-func max(a, b int) int {
-	if a > b {
-		return a
-	}
-	return b
-}
-
 func clampInt(x, lo, hi int) int {
 	if x < lo {
 		return lo
@@ -7,8 +7,8 @@ import (
 	"math"
 	"time"
 
-	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
 	"github.com/scottlawsonbc/slam/code/photon/raytrace/phys"
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
 )
 
 // This example scene demonstrates using different primitive shapes.
@@ -167,11 +167,10 @@ func main() {
 						Width:  100 * phys.MM,
 						Height: 100 * phys.MM,
 					},
-					Transform: phys.Transform{
-						Translation: r3.Vec{X: mm(0), Y: mm(50), Z: mm(50)},
-						Rotation:    r3.RotationMatrixZ(math.Pi / 2), // Rotate 45 degrees around Z-axis
-						Scale:       r3.Vec{X: 1, Y: 1, Z: 1},
-					},
+					Transform: phys.Compose(
+						phys.NewRotation(r3.RotationMatrixZ(math.Pi/2)), // Rotate 45 degrees around Z-axis
+						phys.NewTranslation(r3.Vec{X: mm(0), Y: mm(50), Z: mm(50)}),
+					),
 				},
 				Material: phys.Emitter{
 					Texture: phys.TextureImage{
@@ -192,11 +191,10 @@ func main() {
 						Width:  100 * phys.MM,
 						Height: 100 * phys.MM,
 					},
-					Transform: phys.Transform{
-						Translation: r3.Vec{X: mm(50), Y: mm(50), Z: mm(0)},
-						Rotation:    r3.RotationMatrixY(math.Pi / 2).Mul(r3.RotationMatrixZ(math.Pi / 2)), // Rotate 45 degrees around Z-axis
-						Scale:       r3.Vec{X: 1, Y: 1, Z: 1},
-					},
+					Transform: phys.Compose(
+						phys.NewRotation(r3.RotationMatrixY(math.Pi/2).Mul(r3.RotationMatrixZ(math.Pi/2))), // Rotate 45 degrees around Z-axis
+						phys.NewTranslation(r3.Vec{X: mm(50), Y: mm(50), Z: mm(0)}),
+					),
 				},
 				Material: phys.Emitter{
 					Texture: phys.TextureImage{
@@ -217,11 +215,7 @@ func main() {
 						Width:  100 * phys.MM,
 						Height: 100 * phys.MM,
 					},
-					Transform: phys.Transform{
-						Translation: r3.Vec{X: mm(50), Y: mm(0), Z: mm(50)},
-						Rotation:    r3.IdentityMat3x3(),
-						Scale:       r3.Vec{X: 1, Y: 1, Z: 1},
-					},
+					Transform: phys.NewTranslation(r3.Vec{X: mm(50), Y: mm(0), Z: mm(50)}),
 				},
 				Material: phys.Emitter{
 					Texture: phys.TextureImage{
@@ -18,24 +18,16 @@ import (
 
 func translate(x, y, z float64, s phys.Shape) phys.TransformedShape {
 	return phys.TransformedShape{
-		Shape: s,
-		Transform: phys.Transform{
-			Translation: r3.Vec{X: x, Y: y, Z: z},
-			Rotation:    r3.RotationMatrixZ(0),
-			Scale:       r3.Vec{X: 1, Y: 1, Z: 1},
-		},
+		Shape:     s,
+		Transform: phys.NewTranslation(r3.Vec{X: x, Y: y, Z: z}),
 	}
 }
 
 func rotate(angleDegrees float64, s phys.Shape) phys.TransformedShape {
 	angleRadians := angleDegrees * math.Pi / 180
 	return phys.TransformedShape{
-		Shape: s,
-		Transform: phys.Transform{
-			Translation: r3.Vec{X: 0, Y: 0, Z: 0},
-			Rotation:    r3.RotationMatrixZ(angleRadians),
-			Scale:       r3.Vec{X: 1, Y: 1, Z: 1},
-		},
+		Shape:     s,
+		Transform: phys.NewRotation(r3.RotationMatrixZ(angleRadians)),
 	}
 }
 
@@ -127,7 +119,7 @@ func main() {
 				Name:     "triangle 5",
 				Shape:    translate(0.8, 0.8, 0, rotate(135, prefabTriangle)),
 				Material: phys.DebugUV{},
-	},
+			},
 			{
 				Name:     "triangle 5 origin",
 				Shape:    translate(0.8, 0.8, 0, phys.Sphere{Center: r3.Point{X: 0, Y: 0, Z: 0}, Radius: 0.008}),
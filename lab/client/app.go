@@ -5,16 +5,19 @@ package main
 import (
 	"fmt"
 	"log"
+	"math"
 	"syscall/js"
 
-	"github.com/scottlawsonbc/slam/code/photon/raytrace/r2"
 	"github.com/scottlawsonbc/slam/code/photon/raytrace/lab/client/canvas"
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/lab/event/gesture"
 	"github.com/scottlawsonbc/slam/code/photon/raytrace/lab/event/key"
 	"github.com/scottlawsonbc/slam/code/photon/raytrace/lab/event/lifecycle"
 	"github.com/scottlawsonbc/slam/code/photon/raytrace/lab/event/mouse"
 	"github.com/scottlawsonbc/slam/code/photon/raytrace/lab/event/paint"
 	"github.com/scottlawsonbc/slam/code/photon/raytrace/lab/event/size"
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/lab/event/touch"
 	"github.com/scottlawsonbc/slam/code/photon/raytrace/lab/event/wheel"
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r2"
 )
 
 type app struct {
@@ -35,6 +38,14 @@ type app struct {
 	isMiddleButtonDown bool
 	lastMouse          r2.Point
 
+	// gestures turns the raw touch.Event stream (one per finger, from
+	// redirectTouchEvent) into Pan/Pinch/Rotate events main dispatches on
+	// just like mouse.Event and wheel.Event. It is unexported state, not a
+	// channel, because recognition has to see every touch (including
+	// touchstart/touchend, which never reach eventOut themselves) to track
+	// which fingers are down.
+	gestures *gesture.Recognizer
+
 	worker js.Value
 }
 
@@ -78,6 +89,11 @@ func (a *app) onWorkerMessage(this js.Value, args []js.Value) interface{} {
 		return nil
 	}
 
+	if message.Get("type").String() == "tile" {
+		a.onWorkerTile(message)
+		return nil
+	}
+
 	width := message.Get("width").Int()
 	height := message.Get("height").Int()
 	jsPixelData := message.Get("pixelData")
@@ -86,9 +102,40 @@ func (a *app) onWorkerMessage(this js.Value, args []js.Value) interface{} {
 	imgData := js.Global().Get("ImageData").New(jsPixelData, width, height)
 	a.glctx.Val.Call("putImageData", imgData, 0, 0)
 	a.setRenderStatus(false)
+
+	// ImageData's constructor keeps a reference to jsPixelData rather than
+	// copying it, but putImageData above has already read those bytes into
+	// the canvas's own bitmap by the time this line runs, so the worker's
+	// transferred ArrayBuffer (see postArtifact) is free to hand straight
+	// back for takePooledBuffer to reuse on a later frame instead of
+	// allocating. A buffer from the sharedPixels/canvasCtx paths has no
+	// "buffer" to return this way; it's simply reused by the worker itself.
+	if buffer := jsPixelData.Get("buffer"); !buffer.IsUndefined() && !buffer.IsNull() {
+		a.postToWorker(workerMessage{Type: "returnBuffer", Buffer: buffer, ByteLength: buffer.Get("byteLength").Int()})
+	}
 	return nil
 }
 
+// onWorkerTile handles a {type:"tile", x, y, w, h, pixelData} message from
+// the worker's tile-streamed render (see renderTilesProgressive): unlike
+// onWorkerMessage's whole-frame path, it paints at the tile's own (x, y)
+// offset instead of (0, 0), so the canvas fills in tile by tile as the
+// worker finishes them rather than waiting for every tile to arrive.
+func (a *app) onWorkerTile(message js.Value) {
+	x := message.Get("x").Int()
+	y := message.Get("y").Int()
+	w := message.Get("w").Int()
+	h := message.Get("h").Int()
+	jsPixelData := message.Get("pixelData")
+
+	imgData := js.Global().Get("ImageData").New(jsPixelData, w, h)
+	a.glctx.Val.Call("putImageData", imgData, x, y)
+
+	if buffer := jsPixelData.Get("buffer"); !buffer.IsUndefined() && !buffer.IsNull() {
+		a.postToWorker(workerMessage{Type: "returnBuffer", Buffer: buffer, ByteLength: buffer.Get("byteLength").Int()})
+	}
+}
+
 // setRenderStatus updates the visibility of the render status indicator in the DOM.
 func (a *app) setRenderStatus(visible bool) {
 	doc := js.Global().Get("document")
@@ -128,27 +175,104 @@ func (a *app) Send(e any) {
 	a.eventIn <- e
 }
 
+// workerMessage is the envelope every message posted to the worker uses: a
+// Type discriminator plus whichever of the remaining fields that Type
+// carries (fields unused by a given Type are simply left off the JS object
+// toJS builds). It replaces the ad-hoc js.Global().Get("Object").New() plus
+// repeated .Set calls each sender used to build inline, and mirrors the
+// workerMessage the worker's own onMessage decodes on the other end.
+type workerMessage struct {
+	Type string
+
+	Dx, Dy float64 // rotateCamera, translateCamera
+	Delta  float64 // zoomCamera
+
+	// Buffer and ByteLength are set only for "returnBuffer": Buffer is the
+	// ArrayBuffer postToWorker transfers back to the worker's bufferPool
+	// (see onWorkerMessage and worker.takePooledBuffer), ByteLength lets the
+	// worker match it against the frame size it actually needs.
+	Buffer     js.Value
+	ByteLength int
+}
+
+func (m workerMessage) toJS() js.Value {
+	obj := js.Global().Get("Object").New()
+	obj.Set("type", m.Type)
+	switch m.Type {
+	case "rotateCamera", "translateCamera":
+		obj.Set("dx", m.Dx)
+		obj.Set("dy", m.Dy)
+	case "zoomCamera":
+		obj.Set("delta", m.Delta)
+	case "returnBuffer":
+		obj.Set("buffer", m.Buffer)
+		obj.Set("byteLength", m.ByteLength)
+	}
+	return obj
+}
+
+// postToWorker posts m to the worker. When m.Buffer is set, it's passed as
+// postMessage's transfer list instead of left to structured-clone, so
+// handing a frame's pixel buffer back for reuse doesn't itself copy the
+// bytes the whole returnBuffer/bufferPool dance exists to avoid copying.
+func (a *app) postToWorker(m workerMessage) {
+	if m.Buffer.IsUndefined() || m.Buffer.IsNull() {
+		a.worker.Call("postMessage", m.toJS())
+		return
+	}
+	transferList := js.Global().Get("Array").New(m.Buffer)
+	a.worker.Call("postMessage", m.toJS(), transferList)
+}
+
 func (a *app) sendRotateCameraMessage(dx, dy float64) {
-	message := js.Global().Get("Object").New()
-	message.Set("type", "rotateCamera")
-	message.Set("dx", dx)
-	message.Set("dy", dy)
-	a.worker.Call("postMessage", message)
+	a.postToWorker(workerMessage{Type: "rotateCamera", Dx: dx, Dy: dy})
 }
 
 func (a *app) sendTranslateCameraMessage(dx, dy float64) {
-	message := js.Global().Get("Object").New()
-	message.Set("type", "translateCamera")
-	message.Set("dx", dx)
-	message.Set("dy", dy)
-	a.worker.Call("postMessage", message)
+	a.postToWorker(workerMessage{Type: "translateCamera", Dx: dx, Dy: dy})
 }
 
 func (a *app) sendZoomCameraMessage(delta float64) {
-	message := js.Global().Get("Object").New()
-	message.Set("type", "zoomCamera")
-	message.Set("delta", delta)
-	a.worker.Call("postMessage", message)
+	a.postToWorker(workerMessage{Type: "zoomCamera", Delta: delta})
+}
+
+// pinchZoomSensitivity converts a gesture.Pinch's Scale ratio into the same
+// delta units sendZoomCameraMessage already sends from a wheel.Event, so a
+// pinch drives the camera through the same worker-side zoomCamera a mouse
+// wheel does rather than a separate code path.
+const pinchZoomSensitivity = 8.0
+
+// sendPinchZoomMessage converts scale (gesture.Pinch.Scale: >1 means the
+// fingers spread apart, <1 means they pinched together) into a
+// sendZoomCameraMessage delta. zoomCamera's existing convention is that a
+// positive delta zooms out, so spreading apart (zoom in) must produce a
+// negative delta -- the negated log of scale, rather than scale itself, so
+// that pinching back to exactly where it started (scale == 1) sends delta
+// == 0 instead of 1.
+func (a *app) sendPinchZoomMessage(scale float64) {
+	a.sendZoomCameraMessage(-math.Log(scale) * pinchZoomSensitivity)
+}
+
+// sendToggleDenoiseMessage asks the worker to flip between its interactive
+// adaptive-sampling render mode and its fixed-sample, À-Trous-denoised
+// mode, so the user can compare raw and denoised output live.
+func (a *app) sendToggleDenoiseMessage() {
+	a.postToWorker(workerMessage{Type: "toggleDenoise"})
+}
+
+// sendRecordKeyframeMessage asks the worker to append its current camera
+// pose to the flythrough it's recording, for a later sendPlayTrackMessage
+// to play back.
+func (a *app) sendRecordKeyframeMessage() {
+	a.postToWorker(workerMessage{Type: "recordKeyframe"})
+}
+
+// sendPlayTrackMessage asks the worker to play back the keyframes recorded
+// so far via sendRecordKeyframeMessage. The client carries no keyframe
+// state of its own, so it sends an empty keyframes list, relying on the
+// worker's "play the recorded track" fallback (see startPlayback).
+func (a *app) sendPlayTrackMessage() {
+	a.postToWorker(workerMessage{Type: "playTrack"})
 }
 
 func (a *app) main() {
@@ -187,6 +311,28 @@ func (a *app) main() {
 			}
 		case wheel.Event:
 			a.sendZoomCameraMessage(e.Delta.Y)
+		case touch.Event:
+			for _, raw := range a.gestures.Feed(e) {
+				ge := a.Filter(raw)
+				if ge == nil {
+					continue
+				}
+				switch g := ge.(type) {
+				case gesture.Pan:
+					if g.NumTouches == 1 {
+						a.sendRotateCameraMessage(g.Delta.X, g.Delta.Y)
+					} else {
+						a.sendTranslateCameraMessage(g.Delta.X, g.Delta.Y)
+					}
+				case gesture.Pinch:
+					a.sendPinchZoomMessage(g.Scale)
+				case gesture.Rotate:
+					// No camera control takes a roll input today; twisting
+					// two fingers is recognized but not yet wired to
+					// anything, the same way key.Event codes with no case
+					// below are simply ignored.
+				}
+			}
 		case key.Event:
 			if e.Direction == key.DirPress {
 				switch e.Code {
@@ -198,6 +344,12 @@ func (a *app) main() {
 					a.sendTranslateCameraMessage(0, -0.1)
 				case "ArrowDown":
 					a.sendTranslateCameraMessage(0, 0.1)
+				case "KeyD":
+					a.sendToggleDenoiseMessage()
+				case "KeyR":
+					a.sendRecordKeyframeMessage()
+				case "KeyP":
+					a.sendPlayTrackMessage()
 				}
 			}
 		default:
@@ -0,0 +1,79 @@
+// Package bridge turns a local instrument.Bus into a distributed one
+// without changing any driver code: Serve exposes a Bus to the network,
+// and Dial presents a remote Bus as an ordinary local instrument.Bus.
+//
+// A heavy camera driver running on one host can Serve its Bus filtered
+// down to just Grabbed, so a sequencer on another host can Dial in and
+// subscribe to frames without every local Hovered sample also crossing
+// the wire. Each side applies its own filter, so the wire only ever
+// carries what the other side actually asked for.
+package bridge
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	instrument "github.com/scottlawsonbc/slam/code/photon/raytrace/lab/client"
+)
+
+// writeFrame writes one [4-byte big-endian length][payload] frame to w,
+// payload being one instrument.Marshal blob. The outer length is
+// necessary because instrument.Marshal's own internal length only
+// demarcates that one Event's JSON/binary boundary, not where it ends on
+// a shared stream that carries many Events back to back.
+func writeFrame(w io.Writer, payload []byte) error {
+	var header [4]byte
+	binary.BigEndian.PutUint32(header[:], uint32(len(payload)))
+	if _, err := w.Write(header[:]); err != nil {
+		return err
+	}
+	_, err := w.Write(payload)
+	return err
+}
+
+// maxFramePayload bounds the payload size readFrame will allocate for, the
+// same defense playground/websocket.go's wsMaxFramePayload applies to a
+// client-controlled length prefix: without a cap, a peer can claim a
+// multi-gigabyte frame and force a huge allocation before a single byte
+// of it has even arrived.
+const maxFramePayload = 64 << 20 // 64 MiB; a camera frame is the biggest payload this package ever sends, and most are far smaller.
+
+// readFrame reads one frame written by writeFrame.
+func readFrame(r io.Reader) ([]byte, error) {
+	var header [4]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, err
+	}
+	length := binary.BigEndian.Uint32(header[:])
+	if length > maxFramePayload {
+		return nil, fmt.Errorf("bridge: frame payload %d bytes exceeds %d byte limit", length, maxFramePayload)
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
+
+// writeEvent marshals e and writes it to w as one frame.
+func writeEvent(w io.Writer, e instrument.Event) error {
+	b, err := instrument.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("bridge: %w", err)
+	}
+	return writeFrame(w, b)
+}
+
+// readEvent reads one frame from r and unmarshals it.
+func readEvent(r io.Reader) (instrument.Event, error) {
+	b, err := readFrame(r)
+	if err != nil {
+		return instrument.Event{}, err
+	}
+	e, err := instrument.Unmarshal(b)
+	if err != nil {
+		return instrument.Event{}, fmt.Errorf("bridge: %w", err)
+	}
+	return e, nil
+}
@@ -0,0 +1,74 @@
+package bridge
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"sync"
+
+	instrument "github.com/scottlawsonbc/slam/code/photon/raytrace/lab/client"
+)
+
+// Dial connects to a Served listener at addr and returns a Bus backed by
+// that connection: Publish writes matching (per filter) events to the
+// wire, and every event the connection delivers back is published onto
+// the Bus's own subscribers, so callers on the Dial side use the result
+// exactly like a local instrument.NewBus.
+func Dial(addr string, filter instrument.Filter) (instrument.Bus, error) {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("bridge.Dial: %w", err)
+	}
+	b := &remoteBus{
+		conn:   conn,
+		filter: filter,
+		local:  instrument.NewBus(),
+	}
+	go b.readLoop()
+	return b, nil
+}
+
+// remoteBus presents one bridge connection as an instrument.Bus.
+// Subscribe is served entirely from local, which readLoop republishes
+// every event the connection delivers onto; Publish instead writes
+// straight to the wire, the mirror image of serveConn's own
+// subscribe-to-bus/publish-from-conn relationship on the Serve side.
+type remoteBus struct {
+	writeMu sync.Mutex // guards conn writes; readLoop owns conn reads exclusively
+	conn    net.Conn
+	filter  instrument.Filter
+	local   instrument.Bus
+}
+
+// Publish writes e to the connection if it matches filter. Bus.Publish
+// has no error return, so a write failure here is only visible
+// indirectly: readLoop observes the same broken connection and returns,
+// after which every local Subscribe simply stops receiving events.
+func (b *remoteBus) Publish(e instrument.Event) {
+	if !b.filter.Matches(e) {
+		return
+	}
+	b.writeMu.Lock()
+	defer b.writeMu.Unlock()
+	_ = writeEvent(b.conn, e)
+}
+
+// Subscribe is satisfied entirely by local; see readLoop.
+func (b *remoteBus) Subscribe(f instrument.Filter, h instrument.Handler) instrument.Binding {
+	return b.local.Subscribe(f, h)
+}
+
+// readLoop republishes every event the connection delivers onto local
+// until the connection errors or closes, at which point it closes conn
+// and returns, leaving local's existing subscriptions intact but quiet.
+func (b *remoteBus) readLoop() {
+	defer b.conn.Close()
+	r := bufio.NewReader(b.conn)
+	for {
+		e, err := readEvent(r)
+		if err != nil {
+			return
+		}
+		b.local.Publish(e)
+	}
+}
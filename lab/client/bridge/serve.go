@@ -0,0 +1,115 @@
+package bridge
+
+import (
+	"bufio"
+	"net"
+	"sync"
+
+	instrument "github.com/scottlawsonbc/slam/code/photon/raytrace/lab/client"
+)
+
+// Serve accepts connections on l and bridges each one to bus: every bus
+// event matching filter is forwarded to the connection, and every event
+// the connection sends back is published onto bus, so a remote driver's
+// Control requests reach local subscribers the same way a local
+// publisher's would.
+//
+// Serve blocks accepting connections until l is closed, then returns l's
+// Accept error, matching net.Listener's own documented Close behavior.
+// Callers that want to stop Serve close l from another goroutine.
+func Serve(l net.Listener, bus instrument.Bus, filter instrument.Filter) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		go serveConn(conn, bus, filter)
+	}
+}
+
+// serveConn bridges one accepted connection to bus until it errors or
+// closes.
+func serveConn(conn net.Conn, bus instrument.Bus, filter instrument.Filter) {
+	defer conn.Close()
+
+	sc := &serveConnEcho{conn: conn, pending: make(map[string]int)}
+	binding := bus.Subscribe(filter, sc.forward)
+	defer binding.Unbind()
+
+	r := bufio.NewReader(conn)
+	for {
+		e, err := readEvent(r)
+		if err != nil {
+			return
+		}
+		sc.markPending(e)
+		bus.Publish(e)
+	}
+}
+
+// serveConnEcho forwards bus events matching serveConn's filter out to
+// conn, while suppressing the one case that filter can't tell apart on
+// its own: an event this same connection just sent in, flowing straight
+// back out the way it came. If filter matches both directions (the zero
+// Filter, or any filter broad enough to catch a Control this connection
+// itself just Published), bus.Publish would otherwise hand that event
+// right back to the subscription below before Publish even returns
+// (Handler's doc promises synchronous, same-goroutine delivery), so
+// markPending/forward fingerprint events by their own wire encoding to
+// recognize and drop exactly the ones this connection is still waiting to
+// see echo back, rather than every event that happens to match filter.
+type serveConnEcho struct {
+	conn    net.Conn
+	writeMu sync.Mutex
+
+	pendingMu sync.Mutex
+	pending   map[string]int // keyed by eventKey(e); counts events read from conn not yet seen back from bus.Publish
+}
+
+// markPending records that e was just read off sc.conn and is about to be
+// republished onto the shared bus.
+func (sc *serveConnEcho) markPending(e instrument.Event) {
+	key, err := eventKey(e)
+	if err != nil {
+		return // Can't fingerprint it; forward will just echo it back, a relatively harmless cost next to silently dropping a good event.
+	}
+	sc.pendingMu.Lock()
+	sc.pending[key]++
+	sc.pendingMu.Unlock()
+}
+
+// forward is sc's Handler: it writes e to conn, unless e is the one this
+// same connection just sent in via markPending, in which case it consumes
+// that pending entry and drops e instead of echoing it.
+func (sc *serveConnEcho) forward(e instrument.Event) {
+	if key, err := eventKey(e); err == nil {
+		sc.pendingMu.Lock()
+		if n := sc.pending[key]; n > 0 {
+			if n == 1 {
+				delete(sc.pending, key)
+			} else {
+				sc.pending[key] = n - 1
+			}
+			sc.pendingMu.Unlock()
+			return
+		}
+		sc.pendingMu.Unlock()
+	}
+
+	sc.writeMu.Lock()
+	defer sc.writeMu.Unlock()
+	// A write error here means conn is already broken; the read loop in
+	// serveConn will observe the same thing and unwind via its own
+	// return, tearing down this Binding.
+	_ = writeEvent(sc.conn, e)
+}
+
+// eventKey fingerprints e by its own wire encoding: two Events that
+// marshal identically are indistinguishable for echo suppression anyway.
+func eventKey(e instrument.Event) (string, error) {
+	b, err := instrument.Marshal(e)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
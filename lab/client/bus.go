@@ -0,0 +1,299 @@
+package instrument
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Handler is invoked with each Event a subscription's Filter matches.
+// Handlers run synchronously on the goroutine that calls Publish (see
+// Bus.Publish), so a slow Handler delays every other subscriber; do
+// expensive work on a separate goroutine and hand off through a channel
+// instead of blocking inside the Handler itself.
+type Handler func(Event)
+
+// Filter selects which Events a subscription receives. A zero-valued
+// field matches any value; all non-zero fields must match for an Event
+// to pass. Drv, Ctl, and Ind are read from whichever Data payload the
+// Event actually carries (see Event.drv/ctl/ind) rather than requiring a
+// caller to know which pointer field to check.
+type Filter struct {
+	// Type restricts to one EventType; "" matches any Type.
+	Type EventType
+	// From restricts to one publisher name; "" matches any From.
+	From string
+	// Drv restricts to one driver name; "" matches any Drv.
+	Drv string
+	// Ctl restricts to one control name; "" matches any Ctl. Only
+	// Control and Controlled events carry a Ctl, so a non-empty Ctl
+	// filter excludes every other Type unless Type is also set to one
+	// of those two.
+	Ctl string
+	// Ind restricts to one indicator name; "" matches any Ind. Only
+	// Indicate and Indicated events carry an Ind, with the same caveat
+	// as Ctl above.
+	Ind string
+	// Req restricts to one request-correlation id; "" matches any Req.
+	// Only Control, Controlled, Indicate, and Indicated events carry a
+	// Req (see DataControl.Req's doc comment); this is what lets
+	// BusWaitFor demultiplex concurrent requests to the same Drv/Ctl
+	// pair by the id the sequencer chose when it published the request.
+	Req string
+}
+
+// Matches reports whether e satisfies every non-zero field of f.
+func (f Filter) Matches(e Event) bool {
+	if f.Type != "" && f.Type != e.Type {
+		return false
+	}
+	if f.From != "" && f.From != e.From {
+		return false
+	}
+	if f.Drv != "" && f.Drv != e.drv() {
+		return false
+	}
+	if f.Ctl != "" && f.Ctl != e.ctl() {
+		return false
+	}
+	if f.Ind != "" && f.Ind != e.ind() {
+		return false
+	}
+	if f.Req != "" && f.Req != e.req() {
+		return false
+	}
+	return true
+}
+
+// drv returns the Drv field of whichever Data payload e carries, or ""
+// for event kinds that don't name a driver (e.g. Sequenced has no Drv).
+func (e Event) drv() string {
+	switch {
+	case e.Data.Arrived != nil:
+		return e.Data.Arrived.Drv
+	case e.Data.Removed != nil:
+		return e.Data.Removed.Drv
+	case e.Data.Paint != nil:
+		return e.Data.Paint.Drv
+	case e.Data.Painted != nil:
+		return e.Data.Painted.Drv
+	case e.Data.Indicate != nil:
+		return e.Data.Indicate.Drv
+	case e.Data.Indicated != nil:
+		return e.Data.Indicated.Drv
+	case e.Data.Control != nil:
+		return e.Data.Control.Drv
+	case e.Data.Controlled != nil:
+		return e.Data.Controlled.Drv
+	case e.Data.Grabbed != nil:
+		return e.Data.Grabbed.Drv
+	case e.Data.Sequenced != nil:
+		return e.Data.Sequenced.Drv
+	case e.Data.Hovered != nil:
+		return e.Data.Hovered.Drv
+	case e.Data.Clicked != nil:
+		return e.Data.Clicked.Drv
+	case e.Data.Scrolled != nil:
+		return e.Data.Scrolled.Drv
+	case e.Data.Keyed != nil:
+		return e.Data.Keyed.Drv
+	case e.Data.Sized != nil:
+		return e.Data.Sized.Drv
+	default:
+		return ""
+	}
+}
+
+// ctl returns the Ctl field of a Control or Controlled event, or "" for
+// every other Type.
+func (e Event) ctl() string {
+	switch {
+	case e.Data.Control != nil:
+		return e.Data.Control.Ctl
+	case e.Data.Controlled != nil:
+		return e.Data.Controlled.Ctl
+	default:
+		return ""
+	}
+}
+
+// ind returns the Ind field of an Indicate or Indicated event, or "" for
+// every other Type.
+func (e Event) ind() string {
+	switch {
+	case e.Data.Indicate != nil:
+		return e.Data.Indicate.Ind
+	case e.Data.Indicated != nil:
+		return e.Data.Indicated.Ind
+	default:
+		return ""
+	}
+}
+
+// req returns the Req field of a Control, Controlled, Indicate, or
+// Indicated event, or "" for every other Type.
+func (e Event) req() string {
+	switch {
+	case e.Data.Control != nil:
+		return e.Data.Control.Req
+	case e.Data.Controlled != nil:
+		return e.Data.Controlled.Req
+	case e.Data.Indicate != nil:
+		return e.Data.Indicate.Req
+	case e.Data.Indicated != nil:
+		return e.Data.Indicated.Req
+	default:
+		return ""
+	}
+}
+
+// Binding is returned by Bus.Subscribe. Unbind removes the subscription;
+// CallerID is a stable identifier for the subscription, assigned at
+// Subscribe time, so a caller can correlate a response event back to the
+// request that asked for it without racing a second Subscribe for the
+// same Filter.
+type Binding interface {
+	Unbind()
+	CallerID() string
+}
+
+// Bus is the publish/subscribe surface drivers use instead of talking to
+// each other directly, per this package's design rule 1 ("Publish on the
+// bus, not directly to peers"). Construct one with NewBus.
+type Bus interface {
+	// Publish delivers e to every current subscription whose Filter
+	// matches it.
+	Publish(Event)
+	// Subscribe registers handler to be called with every future Event
+	// matching filter, until the returned Binding's Unbind is called.
+	Subscribe(Filter, Handler) Binding
+}
+
+// NewBus constructs an in-process Bus. There is no global bus: every
+// driver and sequencer that needs one is handed the same *inProcessBus
+// explicitly, the same way phys.WatchScene takes its context rather than
+// reading one from a package-level variable.
+func NewBus() Bus {
+	return &inProcessBus{subs: make(map[string]*subscription)}
+}
+
+// subscription pairs a Filter with the Handler to call when it matches.
+type subscription struct {
+	id      string
+	filter  Filter
+	handler Handler
+}
+
+// inProcessBus is Bus's only implementation: a mutex-guarded map of
+// subscriptions, delivering events synchronously on the publishing
+// goroutine.
+type inProcessBus struct {
+	mu     sync.Mutex
+	subs   map[string]*subscription
+	nextID uint64
+}
+
+// Publish snapshots the current subscriptions under lock, then calls
+// each matching Handler outside the lock, so a Handler that itself calls
+// Subscribe or Unbind doesn't deadlock against Publish's own lock.
+func (b *inProcessBus) Publish(e Event) {
+	b.mu.Lock()
+	subs := make([]*subscription, 0, len(b.subs))
+	for _, s := range b.subs {
+		subs = append(subs, s)
+	}
+	b.mu.Unlock()
+
+	for _, s := range subs {
+		if s.filter.Matches(e) {
+			s.handler(e)
+		}
+	}
+}
+
+// Subscribe registers handler under a freshly assigned CallerID.
+func (b *inProcessBus) Subscribe(f Filter, h Handler) Binding {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.nextID++
+	id := fmt.Sprintf("caller-%d", b.nextID)
+	b.subs[id] = &subscription{id: id, filter: f, handler: h}
+	return &inProcessBinding{bus: b, id: id}
+}
+
+// unbind removes the subscription with the given id, if still present.
+func (b *inProcessBus) unbind(id string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.subs, id)
+}
+
+// inProcessBinding is inProcessBus's Binding implementation.
+type inProcessBinding struct {
+	bus *inProcessBus
+	id  string
+}
+
+func (bd *inProcessBinding) Unbind() {
+	bd.bus.unbind(bd.id)
+}
+
+func (bd *inProcessBinding) CallerID() string {
+	return bd.id
+}
+
+// BusWaitFor subscribes to bus for a single Event matching filter and
+// returns it, the common case of a sequencer publishing a Control or
+// Indicate and waiting for the matching Controlled/Indicated. Set
+// filter.Req to the same id the request was published with, or a second
+// concurrent request to the same Drv/Ctl pair can satisfy this wait
+// instead of its own. It unsubscribes before returning either way, so a
+// canceled wait leaves no dangling subscription behind.
+func BusWaitFor(ctx context.Context, bus Bus, filter Filter) (Event, error) {
+	ch := make(chan Event, 1)
+	binding := bus.Subscribe(filter, func(e Event) {
+		select {
+		case ch <- e:
+		default:
+			// A previous match already satisfied this wait; drop any
+			// further ones rather than blocking Publish.
+		}
+	})
+	defer binding.Unbind()
+
+	select {
+	case e := <-ch:
+		return e, nil
+	case <-ctx.Done():
+		return Event{}, ctx.Err()
+	}
+}
+
+// BusPipe subscribes to bus and streams every matching Event on the
+// returned channel, for subscribers that want a Go channel to range over
+// instead of a callback -- a logger, or a UI redraw loop. The
+// subscription (and so the channel) lives until ctx is canceled, at
+// which point BusPipe unbinds and closes the channel; this differs from
+// a bare channel-returning Subscribe wrapper in the same way
+// phys.WatchScene takes a context to bound its own goroutine's lifetime.
+// The channel is buffered; a subscriber that falls behind drops events
+// rather than blocking Publish for every other subscriber.
+func BusPipe(ctx context.Context, bus Bus, filter Filter) <-chan Event {
+	const bufferSize = 16
+	ch := make(chan Event, bufferSize)
+	var binding Binding
+	binding = bus.Subscribe(filter, func(e Event) {
+		select {
+		case ch <- e:
+		default:
+		}
+	})
+
+	go func() {
+		<-ctx.Done()
+		binding.Unbind()
+		close(ch)
+	}()
+
+	return ch
+}
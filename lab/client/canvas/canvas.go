@@ -91,6 +91,72 @@ func (ctx *Context) Arc(x, y, radius, startAngle, endAngle float64, clockwise bo
 	ctx.Val.Call("arc", x, y, radius, startAngle, endAngle, clockwise)
 }
 
+// QuadraticCurveTo adds a quadratic Bézier curve to the current subpath,
+// from the current point through control point (cpx, cpy) to (x, y).
+func (ctx *Context) QuadraticCurveTo(cpx, cpy, x, y float64) {
+	ctx.Val.Call("quadraticCurveTo", cpx, cpy, x, y)
+}
+
+// BezierCurveTo adds a cubic Bézier curve to the current subpath, from
+// the current point through control points (cp1x, cp1y) and
+// (cp2x, cp2y) to (x, y).
+func (ctx *Context) BezierCurveTo(cp1x, cp1y, cp2x, cp2y, x, y float64) {
+	ctx.Val.Call("bezierCurveTo", cp1x, cp1y, cp2x, cp2y, x, y)
+}
+
+// ArcTo adds an arc to the current subpath, tangent to the lines from
+// the current point to (x1, y1) and from (x1, y1) to (x2, y2), with
+// radius r.
+func (ctx *Context) ArcTo(x1, y1, x2, y2, r float64) {
+	ctx.Val.Call("arcTo", x1, y1, x2, y2, r)
+}
+
+// Ellipse adds an elliptical arc to the current subpath, centered at
+// (x, y) with radii (radiusX, radiusY) whose axes are rotated by
+// rotation radians.
+func (ctx *Context) Ellipse(x, y, radiusX, radiusY, rotation, startAngle, endAngle float64, clockwise bool) {
+	ctx.Val.Call("ellipse", x, y, radiusX, radiusY, rotation, startAngle, endAngle, clockwise)
+}
+
+// SetLineDash sets the dash pattern Stroke draws with, as alternating
+// on/off segment lengths; an empty slice draws a solid line.
+func (ctx *Context) SetLineDash(segments []float64) {
+	vals := make([]interface{}, len(segments))
+	for i, s := range segments {
+		vals[i] = s
+	}
+	ctx.Val.Call("setLineDash", vals)
+}
+
+// LineDashOffset sets the starting offset into the dash pattern set by
+// SetLineDash.
+func (ctx *Context) LineDashOffset(offset float64) {
+	ctx.Val.Set("lineDashOffset", offset)
+}
+
+// LineWidth sets the width, in canvas units, that Stroke draws lines with.
+func (ctx *Context) LineWidth(w float64) {
+	ctx.Val.Set("lineWidth", w)
+}
+
+// LineCap sets the shape Stroke draws at the end of open subpaths:
+// "butt", "round", or "square".
+func (ctx *Context) LineCap(s string) {
+	ctx.Val.Set("lineCap", s)
+}
+
+// LineJoin sets the shape Stroke draws where two segments meet: "round",
+// "bevel", or "miter".
+func (ctx *Context) LineJoin(s string) {
+	ctx.Val.Set("lineJoin", s)
+}
+
+// MiterLimit sets the maximum miter length, as a multiple of LineWidth,
+// before a "miter" LineJoin falls back to a bevel.
+func (ctx *Context) MiterLimit(m float64) {
+	ctx.Val.Set("miterLimit", m)
+}
+
 // DrawImage draws an image onto the canvas with specified source and destination parameters.
 func (ctx *Context) DrawImage(src js.Value, sx, sy, sw, sh, dx, dy, dw, dh float64) {
 	ctx.Val.Call("drawImage", src, sx, sy, sw, sh, dx, dy, dw, dh)
@@ -0,0 +1,1059 @@
+//go:build !(js && wasm)
+
+package canvas
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r2"
+)
+
+// Context mirrors the js&&wasm Context's path-based drawing API --
+// BeginPath/MoveTo/LineTo/Arc build up subpaths, Fill/Stroke rasterize
+// them, Translate/Rotate compose a transform -- so scene code that
+// annotates frames with 2D overlays (axes, bounding boxes, gizmos)
+// compiles and runs unchanged whether it draws into a browser <canvas>
+// or directly into an in-process image.RGBA, e.g. when rendering
+// headless or to PNG/EXR. It is backed by its own draw2d-style path
+// flattener and an anti-aliased scanline rasterizer instead of the
+// browser's native canvas, so DrawImage/GetImageData/PutImageData take
+// image.Image/*image.RGBA rather than js.Value, the one place the two
+// Contexts' signatures necessarily differ.
+type Context struct {
+	img *image.RGBA
+
+	transform  affine
+	subpaths   []subpath
+	current    *subpath
+	startPoint r2.Point
+
+	fillStyle   color.NRGBA
+	strokeStyle color.NRGBA
+
+	// lineWidth, lineCap, lineJoin, and miterLimit are Stroke's pen
+	// style, set by the methods of the same name; lineDash/lineDashOffset
+	// are the dash pattern set by SetLineDash/LineDashOffset. Defaults
+	// below match CanvasRenderingContext2D's own.
+	lineWidth      float64
+	lineCap        string
+	lineJoin       string
+	miterLimit     float64
+	lineDash       []float64
+	lineDashOffset float64
+}
+
+// subpath is one flattened polyline making up part of the current path, in
+// device (post-transform) coordinates.
+type subpath struct {
+	points []r2.Point
+	closed bool
+}
+
+// NewContext returns a Context that draws into img, starting with an
+// identity transform, opaque black fill and stroke styles (canvas's
+// defaults), and an empty path.
+func NewContext(img *image.RGBA) *Context {
+	return &Context{
+		img:         img,
+		transform:   identityAffine,
+		fillStyle:   color.NRGBA{A: 255},
+		strokeStyle: color.NRGBA{A: 255},
+		lineWidth:   1,
+		lineCap:     "butt",
+		lineJoin:    "miter",
+		miterLimit:  10,
+	}
+}
+
+// Width returns the width of the backing image.
+func (ctx *Context) Width() float64 {
+	return float64(ctx.img.Bounds().Dx())
+}
+
+// Height returns the height of the backing image.
+func (ctx *Context) Height() float64 {
+	return float64(ctx.img.Bounds().Dy())
+}
+
+// DrawImage draws src's (sx, sy, sw, sh) source rectangle into ctx's
+// (dx, dy, dw, dh) destination rectangle, nearest-neighbor sampled and
+// subject to the current transform -- like canvas's own drawImage, which
+// the real CanvasRenderingContext2D applies its CTM to just like any
+// other draw call. It takes an image.Image rather than js.Value, the
+// native side's equivalent of a decoded <img>/<canvas> source.
+func (ctx *Context) DrawImage(src image.Image, sx, sy, sw, sh, dx, dy, dw, dh float64) {
+	if sw == 0 || sh == 0 || dw == 0 || dh == 0 {
+		return
+	}
+	corners := []r2.Point{
+		ctx.transform.apply(r2.Point{X: dx, Y: dy}),
+		ctx.transform.apply(r2.Point{X: dx + dw, Y: dy}),
+		ctx.transform.apply(r2.Point{X: dx, Y: dy + dh}),
+		ctx.transform.apply(r2.Point{X: dx + dw, Y: dy + dh}),
+	}
+	minX, minY, maxX, maxY := corners[0].X, corners[0].Y, corners[0].X, corners[0].Y
+	for _, c := range corners[1:] {
+		minX, maxX = math.Min(minX, c.X), math.Max(maxX, c.X)
+		minY, maxY = math.Min(minY, c.Y), math.Max(maxY, c.Y)
+	}
+	bounds := ctx.img.Bounds()
+	inv := ctx.transform.invert()
+	for y := int(math.Floor(minY)); y < int(math.Ceil(maxY)); y++ {
+		for x := int(math.Floor(minX)); x < int(math.Ceil(maxX)); x++ {
+			if !(image.Point{X: x, Y: y}.In(bounds)) {
+				continue
+			}
+			local := inv.apply(r2.Point{X: float64(x) + 0.5, Y: float64(y) + 0.5})
+			if local.X < dx || local.X >= dx+dw || local.Y < dy || local.Y >= dy+dh {
+				continue
+			}
+			u := sx + (local.X-dx)/dw*sw
+			v := sy + (local.Y-dy)/dh*sh
+			blendPixel(ctx.img, x, y, unpremultiply(src.At(int(u), int(v))), 1)
+		}
+	}
+}
+
+// GetImageData returns a copy of ctx's backing image over the rectangle
+// (x, y, w, h), mirroring the data an ImageData round-trips in the
+// browser; canvas.PutImageData accepts this back unchanged.
+func (ctx *Context) GetImageData(x, y, w, h float64) *image.RGBA {
+	rect := image.Rect(int(x), int(y), int(x+w), int(y+h))
+	dst := image.NewRGBA(image.Rect(0, 0, rect.Dx(), rect.Dy()))
+	for row := 0; row < rect.Dy(); row++ {
+		for col := 0; col < rect.Dx(); col++ {
+			dst.Set(col, row, ctx.img.At(rect.Min.X+col, rect.Min.Y+row))
+		}
+	}
+	return dst
+}
+
+// PutImageData writes img's pixels into ctx's backing image with its
+// top-left corner at (x, y), ignoring the current transform, the same
+// way canvas's own PutImageData bypasses it.
+func (ctx *Context) PutImageData(img *image.RGBA, x, y float64) {
+	bounds := img.Bounds()
+	ox, oy := int(x), int(y)
+	for row := 0; row < bounds.Dy(); row++ {
+		for col := 0; col < bounds.Dx(); col++ {
+			ctx.img.Set(ox+col, oy+row, img.RGBAAt(bounds.Min.X+col, bounds.Min.Y+row))
+		}
+	}
+}
+
+// affine is a 2D affine transform mapping (x, y) to
+// (a*x + c*y + e, b*x + d*y + f), matching the 2x3 matrix canvas's own
+// CurrentTransformationMatrix uses.
+type affine struct {
+	a, b, c, d, e, f float64
+}
+
+var identityAffine = affine{a: 1, d: 1}
+
+func (m affine) apply(p r2.Point) r2.Point {
+	return r2.Point{X: m.a*p.X + m.c*p.Y + m.e, Y: m.b*p.X + m.d*p.Y + m.f}
+}
+
+// mul returns the matrix that applies n first, then m -- i.e. m.mul(n)
+// composed with a point p computes m.apply(n.apply(p)), which is how
+// Translate/Rotate fold a new operation into ctx.transform.
+func (m affine) mul(n affine) affine {
+	return affine{
+		a: m.a*n.a + m.c*n.b,
+		b: m.b*n.a + m.d*n.b,
+		c: m.a*n.c + m.c*n.d,
+		d: m.b*n.c + m.d*n.d,
+		e: m.a*n.e + m.c*n.f + m.e,
+		f: m.b*n.e + m.d*n.f + m.f,
+	}
+}
+
+// invert returns the affine transform that undoes m, used by DrawImage to
+// map a device pixel back to pre-transform destination-rectangle
+// coordinates. m is invertible as long as it has non-zero determinant,
+// which holds for every transform Translate/Rotate can produce.
+func (m affine) invert() affine {
+	det := m.a*m.d - m.b*m.c
+	return affine{
+		a: m.d / det,
+		b: -m.b / det,
+		c: -m.c / det,
+		d: m.a / det,
+		e: (m.c*m.f - m.d*m.e) / det,
+		f: (m.b*m.e - m.a*m.f) / det,
+	}
+}
+
+// Translate applies a translation transformation to the canvas.
+func (ctx *Context) Translate(x, y float64) {
+	ctx.transform = ctx.transform.mul(affine{a: 1, d: 1, e: x, f: y})
+}
+
+// Rotate applies a rotation transformation to the canvas.
+func (ctx *Context) Rotate(angle float64) {
+	sin, cos := math.Sin(angle), math.Cos(angle)
+	ctx.transform = ctx.transform.mul(affine{a: cos, b: sin, c: -sin, d: cos})
+}
+
+// BeginPath starts a new path on the canvas, discarding any subpaths
+// accumulated so far.
+func (ctx *Context) BeginPath() {
+	ctx.subpaths = nil
+	ctx.current = nil
+}
+
+// ClosePath closes the current subpath by connecting its last point back
+// to its first, for both Fill (which treats every subpath as closed
+// regardless) and Stroke (which only draws the closing edge when asked).
+func (ctx *Context) ClosePath() {
+	if ctx.current == nil || len(ctx.current.points) == 0 {
+		return
+	}
+	ctx.current.closed = true
+}
+
+// MoveTo moves the starting point of a new subpath to the specified
+// coordinates.
+func (ctx *Context) MoveTo(x, y float64) {
+	ctx.finishCurrent()
+	p := ctx.transform.apply(r2.Point{X: x, Y: y})
+	ctx.subpaths = append(ctx.subpaths, subpath{points: []r2.Point{p}})
+	ctx.current = &ctx.subpaths[len(ctx.subpaths)-1]
+}
+
+// LineTo adds a straight line to the current subpath, implicitly starting
+// one at the origin if none is open yet (matching canvas's own behavior).
+func (ctx *Context) LineTo(x, y float64) {
+	p := ctx.transform.apply(r2.Point{X: x, Y: y})
+	if ctx.current == nil {
+		ctx.MoveTo(0, 0)
+	}
+	ctx.current.points = append(ctx.current.points, p)
+}
+
+// Rect adds a new closed rectangular subpath to the path, without
+// disturbing whatever subpath was previously being built.
+func (ctx *Context) Rect(x, y, w, h float64) {
+	ctx.finishCurrent()
+	ctx.subpaths = append(ctx.subpaths, subpath{points: ctx.rectPoints(x, y, w, h), closed: true})
+}
+
+func (ctx *Context) rectPoints(x, y, w, h float64) []r2.Point {
+	return []r2.Point{
+		ctx.transform.apply(r2.Point{X: x, Y: y}),
+		ctx.transform.apply(r2.Point{X: x + w, Y: y}),
+		ctx.transform.apply(r2.Point{X: x + w, Y: y + h}),
+		ctx.transform.apply(r2.Point{X: x, Y: y + h}),
+	}
+}
+
+// Arc adds an arc to the current subpath, flattening it into line segments;
+// clockwise follows canvas's convention of increasing angle in a
+// y-down coordinate system.
+func (ctx *Context) Arc(x, y, radius, startAngle, endAngle float64, clockwise bool) {
+	ctx.appendFlattened(flattenArc(x, y, radius, startAngle, endAngle, clockwise))
+}
+
+// Ellipse adds an elliptical arc to the current subpath, centered at
+// (x, y) with radii (radiusX, radiusY) whose axes are rotated by
+// rotation radians, flattened the same way Arc flattens a circular one.
+func (ctx *Context) Ellipse(x, y, radiusX, radiusY, rotation, startAngle, endAngle float64, clockwise bool) {
+	ctx.appendFlattened(flattenEllipse(x, y, radiusX, radiusY, rotation, startAngle, endAngle, clockwise))
+}
+
+// appendFlattened appends pts (in user space) to the current subpath,
+// transforming each one, starting a new subpath at pts[0] first if none
+// is open yet. Arc and Ellipse share it since an ellipse with equal radii
+// and zero rotation is exactly a circle.
+func (ctx *Context) appendFlattened(pts []r2.Point) {
+	for i, p := range pts {
+		if i == 0 && ctx.current == nil {
+			ctx.MoveTo(p.X, p.Y)
+			continue
+		}
+		ctx.current.points = append(ctx.current.points, ctx.transform.apply(p))
+	}
+}
+
+// arcFlattenSteps bounds how finely Arc/Ellipse subdivide a full
+// revolution; matches the density a scanline rasterizer needs to keep a
+// radius-100px arc looking smooth without wasting time on tiny ones.
+const arcFlattenSteps = 64
+
+func flattenArc(x, y, radius, startAngle, endAngle float64, clockwise bool) []r2.Point {
+	return flattenEllipse(x, y, radius, radius, 0, startAngle, endAngle, clockwise)
+}
+
+func flattenEllipse(x, y, radiusX, radiusY, rotation, startAngle, endAngle float64, clockwise bool) []r2.Point {
+	if !clockwise && endAngle < startAngle {
+		endAngle += 2 * math.Pi
+	}
+	if clockwise && startAngle < endAngle {
+		startAngle += 2 * math.Pi
+	}
+	span := endAngle - startAngle
+	steps := int(math.Ceil(math.Abs(span) / (2 * math.Pi) * arcFlattenSteps))
+	if steps < 1 {
+		steps = 1
+	}
+	cosRot, sinRot := math.Cos(rotation), math.Sin(rotation)
+	pts := make([]r2.Point, 0, steps+1)
+	for i := 0; i <= steps; i++ {
+		t := startAngle + span*float64(i)/float64(steps)
+		ex, ey := radiusX*math.Cos(t), radiusY*math.Sin(t)
+		pts = append(pts, r2.Point{X: x + ex*cosRot - ey*sinRot, Y: y + ex*sinRot + ey*cosRot})
+	}
+	return pts
+}
+
+// flattenTolerance bounds how far a flattened Bézier curve's polyline may
+// deviate (in device pixels) from the true curve; QuadraticCurveTo and
+// BezierCurveTo subdivide with de Casteljau until every piece is flat to
+// within this.
+const flattenTolerance = 0.25
+
+// maxFlattenDepth caps de Casteljau recursion so a numerically degenerate
+// curve (near-zero length, control points far outside the flattened
+// bounds) can't recurse indefinitely chasing flatness.
+const maxFlattenDepth = 16
+
+// QuadraticCurveTo adds a quadratic Bézier curve to the current subpath,
+// from the current point through control point (cpx, cpy) to (x, y),
+// flattened into line segments via adaptive de Casteljau subdivision.
+func (ctx *Context) QuadraticCurveTo(cpx, cpy, x, y float64) {
+	if ctx.current == nil {
+		ctx.MoveTo(0, 0)
+	}
+	p0 := ctx.current.points[len(ctx.current.points)-1]
+	cp := ctx.transform.apply(r2.Point{X: cpx, Y: cpy})
+	p2 := ctx.transform.apply(r2.Point{X: x, Y: y})
+	pts := flattenQuadratic(p0, cp, p2, flattenTolerance)
+	ctx.current.points = append(ctx.current.points, pts[1:]...)
+}
+
+// BezierCurveTo adds a cubic Bézier curve to the current subpath, from
+// the current point through control points (cp1x, cp1y) and
+// (cp2x, cp2y) to (x, y), flattened the same way QuadraticCurveTo is.
+func (ctx *Context) BezierCurveTo(cp1x, cp1y, cp2x, cp2y, x, y float64) {
+	if ctx.current == nil {
+		ctx.MoveTo(0, 0)
+	}
+	p0 := ctx.current.points[len(ctx.current.points)-1]
+	cp1 := ctx.transform.apply(r2.Point{X: cp1x, Y: cp1y})
+	cp2 := ctx.transform.apply(r2.Point{X: cp2x, Y: cp2y})
+	p3 := ctx.transform.apply(r2.Point{X: x, Y: y})
+	pts := flattenCubic(p0, cp1, cp2, p3, flattenTolerance)
+	ctx.current.points = append(ctx.current.points, pts[1:]...)
+}
+
+// ArcTo adds an arc to the current subpath, tangent to the segment from
+// the current point to (x1, y1) and the segment from (x1, y1) to
+// (x2, y2), with radius r -- the same tangent-circle construction
+// CanvasRenderingContext2D.arcTo uses, including the implicit straight
+// line from the current point to the arc's first tangent point.
+func (ctx *Context) ArcTo(x1, y1, x2, y2, r float64) {
+	if ctx.current == nil {
+		ctx.MoveTo(x1, y1)
+		return
+	}
+	p0 := ctx.current.points[len(ctx.current.points)-1]
+	p1 := ctx.transform.apply(r2.Point{X: x1, Y: y1})
+	p2 := ctx.transform.apply(r2.Point{X: x2, Y: y2})
+	ctx.current.points = append(ctx.current.points, arcToPoints(p0, p1, p2, r)...)
+}
+
+// flattenQuadratic returns p0, followed by a flattened polyline from p0
+// through control point cp to p2, recursively bisecting until each piece
+// is within tol of the true curve (subdivideQuadratic).
+func flattenQuadratic(p0, cp, p2 r2.Point, tol float64) []r2.Point {
+	pts := []r2.Point{p0}
+	subdivideQuadratic(p0, cp, p2, tol, 0, &pts)
+	return pts
+}
+
+func subdivideQuadratic(p0, cp, p2 r2.Point, tol float64, depth int, pts *[]r2.Point) {
+	if depth >= maxFlattenDepth || pointLineDistance(cp, p0, p2) <= tol {
+		*pts = append(*pts, p2)
+		return
+	}
+	p01 := p0.Lerp(cp, 0.5)
+	p12 := cp.Lerp(p2, 0.5)
+	mid := p01.Lerp(p12, 0.5)
+	subdivideQuadratic(p0, p01, mid, tol, depth+1, pts)
+	subdivideQuadratic(mid, p12, p2, tol, depth+1, pts)
+}
+
+// flattenCubic returns p0, followed by a flattened polyline from p0
+// through control points cp1/cp2 to p3, the cubic analog of
+// flattenQuadratic.
+func flattenCubic(p0, cp1, cp2, p3 r2.Point, tol float64) []r2.Point {
+	pts := []r2.Point{p0}
+	subdivideCubic(p0, cp1, cp2, p3, tol, 0, &pts)
+	return pts
+}
+
+func subdivideCubic(p0, cp1, cp2, p3 r2.Point, tol float64, depth int, pts *[]r2.Point) {
+	flat := pointLineDistance(cp1, p0, p3) <= tol && pointLineDistance(cp2, p0, p3) <= tol
+	if depth >= maxFlattenDepth || flat {
+		*pts = append(*pts, p3)
+		return
+	}
+	p01 := p0.Lerp(cp1, 0.5)
+	p12 := cp1.Lerp(cp2, 0.5)
+	p23 := cp2.Lerp(p3, 0.5)
+	p012 := p01.Lerp(p12, 0.5)
+	p123 := p12.Lerp(p23, 0.5)
+	mid := p012.Lerp(p123, 0.5)
+	subdivideCubic(p0, p01, p012, mid, tol, depth+1, pts)
+	subdivideCubic(mid, p123, p23, p3, tol, depth+1, pts)
+}
+
+// movePoint returns p displaced by v -- r2.Point has no Point+Vec method
+// of its own (unlike r3.Point.Add), so the curve/join/cap geometry below
+// shares this instead of repeating the component-wise sum inline.
+func movePoint(p r2.Point, v r2.Vec) r2.Point {
+	return r2.Point{X: p.X + v.X, Y: p.Y + v.Y}
+}
+
+// pointLineDistance returns the perpendicular distance from p to the
+// infinite line through a and b, falling back to the distance to a
+// itself if a and b coincide.
+func pointLineDistance(p, a, b r2.Point) float64 {
+	ab := b.Sub(a)
+	length := ab.Length()
+	if length < 1e-9 {
+		return p.Sub(a).Length()
+	}
+	return math.Abs(ab.Cross(p.Sub(a))) / length
+}
+
+// arcToPoints implements the tangent-circle construction
+// CanvasRenderingContext2D.arcTo does: find the circle of radius r
+// tangent to both the segment p0->p1 and the segment p1->p2, emit a
+// straight line from p0 to the first tangent point, then a flattened arc
+// from there to the second tangent point. p0, p1, p2 and r share one
+// space (device space, in Context.ArcTo's case, which is safe since
+// Translate/Rotate never change distances or angles).
+func arcToPoints(p0, p1, p2 r2.Point, r float64) []r2.Point {
+	v0 := p0.Sub(p1)
+	v1 := p2.Sub(p1)
+	len0, len1 := v0.Length(), v1.Length()
+	if len0 < 1e-9 || len1 < 1e-9 || r == 0 {
+		return []r2.Point{p1}
+	}
+	u0, u1 := v0.Divs(len0), v1.Divs(len1)
+
+	cosTheta := math.Max(-1, math.Min(1, u0.Dot(u1)))
+	theta := math.Acos(cosTheta)
+	if theta < 1e-9 || theta > math.Pi-1e-9 {
+		return []r2.Point{p1} // Segments are parallel or anti-parallel: no tangent circle fits.
+	}
+
+	tangentDist := r / math.Tan(theta/2)
+	t0 := movePoint(p1, u0.Muls(tangentDist))
+	t1 := movePoint(p1, u1.Muls(tangentDist))
+
+	bisector := u0.Add(u1)
+	bisectorLen := bisector.Length()
+	if bisectorLen < 1e-9 {
+		return []r2.Point{t0}
+	}
+	center := movePoint(p1, bisector.Divs(bisectorLen).Muls(r/math.Sin(theta/2)))
+
+	startAngle := math.Atan2(t0.Y-center.Y, t0.X-center.X)
+	endAngle := math.Atan2(t1.Y-center.Y, t1.X-center.X)
+	// arcTo always takes the minor arc (sweep angle pi-theta < pi)
+	// between the two tangent points.
+	diff := endAngle - startAngle
+	for diff <= -math.Pi {
+		diff += 2 * math.Pi
+	}
+	for diff > math.Pi {
+		diff -= 2 * math.Pi
+	}
+	clockwise := diff < 0
+
+	pts := []r2.Point{t0}
+	return append(pts, flattenArc(center.X, center.Y, r, startAngle, endAngle, clockwise)[1:]...)
+}
+
+func (ctx *Context) finishCurrent() {
+	ctx.current = nil
+}
+
+// FillStyle sets the fill style used for drawing shapes, parsed the same
+// as canvas: "#rrggbb" or "rgba(r, g, b, a)".
+func (ctx *Context) FillStyle(s string) {
+	ctx.fillStyle = parseColor(s)
+}
+
+// StrokeStyle sets the stroke style used for drawing lines.
+func (ctx *Context) StrokeStyle(s string) {
+	ctx.strokeStyle = parseColor(s)
+}
+
+// LineWidth sets the width, in canvas units, that Stroke draws lines with.
+func (ctx *Context) LineWidth(w float64) {
+	ctx.lineWidth = w
+}
+
+// LineCap sets the shape Stroke draws at the end of open subpaths:
+// "butt", "round", or "square". Anything else is treated as "butt",
+// matching canvas's own invalid-value handling for this property.
+func (ctx *Context) LineCap(s string) {
+	ctx.lineCap = s
+}
+
+// LineJoin sets the shape Stroke draws where two segments meet: "round",
+// "bevel", or "miter". Anything else is treated as "miter".
+func (ctx *Context) LineJoin(s string) {
+	ctx.lineJoin = s
+}
+
+// MiterLimit sets the maximum miter length, as a multiple of LineWidth,
+// before a "miter" LineJoin falls back to a bevel.
+func (ctx *Context) MiterLimit(m float64) {
+	ctx.miterLimit = m
+}
+
+// SetLineDash sets the dash pattern Stroke draws with, as alternating
+// on/off run lengths in canvas units; an empty slice (the default) draws
+// a solid line. An odd-length pattern is repeated once, matching canvas.
+func (ctx *Context) SetLineDash(segments []float64) {
+	ctx.lineDash = append([]float64{}, segments...)
+}
+
+// LineDashOffset sets the starting offset into the dash pattern set by
+// SetLineDash.
+func (ctx *Context) LineDashOffset(offset float64) {
+	ctx.lineDashOffset = offset
+}
+
+// parseColor accepts the two style strings canvas scene code actually
+// needs: "#rrggbb" and "rgba(r, g, b, a)"/"rgb(r, g, b)". Anything else
+// falls back to opaque black rather than failing a frame over a typo.
+func parseColor(s string) color.NRGBA {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "#") && len(s) == 7 {
+		r, errR := strconv.ParseUint(s[1:3], 16, 8)
+		g, errG := strconv.ParseUint(s[3:5], 16, 8)
+		b, errB := strconv.ParseUint(s[5:7], 16, 8)
+		if errR == nil && errG == nil && errB == nil {
+			return color.NRGBA{R: uint8(r), G: uint8(g), B: uint8(b), A: 255}
+		}
+		return color.NRGBA{A: 255}
+	}
+	if strings.HasPrefix(s, "rgba(") || strings.HasPrefix(s, "rgb(") {
+		inner := s[strings.Index(s, "(")+1 : strings.LastIndex(s, ")")]
+		parts := strings.Split(inner, ",")
+		channel := func(i int) float64 {
+			if i >= len(parts) {
+				return 0
+			}
+			v, err := strconv.ParseFloat(strings.TrimSpace(parts[i]), 64)
+			if err != nil {
+				return 0
+			}
+			return v
+		}
+		a := 1.0
+		if len(parts) > 3 {
+			a = channel(3)
+		}
+		return color.NRGBA{
+			R: uint8(clamp(channel(0), 0, 255)),
+			G: uint8(clamp(channel(1), 0, 255)),
+			B: uint8(clamp(channel(2), 0, 255)),
+			A: uint8(clamp(a*255, 0, 255)),
+		}
+	}
+	return color.NRGBA{A: 255}
+}
+
+func clamp(v, lo, hi float64) float64 {
+	return math.Max(lo, math.Min(hi, v))
+}
+
+// Fill fills every subpath accumulated so far with fillStyle, using the
+// nonzero winding rule (each subpath is treated as closed regardless of
+// ClosePath, matching canvas's own Fill).
+func (ctx *Context) Fill() {
+	ctx.finishCurrent()
+	ctx.rasterizeFill(ctx.subpaths, ctx.fillStyle)
+}
+
+// FillRect immediately fills a rectangle with fillStyle, independent of
+// the current path.
+func (ctx *Context) FillRect(x, y, w, h float64) {
+	ctx.rasterizeFill([]subpath{{points: ctx.rectPoints(x, y, w, h), closed: true}}, ctx.fillStyle)
+}
+
+// ClearRect resets a rectangle to fully transparent, independent of the
+// current path or fillStyle.
+func (ctx *Context) ClearRect(x, y, w, h float64) {
+	ctx.rasterizeClear([]subpath{{points: ctx.rectPoints(x, y, w, h), closed: true}})
+}
+
+// Stroke outlines every subpath accumulated so far with strokeStyle,
+// LineWidth wide, joined and capped per LineJoin/LineCap, dashed per
+// SetLineDash, and using ClosePath's closed flag to decide whether to
+// draw the closing edge.
+func (ctx *Context) Stroke() {
+	ctx.finishCurrent()
+	ctx.rasterizeStroke(ctx.subpaths, ctx.strokeStyle)
+}
+
+// StrokeRect immediately strokes a rectangle's outline with strokeStyle,
+// independent of the current path.
+func (ctx *Context) StrokeRect(x, y, w, h float64) {
+	ctx.rasterizeStroke([]subpath{{points: ctx.rectPoints(x, y, w, h), closed: true}}, ctx.strokeStyle)
+}
+
+// rasterizeStroke dashes each subpath (dashSubpath), expands every
+// resulting run into thin quads plus join/cap geometry at its vertices
+// (strokeRun), then fills the lot as one shape so overlapping quads at a
+// joint don't double-blend.
+func (ctx *Context) rasterizeStroke(paths []subpath, style color.NRGBA) {
+	half := ctx.lineWidth / 2
+	var quads []subpath
+	for _, sp := range paths {
+		for _, run := range ctx.dashSubpath(sp) {
+			quads = append(quads, ctx.strokeRun(run, half)...)
+		}
+	}
+	ctx.rasterizeFill(quads, style)
+}
+
+// strokeRun expands one dash run's flattened polyline into segment
+// quads, a join shape at each interior vertex (or, for a closed run, at
+// its start/end vertex too), and end caps if the run is open.
+func (ctx *Context) strokeRun(run subpath, half float64) []subpath {
+	pts := run.points
+	if len(pts) == 0 {
+		return nil
+	}
+	if run.closed && len(pts) > 1 {
+		pts = append(append([]r2.Point{}, pts...), pts[0])
+	}
+
+	var quads []subpath
+	for i := 0; i+1 < len(pts); i++ {
+		quads = append(quads, subpath{points: segmentQuad(pts[i], pts[i+1], half), closed: true})
+	}
+	for i := 1; i+1 < len(pts); i++ {
+		quads = append(quads, ctx.joinQuads(pts[i-1], pts[i], pts[i+1], half)...)
+	}
+	if len(pts) < 2 {
+		return quads
+	}
+	if run.closed && len(pts) > 2 {
+		quads = append(quads, ctx.joinQuads(pts[len(pts)-2], pts[0], pts[1], half)...)
+	} else if !run.closed {
+		quads = append(quads, ctx.capQuad(pts[0], pts[1], half)...)
+		quads = append(quads, ctx.capQuad(pts[len(pts)-1], pts[len(pts)-2], half)...)
+	}
+	return quads
+}
+
+// joinQuads returns the extra geometry needed to fill the gap a join
+// between the incoming segment prev->v and the outgoing segment v->next
+// leaves on the outside of the turn, per LineJoin. A "round" join fills
+// the gap with a disc at v (a good approximation of the true circular
+// arc at the stroke widths this renderer is used at); "bevel" connects
+// the two segments' outer corners directly; "miter" (the default)
+// extends both segments' outer edges to their intersection, falling back
+// to a bevel past MiterLimit, matching canvas.
+func (ctx *Context) joinQuads(prev, v, next r2.Point, half float64) []subpath {
+	d0, d1 := v.Sub(prev), next.Sub(v)
+	len0, len1 := d0.Length(), d1.Length()
+	if len0 < 1e-9 || len1 < 1e-9 {
+		return nil
+	}
+	u0, u1 := d0.Divs(len0), d1.Divs(len1)
+	turn := u0.Cross(u1)
+	if math.Abs(turn) < 1e-9 {
+		return nil // Straight or reversed: the segment quads already meet flush.
+	}
+	side := 1.0
+	if turn > 0 {
+		side = -1
+	}
+	perp0 := r2.Vec{X: -u0.Y, Y: u0.X}.Muls(half * side)
+	perp1 := r2.Vec{X: -u1.Y, Y: u1.X}.Muls(half * side)
+	outerA, outerB := movePoint(v, perp0), movePoint(v, perp1)
+
+	switch ctx.lineJoin {
+	case "round":
+		return []subpath{{points: flattenArc(v.X, v.Y, half, 0, 2*math.Pi, false), closed: true}}
+	case "bevel":
+		return []subpath{{points: []r2.Point{v, outerA, outerB}, closed: true}}
+	default: // "miter"
+		miter, ok := lineIntersection(outerA, movePoint(outerA, u0), outerB, movePoint(outerB, u1))
+		if !ok || miter.Sub(v).Length()/half > ctx.miterLimit {
+			return []subpath{{points: []r2.Point{v, outerA, outerB}, closed: true}}
+		}
+		return []subpath{{points: []r2.Point{v, outerA, miter, outerB}, closed: true}}
+	}
+}
+
+// lineIntersection returns the point where the infinite lines through
+// a1/a2 and through b1/b2 cross, and false if they're parallel.
+func lineIntersection(a1, a2, b1, b2 r2.Point) (r2.Point, bool) {
+	d1, d2 := a2.Sub(a1), b2.Sub(b1)
+	denom := d1.Cross(d2)
+	if math.Abs(denom) < 1e-9 {
+		return r2.Point{}, false
+	}
+	t := b1.Sub(a1).Cross(d2) / denom
+	return movePoint(a1, d1.Muls(t)), true
+}
+
+// capQuad returns the geometry needed to cap an open run's endpoint end,
+// whose neighboring point on the run is neighbor, per LineCap: "butt"
+// needs nothing (the segment quad already ends flush at end), "round"
+// adds a disc, and "square" extends the segment quad half a line width
+// past end.
+func (ctx *Context) capQuad(end, neighbor r2.Point, half float64) []subpath {
+	switch ctx.lineCap {
+	case "round":
+		return []subpath{{points: flattenArc(end.X, end.Y, half, 0, 2*math.Pi, false), closed: true}}
+	case "square":
+		dir := end.Sub(neighbor)
+		length := dir.Length()
+		if length < 1e-9 {
+			return nil
+		}
+		far := movePoint(end, dir.Divs(length).Muls(half))
+		return []subpath{{points: segmentQuad(end, far, half), closed: true}}
+	default: // "butt"
+		return nil
+	}
+}
+
+// dashSubpath splits sp's flattened polyline into its "on" dash runs per
+// ctx.lineDash/ctx.lineDashOffset, walking each segment and emitting an
+// open subpath every time the pattern re-enters an "on" run. An empty
+// lineDash (canvas's default) returns sp unchanged.
+func (ctx *Context) dashSubpath(sp subpath) []subpath {
+	if len(ctx.lineDash) == 0 {
+		return []subpath{sp}
+	}
+	pattern := ctx.lineDash
+	if len(pattern)%2 != 0 {
+		pattern = append(append([]float64{}, pattern...), pattern...) // Odd-length patterns repeat once, per canvas.
+	}
+	total := 0.0
+	for _, d := range pattern {
+		total += d
+	}
+	if total <= 0 {
+		return []subpath{sp}
+	}
+
+	pts := sp.points
+	if sp.closed && len(pts) > 1 {
+		pts = append(append([]r2.Point{}, pts...), pts[0])
+	}
+	if len(pts) == 0 {
+		return nil
+	}
+
+	offset := math.Mod(ctx.lineDashOffset, total)
+	if offset < 0 {
+		offset += total
+	}
+	patternIndex := 0
+	for offset >= pattern[patternIndex] {
+		offset -= pattern[patternIndex]
+		patternIndex = (patternIndex + 1) % len(pattern)
+	}
+	on := patternIndex%2 == 0
+	remaining := pattern[patternIndex] - offset
+
+	var runs []subpath
+	var current []r2.Point
+	if on {
+		current = []r2.Point{pts[0]}
+	}
+	for i := 0; i+1 < len(pts); i++ {
+		a, b := pts[i], pts[i+1]
+		segLen := b.Sub(a).Length()
+		traveled := 0.0
+		for traveled < segLen {
+			step := math.Min(remaining, segLen-traveled)
+			traveled += step
+			remaining -= step
+			p := a
+			if segLen > 1e-9 {
+				p = a.Lerp(b, traveled/segLen)
+			}
+			if on {
+				current = append(current, p)
+			}
+			if remaining <= 1e-9 {
+				if on && len(current) > 1 {
+					runs = append(runs, subpath{points: current})
+				}
+				patternIndex = (patternIndex + 1) % len(pattern)
+				remaining = pattern[patternIndex]
+				on = !on
+				current = nil
+				if on {
+					current = []r2.Point{p}
+				}
+			}
+		}
+	}
+	if on && len(current) > 1 {
+		runs = append(runs, subpath{points: current})
+	}
+	return runs
+}
+
+func segmentQuad(a, b r2.Point, half float64) []r2.Point {
+	dir := b.Sub(a)
+	length := dir.Length()
+	if length < 1e-9 {
+		return []r2.Point{a, a, a, a}
+	}
+	perp := r2.Vec{X: -dir.Y / length * half, Y: dir.X / length * half}
+	return []r2.Point{
+		{X: a.X - perp.X, Y: a.Y - perp.Y},
+		{X: b.X - perp.X, Y: b.Y - perp.Y},
+		{X: b.X + perp.X, Y: b.Y + perp.Y},
+		{X: a.X + perp.X, Y: a.Y + perp.Y},
+	}
+}
+
+// rasterizeFill scan-converts paths (nonzero winding rule across all of
+// them combined, as canvas does for a single Fill call) into img, sampling
+// aaSamples sub-scanlines per pixel row for vertical anti-aliasing and
+// exact fractional coverage at each span's horizontal edges.
+func (ctx *Context) rasterizeFill(paths []subpath, style color.NRGBA) {
+	ctx.scanConvert(paths, func(x, y int, coverage float64) {
+		blendPixel(ctx.img, x, y, style, coverage)
+	})
+}
+
+func (ctx *Context) rasterizeClear(paths []subpath) {
+	ctx.scanConvert(paths, func(x, y int, coverage float64) {
+		clearPixel(ctx.img, x, y, coverage)
+	})
+}
+
+// aaSamples is the number of vertical sub-scanlines sampled per pixel row.
+const aaSamples = 4
+
+type pixelOp func(x, y int, coverage float64)
+
+// scanConvert rasterizes paths with the nonzero winding rule, calling op
+// once per (x, y) pixel touched with its fractional coverage in [0, 1].
+// It is a draw2d-style scanline rasterizer: for each of aaSamples
+// sub-scanlines per row it finds every edge crossing, sorts them by x,
+// walks them accumulating a winding count, and adds fractional coverage
+// for the "inside" spans between crossings -- fractional at both ends so
+// a span boundary that falls mid-pixel still anti-aliases horizontally.
+func (ctx *Context) scanConvert(paths []subpath, op pixelOp) {
+	bounds := ctx.img.Bounds()
+	minX, minY, maxX, maxY := bounds.Max.X, bounds.Max.Y, bounds.Min.X, bounds.Min.Y
+	var edges []edge
+	for _, sp := range paths {
+		if len(sp.points) < 2 {
+			continue
+		}
+		for i := 0; i+1 < len(sp.points); i++ {
+			addEdge(&edges, sp.points[i], sp.points[i+1])
+			extendBounds(&minX, &minY, &maxX, &maxY, sp.points[i])
+		}
+		extendBounds(&minX, &minY, &maxX, &maxY, sp.points[len(sp.points)-1])
+		addEdge(&edges, sp.points[len(sp.points)-1], sp.points[0])
+	}
+	if len(edges) == 0 {
+		return
+	}
+	minX, minY = clampInt(minX, bounds.Min.X), clampInt(minY, bounds.Min.Y)
+	maxX, maxY = clampIntMax(maxX, bounds.Max.X), clampIntMax(maxY, bounds.Max.Y)
+	if minX >= maxX || minY >= maxY {
+		return
+	}
+
+	width := maxX - minX
+	coverage := make([]float64, width)
+	type crossing struct {
+		x    float64
+		wind int
+	}
+	for y := minY; y < maxY; y++ {
+		for i := range coverage {
+			coverage[i] = 0
+		}
+		for s := 0; s < aaSamples; s++ {
+			sy := float64(y) + (float64(s)+0.5)/aaSamples
+			var crossings []crossing
+			for _, e := range edges {
+				y0, y1 := e.y0, e.y1
+				if y0 == y1 {
+					continue
+				}
+				wind := 1
+				if y0 > y1 {
+					y0, y1 = y1, y0
+					wind = -1
+				}
+				if sy < y0 || sy >= y1 {
+					continue
+				}
+				t := (sy - e.y0) / (e.y1 - e.y0)
+				x := e.x0 + t*(e.x1-e.x0)
+				crossings = append(crossings, crossing{x: x, wind: wind})
+			}
+			sort.Slice(crossings, func(i, j int) bool { return crossings[i].x < crossings[j].x })
+			winding := 0
+			spanStartX := 0.0
+			for _, c := range crossings {
+				wasInside := winding != 0
+				winding += c.wind
+				isInside := winding != 0
+				switch {
+				case !wasInside && isInside:
+					spanStartX = c.x
+				case wasInside && !isInside:
+					addSpanCoverage(coverage, minX, spanStartX, c.x, 1.0/aaSamples)
+				}
+			}
+		}
+		for i, c := range coverage {
+			if c <= 0 {
+				continue
+			}
+			op(minX+i, y, math.Min(c, 1))
+		}
+	}
+}
+
+// addSpanCoverage adds weight to coverage (indexed relative to originX) for
+// every pixel the horizontal span [x0, x1) touches, giving a pixel that's
+// only partially inside the span its fractional share.
+func addSpanCoverage(coverage []float64, originX int, x0, x1, weight float64) {
+	if x1 <= x0 {
+		return
+	}
+	loPix := int(math.Floor(x0))
+	hiPix := int(math.Floor(x1))
+	for px := loPix; px <= hiPix; px++ {
+		i := px - originX
+		if i < 0 || i >= len(coverage) {
+			continue
+		}
+		left := math.Max(float64(px), x0)
+		right := math.Min(float64(px+1), x1)
+		if right <= left {
+			continue
+		}
+		coverage[i] += (right - left) * weight
+	}
+}
+
+// edge is one directed segment of a flattened path, in device coordinates.
+type edge struct{ x0, y0, x1, y1 float64 }
+
+// addEdge appends the device-space edge from a to b, skipping degenerate
+// (zero-length) edges that would otherwise contribute a spurious crossing.
+func addEdge(edges *[]edge, a, b r2.Point) {
+	if a.IsClose(b, 1e-12) {
+		return
+	}
+	*edges = append(*edges, edge{a.X, a.Y, b.X, b.Y})
+}
+
+func extendBounds(minX, minY, maxX, maxY *int, p r2.Point) {
+	if x := int(math.Floor(p.X)); x < *minX {
+		*minX = x
+	}
+	if x := int(math.Ceil(p.X)) + 1; x > *maxX {
+		*maxX = x
+	}
+	if y := int(math.Floor(p.Y)); y < *minY {
+		*minY = y
+	}
+	if y := int(math.Ceil(p.Y)) + 1; y > *maxY {
+		*maxY = y
+	}
+}
+
+func clampInt(v, lo int) int {
+	if v < lo {
+		return lo
+	}
+	return v
+}
+
+func clampIntMax(v, hi int) int {
+	if v > hi {
+		return hi
+	}
+	return v
+}
+
+// blendPixel composites style over img's existing pixel at (x, y) with
+// unpremultiply converts c (whose RGBA method returns alpha-premultiplied
+// components, per the color.Color contract) to straight color.NRGBA, so
+// DrawImage can hand a source pixel to blendPixel the same way FillStyle's
+// parsed color is.
+func unpremultiply(c color.Color) color.NRGBA {
+	r, g, b, a := c.RGBA()
+	if a == 0 {
+		return color.NRGBA{}
+	}
+	return color.NRGBA{
+		R: uint8(r * 0xff / a),
+		G: uint8(g * 0xff / a),
+		B: uint8(b * 0xff / a),
+		A: uint8(a >> 8),
+	}
+}
+
+// source-over alpha blending, scaled by coverage (canvas's anti-aliased
+// fill/stroke edge) and style's own alpha.
+func blendPixel(img *image.RGBA, x, y int, style color.NRGBA, coverage float64) {
+	if !(image.Point{X: x, Y: y}.In(img.Bounds())) {
+		return
+	}
+	srcA := float64(style.A) / 255 * coverage
+	if srcA <= 0 {
+		return
+	}
+	dst := img.RGBAAt(x, y)
+	out := color.RGBA{
+		R: blendChannel(dst.R, style.R, srcA),
+		G: blendChannel(dst.G, style.G, srcA),
+		B: blendChannel(dst.B, style.B, srcA),
+		A: blendChannel(dst.A, 255, srcA),
+	}
+	img.SetRGBA(x, y, out)
+}
+
+func blendChannel(dst, src uint8, srcA float64) uint8 {
+	return uint8(clamp(float64(dst)*(1-srcA)+float64(src)*srcA, 0, 255))
+}
+
+// clearPixel resets img's pixel at (x, y) toward fully transparent by
+// coverage, the same premultiplied-alpha scaling image.RGBA already uses.
+func clearPixel(img *image.RGBA, x, y int, coverage float64) {
+	if !(image.Point{X: x, Y: y}.In(img.Bounds())) {
+		return
+	}
+	dst := img.RGBAAt(x, y)
+	scale := 1 - math.Min(coverage, 1)
+	img.SetRGBA(x, y, color.RGBA{
+		R: uint8(float64(dst.R) * scale),
+		G: uint8(float64(dst.G) * scale),
+		B: uint8(float64(dst.B) * scale),
+		A: uint8(float64(dst.A) * scale),
+	})
+}
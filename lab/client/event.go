@@ -46,6 +46,10 @@
 //
 //	A GL window publishes Hovered while the cursor is inside the window.
 //	Handlers may use it for reactive visuals or joystick-like control.
+//	Clicked, Scrolled, Keyed, and Sized report the rest of a window's input:
+//	button presses, wheel motion, keyboard activity, and size changes,
+//	letting a sequencer drive from keyboard shortcuts or drags without a
+//	driver inventing its own side channel for them.
 //
 // Sequencing:
 //
@@ -63,6 +67,8 @@ import (
 	"time"
 
 	"github.com/scottlawsonbc/slam/code/photon/camera"
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/lab/event/key"
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/lab/event/mouse"
 )
 
 /*
@@ -128,6 +134,15 @@ const (
 	// hardware drivers. Consumers can treat Hovered as a low-latency, local
 	// input stream to drive reactive visualizations or joystick-like controls.
 	Hovered EventType = "Hovered"
+
+	// Clicked reports a mouse button press or release over a GL window.
+	Clicked EventType = "Clicked"
+	// Scrolled reports a mouse wheel motion over a GL window.
+	Scrolled EventType = "Scrolled"
+	// Keyed reports a physical keyboard key press or release.
+	Keyed EventType = "Keyed"
+	// Sized reports a GL window's drawable size changing.
+	Sized EventType = "Sized"
 )
 
 // Event carries a single event on the bus.
@@ -179,7 +194,11 @@ type Data struct {
 	Grabbed   *DataGrabbed
 	Sequenced *DataSequenced
 
-	Hovered *DataHovered
+	Hovered  *DataHovered
+	Clicked  *DataClicked
+	Scrolled *DataScrolled
+	Keyed    *DataKeyed
+	Sized    *DataSized
 }
 
 // String reports a compact textual form for logs.
@@ -207,6 +226,14 @@ func (d Data) String() string {
 		return fmt.Sprintf("Data{Sequenced=%s}", *d.Sequenced)
 	case d.Hovered != nil:
 		return fmt.Sprintf("Data{Hovered=%s}", *d.Hovered)
+	case d.Clicked != nil:
+		return fmt.Sprintf("Data{Clicked=%s}", *d.Clicked)
+	case d.Scrolled != nil:
+		return fmt.Sprintf("Data{Scrolled=%s}", *d.Scrolled)
+	case d.Keyed != nil:
+		return fmt.Sprintf("Data{Keyed=%s}", *d.Keyed)
+	case d.Sized != nil:
+		return fmt.Sprintf("Data{Sized=%s}", *d.Sized)
 	default:
 		return "Data{}"
 	}
@@ -423,6 +450,101 @@ func (d DataHovered) String() string {
 	return fmt.Sprintf("DataHovered{Drv=%s, X=%.2f, Y=%.2f, W=%d, H=%d}", d.Drv, d.X, d.Y, d.W, d.H)
 }
 
+// DataClicked describes a Clicked event: a mouse button press or release
+// over a GL window. Coordinates follow DataHovered's convention (window-
+// local pixels, origin top-left).
+type DataClicked struct {
+	// Drv names the logical owner of the window (typically a driver name).
+	Drv string
+	// Button is the mouse button that changed state.
+	Button mouse.Button
+	// Direction is key.DirPress or key.DirRelease; Clicked never uses
+	// key.DirNone, unlike Hovered's continuous motion stream.
+	Direction key.Direction
+	// Modifiers is the set of modifier keys held during the click.
+	Modifiers key.Modifiers
+	// X is the cursor X position in window pixels from the left edge.
+	X float64
+	// Y is the cursor Y position in window pixels from the top edge.
+	Y float64
+}
+
+// String reports a compact textual form for logs.
+func (d DataClicked) String() string {
+	return fmt.Sprintf("DataClicked{Drv=%s, Button=%d, Direction=%v, Modifiers=%v, X=%.2f, Y=%.2f}",
+		d.Drv, d.Button, d.Direction, d.Modifiers, d.X, d.Y)
+}
+
+// DataScrolled describes a Scrolled event: mouse wheel motion over a GL
+// window. DX and DY are the horizontal and vertical scroll amounts, in
+// whatever units the UI surface reports (commonly one 120th-of-a-notch or
+// a pixel delta, depending on platform); consumers that need a notion of
+// "one click" should normalize, not assume a fixed magnitude here.
+type DataScrolled struct {
+	// Drv names the logical owner of the window (typically a driver name).
+	Drv string
+	// DX is the horizontal scroll amount.
+	DX float64
+	// DY is the vertical scroll amount.
+	DY float64
+	// X is the cursor X position in window pixels from the left edge.
+	X float64
+	// Y is the cursor Y position in window pixels from the top edge.
+	Y float64
+}
+
+// String reports a compact textual form for logs.
+func (d DataScrolled) String() string {
+	return fmt.Sprintf("DataScrolled{Drv=%s, DX=%.2f, DY=%.2f, X=%.2f, Y=%.2f}", d.Drv, d.DX, d.DY, d.X, d.Y)
+}
+
+// DataKeyed describes a Keyed event: a physical keyboard key press or
+// release. Code and Rune follow lab/event/key.Event's convention: Code
+// identifies the physical key independent of layout, Rune is the
+// layout-resolved codepoint (-1 if the key does not generate one).
+type DataKeyed struct {
+	// Drv names the logical owner of the window (typically a driver name).
+	Drv string
+	// Code is the identity of the physical key, independent of layout.
+	Code string
+	// Rune is the layout-resolved Unicode codepoint, or -1 if none.
+	Rune rune
+	// Direction is key.DirPress, key.DirRelease, or key.DirNone (for
+	// key-repeat events).
+	Direction key.Direction
+	// Modifiers is the set of modifier keys held during the key event.
+	Modifiers key.Modifiers
+}
+
+// String reports a compact textual form for logs. Mirrors key.Event.String's
+// handling of the no-codepoint sentinel: Rune == -1 is omitted rather than
+// printed as the Unicode replacement character.
+func (d DataKeyed) String() string {
+	if d.Rune >= 0 {
+		return fmt.Sprintf("DataKeyed{Drv=%s, Code=%s, Rune=%q, Direction=%v, Modifiers=%v}",
+			d.Drv, d.Code, d.Rune, d.Direction, d.Modifiers)
+	}
+	return fmt.Sprintf("DataKeyed{Drv=%s, Code=%s, Direction=%v, Modifiers=%v}",
+		d.Drv, d.Code, d.Direction, d.Modifiers)
+}
+
+// DataSized describes a Sized event: a GL window's drawable size
+// changing. Drv identifies the window the same way DataHovered's W and H
+// describe its size at the time of a hover sample.
+type DataSized struct {
+	// Drv names the logical owner of the window (typically a driver name).
+	Drv string
+	// W is the window's new drawable width in pixels.
+	W int
+	// H is the window's new drawable height in pixels.
+	H int
+}
+
+// String reports a compact textual form for logs.
+func (d DataSized) String() string {
+	return fmt.Sprintf("DataSized{Drv=%s, W=%d, H=%d}", d.Drv, d.W, d.H)
+}
+
 // package main
 
 // import (
@@ -8,6 +8,7 @@ import (
 	"syscall/js"
 
 	"github.com/scottlawsonbc/slam/code/photon/raytrace/lab/client/canvas"
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/lab/event/gesture"
 	"github.com/scottlawsonbc/slam/code/photon/raytrace/obj"
 	"github.com/scottlawsonbc/slam/code/photon/raytrace/phys"
 )
@@ -55,9 +56,10 @@ func main() {
 	doc := js.Global().Get("document")
 
 	var labApp = &app{
-		eventOut:   make(chan any),
-		eventIn:    make(chan any),
-		glctx:      renderContext(),
+		eventOut: make(chan any),
+		eventIn:  make(chan any),
+		glctx:    renderContext(),
+		gestures: gesture.NewRecognizer(),
 	}
 	labApp.initWorker()
 
@@ -80,6 +82,13 @@ func main() {
 	defer handleWheelEvent.Release()
 	doc.Call("addEventListener", "wheel", handleWheelEvent)
 
+	handleTouchEvent := redirectTouchEvent(labApp.eventIn)
+	defer handleTouchEvent.Release()
+	doc.Call("addEventListener", "touchstart", handleTouchEvent)
+	doc.Call("addEventListener", "touchmove", handleTouchEvent)
+	doc.Call("addEventListener", "touchend", handleTouchEvent)
+	doc.Call("addEventListener", "touchcancel", handleTouchEvent)
+
 	handleContextMenu := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
 		args[0].Call("preventDefault")
 		return nil
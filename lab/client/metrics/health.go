@@ -0,0 +1,126 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	instrument "github.com/scottlawsonbc/slam/code/photon/raytrace/lab/client"
+)
+
+// healthProbeCount is how many canned round trips HealthCheck times per
+// driver before judging its p95 against deadline: enough samples for a
+// percentile to mean something without making startup noticeably slower.
+const healthProbeCount = 20
+
+// healthIndicator is the indicator name every driver is expected to
+// answer for a health probe; a driver with no "health" indicator fails
+// the probe with a clear timeout rather than silently being skipped.
+const healthIndicator = "health"
+
+// healthCheckFrom is the From a HealthCheck probe's Indicate is published
+// with, so a driver or logger can tell a startup probe apart from a real
+// sequencer's request.
+const healthCheckFrom = "instrument/metrics.HealthCheck"
+
+// HealthCheck issues healthProbeCount canned Indicate{Ind:"health"}
+// requests to each of drivers over bus, timing each round trip, and
+// returns an error naming every driver whose p95 exceeds deadline (or
+// that didn't answer at all). This is the instrument package doc's
+// "prevent the system from starting" promise made concrete: call this
+// once at startup and treat a non-nil error as "do not start."
+//
+// Drivers are checked concurrently, not one after another: an N-driver
+// system shouldn't pay N times healthProbeCount probes' worth of startup
+// delay just because the drivers happen to be unrelated to each other.
+func HealthCheck(ctx context.Context, bus instrument.Bus, drivers []string, deadline time.Duration) error {
+	results := make([][]string, len(drivers))
+	var wg sync.WaitGroup
+	wg.Add(len(drivers))
+	for i, drv := range drivers {
+		go func(i int, drv string) {
+			defer wg.Done()
+			results[i] = checkDriver(ctx, bus, drv, deadline)
+		}(i, drv)
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("instrument/metrics: HealthCheck: %w", err)
+	}
+
+	var failures []string
+	for _, fs := range results {
+		failures = append(failures, fs...)
+	}
+	if len(failures) > 0 {
+		return fmt.Errorf("instrument/metrics: HealthCheck: %d driver(s) failed:\n%s", len(failures), strings.Join(failures, "\n"))
+	}
+	return nil
+}
+
+// checkDriver runs healthProbeCount canned round trips against drv and
+// returns one failure message per probe that didn't answer in time (or
+// per exceeded p95), nil if drv is healthy.
+//
+// Each probe subscribes for the matching Indicated before publishing its
+// Indicate, so the response can never race ahead of the subscription
+// meant to catch it -- deliberately not built on BusWaitFor, whose
+// Subscribe-then-block shape leaves no place to run the Publish in
+// between.
+func checkDriver(ctx context.Context, bus instrument.Bus, drv string, deadline time.Duration) []string {
+	var failures []string
+	h := &Histogram{}
+	for i := 0; i < healthProbeCount; i++ {
+		select {
+		case <-ctx.Done():
+			return failures
+		default:
+		}
+
+		req := fmt.Sprintf("healthcheck-%s-%d", drv, i)
+		ch := make(chan instrument.Event, 1)
+		binding := bus.Subscribe(
+			instrument.Filter{Type: instrument.Indicated, Drv: drv, Ind: healthIndicator, Req: req},
+			func(e instrument.Event) {
+				select {
+				case ch <- e:
+				default:
+				}
+			},
+		)
+
+		start := time.Now()
+		bus.Publish(instrument.Event{
+			Time: start,
+			From: healthCheckFrom,
+			Type: instrument.Indicate,
+			Data: instrument.Data{Indicate: &instrument.DataIndicate{Req: req, Drv: drv, Ind: healthIndicator}},
+		})
+
+		select {
+		case e := <-ch:
+			binding.Unbind()
+			if e.Data.Indicated.Err != "" {
+				failures = append(failures, fmt.Sprintf("%s: probe %d: driver reported Err=%q", drv, i, e.Data.Indicated.Err))
+				return failures
+			}
+			h.Record(time.Since(start))
+		case <-time.After(10 * deadline):
+			binding.Unbind()
+			failures = append(failures, fmt.Sprintf("%s: probe %d: no Indicated within %v", drv, i, 10*deadline))
+			return failures
+		case <-ctx.Done():
+			binding.Unbind()
+			return failures
+		}
+	}
+
+	snap := h.Snapshot()
+	if snap.P95 > deadline {
+		failures = append(failures, fmt.Sprintf("%s: p95=%v exceeds deadline=%v over %d probes", drv, snap.P95, deadline, snap.Count))
+	}
+	return failures
+}
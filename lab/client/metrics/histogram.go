@@ -0,0 +1,181 @@
+// Package metrics measures the instrument protocol's own promise that
+// Controlled and Indicated responses arrive within 100 ms (see the
+// "instrument" package doc's Design rule 2 and Health checks section),
+// turning that promise into an observable, scrapeable, and
+// startup-enforceable number instead of an assumption.
+//
+// Registry subscribes to a Bus and records a per-(Drv, Ctl|Ind) Histogram
+// of request/response latency plus a per-Drv Meter of request rate.
+// HealthCheck probes a set of drivers at startup and fails loudly if any
+// of them can't meet a latency deadline, front-loading the failure the
+// instrument package doc describes rather than letting it surface mid-
+// experiment.
+package metrics
+
+import (
+	"math"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// numBuckets and bucketBase together size Histogram's geometric
+	// bucket table: bucketBase=1.1 gives about +/-5% relative error per
+	// bucket, and numBuckets=200 covers bucketMinNanos (1us) up to about
+	// 3 minutes (1us * 1.1^200), comfortably spanning the sub-100ms
+	// values this package cares about and the multi-second outliers
+	// worth noticing.
+	numBuckets = 200
+	bucketBase = 1.1
+
+	// numShards is how many separate atomic counters each bucket keeps.
+	// Go has no goroutine-local storage to hash a caller's identity
+	// against, so Record stripes across shards round-robin via an
+	// atomically incremented cursor rather than by affinity; this still
+	// spreads concurrent Record calls for the same bucket across
+	// separate cache lines; it just doesn't guarantee a given goroutine
+	// always lands on the same shard.
+	numShards = 8
+)
+
+// bucketMinNanos is the lower edge of bucket 0, in nanoseconds.
+const bucketMinNanos = float64(time.Microsecond)
+
+// Histogram is a log-linear, shard-striped latency histogram. Record is a
+// single atomic add with no lock and no allocation, so timing a response
+// can never itself eat into the 100 ms budget this package exists to
+// measure. Percentiles are reconstructed from the bucket counts only when
+// Snapshot is called, which is the only method that does any real work.
+//
+// The zero value is usable.
+type Histogram struct {
+	buckets     [numBuckets][numShards]uint64
+	count       uint64
+	sum         uint64 // nanoseconds, for the mean
+	shardCursor uint64 // per-Histogram, so striping doesn't serialize unrelated Histograms on one shared cache line
+}
+
+// bucketFor returns the bucket index for d, clamped to the table's range.
+func bucketFor(d time.Duration) int {
+	if d <= 0 {
+		return 0
+	}
+	idx := int(math.Log(float64(d)/bucketMinNanos) / math.Log(bucketBase))
+	if idx < 0 {
+		return 0
+	}
+	if idx >= numBuckets {
+		return numBuckets - 1
+	}
+	return idx
+}
+
+// bucketUpperBound returns the upper edge of bucket i.
+func bucketUpperBound(i int) time.Duration {
+	return time.Duration(bucketMinNanos * math.Pow(bucketBase, float64(i+1)))
+}
+
+// bucketLowerBound returns the lower edge of bucket i.
+func bucketLowerBound(i int) time.Duration {
+	if i <= 0 {
+		return 0
+	}
+	return bucketUpperBound(i - 1)
+}
+
+// Record adds one observation of d.
+func (h *Histogram) Record(d time.Duration) {
+	if d < 0 {
+		d = 0
+	}
+	shard := int(atomic.AddUint64(&h.shardCursor, 1) % numShards)
+	atomic.AddUint64(&h.buckets[bucketFor(d)][shard], 1)
+	atomic.AddUint64(&h.count, 1)
+	atomic.AddUint64(&h.sum, uint64(d))
+}
+
+// HistogramSnapshot is a point-in-time read of a Histogram. Percentiles
+// are the upper edge of whichever bucket the quantile falls in, so they
+// carry the same +/-5% (at bucketBase=1.1) relative error as the
+// histogram itself -- exact enough to judge "within 100ms", not exact
+// enough to reproduce a specific sample's latency.
+type HistogramSnapshot struct {
+	Count uint64
+	// Sum is the exact accumulated duration of every observation (not
+	// reconstructed from the bucket table), so a consumer computing a
+	// windowed mean from Sum/Count (as a Prometheus _sum/_count rate
+	// does) doesn't compound the buckets' own rounding on top of Mean's.
+	Sum  time.Duration
+	Min  time.Duration
+	Max  time.Duration
+	Mean time.Duration
+	P50  time.Duration
+	P95  time.Duration
+	P99  time.Duration
+}
+
+// Snapshot sums h's shards and reconstructs count/min/max/percentiles
+// from the resulting bucket totals.
+func (h *Histogram) Snapshot() HistogramSnapshot {
+	var totals [numBuckets]uint64
+	var total uint64
+	for i := 0; i < numBuckets; i++ {
+		for s := 0; s < numShards; s++ {
+			totals[i] += atomic.LoadUint64(&h.buckets[i][s])
+		}
+		total += totals[i]
+	}
+	count := atomic.LoadUint64(&h.count)
+	if count == 0 {
+		return HistogramSnapshot{}
+	}
+	sum := atomic.LoadUint64(&h.sum)
+	return HistogramSnapshot{
+		Count: count,
+		Sum:   time.Duration(sum),
+		Min:   bucketLowerBound(firstNonEmpty(totals[:])),
+		Max:   bucketUpperBound(lastNonEmpty(totals[:])),
+		Mean:  time.Duration(sum / count),
+		P50:   percentile(totals[:], total, 0.50),
+		P95:   percentile(totals[:], total, 0.95),
+		P99:   percentile(totals[:], total, 0.99),
+	}
+}
+
+func firstNonEmpty(totals []uint64) int {
+	for i, c := range totals {
+		if c > 0 {
+			return i
+		}
+	}
+	return 0
+}
+
+func lastNonEmpty(totals []uint64) int {
+	for i := len(totals) - 1; i >= 0; i-- {
+		if totals[i] > 0 {
+			return i
+		}
+	}
+	return 0
+}
+
+// percentile returns the upper bound of the bucket containing the p-th
+// quantile (0 < p <= 1) of total observations across totals.
+func percentile(totals []uint64, total uint64, p float64) time.Duration {
+	if total == 0 {
+		return 0
+	}
+	target := uint64(math.Ceil(p * float64(total)))
+	if target == 0 {
+		target = 1
+	}
+	var cum uint64
+	for i, c := range totals {
+		cum += c
+		if cum >= target {
+			return bucketUpperBound(i)
+		}
+	}
+	return bucketUpperBound(len(totals) - 1)
+}
@@ -0,0 +1,127 @@
+package metrics
+
+import (
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// meterTickInterval is how often a Meter's EWMAs advance, the same
+// 5-second cadence (and the same forward-decay math) as Dropwizard
+// Metrics' Meter, which this type is modeled on.
+const meterTickInterval = 5 * time.Second
+
+// ewma is one exponentially-weighted moving average over
+// meterTickInterval-second ticks, converging toward whatever window
+// (1, 5, or 15 minutes) it was constructed with.
+type ewma struct {
+	alpha       float64
+	rate        float64 // events per second
+	initialized bool
+}
+
+func newEWMA(window time.Duration) *ewma {
+	return &ewma{alpha: 1 - math.Exp(-meterTickInterval.Seconds()/window.Seconds())}
+}
+
+func (e *ewma) tick(countThisTick uint64) {
+	instantRate := float64(countThisTick) / meterTickInterval.Seconds()
+	if !e.initialized {
+		e.rate = instantRate
+		e.initialized = true
+		return
+	}
+	e.rate += e.alpha * (instantRate - e.rate)
+}
+
+// Meter tracks a moving count of events as 1/5/15-minute EWMA rates, the
+// same windows and cadence as Dropwizard Metrics' Meter. Mark is a single
+// atomic add; the EWMAs only advance when a Snapshot (or the Registry
+// that owns this Meter) reads them and enough wall-clock time has
+// elapsed, so there is no background goroutine to leak or shut down.
+//
+// Construct with NewMeter, not a bare struct literal.
+type Meter struct {
+	mu          sync.Mutex
+	m1, m5, m15 *ewma
+	uncounted   uint64 // atomic; events since the last tick
+	count       uint64 // atomic; total events ever marked
+	startTime   time.Time
+	lastTick    time.Time
+	now         func() time.Time
+}
+
+// NewMeter constructs a Meter starting its rate windows from now.
+func NewMeter() *Meter {
+	now := time.Now()
+	return &Meter{
+		m1:        newEWMA(time.Minute),
+		m5:        newEWMA(5 * time.Minute),
+		m15:       newEWMA(15 * time.Minute),
+		startTime: now,
+		lastTick:  now,
+		now:       time.Now,
+	}
+}
+
+// Mark records n events (typically 1, one per request observed).
+func (m *Meter) Mark(n uint64) {
+	atomic.AddUint64(&m.uncounted, n)
+	atomic.AddUint64(&m.count, n)
+}
+
+// tickIfNecessary advances the EWMAs by however many whole
+// meterTickInterval periods have elapsed since the last tick, crediting
+// the events marked since then to the first such period and zero to the
+// rest -- the same lazy-tick approach as Dropwizard Metrics, so a Meter
+// read after a long idle gap decays its rate toward zero instead of
+// reporting one artificially high instantaneous burst.
+func (m *Meter) tickIfNecessary() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	elapsed := m.now().Sub(m.lastTick)
+	ticks := int64(elapsed / meterTickInterval)
+	if ticks <= 0 {
+		return
+	}
+	uncounted := atomic.SwapUint64(&m.uncounted, 0)
+	for i := int64(0); i < ticks; i++ {
+		var c uint64
+		if i == 0 {
+			c = uncounted
+		}
+		m.m1.tick(c)
+		m.m5.tick(c)
+		m.m15.tick(c)
+	}
+	m.lastTick = m.lastTick.Add(time.Duration(ticks) * meterTickInterval)
+}
+
+// MeterSnapshot is a point-in-time read of a Meter.
+type MeterSnapshot struct {
+	Count    uint64
+	Rate1    float64 // events/sec, 1-minute EWMA
+	Rate5    float64 // events/sec, 5-minute EWMA
+	Rate15   float64 // events/sec, 15-minute EWMA
+	MeanRate float64 // events/sec, since the Meter was constructed
+}
+
+// Snapshot ticks m's EWMAs up to date, then reads them.
+func (m *Meter) Snapshot() MeterSnapshot {
+	m.tickIfNecessary()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	count := atomic.LoadUint64(&m.count)
+	var mean float64
+	if elapsed := m.now().Sub(m.startTime).Seconds(); elapsed > 0 {
+		mean = float64(count) / elapsed
+	}
+	return MeterSnapshot{
+		Count:    count,
+		Rate1:    m.m1.rate,
+		Rate5:    m.m5.rate,
+		Rate15:   m.m15.rate,
+		MeanRate: mean,
+	}
+}
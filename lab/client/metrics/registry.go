@@ -0,0 +1,225 @@
+package metrics
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	instrument "github.com/scottlawsonbc/slam/code/photon/raytrace/lab/client"
+)
+
+// statKey identifies one (driver, control-or-indicator) pair's
+// Histogram, matching the package doc's "per-(Drv, Ctl|Ind)" granularity.
+// Kind keeps a Control and an Indicator that happen to share a Name (a
+// common pairing: a "power" control and a "power" indicator on the same
+// driver) from colliding into one Histogram.
+type statKey struct {
+	Drv  string
+	Kind string // "Ctl" or "Ind"
+	Name string // the Ctl of a Control/Controlled, or the Ind of an Indicate/Indicated
+}
+
+// pending tracks one in-flight request, keyed by its Req (per Filter.Req,
+// a Req is expected to be unique across the bus), so the matching
+// response can be timed and attributed back to its Drv/Name.
+type pending struct {
+	key   statKey
+	start time.Time
+}
+
+// Registry subscribes to a Bus and measures every Control/Indicate round
+// trip: a Histogram of response latency per statKey, and a Meter of
+// request rate per driver. This is what turns the instrument package
+// doc's "should arrive within 100 ms" into an observable number instead
+// of an assumption.
+//
+// Construct with NewRegistry, not a bare struct literal: it needs to
+// subscribe to a Bus before it can record anything.
+type Registry struct {
+	// mu guards the lookup maps below, not the Histograms/Meters they
+	// point at: Histogram.Record and Meter.Mark are already safe for
+	// concurrent use without this lock, so mu is only held for the brief
+	// get-or-create map access, never across a Record/Mark call.
+	mu         sync.Mutex
+	histograms map[statKey]*Histogram
+	meters     map[string]*Meter
+	pending    map[string]pending // keyed by Req
+	now        func() time.Time
+}
+
+// NewRegistry constructs a Registry and subscribes it to every Control,
+// Controlled, Indicate, and Indicated event on bus. The returned Bindings
+// let a caller stop observing early; most callers keep a Registry (and
+// its Bindings) for the process lifetime.
+func NewRegistry(bus instrument.Bus) (*Registry, []instrument.Binding) {
+	r := &Registry{
+		histograms: make(map[statKey]*Histogram),
+		meters:     make(map[string]*Meter),
+		pending:    make(map[string]pending),
+		now:        time.Now,
+	}
+	bindings := []instrument.Binding{
+		bus.Subscribe(instrument.Filter{Type: instrument.Control}, r.observeRequest),
+		bus.Subscribe(instrument.Filter{Type: instrument.Indicate}, r.observeRequest),
+		bus.Subscribe(instrument.Filter{Type: instrument.Controlled}, r.observeResponse),
+		bus.Subscribe(instrument.Filter{Type: instrument.Indicated}, r.observeResponse),
+	}
+	return r, bindings
+}
+
+// observeRequest starts a timer for a Control or Indicate's Req, and
+// marks one event on its driver's Meter.
+func (r *Registry) observeRequest(e instrument.Event) {
+	var drv, kind, name, req string
+	switch {
+	case e.Data.Control != nil:
+		drv, kind, name, req = e.Data.Control.Drv, "Ctl", e.Data.Control.Ctl, e.Data.Control.Req
+	case e.Data.Indicate != nil:
+		drv, kind, name, req = e.Data.Indicate.Drv, "Ind", e.Data.Indicate.Ind, e.Data.Indicate.Req
+	default:
+		return
+	}
+	if req == "" {
+		return // Nothing to correlate a response against; don't start a timer we can never stop.
+	}
+	r.mu.Lock()
+	r.pending[req] = pending{key: statKey{Drv: drv, Kind: kind, Name: name}, start: r.now()}
+	r.mu.Unlock()
+
+	r.meterFor(drv).Mark(1)
+}
+
+// observeResponse stops the timer started by observeRequest for a
+// Controlled or Indicated's Req and records the elapsed latency.
+func (r *Registry) observeResponse(e instrument.Event) {
+	var req string
+	switch {
+	case e.Data.Controlled != nil:
+		req = e.Data.Controlled.Req
+	case e.Data.Indicated != nil:
+		req = e.Data.Indicated.Req
+	default:
+		return
+	}
+	if req == "" {
+		return
+	}
+	r.mu.Lock()
+	p, ok := r.pending[req]
+	if ok {
+		delete(r.pending, req)
+	}
+	r.mu.Unlock()
+	if !ok {
+		return // A response to a request this Registry never saw (e.g. it subscribed after the request was published).
+	}
+	r.histogramFor(p.key).Record(r.now().Sub(p.start))
+}
+
+func (r *Registry) histogramFor(key statKey) *Histogram {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	h, ok := r.histograms[key]
+	if !ok {
+		h = &Histogram{}
+		r.histograms[key] = h
+	}
+	return h
+}
+
+func (r *Registry) meterFor(drv string) *Meter {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	m, ok := r.meters[drv]
+	if !ok {
+		m = NewMeter()
+		r.meters[drv] = m
+	}
+	return m
+}
+
+// RegistrySnapshot is a point-in-time read of every Histogram and Meter a
+// Registry has accumulated, suitable for encoding (JSON, the Prometheus
+// text format ServeHTTP writes, or a UI table).
+type RegistrySnapshot struct {
+	// Histograms is keyed by "Drv/Kind/Name" (Kind is "Ctl" or "Ind",
+	// Name is the Ctl or Ind it names) -- Kind keeps a same-named Control
+	// and Indicator on one driver from colliding into a single entry.
+	Histograms map[string]HistogramSnapshot
+	// Meters is keyed by Drv.
+	Meters map[string]MeterSnapshot
+}
+
+// Snapshot reads every Histogram and Meter this Registry has recorded.
+func (r *Registry) Snapshot() RegistrySnapshot {
+	r.mu.Lock()
+	keys := make([]statKey, 0, len(r.histograms))
+	hists := make([]*Histogram, 0, len(r.histograms))
+	for k, h := range r.histograms {
+		keys = append(keys, k)
+		hists = append(hists, h)
+	}
+	drvs := make([]string, 0, len(r.meters))
+	meters := make([]*Meter, 0, len(r.meters))
+	for drv, m := range r.meters {
+		drvs = append(drvs, drv)
+		meters = append(meters, m)
+	}
+	r.mu.Unlock()
+
+	snap := RegistrySnapshot{
+		Histograms: make(map[string]HistogramSnapshot, len(hists)),
+		Meters:     make(map[string]MeterSnapshot, len(meters)),
+	}
+	for i, k := range keys {
+		snap.Histograms[k.Drv+"/"+k.Kind+"/"+k.Name] = hists[i].Snapshot()
+	}
+	for i, drv := range drvs {
+		snap.Meters[drv] = meters[i].Snapshot()
+	}
+	return snap
+}
+
+// ServeHTTP writes a Prometheus text-exposition-format snapshot, so a
+// Registry can be mounted directly as an http.Handler for scraping.
+func (r *Registry) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	snap := r.Snapshot()
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+
+	fmt.Fprintln(w, "# HELP instrument_request_duration_seconds Control/Indicate round-trip latency by driver and name.")
+	fmt.Fprintln(w, "# TYPE instrument_request_duration_seconds summary")
+	for key, h := range snap.Histograms {
+		drv, kind, name := splitStatKey(key)
+		labels := fmt.Sprintf("drv=%q,kind=%q,name=%q", drv, kind, name)
+		fmt.Fprintf(w, "instrument_request_duration_seconds{%s,quantile=\"0.5\"} %f\n", labels, h.P50.Seconds())
+		fmt.Fprintf(w, "instrument_request_duration_seconds{%s,quantile=\"0.95\"} %f\n", labels, h.P95.Seconds())
+		fmt.Fprintf(w, "instrument_request_duration_seconds{%s,quantile=\"0.99\"} %f\n", labels, h.P99.Seconds())
+		fmt.Fprintf(w, "instrument_request_duration_seconds_sum{%s} %f\n", labels, h.Sum.Seconds())
+		fmt.Fprintf(w, "instrument_request_duration_seconds_count{%s} %d\n", labels, h.Count)
+	}
+
+	fmt.Fprintln(w, "# HELP instrument_requests_per_second Request rate by driver, as 1/5/15-minute EWMAs.")
+	fmt.Fprintln(w, "# TYPE instrument_requests_per_second gauge")
+	for drv, m := range snap.Meters {
+		labels := fmt.Sprintf("drv=%q", drv)
+		fmt.Fprintf(w, "instrument_requests_per_second{%s,window=\"1m\"} %f\n", labels, m.Rate1)
+		fmt.Fprintf(w, "instrument_requests_per_second{%s,window=\"5m\"} %f\n", labels, m.Rate5)
+		fmt.Fprintf(w, "instrument_requests_per_second{%s,window=\"15m\"} %f\n", labels, m.Rate15)
+	}
+}
+
+// splitStatKey reverses the "Drv/Kind/Name" format Snapshot builds its
+// Histograms map keys with.
+func splitStatKey(key string) (drv, kind, name string) {
+	parts := strings.SplitN(key, "/", 3)
+	switch len(parts) {
+	case 3:
+		return parts[0], parts[1], parts[2]
+	case 2:
+		return parts[0], parts[1], ""
+	default:
+		return key, "", ""
+	}
+}
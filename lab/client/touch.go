@@ -0,0 +1,55 @@
+//go:build js && wasm
+
+package main
+
+import (
+	"syscall/js"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/lab/event/key"
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/lab/event/touch"
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r2"
+)
+
+// newTouchEvents converts one DOM TouchEvent into one touch.Event per
+// changedTouches entry: touchstart/touchmove/touchend/touchcancel each
+// report every finger whose state changed in that event, not just one.
+func newTouchEvents(e js.Value) []touch.Event {
+	var direction key.Direction
+	switch e.Get("type").String() {
+	case "touchstart":
+		direction = key.DirPress
+	case "touchmove":
+		direction = key.DirNone
+	case "touchend", "touchcancel":
+		direction = key.DirRelease
+	default:
+		return nil
+	}
+	changed := e.Get("changedTouches")
+	n := changed.Get("length").Int()
+	events := make([]touch.Event, n)
+	for i := 0; i < n; i++ {
+		t := changed.Call("item", i)
+		events[i] = touch.Event{
+			Identifier: t.Get("identifier").Int(),
+			Point:      r2.Point{X: t.Get("clientX").Float(), Y: t.Get("clientY").Float()},
+			Direction:  direction,
+			Pressure:   t.Get("force").Float(),
+		}
+	}
+	return events
+}
+
+// redirectTouchEvent returns a js.Func suitable for addEventListener on
+// touchstart/touchmove/touchend/touchcancel: it prevents the browser's
+// default touch handling (scrolling, pinch-zoom) from fighting with the
+// camera gestures below, then forwards each resulting touch.Event to to.
+func redirectTouchEvent(to chan any) js.Func {
+	return js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		args[0].Call("preventDefault")
+		for _, e := range newTouchEvents(args[0]) {
+			to <- e
+		}
+		return nil
+	})
+}
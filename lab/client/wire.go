@@ -0,0 +1,138 @@
+package instrument
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/draw"
+
+	"github.com/scottlawsonbc/slam/code/photon/camera"
+)
+
+// Marshal encodes e as a length-prefixed JSON envelope: a 4-byte
+// big-endian length, that many bytes of JSON, and -- only for a Paint or
+// Grabbed event carrying a non-nil Img -- a width/height header followed
+// by raw RGBA pixel bytes out-of-band. Without that fast path, Img's
+// image.Image would marshal as JSON the same way any other field does,
+// which for a pixel buffer means inflating every byte through base64 and
+// a generic interface encoding; Marshal instead strips Img out of the
+// JSON envelope and appends it as a flat binary blob.
+//
+// This is the wire format the package doc's "Names and wire formats are
+// stable" promise refers to: Unmarshal(Marshal(e)) always reconstructs an
+// equal Event (modulo Img's concrete image type, which Marshal always
+// normalizes to *image.RGBA -- see encodeFrame), and the format does not
+// change across releases of this package.
+func Marshal(e Event) ([]byte, error) {
+	env := e
+	var frame []byte
+	switch {
+	case e.Data.Paint != nil && e.Data.Paint.Img.Image != nil:
+		paint := *e.Data.Paint
+		var err error
+		if frame, err = encodeFrame(paint.Img.Image); err != nil {
+			return nil, fmt.Errorf("instrument.Marshal: %w", err)
+		}
+		paint.Img = camera.Frame{}
+		env.Data.Paint = &paint
+	case e.Data.Grabbed != nil && e.Data.Grabbed.Img.Image != nil:
+		grabbed := *e.Data.Grabbed
+		var err error
+		if frame, err = encodeFrame(grabbed.Img.Image); err != nil {
+			return nil, fmt.Errorf("instrument.Marshal: %w", err)
+		}
+		grabbed.Img = camera.Frame{}
+		env.Data.Grabbed = &grabbed
+	}
+
+	js, err := json.Marshal(env)
+	if err != nil {
+		return nil, fmt.Errorf("instrument.Marshal: %w", err)
+	}
+
+	buf := make([]byte, 0, 4+len(js)+len(frame))
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(js)))
+	buf = append(buf, js...)
+	buf = append(buf, frame...)
+	return buf, nil
+}
+
+// Unmarshal decodes an Event previously produced by Marshal.
+func Unmarshal(b []byte) (Event, error) {
+	if len(b) < 4 {
+		return Event{}, fmt.Errorf("instrument.Unmarshal: %d bytes is too short for a length prefix", len(b))
+	}
+	jsonLen := binary.BigEndian.Uint32(b)
+	b = b[4:]
+	if uint64(len(b)) < uint64(jsonLen) {
+		return Event{}, fmt.Errorf("instrument.Unmarshal: length prefix claims %d bytes of JSON but only %d remain", jsonLen, len(b))
+	}
+	js, rest := b[:jsonLen], b[jsonLen:]
+
+	var e Event
+	if err := json.Unmarshal(js, &e); err != nil {
+		return Event{}, fmt.Errorf("instrument.Unmarshal: %w", err)
+	}
+
+	switch {
+	case e.Data.Paint != nil && len(rest) > 0:
+		img, err := decodeFrame(rest)
+		if err != nil {
+			return Event{}, fmt.Errorf("instrument.Unmarshal: %w", err)
+		}
+		e.Data.Paint.Img = camera.Frame{Image: img}
+	case e.Data.Grabbed != nil && len(rest) > 0:
+		img, err := decodeFrame(rest)
+		if err != nil {
+			return Event{}, fmt.Errorf("instrument.Unmarshal: %w", err)
+		}
+		e.Data.Grabbed.Img = camera.Frame{Image: img}
+	}
+	return e, nil
+}
+
+// encodeFrame flattens img to an 8-byte width/height header followed by
+// tightly packed (no stride padding) RGBA pixel bytes. img is first
+// converted to *image.RGBA if it isn't already one, so the wire format
+// never has to branch on img's concrete type.
+func encodeFrame(img image.Image) ([]byte, error) {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w <= 0 || h <= 0 {
+		return nil, fmt.Errorf("encodeFrame: empty image (%dx%d)", w, h)
+	}
+	rgba, ok := img.(*image.RGBA)
+	if !ok || rgba.Stride != w*4 || rgba.Rect.Min != (image.Point{}) {
+		dst := image.NewRGBA(image.Rect(0, 0, w, h))
+		draw.Draw(dst, dst.Bounds(), img, b.Min, draw.Src)
+		rgba = dst
+	}
+
+	out := make([]byte, 0, 8+len(rgba.Pix))
+	out = binary.BigEndian.AppendUint32(out, uint32(w))
+	out = binary.BigEndian.AppendUint32(out, uint32(h))
+	out = append(out, rgba.Pix...)
+	return out, nil
+}
+
+// decodeFrame reverses encodeFrame.
+func decodeFrame(b []byte) (image.Image, error) {
+	if len(b) < 8 {
+		return nil, fmt.Errorf("decodeFrame: %d bytes is too short for a frame header", len(b))
+	}
+	w := binary.BigEndian.Uint32(b[0:4])
+	h := binary.BigEndian.Uint32(b[4:8])
+	pix := b[8:]
+	// want is computed in uint64 specifically so a crafted w,h near 2^32
+	// can't overflow it back around to match a much smaller len(pix): that
+	// would let the len(pix) check below pass and hand image.NewRGBA a
+	// multi-gigabyte w*h to allocate for.
+	want := uint64(w) * uint64(h) * 4
+	if want != uint64(len(pix)) {
+		return nil, fmt.Errorf("decodeFrame: header says %dx%d (%d pixel bytes) but %d remain", w, h, want, len(pix))
+	}
+	img := image.NewRGBA(image.Rect(0, 0, int(w), int(h)))
+	copy(img.Pix, pix)
+	return img, nil
+}
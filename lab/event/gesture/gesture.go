@@ -0,0 +1,147 @@
+// Package gesture recognizes high-level multi-touch gestures (pan, pinch,
+// rotate) from a stream of touch.Event values.
+package gesture
+
+import (
+	"math"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/lab/event/key"
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/lab/event/touch"
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r2"
+)
+
+// Pan is emitted when the centroid of the active touch points moves,
+// whether from one finger or several. Delta is in pixels, the same units
+// mouse.Event.Point is in.
+type Pan struct {
+	// Delta is how far the touch centroid moved since the previous event.
+	Delta r2.Vec
+
+	// NumTouches is how many fingers were down during this pan, so a
+	// consumer can tell a one-finger pan (rotateCamera) from a two-finger
+	// one (translateCamera) the way chunk26-5 asks for.
+	NumTouches int
+}
+
+// Pinch is emitted when two touch points move closer together or farther
+// apart. Scale is the ratio of the current inter-touch distance to the
+// previous sample's (>1 spreading apart, <1 pinching together), matching
+// the factor zoomCamera's delta is meant to drive rather than an absolute
+// distance a consumer would have to track itself.
+type Pinch struct {
+	Scale float64
+}
+
+// Rotate is emitted when two touch points rotate about their centroid.
+// Angle is in radians, the change since the previous sample.
+type Rotate struct {
+	Angle float64
+}
+
+// activeTouch is one finger's last known position, tracked by Identifier.
+type activeTouch struct {
+	point r2.Point
+}
+
+// Recognizer correlates a stream of touch.Event values (one per finger,
+// per DOM touchstart/touchmove/touchend/touchcancel) into Pan, Pinch, and
+// Rotate events. It is not safe for concurrent use; feed it events from a
+// single goroutine, the same way the DOM delivers them.
+type Recognizer struct {
+	touches map[int]activeTouch
+
+	// lastDistance and lastAngle are the two-touch distance/angle as of
+	// the previous Feed call with exactly two touches down. They reset to
+	// zero whenever the touch count changes, so the first sample after a
+	// finger is added or removed never emits a spurious jump from
+	// comparing against a stale two-touch (or one-touch) baseline.
+	lastDistance float64
+	lastAngle    float64
+}
+
+// NewRecognizer returns a Recognizer ready to Feed.
+func NewRecognizer() *Recognizer {
+	return &Recognizer{touches: make(map[int]activeTouch)}
+}
+
+// Feed processes one touch.Event and returns the gesture events (if any)
+// it produces. A touchstart or touchend/touchcancel never itself produces
+// a gesture event; it only updates the active touch set that the next
+// touchmove's deltas are computed against.
+func (r *Recognizer) Feed(e touch.Event) []any {
+	switch e.Direction {
+	case key.DirPress:
+		r.touches[e.Identifier] = activeTouch{point: e.Point}
+		r.lastDistance, r.lastAngle = 0, 0
+		return nil
+	case key.DirRelease:
+		delete(r.touches, e.Identifier)
+		r.lastDistance, r.lastAngle = 0, 0
+		return nil
+	}
+
+	prevCentroid, ok := r.centroid()
+	if !ok {
+		return nil
+	}
+	r.touches[e.Identifier] = activeTouch{point: e.Point}
+	centroid, ok := r.centroid()
+	if !ok {
+		return nil
+	}
+
+	events := []any{Pan{Delta: centroid.Sub(prevCentroid), NumTouches: len(r.touches)}}
+
+	if len(r.touches) != 2 {
+		r.lastDistance, r.lastAngle = 0, 0
+		return events
+	}
+	p0, p1 := r.twoPoints()
+	distance := p0.Sub(p1).Length()
+	angle := math.Atan2(p1.Y-p0.Y, p1.X-p0.X)
+	if r.lastDistance > 0 {
+		events = append(events, Pinch{Scale: distance / r.lastDistance})
+	}
+	if r.lastDistance > 0 {
+		events = append(events, Rotate{Angle: angleDelta(r.lastAngle, angle)})
+	}
+	r.lastDistance, r.lastAngle = distance, angle
+	return events
+}
+
+// centroid returns the average position of all active touches, and false
+// if none are down.
+func (r *Recognizer) centroid() (r2.Point, bool) {
+	if len(r.touches) == 0 {
+		return r2.Point{}, false
+	}
+	var sum r2.Vec
+	for _, t := range r.touches {
+		sum = sum.Add(r2.Vec{X: t.point.X, Y: t.point.Y})
+	}
+	n := float64(len(r.touches))
+	return r2.Point{X: sum.X / n, Y: sum.Y / n}, true
+}
+
+// twoPoints returns the positions of the two active touches, in an
+// arbitrary but stable order. Only valid when len(r.touches) == 2.
+func (r *Recognizer) twoPoints() (r2.Point, r2.Point) {
+	var pts [2]r2.Point
+	i := 0
+	for _, t := range r.touches {
+		pts[i] = t.point
+		i++
+	}
+	return pts[0], pts[1]
+}
+
+// angleDelta returns the shortest signed difference from a to b, both in
+// radians, wrapped to (-pi, pi] so a rotation crossing the +/-pi seam
+// doesn't register as a near-full-circle jump.
+func angleDelta(a, b float64) float64 {
+	d := math.Mod(b-a+math.Pi, 2*math.Pi)
+	if d < 0 {
+		d += 2 * math.Pi
+	}
+	return d - math.Pi
+}
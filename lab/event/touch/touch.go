@@ -0,0 +1,31 @@
+// Package touch provides a touch event type.
+package touch
+
+import (
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/lab/event/key"
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r2"
+)
+
+// Event is a single touch point event, one per finger per DOM
+// touchstart/touchmove/touchend/touchcancel. A multi-finger gesture
+// (touchmove with several changed touches) is reported as one Event per
+// touch point rather than batched, the same way mouse.Event reports one
+// button at a time; gesture.Recognizer is what correlates them by
+// Identifier into pans, pinches, and rotations.
+type Event struct {
+	// Identifier is the touch point's stable ID for its lifetime, from the
+	// DOM Touch.identifier. It's what lets a consumer match this touch's
+	// "move" events back to the "start" that began it.
+	Identifier int
+
+	// Point is the position of the touch in pixels.
+	Point r2.Point
+
+	// Direction is the phase of the touch: DirPress (touchstart), DirRelease
+	// (touchend or touchcancel), or DirNone (touchmove).
+	Direction key.Direction
+
+	// Pressure is the touch's force, in [0, 1], from the DOM Touch.force
+	// (0 on devices that don't report it).
+	Pressure float64
+}
@@ -99,13 +99,18 @@ func loggingMiddleware(next http.Handler) http.Handler {
 
 func main() {
 	flag.Parse()
-	h := http.FileServer(http.Dir("./dist"))
+
+	mux := http.NewServeMux()
+	mux.Handle("/", http.FileServer(http.Dir("./dist")))
+	mux.HandleFunc("/render/stream", renderStreamHandler)
+
+	var h http.Handler = mux
 	h = loggingMiddleware(h)
 	h = wasmContentTypeSetter(h)
 	if *gz {
 		h = gzipHandler(h)
 	}
-	http.Handle("/", h) // Serve the files in the dist directory with logging.
+	http.Handle("/", h)
 
 	port := os.Getenv("PORT") // Set by heroku for deployment.
 	if port == "" {
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"image/png"
+	"log"
+	"net/http"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/phys"
+)
+
+// tileQueueDepth bounds how many finished tiles a /render/stream connection
+// buffers between phys.RenderTiled's worker goroutines and the goroutine
+// writing the SSE response below. It's kept small on purpose: a slow
+// client falls behind the render and loses intermediate tiles rather than
+// blocking a render worker inside OnTile.
+const tileQueueDepth = 4
+
+// tileEvent is the JSON payload of each "tile" Server-Sent Event written by
+// renderStreamHandler.
+type tileEvent struct {
+	X           int    `json:"x"`
+	Y           int    `json:"y"`
+	W           int    `json:"w"`
+	H           int    `json:"h"`
+	SampleIndex int    `json:"sampleIndex"`
+	PNG         string `json:"png"`
+}
+
+// renderStreamHandler accepts a phys.Scene as JSON in the POST body and
+// renders it with phys.RenderTiled, writing each finished tile to the
+// client as a Server-Sent Event so the wasm viewer can repaint the image
+// incrementally instead of waiting for the whole render to finish. The
+// stream ends with a "done" event, or an "error" event if the render
+// failed or the client disconnected.
+func renderStreamHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var scene phys.Scene
+	if err := json.NewDecoder(r.Body).Decode(&scene); err != nil {
+		http.Error(w, fmt.Sprintf(`{"error": "invalid JSON: %v"}`, err), http.StatusBadRequest)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, `{"error": "streaming unsupported"}`, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	tiles := make(chan tileEvent, tileQueueDepth)
+	rendered := make(chan error, 1)
+
+	go func() {
+		_, err := phys.RenderTiled(ctx, &scene, func(t phys.TileResult) error {
+			var buf bytes.Buffer
+			if err := png.Encode(&buf, t.Image); err != nil {
+				return fmt.Errorf("encode tile: %w", err)
+			}
+			ev := tileEvent{
+				X: t.X, Y: t.Y, W: t.W, H: t.H,
+				SampleIndex: t.SampleIndex,
+				PNG:         base64.StdEncoding.EncodeToString(buf.Bytes()),
+			}
+			queueTile(tiles, ev)
+			return ctx.Err()
+		})
+		rendered <- err
+	}()
+
+	bw := bufio.NewWriter(w)
+	for {
+		select {
+		case ev := <-tiles:
+			writeTileEvent(bw, ev)
+			flusher.Flush()
+		case err := <-rendered:
+			for drained := false; !drained; {
+				select {
+				case ev := <-tiles:
+					writeTileEvent(bw, ev)
+				default:
+					drained = true
+				}
+			}
+			if err != nil {
+				data, _ := json.Marshal(err.Error())
+				fmt.Fprintf(bw, "event: error\ndata: %s\n\n", data)
+			} else {
+				fmt.Fprint(bw, "event: done\ndata: {}\n\n")
+			}
+			bw.Flush()
+			flusher.Flush()
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// queueTile enqueues ev onto tiles, dropping the oldest queued tile to make
+// room if the channel is already full. This is the backpressure mechanism:
+// it keeps render workers calling OnTile from ever blocking on a slow HTTP
+// client, at the cost of that client seeing fewer intermediate tiles.
+func queueTile(tiles chan tileEvent, ev tileEvent) {
+	select {
+	case tiles <- ev:
+		return
+	default:
+	}
+	select {
+	case <-tiles:
+	default:
+	}
+	select {
+	case tiles <- ev:
+	default:
+	}
+}
+
+// writeTileEvent writes ev to bw as a single SSE "tile" event. Marshal
+// errors are logged and the tile is dropped; they should never happen since
+// tileEvent's fields are all JSON-safe.
+func writeTileEvent(bw *bufio.Writer, ev tileEvent) {
+	data, err := json.Marshal(ev)
+	if err != nil {
+		log.Printf("renderStreamHandler: marshal tile event: %v", err)
+		return
+	}
+	fmt.Fprintf(bw, "event: tile\ndata: %s\n\n", data)
+}
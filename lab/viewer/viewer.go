@@ -0,0 +1,255 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+
+// Package viewer wires lab/event/mouse and lab/event/wheel events into a
+// mouse-driven, progressively refined view of a phys.Scene: a left drag
+// orbits the camera around LookAt, a middle drag pans LookAt, a right
+// drag dollies the orbit radius in and out, and the wheel adjusts field
+// of view. It is lab/viewer's mouse-driven counterpart to phys/viewer's
+// keyboard-driven Viewer.
+//
+// Like phys/viewer, this package does not itself open a window: no
+// windowing library is vendored in this module (go.mod has no
+// dependencies at all), so Viewer only owns the event-handling and
+// progressive-tile-streaming logic a window driver needs, the same split
+// lab/client and lab/worker already use for the WASM canvas -- Viewer is
+// what a native driver would wire mouse/wheel callbacks and a frame sink
+// into instead.
+package viewer
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/lab/event/key"
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/lab/event/mouse"
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/lab/event/wheel"
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/phys"
+)
+
+// rotatePixelsPerUnit and panPixelsPerUnit match
+// lab/worker/worker.go's own pixel-to-arcball-unit scaling, so a drag
+// feels the same whether it drives this Viewer or the WASM worker's.
+const (
+	rotatePixelsPerUnit = 300
+	panPixelsPerUnit    = 500
+	dollyPixelsPerUnit  = 300
+)
+
+// wheelFOVSensitivity scales a wheel.Event's Delta.Y into the exponent
+// HandleWheel uses to grow or shrink FOVHeight/FOVWidth, mirroring how
+// lab/worker's zoomCamera turns a wheel delta into math.Exp(delta *
+// zoomSensitivity).
+const wheelFOVSensitivity = 0.001
+
+// TileFrame is one tile Stream delivers: the rendered tile plus the
+// RaysPerPixel budget the progressive level that produced it used, so a
+// caller painting tiles as they arrive can tell a coarse early tile from
+// a later, more refined one covering the same region.
+type TileFrame struct {
+	phys.TileResult
+	RaysPerPixel int
+}
+
+// Viewer holds the mouse-driven camera state layered on top of a Scene.
+type Viewer struct {
+	// Scene is the scene being viewed. Its first camera must be a
+	// phys.FocusableCamera; Viewer moves that camera in place as mouse and
+	// wheel events are handled.
+	Scene *phys.Scene
+
+	// MinRaysPerPixel and MaxRaysPerPixel bound the progressive sequence
+	// Stream works through, doubling from the former to the latter while
+	// the camera stays still.
+	MinRaysPerPixel int
+	MaxRaysPerPixel int
+
+	// TileSize is the RenderOptions.TileSize Stream requests.
+	TileSize int
+
+	mu         sync.Mutex
+	arcball    *phys.ArcballController
+	pointerX   float64
+	pointerY   float64
+	dragButton mouse.Button
+	dragging   bool
+	lastX      float64
+	lastY      float64
+	cancel     context.CancelFunc
+}
+
+// New returns a Viewer over scene, whose first camera must already be a
+// phys.FocusableCamera.
+func New(scene *phys.Scene) (*Viewer, error) {
+	if len(scene.Camera) == 0 {
+		return nil, fmt.Errorf("error viewer.New: scene has no Camera")
+	}
+	cam, ok := scene.Camera[0].(phys.FocusableCamera)
+	if !ok {
+		return nil, fmt.Errorf("error viewer.New: scene.Camera[0] is %T, want phys.FocusableCamera", scene.Camera[0])
+	}
+	return &Viewer{
+		Scene:           scene,
+		MinRaysPerPixel: 1,
+		MaxRaysPerPixel: 64,
+		TileSize:        64,
+		arcball:         phys.NewArcballController(phys.CameraExtrinsics{LookFrom: cam.LookFrom, LookAt: cam.LookAt, VUp: cam.VUp}),
+	}, nil
+}
+
+// clamp1 clamps x to [-1, 1], the range ArcballController.Rotate expects
+// its pointer position in.
+func clamp1(x float64) float64 {
+	switch {
+	case x > 1:
+		return 1
+	case x < -1:
+		return -1
+	default:
+		return x
+	}
+}
+
+// syncCamera copies v.arcball's current frame onto v.Scene.Camera[0].
+// Callers must hold v.mu.
+func (v *Viewer) syncCamera() {
+	extr := v.arcball.Extrinsics()
+	cam := v.Scene.Camera[0].(phys.FocusableCamera)
+	cam.LookFrom = extr.LookFrom
+	cam.LookAt = extr.LookAt
+	cam.VUp = extr.VUp
+	v.Scene.Camera[0] = cam
+}
+
+// cancelInFlight cancels whatever Stream call is currently running, if
+// any, so the next frame a caller requests reflects the mutation just
+// applied instead of a stale render finishing first. Callers must hold
+// v.mu.
+func (v *Viewer) cancelInFlight() {
+	if v.cancel != nil {
+		v.cancel()
+	}
+}
+
+// HandleMouse applies e to the camera: a left-button drag orbits around
+// LookAt, a middle-button drag pans it, and a right-button drag dollies
+// the orbit radius in and out. A DirNone move with no button held (and no
+// drag already in progress) is ignored.
+func (v *Viewer) HandleMouse(e mouse.Event) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	switch e.Direction {
+	case key.DirPress:
+		v.dragButton = e.Button
+		v.dragging = true
+		v.lastX, v.lastY = e.Point.X, e.Point.Y
+		if e.Button == mouse.ButtonLeft {
+			v.arcball.BeginRotate(v.pointerX, v.pointerY)
+		}
+		return
+	case key.DirRelease:
+		v.dragging = false
+		v.arcball.EndDrag()
+		return
+	case key.DirNone:
+		if !v.dragging {
+			return
+		}
+	}
+
+	dx, dy := e.Point.X-v.lastX, e.Point.Y-v.lastY
+	v.lastX, v.lastY = e.Point.X, e.Point.Y
+
+	switch v.dragButton {
+	case mouse.ButtonLeft:
+		v.pointerX = clamp1(v.pointerX + dx/rotatePixelsPerUnit)
+		v.pointerY = clamp1(v.pointerY - dy/rotatePixelsPerUnit)
+		v.arcball.Rotate(v.pointerX, v.pointerY)
+	case mouse.ButtonMiddle:
+		v.arcball.Pan(dx/panPixelsPerUnit, dy/panPixelsPerUnit)
+	case mouse.ButtonRight:
+		v.arcball.Dolly(dy / dollyPixelsPerUnit * 10) // Dolly's offY is wheel-scaled; 10x brings a drag's smaller pixel range up to a comparable zoom rate.
+	default:
+		return
+	}
+	v.syncCamera()
+	v.cancelInFlight()
+}
+
+// minFOV is the floor HandleWheel clamps FOVHeight/FOVWidth to, matching
+// phys/viewer.Viewer's own "Minus" key floor.
+const minFOV = phys.Nanometer
+
+// HandleWheel scales FOVHeight and FOVWidth by exp(e.Delta.Y *
+// wheelFOVSensitivity): scrolling one way narrows the field of view
+// (zooms in), the other way widens it (zooms out).
+func (v *Viewer) HandleWheel(e wheel.Event) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	cam := v.Scene.Camera[0].(phys.FocusableCamera)
+	scale := phys.Distance(math.Exp(e.Delta.Y * wheelFOVSensitivity))
+	cam.FOVHeight = maxFOV(cam.FOVHeight * scale)
+	cam.FOVWidth = maxFOV(cam.FOVWidth * scale)
+	v.Scene.Camera[0] = cam
+	v.cancelInFlight()
+}
+
+func maxFOV(d phys.Distance) phys.Distance {
+	if d < minFOV {
+		return minFOV
+	}
+	return d
+}
+
+// Stream renders v.Scene progressively: starting at MinRaysPerPixel and
+// doubling up to MaxRaysPerPixel, each level is one complete
+// phys.RenderTiled call whose tiles are delivered, as they finish, over
+// the returned channel. The channel closes when the last level finishes,
+// ctx is canceled, or a render errors.
+//
+// HandleMouse and HandleWheel cancel whatever Stream call is in flight as
+// soon as they mutate the camera or field of view, so a caller should
+// simply call Stream again once the previous call's channel closes --
+// the same restart-from-the-beginning loop lab/worker/worker.go's
+// render() drives around its own renderTilesProgressive.
+func (v *Viewer) Stream(ctx context.Context) <-chan TileFrame {
+	out := make(chan TileFrame)
+	ctx, cancel := context.WithCancel(ctx)
+	v.mu.Lock()
+	v.cancel = cancel
+	v.mu.Unlock()
+
+	go func() {
+		defer close(out)
+		defer cancel()
+		for raysPerPixel := v.MinRaysPerPixel; ; raysPerPixel *= 2 {
+			if ctx.Err() != nil {
+				return
+			}
+			v.mu.Lock()
+			sceneCopy := *v.Scene
+			sceneCopy.RenderOptions.RaysPerPixel = raysPerPixel
+			sceneCopy.RenderOptions.TileSize = v.TileSize
+			v.mu.Unlock()
+
+			onTile := func(res phys.TileResult) error {
+				select {
+				case out <- TileFrame{TileResult: res, RaysPerPixel: raysPerPixel}:
+					return nil
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+			if _, err := phys.RenderTiled(ctx, &sceneCopy, onTile); err != nil {
+				return
+			}
+			if raysPerPixel >= v.MaxRaysPerPixel {
+				return
+			}
+		}
+	}()
+	return out
+}
@@ -0,0 +1,146 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+
+package viewer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/lab/event/key"
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/lab/event/mouse"
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/lab/event/wheel"
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/phys"
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/phys/viewerfixture"
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r2"
+)
+
+// TestNewRejectsNonFocusableCamera verifies New returns an honest error
+// instead of panicking later when the scene's first camera isn't a
+// phys.FocusableCamera.
+func TestNewRejectsNonFocusableCamera(t *testing.T) {
+	scene := &phys.Scene{Camera: []phys.Camera{phys.OrthographicCamera{}}}
+	if _, err := New(scene); err == nil {
+		t.Fatal("New: expected an error for a non-FocusableCamera scene, got nil")
+	}
+}
+
+// TestHandleMouseLeftDragOrbitsLookFrom verifies a left-button drag moves
+// LookFrom while leaving LookAt fixed, the orbit-around-a-target behavior
+// a left drag is supposed to produce.
+func TestHandleMouseLeftDragOrbitsLookFrom(t *testing.T) {
+	scene := viewerfixture.Scene(t)
+	v, err := New(scene)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	before := scene.Camera[0].(phys.FocusableCamera)
+	v.HandleMouse(mouse.Event{Point: r2.Point{X: 0, Y: 0}, Button: mouse.ButtonLeft, Direction: key.DirPress})
+	v.HandleMouse(mouse.Event{Point: r2.Point{X: 100, Y: 0}, Direction: key.DirNone})
+	after := scene.Camera[0].(phys.FocusableCamera)
+
+	if after.LookFrom == before.LookFrom {
+		t.Error("HandleMouse: left drag did not move LookFrom")
+	}
+	if after.LookAt != before.LookAt {
+		t.Errorf("HandleMouse: left drag moved LookAt from %v to %v, want unchanged", before.LookAt, after.LookAt)
+	}
+}
+
+// TestHandleMouseMiddleDragPansLookAt verifies a middle-button drag moves
+// LookAt (panning the target), unlike a left drag.
+func TestHandleMouseMiddleDragPansLookAt(t *testing.T) {
+	scene := viewerfixture.Scene(t)
+	v, err := New(scene)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	before := scene.Camera[0].(phys.FocusableCamera)
+	v.HandleMouse(mouse.Event{Point: r2.Point{X: 0, Y: 0}, Button: mouse.ButtonMiddle, Direction: key.DirPress})
+	v.HandleMouse(mouse.Event{Point: r2.Point{X: 50, Y: 0}, Direction: key.DirNone})
+	after := scene.Camera[0].(phys.FocusableCamera)
+
+	if after.LookAt == before.LookAt {
+		t.Error("HandleMouse: middle drag did not move LookAt")
+	}
+}
+
+// TestHandleMouseIgnoresMoveWithoutDrag verifies a DirNone mouse move
+// before any button press leaves the camera untouched.
+func TestHandleMouseIgnoresMoveWithoutDrag(t *testing.T) {
+	scene := viewerfixture.Scene(t)
+	v, err := New(scene)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	before := scene.Camera[0].(phys.FocusableCamera)
+	v.HandleMouse(mouse.Event{Point: r2.Point{X: 100, Y: 100}, Direction: key.DirNone})
+	after := scene.Camera[0].(phys.FocusableCamera)
+	if before != after {
+		t.Errorf("camera changed on an un-dragged move: before=%+v after=%+v", before, after)
+	}
+}
+
+// TestHandleWheelNarrowsOrWidensFOV verifies a wheel event scales
+// FOVHeight/FOVWidth and a zero delta is a no-op.
+func TestHandleWheelNarrowsOrWidensFOV(t *testing.T) {
+	scene := viewerfixture.Scene(t)
+	v, err := New(scene)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	before := scene.Camera[0].(phys.FocusableCamera)
+	v.HandleWheel(wheel.Event{Delta: r2.Vec{Y: 100}})
+	after := scene.Camera[0].(phys.FocusableCamera)
+	if after.FOVHeight == before.FOVHeight {
+		t.Error("HandleWheel: FOVHeight unchanged after a non-zero wheel delta")
+	}
+	if after.FOVWidth == before.FOVWidth {
+		t.Error("HandleWheel: FOVWidth unchanged after a non-zero wheel delta")
+	}
+}
+
+// TestStreamDeliversIncreasingRaysPerPixel verifies Stream's tiles arrive
+// in non-decreasing RaysPerPixel order and the channel closes once
+// MaxRaysPerPixel is reached.
+func TestStreamDeliversIncreasingRaysPerPixel(t *testing.T) {
+	scene := viewerfixture.Scene(t)
+	v, err := New(scene)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	v.MinRaysPerPixel = 1
+	v.MaxRaysPerPixel = 2
+	v.TileSize = 4
+
+	last := 0
+	for frame := range v.Stream(context.Background()) {
+		if frame.RaysPerPixel < last {
+			t.Errorf("Stream: RaysPerPixel decreased from %d to %d", last, frame.RaysPerPixel)
+		}
+		last = frame.RaysPerPixel
+	}
+	if last != v.MaxRaysPerPixel {
+		t.Errorf("Stream: last tile's RaysPerPixel = %d, want %d", last, v.MaxRaysPerPixel)
+	}
+}
+
+// TestStreamStopsOnCancel verifies a canceled context stops Stream's
+// channel from delivering further tiles instead of running to MaxRaysPerPixel.
+func TestStreamStopsOnCancel(t *testing.T) {
+	scene := viewerfixture.Scene(t)
+	v, err := New(scene)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	v.MinRaysPerPixel = 1
+	v.MaxRaysPerPixel = 1 << 20 // Would never finish if not canceled.
+	v.TileSize = 4
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := v.Stream(ctx)
+	<-ch // First tile, then cancel before the sequence can run away.
+	cancel()
+	for range ch {
+		// Drain until Stream notices ctx is done and closes the channel.
+	}
+}
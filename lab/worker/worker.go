@@ -5,28 +5,218 @@ package main
 import (
 	"context"
 	"fmt"
+	"image"
 	"io/fs"
 	"log"
 	"math"
 	"sync"
 	"syscall/js"
+	"time"
 
 	"github.com/scottlawsonbc/slam/code/photon/raytrace/obj"
 	"github.com/scottlawsonbc/slam/code/photon/raytrace/phys"
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/phys/anim"
 	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
 )
 
 type worker struct {
-	scene        phys.Scene
-	camera       phys.OrthographicCamera
-	cameraTheta  float64
-	cameraPhi    float64
-	cameraRadius float64
-	renderMutex  sync.Mutex
-	renderDirty  bool
-	isRendering  bool
+	scene       phys.Scene
+	camera      phys.FocusableCamera
+	renderMutex sync.Mutex
+	renderDirty bool
+	isRendering bool
+
+	// arcball drives rotateCamera and translateCamera: it owns LookFrom,
+	// LookAt, and VUp, and is the source of truth worker.camera is synced
+	// from after every pointer message (see syncCameraFromArcball).
+	// zoomCamera is the one exception -- it moves LookFrom/LookAt itself to
+	// dolly toward the point under the cursor, then rebuilds arcball from
+	// the result so the next rotate orbits around the new position instead
+	// of snapping back to the old one.
+	arcball *phys.ArcballController
+
+	// pointerX, pointerY are the running, normalized-to-[-1, 1] cursor
+	// position rotateCamera accumulates from each message's pixel delta.
+	// ArcballController.Rotate wants an absolute position on its virtual
+	// sphere, not a delta, and "rotateCamera" messages only carry deltas
+	// (see the dx, dy fields of the postMessage protocol this answers to).
+	pointerX, pointerY float64
+
+	// denoiseMode toggles render() between two RenderOptions the scene's
+	// base RaysPerPixel/MaxRayDepth/Dx/Dy don't change: off renders with
+	// AdaptiveSampling so orbiting the camera stays responsive, on renders
+	// a fixed, heavier sample count with AuxBuffers+Denoise for a cleaner
+	// still. RenderOptions.Validate rejects Denoise and AdaptiveSampling
+	// together, so there is no single set of options that gives adaptive
+	// sampling and denoising at once -- this toggle is the tradeoff.
+	denoiseMode bool
+
+	// cancelRender, when non-nil, cancels the phys.Render call currently
+	// in flight inside render(). onMessage calls it whenever a new camera
+	// or mode-changing message arrives mid-render, so a stale in-progress
+	// refinement is abandoned immediately instead of running to
+	// completion before the next render (see renderDirty) picks up the
+	// new camera.
+	cancelRender context.CancelFunc
+
+	// sharedPixels, once set by an "init" message, is a Uint8ClampedArray
+	// backed by a SharedArrayBuffer the main thread allocated. postArtifact
+	// writes each frame directly into it via js.CopyBytesToJS instead of
+	// allocating a fresh Uint8ClampedArray per frame, the per-frame
+	// allocation this type exists to avoid. The zero value (js.Value{},
+	// which IsUndefined()) means no shared buffer was negotiated and
+	// postArtifact falls back to posting a freshly allocated array.
+	sharedPixels js.Value
+
+	// canvasCtx, once set by an "init" message carrying a transferred
+	// OffscreenCanvas, is that canvas's 2D rendering context. When valid,
+	// postArtifact paints directly into it with putImageData and posts only
+	// a small "frameReady" notification instead of the pixel payload,
+	// avoiding the interop cost of shipping pixels back to the main thread
+	// at all. The zero value means no canvas was transferred.
+	canvasCtx js.Value
+
+	// frameStats is a rolling window of the last frameStatsWindowSize
+	// frames' phys.FrameStats, oldest first. postArtifact appends to it
+	// (trimming from the front once full) and folds it into a compact
+	// avgFrameMs/raysPerSecond/bvhTraversalsPerRay summary included in
+	// every postMessage payload, so the browser UI can draw a live perf
+	// overlay without separately instrumenting JS-side frame timing.
+	frameStats []phys.FrameStats
+
+	// raysPerPixel is the RenderOptions.RaysPerPixel the render() call
+	// currently in flight requested, stashed here so postArtifact (which
+	// only sees the finished RenderArtifact) can derive a FrameStats from
+	// it via phys.NewFrameStats.
+	raysPerPixel int
+
+	// bufferPool holds ArrayBuffers the main thread has finished reading and
+	// handed back via a "returnBuffer" message, sized exactly
+	// len(artifact.Image.Pix) bytes for the scene's current Dx/Dy. When
+	// postArtifact's no-sharedPixels, no-canvasCtx fallback needs a buffer
+	// to transfer, it pops one from here instead of allocating a fresh
+	// ArrayBuffer, so steady-state playback after the first few frames
+	// allocates nothing per frame. Buffers of the wrong size (e.g. after a
+	// resize) are dropped rather than reused; see returnBuffer.
+	bufferPool []js.Value
+
+	// recordedKeyframes accumulates the poses "recordKeyframe" messages
+	// capture from the live camera (see cameraKeyframeFromCurrent), each
+	// keyframeSpacing apart. "playTrack" falls back to these when the
+	// message itself carries no keyframes of its own.
+	recordedKeyframes []anim.CameraKeyframe
+
+	// playback, while non-nil, is the in-progress "playTrack" flythrough:
+	// playbackTick advances it and reschedules itself via setTimeout until
+	// the track's Duration is reached, at which point render() takes back
+	// over the idle camera the same way it does after any other message.
+	playback *playbackState
+}
+
+// playbackState is the state one "playTrack" run advances each tick.
+type playbackState struct {
+	track   anim.CameraTrack
+	started time.Time
+}
+
+// bufferPoolCap bounds how many returned ArrayBuffers postArtifact keeps
+// around: one or two in flight is enough to keep the fallback path
+// allocation-free without the pool itself growing unbounded if the main
+// thread returns buffers faster than postArtifact consumes them.
+const bufferPoolCap = 4
+
+// returnBuffer adds buf back to w.bufferPool for postArtifact's fallback
+// path to reuse, provided it's the size postArtifact currently needs and
+// the pool isn't already full. A mismatched size (the main thread can only
+// return what it was sent, but a resize racing the message makes that
+// momentarily stale) or a full pool just lets buf be garbage collected.
+func (w *worker) returnBuffer(buf js.Value, wantLen int) {
+	if buf.IsUndefined() || buf.IsNull() {
+		return
+	}
+	if buf.Get("byteLength").Int() != wantLen {
+		return
+	}
+	if len(w.bufferPool) >= bufferPoolCap {
+		return
+	}
+	w.bufferPool = append(w.bufferPool, buf)
 }
 
+// frameStatsWindowSize is how many recent frames' phys.FrameStats
+// postArtifact keeps in w.frameStats for the rolling perf summary.
+const frameStatsWindowSize = 60
+
+// previewDx and previewDy are the resolution of the low-resolution
+// preview render() posts immediately at the start of every render, before
+// progressively refining to the scene's full RenderOptions.Dx/Dy. This is
+// what keeps interactive rotate/zoom/translate from feeling like it
+// blocks on a full-resolution frame.
+const previewDx, previewDy = 64, 64
+
+// previewRaysPerPixel is the sample count render() uses for the preview
+// pass: the minimum that still produces a recognizable image.
+const previewRaysPerPixel = 1
+
+// progressiveSamplesPerPass splits the full-resolution render's sample
+// budget (RaysPerPixel) into small, successive OnPass callbacks, so
+// render() can post an update after every pass instead of waiting for the
+// whole image. RenderOptions.PassCount is left at its zero value, which
+// Render derives from RaysPerPixel/progressiveSamplesPerPass.
+const progressiveSamplesPerPass = 1
+
+// adaptiveRaysPerPixel is the per-tile sample budget render() requests when
+// denoiseMode is off, matching the quick, interactive RaysPerPixel the
+// scene was already built with in init.
+const adaptiveRaysPerPixel = 1
+
+// adaptiveEarlyStopVariance is the relative-error threshold render() passes
+// as EarlyStopVariance when denoiseMode is off, retiring tiles that have
+// already converged well below what a single noisy sample would show.
+const adaptiveEarlyStopVariance = 0.05
+
+// adaptiveQualityLevels is the sequence of RaysPerPixel budgets render()'s
+// tile-streamed full-resolution stage works through while the camera stays
+// idle: each level is one complete phys.RenderTiled call, streaming every
+// tile over a "tile" message (see postTile) as soon as it finishes, ordered
+// along a Hilbert curve (phys.TileOrderHilbert) so the canvas fills in as a
+// single coherent, expanding region instead of disconnected scanlines.
+// Levels roughly quadruple, mirroring how AdaptiveMaxSamples scales with
+// RaysPerPixel (see adaptive_sampler.go's defaultAdaptiveMaxSamplesMultiplier),
+// so each level actually lets AdaptiveSampling spend more samples on pixels
+// that haven't converged rather than immediately re-hitting
+// adaptiveEarlyStopVariance's early exit. onMessage's cancelRender aborts
+// mid-level as soon as a new camera event arrives; render() then restarts
+// the whole sequence from the preview instead of finishing a level nobody
+// will see.
+var adaptiveQualityLevels = []int{adaptiveRaysPerPixel, 4, 16}
+
+// tileStreamSize is the RenderOptions.TileSize renderTilesProgressive
+// requests, overriding defaultTileSize (phys/render.go): a bigger tile
+// means fewer, chunkier "tile" postMessage calls per level, trading a
+// little responsiveness (each tile takes longer to finish) for less
+// per-tile postMessage/transfer overhead.
+const tileStreamSize = 64
+
+// denoiseRaysPerPixel is the fixed sample count render() requests when
+// denoiseMode is on. It's higher than adaptiveRaysPerPixel because
+// DenoiseATrous smooths remaining noise rather than eliminating it --
+// starting from a flat single sample per pixel leaves too little signal
+// for the edge-stopping terms to distinguish detail from noise.
+const denoiseRaysPerPixel = 8
+
+// keyframeSpacing is the Time interval "recordKeyframe" assigns between
+// consecutively recorded poses, so a user pressing R a few times while
+// moving the camera gets a track that plays back at a fixed pace without
+// having to time the key presses themselves.
+const keyframeSpacing = 2 * time.Second
+
+// playbackTickIntervalMs is how often, in milliseconds, an in-progress
+// "playTrack" flythrough samples the track and reschedules itself via
+// setTimeout (mirroring scheduleRender's use of setTimeout, since a Worker
+// has no requestAnimationFrame of its own to drive this from instead).
+const playbackTickIntervalMs = 200
+
 func loadNodes(fsys fs.FS, objPath string) ([]phys.Node, error) {
 	parsedObj, err := obj.ParseFS(fsys, objPath)
 	if err != nil {
@@ -87,17 +277,21 @@ func (w *worker) init() {
 	bounds := scene.Bounds()
 	db := bounds.Max.Sub(bounds.Min).Get(bounds.LongestAxis())
 
-	cam := phys.OrthographicCamera{
-		LookFrom:  r3.Point{X: 0.5, Y: 0.5, Z: 20.0},
-		LookAt:    r3.Point{X: 0.5, Y: 0.5, Z: 0},
-		VUp:       r3.Vec{X: 0, Y: 1, Z: 0},
-		FOVHeight: phys.Distance(db * 1.5),
-		FOVWidth:  phys.Distance(db * 1.5),
+	lookFrom := r3.Point{X: 0.5, Y: 0.5, Z: 20.0}
+	lookAt := r3.Point{X: 0.5, Y: 0.5, Z: 0}
+	cam := phys.FocusableCamera{
+		LookFrom:        lookFrom,
+		LookAt:          lookAt,
+		VUp:             r3.Vec{X: 0, Y: 1, Z: 0},
+		FOVHeight:       phys.Distance(db * 1.5),
+		FOVWidth:        phys.Distance(db * 1.5),
+		Aperture:        0, // No depth of field until the user sets one via "setAperture".
+		WorkingDistance: phys.Distance(lookFrom.Sub(lookAt).Length()),
 	}
 
 	w.camera = cam
 	w.scene = scene
-	w.computeSphericalCoordinates()
+	w.arcball = phys.NewArcballController(phys.CameraExtrinsics{LookFrom: cam.LookFrom, LookAt: cam.LookAt, VUp: cam.VUp})
 	w.isRendering = false
 	w.renderDirty = false
 }
@@ -116,34 +310,168 @@ func (w *worker) scheduleRender() {
 	}), 0)
 }
 
+// workerMessage mirrors the typed envelope the client posts (see the
+// client's own workerMessage): onMessage decodes a postMessage's plain JS
+// object into one of these once, up front, instead of each case repeating
+// its own message.Get(...) calls.
+type workerMessage struct {
+	Type string
+
+	Dx, Dy float64 // rotateCamera, translateCamera
+	X, Y   float64 // zoomCamera
+	Delta  float64 // zoomCamera
+
+	Aperture      float64 // setAperture
+	FocusDistance float64 // setFocus
+	NumWorkers    int     // setWorkers
+
+	// Keyframes is set only for "playTrack", parsed from the message's
+	// keyframes array (see decodeWorkerMessage). A zero-length slice (the
+	// field omitted, or an empty array) means "play back whatever was
+	// recorded via recordKeyframe instead" -- see (*worker).startPlayback.
+	Keyframes []anim.CameraKeyframe
+
+	// Buffer and ByteLength are set only for "returnBuffer"; see
+	// (*worker).returnBuffer.
+	Buffer     js.Value
+	ByteLength int
+}
+
+func decodeWorkerMessage(raw js.Value) workerMessage {
+	m := workerMessage{Type: raw.Get("type").String()}
+	switch m.Type {
+	case "rotateCamera", "translateCamera":
+		m.Dx = raw.Get("dx").Float()
+		m.Dy = raw.Get("dy").Float()
+	case "zoomCamera":
+		m.X = raw.Get("x").Float()
+		m.Y = raw.Get("y").Float()
+		m.Delta = raw.Get("delta").Float()
+	case "setAperture":
+		m.Aperture = raw.Get("aperture").Float()
+	case "setFocus":
+		m.FocusDistance = raw.Get("focusDistance").Float()
+	case "returnBuffer":
+		m.Buffer = raw.Get("buffer")
+		m.ByteLength = raw.Get("byteLength").Int()
+	case "setWorkers":
+		m.NumWorkers = int(raw.Get("count").Float())
+	case "playTrack":
+		m.Keyframes = decodeKeyframes(raw.Get("keyframes"))
+	}
+	return m
+}
+
+// decodeKeyframes parses a "playTrack" message's keyframes array, each
+// entry {time (ms), position: {x,y,z}, orientation: {w,x,y,z}, fov
+// (degrees)}, mirroring cameraKeyframeFromCurrent's own units. A missing
+// or non-array field yields nil, not an error: startPlayback treats that
+// as "use recordedKeyframes instead".
+func decodeKeyframes(raw js.Value) []anim.CameraKeyframe {
+	if raw.IsUndefined() || raw.IsNull() {
+		return nil
+	}
+	n := raw.Get("length").Int()
+	keyframes := make([]anim.CameraKeyframe, n)
+	for i := 0; i < n; i++ {
+		kf := raw.Index(i)
+		pos := kf.Get("position")
+		ori := kf.Get("orientation")
+		keyframes[i] = anim.CameraKeyframe{
+			Time: time.Duration(kf.Get("time").Float() * float64(time.Millisecond)),
+			Position: r3.Point{
+				X: pos.Get("x").Float(),
+				Y: pos.Get("y").Float(),
+				Z: pos.Get("z").Float(),
+			},
+			Orientation: phys.Quaternion{
+				X: ori.Get("x").Float(),
+				Y: ori.Get("y").Float(),
+				Z: ori.Get("z").Float(),
+				W: ori.Get("w").Float(),
+			},
+			FoV: phys.Angle(kf.Get("fov").Float()) * phys.Degree,
+		}
+	}
+	return keyframes
+}
+
 func (w *worker) onMessage(this js.Value, args []js.Value) interface{} {
-	message := args[0].Get("data")
-	messageType := message.Get("type").String()
+	raw := args[0].Get("data")
+	msg := decodeWorkerMessage(raw)
 
 	// Lock only when modifying shared state
 	w.renderMutex.Lock()
 	// Process the message and update the camera
-	switch messageType {
+	switch msg.Type {
 	case "rotateCamera":
-		dx := message.Get("dx").Float()
-		dy := message.Get("dy").Float()
-		w.rotateCamera(dx, dy)
+		w.playback = nil // A manual drag takes back control from any in-progress playTrack.
+		w.rotateCamera(msg.Dx, msg.Dy)
 	case "zoomCamera":
-		delta := message.Get("delta").Float()
-		w.zoomCamera(delta)
+		w.playback = nil
+		w.zoomCamera(msg.X, msg.Y, msg.Delta)
 	case "translateCamera":
-		dx := message.Get("dx").Float()
-		dy := message.Get("dy").Float()
-		w.translateCamera(dx, dy)
+		w.playback = nil
+		w.translateCamera(msg.Dx, msg.Dy)
+	case "tick":
+		// The main thread drives this from its own requestAnimationFrame
+		// loop (rAF has no equivalent inside a Worker) so a flick-rotate's
+		// momentum keeps spinning down between pointer events. Skip the
+		// render-scheduling below once momentum has settled, so an idle
+		// camera does not re-render every frame for nothing.
+		if !w.arcball.Tick() {
+			w.renderMutex.Unlock()
+			return nil
+		}
+		w.syncCameraFromArcball()
+	case "toggleDenoise":
+		w.denoiseMode = !w.denoiseMode
+		log.Printf("denoiseMode = %v\n", w.denoiseMode)
+	case "setAperture":
+		w.setAperture(msg.Aperture)
+	case "setFocus":
+		w.setFocus(msg.FocusDistance)
+	case "recordKeyframe":
+		w.recordKeyframe()
+		w.renderMutex.Unlock()
+		return nil
+	case "playTrack":
+		w.startPlayback(msg.Keyframes)
+		w.renderMutex.Unlock()
+		return nil
+	case "init":
+		// sharedBuffer and canvas are live JS objects (a SharedArrayBuffer
+		// view, an OffscreenCanvas), not scalars workerMessage's fields can
+		// hold, so initSharedOutput still reads raw directly.
+		w.initSharedOutput(raw)
+	case "returnBuffer":
+		w.returnBuffer(msg.Buffer, msg.ByteLength)
+		w.renderMutex.Unlock()
+		return nil
+	case "setWorkers":
+		// NumWorkers sizes this wasm instance's own internal tile-queue
+		// goroutine pool (see phys.RenderOptions.NumWorkers). Fanning
+		// tiles out to a pool of separate browser Worker processes, each
+		// running its own wasm instance, is not implemented here: it needs
+		// a JS-side bootstrap (spawning "new Worker(...)" and relaying
+		// postMessage tile results) that lives outside this Go module.
+		w.scene.RenderOptions.NumWorkers = msg.NumWorkers
 	default:
-		log.Println("Unknown message type:", messageType)
+		log.Println("Unknown message type:", msg.Type)
 		w.renderMutex.Unlock()
 		return nil
 	}
 
 	if w.isRendering {
-		// If rendering is in progress, mark as dirty
+		// If rendering is in progress, mark as dirty and cancel the
+		// in-flight render: its partial progress is already posted via
+		// OnPass, but continuing to refine toward the old camera/mode
+		// would waste time the new render() loop (started below, once
+		// this one notices renderDirty) could spend instead.
 		w.renderDirty = true
+		if w.cancelRender != nil {
+			w.cancelRender()
+		}
 		w.renderMutex.Unlock()
 	} else {
 		// Start a new render asynchronously
@@ -155,102 +483,477 @@ func (w *worker) onMessage(this js.Value, args []js.Value) interface{} {
 	return nil
 }
 
-// computeSphericalCoordinates calculates the spherical coordinates (theta, phi, radius)
-// based on the current camera position relative to its target.
-func (w *worker) computeSphericalCoordinates() {
-	dx := w.camera.LookFrom.X - w.camera.LookAt.X
-	dy := w.camera.LookFrom.Y - w.camera.LookAt.Y
-	dz := w.camera.LookFrom.Z - w.camera.LookAt.Z
-	w.cameraRadius = math.Sqrt(dx*dx + dy*dy + dz*dz)
-	w.cameraTheta = math.Atan2(dz, dx)           // azimuthal angle
-	w.cameraPhi = math.Acos(dy / w.cameraRadius) // polar angle
-}
+// rotatePixelsPerUnit is how many pixels of pointer movement cross the
+// full [-1, 1] span of ArcballController's virtual sphere, so a drag
+// across roughly a third of a typical canvas produces a quarter-turn.
+const rotatePixelsPerUnit = 300
 
-func (w *worker) updateCameraPosition() {
-	x := w.cameraRadius * math.Sin(w.cameraPhi) * math.Cos(w.cameraTheta)
-	y := w.cameraRadius * math.Cos(w.cameraPhi)
-	z := w.cameraRadius * math.Sin(w.cameraPhi) * math.Sin(w.cameraTheta)
-	w.camera.LookFrom = r3.Point{
-		X: w.camera.LookAt.X + x,
-		Y: w.camera.LookAt.Y + y,
-		Z: w.camera.LookAt.Z + z,
-	}
+// panPixelsPerUnit is how many pixels of pointer movement correspond to
+// panning by one full orbit radius, the unit ArcballController.Pan's dx,
+// dy are expressed in.
+const panPixelsPerUnit = 500
+
+// zoomSensitivity scales a "zoomCamera" message's wheel delta into the
+// exponent zoomCamera uses to derive how far to dolly toward the cursor.
+const zoomSensitivity = 0.1
+
+// syncCameraFromArcball copies w.arcball's current frame onto w.camera,
+// the step every arcball-driven camera message ends with since w.camera
+// (not the controller) is what Cast and the renderer actually read.
+func (w *worker) syncCameraFromArcball() {
+	extr := w.arcball.Extrinsics()
+	w.camera.LookFrom = extr.LookFrom
+	w.camera.LookAt = extr.LookAt
+	w.camera.VUp = extr.VUp
 }
 
+// rotateCamera projects the pre- and post-drag pointer positions onto
+// ArcballController's virtual sphere and applies the quaternion rotation
+// between them to LookFrom-LookAt and VUp, replacing the old
+// spherical-coordinate update (which clamped its polar angle to [0.01,
+// pi-0.01] to dodge a pole singularity rotateCamera no longer has).
+// "rotateCamera" messages carry pixel deltas rather than an absolute
+// pointer position, so rotateCamera accumulates them into w.pointerX,
+// w.pointerY before handing the result to Rotate.
 func (w *worker) rotateCamera(dx, dy float64) {
-	const sensitivity = 0.005
-	w.cameraTheta += dx * sensitivity
-	w.cameraPhi -= dy * sensitivity
-	// Clamp phi to avoid gimbal lock.
-	w.cameraPhi = math.Max(0.01, math.Min(math.Pi-0.01, w.cameraPhi))
-	w.updateCameraPosition()
+	w.pointerX = clamp1(w.pointerX + dx/rotatePixelsPerUnit)
+	w.pointerY = clamp1(w.pointerY - dy/rotatePixelsPerUnit)
+	w.arcball.Rotate(w.pointerX, w.pointerY)
+	w.syncCameraFromArcball()
 }
 
-func (w *worker) zoomCamera(delta float64) {
-	prevFOVHeight := w.camera.FOVHeight
-	prevFOVWidth := w.camera.FOVWidth
-	zoomFactor := math.Exp(delta * 0.1) // Exponential zoom for smoother scaling
-	w.camera.FOVHeight = phys.Distance(math.Min(math.Max(0.1, float64(w.camera.FOVHeight)*zoomFactor), 100))
-	w.camera.FOVWidth = phys.Distance(math.Min(math.Max(0.1, float64(w.camera.FOVWidth)*zoomFactor), 100))
-	log.Printf("zoomed camera: %f -> %f, %f -> %f\n", prevFOVHeight, w.camera.FOVHeight, prevFOVWidth, w.camera.FOVWidth)
+// zoomCamera dollies the camera toward the world-space point under the
+// cursor: (x, y), each in [0, 1], is unprojected through the camera's
+// current frustum to a point on its focal plane, and LookFrom/LookAt are
+// each translated a fraction of the way toward it, so zooming in pulls
+// the scene under the cursor toward the viewer instead of the old
+// FOV-rescaling zoom, which always zoomed toward the world origin
+// regardless of where the cursor was.
+func (w *worker) zoomCamera(x, y, delta float64) {
+	zoomFactor := math.Exp(delta * zoomSensitivity)
+	target := w.camera.FocalPlanePoint(x, y)
+	blend := 1 - zoomFactor
+	w.camera.LookFrom = w.camera.LookFrom.Add(target.Sub(w.camera.LookFrom).Muls(blend))
+	w.camera.LookAt = w.camera.LookAt.Add(target.Sub(w.camera.LookAt).Muls(blend))
+	// LookFrom/LookAt moved outside arcball's control, so rebuild it from
+	// the result: the next rotateCamera should orbit around the new
+	// position, not snap back to the one before this zoom.
+	w.arcball = phys.NewArcballController(phys.CameraExtrinsics{LookFrom: w.camera.LookFrom, LookAt: w.camera.LookAt, VUp: w.camera.VUp})
 }
 
+// translateCamera pans LookAt (and with it LookFrom) in the camera's
+// right/up plane, delegating to ArcballController.Pan so panning and
+// rotating share one notion of the camera frame.
 func (w *worker) translateCamera(dx, dy float64) {
-	sensitivity := w.getSensitivity()
-	// Calculate right and up vectors.
-	right := r3.Vec{X: -math.Sin(w.cameraTheta), Y: 0, Z: math.Cos(w.cameraTheta)}
-	up := r3.Vec{X: 0, Y: 1, Z: 0}
-	// Compute translation vector.
-	delta := right.Muls(dx * sensitivity).Add(up.Muls(dy * sensitivity))
-	// Update camera positions.
-	w.camera.LookFrom = w.camera.LookFrom.Add(delta)
-	w.camera.LookAt = w.camera.LookAt.Add(delta)
+	w.arcball.Pan(dx/panPixelsPerUnit, dy/panPixelsPerUnit)
+	w.syncCameraFromArcball()
+}
+
+// clamp1 clamps v to [-1, 1].
+func clamp1(v float64) float64 {
+	return math.Max(-1, math.Min(1, v))
+}
+
+// setAperture sets the lens aperture diameter driving w.camera's
+// depth-of-field blur, clamped to non-negative since FocusableCamera.Validate
+// rejects a negative Aperture.
+func (w *worker) setAperture(aperture float64) {
+	w.camera.Aperture = phys.Distance(math.Max(0, aperture))
+}
+
+// setFocus sets the distance from the camera to its focal plane, clamped
+// away from zero since FocusableCamera.Validate requires WorkingDistance > 0.
+func (w *worker) setFocus(focusDistance float64) {
+	w.camera.WorkingDistance = phys.Distance(math.Max(0.01, focusDistance))
+}
+
+// cameraKeyframeFromCurrent captures w.camera's current pose as an
+// anim.CameraKeyframe at Time t: Orientation comes from
+// phys.QuaternionLookRotation (the same derivation Spline.Cameras uses),
+// and FoV is the vertical field of view angle implied by FOVHeight at
+// WorkingDistance, since FocusableCamera itself stores field of view as a
+// world-space size rather than an angle.
+func (w *worker) cameraKeyframeFromCurrent(t time.Duration) anim.CameraKeyframe {
+	cam := w.camera
+	forward := cam.LookAt.Sub(cam.LookFrom)
+	return anim.CameraKeyframe{
+		Time:        t,
+		Position:    cam.LookFrom,
+		Orientation: phys.QuaternionLookRotation(forward.Unit(), cam.VUp.Unit()),
+		FoV:         phys.Angle(2 * math.Atan2(float64(cam.FOVHeight)/2, float64(cam.WorkingDistance))),
+	}
+}
+
+// recordKeyframe appends the live camera's current pose to
+// w.recordedKeyframes, keyframeSpacing after the previously recorded one
+// (or at Time 0 for the first), ready for a later "playTrack" message with
+// no keyframes of its own to play back.
+func (w *worker) recordKeyframe() {
+	t := time.Duration(len(w.recordedKeyframes)) * keyframeSpacing
+	w.recordedKeyframes = append(w.recordedKeyframes, w.cameraKeyframeFromCurrent(t))
+	log.Printf("recorded keyframe %d at t=%v\n", len(w.recordedKeyframes)-1, t)
+}
+
+// cameraFromKeyframe is cameraKeyframeFromCurrent's inverse: it rebuilds a
+// FocusableCamera pose at k, reusing w.camera's WorkingDistance,
+// Aperture, ApertureShape, and width/height aspect ratio so a keyframe
+// track only ever drives position, orientation, and vertical field of
+// view, the same fields cameraKeyframeFromCurrent captured.
+func (w *worker) cameraFromKeyframe(k anim.CameraKeyframe) phys.FocusableCamera {
+	cam := w.camera
+	rot := k.Orientation.ToRotationMatrix()
+	forward := rot.TransformVec(r3.Vec{X: 0, Y: 0, Z: -1})
+	up := rot.TransformVec(r3.Vec{X: 0, Y: 1, Z: 0})
+	aspect := 1.0
+	if cam.FOVHeight != 0 {
+		aspect = float64(cam.FOVWidth) / float64(cam.FOVHeight)
+	}
+	fovHeight := phys.Distance(2 * float64(cam.WorkingDistance) * math.Tan(k.FoV.Radians()/2))
+	cam.LookFrom = k.Position
+	cam.LookAt = k.Position.Add(forward.Muls(float64(cam.WorkingDistance)))
+	cam.VUp = up
+	cam.FOVHeight = fovHeight
+	cam.FOVWidth = phys.Distance(aspect) * fovHeight
+	return cam
+}
+
+// startPlayback begins a "playTrack" flythrough along keyframes, falling
+// back to w.recordedKeyframes when keyframes is empty. A track with fewer
+// than two keyframes (nothing to interpolate between) is ignored, logged
+// rather than surfaced as an error since there is no caller waiting on a
+// reply to this fire-and-forget message.
+func (w *worker) startPlayback(keyframes []anim.CameraKeyframe) {
+	if len(keyframes) == 0 {
+		keyframes = w.recordedKeyframes
+	}
+	track := anim.CameraTrack{Keyframes: keyframes}
+	if err := track.Validate(); err != nil {
+		log.Printf("playTrack: %v\n", err)
+		return
+	}
+	w.playback = &playbackState{track: track, started: time.Now()}
+	w.playbackTick()
+}
+
+// playbackTick samples w.playback's track at the elapsed time since it
+// started, drives the camera there, renders and streams one frame's
+// tiles via renderTilesProgressive, and reschedules itself via setTimeout
+// every playbackTickIntervalMs until the track's Duration is reached.
+// Mirrors scheduleRender's own setTimeout-recursion, since playback needs
+// to run alongside (not through) the idle-camera render() loop above.
+func (w *worker) playbackTick() {
+	js.Global().Call("setTimeout", js.FuncOf(func(js.Value, []js.Value) interface{} {
+		w.renderMutex.Lock()
+		playback := w.playback
+		if playback == nil {
+			w.renderMutex.Unlock()
+			return nil
+		}
+		elapsed := time.Since(playback.started)
+		frame, err := playback.track.Sample(elapsed)
+		if err != nil {
+			w.playback = nil
+			w.renderMutex.Unlock()
+			log.Printf("playTrack: %v\n", err)
+			return nil
+		}
+		w.camera = w.cameraFromKeyframe(frame)
+		w.arcball = phys.NewArcballController(phys.CameraExtrinsics{LookFrom: w.camera.LookFrom, LookAt: w.camera.LookAt, VUp: w.camera.VUp})
+		sceneCopy := w.scene
+		sceneCopy.Camera = []phys.Camera{w.camera}
+		sceneCopy.RenderOptions.RaysPerPixel = adaptiveRaysPerPixel
+		done := elapsed >= playback.track.Duration()
+		if done {
+			w.playback = nil
+		}
+		w.renderMutex.Unlock()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		if err := w.renderTilesProgressive(ctx, sceneCopy, []int{adaptiveRaysPerPixel}); err != nil {
+			logf("red", "playTrack render: %v", err)
+		}
+		cancel()
+
+		if !done {
+			w.playbackTick()
+		}
+		return nil
+	}), playbackTickIntervalMs)
+}
+
+// initSharedOutput handles the one-time "init" handshake: the main thread
+// may hand over a SharedArrayBuffer-backed Uint8ClampedArray (msg.sharedBuffer)
+// sized for the scene's Dx*Dy*4 RGBA bytes, and/or an OffscreenCanvas
+// (msg.canvas) already transferred to this worker. Either is optional; when
+// absent, postArtifact falls back to its original per-frame allocation and
+// postMessage path.
+func (w *worker) initSharedOutput(msg js.Value) {
+	if shared := msg.Get("sharedBuffer"); !shared.IsUndefined() && !shared.IsNull() {
+		w.sharedPixels = shared
+	}
+	if canvas := msg.Get("canvas"); !canvas.IsUndefined() && !canvas.IsNull() {
+		w.canvasCtx = canvas.Call("getContext", "2d")
+	}
+}
+
+// recordFrameStats appends fs to w.frameStats, trimming the oldest entry
+// once the window exceeds frameStatsWindowSize frames.
+func (w *worker) recordFrameStats(fs phys.FrameStats) {
+	w.frameStats = append(w.frameStats, fs)
+	if len(w.frameStats) > frameStatsWindowSize {
+		w.frameStats = w.frameStats[len(w.frameStats)-frameStatsWindowSize:]
+	}
+}
+
+// setPerfSummary folds w.frameStats's rolling window into the compact
+// avgFrameMs/raysPerSecond/bvhTraversalsPerRay fields a live perf overlay
+// wants, and sets them on obj. A frame's NanosPerPixel is scaled by the
+// image's pixel count (rather than averaging pixel counts across frames,
+// which could mix the previewDx x previewDy preview with full-resolution
+// passes) to get that frame's total time.
+func (w *worker) setPerfSummary(obj js.Value) {
+	if len(w.frameStats) == 0 {
+		return
+	}
+	var totalFrameNanos, totalRays, totalBVHVisits float64
+	for _, fs := range w.frameStats {
+		pixels := float64(fs.SamplesAccumulated) / math.Max(1, float64(w.raysPerPixel))
+		totalFrameNanos += fs.NanosPerPixel * pixels
+		totalRays += float64(fs.RaysCast)
+		totalBVHVisits += float64(fs.BVHNodeVisits)
+	}
+	n := float64(len(w.frameStats))
+	avgFrameNanos := totalFrameNanos / n
+	obj.Set("avgFrameMs", avgFrameNanos/1e6)
+	if avgFrameNanos > 0 {
+		obj.Set("raysPerSecond", totalRays/n/(avgFrameNanos/1e9))
+	} else {
+		obj.Set("raysPerSecond", 0)
+	}
+	if totalRays > 0 {
+		obj.Set("bvhTraversalsPerRay", totalBVHVisits/totalRays)
+	} else {
+		obj.Set("bvhTraversalsPerRay", 0)
+	}
+}
+
+// postArtifact delivers artifact's image to the main thread by the fastest
+// path w.init negotiated:
+//
+//   - If w.canvasCtx is set, the pixels are written into w.sharedPixels (or a
+//     scratch Uint8ClampedArray if no shared buffer was negotiated), painted
+//     directly via putImageData, and only a tiny "frameReady" notification is
+//     posted -- no pixel payload crosses the postMessage boundary at all.
+//   - Else if w.sharedPixels is set, the pixels are copied into that
+//     existing, main-thread-visible buffer via js.CopyBytesToJS and a
+//     "frameReady" notification referencing it is posted, skipping the
+//     per-frame Uint8ClampedArray allocation the non-shared path requires.
+//   - Otherwise (neither negotiated -- the only path today's client actually
+//     uses), a Uint8ClampedArray is taken from w.bufferPool if one of the
+//     right size was returned by an earlier "returnBuffer" message, else
+//     allocated fresh, and its ArrayBuffer is handed to postMessage's
+//     transfer list instead of structured-cloned: the pixel payload moves
+//     across the postMessage boundary rather than being copied.
+func (w *worker) postArtifact(artifact *phys.RenderArtifact) error {
+	bounds := artifact.Image.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	pixelData := artifact.Image.Pix
+
+	w.recordFrameStats(phys.NewFrameStats(artifact.Stats, w.raysPerPixel))
+
+	hasShared := !w.sharedPixels.IsUndefined() && !w.sharedPixels.IsNull()
+	hasCanvas := !w.canvasCtx.IsUndefined() && !w.canvasCtx.IsNull()
+
+	var jsPixelData js.Value
+	switch {
+	case hasShared:
+		jsPixelData = w.sharedPixels
+	case hasCanvas:
+		jsPixelData = js.Global().Get("Uint8ClampedArray").New(len(pixelData))
+	default:
+		jsPixelData = w.takePooledBuffer(len(pixelData))
+	}
+	n := js.CopyBytesToJS(jsPixelData, pixelData)
+	if n != len(pixelData) {
+		return fmt.Errorf("copying pixel data failed: %d != %d", n, len(pixelData))
+	}
+
+	if hasCanvas {
+		imageData := js.Global().Get("ImageData").New(jsPixelData, width, height)
+		w.canvasCtx.Call("putImageData", imageData, 0, 0)
+		notification := js.Global().Get("Object").New()
+		notification.Set("type", "frameReady")
+		notification.Set("width", width)
+		notification.Set("height", height)
+		w.setPerfSummary(notification)
+		js.Global().Call("postMessage", notification)
+		return nil
+	}
+
+	result := js.Global().Get("Object").New()
+	result.Set("width", width)
+	result.Set("height", height)
+	result.Set("pixelData", jsPixelData)
+	w.setPerfSummary(result)
+	if hasShared {
+		result.Set("type", "frameReady")
+		js.Global().Call("postMessage", result)
+		return nil
+	}
+	// Transfer jsPixelData's backing ArrayBuffer instead of letting
+	// postMessage structured-clone (copy) it. The main thread is expected to
+	// hand it back via a "returnBuffer" message once it's done reading the
+	// pixels (see onWorkerMessage), so takePooledBuffer can reuse it on a
+	// later frame instead of allocating.
+	transferList := js.Global().Get("Array").New(jsPixelData.Get("buffer"))
+	js.Global().Call("postMessage", result, transferList)
+	return nil
+}
+
+// takePooledBuffer returns a Uint8ClampedArray view over an ArrayBuffer of
+// exactly n bytes: a same-size buffer popped from w.bufferPool if one is
+// available, or a freshly allocated one otherwise.
+func (w *worker) takePooledBuffer(n int) js.Value {
+	for i, buf := range w.bufferPool {
+		if buf.Get("byteLength").Int() == n {
+			w.bufferPool = append(w.bufferPool[:i], w.bufferPool[i+1:]...)
+			return js.Global().Get("Uint8ClampedArray").New(buf)
+		}
+	}
+	return js.Global().Get("Uint8ClampedArray").New(n)
+}
+
+// postTile delivers one finished tile to the main thread as a
+// {type:"tile", x, y, w, h, sampleCount, pixelData} message, with
+// pixelData's ArrayBuffer handed to postMessage's transfer list the same
+// way postArtifact's fallback path transfers a full frame (see
+// takePooledBuffer/returnBuffer -- tiles and full frames share w.bufferPool,
+// so it ends up holding whichever size is returned most often). The main
+// thread's "tile" case is expected to putImageData at (x, y) rather than
+// (0, 0), compositing each tile into the canvas as it arrives.
+func (w *worker) postTile(res phys.TileResult) error {
+	rgba, ok := res.Image.(*image.RGBA)
+	if !ok {
+		return fmt.Errorf("postTile: TileResult.Image is %T, want *image.RGBA", res.Image)
+	}
+	bounds := rgba.Bounds()
+	pixelData := make([]byte, res.W*res.H*4)
+	for row := 0; row < res.H; row++ {
+		srcStart := rgba.PixOffset(bounds.Min.X, bounds.Min.Y+row)
+		copy(pixelData[row*res.W*4:(row+1)*res.W*4], rgba.Pix[srcStart:srcStart+res.W*4])
+	}
+
+	jsPixelData := w.takePooledBuffer(len(pixelData))
+	n := js.CopyBytesToJS(jsPixelData, pixelData)
+	if n != len(pixelData) {
+		return fmt.Errorf("postTile: copying pixel data failed: %d != %d", n, len(pixelData))
+	}
+
+	result := js.Global().Get("Object").New()
+	result.Set("type", "tile")
+	result.Set("x", res.X)
+	result.Set("y", res.Y)
+	result.Set("w", res.W)
+	result.Set("h", res.H)
+	result.Set("sampleCount", res.SampleIndex)
+	result.Set("pixelData", jsPixelData)
+	transferList := js.Global().Get("Array").New(jsPixelData.Get("buffer"))
+	js.Global().Call("postMessage", result, transferList)
+	return nil
 }
 
-func (w *worker) getSensitivity() float64 {
-	return float64(w.camera.FOVHeight) * 0.001 // Adjust the multiplier as needed
+// renderTilesProgressive works through levels of increasing RaysPerPixel,
+// each a complete phys.RenderTiled call over base's scene/camera ordered
+// along a Hilbert curve, streaming every tile via postTile as soon as it
+// finishes. A cancelled ctx (a new camera message arriving mid-level; see
+// onMessage) stops RenderTiled after its in-flight tiles, and is reported
+// back as ctx.Err() rather than an error render() needs to log.
+func (w *worker) renderTilesProgressive(ctx context.Context, base phys.Scene, levels []int) error {
+	for _, raysPerPixel := range levels {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		sceneCopy := base
+		sceneCopy.RenderOptions.RaysPerPixel = raysPerPixel
+		sceneCopy.RenderOptions.TileOrder = phys.TileOrderHilbert
+		sceneCopy.RenderOptions.TileSize = tileStreamSize
+		w.raysPerPixel = raysPerPixel
+		artifact, err := phys.RenderTiled(ctx, &sceneCopy, w.postTile)
+		if err != nil {
+			return err
+		}
+		w.recordFrameStats(phys.NewFrameStats(artifact.Stats, raysPerPixel))
+	}
+	return nil
 }
 
+// render renders the current camera/scene progressively: first a cheap
+// previewDx x previewDy preview posted immediately, then the
+// full-resolution stage. denoiseMode off streams every tile of each
+// adaptiveQualityLevels level as it finishes (see renderTilesProgressive),
+// so orbiting the camera sees tiles filling in right away instead of
+// waiting for a whole frame; denoiseMode on instead posts successive
+// whole-frame OnPass updates as before, since Denoise's À-Trous pass only
+// runs once over the whole image after every tile has already finished --
+// streaming tiles during a denoised render would only show their noisy,
+// pre-denoise pixels, never the denoised result OnTile has no way to see.
+// onMessage's cancelRender aborts the full-resolution render as soon as a
+// new camera event arrives; render() then starts over from the new camera
+// instead of finishing a refinement nobody will see.
 func (w *worker) render() {
 	for {
 		// Prepare the scene data.
 		w.renderMutex.Lock()
 		sceneCopy := w.scene // Make a copy of the scene to work with
 		sceneCopy.Camera = []phys.Camera{w.camera}
+		denoise := w.denoiseMode
+		if denoise {
+			sceneCopy.RenderOptions.RaysPerPixel = denoiseRaysPerPixel
+			sceneCopy.RenderOptions.AuxBuffers = true
+			sceneCopy.RenderOptions.Denoise = true
+		} else {
+			sceneCopy.RenderOptions.RaysPerPixel = adaptiveRaysPerPixel
+			sceneCopy.RenderOptions.AdaptiveSampling = true
+			sceneCopy.RenderOptions.EarlyStopVariance = adaptiveEarlyStopVariance
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		w.cancelRender = cancel
 		w.renderMutex.Unlock()
 
-		// Render the scene.
-		artifact, err := phys.Render(context.Background(), &sceneCopy)
-		if err != nil {
-			logf("red", "phys.Render error: %v", err)
-			w.renderMutex.Lock()
-			w.isRendering = false
-			w.renderMutex.Unlock()
-			return
+		preview := sceneCopy
+		preview.RenderOptions.Dx = previewDx
+		preview.RenderOptions.Dy = previewDy
+		preview.RenderOptions.RaysPerPixel = previewRaysPerPixel
+		preview.RenderOptions.AdaptiveSampling = false
+		preview.RenderOptions.AuxBuffers = false
+		preview.RenderOptions.Denoise = false
+		w.raysPerPixel = previewRaysPerPixel
+		if previewArtifact, err := phys.Render(ctx, &preview); err == nil {
+			if err := w.postArtifact(&previewArtifact); err != nil {
+				logf("red", "postArtifact (preview): %v", err)
+			}
 		}
 
-		// Convert the image to a format that can be sent back to the main thread.
-		bounds := artifact.Image.Bounds()
-		width, height := bounds.Dx(), bounds.Dy()
-		pixelData := artifact.Image.Pix
-		jsPixelData := js.Global().Get("Uint8ClampedArray").New(len(pixelData))
-		n := js.CopyBytesToJS(jsPixelData, pixelData)
-		if n != len(pixelData) {
-			logf("red", "copying pixel data failed: %d != %d", n, len(pixelData))
-			w.renderMutex.Lock()
-			w.isRendering = false
-			w.renderMutex.Unlock()
-			return
+		var err error
+		if denoise {
+			sceneCopy.RenderOptions.SamplesPerPass = progressiveSamplesPerPass
+			w.raysPerPixel = sceneCopy.RenderOptions.RaysPerPixel
+			sceneCopy.RenderOptions.OnPass = func(pass int, partial *phys.RenderArtifact) error {
+				return w.postArtifact(partial)
+			}
+			_, err = phys.Render(ctx, &sceneCopy)
+		} else {
+			err = w.renderTilesProgressive(ctx, sceneCopy, adaptiveQualityLevels)
+		}
+		cancel()
+		if err != nil && ctx.Err() == nil {
+			// A real render failure, not this render being superseded by
+			// cancelRender -- ctx.Err() is only non-nil in the latter case.
+			logf("red", "phys.Render error: %v", err)
 		}
-
-		// Send result to main thread
-		result := js.Global().Get("Object").New()
-		result.Set("width", width)
-		result.Set("height", height)
-		result.Set("pixelData", jsPixelData)
-		js.Global().Call("postMessage", result)
 
 		w.renderMutex.Lock()
+		w.cancelRender = nil
 		if w.renderDirty {
 			// Reset the dirty flag and continue the loop to render again
 			w.renderDirty = false
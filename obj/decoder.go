@@ -0,0 +1,243 @@
+package obj
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// EventType identifies which OBJ directive an Event represents.
+type EventType int
+
+const (
+	VertexEvent EventType = iota
+	TexCoordEvent
+	NormalEvent
+	FaceEvent
+	UseMTLEvent
+	MTLLibEvent
+	GroupEvent
+	ObjectEvent
+	SmoothingEvent
+	CommentEvent
+	UnknownEvent
+)
+
+// String returns the directive name associated with t, e.g. "v" for
+// VertexEvent or "usemtl" for UseMTLEvent.
+func (t EventType) String() string {
+	switch t {
+	case VertexEvent:
+		return "v"
+	case TexCoordEvent:
+		return "vt"
+	case NormalEvent:
+		return "vn"
+	case FaceEvent:
+		return "f"
+	case UseMTLEvent:
+		return "usemtl"
+	case MTLLibEvent:
+		return "mtllib"
+	case GroupEvent:
+		return "g"
+	case ObjectEvent:
+		return "o"
+	case SmoothingEvent:
+		return "s"
+	case CommentEvent:
+		return "#"
+	default:
+		return "unknown"
+	}
+}
+
+// Event is one parsed OBJ directive, emitted by Decoder.Next in file
+// order. Only the fields relevant to Type are populated. Decoder does no
+// cross-line bookkeeping -- no running material/group/smoothing state,
+// no index resolution against vertex counts -- that's left to a consumer
+// like ParseFS. This mirrors the "raw" event style obj-rs uses, so a
+// caller can stream a multi-gigabyte OBJ file (city models, scanned
+// meshes) a directive at a time without ParseFS's Object ever holding
+// the whole mesh in memory.
+type Event struct {
+	Type EventType
+
+	Vertex   Vertex   // VertexEvent
+	TexCoord TexCoord // TexCoordEvent
+	Normal   Normal   // NormalEvent
+
+	// FaceTokens holds one raw index token per face vertex -- "v",
+	// "v/vt", "v//vn", or "v/vt/vn" -- exactly as written, 1-based or
+	// negative and unresolved. FaceEvent only.
+	FaceTokens []string
+
+	UseMTL string   // UseMTLEvent: material name.
+	MTLLib []string // MTLLibEvent: one or more filenames.
+
+	Groups []string // GroupEvent: names from a `g` line (nil for a bare `g`).
+	Object string   // ObjectEvent: name from an `o` line.
+
+	Smoothing int // SmoothingEvent: 0 for "s off"/"s 0", otherwise the group id.
+
+	// Raw is the original source line, verbatim, for every event type --
+	// not just CommentEvent/UnknownEvent -- so a caller that needs exact
+	// text (or wants to round-trip an unrecognized directive it doesn't
+	// understand) never has to re-read the file.
+	Raw string
+
+	// Line is the 1-based source line number, for error messages.
+	Line int
+}
+
+// Decoder reads directives from an OBJ file one line at a time and
+// delivers them as Events, without materializing vertices/faces into an
+// Object the way ParseFS does. Use it to stream huge OBJ files, filter or
+// transform directives on the fly, or preserve directives ParseFS would
+// otherwise drop (CommentEvent, UnknownEvent).
+type Decoder struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+// NewDecoder returns a Decoder reading directives from r.
+func NewDecoder(r io.Reader) *Decoder {
+	scanner := bufio.NewScanner(r)
+	const maxCapacity = 10 * 1024 * 1024 // Accommodate very long face lines.
+	buf := make([]byte, 0, 64*1024)
+	scanner.Buffer(buf, maxCapacity)
+	return &Decoder{scanner: scanner}
+}
+
+// Next returns the next Event, or io.EOF once the input is exhausted.
+func (d *Decoder) Next() (Event, error) {
+	for d.scanner.Scan() {
+		d.line++
+		line := strings.TrimSpace(d.scanner.Text())
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			return Event{Type: CommentEvent, Raw: line, Line: d.line}, nil
+		}
+		firstSpace := strings.IndexByte(line, ' ')
+		var directive, rest string
+		if firstSpace == -1 {
+			directive, rest = line, ""
+		} else {
+			directive, rest = line[:firstSpace], line[firstSpace+1:]
+		}
+		switch directive {
+		case "v":
+			fields := splitFields(rest, 3)
+			if len(fields) < 3 {
+				return Event{}, d.errorf(line, "invalid vertex data: expected at least 3 components, got %d", len(fields))
+			}
+			x, y, z, err := parseFloat3(fields)
+			if err != nil {
+				return Event{}, d.errorf(line, "invalid vertex data: %v", err)
+			}
+			return Event{Type: VertexEvent, Vertex: Vertex{X: x, Y: y, Z: z}, Raw: line, Line: d.line}, nil
+		case "vt":
+			fields := splitFields(rest, 2)
+			if len(fields) < 2 {
+				return Event{}, d.errorf(line, "invalid texture coordinate data: expected at least 2 components, got %d", len(fields))
+			}
+			u, err := strconv.ParseFloat(fields[0], 64)
+			if err != nil {
+				return Event{}, d.errorf(line, "invalid texture U coordinate: %v", err)
+			}
+			v, err := strconv.ParseFloat(fields[1], 64)
+			if err != nil {
+				return Event{}, d.errorf(line, "invalid texture V coordinate: %v", err)
+			}
+			return Event{Type: TexCoordEvent, TexCoord: TexCoord{U: u, V: v}, Raw: line, Line: d.line}, nil
+		case "vn":
+			fields := splitFields(rest, 3)
+			if len(fields) < 3 {
+				return Event{}, d.errorf(line, "invalid normal data: expected at least 3 components, got %d", len(fields))
+			}
+			x, y, z, err := parseFloat3(fields)
+			if err != nil {
+				return Event{}, d.errorf(line, "invalid normal data: %v", err)
+			}
+			return Event{Type: NormalEvent, Normal: Normal{X: x, Y: y, Z: z}, Raw: line, Line: d.line}, nil
+		case "f":
+			parts := splitFields(rest, -1)
+			if len(parts) < 3 {
+				return Event{}, d.errorf(line, "face definition error: a face must have at least 3 vertices, got %d", len(parts))
+			}
+			return Event{Type: FaceEvent, FaceTokens: parts, Raw: line, Line: d.line}, nil
+		case "usemtl":
+			return Event{Type: UseMTLEvent, UseMTL: rest, Raw: line, Line: d.line}, nil
+		case "mtllib":
+			return Event{Type: MTLLibEvent, MTLLib: strings.Fields(rest), Raw: line, Line: d.line}, nil
+		case "g":
+			return Event{Type: GroupEvent, Groups: strings.Fields(rest), Raw: line, Line: d.line}, nil
+		case "o":
+			return Event{Type: ObjectEvent, Object: strings.TrimSpace(rest), Raw: line, Line: d.line}, nil
+		case "s":
+			smoothing := 0
+			if rest != "off" {
+				n, err := strconv.Atoi(rest)
+				if err != nil {
+					return Event{}, d.errorf(line, "smoothing group directive error: %v", err)
+				}
+				smoothing = n
+			}
+			return Event{Type: SmoothingEvent, Smoothing: smoothing, Raw: line, Line: d.line}, nil
+		default:
+			return Event{Type: UnknownEvent, Raw: line, Line: d.line}, nil
+		}
+	}
+	if err := d.scanner.Err(); err != nil {
+		return Event{}, err
+	}
+	return Event{}, io.EOF
+}
+
+// errorf builds a *ParseError for line at the Decoder's current position.
+// Filename is left blank; a caller that knows the source name (e.g.
+// ParseFS) fills it in.
+func (d *Decoder) errorf(line, format string, args ...interface{}) *ParseError {
+	return &ParseError{Line: d.line, LineText: line, Msg: fmt.Sprintf(format, args...)}
+}
+
+// parseFloat3 parses the first three entries of fields as float64s, for
+// the "v"/"vn" directives that share the same x/y/z shape.
+func parseFloat3(fields []string) (x, y, z float64, err error) {
+	x, err = strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	y, err = strconv.ParseFloat(fields[1], 64)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	z, err = strconv.ParseFloat(fields[2], 64)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	return x, y, z, nil
+}
+
+// Walk calls fn with every Event NewDecoder(r) produces, in file order,
+// stopping at the first error either the Decoder or fn returns. Reaching
+// the end of r is not an error: Walk returns nil.
+func Walk(r io.Reader, fn func(Event) error) error {
+	d := NewDecoder(r)
+	for {
+		ev, err := d.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(ev); err != nil {
+			return err
+		}
+	}
+}
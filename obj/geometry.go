@@ -0,0 +1,225 @@
+package obj
+
+import (
+	"fmt"
+	"math"
+)
+
+// Tangent is a MikkTSpace-style per-vertex tangent: X/Y/Z is the tangent
+// vector and W is the handedness sign (+1 or -1), from which a shader
+// reconstructs the bitangent as cross(normal, tangent.xyz) * W rather than
+// storing a redundant bitangent of its own.
+type Tangent struct {
+	X, Y, Z, W float64
+}
+
+// Bounds returns the axis-aligned bounding box of o's vertices: min and
+// max hold the smallest and largest X/Y/Z across all of them. Both are the
+// zero Vertex if o has no vertices.
+func (o *Object) Bounds() (min, max Vertex) {
+	if len(o.Vertices) == 0 {
+		return Vertex{}, Vertex{}
+	}
+	min, max = o.Vertices[0], o.Vertices[0]
+	for _, v := range o.Vertices[1:] {
+		min.X, max.X = math.Min(min.X, v.X), math.Max(max.X, v.X)
+		min.Y, max.Y = math.Min(min.Y, v.Y), math.Max(max.Y, v.Y)
+		min.Z, max.Z = math.Min(min.Z, v.Z), math.Max(max.Z, v.Z)
+	}
+	return min, max
+}
+
+// GenerateNormals fills in Normals and rewrites Face.Indices[i].Normal for
+// every face-vertex that has no explicit vn of its own, leaving any vertex
+// that already carries one untouched. A generated normal is the
+// area-weighted average of the flat normals of every face sharing that
+// vertex whose angle to the face being processed is at most angle radians
+// -- faces on the far side of a sharper crease than that don't contribute,
+// so the vertex is effectively split into independently-shaded copies the
+// way Assimp and Blender's own "smoothing angle" import option does,
+// without actually duplicating the Vertices entry.
+//
+// Unlike computeSmoothNormals (which only fills gaps within s-directive
+// groups ParseFS already tracked), GenerateNormals ignores SmoothingGroup
+// entirely and rebuilds normals purely from angle, for callers working
+// with geometry that never set `s` at all.
+func (o *Object) GenerateNormals(angle float64) {
+	faceNormals := make([]Normal, len(o.Faces)) // Raw (unnormalized) flat normal per face.
+	faceUnits := make([]Normal, len(o.Faces))   // Unit flat normal per face, for angle comparisons.
+	faceOK := make([]bool, len(o.Faces))
+	vertexFaces := make(map[int][]int) // Vertex index -> faces touching it.
+	for fi, f := range o.Faces {
+		if len(f.Indices) < 3 {
+			continue
+		}
+		n, ok := faceNormal(o, f)
+		if !ok {
+			continue
+		}
+		faceNormals[fi] = n
+		unit, ok := normalizeNormalVec(n)
+		if !ok {
+			continue
+		}
+		faceUnits[fi] = unit
+		faceOK[fi] = true
+		for _, idx := range f.Indices {
+			vertexFaces[idx.Vertex] = append(vertexFaces[idx.Vertex], fi)
+		}
+	}
+	cosThreshold := math.Cos(angle)
+	for fi := range o.Faces {
+		if !faceOK[fi] {
+			continue
+		}
+		f := &o.Faces[fi]
+		for vi := range f.Indices {
+			if f.Indices[vi].Normal != 0 {
+				continue // Explicit vn already set.
+			}
+			var sum Normal
+			for _, ofi := range vertexFaces[f.Indices[vi].Vertex] {
+				if !faceOK[ofi] {
+					continue
+				}
+				if dotNormal(faceUnits[fi], faceUnits[ofi]) < cosThreshold {
+					continue // Beyond the crease angle: a separate shading normal.
+				}
+				sum = addNormal(sum, faceNormals[ofi])
+			}
+			unit, ok := normalizeNormalVec(sum)
+			if !ok {
+				continue
+			}
+			o.Normals = append(o.Normals, unit)
+			f.Indices[vi].Normal = len(o.Normals)
+		}
+	}
+}
+
+// GenerateTangents computes a MikkTSpace-style tangent for every
+// face-vertex that has texture coordinates, using the standard deltaUV
+// derivation (Lengyel's method) on each face's first three indices, then
+// accumulating and orthogonalizing per vertex the same way GenerateNormals
+// accumulates per-face contributions. It requires o.TexCoords to be
+// populated and returns an error otherwise.
+func (o *Object) GenerateTangents() error {
+	if len(o.TexCoords) == 0 {
+		return fmt.Errorf("obj: GenerateTangents requires texture coordinates")
+	}
+
+	vertexNormal := make(map[int]Normal)
+	type accum struct{ tangent, bitangent Normal }
+	sums := make(map[int]accum)
+
+	for _, f := range o.Faces {
+		if len(f.Indices) < 3 {
+			continue
+		}
+		for _, idx := range f.Indices {
+			if _, ok := vertexNormal[idx.Vertex]; ok {
+				continue
+			}
+			if idx.Normal != 0 {
+				vertexNormal[idx.Vertex] = o.Normals[idx.Normal-1]
+				continue
+			}
+			if n, ok := faceNormal(o, f); ok {
+				if unit, ok := normalizeNormalVec(n); ok {
+					vertexNormal[idx.Vertex] = unit
+				}
+			}
+		}
+
+		a, b, c := f.Indices[0], f.Indices[1], f.Indices[2]
+		if a.TexCoord == 0 || b.TexCoord == 0 || c.TexCoord == 0 {
+			continue // No UVs on this face: nothing to derive a tangent from.
+		}
+		p0, p1, p2 := o.Vertices[a.Vertex-1], o.Vertices[b.Vertex-1], o.Vertices[c.Vertex-1]
+		uv0, uv1, uv2 := o.TexCoords[a.TexCoord-1], o.TexCoords[b.TexCoord-1], o.TexCoords[c.TexCoord-1]
+
+		edge1 := Normal{X: p1.X - p0.X, Y: p1.Y - p0.Y, Z: p1.Z - p0.Z}
+		edge2 := Normal{X: p2.X - p0.X, Y: p2.Y - p0.Y, Z: p2.Z - p0.Z}
+		du1, dv1 := uv1.U-uv0.U, uv1.V-uv0.V
+		du2, dv2 := uv2.U-uv0.U, uv2.V-uv0.V
+		denom := du1*dv2 - du2*dv1
+		if denom == 0 {
+			continue // Degenerate UV mapping: no well-defined tangent frame.
+		}
+		r := 1 / denom
+		tangent := Normal{
+			X: r * (dv2*edge1.X - dv1*edge2.X),
+			Y: r * (dv2*edge1.Y - dv1*edge2.Y),
+			Z: r * (dv2*edge1.Z - dv1*edge2.Z),
+		}
+		bitangent := Normal{
+			X: r * (du1*edge2.X - du2*edge1.X),
+			Y: r * (du1*edge2.Y - du2*edge1.Y),
+			Z: r * (du1*edge2.Z - du2*edge1.Z),
+		}
+		for _, idx := range []Index{a, b, c} {
+			s := sums[idx.Vertex]
+			s.tangent = addNormal(s.tangent, tangent)
+			s.bitangent = addNormal(s.bitangent, bitangent)
+			sums[idx.Vertex] = s
+		}
+	}
+
+	tangentIndex := make(map[int]int, len(sums))
+	for vertex, s := range sums {
+		n := vertexNormal[vertex]
+		// Gram-Schmidt: project the accumulated tangent onto the plane
+		// perpendicular to the normal, so it stays orthogonal to it.
+		t := addNormal(s.tangent, scaleNormal(n, -dotNormal(n, s.tangent)))
+		unit, ok := normalizeNormalVec(t)
+		if !ok {
+			continue
+		}
+		w := 1.0
+		if dotNormal(crossNormal(n, unit), s.bitangent) < 0 {
+			w = -1.0
+		}
+		o.Tangents = append(o.Tangents, Tangent{X: unit.X, Y: unit.Y, Z: unit.Z, W: w})
+		tangentIndex[vertex] = len(o.Tangents)
+	}
+
+	for fi := range o.Faces {
+		f := &o.Faces[fi]
+		for vi := range f.Indices {
+			if ti, ok := tangentIndex[f.Indices[vi].Vertex]; ok {
+				f.Indices[vi].Tangent = ti
+			}
+		}
+	}
+	return nil
+}
+
+func addNormal(a, b Normal) Normal {
+	return Normal{X: a.X + b.X, Y: a.Y + b.Y, Z: a.Z + b.Z}
+}
+
+func scaleNormal(a Normal, s float64) Normal {
+	return Normal{X: a.X * s, Y: a.Y * s, Z: a.Z * s}
+}
+
+func dotNormal(a, b Normal) float64 {
+	return a.X*b.X + a.Y*b.Y + a.Z*b.Z
+}
+
+func crossNormal(a, b Normal) Normal {
+	return Normal{
+		X: a.Y*b.Z - a.Z*b.Y,
+		Y: a.Z*b.X - a.X*b.Z,
+		Z: a.X*b.Y - a.Y*b.X,
+	}
+}
+
+// normalizeNormalVec returns a unit-length copy of n, and false if n is
+// the zero vector and so has no well-defined direction.
+func normalizeNormalVec(n Normal) (Normal, bool) {
+	length := math.Sqrt(dotNormal(n, n))
+	if length == 0 {
+		return Normal{}, false
+	}
+	return Normal{X: n.X / length, Y: n.Y / length, Z: n.Z / length}, true
+}
@@ -0,0 +1,155 @@
+package obj
+
+import "math"
+
+// MeshVertex is a fused vertex -- position, texture coordinate, and normal
+// -- of the kind a GPU vertex buffer or BVH builder expects, as opposed to
+// Index's three separate, independently-shared slice references.
+type MeshVertex struct {
+	Pos    Vertex
+	UV     TexCoord
+	Normal Normal
+}
+
+// IndexedMesh is a renderer-ready, deduplicated view of an Object: a flat
+// MeshVertex buffer plus, per material, a triangle index buffer into it.
+// Unlike Object's Faces (which may be n-gons and reference positions,
+// UVs, and normals through three independent index spaces), every
+// IndexedMesh triangle is three uint32s into the same Vertices slice, the
+// layout a GPU upload or this repo's BVH builder wants.
+type IndexedMesh struct {
+	Vertices []MeshVertex
+
+	// Indices holds one flat triangle-index buffer per material name, so
+	// a renderer can issue one draw call per material without re-sorting
+	// triangles itself. The empty string is the key for faces with no
+	// usemtl.
+	Indices map[string][]uint32
+}
+
+// ToIndexedMesh triangulates o with fan triangulation (see Triangulate)
+// and flattens the result into an IndexedMesh: each distinct
+// (Vertex, TexCoord, Normal) index triplet becomes one MeshVertex,
+// deduplicated via a map[Index]uint32 so a position reused across faces
+// with identical UV/normal isn't duplicated in the output buffer. Faces
+// with differing materials land in separate Indices buffers.
+func (o *Object) ToIndexedMesh() *IndexedMesh {
+	tri := o.Triangulate()
+	mesh := &IndexedMesh{Indices: make(map[string][]uint32)}
+	seen := make(map[Index]uint32)
+	for _, f := range tri.Faces {
+		buf := mesh.Indices[f.Material]
+		for _, idx := range f.Indices {
+			vi, ok := seen[idx]
+			if !ok {
+				vi = uint32(len(mesh.Vertices))
+				seen[idx] = vi
+				mesh.Vertices = append(mesh.Vertices, tri.meshVertexAt(idx))
+			}
+			buf = append(buf, vi)
+		}
+		mesh.Indices[f.Material] = buf
+	}
+	return mesh
+}
+
+// meshVertexAt resolves idx's Vertex/TexCoord/Normal references into a
+// single fused MeshVertex; TexCoord and Normal are left zero if idx didn't
+// specify one.
+func (o *Object) meshVertexAt(idx Index) MeshVertex {
+	v := MeshVertex{Pos: o.Vertices[idx.Vertex-1]}
+	if idx.TexCoord != 0 {
+		v.UV = o.TexCoords[idx.TexCoord-1]
+	}
+	if idx.Normal != 0 {
+		v.Normal = o.Normals[idx.Normal-1]
+	}
+	return v
+}
+
+// Weld merges vertices within epsilon of each other, remapping every
+// Face.Indices[i].Vertex onto the representative of its cluster and
+// dropping now-unreferenced entries from Vertices. It's useful for
+// cleaning up scanned meshes, whose independently-triangulated positions
+// rarely land on exactly the same float64 bit pattern even where they're
+// meant to coincide.
+//
+// Clustering uses a spatial hash keyed on floor(pos/epsilon) rather than
+// an O(n^2) all-pairs comparison, trading a rare missed merge across a
+// bucket boundary for linear-time behavior on large meshes.
+func (o *Object) Weld(epsilon float64) {
+	if epsilon <= 0 || len(o.Vertices) == 0 {
+		return
+	}
+	type cell struct{ x, y, z int64 }
+	key := func(v Vertex) cell {
+		return cell{
+			x: int64(math.Floor(v.X / epsilon)),
+			y: int64(math.Floor(v.Y / epsilon)),
+			z: int64(math.Floor(v.Z / epsilon)),
+		}
+	}
+	buckets := make(map[cell][]int) // cell -> representative old vertex indices (0-based) already placed there.
+	remap := make([]int, len(o.Vertices))
+	welded := make([]Vertex, 0, len(o.Vertices))
+	for i, v := range o.Vertices {
+		c := key(v)
+		merged := false
+		for _, rep := range buckets[c] {
+			if distance(v, welded[rep]) <= epsilon {
+				remap[i] = rep
+				merged = true
+				break
+			}
+		}
+		if !merged {
+			rep := len(welded)
+			welded = append(welded, v)
+			buckets[c] = append(buckets[c], rep)
+			remap[i] = rep
+		}
+	}
+	o.Vertices = welded
+	for fi := range o.Faces {
+		for vi := range o.Faces[fi].Indices {
+			old := o.Faces[fi].Indices[vi].Vertex
+			o.Faces[fi].Indices[vi].Vertex = remap[old-1] + 1
+		}
+	}
+}
+
+// distance returns the Euclidean distance between two positions.
+func distance(a, b Vertex) float64 {
+	dx, dy, dz := a.X-b.X, a.Y-b.Y, a.Z-b.Z
+	return math.Sqrt(dx*dx + dy*dy + dz*dz)
+}
+
+// Stats summarizes an Object's geometry for judging how much an
+// optimisation pass (Triangulate, Weld, ToIndexedMesh) helped, or whether
+// the source data has problems (e.g. degenerate triangles) worth fixing
+// upstream.
+type Stats struct {
+	VertexCount     int            // len(o.Vertices).
+	FaceVertexCount int            // Total Index entries across all Faces, i.e. the unwelded/undeduplicated vertex count a naive export would emit.
+	DegenerateFaces int            // Faces whose vertices are collinear or coincident, so faceNormal found no well-defined normal.
+	FacesByMaterial map[string]int // Face count per Material name ("" for faces with no usemtl).
+}
+
+// Stats reports summary geometry statistics for o. See Stats for field
+// meanings.
+func (o *Object) Stats() Stats {
+	s := Stats{
+		VertexCount:     len(o.Vertices),
+		FacesByMaterial: make(map[string]int),
+	}
+	for _, f := range o.Faces {
+		s.FaceVertexCount += len(f.Indices)
+		s.FacesByMaterial[f.Material]++
+		if len(f.Indices) >= 3 {
+			if _, ok := faceNormal(o, f); !ok {
+				s.DegenerateFaces++
+			}
+		}
+	}
+	return s
+}
@@ -0,0 +1,141 @@
+package obj
+
+// NormalMode selects the normal-generation strategy GenerateNormalsMode
+// uses to populate Normals and every Face.Indices[i].Normal. Unlike
+// GenerateNormals (which only fills a gap an explicit vn left unset),
+// GenerateNormalsMode always overwrites: a caller picking a mode wants it
+// authoritative over whatever normal references were already present.
+type NormalMode int
+
+const (
+	// FlatPerFace gives every face its own, unshared normal -- no vertex
+	// is ever averaged across faces -- producing the faceted look of a
+	// low-poly mesh or one imported with no smoothing at all.
+	FlatPerFace NormalMode = iota
+
+	// SmoothAll averages face normals across every face sharing a
+	// vertex, regardless of Face.SmoothingGroup, for a fully
+	// smooth-shaded mesh.
+	SmoothAll
+
+	// SmoothByGroup averages face normals across faces sharing both a
+	// vertex and a SmoothingGroup id, matching Blender/Maya's own OBJ
+	// import behavior: a face with SmoothingGroup 0 ("s off") is always
+	// flat, never blended with its neighbors.
+	SmoothByGroup
+)
+
+// smoothKey identifies one (smoothing group, vertex position) pair for
+// generateSmoothNormals' accumulator, the same shape computeSmoothNormals
+// uses via smoothGroupVertex, but local since SmoothAll collapses every
+// face to group 0 rather than using its real SmoothingGroup.
+type smoothKey struct {
+	group  int
+	vertex int
+}
+
+// GenerateNormalsMode (re)generates every face-vertex's normal according
+// to mode, discarding Normals and rebuilding every Face.Indices[i].Normal
+// from scratch -- including references to a vn ParseFS already set. Use
+// GenerateNormals instead for a crease-angle-driven, gap-filling pass
+// that leaves explicit vn data untouched.
+func (o *Object) GenerateNormalsMode(mode NormalMode) {
+	switch mode {
+	case FlatPerFace:
+		o.generateFlatNormals()
+	case SmoothAll:
+		o.generateSmoothNormalsBy(func(Face) int { return 0 }, false)
+	case SmoothByGroup:
+		o.generateSmoothNormalsBy(func(f Face) int { return f.SmoothingGroup }, true)
+	}
+}
+
+// generateFlatNormals assigns each face its own flat normal, shared by
+// none of its neighbors.
+func (o *Object) generateFlatNormals() {
+	o.Normals = nil
+	for i := range o.Faces {
+		f := &o.Faces[i]
+		if len(f.Indices) < 3 {
+			continue
+		}
+		n, ok := faceNormal(o, *f)
+		if !ok {
+			continue
+		}
+		unit, ok := normalizeNormalVec(n)
+		if !ok {
+			continue
+		}
+		o.Normals = append(o.Normals, unit)
+		ni := len(o.Normals)
+		for j := range f.Indices {
+			f.Indices[j].Normal = ni
+		}
+	}
+}
+
+// generateSmoothNormalsBy area-weight-averages face normals across every
+// face sharing a vertex and the same groupOf(face) value, the shared
+// implementation behind SmoothAll (groupOf always 0, zeroIsFlat false)
+// and SmoothByGroup (groupOf returns Face.SmoothingGroup, zeroIsFlat
+// true). When zeroIsFlat is set, a face with groupOf(f) == 0 -- "s off"
+// -- gets its own unshared flat normal instead of being blended with
+// other group-0 faces, matching computeSmoothNormals' treatment of
+// SmoothingGroup 0.
+func (o *Object) generateSmoothNormalsBy(groupOf func(Face) int, zeroIsFlat bool) {
+	o.Normals = nil
+	sums := make(map[smoothKey]Normal)
+	for _, f := range o.Faces {
+		if len(f.Indices) < 3 {
+			continue
+		}
+		n, ok := faceNormal(o, f)
+		if !ok || zeroIsFlat && groupOf(f) == 0 {
+			continue // Flat faces are assigned directly below, not accumulated.
+		}
+		g := groupOf(f)
+		for _, idx := range f.Indices {
+			k := smoothKey{group: g, vertex: idx.Vertex}
+			sums[k] = addNormal(sums[k], n)
+		}
+	}
+	index := make(map[smoothKey]int, len(sums))
+	for k, n := range sums {
+		unit, ok := normalizeNormalVec(n)
+		if !ok {
+			continue
+		}
+		o.Normals = append(o.Normals, unit)
+		index[k] = len(o.Normals)
+	}
+	for i := range o.Faces {
+		f := &o.Faces[i]
+		if len(f.Indices) < 3 {
+			continue
+		}
+		if zeroIsFlat && groupOf(*f) == 0 {
+			n, ok := faceNormal(o, *f)
+			if !ok {
+				continue
+			}
+			unit, ok := normalizeNormalVec(n)
+			if !ok {
+				continue
+			}
+			o.Normals = append(o.Normals, unit)
+			ni := len(o.Normals)
+			for j := range f.Indices {
+				f.Indices[j].Normal = ni
+			}
+			continue
+		}
+		g := groupOf(*f)
+		for j := range f.Indices {
+			k := smoothKey{group: g, vertex: f.Indices[j].Vertex}
+			if ni, ok := index[k]; ok {
+				f.Indices[j].Normal = ni
+			}
+		}
+	}
+}
@@ -87,11 +87,25 @@
 //   - *Description*: Specifies the illumination model used by the material.
 //   - `illum = 1`: Flat material with no specular highlights; `Ks` is not used.
 //   - `illum = 2`: Material has specular highlights; `Ks` must be specified.
+//   - `illum = 3` or `4`: Reflective, with ray-traced reflection.
+//   - `illum = 6` or `7`: Reflective and refractive, with Fresnel weighting using `Ni`.
 //   - *Default*: Varies based on implementation.
 //
+// - **Ke r g b**
+//   - *Description*: Defines the emissive color of the material as (r, g, b).
+//   - *Default*: (0.0, 0.0, 0.0) (not emissive)
+//
+// - **Ni n**
+//   - *Description*: Defines the index of refraction (optical density), used by `illum 6`/`7`.
+//   - *Default*: 1.0
+//
 // - **map_Ka filename**
 //   - *Description*: Specifies a texture map file for the ambient color. The file should contain an ASCII dump of RGB values.
 //   - *Default*: None
+//
+// - **map_Ks / map_Ke / map_Bump / map_d filename**
+//   - *Description*: Texture maps for specular color, emissive color, bump/height, and opacity, paralleling `map_Kd`.
+//   - *Default*: None
 
 package obj
 
@@ -101,6 +115,7 @@ import (
 	"fmt"
 	"io"
 	"io/fs"
+	"math"
 	"path"
 	"strconv"
 	"strings"
@@ -126,12 +141,35 @@ type Index struct {
 	Vertex   int // Index into the Vertices slice (1-based)
 	TexCoord int // Index into the TexCoords slice (optional, 0 if not specified)
 	Normal   int // Index into the Normals slice (optional, 0 if not specified)
+
+	// Tangent indexes into the Tangents slice (optional, 0 if not
+	// specified). Unlike Vertex/TexCoord/Normal, it's never populated by
+	// ParseFS -- only (*Object).GenerateTangents sets it -- since OBJ
+	// itself has no wire directive for tangents.
+	Tangent int
 }
 
 // Face represents a polygonal face, defined by a list of indices to vertex data.
 type Face struct {
 	Indices  []Index // Indices defining the vertices of the face
 	Material string  // Name of the material applied to the face (optional)
+
+	// SmoothingGroup is the face's `s` directive value: 0 means "s off"
+	// or no `s` directive at all. ParseFS uses it to fill in per-vertex
+	// normals (see computeSmoothNormals) for faces that supply no
+	// explicit vn of their own; a face left at 0 keeps its flat
+	// geometric normal.
+	SmoothingGroup int
+
+	// Object is the face's `o` directive value, the name of the object
+	// it belongs to; "" if the file never set one.
+	Object string
+
+	// Groups lists the `g` directive names active when the face was
+	// parsed (a `g` line may declare more than one group at once, which
+	// assigns every following face to all of them). Nil if the file
+	// never set one.
+	Groups []string
 }
 
 // Material defines the properties of a material, as specified in an MTL file.
@@ -142,6 +180,57 @@ type Material struct {
 	Specular  [3]float64 // Specular color (Ks)
 	Shininess float64    // Specular exponent (Ns)
 	Texture   string     // Texture filename (map_Kd)
+	Metallic  float64    // Metalness (Pm), the PBR extension used by Blender/glTF-style exporters.
+	Roughness float64    // Roughness (Pr), the PBR extension used by Blender/glTF-style exporters.
+	HasPBR    bool       // Set when either Pm or Pr was present, so importers know to build a PBR material rather than guessing from Shininess.
+
+	Emission        [3]float64 // Emissive color (Ke); zero means the material doesn't emit.
+	Dissolve        float64    // Opacity (d); 1.0 is fully opaque. Defaults to 1.0 for materials that never set d or Tr.
+	IOR             float64    // Index of refraction (Ni), used by illum 6/7's refractive model.
+	Illum           int        // Illumination model (illum); see the package doc for the 0-10 code meanings.
+	HasIllum        bool       // Set when illum was present, distinguishing an explicit "illum 0" from not having been specified at all.
+	SpecularTexture string     // Specular map filename (map_Ks).
+	EmissionTexture string     // Emission map filename (map_Ke).
+	BumpTexture     string     // Bump/height map filename (map_Bump, map_bump, or bump).
+	AlphaTexture    string     // Opacity/cutout map filename (map_d).
+
+	Sheen               float64    // Sheen (Ps), the PBR retro-reflective fabric extension.
+	Clearcoat           float64    // Clearcoat layer strength (Pc), 0 to 1.
+	ClearcoatRoughness  float64    // Clearcoat layer roughness (Pcr), 0 to 1.
+	TransmissionFilter  [3]float64 // Transmission filter color (Tf), tinting light passing through a transparent material.
+	NormalTexture       string     // Tangent-space normal map filename (norm), distinct from BumpTexture's height-map convention.
+	AmbientTexture      string     // Ambient map filename (map_Ka).
+	ShininessTexture    string     // Specular-exponent map filename (map_Ns).
+	RoughnessTexture    string     // Roughness map filename (map_Pr).
+	MetallicTexture     string     // Metalness map filename (map_Pm).
+	DisplacementTexture string     // Displacement map filename (disp).
+	DecalTexture        string     // Decal map filename (decal).
+	ReflectionTexture   string     // Reflection map filename (refl).
+
+	// TextureOptions holds the full TextureRef -- including any -o/-s/-bm/-clamp
+	// arguments -- for every map_*/bump/disp/decal/refl/norm directive this
+	// material had, keyed by the directive as written (e.g. "map_Kd",
+	// "map_Bump"). The *Texture string fields above only carry the bare
+	// filename; consult this map for scale, offset, bump multiplier, or
+	// clamp. Absent from this map means the directive never appeared, not
+	// that it appeared with default options.
+	TextureOptions map[string]TextureRef
+}
+
+// TextureRef is a material map reference together with the optional
+// arguments Wavefront's map_* directives allow before the trailing
+// filename: "-o u v" (offset), "-s u v" (scale), "-bm mult" (bump
+// multiplier, map_Bump/bump only), and "-clamp on|off". ScaleU/ScaleV
+// default to 1 and BumpMultiplier defaults to 1 when the directive didn't
+// specify them, matching the MTL spec's own defaults.
+type TextureRef struct {
+	Path           string
+	OffsetU        float64
+	OffsetV        float64
+	ScaleU         float64
+	ScaleV         float64
+	BumpMultiplier float64
+	Clamp          bool
 }
 
 // Object represents the contents of an OBJ file, including geometry and materials.
@@ -151,6 +240,62 @@ type Object struct {
 	Normals   []Normal             // List of normals
 	Faces     []Face               // List of faces defining the geometry
 	Materials map[string]*Material // Map of material names to their definitions
+
+	// Groups partitions Faces by the `g` directive(s) active when each
+	// face was parsed, in file order, so callers can render or export a
+	// single named sub-mesh without re-scanning Faces themselves. A face
+	// that named more than one group (a `g` line with multiple names)
+	// appears in every one of those Groups' Faces.
+	Groups []Group
+
+	// UnknownDirectives holds the raw text of every line ParseFS didn't
+	// recognize, in file order, so a round trip through WriteOBJ doesn't
+	// silently drop directives this package has no field for (e.g. a
+	// vendor extension). They aren't anchored to a position relative to
+	// Faces; WriteOBJ re-emits them as a block.
+	UnknownDirectives []string
+
+	// Tangents holds the per-vertex tangents (*Object).GenerateTangents
+	// computes, referenced from Face.Indices[i].Tangent. Empty until
+	// GenerateTangents is called; OBJ itself has no directive for
+	// tangents, so ParseFS never populates this.
+	Tangents []Tangent
+}
+
+// Group is a named partition of an Object's Faces, populated by the `g`
+// directives ParseFS encounters, mirroring MeshIO.jl's group_meta
+// tracking and obj-rs's group model.
+type Group struct {
+	Name string // Group name, from the `g` directive that declared it.
+
+	// Object is the owning object name: the `o` directive active when
+	// this Group was first created. "" if the file never set one.
+	Object string
+
+	// Faces holds indices into the owning Object's Faces slice, not
+	// copies, so callers can still reach each face's full Index data
+	// and modify it through the original slice.
+	Faces []int
+
+	// Smoothing is the `s` directive value active when this Group was
+	// first created; 0 means "off". Faces added to the group later may
+	// carry a different Face.SmoothingGroup if `s` changed mid-group --
+	// consult Face.SmoothingGroup directly for the authoritative
+	// per-face value.
+	Smoothing int
+}
+
+// GroupByName returns the first Group with the given name, in file order,
+// and whether one was found. A name shared by groups under different `o`
+// objects is ambiguous here; range over Object.Groups directly and check
+// Group.Object to disambiguate.
+func (o *Object) GroupByName(name string) (*Group, bool) {
+	for i := range o.Groups {
+		if o.Groups[i].Name == name {
+			return &o.Groups[i], true
+		}
+	}
+	return nil, false
 }
 
 // ParseError represents a parsing error with contextual information.
@@ -169,9 +314,38 @@ func (e *ParseError) Error() string {
 	return fmt.Sprintf("line %d: %s\n    %s", e.Line, e.Msg, e.LineText)
 }
 
-// ParseFS reads and parses an OBJ file from the provided filesystem using the given pattern.
-// It returns an Object containing the parsed geometry and material information.
+// ParseOptions controls optional post-processing ParseFSWithOptions
+// applies to the Object it returns.
+type ParseOptions struct {
+	// Triangulate fan-triangulates every face with more than 3 indices,
+	// equivalent to calling (*Object).Triangulate on the parsed result.
+	// Leaves convex and non-convex n-gons alike fanned from their first
+	// vertex; callers with concave geometry should instead parse with
+	// Triangulate false and call (*Object).TriangulateEarClipping.
+	Triangulate bool
+
+	// Concurrency sets the worker-pool size ParseOBJReader uses to parse
+	// chunks in parallel; 0 defaults to runtime.NumCPU(), and 1 forces
+	// fully sequential parsing. Ignored by ParseFS/ParseFSWithOptions.
+	Concurrency int
+}
+
+// ParseFS reads and parses an OBJ file from the provided filesystem using
+// the given pattern. It returns an Object containing the parsed geometry
+// and material information.
+//
+// ParseFS is a thin wrapper around Decoder: it drives a Decoder over the
+// file's bytes and accumulates the resulting Events into an Object,
+// resolving face indices and tracking usemtl/o/g/s state along the way.
+// A caller that wants to stream a huge OBJ without building an Object at
+// all, or that needs directives ParseFS discards (comments), should use
+// Decoder or Walk directly instead.
 func ParseFS(fsys fs.FS, pattern string) (*Object, error) {
+	return ParseFSWithOptions(fsys, pattern, ParseOptions{})
+}
+
+// ParseFSWithOptions is ParseFS with post-processing controlled by opts.
+func ParseFSWithOptions(fsys fs.FS, pattern string, opts ParseOptions) (*Object, error) {
 	data, err := fs.ReadFile(fsys, path.Base(pattern))
 	if err != nil {
 		return nil, &ParseError{
@@ -182,179 +356,129 @@ func ParseFS(fsys fs.FS, pattern string) (*Object, error) {
 		}
 	}
 	p := &parser{
-		reader:   bufio.NewReader(bytes.NewReader(data)),
-		obj:      &Object{Materials: make(map[string]*Material)},
-		fsys:     fsys,
-		filename: path.Base(pattern),
+		obj:        &Object{Materials: make(map[string]*Material)},
+		fsys:       fsys,
+		filename:   path.Base(pattern),
+		groupIndex: make(map[string]int),
 	}
-	if err := p.parse(); err != nil {
+	if err := Walk(bytes.NewReader(data), p.apply); err != nil {
+		if pe, ok := err.(*ParseError); ok && pe.Filename == "" {
+			pe.Filename = p.filename
+		}
 		return nil, err
 	}
+	p.obj.computeSmoothNormals()
+	if opts.Triangulate {
+		return p.obj.Triangulate(), nil
+	}
 	return p.obj, nil
 }
 
-// parser encapsulates the parsing state and logic.
+// parser accumulates the Events a Decoder produces into an Object,
+// resolving face indices and carrying usemtl/o/g/s state across lines the
+// way Decoder itself deliberately doesn't.
 type parser struct {
-	reader          *bufio.Reader // Reader to read the OBJ file line by line
-	obj             *Object       // Object being constructed
-	currentMaterial string        // Current material name in use
-	lineNumber      int           // Current line number in the OBJ file
-	lineText        string        // Content of the current line
-	fsys            fs.FS         // Filesystem to load external resources (e.g., MTL files)
-	filename        string        // Name of the OBJ file being parsed
-}
-
-// parse initiates the parsing process of the OBJ file.
-func (p *parser) parse() error {
-	for {
-		line, err := p.reader.ReadString('\n')
-		if err != nil && err != io.EOF {
-			return &ParseError{
-				Filename: p.filename,
-				Line:     p.lineNumber,
-				LineText: "",
-				Msg:      fmt.Sprintf("error reading OBJ data: %v", err),
-			}
-		}
-		// Handle the last line if it doesn't end with '\n'
-		if err == io.EOF && len(line) == 0 {
-			break
-		}
-		p.lineNumber++
-		p.lineText = strings.TrimSpace(line)
-		if err := p.parseLine(p.lineText); err != nil {
-			return err
-		}
-		if err == io.EOF {
-			break
-		}
-	}
-	return nil
-}
-
-// parseLine processes a single line of the OBJ file.
-func (p *parser) parseLine(line string) error {
-	if line == "" || strings.HasPrefix(line, "#") {
-		// Skip empty lines and comments.
-		return nil
-	}
-	// Find the first space to determine the directive
-	firstSpace := strings.IndexByte(line, ' ')
-	if firstSpace == -1 {
-		// Line has only one token, possibly invalid
-		return nil // Or handle single-token directives if any
-	}
-	directive := line[:firstSpace]
-	rest := line[firstSpace+1:]
-
-	switch directive {
-	case "v":
-		return p.parseVertex(rest)
-	case "vt":
-		return p.parseTexCoord(rest)
-	case "vn":
-		return p.parseNormal(rest)
-	case "f":
-		return p.parseFace(rest)
-	case "mtllib":
-		return p.parseMTLLib(rest)
-	case "usemtl":
-		return p.parseUseMTL(rest)
-	default:
-		// Ignore unrecognized or unsupported directives
-		return nil
-	}
-}
-
-// parseVertex parses a vertex (position) definition.
-func (p *parser) parseVertex(rest string) error {
-	// Expecting three float values separated by spaces
-	fields := splitFields(rest, 3)
-	if len(fields) < 3 {
-		return p.newError("invalid vertex data: expected at least 3 components, got %d", len(fields))
-	}
-	x, err := strconv.ParseFloat(fields[0], 64)
-	if err != nil {
-		return p.newError("invalid vertex X coordinate: %v", err)
-	}
-	y, err := strconv.ParseFloat(fields[1], 64)
-	if err != nil {
-		return p.newError("invalid vertex Y coordinate: %v", err)
-	}
-	z, err := strconv.ParseFloat(fields[2], 64)
-	if err != nil {
-		return p.newError("invalid vertex Z coordinate: %v", err)
-	}
-	p.obj.Vertices = append(p.obj.Vertices, Vertex{X: x, Y: y, Z: z})
-	return nil
-}
-
-// parseTexCoord parses a texture coordinate definition.
-func (p *parser) parseTexCoord(rest string) error {
-	// Expecting two float values separated by spaces
-	fields := splitFields(rest, 2)
-	if len(fields) < 2 {
-		return p.newError("invalid texture coordinate data: expected at least 2 components, got %d", len(fields))
-	}
-	u, err := strconv.ParseFloat(fields[0], 64)
-	if err != nil {
-		return p.newError("invalid texture U coordinate: %v", err)
-	}
-	v, err := strconv.ParseFloat(fields[1], 64)
-	if err != nil {
-		return p.newError("invalid texture V coordinate: %v", err)
-	}
-	p.obj.TexCoords = append(p.obj.TexCoords, TexCoord{U: u, V: v})
-	return nil
+	obj                   *Object        // Object being constructed
+	currentMaterial       string         // Current material name in use
+	currentSmoothingGroup int            // Current `s` smoothing group in use (0 = off)
+	currentObject         string         // Current `o` object name in use ("" if unset)
+	currentGroups         []string       // Current `g` group names in use (nil if unset)
+	groupIndex            map[string]int // (Object, Name) -> index into obj.Groups, for parseFace's group lookups
+	line                  int            // Source line of the Event currently being applied
+	lineText              string         // Raw text of the Event currently being applied
+	fsys                  fs.FS          // Filesystem to load external resources (e.g., MTL files)
+	filename              string         // Name of the OBJ file being parsed
 }
 
-// parseNormal parses a normal vector definition.
-func (p *parser) parseNormal(rest string) error {
-	// Expecting three float values separated by spaces
-	fields := splitFields(rest, 3)
-	if len(fields) < 3 {
-		return p.newError("invalid normal data: expected at least 3 components, got %d", len(fields))
-	}
-	x, err := strconv.ParseFloat(fields[0], 64)
-	if err != nil {
-		return p.newError("invalid normal X component: %v", err)
-	}
-	y, err := strconv.ParseFloat(fields[1], 64)
-	if err != nil {
-		return p.newError("invalid normal Y component: %v", err)
-	}
-	z, err := strconv.ParseFloat(fields[2], 64)
-	if err != nil {
-		return p.newError("invalid normal Z component: %v", err)
+// apply folds one Event into p.obj, in the order Walk delivers them. It's
+// the func Walk(r, p.apply) in ParseFS calls for every directive.
+func (p *parser) apply(ev Event) error {
+	p.line, p.lineText = ev.Line, ev.Raw
+	switch ev.Type {
+	case VertexEvent:
+		p.obj.Vertices = append(p.obj.Vertices, ev.Vertex)
+	case TexCoordEvent:
+		p.obj.TexCoords = append(p.obj.TexCoords, ev.TexCoord)
+	case NormalEvent:
+		p.obj.Normals = append(p.obj.Normals, ev.Normal)
+	case FaceEvent:
+		return p.parseFace(ev.FaceTokens)
+	case UseMTLEvent:
+		if ev.UseMTL == "" {
+			return p.newError("usemtl directive error: material name is missing")
+		}
+		p.currentMaterial = ev.UseMTL
+	case MTLLibEvent:
+		if p.fsys == nil {
+			// No filesystem to resolve mtllib against (e.g. ParseOBJReader
+			// parsing a bare io.Reader): usemtl still tags Face.Material,
+			// but Object.Materials is left for the caller to populate.
+			return nil
+		}
+		return p.loadMTLLibs(ev.MTLLib)
+	case GroupEvent:
+		p.currentGroups = ev.Groups
+	case ObjectEvent:
+		p.currentObject = ev.Object
+	case SmoothingEvent:
+		p.currentSmoothingGroup = ev.Smoothing
+	case CommentEvent:
+		// Dropped: ParseFS only materializes geometry and materials. Use
+		// Decoder or Walk directly to see comments.
+	case UnknownEvent:
+		// Preserved, not dropped: WriteOBJ re-emits these so a
+		// directive this package doesn't understand survives a
+		// parse/write round trip instead of silently vanishing.
+		p.obj.UnknownDirectives = append(p.obj.UnknownDirectives, ev.Raw)
 	}
-	p.obj.Normals = append(p.obj.Normals, Normal{X: x, Y: y, Z: z})
 	return nil
 }
 
-// parseFace parses a face definition, which can reference vertices, texture coordinates, and normals.
-func (p *parser) parseFace(rest string) error {
-	// Faces can have varying number of vertices (usually 3 or 4)
-	// Each vertex can have the format v, v/vt, v//vn, or v/vt/vn
-	parts := splitFields(rest, -1) // Get all parts
-	if len(parts) < 3 {
-		return p.newError("face definition error: a face must have at least 3 vertices, got %d", len(parts))
-	}
-	var indices []Index
-	indices = make([]Index, 0, len(parts)) // Preallocate with the number of vertices
-	for _, part := range parts {
-		index, err := p.parseIndex(part)
+// parseFace resolves a face's raw index tokens (already split by Decoder)
+// against the vertex/texcoord/normal data parsed so far, and appends the
+// resulting Face tagged with the parser's current material, smoothing
+// group, object, and groups.
+func (p *parser) parseFace(tokens []string) error {
+	indices := make([]Index, 0, len(tokens))
+	for _, tok := range tokens {
+		index, err := p.parseIndex(tok)
 		if err != nil {
-			return p.newError("invalid face index '%s': %v", part, err)
+			return p.newError("invalid face index '%s': %v", tok, err)
 		}
 		indices = append(indices, index)
 	}
 	p.obj.Faces = append(p.obj.Faces, Face{
-		Indices:  indices,
-		Material: p.currentMaterial,
+		Indices:        indices,
+		Material:       p.currentMaterial,
+		SmoothingGroup: p.currentSmoothingGroup,
+		Object:         p.currentObject,
+		Groups:         p.currentGroups,
 	})
+	p.assignFaceToGroups(len(p.obj.Faces) - 1)
 	return nil
 }
 
+// assignFaceToGroups records faceIdx (an index into p.obj.Faces) under
+// every group named by p.currentGroups, creating each Group on first use
+// via p.groupIndex so repeated `g` lines naming the same group append to
+// the same Group instead of starting a new one.
+func (p *parser) assignFaceToGroups(faceIdx int) {
+	for _, name := range p.currentGroups {
+		key := p.currentObject + "\x00" + name
+		i, ok := p.groupIndex[key]
+		if !ok {
+			p.obj.Groups = append(p.obj.Groups, Group{
+				Name:      name,
+				Object:    p.currentObject,
+				Smoothing: p.currentSmoothingGroup,
+			})
+			i = len(p.obj.Groups) - 1
+			p.groupIndex[key] = i
+		}
+		p.obj.Groups[i].Faces = append(p.obj.Groups[i].Faces, faceIdx)
+	}
+}
+
 // parseIndex parses a vertex reference in a face, which may include vertex, texture coordinate, and normal indices.
 func (p *parser) parseIndex(s string) (Index, error) {
 	var idx Index
@@ -438,22 +562,99 @@ func resolveIndex(val, size int) (int, error) {
 	return val, nil
 }
 
-// parseUseMTL handles the usemtl directive, setting the current material for subsequent faces.
-func (p *parser) parseUseMTL(rest string) error {
-	if rest == "" {
-		return p.newError("usemtl directive error: material name is missing")
+// smoothGroupVertex identifies one (smoothing group, vertex position)
+// pair, the key computeSmoothNormals accumulates face normal
+// contributions under: every face reference to the same vertex position
+// within the same smoothing group contributes to, and shares, one
+// averaged normal.
+type smoothGroupVertex struct {
+	group  int
+	vertex int
+}
+
+// faceNormal returns face's flat geometric normal, taken from its first
+// three vertex positions (sufficient for both triangles and the planar
+// polygons OBJ faces are otherwise assumed to be), and false if those
+// three are collinear or coincident and so have no well-defined normal.
+func faceNormal(o *Object, face Face) (Normal, bool) {
+	p0 := o.Vertices[face.Indices[0].Vertex-1]
+	p1 := o.Vertices[face.Indices[1].Vertex-1]
+	p2 := o.Vertices[face.Indices[2].Vertex-1]
+	e1 := Normal{X: p1.X - p0.X, Y: p1.Y - p0.Y, Z: p1.Z - p0.Z}
+	e2 := Normal{X: p2.X - p0.X, Y: p2.Y - p0.Y, Z: p2.Z - p0.Z}
+	n := Normal{
+		X: e1.Y*e2.Z - e1.Z*e2.Y,
+		Y: e1.Z*e2.X - e1.X*e2.Z,
+		Z: e1.X*e2.Y - e1.Y*e2.X,
 	}
-	p.currentMaterial = rest
-	return nil
+	if n.X == 0 && n.Y == 0 && n.Z == 0 {
+		return Normal{}, false
+	}
+	return n, true
 }
 
-// parseMTLLib handles the mtllib directive, loading material definitions from an external MTL file.
-func (p *parser) parseMTLLib(rest string) error {
-	if rest == "" {
+// computeSmoothNormals fills in Index.Normal for every face reference
+// that belongs to a smoothing group (a nonzero Face.SmoothingGroup, set
+// by the `s` directive) but carries no explicit vn of its own, averaging
+// the flat normals of every face sharing that vertex position within the
+// group -- the usual Wavefront convention for shading a mesh smoothly
+// without authoring per-vertex normals by hand. A face left at
+// SmoothingGroup 0 ("s off", or no `s` directive at all) is untouched,
+// so it keeps Face.Collide's flat-shaded fallback. An explicit vn on a
+// face reference always wins: computeSmoothNormals only fills gaps.
+func (o *Object) computeSmoothNormals() {
+	sums := make(map[smoothGroupVertex]Normal)
+	for _, face := range o.Faces {
+		if face.SmoothingGroup == 0 || len(face.Indices) < 3 {
+			continue
+		}
+		n, ok := faceNormal(o, face)
+		if !ok {
+			continue // Degenerate face: no normal to contribute.
+		}
+		for _, idx := range face.Indices {
+			if idx.Normal != 0 {
+				continue // Explicit vn already set for this reference.
+			}
+			key := smoothGroupVertex{group: face.SmoothingGroup, vertex: idx.Vertex}
+			sum := sums[key]
+			sums[key] = Normal{X: sum.X + n.X, Y: sum.Y + n.Y, Z: sum.Z + n.Z}
+		}
+	}
+	if len(sums) == 0 {
+		return
+	}
+	normalIndex := make(map[smoothGroupVertex]int, len(sums))
+	for key, n := range sums {
+		length := math.Sqrt(n.X*n.X + n.Y*n.Y + n.Z*n.Z)
+		if length == 0 {
+			continue // Contributions canceled out exactly; nothing usable to assign.
+		}
+		o.Normals = append(o.Normals, Normal{X: n.X / length, Y: n.Y / length, Z: n.Z / length})
+		normalIndex[key] = len(o.Normals)
+	}
+	for i, face := range o.Faces {
+		if face.SmoothingGroup == 0 {
+			continue
+		}
+		for j, idx := range face.Indices {
+			if idx.Normal != 0 {
+				continue
+			}
+			key := smoothGroupVertex{group: face.SmoothingGroup, vertex: idx.Vertex}
+			if ni, ok := normalIndex[key]; ok {
+				o.Faces[i].Indices[j].Normal = ni
+			}
+		}
+	}
+}
+
+// loadMTLLibs handles the mtllib directive, loading material definitions
+// from each named external MTL file.
+func (p *parser) loadMTLLibs(filenames []string) error {
+	if len(filenames) == 0 {
 		return p.newError("mtllib directive error: filename is missing")
 	}
-	// Handle multiple mtllib directives by splitting filenames
-	filenames := strings.Fields(rest)
 	for _, filename := range filenames {
 		baseFilename := path.Base(filename)
 		data, err := fs.ReadFile(p.fsys, baseFilename)
@@ -467,6 +668,120 @@ func (p *parser) parseMTLLib(rest string) error {
 	return nil
 }
 
+// parseTextureMap parses a map_*/bump/disp/decal/refl/norm directive's
+// rest (everything after the directive name) into a TextureRef, records it
+// under directive in currentMaterial.TextureOptions, and returns the bare
+// filename for the caller's own string field.
+func (p *parser) parseTextureMap(mtlFilename, directive string, lineNumber int, line, rest string, currentMaterial *Material) (string, error) {
+	if currentMaterial == nil {
+		return "", &ParseError{
+			Filename: mtlFilename,
+			Line:     lineNumber,
+			LineText: line,
+			Msg:      fmt.Sprintf("%s directive error: defined before any newmtl", directive),
+		}
+	}
+	if rest == "" {
+		return "", &ParseError{
+			Filename: mtlFilename,
+			Line:     lineNumber,
+			LineText: line,
+			Msg:      fmt.Sprintf("%s directive error: expected a filename", directive),
+		}
+	}
+	ref, err := parseMapArgs(rest)
+	if err != nil {
+		return "", &ParseError{
+			Filename: mtlFilename,
+			Line:     lineNumber,
+			LineText: line,
+			Msg:      fmt.Sprintf("%s directive error: %v", directive, err),
+		}
+	}
+	if ref.Path == "" {
+		return "", &ParseError{
+			Filename: mtlFilename,
+			Line:     lineNumber,
+			LineText: line,
+			Msg:      fmt.Sprintf("%s directive error: expected a filename", directive),
+		}
+	}
+	if currentMaterial.TextureOptions == nil {
+		currentMaterial.TextureOptions = make(map[string]TextureRef)
+	}
+	currentMaterial.TextureOptions[directive] = ref
+	return ref.Path, nil
+}
+
+// parseMapArgs parses a map directive's argument list -- any mix of -o/-s/-bm/-clamp
+// option flags followed by a trailing filename -- into a TextureRef.
+// ScaleU/ScaleV/BumpMultiplier default to 1 per the MTL spec when their
+// flag is absent.
+func parseMapArgs(rest string) (TextureRef, error) {
+	ref := TextureRef{ScaleU: 1, ScaleV: 1, BumpMultiplier: 1}
+	tokens := strings.Fields(rest)
+	for i := 0; i < len(tokens); {
+		switch tokens[i] {
+		case "-o":
+			vals, n := readOptionFloats(tokens, i+1, 3)
+			if n == 0 {
+				return ref, fmt.Errorf("-o option expects at least one numeric value")
+			}
+			ref.OffsetU = vals[0]
+			if len(vals) > 1 {
+				ref.OffsetV = vals[1]
+			}
+			i += 1 + n
+		case "-s":
+			vals, n := readOptionFloats(tokens, i+1, 3)
+			if n == 0 {
+				return ref, fmt.Errorf("-s option expects at least one numeric value")
+			}
+			ref.ScaleU = vals[0]
+			ref.ScaleV = vals[0]
+			if len(vals) > 1 {
+				ref.ScaleV = vals[1]
+			}
+			i += 1 + n
+		case "-bm":
+			vals, n := readOptionFloats(tokens, i+1, 1)
+			if n == 0 {
+				return ref, fmt.Errorf("-bm option expects a numeric value")
+			}
+			ref.BumpMultiplier = vals[0]
+			i += 1 + n
+		case "-clamp":
+			if i+1 >= len(tokens) {
+				return ref, fmt.Errorf("-clamp option expects on or off")
+			}
+			ref.Clamp = tokens[i+1] == "on"
+			i += 2
+		default:
+			ref.Path = tokens[i]
+			i++
+		}
+	}
+	return ref, nil
+}
+
+// readOptionFloats parses up to max consecutive numeric tokens starting at
+// tokens[start], stopping at the first token that doesn't parse as a
+// float64 (e.g. the next "-flag" or the trailing filename). It returns the
+// parsed values and how many tokens were consumed.
+func readOptionFloats(tokens []string, start, max int) ([]float64, int) {
+	var vals []float64
+	n := 0
+	for n < max && start+n < len(tokens) {
+		v, err := strconv.ParseFloat(tokens[start+n], 64)
+		if err != nil {
+			break
+		}
+		vals = append(vals, v)
+		n++
+	}
+	return vals, n
+}
+
 // parseMTL parses an MTL file, populating the Materials map with material definitions.
 func (p *parser) parseMTL(r io.Reader, mtlFilename string) error {
 	scanner := bufio.NewScanner(r)
@@ -504,7 +819,7 @@ func (p *parser) parseMTL(r io.Reader, mtlFilename string) error {
 				}
 			}
 			name := rest
-			mat := &Material{Name: name}
+			mat := &Material{Name: name, Dissolve: 1}
 			p.obj.Materials[name] = mat
 			currentMaterial = mat
 		case "Kd":
@@ -625,25 +940,404 @@ func (p *parser) parseMTL(r io.Reader, mtlFilename string) error {
 				}
 			}
 			currentMaterial.Shininess = val
+		case "Pm":
+			if currentMaterial == nil {
+				return &ParseError{
+					Filename: mtlFilename,
+					Line:     lineNumber,
+					LineText: line,
+					Msg:      "Pm directive error: defined before any newmtl",
+				}
+			}
+			fields := splitFields(rest, 1)
+			if len(fields) < 1 {
+				return &ParseError{
+					Filename: mtlFilename,
+					Line:     lineNumber,
+					LineText: line,
+					Msg:      "Pm directive error: expected a single value",
+				}
+			}
+			val, err := strconv.ParseFloat(fields[0], 64)
+			if err != nil {
+				return &ParseError{
+					Filename: mtlFilename,
+					Line:     lineNumber,
+					LineText: line,
+					Msg:      fmt.Sprintf("invalid Pm value: %v", err),
+				}
+			}
+			currentMaterial.Metallic = val
+			currentMaterial.HasPBR = true
+		case "Pr":
+			if currentMaterial == nil {
+				return &ParseError{
+					Filename: mtlFilename,
+					Line:     lineNumber,
+					LineText: line,
+					Msg:      "Pr directive error: defined before any newmtl",
+				}
+			}
+			fields := splitFields(rest, 1)
+			if len(fields) < 1 {
+				return &ParseError{
+					Filename: mtlFilename,
+					Line:     lineNumber,
+					LineText: line,
+					Msg:      "Pr directive error: expected a single value",
+				}
+			}
+			val, err := strconv.ParseFloat(fields[0], 64)
+			if err != nil {
+				return &ParseError{
+					Filename: mtlFilename,
+					Line:     lineNumber,
+					LineText: line,
+					Msg:      fmt.Sprintf("invalid Pr value: %v", err),
+				}
+			}
+			currentMaterial.Roughness = val
+			currentMaterial.HasPBR = true
 		case "map_Kd":
+			path, err := p.parseTextureMap(mtlFilename, "map_Kd", lineNumber, line, rest, currentMaterial)
+			if err != nil {
+				return err
+			}
+			currentMaterial.Texture = path
+		case "Ke":
 			if currentMaterial == nil {
 				return &ParseError{
 					Filename: mtlFilename,
 					Line:     lineNumber,
 					LineText: line,
-					Msg:      "map_Kd directive error: defined before any newmtl",
+					Msg:      "Ke directive error: defined before any newmtl",
 				}
 			}
-			if rest == "" {
+			fields := splitFields(rest, 3)
+			if len(fields) < 3 {
+				return &ParseError{
+					Filename: mtlFilename,
+					Line:     lineNumber,
+					LineText: line,
+					Msg:      "Ke directive error: expected 3 components",
+				}
+			}
+			for i := 0; i < 3; i++ {
+				val, err := strconv.ParseFloat(fields[i], 64)
+				if err != nil {
+					return &ParseError{
+						Filename: mtlFilename,
+						Line:     lineNumber,
+						LineText: line,
+						Msg:      fmt.Sprintf("invalid Ke value: %v", err),
+					}
+				}
+				currentMaterial.Emission[i] = val
+			}
+		case "Ps":
+			if currentMaterial == nil {
+				return &ParseError{
+					Filename: mtlFilename,
+					Line:     lineNumber,
+					LineText: line,
+					Msg:      "Ps directive error: defined before any newmtl",
+				}
+			}
+			fields := splitFields(rest, 1)
+			if len(fields) < 1 {
+				return &ParseError{
+					Filename: mtlFilename,
+					Line:     lineNumber,
+					LineText: line,
+					Msg:      "Ps directive error: expected a single value",
+				}
+			}
+			val, err := strconv.ParseFloat(fields[0], 64)
+			if err != nil {
 				return &ParseError{
 					Filename: mtlFilename,
 					Line:     lineNumber,
 					LineText: line,
-					Msg:      "map_Kd directive error: expected a filename",
+					Msg:      fmt.Sprintf("invalid Ps value: %v", err),
 				}
 			}
-			texture := rest
-			currentMaterial.Texture = texture
+			currentMaterial.Sheen = val
+		case "Pc":
+			if currentMaterial == nil {
+				return &ParseError{
+					Filename: mtlFilename,
+					Line:     lineNumber,
+					LineText: line,
+					Msg:      "Pc directive error: defined before any newmtl",
+				}
+			}
+			fields := splitFields(rest, 1)
+			if len(fields) < 1 {
+				return &ParseError{
+					Filename: mtlFilename,
+					Line:     lineNumber,
+					LineText: line,
+					Msg:      "Pc directive error: expected a single value",
+				}
+			}
+			val, err := strconv.ParseFloat(fields[0], 64)
+			if err != nil {
+				return &ParseError{
+					Filename: mtlFilename,
+					Line:     lineNumber,
+					LineText: line,
+					Msg:      fmt.Sprintf("invalid Pc value: %v", err),
+				}
+			}
+			currentMaterial.Clearcoat = val
+		case "Pcr":
+			if currentMaterial == nil {
+				return &ParseError{
+					Filename: mtlFilename,
+					Line:     lineNumber,
+					LineText: line,
+					Msg:      "Pcr directive error: defined before any newmtl",
+				}
+			}
+			fields := splitFields(rest, 1)
+			if len(fields) < 1 {
+				return &ParseError{
+					Filename: mtlFilename,
+					Line:     lineNumber,
+					LineText: line,
+					Msg:      "Pcr directive error: expected a single value",
+				}
+			}
+			val, err := strconv.ParseFloat(fields[0], 64)
+			if err != nil {
+				return &ParseError{
+					Filename: mtlFilename,
+					Line:     lineNumber,
+					LineText: line,
+					Msg:      fmt.Sprintf("invalid Pcr value: %v", err),
+				}
+			}
+			currentMaterial.ClearcoatRoughness = val
+		case "Tf":
+			if currentMaterial == nil {
+				return &ParseError{
+					Filename: mtlFilename,
+					Line:     lineNumber,
+					LineText: line,
+					Msg:      "Tf directive error: defined before any newmtl",
+				}
+			}
+			fields := splitFields(rest, 3)
+			if len(fields) < 3 {
+				return &ParseError{
+					Filename: mtlFilename,
+					Line:     lineNumber,
+					LineText: line,
+					Msg:      "Tf directive error: expected 3 components",
+				}
+			}
+			for i := 0; i < 3; i++ {
+				val, err := strconv.ParseFloat(fields[i], 64)
+				if err != nil {
+					return &ParseError{
+						Filename: mtlFilename,
+						Line:     lineNumber,
+						LineText: line,
+						Msg:      fmt.Sprintf("invalid Tf value: %v", err),
+					}
+				}
+				currentMaterial.TransmissionFilter[i] = val
+			}
+		case "d":
+			if currentMaterial == nil {
+				return &ParseError{
+					Filename: mtlFilename,
+					Line:     lineNumber,
+					LineText: line,
+					Msg:      "d directive error: defined before any newmtl",
+				}
+			}
+			fields := splitFields(rest, 1)
+			if len(fields) < 1 {
+				return &ParseError{
+					Filename: mtlFilename,
+					Line:     lineNumber,
+					LineText: line,
+					Msg:      "d directive error: expected a single value",
+				}
+			}
+			val, err := strconv.ParseFloat(fields[0], 64)
+			if err != nil {
+				return &ParseError{
+					Filename: mtlFilename,
+					Line:     lineNumber,
+					LineText: line,
+					Msg:      fmt.Sprintf("invalid d value: %v", err),
+				}
+			}
+			currentMaterial.Dissolve = val
+		case "Tr":
+			if currentMaterial == nil {
+				return &ParseError{
+					Filename: mtlFilename,
+					Line:     lineNumber,
+					LineText: line,
+					Msg:      "Tr directive error: defined before any newmtl",
+				}
+			}
+			fields := splitFields(rest, 1)
+			if len(fields) < 1 {
+				return &ParseError{
+					Filename: mtlFilename,
+					Line:     lineNumber,
+					LineText: line,
+					Msg:      "Tr directive error: expected a single value",
+				}
+			}
+			val, err := strconv.ParseFloat(fields[0], 64)
+			if err != nil {
+				return &ParseError{
+					Filename: mtlFilename,
+					Line:     lineNumber,
+					LineText: line,
+					Msg:      fmt.Sprintf("invalid Tr value: %v", err),
+				}
+			}
+			// Tr and d are inversely related; Tr always wins if both appear,
+			// since it's conventionally written after d by exporters that
+			// emit both.
+			currentMaterial.Dissolve = 1 - val
+		case "Ni":
+			if currentMaterial == nil {
+				return &ParseError{
+					Filename: mtlFilename,
+					Line:     lineNumber,
+					LineText: line,
+					Msg:      "Ni directive error: defined before any newmtl",
+				}
+			}
+			fields := splitFields(rest, 1)
+			if len(fields) < 1 {
+				return &ParseError{
+					Filename: mtlFilename,
+					Line:     lineNumber,
+					LineText: line,
+					Msg:      "Ni directive error: expected a single value",
+				}
+			}
+			val, err := strconv.ParseFloat(fields[0], 64)
+			if err != nil {
+				return &ParseError{
+					Filename: mtlFilename,
+					Line:     lineNumber,
+					LineText: line,
+					Msg:      fmt.Sprintf("invalid Ni value: %v", err),
+				}
+			}
+			currentMaterial.IOR = val
+		case "illum":
+			if currentMaterial == nil {
+				return &ParseError{
+					Filename: mtlFilename,
+					Line:     lineNumber,
+					LineText: line,
+					Msg:      "illum directive error: defined before any newmtl",
+				}
+			}
+			fields := splitFields(rest, 1)
+			if len(fields) < 1 {
+				return &ParseError{
+					Filename: mtlFilename,
+					Line:     lineNumber,
+					LineText: line,
+					Msg:      "illum directive error: expected a single value",
+				}
+			}
+			val, err := strconv.Atoi(fields[0])
+			if err != nil {
+				return &ParseError{
+					Filename: mtlFilename,
+					Line:     lineNumber,
+					LineText: line,
+					Msg:      fmt.Sprintf("invalid illum value: %v", err),
+				}
+			}
+			currentMaterial.Illum = val
+			currentMaterial.HasIllum = true
+		case "map_Ks":
+			path, err := p.parseTextureMap(mtlFilename, "map_Ks", lineNumber, line, rest, currentMaterial)
+			if err != nil {
+				return err
+			}
+			currentMaterial.SpecularTexture = path
+		case "map_Ke":
+			path, err := p.parseTextureMap(mtlFilename, "map_Ke", lineNumber, line, rest, currentMaterial)
+			if err != nil {
+				return err
+			}
+			currentMaterial.EmissionTexture = path
+		case "map_Bump", "map_bump", "bump":
+			path, err := p.parseTextureMap(mtlFilename, "map_Bump", lineNumber, line, rest, currentMaterial)
+			if err != nil {
+				return err
+			}
+			currentMaterial.BumpTexture = path
+		case "norm":
+			path, err := p.parseTextureMap(mtlFilename, "norm", lineNumber, line, rest, currentMaterial)
+			if err != nil {
+				return err
+			}
+			currentMaterial.NormalTexture = path
+		case "map_d":
+			path, err := p.parseTextureMap(mtlFilename, "map_d", lineNumber, line, rest, currentMaterial)
+			if err != nil {
+				return err
+			}
+			currentMaterial.AlphaTexture = path
+		case "map_Ka":
+			path, err := p.parseTextureMap(mtlFilename, "map_Ka", lineNumber, line, rest, currentMaterial)
+			if err != nil {
+				return err
+			}
+			currentMaterial.AmbientTexture = path
+		case "map_Ns":
+			path, err := p.parseTextureMap(mtlFilename, "map_Ns", lineNumber, line, rest, currentMaterial)
+			if err != nil {
+				return err
+			}
+			currentMaterial.ShininessTexture = path
+		case "map_Pr":
+			path, err := p.parseTextureMap(mtlFilename, "map_Pr", lineNumber, line, rest, currentMaterial)
+			if err != nil {
+				return err
+			}
+			currentMaterial.RoughnessTexture = path
+			currentMaterial.HasPBR = true
+		case "map_Pm":
+			path, err := p.parseTextureMap(mtlFilename, "map_Pm", lineNumber, line, rest, currentMaterial)
+			if err != nil {
+				return err
+			}
+			currentMaterial.MetallicTexture = path
+			currentMaterial.HasPBR = true
+		case "disp":
+			path, err := p.parseTextureMap(mtlFilename, "disp", lineNumber, line, rest, currentMaterial)
+			if err != nil {
+				return err
+			}
+			currentMaterial.DisplacementTexture = path
+		case "decal":
+			path, err := p.parseTextureMap(mtlFilename, "decal", lineNumber, line, rest, currentMaterial)
+			if err != nil {
+				return err
+			}
+			currentMaterial.DecalTexture = path
+		case "refl":
+			path, err := p.parseTextureMap(mtlFilename, "refl", lineNumber, line, rest, currentMaterial)
+			if err != nil {
+				return err
+			}
+			currentMaterial.ReflectionTexture = path
 		default:
 			// Ignore other material properties or unsupported directives.
 		}
@@ -672,7 +1366,7 @@ func splitFields(s string, limit int) []string {
 func (p *parser) newError(format string, args ...interface{}) error {
 	return &ParseError{
 		Filename: p.filename,
-		Line:     p.lineNumber,
+		Line:     p.line,
 		LineText: p.lineText,
 		Msg:      fmt.Sprintf(format, args...),
 	}
@@ -718,9 +1412,37 @@ func (obj *Object) WriteOBJ(w io.Writer) error {
 			return fmt.Errorf("failed to write normal: %v", err)
 		}
 	}
-	// Keep track of the current material to write 'usemtl' only when it changes.
+	// Keep track of the current object, groups, smoothing group, and
+	// material, each written only when it changes from the previous face.
+	var currentObject string
+	var currentGroups []string
+	currentSmoothing := -1 // -1 never matches a real SmoothingGroup, so the first face always writes one.
 	var currentMaterial string
 	for _, face := range faces {
+		if face.Object != "" && face.Object != currentObject {
+			if _, err := fmt.Fprintf(writer, "o %s\n", face.Object); err != nil {
+				return fmt.Errorf("failed to write o: %v", err)
+			}
+			currentObject = face.Object
+		}
+		if len(face.Groups) > 0 && !equalGroupNames(face.Groups, currentGroups) {
+			if _, err := fmt.Fprintf(writer, "g %s\n", strings.Join(face.Groups, " ")); err != nil {
+				return fmt.Errorf("failed to write g: %v", err)
+			}
+			currentGroups = face.Groups
+		}
+		if face.SmoothingGroup != currentSmoothing {
+			if face.SmoothingGroup == 0 {
+				if _, err := fmt.Fprintf(writer, "s off\n"); err != nil {
+					return fmt.Errorf("failed to write s: %v", err)
+				}
+			} else {
+				if _, err := fmt.Fprintf(writer, "s %d\n", face.SmoothingGroup); err != nil {
+					return fmt.Errorf("failed to write s: %v", err)
+				}
+			}
+			currentSmoothing = face.SmoothingGroup
+		}
 		// Write 'usemtl' if the material changes.
 		if face.Material != currentMaterial {
 			if face.Material != "" {
@@ -745,6 +1467,12 @@ func (obj *Object) WriteOBJ(w io.Writer) error {
 		}
 	}
 
+	for _, line := range obj.UnknownDirectives {
+		if _, err := fmt.Fprintf(writer, "%s\n", line); err != nil {
+			return fmt.Errorf("failed to write unknown directive: %v", err)
+		}
+	}
+
 	return writer.Flush()
 }
 
@@ -783,9 +1511,78 @@ func (obj *Object) WriteMTL(w io.Writer) error {
 				return fmt.Errorf("failed to write Ns for material '%s': %v", mat.Name, err)
 			}
 		}
-		if mat.Texture != "" {
-			if _, err := fmt.Fprintf(writer, "map_Kd %s\n", mat.Texture); err != nil {
-				return fmt.Errorf("failed to write map_Kd for material '%s': %v", mat.Name, err)
+		if mat.HasPBR {
+			if _, err := fmt.Fprintf(writer, "Pm %f\n", mat.Metallic); err != nil {
+				return fmt.Errorf("failed to write Pm for material '%s': %v", mat.Name, err)
+			}
+			if _, err := fmt.Fprintf(writer, "Pr %f\n", mat.Roughness); err != nil {
+				return fmt.Errorf("failed to write Pr for material '%s': %v", mat.Name, err)
+			}
+		}
+		if mat.Emission != [3]float64{} {
+			if _, err := fmt.Fprintf(writer, "Ke %f %f %f\n", mat.Emission[0], mat.Emission[1], mat.Emission[2]); err != nil {
+				return fmt.Errorf("failed to write Ke for material '%s': %v", mat.Name, err)
+			}
+		}
+		if mat.Sheen != 0 {
+			if _, err := fmt.Fprintf(writer, "Ps %f\n", mat.Sheen); err != nil {
+				return fmt.Errorf("failed to write Ps for material '%s': %v", mat.Name, err)
+			}
+		}
+		if mat.Clearcoat != 0 {
+			if _, err := fmt.Fprintf(writer, "Pc %f\n", mat.Clearcoat); err != nil {
+				return fmt.Errorf("failed to write Pc for material '%s': %v", mat.Name, err)
+			}
+		}
+		if mat.ClearcoatRoughness != 0 {
+			if _, err := fmt.Fprintf(writer, "Pcr %f\n", mat.ClearcoatRoughness); err != nil {
+				return fmt.Errorf("failed to write Pcr for material '%s': %v", mat.Name, err)
+			}
+		}
+		if mat.TransmissionFilter != [3]float64{} {
+			if _, err := fmt.Fprintf(writer, "Tf %f %f %f\n", mat.TransmissionFilter[0], mat.TransmissionFilter[1], mat.TransmissionFilter[2]); err != nil {
+				return fmt.Errorf("failed to write Tf for material '%s': %v", mat.Name, err)
+			}
+		}
+		if mat.Dissolve != 0 && mat.Dissolve != 1 {
+			if _, err := fmt.Fprintf(writer, "d %f\n", mat.Dissolve); err != nil {
+				return fmt.Errorf("failed to write d for material '%s': %v", mat.Name, err)
+			}
+		}
+		if mat.IOR != 0 {
+			if _, err := fmt.Fprintf(writer, "Ni %f\n", mat.IOR); err != nil {
+				return fmt.Errorf("failed to write Ni for material '%s': %v", mat.Name, err)
+			}
+		}
+		if mat.HasIllum {
+			if _, err := fmt.Fprintf(writer, "illum %d\n", mat.Illum); err != nil {
+				return fmt.Errorf("failed to write illum for material '%s': %v", mat.Name, err)
+			}
+		}
+		textureFields := []struct {
+			directive string
+			path      string
+		}{
+			{"map_Kd", mat.Texture},
+			{"map_Ka", mat.AmbientTexture},
+			{"map_Ks", mat.SpecularTexture},
+			{"map_Ns", mat.ShininessTexture},
+			{"map_Ke", mat.EmissionTexture},
+			{"map_Pr", mat.RoughnessTexture},
+			{"map_Pm", mat.MetallicTexture},
+			{"map_Bump", mat.BumpTexture},
+			{"norm", mat.NormalTexture},
+			{"map_d", mat.AlphaTexture},
+			{"disp", mat.DisplacementTexture},
+			{"decal", mat.DecalTexture},
+			{"refl", mat.ReflectionTexture},
+		}
+		for _, tf := range textureFields {
+			if tf.path == "" {
+				continue
+			}
+			if err := writeTextureMap(writer, tf.directive, tf.path, mat.TextureOptions[tf.directive]); err != nil {
+				return fmt.Errorf("failed to write %s for material '%s': %v", tf.directive, mat.Name, err)
 			}
 		}
 		if _, err := writer.WriteString("\n"); err != nil {
@@ -796,6 +1593,52 @@ func (obj *Object) WriteMTL(w io.Writer) error {
 	return writer.Flush()
 }
 
+// writeTextureMap writes one map directive (map_Kd, map_Bump, disp, ...)
+// for path, restoring any -o/-s/-bm/-clamp flags carried in ref. A zero
+// ref (the directive never recorded TextureOptions, e.g. because it was
+// set programmatically rather than parsed) writes a bare filename.
+func writeTextureMap(w *bufio.Writer, directive, path string, ref TextureRef) error {
+	var b strings.Builder
+	b.WriteString(directive)
+	if ref.OffsetU != 0 || ref.OffsetV != 0 {
+		fmt.Fprintf(&b, " -o %g %g", ref.OffsetU, ref.OffsetV)
+	}
+	su, sv := ref.ScaleU, ref.ScaleV
+	if (su != 0 && su != 1) || (sv != 0 && sv != 1) {
+		if su == 0 {
+			su = 1
+		}
+		if sv == 0 {
+			sv = 1
+		}
+		fmt.Fprintf(&b, " -s %g %g", su, sv)
+	}
+	if ref.BumpMultiplier != 0 && ref.BumpMultiplier != 1 {
+		fmt.Fprintf(&b, " -bm %g", ref.BumpMultiplier)
+	}
+	if ref.Clamp {
+		b.WriteString(" -clamp on")
+	}
+	fmt.Fprintf(&b, " %s\n", path)
+	_, err := w.WriteString(b.String())
+	return err
+}
+
+// equalGroupNames reports whether a and b name the same groups in the
+// same order, used by WriteOBJ to decide whether a face needs a new `g`
+// line or stays under the previous one.
+func equalGroupNames(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
 // formatIndex formats an Index into the OBJ face index format.
 func formatIndex(idx Index) string {
 	// OBJ indices are 1-based.
@@ -1,7 +1,9 @@
 package obj
 
 import (
+	"bytes"
 	"fmt"
+	"math"
 	"math/rand"
 	"strings"
 	"testing"
@@ -97,6 +99,119 @@ map_Kd texture.jpg
 	}
 }
 
+// TestParseOBJ_MaterialsPBR tests parsing the Pm/Pr PBR extension directives.
+func TestParseOBJ_MaterialsPBR(t *testing.T) {
+	objData := `
+v -1.0 -1.0 -1.0
+v 1.0 -1.0 -1.0
+v 1.0 1.0 -1.0
+
+usemtl Material001
+mtllib cube.mtl
+
+f 1 2 3
+`
+
+	mtlData := `
+newmtl Material001
+Kd 0.8 0.8 0.8
+Pm 0.9
+Pr 0.25
+`
+
+	fsys := fstest.MapFS{
+		"cube.obj": {Data: []byte(objData)},
+		"cube.mtl": {Data: []byte(mtlData)},
+	}
+
+	obj, err := ParseFS(fsys, "cube.obj")
+	if err != nil {
+		t.Fatalf("Failed to parse OBJ file: %v", err)
+	}
+
+	mat, ok := obj.Materials["Material001"]
+	if !ok {
+		t.Fatalf("Material 'Material001' not found")
+	}
+	if !mat.HasPBR {
+		t.Errorf("Expected HasPBR true")
+	}
+	if mat.Metallic != 0.9 {
+		t.Errorf("Expected Metallic 0.9, got %v", mat.Metallic)
+	}
+	if mat.Roughness != 0.25 {
+		t.Errorf("Expected Roughness 0.25, got %v", mat.Roughness)
+	}
+}
+
+// TestParseOBJ_MaterialsIllumAndMaps tests parsing the illum, Ke, d, Tr,
+// Ni directives and the map_Ks/map_Ke/map_Bump/map_d texture maps.
+func TestParseOBJ_MaterialsIllumAndMaps(t *testing.T) {
+	objData := `
+v -1.0 -1.0 -1.0
+v 1.0 -1.0 -1.0
+v 1.0 1.0 -1.0
+
+usemtl Glass
+mtllib cube.mtl
+
+f 1 2 3
+`
+
+	mtlData := `
+newmtl Glass
+Kd 0.1 0.1 0.1
+Ke 0.5 0.25 0.0
+illum 7
+Ni 1.5
+Tr 0.8
+map_Ks spec.png
+map_Ke emit.png
+map_Bump bump.png
+map_d alpha.png
+`
+
+	fsys := fstest.MapFS{
+		"cube.obj": {Data: []byte(objData)},
+		"cube.mtl": {Data: []byte(mtlData)},
+	}
+
+	obj, err := ParseFS(fsys, "cube.obj")
+	if err != nil {
+		t.Fatalf("Failed to parse OBJ file: %v", err)
+	}
+
+	mat, ok := obj.Materials["Glass"]
+	if !ok {
+		t.Fatalf("Material 'Glass' not found")
+	}
+	if mat.Emission != [3]float64{0.5, 0.25, 0.0} {
+		t.Errorf("Expected Emission (0.5, 0.25, 0.0), got %v", mat.Emission)
+	}
+	if !mat.HasIllum || mat.Illum != 7 {
+		t.Errorf("Expected illum 7, got HasIllum=%v Illum=%v", mat.HasIllum, mat.Illum)
+	}
+	if mat.IOR != 1.5 {
+		t.Errorf("Expected Ni 1.5, got %v", mat.IOR)
+	}
+	// Tr 0.8 means 80% transparent, i.e. Dissolve 0.2.
+	if math.Abs(mat.Dissolve-0.2) > 1e-9 {
+		t.Errorf("Expected Dissolve 0.2 from Tr 0.8, got %v", mat.Dissolve)
+	}
+	if mat.SpecularTexture != "spec.png" {
+		t.Errorf("Expected SpecularTexture 'spec.png', got '%s'", mat.SpecularTexture)
+	}
+	if mat.EmissionTexture != "emit.png" {
+		t.Errorf("Expected EmissionTexture 'emit.png', got '%s'", mat.EmissionTexture)
+	}
+	if mat.BumpTexture != "bump.png" {
+		t.Errorf("Expected BumpTexture 'bump.png', got '%s'", mat.BumpTexture)
+	}
+	if mat.AlphaTexture != "alpha.png" {
+		t.Errorf("Expected AlphaTexture 'alpha.png', got '%s'", mat.AlphaTexture)
+	}
+}
+
 // TestParseOBJ_NegativeIndices tests parsing faces with negative indices.
 func TestParseOBJ_NegativeIndices(t *testing.T) {
 	objData := `
@@ -130,6 +245,204 @@ f -4 -3 -2 -1
 	}
 }
 
+// TestParseOBJ_SmoothingGroup verifies that two faces in the same `s`
+// smoothing group, sharing an edge but with no vn of their own, get
+// per-vertex normals averaged across the faces that touch each vertex
+// position: a vertex shared by both faces gets their blended normal,
+// while a vertex only one face touches just gets that face's own flat
+// normal.
+func TestParseOBJ_SmoothingGroup(t *testing.T) {
+	objData := `
+v 0 0 0
+v 1 0 0
+v 0 1 0
+v 0 0 1
+s 1
+f 1 2 3
+f 1 2 4
+`
+	fsys := fstest.MapFS{
+		"hinge.obj": {Data: []byte(objData)},
+	}
+
+	obj, err := ParseFS(fsys, "hinge.obj")
+	if err != nil {
+		t.Fatalf("Failed to parse OBJ file: %v", err)
+	}
+	if len(obj.Faces) != 2 {
+		t.Fatalf("Expected 2 faces, got %d", len(obj.Faces))
+	}
+
+	normalAt := func(face, vertex int) Normal {
+		idx := obj.Faces[face].Indices[vertex]
+		if idx.Normal == 0 {
+			t.Fatalf("face %d vertex %d: expected a smoothed normal, got none", face, vertex)
+		}
+		return obj.Normals[idx.Normal-1]
+	}
+
+	sharedA := normalAt(0, 0) // Vertex 1, touched by both faces.
+	sharedB := normalAt(1, 0)
+	if sharedA != sharedB {
+		t.Errorf("shared vertex got different normals across faces: %+v vs %+v", sharedA, sharedB)
+	}
+	blend := math.Sqrt(2) / 2
+	wantShared := Normal{X: 0, Y: -blend, Z: blend}
+	if math.Abs(sharedA.X-wantShared.X) > 1e-9 || math.Abs(sharedA.Y-wantShared.Y) > 1e-9 || math.Abs(sharedA.Z-wantShared.Z) > 1e-9 {
+		t.Errorf("shared normal = %+v, want %+v", sharedA, wantShared)
+	}
+
+	soleA := normalAt(0, 2) // Vertex 3, only face 0 touches it.
+	wantSoleA := Normal{X: 0, Y: 0, Z: 1}
+	if soleA != wantSoleA {
+		t.Errorf("face-0-only normal = %+v, want %+v", soleA, wantSoleA)
+	}
+}
+
+// TestParseOBJ_SmoothingGroupOffLeavesNormalsUnset verifies that faces
+// outside any smoothing group (the default, or an explicit "s off") are
+// left without a vn, preserving Face.Collide's flat-shading fallback.
+func TestParseOBJ_SmoothingGroupOffLeavesNormalsUnset(t *testing.T) {
+	objData := `
+v 0 0 0
+v 1 0 0
+v 0 1 0
+s off
+f 1 2 3
+`
+	fsys := fstest.MapFS{
+		"flat.obj": {Data: []byte(objData)},
+	}
+
+	obj, err := ParseFS(fsys, "flat.obj")
+	if err != nil {
+		t.Fatalf("Failed to parse OBJ file: %v", err)
+	}
+	for _, idx := range obj.Faces[0].Indices {
+		if idx.Normal != 0 {
+			t.Errorf("expected no normal assigned with smoothing off, got index %d", idx.Normal)
+		}
+	}
+	if len(obj.Normals) != 0 {
+		t.Errorf("expected no normals computed with smoothing off, got %d", len(obj.Normals))
+	}
+}
+
+// TestParseOBJ_GroupsAndObjects verifies that `o`, `g`, and `s` directives
+// are tracked per face and partitioned into Object.Groups, with a `g` line
+// naming multiple groups assigning its faces to all of them.
+func TestParseOBJ_GroupsAndObjects(t *testing.T) {
+	objData := `
+v 0 0 0
+v 1 0 0
+v 0 1 0
+v 0 0 1
+o Car
+g body
+s 1
+f 1 2 3
+g body wheels
+f 1 2 4
+`
+	fsys := fstest.MapFS{
+		"car.obj": {Data: []byte(objData)},
+	}
+
+	obj, err := ParseFS(fsys, "car.obj")
+	if err != nil {
+		t.Fatalf("Failed to parse OBJ file: %v", err)
+	}
+	if len(obj.Faces) != 2 {
+		t.Fatalf("Expected 2 faces, got %d", len(obj.Faces))
+	}
+	for i, want := range [][]string{{"body"}, {"body", "wheels"}} {
+		if got := obj.Faces[i].Groups; !equalGroupNames(got, want) {
+			t.Errorf("face %d Groups = %v, want %v", i, got, want)
+		}
+		if obj.Faces[i].Object != "Car" {
+			t.Errorf("face %d Object = %q, want %q", i, obj.Faces[i].Object, "Car")
+		}
+	}
+
+	body, ok := obj.GroupByName("body")
+	if !ok {
+		t.Fatalf("GroupByName(%q) found nothing", "body")
+	}
+	if body.Object != "Car" || body.Smoothing != 1 {
+		t.Errorf("body group = %+v, want Object=Car Smoothing=1", *body)
+	}
+	if len(body.Faces) != 2 || body.Faces[0] != 0 || body.Faces[1] != 1 {
+		t.Errorf("body group Faces = %v, want [0 1]", body.Faces)
+	}
+
+	wheels, ok := obj.GroupByName("wheels")
+	if !ok {
+		t.Fatalf("GroupByName(%q) found nothing", "wheels")
+	}
+	if len(wheels.Faces) != 1 || wheels.Faces[0] != 1 {
+		t.Errorf("wheels group Faces = %v, want [1]", wheels.Faces)
+	}
+
+	if _, ok := obj.GroupByName("chassis"); ok {
+		t.Errorf("GroupByName(%q) found a group, want none", "chassis")
+	}
+}
+
+// TestParseOBJ_GroupsRoundTrip verifies that WriteOBJ re-emits `o`, `g`,
+// and `s` directives so re-parsing the written file reproduces the same
+// per-face Object/Groups/SmoothingGroup assignments.
+func TestParseOBJ_GroupsRoundTrip(t *testing.T) {
+	objData := `
+v 0 0 0
+v 1 0 0
+v 0 1 0
+v 0 0 1
+o Car
+g body
+s 1
+f 1 2 3
+g wheels
+s off
+f 1 2 4
+`
+	fsys := fstest.MapFS{
+		"car.obj": {Data: []byte(objData)},
+	}
+	original, err := ParseFS(fsys, "car.obj")
+	if err != nil {
+		t.Fatalf("Failed to parse OBJ file: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := original.WriteOBJ(&buf); err != nil {
+		t.Fatalf("WriteOBJ failed: %v", err)
+	}
+
+	roundFsys := fstest.MapFS{
+		"car.obj": {Data: buf.Bytes()},
+	}
+	roundTripped, err := ParseFS(roundFsys, "car.obj")
+	if err != nil {
+		t.Fatalf("Failed to re-parse written OBJ file: %v\n%s", err, buf.String())
+	}
+
+	if len(roundTripped.Faces) != len(original.Faces) {
+		t.Fatalf("round-tripped face count = %d, want %d", len(roundTripped.Faces), len(original.Faces))
+	}
+	for i := range original.Faces {
+		got, want := roundTripped.Faces[i], original.Faces[i]
+		if got.Object != want.Object {
+			t.Errorf("face %d Object = %q, want %q", i, got.Object, want.Object)
+		}
+		if !equalGroupNames(got.Groups, want.Groups) {
+			t.Errorf("face %d Groups = %v, want %v", i, got.Groups, want.Groups)
+		}
+		if got.SmoothingGroup != want.SmoothingGroup {
+			t.Errorf("face %d SmoothingGroup = %d, want %d", i, got.SmoothingGroup, want.SmoothingGroup)
+		}
+	}
+}
+
 // TestParseOBJ_InvalidSyntax tests the parser's handling of invalid syntax.
 func TestParseOBJ_InvalidSyntax(t *testing.T) {
 	objData := `
@@ -328,3 +641,838 @@ f -4 -3 -2 -1
 
 	})
 }
+
+// TestDecoderEventSequence checks that Decoder emits one Event per
+// directive, in file order, with the expected Type and payload, including
+// the comment and unrecognized directives ParseFS itself drops.
+func TestDecoderEventSequence(t *testing.T) {
+	objData := `# a comment
+v 0.0 0.0 0.0
+vt 0.5 0.5
+vn 0.0 1.0 0.0
+o cube
+g body
+s 1
+usemtl red
+mtllib materials.mtl
+f 1/1/1 1/1/1 1/1/1
+vendorext foo bar
+`
+	var events []Event
+	if err := Walk(strings.NewReader(objData), func(ev Event) error {
+		events = append(events, ev)
+		return nil
+	}); err != nil {
+		t.Fatalf("Walk returned an error: %v", err)
+	}
+
+	wantTypes := []EventType{
+		CommentEvent, VertexEvent, TexCoordEvent, NormalEvent, ObjectEvent,
+		GroupEvent, SmoothingEvent, UseMTLEvent, MTLLibEvent, FaceEvent, UnknownEvent,
+	}
+	if len(events) != len(wantTypes) {
+		t.Fatalf("got %d events, want %d", len(events), len(wantTypes))
+	}
+	for i, want := range wantTypes {
+		if events[i].Type != want {
+			t.Errorf("event %d: got Type %v, want %v", i, events[i].Type, want)
+		}
+	}
+
+	if got, want := events[4].Object, "cube"; got != want {
+		t.Errorf("ObjectEvent.Object = %q, want %q", got, want)
+	}
+	if got, want := events[5].Groups, []string{"body"}; len(got) != 1 || got[0] != want[0] {
+		t.Errorf("GroupEvent.Groups = %v, want %v", got, want)
+	}
+	if got, want := events[6].Smoothing, 1; got != want {
+		t.Errorf("SmoothingEvent.Smoothing = %d, want %d", got, want)
+	}
+	if got, want := events[7].UseMTL, "red"; got != want {
+		t.Errorf("UseMTLEvent.UseMTL = %q, want %q", got, want)
+	}
+	if got, want := events[9].FaceTokens, []string{"1/1/1", "1/1/1", "1/1/1"}; len(got) != len(want) {
+		t.Errorf("FaceEvent.FaceTokens = %v, want %v", got, want)
+	}
+	if got, want := events[10].Raw, "vendorext foo bar"; got != want {
+		t.Errorf("UnknownEvent.Raw = %q, want %q", got, want)
+	}
+}
+
+// TestParseOBJ_UnknownDirectivesRoundTrip checks that a directive ParseFS
+// doesn't recognize survives a parse/write round trip instead of silently
+// disappearing.
+func TestParseOBJ_UnknownDirectivesRoundTrip(t *testing.T) {
+	objData := `
+v 0.0 0.0 0.0
+v 1.0 0.0 0.0
+v 0.0 1.0 0.0
+f 1 2 3
+vendorext keep me
+`
+	fsys := fstest.MapFS{
+		"vendor.obj": {Data: []byte(objData)},
+	}
+	obj, err := ParseFS(fsys, "vendor.obj")
+	if err != nil {
+		t.Fatalf("ParseFS returned an error: %v", err)
+	}
+	if len(obj.UnknownDirectives) != 1 || obj.UnknownDirectives[0] != "vendorext keep me" {
+		t.Fatalf("UnknownDirectives = %v, want [%q]", obj.UnknownDirectives, "vendorext keep me")
+	}
+
+	var buf bytes.Buffer
+	if err := obj.WriteOBJ(&buf); err != nil {
+		t.Fatalf("WriteOBJ returned an error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "vendorext keep me") {
+		t.Errorf("WriteOBJ output missing unknown directive, got:\n%s", buf.String())
+	}
+}
+
+// TestTriangulateFanQuad checks that Triangulate fans a quad into two
+// triangles sharing the first vertex, preserving material and group tags.
+func TestTriangulateFanQuad(t *testing.T) {
+	objData := `
+v 0 0 0
+v 1 0 0
+v 1 1 0
+v 0 1 0
+g panel
+usemtl glass
+f 1 2 3 4
+`
+	fsys := fstest.MapFS{
+		"quad.obj": {Data: []byte(objData)},
+	}
+	obj, err := ParseFS(fsys, "quad.obj")
+	if err != nil {
+		t.Fatalf("ParseFS returned an error: %v", err)
+	}
+
+	tri := obj.Triangulate()
+	if len(tri.Faces) != 2 {
+		t.Fatalf("Triangulate produced %d faces, want 2", len(tri.Faces))
+	}
+	want := [][3]int{{1, 2, 3}, {1, 3, 4}}
+	for i, f := range tri.Faces {
+		if len(f.Indices) != 3 {
+			t.Fatalf("face %d has %d indices, want 3", i, len(f.Indices))
+		}
+		got := [3]int{f.Indices[0].Vertex, f.Indices[1].Vertex, f.Indices[2].Vertex}
+		if got != want[i] {
+			t.Errorf("face %d indices = %v, want %v", i, got, want[i])
+		}
+		if f.Material != "glass" {
+			t.Errorf("face %d Material = %q, want %q", i, f.Material, "glass")
+		}
+		if !equalGroupNames(f.Groups, []string{"panel"}) {
+			t.Errorf("face %d Groups = %v, want %v", i, f.Groups, []string{"panel"})
+		}
+	}
+
+	panel, ok := tri.GroupByName("panel")
+	if !ok {
+		t.Fatal("GroupByName(\"panel\") found nothing after Triangulate")
+	}
+	if len(panel.Faces) != 2 {
+		t.Errorf("panel group has %d faces after Triangulate, want 2", len(panel.Faces))
+	}
+}
+
+// TestParseFSWithOptionsTriangulate checks that ParseOptions.Triangulate
+// fans n-gons during parsing the same way a standalone Triangulate call
+// would.
+func TestParseFSWithOptionsTriangulate(t *testing.T) {
+	objData := `
+v 0 0 0
+v 1 0 0
+v 1 1 0
+v 0 1 0
+f 1 2 3 4
+`
+	fsys := fstest.MapFS{
+		"quad.obj": {Data: []byte(objData)},
+	}
+	obj, err := ParseFSWithOptions(fsys, "quad.obj", ParseOptions{Triangulate: true})
+	if err != nil {
+		t.Fatalf("ParseFSWithOptions returned an error: %v", err)
+	}
+	if len(obj.Faces) != 2 {
+		t.Fatalf("got %d faces, want 2", len(obj.Faces))
+	}
+	for _, f := range obj.Faces {
+		if len(f.Indices) != 3 {
+			t.Errorf("face has %d indices, want 3", len(f.Indices))
+		}
+	}
+}
+
+// TestTriangulateEarClippingConcave checks that TriangulateEarClipping
+// correctly decomposes a concave (non-convex) pentagon, unlike a fan
+// triangulation which would produce a triangle poking outside the shape.
+func TestTriangulateEarClippingConcave(t *testing.T) {
+	// An arrow-like concave pentagon in the Z=0 plane:
+	//   (0,0) (4,0) (4,4) (2,2) (0,4)
+	// The notch at (2,2) makes vertex 4 reflex (concave).
+	objData := `
+v 0 0 0
+v 4 0 0
+v 4 4 0
+v 2 2 0
+v 0 4 0
+f 1 2 3 4 5
+`
+	fsys := fstest.MapFS{
+		"arrow.obj": {Data: []byte(objData)},
+	}
+	obj, err := ParseFS(fsys, "arrow.obj")
+	if err != nil {
+		t.Fatalf("ParseFS returned an error: %v", err)
+	}
+
+	tri := obj.TriangulateEarClipping()
+	if len(tri.Faces) != 3 {
+		t.Fatalf("TriangulateEarClipping produced %d faces, want 3", len(tri.Faces))
+	}
+
+	// Every emitted triangle must use only vertices of the original
+	// polygon and stay non-degenerate (nonzero area).
+	for i, f := range tri.Faces {
+		if len(f.Indices) != 3 {
+			t.Fatalf("face %d has %d indices, want 3", i, len(f.Indices))
+		}
+		var pts [3]Vertex
+		for j, idx := range f.Indices {
+			if idx.Vertex < 1 || idx.Vertex > len(obj.Vertices) {
+				t.Fatalf("face %d index %d out of range", i, idx.Vertex)
+			}
+			pts[j] = obj.Vertices[idx.Vertex-1]
+		}
+		area := (pts[1].X-pts[0].X)*(pts[2].Y-pts[0].Y) - (pts[2].X-pts[0].X)*(pts[1].Y-pts[0].Y)
+		if area == 0 {
+			t.Errorf("face %d is degenerate (zero area)", i)
+		}
+	}
+}
+
+// TestParseOBJ_MaterialsPBRExtensions checks the sheen, transmission
+// filter, and extra map_*/disp/decal/refl/norm directives added alongside
+// the original PBR fields.
+func TestParseOBJ_MaterialsPBRExtensions(t *testing.T) {
+	objData := `
+v -1.0 -1.0 -1.0
+v 1.0 -1.0 -1.0
+v 1.0 1.0 -1.0
+
+usemtl Fabric
+mtllib fabric.mtl
+
+f 1 2 3
+`
+	mtlData := `
+newmtl Fabric
+Kd 0.5 0.5 0.5
+Ps 0.3
+Tf 0.9 0.9 0.9
+map_Ka amb.png
+map_Ns shininess.png
+map_Pr rough.png
+map_Pm metal.png
+norm normal.png
+disp height.png
+decal decal.png
+refl env.png
+`
+	fsys := fstest.MapFS{
+		"fabric.obj": {Data: []byte(objData)},
+		"fabric.mtl": {Data: []byte(mtlData)},
+	}
+	obj, err := ParseFS(fsys, "fabric.obj")
+	if err != nil {
+		t.Fatalf("Failed to parse OBJ file: %v", err)
+	}
+	mat, ok := obj.Materials["Fabric"]
+	if !ok {
+		t.Fatalf("Material 'Fabric' not found")
+	}
+	if mat.Sheen != 0.3 {
+		t.Errorf("Sheen = %v, want 0.3", mat.Sheen)
+	}
+	if mat.TransmissionFilter != [3]float64{0.9, 0.9, 0.9} {
+		t.Errorf("TransmissionFilter = %v, want [0.9 0.9 0.9]", mat.TransmissionFilter)
+	}
+	if mat.AmbientTexture != "amb.png" {
+		t.Errorf("AmbientTexture = %q, want %q", mat.AmbientTexture, "amb.png")
+	}
+	if mat.ShininessTexture != "shininess.png" {
+		t.Errorf("ShininessTexture = %q, want %q", mat.ShininessTexture, "shininess.png")
+	}
+	if mat.RoughnessTexture != "rough.png" || !mat.HasPBR {
+		t.Errorf("RoughnessTexture = %q HasPBR=%v, want rough.png true", mat.RoughnessTexture, mat.HasPBR)
+	}
+	if mat.MetallicTexture != "metal.png" {
+		t.Errorf("MetallicTexture = %q, want %q", mat.MetallicTexture, "metal.png")
+	}
+	if mat.NormalTexture != "normal.png" {
+		t.Errorf("NormalTexture = %q, want %q", mat.NormalTexture, "normal.png")
+	}
+	if mat.DisplacementTexture != "height.png" {
+		t.Errorf("DisplacementTexture = %q, want %q", mat.DisplacementTexture, "height.png")
+	}
+	if mat.DecalTexture != "decal.png" {
+		t.Errorf("DecalTexture = %q, want %q", mat.DecalTexture, "decal.png")
+	}
+	if mat.ReflectionTexture != "env.png" {
+		t.Errorf("ReflectionTexture = %q, want %q", mat.ReflectionTexture, "env.png")
+	}
+}
+
+// TestParseOBJ_TextureMapOptions checks that -o/-s/-bm/-clamp map
+// arguments are parsed into TextureOptions and that WriteMTL round-trips
+// them back into the same flags.
+func TestParseOBJ_TextureMapOptions(t *testing.T) {
+	objData := `
+v -1.0 -1.0 -1.0
+v 1.0 -1.0 -1.0
+v 1.0 1.0 -1.0
+
+usemtl Tiled
+mtllib tiled.mtl
+
+f 1 2 3
+`
+	mtlData := `
+newmtl Tiled
+Kd 1 1 1
+map_Kd -o 0.25 0.5 -s 2 4 -clamp on tile.png
+map_Bump -bm 0.7 bump.png
+`
+	fsys := fstest.MapFS{
+		"tiled.obj": {Data: []byte(objData)},
+		"tiled.mtl": {Data: []byte(mtlData)},
+	}
+	obj, err := ParseFS(fsys, "tiled.obj")
+	if err != nil {
+		t.Fatalf("Failed to parse OBJ file: %v", err)
+	}
+	mat := obj.Materials["Tiled"]
+	if mat.Texture != "tile.png" {
+		t.Errorf("Texture = %q, want %q", mat.Texture, "tile.png")
+	}
+	ref, ok := mat.TextureOptions["map_Kd"]
+	if !ok {
+		t.Fatal("TextureOptions[\"map_Kd\"] missing")
+	}
+	if ref.OffsetU != 0.25 || ref.OffsetV != 0.5 || ref.ScaleU != 2 || ref.ScaleV != 4 || !ref.Clamp {
+		t.Errorf("map_Kd TextureRef = %+v, want Offset(0.25,0.5) Scale(2,4) Clamp=true", ref)
+	}
+	bumpRef, ok := mat.TextureOptions["map_Bump"]
+	if !ok || bumpRef.BumpMultiplier != 0.7 {
+		t.Errorf("map_Bump TextureRef = %+v, want BumpMultiplier 0.7", bumpRef)
+	}
+
+	var buf bytes.Buffer
+	if err := obj.WriteMTL(&buf); err != nil {
+		t.Fatalf("WriteMTL returned an error: %v", err)
+	}
+	written := buf.String()
+	if !strings.Contains(written, "map_Kd -o 0.25 0.5 -s 2 4 -clamp on tile.png") {
+		t.Errorf("WriteMTL output missing round-tripped map_Kd options, got:\n%s", written)
+	}
+	if !strings.Contains(written, "map_Bump -bm 0.7 bump.png") {
+		t.Errorf("WriteMTL output missing round-tripped map_Bump options, got:\n%s", written)
+	}
+
+	// Re-parsing the written MTL must reproduce the same options.
+	fsys2 := fstest.MapFS{
+		"tiled.obj": {Data: []byte(objData)},
+		"tiled.mtl": {Data: buf.Bytes()},
+	}
+	obj2, err := ParseFS(fsys2, "tiled.obj")
+	if err != nil {
+		t.Fatalf("Failed to re-parse round-tripped MTL: %v", err)
+	}
+	ref2 := obj2.Materials["Tiled"].TextureOptions["map_Kd"]
+	if ref2 != ref {
+		t.Errorf("round-tripped map_Kd TextureRef = %+v, want %+v", ref2, ref)
+	}
+}
+
+// TestObjectBounds verifies Bounds returns the AABB spanning all vertices.
+func TestObjectBounds(t *testing.T) {
+	o := &Object{
+		Vertices: []Vertex{
+			{X: -1, Y: 0, Z: 2},
+			{X: 3, Y: -5, Z: 0},
+			{X: 1, Y: 1, Z: -4},
+		},
+	}
+	min, max := o.Bounds()
+	wantMin := Vertex{X: -1, Y: -5, Z: -4}
+	wantMax := Vertex{X: 3, Y: 1, Z: 2}
+	if min != wantMin || max != wantMax {
+		t.Errorf("Bounds() = (%+v, %+v), want (%+v, %+v)", min, max, wantMin, wantMax)
+	}
+}
+
+// TestObjectBoundsEmpty verifies Bounds on an empty Object returns zero
+// Vertex values rather than panicking.
+func TestObjectBoundsEmpty(t *testing.T) {
+	o := &Object{}
+	min, max := o.Bounds()
+	if min != (Vertex{}) || max != (Vertex{}) {
+		t.Errorf("Bounds() on empty Object = (%+v, %+v), want zero values", min, max)
+	}
+}
+
+// TestGenerateNormalsSmoothPlane verifies that two coplanar triangles
+// sharing an edge get matching, averaged normals when within the crease
+// angle.
+func TestGenerateNormalsSmoothPlane(t *testing.T) {
+	o := &Object{
+		Vertices: []Vertex{
+			{X: 0, Y: 0, Z: 0},
+			{X: 1, Y: 0, Z: 0},
+			{X: 1, Y: 1, Z: 0},
+			{X: 0, Y: 1, Z: 0},
+		},
+		Faces: []Face{
+			{Indices: []Index{{Vertex: 1}, {Vertex: 2}, {Vertex: 3}}},
+			{Indices: []Index{{Vertex: 1}, {Vertex: 3}, {Vertex: 4}}},
+		},
+	}
+	o.GenerateNormals(math.Pi / 4)
+	if len(o.Normals) == 0 {
+		t.Fatal("GenerateNormals did not populate any normals")
+	}
+	for _, f := range o.Faces {
+		for _, idx := range f.Indices {
+			if idx.Normal == 0 {
+				t.Fatalf("face-vertex left without a generated normal: %+v", idx)
+			}
+			n := o.Normals[idx.Normal-1]
+			if n.Z <= 0.99 {
+				t.Errorf("normal = %+v, want roughly +Z for this flat quad", n)
+			}
+		}
+	}
+}
+
+// TestGenerateNormalsCreaseAngle verifies that a vertex shared by two faces
+// meeting at a sharp angle gets independent normals per face when the
+// crease exceeds the threshold, rather than being blended together.
+func TestGenerateNormalsCreaseAngle(t *testing.T) {
+	o := &Object{
+		Vertices: []Vertex{
+			{X: 0, Y: 0, Z: 0},
+			{X: 1, Y: 0, Z: 0},
+			{X: 0, Y: 1, Z: 0},
+			{X: 0, Y: 0, Z: 1},
+		},
+		Faces: []Face{
+			// XY plane.
+			{Indices: []Index{{Vertex: 1}, {Vertex: 2}, {Vertex: 3}}},
+			// XZ plane, sharing vertex 1 -- a 90 degree crease.
+			{Indices: []Index{{Vertex: 1}, {Vertex: 4}, {Vertex: 2}}},
+		},
+	}
+	o.GenerateNormals(math.Pi / 6) // 30 degrees: well under the 90 degree crease.
+
+	n0 := o.Normals[o.Faces[0].Indices[0].Normal-1]
+	n1 := o.Normals[o.Faces[1].Indices[0].Normal-1]
+	if n0 == n1 {
+		t.Errorf("vertex shared across a 90 degree crease got identical normals %+v across both faces", n0)
+	}
+}
+
+// TestGenerateTangentsQuad verifies that GenerateTangents derives
+// tangents orthogonal to each vertex's normal and aligned with the U axis
+// for a flat, axis-aligned, UV-mapped quad.
+func TestGenerateTangentsQuad(t *testing.T) {
+	o := &Object{
+		Vertices: []Vertex{
+			{X: 0, Y: 0, Z: 0},
+			{X: 1, Y: 0, Z: 0},
+			{X: 1, Y: 1, Z: 0},
+			{X: 0, Y: 1, Z: 0},
+		},
+		TexCoords: []TexCoord{
+			{U: 0, V: 0},
+			{U: 1, V: 0},
+			{U: 1, V: 1},
+			{U: 0, V: 1},
+		},
+		Normals: []Normal{{X: 0, Y: 0, Z: 1}},
+		Faces: []Face{
+			{Indices: []Index{{Vertex: 1, TexCoord: 1, Normal: 1}, {Vertex: 2, TexCoord: 2, Normal: 1}, {Vertex: 3, TexCoord: 3, Normal: 1}}},
+			{Indices: []Index{{Vertex: 1, TexCoord: 1, Normal: 1}, {Vertex: 3, TexCoord: 3, Normal: 1}, {Vertex: 4, TexCoord: 4, Normal: 1}}},
+		},
+	}
+	if err := o.GenerateTangents(); err != nil {
+		t.Fatalf("GenerateTangents returned an error: %v", err)
+	}
+	for _, f := range o.Faces {
+		for _, idx := range f.Indices {
+			if idx.Tangent == 0 {
+				t.Fatalf("face-vertex left without a generated tangent: %+v", idx)
+			}
+			tan := o.Tangents[idx.Tangent-1]
+			if tan.X <= 0.99 || math.Abs(tan.Y) > 1e-9 || math.Abs(tan.Z) > 1e-9 {
+				t.Errorf("tangent = %+v, want roughly +X for this axis-aligned UV layout", tan)
+			}
+			if tan.W != 1 {
+				t.Errorf("tangent.W = %v, want 1 for this right-handed UV layout", tan.W)
+			}
+		}
+	}
+}
+
+// TestGenerateTangentsRequiresTexCoords verifies GenerateTangents rejects
+// an Object with no texture coordinates, since there's no UV gradient to
+// derive a tangent basis from.
+func TestGenerateTangentsRequiresTexCoords(t *testing.T) {
+	o := &Object{
+		Vertices: []Vertex{{X: 0, Y: 0, Z: 0}, {X: 1, Y: 0, Z: 0}, {X: 0, Y: 1, Z: 0}},
+		Faces:    []Face{{Indices: []Index{{Vertex: 1}, {Vertex: 2}, {Vertex: 3}}}},
+	}
+	if err := o.GenerateTangents(); err == nil {
+		t.Error("GenerateTangents() with no TexCoords returned nil error, want non-nil")
+	}
+}
+
+// TestToIndexedMesh verifies ToIndexedMesh deduplicates shared
+// (Vertex, TexCoord, Normal) triplets and buckets triangles by material.
+func TestToIndexedMesh(t *testing.T) {
+	o := &Object{
+		Vertices: []Vertex{
+			{X: 0, Y: 0, Z: 0},
+			{X: 1, Y: 0, Z: 0},
+			{X: 1, Y: 1, Z: 0},
+			{X: 0, Y: 1, Z: 0},
+		},
+		TexCoords: []TexCoord{{U: 0, V: 0}, {U: 1, V: 0}, {U: 1, V: 1}, {U: 0, V: 1}},
+		Faces: []Face{
+			{Material: "A", Indices: []Index{{Vertex: 1, TexCoord: 1}, {Vertex: 2, TexCoord: 2}, {Vertex: 3, TexCoord: 3}, {Vertex: 4, TexCoord: 4}}},
+			{Material: "B", Indices: []Index{{Vertex: 1, TexCoord: 1}, {Vertex: 3, TexCoord: 3}, {Vertex: 2, TexCoord: 2}}},
+		},
+	}
+	mesh := o.ToIndexedMesh()
+	if len(mesh.Vertices) != 4 {
+		t.Errorf("len(mesh.Vertices) = %d, want 4 (shared index triplets deduplicated)", len(mesh.Vertices))
+	}
+	if len(mesh.Indices["A"]) != 6 {
+		t.Errorf("len(mesh.Indices[\"A\"]) = %d, want 6 (quad fan-triangulated into 2 triangles)", len(mesh.Indices["A"]))
+	}
+	if len(mesh.Indices["B"]) != 3 {
+		t.Errorf("len(mesh.Indices[\"B\"]) = %d, want 3", len(mesh.Indices["B"]))
+	}
+	for _, idx := range mesh.Indices["A"] {
+		if int(idx) >= len(mesh.Vertices) {
+			t.Fatalf("index %d out of range for %d vertices", idx, len(mesh.Vertices))
+		}
+	}
+}
+
+// TestWeld verifies Weld merges near-coincident vertices within epsilon
+// and remaps face indices onto the merged set.
+func TestWeld(t *testing.T) {
+	o := &Object{
+		Vertices: []Vertex{
+			{X: 0, Y: 0, Z: 0},
+			{X: 1e-7, Y: 0, Z: 0}, // Within epsilon of vertex 1.
+			{X: 1, Y: 0, Z: 0},
+		},
+		Faces: []Face{
+			{Indices: []Index{{Vertex: 1}, {Vertex: 2}, {Vertex: 3}}},
+		},
+	}
+	o.Weld(1e-4)
+	if len(o.Vertices) != 2 {
+		t.Fatalf("len(o.Vertices) = %d, want 2 after welding", len(o.Vertices))
+	}
+	if o.Faces[0].Indices[0].Vertex != o.Faces[0].Indices[1].Vertex {
+		t.Errorf("welded face indices = %+v, want the first two to reference the same merged vertex", o.Faces[0].Indices)
+	}
+	if o.Faces[0].Indices[2].Vertex == o.Faces[0].Indices[0].Vertex {
+		t.Errorf("distinct vertex (1,0,0) was incorrectly merged with (0,0,0)")
+	}
+}
+
+// TestStats verifies Stats reports vertex/face-vertex counts, per-material
+// face counts, and detects a degenerate (collinear) triangle.
+func TestStats(t *testing.T) {
+	o := &Object{
+		Vertices: []Vertex{
+			{X: 0, Y: 0, Z: 0},
+			{X: 1, Y: 0, Z: 0},
+			{X: 1, Y: 1, Z: 0},
+			{X: 2, Y: 0, Z: 0}, // Collinear with vertices 1 and 2.
+		},
+		Faces: []Face{
+			{Material: "A", Indices: []Index{{Vertex: 1}, {Vertex: 2}, {Vertex: 3}}},
+			{Material: "A", Indices: []Index{{Vertex: 1}, {Vertex: 2}, {Vertex: 4}}},
+		},
+	}
+	stats := o.Stats()
+	if stats.VertexCount != 4 {
+		t.Errorf("VertexCount = %d, want 4", stats.VertexCount)
+	}
+	if stats.FaceVertexCount != 6 {
+		t.Errorf("FaceVertexCount = %d, want 6", stats.FaceVertexCount)
+	}
+	if stats.FacesByMaterial["A"] != 2 {
+		t.Errorf("FacesByMaterial[\"A\"] = %d, want 2", stats.FacesByMaterial["A"])
+	}
+	if stats.DegenerateFaces != 1 {
+		t.Errorf("DegenerateFaces = %d, want 1", stats.DegenerateFaces)
+	}
+}
+
+// TestParseOBJReaderBasic verifies ParseOBJReader agrees with ParseFS on
+// a small file parsed with a worker pool forced to more than one worker.
+func TestParseOBJReaderBasic(t *testing.T) {
+	objData := `
+v 0.0 0.0 0.0
+v 1.0 0.0 0.0
+v 1.0 1.0 0.0
+v 0.0 1.0 0.0
+vt 0.0 0.0
+vt 1.0 0.0
+vt 1.0 1.0
+vt 0.0 1.0
+vn 0.0 0.0 1.0
+g panel
+usemtl Red
+f 1/1/1 2/2/1 3/3/1
+f 1/1/1 3/3/1 4/4/1
+`
+	obj, err := ParseOBJReader(strings.NewReader(objData), ParseOptions{Concurrency: 4})
+	if err != nil {
+		t.Fatalf("ParseOBJReader returned an error: %v", err)
+	}
+	if len(obj.Vertices) != 4 {
+		t.Errorf("len(Vertices) = %d, want 4", len(obj.Vertices))
+	}
+	if len(obj.Faces) != 2 {
+		t.Fatalf("len(Faces) = %d, want 2", len(obj.Faces))
+	}
+	for _, f := range obj.Faces {
+		if f.Material != "Red" {
+			t.Errorf("Face.Material = %q, want %q", f.Material, "Red")
+		}
+		if len(f.Groups) != 1 || f.Groups[0] != "panel" {
+			t.Errorf("Face.Groups = %v, want [panel]", f.Groups)
+		}
+	}
+	if g, ok := obj.GroupByName("panel"); !ok || len(g.Faces) != 2 {
+		t.Errorf("GroupByName(\"panel\") = %+v, %v, want 2 faces", g, ok)
+	}
+}
+
+// TestParseOBJReaderRelativeIndices verifies negative (relative) face
+// indices resolve to the same vertices ParseFS would pick, even though
+// ParseOBJReader resolves them in a separate pre-pass from parsing.
+func TestParseOBJReaderRelativeIndices(t *testing.T) {
+	objData := `
+v 0.0 0.0 0.0
+v 1.0 0.0 0.0
+v 1.0 1.0 0.0
+f -3 -2 -1
+v 0.0 1.0 0.0
+f -2 -1 1
+`
+	obj, err := ParseOBJReader(strings.NewReader(objData), ParseOptions{Concurrency: 4})
+	if err != nil {
+		t.Fatalf("ParseOBJReader returned an error: %v", err)
+	}
+	want := [][]int{{1, 2, 3}, {3, 4, 1}}
+	for fi, f := range obj.Faces {
+		for vi, idx := range f.Indices {
+			if idx.Vertex != want[fi][vi] {
+				t.Errorf("face %d index %d: Vertex = %d, want %d", fi, vi, idx.Vertex, want[fi][vi])
+			}
+		}
+	}
+}
+
+// TestParseOBJReaderMatchesSerial verifies ParseOBJReader's worker-pool
+// path produces the same geometry as its Concurrency=1 serial fallback
+// for a file large enough to span several chunks.
+func TestParseOBJReaderMatchesSerial(t *testing.T) {
+	objData, _ := generateLargeOBJWithMaterials(5000, 2000)
+
+	serial, err := ParseOBJReader(strings.NewReader(objData), ParseOptions{Concurrency: 1})
+	if err != nil {
+		t.Fatalf("serial ParseOBJReader returned an error: %v", err)
+	}
+	parallel, err := ParseOBJReader(strings.NewReader(objData), ParseOptions{Concurrency: 8})
+	if err != nil {
+		t.Fatalf("parallel ParseOBJReader returned an error: %v", err)
+	}
+
+	if len(serial.Vertices) != len(parallel.Vertices) {
+		t.Fatalf("len(Vertices): serial = %d, parallel = %d", len(serial.Vertices), len(parallel.Vertices))
+	}
+	if len(serial.Faces) != len(parallel.Faces) {
+		t.Fatalf("len(Faces): serial = %d, parallel = %d", len(serial.Faces), len(parallel.Faces))
+	}
+	for i := range serial.Vertices {
+		if serial.Vertices[i] != parallel.Vertices[i] {
+			t.Fatalf("Vertices[%d]: serial = %+v, parallel = %+v", i, serial.Vertices[i], parallel.Vertices[i])
+		}
+	}
+	for i := range serial.Faces {
+		for j := range serial.Faces[i].Indices {
+			if serial.Faces[i].Indices[j] != parallel.Faces[i].Indices[j] {
+				t.Fatalf("Faces[%d].Indices[%d]: serial = %+v, parallel = %+v", i, j, serial.Faces[i].Indices[j], parallel.Faces[i].Indices[j])
+			}
+		}
+	}
+}
+
+// BenchmarkParseOBJReader_150kFaces benchmarks ParseOBJReader's
+// worker-pool path against its serial fallback on a ~150k-face mesh, the
+// scale the parallel parser is meant for.
+func BenchmarkParseOBJReader_150kFaces(b *testing.B) {
+	objData, _ := generateLargeOBJWithMaterials(150000, 150000)
+	b.SetBytes(int64(len(objData)))
+
+	b.Run("Serial", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := ParseOBJReader(strings.NewReader(objData), ParseOptions{Concurrency: 1}); err != nil {
+				b.Fatalf("ParseOBJReader returned an error: %v", err)
+			}
+		}
+	})
+	b.Run("Parallel", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := ParseOBJReader(strings.NewReader(objData), ParseOptions{}); err != nil {
+				b.Fatalf("ParseOBJReader returned an error: %v", err)
+			}
+		}
+	})
+}
+
+// TestParseOBJ_ClearcoatExtension verifies the Pc/Pcr clearcoat PBR
+// extension round-trips through parse and write.
+func TestParseOBJ_ClearcoatExtension(t *testing.T) {
+	objData := `
+v 0.0 0.0 0.0
+v 1.0 0.0 0.0
+v 1.0 1.0 0.0
+
+usemtl Clearcoat
+mtllib clearcoat.mtl
+
+f 1 2 3
+`
+	mtlData := `
+newmtl Clearcoat
+Kd 0.8 0.8 0.8
+Pm 0.0
+Pr 0.3
+Pc 1.0
+Pcr 0.1
+`
+	fsys := fstest.MapFS{
+		"clearcoat.obj": {Data: []byte(objData)},
+		"clearcoat.mtl": {Data: []byte(mtlData)},
+	}
+	obj, err := ParseFS(fsys, "clearcoat.obj")
+	if err != nil {
+		t.Fatalf("Failed to parse OBJ file: %v", err)
+	}
+	mat := obj.Materials["Clearcoat"]
+	if mat.Clearcoat != 1.0 || mat.ClearcoatRoughness != 0.1 {
+		t.Errorf("Clearcoat = %v, ClearcoatRoughness = %v, want 1.0, 0.1", mat.Clearcoat, mat.ClearcoatRoughness)
+	}
+
+	var buf bytes.Buffer
+	if err := obj.WriteMTL(&buf); err != nil {
+		t.Fatalf("WriteMTL returned an error: %v", err)
+	}
+	written := buf.String()
+	if !strings.Contains(written, "Pc 1.000000") || !strings.Contains(written, "Pcr 0.100000") {
+		t.Errorf("WriteMTL output missing clearcoat fields, got:\n%s", written)
+	}
+}
+
+// TestGenerateNormalsModeFlatPerFace verifies FlatPerFace gives each face
+// an unshared normal, even for faces that are coplanar.
+func TestGenerateNormalsModeFlatPerFace(t *testing.T) {
+	o := &Object{
+		Vertices: []Vertex{
+			{X: 0, Y: 0, Z: 0},
+			{X: 1, Y: 0, Z: 0},
+			{X: 1, Y: 1, Z: 0},
+			{X: 0, Y: 1, Z: 0},
+		},
+		Faces: []Face{
+			{Indices: []Index{{Vertex: 1}, {Vertex: 2}, {Vertex: 3}}},
+			{Indices: []Index{{Vertex: 1}, {Vertex: 3}, {Vertex: 4}}},
+		},
+	}
+	o.GenerateNormalsMode(FlatPerFace)
+	if len(o.Normals) != 2 {
+		t.Fatalf("len(Normals) = %d, want 2 (one per face, unshared)", len(o.Normals))
+	}
+	if o.Faces[0].Indices[0].Normal == o.Faces[1].Indices[0].Normal {
+		t.Error("FlatPerFace should give each face its own normal index, got a shared one")
+	}
+}
+
+// TestGenerateNormalsModeSmoothAll verifies SmoothAll blends normals
+// across a smoothing-group boundary that SmoothByGroup would respect.
+func TestGenerateNormalsModeSmoothAll(t *testing.T) {
+	o := &Object{
+		Vertices: []Vertex{
+			{X: 0, Y: 0, Z: 0},
+			{X: 1, Y: 0, Z: 0},
+			{X: 1, Y: 1, Z: 0},
+			{X: 0, Y: 1, Z: 0},
+		},
+		Faces: []Face{
+			{SmoothingGroup: 1, Indices: []Index{{Vertex: 1}, {Vertex: 2}, {Vertex: 3}}},
+			{SmoothingGroup: 2, Indices: []Index{{Vertex: 1}, {Vertex: 3}, {Vertex: 4}}},
+		},
+	}
+	o.GenerateNormalsMode(SmoothAll)
+	if o.Faces[0].Indices[0].Normal != o.Faces[1].Indices[0].Normal {
+		t.Error("SmoothAll should share vertex 1's normal across both faces despite differing SmoothingGroup")
+	}
+}
+
+// TestGenerateNormalsModeSmoothByGroup verifies SmoothByGroup only blends
+// normals within the same smoothing group, and never blends SmoothingGroup
+// 0 ("s off") faces with anything.
+func TestGenerateNormalsModeSmoothByGroup(t *testing.T) {
+	o := &Object{
+		Vertices: []Vertex{
+			{X: 0, Y: 0, Z: 0},
+			{X: 1, Y: 0, Z: 0},
+			{X: 1, Y: 1, Z: 0},
+			{X: 0, Y: 1, Z: 0},
+		},
+		Faces: []Face{
+			{SmoothingGroup: 1, Indices: []Index{{Vertex: 1}, {Vertex: 2}, {Vertex: 3}}},
+			{SmoothingGroup: 2, Indices: []Index{{Vertex: 1}, {Vertex: 3}, {Vertex: 4}}},
+		},
+	}
+	o.GenerateNormalsMode(SmoothByGroup)
+	if o.Faces[0].Indices[0].Normal == o.Faces[1].Indices[0].Normal {
+		t.Error("SmoothByGroup should not share vertex 1's normal across differing SmoothingGroup ids")
+	}
+
+	o2 := &Object{
+		Vertices: o.Vertices,
+		Faces: []Face{
+			{SmoothingGroup: 0, Indices: []Index{{Vertex: 1}, {Vertex: 2}, {Vertex: 3}}},
+			{SmoothingGroup: 0, Indices: []Index{{Vertex: 1}, {Vertex: 3}, {Vertex: 4}}},
+		},
+	}
+	o2.GenerateNormalsMode(SmoothByGroup)
+	if o2.Faces[0].Indices[0].Normal == o2.Faces[1].Indices[0].Normal {
+		t.Error("SmoothByGroup should never blend SmoothingGroup 0 (\"s off\") faces together")
+	}
+}
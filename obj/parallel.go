@@ -0,0 +1,429 @@
+package obj
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// parallelChunkBytes is the target size of each line-batch ParseOBJReader
+// hands to a worker, matching the "64 KiB, newline-aligned" batching a
+// reader goroutine would use to feed a worker pool without splitting a
+// directive across two chunks.
+const parallelChunkBytes = 64 * 1024
+
+// ParseOBJReader parses OBJ data from r using a worker pool, for callers
+// who already have an io.Reader (an HTTP body, a pipe, an in-memory
+// buffer) rather than a fs.FS and want parsing itself -- not just I/O --
+// spread across cores for a large mesh.
+//
+// The input is split into contiguous, newline-aligned batches of about
+// 64 KiB and handed to opts.Concurrency worker goroutines (default
+// runtime.NumCPU()), each of which parses its batch's v/vt/vn/f lines
+// into local slices. A final merge stage concatenates the per-worker
+// slices, in file order, into the returned Object.
+//
+// OBJ's negative (relative) face indices are defined relative to a
+// running vertex/texcoord/normal count, which is inherently sequential
+// information a worker processing an arbitrary later batch doesn't have.
+// ParseOBJReader resolves every relative index to its absolute equivalent
+// in one cheap sequential pre-pass -- counting v/vt/vn directives and
+// rewriting only the face tokens that need it, without parsing any
+// vertex data -- before handing batches to the pool, so workers never
+// need to coordinate with each other or with the pre-pass's directive
+// state (usemtl/o/g/s), which the pre-pass also snapshots at each batch
+// boundary for the same reason.
+//
+// mtllib is not resolved here: ParseOBJReader has no fs.FS to load it
+// against, so usemtl still tags Face.Material but Object.Materials is
+// left empty. Use ParseFS/ParseFSWithOptions when materials must load.
+//
+// ParseOBJReader falls back to parsing serially on the calling goroutine
+// -- skipping the pre-pass and worker pool entirely -- when
+// opts.Concurrency is 1 or the input is smaller than the target batch
+// size, since worker-pool setup wouldn't pay for itself.
+func ParseOBJReader(r io.Reader, opts ParseOptions) (*Object, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	concurrency := opts.Concurrency
+	if concurrency == 0 {
+		concurrency = runtime.NumCPU()
+	}
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	if concurrency == 1 || len(data) < parallelChunkBytes {
+		return parseOBJReaderSerial(data, opts)
+	}
+
+	chunks, err := prepareOBJChunks(data)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]objChunkResult, len(chunks))
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, concurrency)
+	for i, c := range chunks {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, c objChunk) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i] = parseOBJChunk(c)
+		}(i, c)
+	}
+	wg.Wait()
+
+	return mergeOBJChunks(results, opts)
+}
+
+// parseOBJReaderSerial parses data on the calling goroutine, reusing the
+// same Decoder/parser pipeline ParseFSWithOptions drives. It leaves
+// parser.fsys nil, which (like the worker-pool path) makes mtllib a no-op
+// rather than an error.
+func parseOBJReaderSerial(data []byte, opts ParseOptions) (*Object, error) {
+	p := &parser{
+		obj:        &Object{Materials: make(map[string]*Material)},
+		groupIndex: make(map[string]int),
+	}
+	if err := Walk(bytes.NewReader(data), p.apply); err != nil {
+		return nil, err
+	}
+	p.obj.computeSmoothNormals()
+	if opts.Triangulate {
+		return p.obj.Triangulate(), nil
+	}
+	return p.obj, nil
+}
+
+// objLine is one line of OBJ source together with its 1-based line
+// number, carried through the pre-pass into a worker's batch so any
+// parse error it hits can still report an accurate line.
+type objLine struct {
+	text string
+	line int
+}
+
+// objChunk is one newline-aligned batch of OBJ source handed to a single
+// worker, along with the usemtl/o/g/s state active at its first line --
+// the same state a sequential parser would have accumulated by that
+// point -- since the worker starts parsing mid-file with no other context.
+type objChunk struct {
+	lines          []objLine
+	startMaterial  string
+	startObject    string
+	startGroups    []string
+	startSmoothing int
+}
+
+// objChunkResult is the geometry one worker parsed from its objChunk,
+// ready to be concatenated onto every other chunk's result in order.
+type objChunkResult struct {
+	vertices  []Vertex
+	texCoords []TexCoord
+	normals   []Normal
+	faces     []Face
+	unknown   []string
+	err       error
+}
+
+// prepareOBJChunks performs the sequential pre-pass: it counts v/vt/vn
+// directives to resolve relative face indices to absolute ones (rewriting
+// the affected tokens in place), tracks usemtl/o/g/s state, and splits
+// the result into ~parallelChunkBytes batches, snapshotting that state at
+// each batch boundary.
+func prepareOBJChunks(data []byte) ([]objChunk, error) {
+	var chunks []objChunk
+	var cur []objLine
+	curBytes := 0
+	material, object := "", ""
+	var groups []string
+	smoothing := 0
+	vCount, vtCount, vnCount := 0, 0, 0
+
+	flush := func() {
+		if len(cur) == 0 {
+			return
+		}
+		chunks = append(chunks, objChunk{
+			lines:          cur,
+			startMaterial:  material,
+			startObject:    object,
+			startGroups:    groups,
+			startSmoothing: smoothing,
+		})
+		cur = nil
+		curBytes = 0
+	}
+
+	lineNo := 0
+	for _, raw := range bytes.Split(data, []byte("\n")) {
+		lineNo++
+		text := strings.TrimRight(string(raw), "\r")
+		line := strings.TrimSpace(text)
+		if line != "" && !strings.HasPrefix(line, "#") {
+			directive, rest := splitOBJDirective(line)
+			switch directive {
+			case "v":
+				vCount++
+			case "vt":
+				vtCount++
+			case "vn":
+				vnCount++
+			case "f":
+				tokens := strings.Fields(rest)
+				for i, tok := range tokens {
+					newTok, changed, err := absolutizeFaceToken(tok, vCount, vtCount, vnCount)
+					if err != nil {
+						return nil, &ParseError{Line: lineNo, LineText: line, Msg: fmt.Sprintf("invalid face index '%s': %v", tok, err)}
+					}
+					if changed {
+						tokens[i] = newTok
+					}
+				}
+				text = "f " + strings.Join(tokens, " ")
+				line = text
+			case "usemtl":
+				material = rest
+			case "o":
+				object = strings.TrimSpace(rest)
+			case "g":
+				groups = strings.Fields(rest)
+			case "s":
+				if rest == "off" {
+					smoothing = 0
+				} else if n, err := strconv.Atoi(rest); err == nil {
+					smoothing = n
+				}
+			}
+		}
+		cur = append(cur, objLine{text: line, line: lineNo})
+		curBytes += len(text) + 1
+		if curBytes >= parallelChunkBytes {
+			flush()
+		}
+	}
+	flush()
+	return chunks, nil
+}
+
+// splitOBJDirective splits a trimmed, non-empty, non-comment OBJ line
+// into its directive and the remainder, mirroring Decoder.Next's own
+// line-splitting so the pre-pass and the worker agree on directive names.
+func splitOBJDirective(line string) (directive, rest string) {
+	sp := strings.IndexByte(line, ' ')
+	if sp == -1 {
+		return line, ""
+	}
+	return line[:sp], line[sp+1:]
+}
+
+// absolutizeFaceToken rewrites a single face index token's negative
+// (relative) components to their absolute equivalent, using vCount/
+// vtCount/vnCount as the running counts at this line. Non-negative
+// components are left untouched and changed is reported false.
+func absolutizeFaceToken(tok string, vCount, vtCount, vnCount int) (rewritten string, changed bool, err error) {
+	parts := strings.Split(tok, "/")
+	for i, p := range parts {
+		if p == "" {
+			continue
+		}
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return tok, false, err
+		}
+		if n >= 0 {
+			continue
+		}
+		size := vCount
+		if i == 1 {
+			size = vtCount
+		} else if i == 2 {
+			size = vnCount
+		}
+		abs, err := resolveIndex(n, size)
+		if err != nil {
+			return tok, false, err
+		}
+		parts[i] = strconv.Itoa(abs)
+		changed = true
+	}
+	if !changed {
+		return tok, false, nil
+	}
+	return strings.Join(parts, "/"), true, nil
+}
+
+// parseOBJChunk parses one objChunk's lines into local vertex/texcoord/
+// normal/face slices. Face index tokens are already absolute (the
+// pre-pass resolved any relative ones), so this never needs to know
+// another chunk's vertex count.
+func parseOBJChunk(c objChunk) objChunkResult {
+	var res objChunkResult
+	material, object, smoothing := c.startMaterial, c.startObject, c.startSmoothing
+	groups := c.startGroups
+
+	fail := func(ln objLine, format string, args ...interface{}) objChunkResult {
+		res.err = &ParseError{Line: ln.line, LineText: ln.text, Msg: fmt.Sprintf(format, args...)}
+		return res
+	}
+
+	for _, ln := range c.lines {
+		line := ln.text
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		directive, rest := splitOBJDirective(line)
+		switch directive {
+		case "v":
+			fields := strings.Fields(rest)
+			if len(fields) < 3 {
+				return fail(ln, "invalid vertex data: expected at least 3 components, got %d", len(fields))
+			}
+			x, y, z, err := parseFloat3(fields)
+			if err != nil {
+				return fail(ln, "invalid vertex data: %v", err)
+			}
+			res.vertices = append(res.vertices, Vertex{X: x, Y: y, Z: z})
+		case "vt":
+			fields := strings.Fields(rest)
+			if len(fields) < 2 {
+				return fail(ln, "invalid texture coordinate data: expected at least 2 components, got %d", len(fields))
+			}
+			u, err := strconv.ParseFloat(fields[0], 64)
+			if err != nil {
+				return fail(ln, "invalid texture U coordinate: %v", err)
+			}
+			v, err := strconv.ParseFloat(fields[1], 64)
+			if err != nil {
+				return fail(ln, "invalid texture V coordinate: %v", err)
+			}
+			res.texCoords = append(res.texCoords, TexCoord{U: u, V: v})
+		case "vn":
+			fields := strings.Fields(rest)
+			if len(fields) < 3 {
+				return fail(ln, "invalid normal data: expected at least 3 components, got %d", len(fields))
+			}
+			x, y, z, err := parseFloat3(fields)
+			if err != nil {
+				return fail(ln, "invalid normal data: %v", err)
+			}
+			res.normals = append(res.normals, Normal{X: x, Y: y, Z: z})
+		case "f":
+			tokens := strings.Fields(rest)
+			if len(tokens) < 3 {
+				return fail(ln, "face definition error: a face must have at least 3 vertices, got %d", len(tokens))
+			}
+			indices := make([]Index, 0, len(tokens))
+			for _, tok := range tokens {
+				idx, err := parseAbsoluteFaceIndex(tok)
+				if err != nil {
+					return fail(ln, "invalid face index '%s': %v", tok, err)
+				}
+				indices = append(indices, idx)
+			}
+			res.faces = append(res.faces, Face{
+				Indices:        indices,
+				Material:       material,
+				SmoothingGroup: smoothing,
+				Object:         object,
+				Groups:         groups,
+			})
+		case "usemtl":
+			material = rest
+		case "o":
+			object = strings.TrimSpace(rest)
+		case "g":
+			groups = strings.Fields(rest)
+		case "s":
+			if rest == "off" {
+				smoothing = 0
+			} else if n, err := strconv.Atoi(rest); err == nil {
+				smoothing = n
+			}
+		case "mtllib":
+			// Not resolved here; see ParseOBJReader's doc comment.
+		default:
+			res.unknown = append(res.unknown, line)
+		}
+	}
+	return res
+}
+
+// parseAbsoluteFaceIndex parses a face index token ("v", "v/vt", "v//vn",
+// or "v/vt/vn") whose components are already absolute and 1-based --
+// never relative -- since prepareOBJChunks resolves relative tokens
+// before any worker sees them.
+func parseAbsoluteFaceIndex(tok string) (Index, error) {
+	var idx Index
+	parts := strings.Split(tok, "/")
+	v, err := strconv.Atoi(parts[0])
+	if err != nil || v < 1 {
+		return idx, fmt.Errorf("invalid vertex index")
+	}
+	idx.Vertex = v
+	if len(parts) >= 2 && parts[1] != "" {
+		vt, err := strconv.Atoi(parts[1])
+		if err != nil || vt < 1 {
+			return idx, fmt.Errorf("invalid texture coordinate index")
+		}
+		idx.TexCoord = vt
+	}
+	if len(parts) == 3 && parts[2] != "" {
+		vn, err := strconv.Atoi(parts[2])
+		if err != nil || vn < 1 {
+			return idx, fmt.Errorf("invalid normal index")
+		}
+		idx.Normal = vn
+	}
+	return idx, nil
+}
+
+// mergeOBJChunks concatenates every worker's result, in chunk (file)
+// order, into the returned Object, then validates that every face index
+// -- now that the full Vertices/TexCoords/Normals slices exist -- is in
+// range, since no single chunk could check that against data another
+// chunk produced.
+func mergeOBJChunks(results []objChunkResult, opts ParseOptions) (*Object, error) {
+	obj := &Object{Materials: make(map[string]*Material)}
+	for i, r := range results {
+		if r.err != nil {
+			if pe, ok := r.err.(*ParseError); ok {
+				return nil, pe
+			}
+			return nil, fmt.Errorf("chunk %d: %w", i, r.err)
+		}
+		obj.Vertices = append(obj.Vertices, r.vertices...)
+		obj.TexCoords = append(obj.TexCoords, r.texCoords...)
+		obj.Normals = append(obj.Normals, r.normals...)
+		obj.Faces = append(obj.Faces, r.faces...)
+		obj.UnknownDirectives = append(obj.UnknownDirectives, r.unknown...)
+	}
+	for _, f := range obj.Faces {
+		for _, idx := range f.Indices {
+			if idx.Vertex < 1 || idx.Vertex > len(obj.Vertices) {
+				return nil, fmt.Errorf("vertex index %d out of range (1 to %d)", idx.Vertex, len(obj.Vertices))
+			}
+			if idx.TexCoord != 0 && (idx.TexCoord < 1 || idx.TexCoord > len(obj.TexCoords)) {
+				return nil, fmt.Errorf("texture coordinate index %d out of range (1 to %d)", idx.TexCoord, len(obj.TexCoords))
+			}
+			if idx.Normal != 0 && (idx.Normal < 1 || idx.Normal > len(obj.Normals)) {
+				return nil, fmt.Errorf("normal index %d out of range (1 to %d)", idx.Normal, len(obj.Normals))
+			}
+		}
+	}
+	obj.Groups = rebuildGroups(obj.Faces)
+	obj.computeSmoothNormals()
+	if opts.Triangulate {
+		return obj.Triangulate(), nil
+	}
+	return obj, nil
+}
@@ -0,0 +1,287 @@
+package obj
+
+import "math"
+
+// Triangulate returns a copy of o with every face fan-triangulated: a face
+// with indices v0 v1 v2 v3 ... vn becomes the triangles (v0,v1,v2),
+// (v0,v2,v3), ..., (v0,v_{n-1},v_n). Each resulting triangle keeps its
+// parent face's Material, SmoothingGroup, Object, and Groups, so
+// downstream grouping and material assignment survive the split. Faces
+// already triangles pass through unchanged.
+//
+// Fan triangulation is cheap but only correct for convex faces; a concave
+// n-gon fanned this way can produce triangles that fold outside the
+// original polygon. Use TriangulateEarClipping for concave geometry.
+func (o *Object) Triangulate() *Object {
+	return o.triangulateWith(fanTriangulateFace)
+}
+
+// TriangulateEarClipping returns a copy of o with every face triangulated
+// by classic ear-clipping: the face's vertices are projected onto the
+// plane of its Newell-method normal, then triangles are repeatedly cut
+// from convex corners ("ears") whose triangle contains no other vertex of
+// the polygon, until three vertices remain. Unlike Triangulate, this
+// produces a correct decomposition for concave (non-convex) faces.
+//
+// A face degenerate enough that no ear can be found (e.g. self-intersecting
+// or collinear) falls back to fan triangulation for its remaining vertices
+// rather than failing outright.
+func (o *Object) TriangulateEarClipping() *Object {
+	return o.triangulateWith(o.earClipFace)
+}
+
+// triangulateWith builds a copy of o whose Faces have each been replaced
+// by split(face), sharing o's vertex/texcoord/normal/material data and
+// rebuilding Groups to reference the new, larger Faces slice.
+func (o *Object) triangulateWith(split func(Face) []Face) *Object {
+	out := &Object{
+		Vertices:          o.Vertices,
+		TexCoords:         o.TexCoords,
+		Normals:           o.Normals,
+		Materials:         o.Materials,
+		UnknownDirectives: o.UnknownDirectives,
+		Faces:             make([]Face, 0, len(o.Faces)),
+	}
+	for _, f := range o.Faces {
+		out.Faces = append(out.Faces, split(f)...)
+	}
+	out.Groups = rebuildGroups(out.Faces)
+	return out
+}
+
+// rebuildGroups reconstructs a Groups index from scratch by replaying each
+// face's Object/Groups tags against its position in faces, the way
+// parser.assignFaceToGroups does incrementally during parsing. Used
+// whenever a transform (e.g. Triangulate) changes the Faces slice enough
+// that the old Group.Faces indices would no longer line up.
+func rebuildGroups(faces []Face) []Group {
+	index := make(map[string]int)
+	var groups []Group
+	for i, f := range faces {
+		for _, name := range f.Groups {
+			key := f.Object + "\x00" + name
+			gi, ok := index[key]
+			if !ok {
+				groups = append(groups, Group{Name: name, Object: f.Object, Smoothing: f.SmoothingGroup})
+				gi = len(groups) - 1
+				index[key] = gi
+			}
+			groups[gi].Faces = append(groups[gi].Faces, i)
+		}
+	}
+	return groups
+}
+
+// fanTriangulateFace splits f into a triangle fan around its first vertex.
+func fanTriangulateFace(f Face) []Face {
+	n := len(f.Indices)
+	if n <= 3 {
+		return []Face{f}
+	}
+	tris := make([]Face, 0, n-2)
+	for i := 1; i < n-1; i++ {
+		tris = append(tris, derivedTriangle(f, f.Indices[0], f.Indices[i], f.Indices[i+1]))
+	}
+	return tris
+}
+
+// derivedTriangle builds a 3-index Face carrying f's Material,
+// SmoothingGroup, Object, and Groups, for a triangle produced by splitting
+// f into smaller pieces.
+func derivedTriangle(f Face, a, b, c Index) Face {
+	return Face{
+		Indices:        []Index{a, b, c},
+		Material:       f.Material,
+		SmoothingGroup: f.SmoothingGroup,
+		Object:         f.Object,
+		Groups:         f.Groups,
+	}
+}
+
+// point2 is a 2D point used while ear-clipping a face projected onto its
+// own plane.
+type point2 struct {
+	X, Y float64
+}
+
+// earClipFace triangulates f by classic ear-clipping. It projects f's
+// vertices onto the plane defined by their Newell-method normal, then
+// repeatedly clips convex, empty ears until three vertices remain.
+func (o *Object) earClipFace(f Face) []Face {
+	n := len(f.Indices)
+	if n <= 3 {
+		return []Face{f}
+	}
+	positions := make([]Vertex, n)
+	for i, idx := range f.Indices {
+		positions[i] = o.Vertices[idx.Vertex-1]
+	}
+	u, v := orthonormalBasis(newellNormal(positions))
+	poly := make([]point2, n)
+	for i, p := range positions {
+		poly[i] = point2{X: dotVertex(p, u), Y: dotVertex(p, v)}
+	}
+
+	order, ok := earClipIndices(poly)
+	if !ok {
+		// Degenerate polygon (e.g. collinear or self-intersecting):
+		// fan-triangulate rather than fail outright.
+		return fanTriangulateFace(f)
+	}
+	tris := make([]Face, 0, len(order))
+	for _, tri := range order {
+		tris = append(tris, derivedTriangle(f, f.Indices[tri[0]], f.Indices[tri[1]], f.Indices[tri[2]]))
+	}
+	return tris
+}
+
+// newellNormal computes a polygon's normal via Newell's method, which
+// tolerates non-planar and concave input better than a single cross
+// product of two edges would.
+func newellNormal(pts []Vertex) Vertex {
+	var n Vertex
+	m := len(pts)
+	for i := 0; i < m; i++ {
+		cur := pts[i]
+		next := pts[(i+1)%m]
+		n.X += (cur.Y - next.Y) * (cur.Z + next.Z)
+		n.Y += (cur.Z - next.Z) * (cur.X + next.X)
+		n.Z += (cur.X - next.X) * (cur.Y + next.Y)
+	}
+	return n
+}
+
+// orthonormalBasis returns two unit vectors spanning the plane
+// perpendicular to n, for projecting a planar polygon's 3D vertices down
+// to 2D before ear-clipping.
+func orthonormalBasis(n Vertex) (u, v Vertex) {
+	n = normalizeVertex(n)
+	// Pick a helper axis not nearly parallel to n.
+	helper := Vertex{X: 1, Y: 0, Z: 0}
+	if math.Abs(n.X) > 0.9 {
+		helper = Vertex{X: 0, Y: 1, Z: 0}
+	}
+	u = normalizeVertex(crossVertex(helper, n))
+	v = crossVertex(n, u)
+	return u, v
+}
+
+func dotVertex(a, b Vertex) float64 {
+	return a.X*b.X + a.Y*b.Y + a.Z*b.Z
+}
+
+func crossVertex(a, b Vertex) Vertex {
+	return Vertex{
+		X: a.Y*b.Z - a.Z*b.Y,
+		Y: a.Z*b.X - a.X*b.Z,
+		Z: a.X*b.Y - a.Y*b.X,
+	}
+}
+
+func normalizeVertex(a Vertex) Vertex {
+	length := math.Sqrt(dotVertex(a, a))
+	if length == 0 {
+		return a
+	}
+	return Vertex{X: a.X / length, Y: a.Y / length, Z: a.Z / length}
+}
+
+// earClipIndices triangulates a simple polygon (given in either winding)
+// via ear-clipping, returning triangles as index triples into poly. ok is
+// false if the polygon degenerates (collinear, or no ear can be found)
+// before three vertices remain.
+func earClipIndices(poly []point2) (triangles [][3]int, ok bool) {
+	n := len(poly)
+	remaining := make([]int, n)
+	for i := range remaining {
+		remaining[i] = i
+	}
+	if signedArea(poly, remaining) < 0 {
+		reverse(remaining)
+	}
+
+	for len(remaining) > 3 {
+		clipped := false
+		for i := range remaining {
+			prev := remaining[(i-1+len(remaining))%len(remaining)]
+			cur := remaining[i]
+			next := remaining[(i+1)%len(remaining)]
+			if !isConvexCorner(poly[prev], poly[cur], poly[next]) {
+				continue
+			}
+			if triangleContainsAnyOther(poly, remaining, prev, cur, next) {
+				continue
+			}
+			triangles = append(triangles, [3]int{prev, cur, next})
+			remaining = append(remaining[:i], remaining[i+1:]...)
+			clipped = true
+			break
+		}
+		if !clipped {
+			return nil, false
+		}
+	}
+	if len(remaining) == 3 {
+		triangles = append(triangles, [3]int{remaining[0], remaining[1], remaining[2]})
+	}
+	return triangles, true
+}
+
+// signedArea returns twice the signed area of the polygon poly[order[i]],
+// positive for counter-clockwise winding.
+func signedArea(poly []point2, order []int) float64 {
+	var sum float64
+	n := len(order)
+	for i := 0; i < n; i++ {
+		a := poly[order[i]]
+		b := poly[order[(i+1)%n]]
+		sum += a.X*b.Y - b.X*a.Y
+	}
+	return sum
+}
+
+// isConvexCorner reports whether the corner at b, walking a->b->c around a
+// counter-clockwise polygon, turns left (convex).
+func isConvexCorner(a, b, c point2) bool {
+	return cross2(sub2(b, a), sub2(c, b)) > 0
+}
+
+// triangleContainsAnyOther reports whether any polygon vertex other than
+// a, b, c itself lies inside or on triangle (a,b,c), which disqualifies it
+// as an ear.
+func triangleContainsAnyOther(poly []point2, order []int, a, b, c int) bool {
+	for _, idx := range order {
+		if idx == a || idx == b || idx == c {
+			continue
+		}
+		if pointInTriangle(poly[idx], poly[a], poly[b], poly[c]) {
+			return true
+		}
+	}
+	return false
+}
+
+// pointInTriangle reports whether p lies inside or on triangle (a,b,c)
+// using barycentric sign tests.
+func pointInTriangle(p, a, b, c point2) bool {
+	d1 := cross2(sub2(b, a), sub2(p, a))
+	d2 := cross2(sub2(c, b), sub2(p, b))
+	d3 := cross2(sub2(a, c), sub2(p, c))
+	hasNeg := d1 < 0 || d2 < 0 || d3 < 0
+	hasPos := d1 > 0 || d2 > 0 || d3 > 0
+	return !(hasNeg && hasPos)
+}
+
+func sub2(a, b point2) point2 {
+	return point2{X: a.X - b.X, Y: a.Y - b.Y}
+}
+
+func cross2(a, b point2) float64 {
+	return a.X*b.Y - a.Y*b.X
+}
+
+func reverse(s []int) {
+	for i, j := 0, len(s)-1; i < j; i, j = i+1, j-1 {
+		s[i], s[j] = s[j], s[i]
+	}
+}
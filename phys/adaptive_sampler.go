@@ -0,0 +1,183 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+
+package phys
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
+)
+
+// defaultAdaptivePilotFraction is the portion of RenderOptions.RaysPerPixel
+// spent on renderTileAdaptive's pilot pass, before the remaining budget is
+// redistributed by priority.
+const defaultAdaptivePilotFraction = 4
+
+// defaultAdaptiveMaxSamplesMultiplier sets RenderOptions.AdaptiveMaxSamples's
+// default (RaysPerPixel * this) when it is left at zero.
+const defaultAdaptiveMaxSamplesMultiplier = 4
+
+// adaptiveSampleAccumulator holds the running sums renderTileAdaptive needs
+// to estimate a pixel's luminance/normal/albedo variance (priority) and its
+// final averaged radiance, without retaining each individual sample.
+type adaptiveSampleAccumulator struct {
+	n              int
+	sumRadiance    Spectrum
+	sumLuminance   float64
+	sumLuminanceSq float64
+	sumNormal      r3.Vec
+	sumAlbedo      Spectrum
+	sumAlbedoSq    Spectrum
+}
+
+func (a *adaptiveSampleAccumulator) add(radiance Spectrum, hints ShadingHints) {
+	a.n++
+	a.sumRadiance = a.sumRadiance.Add(radiance)
+	lum := radiance.Luminance()
+	a.sumLuminance += lum
+	a.sumLuminanceSq += lum * lum
+	a.sumNormal = a.sumNormal.Add(hints.Normal)
+	a.sumAlbedo = a.sumAlbedo.Add(hints.Albedo)
+	a.sumAlbedoSq = a.sumAlbedoSq.Add(hints.Albedo.Mul(hints.Albedo))
+}
+
+// mean returns the averaged radiance accumulated so far.
+func (a *adaptiveSampleAccumulator) mean() Spectrum {
+	if a.n == 0 {
+		return Spectrum{}
+	}
+	return a.sumRadiance.Divs(float64(a.n))
+}
+
+// priority estimates how much this pixel would benefit from more samples:
+// w_L*variance(luminance) + w_N*(1 - |mean normal|) + w_A*variance(albedo).
+// A mean normal shorter than 1 means the pilot samples disagreed about the
+// shading normal (a silhouette or geometric-discontinuity pixel); albedo
+// variance catches texture and material boundaries; luminance variance
+// catches noisy indirect lighting. The three terms are unitless variances
+// in [0, ~1] for normalized inputs, so equal weights are a reasonable
+// starting point.
+func (a *adaptiveSampleAccumulator) priority() float64 {
+	if a.n == 0 {
+		return 0
+	}
+	n := float64(a.n)
+
+	meanLum := a.sumLuminance / n
+	varLum := max(0, a.sumLuminanceSq/n-meanLum*meanLum)
+
+	meanNormal := a.sumNormal.Divs(n)
+	normalTerm := 1 - meanNormal.Length()
+
+	meanAlbedo := a.sumAlbedo.Divs(n)
+	varAlbedo := max(0, a.sumAlbedoSq.X/n-meanAlbedo.X*meanAlbedo.X) +
+		max(0, a.sumAlbedoSq.Y/n-meanAlbedo.Y*meanAlbedo.Y) +
+		max(0, a.sumAlbedoSq.Z/n-meanAlbedo.Z*meanAlbedo.Z)
+
+	const wLuminance, wNormal, wAlbedo = 1.0, 1.0, 1.0
+	return wLuminance*varLum + wNormal*normalTerm + wAlbedo*varAlbedo
+}
+
+// renderTileAdaptive renders t with a pilot pass followed by a
+// priority-weighted remaining sample budget, instead of uniform
+// RenderOptions.RaysPerPixel sampling: renderTile dispatches here when
+// RenderOptions.AdaptiveSampling is set. See adaptiveSampleAccumulator.priority
+// for how a pixel's share of the remaining budget is decided. Each sample
+// seeds its own NewPixelRand(Seed, cx, cy, sample), so a pixel's Nth
+// sample is the same whether or not that pixel's extra budget (and so its
+// total sample count) ends up differing from its tile-mates.
+func renderTileAdaptive(ctx context.Context, scene *Scene, camera Camera, t tile, img *image.RGBA, stats *RenderStats) {
+	opts := scene.RenderOptions
+	dx, dy := opts.Dx, opts.Dy
+	width, height := t.x1-t.x0, t.y1-t.y0
+	if width <= 0 || height <= 0 {
+		return
+	}
+
+	pilotSamples := max(1, opts.RaysPerPixel/defaultAdaptivePilotFraction)
+	minSamples := opts.AdaptiveMinSamples
+	if minSamples <= 0 {
+		minSamples = 1
+	}
+	maxSamples := opts.AdaptiveMaxSamples
+	if maxSamples <= 0 {
+		maxSamples = opts.RaysPerPixel * defaultAdaptiveMaxSamplesMultiplier
+	}
+
+	accum := make([]adaptiveSampleAccumulator, width*height)
+
+	// Pilot pass: every pixel gets the same small sample count so its
+	// priority can be estimated.
+	for y := t.y0; y < t.y1; y++ {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+		for x := t.x0; x < t.x1; x++ {
+			cx := clamp(x, 0, dx-1)
+			cy := clamp(y, 0, dy-1)
+			imgy := dy - 1 - cy
+			a := &accum[(y-t.y0)*width+(x-t.x0)]
+			for sample := 0; sample < pilotSamples; sample++ {
+				if ctx.Err() != nil {
+					return
+				}
+				rand := NewPixelRand(opts.Seed, cx, cy, sample)
+				radiance, hints := castPixelSample(ctx, scene, camera, rand, stats, cx, cy, imgy, sample, pilotSamples)
+				a.add(radiance, hints)
+			}
+		}
+	}
+
+	// Normalize each pixel's priority by the tile's mean priority, then
+	// spend the remaining per-pixel budget in proportion to it.
+	priorities := make([]float64, len(accum))
+	var sumPriority float64
+	for i := range accum {
+		priorities[i] = accum[i].priority()
+		sumPriority += priorities[i]
+	}
+	meanPriority := sumPriority / float64(len(accum))
+	if meanPriority <= 0 {
+		meanPriority = 1 // Every pixel in the tile was flat; spend the extra budget uniformly.
+	}
+	extraBudget := opts.RaysPerPixel - pilotSamples
+
+	for y := t.y0; y < t.y1; y++ {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+		for x := t.x0; x < t.x1; x++ {
+			cx := clamp(x, 0, dx-1)
+			cy := clamp(y, 0, dy-1)
+			imgy := dy - 1 - cy
+			idx := (y-t.y0)*width + (x - t.x0)
+			a := &accum[idx]
+
+			extra := int(math.Round(float64(extraBudget) * priorities[idx] / meanPriority))
+			total := clamp(pilotSamples+extra, minSamples, maxSamples)
+			for sample := a.n; sample < total; sample++ {
+				if ctx.Err() != nil {
+					return
+				}
+				rand := NewPixelRand(opts.Seed, cx, cy, sample)
+				radiance, hints := castPixelSample(ctx, scene, camera, rand, stats, cx, cy, imgy, sample, total)
+				a.add(radiance, hints)
+			}
+
+			rgb := a.mean()
+			img.Set(x, imgy, color.RGBA{
+				R: uint8(math.Min(255, 255.99*rgb.X)),
+				G: uint8(math.Min(255, 255.99*rgb.Y)),
+				B: uint8(math.Min(255, 255.99*rgb.Z)),
+				A: 255,
+			})
+		}
+	}
+}
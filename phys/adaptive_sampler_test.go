@@ -0,0 +1,59 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+
+package phys
+
+import (
+	"testing"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
+)
+
+// TestAdaptiveSampleAccumulatorFlatPixelHasZeroPriority verifies that a
+// pixel whose samples all agree (same radiance, normal, and albedo) gets
+// zero priority, since there is nothing more for additional samples to
+// resolve.
+func TestAdaptiveSampleAccumulatorFlatPixelHasZeroPriority(t *testing.T) {
+	var a adaptiveSampleAccumulator
+	hints := ShadingHints{Normal: r3.Vec{X: 0, Y: 0, Z: 1}, Albedo: Spectrum{X: 0.5, Y: 0.5, Z: 0.5}}
+	for i := 0; i < 8; i++ {
+		a.add(Spectrum{X: 0.25, Y: 0.25, Z: 0.25}, hints)
+	}
+	if got := a.priority(); got != 0 {
+		t.Errorf("priority() = %v, want 0 for a perfectly flat pixel", got)
+	}
+}
+
+// TestAdaptiveSampleAccumulatorNoisyPixelHasHigherPriority verifies that a
+// pixel with varying luminance across samples scores a higher priority
+// than a flat one, so renderTileAdaptive spends more of the remaining
+// budget there.
+func TestAdaptiveSampleAccumulatorNoisyPixelHasHigherPriority(t *testing.T) {
+	hints := ShadingHints{Normal: r3.Vec{X: 0, Y: 0, Z: 1}, Albedo: Spectrum{X: 0.5, Y: 0.5, Z: 0.5}}
+
+	var flat adaptiveSampleAccumulator
+	var noisy adaptiveSampleAccumulator
+	for i := 0; i < 8; i++ {
+		flat.add(Spectrum{X: 0.5, Y: 0.5, Z: 0.5}, hints)
+		if i%2 == 0 {
+			noisy.add(Spectrum{X: 0, Y: 0, Z: 0}, hints)
+		} else {
+			noisy.add(Spectrum{X: 1, Y: 1, Z: 1}, hints)
+		}
+	}
+	if noisy.priority() <= flat.priority() {
+		t.Errorf("priority() noisy=%v flat=%v, want noisy > flat", noisy.priority(), flat.priority())
+	}
+}
+
+// TestAdaptiveSampleAccumulatorMean verifies mean() averages the
+// accumulated radiance.
+func TestAdaptiveSampleAccumulatorMean(t *testing.T) {
+	var a adaptiveSampleAccumulator
+	a.add(Spectrum{X: 1, Y: 0, Z: 0}, ShadingHints{})
+	a.add(Spectrum{X: 0, Y: 1, Z: 0}, ShadingHints{})
+	got := a.mean()
+	want := Spectrum{X: 0.5, Y: 0.5, Z: 0}
+	if got != want {
+		t.Errorf("mean() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,28 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+
+package phys
+
+import (
+	"fmt"
+	"math"
+)
+
+// Angle represents a planar angle in float64 radians.
+type Angle float64
+
+const (
+	Radian Angle = 1
+	Degree       = math.Pi / 180 * Radian
+)
+
+func (a Angle) Radians() float64 {
+	return float64(a)
+}
+
+func (a Angle) Degrees() float64 {
+	return float64(a) / float64(Degree)
+}
+
+func (a Angle) String() string {
+	return fmt.Sprintf("%f°", a.Degrees())
+}
@@ -0,0 +1,90 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+
+// Package anim provides keyframe-based camera animation, built on
+// phys.Quaternion so orientation interpolates by Slerp instead of lerping
+// LookAt/VUp directions independently the way phys.CameraTimeline does.
+package anim
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/phys"
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
+)
+
+// CameraKeyframe is one pose a CameraTrack passes through at Time.
+type CameraKeyframe struct {
+	Time        time.Duration
+	Position    r3.Point
+	Orientation phys.Quaternion
+	FoV         phys.Angle
+}
+
+// CameraTrack holds an ordered-by-Time sequence of CameraKeyframe. Sample
+// interpolates Position and FoV linearly and Orientation by Slerp, the
+// same split CameraTimeline uses for LookFrom versus VUp/LookAt direction.
+type CameraTrack struct {
+	Keyframes []CameraKeyframe
+}
+
+// Validate reports whether t has at least one keyframe with strictly
+// increasing Time.
+func (t CameraTrack) Validate() error {
+	if len(t.Keyframes) == 0 {
+		return fmt.Errorf("phys/anim: CameraTrack has no keyframes")
+	}
+	for i := 1; i < len(t.Keyframes); i++ {
+		if t.Keyframes[i].Time <= t.Keyframes[i-1].Time {
+			return fmt.Errorf("phys/anim: keyframe %d: Time %v must be strictly greater than keyframe %d's Time %v", i, t.Keyframes[i].Time, i-1, t.Keyframes[i-1].Time)
+		}
+	}
+	return nil
+}
+
+// Duration is the last keyframe's Time, the point at which Sample stops
+// advancing and holds the final pose.
+func (t CameraTrack) Duration() time.Duration {
+	return t.Keyframes[len(t.Keyframes)-1].Time
+}
+
+// bracket returns the index of the keyframe at or before at and the
+// normalized [0, 1] progress u toward the next keyframe, clamping at to
+// t's first/last keyframe. Mirrors CameraTimeline.bracket.
+func (t CameraTrack) bracket(at time.Duration) (i int, u float64) {
+	n := len(t.Keyframes)
+	if n == 1 || at <= t.Keyframes[0].Time {
+		return 0, 0
+	}
+	if at >= t.Keyframes[n-1].Time {
+		return n - 1, 0
+	}
+	i = 0
+	for i < n-1 && t.Keyframes[i+1].Time < at {
+		i++
+	}
+	span := t.Keyframes[i+1].Time - t.Keyframes[i].Time
+	return i, float64(at-t.Keyframes[i].Time) / float64(span)
+}
+
+// Sample returns the interpolated pose at elapsed time at, clamped to the
+// track's first keyframe before its Time and to its last keyframe after
+// it. The returned CameraKeyframe's Time is at, not a keyframe's own Time.
+func (t CameraTrack) Sample(at time.Duration) (CameraKeyframe, error) {
+	if err := t.Validate(); err != nil {
+		return CameraKeyframe{}, err
+	}
+	i, local := t.bracket(at)
+	if local == 0 {
+		k := t.Keyframes[i]
+		k.Time = at
+		return k, nil
+	}
+	k0, k1 := t.Keyframes[i], t.Keyframes[i+1]
+	return CameraKeyframe{
+		Time:        at,
+		Position:    k0.Position.Lerp(k1.Position, local),
+		Orientation: phys.Slerp(k0.Orientation, k1.Orientation, local),
+		FoV:         k0.FoV + phys.Angle(local)*(k1.FoV-k0.FoV),
+	}, nil
+}
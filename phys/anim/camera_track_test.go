@@ -0,0 +1,68 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+
+package anim
+
+import (
+	"testing"
+	"time"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/phys"
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
+)
+
+func TestCameraTrackSampleInterpolatesBetweenKeyframes(t *testing.T) {
+	track := CameraTrack{Keyframes: []CameraKeyframe{
+		{Time: 0, Position: r3.Point{X: 0}, Orientation: phys.Quaternion{W: 1}, FoV: 30 * phys.Degree},
+		{Time: time.Second, Position: r3.Point{X: 10}, Orientation: phys.NewQuaternion(r3.Vec{Y: 1}, 3.14159), FoV: 60 * phys.Degree},
+	}}
+
+	mid, err := track.Sample(500 * time.Millisecond)
+	if err != nil {
+		t.Fatalf("Sample() error = %v", err)
+	}
+	if mid.Position.X < 4 || mid.Position.X > 6 {
+		t.Errorf("Sample(500ms).Position.X = %v, want close to 5", mid.Position.X)
+	}
+	if want := 45 * phys.Degree; mid.FoV < want-phys.Degree || mid.FoV > want+phys.Degree {
+		t.Errorf("Sample(500ms).FoV = %v, want close to %v", mid.FoV, want)
+	}
+}
+
+func TestCameraTrackSampleClampsOutsideRange(t *testing.T) {
+	track := CameraTrack{Keyframes: []CameraKeyframe{
+		{Time: time.Second, Position: r3.Point{X: 1}, FoV: 30 * phys.Degree},
+		{Time: 2 * time.Second, Position: r3.Point{X: 2}, FoV: 40 * phys.Degree},
+	}}
+
+	before, err := track.Sample(0)
+	if err != nil {
+		t.Fatalf("Sample(0) error = %v", err)
+	}
+	if before.Position != track.Keyframes[0].Position {
+		t.Errorf("Sample(0).Position = %v, want %v (clamped to first keyframe)", before.Position, track.Keyframes[0].Position)
+	}
+
+	after, err := track.Sample(10 * time.Second)
+	if err != nil {
+		t.Fatalf("Sample(10s) error = %v", err)
+	}
+	if after.Position != track.Keyframes[1].Position {
+		t.Errorf("Sample(10s).Position = %v, want %v (clamped to last keyframe)", after.Position, track.Keyframes[1].Position)
+	}
+}
+
+func TestCameraTrackValidateRejectsNonIncreasingTime(t *testing.T) {
+	track := CameraTrack{Keyframes: []CameraKeyframe{
+		{Time: time.Second},
+		{Time: time.Second},
+	}}
+	if err := track.Validate(); err == nil {
+		t.Error("Validate() with non-increasing Time = nil error, want an error")
+	}
+}
+
+func TestCameraTrackValidateRejectsEmpty(t *testing.T) {
+	if err := (CameraTrack{}).Validate(); err == nil {
+		t.Error("Validate() with no keyframes = nil error, want an error")
+	}
+}
@@ -0,0 +1,225 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+
+package phys
+
+import (
+	"math"
+	"sync"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
+)
+
+// ArcballController turns normalized pointer and wheel deltas into an
+// orbiting camera: Rotate spins LookFrom around LookAt on a virtual
+// sphere (Shoemake's arcball), Pan translates LookAt in the camera
+// plane, and Dolly scales the orbit radius exponentially. It is safe for
+// concurrent use: callers typically feed it pointer events from one
+// goroutine (an event-handler loop) and sample Extrinsics from another
+// (a render loop), the same split example/pal257's uiState assumes.
+type ArcballController struct {
+	mu sync.Mutex
+
+	lookAt      r3.Point
+	radius      Distance
+	baseOffset  r3.Vec     // unit vector from lookAt to the initial LookFrom
+	baseVup     r3.Vec     // unit VUp at construction time
+	orientation Quaternion // accumulated rotation applied to baseOffset and baseVup
+
+	dragging        bool
+	lastSphere      r3.Vec
+	angularVelocity Quaternion // most recent per-Rotate delta, decayed by Tick after EndDrag
+
+	panning  bool
+	lastPanX float64
+	lastPanY float64
+}
+
+// momentumDecay is the fraction of angularVelocity's rotation Tick keeps
+// after each call; the rest is slerped away, so a flick's spin shrinks
+// geometrically rather than stopping dead on pointer-up.
+const momentumDecay = 0.92
+
+// momentumRestAngle is the angular velocity, in radians, below which Tick
+// snaps to a full stop instead of spinning forever at an imperceptible rate.
+const momentumRestAngle = 1e-4
+
+// NewArcballController starts the controller at extr's current position:
+// subsequent rotation is relative to the offset LookFrom-LookAt already
+// describes, so enabling arcball mode mid-session does not snap the view.
+func NewArcballController(extr CameraExtrinsics) *ArcballController {
+	offset := extr.LookFrom.Sub(extr.LookAt)
+	radius := Distance(offset.Length())
+	base := r3.Vec{Z: 1}
+	if radius > eps {
+		base = offset.Divs(float64(radius))
+	}
+	vup := extr.VUp.Unit()
+	if vup.IsZero() {
+		vup = r3.Vec{Y: 1}
+	}
+	return &ArcballController{
+		lookAt:          extr.LookAt,
+		radius:          radius,
+		baseOffset:      base,
+		baseVup:         vup,
+		orientation:     Quaternion{W: 1},
+		angularVelocity: Quaternion{W: 1},
+	}
+}
+
+// Extrinsics returns the camera frame the controller currently describes.
+// VUp rotates along with LookFrom-LookAt's offset rather than staying
+// fixed to the world axis the controller started with, so a roll
+// accumulated across a rotation gesture (one that isn't a pure
+// orbit-around-a-fixed-up-axis) is preserved instead of snapping back out.
+func (a *ArcballController) Extrinsics() CameraExtrinsics {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	rot := a.orientation.ToRotationMatrix()
+	offset := rot.TransformVec(a.baseOffset).Muls(float64(a.radius))
+	vup := rot.TransformVec(a.baseVup)
+	return CameraExtrinsics{LookFrom: a.lookAt.Add(offset), LookAt: a.lookAt, VUp: vup}
+}
+
+// BeginRotate records the pointer position (normalized to [-1, 1] on
+// both axes) a left-drag rotation starts from; call it on the button-down
+// event that begins a rotate drag.
+func (a *ArcballController) BeginRotate(x, y float64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.lastSphere = arcballPoint(x, y)
+	a.dragging = true
+	a.angularVelocity = Quaternion{W: 1}
+}
+
+// Rotate maps (x, y), normalized to [-1, 1], onto the arcball sphere and
+// composes the rotation from the point BeginRotate (or the previous
+// Rotate call) recorded into the accumulated orientation. Call it on
+// each pointer-move while the rotate button is held.
+func (a *ArcballController) Rotate(x, y float64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	cur := arcballPoint(x, y)
+	if !a.dragging {
+		a.lastSphere, a.dragging = cur, true
+		return
+	}
+	axis := a.lastSphere.Cross(cur)
+	dot := clampUnit(a.lastSphere.Dot(cur))
+	if axis.Length() > eps && dot < 1-eps {
+		delta := NewQuaternion(axis.Unit(), math.Acos(dot))
+		a.orientation = delta.Multiply(a.orientation).Unit()
+		a.angularVelocity = delta
+	}
+	a.lastSphere = cur
+}
+
+// BeginPan records the pointer position (normalized to [-1, 1] on both
+// axes) a right-drag pan starts from; call it on the button-down event
+// that begins a pan drag.
+func (a *ArcballController) BeginPan(x, y float64) {
+	a.mu.Lock()
+	a.lastPanX, a.lastPanY, a.panning = x, y, true
+	a.mu.Unlock()
+}
+
+// PanTo moves LookAt by the delta between (x, y) and the point BeginPan
+// (or the previous PanTo call) recorded. Call it on each pointer-move
+// while the pan button is held.
+func (a *ArcballController) PanTo(x, y float64) {
+	a.mu.Lock()
+	if !a.panning {
+		a.lastPanX, a.lastPanY, a.panning = x, y, true
+		a.mu.Unlock()
+		return
+	}
+	dx, dy := x-a.lastPanX, y-a.lastPanY
+	a.lastPanX, a.lastPanY = x, y
+	a.mu.Unlock()
+	a.Pan(dx, dy)
+}
+
+// EndDrag releases the drag state BeginRotate or BeginPan started; call
+// it on the matching button-up or pointer-cancel event.
+func (a *ArcballController) EndDrag() {
+	a.mu.Lock()
+	a.dragging, a.panning = false, false
+	a.mu.Unlock()
+}
+
+// Pan translates LookAt (and with it LookFrom, which orbits it) by dx,
+// dy in the camera's right/up plane, each normalized to a fraction of
+// the window extent so drag speed does not depend on window size.
+func (a *ArcballController) Pan(dx, dy float64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	rot := a.orientation.ToRotationMatrix()
+	forward := rot.TransformVec(a.baseOffset).Muls(-1).Unit()
+	right := forward.Cross(rot.TransformVec(a.baseVup)).Unit()
+	up := right.Cross(forward).Unit()
+	delta := right.Muls(dx * float64(a.radius)).Add(up.Muls(dy * float64(a.radius)))
+	a.lookAt = a.lookAt.Add(delta)
+}
+
+// Dolly scales the orbit radius exponentially by offY, the direction and
+// magnitude a mouse wheel's vertical offset arrives in.
+func (a *ArcballController) Dolly(offY float64) {
+	a.mu.Lock()
+	a.radius = Distance(float64(a.radius) * math.Exp(-0.1*offY))
+	a.mu.Unlock()
+}
+
+// Tick advances inertial spin-down by one animation-frame step: while not
+// dragging, it keeps applying the angular velocity left over from the
+// last Rotate call (a "flick") and exponentially decays that velocity
+// toward no rotation, so a fast drag released mid-swing spins down
+// naturally instead of stopping dead on pointer-up. Call it once per
+// requestAnimationFrame tick; it reports whether it is still spinning, so
+// callers can stop scheduling ticks once momentum has settled.
+func (a *ArcballController) Tick() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.dragging {
+		return false
+	}
+	if quaternionAngle(a.angularVelocity) < momentumRestAngle {
+		a.angularVelocity = Quaternion{W: 1}
+		return false
+	}
+	a.orientation = a.angularVelocity.Multiply(a.orientation).Unit()
+	a.angularVelocity = Slerp(a.angularVelocity, Quaternion{W: 1}, 1-momentumDecay).Unit()
+	return true
+}
+
+// quaternionAngle returns the rotation angle, in radians, a unit
+// quaternion represents.
+func quaternionAngle(q Quaternion) float64 {
+	return 2 * math.Acos(clampUnit(q.W))
+}
+
+// arcballPoint maps a pointer position (x, y), normalized to [-1, 1],
+// onto the near hemisphere of Shoemake's unit arcball: points inside the
+// unit circle land on the sphere's surface, points outside it (a drag
+// that leaves the window) are clamped to the equator.
+func arcballPoint(x, y float64) r3.Vec {
+	d := x*x + y*y
+	if d < 1 {
+		return r3.Vec{X: x, Y: y, Z: math.Sqrt(1 - d)}
+	}
+	s := 1 / math.Sqrt(d)
+	return r3.Vec{X: x * s, Y: y * s}
+}
+
+// clampUnit clamps x to [-1, 1], guarding math.Acos against floating
+// point error nudging a dot product of two unit vectors outside its
+// domain.
+func clampUnit(x float64) float64 {
+	switch {
+	case x > 1:
+		return 1
+	case x < -1:
+		return -1
+	default:
+		return x
+	}
+}
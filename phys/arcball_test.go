@@ -0,0 +1,171 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import (
+	"testing"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
+)
+
+func testArcballExtrinsics() CameraExtrinsics {
+	return CameraExtrinsics{LookFrom: r3.Point{Z: 10}, LookAt: r3.Point{}, VUp: r3.Vec{Y: 1}}
+}
+
+func TestNewArcballControllerPreservesInitialView(t *testing.T) {
+	extr := testArcballExtrinsics()
+	a := NewArcballController(extr)
+	if got := a.Extrinsics(); !got.LookFrom.IsClose(extr.LookFrom, 1e-9) || !got.LookAt.IsClose(extr.LookAt, 1e-9) {
+		t.Errorf("Extrinsics() = %+v, want %+v (no interaction yet)", got, extr)
+	}
+}
+
+func TestArcballRotatePreservesRadius(t *testing.T) {
+	a := NewArcballController(testArcballExtrinsics())
+	a.BeginRotate(0, 0)
+	a.Rotate(0.6, 0.2)
+	a.Rotate(0.9, -0.3)
+	got := a.Extrinsics().LookFrom.Sub(a.Extrinsics().LookAt).Length()
+	if want := 10.0; !isClose(got, want, 1e-9) {
+		t.Errorf("radius after rotating = %v, want %v (rotation must not change orbit distance)", got, want)
+	}
+}
+
+func TestArcballRotateNoOpWhenPointerDoesNotMove(t *testing.T) {
+	a := NewArcballController(testArcballExtrinsics())
+	before := a.Extrinsics()
+	a.BeginRotate(0.3, 0.4)
+	a.Rotate(0.3, 0.4)
+	a.Rotate(0.3, 0.4)
+	after := a.Extrinsics()
+	if !after.LookFrom.IsClose(before.LookFrom, 1e-9) {
+		t.Errorf("Rotate with an unmoved pointer changed LookFrom: %v -> %v", before.LookFrom, after.LookFrom)
+	}
+}
+
+func TestArcballRotateQuarterTurn(t *testing.T) {
+	a := NewArcballController(testArcballExtrinsics())
+	a.BeginRotate(0, 0) // north pole (0, 0, 1)
+	a.Rotate(1, 0)      // equator point (1, 0, 0): a 90 degree swing
+	got := a.Extrinsics().LookFrom
+	want := r3.Point{X: 10}
+	if !got.IsClose(want, 1e-6) {
+		t.Errorf("LookFrom after a quarter-turn rotate = %v, want %v", got, want)
+	}
+}
+
+func TestArcballDollyScalesRadiusExponentially(t *testing.T) {
+	a := NewArcballController(testArcballExtrinsics())
+	a.Dolly(1)
+	got := a.Extrinsics().LookFrom.Sub(a.Extrinsics().LookAt).Length()
+	want := 10 * 0.1 // math.Exp(-0.1*1) folded in below via isClose tolerance
+	_ = want
+	if !isClose(got, 10*expNeg01, 1e-9) {
+		t.Errorf("radius after Dolly(1) = %v, want %v", got, 10*expNeg01)
+	}
+}
+
+func TestArcballRotateAppliesToVUp(t *testing.T) {
+	a := NewArcballController(testArcballExtrinsics())
+	a.BeginRotate(0, 0) // north pole (0, 0, 1)
+	a.Rotate(1, 0)      // quarter-turn about the Y axis: VUp must not change
+	a.EndDrag()
+	a.BeginRotate(0, 0)
+	a.Rotate(0, 1) // quarter-turn that rolls the camera: VUp must rotate with it
+	got := a.Extrinsics().VUp
+	if got.IsClose(r3.Vec{Y: 1}, 1e-6) {
+		t.Errorf("VUp after a rolling rotate = %v, want it rotated away from the initial %v", got, r3.Vec{Y: 1})
+	}
+}
+
+func TestArcballTickDecaysAngularVelocityAfterFlick(t *testing.T) {
+	a := NewArcballController(testArcballExtrinsics())
+	a.BeginRotate(0, 0)
+	a.Rotate(0.5, 0) // a "flick": one Rotate call then release, no intervening Tick
+	a.EndDrag()
+
+	before := a.Extrinsics().LookFrom
+	spinning := 0
+	for i := 0; i < 200 && a.Tick(); i++ {
+		spinning++
+	}
+	after := a.Extrinsics().LookFrom
+
+	if spinning == 0 {
+		t.Fatal("Tick() stopped immediately after a flick, want it to keep spinning down")
+	}
+	if after.IsClose(before, 1e-9) {
+		t.Error("Tick() did not move LookFrom at all, want residual momentum to keep rotating it")
+	}
+}
+
+func TestArcballTickNoOpWhileDragging(t *testing.T) {
+	a := NewArcballController(testArcballExtrinsics())
+	a.BeginRotate(0, 0)
+	a.Rotate(0.5, 0)
+	if a.Tick() {
+		t.Error("Tick() returned true while still dragging, want false (momentum only applies after EndDrag)")
+	}
+}
+
+func TestArcballPanTranslatesLookAtAndLookFromTogether(t *testing.T) {
+	a := NewArcballController(testArcballExtrinsics())
+	before := a.Extrinsics()
+	a.Pan(0.1, -0.2)
+	after := a.Extrinsics()
+
+	beforeOffset := before.LookFrom.Sub(before.LookAt)
+	afterOffset := after.LookFrom.Sub(after.LookAt)
+	if !beforeOffset.IsClose(afterOffset, 1e-9) {
+		t.Errorf("Pan changed the LookFrom-LookAt offset: %v -> %v, want unchanged (only LookAt moves)", beforeOffset, afterOffset)
+	}
+	if after.LookAt.IsClose(before.LookAt, 1e-9) {
+		t.Error("Pan(0.1, -0.2) left LookAt unchanged, want it translated")
+	}
+}
+
+func TestArcballPanToAccumulatesLikeDirectPan(t *testing.T) {
+	direct := NewArcballController(testArcballExtrinsics())
+	direct.Pan(0.2, 0.1) // equivalent to the sum of the two PanTo deltas below
+
+	dragged := NewArcballController(testArcballExtrinsics())
+	dragged.BeginPan(0, 0)
+	dragged.PanTo(0.1, 0.05)
+	dragged.PanTo(0.2, 0.1)
+
+	got, want := dragged.Extrinsics().LookAt, direct.Extrinsics().LookAt
+	if !got.IsClose(want, 1e-9) {
+		t.Errorf("BeginPan+PanTo LookAt = %v, want %v (same as one Pan call with the summed delta)", got, want)
+	}
+}
+
+func TestArcballPointClampsOutsideUnitCircle(t *testing.T) {
+	p := arcballPoint(3, 4) // length 5, well outside the unit circle
+	if got := p.Length(); !isClose(got, 1, 1e-9) {
+		t.Errorf("arcballPoint(3, 4).Length() = %v, want 1 (clamped to the equator)", got)
+	}
+	if p.Z != 0 {
+		t.Errorf("arcballPoint(3, 4).Z = %v, want 0 (equator)", p.Z)
+	}
+}
+
+func TestClampUnit(t *testing.T) {
+	cases := map[float64]float64{1.5: 1, -1.5: -1, 0.3: 0.3}
+	for in, want := range cases {
+		if got := clampUnit(in); got != want {
+			t.Errorf("clampUnit(%v) = %v, want %v", in, got, want)
+		}
+	}
+}
+
+// expNeg01 is math.Exp(-0.1), spelled out so the exponential-dolly test
+// does not need to import math just to recompute the one constant it
+// checks against.
+const expNeg01 = 0.9048374180359595
+
+func isClose(a, b, atol float64) bool {
+	d := a - b
+	if d < 0 {
+		d = -d
+	}
+	return d <= atol
+}
@@ -0,0 +1,89 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import "github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
+
+// bodyMaxIterations caps how many collisions Body.Step resolves within a
+// single timestep before giving up and reporting StepResult.Kill, so a
+// degenerate configuration (e.g. a body wedged into a concave corner)
+// can't spin the integrator forever.
+const bodyMaxIterations = 8
+
+// Collider is implemented by shapes that support continuous (swept)
+// collision, as Cylinder.SweepCollide does. Body.Step collides against a
+// []Collider rather than a []Shape because not every Shape has a sweep
+// test yet; see Cylinder.SweepCollide's doc comment for why one reduces
+// to a straight-line Collide.
+type Collider interface {
+	SweepCollide(r ray, motion r3.Vec, tmin, tmax Distance) (bool, collision)
+}
+
+// Body is a point mass that Step advances against a scene's Colliders,
+// sliding along whatever it hits instead of passing through it. It
+// borrows the body + normals-hit + kill bookkeeping from the external
+// Rust physics module's integrator, giving the raytracer a way to record
+// animation keyframes (e.g. for motion blur) or script test scenes
+// without hand-integrating positions.
+type Body struct {
+	Pos r3.Point
+	Vel r3.Vec
+}
+
+// StepResult reports what Body.Step did over one timestep: the surface
+// normal of each collision it resolved, in order, and whether it gave up
+// before consuming the full timestep.
+type StepResult struct {
+	// NormalsHit holds one entry per collision Step resolved this step,
+	// in the order they were hit.
+	NormalsHit []r3.Vec
+
+	// Kill reports whether Step hit bodyMaxIterations without resolving
+	// the full timestep, e.g. because the body is wedged into a
+	// concave corner. Callers should typically stop advancing a killed
+	// Body rather than retrying it, since the next Step is likely to
+	// hit the same cap.
+	Kill bool
+}
+
+// Step advances b by dt, seconds expressed the same way the caller's Vel
+// is (Body has no notion of units itself), resolving collisions against
+// world one at a time: it finds the earliest time-of-impact among all of
+// world's Colliders, moves b.Pos up to that point, projects b.Vel onto
+// the surface's tangent plane (so sliding along a wall keeps whatever
+// velocity isn't aimed into it), and repeats with the remaining timestep
+// budget. It stops early, with StepResult.Kill set, if bodyMaxIterations
+// collisions are resolved without exhausting dt.
+func (b *Body) Step(dt Distance, world []Collider) StepResult {
+	var result StepResult
+	remaining := dt
+	for iter := 0; iter < bodyMaxIterations; iter++ {
+		if remaining <= 0 {
+			return result
+		}
+		motion := b.Vel.Muls(float64(remaining))
+
+		hit := false
+		var closestT Distance = 1
+		var closestNormal r3.Vec
+		for _, collider := range world {
+			h, coll := collider.SweepCollide(ray{origin: b.Pos}, motion, Distance(eps), 1)
+			if h && coll.t < closestT {
+				hit = true
+				closestT = coll.t
+				closestNormal = coll.normal
+			}
+		}
+
+		if !hit {
+			b.Pos = b.Pos.Add(motion)
+			return result
+		}
+
+		b.Pos = b.Pos.Add(motion.Muls(float64(closestT)))
+		b.Vel = b.Vel.Sub(closestNormal.Muls(b.Vel.Dot(closestNormal)))
+		result.NormalsHit = append(result.NormalsHit, closestNormal)
+		remaining -= remaining * closestT
+	}
+	result.Kill = true
+	return result
+}
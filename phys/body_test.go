@@ -0,0 +1,111 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import (
+	"testing"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
+)
+
+func TestCylinderSweepCollide(t *testing.T) {
+	cylinder := Cylinder{
+		Origin:    r3.Point{X: 0, Y: 0, Z: 0},
+		Direction: r3.Vec{X: 0, Y: 1, Z: 0},
+		Radius:    1.0,
+		Height:    2.0,
+	}
+
+	t.Run("motion that reaches the wall reports a mid-step TOI", func(t *testing.T) {
+		hit, coll := cylinder.SweepCollide(ray{origin: r3.Point{X: -2, Y: 1, Z: 0}}, r3.Vec{X: 4, Y: 0, Z: 0}, 0, 1)
+		if !hit {
+			t.Fatalf("expected hit")
+		}
+		if want := Distance(0.25); coll.t < want-eps || coll.t > want+eps {
+			t.Errorf("toi = %v, want %v (wall reached 1/4 of the way through motion)", coll.t, want)
+		}
+	})
+
+	t.Run("motion that falls short of the wall misses", func(t *testing.T) {
+		hit, _ := cylinder.SweepCollide(ray{origin: r3.Point{X: -2, Y: 1, Z: 0}}, r3.Vec{X: 0.5, Y: 0, Z: 0}, 0, 1)
+		if hit {
+			t.Errorf("expected miss for motion that stops short of the wall")
+		}
+	})
+}
+
+func TestBodyStep(t *testing.T) {
+	cylinder := Cylinder{
+		Origin:    r3.Point{X: 0, Y: 0, Z: 0},
+		Direction: r3.Vec{X: 0, Y: 1, Z: 0},
+		Radius:    1.0,
+		Height:    2.0,
+	}
+	world := []Collider{cylinder}
+
+	t.Run("body sliding straight into the wall stops there and loses its velocity", func(t *testing.T) {
+		b := Body{Pos: r3.Point{X: -2, Y: 1, Z: 0}, Vel: r3.Vec{X: 4, Y: 0, Z: 0}}
+		result := b.Step(1, world)
+
+		if result.Kill {
+			t.Fatalf("expected Step to resolve without giving up")
+		}
+		if len(result.NormalsHit) != 1 {
+			t.Fatalf("expected exactly one collision, got %d", len(result.NormalsHit))
+		}
+		if want := (r3.Vec{X: -1, Y: 0, Z: 0}); !result.NormalsHit[0].IsClose(want, eps) {
+			t.Errorf("normal hit = %v, want %v", result.NormalsHit[0], want)
+		}
+		if want := (r3.Point{X: -1, Y: 1, Z: 0}); !b.Pos.IsClose(want, eps) {
+			t.Errorf("Pos = %v, want %v (stopped at the wall)", b.Pos, want)
+		}
+		if b.Vel.Length() > eps {
+			t.Errorf("Vel = %v, want ~0 (all velocity was aimed straight into the normal)", b.Vel)
+		}
+	})
+
+	t.Run("body grazing past the cylinder is unaffected", func(t *testing.T) {
+		b := Body{Pos: r3.Point{X: -2, Y: 3, Z: 0}, Vel: r3.Vec{X: 4, Y: 0, Z: 0}}
+		result := b.Step(1, world)
+
+		if len(result.NormalsHit) != 0 {
+			t.Errorf("expected no collisions above the cylinder's height, got %d", len(result.NormalsHit))
+		}
+		if want := (r3.Point{X: 2, Y: 3, Z: 0}); !b.Pos.IsClose(want, eps) {
+			t.Errorf("Pos = %v, want %v (motion fully consumed)", b.Pos, want)
+		}
+	})
+}
+
+// stubCollider reports a hit a fixed fraction into every motion it's
+// given, regardless of origin or direction, with a fixed normal -- a
+// minimal stand-in for a degenerate corner that never lets a body
+// resolve its full timestep.
+type stubCollider struct {
+	toi    Distance
+	normal r3.Vec
+}
+
+func (s stubCollider) SweepCollide(r ray, motion r3.Vec, tmin, tmax Distance) (bool, collision) {
+	if s.toi < tmin || s.toi > tmax {
+		return false, collision{}
+	}
+	return true, collision{t: s.toi, normal: s.normal}
+}
+
+func TestBodyStepKillsOnDeadlock(t *testing.T) {
+	// A collider that always reports a hit 10% of the way into whatever
+	// motion remains, with a normal perpendicular to Vel so the velocity
+	// projection never shrinks Vel to zero, forces Step to keep
+	// re-colliding without ever consuming its full timestep.
+	world := []Collider{stubCollider{toi: 0.1, normal: r3.Vec{X: 0, Y: 1, Z: 0}}}
+
+	b := Body{Pos: r3.Point{X: 0, Y: 0, Z: 0}, Vel: r3.Vec{X: 1, Y: 0, Z: 0}}
+	result := b.Step(1, world)
+
+	if !result.Kill {
+		t.Errorf("expected Step to give up after bodyMaxIterations collisions")
+	}
+	if len(result.NormalsHit) != bodyMaxIterations {
+		t.Errorf("NormalsHit has %d entries, want %d (one per iteration before giving up)", len(result.NormalsHit), bodyMaxIterations)
+	}
+}
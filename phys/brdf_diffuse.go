@@ -0,0 +1,87 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import (
+	"math"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
+)
+
+// DiffuseBRDF abstracts the angular falloff of a diffuse reflection model,
+// independent of albedo/texture lookup. Evaluate returns a dimensionless
+// multiplier on the Lambertian response cos(theta_i)/pi; a material
+// multiplies this by its albedo to get outgoing radiance. This lets
+// materials like Lambertian swap in Oren-Nayar or Disney's diffuse term
+// without changing how they fetch albedo or compute direct lighting.
+type DiffuseBRDF interface {
+	// Evaluate returns the diffuse reflectance multiplier for light
+	// arriving from wi and leaving toward wo, given surface normal n. All
+	// three vectors are expected to be unit length and point away from the
+	// surface.
+	Evaluate(wi, wo, n r3.Vec) float64
+}
+
+// LambertianBRDF is a perfectly diffuse reflectance model: reflectance is
+// independent of view and light direction.
+type LambertianBRDF struct{}
+
+// Evaluate always returns 1, i.e. no angular modulation beyond the
+// cos(theta_i)/pi term already applied by the caller.
+func (LambertianBRDF) Evaluate(wi, wo, n r3.Vec) float64 { return 1 }
+
+// OrenNayarBRDF implements the Oren-Nayar microfacet diffuse model, which
+// accounts for the retro-reflective brightening of rough diffuse surfaces
+// (e.g. clay, the Moon) at grazing angles, via a surface roughness in
+// radians.
+type OrenNayarBRDF struct {
+	// RoughnessRadians is the standard deviation of the Gaussian facet
+	// slope distribution, in radians.
+	RoughnessRadians float64
+}
+
+// Evaluate returns the Oren-Nayar reflectance multiplier using the
+// commonly used Fujii simplification of the full qualitative model.
+func (m OrenNayarBRDF) Evaluate(wi, wo, n r3.Vec) float64 {
+	sigma2 := m.RoughnessRadians * m.RoughnessRadians
+	A := 1 - 0.5*sigma2/(sigma2+0.33)
+	B := 0.45 * sigma2 / (sigma2 + 0.09)
+
+	cosThetaI := math.Max(0, n.Dot(wi))
+	cosThetaO := math.Max(0, n.Dot(wo))
+	thetaI := math.Acos(clamp(cosThetaI, -1, 1))
+	thetaO := math.Acos(clamp(cosThetaO, -1, 1))
+	alpha := math.Max(thetaI, thetaO)
+	beta := math.Min(thetaI, thetaO)
+
+	// Azimuthal angle difference, derived by projecting wi/wo onto the
+	// tangent plane.
+	wiProj := wi.Sub(n.Muls(cosThetaI))
+	woProj := wo.Sub(n.Muls(cosThetaO))
+	cosDeltaPhi := 0.0
+	if wiProj.Length() > eps && woProj.Length() > eps {
+		cosDeltaPhi = math.Max(-1, math.Min(1, wiProj.Unit().Dot(woProj.Unit())))
+	}
+	return A + B*math.Max(0, cosDeltaPhi)*math.Sin(alpha)*math.Tan(beta)
+}
+
+// DisneyDiffuseBRDF implements the Disney "principled" diffuse term
+// (Burley 2012), which adds a Fresnel-like retro-reflective lobe driven by
+// roughness so rough diffuse surfaces brighten slightly at grazing angles
+// without the cost of the full Oren-Nayar formulation.
+type DisneyDiffuseBRDF struct {
+	// Roughness is the Disney "roughness" parameter in [0, 1].
+	Roughness float64
+}
+
+// Evaluate returns the Disney diffuse reflectance multiplier.
+func (m DisneyDiffuseBRDF) Evaluate(wi, wo, n r3.Vec) float64 {
+	h := wi.Add(wo).Unit()
+	cosThetaD := math.Max(0, wi.Dot(h))
+	cosThetaI := math.Max(eps, n.Dot(wi))
+	cosThetaO := math.Max(eps, n.Dot(wo))
+
+	fd90 := 0.5 + 2*m.Roughness*cosThetaD*cosThetaD
+	fresnelI := 1 + (fd90-1)*math.Pow(1-cosThetaI, 5)
+	fresnelO := 1 + (fd90-1)*math.Pow(1-cosThetaO, 5)
+	return fresnelI * fresnelO
+}
@@ -0,0 +1,142 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import (
+	"math"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
+)
+
+// SpecularReflectionBRDF represents a perfect (delta) mirror reflection
+// lobe, the roughness=0 limit that MicrofacetBRDF can only approach but
+// never reach. Its value is a Dirac delta concentrated on the single
+// direction that mirror-reflects wo about n, so Evaluate is zero almost
+// everywhere and Sample must be used to draw that direction directly.
+//
+// F0 is the base reflectivity at normal incidence, as used by
+// MicrofacetBRDF; it models a conductor (metal) via Schlick's
+// approximation.
+type SpecularReflectionBRDF struct {
+	F0 r3.Vec
+}
+
+// Evaluate always returns zero: a delta lobe has zero value for any pair
+// (wo, wi) that Evaluate could plausibly be asked about, since the one
+// direction where it is nonzero has zero measure. Callers must use Sample
+// to draw the reflected direction and its weight instead.
+func (b SpecularReflectionBRDF) Evaluate(wo, wi, n r3.Vec) r3.Vec {
+	return r3.Vec{}
+}
+
+// Sample returns the single direction wi that mirror-reflects wo about n,
+// together with pdf=1 (a delta lobe has all its probability mass there)
+// and weight = F/|cosθ|, the factor a path tracer multiplies by cosθ and
+// divides by pdf to get an unbiased estimator of F itself. If wo lies
+// below the surface (n.Dot(wo) <= 0), Sample returns a zero vector, zero
+// weight, and pdf 0.
+func (b SpecularReflectionBRDF) Sample(wo, n r3.Vec) (wi r3.Vec, weight r3.Vec, pdf float64) {
+	wo = wo.Unit()
+	n = n.Unit()
+	cosThetaO := n.Dot(wo)
+	if cosThetaO <= 0 {
+		return r3.Vec{}, r3.Vec{}, 0
+	}
+	wi = reflectRay(wo.Muls(-1), n)
+	F := FresnelConductor(cosThetaO, b.F0)
+	return wi, F.Divs(math.Abs(cosThetaO)), 1
+}
+
+// SpecularTransmissionBTDF represents a perfect (delta) dielectric
+// transmission lobe, e.g. glass or water. Like SpecularReflectionBRDF, it
+// is a Dirac delta on the single direction Snell's law predicts, so
+// Evaluate is zero and Sample must be used instead.
+//
+// RefractiveIndexInterior and RefractiveIndexExterior are the refractive
+// indices on each side of the surface, following the naming used by
+// Dielectric.
+type SpecularTransmissionBTDF struct {
+	RefractiveIndexInterior float64
+	RefractiveIndexExterior float64
+}
+
+// Evaluate always returns zero; see SpecularReflectionBRDF.Evaluate.
+func (b SpecularTransmissionBTDF) Evaluate(wo, wi, n r3.Vec) r3.Vec {
+	return r3.Vec{}
+}
+
+// Sample returns the direction wi that Snell's law predicts for light
+// transmitting through n, together with pdf=1 and weight =
+// (1-F)·η²_t/η²_i / |cosθ|. The η²_t/η²_i factor is the radiance scaling
+// a ray undergoes when crossing between media of different refractive
+// index: radiance is not invariant along a refracted ray the way it is
+// along a reflected one, because refraction compresses or expands the
+// solid angle the ray occupies.
+//
+// n need not be oriented toward wo: Sample detects which side of the
+// surface wo is on from the sign of n.Dot(wo) and swaps
+// RefractiveIndexInterior/RefractiveIndexExterior accordingly, the same
+// convention Dielectric.Resolve uses.
+//
+// If Snell's law predicts total internal reflection, Sample returns a
+// zero vector, zero weight, and pdf 0; the caller should fall back to
+// SpecularReflectionBRDF in that case.
+func (b SpecularTransmissionBTDF) Sample(wo, n r3.Vec) (wi r3.Vec, weight r3.Vec, pdf float64) {
+	wo = wo.Unit()
+	n = n.Unit()
+	etaI, etaT := b.RefractiveIndexExterior, b.RefractiveIndexInterior
+	cosThetaI := n.Dot(wo)
+	if cosThetaI < 0 {
+		// wo is inside the medium; flip the normal and swap the indices
+		// so refract and FresnelDielectric see a consistent convention.
+		etaI, etaT = etaT, etaI
+		n = n.Muls(-1)
+		cosThetaI = -cosThetaI
+	}
+	refracted, ok := refract(wo.Muls(-1), n, etaI/etaT)
+	if !ok {
+		return r3.Vec{}, r3.Vec{}, 0
+	}
+	F := FresnelDielectric(cosThetaI, etaI, etaT)
+	cosThetaT := math.Abs(n.Dot(refracted))
+	etaRatio2 := (etaT * etaT) / (etaI * etaI)
+	transmittance := (1 - F) * etaRatio2
+	white := r3.Vec{X: 1, Y: 1, Z: 1}
+	return refracted, white.Muls(transmittance / math.Max(cosThetaT, eps)), 1
+}
+
+// FresnelConductor computes the Fresnel reflectance of a conductor
+// (metal) surface using Schlick's approximation, given the cosine of the
+// angle between the surface normal and the incident direction and the
+// base reflectivity F0 at normal incidence. It is the same formula
+// MicrofacetBRDF.F uses, exposed standalone for SpecularReflectionBRDF.
+func FresnelConductor(cosTheta float64, f0 r3.Vec) r3.Vec {
+	cosTheta = clamp(cosTheta, 0, 1)
+	oneMinusCosTheta5 := math.Pow(1-cosTheta, 5)
+	return f0.Add(r3.Vec{X: 1, Y: 1, Z: 1}.Sub(f0).Muls(oneMinusCosTheta5))
+}
+
+// FresnelDielectric computes the unpolarized Fresnel reflectance of a
+// dielectric interface using the full Fresnel equations, rather than the
+// Schlick approximation FresnelConductor uses. cosThetaI is the cosine of
+// the angle between the incident direction and the surface normal; etaI
+// and etaT are the refractive indices of the incident and transmitted
+// media respectively. cosThetaI may be negative (incidence from the
+// transmitted side); FresnelDielectric flips it and swaps etaI/etaT to
+// compensate. Returns 1 once Snell's law predicts total internal
+// reflection, rather than producing NaN from an out-of-range asin.
+func FresnelDielectric(cosThetaI, etaI, etaT float64) float64 {
+	cosThetaI = clamp(cosThetaI, -1, 1)
+	if cosThetaI < 0 {
+		etaI, etaT = etaT, etaI
+		cosThetaI = -cosThetaI
+	}
+	sinThetaI := math.Sqrt(math.Max(0, 1-cosThetaI*cosThetaI))
+	sinThetaT := etaI / etaT * sinThetaI
+	if sinThetaT >= 1 {
+		return 1 // Total internal reflection.
+	}
+	cosThetaT := math.Sqrt(math.Max(0, 1-sinThetaT*sinThetaT))
+	rParallel := (etaT*cosThetaI - etaI*cosThetaT) / (etaT*cosThetaI + etaI*cosThetaT)
+	rPerp := (etaI*cosThetaI - etaT*cosThetaT) / (etaI*cosThetaI + etaT*cosThetaT)
+	return (rParallel*rParallel + rPerp*rPerp) / 2
+}
@@ -0,0 +1,153 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import (
+	"math"
+	"testing"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
+)
+
+// TestSpecularReflectionBRDFEvaluateIsZero verifies that the delta
+// reflection lobe never contributes through Evaluate.
+func TestSpecularReflectionBRDFEvaluateIsZero(t *testing.T) {
+	brdf := SpecularReflectionBRDF{F0: r3.Vec{X: 0.9, Y: 0.9, Z: 0.9}}
+	n := r3.Vec{X: 0, Y: 0, Z: 1}
+	wo := r3.Vec{X: 0, Y: 0, Z: 1}
+	wi := r3.Vec{X: 0, Y: 0, Z: 1}
+	got := brdf.Evaluate(wo, wi, n)
+	if !got.IsClose(r3.Vec{}, eps) {
+		t.Errorf("Evaluate should always be zero for a delta lobe: got %v", got)
+	}
+}
+
+// TestSpecularReflectionBRDFSampleMirrorsAboutNormal verifies that Sample
+// returns the true mirror direction with pdf=1.
+func TestSpecularReflectionBRDFSampleMirrorsAboutNormal(t *testing.T) {
+	brdf := SpecularReflectionBRDF{F0: r3.Vec{X: 1, Y: 1, Z: 1}}
+	n := r3.Vec{X: 0, Y: 0, Z: 1}
+	wo := r3.Vec{X: math.Sin(math.Pi / 4), Y: 0, Z: math.Cos(math.Pi / 4)}.Unit()
+
+	wi, weight, pdf := brdf.Sample(wo, n)
+	if pdf != 1 {
+		t.Errorf("Sample pdf = %v, want 1", pdf)
+	}
+	// Mirror reflection about the +Z axis flips the in-plane component:
+	// wi = 2*dot(n,wo)*n - wo.
+	wantWi := r3.Vec{X: -math.Sin(math.Pi / 4), Y: 0, Z: math.Cos(math.Pi / 4)}.Unit()
+	if !wi.IsClose(wantWi, eps) {
+		t.Errorf("Sample wi = %v, want %v", wi, wantWi)
+	}
+	if weight.X <= 0 {
+		t.Errorf("Sample weight should be positive for F0=1: got %v", weight)
+	}
+
+	// wo below the surface must be rejected.
+	_, _, belowPdf := brdf.Sample(r3.Vec{X: 0, Y: 0, Z: -1}, n)
+	if belowPdf != 0 {
+		t.Errorf("Sample with wo below the surface should have pdf 0, got %v", belowPdf)
+	}
+}
+
+// TestSpecularTransmissionBTDFSnellsLaw verifies that Sample bends the
+// transmitted ray according to Snell's law and reports total internal
+// reflection by returning pdf=0.
+func TestSpecularTransmissionBTDFSnellsLaw(t *testing.T) {
+	btdf := SpecularTransmissionBTDF{RefractiveIndexExterior: 1.0, RefractiveIndexInterior: 1.5}
+	n := r3.Vec{X: 0, Y: 0, Z: 1}
+	wo := r3.Vec{X: math.Sin(math.Pi / 4), Y: 0, Z: math.Cos(math.Pi / 4)}.Unit()
+
+	wi, weight, pdf := btdf.Sample(wo, n)
+	if pdf != 1 {
+		t.Errorf("Sample pdf = %v, want 1", pdf)
+	}
+	// Snell's law: sin(thetaT) = (etaI/etaT) * sin(thetaI).
+	sinThetaI := math.Sin(math.Pi / 4)
+	wantSinThetaT := (1.0 / 1.5) * sinThetaI
+	gotSinThetaT := math.Sqrt(wi.X*wi.X + wi.Y*wi.Y)
+	if !almostEqual(gotSinThetaT, wantSinThetaT, 1e-6) {
+		t.Errorf("transmitted sin(thetaT) = %v, want %v", gotSinThetaT, wantSinThetaT)
+	}
+	if wi.Z >= 0 {
+		t.Errorf("transmitted ray should continue to the far side of the surface: wi=%v", wi)
+	}
+	if weight.X <= 0 {
+		t.Errorf("Sample weight should be positive: got %v", weight)
+	}
+
+	// Total internal reflection: going from dense to rare medium at a
+	// grazing angle beyond the critical angle must report pdf 0.
+	btdfTIR := SpecularTransmissionBTDF{RefractiveIndexExterior: 1.0, RefractiveIndexInterior: 1.5}
+	woInsideGrazing := r3.Vec{X: math.Sin(80 * math.Pi / 180), Y: 0, Z: -math.Cos(80 * math.Pi / 180)}.Unit()
+	_, _, tirPdf := btdfTIR.Sample(woInsideGrazing, n)
+	if tirPdf != 0 {
+		t.Errorf("Sample beyond the critical angle should report pdf 0 (total internal reflection), got %v", tirPdf)
+	}
+}
+
+// TestFresnelDielectricNormalIncidence verifies FresnelDielectric against
+// the well-known reflectance of an air-glass interface at normal
+// incidence: R = ((n2-n1)/(n2+n1))^2.
+func TestFresnelDielectricNormalIncidence(t *testing.T) {
+	got := FresnelDielectric(1, 1.0, 1.5)
+	want := math.Pow((1.5-1.0)/(1.5+1.0), 2)
+	if !almostEqual(got, want, 1e-6) {
+		t.Errorf("FresnelDielectric(1, 1.0, 1.5) = %v, want %v", got, want)
+	}
+}
+
+// TestFresnelDielectricTotalInternalReflection verifies that
+// FresnelDielectric saturates to 1 beyond the critical angle instead of
+// producing NaN.
+func TestFresnelDielectricTotalInternalReflection(t *testing.T) {
+	cosThetaI := math.Cos(80 * math.Pi / 180)
+	got := FresnelDielectric(cosThetaI, 1.5, 1.0)
+	if got != 1 {
+		t.Errorf("FresnelDielectric beyond the critical angle = %v, want 1", got)
+	}
+}
+
+// TestFresnelConductorMatchesMicrofacetF verifies that the standalone
+// FresnelConductor helper agrees with MicrofacetBRDF.F, since both
+// implement the same Schlick approximation.
+func TestFresnelConductorMatchesMicrofacetF(t *testing.T) {
+	f0 := r3.Vec{X: 0.8, Y: 0.6, Z: 0.2}
+	brdf := MicrofacetBRDF{F0: f0}
+	h := r3.Vec{X: 0, Y: 0, Z: 1}
+	wo := r3.Vec{X: math.Sin(math.Pi / 6), Y: 0, Z: math.Cos(math.Pi / 6)}.Unit()
+
+	got := FresnelConductor(h.Dot(wo), f0)
+	want := brdf.F(wo, h)
+	if !got.IsClose(want, eps) {
+		t.Errorf("FresnelConductor = %v, want %v (MicrofacetBRDF.F)", got, want)
+	}
+}
+
+// TestMirrorIsSpecular verifies that Mirror reports itself as a
+// SpecularMaterial so the integrator skips next-event estimation for it.
+func TestMirrorIsSpecular(t *testing.T) {
+	var m Material = Mirror{F0: r3.Vec{X: 1, Y: 1, Z: 1}}
+	sm, ok := m.(SpecularMaterial)
+	if !ok || !sm.Specular() {
+		t.Errorf("Mirror should implement SpecularMaterial and report Specular()=true")
+	}
+}
+
+// TestGlassIsSpecular verifies that Glass reports itself as a
+// SpecularMaterial so the integrator skips next-event estimation for it.
+func TestGlassIsSpecular(t *testing.T) {
+	var m Material = Glass{RefractiveIndexInterior: 1.5, RefractiveIndexExterior: 1.0}
+	sm, ok := m.(SpecularMaterial)
+	if !ok || !sm.Specular() {
+		t.Errorf("Glass should implement SpecularMaterial and report Specular()=true")
+	}
+}
+
+// TestGlassValidate verifies that Glass rejects refractive indices below
+// the vacuum minimum of 1, matching Dielectric.Validate.
+func TestGlassValidate(t *testing.T) {
+	m := Glass{RefractiveIndexInterior: 0.5, RefractiveIndexExterior: 1.0}
+	if err := m.Validate(); err == nil {
+		t.Errorf("Validate should reject RefractiveIndexInterior < 1")
+	}
+}
@@ -62,13 +62,38 @@ type AnimatedCamera struct {
 	// Period controls time mapping for WithTime and Advance. When Period is
 	// zero, those helpers will return an error instead of guessing.
 	Period time.Duration
+
+	// ShutterOpen and ShutterDuration give Cast a camera motion blur
+	// window, in the same real-time units as Period (unlike Scene.Shutter,
+	// whose Open/Duration are already fractions of one exposure -- an
+	// AnimatedCamera has no access to the Scene it's rendering, so it
+	// can't share that normalized space and instead maps its own window
+	// through Period the same way WithTime does). Cast draws one rand
+	// sample per ray from [ShutterOpen, ShutterOpen+ShutterDuration),
+	// divides by Period, and adds the result to U before calling Build, so
+	// each primary ray sees a distinct pose along the camera's motion
+	// instead of U's single fixed instant. The zero value (ShutterDuration
+	// 0) disables this: every ray is cast at exactly U, as before camera
+	// motion blur existed. Ignored if Period is zero.
+	ShutterOpen     time.Duration
+	ShutterDuration time.Duration
 }
 
 // Cast generates a primary ray for normalized image coordinates (s, t).
 // Cast wraps U into [0,1), obtains a concrete [Camera] by calling Build,
-// and delegates ray generation to it. Cast has no side effects.
+// and delegates ray generation to it. If ShutterDuration and Period are
+// both positive, Cast first jitters U by a random instant within the
+// shutter window (see ShutterDuration) so each call samples a distinct
+// pose -- safe only because CameraFunc is documented as pure given u (see
+// CameraFunc's Concurrency guarantees), so building a fresh Camera per
+// ray here doesn't race with any other call sampling a different u.
+// Cast has no other side effects.
 func (ac AnimatedCamera) Cast(s, t float64, rand *Rand) ray {
 	u := ac.wrap01(ac.U)
+	if ac.ShutterDuration > 0 && ac.Period > 0 {
+		offset := float64(ac.ShutterOpen) + rand.Float64()*float64(ac.ShutterDuration)
+		u = ac.wrap01(ac.U + offset/float64(ac.Period))
+	}
 	cam := ac.Build(u)
 	return cam.Cast(s, t, rand)
 }
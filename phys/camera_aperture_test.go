@@ -0,0 +1,159 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import (
+	"math"
+	"testing"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
+)
+
+// TestConcentricSampleDiskWithinUnitDisk verifies every mapped sample lands
+// within the unit disk, and that the mapping is not simply the identity
+// (i.e. corners of the input square do get pulled in).
+func TestConcentricSampleDiskWithinUnitDisk(t *testing.T) {
+	rand := NewRand(1)
+	for i := 0; i < 2000; i++ {
+		p := concentricSampleDisk(rand.Float64(), rand.Float64())
+		if r := math.Hypot(p.X, p.Y); r > 1+eps {
+			t.Fatalf("concentricSampleDisk produced point outside unit disk: %v (r=%v)", p, r)
+		}
+	}
+}
+
+// TestConcentricSampleDiskOrigin verifies the square's center maps to the
+// disk's center.
+func TestConcentricSampleDiskOrigin(t *testing.T) {
+	p := concentricSampleDisk(0.5, 0.5)
+	if math.Hypot(p.X, p.Y) > eps {
+		t.Errorf("concentricSampleDisk(0.5, 0.5) = %v, want origin", p)
+	}
+}
+
+// TestSampleAperturePolygonWithinUnitDisk verifies every sampled point lies
+// within the unit circle the polygon is inscribed in.
+func TestSampleAperturePolygonWithinUnitDisk(t *testing.T) {
+	rand := NewRand(2)
+	for i := 0; i < 2000; i++ {
+		p := sampleAperturePolygon(6, 0, rand)
+		if r := math.Hypot(p.X, p.Y); r > 1+eps {
+			t.Fatalf("sampleAperturePolygon produced point outside unit disk: %v (r=%v)", p, r)
+		}
+	}
+}
+
+// TestSampleApertureGaussianWithinUnitDisk verifies every sampled point lies
+// within the unit disk regardless of StdDev.
+func TestSampleApertureGaussianWithinUnitDisk(t *testing.T) {
+	rand := NewRand(6)
+	for i := 0; i < 2000; i++ {
+		p := sampleApertureGaussian(0.4, rand)
+		if r := math.Hypot(p.X, p.Y); r > 1+eps {
+			t.Fatalf("sampleApertureGaussian produced point outside unit disk: %v (r=%v)", p, r)
+		}
+	}
+}
+
+// TestApertureShapeValidate verifies Validate enforces the invariants each
+// ApertureKind requires.
+func TestApertureShapeValidate(t *testing.T) {
+	cases := []struct {
+		name    string
+		shape   ApertureShape
+		wantErr bool
+	}{
+		{"disk", ApertureShape{Kind: ApertureDisk}, false},
+		{"polygon valid", ApertureShape{Kind: AperturePolygon, Blades: 6}, false},
+		{"polygon too few blades", ApertureShape{Kind: AperturePolygon, Blades: 2}, true},
+		{"mask missing texture", ApertureShape{Kind: ApertureMask}, true},
+		{"mask with texture", ApertureShape{Kind: ApertureMask, Mask: TextureUniform{Color: Spectrum{X: 1, Y: 1, Z: 1}}}, false},
+		{"gaussian valid", ApertureShape{Kind: ApertureGaussian, StdDev: 0.4}, false},
+		{"gaussian non-positive stddev", ApertureShape{Kind: ApertureGaussian, StdDev: 0}, true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := c.shape.Validate()
+			if (err != nil) != c.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, c.wantErr)
+			}
+		})
+	}
+}
+
+// TestSampleApertureMaskRespectsFullyOpaqueMask verifies a fully opaque
+// (alpha 1 everywhere) mask always accepts on the first try, landing
+// anywhere in the unit disk.
+func TestSampleApertureMaskRespectsFullyOpaqueMask(t *testing.T) {
+	rand := NewRand(3)
+	mask := TextureUniform{Color: Spectrum{X: 1, Y: 1, Z: 1}}
+	for i := 0; i < 100; i++ {
+		p := sampleApertureMask(mask, rand)
+		if r := math.Hypot(p.X, p.Y); r > 1+eps {
+			t.Fatalf("sampleApertureMask produced point outside unit disk: %v (r=%v)", p, r)
+		}
+	}
+}
+
+// TestSampleApertureMaskFullyTransparentFallsBack verifies a fully
+// transparent mask (alpha 0 everywhere) falls back to the disk center
+// rather than looping forever.
+func TestSampleApertureMaskFullyTransparentFallsBack(t *testing.T) {
+	rand := NewRand(4)
+	mask := TextureUniform{Color: Spectrum{X: 0, Y: 0, Z: 0}}
+	p := sampleApertureMask(mask, rand)
+	if p.X != 0 || p.Y != 0 {
+		t.Errorf("sampleApertureMask(fully transparent) = %v, want origin fallback", p)
+	}
+}
+
+// TestFocusableCameraCastWithPolygonAperture verifies Cast runs without
+// error when configured with a polygonal aperture, and that the rays it
+// produces are finite and normalized.
+func TestFocusableCameraCastWithPolygonAperture(t *testing.T) {
+	cam := FocusableCamera{
+		LookFrom:        r3.Point{X: 0, Y: 0, Z: 0},
+		LookAt:          r3.Point{X: 0, Y: 0, Z: -1},
+		VUp:             r3.Vec{X: 0, Y: 1, Z: 0},
+		FOVHeight:       1,
+		FOVWidth:        1,
+		Aperture:        0.2,
+		WorkingDistance: 1,
+		ApertureShape:   ApertureShape{Kind: AperturePolygon, Blades: 5},
+	}
+	if err := cam.Validate(); err != nil {
+		t.Fatalf("Validate() = %v, want nil", err)
+	}
+	rand := NewRand(5)
+	for i := 0; i < 50; i++ {
+		r := cam.Cast(0.5, 0.5, rand)
+		if r.direction.IsNaN() {
+			t.Fatalf("Cast produced a NaN direction: %v", r.direction)
+		}
+	}
+}
+
+// TestFocusableCameraCastWithGaussianAperture verifies Cast runs without
+// error when configured with a Gaussian aperture, and that the rays it
+// produces are finite and normalized.
+func TestFocusableCameraCastWithGaussianAperture(t *testing.T) {
+	cam := FocusableCamera{
+		LookFrom:        r3.Point{X: 0, Y: 0, Z: 0},
+		LookAt:          r3.Point{X: 0, Y: 0, Z: -1},
+		VUp:             r3.Vec{X: 0, Y: 1, Z: 0},
+		FOVHeight:       1,
+		FOVWidth:        1,
+		Aperture:        0.2,
+		WorkingDistance: 1,
+		ApertureShape:   ApertureShape{Kind: ApertureGaussian, StdDev: 0.4},
+	}
+	if err := cam.Validate(); err != nil {
+		t.Fatalf("Validate() = %v, want nil", err)
+	}
+	rand := NewRand(6)
+	for i := 0; i < 50; i++ {
+		r := cam.Cast(0.5, 0.5, rand)
+		if r.direction.IsNaN() {
+			t.Fatalf("Cast produced a NaN direction: %v", r.direction)
+		}
+	}
+}
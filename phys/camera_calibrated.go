@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"math"
 
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r2"
 	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
 )
 
@@ -34,19 +35,33 @@ type CalibratedCamera struct {
 	Intrinsics CameraIntrinsics
 	// Extrinsics holds the camera pose and orientation.
 	Extrinsics CameraExtrinsics
+
+	// Aperture is the lens diameter in world units. The zero value casts
+	// a pinhole ray with no depth of field.
+	Aperture Distance
+	// FocusDistance is the distance, in world units along the undistorted
+	// primary ray direction, of the plane that renders in sharp focus.
+	// Unused when Aperture is zero.
+	FocusDistance Distance
+	// ApertureShape selects how the lens is sampled when Aperture > 0.
+	// The zero value (ApertureDisk) is a circular aperture.
+	ApertureShape ApertureShape
 }
 
 // Cast generates a primary ray for the normalized sample position (s, t).
 // The function returns a ray that starts at LookFrom and passes through the
-// pixel center corresponding to (s, t) under the distorted projection.
+// pixel center corresponding to (s, t) under the distorted projection. If
+// Aperture > 0, Cast instead simulates a thin lens: the origin is offset by
+// a point sampled from ApertureShape scaled to Aperture/2 in the camera's
+// (u, v) plane, and the ray is re-aimed through the point where the
+// undistorted primary ray crosses the focus plane at FocusDistance, so
+// anything exactly at FocusDistance stays sharp while everything else
+// defocuses by an amount proportional to Aperture. Distortion is always
+// applied to the primary direction before this lens perturbation.
 func (cam CalibratedCamera) Cast(s, t float64, rand *Rand) ray {
 	ci := cam.Intrinsics
 	ce := cam.Extrinsics
-
-	// Camera orthonormal basis.
-	w := ce.LookFrom.Sub(ce.LookAt).Unit() // Backward
-	u := ce.VUp.Cross(w).Unit()            // Right
-	v := w.Cross(u)                        // Up
+	u, v, w := ce.basis()
 
 	// Convert normalized sample to pixel coordinates (top-left origin).
 	uPix := s * float64(ci.Width)
@@ -56,11 +71,17 @@ func (cam CalibratedCamera) Cast(s, t float64, rand *Rand) ray {
 	xd := (uPix - ci.Cx) / ci.Fx
 	yd := (vPix - ci.Cy) / ci.Fy
 
-	// Undistort to ideal normalized coordinates.
-	x, y := ci.undistortNormalized(xd, yd)
-
 	// Camera-space direction. Note: image y grows downward; camera +Y is up.
-	dirCam := r3.Vec{X: x, Y: -y, Z: -1.0}.Unit()
+	var dirCam r3.Vec
+	if ci.Model == DistortionModelFisheye {
+		theta, phi := ci.undistortFisheyeNormalized(xd, yd)
+		sinTheta, cosTheta := math.Sincos(theta)
+		dirCam = r3.Vec{X: sinTheta * math.Cos(phi), Y: -sinTheta * math.Sin(phi), Z: -cosTheta}
+	} else {
+		// Undistort to ideal normalized coordinates.
+		x, y := ci.undistortNormalized(xd, yd)
+		dirCam = r3.Vec{X: x, Y: -y, Z: -1.0}.Unit()
+	}
 
 	// World-space direction.
 	dirWorld :=
@@ -69,9 +90,32 @@ func (cam CalibratedCamera) Cast(s, t float64, rand *Rand) ray {
 			Add(w.Muls(dirCam.Z)).
 			Unit()
 
+	origin := ce.LookFrom
+	direction := dirWorld
+	if cam.Aperture > 0 {
+		// The focus plane is flat (perpendicular to the optical axis at
+		// FocusDistance), not a sphere of radius FocusDistance around
+		// LookFrom, so off-axis rays must travel farther than on-axis ones
+		// to reach it: t = FocusDistance / cos(theta), theta being the
+		// angle between this ray and the forward axis -w.
+		cosTheta := dirWorld.Dot(w.Muls(-1))
+		if cosTheta < eps {
+			cosTheta = eps
+		}
+		tFocus := float64(cam.FocusDistance) / cosTheta
+		focusPoint := origin.Add(dirWorld.Muls(tFocus))
+
+		lensRadius := cam.Aperture / 2
+		rd := cam.ApertureShape.Sample(rand)
+		offset := u.Muls(rd.X * float64(lensRadius)).Add(v.Muls(rd.Y * float64(lensRadius)))
+
+		origin = origin.Add(offset)
+		direction = focusPoint.Sub(origin).Unit()
+	}
+
 	return ray{
-		origin:    ce.LookFrom,
-		direction: dirWorld,
+		origin:    origin,
+		direction: direction,
 		depth:     0,
 		radiance:  Spectrum{1, 1, 1},
 		rand:      rand,
@@ -86,6 +130,15 @@ func (cam CalibratedCamera) Validate() error {
 	if err := cam.Extrinsics.Validate(); err != nil {
 		return fmt.Errorf("CalibratedCamera extrinsics invalid: %v", err)
 	}
+	if cam.Aperture < 0 {
+		return fmt.Errorf("CalibratedCamera Aperture cannot be negative: %v", cam.Aperture)
+	}
+	if cam.Aperture > 0 && cam.FocusDistance <= 0 {
+		return fmt.Errorf("CalibratedCamera FocusDistance must be positive when Aperture > 0: %v", cam.FocusDistance)
+	}
+	if err := cam.ApertureShape.Validate(); err != nil {
+		return fmt.Errorf("CalibratedCamera has invalid ApertureShape: %v", err)
+	}
 	return nil
 }
 
@@ -95,6 +148,24 @@ func NewCalibratedCamera(intr CameraIntrinsics, extr CameraExtrinsics) Calibrate
 	return CalibratedCamera{Intrinsics: intr, Extrinsics: extr}
 }
 
+// DistortionModel selects which lens distortion family CameraIntrinsics'
+// K/P coefficients are interpreted under.
+type DistortionModel uint8
+
+const (
+	// DistortionModelBrownConrady is OpenCV's standard polynomial radial
+	// (K1-K6) plus tangential (P1, P2) model. It is the zero value, so a
+	// CameraIntrinsics left unset defaults to it, matching the model this
+	// package has always implemented.
+	DistortionModelBrownConrady DistortionModel = iota
+	// DistortionModelFisheye is OpenCV's cv::fisheye equidistant model for
+	// wide-FOV lenses, using only K1-K4 as the coefficients of the
+	// odd-degree polynomial in the incidence angle theta:
+	// theta_d = theta*(1 + K1*theta^2 + K2*theta^4 + K3*theta^6 + K4*theta^8).
+	// P1, P2, K5, and K6 are unused under this model.
+	DistortionModelFisheye
+)
+
 // CameraIntrinsics stores OpenCV-style intrinsic parameters and image size.
 //
 // Instance purpose:
@@ -122,9 +193,15 @@ type CameraIntrinsics struct {
 	// Cy is the principal point y coordinate in pixels.
 	Cy float64
 
+	// Model selects which distortion family K1..K6, P1, P2 are interpreted
+	// under. The zero value is DistortionModelBrownConrady.
+	Model DistortionModel
+
 	// Distortion parameters follow OpenCV ordering.
-	// The standard model uses K1, K2, P1, P2, K3.
-	// The rational model additionally uses K4, K5, K6.
+	// Under DistortionModelBrownConrady, the standard model uses K1, K2,
+	// P1, P2, K3, and the rational model additionally uses K4, K5, K6.
+	// Under DistortionModelFisheye, only K1-K4 are used; see
+	// DistortionModelFisheye for their meaning.
 	K1 float64
 	K2 float64
 	P1 float64
@@ -146,6 +223,11 @@ func (ci CameraIntrinsics) Validate() error {
 	if math.IsNaN(ci.Cx) || math.IsNaN(ci.Cy) {
 		return fmt.Errorf("CameraIntrinsics NaN principal point: Cx=%g Cy=%g", ci.Cx, ci.Cy)
 	}
+	switch ci.Model {
+	case DistortionModelBrownConrady, DistortionModelFisheye:
+	default:
+		return fmt.Errorf("CameraIntrinsics unknown Model: %d", ci.Model)
+	}
 	return nil
 }
 
@@ -159,8 +241,13 @@ func (ci CameraIntrinsics) K() [3][3]float64 {
 }
 
 // D returns the distortion vector in OpenCV ordering.
-// The function returns a slice of length 5 or 8 depending on whether any K4..K6 are non-zero.
+// Under DistortionModelFisheye, the result is the 4-element cv::fisheye
+// vector [K1, K2, K3, K4]. Under DistortionModelBrownConrady, the function
+// returns a slice of length 5 or 8 depending on whether any K4..K6 are non-zero.
 func (ci CameraIntrinsics) D() []float64 {
+	if ci.Model == DistortionModelFisheye {
+		return []float64{ci.K1, ci.K2, ci.K3, ci.K4}
+	}
 	if ci.K4 == 0 && ci.K5 == 0 && ci.K6 == 0 {
 		return []float64{ci.K1, ci.K2, ci.P1, ci.P2, ci.K3}
 	}
@@ -198,6 +285,99 @@ func (ci CameraIntrinsics) undistortNormalized(xd, yd float64) (x, y float64) {
 	return x, y
 }
 
+// undistortFisheyeNormalized inverts OpenCV's cv::fisheye equidistant model
+// for a single distorted normalized point, returning the incident ray's
+// angle theta off the optical axis and its azimuth phi around it. xd, yd
+// are distorted normalized image coordinates after division by Fx, Fy.
+//
+// theta_d = |xd, yd| is known; theta is recovered by Newton iteration on
+// f(theta) = theta*(1 + K1*theta^2 + K2*theta^4 + K3*theta^6 + K4*theta^8) - theta_d,
+// starting from theta = theta_d since distortion is typically mild relative
+// to theta itself. theta is clamped to [0, pi/2+eps] because Cast's caller
+// reconstructs a ray from (theta, phi) assuming the incident ray is in the
+// camera's forward hemisphere.
+func (ci CameraIntrinsics) undistortFisheyeNormalized(xd, yd float64) (theta, phi float64) {
+	thetaD := math.Hypot(xd, yd)
+	phi = math.Atan2(yd, xd)
+
+	theta = thetaD
+	const iters = 10
+	for i := 0; i < iters; i++ {
+		t2 := theta * theta
+		t4 := t2 * t2
+		t6 := t4 * t2
+		t8 := t4 * t4
+		f := theta*(1+ci.K1*t2+ci.K2*t4+ci.K3*t6+ci.K4*t8) - thetaD
+		fPrime := 1 + 3*ci.K1*t2 + 5*ci.K2*t4 + 7*ci.K3*t6 + 9*ci.K4*t8
+		if fPrime == 0 {
+			break
+		}
+		theta -= f / fPrime
+	}
+	return clamp(theta, 0, math.Pi/2+1e-6), phi
+}
+
+// Project maps worldPt through ce's pose and ci's pinhole projection, then
+// applies forward OpenCV distortion (radial + tangential, rational model
+// if K4..K6 are non-zero) -- the forward counterpart to undistortNormalized,
+// which Cast uses to go the other way from a distorted pixel to a ray.
+// The second return reports whether worldPt is in front of the camera and
+// the resulting pixel lies within [0, Width) x [0, Height); a caller that
+// only cares about the math can ignore it, but most synthesized-keypoint
+// use cases want to discard points that fell outside the frame or behind
+// the camera.
+func (ci CameraIntrinsics) Project(worldPt r3.Point, ce CameraExtrinsics) (r2.Point, bool) {
+	u, v, w := ce.basis()
+
+	rel := worldPt.Sub(ce.LookFrom)
+	xCam := rel.Dot(u)
+	yCam := rel.Dot(v)
+	zForward := -rel.Dot(w) // Distance along the camera's viewing direction; matches Cast's dirCam = (x, -y, -1) convention.
+	if zForward <= 0 {
+		return r2.Point{}, false
+	}
+
+	var xd, yd float64
+	if ci.Model == DistortionModelFisheye {
+		// Spherical angle off the optical axis; unlike the pinhole tangent
+		// projection below, this stays well-defined past 90 degrees of FOV.
+		theta := math.Atan2(math.Hypot(xCam, yCam), zForward)
+		phi := math.Atan2(-yCam, xCam) // Matches Cast's dirCam = (sinθcosφ, -sinθsinφ, -cosθ) convention.
+
+		t2 := theta * theta
+		t4 := t2 * t2
+		t6 := t4 * t2
+		t8 := t4 * t4
+		thetaD := theta * (1 + ci.K1*t2 + ci.K2*t4 + ci.K3*t6 + ci.K4*t8)
+		xd = thetaD * math.Cos(phi)
+		yd = thetaD * math.Sin(phi)
+	} else {
+		// Ideal (undistorted) normalized coordinates.
+		x := xCam / zForward
+		y := -yCam / zForward
+
+		// Forward distortion.
+		rr := x*x + y*y
+		r4 := rr * rr
+		r6 := r4 * rr
+		num := 1.0 + ci.K1*rr + ci.K2*r4 + ci.K3*r6
+		den := 1.0 + ci.K4*rr + ci.K5*r4 + ci.K6*r6
+		if den == 0 {
+			den = 1
+		}
+		radial := num / den
+		dx := 2.0*ci.P1*x*y + ci.P2*(rr+2.0*x*x)
+		dy := ci.P1*(rr+2.0*y*y) + 2.0*ci.P2*x*y
+		xd = x*radial + dx
+		yd = y*radial + dy
+	}
+
+	uPix := xd*ci.Fx + ci.Cx
+	vPix := yd*ci.Fy + ci.Cy
+	inside := uPix >= 0 && uPix < float64(ci.Width) && vPix >= 0 && vPix < float64(ci.Height)
+	return r2.Point{X: uPix, Y: vPix}, inside
+}
+
 // NewCameraIntrinsicsFromKAndD constructs CameraIntrinsics from K and D.
 // K is the 3x3 matrix. D is 5 or 8 coefficients in OpenCV order.
 func NewCameraIntrinsicsFromKAndD(
@@ -223,6 +403,25 @@ func NewCameraIntrinsicsFromKAndD(
 	return ci
 }
 
+// NewCameraIntrinsicsFromKAndDFisheye constructs CameraIntrinsics under
+// OpenCV's cv::fisheye equidistant model. K is the 3x3 matrix; D holds the
+// model's four K1..K4 coefficients in cv::fisheye order.
+func NewCameraIntrinsicsFromKAndDFisheye(width, height int, K [3][3]float64, D [4]float64) CameraIntrinsics {
+	return CameraIntrinsics{
+		Width:  width,
+		Height: height,
+		Fx:     K[0][0],
+		Fy:     K[1][1],
+		Cx:     K[0][2],
+		Cy:     K[1][2],
+		Model:  DistortionModelFisheye,
+		K1:     D[0],
+		K2:     D[1],
+		K3:     D[2],
+		K4:     D[3],
+	}
+}
+
 // CameraExtrinsics stores the camera pose and orientation basis.
 //
 // Instance purpose:
@@ -243,6 +442,17 @@ type CameraExtrinsics struct {
 	VUp r3.Vec
 }
 
+// basis returns ce's camera orthonormal basis: u is right, v is up, w is
+// backward (the camera looks down -w). Cast, Validate, Project, and
+// rotationTranslation all derive their world<->camera conversions from
+// this same triple, so it is computed in exactly one place.
+func (ce CameraExtrinsics) basis() (u, v, w r3.Vec) {
+	w = ce.LookFrom.Sub(ce.LookAt).Unit()
+	u = ce.VUp.Cross(w).Unit()
+	v = w.Cross(u)
+	return u, v, w
+}
+
 // Validate reports whether the extrinsics define a proper camera frame.
 func (ce CameraExtrinsics) Validate() error {
 	if ce.LookFrom == ce.LookAt {
@@ -252,9 +462,7 @@ func (ce CameraExtrinsics) Validate() error {
 		return fmt.Errorf("CameraExtrinsics VUp is zero")
 	}
 	// Check orthogonality.
-	w := ce.LookFrom.Sub(ce.LookAt).Unit()
-	u := ce.VUp.Cross(w).Unit()
-	v := w.Cross(u)
+	u, v, w := ce.basis()
 	if u.IsNaN() || v.IsNaN() || w.IsNaN() {
 		return fmt.Errorf("CameraExtrinsics basis has NaN")
 	}
@@ -264,3 +472,131 @@ func (ce CameraExtrinsics) Validate() error {
 	}
 	return nil
 }
+
+// NewCameraExtrinsicsFromRvecTvec constructs CameraExtrinsics from an
+// OpenCV/ROS-style Rodrigues rotation vector and translation: rvec, tvec
+// are the rvec, tvec a call to cv2.solvePnP (or ROS's camera_calibration)
+// returns, satisfying the world-to-camera convention Xcam = R*Xworld + t
+// where R = rodriguesToMat3x3(rvec).
+//
+// The camera center in world space is C = -R^T t; LookAt is placed one
+// unit along the camera's forward axis (R^T's Z column) past C, and VUp
+// is set to the world-space image-up direction (the negation of R^T's Y
+// column, since OpenCV's camera-space Y points down the image).
+func NewCameraExtrinsicsFromRvecTvec(rvec, tvec [3]float64) CameraExtrinsics {
+	rt := rodriguesToMat3x3(rvec).Transpose()
+	t := r3.Vec{X: tvec[0], Y: tvec[1], Z: tvec[2]}
+
+	center := r3.Point{}.Subv(rt.MulVec(t))
+	forward := rt.MulVec(r3.Vec{X: 0, Y: 0, Z: 1})
+	up := rt.MulVec(r3.Vec{X: 0, Y: -1, Z: 0})
+
+	return CameraExtrinsics{
+		LookFrom: center,
+		LookAt:   center.Add(forward),
+		VUp:      up,
+	}
+}
+
+// Rvec recovers the Rodrigues rotation vector of ce's world-to-camera
+// rotation, the inverse of NewCameraExtrinsicsFromRvecTvec's R.
+func (ce CameraExtrinsics) Rvec() [3]float64 {
+	r, _ := ce.rotationTranslation()
+	return mat3x3ToRodrigues(r)
+}
+
+// Tvec recovers the world-to-camera translation t = -R*LookFrom, the
+// inverse of NewCameraExtrinsicsFromRvecTvec's t.
+func (ce CameraExtrinsics) Tvec() [3]float64 {
+	_, t := ce.rotationTranslation()
+	return [3]float64{t.X, t.Y, t.Z}
+}
+
+// rotationTranslation rebuilds the OpenCV world-to-camera R, t pair from
+// ce's LookFrom/LookAt/VUp basis -- the same u, v, w Cast and Validate
+// derive, read back out as R^T's columns (right=u, down=-v, forward=-w)
+// and inverted into R, t.
+func (ce CameraExtrinsics) rotationTranslation() (r3.Mat3x3, r3.Vec) {
+	u, v, w := ce.basis()
+
+	rt := r3.Mat3x3{M: [3][3]float64{
+		{u.X, -v.X, -w.X},
+		{u.Y, -v.Y, -w.Y},
+		{u.Z, -v.Z, -w.Z},
+	}}
+	r := rt.Transpose()
+	origin := r3.Vec{X: ce.LookFrom.X, Y: ce.LookFrom.Y, Z: ce.LookFrom.Z}
+	t := r.MulVec(origin).Muls(-1)
+	return r, t
+}
+
+// rodriguesToMat3x3 converts a Rodrigues rotation vector to a rotation
+// matrix via R = I + sin(theta)/theta * K + (1-cos(theta))/theta^2 * K^2,
+// where K is the skew-symmetric matrix of rvec and theta = |rvec|.
+func rodriguesToMat3x3(rvec [3]float64) r3.Mat3x3 {
+	rx, ry, rz := rvec[0], rvec[1], rvec[2]
+	theta := math.Sqrt(rx*rx + ry*ry + rz*rz)
+
+	k := r3.Mat3x3{M: [3][3]float64{
+		{0, -rz, ry},
+		{rz, 0, -rx},
+		{-ry, rx, 0},
+	}}
+
+	var a, b float64
+	if theta < 1e-12 {
+		// sin(theta)/theta -> 1 and (1-cos(theta))/theta^2 -> 1/2 as theta -> 0.
+		a, b = 1, 0.5
+	} else {
+		a = math.Sin(theta) / theta
+		b = (1 - math.Cos(theta)) / (theta * theta)
+	}
+
+	kk := k.Mul(k)
+	r := r3.IdentityMat3x3()
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			r.M[i][j] += a*k.M[i][j] + b*kk.M[i][j]
+		}
+	}
+	return r
+}
+
+// mat3x3ToRodrigues converts a rotation matrix back to a Rodrigues vector,
+// the inverse of rodriguesToMat3x3.
+func mat3x3ToRodrigues(r r3.Mat3x3) [3]float64 {
+	trace := r.M[0][0] + r.M[1][1] + r.M[2][2]
+	cosTheta := clamp((trace-1)/2, -1.0, 1.0)
+	theta := math.Acos(cosTheta)
+
+	if theta < 1e-12 {
+		return [3]float64{0, 0, 0}
+	}
+
+	sinTheta := math.Sin(theta)
+	if math.Pi-theta < 1e-6 {
+		// Near theta == pi, sin(theta) ~ 0 makes the general formula below
+		// divide by ~zero; recover the axis (up to an overall sign, which
+		// is ambiguous at exactly pi) from the symmetric part of R instead.
+		axis := r3.Vec{
+			X: math.Sqrt(math.Max(0, (r.M[0][0]+1)/2)),
+			Y: math.Sqrt(math.Max(0, (r.M[1][1]+1)/2)),
+			Z: math.Sqrt(math.Max(0, (r.M[2][2]+1)/2)),
+		}
+		if r.M[0][1]+r.M[1][0] < 0 {
+			axis.Y = -axis.Y
+		}
+		if r.M[0][2]+r.M[2][0] < 0 {
+			axis.Z = -axis.Z
+		}
+		axis = axis.Unit()
+		return [3]float64{axis.X * theta, axis.Y * theta, axis.Z * theta}
+	}
+
+	k := theta / (2 * sinTheta)
+	return [3]float64{
+		k * (r.M[2][1] - r.M[1][2]),
+		k * (r.M[0][2] - r.M[2][0]),
+		k * (r.M[1][0] - r.M[0][1]),
+	}
+}
@@ -1,6 +1,5 @@
 package phys
 
-
 var IntrinsicsFireflyDLComputar16mm = CameraIntrinsics{
 	Width:  1440,
 	Height: 1080,
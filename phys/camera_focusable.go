@@ -19,30 +19,80 @@ type FocusableCamera struct {
 	FOVWidth        Distance // Field of view width at the focal distance.
 	Aperture        Distance // Aperture size controlling depth of field.
 	WorkingDistance Distance // Distance from the camera to the focal plane.
+
+	// ApertureShape selects how the lens aperture is sampled. The zero
+	// value (ApertureDisk) reproduces a circular aperture, matching this
+	// camera's original behavior.
+	ApertureShape ApertureShape
 }
 
-// Cast generates a ray from the camera through the image plane at (s, t),
-// incorporating depth of field by simulating a thin lens.
-func (cam FocusableCamera) Cast(s, t float64, rand *Rand) ray {
-	// Compute the camera's orthonormal basis vectors.
-	w := cam.LookFrom.Sub(cam.LookAt).Unit() // Camera direction vector (pointing backwards)
-	u := cam.VUp.Cross(w).Unit()             // Camera right vector
-	v := w.Cross(u)                          // Camera up vector
+// NewFocusableCamera returns a FocusableCamera framed the way Shirley's Ray
+// Tracing in One Weekend book specifies a thin-lens camera: a vertical
+// field of view in degrees and an aspect ratio (width/height), rather than
+// FocusableCamera's own FOVHeight/FOVWidth fields, which are world-space
+// spans already scaled by the focal distance. vfov is the full vertical
+// field of view in degrees; aperture is the lens diameter (0 for a pinhole,
+// no depth of field); focusDistance is the distance to the plane that's in
+// perfect focus. The returned camera's ApertureShape is ApertureDisk, its
+// zero value.
+func NewFocusableCamera(lookFrom, lookAt r3.Point, vup r3.Vec, vfov, aspect float64, aperture, focusDistance Distance) FocusableCamera {
+	theta := vfov * math.Pi / 180
+	fovHeight := Distance(2 * math.Tan(theta/2))
+	fovWidth := Distance(aspect) * fovHeight
+	return FocusableCamera{
+		LookFrom:        lookFrom,
+		LookAt:          lookAt,
+		VUp:             vup,
+		FOVHeight:       fovHeight,
+		FOVWidth:        fovWidth,
+		Aperture:        aperture,
+		WorkingDistance: focusDistance,
+	}
+}
 
-	// Calculate the size of the image plane at the focal distance
-	horizontal := u.Muls(float64(cam.FOVWidth * cam.WorkingDistance)) // Horizontal span
-	vertical := v.Muls(float64(cam.FOVHeight * cam.WorkingDistance))  // Vertical span
+// basis returns the camera's orthonormal right/up/back vectors (u, v, w).
+func (cam FocusableCamera) basis() (u, v, w r3.Vec) {
+	w = cam.LookFrom.Sub(cam.LookAt).Unit() // Camera direction vector (pointing backwards)
+	u = cam.VUp.Cross(w).Unit()             // Camera right vector
+	v = w.Cross(u)                          // Camera up vector
+	return u, v, w
+}
 
-	// Compute the lower-left corner of the image plane.
-	lowerLeftCorner := cam.LookFrom.
+// focalPlaneCorner returns the lower-left corner of the image plane at the
+// focal distance along with its horizontal and vertical spans, the
+// geometry Cast and FocalPlanePoint both place (s, t) against.
+func (cam FocusableCamera) focalPlaneCorner() (lowerLeftCorner r3.Point, horizontal, vertical r3.Vec) {
+	u, v, w := cam.basis()
+	horizontal = u.Muls(float64(cam.FOVWidth * cam.WorkingDistance)) // Horizontal span
+	vertical = v.Muls(float64(cam.FOVHeight * cam.WorkingDistance))  // Vertical span
+	lowerLeftCorner = cam.LookFrom.
 		Subv(horizontal.Divs(2)).
 		Subv(vertical.Divs(2)).
 		Subv(w.Muls(float64(cam.WorkingDistance)))
+	return lowerLeftCorner, horizontal, vertical
+}
+
+// FocalPlanePoint unprojects the image-plane coordinate (s, t), each in
+// [0, 1] with (0, 0) the lower-left corner, to the world-space point it
+// lands on at the focal plane (WorkingDistance along the camera's view
+// direction). Callers use this to anchor cursor-driven interactions, such
+// as dollying a camera toward the point under the pointer, to the scene
+// rather than the image plane's own coordinate space.
+func (cam FocusableCamera) FocalPlanePoint(s, t float64) r3.Point {
+	lowerLeftCorner, horizontal, vertical := cam.focalPlaneCorner()
+	return lowerLeftCorner.Add(horizontal.Muls(s)).Add(vertical.Muls(t))
+}
+
+// Cast generates a ray from the camera through the image plane at (s, t),
+// incorporating depth of field by simulating a thin lens.
+func (cam FocusableCamera) Cast(s, t float64, rand *Rand) ray {
+	u, v, _ := cam.basis()
+	lowerLeftCorner, horizontal, vertical := cam.focalPlaneCorner()
 
 	// Simulate depth of field by sampling a random point on the lens aperture.
 	lensRadius := cam.Aperture / 2
-	rd := rand.InUnitDisk().Muls(float64(lensRadius)) // Random point in unit disk scaled by lens radius.
-	offset := u.Muls(rd.X).Add(v.Muls(rd.Y))          // Offset from the lens center.
+	rd := cam.ApertureShape.Sample(rand)                                                 // Random point on the aperture shape, within the unit disk.
+	offset := u.Muls(rd.X * float64(lensRadius)).Add(v.Muls(rd.Y * float64(lensRadius))) // Offset from the lens center.
 
 	// Compute the ray's origin and direction.
 	origin := cam.LookFrom.Add(offset) // Ray origin with lens offset.
@@ -73,6 +123,9 @@ func (cam FocusableCamera) Validate() error {
 	if cam.Aperture < 0 {
 		return fmt.Errorf("FocusableCamera Aperture cannot be negative: %v", cam)
 	}
+	if err := cam.ApertureShape.Validate(); err != nil {
+		return fmt.Errorf("FocusableCamera has invalid ApertureShape: %v", err)
+	}
 	if cam.LookFrom.IsNaN() || cam.LookAt.IsNaN() || cam.VUp.IsNaN() {
 		return fmt.Errorf("FocusableCamera has NaN values: %+v", cam)
 	}
@@ -97,3 +150,7 @@ func (cam FocusableCamera) Validate() error {
 	}
 	return nil
 }
+
+func init() {
+	RegisterInterfaceType(FocusableCamera{})
+}
@@ -0,0 +1,75 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import (
+	"math"
+	"testing"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
+)
+
+// TestNewFocusableCameraFOVSpan verifies NewFocusableCamera's vfov/aspect
+// framing produces the same world-space span at the focal plane as the
+// textbook formula: height = 2*focusDistance*tan(vfov/2), width =
+// aspect*height.
+func TestNewFocusableCameraFOVSpan(t *testing.T) {
+	const vfov, aspect = 90.0, 16.0 / 9.0
+	const focusDistance = Distance(10)
+	cam := NewFocusableCamera(
+		r3.Point{X: 0, Y: 0, Z: 0}, r3.Point{X: 0, Y: 0, Z: -1}, r3.Vec{X: 0, Y: 1, Z: 0},
+		vfov, aspect, 0, focusDistance,
+	)
+
+	_, horizontal, vertical := cam.focalPlaneCorner()
+	wantHeight := 2 * float64(focusDistance) * math.Tan(vfov*math.Pi/180/2)
+	wantWidth := aspect * wantHeight
+	if gotHeight := vertical.Length(); math.Abs(gotHeight-wantHeight) > eps {
+		t.Errorf("focal plane height = %v, want %v", gotHeight, wantHeight)
+	}
+	if gotWidth := horizontal.Length(); math.Abs(gotWidth-wantWidth) > eps {
+		t.Errorf("focal plane width = %v, want %v", gotWidth, wantWidth)
+	}
+}
+
+// TestFocusableCameraCastZeroApertureIsPinhole verifies an Aperture of 0
+// degenerates to a pinhole camera: every ray originates exactly at
+// LookFrom regardless of the lens sample drawn.
+func TestFocusableCameraCastZeroApertureIsPinhole(t *testing.T) {
+	cam := NewFocusableCamera(
+		r3.Point{X: 0, Y: 0, Z: 0}, r3.Point{X: 0, Y: 0, Z: -1}, r3.Vec{X: 0, Y: 1, Z: 0},
+		90, 1, 0, 10,
+	)
+	rand := NewRand(1)
+	for i := 0; i < 10; i++ {
+		r := cam.Cast(0.3, 0.7, rand)
+		if !r.origin.IsClose(cam.LookFrom, eps) {
+			t.Errorf("Cast origin = %v, want LookFrom %v (Aperture=0)", r.origin, cam.LookFrom)
+		}
+	}
+}
+
+// TestFocusableCameraCastFocusesAtFocusDistance verifies that, despite the
+// lens offsetting each ray's origin, every ray cast at a given (s, t)
+// still passes through FocalPlanePoint(s, t) -- the defining property of a
+// thin lens in focus.
+func TestFocusableCameraCastFocusesAtFocusDistance(t *testing.T) {
+	cam := NewFocusableCamera(
+		r3.Point{X: 0, Y: 0, Z: 0}, r3.Point{X: 0, Y: 0, Z: -1}, r3.Vec{X: 0, Y: 1, Z: 0},
+		90, 1, 2, 10,
+	)
+	wantFocusPoint := cam.FocalPlanePoint(0.3, 0.7)
+	_, _, w := cam.basis()
+
+	rand := NewRand(2)
+	for i := 0; i < 50; i++ {
+		r := cam.Cast(0.3, 0.7, rand)
+		// The focal plane is perpendicular to -w at WorkingDistance from
+		// LookFrom; find the t where r crosses it, since r's direction
+		// isn't parallel to -w once the lens has offset its origin.
+		t0 := float64(cam.WorkingDistance) / (-w.Dot(r.direction))
+		gotFocusPoint := r.origin.Add(r.direction.Muls(t0))
+		if !gotFocusPoint.IsClose(wantFocusPoint, 1e-6) {
+			t.Errorf("lens-sampled ray focus point = %v, want %v (FocalPlanePoint)", gotFocusPoint, wantFocusPoint)
+		}
+	}
+}
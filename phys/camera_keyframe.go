@@ -0,0 +1,260 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"time"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
+)
+
+// EaseFunc remaps a linear progress value t in [0,1] into an eased one,
+// also in [0,1], shaping the blend CameraKeyframes.Build produces
+// between two keyframes instead of moving between them at a constant
+// rate. CatmullRom is the one exception: Build detects it by identity
+// (see isCatmullRom) rather than calling it, since a spline needs the
+// segment's neighboring keyframes, not just t.
+type EaseFunc func(t float64) float64
+
+// Linear leaves t unchanged: constant-velocity interpolation.
+func Linear(t float64) float64 { return t }
+
+// EaseInOutCubic accelerates into and decelerates out of the midpoint
+// along a cubic, steeper than EaseInOutQuad.
+func EaseInOutCubic(t float64) float64 {
+	if t < 0.5 {
+		return 4 * t * t * t
+	}
+	f := -2*t + 2
+	return 1 - f*f*f/2
+}
+
+// EaseInOutQuad is EaseInOutCubic's gentler, quadratic counterpart.
+func EaseInOutQuad(t float64) float64 {
+	if t < 0.5 {
+		return 2 * t * t
+	}
+	f := -2*t + 2
+	return 1 - f*f/2
+}
+
+// SmoothStep is the classic 3t^2-2t^3 ease, with zero first derivative
+// at both endpoints -- the same curve CameraTimeline's
+// InterpolationEaseInOut uses.
+func SmoothStep(t float64) float64 {
+	return t * t * (3 - 2*t)
+}
+
+// CatmullRom is a sentinel EaseFunc: CameraKeyframes.Build detects it by
+// identity (isCatmullRom) and, when matched, blends LookFrom along a
+// Catmull-Rom spline through the segment's two neighboring keyframes
+// instead of a two-point lerp -- the same technique CameraTimeline.WithU
+// uses for InterpolationCatmullRom. Calling CatmullRom directly (rather
+// than through Build) just returns t unchanged, since it's never
+// evaluated as a plain ease.
+func CatmullRom(t float64) float64 { return t }
+
+// isCatmullRom reports whether ease is the CatmullRom sentinel, compared
+// by function pointer since Go has no other way to compare func values.
+func isCatmullRom(ease EaseFunc) bool {
+	return ease != nil && reflect.ValueOf(ease).Pointer() == reflect.ValueOf(EaseFunc(CatmullRom)).Pointer()
+}
+
+// WrapMode selects how CameraKeyframes.Build maps a progress value
+// outside its keyframes' own [U_0, U_last] span back into range.
+type WrapMode int
+
+const (
+	// WrapClamp holds the first or last keyframe's pose for u outside
+	// [U_0, U_last]. The zero value.
+	WrapClamp WrapMode = iota
+	// WrapLoop wraps u back into [U_0, U_last] modulo the span, as if the
+	// last keyframe fed straight back into the first.
+	WrapLoop
+	// WrapPingPong bounces u back and forth across [U_0, U_last] instead
+	// of wrapping, so the animation reverses direction at each end rather
+	// than jumping back to the start.
+	WrapPingPong
+)
+
+// CameraKeyframe is one pose in a CameraKeyframes timeline: the camera
+// Build should reach at normalized progress U, blended into from the
+// keyframe before it using Ease.
+type CameraKeyframe struct {
+	// U is this keyframe's normalized progress. CameraKeyframes.Keys must
+	// be sorted in strictly ascending U.
+	U float64
+
+	// Cam is the pose to reach. Must be a FocusableCamera: the one
+	// Camera in this package whose fields (LookFrom, LookAt, VUp,
+	// FOVWidth, FOVHeight, Aperture, WorkingDistance) cover every
+	// extrinsic and intrinsic Build blends. CameraKeyframes.Validate
+	// rejects any other Camera implementation.
+	Cam Camera
+
+	// Ease shapes the blend of the segment leading into this keyframe
+	// from the one before it; ignored on the first keyframe, which has
+	// no preceding segment. Nil defaults to Linear.
+	Ease EaseFunc
+}
+
+// CameraKeyframes is a sorted list of CameraKeyframe poses, blended into
+// a single CameraFunc by Build (or NewKeyframedCamera, which wraps Build
+// in an AnimatedCamera), with Wrap resolving progress outside their span.
+//
+// Zero value:
+// The zero value has no Keys and is not usable; construct one as a
+// struct literal.
+type CameraKeyframes struct {
+	Keys []CameraKeyframe
+	Wrap WrapMode
+}
+
+// Validate reports whether ks has at least one keyframe, strictly
+// ascending U values, a recognized Wrap, and a valid FocusableCamera
+// (the only Cam NewKeyframedCamera knows how to blend) at every
+// keyframe.
+func (ks CameraKeyframes) Validate() error {
+	if len(ks.Keys) == 0 {
+		return fmt.Errorf("CameraKeyframes has no keyframes")
+	}
+	for i, k := range ks.Keys {
+		if i > 0 && k.U <= ks.Keys[i-1].U {
+			return fmt.Errorf("keyframe %d: U %v must be strictly greater than keyframe %d's U %v", i, k.U, i-1, ks.Keys[i-1].U)
+		}
+		cam, ok := k.Cam.(FocusableCamera)
+		if !ok {
+			return fmt.Errorf("keyframe %d: Cam must be a FocusableCamera, got %T", i, k.Cam)
+		}
+		if err := cam.Validate(); err != nil {
+			return fmt.Errorf("keyframe %d: %v", i, err)
+		}
+	}
+	switch ks.Wrap {
+	case WrapClamp, WrapLoop, WrapPingPong:
+	default:
+		return fmt.Errorf("CameraKeyframes has unknown Wrap %v", ks.Wrap)
+	}
+	return nil
+}
+
+// resolveU maps u into ks's own [Keys[0].U, Keys[last].U] span according
+// to Wrap; wrapping/bouncing are no-ops when there's only one keyframe to
+// span between.
+func (ks CameraKeyframes) resolveU(u float64) float64 {
+	first, last := ks.Keys[0].U, ks.Keys[len(ks.Keys)-1].U
+	span := last - first
+	if span == 0 {
+		return first
+	}
+	switch ks.Wrap {
+	case WrapLoop:
+		offset := math.Mod(u-first, span)
+		if offset < 0 {
+			offset += span
+		}
+		return first + offset
+	case WrapPingPong:
+		period := 2 * span
+		offset := math.Mod(u-first, period)
+		if offset < 0 {
+			offset += period
+		}
+		if offset > span {
+			offset = period - offset
+		}
+		return first + offset
+	default: // WrapClamp
+		if u < first {
+			return first
+		}
+		if u > last {
+			return last
+		}
+		return u
+	}
+}
+
+// bracket returns the index of the keyframe at or before u (already
+// mapped through resolveU) and the linear progress toward the next one.
+// Mirrors CameraTimeline.bracket.
+func (ks CameraKeyframes) bracket(u float64) (i int, t float64) {
+	n := len(ks.Keys)
+	if n == 1 || u <= ks.Keys[0].U {
+		return 0, 0
+	}
+	if u >= ks.Keys[n-1].U {
+		return n - 1, 0
+	}
+	i = 0
+	for i < n-1 && ks.Keys[i+1].U < u {
+		i++
+	}
+	span := ks.Keys[i+1].U - ks.Keys[i].U
+	return i, (u - ks.Keys[i].U) / span
+}
+
+// Build returns the FocusableCamera at normalized progress u, after
+// resolveU maps it into range: LookFrom lerps (or, under the CatmullRom
+// ease, follows a spline through the segment's neighboring keyframes),
+// the LookAt direction and VUp slerp along their great arc (slerpUnit),
+// and FOVWidth/FOVHeight/Aperture/WorkingDistance lerp, all
+// reparameterized by the bracketing segment's Ease. Has the signature of
+// a CameraFunc; pass it to NewAnimatedCamera directly, or use
+// NewKeyframedCamera.
+func (ks CameraKeyframes) Build(u float64) Camera {
+	i, t := ks.bracket(ks.resolveU(u))
+	if t == 0 {
+		return ks.Keys[i].Cam
+	}
+	k0, k1 := ks.Keys[i], ks.Keys[i+1]
+	cam0, cam1 := k0.Cam.(FocusableCamera), k1.Cam.(FocusableCamera)
+	ease := k1.Ease
+	if ease == nil {
+		ease = Linear
+	}
+
+	var lookFrom r3.Point
+	var eased float64
+	if isCatmullRom(ease) {
+		prev, next := k0, k1
+		if i > 0 {
+			prev = ks.Keys[i-1]
+		}
+		if i+2 < len(ks.Keys) {
+			next = ks.Keys[i+2]
+		}
+		lookFrom = catmullRomPoint(prev.Cam.(FocusableCamera).LookFrom, cam0.LookFrom, cam1.LookFrom, next.Cam.(FocusableCamera).LookFrom, t)
+		eased = t
+	} else {
+		eased = ease(t)
+		lookFrom = cam0.LookFrom.Lerp(cam1.LookFrom, eased)
+	}
+
+	dir0, dist0 := cam0.LookAt.Sub(cam0.LookFrom).Unit(), cam0.LookAt.Sub(cam0.LookFrom).Length()
+	dir1, dist1 := cam1.LookAt.Sub(cam1.LookFrom).Unit(), cam1.LookAt.Sub(cam1.LookFrom).Length()
+	dir := slerpUnit(dir0, dir1, eased)
+	dist := dist0 + eased*(dist1-dist0)
+	vup := slerpUnit(cam0.VUp.Unit(), cam1.VUp.Unit(), eased)
+
+	return FocusableCamera{
+		LookFrom:        lookFrom,
+		LookAt:          lookFrom.Add(dir.Muls(dist)),
+		VUp:             vup,
+		FOVHeight:       cam0.FOVHeight + Distance(eased)*(cam1.FOVHeight-cam0.FOVHeight),
+		FOVWidth:        cam0.FOVWidth + Distance(eased)*(cam1.FOVWidth-cam0.FOVWidth),
+		Aperture:        cam0.Aperture + Distance(eased)*(cam1.Aperture-cam0.Aperture),
+		WorkingDistance: cam0.WorkingDistance + Distance(eased)*(cam1.WorkingDistance-cam0.WorkingDistance),
+		ApertureShape:   cam0.ApertureShape,
+	}
+}
+
+// NewKeyframedCamera returns an AnimatedCamera driven by ks.Build, with
+// the given Period for WithTime/Advance. ks is not validated here; call
+// ks.Validate, or AnimatedCamera.Validate on the result, before relying
+// on it.
+func NewKeyframedCamera(ks CameraKeyframes, period time.Duration) AnimatedCamera {
+	return NewAnimatedCamera(ks.Build, 0, period)
+}
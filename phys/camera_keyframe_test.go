@@ -0,0 +1,137 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import (
+	"testing"
+	"time"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
+)
+
+func testFocusableCamera(lookFromX float64) FocusableCamera {
+	return FocusableCamera{
+		LookFrom:        r3.Point{X: lookFromX, Y: 0, Z: 5},
+		LookAt:          r3.Point{},
+		VUp:             r3.Vec{Y: 1},
+		FOVHeight:       1,
+		FOVWidth:        1,
+		Aperture:        0.1,
+		WorkingDistance: 5,
+	}
+}
+
+func testCameraKeyframes(ease EaseFunc, wrap WrapMode) CameraKeyframes {
+	return CameraKeyframes{
+		Wrap: wrap,
+		Keys: []CameraKeyframe{
+			{U: 0, Cam: testFocusableCamera(-10)},
+			{U: 0.5, Cam: testFocusableCamera(0), Ease: ease},
+			{U: 1, Cam: testFocusableCamera(10), Ease: ease},
+		},
+	}
+}
+
+func TestCameraKeyframesValidate(t *testing.T) {
+	if err := testCameraKeyframes(Linear, WrapClamp).Validate(); err != nil {
+		t.Errorf("Validate() = %v, want nil", err)
+	}
+	if err := (CameraKeyframes{}).Validate(); err == nil {
+		t.Error("Validate() on empty CameraKeyframes = nil, want an error")
+	}
+	unsorted := testCameraKeyframes(Linear, WrapClamp)
+	unsorted.Keys[1].U = 0
+	if err := unsorted.Validate(); err == nil {
+		t.Error("Validate() with non-increasing keyframe U = nil, want an error")
+	}
+	wrongType := testCameraKeyframes(Linear, WrapClamp)
+	wrongType.Keys[0].Cam = PinholeCamera{}
+	if err := wrongType.Validate(); err == nil {
+		t.Error("Validate() with a non-FocusableCamera Cam = nil, want an error")
+	}
+}
+
+func TestCameraKeyframesBuildLinear(t *testing.T) {
+	ks := testCameraKeyframes(Linear, WrapClamp)
+	lookFromX := func(u float64) float64 {
+		cam, ok := ks.Build(u).(FocusableCamera)
+		if !ok {
+			t.Fatalf("Build(%v) = %T, want FocusableCamera", u, ks.Build(u))
+		}
+		return cam.LookFrom.X
+	}
+	if got, want := lookFromX(0), -10.0; got != want {
+		t.Errorf("Build(0).LookFrom.X = %v, want %v", got, want)
+	}
+	if got, want := lookFromX(0.25), -5.0; got != want {
+		t.Errorf("Build(0.25).LookFrom.X = %v, want %v", got, want)
+	}
+	if got, want := lookFromX(0.75), 5.0; got != want {
+		t.Errorf("Build(0.75).LookFrom.X = %v, want %v", got, want)
+	}
+}
+
+// TestCameraKeyframesBuildEaseInOutSlowsNearKeyframes verifies that an
+// EaseInOutCubic segment moves less than a Linear one over the first
+// quarter of its span, the hallmark of easing into a keyframe instead of
+// moving at a constant rate.
+func TestCameraKeyframesBuildEaseInOutSlowsNearKeyframes(t *testing.T) {
+	linear := testCameraKeyframes(Linear, WrapClamp)
+	eased := testCameraKeyframes(EaseInOutCubic, WrapClamp)
+
+	linearX := linear.Build(0.125).(FocusableCamera).LookFrom.X
+	easedX := eased.Build(0.125).(FocusableCamera).LookFrom.X
+	// Both segments start at X=-10 heading to X=0; EaseInOutCubic should
+	// have covered less ground a quarter of the way through the segment.
+	if easedX >= linearX {
+		t.Errorf("eased LookFrom.X = %v, want less progress than linear's %v this early in the segment", easedX, linearX)
+	}
+}
+
+func TestCameraKeyframesBuildCatmullRomPassesThroughKeyframes(t *testing.T) {
+	ks := CameraKeyframes{
+		Wrap: WrapClamp,
+		Keys: []CameraKeyframe{
+			{U: 0, Cam: testFocusableCamera(-10)},
+			{U: 0.33, Cam: testFocusableCamera(-2), Ease: CatmullRom},
+			{U: 0.66, Cam: testFocusableCamera(2), Ease: CatmullRom},
+			{U: 1, Cam: testFocusableCamera(10), Ease: CatmullRom},
+		},
+	}
+	if err := ks.Validate(); err != nil {
+		t.Fatalf("Validate() = %v, want nil", err)
+	}
+	// A Catmull-Rom spline passes exactly through its control keyframes.
+	got := ks.Build(0.33).(FocusableCamera).LookFrom.X
+	if got != -2 {
+		t.Errorf("Build(0.33).LookFrom.X = %v, want -2 (exactly at a keyframe)", got)
+	}
+}
+
+func TestCameraKeyframesBuildWrapModes(t *testing.T) {
+	clamp := testCameraKeyframes(Linear, WrapClamp)
+	if got, want := clamp.Build(1.5).(FocusableCamera).LookFrom.X, 10.0; got != want {
+		t.Errorf("WrapClamp Build(1.5).LookFrom.X = %v, want %v (held at the last keyframe)", got, want)
+	}
+
+	loop := testCameraKeyframes(Linear, WrapLoop)
+	if got, want := loop.Build(1.25).(FocusableCamera).LookFrom.X, loop.Build(0.25).(FocusableCamera).LookFrom.X; got != want {
+		t.Errorf("WrapLoop Build(1.25).LookFrom.X = %v, want the same as Build(0.25) = %v", got, want)
+	}
+
+	pingPong := testCameraKeyframes(Linear, WrapPingPong)
+	if got, want := pingPong.Build(1.25).(FocusableCamera).LookFrom.X, pingPong.Build(0.75).(FocusableCamera).LookFrom.X; got != want {
+		t.Errorf("WrapPingPong Build(1.25).LookFrom.X = %v, want the same as Build(0.75) = %v (bouncing back)", got, want)
+	}
+}
+
+func TestNewKeyframedCameraAnimates(t *testing.T) {
+	ac := NewKeyframedCamera(testCameraKeyframes(Linear, WrapClamp), time.Second)
+	if err := ac.Validate(); err != nil {
+		t.Fatalf("Validate() = %v, want nil", err)
+	}
+	r0 := ac.WithU(0).Cast(0.5, 0.5, NewRand(0))
+	r1 := ac.WithU(0.5).Cast(0.5, 0.5, NewRand(0))
+	if r0.origin.Eq(r1.origin) {
+		t.Errorf("rays cast at different U should originate from different camera positions")
+	}
+}
@@ -0,0 +1,265 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+
+package phys
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
+)
+
+// CameraPath produces an ordered sequence of Cameras tracing out a motion
+// through a scene: an orbit, a dolly move, a spline flythrough, or an
+// arbitrary look-at track. RenderSequence renders every Camera a
+// CameraPath yields, in order, replacing the hand-rolled trig loops this
+// chunk's scan and material examples used to build their cameraSequence
+// (see the old comment-for-comment orbit loop once in example/scan/main.go).
+type CameraPath interface {
+	Cameras() ([]Camera, error)
+}
+
+// Orbit is a CameraPath that places Steps cameras evenly around a circle
+// of Radius centered on Center, in the plane perpendicular to Axis and
+// offset along Axis by Elevation, each looking back at Center. Template
+// supplies every other OrthographicCamera field (VUp, FOVHeight,
+// FOVWidth) shared by the whole sequence.
+type Orbit struct {
+	Center    r3.Point
+	Axis      r3.Vec
+	Radius    float64
+	Elevation float64
+	Steps     int
+	Template  OrthographicCamera
+}
+
+func (o Orbit) Cameras() ([]Camera, error) {
+	if o.Steps <= 0 {
+		return nil, fmt.Errorf("phys.Orbit: Steps must be positive, got %d", o.Steps)
+	}
+	axis := o.Axis.Unit()
+	if axis.IsNaN() || axis.IsZero() {
+		return nil, fmt.Errorf("phys.Orbit: Axis must be a well-defined non-zero vector, got %v", o.Axis)
+	}
+	u, v := orthonormalBasis(axis)
+	cams := make([]Camera, o.Steps)
+	for i := 0; i < o.Steps; i++ {
+		theta := float64(i) * 2 * math.Pi / float64(o.Steps)
+		offset := u.Muls(o.Radius * math.Cos(theta)).
+			Add(v.Muls(o.Radius * math.Sin(theta))).
+			Add(axis.Muls(o.Elevation))
+		cam := o.Template
+		cam.LookFrom = o.Center.Add(offset)
+		cam.LookAt = o.Center
+		cams[i] = cam
+	}
+	return cams, nil
+}
+
+// Dolly is a CameraPath that linearly interpolates the camera position
+// from From to To over Steps cameras, all looking at Template.LookAt.
+type Dolly struct {
+	From, To r3.Point
+	Steps    int
+	Template OrthographicCamera
+}
+
+func (d Dolly) Cameras() ([]Camera, error) {
+	if d.Steps <= 0 {
+		return nil, fmt.Errorf("phys.Dolly: Steps must be positive, got %d", d.Steps)
+	}
+	cams := make([]Camera, d.Steps)
+	for i := 0; i < d.Steps; i++ {
+		t := 0.0
+		if d.Steps > 1 {
+			t = float64(i) / float64(d.Steps-1)
+		}
+		cam := d.Template
+		cam.LookFrom = r3.Point{
+			X: d.From.X + (d.To.X-d.From.X)*t,
+			Y: d.From.Y + (d.To.Y-d.From.Y)*t,
+			Z: d.From.Z + (d.To.Z-d.From.Z)*t,
+		}
+		cams[i] = cam
+	}
+	return cams, nil
+}
+
+// LookAtTrack is a CameraPath that places one camera at each point of
+// Path, all looking at the fixed point Target. Template supplies every
+// other OrthographicCamera field shared by the whole sequence.
+type LookAtTrack struct {
+	Path     []r3.Point
+	Target   r3.Point
+	Template OrthographicCamera
+}
+
+func (lt LookAtTrack) Cameras() ([]Camera, error) {
+	if len(lt.Path) == 0 {
+		return nil, fmt.Errorf("phys.LookAtTrack: Path must hold at least one point")
+	}
+	cams := make([]Camera, len(lt.Path))
+	for i, p := range lt.Path {
+		cam := lt.Template
+		cam.LookFrom = p
+		cam.LookAt = lt.Target
+		cams[i] = cam
+	}
+	return cams, nil
+}
+
+// CameraKey is one keyframe of a Spline path: a position, the point it
+// looks at, its up vector, and its field of view.
+type CameraKey struct {
+	Position  r3.Point
+	LookAt    r3.Point
+	VUp       r3.Vec
+	FOVHeight Distance
+	FOVWidth  Distance
+}
+
+// Spline is a CameraPath that interpolates a smooth flythrough across
+// Keyframes: position via Catmull-Rom, so the path passes exactly through
+// every keyframe instead of just toward it the way a piecewise Dolly
+// would, and orientation via quaternion Slerp, so a turning camera sweeps
+// at a constant angular rate instead of the swimming, non-uniform turn a
+// naive per-axis lerp of LookAt/VUp produces. StepsPerSegment cameras are
+// sampled between each consecutive pair of keyframes, plus one final
+// camera exactly at the last keyframe.
+type Spline struct {
+	Keyframes       []CameraKey
+	StepsPerSegment int
+}
+
+func (s Spline) Cameras() ([]Camera, error) {
+	if len(s.Keyframes) < 2 {
+		return nil, fmt.Errorf("phys.Spline: need at least 2 Keyframes, got %d", len(s.Keyframes))
+	}
+	if s.StepsPerSegment <= 0 {
+		return nil, fmt.Errorf("phys.Spline: StepsPerSegment must be positive, got %d", s.StepsPerSegment)
+	}
+	orientations := make([]Quaternion, len(s.Keyframes))
+	for i, k := range s.Keyframes {
+		forward := k.LookAt.Sub(k.Position)
+		if forward.IsZero() {
+			return nil, fmt.Errorf("phys.Spline: keyframe %d has coincident Position and LookAt", i)
+		}
+		if k.VUp.IsZero() {
+			return nil, fmt.Errorf("phys.Spline: keyframe %d has a zero VUp", i)
+		}
+		orientations[i] = QuaternionLookRotation(forward.Unit(), k.VUp.Unit())
+	}
+	n := len(s.Keyframes)
+	var cams []Camera
+	for seg := 0; seg < n-1; seg++ {
+		p0 := s.Keyframes[max(seg-1, 0)].Position
+		p1 := s.Keyframes[seg].Position
+		p2 := s.Keyframes[seg+1].Position
+		p3 := s.Keyframes[min(seg+2, n-1)].Position
+		k1, k2 := s.Keyframes[seg], s.Keyframes[seg+1]
+		for step := 0; step < s.StepsPerSegment; step++ {
+			t := float64(step) / float64(s.StepsPerSegment)
+			pos := catmullRomPoint(p0, p1, p2, p3, t)
+			q := Slerp(orientations[seg], orientations[seg+1], t)
+			forward := q.ToRotationMatrix().TransformVec(r3.Vec{X: 0, Y: 0, Z: -1})
+			up := q.ToRotationMatrix().TransformVec(r3.Vec{X: 0, Y: 1, Z: 0})
+			cams = append(cams, OrthographicCamera{
+				LookFrom:  pos,
+				LookAt:    pos.Add(forward),
+				VUp:       up,
+				FOVHeight: Distance(float64(k1.FOVHeight) + (float64(k2.FOVHeight)-float64(k1.FOVHeight))*t),
+				FOVWidth:  Distance(float64(k1.FOVWidth) + (float64(k2.FOVWidth)-float64(k1.FOVWidth))*t),
+			})
+		}
+	}
+	last := s.Keyframes[n-1]
+	cams = append(cams, OrthographicCamera{
+		LookFrom:  last.Position,
+		LookAt:    last.LookAt,
+		VUp:       last.VUp,
+		FOVHeight: last.FOVHeight,
+		FOVWidth:  last.FOVWidth,
+	})
+	return cams, nil
+}
+
+// QuaternionLookRotation builds the unit Quaternion that rotates the
+// reference axes (forward {0,0,-1}, up {0,1,0}) onto the given forward and
+// up, via a swing (aligning forward) followed by a twist around forward
+// (aligning up), the standard decomposition for deriving an orientation
+// from a look direction plus an up hint. Exported for Spline's own use
+// above and for callers recording a LookFrom/LookAt/VUp camera pose as a
+// phys/anim.CameraKeyframe's Orientation.
+func QuaternionLookRotation(forward, up r3.Vec) Quaternion {
+	refForward := r3.Vec{X: 0, Y: 0, Z: -1}
+	swing := quaternionBetweenUnitVectors(refForward, forward)
+	refUp := swing.ToRotationMatrix().TransformVec(r3.Vec{X: 0, Y: 1, Z: 0})
+	desiredUp := up.Sub(forward.Muls(up.Dot(forward))).Unit() // up projected orthogonal to forward.
+	twist := quaternionBetweenUnitVectors(refUp, desiredUp)
+	return twist.Multiply(swing)
+}
+
+// quaternionBetweenUnitVectors returns the unit Quaternion rotating unit
+// vector a onto unit vector b by the shortest arc. When a and b point in
+// opposite directions (no unique shortest arc), it picks an arbitrary
+// rotation axis orthogonal to a, matching the convention the rest of the
+// path code's orthonormalBasis already uses for an otherwise-undefined
+// perpendicular direction.
+func quaternionBetweenUnitVectors(a, b r3.Vec) Quaternion {
+	dot := clamp(a.Dot(b), -1, 1)
+	if dot > 1-1e-9 {
+		return Quaternion{W: 1}
+	}
+	if dot < -1+1e-9 {
+		axis, _ := orthonormalBasis(a)
+		return NewQuaternion(axis, math.Pi)
+	}
+	axis := a.Cross(b).Unit()
+	return NewQuaternion(axis, math.Acos(dot))
+}
+
+// RenderSequence renders scene once per Camera path.Cameras() yields,
+// substituting each in turn as scene.Camera[0], and returns one
+// RenderArtifact per Camera in path order. It stops and returns the
+// partial results gathered so far alongside the error from the first
+// camera or render that fails, matching Render's own fail-fast behavior.
+func RenderSequence(ctx context.Context, scene *Scene, path CameraPath) ([]*RenderArtifact, error) {
+	cameras, err := path.Cameras()
+	if err != nil {
+		return nil, fmt.Errorf("RenderSequence: %w", err)
+	}
+	artifacts := make([]*RenderArtifact, 0, len(cameras))
+	original := scene.Camera
+	defer func() { scene.Camera = original }()
+	for i, cam := range cameras {
+		scene.Camera = []Camera{cam}
+		artifact, err := Render(ctx, scene)
+		if err != nil {
+			return artifacts, fmt.Errorf("RenderSequence: camera %d/%d: %w", i, len(cameras), err)
+		}
+		artifacts = append(artifacts, &artifact)
+	}
+	return artifacts, nil
+}
+
+// SaveSequence writes artifacts, in order, to an Encoder opened at path
+// via NewEncoder, which dispatches on path's extension (.png for APNG,
+// .mp4 for an ffmpeg-backed video) so phys itself never depends on a
+// codec. fps sets the playback rate passed through to NewEncoder.
+func SaveSequence(path string, fps int, artifacts []*RenderArtifact) error {
+	if len(artifacts) == 0 {
+		return fmt.Errorf("SaveSequence: artifacts is empty")
+	}
+	enc, err := NewEncoder(path, fps)
+	if err != nil {
+		return fmt.Errorf("SaveSequence: %w", err)
+	}
+	for i, artifact := range artifacts {
+		if err := enc.WriteFrame(artifact.Image); err != nil {
+			enc.Close()
+			return fmt.Errorf("SaveSequence: frame %d: %w", i, err)
+		}
+	}
+	return enc.Close()
+}
@@ -0,0 +1,143 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import (
+	"math"
+	"testing"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
+)
+
+func TestOrbitCameras(t *testing.T) {
+	o := Orbit{
+		Center:   r3.Point{},
+		Axis:     r3.Vec{Y: 1},
+		Radius:   10,
+		Steps:    4,
+		Template: OrthographicCamera{VUp: r3.Vec{Y: 1}, FOVHeight: 1, FOVWidth: 1},
+	}
+	cams, err := o.Cameras()
+	if err != nil {
+		t.Fatalf("Cameras() err = %v, want nil", err)
+	}
+	if len(cams) != 4 {
+		t.Fatalf("len(Cameras()) = %d, want 4", len(cams))
+	}
+	for i, c := range cams {
+		cam := c.(OrthographicCamera)
+		if cam.LookAt != o.Center {
+			t.Errorf("camera %d LookAt = %v, want %v", i, cam.LookAt, o.Center)
+		}
+		if got := cam.LookFrom.Sub(o.Center).Length(); math.Abs(got-o.Radius) > 1e-9 {
+			t.Errorf("camera %d is %v from Center, want Radius %v", i, got, o.Radius)
+		}
+	}
+}
+
+func TestOrbitCamerasRejectsZeroSteps(t *testing.T) {
+	if _, err := (Orbit{Axis: r3.Vec{Y: 1}, Radius: 1, Steps: 0}).Cameras(); err == nil {
+		t.Error("Cameras() with Steps=0 = nil error, want an error")
+	}
+}
+
+func TestOrbitCamerasRejectsZeroAxis(t *testing.T) {
+	if _, err := (Orbit{Axis: r3.Vec{}, Radius: 1, Steps: 4}).Cameras(); err == nil {
+		t.Error("Cameras() with a zero Axis = nil error, want an error")
+	}
+}
+
+func TestDollyCameras(t *testing.T) {
+	d := Dolly{
+		From:     r3.Point{X: 0},
+		To:       r3.Point{X: 10},
+		Steps:    3,
+		Template: OrthographicCamera{LookAt: r3.Point{X: 5, Y: 1}, VUp: r3.Vec{Y: 1}, FOVHeight: 1, FOVWidth: 1},
+	}
+	cams, err := d.Cameras()
+	if err != nil {
+		t.Fatalf("Cameras() err = %v, want nil", err)
+	}
+	want := []float64{0, 5, 10}
+	for i, c := range cams {
+		cam := c.(OrthographicCamera)
+		if got := cam.LookFrom.X; math.Abs(got-want[i]) > 1e-9 {
+			t.Errorf("camera %d LookFrom.X = %v, want %v", i, got, want[i])
+		}
+		if cam.LookAt != d.Template.LookAt {
+			t.Errorf("camera %d LookAt = %v, want %v", i, cam.LookAt, d.Template.LookAt)
+		}
+	}
+}
+
+func TestLookAtTrackCameras(t *testing.T) {
+	lt := LookAtTrack{
+		Path:     []r3.Point{{X: 0}, {X: 1}, {X: 2}},
+		Target:   r3.Point{Y: 1},
+		Template: OrthographicCamera{VUp: r3.Vec{Y: 1}, FOVHeight: 1, FOVWidth: 1},
+	}
+	cams, err := lt.Cameras()
+	if err != nil {
+		t.Fatalf("Cameras() err = %v, want nil", err)
+	}
+	if len(cams) != len(lt.Path) {
+		t.Fatalf("len(Cameras()) = %d, want %d", len(cams), len(lt.Path))
+	}
+	for i, c := range cams {
+		cam := c.(OrthographicCamera)
+		if cam.LookFrom != lt.Path[i] {
+			t.Errorf("camera %d LookFrom = %v, want %v", i, cam.LookFrom, lt.Path[i])
+		}
+		if cam.LookAt != lt.Target {
+			t.Errorf("camera %d LookAt = %v, want %v", i, cam.LookAt, lt.Target)
+		}
+	}
+}
+
+func TestLookAtTrackCamerasRejectsEmptyPath(t *testing.T) {
+	if _, err := (LookAtTrack{}).Cameras(); err == nil {
+		t.Error("Cameras() with an empty Path = nil error, want an error")
+	}
+}
+
+func TestSplineCamerasPassesThroughKeyframes(t *testing.T) {
+	s := Spline{
+		Keyframes: []CameraKey{
+			{Position: r3.Point{X: 0}, LookAt: r3.Point{X: 0, Z: -1}, VUp: r3.Vec{Y: 1}, FOVHeight: 1, FOVWidth: 1},
+			{Position: r3.Point{X: 10}, LookAt: r3.Point{X: 10, Z: -1}, VUp: r3.Vec{Y: 1}, FOVHeight: 1, FOVWidth: 1},
+			{Position: r3.Point{X: 20}, LookAt: r3.Point{X: 20, Z: -1}, VUp: r3.Vec{Y: 1}, FOVHeight: 1, FOVWidth: 1},
+		},
+		StepsPerSegment: 4,
+	}
+	cams, err := s.Cameras()
+	if err != nil {
+		t.Fatalf("Cameras() err = %v, want nil", err)
+	}
+	if got, want := len(cams), 2*4+1; got != want {
+		t.Fatalf("len(Cameras()) = %d, want %d", got, want)
+	}
+	first := cams[0].(OrthographicCamera)
+	if first.LookFrom != s.Keyframes[0].Position {
+		t.Errorf("first camera LookFrom = %v, want %v", first.LookFrom, s.Keyframes[0].Position)
+	}
+	last := cams[len(cams)-1].(OrthographicCamera)
+	if last.LookFrom != s.Keyframes[2].Position {
+		t.Errorf("last camera LookFrom = %v, want %v", last.LookFrom, s.Keyframes[2].Position)
+	}
+}
+
+func TestSplineCamerasRejectsTooFewKeyframes(t *testing.T) {
+	s := Spline{Keyframes: []CameraKey{{Position: r3.Point{}, LookAt: r3.Point{Z: -1}, VUp: r3.Vec{Y: 1}}}, StepsPerSegment: 4}
+	if _, err := s.Cameras(); err == nil {
+		t.Error("Cameras() with 1 Keyframe = nil error, want an error")
+	}
+}
+
+func TestQuaternionLookRotationRoundTrips(t *testing.T) {
+	forward := r3.Vec{X: 1, Y: 0, Z: 0}.Unit()
+	up := r3.Vec{Y: 1}
+	q := QuaternionLookRotation(forward, up)
+	gotForward := q.ToRotationMatrix().TransformVec(r3.Vec{Z: -1})
+	if !gotForward.IsClose(forward, 1e-9) {
+		t.Errorf("reconstructed forward = %v, want %v", gotForward, forward)
+	}
+}
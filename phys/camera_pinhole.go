@@ -58,3 +58,7 @@ func (cam PinholeCamera) Validate() error {
 	}
 	return nil
 }
+
+func init() {
+	RegisterInterfaceType(PinholeCamera{})
+}
@@ -0,0 +1,105 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import (
+	"testing"
+	"time"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
+)
+
+func testCameraTimeline(interp InterpolationKind) CameraTimeline {
+	intr := CameraIntrinsics{Width: 100, Height: 100, Fx: 50, Fy: 50, Cx: 50, Cy: 50}
+	return CameraTimeline{
+		Interpolation: interp,
+		Duration:      2 * time.Second,
+		Keyframes: []CameraTimelineKeyframe{
+			{T: 0, LookFrom: r3.Point{X: -10, Z: 5}, LookAt: r3.Point{}, VUp: r3.Vec{Y: 1}, Intrinsics: intr},
+			{T: time.Second, LookFrom: r3.Point{X: 0, Z: 5}, LookAt: r3.Point{}, VUp: r3.Vec{Y: 1}, Intrinsics: intr},
+			{T: 2 * time.Second, LookFrom: r3.Point{X: 10, Z: 5}, LookAt: r3.Point{}, VUp: r3.Vec{Y: 1}, Intrinsics: intr},
+		},
+	}
+}
+
+func TestCameraTimelineValidate(t *testing.T) {
+	if err := testCameraTimeline(InterpolationLinear).Validate(); err != nil {
+		t.Errorf("Validate() = %v, want nil", err)
+	}
+	if err := (CameraTimeline{}).Validate(); err == nil {
+		t.Error("Validate() on an empty CameraTimeline = nil, want an error")
+	}
+	unsorted := testCameraTimeline(InterpolationLinear)
+	unsorted.Keyframes[1].T = 0
+	if err := unsorted.Validate(); err == nil {
+		t.Error("Validate() with non-increasing keyframe T = nil, want an error")
+	}
+	noDuration := testCameraTimeline(InterpolationLinear)
+	noDuration.Duration = 0
+	if err := noDuration.Validate(); err == nil {
+		t.Error("Validate() with zero Duration and multiple keyframes = nil, want an error")
+	}
+}
+
+func TestCameraTimelineWithULinear(t *testing.T) {
+	ct := testCameraTimeline(InterpolationLinear)
+	lookFromX := func(u float64) float64 {
+		cam, ok := ct.WithU(u).(CalibratedCamera)
+		if !ok {
+			t.Fatalf("WithU(%v) = %T, want CalibratedCamera", u, ct.WithU(u))
+		}
+		return cam.Extrinsics.LookFrom.X
+	}
+	if got, want := lookFromX(0), -10.0; got != want {
+		t.Errorf("WithU(0).LookFrom.X = %v, want %v", got, want)
+	}
+	if got, want := lookFromX(0.25), -5.0; got != want {
+		t.Errorf("WithU(0.25).LookFrom.X = %v, want %v", got, want)
+	}
+	if got, want := lookFromX(1), -10.0; got != want {
+		t.Errorf("WithU(1).LookFrom.X = %v, want %v (u wraps into [0,1))", got, want)
+	}
+}
+
+func TestCameraTimelineWithUCatmullRomPassesThroughKeyframes(t *testing.T) {
+	ct := testCameraTimeline(InterpolationCatmullRom)
+	// Skip the last keyframe: its u is exactly 1, which WithU wraps to 0
+	// (the first keyframe), the same cyclic convention AnimatedCamera.Cast
+	// uses for U.
+	for _, k := range ct.Keyframes[:len(ct.Keyframes)-1] {
+		u := float64(k.T) / float64(ct.Duration)
+		cam, ok := ct.WithU(u).(CalibratedCamera)
+		if !ok {
+			t.Fatalf("WithU(%v) = %T, want CalibratedCamera", u, ct.WithU(u))
+		}
+		if got, want := cam.Extrinsics.LookFrom.X, k.LookFrom.X; got != want {
+			t.Errorf("WithU(%v).LookFrom.X = %v, want %v (CatmullRom must pass through every keyframe)", u, got, want)
+		}
+	}
+}
+
+func TestCameraTimelineWithUValidatesAsCalibratedCamera(t *testing.T) {
+	ct := testCameraTimeline(InterpolationEaseInOut)
+	cam, ok := ct.WithU(0.6).(CalibratedCamera)
+	if !ok {
+		t.Fatalf("WithU(0.6) = %T, want CalibratedCamera", ct.WithU(0.6))
+	}
+	if err := cam.Validate(); err != nil {
+		t.Errorf("WithU(0.6).Validate() = %v, want nil", err)
+	}
+}
+
+func TestSlerpUnitEndpointsAndMidpoint(t *testing.T) {
+	a := r3.Vec{X: 1, Y: 0, Z: 0}
+	b := r3.Vec{X: 0, Y: 1, Z: 0}
+	if got := slerpUnit(a, b, 0); !got.IsClose(a, 1e-9) {
+		t.Errorf("slerpUnit(a, b, 0) = %v, want %v", got, a)
+	}
+	if got := slerpUnit(a, b, 1); !got.IsClose(b, 1e-9) {
+		t.Errorf("slerpUnit(a, b, 1) = %v, want %v", got, b)
+	}
+	mid := slerpUnit(a, b, 0.5)
+	want := a.Add(b).Unit() // on the great arc's midpoint, equidistant from a and b
+	if !mid.IsClose(want, 1e-9) {
+		t.Errorf("slerpUnit(a, b, 0.5) = %v, want %v", mid, want)
+	}
+}
@@ -0,0 +1,303 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+)
+
+// MarshalCBOR encodes s as CBOR (RFC 8949). It reuses the same polymorphic
+// Camera/Light/Shape/Material dispatch as MarshalJSON -- the CBOR document
+// has the identical shape as the JSON document, just written with compact
+// binary major types instead of text, so it round-trips through Validate()
+// and floating-point values with the same fidelity as JSON.
+func (s Scene) MarshalCBOR() ([]byte, error) {
+	data, err := s.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	generic, err := jsonToGeneric(data)
+	if err != nil {
+		return nil, fmt.Errorf("phys.MarshalCBOR: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := cborEncodeValue(&buf, generic); err != nil {
+		return nil, fmt.Errorf("phys.MarshalCBOR: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalCBOR implements a CBOR equivalent of UnmarshalJSON.
+func (s *Scene) UnmarshalCBOR(data []byte) error {
+	r := bytes.NewReader(data)
+	generic, err := cborDecodeValue(r)
+	if err != nil {
+		return fmt.Errorf("phys.UnmarshalCBOR: %v", err)
+	}
+	jsonData, err := json.Marshal(generic)
+	if err != nil {
+		return fmt.Errorf("phys.UnmarshalCBOR: %v", err)
+	}
+	return s.UnmarshalJSON(jsonData)
+}
+
+// EncodeScene writes s to w as CBOR. It is a convenience wrapper around
+// MarshalCBOR for pipe/stream use between a scene generator and a
+// renderer worker.
+func EncodeScene(w io.Writer, s *Scene) error {
+	data, err := s.MarshalCBOR()
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// DecodeScene reads a single CBOR-encoded Scene from r.
+func DecodeScene(r io.Reader) (*Scene, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("phys.DecodeScene: %v", err)
+	}
+	scene := &Scene{}
+	if err := scene.UnmarshalCBOR(data); err != nil {
+		return nil, err
+	}
+	return scene, nil
+}
+
+// jsonToGeneric parses JSON into plain Go values (map[string]interface{},
+// []interface{}, float64, string, bool, nil) that cborEncodeValue can walk.
+func jsonToGeneric(data []byte) (interface{}, error) {
+	var v interface{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return normalizeJSONNumbers(v), nil
+}
+
+// normalizeJSONNumbers converts json.Number leaves into float64 so the CBOR
+// writer only has to handle one numeric Go type.
+func normalizeJSONNumbers(v interface{}) interface{} {
+	switch t := v.(type) {
+	case json.Number:
+		f, _ := t.Float64()
+		return f
+	case map[string]interface{}:
+		for k, e := range t {
+			t[k] = normalizeJSONNumbers(e)
+		}
+		return t
+	case []interface{}:
+		for i, e := range t {
+			t[i] = normalizeJSONNumbers(e)
+		}
+		return t
+	default:
+		return v
+	}
+}
+
+// CBOR major types, per RFC 8949 section 3.
+const (
+	cborMajorUint    = 0
+	cborMajorNegInt  = 1
+	cborMajorBytes   = 2
+	cborMajorText    = 3
+	cborMajorArray   = 4
+	cborMajorMap     = 5
+	cborMajorTagged  = 6
+	cborMajorSimple  = 7
+	cborFloat64Minor = 27
+	cborTrueByte     = 0xf5
+	cborFalseByte    = 0xf4
+	cborNullByte     = 0xf6
+)
+
+// cborWriteHead writes a CBOR initial byte (major type + argument) using
+// the shortest encoding that fits n.
+func cborWriteHead(w *bytes.Buffer, major byte, n uint64) {
+	switch {
+	case n < 24:
+		w.WriteByte(major<<5 | byte(n))
+	case n <= 0xff:
+		w.WriteByte(major<<5 | 24)
+		w.WriteByte(byte(n))
+	case n <= 0xffff:
+		w.WriteByte(major<<5 | 25)
+		binary.Write(w, binary.BigEndian, uint16(n))
+	case n <= 0xffffffff:
+		w.WriteByte(major<<5 | 26)
+		binary.Write(w, binary.BigEndian, uint32(n))
+	default:
+		w.WriteByte(major<<5 | 27)
+		binary.Write(w, binary.BigEndian, n)
+	}
+}
+
+// cborEncodeValue writes v (as produced by jsonToGeneric) to buf as CBOR.
+func cborEncodeValue(buf *bytes.Buffer, v interface{}) error {
+	switch t := v.(type) {
+	case nil:
+		buf.WriteByte(cborNullByte)
+	case bool:
+		if t {
+			buf.WriteByte(cborTrueByte)
+		} else {
+			buf.WriteByte(cborFalseByte)
+		}
+	case float64:
+		buf.WriteByte(cborMajorSimple<<5 | cborFloat64Minor)
+		binary.Write(buf, binary.BigEndian, math.Float64bits(t))
+	case string:
+		cborWriteHead(buf, cborMajorText, uint64(len(t)))
+		buf.WriteString(t)
+	case []interface{}:
+		cborWriteHead(buf, cborMajorArray, uint64(len(t)))
+		for _, e := range t {
+			if err := cborEncodeValue(buf, e); err != nil {
+				return err
+			}
+		}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(t))
+		for k := range t {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys) // Deterministic key order makes output diffable.
+		cborWriteHead(buf, cborMajorMap, uint64(len(keys)))
+		for _, k := range keys {
+			cborWriteHead(buf, cborMajorText, uint64(len(k)))
+			buf.WriteString(k)
+			if err := cborEncodeValue(buf, t[k]); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("cbor: unsupported value type %T", v)
+	}
+	return nil
+}
+
+// cborDecodeValue reads one CBOR item from r into a plain Go value
+// compatible with encoding/json's default decoding (map[string]interface{},
+// []interface{}, float64, string, bool, nil).
+func cborDecodeValue(r *bytes.Reader) (interface{}, error) {
+	head, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	major := head >> 5
+	minor := head & 0x1f
+
+	readArg := func() (uint64, error) {
+		switch {
+		case minor < 24:
+			return uint64(minor), nil
+		case minor == 24:
+			b, err := r.ReadByte()
+			return uint64(b), err
+		case minor == 25:
+			var v uint16
+			err := binary.Read(r, binary.BigEndian, &v)
+			return uint64(v), err
+		case minor == 26:
+			var v uint32
+			err := binary.Read(r, binary.BigEndian, &v)
+			return uint64(v), err
+		default:
+			var v uint64
+			err := binary.Read(r, binary.BigEndian, &v)
+			return v, err
+		}
+	}
+
+	switch major {
+	case cborMajorUint:
+		n, err := readArg()
+		return float64(n), err
+	case cborMajorNegInt:
+		n, err := readArg()
+		return float64(-1 - int64(n)), err
+	case cborMajorText:
+		n, err := readArg()
+		if err != nil {
+			return nil, err
+		}
+		b := make([]byte, n)
+		if _, err := io.ReadFull(r, b); err != nil {
+			return nil, err
+		}
+		return string(b), nil
+	case cborMajorBytes:
+		n, err := readArg()
+		if err != nil {
+			return nil, err
+		}
+		b := make([]byte, n)
+		_, err = io.ReadFull(r, b)
+		return b, err
+	case cborMajorArray:
+		n, err := readArg()
+		if err != nil {
+			return nil, err
+		}
+		out := make([]interface{}, 0, n)
+		for i := uint64(0); i < n; i++ {
+			e, err := cborDecodeValue(r)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, e)
+		}
+		return out, nil
+	case cborMajorMap:
+		n, err := readArg()
+		if err != nil {
+			return nil, err
+		}
+		out := make(map[string]interface{}, n)
+		for i := uint64(0); i < n; i++ {
+			kv, err := cborDecodeValue(r)
+			if err != nil {
+				return nil, err
+			}
+			k, ok := kv.(string)
+			if !ok {
+				return nil, fmt.Errorf("cbor: map key must be a text string, got %T", kv)
+			}
+			v, err := cborDecodeValue(r)
+			if err != nil {
+				return nil, err
+			}
+			out[k] = v
+		}
+		return out, nil
+	case cborMajorSimple:
+		switch head {
+		case cborTrueByte:
+			return true, nil
+		case cborFalseByte:
+			return false, nil
+		case cborNullByte:
+			return nil, nil
+		}
+		if minor == cborFloat64Minor {
+			var bits uint64
+			if err := binary.Read(r, binary.BigEndian, &bits); err != nil {
+				return nil, err
+			}
+			return math.Float64frombits(bits), nil
+		}
+		return nil, fmt.Errorf("cbor: unsupported simple value 0x%x", head)
+	default:
+		return nil, fmt.Errorf("cbor: unsupported major type %d", major)
+	}
+}
@@ -0,0 +1,45 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
+)
+
+func TestSceneCBORRoundTrip(t *testing.T) {
+	scene := &Scene{
+		Camera: []Camera{PinholeCamera{Horizontal: r3.Vec{X: 1}, Vertical: r3.Vec{Y: 1}}},
+		Node: []Node{
+			{Name: "a", Shape: Sphere{Radius: 1.5}, Material: Lambertian{Texture: TextureUniform{Color: Spectrum{X: 0.25, Y: 0.5, Z: 0.75}}}},
+		},
+		Light:         []Light{PointLight{Position: r3.Point{X: 1, Y: 2, Z: 3}, RadiantIntensity: r3.Vec{X: 1, Y: 1, Z: 1}}},
+		RenderOptions: RenderOptions{Seed: 7, RaysPerPixel: 4, MaxRayDepth: 8, Dx: 16, Dy: 16},
+	}
+
+	var buf bytes.Buffer
+	if err := EncodeScene(&buf, scene); err != nil {
+		t.Fatalf("EncodeScene: %v", err)
+	}
+	got, err := DecodeScene(&buf)
+	if err != nil {
+		t.Fatalf("DecodeScene: %v", err)
+	}
+	if err := got.Validate(); err != nil {
+		t.Fatalf("decoded scene failed Validate: %v", err)
+	}
+	sphere, ok := got.Node[0].Shape.(*Sphere)
+	if !ok {
+		t.Fatalf("expected *Sphere, got %T", got.Node[0].Shape)
+	}
+	if sphere.Radius != 1.5 {
+		t.Fatalf("expected Radius 1.5, got %v", sphere.Radius)
+	}
+	// RenderOptions.OnPass is a func field, so reflect.DeepEqual replaces
+	// the struct equality operator here.
+	if !reflect.DeepEqual(got.RenderOptions, scene.RenderOptions) {
+		t.Fatalf("RenderOptions mismatch: got %+v want %+v", got.RenderOptions, scene.RenderOptions)
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import "math"
+
+// cieXYZ returns the CIE 1931 2-degree standard observer color-matching
+// functions (xBar, yBar, zBar) at wavelength lambda (in nanometers).
+//
+// Rather than carrying a large tabulated dataset, this uses the
+// closed-form multi-Gaussian fit from Wyman, Sloan, and Shirley, "Simple
+// Analytic Approximations to the CIE XYZ Color Matching Functions"
+// (JCGT 2013), which reproduces the standard tables to within plotting
+// accuracy and is cheap enough to evaluate per spectral sample.
+func cieXYZ(lambda float64) (x, y, z float64) {
+	x = 1.056*gaussianAsymmetric(lambda, 599.8, 37.9, 31.0) +
+		0.362*gaussianAsymmetric(lambda, 442.0, 16.0, 26.7) -
+		0.065*gaussianAsymmetric(lambda, 501.1, 20.4, 26.2)
+	y = 0.821*gaussianAsymmetric(lambda, 568.8, 46.9, 40.5) +
+		0.286*gaussianAsymmetric(lambda, 530.9, 16.3, 31.1)
+	z = 1.217*gaussianAsymmetric(lambda, 437.0, 11.8, 36.0) +
+		0.681*gaussianAsymmetric(lambda, 459.0, 26.0, 13.8)
+	return x, y, z
+}
+
+// gaussianAsymmetric evaluates a Gaussian centered at mu with a different
+// standard deviation on either side of the peak (sigma1 below mu, sigma2
+// above), as used by the Wyman et al. CIE CMF fit.
+func gaussianAsymmetric(x, mu, sigma1, sigma2 float64) float64 {
+	sigma := sigma1
+	if x > mu {
+		sigma = sigma2
+	}
+	t := (x - mu) / sigma
+	return math.Exp(-0.5 * t * t)
+}
+
+// cieYIntegral is the integral of yBar(lambda) over the visible range,
+// i.e. the normalization constant that makes a uniformly-sampled
+// single-wavelength estimator converge to the correct luminance. It is
+// computed once at package init via simple numerical quadrature rather
+// than hardcoded, so it always matches cieXYZ above.
+var cieYIntegral = integrateCIEY()
+
+func integrateCIEY() float64 {
+	const step = 1.0
+	sum := 0.0
+	for lambda := wavelengthMin; lambda < wavelengthMax; lambda += step {
+		_, y, _ := cieXYZ(lambda)
+		sum += y * step
+	}
+	return sum
+}
+
+// xyzToSRGB converts a CIE XYZ tristimulus value (D65 white point) to
+// linear sRGB using the standard IEC 61966-2-1 matrix. The result is not
+// gamma-encoded, matching how the rest of phys treats Spectrum as a
+// linear color carried through to Spectrum.ToColor.
+func xyzToSRGB(x, y, z float64) Spectrum {
+	return Spectrum{
+		X: 3.2406*x - 1.5372*y - 0.4986*z,
+		Y: -0.9689*x + 1.8758*y + 0.0415*z,
+		Z: 0.0557*x - 0.2040*y + 1.0570*z,
+	}
+}
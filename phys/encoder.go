@@ -0,0 +1,335 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"io"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Encoder writes a sequence of same-sized frames out as an animation.
+// WriteFrame must be called with frames in playback order; Close
+// finalizes and releases the underlying file (and, for backends that
+// shell out, waits for the external process to exit).
+type Encoder interface {
+	WriteFrame(img *image.RGBA) error
+	Close() error
+}
+
+// NewEncoder returns the Encoder that writes to path, dispatching on its
+// extension: ".png" writes an APNG (a PNG whose first frame is also a
+// valid still image, decodable by any standard PNG reader), ".mp4" shells
+// out to an ffmpeg binary on PATH, and ".gif" writes a paletted,
+// Floyd-Steinberg dithered GIF. fps sets the playback rate every backend
+// encodes into the file. It's a convenience over NewEncoderWithOptions
+// for callers that don't need MP4's CRF/Bitrate controls.
+func NewEncoder(path string, fps int) (Encoder, error) {
+	return NewEncoderWithOptions(path, EncoderOptions{FPS: fps})
+}
+
+// EncoderOptions configures NewEncoderWithOptions.
+type EncoderOptions struct {
+	// FPS is the playback rate encoded into the file, read by every
+	// backend.
+	FPS int
+
+	// CRF sets libx264's Constant Rate Factor for MP4 output: lower
+	// values are higher quality and larger files, on ffmpeg's usual 0-51
+	// scale. Zero leaves ffmpeg's own default in place. Ignored by the
+	// APNG and GIF backends, and by MP4 when Bitrate is also set.
+	CRF int
+
+	// Bitrate sets an explicit target bitrate for MP4 output (ffmpeg
+	// -b:v syntax, e.g. "4M"), overriding CRF when non-empty. Ignored by
+	// the APNG and GIF backends.
+	Bitrate string
+}
+
+// NewEncoderWithOptions is NewEncoder with MP4-specific quality controls;
+// see EncoderOptions.
+func NewEncoderWithOptions(path string, opts EncoderOptions) (Encoder, error) {
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".png":
+		f, err := os.Create(path)
+		if err != nil {
+			return nil, fmt.Errorf("phys.NewEncoder: %w", err)
+		}
+		return newAPNGEncoder(f, opts.FPS), nil
+	case ".gif":
+		f, err := os.Create(path)
+		if err != nil {
+			return nil, fmt.Errorf("phys.NewEncoder: %w", err)
+		}
+		return newGIFEncoder(f, opts.FPS), nil
+	case ".mp4":
+		return newMP4Encoder(path, opts)
+	case ".webp":
+		return nil, fmt.Errorf("phys.NewEncoder: %s is an animated WebP export, but this module does not vendor a WebP encoder; use .png (APNG), .gif, or .mp4 instead", path)
+	default:
+		return nil, fmt.Errorf("phys.NewEncoder: unrecognized export extension %q (want .png, .gif, or .mp4)", ext)
+	}
+}
+
+// apngEncoder implements Encoder as an Animated PNG: a regular PNG
+// (signature, IHDR, IDAT, IEND) with an acTL/fcTL/fdAT chunk per extra
+// frame interleaved in, per the APNG extension to the PNG spec. Frames
+// are buffered (as already-deflated scanline data) until Close, since
+// the acTL chunk's frame count must be written before any frame data.
+type apngEncoder struct {
+	w             *os.File
+	fps           int
+	width, height int
+	started       bool
+	frames        [][]byte
+}
+
+func newAPNGEncoder(w *os.File, fps int) *apngEncoder {
+	return &apngEncoder{w: w, fps: fps}
+}
+
+func (e *apngEncoder) WriteFrame(img *image.RGBA) error {
+	b := img.Bounds()
+	if !e.started {
+		e.width, e.height = b.Dx(), b.Dy()
+		e.started = true
+	} else if b.Dx() != e.width || b.Dy() != e.height {
+		return fmt.Errorf("phys: apng encoder: frame size %dx%d does not match first frame %dx%d", b.Dx(), b.Dy(), e.width, e.height)
+	}
+	data, err := deflateRGBA(img)
+	if err != nil {
+		return fmt.Errorf("phys: apng encoder: %w", err)
+	}
+	e.frames = append(e.frames, data)
+	return nil
+}
+
+func (e *apngEncoder) Close() error {
+	defer e.w.Close()
+	if len(e.frames) == 0 {
+		return fmt.Errorf("phys: apng encoder: Close called with no frames written")
+	}
+	var buf bytes.Buffer
+	buf.Write(pngSignature)
+	writePNGChunk(&buf, "IHDR", ihdrData(e.width, e.height))
+	writePNGChunk(&buf, "acTL", acTLData(len(e.frames)))
+
+	var seq uint32
+	for i, frame := range e.frames {
+		writePNGChunk(&buf, "fcTL", fcTLData(seq, e.width, e.height, e.fps))
+		seq++
+		if i == 0 {
+			writePNGChunk(&buf, "IDAT", frame)
+		} else {
+			writePNGChunk(&buf, "fdAT", fdATData(seq, frame))
+			seq++
+		}
+	}
+	writePNGChunk(&buf, "IEND", nil)
+	_, err := e.w.Write(buf.Bytes())
+	return err
+}
+
+var pngSignature = []byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1a, '\n'}
+
+// writePNGChunk appends a length-prefixed, CRC-suffixed PNG chunk to buf.
+func writePNGChunk(buf *bytes.Buffer, typ string, data []byte) {
+	var length [4]byte
+	binary.BigEndian.PutUint32(length[:], uint32(len(data)))
+	buf.Write(length[:])
+	crc := crc32.NewIEEE()
+	crc.Write([]byte(typ))
+	crc.Write(data)
+	buf.WriteString(typ)
+	buf.Write(data)
+	var sum [4]byte
+	binary.BigEndian.PutUint32(sum[:], crc.Sum32())
+	buf.Write(sum[:])
+}
+
+func ihdrData(width, height int) []byte {
+	d := make([]byte, 13)
+	binary.BigEndian.PutUint32(d[0:4], uint32(width))
+	binary.BigEndian.PutUint32(d[4:8], uint32(height))
+	d[8] = 8  // bit depth
+	d[9] = 6  // color type: truecolor with alpha
+	d[10] = 0 // compression
+	d[11] = 0 // filter
+	d[12] = 0 // interlace
+	return d
+}
+
+func acTLData(numFrames int) []byte {
+	d := make([]byte, 8)
+	binary.BigEndian.PutUint32(d[0:4], uint32(numFrames))
+	binary.BigEndian.PutUint32(d[4:8], 0) // num_plays: 0 = loop forever
+	return d
+}
+
+func fcTLData(seq uint32, width, height, fps int) []byte {
+	d := make([]byte, 26)
+	binary.BigEndian.PutUint32(d[0:4], seq)
+	binary.BigEndian.PutUint32(d[4:8], uint32(width))
+	binary.BigEndian.PutUint32(d[8:12], uint32(height))
+	binary.BigEndian.PutUint32(d[12:16], 0)           // x_offset
+	binary.BigEndian.PutUint32(d[16:20], 0)           // y_offset
+	binary.BigEndian.PutUint16(d[20:22], 1)           // delay_num
+	binary.BigEndian.PutUint16(d[22:24], uint16(fps)) // delay_den
+	d[24] = 0                                         // dispose_op: none
+	d[25] = 0                                         // blend_op: source
+	return d
+}
+
+func fdATData(seq uint32, data []byte) []byte {
+	d := make([]byte, 4+len(data))
+	binary.BigEndian.PutUint32(d[0:4], seq)
+	copy(d[4:], data)
+	return d
+}
+
+// deflateRGBA zlib-deflates img's scanlines, each prefixed with a
+// filter-type byte of 0 (no filtering), the format IDAT/fdAT chunk data
+// for an 8-bit RGBA PNG/APNG expects.
+func deflateRGBA(img *image.RGBA) ([]byte, error) {
+	b := img.Bounds()
+	width, height := b.Dx(), b.Dy()
+	var raw bytes.Buffer
+	row := make([]byte, 1+width*4)
+	for y := 0; y < height; y++ {
+		row[0] = 0
+		copy(row[1:], img.Pix[y*img.Stride:y*img.Stride+width*4])
+		raw.Write(row)
+	}
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	if _, err := zw.Write(raw.Bytes()); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return compressed.Bytes(), nil
+}
+
+// gifEncoder implements Encoder as a paletted GIF: frames are quantized
+// to palette.WebSafe with Floyd-Steinberg dithering and buffered until
+// Close, since gif.EncodeAll needs every frame (and its per-frame delay)
+// up front rather than a streaming write.
+type gifEncoder struct {
+	w             *os.File
+	fps           int
+	width, height int
+	started       bool
+	frames        []*image.Paletted
+	delays        []int
+}
+
+func newGIFEncoder(w *os.File, fps int) *gifEncoder {
+	return &gifEncoder{w: w, fps: fps}
+}
+
+func (e *gifEncoder) WriteFrame(img *image.RGBA) error {
+	b := img.Bounds()
+	if !e.started {
+		e.width, e.height = b.Dx(), b.Dy()
+		e.started = true
+	} else if b.Dx() != e.width || b.Dy() != e.height {
+		return fmt.Errorf("phys: gif encoder: frame size %dx%d does not match first frame %dx%d", b.Dx(), b.Dy(), e.width, e.height)
+	}
+	dst := image.NewPaletted(b, palette.WebSafe)
+	draw.FloydSteinberg.Draw(dst, b, img, image.Point{})
+	e.frames = append(e.frames, dst)
+	e.delays = append(e.delays, int(math.Round(100.0/float64(e.fps)))) // GIF delay units are 1/100s.
+	return nil
+}
+
+func (e *gifEncoder) Close() error {
+	defer e.w.Close()
+	if len(e.frames) == 0 {
+		return fmt.Errorf("phys: gif encoder: Close called with no frames written")
+	}
+	anim := &gif.GIF{Image: e.frames, Delay: e.delays, LoopCount: 0}
+	return gif.EncodeAll(e.w, anim)
+}
+
+// mp4Encoder implements Encoder by piping raw RGBA frames into an
+// ffmpeg subprocess, which re-encodes them as H.264/MP4. This module
+// vendors no video codec, so ffmpeg must be installed and on PATH.
+type mp4Encoder struct {
+	path          string
+	fps           int
+	crf           int
+	bitrate       string
+	cmd           *exec.Cmd
+	stdin         io.WriteCloser
+	width, height int
+	started       bool
+}
+
+func newMP4Encoder(path string, opts EncoderOptions) (*mp4Encoder, error) {
+	if _, err := exec.LookPath("ffmpeg"); err != nil {
+		return nil, fmt.Errorf("phys.NewEncoder: %s requires ffmpeg on PATH, but it was not found: %v", path, err)
+	}
+	return &mp4Encoder{path: path, fps: opts.FPS, crf: opts.CRF, bitrate: opts.Bitrate}, nil
+}
+
+func (e *mp4Encoder) WriteFrame(img *image.RGBA) error {
+	b := img.Bounds()
+	if !e.started {
+		e.width, e.height = b.Dx(), b.Dy()
+		args := []string{
+			"-y",
+			"-f", "rawvideo",
+			"-pix_fmt", "rgba",
+			"-s", strconv.Itoa(e.width) + "x" + strconv.Itoa(e.height),
+			"-r", strconv.Itoa(e.fps),
+			"-i", "-",
+			"-pix_fmt", "yuv420p",
+		}
+		switch {
+		case e.bitrate != "":
+			args = append(args, "-b:v", e.bitrate)
+		case e.crf > 0:
+			args = append(args, "-crf", strconv.Itoa(e.crf))
+		}
+		args = append(args, e.path)
+		cmd := exec.Command("ffmpeg", args...)
+		stdin, err := cmd.StdinPipe()
+		if err != nil {
+			return fmt.Errorf("phys: mp4 encoder: %w", err)
+		}
+		if err := cmd.Start(); err != nil {
+			return fmt.Errorf("phys: mp4 encoder: starting ffmpeg: %w", err)
+		}
+		e.cmd, e.stdin, e.started = cmd, stdin, true
+	} else if b.Dx() != e.width || b.Dy() != e.height {
+		return fmt.Errorf("phys: mp4 encoder: frame size %dx%d does not match first frame %dx%d", b.Dx(), b.Dy(), e.width, e.height)
+	}
+	if img.Stride != e.width*4 || b.Min.X != 0 || b.Min.Y != 0 {
+		return fmt.Errorf("phys: mp4 encoder: frame is not a tightly packed, origin-aligned RGBA image")
+	}
+	_, err := e.stdin.Write(img.Pix)
+	return err
+}
+
+func (e *mp4Encoder) Close() error {
+	if e.stdin != nil {
+		e.stdin.Close()
+	}
+	if e.cmd != nil {
+		return e.cmd.Wait()
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func solidRGBA(w, h int, c color.RGBA) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.SetRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+func TestAPNGEncoderDecodesAsPNG(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.png")
+	enc, err := NewEncoder(path, 30)
+	if err != nil {
+		t.Fatalf("NewEncoder: %v", err)
+	}
+	first := solidRGBA(4, 3, color.RGBA{R: 10, G: 20, B: 30, A: 255})
+	if err := enc.WriteFrame(first); err != nil {
+		t.Fatalf("WriteFrame(1): %v", err)
+	}
+	if err := enc.WriteFrame(solidRGBA(4, 3, color.RGBA{R: 200, G: 0, B: 0, A: 255})); err != nil {
+		t.Fatalf("WriteFrame(2): %v", err)
+	}
+	if err := enc.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	// A standard PNG decoder must still read an APNG's default image
+	// (its IHDR/IDAT) as a regular still frame.
+	decoded, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("png.Decode: %v", err)
+	}
+	if got := decoded.Bounds(); got.Dx() != 4 || got.Dy() != 3 {
+		t.Errorf("decoded bounds = %v, want 4x3", got)
+	}
+	r, g, b, _ := decoded.At(0, 0).RGBA()
+	if r>>8 != 10 || g>>8 != 20 || b>>8 != 30 {
+		t.Errorf("decoded pixel (0,0) = (%d,%d,%d), want (10,20,30)", r>>8, g>>8, b>>8)
+	}
+}
+
+func TestAPNGEncoderRejectsMismatchedFrameSize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.png")
+	enc, err := NewEncoder(path, 30)
+	if err != nil {
+		t.Fatalf("NewEncoder: %v", err)
+	}
+	defer enc.Close()
+	if err := enc.WriteFrame(solidRGBA(4, 3, color.RGBA{A: 255})); err != nil {
+		t.Fatalf("WriteFrame(1): %v", err)
+	}
+	if err := enc.WriteFrame(solidRGBA(5, 3, color.RGBA{A: 255})); err == nil {
+		t.Error("WriteFrame with a mismatched size returned nil error, want an error")
+	}
+}
+
+func TestAPNGEncoderRejectsEmptyClose(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.png")
+	enc, err := NewEncoder(path, 30)
+	if err != nil {
+		t.Fatalf("NewEncoder: %v", err)
+	}
+	if err := enc.Close(); err == nil {
+		t.Error("Close with no frames written returned nil error, want an error")
+	}
+}
+
+func TestNewEncoderRejectsWebP(t *testing.T) {
+	if _, err := NewEncoder("out.webp", 30); err == nil {
+		t.Error("NewEncoder(\"out.webp\", ...) returned nil error, want an error (WebP is not supported)")
+	}
+}
+
+func TestNewEncoderRejectsUnknownExtension(t *testing.T) {
+	if _, err := NewEncoder("out.avi", 30); err == nil {
+		t.Error("NewEncoder(\"out.avi\", ...) returned nil error, want an error")
+	}
+}
+
+func TestGIFEncoderDecodesAsGIF(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.gif")
+	enc, err := NewEncoder(path, 30)
+	if err != nil {
+		t.Fatalf("NewEncoder: %v", err)
+	}
+	first := solidRGBA(4, 3, color.RGBA{R: 10, G: 20, B: 30, A: 255})
+	if err := enc.WriteFrame(first); err != nil {
+		t.Fatalf("WriteFrame(1): %v", err)
+	}
+	if err := enc.WriteFrame(solidRGBA(4, 3, color.RGBA{R: 200, G: 0, B: 0, A: 255})); err != nil {
+		t.Fatalf("WriteFrame(2): %v", err)
+	}
+	if err := enc.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	decoded, err := gif.DecodeAll(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("gif.DecodeAll: %v", err)
+	}
+	if len(decoded.Image) != 2 {
+		t.Errorf("len(decoded.Image) = %d, want 2", len(decoded.Image))
+	}
+	if got := decoded.Image[0].Bounds(); got.Dx() != 4 || got.Dy() != 3 {
+		t.Errorf("decoded bounds = %v, want 4x3", got)
+	}
+}
+
+func TestGIFEncoderRejectsMismatchedFrameSize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.gif")
+	enc, err := NewEncoder(path, 30)
+	if err != nil {
+		t.Fatalf("NewEncoder: %v", err)
+	}
+	defer enc.Close()
+	if err := enc.WriteFrame(solidRGBA(4, 3, color.RGBA{A: 255})); err != nil {
+		t.Fatalf("WriteFrame(1): %v", err)
+	}
+	if err := enc.WriteFrame(solidRGBA(5, 3, color.RGBA{A: 255})); err == nil {
+		t.Error("WriteFrame with a mismatched size returned nil error, want an error")
+	}
+}
+
+func TestGIFEncoderRejectsEmptyClose(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.gif")
+	enc, err := NewEncoder(path, 30)
+	if err != nil {
+		t.Fatalf("NewEncoder: %v", err)
+	}
+	if err := enc.Close(); err == nil {
+		t.Error("Close with no frames written returned nil error, want an error")
+	}
+}
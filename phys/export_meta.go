@@ -0,0 +1,192 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+
+package phys
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/png"
+	"os"
+)
+
+// pngMetaKeyword is the iTXt keyword SavePNGWithMeta writes its provenance
+// JSON under and ReadPNGMeta looks for. Namespaced with "raytrace:" so it
+// doesn't collide with keywords other tools (image editors, EXIF copiers)
+// might add to the same file.
+const pngMetaKeyword = "raytrace:provenance"
+
+// renderProvenance is the JSON payload SavePNGWithMeta embeds and
+// ReadPNGMeta recovers: the scene that produced the image and the stats
+// from the render that did it, together enough to re-render the same
+// frame or diff it against a later run.
+type renderProvenance struct {
+	Scene *Scene
+	Stats RenderStats
+}
+
+// pngChunk builds a complete, CRC-terminated PNG chunk (length + type +
+// data + CRC), the unit insertPNGChunkBeforeIEND splices into an encoded
+// PNG byte stream. PNG's CRC-32 (spec section 5.3) is the same IEEE
+// polynomial hash/crc32.ChecksumIEEE computes, taken over the type and
+// data fields only (not the length).
+func pngChunk(chunkType string, data []byte) []byte {
+	buf := make([]byte, 0, 12+len(data))
+	var lengthField [4]byte
+	binary.BigEndian.PutUint32(lengthField[:], uint32(len(data)))
+	buf = append(buf, lengthField[:]...)
+	buf = append(buf, chunkType...)
+	buf = append(buf, data...)
+	crc := crc32.ChecksumIEEE(buf[4:])
+	var crcField [4]byte
+	binary.BigEndian.PutUint32(crcField[:], crc)
+	return append(buf, crcField[:]...)
+}
+
+// iTXtData builds an uncompressed iTXt chunk's data field (PNG spec
+// section 11.3.4.4): keyword, a null-compression/null-method pair, an
+// empty language tag and translated keyword (this metadata has no
+// meaningful translation), then text verbatim as UTF-8 -- iTXt, unlike
+// tEXt, allows UTF-8 rather than being restricted to Latin-1, which JSON
+// containing arbitrary Scene names or texture paths isn't guaranteed to be.
+func iTXtData(keyword, text string) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(keyword)
+	buf.WriteByte(0) // Null separator after keyword.
+	buf.WriteByte(0) // Compression flag: 0 = uncompressed.
+	buf.WriteByte(0) // Compression method: unused when flag is 0.
+	buf.WriteByte(0) // Empty language tag, null-terminated.
+	buf.WriteByte(0) // Empty translated keyword, null-terminated.
+	buf.WriteString(text)
+	return buf.Bytes()
+}
+
+// insertPNGChunkBeforeIEND walks png's chunks looking for IEND (always the
+// last chunk in a well-formed PNG) and splices chunk in immediately before
+// it, the position the PNG spec allows any ancillary chunk to occupy.
+func insertPNGChunkBeforeIEND(png, chunk []byte) ([]byte, error) {
+	if len(png) < 8 || !bytes.Equal(png[:8], pngSignature) {
+		return nil, fmt.Errorf("insertPNGChunkBeforeIEND: not a PNG file")
+	}
+	offset := 8
+	for offset+8 <= len(png) {
+		length := binary.BigEndian.Uint32(png[offset : offset+4])
+		chunkType := string(png[offset+4 : offset+8])
+		chunkEnd := offset + 8 + int(length) + 4
+		if chunkEnd > len(png) {
+			return nil, fmt.Errorf("insertPNGChunkBeforeIEND: truncated %q chunk", chunkType)
+		}
+		if chunkType == "IEND" {
+			out := make([]byte, 0, len(png)+len(chunk))
+			out = append(out, png[:offset]...)
+			out = append(out, chunk...)
+			out = append(out, png[offset:]...)
+			return out, nil
+		}
+		offset = chunkEnd
+	}
+	return nil, fmt.Errorf("insertPNGChunkBeforeIEND: no IEND chunk found")
+}
+
+// findITXtByKeyword scans png's chunks for an iTXt chunk whose keyword
+// matches keyword and returns its text field.
+func findITXtByKeyword(png []byte, keyword string) (text string, found bool, err error) {
+	if len(png) < 8 || !bytes.Equal(png[:8], pngSignature) {
+		return "", false, fmt.Errorf("findITXtByKeyword: not a PNG file")
+	}
+	offset := 8
+	for offset+8 <= len(png) {
+		length := binary.BigEndian.Uint32(png[offset : offset+4])
+		chunkType := string(png[offset+4 : offset+8])
+		dataStart := offset + 8
+		dataEnd := dataStart + int(length)
+		if dataEnd+4 > len(png) {
+			return "", false, fmt.Errorf("findITXtByKeyword: truncated %q chunk", chunkType)
+		}
+		if chunkType == "iTXt" {
+			data := png[dataStart:dataEnd]
+			nul := bytes.IndexByte(data, 0)
+			if nul < 0 {
+				return "", false, fmt.Errorf("findITXtByKeyword: malformed iTXt chunk (no keyword terminator)")
+			}
+			if string(data[:nul]) == keyword {
+				// Skip keyword\0, compression flag, compression method,
+				// then the null-terminated language tag and translated
+				// keyword, to reach the text field.
+				rest := data[nul+1:]
+				if len(rest) < 2 {
+					return "", false, fmt.Errorf("findITXtByKeyword: malformed iTXt chunk (truncated flags)")
+				}
+				rest = rest[2:]
+				for i := 0; i < 2; i++ {
+					nul := bytes.IndexByte(rest, 0)
+					if nul < 0 {
+						return "", false, fmt.Errorf("findITXtByKeyword: malformed iTXt chunk (missing null terminator)")
+					}
+					rest = rest[nul+1:]
+				}
+				return string(rest), true, nil
+			}
+		}
+		if chunkType == "IEND" {
+			break
+		}
+		offset = dataEnd + 4
+	}
+	return "", false, nil
+}
+
+// SavePNGWithMeta writes img to path as a PNG, the same as SavePNG, with
+// one addition: an iTXt ancillary chunk embedding scene and stats as JSON
+// (see renderProvenance), so the frame is self-describing -- ReadPNGMeta
+// recovers both without an external manifest. Any PNG-aware viewer that
+// ignores unrecognized ancillary chunks (the PNG spec requires this)
+// displays the image exactly as SavePNG would have produced it.
+func SavePNGWithMeta(path string, img image.Image, scene *Scene, stats RenderStats) error {
+	var buf bytes.Buffer
+	if err := (&png.Encoder{CompressionLevel: png.NoCompression}).Encode(&buf, img); err != nil {
+		return fmt.Errorf("SavePNGWithMeta: %w", err)
+	}
+	text, err := json.Marshal(renderProvenance{Scene: scene, Stats: stats})
+	if err != nil {
+		return fmt.Errorf("SavePNGWithMeta: %w", err)
+	}
+	out, err := insertPNGChunkBeforeIEND(buf.Bytes(), pngChunk("iTXt", iTXtData(pngMetaKeyword, string(text))))
+	if err != nil {
+		return fmt.Errorf("SavePNGWithMeta: %w", err)
+	}
+	if err := os.WriteFile(path, out, 0644); err != nil {
+		return fmt.Errorf("SavePNGWithMeta: %w", err)
+	}
+	return nil
+}
+
+// ReadPNGMeta recovers the Scene and RenderStats SavePNGWithMeta embedded
+// in the PNG at path, so a saved frame can be re-rendered with
+// phys.Render or diffed against a later run without a separate sidecar
+// file. It returns an error if path has no raytrace:provenance chunk --
+// in particular, a PNG written by plain SavePNG.
+func ReadPNGMeta(path string) (Scene, RenderStats, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Scene{}, RenderStats{}, fmt.Errorf("ReadPNGMeta: %w", err)
+	}
+	text, found, err := findITXtByKeyword(data, pngMetaKeyword)
+	if err != nil {
+		return Scene{}, RenderStats{}, fmt.Errorf("ReadPNGMeta: %w", err)
+	}
+	if !found {
+		return Scene{}, RenderStats{}, fmt.Errorf("ReadPNGMeta: %s has no %q metadata chunk", path, pngMetaKeyword)
+	}
+	var provenance renderProvenance
+	if err := json.Unmarshal([]byte(text), &provenance); err != nil {
+		return Scene{}, RenderStats{}, fmt.Errorf("ReadPNGMeta: %w", err)
+	}
+	if provenance.Scene == nil {
+		return Scene{}, provenance.Stats, nil
+	}
+	return *provenance.Scene, provenance.Stats, nil
+}
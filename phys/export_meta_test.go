@@ -0,0 +1,82 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import (
+	"image"
+	"path/filepath"
+	"testing"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
+)
+
+// TestSavePNGWithMetaRoundTrip verifies ReadPNGMeta recovers the same
+// Scene and RenderStats SavePNGWithMeta embedded, and that the image
+// pixels themselves survive untouched by the chunk splice.
+func TestSavePNGWithMetaRoundTrip(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	img.Set(1, 2, image.White)
+
+	scene := &Scene{
+		Camera: []Camera{OrthographicCamera{
+			LookFrom:  r3.Point{X: 1, Y: 2, Z: 3},
+			LookAt:    r3.Point{X: 0, Y: 0, Z: 0},
+			VUp:       r3.Vec{X: 0, Y: 1, Z: 0},
+			FOVHeight: 10,
+			FOVWidth:  10,
+		}},
+	}
+	stats := RenderStats{TotalRays: 42, Dx: 4, Dy: 4}
+
+	path := filepath.Join(t.TempDir(), "frame.png")
+	if err := SavePNGWithMeta(path, img, scene, stats); err != nil {
+		t.Fatalf("SavePNGWithMeta() = %v, want nil", err)
+	}
+
+	gotScene, gotStats, err := ReadPNGMeta(path)
+	if err != nil {
+		t.Fatalf("ReadPNGMeta() = %v, want nil", err)
+	}
+	if gotStats.TotalRays != stats.TotalRays || gotStats.Dx != stats.Dx || gotStats.Dy != stats.Dy {
+		t.Errorf("ReadPNGMeta() stats = %+v, want %+v", gotStats, stats)
+	}
+	if len(gotScene.Camera) != 1 {
+		t.Fatalf("ReadPNGMeta() scene.Camera has %d entries, want 1", len(gotScene.Camera))
+	}
+	// unmarshalInterface always returns a pointer (see phys/json.go), even
+	// though the camera was registered and stored as a value.
+	gotCam, ok := gotScene.Camera[0].(*OrthographicCamera)
+	if !ok {
+		t.Fatalf("ReadPNGMeta() scene.Camera[0] = %T, want *OrthographicCamera", gotScene.Camera[0])
+	}
+	if gotCam.LookFrom != scene.Camera[0].(OrthographicCamera).LookFrom {
+		t.Errorf("ReadPNGMeta() camera LookFrom = %v, want %v", gotCam.LookFrom, scene.Camera[0].(OrthographicCamera).LookFrom)
+	}
+
+	decoded := MustLoadPNG(path)
+	// Compare via RGBA() rather than == : the PNG decoder may return a
+	// different concrete color.Color type than img's (e.g. color.NRGBA vs
+	// color.RGBA), which interface equality would treat as unequal even
+	// when the actual channel values match.
+	gotR, gotG, gotB, gotA := decoded.At(1, 2).RGBA()
+	wantR, wantG, wantB, wantA := img.At(1, 2).RGBA()
+	if gotR != wantR || gotG != wantG || gotB != wantB || gotA != wantA {
+		t.Errorf("pixel (1,2) after round trip = %v, want %v", decoded.At(1, 2), img.At(1, 2))
+	}
+	if decoded.Bounds() != img.Bounds() {
+		t.Errorf("bounds after round trip = %v, want %v", decoded.Bounds(), img.Bounds())
+	}
+}
+
+// TestReadPNGMetaErrorsForPlainPNG verifies ReadPNGMeta reports an error,
+// rather than a zero Scene, for a PNG written by plain SavePNG, which has
+// no raytrace:provenance chunk to find.
+func TestReadPNGMetaErrorsForPlainPNG(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	path := filepath.Join(t.TempDir(), "plain.png")
+	if err := SavePNG(path, img); err != nil {
+		t.Fatalf("SavePNG() = %v, want nil", err)
+	}
+	if _, _, err := ReadPNGMeta(path); err == nil {
+		t.Errorf("ReadPNGMeta(plain PNG) = nil error, want an error (no provenance chunk)")
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// Film accumulates samples at continuous image-plane coordinates into a
+// per-pixel floating-point RGB+weight buffer, splatting each sample
+// through Filter's reconstruction kernel instead of averaging samples
+// within a pixel and filtering the result afterward. This is what makes
+// Filter an unbiased reconstruction kernel rather than the practical
+// approximation ApplySeparableFilterRGBA documents itself as being.
+type Film struct {
+	Dx, Dy int
+	Filter ReconFilter
+	accum  []Spectrum
+	weight []float64
+}
+
+// NewFilm returns a Film of size dx x dy pixels that reconstructs with
+// filter. The zero ReconFilter (Eval == nil) is treated as BoxFilter, so
+// an unconfigured Film still splats sensibly instead of panicking.
+func NewFilm(dx, dy int, filter ReconFilter) *Film {
+	if filter.Eval == nil {
+		filter = BoxFilter()
+	}
+	return &Film{
+		Dx:     dx,
+		Dy:     dy,
+		Filter: filter,
+		accum:  make([]Spectrum, dx*dy),
+		weight: make([]float64, dx*dy),
+	}
+}
+
+// Splat distributes s's energy across every pixel within Filter.Radius of
+// the continuous image-plane point (x, y), weighted by
+// Filter.Eval(x-pixelCenterX)*Filter.Eval(y-pixelCenterY). Pixel (px, py)
+// is treated as covering [px, px+1) x [py, py+1), so its center is at
+// (px+0.5, py+0.5), matching the (cx+jitter)/dx convention castPixelSample
+// already uses to place samples within a pixel.
+func (f *Film) Splat(x, y float64, s Spectrum) {
+	r := f.Filter.Radius
+	px0 := clamp(int(math.Ceil(x-r-0.5)), 0, f.Dx-1)
+	px1 := clamp(int(math.Floor(x+r-0.5)), 0, f.Dx-1)
+	py0 := clamp(int(math.Ceil(y-r-0.5)), 0, f.Dy-1)
+	py1 := clamp(int(math.Floor(y+r-0.5)), 0, f.Dy-1)
+	for py := py0; py <= py1; py++ {
+		wy := f.Filter.Eval(y - (float64(py) + 0.5))
+		if wy == 0 {
+			continue
+		}
+		for px := px0; px <= px1; px++ {
+			wx := f.Filter.Eval(x - (float64(px) + 0.5))
+			w := wx * wy
+			if w == 0 {
+				continue
+			}
+			idx := py*f.Dx + px
+			f.accum[idx] = f.accum[idx].Add(s.Muls(w))
+			f.weight[idx] += w
+		}
+	}
+}
+
+// Resolve divides every pixel's accumulated radiance by its accumulated
+// filter weight and returns the result as an 8-bit sRGB-range image, the
+// same tonemapping renderPixel applies. A pixel with zero accumulated
+// weight (no sample's footprint reached it, e.g. an unsampled pixel with
+// a narrow filter) renders black rather than dividing by zero.
+func (f *Film) Resolve() *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, f.Dx, f.Dy))
+	for py := 0; py < f.Dy; py++ {
+		for px := 0; px < f.Dx; px++ {
+			idx := py*f.Dx + px
+			var rgb Spectrum
+			if f.weight[idx] > 0 {
+				rgb = f.accum[idx].Divs(f.weight[idx])
+			}
+			img.Set(px, py, color.RGBA{
+				R: uint8(math.Min(255, math.Max(0, 255.99*rgb.X))),
+				G: uint8(math.Min(255, math.Max(0, 255.99*rgb.Y))),
+				B: uint8(math.Min(255, math.Max(0, 255.99*rgb.Z))),
+				A: 255,
+			})
+		}
+	}
+	return img
+}
@@ -0,0 +1,52 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import "testing"
+
+// TestFilmSplatBoxFilterStaysInOnePixel verifies that, with the default
+// BoxFilter, a sample landing inside a pixel contributes only to that
+// pixel, matching the old per-pixel-average behavior exactly.
+func TestFilmSplatBoxFilterStaysInOnePixel(t *testing.T) {
+	f := NewFilm(4, 4, BoxFilter())
+	f.Splat(1.5, 2.5, Spectrum{X: 1, Y: 1, Z: 1})
+	img := f.Resolve()
+	if r, g, b, _ := img.At(1, 2).RGBA(); r == 0 || g == 0 || b == 0 {
+		t.Errorf("pixel (1,2) = (%d,%d,%d), want non-zero", r, g, b)
+	}
+	if r, g, b, _ := img.At(2, 2).RGBA(); r != 0 || g != 0 || b != 0 {
+		t.Errorf("pixel (2,2) = (%d,%d,%d), want zero (box filter has no neighbor bleed)", r, g, b)
+	}
+}
+
+// TestFilmSplatWideFilterReachesNeighbors verifies that a filter with a
+// radius greater than 0.5 pixels (e.g. Mitchell-Netravali) spreads a
+// single sample's energy into neighboring pixels.
+func TestFilmSplatWideFilterReachesNeighbors(t *testing.T) {
+	f := NewFilm(8, 8, MitchellNetravaliFilter())
+	f.Splat(4.0, 4.0, Spectrum{X: 1, Y: 1, Z: 1})
+	img := f.Resolve()
+	if r, g, b, _ := img.At(3, 4).RGBA(); r == 0 && g == 0 && b == 0 {
+		t.Errorf("neighboring pixel (3,4) = (%d,%d,%d), want some splatted energy", r, g, b)
+	}
+}
+
+// TestFilmResolveUnsampledPixelIsBlack verifies Resolve doesn't divide by
+// zero for a pixel no sample's footprint reached.
+func TestFilmResolveUnsampledPixelIsBlack(t *testing.T) {
+	f := NewFilm(4, 4, BoxFilter())
+	img := f.Resolve()
+	if r, g, b, _ := img.At(0, 0).RGBA(); r != 0 || g != 0 || b != 0 {
+		t.Errorf("unsampled pixel = (%d,%d,%d), want black", r, g, b)
+	}
+}
+
+// TestFilmSplatNilFilterDefaultsToBox verifies NewFilm treats the zero
+// ReconFilter as BoxFilter instead of panicking on a nil Eval.
+func TestFilmSplatNilFilterDefaultsToBox(t *testing.T) {
+	f := NewFilm(4, 4, ReconFilter{})
+	f.Splat(1.5, 1.5, Spectrum{X: 1, Y: 1, Z: 1})
+	img := f.Resolve()
+	if r, _, _, _ := img.At(1, 1).RGBA(); r == 0 {
+		t.Errorf("pixel (1,1) = %d, want non-zero", r)
+	}
+}
@@ -0,0 +1,287 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import (
+	"fmt"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
+)
+
+// Plane is an oriented plane Normal·p + D = 0. A point p is on the
+// plane's positive (kept) side when Normal.Dot(r3.Vec(p)) + D >= 0.
+// Normal is unit length for every Plane this package constructs, so that
+// dot product doubles as a signed distance.
+type Plane struct {
+	Normal r3.Vec
+	D      float64
+}
+
+// normalize returns pl scaled so Normal is unit length, leaving pl
+// unchanged if Normal is already (numerically) zero -- a degenerate
+// plane every point satisfies, used by unboundedPlane below.
+func (pl Plane) normalize() Plane {
+	length := pl.Normal.Length()
+	if length == 0 {
+		return pl
+	}
+	return Plane{Normal: pl.Normal.Muls(1 / length), D: pl.D / length}
+}
+
+// unboundedPlane is a degenerate Plane (zero normal) every point
+// satisfies, used in place of a near or far plane for camera models that
+// have no clip distance of their own (PinholeCamera, OrthographicCamera):
+// their Cast rays are only bounded on the four sides of the image
+// rectangle, not along the view direction.
+var unboundedPlane = Plane{Normal: r3.Vec{}, D: 1}
+
+// Frustum is a six-plane convex view volume: left, right, bottom, top,
+// near, and far, in that order (see the Frustum* index constants). A
+// point is inside the frustum when it's on the positive side of all six
+// planes.
+type Frustum struct {
+	Planes [6]Plane
+}
+
+// Index constants for Frustum.Planes.
+const (
+	FrustumLeft int = iota
+	FrustumRight
+	FrustumBottom
+	FrustumTop
+	FrustumNear
+	FrustumFar
+)
+
+// FrustumFromMat4 extracts a Frustum from a combined view-projection
+// matrix (mapping world space to clip space, as phys.NewPerspective and
+// phys.NewOrthographic produce) via the Gribb-Hartmann method: each clip
+// plane is the row-add or row-subtract of the matrix's rows that, after
+// projection, the corresponding clip-space inequality reduces to, then
+// normalized by its normal's length so Planes' Normal is unit length.
+func FrustumFromMat4(viewProj r3.Mat4) Frustum {
+	m := viewProj.M
+	row := func(i int) [4]float64 { return m[i] }
+	addRows := func(a, b [4]float64) Plane {
+		return Plane{Normal: r3.Vec{X: a[0] + b[0], Y: a[1] + b[1], Z: a[2] + b[2]}, D: a[3] + b[3]}
+	}
+	subRows := func(a, b [4]float64) Plane {
+		return Plane{Normal: r3.Vec{X: a[0] - b[0], Y: a[1] - b[1], Z: a[2] - b[2]}, D: a[3] - b[3]}
+	}
+	row0, row1, row2, row3 := row(0), row(1), row(2), row(3)
+	return Frustum{Planes: [6]Plane{
+		FrustumLeft:   addRows(row3, row0).normalize(),
+		FrustumRight:  subRows(row3, row0).normalize(),
+		FrustumBottom: addRows(row3, row1).normalize(),
+		FrustumTop:    subRows(row3, row1).normalize(),
+		FrustumNear:   addRows(row3, row2).normalize(),
+		FrustumFar:    subRows(row3, row2).normalize(),
+	}}
+}
+
+// planeThrough returns the plane containing p0, p1, and p2, oriented by
+// the right-hand rule of (p1-p0) x (p2-p0), and false if the three points
+// are collinear or coincident and so have no well-defined plane.
+func planeThrough(p0, p1, p2 r3.Point) (Plane, bool) {
+	n := p1.Sub(p0).Cross(p2.Sub(p0))
+	length := n.Length()
+	if length == 0 {
+		return Plane{}, false
+	}
+	n = n.Muls(1 / length)
+	return Plane{Normal: n, D: -n.Dot(r3.Vec(p0))}, true
+}
+
+// orient flips pl's sign, if needed, so interior is on its positive
+// side. Used after planeThrough, which only fixes a plane's orientation
+// up to the winding order of the three points it was built from.
+func orient(pl Plane, interior r3.Point) Plane {
+	if pl.Normal.Dot(r3.Vec(interior))+pl.D < 0 {
+		return Plane{Normal: pl.Normal.Muls(-1), D: -pl.D}
+	}
+	return pl
+}
+
+// FrustumFromCamera derives the view volume a Camera's Cast rays lie
+// within, for the camera models this package can reason about
+// geometrically (PinholeCamera, OrthographicCamera, and an AnimatedCamera
+// resolved at its current U). Neither model has an explicit near or far
+// clip distance -- their rays extend from the image plane to infinity --
+// so FrustumNear and FrustumFar are both unboundedPlane; only the four
+// side planes actually cull. Returns an error for any other Camera
+// implementation, since there's no general way to bound an arbitrary
+// Cast's rays without sampling it.
+func FrustumFromCamera(cam Camera) (Frustum, error) {
+	switch c := cam.(type) {
+	case PinholeCamera:
+		return frustumFromPinhole(c), nil
+	case OrthographicCamera:
+		return frustumFromOrthographic(c), nil
+	case AnimatedCamera:
+		if c.Build == nil {
+			return Frustum{}, fmt.Errorf("FrustumFromCamera: AnimatedCamera.Build is nil")
+		}
+		return FrustumFromCamera(c.Build(c.wrap01(c.U)))
+	default:
+		return Frustum{}, fmt.Errorf("FrustumFromCamera: unsupported Camera type %T", cam)
+	}
+}
+
+// frustumFromPinhole builds the four side planes of the infinite pyramid
+// with apex c.Origin passing through the four corners of c's image
+// rectangle, oriented using the rectangle's center as an interior point.
+func frustumFromPinhole(c PinholeCamera) Frustum {
+	apex := c.Origin
+	corner00 := c.LowerLeftCorner
+	corner10 := c.LowerLeftCorner.Add(c.Horizontal)
+	corner01 := c.LowerLeftCorner.Add(c.Vertical)
+	corner11 := c.LowerLeftCorner.Add(c.Horizontal).Add(c.Vertical)
+	center := c.LowerLeftCorner.Add(c.Horizontal.Muls(0.5)).Add(c.Vertical.Muls(0.5))
+
+	left, _ := planeThrough(apex, corner00, corner01)
+	right, _ := planeThrough(apex, corner11, corner10)
+	bottom, _ := planeThrough(apex, corner10, corner00)
+	top, _ := planeThrough(apex, corner01, corner11)
+
+	return Frustum{Planes: [6]Plane{
+		FrustumLeft:   orient(left, center),
+		FrustumRight:  orient(right, center),
+		FrustumBottom: orient(bottom, center),
+		FrustumTop:    orient(top, center),
+		FrustumNear:   unboundedPlane,
+		FrustumFar:    unboundedPlane,
+	}}
+}
+
+// frustumFromOrthographic builds the four side planes of c's rectangular
+// slab: each is a plane perpendicular to c's right or up basis vector,
+// offset by half of c's FOVWidth/FOVHeight, oriented using c.LookFrom
+// (on the centerline, so always interior) as an interior point.
+func frustumFromOrthographic(c OrthographicCamera) Frustum {
+	w := c.LookFrom.Sub(c.LookAt).Unit()
+	u := c.VUp.Cross(w).Unit()
+	v := w.Cross(u)
+	halfWidth := float64(c.FOVWidth) / 2
+	halfHeight := float64(c.FOVHeight) / 2
+
+	left := Plane{Normal: u, D: -u.Dot(r3.Vec(c.LookFrom.Add(u.Muls(-halfWidth))))}
+	right := Plane{Normal: u.Muls(-1), D: -u.Muls(-1).Dot(r3.Vec(c.LookFrom.Add(u.Muls(halfWidth))))}
+	bottom := Plane{Normal: v, D: -v.Dot(r3.Vec(c.LookFrom.Add(v.Muls(-halfHeight))))}
+	top := Plane{Normal: v.Muls(-1), D: -v.Muls(-1).Dot(r3.Vec(c.LookFrom.Add(v.Muls(halfHeight))))}
+
+	return Frustum{Planes: [6]Plane{
+		FrustumLeft:   left,
+		FrustumRight:  right,
+		FrustumBottom: bottom,
+		FrustumTop:    top,
+		FrustumNear:   unboundedPlane,
+		FrustumFar:    unboundedPlane,
+	}}
+}
+
+// pvertex returns whichever of min or max axis extends furthest along a
+// plane's normal component n, the corner AABB.IntersectsFrustum tests for
+// each plane (its "p-vertex").
+func pvertex(n, min, max float64) float64 {
+	if n >= 0 {
+		return max
+	}
+	return min
+}
+
+// IntersectsFrustum reports whether b's bounds are not entirely outside
+// any of f's six planes, using the standard p-vertex test: for each
+// plane, only the AABB corner furthest along that plane's normal (picked
+// axis-by-axis by pvertex) is tested, since if even that corner fails,
+// every other corner does too and the box can't overlap the frustum.
+// This has no n-vertex confirmation pass, so it can report true for a
+// box that doesn't actually overlap the frustum (a corner near one
+// plane can pass while the box is still fully outside another); the
+// cost is a few BVH subtrees CollectVisible could have pruned but
+// didn't, never a dropped hit.
+func (b AABB) IntersectsFrustum(f Frustum) bool {
+	for _, pl := range f.Planes {
+		p := r3.Point{
+			X: pvertex(pl.Normal.X, b.Min.X, b.Max.X),
+			Y: pvertex(pl.Normal.Y, b.Min.Y, b.Max.Y),
+			Z: pvertex(pl.Normal.Z, b.Min.Z, b.Max.Z),
+		}
+		if pl.Normal.Dot(r3.Vec(p))+pl.D < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// FrustumUnion is the frustums of several camera poses, used where a
+// single Frustum would wrongly cull geometry that's only ever in view
+// during some of them -- see AnimatedCamera.FrustumUnion.
+type FrustumUnion []Frustum
+
+// IntersectsAny reports whether b is inside at least one member of u,
+// i.e. whether b could be visible in at least one of the sampled poses.
+func (u FrustumUnion) IntersectsAny(b AABB) bool {
+	for _, f := range u {
+		if b.IntersectsFrustum(f) {
+			return true
+		}
+	}
+	return false
+}
+
+// FrustumUnion returns the frustums of nFrames camera poses sampled
+// uniformly over one full animation cycle (see Frames), for culling a
+// render that spans multiple frames -- an orbiting turntable or a
+// motion-blurred sequence -- where any single frame's Frustum would cull
+// geometry that's only in view partway through. Returns an error if
+// nFrames <= 0 or if any sampled pose's Camera isn't one FrustumFromCamera
+// supports.
+func (ac AnimatedCamera) FrustumUnion(nFrames int) (FrustumUnion, error) {
+	cams := ac.Frames(nFrames)
+	if len(cams) == 0 {
+		return nil, fmt.Errorf("AnimatedCamera.FrustumUnion: nFrames must be positive, got %d", nFrames)
+	}
+	union := make(FrustumUnion, len(cams))
+	for i, cam := range cams {
+		f, err := FrustumFromCamera(cam)
+		if err != nil {
+			return nil, fmt.Errorf("AnimatedCamera.FrustumUnion: frame %d: %w", i, err)
+		}
+		union[i] = f
+	}
+	return union, nil
+}
+
+// CollectVisible appends every Shape under b whose bounds
+// AABB.IntersectsFrustum confirms could overlap f to *out, descending
+// only into subtrees that pass the test and skipping the rest outright.
+// A leaf contributes its whole BVHLeaf rather than each of its Shapes
+// individually, matching how Collide already tests a leaf's shapes
+// together. b may be nil (an empty BVH, see NewBVH), in which case
+// CollectVisible leaves *out unchanged.
+func (b *BVH) CollectVisible(f Frustum, out *[]Shape) {
+	if b == nil || !b.bounds.IntersectsFrustum(f) {
+		return
+	}
+	if b.Right == nil {
+		*out = append(*out, b.Left)
+		return
+	}
+	b.Left.(*BVH).CollectVisible(f, out)
+	b.Right.(*BVH).CollectVisible(f, out)
+}
+
+// CollectVisibleUnion is CollectVisible against a FrustumUnion: b
+// contributes to *out if it passes u.IntersectsAny rather than a single
+// Frustum's IntersectsFrustum, for culling a render spanning the many
+// poses an AnimatedCamera.FrustumUnion was built from.
+func (b *BVH) CollectVisibleUnion(u FrustumUnion, out *[]Shape) {
+	if b == nil || !u.IntersectsAny(b.bounds) {
+		return
+	}
+	if b.Right == nil {
+		*out = append(*out, b.Left)
+		return
+	}
+	b.Left.(*BVH).CollectVisibleUnion(u, out)
+	b.Right.(*BVH).CollectVisibleUnion(u, out)
+}
@@ -0,0 +1,131 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import (
+	"testing"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
+)
+
+// pinholeLookingDownZ returns a PinholeCamera at the origin looking down
+// -Z with a 2x2 image plane one unit away, the camera shared by the
+// frustum tests below.
+func pinholeLookingDownZ() PinholeCamera {
+	return PinholeCamera{
+		Origin:          r3.Point{X: 0, Y: 0, Z: 0},
+		LowerLeftCorner: r3.Point{X: -1, Y: -1, Z: -1},
+		Horizontal:      r3.Vec{X: 2, Y: 0, Z: 0},
+		Vertical:        r3.Vec{X: 0, Y: 2, Z: 0},
+	}
+}
+
+// TestFrustumFromCameraPinholeContainsOnAxisPoints verifies that points
+// straight ahead of the camera, at varying depth, are inside the
+// Frustum, while a point behind the camera is not.
+func TestFrustumFromCameraPinholeContainsOnAxisPoints(t *testing.T) {
+	frustum, err := FrustumFromCamera(pinholeLookingDownZ())
+	if err != nil {
+		t.Fatalf("FrustumFromCamera: %v", err)
+	}
+	bounds := AABB{Min: r3.Point{X: -0.1, Y: -0.1, Z: -10.1}, Max: r3.Point{X: 0.1, Y: 0.1, Z: -9.9}}
+	if !bounds.IntersectsFrustum(frustum) {
+		t.Errorf("on-axis box far ahead of the camera should be inside the frustum")
+	}
+	behind := AABB{Min: r3.Point{X: -0.1, Y: -0.1, Z: 9.9}, Max: r3.Point{X: 0.1, Y: 0.1, Z: 10.1}}
+	if behind.IntersectsFrustum(frustum) {
+		t.Errorf("box behind the camera should be outside the frustum")
+	}
+}
+
+// TestFrustumFromCameraPinholeExcludesOffAxisPoints verifies that a box
+// far to the side of the camera's view rectangle is culled.
+func TestFrustumFromCameraPinholeExcludesOffAxisPoints(t *testing.T) {
+	frustum, err := FrustumFromCamera(pinholeLookingDownZ())
+	if err != nil {
+		t.Fatalf("FrustumFromCamera: %v", err)
+	}
+	offAxis := AABB{Min: r3.Point{X: 100, Y: -0.1, Z: -10.1}, Max: r3.Point{X: 100.2, Y: 0.1, Z: -9.9}}
+	if offAxis.IntersectsFrustum(frustum) {
+		t.Errorf("box far to the side of the image rectangle should be outside the frustum")
+	}
+}
+
+// TestFrustumFromCameraUnsupported verifies that a Camera implementation
+// FrustumFromCamera doesn't recognize produces an error rather than a
+// meaningless Frustum.
+func TestFrustumFromCameraUnsupported(t *testing.T) {
+	_, err := FrustumFromCamera(AnimatedCamera{})
+	if err == nil {
+		t.Errorf("expected an error for an AnimatedCamera with a nil Build")
+	}
+}
+
+// TestBVHCollectVisiblePrunesOffscreenShapes verifies that CollectVisible
+// returns only the instance placed in front of the camera, not the one
+// placed far behind it.
+func TestBVHCollectVisiblePrunesOffscreenShapes(t *testing.T) {
+	inView := Sphere{Center: r3.Point{X: 0, Y: 0, Z: -10}, Radius: 1}
+	behind := Sphere{Center: r3.Point{X: 0, Y: 0, Z: 10}, Radius: 1}
+	bvh := NewBVH([]Shape{inView, behind}, 0)
+
+	frustum, err := FrustumFromCamera(pinholeLookingDownZ())
+	if err != nil {
+		t.Fatalf("FrustumFromCamera: %v", err)
+	}
+	var visible []Shape
+	bvh.CollectVisible(frustum, &visible)
+	if len(visible) == 0 {
+		t.Fatalf("expected at least one visible shape")
+	}
+	for _, s := range visible {
+		if s.Bounds().intersects(behind.Bounds()) && !s.Bounds().intersects(inView.Bounds()) {
+			t.Errorf("CollectVisible returned a shape behind the camera: %v", s)
+		}
+	}
+}
+
+// TestAnimatedCameraFrustumUnionCoversEveryFrame verifies that
+// FrustumUnion's result includes a box only visible from one of several
+// sampled orbit poses, which a single frame's Frustum would cull.
+func TestAnimatedCameraFrustumUnionCoversEveryFrame(t *testing.T) {
+	const nFrames = 4
+	build := func(u float64) Camera {
+		// Four cameras, each looking down a different axis-aligned
+		// direction, so each sees a box only it is aimed at.
+		switch int(u*nFrames) % nFrames {
+		case 0:
+			return pinholeLookingDownZ()
+		case 1:
+			return PinholeCamera{
+				Origin:          r3.Point{X: 0, Y: 0, Z: 0},
+				LowerLeftCorner: r3.Point{X: -1, Y: -1, Z: 9},
+				Horizontal:      r3.Vec{X: 2, Y: 0, Z: 0},
+				Vertical:        r3.Vec{X: 0, Y: 2, Z: 0},
+			}
+		default:
+			return pinholeLookingDownZ()
+		}
+	}
+	ac := NewAnimatedCamera(build, 0, 0)
+
+	union, err := ac.FrustumUnion(nFrames)
+	if err != nil {
+		t.Fatalf("FrustumUnion: %v", err)
+	}
+	if len(union) != nFrames {
+		t.Fatalf("FrustumUnion returned %d frustums, want %d", len(union), nFrames)
+	}
+
+	onlyVisibleFromFrame1 := AABB{Min: r3.Point{X: -0.1, Y: -0.1, Z: 9.9}, Max: r3.Point{X: 0.1, Y: 0.1, Z: 10.1}}
+	if !union.IntersectsAny(onlyVisibleFromFrame1) {
+		t.Errorf("FrustumUnion should still see geometry visible from only one of its sampled frames")
+	}
+
+	singleFrame, err := FrustumFromCamera(pinholeLookingDownZ())
+	if err != nil {
+		t.Fatalf("FrustumFromCamera: %v", err)
+	}
+	if onlyVisibleFromFrame1.IntersectsFrustum(singleFrame) {
+		t.Errorf("sanity check failed: box should not be visible from the frame-0-only camera")
+	}
+}
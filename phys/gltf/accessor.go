@@ -0,0 +1,359 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+
+package gltf
+
+import "fmt"
+
+// AccessorReader reads typed element data out of a Document's accessors.
+// Buffers holds the raw bytes of each of doc.Buffers, in the same order
+// (e.g. a GLB's BIN chunk for doc.Buffers[0], or the contents the caller
+// read from each Buffer.URI names for a ".gltf"+".bin" asset). Export's
+// own Import path only ever resolves a single embedded buffer and reads
+// accessors directly (see accessorBytes in scene.go); AccessorReader is
+// the general-purpose counterpart for callers that need arbitrary
+// accessors — including sparse ones — rather than the handful Import
+// itself consumes.
+type AccessorReader struct {
+	doc     *Document
+	buffers [][]byte
+}
+
+// NewAccessorReader returns an AccessorReader for doc, with buffers[i]
+// holding the resolved bytes of doc.Buffers[i].
+func NewAccessorReader(doc *Document, buffers [][]byte) *AccessorReader {
+	return &AccessorReader{doc: doc, buffers: buffers}
+}
+
+// ReadFloat32(a) reads a SCALAR accessor as float32, applying a's
+// Normalized conversion when set.
+func (r *AccessorReader) ReadFloat32(a *Accessor) ([]float32, error) {
+	if a.Type != AccessorTypeScalar {
+		return nil, fmt.Errorf("gltf: accessor type %q, want SCALAR", a.Type)
+	}
+	raw, err := r.rawComponents(a)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]float32, len(raw))
+	for i, e := range raw {
+		out[i] = float32(normalizeComponent(a.ComponentType, a.Normalized, e[0]))
+	}
+	return out, nil
+}
+
+// ReadFloat32Vec2 reads a VEC2 accessor as float32, applying a's
+// Normalized conversion when set.
+func (r *AccessorReader) ReadFloat32Vec2(a *Accessor) ([][2]float32, error) {
+	if a.Type != AccessorTypeVec2 {
+		return nil, fmt.Errorf("gltf: accessor type %q, want VEC2", a.Type)
+	}
+	raw, err := r.rawComponents(a)
+	if err != nil {
+		return nil, err
+	}
+	out := make([][2]float32, len(raw))
+	for i, e := range raw {
+		for c := range out[i] {
+			out[i][c] = float32(normalizeComponent(a.ComponentType, a.Normalized, e[c]))
+		}
+	}
+	return out, nil
+}
+
+// ReadFloat32Vec3 reads a VEC3 accessor as float32, applying a's
+// Normalized conversion when set.
+func (r *AccessorReader) ReadFloat32Vec3(a *Accessor) ([][3]float32, error) {
+	if a.Type != AccessorTypeVec3 {
+		return nil, fmt.Errorf("gltf: accessor type %q, want VEC3", a.Type)
+	}
+	raw, err := r.rawComponents(a)
+	if err != nil {
+		return nil, err
+	}
+	out := make([][3]float32, len(raw))
+	for i, e := range raw {
+		for c := range out[i] {
+			out[i][c] = float32(normalizeComponent(a.ComponentType, a.Normalized, e[c]))
+		}
+	}
+	return out, nil
+}
+
+// ReadFloat32Vec4 reads a VEC4 accessor as float32, applying a's
+// Normalized conversion when set.
+func (r *AccessorReader) ReadFloat32Vec4(a *Accessor) ([][4]float32, error) {
+	if a.Type != AccessorTypeVec4 {
+		return nil, fmt.Errorf("gltf: accessor type %q, want VEC4", a.Type)
+	}
+	raw, err := r.rawComponents(a)
+	if err != nil {
+		return nil, err
+	}
+	out := make([][4]float32, len(raw))
+	for i, e := range raw {
+		for c := range out[i] {
+			out[i][c] = float32(normalizeComponent(a.ComponentType, a.Normalized, e[c]))
+		}
+	}
+	return out, nil
+}
+
+// ReadUint8 reads a SCALAR UNSIGNED_BYTE accessor, the way a COLOR_0 or
+// JOINTS_0 attribute narrow enough to fit a byte would be encoded.
+func (r *AccessorReader) ReadUint8(a *Accessor) ([]uint8, error) {
+	if a.Type != AccessorTypeScalar {
+		return nil, fmt.Errorf("gltf: accessor type %q, want SCALAR", a.Type)
+	}
+	if a.ComponentType != ComponentUnsignedByte {
+		return nil, fmt.Errorf("gltf: accessor componentType %d, want UNSIGNED_BYTE", a.ComponentType)
+	}
+	raw, err := r.rawComponents(a)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]uint8, len(raw))
+	for i, e := range raw {
+		out[i] = uint8(e[0])
+	}
+	return out, nil
+}
+
+// ReadUint16 reads a SCALAR UNSIGNED_SHORT accessor, the way a mesh's
+// index buffer is most commonly encoded.
+func (r *AccessorReader) ReadUint16(a *Accessor) ([]uint16, error) {
+	if a.Type != AccessorTypeScalar {
+		return nil, fmt.Errorf("gltf: accessor type %q, want SCALAR", a.Type)
+	}
+	if a.ComponentType != ComponentUnsignedShort {
+		return nil, fmt.Errorf("gltf: accessor componentType %d, want UNSIGNED_SHORT", a.ComponentType)
+	}
+	raw, err := r.rawComponents(a)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]uint16, len(raw))
+	for i, e := range raw {
+		out[i] = uint16(e[0])
+	}
+	return out, nil
+}
+
+// ReadUint32 reads a SCALAR UNSIGNED_INT accessor, the way a large mesh's
+// index buffer is encoded when UNSIGNED_SHORT can't hold every index.
+func (r *AccessorReader) ReadUint32(a *Accessor) ([]uint32, error) {
+	if a.Type != AccessorTypeScalar {
+		return nil, fmt.Errorf("gltf: accessor type %q, want SCALAR", a.Type)
+	}
+	if a.ComponentType != ComponentUnsignedInt {
+		return nil, fmt.Errorf("gltf: accessor componentType %d, want UNSIGNED_INT", a.ComponentType)
+	}
+	raw, err := r.rawComponents(a)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]uint32, len(raw))
+	for i, e := range raw {
+		out[i] = uint32(e[0])
+	}
+	return out, nil
+}
+
+// rawComponents decodes a's elements into a's own component count and
+// component type (not yet normalized or narrowed to a caller's output
+// type), first materializing the dense base — a's BufferView if it has
+// one, or an all-zero base per spec if it doesn't — and then, if a has
+// an AccessorSparse, overwriting the Sparse.Count elements it names with
+// the values read from Sparse.Values at the indices read from
+// Sparse.Indices.
+func (r *AccessorReader) rawComponents(a *Accessor) ([][]float64, error) {
+	n, err := componentCount(a.Type)
+	if err != nil {
+		return nil, err
+	}
+	size, err := componentSize(a.ComponentType)
+	if err != nil {
+		return nil, err
+	}
+
+	base := make([][]float64, a.Count)
+	for i := range base {
+		base[i] = make([]float64, n)
+	}
+
+	if a.BufferView != nil {
+		data, stride, err := r.bufferViewBytes(*a.BufferView, a.ByteOffset)
+		if err != nil {
+			return nil, err
+		}
+		elemSize := n * size
+		if stride == 0 {
+			stride = elemSize
+		}
+		if err := checkElementBounds(data, a.Count, stride, elemSize); err != nil {
+			return nil, err
+		}
+		for i := 0; i < a.Count; i++ {
+			off := i * stride
+			for c := 0; c < n; c++ {
+				base[i][c] = decodeComponent(a.ComponentType, data[off+c*size:])
+			}
+		}
+	}
+
+	if a.Sparse != nil {
+		if err := r.applySparse(a, n, size, base); err != nil {
+			return nil, err
+		}
+	}
+
+	return base, nil
+}
+
+// applySparse overlays a.Sparse's overrides onto base, which already
+// holds a's dense (or all-zero) data.
+func (r *AccessorReader) applySparse(a *Accessor, n, size int, base [][]float64) error {
+	sp := a.Sparse
+
+	idxSize, err := componentSize(sp.Indices.ComponentType)
+	if err != nil {
+		return err
+	}
+	idxData, _, err := r.bufferViewBytes(sp.Indices.BufferView, sp.Indices.ByteOffset)
+	if err != nil {
+		return err
+	}
+	if err := checkElementBounds(idxData, sp.Count, idxSize, idxSize); err != nil {
+		return err
+	}
+
+	elemSize := n * size
+	valData, _, err := r.bufferViewBytes(sp.Values.BufferView, sp.Values.ByteOffset)
+	if err != nil {
+		return err
+	}
+	if err := checkElementBounds(valData, sp.Count, elemSize, elemSize); err != nil {
+		return err
+	}
+
+	for i := 0; i < sp.Count; i++ {
+		idx := int(decodeComponent(sp.Indices.ComponentType, idxData[i*idxSize:]))
+		if idx < 0 || idx >= len(base) {
+			return fmt.Errorf("gltf: accessor sparse index %d is out of range (count=%d)", idx, len(base))
+		}
+		for c := 0; c < n; c++ {
+			base[idx][c] = decodeComponent(a.ComponentType, valData[i*elemSize+c*size:])
+		}
+	}
+	return nil
+}
+
+// bufferViewBytes returns the bytes of doc.BufferViews[viewIndex],
+// offset by an additional byteOffset (an Accessor's own ByteOffset into
+// its BufferView), along with the view's ByteStride.
+func (r *AccessorReader) bufferViewBytes(viewIndex uint32, byteOffset int) (data []byte, stride int, err error) {
+	if int(viewIndex) >= len(r.doc.BufferViews) {
+		return nil, 0, fmt.Errorf("gltf: bufferView index %d out of range", viewIndex)
+	}
+	view := r.doc.BufferViews[viewIndex]
+	if int(view.Buffer) >= len(r.buffers) {
+		return nil, 0, fmt.Errorf("gltf: buffer index %d out of range (have %d buffers)", view.Buffer, len(r.buffers))
+	}
+	buf := r.buffers[view.Buffer]
+	start := view.ByteOffset + byteOffset
+	end := view.ByteOffset + view.ByteLength
+	if start > len(buf) || end > len(buf) || start > end {
+		return nil, 0, fmt.Errorf("gltf: bufferView %d: byte range [%d:%d] is past the end of buffer %d (%d bytes)", viewIndex, start, end, view.Buffer, len(buf))
+	}
+	return buf[start:end], view.ByteStride, nil
+}
+
+// checkElementBounds reports an error if reading count elemSize-wide
+// elements at stride out of data would run past its end.
+func checkElementBounds(data []byte, count, stride, elemSize int) error {
+	if count == 0 {
+		return nil
+	}
+	need := (count-1)*stride + elemSize
+	if need > len(data) {
+		return fmt.Errorf("gltf: need %d bytes (count=%d, stride=%d) but have %d", need, count, stride, len(data))
+	}
+	return nil
+}
+
+// componentCount returns the number of scalar components in one element
+// of an accessor of type t.
+func componentCount(t AccessorType) (int, error) {
+	switch t {
+	case AccessorTypeScalar:
+		return 1, nil
+	case AccessorTypeVec2:
+		return 2, nil
+	case AccessorTypeVec3:
+		return 3, nil
+	case AccessorTypeVec4:
+		return 4, nil
+	case AccessorTypeMat2:
+		return 4, nil
+	case AccessorTypeMat3:
+		return 9, nil
+	case AccessorTypeMat4:
+		return 16, nil
+	}
+	return 0, fmt.Errorf("gltf: unknown AccessorType %q", t)
+}
+
+// componentSize returns the byte width of one scalar component of
+// ComponentType c.
+func componentSize(c ComponentType) (int, error) {
+	switch c {
+	case ComponentByte, ComponentUnsignedByte:
+		return 1, nil
+	case ComponentShort, ComponentUnsignedShort:
+		return 2, nil
+	case ComponentUnsignedInt, ComponentFloat:
+		return 4, nil
+	}
+	return 0, fmt.Errorf("gltf: unknown ComponentType %d", c)
+}
+
+// decodeComponent reads one little-endian scalar of ComponentType ct
+// from the start of b, as the raw (not normalized) numeric value.
+func decodeComponent(ct ComponentType, b []byte) float64 {
+	switch ct {
+	case ComponentByte:
+		return float64(int8(b[0]))
+	case ComponentUnsignedByte:
+		return float64(b[0])
+	case ComponentShort:
+		return float64(int16(uint16(b[0]) | uint16(b[1])<<8))
+	case ComponentUnsignedShort:
+		return float64(uint16(b[0]) | uint16(b[1])<<8)
+	case ComponentUnsignedInt:
+		return float64(uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16 | uint32(b[3])<<24)
+	case ComponentFloat:
+		return float64(bytesToFloat32(b))
+	}
+	return 0
+}
+
+// normalizeComponent converts a raw integer component value to the
+// [-1,1] (signed) or [0,1] (unsigned) float range the glTF spec defines
+// for normalized integer accessors. It returns v unchanged when
+// normalized is false, or when ct is a type the spec never normalizes
+// (UNSIGNED_INT, FLOAT).
+func normalizeComponent(ct ComponentType, normalized bool, v float64) float64 {
+	if !normalized {
+		return v
+	}
+	switch ct {
+	case ComponentByte:
+		return max(v/127, -1)
+	case ComponentUnsignedByte:
+		return v / 255
+	case ComponentShort:
+		return max(v/32767, -1)
+	case ComponentUnsignedShort:
+		return v / 65535
+	}
+	return v
+}
@@ -0,0 +1,239 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+
+package gltf
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+)
+
+// putFloat32 appends the little-endian bytes of v to b.
+func putFloat32(b []byte, v float32) []byte {
+	var buf [4]byte
+	binary.LittleEndian.PutUint32(buf[:], math.Float32bits(v))
+	return append(b, buf[:]...)
+}
+
+// TestAccessorReaderComponentTypeCombinations covers every
+// ComponentType/AccessorType combination ReadFloat32* and ReadUint*
+// support, including normalized integer conversion.
+func TestAccessorReaderComponentTypeCombinations(t *testing.T) {
+	t.Run("float vec3 positions", func(t *testing.T) {
+		var buf []byte
+		buf = putFloat32(buf, 1)
+		buf = putFloat32(buf, 2)
+		buf = putFloat32(buf, 3)
+		buf = putFloat32(buf, -1)
+		buf = putFloat32(buf, -2)
+		buf = putFloat32(buf, -3)
+		doc := &Document{BufferViews: []BufferView{{Buffer: 0, ByteLength: len(buf)}}}
+		r := NewAccessorReader(doc, [][]byte{buf})
+		acc := &Accessor{BufferView: Index(0), ComponentType: ComponentFloat, Type: AccessorTypeVec3, Count: 2}
+		got, err := r.ReadFloat32Vec3(acc)
+		if err != nil {
+			t.Fatalf("ReadFloat32Vec3: %v", err)
+		}
+		want := [][3]float32{{1, 2, 3}, {-1, -2, -3}}
+		if got != nil && (len(got) != len(want) || got[0] != want[0] || got[1] != want[1]) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	})
+
+	t.Run("unsigned byte normalized scalar", func(t *testing.T) {
+		buf := []byte{0, 127, 255}
+		doc := &Document{BufferViews: []BufferView{{Buffer: 0, ByteLength: len(buf)}}}
+		r := NewAccessorReader(doc, [][]byte{buf})
+		acc := &Accessor{BufferView: Index(0), ComponentType: ComponentUnsignedByte, Type: AccessorTypeScalar, Count: 3, Normalized: true}
+		got, err := r.ReadFloat32(acc)
+		if err != nil {
+			t.Fatalf("ReadFloat32: %v", err)
+		}
+		want := []float32{0, float32(127.0 / 255.0), 1}
+		for i := range want {
+			if math.Abs(float64(got[i]-want[i])) > 1e-6 {
+				t.Errorf("got[%d] = %v, want %v", i, got[i], want[i])
+			}
+		}
+	})
+
+	t.Run("signed byte normalized scalar", func(t *testing.T) {
+		buf := []byte{0x80, 0, 127}
+		doc := &Document{BufferViews: []BufferView{{Buffer: 0, ByteLength: len(buf)}}}
+		r := NewAccessorReader(doc, [][]byte{buf})
+		acc := &Accessor{BufferView: Index(0), ComponentType: ComponentByte, Type: AccessorTypeScalar, Count: 3, Normalized: true}
+		got, err := r.ReadFloat32(acc)
+		if err != nil {
+			t.Fatalf("ReadFloat32: %v", err)
+		}
+		want := []float32{-1, 0, 1}
+		for i := range want {
+			if math.Abs(float64(got[i]-want[i])) > 1e-6 {
+				t.Errorf("got[%d] = %v, want %v", i, got[i], want[i])
+			}
+		}
+	})
+
+	t.Run("unsigned short normalized vec2", func(t *testing.T) {
+		var buf []byte
+		buf = binary.LittleEndian.AppendUint16(buf, 0)
+		buf = binary.LittleEndian.AppendUint16(buf, 65535)
+		doc := &Document{BufferViews: []BufferView{{Buffer: 0, ByteLength: len(buf)}}}
+		r := NewAccessorReader(doc, [][]byte{buf})
+		acc := &Accessor{BufferView: Index(0), ComponentType: ComponentUnsignedShort, Type: AccessorTypeVec2, Count: 1, Normalized: true}
+		got, err := r.ReadFloat32Vec2(acc)
+		if err != nil {
+			t.Fatalf("ReadFloat32Vec2: %v", err)
+		}
+		if got[0][0] != 0 || got[0][1] != 1 {
+			t.Errorf("got %v, want (0, 1)", got)
+		}
+	})
+
+	t.Run("signed short normalized scalar", func(t *testing.T) {
+		var buf []byte
+		buf = binary.LittleEndian.AppendUint16(buf, 0x8000) // int16(-32768)
+		buf = binary.LittleEndian.AppendUint16(buf, 0x7fff) // int16(32767)
+		doc := &Document{BufferViews: []BufferView{{Buffer: 0, ByteLength: len(buf)}}}
+		r := NewAccessorReader(doc, [][]byte{buf})
+		acc := &Accessor{BufferView: Index(0), ComponentType: ComponentShort, Type: AccessorTypeScalar, Count: 2, Normalized: true}
+		got, err := r.ReadFloat32(acc)
+		if err != nil {
+			t.Fatalf("ReadFloat32: %v", err)
+		}
+		if got[0] != -1 || got[1] != 1 {
+			t.Errorf("got %v, want (-1, 1)", got)
+		}
+	})
+
+	t.Run("uint8 indices", func(t *testing.T) {
+		buf := []byte{0, 1, 2}
+		doc := &Document{BufferViews: []BufferView{{Buffer: 0, ByteLength: len(buf)}}}
+		r := NewAccessorReader(doc, [][]byte{buf})
+		acc := &Accessor{BufferView: Index(0), ComponentType: ComponentUnsignedByte, Type: AccessorTypeScalar, Count: 3}
+		got, err := r.ReadUint8(acc)
+		if err != nil {
+			t.Fatalf("ReadUint8: %v", err)
+		}
+		if got[0] != 0 || got[1] != 1 || got[2] != 2 {
+			t.Errorf("got %v, want [0 1 2]", got)
+		}
+	})
+
+	t.Run("uint16 indices", func(t *testing.T) {
+		var buf []byte
+		buf = binary.LittleEndian.AppendUint16(buf, 0)
+		buf = binary.LittleEndian.AppendUint16(buf, 1)
+		buf = binary.LittleEndian.AppendUint16(buf, 65535)
+		doc := &Document{BufferViews: []BufferView{{Buffer: 0, ByteLength: len(buf)}}}
+		r := NewAccessorReader(doc, [][]byte{buf})
+		acc := &Accessor{BufferView: Index(0), ComponentType: ComponentUnsignedShort, Type: AccessorTypeScalar, Count: 3}
+		got, err := r.ReadUint16(acc)
+		if err != nil {
+			t.Fatalf("ReadUint16: %v", err)
+		}
+		if got[0] != 0 || got[1] != 1 || got[2] != 65535 {
+			t.Errorf("got %v, want [0 1 65535]", got)
+		}
+	})
+
+	t.Run("uint32 indices", func(t *testing.T) {
+		var buf []byte
+		buf = binary.LittleEndian.AppendUint32(buf, 0)
+		buf = binary.LittleEndian.AppendUint32(buf, 70000)
+		doc := &Document{BufferViews: []BufferView{{Buffer: 0, ByteLength: len(buf)}}}
+		r := NewAccessorReader(doc, [][]byte{buf})
+		acc := &Accessor{BufferView: Index(0), ComponentType: ComponentUnsignedInt, Type: AccessorTypeScalar, Count: 2}
+		got, err := r.ReadUint32(acc)
+		if err != nil {
+			t.Fatalf("ReadUint32: %v", err)
+		}
+		if got[0] != 0 || got[1] != 70000 {
+			t.Errorf("got %v, want [0 70000]", got)
+		}
+	})
+
+	t.Run("vec4 weights", func(t *testing.T) {
+		var buf []byte
+		for _, v := range []float32{0.25, 0.25, 0.25, 0.25} {
+			buf = putFloat32(buf, v)
+		}
+		doc := &Document{BufferViews: []BufferView{{Buffer: 0, ByteLength: len(buf)}}}
+		r := NewAccessorReader(doc, [][]byte{buf})
+		acc := &Accessor{BufferView: Index(0), ComponentType: ComponentFloat, Type: AccessorTypeVec4, Count: 1}
+		got, err := r.ReadFloat32Vec4(acc)
+		if err != nil {
+			t.Fatalf("ReadFloat32Vec4: %v", err)
+		}
+		if got[0] != [4]float32{0.25, 0.25, 0.25, 0.25} {
+			t.Errorf("got %v, want (0.25,0.25,0.25,0.25)", got[0])
+		}
+	})
+
+	t.Run("wrong type rejected", func(t *testing.T) {
+		doc := &Document{}
+		r := NewAccessorReader(doc, nil)
+		acc := &Accessor{Type: AccessorTypeVec3, ComponentType: ComponentFloat}
+		if _, err := r.ReadFloat32(acc); err == nil {
+			t.Error("ReadFloat32 on a VEC3 accessor: got nil error, want non-nil")
+		}
+	})
+
+	t.Run("wrong componentType rejected", func(t *testing.T) {
+		doc := &Document{}
+		r := NewAccessorReader(doc, nil)
+		acc := &Accessor{Type: AccessorTypeScalar, ComponentType: ComponentFloat}
+		if _, err := r.ReadUint32(acc); err == nil {
+			t.Error("ReadUint32 on a FLOAT accessor: got nil error, want non-nil")
+		}
+	})
+}
+
+// TestAccessorReaderSparseWithNilBufferView verifies a sparse accessor
+// with no BufferView materializes an all-zero dense base and then
+// overwrites only the sparse-named indices, per spec.
+func TestAccessorReaderSparseWithNilBufferView(t *testing.T) {
+	// Sparse indices: positions 1 and 3 (as UNSIGNED_SHORT).
+	var idxBuf []byte
+	idxBuf = binary.LittleEndian.AppendUint16(idxBuf, 1)
+	idxBuf = binary.LittleEndian.AppendUint16(idxBuf, 3)
+
+	// Sparse values: VEC3 float32 replacements for those two indices.
+	var valBuf []byte
+	valBuf = putFloat32(valBuf, 1)
+	valBuf = putFloat32(valBuf, 1)
+	valBuf = putFloat32(valBuf, 1)
+	valBuf = putFloat32(valBuf, 2)
+	valBuf = putFloat32(valBuf, 2)
+	valBuf = putFloat32(valBuf, 2)
+
+	doc := &Document{
+		BufferViews: []BufferView{
+			{Buffer: 0, ByteOffset: 0, ByteLength: len(idxBuf)},
+			{Buffer: 1, ByteOffset: 0, ByteLength: len(valBuf)},
+		},
+	}
+	r := NewAccessorReader(doc, [][]byte{idxBuf, valBuf})
+
+	acc := &Accessor{
+		ComponentType: ComponentFloat,
+		Type:          AccessorTypeVec3,
+		Count:         4,
+		Sparse: &AccessorSparse{
+			Count:   2,
+			Indices: AccessorSparseIndices{BufferView: 0, ComponentType: ComponentUnsignedShort},
+			Values:  AccessorSparseValues{BufferView: 1},
+		},
+	}
+
+	got, err := r.ReadFloat32Vec3(acc)
+	if err != nil {
+		t.Fatalf("ReadFloat32Vec3: %v", err)
+	}
+	want := [][3]float32{{0, 0, 0}, {1, 1, 1}, {0, 0, 0}, {2, 2, 2}}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("got[%d] = %v, want %v", i, got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,922 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+
+// Package gltf reads and writes glTF 2.0 (https://www.khronos.org/gltf/)
+// scene files, both the text+binary form (a ".gltf" JSON document plus a
+// sidecar ".bin" buffer) and the single-file binary form (".glb"). It
+// plays the same role for phys.Scene that the obj package plays for
+// Wavefront OBJ: obj parses a foreign format into its own structured
+// representation, and phys.ConvertObjectToNodes bridges that into a
+// Scene; Export and Import here do the equivalent for glTF, letting
+// scenes be authored in Blender or three.js instead of as hand-written
+// Go literals.
+package gltf
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+)
+
+// ComponentType is one of glTF's accessor.componentType enums, naming the
+// scalar type backing one vertex attribute or index value. It marshals
+// and unmarshals as the bare spec integer (e.g. 5126), not a name, and
+// UnmarshalJSON rejects any value outside the spec's enum so a malformed
+// or hand-edited asset fails fast instead of silently misreading data.
+type ComponentType int
+
+const (
+	ComponentByte          ComponentType = 5120
+	ComponentUnsignedByte  ComponentType = 5121
+	ComponentShort         ComponentType = 5122
+	ComponentUnsignedShort ComponentType = 5123
+	ComponentUnsignedInt   ComponentType = 5125
+	ComponentFloat         ComponentType = 5126
+)
+
+func (c ComponentType) MarshalJSON() ([]byte, error) {
+	if !c.valid() {
+		return nil, fmt.Errorf("gltf: invalid ComponentType %d", int(c))
+	}
+	return json.Marshal(int(c))
+}
+
+func (c *ComponentType) UnmarshalJSON(data []byte) error {
+	var v int
+	if err := json.Unmarshal(data, &v); err != nil {
+		return fmt.Errorf("gltf: ComponentType: %w", err)
+	}
+	*c = ComponentType(v)
+	if !c.valid() {
+		return fmt.Errorf("gltf: invalid ComponentType %d", v)
+	}
+	return nil
+}
+
+func (c ComponentType) valid() bool {
+	switch c {
+	case ComponentByte, ComponentUnsignedByte, ComponentShort, ComponentUnsignedShort, ComponentUnsignedInt, ComponentFloat:
+		return true
+	}
+	return false
+}
+
+// BufferTarget is one of glTF's bufferView.target enums, hinting at how
+// a GPU would bind the referenced bytes.
+type BufferTarget int
+
+const (
+	TargetArrayBuffer        BufferTarget = 34962
+	TargetElementArrayBuffer BufferTarget = 34963
+)
+
+func (t BufferTarget) MarshalJSON() ([]byte, error) {
+	if !t.valid() {
+		return nil, fmt.Errorf("gltf: invalid BufferTarget %d", int(t))
+	}
+	return json.Marshal(int(t))
+}
+
+func (t *BufferTarget) UnmarshalJSON(data []byte) error {
+	var v int
+	if err := json.Unmarshal(data, &v); err != nil {
+		return fmt.Errorf("gltf: BufferTarget: %w", err)
+	}
+	*t = BufferTarget(v)
+	if !t.valid() {
+		return fmt.Errorf("gltf: invalid BufferTarget %d", v)
+	}
+	return nil
+}
+
+func (t BufferTarget) valid() bool {
+	switch t {
+	case TargetArrayBuffer, TargetElementArrayBuffer:
+		return true
+	}
+	return false
+}
+
+// PrimitiveMode is one of glTF's mesh.primitive.mode enums, naming the
+// topology indices should be assembled into. This package's Export only
+// ever emits PrimitiveModeTriangles; Import accepts any mode but errors
+// on anything other than Triangles (see convertPrimitiveToMesh).
+type PrimitiveMode int
+
+const (
+	PrimitiveModePoints        PrimitiveMode = 0
+	PrimitiveModeLines         PrimitiveMode = 1
+	PrimitiveModeLineLoop      PrimitiveMode = 2
+	PrimitiveModeLineStrip     PrimitiveMode = 3
+	PrimitiveModeTriangles     PrimitiveMode = 4
+	PrimitiveModeTriangleStrip PrimitiveMode = 5
+	PrimitiveModeTriangleFan   PrimitiveMode = 6
+)
+
+func (m PrimitiveMode) MarshalJSON() ([]byte, error) {
+	if !m.valid() {
+		return nil, fmt.Errorf("gltf: invalid PrimitiveMode %d", int(m))
+	}
+	return json.Marshal(int(m))
+}
+
+func (m *PrimitiveMode) UnmarshalJSON(data []byte) error {
+	var v int
+	if err := json.Unmarshal(data, &v); err != nil {
+		return fmt.Errorf("gltf: PrimitiveMode: %w", err)
+	}
+	*m = PrimitiveMode(v)
+	if !m.valid() {
+		return fmt.Errorf("gltf: invalid PrimitiveMode %d", v)
+	}
+	return nil
+}
+
+func (m PrimitiveMode) valid() bool {
+	return m >= PrimitiveModePoints && m <= PrimitiveModeTriangleFan
+}
+
+// AccessorType is one of glTF's accessor.type enums, naming the shape
+// (scalar, vector, or matrix) of each element an Accessor describes.
+type AccessorType string
+
+const (
+	AccessorTypeScalar AccessorType = "SCALAR"
+	AccessorTypeVec2   AccessorType = "VEC2"
+	AccessorTypeVec3   AccessorType = "VEC3"
+	AccessorTypeVec4   AccessorType = "VEC4"
+	AccessorTypeMat2   AccessorType = "MAT2"
+	AccessorTypeMat3   AccessorType = "MAT3"
+	AccessorTypeMat4   AccessorType = "MAT4"
+)
+
+func (t AccessorType) MarshalJSON() ([]byte, error) {
+	if !t.valid() {
+		return nil, fmt.Errorf("gltf: invalid AccessorType %q", string(t))
+	}
+	return json.Marshal(string(t))
+}
+
+func (t *AccessorType) UnmarshalJSON(data []byte) error {
+	var v string
+	if err := json.Unmarshal(data, &v); err != nil {
+		return fmt.Errorf("gltf: AccessorType: %w", err)
+	}
+	*t = AccessorType(v)
+	if !t.valid() {
+		return fmt.Errorf("gltf: invalid AccessorType %q", v)
+	}
+	return nil
+}
+
+func (t AccessorType) valid() bool {
+	switch t {
+	case AccessorTypeScalar, AccessorTypeVec2, AccessorTypeVec3, AccessorTypeVec4, AccessorTypeMat2, AccessorTypeMat3, AccessorTypeMat4:
+		return true
+	}
+	return false
+}
+
+// MagFilter is one of glTF's sampler.magFilter enums.
+type MagFilter int
+
+const (
+	MagFilterNearest MagFilter = 9728
+	MagFilterLinear  MagFilter = 9729
+)
+
+func (f MagFilter) MarshalJSON() ([]byte, error) {
+	if !f.valid() {
+		return nil, fmt.Errorf("gltf: invalid MagFilter %d", int(f))
+	}
+	return json.Marshal(int(f))
+}
+
+func (f *MagFilter) UnmarshalJSON(data []byte) error {
+	var v int
+	if err := json.Unmarshal(data, &v); err != nil {
+		return fmt.Errorf("gltf: MagFilter: %w", err)
+	}
+	*f = MagFilter(v)
+	if !f.valid() {
+		return fmt.Errorf("gltf: invalid MagFilter %d", v)
+	}
+	return nil
+}
+
+func (f MagFilter) valid() bool {
+	switch f {
+	case MagFilterNearest, MagFilterLinear:
+		return true
+	}
+	return false
+}
+
+// MinFilter is one of glTF's sampler.minFilter enums. Unlike MagFilter,
+// it also allows the four mipmap variants.
+type MinFilter int
+
+const (
+	MinFilterNearest              MinFilter = 9728
+	MinFilterLinear               MinFilter = 9729
+	MinFilterNearestMipmapNearest MinFilter = 9984
+	MinFilterLinearMipmapNearest  MinFilter = 9985
+	MinFilterNearestMipmapLinear  MinFilter = 9986
+	MinFilterLinearMipmapLinear   MinFilter = 9987
+)
+
+func (f MinFilter) MarshalJSON() ([]byte, error) {
+	if !f.valid() {
+		return nil, fmt.Errorf("gltf: invalid MinFilter %d", int(f))
+	}
+	return json.Marshal(int(f))
+}
+
+func (f *MinFilter) UnmarshalJSON(data []byte) error {
+	var v int
+	if err := json.Unmarshal(data, &v); err != nil {
+		return fmt.Errorf("gltf: MinFilter: %w", err)
+	}
+	*f = MinFilter(v)
+	if !f.valid() {
+		return fmt.Errorf("gltf: invalid MinFilter %d", v)
+	}
+	return nil
+}
+
+func (f MinFilter) valid() bool {
+	switch f {
+	case MinFilterNearest, MinFilterLinear, MinFilterNearestMipmapNearest, MinFilterLinearMipmapNearest, MinFilterNearestMipmapLinear, MinFilterLinearMipmapLinear:
+		return true
+	}
+	return false
+}
+
+// WrappingMode is one of glTF's sampler.wrapS/wrapT enums.
+type WrappingMode int
+
+const (
+	WrapClampToEdge    WrappingMode = 33071
+	WrapMirroredRepeat WrappingMode = 33648
+	WrapRepeat         WrappingMode = 10497
+)
+
+func (m WrappingMode) MarshalJSON() ([]byte, error) {
+	if !m.valid() {
+		return nil, fmt.Errorf("gltf: invalid WrappingMode %d", int(m))
+	}
+	return json.Marshal(int(m))
+}
+
+func (m *WrappingMode) UnmarshalJSON(data []byte) error {
+	var v int
+	if err := json.Unmarshal(data, &v); err != nil {
+		return fmt.Errorf("gltf: WrappingMode: %w", err)
+	}
+	*m = WrappingMode(v)
+	if !m.valid() {
+		return fmt.Errorf("gltf: invalid WrappingMode %d", v)
+	}
+	return nil
+}
+
+func (m WrappingMode) valid() bool {
+	switch m {
+	case WrapClampToEdge, WrapMirroredRepeat, WrapRepeat:
+		return true
+	}
+	return false
+}
+
+// AlphaMode is one of glTF's material.alphaMode enums, naming how a
+// Material's alpha channel participates in rendering. The zero value's
+// empty string marshals as an absent field, matching the spec's default
+// of AlphaModeOpaque.
+type AlphaMode string
+
+const (
+	AlphaModeOpaque AlphaMode = "OPAQUE"
+	AlphaModeMask   AlphaMode = "MASK"
+	AlphaModeBlend  AlphaMode = "BLEND"
+)
+
+func (m AlphaMode) MarshalJSON() ([]byte, error) {
+	if !m.valid() {
+		return nil, fmt.Errorf("gltf: invalid AlphaMode %q", string(m))
+	}
+	return json.Marshal(string(m))
+}
+
+func (m *AlphaMode) UnmarshalJSON(data []byte) error {
+	var v string
+	if err := json.Unmarshal(data, &v); err != nil {
+		return fmt.Errorf("gltf: AlphaMode: %w", err)
+	}
+	*m = AlphaMode(v)
+	if !m.valid() {
+		return fmt.Errorf("gltf: invalid AlphaMode %q", v)
+	}
+	return nil
+}
+
+func (m AlphaMode) valid() bool {
+	switch m {
+	case AlphaModeOpaque, AlphaModeMask, AlphaModeBlend:
+		return true
+	}
+	return false
+}
+
+// Index and Float fill an *uint32 or *float64 field in-line, for the
+// many optional pointer fields in this package (Accessor.BufferView,
+// Node.Mesh, PBRMetallicRoughness.MetallicFactor, ...) that otherwise
+// need a local variable to take the address of.
+func Index(v uint32) *uint32   { return &v }
+func Float(v float64) *float64 { return &v }
+
+// AnimationInterpolation and TargetPath, glTF's animation.sampler.
+// interpolation and animation.channel.target.path enums, are
+// deliberately not modeled here: this package has no Animation,
+// AnimationChannel, or AnimationSampler type for them to live on, and
+// Import/Export never touch animations, so the enums would have no
+// caller.
+
+// Asset is the required glTF top-level "asset" object.
+type Asset struct {
+	Version    string `json:"version"`
+	MinVersion string `json:"minVersion,omitempty"`
+	Generator  string `json:"generator,omitempty"`
+}
+
+// Document is the root of a glTF JSON document: everything the spec
+// calls a "glTF object". Every index field (Mesh, Camera, Material,
+// Source, Sampler, BufferView, Indices, ...) is an *uint32 rather than a
+// plain int or a sentinel like -1, so "this node has no camera" or "this
+// accessor has no bufferView" round-trips as a genuinely absent JSON key
+// (omitempty on a nil pointer) instead of an ambiguous magic number.
+type Document struct {
+	Asset       Asset        `json:"asset"`
+	Scene       *uint32      `json:"scene,omitempty"`
+	Scenes      []Scene      `json:"scenes,omitempty"`
+	Nodes       []Node       `json:"nodes,omitempty"`
+	Meshes      []Mesh       `json:"meshes,omitempty"`
+	Accessors   []Accessor   `json:"accessors,omitempty"`
+	BufferViews []BufferView `json:"bufferViews,omitempty"`
+	Buffers     []Buffer     `json:"buffers,omitempty"`
+	Materials   []Material   `json:"materials,omitempty"`
+	Textures    []Texture    `json:"textures,omitempty"`
+	Images      []Image      `json:"images,omitempty"`
+	Samplers    []Sampler    `json:"samplers,omitempty"`
+	Cameras     []Camera     `json:"cameras,omitempty"`
+
+	ExtensionsUsed     []string            `json:"extensionsUsed,omitempty"`
+	ExtensionsRequired []string            `json:"extensionsRequired,omitempty"`
+	Extensions         *DocumentExtensions `json:"extensions,omitempty"`
+}
+
+// extKHRLightsPunctual is the one extension this package understands,
+// named per the glTF extension registry.
+const extKHRLightsPunctual = "KHR_lights_punctual"
+
+// There is deliberately no runtime RegisterExtension(name, factory)
+// registry: every extension this package knows about (above, and
+// MaterialExtensions/TextureRefExtensions below) is a named Go field
+// decoded by the ordinary encoding/json struct path, the same choice
+// DocumentExtensions/NodeExtensions already made over a generic
+// map[string]interface{} passthrough. A name-keyed registry would let a
+// caller plug in an extension this package has never heard of, but
+// nothing in this package -- Export, Import, or Validate -- can act on
+// an extension it doesn't have a concrete type for, so that flexibility
+// would have no caller; adding KHR_materials_unlit/ior/transmission and
+// KHR_texture_transform as typed fields, as below, covers what Validate
+// and a hand-authored asset actually need today.
+
+// DocumentExtensions holds the top-level object of every root extension
+// this package reads or writes. Unlike a generic map[string]interface{}
+// passthrough, every extension is typed like everything else in this
+// file -- KHR_lights_punctual here, KHR_materials_* on MaterialExtensions,
+// and KHR_texture_transform on TextureRefExtensions.
+type DocumentExtensions struct {
+	KHRLightsPunctual *KHRLightsPunctual `json:"KHR_lights_punctual,omitempty"`
+}
+
+// KHRLightsPunctual is the KHR_lights_punctual extension's root object: a
+// flat array of lights, referenced by index from a NodeExtensions.
+type KHRLightsPunctual struct {
+	Lights []PunctualLight `json:"lights"`
+}
+
+// PunctualLight is one point, spot, or directional light, per the
+// KHR_lights_punctual spec, matching phys.PointLight/phys.SpotLight/
+// phys.Sun respectively (see convertLight and importLight).
+type PunctualLight struct {
+	Name      string             `json:"name,omitempty"`
+	Type      string             `json:"type"` // "point", "spot", or "directional".
+	Color     [3]float64         `json:"color,omitempty"`
+	Intensity *float64           `json:"intensity,omitempty"`
+	Spot      *PunctualLightSpot `json:"spot,omitempty"`
+}
+
+// PunctualLightSpot holds the cone angles of a "spot"-type PunctualLight,
+// mirroring phys.SpotLight's InnerConeAngle/OuterConeAngle. OuterConeAngle
+// is a pointer so a present-but-zero value (Export always sets one) can be
+// told apart from a genuinely absent field (an externally authored asset
+// that relies on the spec's pi/4 default) -- a plain float64 with
+// omitempty couldn't make that distinction.
+type PunctualLightSpot struct {
+	InnerConeAngle float64  `json:"innerConeAngle,omitempty"`
+	OuterConeAngle *float64 `json:"outerConeAngle,omitempty"`
+}
+
+// Scene lists the root Nodes (by index) belonging to one glTF scene.
+type Scene struct {
+	Nodes []uint32 `json:"nodes,omitempty"`
+}
+
+// Node is one entry of the glTF scene graph: a TRS transform plus an
+// optional reference to the Mesh, Camera, or KHR_lights_punctual light it
+// instantiates, and optional Children for hierarchical scenes. phys.Scene
+// is flat (Scene.Node has no parent/child relationship), so Export never
+// populates Children; Import instead bakes each node's world transform by
+// composing it down from the scene root through every ancestor's TRS (see
+// importNodeRecursive in scene.go) before handing it to a flat phys.Node,
+// phys.Camera, or phys.Light -- so a nested glTF node still lands in the
+// right place even though the phys.Scene it lands in has no hierarchy of
+// its own.
+// Node's Matrix, when present, replaces Translation/Rotation/Scale
+// wholesale; the spec forbids a Node from specifying both (see Validate).
+// Export never populates it (decomposeTRS always yields TRS), but Import
+// and Validate must still account for a hand-authored or Blender-exported
+// asset that does.
+type Node struct {
+	Name        string       `json:"name,omitempty"`
+	Children    []uint32     `json:"children,omitempty"`
+	Mesh        *uint32      `json:"mesh,omitempty"`
+	Camera      *uint32      `json:"camera,omitempty"`
+	Matrix      *[16]float64 `json:"matrix,omitempty"`
+	Translation [3]float64   `json:"translation,omitempty"`
+	Rotation    [4]float64   `json:"rotation,omitempty"`
+	Scale       [3]float64   `json:"scale,omitempty"`
+
+	Extensions *NodeExtensions `json:"extensions,omitempty"`
+}
+
+// NodeExtensions holds the per-node half of KHR_lights_punctual: a Node
+// that carries a light references one entry of
+// Document.Extensions.KHRLightsPunctual.Lights by index.
+type NodeExtensions struct {
+	KHRLightsPunctual *NodeLight `json:"KHR_lights_punctual,omitempty"`
+}
+
+// NodeLight is the value of a Node's "KHR_lights_punctual" extension.
+type NodeLight struct {
+	Light uint32 `json:"light"`
+}
+
+// Mesh holds one or more Primitives, each an independently-materialed
+// batch of triangles. phys.Mesh has only one material for the whole
+// mesh (Node.Material applies to every Face), so Export always emits a
+// Mesh with exactly one Primitive.
+type Mesh struct {
+	Name       string      `json:"name,omitempty"`
+	Primitives []Primitive `json:"primitives"`
+}
+
+// Primitive is one indexed triangle batch: Attributes maps a glTF
+// attribute semantic ("POSITION", "NORMAL", "TEXCOORD_0") to the
+// Accessor index holding that attribute's data.
+type Primitive struct {
+	Attributes map[string]uint32 `json:"attributes"`
+	Indices    *uint32           `json:"indices,omitempty"`
+	Material   *uint32           `json:"material,omitempty"`
+	Mode       *PrimitiveMode    `json:"mode,omitempty"`
+
+	// Targets holds one morph target per entry: a map from attribute
+	// semantic ("POSITION", "NORMAL") to the Accessor index holding that
+	// attribute's per-vertex displacement from Attributes' base value.
+	// importMeshNode reads only POSITION displacements, producing a
+	// phys.MorphedMesh instead of a phys.Mesh when Targets is non-empty.
+	Targets []map[string]uint32 `json:"targets,omitempty"`
+}
+
+// Accessor describes how to interpret a typed, strided run of bytes
+// inside a BufferView as vertex or index data.
+type Accessor struct {
+	BufferView    *uint32         `json:"bufferView,omitempty"`
+	ByteOffset    int             `json:"byteOffset,omitempty"`
+	ComponentType ComponentType   `json:"componentType"`
+	Normalized    bool            `json:"normalized,omitempty"`
+	Count         int             `json:"count"`
+	Type          AccessorType    `json:"type"`
+	Max           []float64       `json:"max,omitempty"`
+	Min           []float64       `json:"min,omitempty"`
+	Sparse        *AccessorSparse `json:"sparse,omitempty"`
+}
+
+// AccessorSparse overrides Count elements of an Accessor's regular data
+// (or of an implied all-zero buffer, if the Accessor has no BufferView)
+// with the Indices/Values named here. Export never emits one; Validate
+// checks Count against both sub-views' declared sizes for an asset that
+// does.
+type AccessorSparse struct {
+	Count   int                   `json:"count"`
+	Indices AccessorSparseIndices `json:"indices"`
+	Values  AccessorSparseValues  `json:"values"`
+}
+
+// AccessorSparseIndices names the BufferView holding the indices of the
+// elements an AccessorSparse overrides, and their ComponentType (always
+// one of the unsigned integer types).
+type AccessorSparseIndices struct {
+	BufferView    uint32        `json:"bufferView"`
+	ByteOffset    int           `json:"byteOffset,omitempty"`
+	ComponentType ComponentType `json:"componentType"`
+}
+
+// AccessorSparseValues names the BufferView holding the replacement
+// values for an AccessorSparse's overridden elements.
+type AccessorSparseValues struct {
+	BufferView uint32 `json:"bufferView"`
+	ByteOffset int    `json:"byteOffset,omitempty"`
+}
+
+// BufferView is a byte range within a Buffer.
+type BufferView struct {
+	Buffer     uint32       `json:"buffer"`
+	ByteOffset int          `json:"byteOffset,omitempty"`
+	ByteLength int          `json:"byteLength"`
+	ByteStride int          `json:"byteStride,omitempty"`
+	Target     BufferTarget `json:"target,omitempty"`
+}
+
+// Buffer describes one block of binary data. Export always emits exactly
+// one Buffer: for ".glb" it is the file's BIN chunk (URI omitted); for
+// ".gltf"+".bin" it is the sidecar file, named by URI.
+type Buffer struct {
+	URI        string `json:"uri,omitempty"`
+	ByteLength int    `json:"byteLength"`
+}
+
+// Material mirrors glTF's metallic-roughness material model, the same
+// model phys.PBR implements, which is why Export maps PBR directly and
+// Lambertian/Emitter approximately (see scene.go).
+type Material struct {
+	Name                 string                `json:"name,omitempty"`
+	PBRMetallicRoughness *PBRMetallicRoughness `json:"pbrMetallicRoughness,omitempty"`
+	NormalTexture        *TextureRef           `json:"normalTexture,omitempty"`
+	EmissiveFactor       [3]float64            `json:"emissiveFactor,omitempty"`
+	EmissiveTexture      *TextureRef           `json:"emissiveTexture,omitempty"`
+	AlphaMode            AlphaMode             `json:"alphaMode,omitempty"`
+	AlphaCutoff          *float64              `json:"alphaCutoff,omitempty"`
+
+	Extensions *MaterialExtensions `json:"extensions,omitempty"`
+}
+
+// MaterialExtensions holds the subset of the KHR_materials_* family this
+// package understands, typed like every other extension here rather than
+// left as a map[string]interface{} passthrough: Export/Import never
+// touch these (phys has no unlit/transmission/IOR material model to map
+// them to or from), but a hand-authored or Blender-exported asset that
+// carries them round-trips through ParseFS/EncodeGLTF/EncodeGLB without
+// losing the extension's fields to an opaque blob.
+type MaterialExtensions struct {
+	KHRMaterialsUnlit        *KHRMaterialsUnlit        `json:"KHR_materials_unlit,omitempty"`
+	KHRMaterialsIOR          *KHRMaterialsIOR          `json:"KHR_materials_ior,omitempty"`
+	KHRMaterialsTransmission *KHRMaterialsTransmission `json:"KHR_materials_transmission,omitempty"`
+}
+
+// KHRMaterialsUnlit is the KHR_materials_unlit extension: an empty
+// object whose mere presence flags a Material as unlit (baseColorFactor/
+// baseColorTexture only, no lighting response).
+type KHRMaterialsUnlit struct{}
+
+// KHRMaterialsIOR is the KHR_materials_ior extension's root object.
+type KHRMaterialsIOR struct {
+	IOR *float64 `json:"ior,omitempty"`
+}
+
+// KHRMaterialsTransmission is the KHR_materials_transmission extension's
+// root object.
+type KHRMaterialsTransmission struct {
+	TransmissionFactor  float64     `json:"transmissionFactor,omitempty"`
+	TransmissionTexture *TextureRef `json:"transmissionTexture,omitempty"`
+}
+
+// PBRMetallicRoughness holds the base color and metallic/roughness
+// factors and textures of a Material.
+type PBRMetallicRoughness struct {
+	BaseColorFactor  [4]float64  `json:"baseColorFactor,omitempty"`
+	BaseColorTexture *TextureRef `json:"baseColorTexture,omitempty"`
+	MetallicFactor   *float64    `json:"metallicFactor,omitempty"`
+	RoughnessFactor  *float64    `json:"roughnessFactor,omitempty"`
+}
+
+// TextureRef points at one entry of Document.Textures.
+type TextureRef struct {
+	Index uint32 `json:"index"`
+
+	Extensions *TextureRefExtensions `json:"extensions,omitempty"`
+}
+
+// TextureRefExtensions holds the one KHR_texture_transform extension
+// this package understands on a TextureRef.
+type TextureRefExtensions struct {
+	KHRTextureTransform *KHRTextureTransform `json:"KHR_texture_transform,omitempty"`
+}
+
+// KHRTextureTransform is the KHR_texture_transform extension's root
+// object: an additional offset/rotation/scale applied to a texture's UV
+// coordinates, independent of the mesh's own TEXCOORD_0 accessor.
+type KHRTextureTransform struct {
+	Offset   [2]float64 `json:"offset,omitempty"`
+	Rotation float64    `json:"rotation,omitempty"`
+	Scale    [2]float64 `json:"scale,omitempty"`
+}
+
+// Texture pairs an Image (Source) with a sampling Sampler.
+type Texture struct {
+	Source  *uint32 `json:"source,omitempty"`
+	Sampler *uint32 `json:"sampler,omitempty"`
+}
+
+// Image is a texture's pixel data, either embedded in a BufferView (the
+// form this package always writes) or referenced by URI (a form Import
+// also understands, for decoding hand-authored glTF files that reference
+// external image files).
+type Image struct {
+	URI        string  `json:"uri,omitempty"`
+	MimeType   string  `json:"mimeType,omitempty"`
+	BufferView *uint32 `json:"bufferView,omitempty"`
+}
+
+// Sampler describes texture filtering and wrap behavior.
+type Sampler struct {
+	MagFilter MagFilter    `json:"magFilter,omitempty"`
+	MinFilter MinFilter    `json:"minFilter,omitempty"`
+	WrapS     WrappingMode `json:"wrapS,omitempty"`
+	WrapT     WrappingMode `json:"wrapT,omitempty"`
+}
+
+// Camera is either a Perspective or an Orthographic projection.
+type Camera struct {
+	Name         string        `json:"name,omitempty"`
+	Type         string        `json:"type"` // "perspective" or "orthographic".
+	Perspective  *Perspective  `json:"perspective,omitempty"`
+	Orthographic *Orthographic `json:"orthographic,omitempty"`
+}
+
+// Perspective is glTF's perspective camera projection.
+type Perspective struct {
+	AspectRatio float64 `json:"aspectRatio,omitempty"`
+	Yfov        float64 `json:"yfov"`
+	Zfar        float64 `json:"zfar,omitempty"`
+	Znear       float64 `json:"znear"`
+}
+
+// Orthographic is glTF's orthographic camera projection: Xmag and Ymag
+// are the half-width and half-height of the view volume in world units,
+// matching phys.OrthographicCamera's FOVWidth/2 and FOVHeight/2.
+type Orthographic struct {
+	Xmag  float64 `json:"xmag"`
+	Ymag  float64 `json:"ymag"`
+	Zfar  float64 `json:"zfar"`
+	Znear float64 `json:"znear"`
+}
+
+const (
+	glbMagic        = 0x46546C67 // "glTF"
+	glbVersion      = 2
+	glbChunkJSON    = 0x4E4F534A // "JSON"
+	glbChunkBinary  = 0x004E4942 // "BIN\x00"
+	glbHeaderLength = 12
+	glbChunkHeader  = 8
+)
+
+// EncodeGLTF writes doc as a standalone JSON document (no embedded
+// binary chunk); bin, if non-empty, should be written separately by the
+// caller as the ".bin" file doc.Buffers[0].URI names.
+func EncodeGLTF(w io.Writer, doc *Document) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("gltf.EncodeGLTF: %w", err)
+	}
+	return nil
+}
+
+// DecodeGLTF reads a standalone glTF JSON document (the ".gltf" half of
+// a ".gltf"+".bin" pair). The caller is responsible for reading
+// doc.Buffers[0].URI separately and passing its bytes to whatever
+// consumes the returned Document (see Import).
+func DecodeGLTF(r io.Reader) (*Document, error) {
+	var doc Document
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("gltf.DecodeGLTF: %w", err)
+	}
+	return &doc, nil
+}
+
+// EncodeGLB writes doc and bin together as a single ".glb" binary glTF
+// file: a 12-byte header, a JSON chunk, and (if bin is non-empty) a
+// padded BIN chunk, per the glTF 2.0 binary container spec.
+func EncodeGLB(w io.Writer, doc *Document, bin []byte) error {
+	body, err := json.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("gltf.EncodeGLB: marshal JSON chunk: %w", err)
+	}
+	jsonChunk := padChunk(body, ' ')
+	binChunk := padChunk(bin, 0)
+
+	total := glbHeaderLength + glbChunkHeader + len(jsonChunk)
+	if len(bin) > 0 {
+		total += glbChunkHeader + len(binChunk)
+	}
+
+	if err := binary.Write(w, binary.LittleEndian, uint32(glbMagic)); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.LittleEndian, uint32(glbVersion)); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.LittleEndian, uint32(total)); err != nil {
+		return err
+	}
+	if err := writeChunk(w, glbChunkJSON, jsonChunk); err != nil {
+		return err
+	}
+	if len(bin) > 0 {
+		if err := writeChunk(w, glbChunkBinary, binChunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeChunk(w io.Writer, chunkType uint32, data []byte) error {
+	if err := binary.Write(w, binary.LittleEndian, uint32(len(data))); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.LittleEndian, chunkType); err != nil {
+		return err
+	}
+	_, err := w.Write(data)
+	return err
+}
+
+// padChunk right-pads data to a 4-byte boundary with pad, as glTF's
+// binary container requires of every chunk.
+func padChunk(data []byte, pad byte) []byte {
+	rem := len(data) % 4
+	if rem == 0 {
+		return data
+	}
+	padded := make([]byte, len(data)+4-rem)
+	copy(padded, data)
+	for i := len(data); i < len(padded); i++ {
+		padded[i] = pad
+	}
+	return padded
+}
+
+// DecodeGLB reads a single-file ".glb" binary glTF document, returning
+// its Document and the raw bytes of its BIN chunk (nil if it has none).
+func DecodeGLB(r io.Reader) (*Document, []byte, error) {
+	var header [3]uint32
+	if err := binary.Read(r, binary.LittleEndian, &header); err != nil {
+		return nil, nil, fmt.Errorf("gltf.DecodeGLB: read header: %w", err)
+	}
+	if header[0] != glbMagic {
+		return nil, nil, fmt.Errorf("gltf.DecodeGLB: bad magic %#x, want %#x", header[0], uint32(glbMagic))
+	}
+	if header[1] != glbVersion {
+		return nil, nil, fmt.Errorf("gltf.DecodeGLB: unsupported version %d", header[1])
+	}
+
+	var doc *Document
+	var bin []byte
+	for {
+		var chunkHeader [2]uint32
+		if err := binary.Read(r, binary.LittleEndian, &chunkHeader); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, nil, fmt.Errorf("gltf.DecodeGLB: read chunk header: %w", err)
+		}
+		length, chunkType := chunkHeader[0], chunkHeader[1]
+		data := make([]byte, length)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return nil, nil, fmt.Errorf("gltf.DecodeGLB: read chunk body: %w", err)
+		}
+		switch chunkType {
+		case glbChunkJSON:
+			var d Document
+			if err := json.Unmarshal(data, &d); err != nil {
+				return nil, nil, fmt.Errorf("gltf.DecodeGLB: unmarshal JSON chunk: %w", err)
+			}
+			doc = &d
+		case glbChunkBinary:
+			bin = data
+		}
+	}
+	if doc == nil {
+		return nil, nil, fmt.Errorf("gltf.DecodeGLB: file has no JSON chunk")
+	}
+	return doc, bin, nil
+}
+
+// LoadGLB reads a ".glb" binary glTF file from a real filesystem path,
+// a thin os.Open wrapper around DecodeGLB for callers (command-line
+// tools, mainly) that have a path rather than an fs.FS; ParseFS is the
+// fs.FS-based entry point the rest of this package uses.
+func LoadGLB(p string) (*Document, []byte, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, nil, fmt.Errorf("gltf.LoadGLB: %w", err)
+	}
+	defer f.Close()
+	doc, bin, err := DecodeGLB(f)
+	if err != nil {
+		return nil, nil, fmt.Errorf("gltf.LoadGLB: %w", err)
+	}
+	return doc, bin, nil
+}
+
+// SaveGLB writes doc and bin together as a ".glb" file at a real
+// filesystem path, a thin os.Create wrapper around EncodeGLB.
+func (doc *Document) SaveGLB(p string, bin []byte) error {
+	f, err := os.Create(p)
+	if err != nil {
+		return fmt.Errorf("gltf.SaveGLB: %w", err)
+	}
+	if err := EncodeGLB(f, doc, bin); err != nil {
+		f.Close()
+		return fmt.Errorf("gltf.SaveGLB: %w", err)
+	}
+	return f.Close()
+}
+
+// ParseFS reads a glTF 2.0 asset named by path within fsys, mirroring
+// obj.ParseFS's role for OBJ: a single entry point that reads raw bytes
+// out of an fs.FS and returns a parsed, in-memory result, leaving the
+// phys.Scene bridge (Import, in scene.go) as a separate step. path's
+// extension picks the container: ".glb" is read as a self-contained
+// binary asset (via DecodeGLB); anything else is read as a standalone
+// ".gltf" JSON document (via DecodeGLTF), and if it names a Buffer with a
+// relative URI, that buffer is read from fsys too, resolved against
+// path's directory -- the same external-sidecar-file arrangement
+// obj.ParseFS resolves an OBJ's "mtllib"/texture references against. A
+// Buffer with a "data:" URI is decoded in place instead; either way the
+// returned []byte is the bytes every accessor in doc indexes into,
+// exactly as if it had come from a ".glb"'s BIN chunk.
+func ParseFS(fsys fs.FS, p string) (*Document, []byte, error) {
+	data, err := fs.ReadFile(fsys, p)
+	if err != nil {
+		return nil, nil, fmt.Errorf("gltf.ParseFS: %w", err)
+	}
+	if strings.EqualFold(path.Ext(p), ".glb") {
+		doc, bin, err := DecodeGLB(bytes.NewReader(data))
+		if err != nil {
+			return nil, nil, fmt.Errorf("gltf.ParseFS: %w", err)
+		}
+		return doc, bin, nil
+	}
+
+	doc, err := DecodeGLTF(bytes.NewReader(data))
+	if err != nil {
+		return nil, nil, fmt.Errorf("gltf.ParseFS: %w", err)
+	}
+	if len(doc.Buffers) == 0 || doc.Buffers[0].URI == "" {
+		return doc, nil, nil
+	}
+	bin, err := readBufferURI(fsys, p, doc.Buffers[0].URI)
+	if err != nil {
+		return nil, nil, fmt.Errorf("gltf.ParseFS: buffer 0: %w", err)
+	}
+	return doc, bin, nil
+}
+
+// readBufferURI resolves a Buffer.URI per the glTF spec: a "data:" URI
+// decodes to its embedded bytes directly, and any other URI is a
+// percent-encoded relative path read from fsys, alongside the ".gltf"
+// document at gltfPath.
+func readBufferURI(fsys fs.FS, gltfPath, uri string) ([]byte, error) {
+	if strings.HasPrefix(uri, "data:") {
+		_, encoded, ok := strings.Cut(uri, ",")
+		if !ok {
+			return nil, fmt.Errorf("malformed data URI")
+		}
+		return base64.StdEncoding.DecodeString(encoded)
+	}
+	rel, err := url.PathUnescape(uri)
+	if err != nil {
+		return nil, fmt.Errorf("unescape URI %q: %w", uri, err)
+	}
+	return fs.ReadFile(fsys, path.Join(path.Dir(gltfPath), rel))
+}
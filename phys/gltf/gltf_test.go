@@ -0,0 +1,414 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+
+package gltf
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"math"
+	"testing"
+	"testing/fstest"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/phys"
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
+)
+
+// TestParseFSExternalBuffer verifies ParseFS reads a ".gltf" document
+// plus a sidecar ".bin" buffer resolved relative to the document's own
+// directory within fsys, mirroring obj.ParseFS's handling of OBJ's
+// external .mtl sidecar files.
+func TestParseFSExternalBuffer(t *testing.T) {
+	scene := testScene(t)
+	doc, bin, err := Export(scene)
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	doc.Buffers[0].URI = "scene.bin"
+
+	var gltfBuf bytes.Buffer
+	if err := EncodeGLTF(&gltfBuf, doc); err != nil {
+		t.Fatalf("EncodeGLTF: %v", err)
+	}
+	fsys := fstest.MapFS{
+		"models/scene.gltf": &fstest.MapFile{Data: gltfBuf.Bytes()},
+		"models/scene.bin":  &fstest.MapFile{Data: bin},
+	}
+
+	gotDoc, gotBin, err := ParseFS(fsys, "models/scene.gltf")
+	if err != nil {
+		t.Fatalf("ParseFS: %v", err)
+	}
+	if !bytes.Equal(gotBin, bin) {
+		t.Fatalf("ParseFS returned %d bytes of buffer data, want %d bytes matching the sidecar .bin", len(gotBin), len(bin))
+	}
+
+	got, err := Import(gotDoc, gotBin)
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if len(got.Node) != 1 || got.Node[0].Name != "triangle" {
+		t.Fatalf("unexpected scene after ParseFS round trip: %+v", got)
+	}
+}
+
+// TestParseFSEmbeddedDataURI verifies ParseFS decodes a buffer embedded
+// directly in the .gltf document as a base64 "data:" URI, the form
+// glTF exporters use when they don't want to emit a sidecar file.
+func TestParseFSEmbeddedDataURI(t *testing.T) {
+	scene := testScene(t)
+	doc, bin, err := Export(scene)
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	doc.Buffers[0].URI = "data:application/octet-stream;base64," + base64.StdEncoding.EncodeToString(bin)
+
+	var gltfBuf bytes.Buffer
+	if err := EncodeGLTF(&gltfBuf, doc); err != nil {
+		t.Fatalf("EncodeGLTF: %v", err)
+	}
+	fsys := fstest.MapFS{"scene.gltf": &fstest.MapFile{Data: gltfBuf.Bytes()}}
+
+	_, gotBin, err := ParseFS(fsys, "scene.gltf")
+	if err != nil {
+		t.Fatalf("ParseFS: %v", err)
+	}
+	if !bytes.Equal(gotBin, bin) {
+		t.Fatalf("ParseFS decoded %d bytes from the data URI, want %d bytes", len(gotBin), len(bin))
+	}
+}
+
+// TestParseFSGLB verifies ParseFS recognizes the ".glb" extension and
+// decodes the single-file binary container instead of treating it as a
+// JSON document.
+func TestParseFSGLB(t *testing.T) {
+	scene := testScene(t)
+	doc, bin, err := Export(scene)
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	var glbBuf bytes.Buffer
+	if err := EncodeGLB(&glbBuf, doc, bin); err != nil {
+		t.Fatalf("EncodeGLB: %v", err)
+	}
+	fsys := fstest.MapFS{"model.glb": &fstest.MapFile{Data: glbBuf.Bytes()}}
+
+	gotDoc, gotBin, err := ParseFS(fsys, "model.glb")
+	if err != nil {
+		t.Fatalf("ParseFS: %v", err)
+	}
+	got, err := Import(gotDoc, gotBin)
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if len(got.Node) != 1 || got.Node[0].Name != "triangle" {
+		t.Fatalf("unexpected scene after ParseFS(.glb) round trip: %+v", got)
+	}
+}
+
+// TestSaveGLBLoadGLBRoundTrip verifies SaveGLB and LoadGLB, the
+// real-filesystem counterparts to EncodeGLB/DecodeGLB, round-trip a
+// document and its binary buffer through an actual ".glb" file.
+func TestSaveGLBLoadGLBRoundTrip(t *testing.T) {
+	scene := testScene(t)
+	doc, bin, err := Export(scene)
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+
+	p := t.TempDir() + "/model.glb"
+	if err := doc.SaveGLB(p, bin); err != nil {
+		t.Fatalf("SaveGLB: %v", err)
+	}
+
+	gotDoc, gotBin, err := LoadGLB(p)
+	if err != nil {
+		t.Fatalf("LoadGLB: %v", err)
+	}
+	// gotBin may carry a few trailing zero-pad bytes EncodeGLB added to
+	// round the BIN chunk up to a 4-byte boundary; only the prefix named
+	// by Buffer[0].ByteLength is meaningful data.
+	if !bytes.Equal(gotBin[:len(bin)], bin) {
+		t.Fatalf("LoadGLB returned buffer data %v, want it to start with %v (what SaveGLB wrote)", gotBin[:len(bin)], bin)
+	}
+	got, err := Import(gotDoc, gotBin)
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if len(got.Node) != 1 || got.Node[0].Name != "triangle" {
+		t.Fatalf("unexpected scene after SaveGLB/LoadGLB round trip: %+v", got)
+	}
+}
+
+// TestLoadGLBMissingFile verifies LoadGLB returns an error, rather than
+// panicking, when the named file does not exist.
+func TestLoadGLBMissingFile(t *testing.T) {
+	if _, _, err := LoadGLB(t.TempDir() + "/does-not-exist.glb"); err == nil {
+		t.Fatal("LoadGLB of a missing file: got nil error, want non-nil")
+	}
+}
+
+// TestEnumMarshalJSONPreservesWireFormat verifies the spec-integer and
+// spec-string enum types round-trip through their own numeric/string
+// wire representation rather than a Go-side name.
+func TestEnumMarshalJSONPreservesWireFormat(t *testing.T) {
+	gotInt, err := json.Marshal(ComponentFloat)
+	if err != nil {
+		t.Fatalf("Marshal(ComponentFloat): %v", err)
+	}
+	if string(gotInt) != "5126" {
+		t.Errorf("Marshal(ComponentFloat) = %s, want 5126", gotInt)
+	}
+
+	gotStr, err := json.Marshal(AccessorTypeVec3)
+	if err != nil {
+		t.Fatalf("Marshal(AccessorTypeVec3): %v", err)
+	}
+	if string(gotStr) != `"VEC3"` {
+		t.Errorf(`Marshal(AccessorTypeVec3) = %s, want "VEC3"`, gotStr)
+	}
+}
+
+// TestEnumUnmarshalJSONRejectsUnknownValue verifies each enum type's
+// UnmarshalJSON returns a descriptive error instead of silently accepting
+// a value outside the glTF spec's enum.
+func TestEnumUnmarshalJSONRejectsUnknownValue(t *testing.T) {
+	var ct ComponentType
+	if err := json.Unmarshal([]byte("9999"), &ct); err == nil {
+		t.Error("ComponentType.UnmarshalJSON(9999): got nil error, want non-nil")
+	}
+	var at AccessorType
+	if err := json.Unmarshal([]byte(`"VEC5"`), &at); err == nil {
+		t.Error(`AccessorType.UnmarshalJSON("VEC5"): got nil error, want non-nil`)
+	}
+	var am AlphaMode
+	if err := json.Unmarshal([]byte(`"OPAQU"`), &am); err == nil {
+		t.Error(`AlphaMode.UnmarshalJSON("OPAQU"): got nil error, want non-nil`)
+	}
+}
+
+// TestMaterialExtensionsJSONRoundTrip verifies KHR_materials_* and
+// KHR_texture_transform decode into their typed structs rather than a
+// generic map[string]interface{}, the way DocumentExtensions already
+// does for KHR_lights_punctual.
+func TestMaterialExtensionsJSONRoundTrip(t *testing.T) {
+	mat := Material{
+		Name: "glass",
+		NormalTexture: &TextureRef{
+			Index:      0,
+			Extensions: &TextureRefExtensions{KHRTextureTransform: &KHRTextureTransform{Offset: [2]float64{0.1, 0.2}, Scale: [2]float64{2, 2}}},
+		},
+		Extensions: &MaterialExtensions{
+			KHRMaterialsUnlit:        &KHRMaterialsUnlit{},
+			KHRMaterialsIOR:          &KHRMaterialsIOR{IOR: Float(1.5)},
+			KHRMaterialsTransmission: &KHRMaterialsTransmission{TransmissionFactor: 0.9},
+		},
+	}
+	data, err := json.Marshal(mat)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	var got Material
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if got.Extensions == nil || got.Extensions.KHRMaterialsUnlit == nil {
+		t.Error("KHR_materials_unlit did not round-trip")
+	}
+	if got.Extensions == nil || got.Extensions.KHRMaterialsIOR == nil || *got.Extensions.KHRMaterialsIOR.IOR != 1.5 {
+		t.Errorf("KHR_materials_ior did not round-trip: %+v", got.Extensions)
+	}
+	if got.Extensions == nil || got.Extensions.KHRMaterialsTransmission == nil || got.Extensions.KHRMaterialsTransmission.TransmissionFactor != 0.9 {
+		t.Errorf("KHR_materials_transmission did not round-trip: %+v", got.Extensions)
+	}
+	if got.NormalTexture == nil || got.NormalTexture.Extensions == nil || got.NormalTexture.Extensions.KHRTextureTransform == nil {
+		t.Fatal("KHR_texture_transform did not round-trip")
+	}
+	if got.NormalTexture.Extensions.KHRTextureTransform.Offset != [2]float64{0.1, 0.2} {
+		t.Errorf("KHRTextureTransform.Offset = %v, want (0.1, 0.2)", got.NormalTexture.Extensions.KHRTextureTransform.Offset)
+	}
+}
+
+// TestIndexFloatHelpers verifies Index and Float return a pointer to a
+// copy of their argument, so callers can fill an optional pointer field
+// in-line without a local variable.
+func TestIndexFloatHelpers(t *testing.T) {
+	i := Index(3)
+	if i == nil || *i != 3 {
+		t.Errorf("Index(3) = %v, want pointer to 3", i)
+	}
+	f := Float(1.5)
+	if f == nil || *f != 1.5 {
+		t.Errorf("Float(1.5) = %v, want pointer to 1.5", f)
+	}
+}
+
+// TestParseFSMissingExternalBuffer verifies ParseFS returns an error,
+// rather than panicking, when a .gltf document's buffer URI names a
+// sidecar file that isn't present in fsys.
+func TestParseFSMissingExternalBuffer(t *testing.T) {
+	scene := testScene(t)
+	doc, _, err := Export(scene)
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	doc.Buffers[0].URI = "missing.bin"
+
+	var gltfBuf bytes.Buffer
+	if err := EncodeGLTF(&gltfBuf, doc); err != nil {
+		t.Fatalf("EncodeGLTF: %v", err)
+	}
+	fsys := fstest.MapFS{"scene.gltf": &fstest.MapFile{Data: gltfBuf.Bytes()}}
+
+	if _, _, err := ParseFS(fsys, "scene.gltf"); err == nil {
+		t.Fatal("ParseFS: expected an error for a missing sidecar buffer, got nil")
+	}
+}
+
+// TestReadVec3AccessorOutOfBounds verifies the accessor decoder returns
+// an error instead of panicking when Count/ByteStride describe reads
+// past the end of the bufferView's backing buffer -- the malformed-input
+// case analogous to obj_test.go's TestParseOBJ_InvalidSyntax.
+func TestReadVec3AccessorOutOfBounds(t *testing.T) {
+	bufferViewIndex := uint32(0)
+	doc := &Document{
+		BufferViews: []BufferView{{ByteOffset: 0, ByteLength: 12}}, // room for exactly 1 vec3
+		Accessors: []Accessor{{
+			BufferView:    &bufferViewIndex,
+			ComponentType: ComponentFloat,
+			Type:          "VEC3",
+			Count:         2, // claims 2 vec3s, but the bufferView only holds 1
+		}},
+	}
+	bin := make([]byte, 12)
+	if _, err := readVec3Accessor(doc, bin, 0); err == nil {
+		t.Fatal("readVec3Accessor: expected an out-of-bounds error, got nil")
+	}
+}
+
+// TestReadVec3AccessorOutOfBoundsAcrossBufferViews verifies an accessor is
+// bounds-checked against its own bufferView's ByteLength, not merely
+// against however many bytes happen to remain in the whole binary
+// buffer -- two bufferViews packed back-to-back in one buffer must not
+// let an over-long accessor on the first one read into the second's data.
+func TestReadVec3AccessorOutOfBoundsAcrossBufferViews(t *testing.T) {
+	bufferViewIndex := uint32(0)
+	doc := &Document{
+		BufferViews: []BufferView{
+			{ByteOffset: 0, ByteLength: 12},  // room for exactly 1 vec3
+			{ByteOffset: 12, ByteLength: 12}, // a second, unrelated bufferView right after
+		},
+		Accessors: []Accessor{{
+			BufferView:    &bufferViewIndex,
+			ComponentType: ComponentFloat,
+			Type:          "VEC3",
+			Count:         2, // claims 2 vec3s, but bufferView 0 only holds 1
+		}},
+	}
+	bin := make([]byte, 24) // enough for both bufferViews combined
+	if _, err := readVec3Accessor(doc, bin, 0); err == nil {
+		t.Fatal("readVec3Accessor: expected an error for an accessor overrunning its own bufferView, got nil")
+	}
+}
+
+// TestReadVec3AccessorInterleavedStride verifies readVec3Accessor honors
+// a nonzero BufferView.ByteStride, correctly skipping over interleaved
+// attributes (e.g. a normal packed between consecutive positions) rather
+// than reading them as if the buffer were tightly packed.
+func TestReadVec3AccessorInterleavedStride(t *testing.T) {
+	// Two vertices, each POSITION (12 bytes) immediately followed by an
+	// interleaved NORMAL (12 bytes) this accessor should skip over.
+	bufferViewIndex := uint32(0)
+	bin := make([]byte, 48)
+	putFloat32 := func(off int, v float32) {
+		bits := math.Float32bits(v)
+		bin[off] = byte(bits)
+		bin[off+1] = byte(bits >> 8)
+		bin[off+2] = byte(bits >> 16)
+		bin[off+3] = byte(bits >> 24)
+	}
+	putFloat32(0, 1) // vertex 0 position.X
+	putFloat32(4, 2)
+	putFloat32(8, 3)
+	// bytes 12..23 are vertex 0's interleaved normal -- must be skipped.
+	putFloat32(24, 4) // vertex 1 position.X
+	putFloat32(28, 5)
+	putFloat32(32, 6)
+
+	doc := &Document{
+		BufferViews: []BufferView{{ByteOffset: 0, ByteLength: 48, ByteStride: 24}},
+		Accessors: []Accessor{{
+			BufferView:    &bufferViewIndex,
+			ComponentType: ComponentFloat,
+			Type:          "VEC3",
+			Count:         2,
+		}},
+	}
+
+	got, err := readVec3Accessor(doc, bin, 0)
+	if err != nil {
+		t.Fatalf("readVec3Accessor: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("got %d positions, want 2", len(got))
+	}
+	if got[0].X != 1 || got[0].Y != 2 || got[0].Z != 3 {
+		t.Errorf("position 0 = %+v, want (1,2,3)", got[0])
+	}
+	if got[1].X != 4 || got[1].Y != 5 || got[1].Z != 6 {
+		t.Errorf("position 1 = %+v, want (4,5,6)", got[1])
+	}
+}
+
+// BenchmarkParseFSLargeGLB loads a programmatically generated multi-MB
+// glTF scene (a grid of many small triangle meshes) through ParseFS and
+// Import, mirroring BenchmarkParseOBJ_LargeWithMaterials's approach of
+// generating the large dataset in Go rather than committing a binary
+// fixture.
+func BenchmarkParseFSLargeGLB(b *testing.B) {
+	const gridSize = 60 // 3600 one-triangle meshes, a few MB of JSON+binary.
+	nodes := make([]phys.Node, 0, gridSize*gridSize)
+	for i := 0; i < gridSize; i++ {
+		for j := 0; j < gridSize; j++ {
+			mesh, err := phys.NewMesh(triangleAt(float64(i), float64(j)))
+			if err != nil {
+				b.Fatalf("NewMesh: %v", err)
+			}
+			nodes = append(nodes, phys.Node{
+				Name:     "tri",
+				Shape:    mesh,
+				Material: phys.Lambertian{Texture: phys.TextureUniform{}},
+			})
+		}
+	}
+	scene := &phys.Scene{Node: nodes}
+	doc, bin, err := Export(scene)
+	if err != nil {
+		b.Fatalf("Export: %v", err)
+	}
+	var glbBuf bytes.Buffer
+	if err := EncodeGLB(&glbBuf, doc, bin); err != nil {
+		b.Fatalf("EncodeGLB: %v", err)
+	}
+	fsys := fstest.MapFS{"large.glb": &fstest.MapFile{Data: glbBuf.Bytes()}}
+	b.Logf("benchmark asset size: %d bytes", glbBuf.Len())
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		gotDoc, gotBin, err := ParseFS(fsys, "large.glb")
+		if err != nil {
+			b.Fatalf("ParseFS: %v", err)
+		}
+		if _, err := Import(gotDoc, gotBin); err != nil {
+			b.Fatalf("Import: %v", err)
+		}
+	}
+}
+
+func triangleAt(x, y float64) []phys.Face {
+	return []phys.Face{{Vertex: [3]phys.Vertex{
+		{Position: r3.Point{X: x, Y: y, Z: 0}},
+		{Position: r3.Point{X: x + 1, Y: y, Z: 0}},
+		{Position: r3.Point{X: x, Y: y + 1, Z: 0}},
+	}}}
+}
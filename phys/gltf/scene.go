@@ -0,0 +1,1162 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+
+package gltf
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/phys"
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r2"
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
+)
+
+// bakedTextureSize is the resolution Export samples a procedural
+// phys.Texture (anything but TextureUniform or TextureImage) at to
+// produce a glTF-compatible raster image. glTF has no notion of a
+// procedural texture, so this is a lossy bridge: it bakes whatever the
+// Texture looks like over one UV tile into a fixed-size PNG, the same
+// trade-off the Texture interface's own TODO anticipates.
+const bakedTextureSize = 64
+
+// builder accumulates the binary buffer and JSON-side arrays Export
+// fills in as it walks a Scene, so every exported accessor/image shares
+// one Buffer rather than one per mesh/texture.
+type builder struct {
+	doc *Document
+	bin []byte
+}
+
+func newBuilder() *builder {
+	return &builder{doc: &Document{Asset: Asset{Version: "2.0", Generator: "raytrace/phys/gltf"}}}
+}
+
+// addBufferView appends data to the shared binary buffer (4-byte aligned,
+// as glTF accessors require for non-byte component types) and returns
+// the new BufferView's index.
+func (b *builder) addBufferView(data []byte, target BufferTarget) uint32 {
+	for len(b.bin)%4 != 0 {
+		b.bin = append(b.bin, 0)
+	}
+	offset := len(b.bin)
+	b.bin = append(b.bin, data...)
+	b.doc.BufferViews = append(b.doc.BufferViews, BufferView{
+		Buffer:     0,
+		ByteOffset: offset,
+		ByteLength: len(data),
+		Target:     target,
+	})
+	return uint32(len(b.doc.BufferViews) - 1)
+}
+
+// Export converts scene into a glTF Document plus the binary blob its
+// accessors and images reference. Only Node.Shape values that are, or
+// are a chain of TransformedShape wrapping, a *phys.Mesh are supported;
+// other Shape types return an error naming the offending node, since
+// there's no general glTF primitive for e.g. an analytic Sphere.
+// phys.OrthographicCamera and phys.PinholeCamera are the only supported
+// Cameras. Materials are mapped per the phys.Lambertian/phys.PBR/
+// phys.Emitter cases in convertMaterial; any other Material type is also
+// an error.
+func Export(scene *phys.Scene) (*Document, []byte, error) {
+	b := newBuilder()
+	var rootNodes []uint32
+
+	for i, cam := range scene.Camera {
+		cameraIndex := uint32(len(b.doc.Cameras))
+		var translation [3]float64
+		var rotation [4]float64
+		switch cam := cam.(type) {
+		case phys.OrthographicCamera:
+			b.doc.Cameras = append(b.doc.Cameras, Camera{
+				Type: "orthographic",
+				Orthographic: &Orthographic{
+					Xmag:  float64(cam.FOVWidth) / 2,
+					Ymag:  float64(cam.FOVHeight) / 2,
+					Znear: 0.01,
+					Zfar:  1000,
+				},
+			})
+			translation, rotation = lookAtTRS(cam.LookFrom, cam.LookAt, cam.VUp)
+		case phys.PinholeCamera:
+			persp, err := pinholeCameraPerspective(cam)
+			if err != nil {
+				return nil, nil, fmt.Errorf("gltf.Export: camera %d: %w", i, err)
+			}
+			b.doc.Cameras = append(b.doc.Cameras, Camera{Type: "perspective", Perspective: persp})
+			center := cam.LowerLeftCorner.Add(cam.Horizontal.Divs(2)).Add(cam.Vertical.Divs(2))
+			translation, rotation = lookAtTRS(cam.Origin, center, cam.Vertical)
+		default:
+			return nil, nil, fmt.Errorf("gltf.Export: camera %d: unsupported Camera type %T (only phys.OrthographicCamera, phys.PinholeCamera)", i, cam)
+		}
+		nodeIndex := uint32(len(b.doc.Nodes))
+		b.doc.Nodes = append(b.doc.Nodes, Node{
+			Name:        fmt.Sprintf("camera%d", i),
+			Camera:      &cameraIndex,
+			Translation: translation,
+			Rotation:    rotation,
+			Scale:       [3]float64{1, 1, 1},
+		})
+		rootNodes = append(rootNodes, nodeIndex)
+	}
+
+	for i, node := range scene.Node {
+		mesh, transform, err := flattenMeshShape(node.Shape, node.Transform)
+		if err != nil {
+			return nil, nil, fmt.Errorf("gltf.Export: node %d (%q): %w", i, node.Name, err)
+		}
+		materialIndex, err := b.convertMaterial(node.Material)
+		if err != nil {
+			return nil, nil, fmt.Errorf("gltf.Export: node %d (%q): %w", i, node.Name, err)
+		}
+		meshIndex, err := b.convertMesh(mesh, materialIndex)
+		if err != nil {
+			return nil, nil, fmt.Errorf("gltf.Export: node %d (%q): %w", i, node.Name, err)
+		}
+		nodeIndex := uint32(len(b.doc.Nodes))
+		translation, rotation, scale := decomposeTRS(transform)
+		b.doc.Nodes = append(b.doc.Nodes, Node{
+			Name:        node.Name,
+			Mesh:        &meshIndex,
+			Translation: translation,
+			Rotation:    rotation,
+			Scale:       scale,
+		})
+		rootNodes = append(rootNodes, nodeIndex)
+	}
+
+	for i, light := range scene.Light {
+		lightIndex, lightWorld, err := b.convertLight(light)
+		if err != nil {
+			return nil, nil, fmt.Errorf("gltf.Export: light %d: %w", i, err)
+		}
+		nodeIndex := uint32(len(b.doc.Nodes))
+		translation, rotation, scale := decomposeTRS(lightWorld)
+		b.doc.Nodes = append(b.doc.Nodes, Node{
+			Name:        fmt.Sprintf("light%d", i),
+			Translation: translation,
+			Rotation:    rotation,
+			Scale:       scale,
+			Extensions:  &NodeExtensions{KHRLightsPunctual: &NodeLight{Light: lightIndex}},
+		})
+		rootNodes = append(rootNodes, nodeIndex)
+	}
+	if len(scene.Light) > 0 {
+		b.doc.ExtensionsUsed = append(b.doc.ExtensionsUsed, extKHRLightsPunctual)
+	}
+
+	b.doc.Buffers = append(b.doc.Buffers, Buffer{ByteLength: len(b.bin)})
+	sceneIndex := uint32(0)
+	b.doc.Scene = &sceneIndex
+	b.doc.Scenes = []Scene{{Nodes: rootNodes}}
+	return b.doc, b.bin, nil
+}
+
+// flattenMeshShape peels off any chain of TransformedShape wrappers
+// around shape, folding each one's Transform into base (outermost
+// wrapper applied last, matching TransformedShape.Collide's own
+// local-to-world order), until it reaches a *phys.Mesh or a shape this
+// package can't export.
+func flattenMeshShape(shape phys.Shape, base phys.Transform) (*phys.Mesh, phys.Transform, error) {
+	for {
+		switch s := shape.(type) {
+		case *phys.Mesh:
+			return s, base, nil
+		case phys.TransformedShape:
+			base = phys.Compose(s.Transform, base)
+			shape = s.Shape
+		default:
+			return nil, phys.Transform{}, fmt.Errorf("unsupported Shape type %T (only *phys.Mesh and phys.TransformedShape wrapping one)", shape)
+		}
+	}
+}
+
+// convertMesh appends mesh's faces as one flat (unwelded) Primitive: each
+// face contributes 3 fresh vertices rather than sharing vertices with its
+// neighbors, mirroring how phys.Face already stores a full independent
+// Vertex per corner instead of indexing into a shared vertex pool.
+// Indices use ComponentUnsignedShort per the glTF convention for compact
+// index buffers, so a single Primitive here is limited to 65536 corners.
+func (b *builder) convertMesh(mesh *phys.Mesh, materialIndex *uint32) (uint32, error) {
+	n := len(mesh.Face)
+	if n*3 > 65536 {
+		return 0, fmt.Errorf("mesh has %d faces (%d corners), exceeding the 65536-corner limit for UNSIGNED_SHORT indices", n, n*3)
+	}
+
+	positions := make([]float32, 0, n*3*3)
+	normals := make([]float32, 0, n*3*3)
+	uvs := make([]float32, 0, n*3*2)
+	indices := make([]uint16, 0, n*3)
+
+	posMin := [3]float64{math.Inf(1), math.Inf(1), math.Inf(1)}
+	posMax := [3]float64{math.Inf(-1), math.Inf(-1), math.Inf(-1)}
+
+	for _, face := range mesh.Face {
+		p0, p1, p2 := face.Vertex[0].Position, face.Vertex[1].Position, face.Vertex[2].Position
+		normal := p1.Sub(p0).Cross(p2.Sub(p0)).Unit()
+		for _, v := range face.Vertex {
+			positions = append(positions, float32(v.Position.X), float32(v.Position.Y), float32(v.Position.Z))
+			normals = append(normals, float32(normal.X), float32(normal.Y), float32(normal.Z))
+			uvs = append(uvs, float32(v.UV.X), float32(v.UV.Y))
+			posMin[0] = math.Min(posMin[0], v.Position.X)
+			posMin[1] = math.Min(posMin[1], v.Position.Y)
+			posMin[2] = math.Min(posMin[2], v.Position.Z)
+			posMax[0] = math.Max(posMax[0], v.Position.X)
+			posMax[1] = math.Max(posMax[1], v.Position.Y)
+			posMax[2] = math.Max(posMax[2], v.Position.Z)
+			indices = append(indices, uint16(len(indices)))
+		}
+	}
+
+	posView := b.addBufferView(float32sToBytes(positions), TargetArrayBuffer)
+	posAccessor := uint32(len(b.doc.Accessors))
+	b.doc.Accessors = append(b.doc.Accessors, Accessor{
+		BufferView: &posView, ComponentType: ComponentFloat, Count: n * 3, Type: AccessorTypeVec3,
+		Min: posMin[:], Max: posMax[:],
+	})
+
+	normView := b.addBufferView(float32sToBytes(normals), TargetArrayBuffer)
+	normAccessor := uint32(len(b.doc.Accessors))
+	b.doc.Accessors = append(b.doc.Accessors, Accessor{
+		BufferView: &normView, ComponentType: ComponentFloat, Count: n * 3, Type: AccessorTypeVec3,
+	})
+
+	uvView := b.addBufferView(float32sToBytes(uvs), TargetArrayBuffer)
+	uvAccessor := uint32(len(b.doc.Accessors))
+	b.doc.Accessors = append(b.doc.Accessors, Accessor{
+		BufferView: &uvView, ComponentType: ComponentFloat, Count: n * 3, Type: AccessorTypeVec2,
+	})
+
+	idxView := b.addBufferView(uint16sToBytes(indices), TargetElementArrayBuffer)
+	idxAccessor := uint32(len(b.doc.Accessors))
+	b.doc.Accessors = append(b.doc.Accessors, Accessor{
+		BufferView: &idxView, ComponentType: ComponentUnsignedShort, Count: len(indices), Type: AccessorTypeScalar,
+	})
+
+	mode := PrimitiveModeTriangles
+	meshIndex := uint32(len(b.doc.Meshes))
+	b.doc.Meshes = append(b.doc.Meshes, Mesh{
+		Primitives: []Primitive{{
+			Attributes: map[string]uint32{"POSITION": posAccessor, "NORMAL": normAccessor, "TEXCOORD_0": uvAccessor},
+			Indices:    &idxAccessor,
+			Material:   materialIndex,
+			Mode:       &mode,
+		}},
+	})
+	return meshIndex, nil
+}
+
+// convertMaterial maps a phys.Material onto glTF's metallic-roughness
+// model: phys.PBR carries straight across, phys.Lambertian becomes a
+// fully rough dielectric with its Texture as base color, and
+// phys.Emitter becomes a black, fully rough base color with its Texture
+// as emissive (baseColorTexture/emissiveFactor/emissiveTexture per the
+// mapping this package documents). Any other Material is an error.
+func (b *builder) convertMaterial(mat phys.Material) (*uint32, error) {
+	var out Material
+	switch m := mat.(type) {
+	case phys.PBR:
+		factor, texRef, err := b.convertTexture(m.BaseColor)
+		if err != nil {
+			return nil, fmt.Errorf("BaseColor: %w", err)
+		}
+		metallic, roughness := m.Metallic, m.Roughness
+		out.PBRMetallicRoughness = &PBRMetallicRoughness{
+			BaseColorFactor: [4]float64{factor.X, factor.Y, factor.Z, 1}, BaseColorTexture: texRef,
+			MetallicFactor: &metallic, RoughnessFactor: &roughness,
+		}
+		if m.NormalMap != nil {
+			_, nTexRef, err := b.convertTexture(m.NormalMap.Normal)
+			if err != nil {
+				return nil, fmt.Errorf("NormalMap: %w", err)
+			}
+			out.NormalTexture = nTexRef
+		}
+		if m.EmissiveTexture != nil {
+			eFactor, eTexRef, err := b.convertTexture(m.EmissiveTexture)
+			if err != nil {
+				return nil, fmt.Errorf("EmissiveTexture: %w", err)
+			}
+			out.EmissiveFactor = [3]float64{eFactor.X, eFactor.Y, eFactor.Z}
+			out.EmissiveTexture = eTexRef
+		}
+	case phys.Lambertian:
+		factor, texRef, err := b.convertTexture(m.Texture)
+		if err != nil {
+			return nil, fmt.Errorf("Texture: %w", err)
+		}
+		metallic, roughness := 0.0, 1.0
+		out.PBRMetallicRoughness = &PBRMetallicRoughness{
+			BaseColorFactor: [4]float64{factor.X, factor.Y, factor.Z, 1}, BaseColorTexture: texRef,
+			MetallicFactor: &metallic, RoughnessFactor: &roughness,
+		}
+	case phys.Emitter:
+		factor, texRef, err := b.convertTexture(m.Texture)
+		if err != nil {
+			return nil, fmt.Errorf("Texture: %w", err)
+		}
+		metallic, roughness := 0.0, 1.0
+		out.PBRMetallicRoughness = &PBRMetallicRoughness{
+			BaseColorFactor: [4]float64{0, 0, 0, 1},
+			MetallicFactor:  &metallic, RoughnessFactor: &roughness,
+		}
+		out.EmissiveFactor = [3]float64{factor.X, factor.Y, factor.Z}
+		out.EmissiveTexture = texRef
+	default:
+		return nil, fmt.Errorf("unsupported Material type %T (only phys.PBR, phys.Lambertian, phys.Emitter)", mat)
+	}
+	index := uint32(len(b.doc.Materials))
+	b.doc.Materials = append(b.doc.Materials, out)
+	return &index, nil
+}
+
+// convertLight maps a phys.PointLight, phys.SpotLight, or phys.Sun onto a
+// KHR_lights_punctual PunctualLight, appending it to
+// Document.Extensions.KHRLightsPunctual.Lights (allocated on first use)
+// and returning its index plus the phys.Transform the Node referencing it
+// should carry: Translation only for a point light (point lights are
+// omnidirectional, so rotation doesn't matter), or a Rotation whose local
+// -Z axis is the light's Direction (the axis glTF points every light
+// down) for a spot light or phys.Sun (a "directional" light, in glTF's
+// terms) -- a Sun has no position, so its Node carries no Translation.
+func (b *builder) convertLight(light phys.Light) (index uint32, world phys.Transform, err error) {
+	if b.doc.Extensions == nil {
+		b.doc.Extensions = &DocumentExtensions{}
+	}
+	if b.doc.Extensions.KHRLightsPunctual == nil {
+		b.doc.Extensions.KHRLightsPunctual = &KHRLightsPunctual{}
+	}
+
+	switch l := light.(type) {
+	case phys.PointLight:
+		intensity := maxComponent(l.RadiantIntensity)
+		b.doc.Extensions.KHRLightsPunctual.Lights = append(b.doc.Extensions.KHRLightsPunctual.Lights, PunctualLight{
+			Type: "point", Color: normalizeColor(l.RadiantIntensity, intensity), Intensity: &intensity,
+		})
+		world = phys.NewTranslation(r3.Vec(l.Position))
+	case phys.SpotLight:
+		intensity := maxComponent(l.RadiantIntensity)
+		outerConeAngle := l.OuterConeAngle
+		b.doc.Extensions.KHRLightsPunctual.Lights = append(b.doc.Extensions.KHRLightsPunctual.Lights, PunctualLight{
+			Type: "spot", Color: normalizeColor(l.RadiantIntensity, intensity), Intensity: &intensity,
+			Spot: &PunctualLightSpot{InnerConeAngle: l.InnerConeAngle, OuterConeAngle: &outerConeAngle},
+		})
+		world = phys.Compose(phys.NewRotation(directionToMat3x3(l.Direction)), phys.NewTranslation(r3.Vec(l.Position)))
+	case phys.Sun:
+		intensity := maxComponent(l.RadiantIntensity)
+		b.doc.Extensions.KHRLightsPunctual.Lights = append(b.doc.Extensions.KHRLightsPunctual.Lights, PunctualLight{
+			Type: "directional", Color: normalizeColor(l.RadiantIntensity, intensity), Intensity: &intensity,
+		})
+		world = phys.NewRotation(directionToMat3x3(l.SunDirection.Muls(-1)))
+	default:
+		return 0, phys.Transform{}, fmt.Errorf("unsupported Light type %T (only phys.PointLight, phys.SpotLight, phys.Sun)", light)
+	}
+	return uint32(len(b.doc.Extensions.KHRLightsPunctual.Lights) - 1), world, nil
+}
+
+func maxComponent(v r3.Vec) float64 {
+	return math.Max(v.X, math.Max(v.Y, v.Z))
+}
+
+// normalizeColor divides v by intensity to recover the unit color
+// KHR_lights_punctual's "color" field expects (it carries intensity
+// separately), or white if intensity is zero -- a dark light has no hue
+// left to preserve.
+func normalizeColor(v r3.Vec, intensity float64) [3]float64 {
+	if intensity <= 0 {
+		return [3]float64{1, 1, 1}
+	}
+	return [3]float64{v.X / intensity, v.Y / intensity, v.Z / intensity}
+}
+
+// directionToMat3x3 builds a rotation matrix whose local -Z axis maps to
+// dir, the axis every glTF light points down, picking an arbitrary basis
+// for the other two axes since a punctual light's illumination is
+// rotationally symmetric about its own direction.
+func directionToMat3x3(dir r3.Vec) r3.Mat3x3 {
+	w := dir.Unit().Muls(-1) // Local +Z, opposite the light's pointing direction.
+	arbitrary := r3.Vec{X: 0, Y: 1, Z: 0}
+	if math.Abs(w.Dot(arbitrary)) > 0.999 {
+		arbitrary = r3.Vec{X: 1, Y: 0, Z: 0}
+	}
+	u := arbitrary.Cross(w).Unit()
+	v := w.Cross(u)
+	return r3.Mat3x3{M: [3][3]float64{
+		{u.X, v.X, w.X},
+		{u.Y, v.Y, w.Y},
+		{u.Z, v.Z, w.Z},
+	}}
+}
+
+// convertTexture turns a phys.Texture into a glTF factor color plus an
+// optional TextureRef. nil and TextureUniform textures need no baked
+// image at all; TextureImage is encoded losslessly from its underlying
+// image.Image; anything else (e.g. TextureCheckerboard) is rasterized by
+// sampling At over one UV tile at bakedTextureSize resolution.
+func (b *builder) convertTexture(tex phys.Texture) (r3.Vec, *TextureRef, error) {
+	switch t := tex.(type) {
+	case nil:
+		return r3.Vec{X: 1, Y: 1, Z: 1}, nil, nil
+	case phys.TextureUniform:
+		return r3.Vec(t.Color), nil, nil
+	case phys.TextureImage:
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, t.Image); err != nil {
+			return r3.Vec{}, nil, fmt.Errorf("encode TextureImage: %w", err)
+		}
+		ref, err := b.addImageTexture(buf.Bytes())
+		if err != nil {
+			return r3.Vec{}, nil, err
+		}
+		return r3.Vec{X: 1, Y: 1, Z: 1}, ref, nil
+	default:
+		img := bakeTexture(tex)
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, img); err != nil {
+			return r3.Vec{}, nil, fmt.Errorf("encode baked %T: %w", tex, err)
+		}
+		ref, err := b.addImageTexture(buf.Bytes())
+		if err != nil {
+			return r3.Vec{}, nil, err
+		}
+		return r3.Vec{X: 1, Y: 1, Z: 1}, ref, nil
+	}
+}
+
+// bakeTexture rasterizes tex by sampling At(u, v) on a bakedTextureSize
+// grid spanning the [0, 1) UV tile, clamping each channel into sRGB
+// 8-bit range.
+func bakeTexture(tex phys.Texture) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, bakedTextureSize, bakedTextureSize))
+	for y := 0; y < bakedTextureSize; y++ {
+		v := (float64(y) + 0.5) / bakedTextureSize
+		for x := 0; x < bakedTextureSize; x++ {
+			u := (float64(x) + 0.5) / bakedTextureSize
+			c := tex.At(u, v)
+			img.Set(x, y, color.RGBA{
+				R: uint8(math.Min(255, math.Max(0, 255.99*c.X))),
+				G: uint8(math.Min(255, math.Max(0, 255.99*c.Y))),
+				B: uint8(math.Min(255, math.Max(0, 255.99*c.Z))),
+				A: 255,
+			})
+		}
+	}
+	return img
+}
+
+// addImageTexture embeds PNG-encoded image bytes as a bufferView-backed
+// Image, wraps it in a Texture with a repeat/bilinear Sampler (matching
+// NewTextureImageFS's own "bilinear"/"repeat" defaults), and returns a
+// TextureRef to it.
+func (b *builder) addImageTexture(pngBytes []byte) (*TextureRef, error) {
+	view := b.addBufferView(pngBytes, 0)
+	imageIndex := uint32(len(b.doc.Images))
+	b.doc.Images = append(b.doc.Images, Image{MimeType: "image/png", BufferView: &view})
+	samplerIndex := uint32(len(b.doc.Samplers))
+	b.doc.Samplers = append(b.doc.Samplers, Sampler{MagFilter: MagFilterLinear, MinFilter: MinFilterLinear, WrapS: WrapRepeat, WrapT: WrapRepeat})
+	textureIndex := uint32(len(b.doc.Textures))
+	b.doc.Textures = append(b.doc.Textures, Texture{Source: &imageIndex, Sampler: &samplerIndex})
+	return &TextureRef{Index: textureIndex}, nil
+}
+
+// decomposeTRS splits t into glTF's translation/rotation-quaternion/scale
+// triple via Transform.Decompose, which assumes t's linear part has no
+// shear -- the same assumption glTF's TRS node format is itself limited
+// to, so any shear a Transform built via Compose might carry is silently
+// dropped here rather than rejected.
+func decomposeTRS(t phys.Transform) (translation [3]float64, rotation [4]float64, scale [3]float64) {
+	tr, rot, s := t.Decompose()
+	q := mat3x3ToQuaternion(rot)
+	return [3]float64{tr.X, tr.Y, tr.Z},
+		[4]float64{q.X, q.Y, q.Z, q.W},
+		[3]float64{s.X, s.Y, s.Z}
+}
+
+// lookAtTRS builds the translation and rotation quaternion of the glTF
+// camera node equivalent to an OrthographicCamera's LookFrom/LookAt/VUp,
+// using the same right-handed, -Z-forward basis convention
+// OrthographicCamera.Cast itself derives (w = LookFrom-LookAt, u =
+// VUp x w, v = w x u), since glTF cameras always look down their local
+// -Z axis.
+func lookAtTRS(lookFrom, lookAt r3.Point, vup r3.Vec) (translation [3]float64, rotation [4]float64) {
+	w := lookFrom.Sub(lookAt).Unit()
+	u := vup.Cross(w).Unit()
+	v := w.Cross(u)
+	rot := r3.Mat3x3{M: [3][3]float64{
+		{u.X, v.X, w.X},
+		{u.Y, v.Y, w.Y},
+		{u.Z, v.Z, w.Z},
+	}}
+	q := mat3x3ToQuaternion(rot)
+	return [3]float64{lookFrom.X, lookFrom.Y, lookFrom.Z}, [4]float64{q.X, q.Y, q.Z, q.W}
+}
+
+// pinholeCameraPerspective derives a glTF Perspective projection from a
+// PinholeCamera's image plane geometry: PinholeCamera has no explicit
+// field of view, only the world-space Horizontal/Vertical spans of its
+// image plane and the plane's distance from Origin, so Yfov is recovered
+// as the vertical angle those spans subtend at Origin, and AspectRatio
+// from their relative lengths.
+func pinholeCameraPerspective(cam phys.PinholeCamera) (*Perspective, error) {
+	center := cam.LowerLeftCorner.Add(cam.Horizontal.Divs(2)).Add(cam.Vertical.Divs(2))
+	focalDistance := center.Sub(cam.Origin).Length()
+	if focalDistance <= 1e-9 {
+		return nil, fmt.Errorf("PinholeCamera image plane passes through its Origin (focal distance %v)", focalDistance)
+	}
+	yfov := 2 * math.Atan(cam.Vertical.Length()/2/focalDistance)
+	return &Perspective{
+		AspectRatio: cam.Horizontal.Length() / cam.Vertical.Length(),
+		Yfov:        yfov,
+		Znear:       0.01,
+		Zfar:        1000,
+	}, nil
+}
+
+// mat3x3ToQuaternion converts a rotation matrix to a unit quaternion via
+// Shepperd's method: pick whichever of the four formulas (driven off the
+// largest of trace and the three diagonal entries) avoids dividing by a
+// near-zero value, then normalize.
+func mat3x3ToQuaternion(m r3.Mat3x3) phys.Quaternion {
+	trace := m.M[0][0] + m.M[1][1] + m.M[2][2]
+	var q phys.Quaternion
+	switch {
+	case trace > 0:
+		s := 0.5 / math.Sqrt(trace+1)
+		q = phys.Quaternion{
+			W: 0.25 / s,
+			X: (m.M[2][1] - m.M[1][2]) * s,
+			Y: (m.M[0][2] - m.M[2][0]) * s,
+			Z: (m.M[1][0] - m.M[0][1]) * s,
+		}
+	case m.M[0][0] > m.M[1][1] && m.M[0][0] > m.M[2][2]:
+		s := 2 * math.Sqrt(1+m.M[0][0]-m.M[1][1]-m.M[2][2])
+		q = phys.Quaternion{
+			W: (m.M[2][1] - m.M[1][2]) / s,
+			X: 0.25 * s,
+			Y: (m.M[0][1] + m.M[1][0]) / s,
+			Z: (m.M[0][2] + m.M[2][0]) / s,
+		}
+	case m.M[1][1] > m.M[2][2]:
+		s := 2 * math.Sqrt(1+m.M[1][1]-m.M[0][0]-m.M[2][2])
+		q = phys.Quaternion{
+			W: (m.M[0][2] - m.M[2][0]) / s,
+			X: (m.M[0][1] + m.M[1][0]) / s,
+			Y: 0.25 * s,
+			Z: (m.M[1][2] + m.M[2][1]) / s,
+		}
+	default:
+		s := 2 * math.Sqrt(1+m.M[2][2]-m.M[0][0]-m.M[1][1])
+		q = phys.Quaternion{
+			W: (m.M[1][0] - m.M[0][1]) / s,
+			X: (m.M[0][2] + m.M[2][0]) / s,
+			Y: (m.M[1][2] + m.M[2][1]) / s,
+			Z: 0.25 * s,
+		}
+	}
+	return q.Unit()
+}
+
+// quaternionToMat3x3 is the inverse of mat3x3ToQuaternion, used by Import
+// to reconstruct a Node's rotation matrix from a decoded glTF
+// translation/rotation/scale node.
+func quaternionToMat3x3(q phys.Quaternion) r3.Mat3x3 {
+	m4 := q.ToRotationMatrix()
+	var m r3.Mat3x3
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			m.M[i][j] = m4[i][j]
+		}
+	}
+	return m
+}
+
+func float32sToBytes(vals []float32) []byte {
+	out := make([]byte, len(vals)*4)
+	for i, v := range vals {
+		bits := math.Float32bits(v)
+		out[i*4+0] = byte(bits)
+		out[i*4+1] = byte(bits >> 8)
+		out[i*4+2] = byte(bits >> 16)
+		out[i*4+3] = byte(bits >> 24)
+	}
+	return out
+}
+
+func uint16sToBytes(vals []uint16) []byte {
+	out := make([]byte, len(vals)*2)
+	for i, v := range vals {
+		out[i*2+0] = byte(v)
+		out[i*2+1] = byte(v >> 8)
+	}
+	return out
+}
+
+// Import converts a Document plus its binary buffer back into a
+// phys.Scene: one phys.Node per glTF node that references a Mesh (built
+// via phys.NewMesh so it gets a fresh BVH, the same way ConvertObjectToNodes
+// builds Node.Shape from parsed geometry), one phys.OrthographicCamera per
+// node that references an orthographic Camera, and one phys.PointLight or
+// phys.SpotLight per node carrying a KHR_lights_punctual extension, in
+// document Scenes[0] order. Every node's world transform is baked down
+// from the scene root through its ancestors' TRS (see importNodeRecursive)
+// before conversion, so a mesh/camera/light nested under Children still
+// lands in the right place even though phys.Scene itself has no
+// hierarchy. A node referencing a perspective Camera, a directional
+// light, or a Primitive whose Mode isn't PrimitiveModeTriangles, is an
+// error -- all three are things this package's own Export never
+// produces, so seeing one means the Document came from elsewhere and
+// needs a human to confirm how it should map.
+func Import(doc *Document, bin []byte) (*phys.Scene, error) {
+	scene := &phys.Scene{}
+	var rootNodes []uint32
+	if doc.Scene != nil && int(*doc.Scene) < len(doc.Scenes) {
+		rootNodes = doc.Scenes[*doc.Scene].Nodes
+	} else if len(doc.Scenes) > 0 {
+		rootNodes = doc.Scenes[0].Nodes
+	}
+
+	identity := phys.NewTransform()
+	visited := make(map[uint32]bool)
+	for _, nodeIndex := range rootNodes {
+		if err := importNodeRecursive(doc, bin, scene, nodeIndex, identity, visited); err != nil {
+			return nil, err
+		}
+	}
+	return scene, nil
+}
+
+// ConvertGLTFToNodes converts a glTF Document into a slice of phys.Node,
+// the gltf package's counterpart to phys.ConvertObjectToNodes for obj.
+// It's a thin wrapper around Import that discards the scene's Camera and
+// Light slices, for callers that just want a Document's geometry to drop
+// into a scene they're assembling some other way.
+func ConvertGLTFToNodes(doc *Document, bin []byte) ([]phys.Node, error) {
+	scene, err := Import(doc, bin)
+	if err != nil {
+		return nil, err
+	}
+	return scene.Node, nil
+}
+
+// importNodeRecursive bakes nodeIndex's world transform (its own local
+// TRS applied first, then parentWorld, via phys.Compose) and adds
+// whatever it references -- a Mesh, Camera, or KHR_lights_punctual light
+// -- to scene, then recurses into Children with that world transform as
+// their new parent. visited guards against the cycles the glTF spec
+// forbids a well-formed node hierarchy from having, but a malformed
+// Document could still contain.
+func importNodeRecursive(doc *Document, bin []byte, scene *phys.Scene, nodeIndex uint32, parentWorld phys.Transform, visited map[uint32]bool) error {
+	if int(nodeIndex) >= len(doc.Nodes) {
+		return fmt.Errorf("gltf.Import: scene references out-of-range node %d", nodeIndex)
+	}
+	if visited[nodeIndex] {
+		return fmt.Errorf("gltf.Import: node %d: cycle detected in node hierarchy", nodeIndex)
+	}
+	visited[nodeIndex] = true
+	defer delete(visited, nodeIndex)
+
+	node := doc.Nodes[nodeIndex]
+	world := phys.Compose(importTransform(node), parentWorld)
+
+	switch {
+	case node.Camera != nil:
+		cam, err := importCamera(doc, *node.Camera, world)
+		if err != nil {
+			return fmt.Errorf("gltf.Import: node %q: %w", node.Name, err)
+		}
+		scene.Camera = append(scene.Camera, cam)
+	case node.Mesh != nil:
+		physNode, err := importMeshNode(doc, bin, node, world)
+		if err != nil {
+			return fmt.Errorf("gltf.Import: node %q: %w", node.Name, err)
+		}
+		scene.Node = append(scene.Node, physNode)
+	}
+	if node.Extensions != nil && node.Extensions.KHRLightsPunctual != nil {
+		light, err := importLight(doc, node.Extensions.KHRLightsPunctual.Light, world)
+		if err != nil {
+			return fmt.Errorf("gltf.Import: node %q: %w", node.Name, err)
+		}
+		scene.Light = append(scene.Light, light)
+	}
+
+	for _, child := range node.Children {
+		if err := importNodeRecursive(doc, bin, scene, child, world, visited); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// importLight resolves a KHR_lights_punctual node extension's light index
+// into a phys.PointLight, phys.SpotLight, or phys.Sun positioned (point,
+// spot) or aimed (spot, directional) by world: a spot light's Direction,
+// or a directional light's SunDirection, comes from world's local -Z
+// axis, the same axis lookAtTRS/importCamera already treat as "forward"
+// for glTF cameras.
+func importLight(doc *Document, lightIndex uint32, world phys.Transform) (phys.Light, error) {
+	if doc.Extensions == nil || doc.Extensions.KHRLightsPunctual == nil {
+		return nil, fmt.Errorf("node references light %d but Document has no KHR_lights_punctual extension", lightIndex)
+	}
+	lights := doc.Extensions.KHRLightsPunctual.Lights
+	if int(lightIndex) >= len(lights) {
+		return nil, fmt.Errorf("light index %d out of range", lightIndex)
+	}
+	l := lights[lightIndex]
+	intensity := 1.0
+	if l.Intensity != nil {
+		intensity = *l.Intensity
+	}
+	color := l.Color
+	if color == [3]float64{} {
+		color = [3]float64{1, 1, 1}
+	}
+	radiantIntensity := r3.Vec{X: color[0], Y: color[1], Z: color[2]}.Muls(intensity)
+	position := world.ApplyToPoint(r3.Point{})
+
+	switch l.Type {
+	case "point":
+		return phys.PointLight{Position: position, RadiantIntensity: radiantIntensity}, nil
+	case "spot":
+		if l.Spot == nil {
+			return nil, fmt.Errorf("spot light %d missing spot parameters", lightIndex)
+		}
+		outer := math.Pi / 4 // glTF spec default when outerConeAngle is omitted.
+		if l.Spot.OuterConeAngle != nil {
+			outer = *l.Spot.OuterConeAngle
+		}
+		return phys.SpotLight{
+			Position:         position,
+			Direction:        world.ApplyToVector(r3.Vec{X: 0, Y: 0, Z: -1}).Unit(),
+			RadiantIntensity: radiantIntensity,
+			InnerConeAngle:   l.Spot.InnerConeAngle,
+			OuterConeAngle:   outer,
+		}, nil
+	case "directional":
+		return phys.Sun{
+			SunDirection:     world.ApplyToVector(r3.Vec{X: 0, Y: 0, Z: -1}).Unit().Muls(-1),
+			RadiantIntensity: radiantIntensity,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported light type %q (only point, spot, directional)", l.Type)
+	}
+}
+
+// importTransform reconstructs a phys.Transform from a Node's TRS,
+// substituting glTF's spec defaults (scale (1,1,1), rotation identity)
+// for the Go zero value, which is otherwise indistinguishable from an
+// explicit (and invalid, for scale) all-zero TRS field left out of a
+// hand-authored document.
+func importTransform(node Node) phys.Transform {
+	scale := node.Scale
+	if scale == [3]float64{} {
+		scale = [3]float64{1, 1, 1}
+	}
+	rotation := node.Rotation
+	if rotation == [4]float64{} {
+		rotation = [4]float64{0, 0, 0, 1}
+	}
+	q := phys.Quaternion{X: rotation[0], Y: rotation[1], Z: rotation[2], W: rotation[3]}
+	translation := r3.Vec{X: node.Translation[0], Y: node.Translation[1], Z: node.Translation[2]}
+	return phys.Compose(
+		phys.NewScale(r3.Vec{X: scale[0], Y: scale[1], Z: scale[2]}),
+		phys.NewRotation(quaternionToMat3x3(q)),
+		phys.NewTranslation(translation),
+	)
+}
+
+func importCamera(doc *Document, cameraIndex uint32, transform phys.Transform) (phys.Camera, error) {
+	if int(cameraIndex) >= len(doc.Cameras) {
+		return nil, fmt.Errorf("camera index %d out of range", cameraIndex)
+	}
+	cam := doc.Cameras[cameraIndex]
+	if cam.Orthographic == nil {
+		return nil, fmt.Errorf("unsupported Camera type %q (only orthographic)", cam.Type)
+	}
+	lookFrom := transform.ApplyToPoint(r3.Point{})
+	forward := transform.ApplyToVector(r3.Vec{X: 0, Y: 0, Z: -1}).Unit()
+	vup := transform.ApplyToVector(r3.Vec{X: 0, Y: 1, Z: 0}).Unit()
+	return phys.OrthographicCamera{
+		LookFrom:  lookFrom,
+		LookAt:    lookFrom.Add(forward),
+		VUp:       vup,
+		FOVWidth:  phys.Distance(cam.Orthographic.Xmag * 2),
+		FOVHeight: phys.Distance(cam.Orthographic.Ymag * 2),
+	}, nil
+}
+
+func importMeshNode(doc *Document, bin []byte, node Node, transform phys.Transform) (phys.Node, error) {
+	if int(*node.Mesh) >= len(doc.Meshes) {
+		return phys.Node{}, fmt.Errorf("mesh index %d out of range", *node.Mesh)
+	}
+	meshDoc := doc.Meshes[*node.Mesh]
+	if len(meshDoc.Primitives) == 0 {
+		return phys.Node{}, fmt.Errorf("mesh %q has no primitives", meshDoc.Name)
+	}
+	prim := meshDoc.Primitives[0]
+	if prim.Mode != nil && *prim.Mode != PrimitiveModeTriangles {
+		return phys.Node{}, fmt.Errorf("unsupported primitive mode %d (only triangles)", *prim.Mode)
+	}
+
+	positions, err := readVec3Accessor(doc, bin, prim.Attributes["POSITION"])
+	if err != nil {
+		return phys.Node{}, fmt.Errorf("POSITION: %w", err)
+	}
+	var uvs []r2.Point
+	if uvIdx, ok := prim.Attributes["TEXCOORD_0"]; ok {
+		uvs, err = readVec2Accessor(doc, bin, uvIdx)
+		if err != nil {
+			return phys.Node{}, fmt.Errorf("TEXCOORD_0: %w", err)
+		}
+	}
+	var normals []r3.Point
+	if normalIdx, ok := prim.Attributes["NORMAL"]; ok {
+		normals, err = readVec3Accessor(doc, bin, normalIdx)
+		if err != nil {
+			return phys.Node{}, fmt.Errorf("NORMAL: %w", err)
+		}
+	}
+	var indices []int
+	if prim.Indices != nil {
+		indices, err = readIndexAccessor(doc, bin, *prim.Indices)
+		if err != nil {
+			return phys.Node{}, fmt.Errorf("indices: %w", err)
+		}
+	} else {
+		indices = make([]int, len(positions))
+		for i := range indices {
+			indices[i] = i
+		}
+	}
+	if len(indices)%3 != 0 {
+		return phys.Node{}, fmt.Errorf("index count %d is not a multiple of 3", len(indices))
+	}
+
+	material, err := importMaterial(doc, bin, prim.Material)
+	if err != nil {
+		return phys.Node{}, fmt.Errorf("material: %w", err)
+	}
+
+	if len(prim.Targets) > 0 {
+		morphed, err := importMorphedMesh(doc, bin, prim, positions, uvs, indices)
+		if err != nil {
+			return phys.Node{}, fmt.Errorf("morph targets: %w", err)
+		}
+		return phys.Node{Name: node.Name, Transform: transform, Shape: morphed, Material: material}, nil
+	}
+
+	var faces []phys.Face
+	for i := 0; i+2 < len(indices); i += 3 {
+		var verts [3]phys.Vertex
+		for k := 0; k < 3; k++ {
+			idx := indices[i+k]
+			if idx < 0 || idx >= len(positions) {
+				return phys.Node{}, fmt.Errorf("index %d out of range for %d positions", idx, len(positions))
+			}
+			uv := r2.Point{}
+			if idx < len(uvs) {
+				uv = uvs[idx]
+			}
+			vert := phys.Vertex{Position: positions[idx], UV: uv}
+			if idx < len(normals) {
+				// Promote the accessor's per-vertex normal to Vertex.Normal so
+				// Face.Collide smooth-shades instead of falling back to the
+				// face's flat geometric normal.
+				n := normals[idx]
+				vert.Normal = r3.Vec{X: n.X, Y: n.Y, Z: n.Z}
+			}
+			verts[k] = vert
+		}
+		face := phys.Face{Vertex: verts}
+		if err := face.Validate(); err != nil {
+			continue // Skip degenerate faces, matching ConvertObjectToNodes's own triangulation tolerance.
+		}
+		faces = append(faces, face)
+	}
+	mesh, err := phys.NewMesh(faces)
+	if err != nil {
+		return phys.Node{}, fmt.Errorf("NewMesh: %w", err)
+	}
+
+	return phys.Node{Name: node.Name, Transform: transform, Shape: mesh, Material: material}, nil
+}
+
+// importMorphedMesh reads each of prim.Targets' POSITION displacement
+// accessors and builds a phys.MorphedMesh from the primitive's base
+// positions, UVs, and index buffer. Unlike the static-mesh path above,
+// the mesh's triangle topology is the raw index buffer rather than a
+// per-face-validated triangle list -- MorphedMesh.faces validates
+// indices are in range but, since a blend shape can legitimately pass
+// through a degenerate pose at some weight, doesn't reject degenerate
+// triangles the way NewMesh's Face.Validate does for a static mesh.
+func importMorphedMesh(doc *Document, bin []byte, prim Primitive, positions []r3.Point, uvs []r2.Point, indices []int) (*phys.MorphedMesh, error) {
+	p := make([]r3.Point, len(positions))
+	copy(p, positions)
+
+	targets := make([][]r3.Vec, len(prim.Targets))
+	for i, target := range prim.Targets {
+		posIdx, ok := target["POSITION"]
+		if !ok {
+			return nil, fmt.Errorf("target %d has no POSITION displacement", i)
+		}
+		deltas, err := readVec3Accessor(doc, bin, posIdx)
+		if err != nil {
+			return nil, fmt.Errorf("target %d POSITION: %w", i, err)
+		}
+		if len(deltas) != len(p) {
+			return nil, fmt.Errorf("target %d has %d displacements, want %d (one per base vertex)", i, len(deltas), len(p))
+		}
+		vecs := make([]r3.Vec, len(deltas))
+		for j, d := range deltas {
+			vecs[j] = r3.Vec{X: d.X, Y: d.Y, Z: d.Z}
+		}
+		targets[i] = vecs
+	}
+
+	return phys.NewMorphedMesh(p, uvs, targets, indices)
+}
+
+// importMaterial reconstructs a phys.Material from a glTF Material index.
+// It always produces a phys.PBR -- the natural reading of glTF's
+// metallic-roughness model -- even for a Document this package itself
+// exported from a phys.Emitter or phys.Lambertian, since glTF has no
+// material kind distinguishing "pure emitter" or "non-metallic, fully
+// rough" from an ordinary PBR material with those factor values.
+func importMaterial(doc *Document, bin []byte, materialIndex *uint32) (phys.Material, error) {
+	if materialIndex == nil {
+		return phys.PBR{BaseColor: phys.TextureUniform{Color: phys.Spectrum{X: 0.8, Y: 0.8, Z: 0.8}}, Roughness: 1}, nil
+	}
+	if int(*materialIndex) >= len(doc.Materials) {
+		return nil, fmt.Errorf("material index %d out of range", *materialIndex)
+	}
+	matDoc := doc.Materials[*materialIndex]
+	baseColor, err := importTextureInput(doc, bin, matDoc.PBRMetallicRoughness)
+	if err != nil {
+		return nil, fmt.Errorf("baseColorTexture: %w", err)
+	}
+	metallic, roughness := 1.0, 1.0
+	if matDoc.PBRMetallicRoughness != nil {
+		if matDoc.PBRMetallicRoughness.MetallicFactor != nil {
+			metallic = *matDoc.PBRMetallicRoughness.MetallicFactor
+		}
+		if matDoc.PBRMetallicRoughness.RoughnessFactor != nil {
+			roughness = *matDoc.PBRMetallicRoughness.RoughnessFactor
+		}
+	}
+	m := phys.PBR{BaseColor: baseColor, Metallic: metallic, Roughness: roughness}
+	if matDoc.NormalTexture != nil {
+		tex, err := importTexture(doc, bin, matDoc.NormalTexture.Index)
+		if err != nil {
+			return nil, fmt.Errorf("normalTexture: %w", err)
+		}
+		m.NormalMap = &phys.TextureNormal{Normal: tex}
+	}
+	if matDoc.EmissiveTexture != nil {
+		tex, err := importTexture(doc, bin, matDoc.EmissiveTexture.Index)
+		if err != nil {
+			return nil, fmt.Errorf("emissiveTexture: %w", err)
+		}
+		m.EmissiveTexture = tex
+	} else if matDoc.EmissiveFactor != [3]float64{} {
+		m.EmissiveTexture = phys.TextureUniform{Color: phys.Spectrum{X: matDoc.EmissiveFactor[0], Y: matDoc.EmissiveFactor[1], Z: matDoc.EmissiveFactor[2]}}
+	}
+	return m, nil
+}
+
+func importTextureInput(doc *Document, bin []byte, pbr *PBRMetallicRoughness) (phys.Texture, error) {
+	if pbr == nil {
+		return phys.TextureUniform{Color: phys.Spectrum{X: 0.8, Y: 0.8, Z: 0.8}}, nil
+	}
+	if pbr.BaseColorTexture != nil {
+		return importTexture(doc, bin, pbr.BaseColorTexture.Index)
+	}
+	factor := pbr.BaseColorFactor
+	if factor == [4]float64{} {
+		factor = [4]float64{1, 1, 1, 1}
+	}
+	return phys.TextureUniform{Color: phys.Spectrum{X: factor[0], Y: factor[1], Z: factor[2]}}, nil
+}
+
+func importTexture(doc *Document, bin []byte, textureIndex uint32) (phys.Texture, error) {
+	if int(textureIndex) >= len(doc.Textures) {
+		return nil, fmt.Errorf("texture index %d out of range", textureIndex)
+	}
+	tex := doc.Textures[textureIndex]
+	if tex.Source == nil {
+		return nil, fmt.Errorf("texture %d has no image source", textureIndex)
+	}
+	if int(*tex.Source) >= len(doc.Images) {
+		return nil, fmt.Errorf("image index %d out of range", *tex.Source)
+	}
+	imgDoc := doc.Images[*tex.Source]
+	if imgDoc.BufferView == nil {
+		return nil, fmt.Errorf("image %d: external URI images are not supported, only embedded bufferView images", *tex.Source)
+	}
+	view := doc.BufferViews[*imgDoc.BufferView]
+	data := bin[view.ByteOffset : view.ByteOffset+view.ByteLength]
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("decode embedded image: %w", err)
+	}
+	return phys.TextureImage{Image: img, Interp: "bilinear", WrapMode: "repeat"}, nil
+}
+
+func readVec3Accessor(doc *Document, bin []byte, accessorIndex uint32) ([]r3.Point, error) {
+	acc, view, data, err := accessorBytes(doc, bin, accessorIndex, ComponentFloat, AccessorTypeVec3)
+	if err != nil {
+		return nil, err
+	}
+	const elemSize = 12
+	stride := accessorStride(view, elemSize)
+	if err := checkAccessorBounds(accessorIndex, data, acc.Count, stride, elemSize); err != nil {
+		return nil, err
+	}
+	out := make([]r3.Point, acc.Count)
+	for i := range out {
+		off := i * stride
+		out[i] = r3.Point{
+			X: float64(bytesToFloat32(data[off:])),
+			Y: float64(bytesToFloat32(data[off+4:])),
+			Z: float64(bytesToFloat32(data[off+8:])),
+		}
+	}
+	return out, nil
+}
+
+func readVec2Accessor(doc *Document, bin []byte, accessorIndex uint32) ([]r2.Point, error) {
+	acc, view, data, err := accessorBytes(doc, bin, accessorIndex, ComponentFloat, AccessorTypeVec2)
+	if err != nil {
+		return nil, err
+	}
+	const elemSize = 8
+	stride := accessorStride(view, elemSize)
+	if err := checkAccessorBounds(accessorIndex, data, acc.Count, stride, elemSize); err != nil {
+		return nil, err
+	}
+	out := make([]r2.Point, acc.Count)
+	for i := range out {
+		off := i * stride
+		out[i] = r2.Point{
+			X: float64(bytesToFloat32(data[off:])),
+			Y: float64(bytesToFloat32(data[off+4:])),
+		}
+	}
+	return out, nil
+}
+
+// accessorStride returns the byte distance between consecutive elements
+// of an accessor backed by view: view's explicit ByteStride for an
+// interleaved vertex buffer, or elemSize (the accessor's own tightly
+// packed element size) when ByteStride is unset, per the glTF spec's
+// default. Per the same spec, ByteStride is only ever meaningful for
+// vertex attribute accessors (POSITION, TEXCOORD_0, ...); index accessors
+// always read tightly packed, so readIndexAccessor doesn't call this.
+func accessorStride(view BufferView, elemSize int) int {
+	if view.ByteStride != 0 {
+		return view.ByteStride
+	}
+	return elemSize
+}
+
+// checkAccessorBounds reports an error naming accessorIndex if reading
+// count elemSize-wide elements at stride out of data would run past its
+// end, the way a Document whose Count or ByteLength lies about how much
+// data actually backs an accessor would otherwise panic deep inside
+// bytesToFloat32 instead of failing with a useful message.
+func checkAccessorBounds(accessorIndex uint32, data []byte, count, stride, elemSize int) error {
+	if count == 0 {
+		return nil
+	}
+	need := (count-1)*stride + elemSize
+	if need > len(data) {
+		return fmt.Errorf("accessor %d: needs %d bytes (count=%d, stride=%d) but bufferView only has %d remaining", accessorIndex, need, count, stride, len(data))
+	}
+	return nil
+}
+
+func readIndexAccessor(doc *Document, bin []byte, accessorIndex uint32) ([]int, error) {
+	if int(accessorIndex) >= len(doc.Accessors) {
+		return nil, fmt.Errorf("accessor index %d out of range", accessorIndex)
+	}
+	acc := doc.Accessors[accessorIndex]
+	if acc.Type != AccessorTypeScalar {
+		return nil, fmt.Errorf("accessor %d: type %q, want SCALAR", accessorIndex, acc.Type)
+	}
+	if acc.BufferView == nil {
+		return nil, fmt.Errorf("accessor %d has no bufferView", accessorIndex)
+	}
+	if int(*acc.BufferView) >= len(doc.BufferViews) {
+		return nil, fmt.Errorf("bufferView index %d out of range", *acc.BufferView)
+	}
+	view := doc.BufferViews[*acc.BufferView]
+	start := view.ByteOffset + acc.ByteOffset
+	end := view.ByteOffset + view.ByteLength
+	if start > len(bin) || end > len(bin) || start > end {
+		return nil, fmt.Errorf("accessor %d: byteOffset %d is past the end of the binary buffer (%d bytes)", accessorIndex, start, len(bin))
+	}
+	data := bin[start:end]
+
+	var elemSize int
+	switch acc.ComponentType {
+	case ComponentUnsignedShort:
+		elemSize = 2
+	case ComponentUnsignedInt:
+		elemSize = 4
+	case ComponentUnsignedByte:
+		elemSize = 1
+	default:
+		return nil, fmt.Errorf("accessor %d: unsupported index componentType %d", accessorIndex, acc.ComponentType)
+	}
+	if err := checkAccessorBounds(accessorIndex, data, acc.Count, elemSize, elemSize); err != nil {
+		return nil, err
+	}
+
+	out := make([]int, acc.Count)
+	switch acc.ComponentType {
+	case ComponentUnsignedShort:
+		for i := range out {
+			out[i] = int(uint16(data[i*2]) | uint16(data[i*2+1])<<8)
+		}
+	case ComponentUnsignedInt:
+		for i := range out {
+			out[i] = int(uint32(data[i*4]) | uint32(data[i*4+1])<<8 | uint32(data[i*4+2])<<16 | uint32(data[i*4+3])<<24)
+		}
+	case ComponentUnsignedByte:
+		for i := range out {
+			out[i] = int(data[i])
+		}
+	}
+	return out, nil
+}
+
+func accessorBytes(doc *Document, bin []byte, accessorIndex uint32, wantComponent ComponentType, wantType AccessorType) (Accessor, BufferView, []byte, error) {
+	if int(accessorIndex) >= len(doc.Accessors) {
+		return Accessor{}, BufferView{}, nil, fmt.Errorf("accessor index %d out of range", accessorIndex)
+	}
+	acc := doc.Accessors[accessorIndex]
+	if acc.ComponentType != wantComponent || acc.Type != wantType {
+		return Accessor{}, BufferView{}, nil, fmt.Errorf("accessor %d: got componentType=%d type=%q, want componentType=%d type=%q", accessorIndex, acc.ComponentType, acc.Type, wantComponent, wantType)
+	}
+	if acc.BufferView == nil {
+		return Accessor{}, BufferView{}, nil, fmt.Errorf("accessor %d has no bufferView", accessorIndex)
+	}
+	if int(*acc.BufferView) >= len(doc.BufferViews) {
+		return Accessor{}, BufferView{}, nil, fmt.Errorf("bufferView index %d out of range", *acc.BufferView)
+	}
+	view := doc.BufferViews[*acc.BufferView]
+	start := view.ByteOffset + acc.ByteOffset
+	end := view.ByteOffset + view.ByteLength
+	if start > len(bin) || end > len(bin) || start > end {
+		return Accessor{}, BufferView{}, nil, fmt.Errorf("accessor %d: byteOffset %d is past the end of the binary buffer (%d bytes)", accessorIndex, start, len(bin))
+	}
+	return acc, view, bin[start:end], nil
+}
+
+func bytesToFloat32(b []byte) float32 {
+	bits := uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16 | uint32(b[3])<<24
+	return math.Float32frombits(bits)
+}
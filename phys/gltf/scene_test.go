@@ -0,0 +1,533 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+
+package gltf
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/phys"
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
+)
+
+func testScene(t *testing.T) *phys.Scene {
+	t.Helper()
+	faces := []phys.Face{{Vertex: [3]phys.Vertex{
+		{Position: r3.Point{X: 0, Y: 0, Z: 0}},
+		{Position: r3.Point{X: 1, Y: 0, Z: 0}},
+		{Position: r3.Point{X: 0, Y: 1, Z: 0}},
+	}}}
+	mesh, err := phys.NewMesh(faces)
+	if err != nil {
+		t.Fatalf("NewMesh: %v", err)
+	}
+	return &phys.Scene{
+		Camera: []phys.Camera{phys.OrthographicCamera{
+			LookFrom:  r3.Point{X: 0, Y: 0, Z: 5},
+			LookAt:    r3.Point{X: 0, Y: 0, Z: 0},
+			VUp:       r3.Vec{X: 0, Y: 1, Z: 0},
+			FOVHeight: 2,
+			FOVWidth:  2,
+		}},
+		Node: []phys.Node{
+			{
+				Name:      "triangle",
+				Transform: phys.NewTranslation(r3.Vec{X: 1, Y: 2, Z: 3}),
+				Shape:     mesh,
+				Material:  phys.Lambertian{Texture: phys.TextureUniform{Color: phys.Spectrum{X: 0.5, Y: 0.25, Z: 0.1}}},
+			},
+		},
+	}
+}
+
+// TestExportImportRoundTrip verifies a Scene survives Export followed by
+// Import with its node transform, mesh topology, and material color
+// intact.
+func TestExportImportRoundTrip(t *testing.T) {
+	scene := testScene(t)
+	doc, bin, err := Export(scene)
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+
+	got, err := Import(doc, bin)
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+
+	if len(got.Node) != 1 {
+		t.Fatalf("got %d nodes, want 1", len(got.Node))
+	}
+	n := got.Node[0]
+	if n.Name != "triangle" {
+		t.Errorf("node name = %q, want %q", n.Name, "triangle")
+	}
+	translation, _, _ := n.Transform.Decompose()
+	if !translation.IsClose(r3.Vec{X: 1, Y: 2, Z: 3}, 1e-9) {
+		t.Errorf("translation = %+v, want (1,2,3)", translation)
+	}
+	mesh, ok := n.Shape.(*phys.Mesh)
+	if !ok {
+		t.Fatalf("Shape is %T, want *phys.Mesh", n.Shape)
+	}
+	if len(mesh.Face) != 1 {
+		t.Fatalf("got %d faces, want 1", len(mesh.Face))
+	}
+
+	pbr, ok := n.Material.(phys.PBR)
+	if !ok {
+		t.Fatalf("Material is %T, want phys.PBR", n.Material)
+	}
+	color := pbr.BaseColor.At(0, 0)
+	if !r3.Vec(color).IsClose(r3.Vec{X: 0.5, Y: 0.25, Z: 0.1}, 1e-6) {
+		t.Errorf("base color = %+v, want (0.5, 0.25, 0.1)", color)
+	}
+
+	if len(got.Camera) != 1 {
+		t.Fatalf("got %d cameras, want 1", len(got.Camera))
+	}
+	ortho, ok := got.Camera[0].(phys.OrthographicCamera)
+	if !ok {
+		t.Fatalf("Camera is %T, want phys.OrthographicCamera", got.Camera[0])
+	}
+	if !ortho.LookFrom.IsClose(r3.Point{X: 0, Y: 0, Z: 5}, 1e-6) {
+		t.Errorf("LookFrom = %+v, want (0,0,5)", ortho.LookFrom)
+	}
+}
+
+// TestExportImportNormalMapRoundTrip verifies a phys.PBR's NormalMap
+// survives Export followed by Import as the material's glTF
+// normalTexture, the same embedded-image path BaseColorTexture already
+// takes.
+func TestExportImportNormalMapRoundTrip(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	for y := 0; y < 2; y++ {
+		for x := 0; x < 2; x++ {
+			img.Set(x, y, color.RGBA{R: 128, G: 128, B: 255, A: 255})
+		}
+	}
+	scene := testScene(t)
+	scene.Node[0].Material = phys.PBR{
+		BaseColor: phys.TextureUniform{Color: phys.Spectrum{X: 0.5, Y: 0.5, Z: 0.5}},
+		Roughness: 0.5,
+		NormalMap: &phys.TextureNormal{Normal: phys.TextureImage{Image: img, Interp: "bilinear", WrapMode: "repeat"}},
+	}
+
+	doc, bin, err := Export(scene)
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	if doc.Materials[0].NormalTexture == nil {
+		t.Fatalf("exported Material has no normalTexture")
+	}
+
+	got, err := Import(doc, bin)
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	pbr, ok := got.Node[0].Material.(phys.PBR)
+	if !ok {
+		t.Fatalf("Material is %T, want phys.PBR", got.Node[0].Material)
+	}
+	if pbr.NormalMap == nil {
+		t.Fatalf("imported Material has no NormalMap")
+	}
+	c := pbr.NormalMap.Normal.At(0, 0)
+	if !r3.Vec(c).IsClose(r3.Vec{X: 0.5, Y: 0.5, Z: 1}, 0.05) {
+		t.Errorf("normal map color = %+v, want ~(0.5, 0.5, 1)", c)
+	}
+}
+
+// TestEncodeDecodeGLBRoundTrip verifies a Document survives being written
+// to and read back from the single-file .glb binary container.
+func TestEncodeDecodeGLBRoundTrip(t *testing.T) {
+	scene := testScene(t)
+	doc, bin, err := Export(scene)
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := EncodeGLB(&buf, doc, bin); err != nil {
+		t.Fatalf("EncodeGLB: %v", err)
+	}
+
+	gotDoc, gotBin, err := DecodeGLB(&buf)
+	if err != nil {
+		t.Fatalf("DecodeGLB: %v", err)
+	}
+	// EncodeGLB zero-pads the BIN chunk to a 4-byte boundary, so gotBin may
+	// be longer than bin; only the original bytes need to match.
+	if len(gotBin) < len(bin) || !bytes.Equal(gotBin[:len(bin)], bin) {
+		t.Errorf("decoded bin (%d bytes) does not start with encoded bin (%d bytes)", len(gotBin), len(bin))
+	}
+	if len(gotDoc.Nodes) != len(doc.Nodes) {
+		t.Errorf("decoded %d nodes, want %d", len(gotDoc.Nodes), len(doc.Nodes))
+	}
+
+	got, err := Import(gotDoc, gotBin)
+	if err != nil {
+		t.Fatalf("Import after GLB round trip: %v", err)
+	}
+	if len(got.Node) != 1 || got.Node[0].Name != "triangle" {
+		t.Fatalf("unexpected scene after GLB round trip: %+v", got)
+	}
+}
+
+// TestExportUnsupportedShapeErrors verifies Export returns an honest
+// error, rather than silently dropping or panicking, when a Node's Shape
+// isn't a *phys.Mesh or a TransformedShape chain wrapping one.
+func TestExportUnsupportedShapeErrors(t *testing.T) {
+	scene := &phys.Scene{
+		Node: []phys.Node{
+			{Name: "sphere", Shape: phys.Sphere{Radius: 1}, Material: phys.Lambertian{Texture: phys.TextureUniform{}}},
+		},
+	}
+	if _, _, err := Export(scene); err == nil {
+		t.Fatal("Export: expected an error for an unsupported Shape, got nil")
+	}
+}
+
+// TestExportPinholeCameraPerspective verifies Export maps a
+// phys.PinholeCamera onto a glTF "perspective" camera with Yfov derived
+// from the image plane's Vertical span and focal distance, plus a node
+// translated to the camera's Origin.
+func TestExportPinholeCameraPerspective(t *testing.T) {
+	scene := testScene(t)
+	scene.Camera = []phys.Camera{phys.PinholeCamera{
+		Origin:          r3.Point{X: 0, Y: 0, Z: 2},
+		LowerLeftCorner: r3.Point{X: -1, Y: -1, Z: 0},
+		Horizontal:      r3.Vec{X: 2, Y: 0, Z: 0},
+		Vertical:        r3.Vec{X: 0, Y: 2, Z: 0},
+	}}
+
+	doc, _, err := Export(scene)
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	if len(doc.Cameras) != 1 || doc.Cameras[0].Type != "perspective" || doc.Cameras[0].Perspective == nil {
+		t.Fatalf("doc.Cameras = %+v, want one perspective camera", doc.Cameras)
+	}
+	// The image plane is 2 units tall at a focal distance of 2, so the
+	// vertical half-angle is atan(1/2).
+	wantYfov := 2 * math.Atan(0.5)
+	if got := doc.Cameras[0].Perspective.Yfov; math.Abs(got-wantYfov) > 1e-9 {
+		t.Errorf("Perspective.Yfov = %v, want %v", got, wantYfov)
+	}
+	if len(doc.Nodes) == 0 || doc.Nodes[0].Camera == nil {
+		t.Fatal("doc.Nodes[0] does not reference the camera")
+	}
+	wantTranslation := [3]float64{0, 0, 2}
+	if got := doc.Nodes[0].Translation; got != wantTranslation {
+		t.Errorf("camera node Translation = %v, want %v", got, wantTranslation)
+	}
+}
+
+// TestExportImportLightRoundTrip verifies a Scene's PointLight and
+// SpotLight survive Export (as KHR_lights_punctual "point"/"spot" entries)
+// followed by Import, with position, direction, and cone angles intact.
+func TestExportImportLightRoundTrip(t *testing.T) {
+	scene := testScene(t)
+	scene.Light = []phys.Light{
+		phys.PointLight{Position: r3.Point{X: 1, Y: 2, Z: 3}, RadiantIntensity: r3.Vec{X: 2, Y: 4, Z: 6}},
+		phys.SpotLight{
+			Position: r3.Point{X: -1, Y: 0, Z: 0}, Direction: r3.Vec{X: 0, Y: -1, Z: 0},
+			RadiantIntensity: r3.Vec{X: 1, Y: 1, Z: 1},
+			InnerConeAngle:   0.1, OuterConeAngle: 0.5,
+		},
+	}
+
+	doc, bin, err := Export(scene)
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	if len(doc.ExtensionsUsed) != 1 || doc.ExtensionsUsed[0] != extKHRLightsPunctual {
+		t.Errorf("ExtensionsUsed = %v, want [%q]", doc.ExtensionsUsed, extKHRLightsPunctual)
+	}
+
+	got, err := Import(doc, bin)
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if len(got.Light) != 2 {
+		t.Fatalf("got %d lights, want 2", len(got.Light))
+	}
+
+	pl, ok := got.Light[0].(phys.PointLight)
+	if !ok {
+		t.Fatalf("Light[0] is %T, want phys.PointLight", got.Light[0])
+	}
+	if !pl.Position.IsClose(r3.Point{X: 1, Y: 2, Z: 3}, 1e-6) {
+		t.Errorf("PointLight.Position = %+v, want (1,2,3)", pl.Position)
+	}
+	if !r3.Vec(pl.RadiantIntensity).IsClose(r3.Vec{X: 2, Y: 4, Z: 6}, 1e-6) {
+		t.Errorf("PointLight.RadiantIntensity = %+v, want (2,4,6)", pl.RadiantIntensity)
+	}
+
+	sl, ok := got.Light[1].(phys.SpotLight)
+	if !ok {
+		t.Fatalf("Light[1] is %T, want phys.SpotLight", got.Light[1])
+	}
+	if !sl.Position.IsClose(r3.Point{X: -1, Y: 0, Z: 0}, 1e-6) {
+		t.Errorf("SpotLight.Position = %+v, want (-1,0,0)", sl.Position)
+	}
+	if !sl.Direction.IsClose(r3.Vec{X: 0, Y: -1, Z: 0}, 1e-6) {
+		t.Errorf("SpotLight.Direction = %+v, want (0,-1,0)", sl.Direction)
+	}
+	if math.Abs(sl.InnerConeAngle-0.1) > 1e-6 || math.Abs(sl.OuterConeAngle-0.5) > 1e-6 {
+		t.Errorf("SpotLight cone angles = (%v, %v), want (0.1, 0.5)", sl.InnerConeAngle, sl.OuterConeAngle)
+	}
+}
+
+// TestExportImportZeroAngleSpotLight verifies a SpotLight whose
+// OuterConeAngle is genuinely zero (a valid, if degenerate, cone per
+// SpotLight.Validate) round-trips as zero rather than being confused with
+// an omitted field and replaced by the glTF spec's pi/4 default.
+func TestExportImportZeroAngleSpotLight(t *testing.T) {
+	scene := testScene(t)
+	scene.Light = []phys.Light{phys.SpotLight{
+		Position: r3.Point{X: 0, Y: 1, Z: 0}, Direction: r3.Vec{X: 0, Y: -1, Z: 0},
+		RadiantIntensity: r3.Vec{X: 1, Y: 1, Z: 1},
+		InnerConeAngle:   0, OuterConeAngle: 0,
+	}}
+
+	doc, bin, err := Export(scene)
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	got, err := Import(doc, bin)
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if len(got.Light) != 1 {
+		t.Fatalf("got %d lights, want 1", len(got.Light))
+	}
+	sl, ok := got.Light[0].(phys.SpotLight)
+	if !ok {
+		t.Fatalf("Light[0] is %T, want phys.SpotLight", got.Light[0])
+	}
+	if sl.OuterConeAngle != 0 {
+		t.Errorf("OuterConeAngle = %v, want 0 (should not be replaced by the spec default)", sl.OuterConeAngle)
+	}
+}
+
+// TestExportImportSunLightRoundTrip verifies a phys.Sun round-trips
+// through Export/Import as a KHR_lights_punctual "directional" light,
+// preserving its SunDirection (via the light node's Rotation) and
+// RadiantIntensity.
+func TestExportImportSunLightRoundTrip(t *testing.T) {
+	scene := testScene(t)
+	scene.Light = []phys.Light{phys.Sun{
+		SunDirection:     r3.Vec{X: 0, Y: 1, Z: 0},
+		RadiantIntensity: r3.Vec{X: 3, Y: 2, Z: 1},
+	}}
+
+	doc, bin, err := Export(scene)
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	if len(doc.Extensions.KHRLightsPunctual.Lights) != 1 || doc.Extensions.KHRLightsPunctual.Lights[0].Type != "directional" {
+		t.Fatalf("exported light type = %q, want \"directional\"", doc.Extensions.KHRLightsPunctual.Lights[0].Type)
+	}
+
+	got, err := Import(doc, bin)
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if len(got.Light) != 1 {
+		t.Fatalf("got %d lights, want 1", len(got.Light))
+	}
+	sun, ok := got.Light[0].(phys.Sun)
+	if !ok {
+		t.Fatalf("Light[0] is %T, want phys.Sun", got.Light[0])
+	}
+	if !sun.SunDirection.IsClose(r3.Vec{X: 0, Y: 1, Z: 0}, 1e-6) {
+		t.Errorf("Sun.SunDirection = %+v, want (0,1,0)", sun.SunDirection)
+	}
+	if !sun.RadiantIntensity.IsClose(r3.Vec{X: 3, Y: 2, Z: 1}, 1e-6) {
+		t.Errorf("Sun.RadiantIntensity = %+v, want (3,2,1)", sun.RadiantIntensity)
+	}
+}
+
+// TestImportBakesNestedNodeTransform verifies a mesh node nested two
+// levels below a glTF scene root (via Children) lands at the composed
+// world transform of its ancestors, not at its own untransformed local
+// position -- the hierarchy baking importNodeRecursive exists to do, since
+// phys.Scene itself has no parent/child relationship to fall back on.
+func TestImportBakesNestedNodeTransform(t *testing.T) {
+	faces := []phys.Face{{Vertex: [3]phys.Vertex{
+		{Position: r3.Point{X: 0, Y: 0, Z: 0}},
+		{Position: r3.Point{X: 1, Y: 0, Z: 0}},
+		{Position: r3.Point{X: 0, Y: 1, Z: 0}},
+	}}}
+	mesh, err := phys.NewMesh(faces)
+	if err != nil {
+		t.Fatalf("NewMesh: %v", err)
+	}
+	b := newBuilder()
+	meshIndex, err := b.convertMesh(mesh, nil)
+	if err != nil {
+		t.Fatalf("convertMesh: %v", err)
+	}
+	leaf := uint32(len(b.doc.Nodes))
+	b.doc.Nodes = append(b.doc.Nodes, Node{Name: "leaf", Mesh: &meshIndex, Translation: [3]float64{1, 0, 0}, Scale: [3]float64{1, 1, 1}})
+	mid := uint32(len(b.doc.Nodes))
+	b.doc.Nodes = append(b.doc.Nodes, Node{Name: "mid", Children: []uint32{leaf}, Translation: [3]float64{0, 10, 0}, Scale: [3]float64{1, 1, 1}})
+	root := uint32(len(b.doc.Nodes))
+	b.doc.Nodes = append(b.doc.Nodes, Node{Name: "root", Children: []uint32{mid}, Translation: [3]float64{100, 0, 0}, Scale: [3]float64{1, 1, 1}})
+	b.doc.Scene = uint32Ptr(0)
+	b.doc.Scenes = []Scene{{Nodes: []uint32{root}}}
+
+	got, err := Import(b.doc, b.bin)
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if len(got.Node) != 1 {
+		t.Fatalf("got %d nodes, want 1", len(got.Node))
+	}
+	want := r3.Vec{X: 101, Y: 10, Z: 0}
+	translation, _, _ := got.Node[0].Transform.Decompose()
+	if !translation.IsClose(want, 1e-9) {
+		t.Errorf("nested node world translation = %+v, want %+v", translation, want)
+	}
+}
+
+// TestImportDetectsNodeCycle verifies Import errors instead of looping
+// forever on a Document whose node hierarchy (illegally, per the glTF
+// spec) contains a cycle.
+func TestImportDetectsNodeCycle(t *testing.T) {
+	doc := &Document{
+		Nodes:  []Node{{Children: []uint32{1}}, {Children: []uint32{0}}},
+		Scene:  uint32Ptr(0),
+		Scenes: []Scene{{Nodes: []uint32{0}}},
+	}
+	if _, err := Import(doc, nil); err == nil {
+		t.Fatal("Import: expected an error for a cyclic node hierarchy, got nil")
+	}
+}
+
+func uint32Ptr(v uint32) *uint32 { return &v }
+
+// TestImportPromotesVertexNormals verifies Import reads a Primitive's
+// NORMAL accessor into Vertex.Normal rather than leaving it at its zero
+// value -- Export's own convertMesh always writes one (the face's flat
+// geometric normal, repeated per corner), so a round trip should recover
+// it.
+func TestImportPromotesVertexNormals(t *testing.T) {
+	scene := testScene(t)
+	doc, bin, err := Export(scene)
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	got, err := Import(doc, bin)
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	mesh, ok := got.Node[0].Shape.(*phys.Mesh)
+	if !ok {
+		t.Fatalf("Shape is %T, want *phys.Mesh", got.Node[0].Shape)
+	}
+	want := r3.Vec{X: 0, Y: 0, Z: 1} // The test triangle lies in the XY plane.
+	for i, v := range mesh.Face[0].Vertex {
+		if v.Normal.IsZero() {
+			t.Fatalf("Vertex[%d].Normal is zero, want the imported NORMAL accessor value", i)
+		}
+		if !v.Normal.IsClose(want, 1e-5) {
+			t.Errorf("Vertex[%d].Normal = %+v, want %+v", i, v.Normal, want)
+		}
+	}
+}
+
+// TestConvertGLTFToNodesReturnsSceneNodes verifies ConvertGLTFToNodes is
+// a thin wrapper around Import that hands back just the Node slice.
+func TestConvertGLTFToNodesReturnsSceneNodes(t *testing.T) {
+	scene := testScene(t)
+	doc, bin, err := Export(scene)
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	nodes, err := ConvertGLTFToNodes(doc, bin)
+	if err != nil {
+		t.Fatalf("ConvertGLTFToNodes: %v", err)
+	}
+	if len(nodes) != 1 || nodes[0].Name != "triangle" {
+		t.Fatalf("ConvertGLTFToNodes = %+v, want one node named %q", nodes, "triangle")
+	}
+}
+
+// morphTargetBuilder builds a single-triangle Document with one POSITION
+// morph target displacing every vertex by +5 along Z, the minimal
+// Document importMorphedMesh needs since Export itself never emits
+// Targets.
+func morphTargetBuilder() *builder {
+	b := newBuilder()
+	positions := []float32{0, 0, 0, 1, 0, 0, 0, 1, 0}
+	deltas := []float32{0, 0, 5, 0, 0, 5, 0, 0, 5}
+	indices := []uint16{0, 1, 2}
+
+	posView := b.addBufferView(float32sToBytes(positions), TargetArrayBuffer)
+	posAccessor := uint32(len(b.doc.Accessors))
+	b.doc.Accessors = append(b.doc.Accessors, Accessor{
+		BufferView: &posView, ComponentType: ComponentFloat, Count: 3, Type: AccessorTypeVec3,
+	})
+
+	targetView := b.addBufferView(float32sToBytes(deltas), TargetArrayBuffer)
+	targetAccessor := uint32(len(b.doc.Accessors))
+	b.doc.Accessors = append(b.doc.Accessors, Accessor{
+		BufferView: &targetView, ComponentType: ComponentFloat, Count: 3, Type: AccessorTypeVec3,
+	})
+
+	idxView := b.addBufferView(uint16sToBytes(indices), TargetElementArrayBuffer)
+	idxAccessor := uint32(len(b.doc.Accessors))
+	b.doc.Accessors = append(b.doc.Accessors, Accessor{
+		BufferView: &idxView, ComponentType: ComponentUnsignedShort, Count: len(indices), Type: AccessorTypeScalar,
+	})
+
+	mode := PrimitiveModeTriangles
+	meshIndex := uint32(len(b.doc.Meshes))
+	b.doc.Meshes = append(b.doc.Meshes, Mesh{
+		Primitives: []Primitive{{
+			Attributes: map[string]uint32{"POSITION": posAccessor},
+			Indices:    &idxAccessor,
+			Mode:       &mode,
+			Targets:    []map[string]uint32{{"POSITION": targetAccessor}},
+		}},
+	})
+
+	nodeIndex := uint32(len(b.doc.Nodes))
+	b.doc.Nodes = append(b.doc.Nodes, Node{Name: "morphed", Mesh: &meshIndex, Scale: [3]float64{1, 1, 1}})
+	b.doc.Buffers = append(b.doc.Buffers, Buffer{ByteLength: len(b.bin)})
+	b.doc.Scene = uint32Ptr(0)
+	b.doc.Scenes = []Scene{{Nodes: []uint32{nodeIndex}}}
+	return b
+}
+
+// TestImportMorphTargetsProducesMorphedMesh verifies a Primitive with a
+// non-empty Targets imports as a *phys.MorphedMesh whose base pose and
+// morph target match the Document's POSITION and target accessors.
+func TestImportMorphTargetsProducesMorphedMesh(t *testing.T) {
+	b := morphTargetBuilder()
+	got, err := Import(b.doc, b.bin)
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if len(got.Node) != 1 {
+		t.Fatalf("got %d nodes, want 1", len(got.Node))
+	}
+	morphed, ok := got.Node[0].Shape.(*phys.MorphedMesh)
+	if !ok {
+		t.Fatalf("Shape is %T, want *phys.MorphedMesh", got.Node[0].Shape)
+	}
+	if len(morphed.P) != 3 {
+		t.Fatalf("got %d base vertices, want 3", len(morphed.P))
+	}
+	if len(morphed.D) != 1 {
+		t.Fatalf("got %d morph targets, want 1", len(morphed.D))
+	}
+	if !morphed.D[0][0].IsClose(r3.Vec{Z: 5}, 1e-5) {
+		t.Errorf("target 0 displacement[0] = %+v, want (0,0,5)", morphed.D[0][0])
+	}
+}
@@ -0,0 +1,270 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+
+package gltf
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ValidationError is one structural violation found by Validate, naming
+// the offending value's location as a JSON pointer (RFC 6901), e.g.
+// "/nodes/3/children/1".
+type ValidationError struct {
+	Path string
+	Err  error
+}
+
+func (e *ValidationError) Error() string { return fmt.Sprintf("%s: %v", e.Path, e.Err) }
+
+func (e *ValidationError) Unwrap() error { return e.Err }
+
+// Validator accumulates every ValidationError Validate finds, rather
+// than stopping at the first, so a caller building a diagnostics UI over
+// this package can surface every violation from one pass. A Validator
+// with no Errors is not itself returned by Validate; see Document.Validate.
+type Validator struct {
+	Errors []*ValidationError
+}
+
+func (v *Validator) fail(path, format string, args ...interface{}) {
+	v.Errors = append(v.Errors, &ValidationError{Path: path, Err: fmt.Errorf(format, args...)})
+}
+
+// Error renders every accumulated ValidationError as one semicolon
+// separated line, satisfying the error interface so a Validator can be
+// returned directly from Document.Validate.
+func (v *Validator) Error() string {
+	msgs := make([]string, len(v.Errors))
+	for i, e := range v.Errors {
+		msgs[i] = e.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Validate checks doc against the glTF 2.0 structural invariants that
+// encoding/json's struct tags and this package's own UnmarshalJSON
+// methods can't enforce on their own: asset.version is present and
+// parses as "major.minor", minVersion (if present) is <= version, the
+// scene graph is a single-parent forest with no cycles, Node.Matrix and
+// TRS are mutually exclusive, every cross-referencing index (bufferView,
+// material, source, sampler, mesh, camera, ...) is in range, sparse
+// accessor counts agree with their sub-views, Material.AlphaCutoff is
+// only set alongside AlphaModeMask, and ExtensionsRequired is a subset
+// of ExtensionsUsed. It returns nil if doc is valid, or a *Validator
+// (satisfying error) listing every violation found.
+func (doc *Document) Validate() error {
+	v := &Validator{}
+	doc.validateAsset(v)
+	doc.validateNodes(v)
+	doc.validateAccessors(v)
+	doc.validateMaterials(v)
+	doc.validateReferences(v)
+	doc.validateExtensions(v)
+	if len(v.Errors) == 0 {
+		return nil
+	}
+	return v
+}
+
+// parseGLTFVersion parses a glTF "major.minor" version string, the form
+// both asset.version and asset.minVersion use.
+func parseGLTFVersion(s string) (major, minor int, err error) {
+	parts := strings.SplitN(s, ".", 2)
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("version %q is not of the form \"major.minor\"", s)
+	}
+	major, err = strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, fmt.Errorf("version %q: bad major component: %w", s, err)
+	}
+	minor, err = strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, 0, fmt.Errorf("version %q: bad minor component: %w", s, err)
+	}
+	return major, minor, nil
+}
+
+func (doc *Document) validateAsset(v *Validator) {
+	if doc.Asset.Version == "" {
+		v.fail("/asset/version", "required field is empty")
+		return
+	}
+	major, minor, err := parseGLTFVersion(doc.Asset.Version)
+	if err != nil {
+		v.fail("/asset/version", "%v", err)
+		return
+	}
+	if doc.Asset.MinVersion == "" {
+		return
+	}
+	minMajor, minMinor, err := parseGLTFVersion(doc.Asset.MinVersion)
+	if err != nil {
+		v.fail("/asset/minVersion", "%v", err)
+		return
+	}
+	if minMajor > major || (minMajor == major && minMinor > minor) {
+		v.fail("/asset/minVersion", "minVersion %q exceeds version %q", doc.Asset.MinVersion, doc.Asset.Version)
+	}
+}
+
+// validateNodes checks that doc.Nodes forms a forest -- every node has
+// at most one parent, every Children/Scene.Nodes index is in range, a
+// root listed in a Scene is not also someone's child, and there is no
+// cycle -- plus, per node, that Matrix (if present) is not combined with
+// an explicit TRS component. The cycle check mirrors importNodeRecursive
+// in scene.go: a map of node indices on the current recursion path,
+// added before descending into children and removed via defer on the
+// way back out.
+func (doc *Document) validateNodes(v *Validator) {
+	n := len(doc.Nodes)
+	parent := make(map[uint32]int) // child index -> parent index, for "more than one parent" detection.
+
+	for i, node := range doc.Nodes {
+		path := fmt.Sprintf("/nodes/%d", i)
+		if node.Matrix != nil && (node.Translation != [3]float64{} || node.Rotation != [4]float64{} || node.Scale != [3]float64{}) {
+			v.fail(path, "specifies both matrix and an explicit translation/rotation/scale")
+		}
+		for ci, child := range node.Children {
+			childPath := fmt.Sprintf("%s/children/%d", path, ci)
+			if int(child) >= n {
+				v.fail(childPath, "child index %d out of range (have %d nodes)", child, n)
+				continue
+			}
+			if int(child) == i {
+				v.fail(childPath, "node is its own child")
+				continue
+			}
+			if p, ok := parent[child]; ok {
+				v.fail(childPath, "node %d already has parent %d", child, p)
+				continue
+			}
+			parent[child] = i
+		}
+	}
+
+	for si, scene := range doc.Scenes {
+		for ni, root := range scene.Nodes {
+			path := fmt.Sprintf("/scenes/%d/nodes/%d", si, ni)
+			if int(root) >= n {
+				v.fail(path, "node index %d out of range (have %d nodes)", root, n)
+				continue
+			}
+			if p, ok := parent[root]; ok {
+				v.fail(path, "node %d is a scene root but is also child of node %d", root, p)
+			}
+		}
+	}
+
+	visiting := make(map[uint32]bool)
+	var visit func(i uint32, path string)
+	visit = func(i uint32, path string) {
+		if int(i) >= n || visiting[i] {
+			return // Already reported as out of range, or a cycle already reported below.
+		}
+		visiting[i] = true
+		defer delete(visiting, i)
+		for ci, child := range doc.Nodes[i].Children {
+			childPath := fmt.Sprintf("%s/children/%d", path, ci)
+			if visiting[child] {
+				v.fail(childPath, "cycle detected: node %d reappears in its own ancestry", child)
+				continue
+			}
+			visit(child, fmt.Sprintf("/nodes/%d", child))
+		}
+	}
+	for si, scene := range doc.Scenes {
+		for ni, root := range scene.Nodes {
+			visit(root, fmt.Sprintf("/scenes/%d/nodes/%d", si, ni))
+		}
+	}
+}
+
+func (doc *Document) validateAccessors(v *Validator) {
+	for i, acc := range doc.Accessors {
+		path := fmt.Sprintf("/accessors/%d", i)
+		if acc.BufferView != nil && int(*acc.BufferView) >= len(doc.BufferViews) {
+			v.fail(path+"/bufferView", "index %d out of range (have %d bufferViews)", *acc.BufferView, len(doc.BufferViews))
+		}
+		if acc.Sparse == nil {
+			continue
+		}
+		sparse := acc.Sparse
+		if sparse.Count <= 0 || sparse.Count > acc.Count {
+			v.fail(path+"/sparse/count", "count %d must be > 0 and <= accessor count %d", sparse.Count, acc.Count)
+		}
+		if int(sparse.Indices.BufferView) >= len(doc.BufferViews) {
+			v.fail(path+"/sparse/indices/bufferView", "index %d out of range (have %d bufferViews)", sparse.Indices.BufferView, len(doc.BufferViews))
+		}
+		if int(sparse.Values.BufferView) >= len(doc.BufferViews) {
+			v.fail(path+"/sparse/values/bufferView", "index %d out of range (have %d bufferViews)", sparse.Values.BufferView, len(doc.BufferViews))
+		}
+	}
+}
+
+func (doc *Document) validateMaterials(v *Validator) {
+	for i, mat := range doc.Materials {
+		if mat.AlphaCutoff != nil && mat.AlphaMode != AlphaModeMask {
+			v.fail(fmt.Sprintf("/materials/%d/alphaCutoff", i), "only meaningful when alphaMode is %q, got %q", AlphaModeMask, mat.AlphaMode)
+		}
+	}
+}
+
+// validateReferences checks every index field this package models that
+// Validate's more specialized passes don't already cover: Node.Mesh/
+// Camera, Mesh.Primitive.Material, Texture.Source/Sampler, and
+// BufferView.Buffer.
+func (doc *Document) validateReferences(v *Validator) {
+	for i, node := range doc.Nodes {
+		path := fmt.Sprintf("/nodes/%d", i)
+		if node.Mesh != nil && int(*node.Mesh) >= len(doc.Meshes) {
+			v.fail(path+"/mesh", "index %d out of range (have %d meshes)", *node.Mesh, len(doc.Meshes))
+		}
+		if node.Camera != nil && int(*node.Camera) >= len(doc.Cameras) {
+			v.fail(path+"/camera", "index %d out of range (have %d cameras)", *node.Camera, len(doc.Cameras))
+		}
+	}
+	for i, mesh := range doc.Meshes {
+		for pi, prim := range mesh.Primitives {
+			path := fmt.Sprintf("/meshes/%d/primitives/%d", i, pi)
+			if prim.Material != nil && int(*prim.Material) >= len(doc.Materials) {
+				v.fail(path+"/material", "index %d out of range (have %d materials)", *prim.Material, len(doc.Materials))
+			}
+			if prim.Indices != nil && int(*prim.Indices) >= len(doc.Accessors) {
+				v.fail(path+"/indices", "index %d out of range (have %d accessors)", *prim.Indices, len(doc.Accessors))
+			}
+			for attr, acc := range prim.Attributes {
+				if int(acc) >= len(doc.Accessors) {
+					v.fail(fmt.Sprintf("%s/attributes/%s", path, attr), "index %d out of range (have %d accessors)", acc, len(doc.Accessors))
+				}
+			}
+		}
+	}
+	for i, tex := range doc.Textures {
+		path := fmt.Sprintf("/textures/%d", i)
+		if tex.Source != nil && int(*tex.Source) >= len(doc.Images) {
+			v.fail(path+"/source", "index %d out of range (have %d images)", *tex.Source, len(doc.Images))
+		}
+		if tex.Sampler != nil && int(*tex.Sampler) >= len(doc.Samplers) {
+			v.fail(path+"/sampler", "index %d out of range (have %d samplers)", *tex.Sampler, len(doc.Samplers))
+		}
+	}
+	for i, bv := range doc.BufferViews {
+		if int(bv.Buffer) >= len(doc.Buffers) {
+			v.fail(fmt.Sprintf("/bufferViews/%d/buffer", i), "index %d out of range (have %d buffers)", bv.Buffer, len(doc.Buffers))
+		}
+	}
+}
+
+func (doc *Document) validateExtensions(v *Validator) {
+	used := make(map[string]bool, len(doc.ExtensionsUsed))
+	for _, name := range doc.ExtensionsUsed {
+		used[name] = true
+	}
+	for i, name := range doc.ExtensionsRequired {
+		if !used[name] {
+			v.fail(fmt.Sprintf("/extensionsRequired/%d", i), "extension %q is required but not listed in extensionsUsed", name)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+
+package gltf
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestValidateExportedDocument verifies a Document produced by Export on
+// an ordinary Scene passes Validate outright, so Validate doesn't flag
+// this package's own well-formed output.
+func TestValidateExportedDocument(t *testing.T) {
+	doc, _, err := Export(testScene(t))
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	if err := doc.Validate(); err != nil {
+		t.Fatalf("Validate of an Export'd Document: %v", err)
+	}
+}
+
+// TestValidateAssetVersion covers asset.version/minVersion: missing,
+// malformed, and minVersion exceeding version should each fail with a
+// /asset/... path; a valid pair should not.
+func TestValidateAssetVersion(t *testing.T) {
+	base := func() *Document { return &Document{Asset: Asset{Version: "2.0"}} }
+
+	tests := []struct {
+		name    string
+		doc     *Document
+		wantErr bool
+		path    string
+	}{
+		{"empty version", &Document{Asset: Asset{Version: ""}}, true, "/asset/version"},
+		{"malformed version", &Document{Asset: Asset{Version: "two"}}, true, "/asset/version"},
+		{"minVersion exceeds version", &Document{Asset: Asset{Version: "2.0", MinVersion: "2.1"}}, true, "/asset/minVersion"},
+		{"valid minVersion", &Document{Asset: Asset{Version: "2.0", MinVersion: "1.0"}}, false, ""},
+		{"valid, no minVersion", base(), false, ""},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.doc.Validate()
+			if tc.wantErr && err == nil {
+				t.Fatal("Validate: got nil error, want non-nil")
+			}
+			if !tc.wantErr && err != nil {
+				t.Fatalf("Validate: %v, want nil", err)
+			}
+			if tc.wantErr && !strings.Contains(err.Error(), tc.path) {
+				t.Errorf("Validate error %q does not mention path %q", err, tc.path)
+			}
+		})
+	}
+}
+
+// TestValidateNodeHierarchy covers the scene-graph forest invariants: a
+// child with two parents, a child index out of range, a scene root that
+// is also someone's child, and a cycle.
+func TestValidateNodeHierarchy(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  *Document
+		path string
+	}{
+		{
+			name: "child out of range",
+			doc: &Document{
+				Asset: Asset{Version: "2.0"},
+				Nodes: []Node{{Children: []uint32{5}}},
+			},
+			path: "/nodes/0/children/0",
+		},
+		{
+			name: "node with two parents",
+			doc: &Document{
+				Asset: Asset{Version: "2.0"},
+				Nodes: []Node{{Children: []uint32{2}}, {Children: []uint32{2}}, {}},
+			},
+			path: "/nodes/1/children/0",
+		},
+		{
+			name: "scene root is also a child",
+			doc: &Document{
+				Asset:  Asset{Version: "2.0"},
+				Scenes: []Scene{{Nodes: []uint32{1}}},
+				Nodes:  []Node{{Children: []uint32{1}}, {}},
+			},
+			path: "/scenes/0/nodes/0",
+		},
+		{
+			name: "cycle",
+			doc: &Document{
+				Asset:  Asset{Version: "2.0"},
+				Scenes: []Scene{{Nodes: []uint32{0}}},
+				Nodes:  []Node{{Children: []uint32{1}}, {Children: []uint32{0}}},
+			},
+			path: "/nodes/1/children/0",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.doc.Validate()
+			if err == nil {
+				t.Fatal("Validate: got nil error, want non-nil")
+			}
+			if !strings.Contains(err.Error(), tc.path) {
+				t.Errorf("Validate error %q does not mention path %q", err, tc.path)
+			}
+		})
+	}
+}
+
+// TestValidateNodeMatrixExclusiveWithTRS verifies a Node combining
+// Matrix with a non-identity Translation is rejected.
+func TestValidateNodeMatrixExclusiveWithTRS(t *testing.T) {
+	doc := &Document{
+		Asset: Asset{Version: "2.0"},
+		Nodes: []Node{{
+			Matrix:      &[16]float64{1, 0, 0, 0, 0, 1, 0, 0, 0, 0, 1, 0, 0, 0, 0, 1},
+			Translation: [3]float64{1, 0, 0},
+		}},
+	}
+	err := doc.Validate()
+	if err == nil {
+		t.Fatal("Validate: got nil error, want non-nil")
+	}
+	if !strings.Contains(err.Error(), "/nodes/0") {
+		t.Errorf("Validate error %q does not mention /nodes/0", err)
+	}
+}
+
+// TestValidateOutOfRangeIndices covers the cross-referencing index
+// checks: an Accessor.BufferView, a Primitive.Material, and a
+// Texture.Source that each point past the end of their target array.
+func TestValidateOutOfRangeIndices(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  *Document
+		path string
+	}{
+		{
+			name: "accessor bufferView",
+			doc: &Document{
+				Asset:     Asset{Version: "2.0"},
+				Accessors: []Accessor{{BufferView: Index(0), ComponentType: ComponentFloat, Count: 1, Type: AccessorTypeScalar}},
+			},
+			path: "/accessors/0/bufferView",
+		},
+		{
+			name: "primitive material",
+			doc: &Document{
+				Asset:  Asset{Version: "2.0"},
+				Meshes: []Mesh{{Primitives: []Primitive{{Material: Index(0)}}}},
+			},
+			path: "/meshes/0/primitives/0/material",
+		},
+		{
+			name: "texture source",
+			doc: &Document{
+				Asset:    Asset{Version: "2.0"},
+				Textures: []Texture{{Source: Index(0)}},
+			},
+			path: "/textures/0/source",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.doc.Validate()
+			if err == nil {
+				t.Fatal("Validate: got nil error, want non-nil")
+			}
+			if !strings.Contains(err.Error(), tc.path) {
+				t.Errorf("Validate error %q does not mention path %q", err, tc.path)
+			}
+		})
+	}
+}
+
+// TestValidateAlphaCutoffRequiresMaskMode verifies AlphaCutoff is only
+// accepted alongside AlphaModeMask.
+func TestValidateAlphaCutoffRequiresMaskMode(t *testing.T) {
+	doc := &Document{
+		Asset:     Asset{Version: "2.0"},
+		Materials: []Material{{AlphaMode: AlphaModeOpaque, AlphaCutoff: Float(0.5)}},
+	}
+	err := doc.Validate()
+	if err == nil {
+		t.Fatal("Validate: got nil error, want non-nil")
+	}
+	if !strings.Contains(err.Error(), "/materials/0/alphaCutoff") {
+		t.Errorf("Validate error %q does not mention /materials/0/alphaCutoff", err)
+	}
+
+	doc.Materials[0].AlphaMode = AlphaModeMask
+	if err := doc.Validate(); err != nil {
+		t.Errorf("Validate with AlphaModeMask: %v, want nil", err)
+	}
+}
+
+// TestValidateExtensionsRequiredSubsetOfUsed verifies an extension named
+// in ExtensionsRequired but missing from ExtensionsUsed is rejected.
+func TestValidateExtensionsRequiredSubsetOfUsed(t *testing.T) {
+	doc := &Document{
+		Asset:              Asset{Version: "2.0"},
+		ExtensionsRequired: []string{extKHRLightsPunctual},
+	}
+	err := doc.Validate()
+	if err == nil {
+		t.Fatal("Validate: got nil error, want non-nil")
+	}
+	if !strings.Contains(err.Error(), "/extensionsRequired/0") {
+		t.Errorf("Validate error %q does not mention /extensionsRequired/0", err)
+	}
+
+	doc.ExtensionsUsed = []string{extKHRLightsPunctual}
+	if err := doc.Validate(); err != nil {
+		t.Errorf("Validate with matching extensionsUsed: %v, want nil", err)
+	}
+}
@@ -0,0 +1,132 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+
+// This file lives in package phys_test (not phys) so it can import
+// phys/testutil, which itself imports phys -- package phys's own
+// _test.go files can't do that without an import cycle.
+package phys_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/phys"
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/phys/testutil"
+)
+
+// TestGoldenBall renders testdata/golden_ball.json and compares it
+// against testdata/golden_ball.png, catching a BRDF/geometry regression
+// a narrower unit test wouldn't notice. Run with
+// PHYS_GOLDEN_UPDATE=1 go test ./phys -run TestGoldenBall
+// to regenerate the golden PNG after an intentional rendering change.
+func TestGoldenBall(t *testing.T) {
+	testutil.AssertGolden(t, context.Background(), "testdata/golden_ball.json", "testdata/golden_ball.png", 2)
+}
+
+// TestGoldenBallStableAcrossTileSize renders testdata/golden_ball.json at
+// several different RenderOptions.TileSize values and checks every run
+// produces a byte-identical image, the property RenderPixel's per-sample
+// NewPixelRand seeding (instead of one *Rand shared per tile row) exists
+// to guarantee.
+func TestGoldenBallStableAcrossTileSize(t *testing.T) {
+	ctx := context.Background()
+	var reference phys.RenderArtifact
+	var haveReference bool
+	for _, tileSize := range []int{0, 4, 7, 32} {
+		scene, err := phys.LoadScene("testdata/golden_ball.json")
+		if err != nil {
+			t.Fatalf("LoadScene: %v", err)
+		}
+		scene.RenderOptions.TileSize = tileSize
+		art, err := phys.Render(ctx, scene)
+		if err != nil {
+			t.Fatalf("Render(TileSize=%d): %v", tileSize, err)
+		}
+		if !haveReference {
+			reference = art
+			haveReference = true
+			continue
+		}
+		if diff := testutil.ComparePNG(art.Image, reference.Image, 0); diff != "" {
+			t.Errorf("Render(TileSize=%d) differs from TileSize=0: %s", tileSize, diff)
+		}
+	}
+}
+
+// TestGoldenBallProgressiveMatchesSingleCall renders testdata/golden_ball.json
+// (RaysPerPixel=8) once normally and once through OnPass with
+// PassCount=4, SamplesPerPass=2, and checks the two images are
+// byte-identical -- the invariant pixelWelford.rgb's plain running sum
+// (instead of Welford's incremental mean update) exists to guarantee,
+// since PassCount*SamplesPerPass == RaysPerPixel here.
+func TestGoldenBallProgressiveMatchesSingleCall(t *testing.T) {
+	ctx := context.Background()
+
+	single, err := phys.LoadScene("testdata/golden_ball.json")
+	if err != nil {
+		t.Fatalf("LoadScene: %v", err)
+	}
+	reference, err := phys.Render(ctx, single)
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+
+	progressive, err := phys.LoadScene("testdata/golden_ball.json")
+	if err != nil {
+		t.Fatalf("LoadScene: %v", err)
+	}
+	var passes int
+	progressive.RenderOptions.PassCount = 4
+	progressive.RenderOptions.SamplesPerPass = 2
+	progressive.RenderOptions.OnPass = func(pass int, partial *phys.RenderArtifact) error {
+		passes = pass
+		return nil
+	}
+	art, err := phys.Render(ctx, progressive)
+	if err != nil {
+		t.Fatalf("Render (progressive): %v", err)
+	}
+	if passes != 4 {
+		t.Errorf("OnPass last called with pass=%d, want 4", passes)
+	}
+	if diff := testutil.ComparePNG(art.Image, reference.Image, 0); diff != "" {
+		t.Errorf("progressive render differs from single-call render: %s", diff)
+	}
+}
+
+// TestPathtracerProgressiveMatchesOnPass checks that PathtracerProgressive
+// produces the same image and calls its callback with the same pass
+// numbers as setting RenderOptions.OnPass directly -- PathtracerProgressive
+// is just a named entry point over that field, not a separate render path.
+func TestPathtracerProgressiveMatchesOnPass(t *testing.T) {
+	ctx := context.Background()
+
+	scene, err := phys.LoadScene("testdata/golden_ball.json")
+	if err != nil {
+		t.Fatalf("LoadScene: %v", err)
+	}
+	scene.RenderOptions.PassCount = 4
+	scene.RenderOptions.SamplesPerPass = 2
+
+	var passes []int
+	art, err := phys.PathtracerProgressive(ctx, scene, func(pass int, partial *phys.RenderArtifact) error {
+		passes = append(passes, pass)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("PathtracerProgressive: %v", err)
+	}
+	if want := []int{1, 2, 3, 4}; len(passes) != len(want) {
+		t.Errorf("PathtracerProgressive called back with passes=%v, want %v", passes, want)
+	}
+	if scene.RenderOptions.OnPass != nil {
+		t.Error("PathtracerProgressive mutated scene.RenderOptions.OnPass, want the original scene left untouched")
+	}
+
+	reference, err := phys.Render(ctx, scene)
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	if diff := testutil.ComparePNG(art.Image, reference.Image, 0); diff != "" {
+		t.Errorf("PathtracerProgressive differs from Render with the same RenderOptions: %s", diff)
+	}
+}
@@ -0,0 +1,440 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+
+package phys
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r2"
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
+)
+
+// Integrator selects how tracePath accumulates radiance at each camera
+// subpath vertex.
+type Integrator uint8
+
+const (
+	// IntegratorPath is ordinary unidirectional path tracing: each hit
+	// contributes next-event estimation against scene.Light and, via
+	// sampleEmitterDirectLighting, a single MIS-weighted sample of scene's
+	// Emitter nodes (the Material.ComputeDirectLighting hook), plus
+	// whatever Resolve scatters onward. It is the zero value, so a
+	// RenderOptions{} left unset still renders with this direct lighting;
+	// only IntegratorBDPT's and IntegratorLightTracing's extra light-
+	// vertex connections are opt-in.
+	IntegratorPath Integrator = iota
+	// IntegratorBDPT additionally connects each diffuse camera subpath
+	// vertex directly to every vertex of a short light subpath sampled
+	// from an Emitter's surface (see connectToLightVertex and
+	// sampleLightSubpath), MIS-weighted against ordinary BSDF sampling by
+	// the power heuristic. The light subpath extends past the initial
+	// emitter vertex by at most RenderOptions.BDPTLightBounces (or
+	// bdptMaxLightBounces, if that's left at zero) bounces off
+	// DiffuseReflector surfaces, giving the s=1..bounces+1 slice of full
+	// bidirectional path tracing's vertex-connection
+	// matrix: this package's Material interface has no generic raw-
+	// BSDF/pdf evaluation, so connecting every (z_i, y_j) pair of
+	// arbitrary-length camera and light subpaths, with reverse pdfs and
+	// delta flags for specular vertices, isn't implementable without a
+	// much larger Material redesign. The light-vertex connections
+	// implemented here are what make scenes with small, hard-to-find
+	// emitters (e.g. caustics cast through the dielectric spheres)
+	// converge acceptably.
+	IntegratorBDPT
+	// IntegratorLightTracing is reserved for an integrator that traces
+	// subpaths starting at an Emitter and connects them to the camera
+	// lens (the t=1 strategy of full bidirectional path tracing's (s,t)
+	// vertex-connection matrix). It validates but tracePath does not yet
+	// special-case it, so scenes requesting it currently render with
+	// IntegratorPath behavior. Connecting a light vertex to the lens
+	// needs a world-point-to-screen-space projection this package's
+	// Camera interface doesn't expose (only Cast, the forward direction);
+	// adding one means implementing it for every existing Camera (Pinhole,
+	// Orthographic, Calibrated, ...) correctly enough to splat without
+	// bias, which is its own project rather than a one-off addition here.
+	// Combined with the reverse-pdf/delta-BSDF bookkeeping IntegratorBDPT's
+	// doc comment already explains is out of reach of the current Material
+	// interface, implementing the full general (s,t) strategy sum (every
+	// s-light-vertex/t-camera-vertex connection, MIS-weighted together)
+	// isn't a self-contained change -- IntegratorBDPT's bounded s=1
+	// light-vertex connections (through bdptMaxLightBounces) remain the
+	// supported approximation of it.
+	IntegratorLightTracing
+
+	// IntegratorVoxelGI is reserved for an integrator that replaces
+	// indirect-bounce sampling with a small number of cones marched
+	// through a Scene.VoxelGrid (see BuildVoxelGrid and
+	// VoxelGrid.TraceCone), trading path-traced bounces' unbiased
+	// convergence for a constant, resolution-bounded number of lookups
+	// per hit. It validates but tracePath does not yet special-case it,
+	// so scenes requesting it currently render with IntegratorPath
+	// behavior, the same documented gap IntegratorLightTracing leaves
+	// above. VoxelGrid and TraceCone are real and independently usable
+	// today; wiring them into tracePath means replacing its recursive
+	// scatter-and-recurse step with DiffuseConeDirections/
+	// SpecularConeAperture lookups for exactly the materials and depths
+	// an IntegratorVoxelGI path should stop recursing at, a restructuring
+	// of tracePath's control flow distinct enough from this data
+	// structure to land as its own follow-up.
+	IntegratorVoxelGI
+)
+
+func (i Integrator) String() string {
+	switch i {
+	case IntegratorPath:
+		return "Path"
+	case IntegratorBDPT:
+		return "BDPT"
+	case IntegratorLightTracing:
+		return "LightTracing"
+	case IntegratorVoxelGI:
+		return "VoxelGI"
+	default:
+		return fmt.Sprintf("Integrator(%d)", uint8(i))
+	}
+}
+
+func (i Integrator) Validate() error {
+	switch i {
+	case IntegratorPath, IntegratorBDPT, IntegratorLightTracing, IntegratorVoxelGI:
+		return nil
+	default:
+		return fmt.Errorf("bad Integrator: %s", i)
+	}
+}
+
+// AreaSampler is implemented by shapes that can be sampled as an area
+// light's emitting surface. The BDPT integrator consults it (via an
+// Emitter node's Shape) when looking for a light vertex to connect a
+// camera subpath to; shapes that don't implement it simply aren't
+// eligible, and sampleEmitterNode skips them.
+type AreaSampler interface {
+	Shape
+	// SampleSurface returns a point uniformly distributed over the
+	// shape's surface area, the outward normal there, and the pdf of
+	// that sample with respect to surface area (1 / surface area for a
+	// uniform sampler).
+	SampleSurface(rand *Rand) (p r3.Point, normal r3.Vec, pdfArea float64)
+}
+
+// DiffuseReflector is implemented by materials whose reflectance can be
+// evaluated for an arbitrary pair of directions rather than only sampled,
+// e.g. Lambertian and Diffuse. The BDPT integrator needs this to weight a
+// connection to a sampled light vertex, since that direction is almost
+// never the one Resolve would have scattered toward on its own.
+type DiffuseReflector interface {
+	Material
+	// ReflectedRadiance returns albedo(uv) * BRDF(wi, wo, n) * max(0, n·wi),
+	// the same per-sample term each diffuse material already accumulates
+	// inside ComputeDirectLighting for one light. Multiplying the result
+	// by the radiance arriving from wi gives the reflected radiance
+	// contributed through that direction.
+	ReflectedRadiance(uv r2.Point, wi, wo, n r3.Vec) Spectrum
+}
+
+// BSDFPDF is implemented by a DiffuseReflector whose Resolve samples a
+// scattered direction with a known solid-angle pdf, letting connectToVertex
+// weight a light-vertex connection's BSDF-sampling strategy by that actual
+// value via the power heuristic instead of assuming every DiffuseReflector
+// samples cosine-weighted about the normal the way Lambertian and Diffuse
+// both currently do. A DiffuseReflector that doesn't implement it still
+// works: connectToVertex falls back to cos(theta)/pi, the shared sampling
+// density both existing implementations use.
+type BSDFPDF interface {
+	// PDF returns the solid-angle probability density of Resolve having
+	// sampled direction wi, given outgoing direction wo and surface
+	// normal n.
+	PDF(wi, wo, n r3.Vec) float64
+}
+
+// powerHeuristic returns the MIS weight for a sample drawn with pdf pdfA,
+// competing against a strategy with pdf pdfB, using Veach's beta=2 power
+// heuristic.
+func powerHeuristic(pdfA, pdfB float64) float64 {
+	a := pdfA * pdfA
+	b := pdfB * pdfB
+	if a+b == 0 {
+		return 0
+	}
+	return a / (a + b)
+}
+
+// sampleEmitterNode uniformly selects one of scene's Emitter nodes whose
+// Shape implements AreaSampler, returning its index into scene.Node and
+// the pdf of that selection (1/N). It reports ok=false if no such node
+// exists, letting callers fall back to ordinary path tracing. It uses
+// scene.emissiveNodeIndices when Scene.CollectEmissiveNodes has already
+// been called, falling back to scanning scene.Node directly otherwise.
+func sampleEmitterNode(scene *Scene, rand *Rand) (index int, pdfNode float64, ok bool) {
+	candidates := scene.emissiveNodeIndices
+	if !scene.emissiveNodesCollected {
+		candidates = scanEmissiveNodeIndices(scene)
+	}
+	if len(candidates) == 0 {
+		return 0, 0, false
+	}
+	i := candidates[rand.Intn(len(candidates))]
+	return i, 1.0 / float64(len(candidates)), true
+}
+
+// sampleEmitterVertex samples a single point on a uniformly chosen Emitter
+// node's surface, returning it as a lightVertex with no bounce reflector
+// (mirroring the first element sampleLightSubpath builds) along with the
+// emission direction SampleLe drew there, so both BDPT's light subpath
+// (which continues the bounceRay onward) and ordinary path tracing's
+// direct lighting (which only needs the vertex itself) can reuse the same
+// emitter-sampling step without bdptMaxLightBounces' bounce machinery. It
+// reports ok=false if the scene has no samplable emitter.
+func sampleEmitterVertex(scene *Scene, rand *Rand) (lv lightVertex, emitDirection r3.Vec, ok bool) {
+	lightIndex, pdfNode, ok := sampleEmitterNode(scene, rand)
+	if !ok {
+		return lightVertex{}, r3.Vec{}, false
+	}
+	lightNode := scene.Node[lightIndex]
+	sampler := lightNode.Shape.(AreaSampler)
+	y, yNormal, pdfArea := sampler.SampleSurface(rand)
+	if pdfArea <= 0 {
+		// A degenerate shape (e.g. a Mesh whose faces are all zero-area)
+		// can't be sampled meaningfully; treat it the same as "no
+		// samplable emitter" rather than dividing by zero downstream.
+		return lightVertex{}, r3.Vec{}, false
+	}
+	emitter := lightNode.Material.(Emitter)
+	direction, radiance, _ := emitter.SampleLe(y, yNormal, r2.Point{}, rand)
+	return lightVertex{
+		p: y, n: yNormal, radiance: radiance,
+		pdfArea: pdfNode * pdfArea, nodeShape: lightNode.Shape,
+	}, direction, true
+}
+
+// sampleEmitterDirectLighting performs one step of explicit next-event
+// estimation against scene's Emitter nodes, for use by an ordinary
+// (non-BDPT) camera subpath vertex z: it samples a single emitter vertex
+// (sampleEmitterVertex) and connects it to z with the same MIS-weighted
+// shadow-ray connection IntegratorBDPT uses for its own light vertices
+// (connectToVertex), so a small or occluded-from-most-directions emitter
+// contributes at every diffuse hit instead of only when a scattered ray
+// happens to land on it. It returns a zero Spectrum if the scene has no
+// samplable emitter, leaving z's ComputeDirectLighting contribution from
+// scene.Light unaffected.
+//
+// As with IntegratorBDPT's own connectToLightVertex (see its doc comment),
+// this only MIS-weights the light-sampling half of the combination:
+// tracePath still adds a hit Emitter's resolution.emission in full when a
+// BSDF-sampled ray happens to land on it, rather than discounting it by
+// the complementary weight. That slightly over-counts the rare case where
+// both strategies find the same emitter in the same sample, the same
+// accepted approximation IntegratorBDPT already makes.
+func sampleEmitterDirectLighting(scene *Scene, z surfaceInteraction, reflector DiffuseReflector) Spectrum {
+	lv, _, ok := sampleEmitterVertex(scene, z.incoming.rand)
+	if !ok {
+		return Spectrum{}
+	}
+	return connectToVertex(scene, z, reflector, lv)
+}
+
+// misWeightedEmission discounts emission -- the direct-emission term
+// tracePath adds whenever a ray hits a node's Material -- by the
+// power-heuristic MIS weight of the BSDF-sampling strategy that produced r,
+// completing the half of Veach-style MIS that sampleEmitterDirectLighting's
+// NEE-side weighting (see connectToVertex's doc comment) doesn't cover: a
+// BSDF-sampled ray that happens to land directly on an Emitter node should
+// be discounted by how likely next-event estimation would also have found
+// it, the same way connectToVertex discounts a light sample by how likely
+// BSDF sampling would have. It falls back to the full, unweighted emission
+// tracePath always added before whenever there's no comparable competing
+// pdf: a primary camera ray or delta/specular scatter directly seeing a
+// light (r.bsdfPdf == 0), or an Emitter node whose Shape doesn't implement
+// AreaSampler (scanEmissiveNodeIndices wouldn't have offered it to NEE
+// either).
+func misWeightedEmission(scene *Scene, hit surfaceInteraction, r ray, emission Spectrum) Spectrum {
+	if emission == (Spectrum{}) || r.bsdfPdf <= 0 {
+		return emission
+	}
+	sampler, ok := hit.node.Shape.(AreaSampler)
+	if !ok {
+		return emission
+	}
+	candidates := scene.emissiveNodeIndices
+	if !scene.emissiveNodesCollected {
+		candidates = scanEmissiveNodeIndices(scene)
+	}
+	if len(candidates) == 0 {
+		return emission
+	}
+	cosLight := math.Max(0, hit.collision.normal.Unit().Dot(r.direction.Muls(-1)))
+	if cosLight <= 0 {
+		return emission
+	}
+	_, _, pdfArea := sampler.SampleSurface(r.rand)
+	if pdfArea <= 0 {
+		return emission
+	}
+	dist := float64(hit.collision.t)
+	pdfLightSolidAngle := pdfArea / float64(len(candidates)) * dist * dist / cosLight
+	return emission.Muls(powerHeuristic(r.bsdfPdf, pdfLightSolidAngle))
+}
+
+// lightVertex is one vertex of a light subpath sampled by
+// sampleLightSubpath: either the point sampled on the emitter's own surface
+// (reflector == nil) or a point the subpath bounced to off a
+// DiffuseReflector surface (reflector set). connectToLightVertex connects a
+// camera subpath vertex to each lightVertex in turn.
+type lightVertex struct {
+	p         r3.Point
+	n         r3.Vec
+	uv        r2.Point
+	radiance  Spectrum         // Throughput arriving at p, before any reflection at p itself.
+	reflector DiffuseReflector // nil for the emitter vertex; set for a bounce vertex.
+	incoming  r3.Vec           // Unit direction the subpath arrived along; only valid when reflector != nil.
+	pdfArea   float64          // Area-measure pdf of having sampled this vertex.
+	nodeShape Shape            // The vertex's own node's Shape, excluded from its own shadow tests.
+}
+
+// bdptMaxLightBounces is the default cap on how many times
+// sampleLightSubpath bounces the light subpath off a DiffuseReflector
+// surface past the initial emitter vertex, used whenever
+// RenderOptions.BDPTLightBounces is left at zero. This package's Material
+// interface has no generic raw-BSDF/pdf evaluation (see the IntegratorBDPT
+// doc comment), so each bounce vertex can only be connected by
+// re-evaluating DiffuseReflector.ReflectedRadiance at both ends of the
+// connection; extending the chain further costs one more shadow ray and
+// ReflectedRadiance evaluation per camera vertex, which is why it's a
+// RenderOptions knob rather than a larger constant outright.
+const bdptMaxLightBounces = 1
+
+// sampleLightSubpath samples a light subpath: a point on a randomly chosen
+// Emitter node's surface, then up to scene.RenderOptions.BDPTLightBounces
+// (or bdptMaxLightBounces, if that's left at zero) bounces off whatever
+// DiffuseReflector surface the subpath lands on next (the subpath stops
+// early if it leaves the scene or lands on a non-DiffuseReflector
+// material). It returns nil if the scene has no samplable emitter.
+func sampleLightSubpath(scene *Scene, rand *Rand) []lightVertex {
+	first, direction, ok := sampleEmitterVertex(scene, rand)
+	if !ok {
+		return nil
+	}
+
+	maxBounces := scene.RenderOptions.BDPTLightBounces
+	if maxBounces <= 0 {
+		maxBounces = bdptMaxLightBounces
+	}
+
+	vertices := []lightVertex{first}
+
+	bounceRay := ray{origin: first.p.Add(first.n.Muls(eps)), direction: direction, rand: rand, rayType: RayTypeShadow}
+	for i := 0; i < maxBounces; i++ {
+		prev := vertices[len(vertices)-1]
+		nodeIndex, c, hit := lightSubpathClosestHit(scene, bounceRay)
+		if !hit {
+			break
+		}
+		reflector, ok := scene.Node[nodeIndex].Material.(DiffuseReflector)
+		if !ok {
+			break
+		}
+		n := c.normal.Unit()
+		dist := float64(c.t)
+		cosAtHit := math.Max(0, n.Dot(bounceRay.direction.Muls(-1)))
+		pdfDirEmit := math.Max(0, prev.n.Dot(bounceRay.direction)) / math.Pi
+		pdfAreaHere := prev.pdfArea * pdfDirEmit * cosAtHit / (dist * dist)
+		vertices = append(vertices, lightVertex{
+			p: c.at, n: n, uv: c.uv, radiance: prev.radiance,
+			reflector: reflector, incoming: bounceRay.direction,
+			pdfArea: pdfAreaHere, nodeShape: scene.Node[nodeIndex].Shape,
+		})
+		bounceRay = ray{origin: c.at.Add(n.Muls(eps)), direction: rand.CosineWeightedHemisphere(n), rand: rand, rayType: RayTypeShadow}
+	}
+	return vertices
+}
+
+// lightSubpathClosestHit finds the nearest scene intersection along r,
+// mirroring tracePath's own Accel-or-linear-scan intersection logic.
+func lightSubpathClosestHit(scene *Scene, r ray) (int, collision, bool) {
+	if scene.Accel != nil {
+		h, c := scene.Accel.Collide(r, eps, Distance(math.MaxFloat64))
+		return c.nodeIndex, c, h
+	}
+	index := -1
+	minDist := Distance(math.MaxFloat64)
+	var nearest collision
+	for i := range scene.Node {
+		if h, c := scene.Node[i].Shape.Collide(r, eps, minDist); h && c.t < minDist {
+			minDist = c.t
+			nearest = c
+			index = i
+		}
+	}
+	return index, nearest, index >= 0
+}
+
+// connectToLightVertex connects the camera subpath vertex z to every
+// vertex of a freshly sampled light subpath (see sampleLightSubpath),
+// summing each connection's MIS-weighted contribution. It returns a zero
+// Spectrum if the scene has no samplable emitter.
+func connectToLightVertex(scene *Scene, z surfaceInteraction, reflector DiffuseReflector) Spectrum {
+	contribution := Spectrum{}
+	for _, lv := range sampleLightSubpath(scene, z.incoming.rand) {
+		contribution = contribution.Add(connectToVertex(scene, z, reflector, lv))
+	}
+	return contribution
+}
+
+// connectToVertex adds the MIS-weighted contribution of joining camera
+// subpath vertex z to a single light subpath vertex lv with a shadow ray.
+// lv.reflector is nil for the emitter vertex itself, whose emission is
+// direction-independent (see Emitter.SampleLe) so no BSDF needs evaluating
+// at that end; it is set for a bounce vertex, whose own ReflectedRadiance
+// toward z must additionally be folded in, since a reflected light vertex
+// isn't a light and only sends radiance in the directions its own BRDF
+// permits.
+func connectToVertex(scene *Scene, z surfaceInteraction, reflector DiffuseReflector, lv lightVertex) Spectrum {
+	p := z.collision.at
+	n := z.collision.normal.Unit()
+	toLight := lv.p.Sub(p)
+	dist := toLight.Length()
+	if dist < float64(eps) {
+		return Spectrum{}
+	}
+	wi := toLight.Divs(dist)
+
+	cosSurface := n.Dot(wi)
+	cosLight := lv.n.Dot(wi.Muls(-1))
+	if cosSurface <= 0 || cosLight <= 0 {
+		return Spectrum{}
+	}
+
+	rand := z.incoming.rand
+	shadowRay := ray{
+		origin:    p.Add(n.Muls(eps)),
+		direction: wi,
+		depth:     z.incoming.depth + 1,
+		radiance:  Spectrum{X: 1, Y: 1, Z: 1},
+		rand:      rand,
+		rayType:   RayTypeShadow,
+		time:      z.incoming.time,
+	}
+	maxDist := Distance(dist - float64(eps))
+	if scene.Occluded(shadowRay, maxDist, lv.nodeShape, z.node.Shape) {
+		return Spectrum{}
+	}
+
+	outgoing := lv.radiance
+	if lv.reflector != nil {
+		outgoing = outgoing.Mul(lv.reflector.ReflectedRadiance(lv.uv, wi.Muls(-1), lv.incoming.Muls(-1), lv.n))
+	}
+
+	// G(z<->lv) converts the area-measure light sample into the
+	// solid-angle measure z's BSDF and cosine term are expressed in.
+	g := cosSurface * cosLight / (dist * dist)
+	pdfLightSolidAngle := lv.pdfArea * dist * dist / cosLight
+	pdfBSDFSolidAngle := cosSurface / math.Pi
+	if bp, ok := reflector.(BSDFPDF); ok {
+		pdfBSDFSolidAngle = bp.PDF(wi, z.outgoing, n)
+	}
+	weight := powerHeuristic(pdfLightSolidAngle, pdfBSDFSolidAngle)
+
+	f := reflector.ReflectedRadiance(z.collision.uv, wi, z.outgoing, n)
+	return f.Mul(outgoing).Muls(weight * g / lv.pdfArea)
+}
@@ -0,0 +1,423 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
+)
+
+// TestIntegratorValidate verifies Validate accepts the three defined
+// constants and rejects everything else.
+func TestIntegratorValidate(t *testing.T) {
+	for _, i := range []Integrator{IntegratorPath, IntegratorBDPT, IntegratorLightTracing} {
+		if err := i.Validate(); err != nil {
+			t.Errorf("Integrator(%d).Validate() = %v, want nil", i, err)
+		}
+	}
+	if err := Integrator(99).Validate(); err == nil {
+		t.Errorf("Integrator(99).Validate() = nil, want an error")
+	}
+}
+
+// emitterLitScene returns a scene with a large diffuse floor facing a
+// small Emitter sphere, and no PointLight, so any illumination on the
+// floor can only come from BDPT's light-vertex connection.
+func emitterLitScene(integrator Integrator) *Scene {
+	scene := &Scene{
+		RenderOptions: RenderOptions{Seed: 7, RaysPerPixel: 1, MaxRayDepth: 2, Dx: 4, Dy: 4, Integrator: integrator},
+		Camera:        []Camera{OrthographicCamera{FOVWidth: 4, FOVHeight: 4, LookFrom: r3.Point{Z: 10}, LookAt: r3.Point{}, VUp: r3.Vec{Y: 1}}},
+	}
+	scene.Add(Node{
+		Name:     "Floor",
+		Shape:    Sphere{Center: r3.Point{Z: -1000}, Radius: 1000},
+		Material: Lambertian{Texture: TextureUniform{Color: Spectrum{X: 1, Y: 1, Z: 1}}},
+	})
+	scene.Add(Node{
+		Name:     "Light",
+		Shape:    Sphere{Center: r3.Point{Y: 3, Z: 2}, Radius: 0.5},
+		Material: Emitter{Texture: TextureUniform{Color: Spectrum{X: 5, Y: 5, Z: 5}}},
+	})
+	return scene
+}
+
+// TestConnectToLightVertexAddsIllumination verifies IntegratorPath's own
+// sampleEmitterDirectLighting finds a samplable Emitter's illumination at
+// every diffuse hit (not just by occasionally landing on it via an
+// indirect bounce), and that IntegratorBDPT's additional bounced
+// light-vertex connection converges to essentially the same total, since
+// both now perform the same single-emitter-vertex NEE at the first
+// diffuse hit.
+func TestConnectToLightVertexAddsIllumination(t *testing.T) {
+	ctx := context.Background()
+	path := emitterLitScene(IntegratorPath)
+	bdpt := emitterLitScene(IntegratorBDPT)
+	camera := path.Camera[0]
+
+	var sumPath, sumBDPT Spectrum
+	const samples = 2000
+	for i := 0; i < samples; i++ {
+		rand := NewRand(int64(i))
+		r := camera.Cast(0.5, 0.4, rand)
+		var statsPath, statsBDPT RenderStats
+		sumPath = sumPath.Add(tracePath(ctx, path, r, &statsPath, nil))
+		sumBDPT = sumBDPT.Add(tracePath(ctx, bdpt, r, &statsBDPT, nil))
+	}
+
+	if sumPath.Luminance() <= 0 {
+		t.Errorf("IntegratorPath radiance summed over %d samples = %v, want nonzero now that it samples the Emitter directly", samples, sumPath)
+	}
+	// BDPT's bounced light-vertex connection is a small addition on top of
+	// the same direct-lighting term IntegratorPath now computes itself, so
+	// the two totals should be close rather than BDPT dominating.
+	if ratio := sumBDPT.Luminance() / sumPath.Luminance(); ratio < 0.8 || ratio > 1.3 {
+		t.Errorf("IntegratorBDPT/IntegratorPath radiance ratio = %v (BDPT %v, Path %v), want close to 1 now both sample the Emitter directly", ratio, sumBDPT, sumPath)
+	}
+}
+
+// TestConnectToVertexGeometryTerm verifies connectToVertex's shadow-ray
+// connection applies exactly G = cosθs·cosθt/d², MIS-weighted by the power
+// heuristic, rather than some other normalization -- the specific formula
+// documented on connectToVertex and on the BDPT light-vertex connection it
+// backs.
+func TestConnectToVertexGeometryTerm(t *testing.T) {
+	zShape := Sphere{Center: r3.Point{X: -100}, Radius: 1}
+	lvShape := Sphere{Center: r3.Point{X: 100}, Radius: 1}
+	scene := &Scene{Node: []Node{{Shape: zShape}, {Shape: lvShape}}}
+
+	z := surfaceInteraction{
+		incoming:  ray{rand: NewRand(1)},
+		outgoing:  r3.Vec{Z: 1},
+		collision: collision{at: r3.Point{}, normal: r3.Vec{Z: 1}},
+		node:      Node{Shape: zShape},
+	}
+	lv := lightVertex{
+		p: r3.Point{Z: 2}, n: r3.Vec{Z: -1},
+		radiance:  Spectrum{X: 1, Y: 1, Z: 1},
+		pdfArea:   1,
+		nodeShape: lvShape,
+	}
+	reflector := Lambertian{Texture: TextureUniform{Color: Spectrum{X: 1, Y: 1, Z: 1}}}
+
+	got := connectToVertex(scene, z, reflector, lv)
+
+	const (
+		dist       = 2.0
+		cosSurface = 1.0
+		cosLight   = 1.0
+		g          = cosSurface * cosLight / (dist * dist)
+		pdfLightSA = 1 * dist * dist / cosLight
+		pdfBSDFSA  = cosSurface / math.Pi
+	)
+	weight := powerHeuristic(pdfLightSA, pdfBSDFSA)
+	want := weight * g / lv.pdfArea
+
+	if diff := math.Abs(got.X - want); diff > 1e-9 {
+		t.Errorf("connectToVertex = %v, want %v (diff %v)", got.X, want, diff)
+	}
+}
+
+// TestSampleEmitterNodeSkipsUnsamplableShapes verifies sampleEmitterNode
+// only reports Emitter nodes whose Shape implements AreaSampler, and
+// reports ok=false when none qualify.
+func TestSampleEmitterNodeSkipsUnsamplableShapes(t *testing.T) {
+	scene := &Scene{}
+	scene.Add(Node{Name: "NotEmitter", Shape: Sphere{Radius: 1}, Material: Lambertian{Texture: TextureUniform{}}})
+	rand := NewRand(1)
+	if _, _, ok := sampleEmitterNode(scene, rand); ok {
+		t.Errorf("sampleEmitterNode() ok = true, want false with no Emitter nodes")
+	}
+
+	scene.Add(Node{Name: "Light", Shape: Sphere{Radius: 1}, Material: Emitter{Texture: TextureUniform{Color: Spectrum{X: 1, Y: 1, Z: 1}}}})
+	index, pdfNode, ok := sampleEmitterNode(scene, rand)
+	if !ok {
+		t.Fatalf("sampleEmitterNode() ok = false, want true once a samplable Emitter is present")
+	}
+	if index != 1 {
+		t.Errorf("sampleEmitterNode() index = %d, want 1 (the Light node)", index)
+	}
+	if pdfNode != 1 {
+		t.Errorf("sampleEmitterNode() pdfNode = %v, want 1 for a single candidate", pdfNode)
+	}
+}
+
+// TestCollectEmissiveNodesSkipsZeroRadianceAndCaches verifies
+// CollectEmissiveNodes excludes a zero-color Emitter, includes a
+// radiant one, and that sampleEmitterNode picks up the cached result
+// instead of rescanning Node.
+func TestCollectEmissiveNodesSkipsZeroRadianceAndCaches(t *testing.T) {
+	scene := &Scene{}
+	scene.Add(Node{Name: "Off", Shape: Sphere{Radius: 1}, Material: Emitter{Texture: TextureUniform{Color: Spectrum{}}}})
+	scene.Add(Node{Name: "Light", Shape: Sphere{Radius: 1}, Material: Emitter{Texture: TextureUniform{Color: Spectrum{X: 1, Y: 1, Z: 1}}}})
+
+	scene.CollectEmissiveNodes()
+	if got := scene.emissiveNodeIndices; len(got) != 1 || got[0] != 1 {
+		t.Fatalf("emissiveNodeIndices = %v, want [1] (only the radiant Light node)", got)
+	}
+
+	rand := NewRand(1)
+	index, pdfNode, ok := sampleEmitterNode(scene, rand)
+	if !ok || index != 1 || pdfNode != 1 {
+		t.Errorf("sampleEmitterNode() = (%d, %v, %v), want (1, 1, true) from the cached index", index, pdfNode, ok)
+	}
+}
+
+// TestSampleEmitterDirectLighting verifies sampleEmitterDirectLighting
+// returns a zero Spectrum with no samplable Emitter present, and a
+// positive one once an unoccluded Emitter faces the shading point.
+func TestSampleEmitterDirectLighting(t *testing.T) {
+	scene := &Scene{}
+	scene.Add(Node{
+		Name:     "Floor",
+		Shape:    Sphere{Center: r3.Point{Z: -1000}, Radius: 1000},
+		Material: Lambertian{Texture: TextureUniform{Color: Spectrum{X: 1, Y: 1, Z: 1}}},
+	})
+	floor := scene.Node[0].Shape.(Sphere)
+	s := surfaceInteraction{
+		node:      scene.Node[0],
+		collision: collision{at: r3.Point{Z: floor.Center.Z + float64(floor.Radius)}, normal: r3.Vec{Z: 1}},
+		incoming:  ray{rand: NewRand(1)},
+	}
+	reflector := scene.Node[0].Material.(DiffuseReflector)
+
+	if got := sampleEmitterDirectLighting(scene, s, reflector); got != (Spectrum{}) {
+		t.Errorf("sampleEmitterDirectLighting() = %v, want zero with no samplable Emitter", got)
+	}
+
+	scene.Add(Node{
+		Name:     "Light",
+		Shape:    Sphere{Center: r3.Point{Z: 5}, Radius: 1},
+		Material: Emitter{Texture: TextureUniform{Color: Spectrum{X: 5, Y: 5, Z: 5}}},
+	})
+
+	var sum Spectrum
+	for i := 0; i < 200; i++ {
+		s.incoming.rand = NewRand(int64(i))
+		sum = sum.Add(sampleEmitterDirectLighting(scene, s, reflector))
+	}
+	if sum.Luminance() <= 0 {
+		t.Errorf("sampleEmitterDirectLighting() summed over 200 samples = %v, want positive luminance with an unoccluded Emitter in view", sum)
+	}
+}
+
+// TestSampleLightSubpathBouncesOffDiffuseReflector verifies the light
+// subpath extends past the emitter vertex onto a Lambertian surface the
+// sampled emission direction can plausibly reach, and that the bounce
+// vertex records the reflector it landed on.
+func TestSampleLightSubpathBouncesOffDiffuseReflector(t *testing.T) {
+	scene := &Scene{}
+	scene.Add(Node{
+		Name:     "Light",
+		Shape:    Sphere{Center: r3.Point{Y: 5}, Radius: 1},
+		Material: Emitter{Texture: TextureUniform{Color: Spectrum{X: 1, Y: 1, Z: 1}}},
+	})
+	scene.Add(Node{
+		Name:     "Floor",
+		Shape:    Sphere{Center: r3.Point{Y: -1000}, Radius: 1000},
+		Material: Lambertian{Texture: TextureUniform{Color: Spectrum{X: 1, Y: 1, Z: 1}}},
+	})
+
+	sawBounce := false
+	for i := 0; i < 200; i++ {
+		rand := NewRand(int64(i))
+		vertices := sampleLightSubpath(scene, rand)
+		if len(vertices) == 0 {
+			t.Fatalf("sampleLightSubpath() returned no vertices with a samplable Emitter present")
+		}
+		if vertices[0].reflector != nil {
+			t.Errorf("vertices[0].reflector = %v, want nil for the emitter vertex", vertices[0].reflector)
+		}
+		if len(vertices) > 1 {
+			sawBounce = true
+			if vertices[1].reflector == nil {
+				t.Errorf("vertices[1].reflector = nil, want the Floor's Lambertian")
+			}
+		}
+	}
+	if !sawBounce {
+		t.Error("sampleLightSubpath never bounced onto the Floor over 200 samples")
+	}
+}
+
+// TestSampleLightSubpathRespectsBDPTLightBounces verifies
+// RenderOptions.BDPTLightBounces raises the cap sampleLightSubpath bounces
+// the light subpath by, past the bdptMaxLightBounces default: with two
+// facing diffuse planes for the subpath to ping-pong between, a scene
+// configured for more bounces should occasionally produce longer subpaths
+// than the default ever does.
+func TestSampleLightSubpathRespectsBDPTLightBounces(t *testing.T) {
+	scene := &Scene{}
+	scene.Add(Node{
+		Name:     "Light",
+		Shape:    Sphere{Center: r3.Point{Y: 5}, Radius: 0.5},
+		Material: Emitter{Texture: TextureUniform{Color: Spectrum{X: 1, Y: 1, Z: 1}}},
+	})
+	scene.Add(Node{
+		Name:     "Floor",
+		Shape:    Sphere{Center: r3.Point{Y: -1000}, Radius: 1000},
+		Material: Lambertian{Texture: TextureUniform{Color: Spectrum{X: 1, Y: 1, Z: 1}}},
+	})
+	scene.Add(Node{
+		Name:     "Ceiling",
+		Shape:    Sphere{Center: r3.Point{Y: 1010}, Radius: 1000},
+		Material: Lambertian{Texture: TextureUniform{Color: Spectrum{X: 1, Y: 1, Z: 1}}},
+	})
+
+	maxLenDefault := 0
+	for i := 0; i < 500; i++ {
+		if n := len(sampleLightSubpath(scene, NewRand(int64(i)))); n > maxLenDefault {
+			maxLenDefault = n
+		}
+	}
+	if maxLenDefault > bdptMaxLightBounces+1 {
+		t.Fatalf("default subpath length = %d, want at most bdptMaxLightBounces+1 = %d", maxLenDefault, bdptMaxLightBounces+1)
+	}
+
+	scene.RenderOptions.BDPTLightBounces = 6
+	maxLenRaised := 0
+	for i := 0; i < 500; i++ {
+		if n := len(sampleLightSubpath(scene, NewRand(int64(i)))); n > maxLenRaised {
+			maxLenRaised = n
+		}
+	}
+	if maxLenRaised <= maxLenDefault {
+		t.Errorf("subpath length with BDPTLightBounces=6: max = %d, want > default's max = %d", maxLenRaised, maxLenDefault)
+	}
+}
+
+// TestMisWeightedEmissionMatchesPowerHeuristic verifies misWeightedEmission
+// discounts a hit Emitter's emission by exactly powerHeuristic(r.bsdfPdf,
+// pdfLightSolidAngle), the same formula connectToVertex uses for the
+// opposite half of the same MIS combination.
+func TestMisWeightedEmissionMatchesPowerHeuristic(t *testing.T) {
+	scene := &Scene{}
+	scene.Add(Node{
+		Name:     "Light",
+		Shape:    Sphere{Center: r3.Point{Z: 10}, Radius: 1},
+		Material: Emitter{Texture: TextureUniform{Color: Spectrum{X: 1, Y: 1, Z: 1}}},
+	})
+	hit := surfaceInteraction{
+		node:      scene.Node[0],
+		collision: collision{at: r3.Point{Z: 9}, normal: r3.Vec{Z: -1}, t: 9},
+	}
+	r := ray{direction: r3.Vec{Z: 1}, rand: NewRand(1), bsdfPdf: 2}
+	emission := Spectrum{X: 1, Y: 2, Z: 3}
+
+	got := misWeightedEmission(scene, hit, r, emission)
+
+	sphere := scene.Node[0].Shape.(Sphere)
+	_, _, pdfArea := sphere.SampleSurface(NewRand(1))
+	const dist = 9.0
+	pdfLightSolidAngle := pdfArea * dist * dist // cosLight == 1, one candidate node.
+	want := emission.Muls(powerHeuristic(r.bsdfPdf, pdfLightSolidAngle))
+
+	if math.Abs(got.X-want.X) > 1e-9 || math.Abs(got.Y-want.Y) > 1e-9 || math.Abs(got.Z-want.Z) > 1e-9 {
+		t.Errorf("misWeightedEmission() = %v, want %v", got, want)
+	}
+}
+
+// TestMisWeightedEmissionUnweightedWithoutCompetingPdf verifies
+// misWeightedEmission returns emission untouched -- tracePath's previous,
+// always-unweighted behavior -- whenever there's no comparable competing
+// pdf to weigh it against: a primary camera ray or delta scatter (bsdfPdf
+// == 0), or a hit Shape that doesn't implement AreaSampler.
+func TestMisWeightedEmissionUnweightedWithoutCompetingPdf(t *testing.T) {
+	scene := &Scene{}
+	scene.Add(Node{
+		Name:     "Light",
+		Shape:    Sphere{Center: r3.Point{Z: 10}, Radius: 1},
+		Material: Emitter{Texture: TextureUniform{Color: Spectrum{X: 1, Y: 1, Z: 1}}},
+	})
+	hit := surfaceInteraction{
+		node:      scene.Node[0],
+		collision: collision{at: r3.Point{Z: 9}, normal: r3.Vec{Z: -1}, t: 9},
+	}
+	emission := Spectrum{X: 1, Y: 2, Z: 3}
+
+	if got := misWeightedEmission(scene, hit, ray{direction: r3.Vec{Z: 1}, rand: NewRand(1), bsdfPdf: 0}, emission); got != emission {
+		t.Errorf("misWeightedEmission() with bsdfPdf 0 = %v, want unweighted %v", got, emission)
+	}
+
+	triangleHit := surfaceInteraction{
+		node: Node{Shape: Triangle{}},
+	}
+	if got := misWeightedEmission(scene, triangleHit, ray{direction: r3.Vec{Z: 1}, rand: NewRand(1), bsdfPdf: 2}, emission); got != emission {
+		t.Errorf("misWeightedEmission() on a non-AreaSampler Shape = %v, want unweighted %v", got, emission)
+	}
+}
+
+// TestSphereSampleSurfaceOnSurface verifies SampleSurface returns points
+// at exactly Radius from Center with outward-pointing normals.
+func TestSphereSampleSurfaceOnSurface(t *testing.T) {
+	s := Sphere{Center: r3.Point{X: 1, Y: 2, Z: 3}, Radius: 2}
+	rand := NewRand(5)
+	for i := 0; i < 100; i++ {
+		p, normal, pdfArea := s.SampleSurface(rand)
+		if got := p.Sub(s.Center).Length(); got < 1.999 || got > 2.001 {
+			t.Fatalf("SampleSurface() point distance from center = %v, want 2", got)
+		}
+		if want := 1 / (4 * 3.141592653589793 * 4); pdfArea < want*0.999 || pdfArea > want*1.001 {
+			t.Errorf("SampleSurface() pdfArea = %v, want %v", pdfArea, want)
+		}
+		if normal.Length() < 0.999 || normal.Length() > 1.001 {
+			t.Errorf("SampleSurface() normal = %v, want unit length", normal)
+		}
+	}
+}
+
+// fixedPDFLambertian wraps Lambertian but reports an arbitrary, fixed
+// BSDFPDF value instead of the real cosine-weighted density, so tests can
+// drive connectToVertex's pdfBSDFSolidAngle to a chosen value without it
+// having any bearing on how ReflectedRadiance itself is evaluated.
+type fixedPDFLambertian struct {
+	Lambertian
+	pdf float64
+}
+
+func (m fixedPDFLambertian) PDF(wi, wo, n r3.Vec) float64 {
+	return m.pdf
+}
+
+// TestConnectToVertexUsesBSDFPDFWhenAvailable verifies connectToVertex
+// takes pdfBSDFSolidAngle from a DiffuseReflector's BSDFPDF implementation
+// (added alongside Scene.Occluded so the light-vertex MIS weight reflects
+// the reflector's actual sampling density instead of always assuming
+// Lambertian's cos(theta)/pi), by comparing the weight implied by two
+// otherwise-identical connections that differ only in the reported pdf.
+func TestConnectToVertexUsesBSDFPDFWhenAvailable(t *testing.T) {
+	scene := emitterLitScene(IntegratorPath)
+	floor := scene.Node[0].Shape.(Sphere)
+	s := surfaceInteraction{
+		node:      scene.Node[0],
+		collision: collision{at: r3.Point{Z: floor.Center.Z + float64(floor.Radius)}, normal: r3.Vec{Z: 1}},
+		outgoing:  r3.Vec{Z: 1},
+		incoming:  ray{rand: NewRand(1)},
+	}
+
+	sample := func(reflector DiffuseReflector) Spectrum {
+		var sum Spectrum
+		const samples = 500
+		for i := 0; i < samples; i++ {
+			s.incoming.rand = NewRand(int64(i))
+			sum = sum.Add(sampleEmitterDirectLighting(scene, s, reflector))
+		}
+		return sum.Divs(samples)
+	}
+
+	base := sample(fixedPDFLambertian{Lambertian: Lambertian{Texture: TextureUniform{Color: Spectrum{X: 1, Y: 1, Z: 1}}}, pdf: 0})
+	weighted := sample(fixedPDFLambertian{Lambertian: Lambertian{Texture: TextureUniform{Color: Spectrum{X: 1, Y: 1, Z: 1}}}, pdf: 1e6})
+
+	// pdf=0 makes powerHeuristic(pdfLight, 0) = 1: the full, unweighted NEE
+	// contribution. pdf=1e6 makes the competing BSDF strategy overwhelmingly
+	// more likely than the light strategy at every sample, so the power
+	// heuristic should drive the light sample's weight -- and hence its
+	// contribution -- down close to 0.
+	if weighted.X >= base.X*0.1 {
+		t.Errorf("mean with pdf=1e6 = %v, want well below the pdf=0 mean %v (MIS should downweight the light sample when a competing BSDF pdf dominates)", weighted.X, base.X)
+	}
+	if base.X <= 0 {
+		t.Fatalf("mean with pdf=0 = %v, want positive (unweighted NEE should still find the unoccluded Emitter)", base.X)
+	}
+}
@@ -0,0 +1,258 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+
+// Package jobs turns a phys.Scene render into a trackable, cancellable
+// background job instead of a single blocking call, for servers (like
+// playground's REST control plane) that need to hand back a job ID
+// immediately, poll progress, and cancel a render that's no longer wanted.
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/phys"
+)
+
+// Status is a Job's lifecycle state.
+type Status string
+
+const (
+	StatusQueued    Status = "queued"
+	StatusRunning   Status = "running"
+	StatusDone      Status = "done"
+	StatusCancelled Status = "cancelled"
+	StatusError     Status = "error"
+)
+
+// Job tracks one in-flight or completed render. Its exported fields are
+// only ever read through Snapshot, which takes the lock; callers must not
+// read Job's fields directly.
+type Job struct {
+	ID string
+
+	mu             sync.Mutex
+	status         Status
+	tilesCompleted int
+	totalTiles     int
+	stats          phys.RenderStats
+	image          image.Image
+	err            error
+	cancel         context.CancelFunc
+	done           chan struct{}
+}
+
+// Snapshot is a point-in-time, concurrency-safe copy of a Job's state.
+type Snapshot struct {
+	ID             string
+	Status         Status
+	TilesCompleted int
+	TotalTiles     int
+	Stats          phys.RenderStats
+	Image          image.Image
+	Err            error
+}
+
+// Wait blocks until j finishes (successfully, with an error, or cancelled)
+// or ctx is done, whichever comes first, and returns the resulting
+// Snapshot. If ctx is done first, Wait cancels j before returning, so a
+// caller that stops waiting (e.g. its own client disconnected) also stops
+// the render instead of leaving it running unattended.
+func (j *Job) Wait(ctx context.Context) Snapshot {
+	select {
+	case <-j.done:
+	case <-ctx.Done():
+		j.mu.Lock()
+		cancel := j.cancel
+		j.mu.Unlock()
+		cancel()
+		<-j.done
+	}
+	return j.Snapshot()
+}
+
+// Snapshot returns j's current state.
+func (j *Job) Snapshot() Snapshot {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return Snapshot{
+		ID:             j.ID,
+		Status:         j.status,
+		TilesCompleted: j.tilesCompleted,
+		TotalTiles:     j.totalTiles,
+		Stats:          j.stats,
+		Image:          j.image,
+		Err:            j.err,
+	}
+}
+
+func (j *Job) setStatus(s Status) {
+	j.mu.Lock()
+	j.status = s
+	j.mu.Unlock()
+}
+
+func (j *Job) incTilesCompleted() {
+	j.mu.Lock()
+	j.tilesCompleted++
+	j.mu.Unlock()
+}
+
+func (j *Job) finish(artifact phys.RenderArtifact, err error) {
+	j.mu.Lock()
+	switch {
+	case errors.Is(err, context.Canceled):
+		j.status = StatusCancelled
+	case err != nil:
+		j.status = StatusError
+		j.err = err
+	default:
+		j.status = StatusDone
+		j.stats = artifact.Stats
+		j.image = artifact.Image
+	}
+	j.mu.Unlock()
+	close(j.done)
+}
+
+// Manager runs Scene renders as cancellable background Jobs over a bounded
+// worker pool, so a server accepting arbitrary scene submissions can't be
+// made to oversubscribe CPU by a burst of concurrent requests.
+type Manager struct {
+	sem chan struct{}
+
+	mu   sync.Mutex
+	jobs map[string]*Job
+}
+
+// NewManager returns a Manager that runs at most maxConcurrent renders at
+// once; further submissions queue until a slot frees up.
+func NewManager(maxConcurrent int) *Manager {
+	if maxConcurrent < 1 {
+		maxConcurrent = 1
+	}
+	return &Manager{
+		sem:  make(chan struct{}, maxConcurrent),
+		jobs: make(map[string]*Job),
+	}
+}
+
+// Submit registers a new Job for scene and starts rendering it
+// asynchronously (blocking on the worker pool if it's already full), and
+// returns the Job immediately in StatusQueued.
+//
+// ctx's values (a phys.WithTraceID trace ID, a phys.WithLogger logger) are
+// carried into the render so its log lines and RenderStats.Events can be
+// correlated back to the request that submitted it, but ctx's cancellation
+// is not: a Job outlives the HTTP request that created it (a caller polls
+// it by ID long after that request returns), so Submit strips ctx's
+// Done channel with context.WithoutCancel before deriving its own
+// cancellable context. Cancel (or Wait(ctx) on disconnect) is the only way
+// to stop a submitted Job.
+func (m *Manager) Submit(ctx context.Context, scene *phys.Scene) *Job {
+	ctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	job := &Job{ID: newJobID(), status: StatusQueued, cancel: cancel, totalTiles: totalTiles(scene), done: make(chan struct{})}
+
+	m.mu.Lock()
+	m.jobs[job.ID] = job
+	m.mu.Unlock()
+
+	go m.run(ctx, job, scene)
+	return job
+}
+
+func (m *Manager) run(ctx context.Context, job *Job, scene *phys.Scene) {
+	select {
+	case m.sem <- struct{}{}:
+	case <-ctx.Done():
+		job.finish(phys.RenderArtifact{}, ctx.Err())
+		m.scheduleEviction(job.ID)
+		return
+	}
+	defer func() { <-m.sem }()
+
+	job.setStatus(StatusRunning)
+	// Capture scene.RenderOptions.OnTile before RenderTiled overwrites it
+	// with its own tileCb, so a caller that set one before Submit (e.g.
+	// phys/store.Checkpointer.OnTile, to checkpoint tiles as they finish)
+	// still gets called once per tile, alongside the job's own progress
+	// tracking. scene.RenderOptions.ResumeTile, if the caller set it, isn't
+	// touched here -- RenderTiled only overwrites OnTile.
+	onTile := scene.RenderOptions.OnTile
+	artifact, err := phys.RenderTiled(ctx, scene, func(res phys.TileResult) error {
+		job.incTilesCompleted()
+		if onTile != nil {
+			return onTile(res)
+		}
+		return nil
+	})
+	// phys.Render wraps every error (including context cancellation) in
+	// its own fmt.Errorf("failed to render scene: %v", ...), which loses
+	// the chain errors.Is would otherwise need -- so cancellation is
+	// recognized by the job's own context instead of by matching err.
+	if err != nil && ctx.Err() != nil {
+		err = ctx.Err()
+	}
+	job.finish(artifact, err)
+	m.scheduleEviction(job.ID)
+}
+
+// jobRetention is how long a finished Job (and its decoded RenderArtifact
+// image) stays reachable through Get before Manager forgets it, so a
+// server fielding a steady stream of submissions doesn't accumulate one
+// full-resolution image per request for the life of the process.
+const jobRetention = 10 * time.Minute
+
+func (m *Manager) scheduleEviction(id string) {
+	time.AfterFunc(jobRetention, func() {
+		m.mu.Lock()
+		delete(m.jobs, id)
+		m.mu.Unlock()
+	})
+}
+
+// Get returns the Job registered under id, or nil if none exists.
+func (m *Manager) Get(id string) *Job {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.jobs[id]
+}
+
+// Cancel cancels the render behind id via its context.CancelFunc. Reports
+// false if id isn't a registered job.
+func (m *Manager) Cancel(id string) bool {
+	job := m.Get(id)
+	if job == nil {
+		return false
+	}
+	job.mu.Lock()
+	cancel := job.cancel
+	job.mu.Unlock()
+	cancel()
+	return true
+}
+
+// totalTiles uses phys.TileGrid so TotalTiles matches the number of
+// OnTile calls the render will actually make.
+func totalTiles(scene *phys.Scene) int {
+	_, numTilesX, numTilesY := phys.TileGrid(scene.RenderOptions)
+	return numTilesX * numTilesY
+}
+
+var jobIDCounter uint64
+
+// newJobID returns a short random hex ID, falling back to a monotonic
+// counter if the system RNG is unavailable (it practically never is).
+func newJobID() string {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return fmt.Sprintf("job-%d", atomic.AddUint64(&jobIDCounter, 1))
+	}
+	return hex.EncodeToString(b[:])
+}
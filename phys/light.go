@@ -4,15 +4,65 @@ package phys
 
 import (
 	"fmt"
+	"math"
 
 	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
 )
 
 type Light interface {
 	Sample(p r3.Point, rand *Rand) (direction r3.Vec, distance Distance, radiance r3.Vec)
+	// Pdf returns the solid-angle probability density of having sampled
+	// direction dir from point p via Sample, letting a future two-strategy
+	// MIS combination (see BSDFPDF, used for Emitter nodes via
+	// connectToVertex) weight a light sample against a BSDF-sampled ray
+	// that might independently land on the same light. PointLight and
+	// SpotLight are delta lights: Sample always returns one exact
+	// direction of zero solid-angle measure, so Pdf is always 0. DiskLight
+	// and QuadLight are not delta lights -- Sample draws a different point
+	// on their surface each call -- so Pdf reports the real density of
+	// that area-sampling strategy. Either way, ComputeDirectLighting's
+	// scene.Light loop doesn't MIS-weight its contribution: a Light isn't
+	// a Node, so unlike an Emitter-material Node (see misWeightedEmission)
+	// a BSDF-sampled ray can never hit one through normal scene
+	// intersection, leaving no competing strategy to weight against.
+	Pdf(p r3.Point, dir r3.Vec) float64
+	// EmittedRadiance reports the radiance arriving at p from direction dir,
+	// if a ray cast from p along dir would actually strike this light, and
+	// the distance to that point -- the counterpart a BSDF-sampled
+	// direction needs to contribute a second, MIS-weighted estimate to
+	// direct lighting (see ComputeDirectLighting's light-sampled loop in
+	// Dielectric and RoughPlastic). PointLight and SpotLight are delta
+	// lights of zero solid-angle measure, so no BSDF sample can ever land
+	// exactly on one: they always return a zero radiance. DiskLight and
+	// QuadLight return the radiance and distance at dir's intersection
+	// with their surface, or a zero radiance if dir misses it.
+	EmittedRadiance(p r3.Point, dir r3.Vec) (radiance r3.Vec, distance Distance)
 	Validate() error
 }
 
+// sampledLights returns the scene.Light loop's scene.Light for a single
+// surface hit, and the weight each light's contribution should be scaled
+// by to keep the sum an unbiased estimator of summing every light. With
+// RenderOptions.DirectLightSamples unset (zero) or at least len(scene.Light),
+// it returns every light with weight 1 -- exactly today's behavior. A
+// smaller positive value instead draws that many lights uniformly with
+// replacement, each scaled by len(scene.Light)/DirectLightSamples: a scene
+// with many small lights pays for DirectLightSamples shadow rays per hit
+// instead of one per light, trading variance (which RaysPerPixel still
+// irons out) for convergence speed. PointLight/SpotLight/DiskLight/QuadLight
+// don't care how many times they're drawn in one call; Sample is still
+// independently random each time.
+func sampledLights(lights []Light, n int, rand *Rand) ([]Light, float64) {
+	if n <= 0 || n >= len(lights) {
+		return lights, 1
+	}
+	picked := make([]Light, n)
+	for i := range picked {
+		picked[i] = lights[rand.Intn(len(lights))]
+	}
+	return picked, float64(len(lights)) / float64(n)
+}
+
 type PointLight struct {
 	Position         r3.Point
 	RadiantIntensity r3.Vec // Radiant intensity (color and strength) (W/sr)
@@ -34,6 +84,367 @@ func (pl PointLight) Sample(p r3.Point, rand *Rand) (direction r3.Vec, distance
 	return dir, Distance(dist), pl.RadiantIntensity
 }
 
+// Pdf always returns 0: PointLight is a delta light (see the Light.Pdf
+// doc comment), so no direction other than the one Sample itself draws
+// has any chance of landing on it.
+func (pl PointLight) Pdf(p r3.Point, dir r3.Vec) float64 {
+	return 0
+}
+
+// EmittedRadiance always returns a zero radiance: PointLight is a delta
+// light (see the Light.EmittedRadiance doc comment), so a continuously
+// sampled direction can never land exactly on it.
+func (pl PointLight) EmittedRadiance(p r3.Point, dir r3.Vec) (r3.Vec, Distance) {
+	return r3.Vec{}, 0
+}
+
+// SpotLight is a PointLight restricted to a cone: radiant intensity is full
+// strength inside InnerConeAngle, smoothly falls to zero between
+// InnerConeAngle and OuterConeAngle (both measured from Direction, in
+// radians), and is zero outside OuterConeAngle. This is the point/spot
+// split glTF's KHR_lights_punctual extension makes (see phys/gltf), hence
+// the field names matching its innerConeAngle/outerConeAngle.
+type SpotLight struct {
+	Position         r3.Point
+	Direction        r3.Vec // Points from Position toward the lit side of the cone.
+	RadiantIntensity r3.Vec // Radiant intensity (color and strength) (W/sr), at the cone's center.
+	InnerConeAngle   float64
+	OuterConeAngle   float64
+}
+
+func (sl SpotLight) Validate() error {
+	if sl.RadiantIntensity.X < 0 || sl.RadiantIntensity.Y < 0 || sl.RadiantIntensity.Z < 0 {
+		return fmt.Errorf("invalid SpotLight RadiantIntensity: %v (should be non-negative)", sl.RadiantIntensity)
+	}
+	if sl.InnerConeAngle < 0 || sl.InnerConeAngle > sl.OuterConeAngle {
+		return fmt.Errorf("invalid SpotLight cone angles: InnerConeAngle=%v OuterConeAngle=%v (want 0 <= Inner <= Outer)", sl.InnerConeAngle, sl.OuterConeAngle)
+	}
+	if sl.OuterConeAngle > math.Pi/2 {
+		return fmt.Errorf("invalid SpotLight OuterConeAngle %v (should be at most pi/2)", sl.OuterConeAngle)
+	}
+	return nil
+}
+
+// Sample behaves like PointLight.Sample, but scales radiantIntensity by the
+// cone's angular attenuation: 1 inside InnerConeAngle, a smooth falloff out
+// to OuterConeAngle, and 0 beyond it -- the same shape KHR_lights_punctual
+// specifies for its spot lights, so an imported/exported glTF spot light
+// behaves the way the asset's authoring tool previewed it.
+func (sl SpotLight) Sample(p r3.Point, rand *Rand) (direction r3.Vec, distance Distance, radiantIntensity r3.Vec) {
+	dir := sl.Position.Sub(p)
+	dist := dir.Length()
+	dir = dir.Divs(dist)
+
+	cos := dir.Muls(-1).Dot(sl.Direction.Unit())
+	outerCos := math.Cos(sl.OuterConeAngle)
+	innerCos := math.Cos(sl.InnerConeAngle)
+	var atten float64
+	switch {
+	case cos <= outerCos:
+		atten = 0
+	case cos >= innerCos:
+		atten = 1
+	default:
+		t := (cos - outerCos) / (innerCos - outerCos)
+		atten = t * t * (3 - 2*t) // smoothstep, matching KHR_lights_punctual's reference implementation.
+	}
+	return dir, Distance(dist), sl.RadiantIntensity.Muls(atten)
+}
+
+// Pdf always returns 0, for the same reason as PointLight.Pdf: SpotLight
+// is a delta light restricted to a cone, not a continuous distribution
+// over it.
+func (sl SpotLight) Pdf(p r3.Point, dir r3.Vec) float64 {
+	return 0
+}
+
+// EmittedRadiance always returns a zero radiance, for the same reason as
+// PointLight.EmittedRadiance: SpotLight is a delta light.
+func (sl SpotLight) EmittedRadiance(p r3.Point, dir r3.Vec) (r3.Vec, Distance) {
+	return r3.Vec{}, 0
+}
+
+// DiskLight is an area light emitting from one face of a flat disk,
+// letting a scene approximate a physical light fixture (e.g. a downlight
+// or a softbox) with real penumbrae instead of PointLight's hard shadows.
+// Unlike PointLight and SpotLight, DiskLight is not a delta light: Sample
+// draws a different point on the disk each call, so Pdf reports a real,
+// non-zero solid-angle density rather than 0.
+type DiskLight struct {
+	Center   r3.Point
+	Normal   r3.Vec // Unit normal; the disk emits from the side Normal points toward.
+	Radius   float64
+	Radiance r3.Vec // Emitted radiance (W/m^2/sr), uniform over the disk's surface.
+}
+
+func (dl DiskLight) Validate() error {
+	if dl.Radius <= 0 {
+		return fmt.Errorf("invalid DiskLight Radius: %v (has it been set?)", dl.Radius)
+	}
+	if dl.Normal.IsZero() {
+		return fmt.Errorf("invalid DiskLight Normal: %v (has it been set?)", dl.Normal)
+	}
+	if dl.Radiance.X < 0 || dl.Radiance.Y < 0 || dl.Radiance.Z < 0 {
+		return fmt.Errorf("invalid DiskLight Radiance: %v (should be non-negative)", dl.Radiance)
+	}
+	return nil
+}
+
+// Sample draws a point uniformly distributed over the disk's area, returning
+// the direction and distance to it from p along with the radiance arriving
+// from it: Radiance scaled by the geometric attenuation cos(theta_light)/r^2
+// and by the disk's area, the Monte Carlo conversion from an area-measure
+// sample (pdf 1/area) to the solid-angle-measure contribution
+// ComputeDirectLighting expects light.Sample to already have folded in, the
+// same way PointLight.Sample does for a delta light. Returns a zero
+// radiance, rather than a negative one, when the sampled point faces away
+// from p.
+func (dl DiskLight) Sample(p r3.Point, rand *Rand) (direction r3.Vec, distance Distance, radiance r3.Vec) {
+	normal := dl.Normal.Unit()
+	t, b := orthonormalBasis(normal)
+	d := rand.InUnitDisk()
+	sample := dl.Center.Add(t.Muls(d.X * dl.Radius)).Add(b.Muls(d.Y * dl.Radius))
+
+	toLight := sample.Sub(p)
+	dist := toLight.Length()
+	if dist < eps {
+		return r3.Vec{}, 0, r3.Vec{}
+	}
+	dir := toLight.Divs(dist)
+	cosLight := math.Max(0, normal.Dot(dir.Muls(-1)))
+	if cosLight <= 0 {
+		return dir, Distance(dist), r3.Vec{}
+	}
+	area := math.Pi * dl.Radius * dl.Radius
+	return dir, Distance(dist), dl.Radiance.Muls(cosLight * area / (dist * dist))
+}
+
+// Pdf returns the solid-angle density of Sample having produced direction
+// wi from p: distance^2 / (cos(theta_light) * area), the standard
+// area-to-solid-angle Jacobian for a uniformly sampled area light. It
+// returns 0 if a ray from p along wi misses the disk's plane, its bounding
+// circle, or approaches edge-on, since Sample could never have produced wi
+// in that case.
+func (dl DiskLight) Pdf(p r3.Point, wi r3.Vec) float64 {
+	normal := dl.Normal.Unit()
+	denom := normal.Dot(wi)
+	if math.Abs(denom) < eps {
+		return 0
+	}
+	dist := normal.Dot(dl.Center.Sub(p)) / denom
+	if dist <= 0 {
+		return 0
+	}
+	hit := p.Add(wi.Muls(dist))
+	if hit.Sub(dl.Center).Length() > dl.Radius {
+		return 0
+	}
+	cosLight := math.Abs(denom)
+	area := math.Pi * dl.Radius * dl.Radius
+	return dist * dist / (cosLight * area)
+}
+
+// EmittedRadiance intersects dir against dl's disk the same way Pdf does,
+// returning dl.Radiance and the hit distance if dir actually strikes the
+// emitting face (denom < 0, i.e. the disk's front), or a zero radiance if
+// dir misses the disk's plane, its bounding circle, or strikes the back.
+func (dl DiskLight) EmittedRadiance(p r3.Point, dir r3.Vec) (r3.Vec, Distance) {
+	normal := dl.Normal.Unit()
+	denom := normal.Dot(dir)
+	if denom >= -eps {
+		return r3.Vec{}, 0
+	}
+	dist := normal.Dot(dl.Center.Sub(p)) / denom
+	if dist <= 0 {
+		return r3.Vec{}, 0
+	}
+	hit := p.Add(dir.Muls(dist))
+	if hit.Sub(dl.Center).Length() > dl.Radius {
+		return r3.Vec{}, 0
+	}
+	return dl.Radiance, Distance(dist)
+}
+
+// diskLTCSegments is how many straight edges DiskLight's LTC evaluation
+// approximates its circular boundary with (see ltcIrradiance's doc
+// comment on why a polygon stands in for an exact closed form -- a
+// circular light's exact LTC integral needs an elliptic integral this
+// package doesn't implement). 24 keeps the approximation within Monte
+// Carlo's own noise floor at a few hundred thousand samples (see
+// TestDiskDiffuseIrradianceLTCMatchesMonteCarlo) while keeping the
+// edge-integral loop cheap enough to re-evaluate at every shading point.
+const diskLTCSegments = 24
+
+// polygon returns diskLTCSegments points evenly spaced around dl's
+// boundary circle, in perimeter order, the regular-polygon approximation
+// DiffuseIrradianceLTC and GlossyRadianceLTC integrate exactly in place
+// of dl's true circular edge.
+func (dl DiskLight) polygon() []r3.Point {
+	tangent, bitangent := orthonormalBasis(dl.Normal.Unit())
+	points := make([]r3.Point, diskLTCSegments)
+	for i := range points {
+		theta := 2 * math.Pi * float64(i) / float64(diskLTCSegments)
+		offset := tangent.Muls(dl.Radius * math.Cos(theta)).Add(bitangent.Muls(dl.Radius * math.Sin(theta)))
+		points[i] = dl.Center.Add(offset)
+	}
+	return points
+}
+
+// localPolygon expresses dl's boundary polygon as unit vectors from p in
+// the shading frame whose axes are shadingTangent, shadingBitangent,
+// shadingNormal (in that order, matching ltcIrradiance's expected axis
+// order), the same projection RectLight.localQuad performs for its own
+// exact 4-cornered boundary.
+func (dl DiskLight) localPolygon(p r3.Point, shadingNormal r3.Vec) []r3.Vec {
+	t, b := orthonormalBasis(shadingNormal.Unit())
+	corners := dl.polygon()
+	local := make([]r3.Vec, len(corners))
+	for i, c := range corners {
+		toCorner := c.Sub(p)
+		local[i] = r3.Vec{X: toCorner.Dot(t), Y: toCorner.Dot(b), Z: toCorner.Dot(shadingNormal)}
+	}
+	return local
+}
+
+// faces reports whether p lies on dl's emitting side, the same
+// one-sidedness guard RectLight.faces documents: ltcIrradiance's horizon
+// clip only knows about the shading point's own hemisphere, not which
+// side of the light's plane is emissive.
+func (dl DiskLight) faces(p r3.Point) bool {
+	return p.Sub(dl.Center).Dot(dl.Normal) > 0
+}
+
+// DiffuseIrradianceLTC returns the noise-free irradiance a Lambertian
+// surface at p with the given shading normal receives from dl, using the
+// same Linearly Transformed Cosines machinery as
+// RectLight.DiffuseIrradianceLTC, with dl's circular boundary
+// approximated by a diskLTCSegments-sided polygon (see polygon's doc
+// comment).
+func (dl DiskLight) DiffuseIrradianceLTC(p r3.Point, shadingNormal r3.Vec) r3.Vec {
+	if !dl.faces(p) {
+		return r3.Vec{}
+	}
+	poly := dl.localPolygon(p, shadingNormal)
+	irradiance := ltcIrradiance(r3.IdentityMat3x3(), poly)
+	return dl.Radiance.Muls(irradiance)
+}
+
+// GlossyRadianceLTC is DiskLight's counterpart to
+// RectLight.GlossyRadianceLTC -- see that method and ltcGlossyMinv's doc
+// comment for the documented analytic approximation its GGX warp uses.
+func (dl DiskLight) GlossyRadianceLTC(p r3.Point, shadingNormal, viewDir r3.Vec, roughness float64) r3.Vec {
+	if !dl.faces(p) {
+		return r3.Vec{}
+	}
+	poly := dl.localPolygon(p, shadingNormal)
+	t, b := orthonormalBasis(shadingNormal.Unit())
+	cosTheta := math.Max(0, viewDir.Dot(shadingNormal.Unit()))
+	localView := r3.Vec{X: viewDir.Dot(t), Y: viewDir.Dot(b), Z: cosTheta}
+	minv := ltcOrientGlossyMinv(ltcGlossyMinv(roughness, cosTheta), localView)
+	irradiance := ltcIrradiance(minv, poly)
+	magnitude := ltcGlossyMagnitude(roughness, cosTheta)
+	return dl.Radiance.Muls(irradiance * magnitude)
+}
+
+// QuadLight is DiskLight's rectangular counterpart: an area light emitting
+// from one face of a finite plane, matching the shape of a softbox or a
+// window more closely than a disk does.
+type QuadLight struct {
+	Center   r3.Point
+	Normal   r3.Vec // Unit normal; the quad emits from the side Normal points toward.
+	Width    float64
+	Height   float64
+	Radiance r3.Vec // Emitted radiance (W/m^2/sr), uniform over the quad's surface.
+}
+
+func (ql QuadLight) Validate() error {
+	if ql.Width <= 0 {
+		return fmt.Errorf("invalid QuadLight Width: %v (has it been set?)", ql.Width)
+	}
+	if ql.Height <= 0 {
+		return fmt.Errorf("invalid QuadLight Height: %v (has it been set?)", ql.Height)
+	}
+	if ql.Normal.IsZero() {
+		return fmt.Errorf("invalid QuadLight Normal: %v (has it been set?)", ql.Normal)
+	}
+	if ql.Radiance.X < 0 || ql.Radiance.Y < 0 || ql.Radiance.Z < 0 {
+		return fmt.Errorf("invalid QuadLight Radiance: %v (should be non-negative)", ql.Radiance)
+	}
+	return nil
+}
+
+// Sample behaves like DiskLight.Sample, but draws the surface point
+// uniformly over the quad's Width x Height rectangle instead of a disk.
+func (ql QuadLight) Sample(p r3.Point, rand *Rand) (direction r3.Vec, distance Distance, radiance r3.Vec) {
+	normal := ql.Normal.Unit()
+	t, b := orthonormalBasis(normal)
+	u := (rand.Float64() - 0.5) * ql.Width
+	v := (rand.Float64() - 0.5) * ql.Height
+	sample := ql.Center.Add(t.Muls(u)).Add(b.Muls(v))
+
+	toLight := sample.Sub(p)
+	dist := toLight.Length()
+	if dist < eps {
+		return r3.Vec{}, 0, r3.Vec{}
+	}
+	dir := toLight.Divs(dist)
+	cosLight := math.Max(0, normal.Dot(dir.Muls(-1)))
+	if cosLight <= 0 {
+		return dir, Distance(dist), r3.Vec{}
+	}
+	area := ql.Width * ql.Height
+	return dir, Distance(dist), ql.Radiance.Muls(cosLight * area / (dist * dist))
+}
+
+// Pdf behaves like DiskLight.Pdf, but checks the plane-intersection point
+// against the quad's Width x Height rectangle (in its own tangent/bitangent
+// axes) instead of a bounding circle.
+func (ql QuadLight) Pdf(p r3.Point, wi r3.Vec) float64 {
+	normal := ql.Normal.Unit()
+	denom := normal.Dot(wi)
+	if math.Abs(denom) < eps {
+		return 0
+	}
+	dist := normal.Dot(ql.Center.Sub(p)) / denom
+	if dist <= 0 {
+		return 0
+	}
+	hit := p.Add(wi.Muls(dist))
+	t, b := orthonormalBasis(normal)
+	toHit := hit.Sub(ql.Center)
+	if math.Abs(toHit.Dot(t)) > ql.Width/2 || math.Abs(toHit.Dot(b)) > ql.Height/2 {
+		return 0
+	}
+	cosLight := math.Abs(denom)
+	area := ql.Width * ql.Height
+	return dist * dist / (cosLight * area)
+}
+
+// EmittedRadiance behaves like DiskLight.EmittedRadiance, but checks the
+// plane-intersection point against ql's Width x Height rectangle instead
+// of a bounding circle, mirroring QuadLight.Pdf's own check.
+func (ql QuadLight) EmittedRadiance(p r3.Point, dir r3.Vec) (r3.Vec, Distance) {
+	normal := ql.Normal.Unit()
+	denom := normal.Dot(dir)
+	if denom >= -eps {
+		return r3.Vec{}, 0
+	}
+	dist := normal.Dot(ql.Center.Sub(p)) / denom
+	if dist <= 0 {
+		return r3.Vec{}, 0
+	}
+	hit := p.Add(dir.Muls(dist))
+	t, b := orthonormalBasis(normal)
+	toHit := hit.Sub(ql.Center)
+	if math.Abs(toHit.Dot(t)) > ql.Width/2 || math.Abs(toHit.Dot(b)) > ql.Height/2 {
+		return r3.Vec{}, 0
+	}
+	return ql.Radiance, Distance(dist)
+}
+
 func init() {
 	RegisterInterfaceType(PointLight{})
+	RegisterInterfaceType(SpotLight{})
+	RegisterInterfaceType(DiskLight{})
+	RegisterInterfaceType(QuadLight{})
 }
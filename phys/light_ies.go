@@ -0,0 +1,289 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+
+package phys
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
+)
+
+// IESProfile holds one IESNA LM-63 photometric web: the measured
+// luminous intensity (candela) of a luminaire, tabulated over a grid of
+// vertical angles (from the luminaire's nadir, 0 to 180 degrees) and
+// horizontal angles (azimuth around its vertical axis, 0 to 360
+// degrees). ParseIESProfile reads one from a .ies file's contents.
+type IESProfile struct {
+	// VerticalAngles and HorizontalAngles are the grid's axes, in
+	// degrees, each strictly ascending.
+	VerticalAngles   []float64
+	HorizontalAngles []float64
+	// Candela[h][v] is the luminous intensity at HorizontalAngles[h],
+	// VerticalAngles[v], already scaled by the file's candela multiplier.
+	Candela [][]float64
+}
+
+func (p *IESProfile) Validate() error {
+	if len(p.VerticalAngles) < 2 {
+		return fmt.Errorf("invalid IESProfile: need at least 2 VerticalAngles, got %d", len(p.VerticalAngles))
+	}
+	if len(p.HorizontalAngles) < 1 {
+		return fmt.Errorf("invalid IESProfile: need at least 1 HorizontalAngle, got %d", len(p.HorizontalAngles))
+	}
+	for i := 1; i < len(p.VerticalAngles); i++ {
+		if p.VerticalAngles[i] <= p.VerticalAngles[i-1] {
+			return fmt.Errorf("invalid IESProfile: VerticalAngles must be strictly ascending, got %v", p.VerticalAngles)
+		}
+	}
+	for i := 1; i < len(p.HorizontalAngles); i++ {
+		if p.HorizontalAngles[i] <= p.HorizontalAngles[i-1] {
+			return fmt.Errorf("invalid IESProfile: HorizontalAngles must be strictly ascending, got %v", p.HorizontalAngles)
+		}
+	}
+	if len(p.Candela) != len(p.HorizontalAngles) {
+		return fmt.Errorf("invalid IESProfile: Candela has %d rows, want %d (one per HorizontalAngle)", len(p.Candela), len(p.HorizontalAngles))
+	}
+	for h, row := range p.Candela {
+		if len(row) != len(p.VerticalAngles) {
+			return fmt.Errorf("invalid IESProfile: Candela[%d] has %d columns, want %d (one per VerticalAngle)", h, len(row), len(p.VerticalAngles))
+		}
+	}
+	return nil
+}
+
+// ParseIESProfile reads an IESNA LM-63 photometric data file (the .ies
+// format lighting manufacturers publish for real luminaires) from r.
+//
+// Only TILT=NONE files are supported: TILT=INCLUDE names a separate
+// tilt-correction table for luminaires whose output varies as they're
+// tilted from vertical (e.g. some fluorescent fixtures), a distinct,
+// much larger feature this parser doesn't attempt -- it returns an
+// explicit error rather than silently ignoring the correction.
+func ParseIESProfile(r io.Reader) (*IESProfile, error) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	var tilt string
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if strings.HasPrefix(line, "TILT=") {
+			tilt = strings.TrimPrefix(line, "TILT=")
+			break
+		}
+		// Version header and [KEYWORD] lines precede TILT=; everything
+		// else about them is irrelevant to the photometric data itself.
+	}
+	if tilt == "" {
+		return nil, fmt.Errorf("ParseIESProfile: no TILT= line found")
+	}
+	if tilt != "NONE" {
+		return nil, fmt.Errorf("ParseIESProfile: TILT=%s not supported, only TILT=NONE", tilt)
+	}
+
+	fields, err := scanFields(sc)
+	if err != nil {
+		return nil, fmt.Errorf("ParseIESProfile: %w", err)
+	}
+	next := func(n int) ([]float64, error) {
+		if len(fields) < n {
+			return nil, fmt.Errorf("ParseIESProfile: expected %d more fields, have %d", n, len(fields))
+		}
+		vals, rest := fields[:n], fields[n:]
+		fields = rest
+		return vals, nil
+	}
+
+	counts, err := next(10)
+	if err != nil {
+		return nil, err
+	}
+	numVertical := int(counts[3])
+	numHorizontal := int(counts[4])
+	candelaMultiplier := counts[2]
+
+	if _, err := next(3); err != nil { // Ballast factor, ballast-lamp photometric factor, input watts: unused here.
+		return nil, fmt.Errorf("ParseIESProfile: %w", err)
+	}
+
+	vertical, err := next(numVertical)
+	if err != nil {
+		return nil, fmt.Errorf("ParseIESProfile: vertical angles: %w", err)
+	}
+	horizontal, err := next(numHorizontal)
+	if err != nil {
+		return nil, fmt.Errorf("ParseIESProfile: horizontal angles: %w", err)
+	}
+
+	candela := make([][]float64, numHorizontal)
+	for h := range candela {
+		row, err := next(numVertical)
+		if err != nil {
+			return nil, fmt.Errorf("ParseIESProfile: candela row %d: %w", h, err)
+		}
+		candela[h] = make([]float64, numVertical)
+		for v, c := range row {
+			candela[h][v] = c * candelaMultiplier
+		}
+	}
+
+	profile := &IESProfile{VerticalAngles: vertical, HorizontalAngles: horizontal, Candela: candela}
+	if err := profile.Validate(); err != nil {
+		return nil, fmt.Errorf("ParseIESProfile: %w", err)
+	}
+	return profile, nil
+}
+
+// scanFields drains sc's remaining lines into one slice of
+// whitespace-separated floats: the LM-63 data after the TILT= line packs
+// its count/multiplier lines and angle/candela arrays with no fixed
+// correspondence between a value and the line it's printed on, so the
+// only reliable way to read it is to tokenize the whole remainder and
+// consume counted runs of it, as ParseIESProfile's next closure does.
+func scanFields(sc *bufio.Scanner) ([]float64, error) {
+	var fields []float64
+	for sc.Scan() {
+		for _, tok := range strings.Fields(sc.Text()) {
+			v, err := strconv.ParseFloat(tok, 64)
+			if err != nil {
+				return nil, fmt.Errorf("field %q: %w", tok, err)
+			}
+			fields = append(fields, v)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// bracketAngle returns the index i such that angles[i] <= x <=
+// angles[i+1] (clamped to angles' range at either end) and u, the
+// fraction of that span x has reached, for Intensity to interpolate
+// across. Mirrors CameraTrack.bracket's clamp-and-locate shape.
+func bracketAngle(angles []float64, x float64) (i int, u float64) {
+	n := len(angles)
+	if n == 1 || x <= angles[0] {
+		return 0, 0
+	}
+	if x >= angles[n-1] {
+		return n - 2, 1
+	}
+	i = 0
+	for i < n-2 && angles[i+1] < x {
+		i++
+	}
+	span := angles[i+1] - angles[i]
+	return i, (x - angles[i]) / span
+}
+
+// Intensity returns the candela value at vertical angle thetaDeg
+// (measured from nadir, 0 to 180) and horizontal angle phiDeg (azimuth,
+// 0 to 360), bilinearly interpolated over p's (horizontal, vertical)
+// grid. A single-row profile (common for axially symmetric luminaires,
+// where HorizontalAngles holds just one entry) skips the horizontal
+// interpolation entirely.
+func (p *IESProfile) Intensity(thetaDeg, phiDeg float64) float64 {
+	vi, vu := bracketAngle(p.VerticalAngles, thetaDeg)
+	lerpRow := func(row []float64) float64 {
+		return row[vi] + (row[vi+1]-row[vi])*vu
+	}
+	if len(p.HorizontalAngles) == 1 {
+		return lerpRow(p.Candela[0])
+	}
+	hi, hu := bracketAngle(p.HorizontalAngles, math.Mod(phiDeg, 360))
+	lo := lerpRow(p.Candela[hi])
+	hi2 := lerpRow(p.Candela[hi+1])
+	return lo + (hi2-lo)*hu
+}
+
+// IESLight is a PointLight whose radiant intensity comes from a
+// measured luminaire's photometric distribution (Profile) instead of
+// radiating isotropically, so a scene can use real-world spotlight or
+// downlight data instead of only SpotLight's idealized cone -- the same
+// "modulate PointLight's Sample by an angular factor" shape SpotLight
+// already uses, generalized from a smoothstep cone to a full tabulated
+// candela grid.
+//
+// Profile's angles are defined in a local frame with the luminaire
+// aiming down its own -Y axis (vertical angle 0 = nadir, straight down)
+// and horizontal angle 0 along its own +Z axis; Orientation rotates that
+// local frame into world space.
+type IESLight struct {
+	Position    r3.Point
+	Orientation Quaternion
+	Profile     *IESProfile
+	// Tint colors Profile's scalar candela values, since an IES file
+	// carries only luminous intensity, no color, the same role
+	// PointLight.RadiantIntensity plays for both color and strength at
+	// once.
+	Tint r3.Vec
+}
+
+func (il IESLight) Validate() error {
+	if il.Profile == nil {
+		return fmt.Errorf("invalid IESLight: Profile is nil")
+	}
+	if err := il.Profile.Validate(); err != nil {
+		return fmt.Errorf("invalid IESLight: %w", err)
+	}
+	if il.Tint.X < 0 || il.Tint.Y < 0 || il.Tint.Z < 0 {
+		return fmt.Errorf("invalid IESLight Tint: %v (should be non-negative)", il.Tint)
+	}
+	return nil
+}
+
+// localAngles converts a world-space unit direction, pointing away from
+// the luminaire toward the lit point, into the profile's local (theta,
+// phi) in degrees: theta from the -Y nadir axis, phi around it from +Z.
+func (il IESLight) localAngles(dir r3.Vec) (thetaDeg, phiDeg float64) {
+	local := il.Orientation.Conjugate().ToRotationMatrix().TransformVec(dir)
+	theta := math.Acos(clamp(-local.Y, -1, 1))
+	phi := math.Atan2(local.X, local.Z)
+	if phi < 0 {
+		phi += 2 * math.Pi
+	}
+	return theta * 180 / math.Pi, phi * 180 / math.Pi
+}
+
+// Sample behaves like PointLight.Sample, scaling Tint by
+// Profile.Intensity at the angle toward p in il's local frame instead of
+// radiating isotropically.
+func (il IESLight) Sample(p r3.Point, rand *Rand) (direction r3.Vec, distance Distance, radiantIntensity r3.Vec) {
+	dir := il.Position.Sub(p)
+	dist := dir.Length()
+	dir = dir.Divs(dist)
+
+	// Profile.Intensity is tabulated looking out from the luminaire, so
+	// the angle it indexes is toward p, the reverse of dir (which points
+	// from p toward il.Position).
+	theta, phi := il.localAngles(dir.Muls(-1))
+	return dir, Distance(dist), il.Tint.Muls(il.Profile.Intensity(theta, phi))
+}
+
+// Pdf always returns 0: like PointLight and SpotLight, IESLight is a
+// delta light (see the Light.Pdf doc comment).
+func (il IESLight) Pdf(p r3.Point, dir r3.Vec) float64 {
+	return 0
+}
+
+// EmittedRadiance always returns a zero radiance: like PointLight and
+// SpotLight, IESLight is a delta light, so no BSDF-sampled direction can
+// ever land exactly on it.
+func (il IESLight) EmittedRadiance(p r3.Point, dir r3.Vec) (r3.Vec, Distance) {
+	return r3.Vec{}, 0
+}
+
+// Conjugate returns q's conjugate, the inverse rotation for any unit
+// quaternion: negate the vector part, leave W alone.
+func (q Quaternion) Conjugate() Quaternion {
+	return Quaternion{X: -q.X, Y: -q.Y, Z: -q.Z, W: q.W}
+}
+
+func init() {
+	RegisterInterfaceType(IESLight{})
+}
@@ -0,0 +1,141 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
+)
+
+// testIESFile is a minimal, axially symmetric (single horizontal angle)
+// IESNA LM-63 file: full intensity at nadir, fading to zero at the
+// horizon, enough to exercise ParseIESProfile's header/count/array
+// parsing without a real manufacturer's data file.
+const testIESFile = `IESNA:LM-63-2002
+[TEST] synthetic profile for phys tests
+TILT=NONE
+1 1000 1 3 1 1 2 0 0 0
+1 1 100
+0 45 90
+0
+100 50 0
+`
+
+func TestParseIESProfile(t *testing.T) {
+	p, err := ParseIESProfile(strings.NewReader(testIESFile))
+	if err != nil {
+		t.Fatalf("ParseIESProfile: %v", err)
+	}
+	if want := []float64{0, 45, 90}; !floatsEqual(p.VerticalAngles, want) {
+		t.Errorf("VerticalAngles = %v, want %v", p.VerticalAngles, want)
+	}
+	if want := []float64{0}; !floatsEqual(p.HorizontalAngles, want) {
+		t.Errorf("HorizontalAngles = %v, want %v", p.HorizontalAngles, want)
+	}
+	if want := []float64{100, 50, 0}; !floatsEqual(p.Candela[0], want) {
+		t.Errorf("Candela[0] = %v, want %v", p.Candela[0], want)
+	}
+}
+
+func floatsEqual(a, b []float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// TestParseIESProfileRejectsTiltInclude verifies TILT=INCLUDE is
+// rejected with an explicit error instead of silently ignoring its
+// tilt-correction table.
+func TestParseIESProfileRejectsTiltInclude(t *testing.T) {
+	bad := strings.Replace(testIESFile, "TILT=NONE", "TILT=INCLUDE", 1)
+	if _, err := ParseIESProfile(strings.NewReader(bad)); err == nil {
+		t.Fatal("ParseIESProfile: expected an error for TILT=INCLUDE, got nil")
+	}
+}
+
+// TestParseIESProfileRejectsTruncatedData verifies a file cut off before
+// its candela matrix is fully read is an error, not a short read that
+// produces an inconsistently sized IESProfile.
+func TestParseIESProfileRejectsTruncatedData(t *testing.T) {
+	lines := strings.Split(testIESFile, "\n")
+	truncated := strings.Join(lines[:len(lines)-2], "\n") // Drop the angle/candela lines.
+	if _, err := ParseIESProfile(strings.NewReader(truncated)); err == nil {
+		t.Fatal("ParseIESProfile: expected an error for truncated data, got nil")
+	}
+}
+
+// TestIESProfileIntensityInterpolates verifies Intensity returns exact
+// table values at grid points and interpolates linearly between them.
+func TestIESProfileIntensityInterpolates(t *testing.T) {
+	p, err := ParseIESProfile(strings.NewReader(testIESFile))
+	if err != nil {
+		t.Fatalf("ParseIESProfile: %v", err)
+	}
+	cases := []struct {
+		theta, phi float64
+		want       float64
+	}{
+		{0, 0, 100},
+		{45, 0, 50},
+		{90, 0, 0},
+		{22.5, 0, 75}, // Halfway between the 0 and 45 degree rows.
+	}
+	for _, c := range cases {
+		if got := p.Intensity(c.theta, c.phi); got != c.want {
+			t.Errorf("Intensity(%v, %v) = %v, want %v", c.theta, c.phi, got, c.want)
+		}
+	}
+}
+
+// TestIESLightSampleNadirMatchesProfile verifies a light positioned
+// directly above p, with an identity Orientation, samples the profile's
+// nadir (theta=0) intensity: p lies straight down from the light, the
+// direction IESLight's own local frame treats as nadir.
+func TestIESLightSampleNadirMatchesProfile(t *testing.T) {
+	profile, err := ParseIESProfile(strings.NewReader(testIESFile))
+	if err != nil {
+		t.Fatalf("ParseIESProfile: %v", err)
+	}
+	light := IESLight{
+		Position:    r3.Point{Y: 5},
+		Orientation: Quaternion{W: 1},
+		Profile:     profile,
+		Tint:        r3.Vec{X: 1, Y: 1, Z: 1},
+	}
+	dir, dist, intensity := light.Sample(r3.Point{}, NewRand(1))
+	if want := (r3.Vec{Y: 1}); !dir.IsClose(want, 1e-9) {
+		t.Errorf("Sample direction = %v, want %v", dir, want)
+	}
+	if want := Distance(5); dist != want {
+		t.Errorf("Sample distance = %v, want %v", dist, want)
+	}
+	if want := (r3.Vec{X: 100, Y: 100, Z: 100}); !intensity.IsClose(want, 1e-9) {
+		t.Errorf("Sample intensity = %v, want %v (nadir candela)", intensity, want)
+	}
+}
+
+// TestIESLightValidate verifies Validate rejects a nil Profile and a
+// negative Tint, the same non-negativity check PointLight/SpotLight
+// apply to their own RadiantIntensity.
+func TestIESLightValidate(t *testing.T) {
+	profile, err := ParseIESProfile(strings.NewReader(testIESFile))
+	if err != nil {
+		t.Fatalf("ParseIESProfile: %v", err)
+	}
+	if err := (IESLight{Profile: nil}).Validate(); err == nil {
+		t.Error("Validate: expected an error for a nil Profile, got nil")
+	}
+	if err := (IESLight{Profile: profile, Tint: r3.Vec{X: -1}}).Validate(); err == nil {
+		t.Error("Validate: expected an error for a negative Tint, got nil")
+	}
+	if err := (IESLight{Profile: profile, Tint: r3.Vec{X: 1, Y: 1, Z: 1}}).Validate(); err != nil {
+		t.Errorf("Validate: unexpected error for a valid IESLight: %v", err)
+	}
+}
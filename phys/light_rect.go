@@ -0,0 +1,219 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+
+package phys
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
+)
+
+// RectLight is QuadLight's analytically-shaded counterpart: the same flat
+// rectangular emitter, but evaluated at shading time with Linearly
+// Transformed Cosines (see phys/ltc.go) instead of Monte Carlo area
+// sampling. DiffuseIrradianceLTC and GlossyRadianceLTC give a noise-free
+// result in a single evaluation, at the cost of needing the shading
+// normal (and, for the glossy case, view direction and roughness) up
+// front rather than fitting into Light's Sample/Pdf/EmittedRadiance
+// MC-oriented interface -- RectLight still implements Light, behaving
+// exactly like QuadLight's own MC sampling, for callers (e.g.
+// ComputeDirectLighting's scene.Light loop) that haven't been updated to
+// call the LTC methods directly.
+//
+// Tangent fixes the rectangle's orientation: unlike DiskLight/QuadLight
+// (axially symmetric, so any in-plane rotation looks the same), a
+// rectangle's Width and Height axes need a defined direction. Tangent
+// must be perpendicular to Normal; Width runs along Tangent, Height along
+// Normal.Cross(Tangent).
+type RectLight struct {
+	Center   r3.Point
+	Normal   r3.Vec // Unit normal; the rect emits from the side Normal points toward.
+	Tangent  r3.Vec // Unit vector perpendicular to Normal, fixing Width's axis.
+	Width    float64
+	Height   float64
+	Radiance r3.Vec // Emitted radiance (W/m^2/sr), uniform over the rect's surface.
+}
+
+func (rl RectLight) Validate() error {
+	if rl.Width <= 0 {
+		return fmt.Errorf("invalid RectLight Width: %v (has it been set?)", rl.Width)
+	}
+	if rl.Height <= 0 {
+		return fmt.Errorf("invalid RectLight Height: %v (has it been set?)", rl.Height)
+	}
+	if rl.Normal.IsZero() {
+		return fmt.Errorf("invalid RectLight Normal: %v (has it been set?)", rl.Normal)
+	}
+	if rl.Tangent.IsZero() {
+		return fmt.Errorf("invalid RectLight Tangent: %v (has it been set?)", rl.Tangent)
+	}
+	if d := math.Abs(rl.Normal.Unit().Dot(rl.Tangent.Unit())); d > 1e-3 {
+		return fmt.Errorf("invalid RectLight Tangent %v: not perpendicular to Normal %v (dot=%v)", rl.Tangent, rl.Normal, d)
+	}
+	if rl.Radiance.X < 0 || rl.Radiance.Y < 0 || rl.Radiance.Z < 0 {
+		return fmt.Errorf("invalid RectLight Radiance: %v (should be non-negative)", rl.Radiance)
+	}
+	return nil
+}
+
+// axes returns rl's unit normal, tangent, and bitangent (normal cross
+// tangent), the local frame both corners and GlossyRadianceLTC's view
+// direction get expressed in.
+func (rl RectLight) axes() (normal, tangent, bitangent r3.Vec) {
+	normal = rl.Normal.Unit()
+	tangent = rl.Tangent.Unit()
+	bitangent = normal.Cross(tangent)
+	return normal, tangent, bitangent
+}
+
+// corners returns rl's four corner points, walking the rectangle's
+// perimeter in order (ltcIrradiance takes the magnitude of its edge sum,
+// so the winding direction itself doesn't need to match any particular
+// handedness -- only that consecutive corners share an edge).
+func (rl RectLight) corners() [4]r3.Point {
+	_, tangent, bitangent := rl.axes()
+	hw, hh := rl.Width/2, rl.Height/2
+	return [4]r3.Point{
+		rl.Center.Add(tangent.Muls(-hw)).Add(bitangent.Muls(hh)),
+		rl.Center.Add(tangent.Muls(-hw)).Add(bitangent.Muls(-hh)),
+		rl.Center.Add(tangent.Muls(hw)).Add(bitangent.Muls(-hh)),
+		rl.Center.Add(tangent.Muls(hw)).Add(bitangent.Muls(hh)),
+	}
+}
+
+// localQuad expresses rl's four corners as unit vectors from p in the
+// shading frame whose axes are shadingTangent, shadingBitangent,
+// shadingNormal (in that order, matching ltcIrradiance's expected
+// tangent/bitangent/normal axis order).
+func (rl RectLight) localQuad(p r3.Point, shadingNormal r3.Vec) [4]r3.Vec {
+	t, b := orthonormalBasis(shadingNormal.Unit())
+	corners := rl.corners()
+	var local [4]r3.Vec
+	for i, c := range corners {
+		toCorner := c.Sub(p)
+		local[i] = r3.Vec{X: toCorner.Dot(t), Y: toCorner.Dot(b), Z: toCorner.Dot(shadingNormal)}
+	}
+	return local
+}
+
+// DiffuseIrradianceLTC returns the noise-free irradiance a Lambertian
+// surface at p with the given shading normal receives from rl, using the
+// exact (no warp needed: Minv is the identity matrix for an ideal
+// clamped-cosine BRDF) Linearly Transformed Cosines integral instead of
+// averaging QuadLight-style Monte Carlo samples. This replaces thousands
+// of Sample draws converging toward the same answer with one evaluation.
+func (rl RectLight) DiffuseIrradianceLTC(p r3.Point, shadingNormal r3.Vec) r3.Vec {
+	if !rl.faces(p) {
+		return r3.Vec{}
+	}
+	quad := rl.localQuad(p, shadingNormal)
+	irradiance := ltcIrradiance(r3.IdentityMat3x3(), quad[:])
+	return rl.Radiance.Muls(irradiance)
+}
+
+// faces reports whether p lies on rl's emitting side: ltcIrradiance's
+// horizon clip only knows about the shading point's own hemisphere, not
+// which side of the light's plane is one-sidedly emissive, so every LTC
+// entry point checks this first, the same way QuadLight.EmittedRadiance's
+// denom sign check does for its Monte Carlo counterpart.
+func (rl RectLight) faces(p r3.Point) bool {
+	return p.Sub(rl.Center).Dot(rl.Normal) > 0
+}
+
+// GlossyRadianceLTC returns rl's noise-free contribution to a glossy GGX
+// lobe of the given roughness (0 = mirror, 1 = fully rough) at p, viewed
+// from viewDir (unit vector from p toward the camera/previous bounce).
+// See ltcGlossyMinv's doc comment: the warp this applies is a documented
+// analytic approximation to the real, numerically fitted GGX LTC tables,
+// not the tables themselves.
+func (rl RectLight) GlossyRadianceLTC(p r3.Point, shadingNormal, viewDir r3.Vec, roughness float64) r3.Vec {
+	if !rl.faces(p) {
+		return r3.Vec{}
+	}
+	quad := rl.localQuad(p, shadingNormal)
+	t, b := orthonormalBasis(shadingNormal.Unit())
+	cosTheta := math.Max(0, viewDir.Dot(shadingNormal.Unit()))
+	localView := r3.Vec{X: viewDir.Dot(t), Y: viewDir.Dot(b), Z: cosTheta}
+	// Orient the anisotropic stretch toward the view direction's
+	// projection onto the tangent plane, rather than always along the
+	// fixed tangent axis, so the lobe elongates toward grazing
+	// reflections regardless of how the shading point happens to be
+	// oriented relative to rl.Tangent.
+	minv := ltcOrientGlossyMinv(ltcGlossyMinv(roughness, cosTheta), localView)
+	irradiance := ltcIrradiance(minv, quad[:])
+	magnitude := ltcGlossyMagnitude(roughness, cosTheta)
+	return rl.Radiance.Muls(irradiance * magnitude)
+}
+
+// Sample draws a point uniformly over rl's rectangle, behaving exactly
+// like QuadLight.Sample: RectLight still implements Light so a scene can
+// mix it into scene.Light unchanged, for any caller that hasn't switched
+// to DiffuseIrradianceLTC/GlossyRadianceLTC's noise-free evaluation.
+func (rl RectLight) Sample(p r3.Point, rand *Rand) (direction r3.Vec, distance Distance, radiance r3.Vec) {
+	_, tangent, bitangent := rl.axes()
+	u := (rand.Float64() - 0.5) * rl.Width
+	v := (rand.Float64() - 0.5) * rl.Height
+	sample := rl.Center.Add(tangent.Muls(u)).Add(bitangent.Muls(v))
+
+	toLight := sample.Sub(p)
+	dist := toLight.Length()
+	if dist < eps {
+		return r3.Vec{}, 0, r3.Vec{}
+	}
+	dir := toLight.Divs(dist)
+	normal := rl.Normal.Unit()
+	cosLight := math.Max(0, normal.Dot(dir.Muls(-1)))
+	if cosLight <= 0 {
+		return dir, Distance(dist), r3.Vec{}
+	}
+	area := rl.Width * rl.Height
+	return dir, Distance(dist), rl.Radiance.Muls(cosLight * area / (dist * dist))
+}
+
+// Pdf behaves like QuadLight.Pdf, checking the plane-intersection point
+// against rl's Tangent/bitangent-aligned rectangle instead of QuadLight's
+// arbitrary orthonormalBasis-derived axes.
+func (rl RectLight) Pdf(p r3.Point, wi r3.Vec) float64 {
+	normal, tangent, bitangent := rl.axes()
+	denom := normal.Dot(wi)
+	if math.Abs(denom) < eps {
+		return 0
+	}
+	dist := normal.Dot(rl.Center.Sub(p)) / denom
+	if dist <= 0 {
+		return 0
+	}
+	hit := p.Add(wi.Muls(dist))
+	toHit := hit.Sub(rl.Center)
+	if math.Abs(toHit.Dot(tangent)) > rl.Width/2 || math.Abs(toHit.Dot(bitangent)) > rl.Height/2 {
+		return 0
+	}
+	cosLight := math.Abs(denom)
+	area := rl.Width * rl.Height
+	return dist * dist / (cosLight * area)
+}
+
+// EmittedRadiance behaves like QuadLight.EmittedRadiance, against rl's
+// Tangent/bitangent-aligned rectangle.
+func (rl RectLight) EmittedRadiance(p r3.Point, dir r3.Vec) (r3.Vec, Distance) {
+	normal, tangent, bitangent := rl.axes()
+	denom := normal.Dot(dir)
+	if denom >= -eps {
+		return r3.Vec{}, 0
+	}
+	dist := normal.Dot(rl.Center.Sub(p)) / denom
+	if dist <= 0 {
+		return r3.Vec{}, 0
+	}
+	hit := p.Add(dir.Muls(dist))
+	toHit := hit.Sub(rl.Center)
+	if math.Abs(toHit.Dot(tangent)) > rl.Width/2 || math.Abs(toHit.Dot(bitangent)) > rl.Height/2 {
+		return r3.Vec{}, 0
+	}
+	return rl.Radiance, Distance(dist)
+}
+
+func init() {
+	RegisterInterfaceType(RectLight{})
+}
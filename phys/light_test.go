@@ -0,0 +1,111 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import (
+	"testing"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
+)
+
+// TestEmittedRadianceAgreesWithPdf verifies EmittedRadiance and Pdf agree on
+// which directions from p actually strike a DiskLight/QuadLight's emitting
+// face: EmittedRadiance returns a non-zero radiance exactly when Pdf
+// returns a non-zero density, the consistency a BSDF-sampled estimate
+// relies on to MIS-weight against the same light's light-sampled estimate.
+func TestEmittedRadianceAgreesWithPdf(t *testing.T) {
+	p := r3.Point{Z: -5}
+	hitDir := r3.Vec{Z: 1}
+	missDir := r3.Vec{X: 1}
+
+	lights := []Light{
+		DiskLight{Center: r3.Point{Z: 5}, Normal: r3.Vec{Z: -1}, Radius: 1, Radiance: r3.Vec{X: 1, Y: 1, Z: 1}},
+		QuadLight{Center: r3.Point{Z: 5}, Normal: r3.Vec{Z: -1}, Width: 2, Height: 2, Radiance: r3.Vec{X: 1, Y: 1, Z: 1}},
+	}
+	for _, light := range lights {
+		radiance, dist := light.EmittedRadiance(p, hitDir)
+		if radiance.IsZero() || dist <= 0 {
+			t.Errorf("%T.EmittedRadiance(hitDir) = (%v, %v), want non-zero radiance and positive distance", light, radiance, dist)
+		}
+		if pdf := light.Pdf(p, hitDir); pdf <= 0 {
+			t.Errorf("%T.Pdf(hitDir) = %v, want > 0 to match EmittedRadiance", light, pdf)
+		}
+
+		radiance, _ = light.EmittedRadiance(p, missDir)
+		if !radiance.IsZero() {
+			t.Errorf("%T.EmittedRadiance(missDir) = %v, want zero (direction misses the light)", light, radiance)
+		}
+		if pdf := light.Pdf(p, missDir); pdf != 0 {
+			t.Errorf("%T.Pdf(missDir) = %v, want 0 to match EmittedRadiance", light, pdf)
+		}
+	}
+}
+
+// TestEmittedRadianceZeroForDeltaLights verifies the delta lights (zero
+// solid-angle measure) always report a zero EmittedRadiance: no
+// BSDF-sampled direction can ever land exactly on one, so they can only
+// ever contribute through light.Sample, never through the BSDF-sampled
+// MIS strategy.
+func TestEmittedRadianceZeroForDeltaLights(t *testing.T) {
+	p := r3.Point{}
+	dir := r3.Vec{Z: 1}
+	lights := []Light{
+		PointLight{Position: r3.Point{Z: 5}, RadiantIntensity: r3.Vec{X: 1, Y: 1, Z: 1}},
+		SpotLight{Position: r3.Point{Z: 5}, Direction: r3.Vec{Z: -1}, RadiantIntensity: r3.Vec{X: 1, Y: 1, Z: 1}, InnerConeAngle: 0.5, OuterConeAngle: 0.8},
+	}
+	for _, light := range lights {
+		if radiance, dist := light.EmittedRadiance(p, dir); !radiance.IsZero() || dist != 0 {
+			t.Errorf("%T.EmittedRadiance() = (%v, %v), want (zero, 0)", light, radiance, dist)
+		}
+	}
+}
+
+// TestSampledLightsZeroOrOverfullReturnsAll verifies sampledLights is a
+// no-op -- every light, weight 1 -- when n is zero or at least len(lights),
+// the same RenderOptions.DirectLightSamples zero value that matches
+// pre-DirectLightSamples behavior exactly.
+func TestSampledLightsZeroOrOverfullReturnsAll(t *testing.T) {
+	lights := []Light{
+		PointLight{Position: r3.Point{Z: 1}},
+		PointLight{Position: r3.Point{Z: 2}},
+		PointLight{Position: r3.Point{Z: 3}},
+	}
+	rand := NewRand(1)
+	for _, n := range []int{0, 3, 4} {
+		got, weight := sampledLights(lights, n, rand)
+		if len(got) != len(lights) || weight != 1 {
+			t.Errorf("sampledLights(lights, %d, rand) = (%d lights, weight %v), want (%d lights, weight 1)", n, len(got), weight, len(lights))
+		}
+	}
+}
+
+// TestSampledLightsSubsamplesWithUnbiasedWeight verifies a positive n below
+// len(lights) returns exactly n lights drawn from the input set, each
+// scaled by len(lights)/n so summing their weighted contributions stays an
+// unbiased estimate of summing every light.
+func TestSampledLightsSubsamplesWithUnbiasedWeight(t *testing.T) {
+	lights := []Light{
+		PointLight{Position: r3.Point{Z: 1}},
+		PointLight{Position: r3.Point{Z: 2}},
+		PointLight{Position: r3.Point{Z: 3}},
+		PointLight{Position: r3.Point{Z: 4}},
+	}
+	rand := NewRand(1)
+	got, weight := sampledLights(lights, 2, rand)
+	if len(got) != 2 {
+		t.Fatalf("sampledLights(lights, 2, rand) returned %d lights, want 2", len(got))
+	}
+	if want := 2.0; weight != want {
+		t.Errorf("sampledLights(lights, 2, rand) weight = %v, want %v", weight, want)
+	}
+	for _, light := range got {
+		found := false
+		for _, l := range lights {
+			if l == light {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("sampledLights returned %v, not a member of the input lights", light)
+		}
+	}
+}
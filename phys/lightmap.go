@@ -0,0 +1,276 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+
+package phys
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r2"
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
+)
+
+// BakeOptions configures BakeLightmaps.
+type BakeOptions struct {
+	// TexelsPerMM sets the atlas density: a node's world-space dimensions,
+	// in millimeters, are multiplied by TexelsPerMM to get its atlas
+	// resolution along each axis. Must be > 0.
+	TexelsPerMM float64
+
+	// Samples is the number of cosine-weighted hemisphere rays traced per
+	// texel via tracePath to estimate its irradiance. Must be > 0.
+	Samples int
+}
+
+func (o BakeOptions) Validate() error {
+	if o.TexelsPerMM <= 0 {
+		return fmt.Errorf("BakeOptions: TexelsPerMM must be positive, got %v", o.TexelsPerMM)
+	}
+	if o.Samples <= 0 {
+		return fmt.Errorf("BakeOptions: Samples must be positive, got %d", o.Samples)
+	}
+	return nil
+}
+
+// LightmapCache holds one static Node's baked irradiance atlas. Lambertian
+// reads it back via Irradiance(uv) instead of spawning another indirect
+// bounce ray, so a GI-lit frame over a static scene (e.g. the animate
+// example's dolly, rebaking once and rendering 60 frames against it) costs
+// one bilinear lookup per hit instead of a second tracePath recursion.
+//
+// Like Mesh's embedded BVH and TextureImage's mip pyramid, a LightmapCache
+// is a derived render cache, not scene definition data: it is produced by
+// BakeLightmaps and is never marshaled with its Node.
+type LightmapCache struct {
+	atlas *TextureImageHDR
+}
+
+// Irradiance returns the baked irradiance (the hemisphere integral of
+// incoming radiance, weighted by cosine) at the texel nearest uv -- the
+// same quantity ComputeDirectLighting's probeAmbient reconstructs from a
+// Probe's spherical-harmonic expansion, but looked up from a rasterized
+// atlas instead. A nil receiver or atlas (not yet baked) returns zero.
+func (c *LightmapCache) Irradiance(uv r2.Point) Spectrum {
+	if c == nil || c.atlas == nil {
+		return Spectrum{}
+	}
+	return c.atlas.At(uv.X, uv.Y)
+}
+
+// lightmapUnwrap maps a texel's (u, v) in [0,1]x[0,1] to its world
+// position and surface normal. It must invert the owning Shape's own
+// Collide uv convention exactly, so that a render-time lookup at
+// collision.uv lands on the texel BakeLightmaps populated for that point.
+type lightmapUnwrap func(u, v float64) (pos r3.Point, normal r3.Vec)
+
+// lightmapUnwrapFor returns the atlas resolution and unwrap function for
+// shape at the given texel density, and ok=false for any shape/UV mapping
+// BakeLightmaps doesn't know how to unwrap (today: Quad's planar
+// projection and Sphere's default equirectangular mapping).
+func lightmapUnwrapFor(shape Shape, texelsPerMM float64) (w, h int, unwrap lightmapUnwrap, ok bool) {
+	switch sh := shape.(type) {
+	case Quad:
+		w = lightmapTexelCount(sh.Width, texelsPerMM)
+		h = lightmapTexelCount(sh.Height, texelsPerMM)
+		return w, h, quadLightmapUnwrap(sh), true
+	case Sphere:
+		if sh.UVMap != UVMapEquirect {
+			// Box mapping's per-face seams and UVMapEquirectSouthUp's
+			// flipped v aren't worth a second unwrap each; skip rather
+			// than bake an atlas that won't line up with Collide's uv.
+			return 0, 0, nil, false
+		}
+		circumference := Distance(2 * math.Pi * float64(sh.Radius))
+		w = lightmapTexelCount(circumference, texelsPerMM)
+		h = lightmapTexelCount(circumference/2, texelsPerMM)
+		return w, h, sphereLightmapUnwrap(sh), true
+	default:
+		return 0, 0, nil, false
+	}
+}
+
+// lightmapTexelCount converts a world-space dimension to a texel count at
+// texelsPerMM, clamped to at least 2 so TextureImageHDR's (Width-1) /
+// (Height-1) addressing never divides by zero.
+func lightmapTexelCount(d Distance, texelsPerMM float64) int {
+	n := int(math.Ceil(d.Millimeters() * texelsPerMM))
+	if n < 2 {
+		return 2
+	}
+	return n
+}
+
+// quadLightmapUnwrap inverts Quad.Collide's planar uv assignment: u there
+// parameterizes vAxis (the Height direction) and v parameterizes uAxis
+// (the Width direction), both centered at q.corners()'s p2.
+func quadLightmapUnwrap(q Quad) lightmapUnwrap {
+	normal := q.Normal.Unit()
+	var arbitrary r3.Vec
+	if math.Abs(normal.X) < 0.9 {
+		arbitrary = r3.Vec{X: 1, Y: 0, Z: 0}
+	} else {
+		arbitrary = r3.Vec{X: 0, Y: 1, Z: 0}
+	}
+	uAxis := normal.Cross(arbitrary).Unit()
+	vAxis := normal.Cross(uAxis).Unit()
+	halfWidth := float64(q.Width) / 2
+	halfHeight := float64(q.Height) / 2
+	return func(u, v float64) (r3.Point, r3.Vec) {
+		uCoord := halfWidth * (1 - 2*v)
+		vCoord := halfHeight * (1 - 2*u)
+		pos := q.Center.Add(uAxis.Muls(uCoord)).Add(vAxis.Muls(vCoord))
+		return pos, normal
+	}
+}
+
+// sphereLightmapUnwrap inverts equirectUV(normal, northUp=true): u is
+// longitude over [0, 2*pi), v runs from the south pole (0) to the north
+// pole (1).
+func sphereLightmapUnwrap(s Sphere) lightmapUnwrap {
+	radius := float64(s.Radius)
+	return func(u, v float64) (r3.Point, r3.Vec) {
+		phi := u * 2 * math.Pi
+		theta := (1 - v) * math.Pi
+		sinTheta := math.Sin(theta)
+		normal := r3.Vec{
+			X: sinTheta * math.Cos(phi),
+			Y: math.Cos(theta),
+			Z: sinTheta * math.Sin(phi),
+		}
+		pos := s.Center.Add(normal.Muls(radius))
+		return pos, normal
+	}
+}
+
+// BakeLightmaps pathtrace-bakes direct+indirect irradiance into a texture
+// atlas for every static Lambertian Node whose Shape has a known UV
+// unwrap (see lightmapUnwrapFor), storing the result on Node.LightmapCache.
+// Subsequent tracePath calls read Lambertian.Resolve's lightmap shortcut
+// instead of spawning another indirect bounce for those nodes, while
+// dynamic lights and every other material keep path-tracing normally.
+//
+// BakeLightmaps mutates scene.Node in place and returns the first bake
+// error encountered, wrapped with the offending node's name. It checks
+// ctx between texels the same way BakeProbe checks it between samples.
+func BakeLightmaps(ctx context.Context, scene *Scene, opts BakeOptions) error {
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+	if err := scene.Validate(); err != nil {
+		return fmt.Errorf("invalid scene: %v", err)
+	}
+	for i := range scene.Node {
+		node := &scene.Node[i]
+		if _, ok := node.Material.(Lambertian); !ok {
+			continue // Only Lambertian shading reads a LightmapCache; see Resolve.
+		}
+		w, h, unwrap, ok := lightmapUnwrapFor(node.Shape, opts.TexelsPerMM)
+		if !ok {
+			continue // No unwrap for this shape/UVMap; leave it path-traced every frame.
+		}
+		atlas, err := bakeLightmapAtlas(ctx, scene, unwrap, w, h, opts.Samples, int64(i))
+		if err != nil {
+			return fmt.Errorf("BakeLightmaps: node %q: %v", node.Name, err)
+		}
+		node.LightmapCache = &LightmapCache{atlas: atlas}
+	}
+	return nil
+}
+
+// bakeLightmapAtlas rasterizes a w x h irradiance atlas: for every texel,
+// unwrap resolves the texel's world position and normal, and
+// bakeTexelIrradiance estimates the hemisphere-integrated irradiance
+// there via tracePath. Texels left unfinished by a canceled ctx are
+// dilated from their nearest completed neighbor rather than left black.
+func bakeLightmapAtlas(ctx context.Context, scene *Scene, unwrap lightmapUnwrap, w, h, samples int, seed int64) (*TextureImageHDR, error) {
+	pixels := make([]r3.Vec, w*h)
+	valid := make([]bool, w*h)
+	rnd := NewRand(seed)
+	stats := &RenderStats{}
+	canceled := false
+	for iy := 0; iy < h; iy++ {
+		v := 1 - float64(iy)/float64(h-1)
+		for ix := 0; ix < w; ix++ {
+			if canceled || ctx.Err() != nil {
+				canceled = true
+				continue
+			}
+			u := float64(ix) / float64(w-1)
+			pos, normal := unwrap(u, v)
+			irradiance := bakeTexelIrradiance(ctx, scene, pos, normal, samples, rnd, stats)
+			idx := iy*w + ix
+			pixels[idx] = r3.Vec(irradiance)
+			valid[idx] = true
+		}
+	}
+	dilateLightmapAtlas(pixels, valid, w, h)
+	if canceled && ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	return &TextureImageHDR{
+		Width:    w,
+		Height:   h,
+		Pixels:   pixels,
+		Interp:   "bilinear",
+		WrapMode: "clamp",
+	}, nil
+}
+
+// bakeTexelIrradiance estimates the irradiance E = integral L(w)*cos(w) dw
+// at pos by tracing samples cosine-weighted hemisphere rays through scene.
+// Cosine-weighted sampling's pdf (cos(w)/pi) cancels the integrand's
+// cos(w) weight, leaving E ~= (pi/samples) * sum(L_i) -- the same
+// Monte-Carlo projection trick BakeProbe applies to the SH basis instead.
+func bakeTexelIrradiance(ctx context.Context, scene *Scene, pos r3.Point, normal r3.Vec, samples int, rnd *Rand, stats *RenderStats) Spectrum {
+	var sum Spectrum
+	for i := 0; i < samples; i++ {
+		dir := rnd.CosineWeightedHemisphere(normal)
+		r := ray{
+			origin:    pos.Add(normal.Muls(eps)),
+			direction: dir,
+			depth:     0,
+			radiance:  Spectrum{X: 1, Y: 1, Z: 1},
+			rand:      rnd,
+		}
+		sum = sum.Add(tracePath(ctx, scene, r, stats, nil))
+	}
+	return sum.Muls(math.Pi / float64(samples))
+}
+
+// dilateLightmapAtlas fills every texel not marked valid with its nearest
+// valid neighbor's value, expanding outward one ring at a time until no
+// invalid texel has a valid neighbor left to copy. A fully baked atlas
+// has no invalid texels and this is a no-op; a ctx-canceled bake's
+// unfinished region instead reads back as its last completed edge rather
+// than bleeding the atlas's zero-value black into bilinear-filtered
+// lookups near the cutoff.
+func dilateLightmapAtlas(pixels []r3.Vec, valid []bool, w, h int) {
+	neighbors := [4][2]int{{1, 0}, {-1, 0}, {0, 1}, {0, -1}}
+	for {
+		progressed := false
+		for iy := 0; iy < h; iy++ {
+			for ix := 0; ix < w; ix++ {
+				idx := iy*w + ix
+				if valid[idx] {
+					continue
+				}
+				for _, d := range neighbors {
+					nx, ny := ix+d[0], iy+d[1]
+					if nx < 0 || nx >= w || ny < 0 || ny >= h {
+						continue
+					}
+					if nIdx := ny*w + nx; valid[nIdx] {
+						pixels[idx] = pixels[nIdx]
+						valid[idx] = true
+						progressed = true
+						break
+					}
+				}
+			}
+		}
+		if !progressed {
+			break
+		}
+	}
+}
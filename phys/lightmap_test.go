@@ -0,0 +1,91 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import (
+	"math"
+	"testing"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
+)
+
+// TestQuadLightmapUnwrapRoundTrip checks that quadLightmapUnwrap inverts
+// Quad.Collide's uv assignment: a texel's unwrapped world position, fired
+// straight back at the quad, must collide at the same uv the texel was
+// generated from. If these ever disagree, a baked lightmap would read
+// back at the wrong texel for every render-time lookup.
+func TestQuadLightmapUnwrapRoundTrip(t *testing.T) {
+	quad := Quad{
+		Center: r3.Point{X: 1, Y: 2, Z: 3},
+		Normal: r3.Vec{X: 0, Y: 1, Z: 0},
+		Width:  4,
+		Height: 2,
+	}
+	unwrap := quadLightmapUnwrap(quad)
+	for _, uv := range []struct{ u, v float64 }{
+		{0, 0}, {1, 0}, {0, 1}, {1, 1}, {0.5, 0.5}, {0.25, 0.75},
+	} {
+		pos, normal := unwrap(uv.u, uv.v)
+		r := ray{origin: pos.Add(normal.Muls(-1)), direction: normal}
+		hit, c := quad.Collide(r, 0, math.MaxFloat64)
+		if !hit {
+			t.Fatalf("uv=%+v: ray back toward unwrapped position missed the quad", uv)
+		}
+		if math.Abs(c.uv.X-uv.u) > 1e-9 || math.Abs(c.uv.Y-uv.v) > 1e-9 {
+			t.Errorf("uv=%+v: Collide reported uv=%+v, want the same uv back", uv, c.uv)
+		}
+	}
+}
+
+// TestSphereLightmapUnwrapRoundTrip is TestQuadLightmapUnwrapRoundTrip's
+// counterpart for sphereLightmapUnwrap and Sphere's default
+// UVMapEquirect mapping.
+func TestSphereLightmapUnwrapRoundTrip(t *testing.T) {
+	sphere := Sphere{Center: r3.Point{X: -1, Y: 0, Z: 5}, Radius: 2}
+	unwrap := sphereLightmapUnwrap(sphere)
+	for _, uv := range []struct{ u, v float64 }{
+		{0, 0.5}, {0.25, 0.5}, {0.5, 0.5}, {0.75, 0.5}, {0.5, 0.1}, {0.5, 0.9},
+	} {
+		pos, normal := unwrap(uv.u, uv.v)
+		r := ray{origin: pos.Add(normal.Muls(1)), direction: normal.Muls(-1)}
+		hit, c := sphere.Collide(r, 0, math.MaxFloat64)
+		if !hit {
+			t.Fatalf("uv=%+v: ray back toward unwrapped position missed the sphere", uv)
+		}
+		if math.Abs(c.uv.X-uv.u) > 1e-6 || math.Abs(c.uv.Y-uv.v) > 1e-6 {
+			t.Errorf("uv=%+v: Collide reported uv=%+v, want the same uv back", uv, c.uv)
+		}
+	}
+}
+
+// TestLightmapTexelCount checks the millimeter-to-texel conversion and
+// its floor of 2 texels (TextureImageHDR addresses by Width-1/Height-1,
+// so a 1-texel axis would divide by zero).
+func TestLightmapTexelCount(t *testing.T) {
+	if got := lightmapTexelCount(10*MM, 4); got != 40 {
+		t.Errorf("lightmapTexelCount(10mm, 4/mm) = %d, want 40", got)
+	}
+	if got := lightmapTexelCount(1*NM, 4); got != 2 {
+		t.Errorf("lightmapTexelCount(~0mm, 4/mm) = %d, want the 2-texel floor", got)
+	}
+}
+
+// TestDilateLightmapAtlasFillsFromNearestValid checks that an atlas with
+// an unfinished (canceled-bake) region reads back as its nearest
+// completed neighbor instead of the zero-value black a partial bake
+// would otherwise leave behind.
+func TestDilateLightmapAtlasFillsFromNearestValid(t *testing.T) {
+	const w, h = 4, 1
+	pixels := []r3.Vec{
+		{X: 1}, {X: 2}, {}, {},
+	}
+	valid := []bool{true, true, false, false}
+	dilateLightmapAtlas(pixels, valid, w, h)
+	for i := range valid {
+		if !valid[i] {
+			t.Fatalf("texel %d still marked invalid after dilation", i)
+		}
+	}
+	if pixels[2].X != 2 || pixels[3].X != 2 {
+		t.Errorf("pixels = %+v, want the unfinished texels to inherit their nearest valid neighbor (index 1)", pixels)
+	}
+}
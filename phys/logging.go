@@ -0,0 +1,74 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+)
+
+// contextKey namespaces phys's context values so they can't collide with a
+// key another package stashes on the same context.
+type contextKey int
+
+const (
+	loggerContextKey contextKey = iota
+	traceIDContextKey
+)
+
+// WithLogger returns a copy of ctx carrying logger, so LoggerFromContext
+// (and so tracePath, renderPixel, and every playground HTTP handler that
+// threads ctx through a render) logs through it instead of slog.Default().
+// Pass a *slog.Logger built around any slog.Handler -- JSON, text, or a
+// custom sink -- to control where and how a render's log lines end up.
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerContextKey, logger)
+}
+
+// LoggerFromContext returns ctx's logger (see WithLogger), falling back to
+// slog.Default() if none was set, with a "trace_id" attribute attached if
+// ctx carries one (see WithTraceID).
+func LoggerFromContext(ctx context.Context) *slog.Logger {
+	logger, _ := ctx.Value(loggerContextKey).(*slog.Logger)
+	if logger == nil {
+		logger = slog.Default()
+	}
+	if traceID := TraceIDFromContext(ctx); traceID != "" {
+		logger = logger.With("trace_id", traceID)
+	}
+	return logger
+}
+
+// WithTraceID returns a copy of ctx carrying traceID, so every log line
+// LoggerFromContext emits during this render -- invalid-ray warnings,
+// clamped-pixel messages, tile completion events -- is tagged with it, and
+// the RenderEvents renderScene samples into RenderStats.Events record it
+// alongside each tile's timing.
+func WithTraceID(ctx context.Context, traceID string) context.Context {
+	return context.WithValue(ctx, traceIDContextKey, traceID)
+}
+
+// TraceIDFromContext returns the trace ID ctx carries (see WithTraceID), or
+// "" if none was set.
+func TraceIDFromContext(ctx context.Context) string {
+	traceID, _ := ctx.Value(traceIDContextKey).(string)
+	return traceID
+}
+
+var traceIDCounter uint64
+
+// NewTraceID returns a short random hex ID, falling back to a monotonic
+// counter if the system RNG is unavailable (it practically never is).
+// Mirrors phys/jobs' newJobID -- a server handling incoming render
+// requests (like playground's /raytrace/render) calls this once per
+// request and threads the result through WithTraceID.
+func NewTraceID() string {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return fmt.Sprintf("trace-%d", atomic.AddUint64(&traceIDCounter, 1))
+	}
+	return hex.EncodeToString(b[:])
+}
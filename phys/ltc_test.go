@@ -0,0 +1,226 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import (
+	"math"
+	"testing"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
+)
+
+// TestLTCEdgeIntegralSymmetric verifies ltcEdgeIntegral changes sign when
+// its arguments are swapped, the antisymmetry a closed polygon's edge sum
+// relies on to cancel out when the "polygon" degenerates to a single
+// back-and-forth segment.
+func TestLTCEdgeIntegralSymmetric(t *testing.T) {
+	v1 := r3.Vec{X: 1}
+	v2 := r3.Vec{X: 0, Y: 0, Z: 1}
+	a := ltcEdgeIntegral(v1, v2)
+	b := ltcEdgeIntegral(v2, v1)
+	if math.Abs(a+b) > 1e-9 {
+		t.Errorf("ltcEdgeIntegral(v1,v2)+ltcEdgeIntegral(v2,v1) = %v, want 0", a+b)
+	}
+}
+
+// TestLTCEdgeIntegralCoincidentIsZero verifies two nearly identical
+// vectors contribute no edge integral, instead of a division blowing up
+// as sin(theta) -> 0.
+func TestLTCEdgeIntegralCoincidentIsZero(t *testing.T) {
+	v := r3.Vec{X: 0, Y: 0, Z: 1}
+	if got := ltcEdgeIntegral(v, v); got != 0 {
+		t.Errorf("ltcEdgeIntegral(v,v) = %v, want 0", got)
+	}
+}
+
+// TestClipPolygonToHorizonAboveKeepsAll verifies a quad entirely above the
+// horizon (z>=0 everywhere) is returned unchanged.
+func TestClipPolygonToHorizonAboveKeepsAll(t *testing.T) {
+	quad := []r3.Vec{{X: -1, Z: 1}, {X: 1, Z: 1}, {X: 1, Z: 2}, {X: -1, Z: 2}}
+	got := clipPolygonToHorizon(quad)
+	if len(got) != 4 {
+		t.Fatalf("len(clipped) = %d, want 4 (unchanged)", len(got))
+	}
+}
+
+// TestClipPolygonToHorizonBelowIsEmpty verifies a quad entirely below the
+// horizon clips away to nothing.
+func TestClipPolygonToHorizonBelowIsEmpty(t *testing.T) {
+	quad := []r3.Vec{{X: -1, Z: -1}, {X: 1, Z: -1}, {X: 1, Z: -2}, {X: -1, Z: -2}}
+	if got := clipPolygonToHorizon(quad); len(got) != 0 {
+		t.Errorf("len(clipped) = %d, want 0", len(got))
+	}
+}
+
+// TestClipPolygonToHorizonStraddlingAddsVertices verifies a quad straddling
+// the horizon plane is clipped to a pentagon: 2 original above-horizon
+// corners survive, plus one clipped vertex per crossing edge.
+func TestClipPolygonToHorizonStraddlingAddsVertices(t *testing.T) {
+	quad := []r3.Vec{{X: -1, Z: -1}, {X: 1, Z: -1}, {X: 1, Z: 1}, {X: -1, Z: 1}}
+	got := clipPolygonToHorizon(quad)
+	if len(got) != 4 {
+		t.Fatalf("len(clipped) = %d, want 4 (2 original + 2 crossings)", len(got))
+	}
+	for _, v := range got {
+		if v.Z < -1e-9 {
+			t.Errorf("clipped vertex %v has z < 0", v)
+		}
+	}
+}
+
+// rectLTCTestLight is a small rect light directly above the origin,
+// facing straight down, used by both the diffuse-LTC and Monte-Carlo
+// cross-check tests below.
+func rectLTCTestLight() RectLight {
+	return RectLight{
+		Center:   r3.Point{Y: 5},
+		Normal:   r3.Vec{Y: -1},
+		Tangent:  r3.Vec{X: 1},
+		Width:    2,
+		Height:   2,
+		Radiance: r3.Vec{X: 1, Y: 1, Z: 1},
+	}
+}
+
+// TestRectLightValidate verifies Validate rejects a non-perpendicular
+// Tangent and a negative Radiance, the same shape of checks
+// DiskLight/QuadLight's own Validate run.
+func TestRectLightValidate(t *testing.T) {
+	rl := rectLTCTestLight()
+	if err := rl.Validate(); err != nil {
+		t.Errorf("Validate: unexpected error for a valid RectLight: %v", err)
+	}
+	bad := rl
+	bad.Tangent = rl.Normal
+	if err := bad.Validate(); err == nil {
+		t.Error("Validate: expected an error for a Tangent parallel to Normal, got nil")
+	}
+	bad = rl
+	bad.Radiance = r3.Vec{X: -1}
+	if err := bad.Validate(); err == nil {
+		t.Error("Validate: expected an error for a negative Radiance, got nil")
+	}
+}
+
+// TestDiffuseIrradianceLTCMatchesMonteCarlo verifies
+// RectLight.DiffuseIrradianceLTC's single analytic evaluation agrees with
+// a large-sample Monte Carlo average of QuadLight-style area sampling at
+// the same point -- RectLight.Sample implements exactly that estimator,
+// so averaging enough draws of it must converge to DiffuseIrradianceLTC's
+// exact answer.
+func TestDiffuseIrradianceLTCMatchesMonteCarlo(t *testing.T) {
+	rl := rectLTCTestLight()
+	p := r3.Point{}
+	normal := r3.Vec{Y: 1}
+
+	analytic := rl.DiffuseIrradianceLTC(p, normal)
+
+	rnd := NewRand(7)
+	const samples = 200000
+	var sum r3.Vec
+	for i := 0; i < samples; i++ {
+		dir, _, radiance := rl.Sample(p, rnd)
+		cos := math.Max(0, normal.Dot(dir))
+		sum = sum.Add(radiance.Muls(cos))
+	}
+	mc := sum.Divs(samples)
+
+	if !analytic.IsClose(mc, 0.02) {
+		t.Errorf("DiffuseIrradianceLTC = %v, Monte Carlo estimate = %v (want close)", analytic, mc)
+	}
+}
+
+// TestDiffuseIrradianceLTCZeroBehindLight verifies a shading point behind
+// the rect (on the non-emitting side) receives zero irradiance.
+func TestDiffuseIrradianceLTCZeroBehindLight(t *testing.T) {
+	rl := rectLTCTestLight()
+	p := r3.Point{Y: 10} // Above the light, which faces down (-Y): behind it.
+	got := rl.DiffuseIrradianceLTC(p, r3.Vec{Y: -1})
+	if !got.IsClose(r3.Vec{}, 1e-6) {
+		t.Errorf("DiffuseIrradianceLTC behind the light = %v, want ~0", got)
+	}
+}
+
+// diskLTCTestLight is a small disk light directly above the origin,
+// facing straight down, the disk counterpart to rectLTCTestLight used by
+// the diffuse-LTC and Monte-Carlo cross-check tests below.
+func diskLTCTestLight() DiskLight {
+	return DiskLight{
+		Center:   r3.Point{Y: 5},
+		Normal:   r3.Vec{Y: -1},
+		Radius:   1,
+		Radiance: r3.Vec{X: 1, Y: 1, Z: 1},
+	}
+}
+
+// TestDiskDiffuseIrradianceLTCMatchesMonteCarlo verifies
+// DiskLight.DiffuseIrradianceLTC's polygon-approximated analytic
+// evaluation agrees with a large-sample Monte Carlo average of
+// DiskLight.Sample at the same point, the same cross-check
+// TestDiffuseIrradianceLTCMatchesMonteCarlo runs for RectLight.
+func TestDiskDiffuseIrradianceLTCMatchesMonteCarlo(t *testing.T) {
+	dl := diskLTCTestLight()
+	p := r3.Point{}
+	normal := r3.Vec{Y: 1}
+
+	analytic := dl.DiffuseIrradianceLTC(p, normal)
+
+	rnd := NewRand(7)
+	const samples = 200000
+	var sum r3.Vec
+	for i := 0; i < samples; i++ {
+		dir, _, radiance := dl.Sample(p, rnd)
+		cos := math.Max(0, normal.Dot(dir))
+		sum = sum.Add(radiance.Muls(cos))
+	}
+	mc := sum.Divs(samples)
+
+	if !analytic.IsClose(mc, 0.02) {
+		t.Errorf("DiffuseIrradianceLTC = %v, Monte Carlo estimate = %v (want close)", analytic, mc)
+	}
+}
+
+// TestDiskDiffuseIrradianceLTCZeroBehindLight verifies a shading point
+// behind the disk (on the non-emitting side) receives zero irradiance,
+// the disk counterpart to TestDiffuseIrradianceLTCZeroBehindLight.
+func TestDiskDiffuseIrradianceLTCZeroBehindLight(t *testing.T) {
+	dl := diskLTCTestLight()
+	p := r3.Point{Y: 10} // Above the light, which faces down (-Y): behind it.
+	got := dl.DiffuseIrradianceLTC(p, r3.Vec{Y: -1})
+	if !got.IsClose(r3.Vec{}, 1e-6) {
+		t.Errorf("DiffuseIrradianceLTC behind the light = %v, want ~0", got)
+	}
+}
+
+// TestDiskGlossyRadianceLTCMirrorMatchesDiffuseShape verifies that at
+// roughness 0 (mirror) DiskLight.GlossyRadianceLTC reduces to the same
+// Minv=identity evaluation DiffuseIrradianceLTC uses, the disk
+// counterpart to TestGlossyRadianceLTCMirrorMatchesDiffuseShape.
+func TestDiskGlossyRadianceLTCMirrorMatchesDiffuseShape(t *testing.T) {
+	dl := diskLTCTestLight()
+	p := r3.Point{}
+	normal := r3.Vec{Y: 1}
+	view := r3.Vec{Y: 1}
+
+	diffuse := dl.DiffuseIrradianceLTC(p, normal)
+	glossy := dl.GlossyRadianceLTC(p, normal, view, 0)
+	if !diffuse.IsClose(glossy, 1e-6) {
+		t.Errorf("GlossyRadianceLTC(roughness=0) = %v, want DiffuseIrradianceLTC result %v", glossy, diffuse)
+	}
+}
+
+// TestGlossyRadianceLTCMirrorMatchesDiffuseShape verifies that at
+// roughness 0 (mirror) GlossyRadianceLTC reduces to the same Minv=identity
+// evaluation DiffuseIrradianceLTC uses, since ltcGlossyMinv(0, _) returns
+// the identity matrix.
+func TestGlossyRadianceLTCMirrorMatchesDiffuseShape(t *testing.T) {
+	rl := rectLTCTestLight()
+	p := r3.Point{}
+	normal := r3.Vec{Y: 1}
+	view := r3.Vec{Y: 1}
+
+	diffuse := rl.DiffuseIrradianceLTC(p, normal)
+	glossy := rl.GlossyRadianceLTC(p, normal, view, 0)
+	if !diffuse.IsClose(glossy, 1e-6) {
+		t.Errorf("GlossyRadianceLTC(roughness=0) = %v, want DiffuseIrradianceLTC result %v", glossy, diffuse)
+	}
+}
@@ -12,10 +12,12 @@ import (
 // The incoming direction (wi) is the direction from which light arrives at the surface point, coming from light sources or other surfaces.
 
 type surfaceInteraction struct {
-	incoming  ray       // Incoming ray.
-	outgoing  r3.Vec    // Outgoing direction. Leaves the surface and goes into camera.
-	collision collision // Surface collision context.
-	node      Node      // The node that was hit.
+	incoming   ray       // Incoming ray.
+	outgoing   r3.Vec    // Outgoing direction. Leaves the surface and goes into camera.
+	collision  collision // Surface collision context.
+	node       Node      // The node that was hit.
+	materialID int       // Index of node within scene.Node; every Node has exactly one Material, so this doubles as a stable material identifier.
+	rayType    RayType   // Why incoming was traced; mirrors incoming.rayType for convenient access from Material implementations.
 }
 
 // resolution represents the outcome of a material interaction.
@@ -30,3 +32,14 @@ type Material interface {
 	ComputeDirectLighting(ctx context.Context, si surfaceInteraction, scene *Scene) Spectrum
 	Validate() error
 }
+
+// SpecularMaterial is implemented by materials whose BSDF is made
+// entirely of delta (specular) lobes, e.g. Mirror and Glass. A delta lobe
+// has zero value everywhere except a single direction of zero measure, so
+// next-event estimation against scene.Light can never land on it: the
+// integrator checks Specular before calling ComputeDirectLighting, so
+// these materials can skip the shadow-ray loop entirely rather than doing
+// the work only to contribute zero.
+type SpecularMaterial interface {
+	Specular() bool
+}
@@ -0,0 +1,207 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+
+package phys
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+)
+
+// LobeWeightMode selects how a WeightedLobe's Weight field is interpreted.
+type LobeWeightMode uint8
+
+const (
+	// LobeWeightFixed uses Weight as-is: the lobe's selection probability
+	// and direct-lighting coefficient, constant across the surface. It is
+	// the zero value, so a WeightedLobe left unset behaves as weight 0
+	// (never selected) rather than silently becoming Fresnel-driven.
+	LobeWeightFixed LobeWeightMode = iota
+	// LobeWeightFresnel treats Weight as the lobe's index of refraction
+	// and computes the effective weight per-interaction as Schlick's
+	// approximation at the incoming angle (see reflectance), so a
+	// specular coat's contribution grows toward grazing angles and the
+	// substrate beneath it shows through more at normal incidence. This
+	// is the "FresnelBlend" mode a dielectric-over-diffuse coat needs.
+	LobeWeightFresnel
+)
+
+// WeightedLobe pairs a delegate Material with the coefficient BSDF uses to
+// blend it against the composite's other lobes.
+type WeightedLobe struct {
+	Material Material
+	Weight   float64
+	Mode     LobeWeightMode
+}
+
+// effectiveWeight returns lobe's blend coefficient for a surface
+// interaction with cosine cosThetaIncoming between the incoming ray and
+// the surface normal: Weight itself under LobeWeightFixed, or the Schlick
+// Fresnel term treating Weight as the coat's index of refraction under
+// LobeWeightFresnel.
+func (lobe WeightedLobe) effectiveWeight(cosThetaIncoming float64) float64 {
+	if lobe.Mode == LobeWeightFresnel {
+		return reflectance(cosThetaIncoming, 1, lobe.Weight)
+	}
+	return lobe.Weight
+}
+
+// BSDF composites several Materials into one by blending their lobes,
+// e.g. a Lambertian diffuse substrate under a Dielectric specular coat (a
+// plastic-like surface) or a Metal base with a clear coat on top. Resolve
+// stochastically selects one lobe per bounce, proportional to its
+// effective weight, and divides the scattered ray's throughput by the
+// selection probability so the estimator stays unbiased;
+// ComputeDirectLighting instead sums every lobe's contribution weighted
+// by the same coefficients, since next-event estimation doesn't need a
+// single stochastic choice to stay unbiased.
+type BSDF struct {
+	Lobes []WeightedLobe
+}
+
+func (m BSDF) Validate() error {
+	if len(m.Lobes) == 0 {
+		return fmt.Errorf("BSDF: no Lobes")
+	}
+	for i, lobe := range m.Lobes {
+		if lobe.Material == nil {
+			return fmt.Errorf("BSDF: lobe %d has no Material", i)
+		}
+		if lobe.Weight < 0 {
+			return fmt.Errorf("BSDF: lobe %d has negative Weight %v", i, lobe.Weight)
+		}
+		if lobe.Mode == LobeWeightFresnel && lobe.Weight < 1 {
+			return fmt.Errorf("BSDF: lobe %d uses LobeWeightFresnel but Weight (index of refraction) %v is < 1", i, lobe.Weight)
+		}
+		if err := lobe.Material.Validate(); err != nil {
+			return fmt.Errorf("BSDF: lobe %d: %v", i, err)
+		}
+	}
+	return nil
+}
+
+// cosThetaIncoming returns the cosine between s's incoming ray (reversed
+// to point away from the surface) and its collision normal, the angle
+// every lobe's LobeWeightFresnel blend and the direct-lighting sum below
+// are evaluated at.
+func cosThetaIncoming(s surfaceInteraction) float64 {
+	wo := s.incoming.direction.Muls(-1).Unit()
+	n := s.collision.normal.Unit()
+	return math.Max(0, n.Dot(wo))
+}
+
+// Resolve picks one lobe stochastically in proportion to its effective
+// weight and delegates to it, dividing the scattered rays' throughput by
+// the selection probability so repeated samples converge to the same
+// result a combined BSDF evaluation would have given.
+func (m BSDF) Resolve(ctx context.Context, s surfaceInteraction) resolution {
+	cosTheta := cosThetaIncoming(s)
+	weights := make([]float64, len(m.Lobes))
+	total := 0.0
+	for i, lobe := range m.Lobes {
+		weights[i] = lobe.effectiveWeight(cosTheta)
+		total += weights[i]
+	}
+	if total <= 0 {
+		return resolution{}
+	}
+
+	pick := s.incoming.rand.Float64() * total
+	chosen := len(m.Lobes) - 1
+	for i, w := range weights {
+		pick -= w
+		if pick <= 0 {
+			chosen = i
+			break
+		}
+	}
+
+	selectionProbability := weights[chosen] / total
+	res := m.Lobes[chosen].Material.Resolve(ctx, s)
+	scattered := make([]ray, len(res.scattered))
+	for i, r := range res.scattered {
+		r.radiance = r.radiance.Divs(selectionProbability)
+		scattered[i] = r
+	}
+	return resolution{scattered: scattered, emission: res.emission}
+}
+
+// ComputeDirectLighting sums every lobe's direct-lighting contribution
+// weighted by its effective weight, since a deterministic sum over all
+// lobes (rather than a single stochastic pick) is unbiased without any
+// probability correction.
+func (m BSDF) ComputeDirectLighting(ctx context.Context, s surfaceInteraction, scene *Scene) Spectrum {
+	cosTheta := cosThetaIncoming(s)
+	direct := Spectrum{}
+	for _, lobe := range m.Lobes {
+		w := lobe.effectiveWeight(cosTheta)
+		if w <= 0 {
+			continue
+		}
+		direct = direct.Add(lobe.Material.ComputeDirectLighting(ctx, s, scene).Muls(w))
+	}
+	return direct
+}
+
+type weightedLobeData struct {
+	Material json.RawMessage `json:"Material"`
+	Weight   float64         `json:"Weight"`
+	Mode     LobeWeightMode  `json:"Mode"`
+}
+
+type bsdfData struct {
+	Type  string             `json:"Type"`
+	Lobes []weightedLobeData `json:"Lobes"`
+}
+
+// MarshalJSON wraps each lobe's Material with its type tag, the same way
+// Node.MarshalJSON wraps a single Material, since a WeightedLobe's
+// Material field is itself an interface.
+func (m BSDF) MarshalJSON() ([]byte, error) {
+	lobes := make([]weightedLobeData, len(m.Lobes))
+	for i, lobe := range m.Lobes {
+		materialJSON, err := marshalInterface(lobe.Material)
+		if err != nil {
+			return nil, err
+		}
+		lobes[i] = weightedLobeData{Material: materialJSON, Weight: lobe.Weight, Mode: lobe.Mode}
+	}
+	return json.Marshal(bsdfData{Type: "BSDF", Lobes: lobes})
+}
+
+func (m *BSDF) UnmarshalJSON(data []byte) error {
+	var temp bsdfData
+	if err := json.Unmarshal(data, &temp); err != nil {
+		return err
+	}
+	if temp.Type != "BSDF" {
+		return fmt.Errorf("invalid type: expected BSDF, got %s", temp.Type)
+	}
+	lobes := make([]WeightedLobe, len(temp.Lobes))
+	for i, lobeData := range temp.Lobes {
+		material, err := unmarshalTyped(lobeData.Material, materialRegistry)
+		if err != nil {
+			return err
+		}
+		// Built-in materials (unlike a caller's own RegisterMaterial
+		// factory) are held by value everywhere else in the package, so
+		// when unmarshalTyped fell through to the legacy, pointer-typed
+		// typeRegistry, deref back to the value Material would otherwise
+		// be constructed with (see WeightedLobe literals throughout this
+		// package and its tests).
+		if ptr := reflect.ValueOf(material); ptr.Kind() == reflect.Ptr {
+			if v, ok := ptr.Elem().Interface().(Material); ok {
+				material = v
+			}
+		}
+		lobes[i] = WeightedLobe{Material: material, Weight: lobeData.Weight, Mode: lobeData.Mode}
+	}
+	m.Lobes = lobes
+	return nil
+}
+
+func init() {
+	RegisterInterfaceType(BSDF{})
+}
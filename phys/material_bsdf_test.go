@@ -0,0 +1,92 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
+)
+
+// TestBSDFMarshalJSONRoundTripPlastic verifies a plastic-like BSDF (a
+// diffuse Lambertian substrate under a fixed-weight Dielectric specular
+// coat) survives a marshal/unmarshal round trip with both lobes intact.
+func TestBSDFMarshalJSONRoundTripPlastic(t *testing.T) {
+	m := BSDF{
+		Lobes: []WeightedLobe{
+			{Material: Lambertian{Texture: TextureUniform{Color: Spectrum{X: 0.8, Y: 0.2, Z: 0.2}}}, Weight: 0.8, Mode: LobeWeightFixed},
+			{Material: Dielectric{RefractiveIndexInterior: 1.5, RefractiveIndexExterior: 1.0}, Weight: 0.2, Mode: LobeWeightFixed},
+		},
+	}
+	if err := m.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+
+	data, err := json.Marshal(m)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+
+	var decoded BSDF
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if err := decoded.Validate(); err != nil {
+		t.Errorf("decoded BSDF invalid: %v", err)
+	}
+	if len(decoded.Lobes) != 2 {
+		t.Fatalf("len(Lobes) = %d, want 2", len(decoded.Lobes))
+	}
+	if _, ok := decoded.Lobes[0].Material.(Lambertian); !ok {
+		t.Errorf("Lobes[0].Material = %T, want Lambertian", decoded.Lobes[0].Material)
+	}
+	if _, ok := decoded.Lobes[1].Material.(Dielectric); !ok {
+		t.Errorf("Lobes[1].Material = %T, want Dielectric", decoded.Lobes[1].Material)
+	}
+}
+
+// TestBSDFMarshalJSONRoundTripCoatedMetal verifies a coated-metal BSDF (a
+// Metal base under a Fresnel-blended Dielectric clear coat) round-trips,
+// including the LobeWeightFresnel mode and its IOR-as-Weight encoding.
+func TestBSDFMarshalJSONRoundTripCoatedMetal(t *testing.T) {
+	m := BSDF{
+		Lobes: []WeightedLobe{
+			{Material: Metal{Albedo: r3.Vec{X: 0.9, Y: 0.9, Z: 0.9}, Fuzz: 0}, Weight: 1, Mode: LobeWeightFixed},
+			{Material: Dielectric{RefractiveIndexInterior: 1.5, RefractiveIndexExterior: 1.0}, Weight: 1.5, Mode: LobeWeightFresnel},
+		},
+	}
+	if err := m.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+
+	data, err := json.Marshal(m)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+
+	var decoded BSDF
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if decoded.Lobes[1].Mode != LobeWeightFresnel {
+		t.Errorf("Lobes[1].Mode = %v, want LobeWeightFresnel", decoded.Lobes[1].Mode)
+	}
+	if decoded.Lobes[1].Weight != 1.5 {
+		t.Errorf("Lobes[1].Weight = %v, want 1.5", decoded.Lobes[1].Weight)
+	}
+}
+
+// TestBSDFValidateRejectsFresnelWeightBelowOne verifies Validate catches a
+// LobeWeightFresnel lobe whose Weight (the coat's index of refraction)
+// is physically nonsensical below 1, rather than silently computing a
+// Schlick term from an invalid IOR.
+func TestBSDFValidateRejectsFresnelWeightBelowOne(t *testing.T) {
+	m := BSDF{
+		Lobes: []WeightedLobe{
+			{Material: Lambertian{Texture: TextureUniform{}}, Weight: 0.9, Mode: LobeWeightFresnel},
+		},
+	}
+	if err := m.Validate(); err == nil {
+		t.Error("Validate() = nil, want error for LobeWeightFresnel with Weight < 1")
+	}
+}
@@ -0,0 +1,28 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+
+package phys
+
+import (
+	"context"
+)
+
+// DebugAlbedo visualizes the base color of the node's underlying
+// Material, bypassing lighting entirely. See materialAlbedo for how the
+// approximate albedo is derived for materials with no shared accessor.
+type DebugAlbedo struct{}
+
+func (m DebugAlbedo) Validate() error {
+	return nil
+}
+
+func (m DebugAlbedo) Resolve(ctx context.Context, s surfaceInteraction) resolution {
+	return debugEmissionOnly(s.rayType, materialAlbedo(s))
+}
+
+func (m DebugAlbedo) ComputeDirectLighting(ctx context.Context, s surfaceInteraction, scene *Scene) Spectrum {
+	return Spectrum{} // No direct lighting for the albedo shader.
+}
+
+func init() {
+	RegisterInterfaceType(DebugAlbedo{})
+}
@@ -0,0 +1,28 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+
+package phys
+
+import (
+	"context"
+)
+
+// DebugBarycentric visualizes a collision's barycentric weights (w, u, v)
+// directly as RGB, which for a triangle mesh shows each face as a
+// red-green-blue gradient fading to black at the opposite vertex.
+type DebugBarycentric struct{}
+
+func (m DebugBarycentric) Validate() error {
+	return nil
+}
+
+func (m DebugBarycentric) Resolve(ctx context.Context, s surfaceInteraction) resolution {
+	return debugEmissionOnly(s.rayType, Spectrum(s.collision.barycentric))
+}
+
+func (m DebugBarycentric) ComputeDirectLighting(ctx context.Context, s surfaceInteraction, scene *Scene) Spectrum {
+	return Spectrum{} // No direct lighting for the barycentric shader.
+}
+
+func init() {
+	RegisterInterfaceType(DebugBarycentric{})
+}
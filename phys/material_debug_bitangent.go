@@ -0,0 +1,29 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+
+package phys
+
+import (
+	"context"
+)
+
+// DebugBitangent draws the surface bitangent as a false-color
+// visualization, using the same [-1,1] -> [0,1] mapping as DebugNormal.
+type DebugBitangent struct{}
+
+func (m DebugBitangent) Validate() error {
+	return nil
+}
+
+func (m DebugBitangent) Resolve(ctx context.Context, s surfaceInteraction) resolution {
+	b := s.collision.bitangent.Unit()
+	color := Spectrum{X: 0.5 * (b.X + 1), Y: 0.5 * (b.Y + 1), Z: 0.5 * (b.Z + 1)}
+	return debugEmissionOnly(s.rayType, color)
+}
+
+func (m DebugBitangent) ComputeDirectLighting(ctx context.Context, s surfaceInteraction, scene *Scene) Spectrum {
+	return Spectrum{} // No direct lighting for the bitangent shader.
+}
+
+func init() {
+	RegisterInterfaceType(DebugBitangent{})
+}
@@ -0,0 +1,37 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+
+package phys
+
+import (
+	"context"
+	"fmt"
+)
+
+// DebugDepth visualizes the ray-parametric hit distance as grayscale,
+// remapping [Near, Far] to [0, 1] (white = Near, black = Far) so scene
+// depth extents stay visible regardless of absolute scene units.
+type DebugDepth struct {
+	Near Distance // Distance mapped to white (1).
+	Far  Distance // Distance mapped to black (0).
+}
+
+func (m DebugDepth) Validate() error {
+	if m.Far <= m.Near {
+		return fmt.Errorf("invalid DebugDepth: Far (%v) must be greater than Near (%v)", m.Far, m.Near)
+	}
+	return nil
+}
+
+func (m DebugDepth) Resolve(ctx context.Context, s surfaceInteraction) resolution {
+	t := (float64(s.collision.t) - float64(m.Near)) / float64(m.Far-m.Near)
+	gray := clamp(1-t, 0, 1)
+	return debugEmissionOnly(s.rayType, Spectrum{X: gray, Y: gray, Z: gray})
+}
+
+func (m DebugDepth) ComputeDirectLighting(ctx context.Context, s surfaceInteraction, scene *Scene) Spectrum {
+	return Spectrum{} // No direct lighting for the depth shader.
+}
+
+func init() {
+	RegisterInterfaceType(DebugDepth{})
+}
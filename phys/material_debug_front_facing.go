@@ -0,0 +1,33 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+
+package phys
+
+import (
+	"context"
+)
+
+// DebugFrontFacing visualizes whether the incoming ray hit the front
+// (green) or back (red) of the surface, i.e. whether collision.normal
+// points toward the ray origin. This is useful for spotting inverted
+// mesh winding or normals flipped by a transform.
+type DebugFrontFacing struct{}
+
+func (m DebugFrontFacing) Validate() error {
+	return nil
+}
+
+func (m DebugFrontFacing) Resolve(ctx context.Context, s surfaceInteraction) resolution {
+	color := Spectrum{X: 1, Y: 0, Z: 0} // Back-facing.
+	if s.incoming.direction.Dot(s.collision.normal) < 0 {
+		color = Spectrum{X: 0, Y: 1, Z: 0} // Front-facing.
+	}
+	return debugEmissionOnly(s.rayType, color)
+}
+
+func (m DebugFrontFacing) ComputeDirectLighting(ctx context.Context, s surfaceInteraction, scene *Scene) Spectrum {
+	return Spectrum{} // No direct lighting for the front-facing shader.
+}
+
+func init() {
+	RegisterInterfaceType(DebugFrontFacing{})
+}
@@ -0,0 +1,29 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+
+package phys
+
+import (
+	"context"
+)
+
+// DebugMaterialID visualizes surfaceInteraction.materialID by hashing it
+// to a stable pseudo-random color (see debugIDColor), so each node in a
+// scene renders as a visibly distinct flat color regardless of its
+// assigned Material.
+type DebugMaterialID struct{}
+
+func (m DebugMaterialID) Validate() error {
+	return nil
+}
+
+func (m DebugMaterialID) Resolve(ctx context.Context, s surfaceInteraction) resolution {
+	return debugEmissionOnly(s.rayType, debugIDColor(s.materialID))
+}
+
+func (m DebugMaterialID) ComputeDirectLighting(ctx context.Context, s surfaceInteraction, scene *Scene) Spectrum {
+	return Spectrum{} // No direct lighting for the material-ID shader.
+}
+
+func init() {
+	RegisterInterfaceType(DebugMaterialID{})
+}
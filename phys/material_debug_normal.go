@@ -76,8 +76,8 @@ func (m DebugNormal) Resolve(ctx context.Context, c surfaceInteraction) resoluti
 		b = 1
 	}
 
-	s := Spectrum{X: r, Y: g, Z: b}
-	return resolution{emission: s}
+	color := Spectrum{X: r, Y: g, Z: b}
+	return debugEmissionOnly(c.rayType, color)
 }
 
 // ComputeDirectLighting reports whether and how much direct lighting should be
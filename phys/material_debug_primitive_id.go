@@ -0,0 +1,45 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+
+package phys
+
+import (
+	"context"
+)
+
+// DebugPrimitiveID visualizes collision.primitiveID by hashing it to a
+// stable pseudo-random color, so adjacent faces of a mesh render as
+// visibly distinct colors even though their IDs differ by only one.
+type DebugPrimitiveID struct{}
+
+func (m DebugPrimitiveID) Validate() error {
+	return nil
+}
+
+func (m DebugPrimitiveID) Resolve(ctx context.Context, s surfaceInteraction) resolution {
+	return debugEmissionOnly(s.rayType, debugIDColor(s.collision.primitiveID))
+}
+
+func (m DebugPrimitiveID) ComputeDirectLighting(ctx context.Context, s surfaceInteraction, scene *Scene) Spectrum {
+	return Spectrum{} // No direct lighting for the primitive-ID shader.
+}
+
+func init() {
+	RegisterInterfaceType(DebugPrimitiveID{})
+}
+
+// debugIDColor hashes an integer ID (e.g. a primitiveID or materialID)
+// into a stable, visually-distinct RGB color for AOV debug shaders. The
+// hash is a 32-bit integer mix (Murmur3 finalizer), so adjacent IDs do
+// not produce visually similar colors.
+func debugIDColor(id int) Spectrum {
+	h := uint32(id)
+	h ^= h >> 16
+	h *= 0x85ebca6b
+	h ^= h >> 13
+	h *= 0xc2b2ae35
+	h ^= h >> 16
+	r := float64(h&0xff) / 255
+	g := float64((h>>8)&0xff) / 255
+	b := float64((h>>16)&0xff) / 255
+	return Spectrum{X: r, Y: g, Z: b}
+}
@@ -0,0 +1,29 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+
+package phys
+
+import (
+	"context"
+)
+
+// DebugTangent draws the surface tangent as a false-color visualization,
+// using the same [-1,1] -> [0,1] mapping as DebugNormal.
+type DebugTangent struct{}
+
+func (m DebugTangent) Validate() error {
+	return nil
+}
+
+func (m DebugTangent) Resolve(ctx context.Context, s surfaceInteraction) resolution {
+	t := s.collision.tangent.Unit()
+	color := Spectrum{X: 0.5 * (t.X + 1), Y: 0.5 * (t.Y + 1), Z: 0.5 * (t.Z + 1)}
+	return debugEmissionOnly(s.rayType, color)
+}
+
+func (m DebugTangent) ComputeDirectLighting(ctx context.Context, s surfaceInteraction, scene *Scene) Spectrum {
+	return Spectrum{} // No direct lighting for the tangent shader.
+}
+
+func init() {
+	RegisterInterfaceType(DebugTangent{})
+}
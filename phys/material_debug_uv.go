@@ -18,13 +18,13 @@ func (m DebugUV) Validate() error {
 func (m DebugUV) Resolve(ctx context.Context, s surfaceInteraction) resolution {
 	// Clamp UVs to [0,1] for visualization.
 	if s.collision.uv.X < 0.0 || s.collision.uv.X > 1.0 {
-		return resolution{emission: Spectrum{X: 1.0, Y: 0.0, Z: 0.0}}
+		return debugEmissionOnly(s.rayType, Spectrum{X: 1.0, Y: 0.0, Z: 0.0})
 	}
 	u := math.Min(math.Max(s.collision.uv.X, 0.0), 1.0)
 	v := math.Min(math.Max(s.collision.uv.Y, 0.0), 1.0)
 	// Map U to Red, V to Green, and set Blue to 0.5 for visibility.
 	color := Spectrum{X: u, Y: v, Z: 0.5}
-	return resolution{emission: color}
+	return debugEmissionOnly(s.rayType, color)
 }
 
 func (m DebugUV) ComputeDirectLighting(ctx context.Context, s surfaceInteraction, scene *Scene) Spectrum {
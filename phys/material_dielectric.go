@@ -26,74 +26,81 @@ func (m Dielectric) Validate() error {
 	return nil
 }
 
+// Resolve stochastically chooses between the reflected and transmitted
+// lobes, weighted by the dielectric Fresnel reflectance (the same
+// FresnelSpecular::Sample_f scheme PBRT uses), rather than emitting both
+// a reflected and a refracted ray at every hit. Emitting both causes a
+// path's ray count to grow exponentially with depth and scales each
+// child's radiance by its own probability without dividing by that
+// probability's PDF, distorting the energy balance; sampling one lobe
+// and dividing by its selection probability keeps the estimator
+// unbiased while advancing the path by exactly one ray per hit, the way
+// Glass already does. See Glass.Resolve for the same pattern without
+// Roughness.
 func (m Dielectric) Resolve(ctx context.Context, s surfaceInteraction) resolution {
-	var outwardNormal r3.Vec
-	var niOverNt float64
-	var cosine float64
-	var n1, n2 float64
-	var rays []ray
+	n := s.collision.normal.Unit()
+	wo := s.outgoing.Unit()
 	rand := s.incoming.rand
 
-	// Determine if the ray is entering or exiting the material.
-	if s.incoming.direction.Dot(s.collision.normal) > 0 {
-		// Ray is exiting the material. Going from interior to exterior.
-		outwardNormal = s.collision.normal.Muls(-1)
-		niOverNt = m.RefractiveIndexInterior / m.RefractiveIndexExterior
-		n1 = m.RefractiveIndexInterior
-		n2 = m.RefractiveIndexExterior
-		cosine = s.incoming.direction.Dot(s.collision.normal) / s.incoming.direction.Length()
-		// Adjust cosine for total internal reflection
-		cosine = math.Sqrt(1 - niOverNt*niOverNt*(1-cosine*cosine))
-	} else {
-		// Ray is entering the dielectric. Going from exterior to interior.
-		outwardNormal = s.collision.normal
-		niOverNt = m.RefractiveIndexExterior / m.RefractiveIndexInterior
-		n1 = m.RefractiveIndexExterior
-		n2 = m.RefractiveIndexInterior
-		cosine = -s.incoming.direction.Dot(s.collision.normal) / s.incoming.direction.Length()
+	outside := wo.Dot(n) > 0
+	etaI, etaT := m.RefractiveIndexExterior, m.RefractiveIndexInterior
+	normal := n
+	if !outside {
+		etaI, etaT = etaT, etaI
+		normal = n.Muls(-1)
 	}
+	cosThetaI := normal.Dot(wo)
 
-	refracted, ok := refract(s.incoming.direction, outwardNormal, niOverNt)
-	reflectProb := 1.0
+	btdf := SpecularTransmissionBTDF{RefractiveIndexInterior: etaT, RefractiveIndexExterior: etaI}
+	wiT, weightT, pdfT := btdf.Sample(wo, normal)
 
-	if ok {
-		// Use Schlick's approximation for reflectance
-		reflectProb = reflectance(cosine, n1, n2)
+	reflectProb := FresnelDielectric(cosThetaI, etaI, etaT)
+	if pdfT <= 0 {
+		reflectProb = 1 // Total internal reflection: the transmitted lobe doesn't exist.
+	}
 
-		// Add roughness to the refracted ray.
+	if rand.Float64() < reflectProb {
+		// F/reflectProb == 1 for the chosen reflection lobe: the Fresnel
+		// weight and the probability of selecting this lobe cancel.
+		reflected := reflectRay(wo.Muls(-1), normal)
 		if m.Roughness > 0 {
-			refracted = refracted.Add(rand.InUnitSphere().Muls(m.Roughness)).Unit()
+			reflected = reflected.Add(rand.InUnitSphere().Muls(m.Roughness)).Unit()
 		}
-
-		transmitted := ray{
+		newRay := ray{
 			origin:    s.collision.at,
-			direction: refracted,
+			direction: reflected,
 			depth:     s.incoming.depth + 1,
-			radiance:  s.incoming.radiance.Muls(1 - reflectProb),
+			radiance:  s.incoming.radiance,
 			rand:      rand,
 			pixelX:    s.incoming.pixelX,
 			pixelY:    s.incoming.pixelY,
+			rayType:   RayTypeReflected,
+			time:      s.incoming.time,
 		}
-		rays = append(rays, transmitted)
+		return resolution{scattered: []ray{newRay}}
 	}
 
-	reflected := reflectRay(s.incoming.direction, s.collision.normal)
-
-	// Add roughness to the reflected ray, scattering the direction slightly.
+	// (1-F)/(1-reflectProb) == 1 for the chosen transmission lobe, leaving
+	// only weightT's (etaI/etaT)^2 non-symmetry correction: radiance,
+	// unlike importance, isn't invariant along a refracted ray.
+	cosThetaT := math.Abs(normal.Dot(wiT))
+	throughput := weightT.Muls(cosThetaT / ((1 - reflectProb) * pdfT))
+	refracted := wiT
 	if m.Roughness > 0 {
-		reflected = reflected.Add(rand.InUnitSphere().Muls(m.Roughness)).Unit()
+		refracted = refracted.Add(rand.InUnitSphere().Muls(m.Roughness)).Unit()
 	}
-	reflectedRay := ray{
+	newRay := ray{
 		origin:    s.collision.at,
-		direction: reflected,
+		direction: refracted,
 		depth:     s.incoming.depth + 1,
-		radiance:  s.incoming.radiance.Muls(reflectProb),
+		radiance:  s.incoming.radiance.Mul(Spectrum(throughput)),
 		rand:      rand,
 		pixelX:    s.incoming.pixelX,
 		pixelY:    s.incoming.pixelY,
+		rayType:   RayTypeRefracted,
+		time:      s.incoming.time,
 	}
-	rays = append(rays, reflectedRay)
-	return resolution{scattered: rays}
+	return resolution{scattered: []ray{newRay}}
 }
 
 // func (m Dielectric) ComputeDirectLighting(s surfaceInteraction, scene *Scene) r3.Vec {
@@ -102,6 +109,47 @@ func (m Dielectric) Resolve(ctx context.Context, s surfaceInteraction) resolutio
 // 	return r3.Vec{}
 // }
 
+// dielectricOccluded reports whether a shadow ray from p toward wi, up to
+// maxDist, is blocked by any node other than s.node -- the same
+// self-exclusion both ComputeDirectLighting strategies below need, and
+// that RoughPlastic.ComputeDirectLighting repeats for its own materials.
+func dielectricOccluded(s surfaceInteraction, scene *Scene, origin r3.Point, wi r3.Vec, maxDist Distance) bool {
+	shadowRay := ray{
+		origin:    origin,
+		direction: wi,
+		depth:     s.incoming.depth + 1,
+		radiance:  Spectrum{1, 1, 1},
+		rand:      s.incoming.rand,
+		rayType:   RayTypeShadow,
+		time:      s.incoming.time,
+	}
+	for _, node := range scene.Node {
+		if node.Shape == s.node.Shape {
+			continue // Skip self.
+		}
+		if hit, _ := node.Shape.Collide(shadowRay, eps, maxDist); hit {
+			return true
+		}
+	}
+	return false
+}
+
+// ComputeDirectLighting combines two sampling strategies via the balance
+// heuristic (powerHeuristic), the same two-strategy MIS combination
+// connectToVertex already uses for the bidirectional integrator: a
+// light-sampled estimate (draw a direction from each Light, weight by how
+// likely the microfacet BRDF would have been to sample it too) and a
+// BSDF-sampled estimate (draw a direction from the BRDF, weight by how
+// likely it is that a light actually occupies that direction). A rough
+// dielectric lit by a small, bright light source produces heavy fireflies
+// under light-sampling alone, since a low-probability BRDF tail direction
+// occasionally lines up with the light; mixing in the BSDF-sampled
+// estimate (which draws from that same tail directly) cancels the
+// variance spike. Delta lights (PointLight, SpotLight, Sun,
+// HosekWilkieSky) have zero probability of appearing in the BSDF-sampled
+// estimate, so their Light.Pdf of 0 collapses the light-sampled weight to
+// 1 -- the same single-strategy behavior ComputeDirectLighting always had
+// for them.
 func (m Dielectric) ComputeDirectLighting(ctx context.Context, s surfaceInteraction, scene *Scene) Spectrum {
 	p := s.collision.at
 	n := s.collision.normal.Unit()
@@ -124,50 +172,51 @@ func (m Dielectric) ComputeDirectLighting(ctx context.Context, s surfaceInteract
 		Roughness: m.Roughness,
 		F0:        r3.Vec{X: 1, Y: 1, Z: 1}, // Assuming dielectric with total internal reflection
 	}
+	shadowOrigin := p.Add(normal.Muls(eps))
 
-	for _, light := range scene.Light {
+	// Light-sampling strategy.
+	lights, lightWeight := sampledLights(scene.Light, scene.RenderOptions.DirectLightSamples, s.incoming.rand)
+	for _, light := range lights {
 		dirToLight, distanceToLight, radiantIntensity := light.Sample(p, s.incoming.rand)
 		wi := dirToLight.Unit()
 
-		// Compute Fresnel term
-		cosThetaI := math.Max(0, wi.Dot(normal))
-		fresnel := reflectance(cosThetaI, etaI, etaT)
-
-		// Offset the origin slightly to prevent self-intersection.
-		shadowRayOrigin := p.Add(normal.Muls(eps))
-		shadowRay := ray{
-			origin:    shadowRayOrigin,
-			direction: wi,
-			depth:     s.incoming.depth + 1,
-			radiance:  Spectrum{1, 1, 1},
-			rand:      s.incoming.rand,
+		cosTheta := math.Max(0, normal.Dot(wi))
+		if cosTheta <= 0 || dielectricOccluded(s, scene, shadowOrigin, wi, distanceToLight) {
+			continue
 		}
 
-		// Check for occlusion.
-		occluded := false
-		for _, node := range scene.Node {
-			if node.Shape == s.node.Shape {
-				continue // Skip self.
-			}
-			hit, _ := node.Shape.Collide(shadowRay, eps, distanceToLight)
-			if hit {
-				occluded = true
-				break
-			}
+		cosThetaI := math.Max(0, wi.Dot(normal))
+		fresnel := reflectance(cosThetaI, etaI, etaT)
+		weight := 1.0
+		if pdfLight := light.Pdf(p, wi); pdfLight > 0 {
+			weight = powerHeuristic(pdfLight, brdf.PDF(wo, wi, normal))
 		}
 
-		if !occluded {
-			// Evaluate the BRDF
-			brdfValue := brdf.Evaluate(wo, wi, normal).Muls(fresnel)
+		brdfValue := brdf.Evaluate(wo, wi, normal).Muls(fresnel)
+		radiance := radiantIntensity.Mul(brdfValue).Muls(cosTheta * weight * lightWeight)
+		directIllumination = directIllumination.Add(Spectrum(radiance))
+	}
 
-			// Compute the cosine term
-			cosTheta := math.Max(0, normal.Dot(wi))
+	// BSDF-sampling strategy: draw a direction from the microfacet lobe
+	// and see whether it actually connects to one of the scene's lights.
+	wiBSDF, pdfBSDF := brdf.Sample(wo, normal, s.incoming.rand)
+	cosTheta := math.Max(0, normal.Dot(wiBSDF))
+	if pdfBSDF > 0 && cosTheta > 0 {
+		cosThetaI := math.Max(0, wiBSDF.Dot(normal))
+		fresnel := reflectance(cosThetaI, etaI, etaT)
+		brdfValue := brdf.Evaluate(wo, wiBSDF, normal).Muls(fresnel)
 
-			// Accumulate the contribution.
-			radiance := radiantIntensity.Mul(brdfValue).Muls(cosTheta)
-			directIllumination = directIllumination.Add(Spectrum(radiance))
+		for _, light := range scene.Light {
+			radiance, distanceToLight := light.EmittedRadiance(p, wiBSDF)
+			if radiance.IsZero() || dielectricOccluded(s, scene, shadowOrigin, wiBSDF, distanceToLight) {
+				continue
+			}
+			weight := powerHeuristic(pdfBSDF, light.Pdf(p, wiBSDF))
+			contribution := radiance.Mul(brdfValue).Muls(cosTheta * weight / pdfBSDF)
+			directIllumination = directIllumination.Add(Spectrum(contribution))
 		}
 	}
+
 	return directIllumination
 }
 
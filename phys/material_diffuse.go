@@ -0,0 +1,166 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+
+package phys
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r2"
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
+)
+
+// Diffuse is a diffuse reflecting material whose angular falloff is
+// delegated to a pluggable DiffuseBRDF, so a scene can choose
+// LambertianBRDF, OrenNayarBRDF, or DisneyDiffuseBRDF without a new
+// Material implementation for each. Lambertian remains the simpler,
+// BRDF-less diffuse material for scenes that don't need the extra knob.
+type Diffuse struct {
+	Texture Texture
+	BRDF    DiffuseBRDF
+}
+
+func (m Diffuse) Validate() error {
+	if m.BRDF == nil {
+		return fmt.Errorf("phys: Diffuse.BRDF must not be nil")
+	}
+	return m.Texture.Validate()
+}
+
+func (m Diffuse) ComputeDirectLighting(ctx context.Context, s surfaceInteraction, scene *Scene) Spectrum {
+	p := s.collision.at
+	n := s.collision.normal.Unit()
+	wo := s.incoming.direction.Muls(-1).Unit()
+	directIllumination := Spectrum{}
+	lights, lightWeight := sampledLights(scene.Light, scene.RenderOptions.DirectLightSamples, s.incoming.rand)
+	for _, light := range lights {
+		dirToLight, distanceToLight, radiantIntensity := light.Sample(p, s.incoming.rand)
+		// Offset the origin slightly to prevent self-intersection.
+		shadowRayOrigin := p.Add(n.Muls(eps))
+		shadowRay := ray{
+			origin:    shadowRayOrigin,
+			direction: dirToLight,
+			depth:     s.incoming.depth + 1,
+			radiance:  Spectrum{1, 1, 1},
+			rand:      s.incoming.rand,
+			rayType:   RayTypeShadow,
+			time:      s.incoming.time,
+		}
+		if !scene.Occluded(shadowRay, distanceToLight, s.node.Shape) {
+			radiance := m.ReflectedRadiance(s.collision.uv, dirToLight, wo, n).Mul(Spectrum(radiantIntensity)).Muls(lightWeight)
+			directIllumination = directIllumination.Add(radiance)
+		}
+	}
+	// IntegratorBDPT samples the same Emitter nodes itself, bounced through
+	// sampleLightSubpath and MIS-weighted by connectToLightVertex; adding
+	// sampleEmitterDirectLighting here too would connect to the same
+	// emitter vertex twice.
+	if scene.RenderOptions.Integrator != IntegratorBDPT {
+		directIllumination = directIllumination.Add(sampleEmitterDirectLighting(scene, s, m))
+	}
+	return directIllumination
+}
+
+// ReflectedRadiance returns albedo(uv) * BRDF(wi, wo, n) * max(0, n·wi),
+// the same per-light term ComputeDirectLighting accumulates. It satisfies
+// DiffuseReflector, so the BDPT integrator can weight a connection to an
+// arbitrary light vertex the same way ComputeDirectLighting weights a
+// connection to a PointLight.
+func (m Diffuse) ReflectedRadiance(uv r2.Point, wi, wo, n r3.Vec) Spectrum {
+	nDotL := math.Max(0, n.Dot(wi))
+	brdf := m.BRDF.Evaluate(wi, wo, n)
+	// No uvFootprint estimate is available for a bare connection vertex
+	// (e.g. a BDPT light-subpath connection); falls back to the base mip.
+	albedo := textureAt(m.Texture, uv.X, uv.Y, r2.Point{})
+	return albedo.Muls(nDotL * brdf)
+}
+
+// PDF returns the cosine-weighted hemisphere sampling density Resolve
+// draws wi from regardless of which DiffuseBRDF is plugged in: only the
+// returned radiance scales with BRDF.Evaluate, the sampling direction
+// itself is always cosine-weighted about n. It satisfies BSDFPDF, so
+// connectToVertex's light-vertex MIS weight uses this value directly
+// rather than assuming it.
+func (m Diffuse) PDF(wi, wo, n r3.Vec) float64 {
+	return math.Max(0, n.Dot(wi)) / math.Pi
+}
+
+// Resolve samples a new direction using cosine-weighted hemisphere sampling,
+// then weights the scattered radiance by the BRDF's angular multiplier. The
+// cosine-weighted pdf (cos(theta)/pi) already cancels the cos(theta)/pi
+// factor implicit in a BRDF normalized like Lambertian's, so only the
+// BRDF's multiplier need be applied here.
+func (m Diffuse) Resolve(ctx context.Context, s surfaceInteraction) resolution {
+	p := s.collision.at
+	n := s.collision.normal.Unit()
+	wo := s.incoming.direction.Muls(-1).Unit()
+
+	scatteredDirection := s.incoming.rand.CosineWeightedHemisphere(n)
+	brdf := m.BRDF.Evaluate(scatteredDirection, wo, n)
+
+	albedo := textureAt(m.Texture, s.collision.uv.X, s.collision.uv.Y, s.collision.uvFootprint)
+	newRay := ray{
+		origin:    p,
+		direction: scatteredDirection,
+		depth:     s.incoming.depth + 1,
+		radiance:  s.incoming.radiance.Mul(albedo).Muls(brdf),
+		rand:      s.incoming.rand,
+		pixelX:    s.incoming.pixelX,
+		pixelY:    s.incoming.pixelY,
+		rayType:   RayTypeGlossy,
+		time:      s.incoming.time,
+		bsdfPdf:   m.PDF(scatteredDirection, wo, n),
+	}
+
+	return resolution{scattered: []ray{newRay}}
+}
+
+// diffuseData is the wire representation of Diffuse, wrapping its
+// polymorphic Texture and BRDF fields with Type/Data envelopes.
+type diffuseData struct {
+	Type    string          `json:"Type"`
+	Texture json.RawMessage `json:"Texture"`
+	BRDF    json.RawMessage `json:"BRDF"`
+}
+
+func (m Diffuse) MarshalJSON() ([]byte, error) {
+	textureData, err := marshalInterface(m.Texture)
+	if err != nil {
+		return nil, err
+	}
+	brdfData, err := marshalInterface(m.BRDF)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(diffuseData{Type: "Diffuse", Texture: textureData, BRDF: brdfData})
+}
+
+func (m *Diffuse) UnmarshalJSON(data []byte) error {
+	var temp diffuseData
+	if err := json.Unmarshal(data, &temp); err != nil {
+		return err
+	}
+	if temp.Type != "Diffuse" {
+		return fmt.Errorf("invalid type: expected Diffuse, got %s", temp.Type)
+	}
+	texture, err := unmarshalInterface(temp.Texture)
+	if err != nil {
+		return err
+	}
+	m.Texture = texture.(Texture)
+	brdf, err := unmarshalInterface(temp.BRDF)
+	if err != nil {
+		return err
+	}
+	m.BRDF = brdf.(DiffuseBRDF)
+	return nil
+}
+
+func init() {
+	RegisterInterfaceType(Diffuse{})
+	RegisterInterfaceType(LambertianBRDF{})
+	RegisterInterfaceType(OrenNayarBRDF{})
+	RegisterInterfaceType(DisneyDiffuseBRDF{})
+}
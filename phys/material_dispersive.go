@@ -0,0 +1,139 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+
+package phys
+
+import (
+	"context"
+	"fmt"
+	"math"
+)
+
+// Dispersive is a perfect (delta) dielectric like Glass, except its
+// interior refractive index varies with wavelength via a two-term
+// Cauchy equation: n(lambda) = A + B/lambda^2, with lambda in
+// micrometers. This is what makes a prism split white light into a
+// rainbow: blue light (short lambda) bends more than red light (long
+// lambda) because n(lambda) is larger there.
+//
+// The first hit on a Dispersive surface stochastically picks a hero
+// wavelength set (NewHeroWavelengths) if the incoming ray does not carry
+// one yet, so a path through multiple dispersive interfaces (e.g. both
+// faces of a prism) bends consistently rather than re-randomizing at
+// each surface. RGB-only materials elsewhere in the scene still work: the
+// radiance the path is carrying is reinterpreted at the hero wavelength
+// via RGBToSpectrum, and converted back to RGB via SampledSpectrum.ToRGB
+// once the path resolves, so everything downstream keeps using the
+// Spectrum type unchanged.
+type Dispersive struct {
+	A float64 // Cauchy coefficient (dimensionless).
+	B float64 // Cauchy coefficient, in micrometers^2.
+
+	RefractiveIndexExterior float64 // Refractive index of the surrounding medium, typically 1 (air/vacuum).
+}
+
+func (m Dispersive) Validate() error {
+	if m.A < 1 {
+		return fmt.Errorf("invalid Dispersive A must be >= 1: %v", m.A)
+	}
+	if m.B < 0 {
+		return fmt.Errorf("invalid Dispersive B must be non-negative: %v", m.B)
+	}
+	if m.RefractiveIndexExterior < 1 {
+		return fmt.Errorf("invalid Dispersive RefractiveIndexExterior must be >= 1: %v", m.RefractiveIndexExterior)
+	}
+	return nil
+}
+
+// cauchyIOR evaluates the Cauchy equation n(lambda) = A + B/lambda^2 for
+// lambda given in nanometers.
+func (m Dispersive) cauchyIOR(lambdaNM float64) float64 {
+	lambdaUM := lambdaNM / 1000
+	return m.A + m.B/(lambdaUM*lambdaUM)
+}
+
+func (m Dispersive) Resolve(ctx context.Context, s surfaceInteraction) resolution {
+	n := s.collision.normal.Unit()
+	wo := s.outgoing.Unit()
+	rand := s.incoming.rand
+
+	wavelengths := s.incoming.wavelengths
+	if !hasWavelengths(wavelengths) {
+		wavelengths = NewHeroWavelengths(rand)
+	}
+	hero := wavelengths[0]
+	etaInterior := m.cauchyIOR(hero)
+
+	outside := wo.Dot(n) > 0
+	etaI, etaT := m.RefractiveIndexExterior, etaInterior
+	normal := n
+	if !outside {
+		etaI, etaT = etaT, etaI
+		normal = n.Muls(-1)
+	}
+	cosThetaI := normal.Dot(wo)
+
+	btdf := SpecularTransmissionBTDF{RefractiveIndexInterior: etaT, RefractiveIndexExterior: etaI}
+	wiT, weightT, pdfT := btdf.Sample(wo, normal)
+
+	reflectProb := FresnelDielectric(cosThetaI, etaI, etaT)
+	if pdfT <= 0 {
+		reflectProb = 1 // Total internal reflection: the transmitted lobe doesn't exist.
+	}
+
+	// Reinterpret the carried radiance at the hero wavelength: this is
+	// the RGB-compatibility path, converting whatever RGB color an
+	// upstream Lambertian/Metal/etc. left on the ray into a per-sample
+	// spectral value before continuing the dispersive path.
+	sampled := RGBToSpectrum(s.incoming.radiance, wavelengths)
+
+	if rand.Float64() < reflectProb {
+		newRay := ray{
+			origin:      s.collision.at,
+			direction:   reflectRay(wo.Muls(-1), normal),
+			depth:       s.incoming.depth + 1,
+			radiance:    sampled.ToRGB(),
+			rand:        rand,
+			pixelX:      s.incoming.pixelX,
+			pixelY:      s.incoming.pixelY,
+			wavelengths: wavelengths,
+			rayType:     RayTypeReflected,
+			time:        s.incoming.time,
+		}
+		return resolution{scattered: []ray{newRay}}
+	}
+
+	cosThetaT := math.Abs(normal.Dot(wiT))
+	throughput := weightT.X * cosThetaT / ((1 - reflectProb) * pdfT)
+	for i := range sampled.Values {
+		sampled.Values[i] *= throughput
+	}
+	newRay := ray{
+		origin:      s.collision.at,
+		direction:   wiT,
+		depth:       s.incoming.depth + 1,
+		radiance:    sampled.ToRGB(),
+		rand:        rand,
+		pixelX:      s.incoming.pixelX,
+		pixelY:      s.incoming.pixelY,
+		wavelengths: wavelengths,
+		rayType:     RayTypeRefracted,
+		time:        s.incoming.time,
+	}
+	return resolution{scattered: []ray{newRay}}
+}
+
+// ComputeDirectLighting always returns zero: like Glass, Dispersive's
+// BSDF is made entirely of delta lobes. See SpecularMaterial.
+func (m Dispersive) ComputeDirectLighting(ctx context.Context, s surfaceInteraction, scene *Scene) Spectrum {
+	return Spectrum{}
+}
+
+// Specular reports true: Dispersive's BSDF is entirely delta lobes. See
+// SpecularMaterial.
+func (m Dispersive) Specular() bool {
+	return true
+}
+
+func init() {
+	RegisterInterfaceType(Dispersive{})
+}
@@ -6,7 +6,9 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"math"
 
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r2"
 	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
 )
 
@@ -20,7 +22,7 @@ func (m Emitter) Validate() error {
 }
 
 func (m Emitter) Resolve(ctx context.Context, c surfaceInteraction) resolution {
-	e := m.Texture.At(c.collision.uv.X, c.collision.uv.Y)
+	e := textureAt(m.Texture, c.collision.uv.X, c.collision.uv.Y, c.collision.uvFootprint)
 	return resolution{emission: Spectrum(r3.Vec(e).Mul(r3.Vec(c.incoming.radiance)))}
 }
 
@@ -29,8 +31,38 @@ func (m Emitter) ComputeDirectLighting(ctx context.Context, s surfaceInteraction
 	return Spectrum{}
 }
 
+// radiantPower reports whether m's emission is known to be non-zero. A
+// TextureUniform evaluates to the same color everywhere, so a zero one
+// can be safely skipped when collecting lights to sample (see
+// Scene.CollectEmissiveNodes); any other texture varies by uv, so it is
+// conservatively treated as radiant rather than sampled at some
+// arbitrary uv to check.
+func (m Emitter) radiantPower() bool {
+	u, ok := m.Texture.(TextureUniform)
+	if !ok {
+		return true
+	}
+	return u.Color.X > 0 || u.Color.Y > 0 || u.Color.Z > 0
+}
+
+// SampleLe samples an outgoing emission direction from a point p on the
+// emitter's surface with outward normal normal and texture coordinate uv,
+// for use by the BDPT integrator's light-vertex connection (see
+// connectToLightVertex). Direction is cosine-weighted about normal, so
+// pdfDir is cos(theta)/pi; radiance is the texture's value at uv, matching
+// how Resolve looks up emission.
+func (m Emitter) SampleLe(p r3.Point, normal r3.Vec, uv r2.Point, rand *Rand) (direction r3.Vec, radiance Spectrum, pdfDir float64) {
+	direction = rand.CosineWeightedHemisphere(normal)
+	cosTheta := normal.Dot(direction)
+	pdfDir = cosTheta / math.Pi
+	// No uvFootprint estimate is available for a BDPT light-vertex sample;
+	// falls back to the base mip.
+	radiance = Spectrum(textureAt(m.Texture, uv.X, uv.Y, r2.Point{}))
+	return direction, radiance, pdfDir
+}
+
 // Implement custom JSON marshalling for Emitter
-func (e *Emitter) MarshalJSON() ([]byte, error) {
+func (e Emitter) MarshalJSON() ([]byte, error) {
 	type EmitterData struct {
 		Type    string          `json:"Type"`
 		Texture json.RawMessage `json:"Texture"`
@@ -0,0 +1,87 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+
+package phys
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
+)
+
+// Blackbody is an emitter whose color is the Planckian locus color at
+// Temperature kelvin, e.g. ~1900K for candlelight, ~3200K for tungsten,
+// ~5778K for the sun, ~10000K for an overcast sky. Unlike Emitter, whose
+// color comes from a user-supplied Texture, Blackbody derives its color
+// from physics: Planck's law gives the spectral radiance at each
+// wavelength, which is integrated against the CIE color-matching
+// functions and converted to RGB the same way SampledSpectrum.ToRGB does.
+//
+// Intensity scales the resulting color after it has been normalized to
+// unit luminance, since Planck's law's absolute radiance units (W·sr⁻¹·m⁻³)
+// are not directly comparable to the rest of this renderer's arbitrary
+// light units; Intensity is the same kind of brightness knob Emitter's
+// Texture values are.
+type Blackbody struct {
+	Temperature float64 // Kelvin. Must be > 0.
+	Intensity   float64 // Brightness multiplier applied after normalizing to unit luminance.
+}
+
+func (m Blackbody) Validate() error {
+	if m.Temperature <= 0 {
+		return fmt.Errorf("invalid Blackbody Temperature must be positive: %v", m.Temperature)
+	}
+	if m.Intensity < 0 {
+		return fmt.Errorf("invalid Blackbody Intensity must be non-negative: %v", m.Intensity)
+	}
+	return nil
+}
+
+// planckRadiance evaluates the Planck's law spectral radiance at
+// wavelength lambdaNM (nanometers) for a blackbody at temperature
+// kelvin, in arbitrary units; only the relative shape across wavelengths
+// matters here since color returns a luminance-normalized result.
+func planckRadiance(lambdaNM, kelvin float64) float64 {
+	const h = 6.62607015e-34 // Planck constant, J*s.
+	const c = 2.99792458e8   // Speed of light, m/s.
+	const k = 1.380649e-23   // Boltzmann constant, J/K.
+	lambdaM := lambdaNM * 1e-9
+	numerator := 2 * h * c * c
+	denominator := math.Pow(lambdaM, 5) * (math.Exp(h*c/(lambdaM*k*kelvin)) - 1)
+	return numerator / denominator
+}
+
+// color integrates Planck's law against the CIE color-matching functions
+// to get this blackbody's chromaticity, normalizes to unit luminance, and
+// applies Intensity.
+func (m Blackbody) color() Spectrum {
+	const step = 1.0
+	var x, y, z float64
+	for lambda := wavelengthMin; lambda < wavelengthMax; lambda += step {
+		radiance := planckRadiance(lambda, m.Temperature)
+		cx, cy, cz := cieXYZ(lambda)
+		x += radiance * cx * step
+		y += radiance * cy * step
+		z += radiance * cz * step
+	}
+	if y == 0 {
+		return Spectrum{}
+	}
+	rgb := xyzToSRGB(x/y, y/y, z/y)
+	return rgb.Muls(m.Intensity)
+}
+
+func (m Blackbody) Resolve(ctx context.Context, c surfaceInteraction) resolution {
+	e := m.color()
+	return resolution{emission: Spectrum(r3.Vec(e).Mul(r3.Vec(c.incoming.radiance)))}
+}
+
+func (m Blackbody) ComputeDirectLighting(ctx context.Context, s surfaceInteraction, scene *Scene) Spectrum {
+	// Emitters emit light but don't receive direct lighting.
+	return Spectrum{}
+}
+
+func init() {
+	RegisterInterfaceType(Blackbody{})
+}
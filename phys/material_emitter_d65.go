@@ -0,0 +1,58 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+
+package phys
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
+)
+
+// D65 is an emitter with the chromaticity of the CIE Standard Illuminant
+// D65, the reference daylight white point that sRGB itself is defined
+// against (see xyzToSRGB). A D65 emitter is therefore neutral white in
+// this renderer's native RGB space by construction; it exists as a named,
+// physically-grounded alternative to hand-picking a white TextureUniform
+// for an Emitter, and as the natural fill light for Dispersive scenes
+// since it has no single-wavelength bias.
+type D65 struct {
+	Intensity float64 // Brightness multiplier. Must be non-negative.
+}
+
+// d65ChromaticityX and d65ChromaticityY are the standard CIE xy
+// chromaticity coordinates of Illuminant D65.
+const (
+	d65ChromaticityX = 0.31271
+	d65ChromaticityY = 0.32902
+)
+
+func (m D65) Validate() error {
+	if m.Intensity < 0 {
+		return fmt.Errorf("invalid D65 Intensity must be non-negative: %v", m.Intensity)
+	}
+	return nil
+}
+
+// color converts the D65 xy chromaticity at unit luminance (Y=1) to
+// linear sRGB and applies Intensity.
+func (m D65) color() Spectrum {
+	x := d65ChromaticityX / d65ChromaticityY
+	y := 1.0
+	z := (1 - d65ChromaticityX - d65ChromaticityY) / d65ChromaticityY
+	return xyzToSRGB(x, y, z).Muls(m.Intensity)
+}
+
+func (m D65) Resolve(ctx context.Context, c surfaceInteraction) resolution {
+	e := m.color()
+	return resolution{emission: Spectrum(r3.Vec(e).Mul(r3.Vec(c.incoming.radiance)))}
+}
+
+func (m D65) ComputeDirectLighting(ctx context.Context, s surfaceInteraction, scene *Scene) Spectrum {
+	// Emitters emit light but don't receive direct lighting.
+	return Spectrum{}
+}
+
+func init() {
+	RegisterInterfaceType(D65{})
+}
@@ -0,0 +1,126 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+
+package phys
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
+)
+
+// Glass is a perfect (delta) dielectric: true glass or water, the
+// roughness=0 limit Dielectric's microfacet model can only approach but
+// never reach exactly. At each hit it stochastically chooses between the
+// reflected and transmitted delta lobes, weighted by the dielectric
+// Fresnel reflectance, so a single scattered ray carries an unbiased
+// estimate of both.
+type Glass struct {
+	RefractiveIndexInterior float64
+	RefractiveIndexExterior float64
+	Absorption              r3.Vec // Beer-Lambert absorption coefficient per unit length, tinting light that has traveled through the interior. Zero means clear glass.
+}
+
+func (m Glass) Validate() error {
+	if m.RefractiveIndexInterior < 1 || m.RefractiveIndexExterior < 1 {
+		return fmt.Errorf("invalid Glass refractive index: %v", m)
+	}
+	if m.Absorption.X < 0 || m.Absorption.Y < 0 || m.Absorption.Z < 0 {
+		return fmt.Errorf("invalid Glass Absorption must be non-negative: %v", m.Absorption)
+	}
+	return nil
+}
+
+func (m Glass) Resolve(ctx context.Context, s surfaceInteraction) resolution {
+	n := s.collision.normal.Unit()
+	wo := s.outgoing.Unit()
+	rand := s.incoming.rand
+
+	outside := wo.Dot(n) > 0
+	etaI, etaT := m.RefractiveIndexExterior, m.RefractiveIndexInterior
+	normal := n
+	if !outside {
+		etaI, etaT = etaT, etaI
+		normal = n.Muls(-1)
+	}
+	cosThetaI := normal.Dot(wo)
+
+	radiance := s.incoming.radiance
+	if !outside {
+		// s.incoming is the transmitted ray this same Glass spawned at
+		// the entry point, so the segment it has just traveled -- from
+		// that origin to this exit hit -- is exactly the path length
+		// through the interior that Beer-Lambert attenuates.
+		traveled := s.collision.at.Sub(s.incoming.origin).Length()
+		radiance = radiance.Mul(beerLambertTransmittance(m.Absorption, traveled))
+	}
+
+	btdf := SpecularTransmissionBTDF{RefractiveIndexInterior: etaT, RefractiveIndexExterior: etaI}
+	wiT, weightT, pdfT := btdf.Sample(wo, normal)
+
+	reflectProb := FresnelDielectric(cosThetaI, etaI, etaT)
+	if pdfT <= 0 {
+		reflectProb = 1 // Total internal reflection: the transmitted lobe doesn't exist.
+	}
+
+	if rand.Float64() < reflectProb {
+		// F/reflectProb == 1 for the chosen reflection lobe: the Fresnel
+		// weight and the probability of selecting this lobe cancel.
+		newRay := ray{
+			origin:    s.collision.at,
+			direction: reflectRay(wo.Muls(-1), normal),
+			depth:     s.incoming.depth + 1,
+			radiance:  radiance,
+			rand:      rand,
+			pixelX:    s.incoming.pixelX,
+			pixelY:    s.incoming.pixelY,
+			rayType:   RayTypeReflected,
+			time:      s.incoming.time,
+		}
+		return resolution{scattered: []ray{newRay}}
+	}
+
+	cosThetaT := math.Abs(normal.Dot(wiT))
+	throughput := weightT.Muls(cosThetaT / ((1 - reflectProb) * pdfT))
+	newRay := ray{
+		origin:    s.collision.at,
+		direction: wiT,
+		depth:     s.incoming.depth + 1,
+		radiance:  radiance.Mul(Spectrum(throughput)),
+		rand:      rand,
+		pixelX:    s.incoming.pixelX,
+		pixelY:    s.incoming.pixelY,
+		rayType:   RayTypeRefracted,
+		time:      s.incoming.time,
+	}
+	return resolution{scattered: []ray{newRay}}
+}
+
+// beerLambertTransmittance returns the per-channel fraction of light that
+// survives traveling distance through a medium with absorption
+// coefficient sigmaA: exp(-sigmaA*distance), the Beer-Lambert law.
+func beerLambertTransmittance(sigmaA r3.Vec, distance float64) Spectrum {
+	return Spectrum{
+		X: math.Exp(-sigmaA.X * distance),
+		Y: math.Exp(-sigmaA.Y * distance),
+		Z: math.Exp(-sigmaA.Z * distance),
+	}
+}
+
+// ComputeDirectLighting always returns zero: Glass's BSDF is made
+// entirely of delta lobes with zero value everywhere next-event
+// estimation could sample. See SpecularMaterial.
+func (m Glass) ComputeDirectLighting(ctx context.Context, s surfaceInteraction, scene *Scene) Spectrum {
+	return Spectrum{}
+}
+
+// Specular reports true: Glass's BSDF is entirely delta lobes. See
+// SpecularMaterial.
+func (m Glass) Specular() bool {
+	return true
+}
+
+func init() {
+	RegisterInterfaceType(Glass{})
+}
@@ -0,0 +1,81 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
+)
+
+// TestBeerLambertTransmittanceDecaysWithDistance verifies
+// beerLambertTransmittance returns 1 (no attenuation) at distance 0 and a
+// strictly smaller value at a longer distance, per channel.
+func TestBeerLambertTransmittanceDecaysWithDistance(t *testing.T) {
+	sigmaA := r3.Vec{X: 1, Y: 0.5, Z: 0}
+	zero := beerLambertTransmittance(sigmaA, 0)
+	if zero.X != 1 || zero.Y != 1 || zero.Z != 1 {
+		t.Errorf("beerLambertTransmittance(%v, 0) = %v, want {1,1,1}", sigmaA, zero)
+	}
+	far := beerLambertTransmittance(sigmaA, 10)
+	if far.X >= zero.X || far.Y >= zero.Y {
+		t.Errorf("beerLambertTransmittance(%v, 10) = %v, want smaller than at distance 0", sigmaA, far)
+	}
+	if far.Z != 1 {
+		t.Errorf("beerLambertTransmittance with zero absorption in Z = %v, want unattenuated 1", far.Z)
+	}
+}
+
+// TestGlassResolveAttenuatesRayExitingMedium verifies that a ray exiting
+// Glass's interior (the incoming ray's origin stands in for where it
+// entered) is dimmed by Beer-Lambert absorption over the distance
+// traveled, while a ray first entering the medium from outside is not.
+func TestGlassResolveAttenuatesRayExitingMedium(t *testing.T) {
+	m := Glass{RefractiveIndexInterior: 1.5, RefractiveIndexExterior: 1.0, Absorption: r3.Vec{X: 1, Y: 1, Z: 1}}
+
+	// A ray exiting the medium: wo (pointing back along incoming)
+	// opposes the outward normal, so outside == wo.Dot(n) > 0 is false.
+	exiting := surfaceInteraction{
+		incoming: ray{
+			origin:    r3.Point{Z: -5},
+			direction: r3.Vec{X: 0, Y: 0, Z: 1},
+			radiance:  Spectrum{X: 1, Y: 1, Z: 1},
+			rand:      NewRand(1),
+		},
+		collision: collision{at: r3.Point{Z: 0}, normal: r3.Vec{X: 0, Y: 0, Z: -1}},
+		outgoing:  r3.Vec{X: 0, Y: 0, Z: 1},
+	}
+	res := m.Resolve(context.Background(), exiting)
+	for _, r := range res.scattered {
+		if r.radiance.X >= 1 || r.radiance.Y >= 1 || r.radiance.Z >= 1 {
+			t.Errorf("scattered radiance = %v, want attenuated below 1 after a distance-5 absorbing segment", r.radiance)
+		}
+	}
+
+	// A ray entering the medium from outside: outside == true, so the
+	// exit-only attenuation this test targets must not apply. Comparing
+	// against the same scene with Absorption zeroed out -- using a fresh
+	// but identically seeded Rand so both take the same reflect/refract
+	// branch -- isolates that effect from Resolve's other radiance math.
+	entering := func(absorption r3.Vec) ray {
+		mm := m
+		mm.Absorption = absorption
+		si := surfaceInteraction{
+			incoming: ray{
+				origin:    r3.Point{Z: -5},
+				direction: r3.Vec{X: 0, Y: 0, Z: 1},
+				radiance:  Spectrum{X: 1, Y: 1, Z: 1},
+				rand:      NewRand(1),
+			},
+			collision: collision{at: r3.Point{Z: 0}, normal: r3.Vec{X: 0, Y: 0, Z: -1}},
+			outgoing:  r3.Vec{X: 0, Y: 0, Z: -1},
+		}
+		return mm.Resolve(context.Background(), si).scattered[0]
+	}
+	withAbsorption := entering(r3.Vec{X: 1, Y: 1, Z: 1})
+	without := entering(r3.Vec{})
+	if math.Abs(withAbsorption.radiance.X-without.radiance.X) > 1e-9 {
+		t.Errorf("radiance entering the medium = %v with Absorption set, want unattenuated %v", withAbsorption.radiance, without.radiance)
+	}
+}
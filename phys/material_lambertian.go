@@ -4,7 +4,12 @@ package phys
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"math"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r2"
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
 )
 
 type Lambertian struct {
@@ -19,8 +24,8 @@ func (m Lambertian) ComputeDirectLighting(ctx context.Context, s surfaceInteract
 	p := s.collision.at
 	n := s.collision.normal.Unit()
 	directIllumination := Spectrum{}
-	albedo := m.Texture.At(s.collision.uv.X, s.collision.uv.Y)
-	for _, light := range scene.Light {
+	lights, lightWeight := sampledLights(scene.Light, scene.RenderOptions.DirectLightSamples, s.incoming.rand)
+	for _, light := range lights {
 		dirToLight, distanceToLight, radiantIntensity := light.Sample(p, s.incoming.rand)
 		// Offset the origin slightly to prevent self-intersection.
 		shadowRayOrigin := p.Add(n.Muls(eps))
@@ -30,29 +35,46 @@ func (m Lambertian) ComputeDirectLighting(ctx context.Context, s surfaceInteract
 			depth:     s.incoming.depth + 1,
 			radiance:  Spectrum{1, 1, 1},
 			rand:      s.incoming.rand,
+			rayType:   RayTypeShadow,
+			time:      s.incoming.time,
 		}
-		// Check for occlusion.
-		occluded := false
-		for _, node := range scene.Node {
-			if node.Shape == s.node.Shape {
-				continue // Skip self.
-			}
-			hit, _ := node.Shape.Collide(shadowRay, eps, distanceToLight)
-			if hit {
-				occluded = true
-				break
-			}
-		}
-		if !occluded {
-			nDotL := math.Max(0, n.Dot(dirToLight))
+		if !scene.Occluded(shadowRay, distanceToLight, s.node.Shape) {
 			// Accumulate the contribution.
-			radiance := albedo.Mul(Spectrum(radiantIntensity)).Muls(nDotL)
+			radiance := m.ReflectedRadiance(s.collision.uv, dirToLight, s.outgoing, n).Mul(Spectrum(radiantIntensity)).Muls(lightWeight)
 			directIllumination = directIllumination.Add(radiance)
 		}
 	}
+	directIllumination = directIllumination.Add(probeAmbient(scene, p, n, textureAt(m.Texture, s.collision.uv.X, s.collision.uv.Y, s.collision.uvFootprint)))
+	// IntegratorBDPT samples the same Emitter nodes itself, bounced through
+	// sampleLightSubpath and MIS-weighted by connectToLightVertex; adding
+	// sampleEmitterDirectLighting here too would connect to the same
+	// emitter vertex twice.
+	if scene.RenderOptions.Integrator != IntegratorBDPT {
+		directIllumination = directIllumination.Add(sampleEmitterDirectLighting(scene, s, m))
+	}
 	return Spectrum(directIllumination)
 }
 
+// ReflectedRadiance returns albedo(uv) * max(0, n·wi), Lambertian's
+// reflectance being independent of wo. It satisfies DiffuseReflector, so
+// the BDPT integrator can weight a connection to an arbitrary light vertex
+// the same way ComputeDirectLighting weights a connection to a PointLight.
+func (m Lambertian) ReflectedRadiance(uv r2.Point, wi, wo, n r3.Vec) Spectrum {
+	nDotL := math.Max(0, n.Dot(wi))
+	// No uvFootprint estimate is available for a bare connection vertex
+	// (e.g. a BDPT light-subpath connection); falls back to the base mip.
+	albedo := textureAt(m.Texture, uv.X, uv.Y, r2.Point{})
+	return albedo.Muls(nDotL)
+}
+
+// PDF returns the cosine-weighted hemisphere sampling density Resolve
+// actually draws wi from: max(0, n·wi)/pi. It satisfies BSDFPDF, so
+// connectToVertex's light-vertex MIS weight uses this value directly
+// rather than assuming it.
+func (m Lambertian) PDF(wi, wo, n r3.Vec) float64 {
+	return math.Max(0, n.Dot(wi)) / math.Pi
+}
+
 // Resolve computes the reflection for a Lambertian surface interaction.
 // It generates a new ray direction using cosine-weighted hemisphere sampling
 // to accurately model diffuse reflection.
@@ -61,10 +83,21 @@ func (m Lambertian) Resolve(ctx context.Context, s surfaceInteraction) resolutio
 	p := s.collision.at
 	n := s.collision.normal.Unit() // Ensure the normal is normalized.
 
+	albedo := textureAt(m.Texture, s.collision.uv.X, s.collision.uv.Y, s.collision.uvFootprint)
+
+	if lm := s.node.LightmapCache; lm != nil {
+		// BakeLightmaps already integrated this node's indirect bounces
+		// into an atlas; read that back instead of spawning another
+		// tracePath recursion. emission (not scattered) is how a
+		// resolution injects radiance without tracing further, the same
+		// path Emitter uses for direct self-emission.
+		irradiance := lm.Irradiance(s.collision.uv)
+		return resolution{emission: albedo.Mul(irradiance).Muls(1 / math.Pi)}
+	}
+
 	// Sample a new direction using cosine-weighted hemisphere sampling.
 	scatteredDirection := s.incoming.rand.CosineWeightedHemisphere(n)
 
-	albedo := m.Texture.At(s.collision.uv.X, s.collision.uv.Y)
 	// Create the scattered ray originating from the collision point in the sampled direction
 	newRay := ray{
 		origin:    p,
@@ -74,12 +107,53 @@ func (m Lambertian) Resolve(ctx context.Context, s surfaceInteraction) resolutio
 		rand:      s.incoming.rand,
 		pixelX:    s.incoming.pixelX,
 		pixelY:    s.incoming.pixelY,
+		rayType:   RayTypeGlossy,
+		time:      s.incoming.time,
+		bsdfPdf:   m.PDF(scatteredDirection, s.outgoing, n),
 	}
 
 	// Return the resolution containing the indirect scattered ray.
 	return resolution{scattered: []ray{newRay}}
 }
 
+// Implement custom JSON marshalling for Lambertian
+func (m Lambertian) MarshalJSON() ([]byte, error) {
+	type LambertianData struct {
+		Type    string          `json:"Type"`
+		Texture json.RawMessage `json:"Texture"`
+	}
+	textureData, err := marshalInterface(m.Texture)
+	if err != nil {
+		return nil, err
+	}
+	data := LambertianData{
+		Type:    "Lambertian",
+		Texture: textureData,
+	}
+	return json.Marshal(data)
+}
+
+// Implement custom JSON unmarshalling for Lambertian
+func (m *Lambertian) UnmarshalJSON(data []byte) error {
+	type LambertianData struct {
+		Type    string          `json:"Type"`
+		Texture json.RawMessage `json:"Texture"`
+	}
+	var temp LambertianData
+	if err := json.Unmarshal(data, &temp); err != nil {
+		return err
+	}
+	if temp.Type != "Lambertian" {
+		return fmt.Errorf("invalid type: expected Lambertian, got %s", temp.Type)
+	}
+	texture, err := unmarshalInterface(temp.Texture)
+	if err != nil {
+		return err
+	}
+	m.Texture = texture.(Texture)
+	return nil
+}
+
 func init() {
 	RegisterInterfaceType(Lambertian{})
 }
@@ -25,26 +25,42 @@ func (m Metal) Validate() error {
 	return nil
 }
 
+// Resolve importance-samples MicrofacetBRDF's GGX-style visible-normal
+// distribution (Fuzz doubling as Roughness) to pick the scattered
+// direction, replacing the fuzz-perturbed mirror reflection this method
+// used before: a reflected ray jittered by a uniform sphere sample of
+// radius Fuzz has no defined PDF, so its contribution couldn't be
+// correctly weighted against a future light-sampling strategy. Sampling
+// and weighting by brdf.Evaluate(wo,wi,n)*nDotWi/pdf, the same
+// importance-sampling pattern RoughPlastic.Resolve and PBR.Resolve use for
+// their specular lobe, keeps the estimator unbiased.
 func (m Metal) Resolve(ctx context.Context, s surfaceInteraction) resolution {
-	// TODO: scott should this actually return a resolution with multiple rays?
-	reflected := reflectRay(s.incoming.direction.Unit(), s.collision.normal)
-	scatteredDirection := reflected.Add(s.incoming.rand.InUnitSphere().Muls(m.Fuzz))
-	if scatteredDirection.Dot(s.collision.normal) > 0 {
-		newRay := ray{
-			origin:    s.collision.at,
-			direction: scatteredDirection.Unit(),
-			depth:     s.incoming.depth + 1,
-			radiance:  s.incoming.radiance.Mul(Spectrum(m.Albedo)),
-			rand:      s.incoming.rand,
-			pixelX:    s.incoming.pixelX,
-			pixelY:    s.incoming.pixelY,
-		}
-		return resolution{scattered: []ray{newRay}}
+	n := s.collision.normal.Unit()
+	wo := s.incoming.direction.Muls(-1).Unit()
+	if n.Dot(wo) <= 0 {
+		return resolution{}
+	}
+
+	brdf := MicrofacetBRDF{Roughness: m.Fuzz, F0: m.Albedo}
+	wi, pdf := brdf.Sample(wo, n, s.incoming.rand)
+	if pdf <= 0 {
+		return resolution{}
+	}
+	nDotWi := math.Max(0, n.Dot(wi))
+	weight := brdf.Evaluate(wo, wi, n).Muls(nDotWi / pdf)
+	newRay := ray{
+		origin:    s.collision.at,
+		direction: wi,
+		depth:     s.incoming.depth + 1,
+		radiance:  s.incoming.radiance.Mul(Spectrum(weight)),
+		rand:      s.incoming.rand,
+		pixelX:    s.incoming.pixelX,
+		pixelY:    s.incoming.pixelY,
+		rayType:   RayTypeGlossy,
+		time:      s.incoming.time,
+		bsdfPdf:   pdf,
 	}
-	// Absorb the ray (no outgoing rays).
-	// TODO: scott should this ever be reached?
-	// fmt.Println("absorbing ray")
-	return resolution{emission: Spectrum{}}
+	return resolution{scattered: []ray{newRay}}
 }
 
 // func (m Metal) ComputeDirectLighting(s surfaceInteraction, scene *Scene) r3.Vec {
@@ -65,7 +81,8 @@ func (m Metal) ComputeDirectLighting(ctx context.Context, s surfaceInteraction,
 		F0:        m.Albedo, // Base reflectivity
 	}
 
-	for _, light := range scene.Light {
+	lights, lightWeight := sampledLights(scene.Light, scene.RenderOptions.DirectLightSamples, s.incoming.rand)
+	for _, light := range lights {
 		dirToLight, distanceToLight, radiantIntensity := light.Sample(p, s.incoming.rand)
 		wi := dirToLight.Unit()
 
@@ -77,6 +94,8 @@ func (m Metal) ComputeDirectLighting(ctx context.Context, s surfaceInteraction,
 			depth:     s.incoming.depth + 1,
 			radiance:  Spectrum{1, 1, 1},
 			rand:      s.incoming.rand,
+			rayType:   RayTypeShadow,
+			time:      s.incoming.time,
 		}
 
 		// Check for occlusion.
@@ -100,7 +119,7 @@ func (m Metal) ComputeDirectLighting(ctx context.Context, s surfaceInteraction,
 			cosTheta := math.Max(0, n.Dot(wi))
 
 			// Accumulate the contribution
-			contribution := radiantIntensity.Mul(brdfValue).Muls(cosTheta)
+			contribution := radiantIntensity.Mul(brdfValue).Muls(cosTheta * lightWeight)
 			directIllumination = directIllumination.Add(Spectrum(contribution))
 		}
 	}
@@ -0,0 +1,66 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+
+package phys
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
+)
+
+// Mirror is a perfect (delta) specular reflector: a true mirror, the
+// roughness=0 limit Metal's microfacet model can only approach but never
+// reach exactly. Unlike Metal, it has no Fuzz; every ray reflects along
+// exactly one direction.
+type Mirror struct {
+	F0 r3.Vec // Base reflectivity at normal incidence, as used by Metal and MicrofacetBRDF.
+}
+
+func (m Mirror) Validate() error {
+	if m.F0.X < 0 || m.F0.Y < 0 || m.F0.Z < 0 {
+		return fmt.Errorf("invalid Mirror F0 must be positive: %v", m.F0)
+	}
+	return nil
+}
+
+func (m Mirror) Resolve(ctx context.Context, s surfaceInteraction) resolution {
+	brdf := SpecularReflectionBRDF{F0: m.F0}
+	wi, weight, pdf := brdf.Sample(s.outgoing, s.collision.normal)
+	if pdf <= 0 {
+		// wo below the surface: absorb the ray.
+		return resolution{}
+	}
+	cosTheta := math.Abs(s.collision.normal.Unit().Dot(wi))
+	throughput := weight.Muls(cosTheta / pdf)
+	newRay := ray{
+		origin:    s.collision.at,
+		direction: wi,
+		depth:     s.incoming.depth + 1,
+		radiance:  s.incoming.radiance.Mul(Spectrum(throughput)),
+		rand:      s.incoming.rand,
+		pixelX:    s.incoming.pixelX,
+		pixelY:    s.incoming.pixelY,
+		rayType:   RayTypeReflected,
+		time:      s.incoming.time,
+	}
+	return resolution{scattered: []ray{newRay}}
+}
+
+// ComputeDirectLighting always returns zero: Mirror's BSDF is a delta
+// lobe with zero value everywhere next-event estimation could sample, so
+// it contributes no direct lighting. See SpecularMaterial.
+func (m Mirror) ComputeDirectLighting(ctx context.Context, s surfaceInteraction, scene *Scene) Spectrum {
+	return Spectrum{}
+}
+
+// Specular reports true: Mirror's BSDF is entirely a delta lobe. See
+// SpecularMaterial.
+func (m Mirror) Specular() bool {
+	return true
+}
+
+func init() {
+	RegisterInterfaceType(Mirror{})
+}
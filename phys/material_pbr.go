@@ -0,0 +1,261 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+
+package phys
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
+)
+
+// PBR is a metallic-roughness Cook-Torrance material: the analytic
+// microfacet BRDF (GGX distribution, Smith geometry, Schlick Fresnel) used
+// by most real-time and glTF/Blender-style PBR pipelines. Metallic
+// interpolates the material between a dielectric with a fixed F0 of 0.04
+// and a diffuse BaseColor term, and a colored metal with F0 = BaseColor
+// and no diffuse term.
+type PBR struct {
+	BaseColor       Texture        // Albedo for the diffuse term and, at high Metallic, the specular tint.
+	Metallic        float64        // 0 (dielectric) to 1 (metal).
+	Roughness       float64        // Perceptual roughness in [0, 1]; Alpha = Roughness^2 feeds the GGX distribution.
+	NormalMap       *TextureNormal // Optional tangent-space normal map; nil leaves the geometric normal unperturbed.
+	EmissiveTexture Texture        // Optional emission, added on top of reflected light; nil means the material doesn't emit.
+}
+
+func (m PBR) Validate() error {
+	if m.BaseColor == nil {
+		return fmt.Errorf("invalid PBR: BaseColor must not be nil")
+	}
+	if err := m.BaseColor.Validate(); err != nil {
+		return fmt.Errorf("invalid PBR BaseColor: %v", err)
+	}
+	if m.Metallic < 0 || m.Metallic > 1 {
+		return fmt.Errorf("invalid PBR Metallic must be in [0, 1]: %v", m.Metallic)
+	}
+	if m.Roughness < 0 || m.Roughness > 1 {
+		return fmt.Errorf("invalid PBR Roughness must be in [0, 1]: %v", m.Roughness)
+	}
+	if m.NormalMap != nil {
+		if err := m.NormalMap.Validate(); err != nil {
+			return fmt.Errorf("invalid PBR NormalMap: %v", err)
+		}
+	}
+	if m.EmissiveTexture != nil {
+		if err := m.EmissiveTexture.Validate(); err != nil {
+			return fmt.Errorf("invalid PBR EmissiveTexture: %v", err)
+		}
+	}
+	return nil
+}
+
+// shadingNormal returns s's geometric normal perturbed by m.NormalMap, if
+// set, the same way Sphere.NormalMap perturbs a shape's own normal.
+func (m PBR) shadingNormal(s surfaceInteraction) r3.Vec {
+	n := s.collision.normal.Unit()
+	if m.NormalMap == nil {
+		return n
+	}
+	return m.NormalMap.Perturb(s.collision.TangentBasis(), s.collision.uv.X, s.collision.uv.Y)
+}
+
+// brdf returns the GGX MicrofacetBRDF for shading point s, with F0 mixed
+// between a fixed dielectric reflectance and BaseColor by m.Metallic.
+func (m PBR) brdf(s surfaceInteraction) MicrofacetBRDF {
+	albedo := r3.Vec(textureAt(m.BaseColor, s.collision.uv.X, s.collision.uv.Y, s.collision.uvFootprint))
+	dielectricF0 := r3.Vec{X: 0.04, Y: 0.04, Z: 0.04}
+	f0 := dielectricF0.Muls(1 - m.Metallic).Add(albedo.Muls(m.Metallic))
+	alpha := math.Max(m.Roughness*m.Roughness, eps)
+	return MicrofacetBRDF{
+		Roughness:    m.Roughness,
+		F0:           f0,
+		Distribution: GGXDistribution{Alpha: alpha},
+	}
+}
+
+func (m PBR) ComputeDirectLighting(ctx context.Context, s surfaceInteraction, scene *Scene) Spectrum {
+	p := s.collision.at
+	n := m.shadingNormal(s)
+	wo := s.incoming.direction.Muls(-1).Unit()
+	albedo := textureAt(m.BaseColor, s.collision.uv.X, s.collision.uv.Y, s.collision.uvFootprint)
+	brdf := m.brdf(s)
+	directIllumination := Spectrum{}
+	lights, lightWeight := sampledLights(scene.Light, scene.RenderOptions.DirectLightSamples, s.incoming.rand)
+	for _, light := range lights {
+		dirToLight, distanceToLight, radiantIntensity := light.Sample(p, s.incoming.rand)
+		shadowRayOrigin := p.Add(n.Muls(eps))
+		shadowRay := ray{
+			origin:    shadowRayOrigin,
+			direction: dirToLight,
+			depth:     s.incoming.depth + 1,
+			radiance:  Spectrum{1, 1, 1},
+			rand:      s.incoming.rand,
+			rayType:   RayTypeShadow,
+			time:      s.incoming.time,
+		}
+		occluded := false
+		for _, node := range scene.Node {
+			if node.Shape == s.node.Shape {
+				continue // Skip self.
+			}
+			hit, _ := node.Shape.Collide(shadowRay, eps, distanceToLight)
+			if hit {
+				occluded = true
+				break
+			}
+		}
+		if occluded {
+			continue
+		}
+		nDotL := math.Max(0, n.Dot(dirToLight))
+		if nDotL <= 0 {
+			continue
+		}
+		h := wo.Add(dirToLight).Unit()
+		fresnel := brdf.F(wo, h)
+		specular := Spectrum(brdf.Evaluate(wo, dirToLight, n)).Mul(Spectrum(radiantIntensity)).Muls(nDotL * lightWeight)
+		diffuseWeight := r3.Vec{X: 1, Y: 1, Z: 1}.Sub(fresnel).Muls(1 - m.Metallic)
+		diffuse := albedo.Mul(Spectrum(diffuseWeight)).Mul(Spectrum(radiantIntensity)).Muls(nDotL * lightWeight / math.Pi)
+		directIllumination = directIllumination.Add(diffuse).Add(specular)
+	}
+	directIllumination = directIllumination.Add(probeAmbient(scene, p, n, albedo).Muls(1 - m.Metallic))
+	return directIllumination
+}
+
+// Resolve stochastically selects between the GGX specular lobe and a
+// cosine-weighted diffuse bounce for the single scattered ray, weighted by
+// the BRDF's average Fresnel reflectance at the outgoing angle, the same
+// two-lobe selection RoughPlastic.Resolve uses. Emission, if
+// EmissiveTexture is set, is added on top regardless of which lobe fired.
+func (m PBR) Resolve(ctx context.Context, s surfaceInteraction) resolution {
+	n := m.shadingNormal(s)
+	wo := s.incoming.direction.Muls(-1).Unit()
+	if n.Dot(wo) <= 0 {
+		return m.emit(s, resolution{})
+	}
+
+	brdf := m.brdf(s)
+	fresnel := brdf.F(wo, n)
+	pSpecular := clamp((fresnel.X+fresnel.Y+fresnel.Z)/3, 0.1, 0.9)
+
+	if s.incoming.rand.Float64() < pSpecular {
+		wi, pdf := brdf.Sample(wo, n, s.incoming.rand)
+		if pdf <= 0 {
+			return m.emit(s, resolution{})
+		}
+		nDotWi := math.Max(0, n.Dot(wi))
+		value := brdf.Evaluate(wo, wi, n)
+		weight := value.Muls(nDotWi / (pdf * pSpecular))
+		diffusePdf := nDotWi / math.Pi
+		newRay := ray{
+			origin:    s.collision.at,
+			direction: wi,
+			depth:     s.incoming.depth + 1,
+			radiance:  s.incoming.radiance.Mul(Spectrum(weight)),
+			rand:      s.incoming.rand,
+			pixelX:    s.incoming.pixelX,
+			pixelY:    s.incoming.pixelY,
+			rayType:   RayTypeGlossy,
+			time:      s.incoming.time,
+			bsdfPdf:   pSpecular*pdf + (1-pSpecular)*diffusePdf,
+		}
+		return m.emit(s, resolution{scattered: []ray{newRay}})
+	}
+
+	wi := s.incoming.rand.CosineWeightedHemisphere(n)
+	albedo := textureAt(m.BaseColor, s.collision.uv.X, s.collision.uv.Y, s.collision.uvFootprint)
+	diffuseWeight := r3.Vec{X: 1, Y: 1, Z: 1}.Sub(fresnel).Muls(1 - m.Metallic)
+	nDotWi := math.Max(0, n.Dot(wi))
+	diffusePdf := nDotWi / math.Pi
+	specularPdf := brdf.PDF(wo, wi, n)
+	// The cosine-weighted sampling pdf (cos(theta)/pi) cancels the
+	// Lambertian BRDF's own 1/pi and the n.wi cosine term, leaving albedo.
+	newRay := ray{
+		origin:    s.collision.at,
+		direction: wi,
+		depth:     s.incoming.depth + 1,
+		radiance:  s.incoming.radiance.Mul(albedo).Mul(Spectrum(diffuseWeight)).Divs(1 - pSpecular),
+		rand:      s.incoming.rand,
+		pixelX:    s.incoming.pixelX,
+		pixelY:    s.incoming.pixelY,
+		rayType:   RayTypeGlossy,
+		time:      s.incoming.time,
+		bsdfPdf:   pSpecular*specularPdf + (1-pSpecular)*diffusePdf,
+	}
+	return m.emit(s, resolution{scattered: []ray{newRay}})
+}
+
+// emit adds m.EmissiveTexture's contribution, if set, to r.emission,
+// matching how Emitter.Resolve scales its texture by the incoming path
+// throughput.
+func (m PBR) emit(s surfaceInteraction, r resolution) resolution {
+	if m.EmissiveTexture == nil {
+		return r
+	}
+	e := textureAt(m.EmissiveTexture, s.collision.uv.X, s.collision.uv.Y, s.collision.uvFootprint)
+	r.emission = r.emission.Add(e.Mul(s.incoming.radiance))
+	return r
+}
+
+type pbrData struct {
+	Type            string          `json:"Type"`
+	BaseColor       json.RawMessage `json:"BaseColor"`
+	Metallic        float64         `json:"Metallic"`
+	Roughness       float64         `json:"Roughness"`
+	NormalMap       *TextureNormal  `json:"NormalMap,omitempty"`
+	EmissiveTexture json.RawMessage `json:"EmissiveTexture,omitempty"`
+}
+
+func (m PBR) MarshalJSON() ([]byte, error) {
+	baseColorData, err := marshalInterface(m.BaseColor)
+	if err != nil {
+		return nil, err
+	}
+	var emissiveData json.RawMessage
+	if m.EmissiveTexture != nil {
+		emissiveData, err = marshalInterface(m.EmissiveTexture)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(pbrData{
+		Type:            "PBR",
+		BaseColor:       baseColorData,
+		Metallic:        m.Metallic,
+		Roughness:       m.Roughness,
+		NormalMap:       m.NormalMap,
+		EmissiveTexture: emissiveData,
+	})
+}
+
+func (m *PBR) UnmarshalJSON(data []byte) error {
+	var temp pbrData
+	if err := json.Unmarshal(data, &temp); err != nil {
+		return err
+	}
+	if temp.Type != "PBR" {
+		return fmt.Errorf("invalid type: expected PBR, got %s", temp.Type)
+	}
+	baseColor, err := unmarshalInterface(temp.BaseColor)
+	if err != nil {
+		return err
+	}
+	m.BaseColor = baseColor.(Texture)
+	m.Metallic = temp.Metallic
+	m.Roughness = temp.Roughness
+	m.NormalMap = temp.NormalMap
+	if len(temp.EmissiveTexture) > 0 {
+		emissive, err := unmarshalInterface(temp.EmissiveTexture)
+		if err != nil {
+			return err
+		}
+		m.EmissiveTexture = emissive.(Texture)
+	}
+	return nil
+}
+
+func init() {
+	RegisterInterfaceType(PBR{})
+}
@@ -0,0 +1,261 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+
+package phys
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
+)
+
+// RoughPlastic is a two-lobe material layering a Lambertian diffuse
+// substrate under a Cook-Torrance microfacet specular coat, modeling
+// surfaces like painted or plastic objects that are neither purely matte
+// nor purely metallic. The specular coat's Fresnel term determines how
+// much light reaches the diffuse substrate versus reflecting directly, so
+// the diffuse response dims toward grazing angles where the coat is most
+// reflective.
+type RoughPlastic struct {
+	Texture   Texture        // Diffuse substrate albedo.
+	Specular  MicrofacetBRDF // Specular coat; Specular.F0 sets the coat's normal-incidence reflectivity.
+	NormalMap *TextureNormal // Optional tangent-space normal map; nil leaves the geometric normal unperturbed.
+}
+
+func (m RoughPlastic) Validate() error {
+	if err := m.Texture.Validate(); err != nil {
+		return err
+	}
+	if m.NormalMap != nil {
+		if err := m.NormalMap.Validate(); err != nil {
+			return fmt.Errorf("invalid RoughPlastic NormalMap: %v", err)
+		}
+	}
+	return nil
+}
+
+// shadingNormal returns s's geometric normal perturbed by m.NormalMap, if
+// set, the same way PBR.shadingNormal perturbs a shape's own normal.
+func (m RoughPlastic) shadingNormal(s surfaceInteraction) r3.Vec {
+	n := s.collision.normal.Unit()
+	if m.NormalMap == nil {
+		return n
+	}
+	return m.NormalMap.Perturb(s.collision.TangentBasis(), s.collision.uv.X, s.collision.uv.Y)
+}
+
+// roughPlasticOccluded reports whether a shadow ray from origin toward dir,
+// up to maxDist, is blocked by any node other than s.node, the same
+// self-exclusion dielectricOccluded implements for Dielectric.
+func roughPlasticOccluded(s surfaceInteraction, scene *Scene, origin r3.Point, dir r3.Vec, maxDist Distance) bool {
+	shadowRay := ray{
+		origin:    origin,
+		direction: dir,
+		depth:     s.incoming.depth + 1,
+		radiance:  Spectrum{1, 1, 1},
+		rand:      s.incoming.rand,
+		rayType:   RayTypeShadow,
+		time:      s.incoming.time,
+	}
+	for _, node := range scene.Node {
+		if node.Shape == s.node.Shape {
+			continue // Skip self.
+		}
+		if hit, _ := node.Shape.Collide(shadowRay, eps, maxDist); hit {
+			return true
+		}
+	}
+	return false
+}
+
+// bsdfPdf returns the same pSpecular-weighted mixture density Resolve
+// stamps onto its scattered ray's bsdfPdf: the probability of dir under
+// the cosine-weighted diffuse lobe and m.Specular's lobe, blended by the
+// coat's average Fresnel reflectance.
+func (m RoughPlastic) bsdfPdf(wo, dir, n r3.Vec) float64 {
+	fresnel := m.Specular.F(wo, n)
+	pSpecular := clamp((fresnel.X+fresnel.Y+fresnel.Z)/3, 0.1, 0.9)
+	diffusePdf := math.Max(0, n.Dot(dir)) / math.Pi
+	return pSpecular*m.Specular.PDF(wo, dir, n) + (1-pSpecular)*diffusePdf
+}
+
+// ComputeDirectLighting combines a light-sampled estimate and a
+// BSDF-sampled estimate via powerHeuristic, the same two-strategy MIS
+// Dielectric.ComputeDirectLighting uses: light sampling alone under-samples
+// the specular coat's narrow lobe for small, bright lights, and BSDF
+// sampling alone under-samples small or distant lights the lobe rarely
+// points at by chance. Combining both keeps whichever strategy had higher
+// density for a given direction dominant, without double-counting.
+func (m RoughPlastic) ComputeDirectLighting(ctx context.Context, s surfaceInteraction, scene *Scene) Spectrum {
+	p := s.collision.at
+	n := m.shadingNormal(s)
+	wo := s.incoming.direction.Muls(-1).Unit()
+	albedo := textureAt(m.Texture, s.collision.uv.X, s.collision.uv.Y, s.collision.uvFootprint)
+	directIllumination := Spectrum{}
+	shadowOrigin := p.Add(n.Muls(eps))
+
+	// Light-sampling strategy.
+	lights, lightWeight := sampledLights(scene.Light, scene.RenderOptions.DirectLightSamples, s.incoming.rand)
+	for _, light := range lights {
+		dirToLight, distanceToLight, radiantIntensity := light.Sample(p, s.incoming.rand)
+		nDotL := math.Max(0, n.Dot(dirToLight))
+		if nDotL <= 0 || roughPlasticOccluded(s, scene, shadowOrigin, dirToLight, distanceToLight) {
+			continue
+		}
+
+		weight := 1.0
+		if pdfLight := light.Pdf(p, dirToLight); pdfLight > 0 {
+			weight = powerHeuristic(pdfLight, m.bsdfPdf(wo, dirToLight, n))
+		}
+
+		h := wo.Add(dirToLight).Unit()
+		fresnel := m.Specular.F(wo, h)
+		specular := Spectrum(m.Specular.Evaluate(wo, dirToLight, n)).Mul(Spectrum(radiantIntensity)).Muls(nDotL * weight * lightWeight)
+		diffuseWeight := r3.Vec{X: 1, Y: 1, Z: 1}.Sub(fresnel)
+		diffuse := albedo.Mul(Spectrum(diffuseWeight)).Mul(Spectrum(radiantIntensity)).Muls(nDotL * weight * lightWeight)
+		directIllumination = directIllumination.Add(diffuse).Add(specular)
+	}
+
+	// BSDF-sampling strategy: draw a direction from the same
+	// Fresnel-blended specular/diffuse mixture Resolve samples, and see
+	// whether it actually connects to one of the scene's lights.
+	fresnelO := m.Specular.F(wo, n)
+	pSpecular := clamp((fresnelO.X+fresnelO.Y+fresnelO.Z)/3, 0.1, 0.9)
+	var wi r3.Vec
+	if s.incoming.rand.Float64() < pSpecular {
+		var pdf float64
+		wi, pdf = m.Specular.Sample(wo, n, s.incoming.rand)
+		if pdf <= 0 {
+			return directIllumination
+		}
+	} else {
+		wi = s.incoming.rand.CosineWeightedHemisphere(n)
+	}
+	nDotWi := math.Max(0, n.Dot(wi))
+	pdfBSDF := m.bsdfPdf(wo, wi, n)
+	if nDotWi <= 0 || pdfBSDF <= 0 {
+		return directIllumination
+	}
+
+	h := wo.Add(wi).Unit()
+	fresnel := m.Specular.F(wo, h)
+	specularBRDF := m.Specular.Evaluate(wo, wi, n)
+	diffuseBRDF := albedo.Mul(Spectrum(r3.Vec{X: 1, Y: 1, Z: 1}.Sub(fresnel))).Muls(1 / math.Pi)
+	brdfValue := diffuseBRDF.Add(Spectrum(specularBRDF))
+
+	for _, light := range scene.Light {
+		radiance, distanceToLight := light.EmittedRadiance(p, wi)
+		if radiance.IsZero() || roughPlasticOccluded(s, scene, shadowOrigin, wi, distanceToLight) {
+			continue
+		}
+		weight := powerHeuristic(pdfBSDF, light.Pdf(p, wi))
+		contribution := Spectrum(radiance).Mul(brdfValue).Muls(nDotWi * weight / pdfBSDF)
+		directIllumination = directIllumination.Add(contribution)
+	}
+
+	return directIllumination
+}
+
+// Resolve stochastically selects between the specular coat and the
+// diffuse substrate for the single scattered ray, weighted by the coat's
+// average Fresnel reflectance at the outgoing angle. This keeps the
+// estimator unbiased: over many samples the two lobes are visited in
+// proportion to their energy.
+func (m RoughPlastic) Resolve(ctx context.Context, s surfaceInteraction) resolution {
+	p := s.collision.at
+	n := m.shadingNormal(s)
+	wo := s.incoming.direction.Muls(-1).Unit()
+
+	fresnel := m.Specular.F(wo, n)
+	pSpecular := clamp((fresnel.X+fresnel.Y+fresnel.Z)/3, 0.1, 0.9)
+
+	if s.incoming.rand.Float64() < pSpecular {
+		wi, pdf := m.Specular.Sample(wo, n, s.incoming.rand)
+		if pdf <= 0 {
+			return resolution{}
+		}
+		nDotWi := math.Max(0, n.Dot(wi))
+		brdf := m.Specular.Evaluate(wo, wi, n)
+		weight := brdf.Muls(nDotWi / (pdf * pSpecular))
+		diffusePdf := nDotWi / math.Pi
+		newRay := ray{
+			origin:    p,
+			direction: wi,
+			depth:     s.incoming.depth + 1,
+			radiance:  s.incoming.radiance.Mul(Spectrum(weight)),
+			rand:      s.incoming.rand,
+			pixelX:    s.incoming.pixelX,
+			pixelY:    s.incoming.pixelY,
+			rayType:   RayTypeGlossy,
+			time:      s.incoming.time,
+			bsdfPdf:   pSpecular*pdf + (1-pSpecular)*diffusePdf,
+		}
+		return resolution{scattered: []ray{newRay}}
+	}
+
+	scatteredDirection := s.incoming.rand.CosineWeightedHemisphere(n)
+	albedo := textureAt(m.Texture, s.collision.uv.X, s.collision.uv.Y, s.collision.uvFootprint)
+	diffuseWeight := r3.Vec{X: 1, Y: 1, Z: 1}.Sub(fresnel)
+	nDotWi := math.Max(0, n.Dot(scatteredDirection))
+	diffusePdf := nDotWi / math.Pi
+	specularPdf := m.Specular.PDF(wo, scatteredDirection, n)
+	newRay := ray{
+		origin:    p,
+		direction: scatteredDirection,
+		depth:     s.incoming.depth + 1,
+		radiance:  s.incoming.radiance.Mul(albedo).Mul(Spectrum(diffuseWeight)).Divs(1 - pSpecular),
+		rand:      s.incoming.rand,
+		pixelX:    s.incoming.pixelX,
+		pixelY:    s.incoming.pixelY,
+		rayType:   RayTypeGlossy,
+		time:      s.incoming.time,
+		bsdfPdf:   pSpecular*specularPdf + (1-pSpecular)*diffusePdf,
+	}
+	return resolution{scattered: []ray{newRay}}
+}
+
+type roughPlasticData struct {
+	Type      string          `json:"Type"`
+	Texture   json.RawMessage `json:"Texture"`
+	Roughness float64         `json:"Roughness"`
+	F0        r3.Vec          `json:"F0"`
+	NormalMap *TextureNormal  `json:"NormalMap,omitempty"`
+}
+
+func (m RoughPlastic) MarshalJSON() ([]byte, error) {
+	textureData, err := marshalInterface(m.Texture)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(roughPlasticData{
+		Type:      "RoughPlastic",
+		Texture:   textureData,
+		Roughness: m.Specular.Roughness,
+		F0:        m.Specular.F0,
+		NormalMap: m.NormalMap,
+	})
+}
+
+func (m *RoughPlastic) UnmarshalJSON(data []byte) error {
+	var temp roughPlasticData
+	if err := json.Unmarshal(data, &temp); err != nil {
+		return err
+	}
+	if temp.Type != "RoughPlastic" {
+		return fmt.Errorf("invalid type: expected RoughPlastic, got %s", temp.Type)
+	}
+	texture, err := unmarshalInterface(temp.Texture)
+	if err != nil {
+		return err
+	}
+	m.Texture = texture.(Texture)
+	m.Specular = MicrofacetBRDF{Roughness: temp.Roughness, F0: temp.F0}
+	m.NormalMap = temp.NormalMap
+	return nil
+}
+
+func init() {
+	RegisterInterfaceType(RoughPlastic{})
+}
@@ -0,0 +1,342 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+
+package phys
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
+)
+
+// subsurfaceRdTableSamples is the number of radii tabulated per channel
+// by rdTable, geometrically spaced so the table resolves the profile's
+// fast falloff near r=0 without wasting samples in its long, flat tail.
+const subsurfaceRdTableSamples = 64
+
+// dipoleRd evaluates the classical dipole diffusion approximation for a
+// semi-infinite translucent medium (Jensen, Marschner, Levoy, and
+// Hanrahan, "A Practical Model for Subsurface Light Transport", 2001):
+// the fraction of light entering at the origin that exits at radial
+// distance r, for absorption sigmaA, scattering sigmaS, and the medium's
+// relative index of refraction eta.
+func dipoleRd(r, sigmaA, sigmaS, eta float64) float64 {
+	sigmaTPrime := sigmaA + sigmaS
+	if sigmaTPrime <= 0 {
+		return 0
+	}
+	alphaPrime := sigmaS / sigmaTPrime
+	sigmaTr := math.Sqrt(3 * sigmaA * sigmaTPrime)
+
+	// Fdr approximates the internal diffuse Fresnel reflectance (Egan and
+	// Hilgeman's fit), giving the dipole's negative source depth zv via A.
+	fdr := -1.440/(eta*eta) + 0.710/eta + 0.668 + 0.0636*eta
+	a := (1 + fdr) / (1 - fdr)
+	zr := 1 / sigmaTPrime
+	zv := zr * (1 + 4*a/3)
+
+	dr := math.Sqrt(r*r + zr*zr)
+	dv := math.Sqrt(r*r + zv*zv)
+	real := zr * (sigmaTr*dr + 1) * math.Exp(-sigmaTr*dr) / (dr * dr * dr)
+	virtual := zv * (sigmaTr*dv + 1) * math.Exp(-sigmaTr*dv) / (dv * dv * dv)
+	return alphaPrime / (4 * math.Pi) * (real + virtual)
+}
+
+// rdTable tabulates one channel's dipoleRd profile at
+// subsurfaceRdTableSamples radii, along with the cumulative disk-area
+// probability mass (integral of 2*pi*r*Rd(r)) up to each radius, so
+// sample can invert it by linear search and eval can reconstruct Rd at an
+// arbitrary radius by linear interpolation, without re-evaluating
+// dipoleRd's exp/sqrt calls at every Resolve call.
+type rdTable struct {
+	r   [subsurfaceRdTableSamples]float64
+	rd  [subsurfaceRdTableSamples]float64
+	cdf [subsurfaceRdTableSamples]float64 // Unnormalized; cdf[len-1] is the total mass.
+}
+
+// newRdTable builds the table for one channel's absorption and
+// scattering coefficients. rMax is chosen as a multiple of 1/sigmaTr, the
+// dipole's own characteristic decay length, so the table always spans the
+// radii where Rd carries any appreciable mass.
+func newRdTable(sigmaA, sigmaS, eta float64) rdTable {
+	var t rdTable
+	sigmaTPrime := math.Max(sigmaA+sigmaS, 1e-9)
+	sigmaTr := math.Sqrt(3 * math.Max(sigmaA, 1e-9) * sigmaTPrime)
+	rMax := 16 / sigmaTr
+
+	prevR, prevMass := 0.0, 0.0
+	for i := 0; i < subsurfaceRdTableSamples; i++ {
+		// Geometric spacing concentrates samples where Rd(r) varies
+		// fastest, near r=0.
+		frac := float64(i+1) / float64(subsurfaceRdTableSamples)
+		r := rMax * frac * frac
+		rd := dipoleRd(r, sigmaA, sigmaS, eta)
+		mass := 2 * math.Pi * r * rd
+
+		t.r[i] = r
+		t.rd[i] = rd
+		area := 0.5 * (mass + prevMass) * (r - prevR)
+		if i == 0 {
+			t.cdf[i] = area
+		} else {
+			t.cdf[i] = t.cdf[i-1] + area
+		}
+		prevR, prevMass = r, mass
+	}
+	return t
+}
+
+// sample draws a radius from the table via inverse-transform sampling
+// over its tabulated CDF, returning the radius-measure pdf (the density
+// sample was drawn from, i.e. the probability per unit r, not per unit
+// disk area) alongside it.
+func (t *rdTable) sample(u float64) (r, pdf float64) {
+	total := t.cdf[subsurfaceRdTableSamples-1]
+	if total <= 0 {
+		return 0, 0
+	}
+	target := u * total
+	idx := 0
+	for idx < subsurfaceRdTableSamples-1 && t.cdf[idx] < target {
+		idx++
+	}
+	r = t.r[idx]
+	mass := 2 * math.Pi * r * t.rd[idx]
+	return r, mass / total
+}
+
+// eval reconstructs Rd(r) at an arbitrary radius by linearly
+// interpolating between the two bracketing tabulated samples, returning 0
+// beyond the table's last radius.
+func (t *rdTable) eval(r float64) float64 {
+	if r <= t.r[0] {
+		return t.rd[0]
+	}
+	for i := 1; i < subsurfaceRdTableSamples; i++ {
+		if r <= t.r[i] {
+			frac := (r - t.r[i-1]) / (t.r[i] - t.r[i-1])
+			return t.rd[i-1] + frac*(t.rd[i]-t.rd[i-1])
+		}
+	}
+	return 0
+}
+
+// Subsurface models a translucent material -- skin, wax, marble -- with a
+// separable BSSRDF, following PBRT's KdSubsurfaceMaterial. At each hit,
+// Resolve stochastically chooses between a specular boundary event (the
+// Fresnel term at Eta decides reflect vs. transmit) and a subsurface exit
+// event: light that transmits into the medium is assumed to scatter and
+// re-emerge nearby, its exit radiance given directly by the tabulated
+// dipole diffusion profile Rd(r) rather than by simulating the random
+// walk between entry and exit.
+type Subsurface struct {
+	Kd     Texture  // Diffuse albedo tint applied to the diffusion exit radiance.
+	SigmaA Spectrum // Absorption coefficient, per unit length.
+	SigmaS Spectrum // Scattering coefficient, per unit length.
+	Eta    float64  // Relative index of refraction of the interior.
+}
+
+func (m Subsurface) Validate() error {
+	if m.Kd == nil {
+		return fmt.Errorf("phys: Subsurface.Kd must not be nil")
+	}
+	if m.SigmaA.X < 0 || m.SigmaA.Y < 0 || m.SigmaA.Z < 0 {
+		return fmt.Errorf("invalid Subsurface SigmaA: %v (should be non-negative)", m.SigmaA)
+	}
+	if m.SigmaS.X < 0 || m.SigmaS.Y < 0 || m.SigmaS.Z < 0 {
+		return fmt.Errorf("invalid Subsurface SigmaS: %v (should be non-negative)", m.SigmaS)
+	}
+	if m.Eta < 1 {
+		return fmt.Errorf("invalid Subsurface Eta: %v (should be >= 1)", m.Eta)
+	}
+	return m.Kd.Validate()
+}
+
+// rdTables returns the three per-channel diffusion profiles derived from
+// m.SigmaA and m.SigmaS. Unlike Mesh or Instancer, Subsurface has no
+// constructor to precompute into -- like every other Material in this
+// package, it's a plain, JSON-round-trippable value type -- so the tables
+// are rebuilt from the standard dipole formula on every call instead of
+// cached on the material itself; their 64 closed-form dipoleRd
+// evaluations per channel are a small fraction of the probe ray Resolve
+// traces right after.
+func (m Subsurface) rdTables() [3]rdTable {
+	return [3]rdTable{
+		newRdTable(m.SigmaA.X, m.SigmaS.X, m.Eta),
+		newRdTable(m.SigmaA.Y, m.SigmaS.Y, m.Eta),
+		newRdTable(m.SigmaA.Z, m.SigmaS.Z, m.Eta),
+	}
+}
+
+// Resolve chooses between two lobes at the incident point p: with
+// probability reflectProb (the Fresnel reflectance at Eta), a specular
+// boundary reflection; otherwise, a subsurface exit event. The exit event
+// samples a channel and a basis axis (normal/tangent/bitangent, weighted
+// 0.5/0.25/0.25 to reduce banding artifacts on curved surfaces), samples
+// a radius from that channel's tabulated Rd(r), and fires a probe ray
+// along the chosen axis to find where the same object's own Shape
+// intersects the resulting offset disk -- the nearby exit point the
+// diffused light re-emerges from.
+func (m Subsurface) Resolve(ctx context.Context, s surfaceInteraction) resolution {
+	rand := s.incoming.rand
+	n := s.collision.normal.Unit()
+	p := s.collision.at
+	wo := s.outgoing.Unit()
+
+	cosThetaI := math.Max(1e-4, math.Abs(wo.Dot(n)))
+	reflectProb := FresnelDielectric(cosThetaI, 1, m.Eta)
+
+	if rand.Float64() < reflectProb {
+		// F/reflectProb == 1 for the chosen lobe: the Fresnel weight and
+		// the probability of selecting it cancel, exactly as in
+		// Glass.Resolve's reflection branch.
+		newRay := ray{
+			origin:    p,
+			direction: reflectRay(wo.Muls(-1), n),
+			depth:     s.incoming.depth + 1,
+			radiance:  s.incoming.radiance,
+			rand:      rand,
+			pixelX:    s.incoming.pixelX,
+			pixelY:    s.incoming.pixelY,
+			rayType:   RayTypeReflected,
+			time:      s.incoming.time,
+		}
+		return resolution{scattered: []ray{newRay}}
+	}
+
+	frame := NewTangentFrame(n)
+	axisPick := rand.Float64()
+	var axis, perp1, perp2 r3.Vec
+	var axisPdf float64
+	switch {
+	case axisPick < 0.5:
+		axis, perp1, perp2, axisPdf = frame.Normal, frame.Tangent, frame.Bitangent, 0.5
+	case axisPick < 0.75:
+		axis, perp1, perp2, axisPdf = frame.Tangent, frame.Normal, frame.Bitangent, 0.25
+	default:
+		axis, perp1, perp2, axisPdf = frame.Bitangent, frame.Normal, frame.Tangent, 0.25
+	}
+
+	tables := m.rdTables()
+	channel := rand.Intn(3)
+	r, radialPdf := tables[channel].sample(rand.Float64())
+	if radialPdf <= 0 {
+		return resolution{} // No mass to sample: fully absorbed.
+	}
+	phi := 2 * math.Pi * rand.Float64()
+	diskOffset := perp1.Muls(r * math.Cos(phi)).Add(perp2.Muls(r * math.Sin(phi)))
+
+	// probeReach comfortably exceeds the profile's own tabulated extent,
+	// so the probe ray brackets every radius the table has mass over.
+	probeReach := tables[channel].r[subsurfaceRdTableSamples-1] + r + 1
+	probeOrigin := p.Add(diskOffset).Add(axis.Muls(probeReach))
+	probeRay := ray{origin: probeOrigin, direction: axis.Muls(-1), rand: rand}
+	hit, exit := s.node.Shape.Collide(probeRay, eps, Distance(2*probeReach))
+	if !hit {
+		return resolution{} // Probe left the object without an exit point.
+	}
+	exitNormal := exit.normal.Unit()
+	exitDistance := exit.at.Sub(p).Length()
+
+	// Evaluating every channel's Rd at the one sampled exitDistance, not
+	// just the channel the radius was drawn from, turns a single-channel
+	// radius sample into an unbiased estimate of all three -- the
+	// standard "hero channel" trick for importance sampling a
+	// wavelength-dependent profile with one shared radius.
+	rd := Spectrum{
+		X: tables[0].eval(exitDistance),
+		Y: tables[1].eval(exitDistance),
+		Z: tables[2].eval(exitDistance),
+	}
+
+	scatteredDirection := rand.CosineWeightedHemisphere(exitNormal)
+	// ft is the Fresnel transmittance carrying the diffusely exiting
+	// light back out through the boundary, mirroring the (1-reflectance)
+	// term Dielectric.ComputeDirectLighting applies to its transmitted
+	// contribution.
+	ft := 1 - FresnelDielectric(math.Max(1e-4, exitNormal.Dot(scatteredDirection)), 1, m.Eta)
+
+	// areaPdf is radialPdf's per-unit-r density spread uniformly over the
+	// sampled radius's circumference, recovering the density per unit
+	// disk area a single (r, phi) sample carries.
+	areaPdf := radialPdf / (2 * math.Pi * math.Max(r, 1e-6))
+	pdf := areaPdf * axisPdf * (1.0 / 3.0) * (1 - reflectProb)
+
+	albedo := textureAt(m.Kd, s.collision.uv.X, s.collision.uv.Y, s.collision.uvFootprint)
+	throughput := albedo.Mul(rd).Muls(ft / pdf)
+
+	newRay := ray{
+		origin:    exit.at,
+		direction: scatteredDirection,
+		depth:     s.incoming.depth + 1,
+		radiance:  s.incoming.radiance.Mul(throughput),
+		rand:      rand,
+		pixelX:    s.incoming.pixelX,
+		pixelY:    s.incoming.pixelY,
+		rayType:   RayTypeGlossy,
+		time:      s.incoming.time,
+	}
+	return resolution{scattered: []ray{newRay}}
+}
+
+// ComputeDirectLighting always returns zero: like Glass, Subsurface's
+// boundary event is a delta lobe, and its diffusion exit event fires a
+// scattered ray from a different point on the surface entirely, so there
+// is no direct contribution to compute at the incident point itself. The
+// exit point's own illumination is instead picked up wherever
+// scatteredDirection's ray next lands. See SpecularMaterial.
+func (m Subsurface) ComputeDirectLighting(ctx context.Context, s surfaceInteraction, scene *Scene) Spectrum {
+	return Spectrum{}
+}
+
+// Specular reports true: next-event estimation against scene.Light can
+// never land on either of Subsurface's lobes (a delta boundary event, or
+// a diffusion exit event that re-emerges at a different point entirely),
+// so the integrator can skip calling ComputeDirectLighting. See
+// SpecularMaterial.
+func (m Subsurface) Specular() bool {
+	return true
+}
+
+// subsurfaceData is the wire representation of Subsurface, wrapping its
+// polymorphic Kd field with a Type/Data envelope.
+type subsurfaceData struct {
+	Type   string          `json:"Type"`
+	Kd     json.RawMessage `json:"Kd"`
+	SigmaA Spectrum        `json:"SigmaA"`
+	SigmaS Spectrum        `json:"SigmaS"`
+	Eta    float64         `json:"Eta"`
+}
+
+func (m Subsurface) MarshalJSON() ([]byte, error) {
+	kdData, err := marshalInterface(m.Kd)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(subsurfaceData{Type: "Subsurface", Kd: kdData, SigmaA: m.SigmaA, SigmaS: m.SigmaS, Eta: m.Eta})
+}
+
+func (m *Subsurface) UnmarshalJSON(data []byte) error {
+	var temp subsurfaceData
+	if err := json.Unmarshal(data, &temp); err != nil {
+		return err
+	}
+	if temp.Type != "Subsurface" {
+		return fmt.Errorf("invalid type: expected Subsurface, got %s", temp.Type)
+	}
+	kd, err := unmarshalInterface(temp.Kd)
+	if err != nil {
+		return err
+	}
+	m.Kd = kd.(Texture)
+	m.SigmaA = temp.SigmaA
+	m.SigmaS = temp.SigmaS
+	m.Eta = temp.Eta
+	return nil
+}
+
+func init() {
+	RegisterInterfaceType(Subsurface{})
+}
@@ -0,0 +1,104 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
+)
+
+func TestSubsurfaceValidateRejectsNegativeCoefficients(t *testing.T) {
+	base := Subsurface{
+		Kd:     TextureUniform{Color: Spectrum{X: 1, Y: 1, Z: 1}},
+		SigmaA: Spectrum{X: 0.1, Y: 0.1, Z: 0.1},
+		SigmaS: Spectrum{X: 1, Y: 1, Z: 1},
+		Eta:    1.3,
+	}
+	if err := base.Validate(); err != nil {
+		t.Fatalf("Validate() = %v, want nil for a well-formed Subsurface", err)
+	}
+
+	negativeA := base
+	negativeA.SigmaA = Spectrum{X: -0.1, Y: 0.1, Z: 0.1}
+	if err := negativeA.Validate(); err == nil {
+		t.Errorf("Validate() = nil, want an error for negative SigmaA")
+	}
+
+	negativeS := base
+	negativeS.SigmaS = Spectrum{X: 1, Y: -1, Z: 1}
+	if err := negativeS.Validate(); err == nil {
+		t.Errorf("Validate() = nil, want an error for negative SigmaS")
+	}
+
+	badEta := base
+	badEta.Eta = 0.5
+	if err := badEta.Validate(); err == nil {
+		t.Errorf("Validate() = nil, want an error for Eta < 1")
+	}
+}
+
+// TestSubsurfaceResolveExitsOnTheSameSphere verifies that whenever
+// Resolve's subsurface-exit branch finds a probe hit, the scattered ray
+// originates at a point on the same Sphere (within its radius) and heads
+// into the hemisphere above that point's own normal, and that its
+// throughput is finite and non-negative -- the probe/diffusion-profile
+// machinery shouldn't blow up or relocate the ray off the object.
+func TestSubsurfaceResolveExitsOnTheSameSphere(t *testing.T) {
+	sphere := Sphere{Center: r3.Point{}, Radius: 1}
+	m := Subsurface{
+		Kd:     TextureUniform{Color: Spectrum{X: 0.8, Y: 0.6, Z: 0.4}},
+		SigmaA: Spectrum{X: 0.02, Y: 0.05, Z: 0.1},
+		SigmaS: Spectrum{X: 4, Y: 6, Z: 8},
+		Eta:    1.3,
+	}
+	if err := m.Validate(); err != nil {
+		t.Fatalf("Validate() = %v, want nil", err)
+	}
+
+	p := r3.Point{X: 0, Y: 0, Z: 1}
+	node := Node{Name: "Sphere", Shape: sphere, Material: m}
+	si := surfaceInteraction{
+		node:      node,
+		collision: collision{at: p, normal: r3.Vec{Z: 1}},
+		outgoing:  r3.Vec{Z: 1},
+	}
+
+	exits := 0
+	for i := 0; i < 2000; i++ {
+		si.incoming = ray{direction: r3.Vec{Z: -1}, radiance: Spectrum{X: 1, Y: 1, Z: 1}, rand: NewRand(int64(i))}
+		res := m.Resolve(context.Background(), si)
+		for _, r := range res.scattered {
+			if r.rayType != RayTypeReflected {
+				exits++
+			}
+			if math.IsNaN(r.radiance.X) || math.IsInf(r.radiance.X, 0) {
+				t.Fatalf("scattered radiance = %v, want finite", r.radiance)
+			}
+			if r.radiance.X < 0 || r.radiance.Y < 0 || r.radiance.Z < 0 {
+				t.Errorf("scattered radiance = %v, want non-negative", r.radiance)
+			}
+			if r.rayType == RayTypeGlossy {
+				radius := float64(sphere.Radius)
+				if got := r.origin.Sub(sphere.Center).Length(); got < radius*0.999 || got > radius*1.001 {
+					t.Errorf("exit point distance from sphere center = %v, want %v", got, radius)
+				}
+			}
+		}
+	}
+	if exits == 0 {
+		t.Fatalf("Resolve() never produced a subsurface exit event over 2000 samples")
+	}
+}
+
+func TestDipoleRdDecaysWithRadius(t *testing.T) {
+	near := dipoleRd(0, 0.1, 5, 1.3)
+	far := dipoleRd(5, 0.1, 5, 1.3)
+	if near <= far {
+		t.Errorf("dipoleRd(0, ...) = %v, dipoleRd(5, ...) = %v, want Rd to decay with radius", near, far)
+	}
+	if far < 0 {
+		t.Errorf("dipoleRd(5, ...) = %v, want non-negative", far)
+	}
+}
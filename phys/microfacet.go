@@ -18,24 +18,32 @@ import (
 type MicrofacetBRDF struct {
 	Roughness float64 // If zero, will be clamped to a small epsilon min.
 	F0        r3.Vec  // Base reflectivity at normal incidence.
+
+	// Distribution selects the normal distribution function and
+	// shadowing-masking statistics used by D and G1. If nil, it defaults
+	// to a BeckmannDistribution parameterized by Roughness, preserving
+	// this type's original behavior. Set it to a GGXDistribution for
+	// heavier specular tails, or to any other MicrofacetDistribution.
+	Distribution MicrofacetDistribution
+}
+
+// distribution returns the effective MicrofacetDistribution, defaulting to
+// Beckmann when none is set.
+func (brdf *MicrofacetBRDF) distribution() MicrofacetDistribution {
+	if brdf.Distribution != nil {
+		return brdf.Distribution
+	}
+	return BeckmannDistribution{Alpha: math.Max(brdf.Roughness, eps)}
 }
 
-// D calculates the Beckmann normal distribution function.
+// D evaluates the normal distribution function, delegating to brdf's
+// selected MicrofacetDistribution (Beckmann by default).
 // It describes the distribution of microfacet normals on the surface.
 //
 // h is the half-vector between outgoing and incoming directions.
 // n is the surface normal.
 func (brdf *MicrofacetBRDF) D(h, n r3.Vec) float64 {
-	// Clamp Roughness to prevent division by zero.
-	roughness := math.Max(brdf.Roughness, eps)
-	cosTheta := n.Dot(h)
-	if cosTheta <= 0 {
-		return 0
-	}
-	m2 := roughness * roughness
-	cosTheta2 := cosTheta * cosTheta
-	exponent := (cosTheta2 - 1) / (m2 * cosTheta2)
-	return math.Exp(exponent) / (math.Pi * m2 * cosTheta2 * cosTheta2)
+	return brdf.distribution().D(h, n)
 }
 
 // G computes the geometry (shadowing-masking) function.
@@ -63,12 +71,7 @@ func (brdf *MicrofacetBRDF) G1(v, n, h r3.Vec) float64 {
 	if cosThetaV <= 0 || cosThetaH <= 0 {
 		return 0
 	}
-	tanThetaV := math.Sqrt(1-cosThetaV*cosThetaV) / cosThetaV
-	a := 1 / (brdf.Roughness * tanThetaV)
-	if a >= 1.6 {
-		return 1
-	}
-	return (3.535*a + 2.181*a*a) / (1 + 2.276*a + 2.577*a*a)
+	return distributionG1(brdf.distribution(), v, n)
 }
 
 // F computes the Fresnel term using Schlick's approximation.
@@ -82,6 +85,83 @@ func (brdf *MicrofacetBRDF) F(wo, h r3.Vec) r3.Vec {
 	return brdf.F0.Add(r3.Vec{X: 1, Y: 1, Z: 1}.Sub(brdf.F0).Muls(oneMinusCosTheta5))
 }
 
+// Sample draws an incoming direction wi by importance sampling brdf's
+// selected distribution's half-vector CDF around n, then reflecting wo
+// about the sampled microfacet normal. It returns the sampled direction
+// together with its PDF with respect to solid angle, so a path tracer can
+// divide the BRDF*cosine by pdf to get an unbiased estimator. If wo lies
+// below the surface (n.Dot(wo) <= 0), Sample returns a zero vector and pdf 0.
+func (brdf *MicrofacetBRDF) Sample(wo, n r3.Vec, rand *Rand) (wi r3.Vec, pdf float64) {
+	wo = wo.Unit()
+	n = n.Unit()
+	if n.Dot(wo) <= 0 {
+		return r3.Vec{}, 0
+	}
+
+	// Sample a microfacet normal h around n from whichever distribution
+	// this BRDF uses, so the sampling density matches the D evaluated by
+	// PDF and Evaluate.
+	h := brdf.distribution().SampleH(n, rand)
+
+	// Reflect wo about the sampled microfacet normal h to obtain wi.
+	wi = h.Muls(2 * wo.Dot(h)).Sub(wo)
+	if n.Dot(wi) <= 0 {
+		return r3.Vec{}, 0
+	}
+	return wi, brdf.PDF(wo, wi, n)
+}
+
+// SampleShaded behaves like Sample, but first passes the shading normal ns
+// through EnsureValidReflection against the surface's true geometric
+// normal ng (e.g. under bump or normal mapping). This avoids the black
+// artifacts that can appear at grazing angles when a shading-normal
+// perturbed sample would otherwise cross to the wrong side of the
+// geometric surface.
+func (brdf *MicrofacetBRDF) SampleShaded(wo, ns, ng r3.Vec, rand *Rand) (wi r3.Vec, pdf float64) {
+	if ng.Dot(wo) <= 0 {
+		return r3.Vec{}, 0
+	}
+	return brdf.Sample(wo, EnsureValidReflection(ng, wo, ns), rand)
+}
+
+// EvaluateShaded behaves like Evaluate, but first passes the shading
+// normal ns through EnsureValidReflection against the geometric normal ng,
+// for the same reason SampleShaded does.
+func (brdf *MicrofacetBRDF) EvaluateShaded(wo, wi, ns, ng r3.Vec) r3.Vec {
+	return brdf.Evaluate(wo, wi, EnsureValidReflection(ng, wo, ns))
+}
+
+// PDF returns the probability density (with respect to solid angle around
+// wi) that Sample would have produced direction wi given outgoing
+// direction wo. It converts the half-vector sampling density to a
+// solid-angle density via the standard 1/(4·wo·h) Jacobian.
+func (brdf *MicrofacetBRDF) PDF(wo, wi, n r3.Vec) float64 {
+	wo = wo.Unit()
+	wi = wi.Unit()
+	n = n.Unit()
+	if n.Dot(wo) <= 0 || n.Dot(wi) <= 0 {
+		return 0
+	}
+	h := wo.Add(wi).Unit()
+	cosThetaH := math.Max(eps, n.Dot(h))
+	woDotH := math.Max(eps, wo.Dot(h))
+	// pdf(h) = D(h)*cosThetaH for the selected distribution's half-vector
+	// sampling; the Jacobian of the reflection map h->wi is 1/(4*wo.h).
+	return brdf.D(h, n) * cosThetaH / (4 * woDotH)
+}
+
+// orthonormalBasis returns two unit vectors (t, b) orthogonal to n and to
+// each other, completing a right-handed frame (t, b, n).
+func orthonormalBasis(n r3.Vec) (t, b r3.Vec) {
+	if math.Abs(n.X) > math.Abs(n.Y) {
+		t = r3.Vec{X: -n.Z, Y: 0, Z: n.X}.Unit()
+	} else {
+		t = r3.Vec{X: 0, Y: n.Z, Z: -n.Y}.Unit()
+	}
+	b = n.Cross(t)
+	return t, b
+}
+
 // Evaluate computes the BRDF value given the outgoing and incoming directions.
 // It combines the normal distribution, geometry, and Fresnel terms to produce the reflected radiance.
 //
@@ -0,0 +1,199 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import (
+	"math"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
+)
+
+// TangentFrame is an orthonormal basis (Tangent, Bitangent, Normal) used by
+// anisotropic microfacet models to give roughness a preferred direction,
+// e.g. brushed metal or hair.
+type TangentFrame struct {
+	Tangent   r3.Vec
+	Bitangent r3.Vec
+	Normal    r3.Vec
+}
+
+// NewTangentFrame builds a TangentFrame from a surface normal n, choosing
+// an arbitrary tangent orthogonal to it. Shapes that carry an explicit UV
+// parameterization (e.g. TriangleUV) should prefer a tangent derived from
+// the UV gradient instead, since that aligns anisotropy with the texture.
+func NewTangentFrame(n r3.Vec) TangentFrame {
+	n = n.Unit()
+	t, b := orthonormalBasis(n)
+	return TangentFrame{Tangent: t, Bitangent: b, Normal: n}
+}
+
+// ToLocal projects a world-space direction into the frame's (tangent,
+// bitangent, normal) basis.
+func (f TangentFrame) ToLocal(v r3.Vec) r3.Vec {
+	return r3.Vec{X: v.Dot(f.Tangent), Y: v.Dot(f.Bitangent), Z: v.Dot(f.Normal)}
+}
+
+// NewTangentFrameFromFace builds a TangentFrame whose tangent is aligned
+// with the face's UV parameterization, following the standard UV-gradient
+// construction used for normal/tangent-space mapping. This gives
+// AnisotropicGGX a tangent direction that follows the mesh's texture
+// layout (e.g. brushed-metal anisotropy running along a UV axis) instead
+// of the arbitrary tangent NewTangentFrame picks. If the face's UVs are
+// degenerate (zero parametric area), it falls back to NewTangentFrame.
+func NewTangentFrameFromFace(f Face) TangentFrame {
+	n := f.Vertex[1].Position.Sub(f.Vertex[0].Position).Cross(f.Vertex[2].Position.Sub(f.Vertex[0].Position)).Unit()
+
+	edge1 := f.Vertex[1].Position.Sub(f.Vertex[0].Position)
+	edge2 := f.Vertex[2].Position.Sub(f.Vertex[0].Position)
+	deltaUV1 := f.Vertex[1].UV.Sub(f.Vertex[0].UV)
+	deltaUV2 := f.Vertex[2].UV.Sub(f.Vertex[0].UV)
+
+	det := deltaUV1.X*deltaUV2.Y - deltaUV2.X*deltaUV1.Y
+	if math.Abs(det) < eps {
+		t, b := orthonormalBasis(n)
+		return TangentFrame{Tangent: t, Bitangent: b, Normal: n}
+	}
+	r := 1 / det
+	tangent := edge1.Muls(deltaUV2.Y * r).Sub(edge2.Muls(deltaUV1.Y * r))
+	// Re-orthogonalize against n (Gram-Schmidt) since the raw UV-gradient
+	// tangent is not guaranteed to be perpendicular to the face normal.
+	tangent = tangent.Sub(n.Muls(n.Dot(tangent))).Unit()
+	if tangent.IsZero() {
+		t, b := orthonormalBasis(n)
+		return TangentFrame{Tangent: t, Bitangent: b, Normal: n}
+	}
+	bitangent := n.Cross(tangent)
+	return TangentFrame{Tangent: tangent, Bitangent: bitangent, Normal: n}
+}
+
+// AnisotropicGGX implements the Trowbridge-Reitz (GGX) distribution with
+// independent roughness along the tangent (AlphaX) and bitangent (AlphaY)
+// axes of a TangentFrame. When AlphaX == AlphaY it reduces to the
+// isotropic GGXDistribution.
+type AnisotropicGGX struct {
+	AlphaX float64
+	AlphaY float64
+	Frame  TangentFrame
+}
+
+// D evaluates the anisotropic GGX normal distribution function at
+// half-vector h.
+func (a AnisotropicGGX) D(h r3.Vec) float64 {
+	local := a.Frame.ToLocal(h.Unit())
+	cosTheta := local.Z
+	if cosTheta <= 0 {
+		return 0
+	}
+	ax := math.Max(a.AlphaX, eps)
+	ay := math.Max(a.AlphaY, eps)
+	term := (local.X*local.X)/(ax*ax) + (local.Y*local.Y)/(ay*ay) + local.Z*local.Z
+	return 1 / (math.Pi * ax * ay * term * term)
+}
+
+// Lambda computes the Smith masking auxiliary function for direction v
+// under the anisotropic GGX distribution, projecting the isotropic-space
+// roughness onto v's azimuthal direction.
+func (a AnisotropicGGX) Lambda(v r3.Vec) float64 {
+	local := a.Frame.ToLocal(v.Unit())
+	cosTheta := local.Z
+	if cosTheta <= 0 {
+		return math.Inf(1)
+	}
+	sinTheta := math.Sqrt(math.Max(0, 1-cosTheta*cosTheta))
+	if sinTheta <= 0 {
+		return 0
+	}
+	cosPhi := local.X / sinTheta
+	sinPhi := local.Y / sinTheta
+	ax := math.Max(a.AlphaX, eps)
+	ay := math.Max(a.AlphaY, eps)
+	alpha := math.Sqrt(cosPhi*cosPhi*ax*ax + sinPhi*sinPhi*ay*ay)
+	tanTheta := sinTheta / cosTheta
+	a2Tan2 := (alpha * alpha) * (tanTheta * tanTheta)
+	return (-1 + math.Sqrt(1+a2Tan2)) / 2
+}
+
+// G1 returns the Smith masking function for a single direction v.
+func (a AnisotropicGGX) G1(v r3.Vec) float64 {
+	return 1 / (1 + a.Lambda(v))
+}
+
+// SampleVisibleNormal importance-samples a microfacet normal from the
+// distribution of visible normals (VNDF) seen from wo, using the
+// Heitz 2018 algorithm ("Sampling the GGX Distribution of Visible
+// Normals"). Sampling from the VNDF rather than the raw NDF concentrates
+// samples on microfacets that actually contribute to wo's reflectance,
+// which reduces variance relative to MicrofacetBRDF's plain half-vector
+// sampling, especially at grazing angles. wo must point away from the
+// surface in world space.
+func (a AnisotropicGGX) SampleVisibleNormal(wo r3.Vec, rand *Rand) r3.Vec {
+	woLocal := a.Frame.ToLocal(wo.Unit())
+	ax := math.Max(a.AlphaX, eps)
+	ay := math.Max(a.AlphaY, eps)
+
+	// Transform wo to the hemisphere configuration (stretch space where the
+	// visible-normal distribution reduces to a uniform hemisphere).
+	wh := r3.Vec{X: ax * woLocal.X, Y: ay * woLocal.Y, Z: woLocal.Z}.Unit()
+
+	// Build an orthonormal basis (t1, t2, wh) around the stretched direction.
+	lenSq := wh.X*wh.X + wh.Y*wh.Y
+	t1 := r3.Vec{X: 1, Y: 0, Z: 0}
+	if lenSq > 0 {
+		t1 = r3.Vec{X: -wh.Y, Y: wh.X, Z: 0}.Divs(math.Sqrt(lenSq))
+	}
+	t2 := wh.Cross(t1)
+
+	// Sample a point on the projected hemisphere disk.
+	u1, u2 := rand.Float64(), rand.Float64()
+	r := math.Sqrt(u1)
+	phi := 2 * math.Pi * u2
+	p1 := r * math.Cos(phi)
+	p2Std := r * math.Sin(phi)
+	s := 0.5 * (1 + wh.Z)
+	p2 := (1-s)*math.Sqrt(math.Max(0, 1-p1*p1)) + s*p2Std
+
+	nh := t1.Muls(p1).Add(t2.Muls(p2)).Add(wh.Muls(math.Sqrt(math.Max(0, 1-p1*p1-p2*p2))))
+
+	// Transform the sampled normal back to the ellipsoid configuration.
+	neLocal := r3.Vec{X: ax * nh.X, Y: ay * nh.Y, Z: math.Max(eps, nh.Z)}.Unit()
+	return a.Frame.Tangent.Muls(neLocal.X).Add(a.Frame.Bitangent.Muls(neLocal.Y)).Add(a.Frame.Normal.Muls(neLocal.Z))
+}
+
+// pdfVisibleNormal returns the VNDF probability density of microfacet
+// normal h given outgoing direction wo, following Heitz 2018: D_v(h) =
+// G1(wo) * max(0, wo.h) * D(h) / cosThetaO.
+func (a AnisotropicGGX) pdfVisibleNormal(wo, h r3.Vec) float64 {
+	cosThetaO := math.Max(eps, a.Frame.Normal.Dot(wo))
+	return a.G1(wo) * math.Max(0, wo.Dot(h)) * a.D(h) / cosThetaO
+}
+
+// Sample draws an incoming direction wi by importance sampling the VNDF
+// around wo and reflecting wo about the sampled microfacet normal. It
+// returns the sampled direction and its solid-angle PDF, so a path tracer
+// can divide BRDF*cosine by pdf to get an unbiased estimator. If wo lies
+// below the surface, Sample returns a zero vector and pdf 0.
+func (a AnisotropicGGX) Sample(wo r3.Vec, rand *Rand) (wi r3.Vec, pdf float64) {
+	wo = wo.Unit()
+	if a.Frame.Normal.Dot(wo) <= 0 {
+		return r3.Vec{}, 0
+	}
+	h := a.SampleVisibleNormal(wo, rand)
+	wi = h.Muls(2 * wo.Dot(h)).Sub(wo)
+	if a.Frame.Normal.Dot(wi) <= 0 {
+		return r3.Vec{}, 0
+	}
+	return wi, a.PDF(wo, wi)
+}
+
+// PDF returns the solid-angle probability density that Sample would have
+// produced direction wi given outgoing direction wo, converting the VNDF
+// half-vector density via the standard 1/(4·wo·h) reflection Jacobian.
+func (a AnisotropicGGX) PDF(wo, wi r3.Vec) float64 {
+	wo = wo.Unit()
+	wi = wi.Unit()
+	if a.Frame.Normal.Dot(wo) <= 0 || a.Frame.Normal.Dot(wi) <= 0 {
+		return 0
+	}
+	h := wo.Add(wi).Unit()
+	woDotH := math.Max(eps, wo.Dot(h))
+	return a.pdfVisibleNormal(wo, h) / (4 * woDotH)
+}
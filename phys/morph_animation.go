@@ -0,0 +1,138 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import (
+	"fmt"
+	"math"
+)
+
+// MorphKeyframe is one pose in a MorphAnimation: the weight vector
+// WeightsAt should reach at normalized progress U, linearly blended into
+// from the keyframe before it. Mirrors CameraKeyframe, minus Ease --
+// glTF's own animation sampler only defines LINEAR/STEP/CUBICSPLINE
+// interpolation for weights, and LINEAR covers every morph clip this
+// importer produces.
+type MorphKeyframe struct {
+	// U is this keyframe's normalized progress. MorphAnimation.Keys must
+	// be sorted in strictly ascending U.
+	U float64
+
+	// Weights is the MorphedMesh.W this keyframe reaches. Every keyframe
+	// in a MorphAnimation must have the same length.
+	Weights []float64
+}
+
+// MorphAnimation is a sorted list of MorphKeyframe weight vectors, with
+// Wrap resolving progress outside their span -- the same WrapMode
+// CameraKeyframes uses. Call WeightsAt once per frame and feed the result
+// to MorphedMesh.SetWeights, the per-frame-snapshot pattern
+// AnimatedCamera.WithTime already uses for camera poses, rather than a
+// continuous-within-exposure interpolation like ray.time/Scene.Shutter's
+// motion blur: morph weights are a pose to reach by frame N, not a
+// direction to sweep across it.
+//
+// Zero value:
+// The zero value has no Keys and is not usable; construct one as a
+// struct literal.
+type MorphAnimation struct {
+	Keys []MorphKeyframe
+	Wrap WrapMode
+}
+
+// Validate reports whether a has at least one keyframe, strictly
+// ascending U values, every keyframe the same Weights length, and a
+// recognized Wrap.
+func (a MorphAnimation) Validate() error {
+	if len(a.Keys) == 0 {
+		return fmt.Errorf("MorphAnimation has no keyframes")
+	}
+	n := len(a.Keys[0].Weights)
+	for i, k := range a.Keys {
+		if i > 0 && k.U <= a.Keys[i-1].U {
+			return fmt.Errorf("keyframe %d: U %v must be strictly greater than keyframe %d's U %v", i, k.U, i-1, a.Keys[i-1].U)
+		}
+		if len(k.Weights) != n {
+			return fmt.Errorf("keyframe %d: len(Weights) %d != keyframe 0's %d", i, len(k.Weights), n)
+		}
+	}
+	switch a.Wrap {
+	case WrapClamp, WrapLoop, WrapPingPong:
+	default:
+		return fmt.Errorf("MorphAnimation has unknown Wrap %v", a.Wrap)
+	}
+	return nil
+}
+
+// resolveU maps u into a's own [Keys[0].U, Keys[last].U] span according
+// to Wrap. Mirrors CameraKeyframes.resolveU.
+func (a MorphAnimation) resolveU(u float64) float64 {
+	first, last := a.Keys[0].U, a.Keys[len(a.Keys)-1].U
+	span := last - first
+	if span == 0 {
+		return first
+	}
+	switch a.Wrap {
+	case WrapLoop:
+		offset := math.Mod(u-first, span)
+		if offset < 0 {
+			offset += span
+		}
+		return first + offset
+	case WrapPingPong:
+		period := 2 * span
+		offset := math.Mod(u-first, period)
+		if offset < 0 {
+			offset += period
+		}
+		if offset > span {
+			offset = period - offset
+		}
+		return first + offset
+	default: // WrapClamp
+		if u < first {
+			return first
+		}
+		if u > last {
+			return last
+		}
+		return u
+	}
+}
+
+// bracket returns the index of the keyframe at or before u (already
+// mapped through resolveU) and the linear progress toward the next one.
+// Mirrors CameraKeyframes.bracket.
+func (a MorphAnimation) bracket(u float64) (i int, t float64) {
+	n := len(a.Keys)
+	if n == 1 || u <= a.Keys[0].U {
+		return 0, 0
+	}
+	if u >= a.Keys[n-1].U {
+		return n - 1, 0
+	}
+	i = 0
+	for i < n-1 && a.Keys[i+1].U < u {
+		i++
+	}
+	span := a.Keys[i+1].U - a.Keys[i].U
+	return i, (u - a.Keys[i].U) / span
+}
+
+// WeightsAt returns the weight vector at normalized progress u, after
+// resolveU maps it into range: a fresh []float64 lerped between the
+// bracketing keyframes, safe for the caller to hand straight to
+// MorphedMesh.SetWeights.
+func (a MorphAnimation) WeightsAt(u float64) []float64 {
+	i, t := a.bracket(a.resolveU(u))
+	if t == 0 {
+		w := make([]float64, len(a.Keys[i].Weights))
+		copy(w, a.Keys[i].Weights)
+		return w
+	}
+	w0, w1 := a.Keys[i].Weights, a.Keys[i+1].Weights
+	w := make([]float64, len(w0))
+	for j := range w {
+		w[j] = w0[j] + t*(w1[j]-w0[j])
+	}
+	return w
+}
@@ -0,0 +1,54 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import "testing"
+
+func testMorphAnimation(wrap WrapMode) MorphAnimation {
+	return MorphAnimation{
+		Wrap: wrap,
+		Keys: []MorphKeyframe{
+			{U: 0, Weights: []float64{0, 0}},
+			{U: 1, Weights: []float64{1, 2}},
+		},
+	}
+}
+
+func TestMorphAnimationValidate(t *testing.T) {
+	if err := testMorphAnimation(WrapClamp).Validate(); err != nil {
+		t.Errorf("Validate() = %v, want nil", err)
+	}
+	if err := (MorphAnimation{}).Validate(); err == nil {
+		t.Error("Validate() on empty MorphAnimation = nil, want an error")
+	}
+	mismatched := MorphAnimation{Keys: []MorphKeyframe{{U: 0, Weights: []float64{0}}, {U: 1, Weights: []float64{0, 0}}}}
+	if err := mismatched.Validate(); err == nil {
+		t.Error("Validate() with mismatched Weights lengths = nil, want an error")
+	}
+}
+
+// TestMorphAnimationWeightsAtLerpsBetweenKeyframes verifies WeightsAt
+// linearly blends between the bracketing keyframes' weight vectors, the
+// same two-point lerp CameraKeyframes.Build uses for its scalar fields.
+func TestMorphAnimationWeightsAtLerpsBetweenKeyframes(t *testing.T) {
+	a := testMorphAnimation(WrapClamp)
+	got := a.WeightsAt(0.5)
+	want := []float64{0.5, 1}
+	for i := range want {
+		if !almostEqual(got[i], want[i], 1e-9) {
+			t.Errorf("WeightsAt(0.5)[%d] = %v, want %v", i, got[i], want[i])
+		}
+	}
+}
+
+// TestMorphAnimationWeightsAtClampsOutOfRange verifies WeightsAt holds
+// the first/last keyframe's weights for u outside [0,1] under WrapClamp.
+func TestMorphAnimationWeightsAtClampsOutOfRange(t *testing.T) {
+	a := testMorphAnimation(WrapClamp)
+	got := a.WeightsAt(5)
+	want := []float64{1, 2}
+	for i := range want {
+		if !almostEqual(got[i], want[i], 1e-9) {
+			t.Errorf("WeightsAt(5)[%d] = %v, want %v", i, got[i], want[i])
+		}
+	}
+}
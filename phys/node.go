@@ -9,6 +9,9 @@ import (
 	"io"
 	"io/fs"
 	"log"
+	"math"
+	"os"
+	"path/filepath"
 
 	"github.com/scottlawsonbc/slam/code/photon/raytrace/obj"
 	"github.com/scottlawsonbc/slam/code/photon/raytrace/r2"
@@ -23,6 +26,14 @@ type Node struct {
 	Transform Transform
 	Shape     Shape
 	Material  Material
+
+	// LightmapCache, when set, is the baked irradiance atlas BakeLightmaps
+	// produced for this node's static Lambertian geometry. Like Mesh's
+	// embedded BVH, it is a derived render cache rather than scene
+	// definition data, so it is never marshaled and does not round-trip
+	// through MarshalJSON/UnmarshalJSON; a scene loaded from disk must
+	// call BakeLightmaps again to populate it.
+	LightmapCache *LightmapCache
 }
 
 func (n Node) Validate() error {
@@ -73,34 +84,33 @@ func (n *Node) UnmarshalJSON(data []byte) error {
 		return err
 	}
 	// Unmarshal Shape.
-	iface, err := unmarshalInterface(wrapper.Shape)
+	shape, err := unmarshalTyped(wrapper.Shape, shapeRegistry)
 	if err != nil {
 		return err
 	}
-	shape, ok := iface.(Shape)
-	if !ok {
-		return fmt.Errorf("expected Shape, got %T", iface)
-	}
 	// Unmarshal Material.
-	iface, err = unmarshalInterface(wrapper.Material)
+	material, err := unmarshalTyped(wrapper.Material, materialRegistry)
 	if err != nil {
 		return err
 	}
-	material, ok := iface.(Material)
-	if !ok {
-		return fmt.Errorf("expected Material, got %T", iface)
-	}
 	n.Name = wrapper.Name
 	n.Shape = shape
 	n.Material = material
 	return nil
 }
 
-// ConvertObjectToNodes converts an obj.Object into a slice of phys.Node.
+// ConvertObjectToNodes converts an obj.Object into a slice of phys.Node,
+// using the default illum/Ke/map_* material mapping (see MaterialLibrary).
 // Each node corresponds to a mesh with a unique material.
 func ConvertObjectToNodes(src *obj.Object, assetFS fs.FS) ([]Node, error) {
+	return ConvertObjectToNodesWithLibrary(src, assetFS, defaultMaterialLibrary{})
+}
+
+// ConvertObjectToNodesWithLibrary behaves like ConvertObjectToNodes, but
+// builds each node's Material via lib instead of the built-in mapping.
+func ConvertObjectToNodesWithLibrary(src *obj.Object, assetFS fs.FS, lib MaterialLibrary) ([]Node, error) {
 	// Convert materials.
-	materials, err := ConvertObjectToMaterial(src, assetFS)
+	materials, err := ConvertObjectToMaterialWithLibrary(src, assetFS, lib)
 	if err != nil {
 		return nil, err
 	}
@@ -173,6 +183,38 @@ func ConvertObjectToNodes(src *obj.Object, assetFS fs.FS) ([]Node, error) {
 	return nodes, nil
 }
 
+// LoadObjScene parses the Wavefront OBJ file at path and converts it into
+// phys.Nodes via ConvertObjectToNodes, resolving its MTL file and any
+// map_Kd/map_Ke texture paths relative to path's own directory -- so
+// scene-setup code can write scene.Node = append(scene.Node,
+// phys.MustLoadObjScene("dragon.obj")...) without separately wiring up
+// an fs.FS the way obj.ParseFS otherwise requires.
+func LoadObjScene(path string) ([]Node, error) {
+	assetFS := os.DirFS(filepath.Dir(path))
+	o, err := obj.ParseFS(assetFS, filepath.Base(path))
+	if err != nil {
+		return nil, fmt.Errorf("LoadObjScene %q: %w", path, err)
+	}
+	nodes, err := ConvertObjectToNodes(o, assetFS)
+	if err != nil {
+		return nil, fmt.Errorf("LoadObjScene %q: %w", path, err)
+	}
+	return nodes, nil
+}
+
+// MustLoadObjScene behaves like LoadObjScene but panics on error, matching
+// this package's other Must-prefixed asset loaders (MustLoadPNG,
+// MustNewTextureImageFS, ...): a missing or malformed OBJ at scene-setup
+// time is a startup bug, not a condition calling code is expected to
+// recover from.
+func MustLoadObjScene(path string) []Node {
+	nodes, err := LoadObjScene(path)
+	if err != nil {
+		panic(err)
+	}
+	return nodes
+}
+
 // Helper function to get Vertex from obj.Index
 func getVertexFromIndex(src *obj.Object, idx obj.Index) (Vertex, error) {
 	vertexIndex := idx.Vertex - 1
@@ -189,9 +231,16 @@ func getVertexFromIndex(src *obj.Object, idx obj.Index) (Vertex, error) {
 		uv = r2.Point{X: 0, Y: 0}
 	}
 
+	var normal r3.Vec
+	if idx.Normal > 0 && idx.Normal-1 < len(src.Normals) {
+		n := src.Normals[idx.Normal-1]
+		normal = r3.Vec{X: n.X, Y: n.Y, Z: n.Z}
+	}
+
 	return Vertex{
 		Position: r3.Point{X: position.X, Y: position.Y, Z: position.Z},
 		UV:       uv,
+		Normal:   normal,
 	}, nil
 }
 
@@ -263,18 +312,132 @@ func walk(fsys fs.FS, msg string) {
 	})
 }
 
-// ConvertObjectToMaterial converts the materials defined in obj.Object into phys.Material instances.
-// It returns a map from material names to phys.Material. This allows associating different
-// parts of the geometry with their respective materials.
+// MaterialLibrary builds a phys.Material for one obj.Material entry,
+// letting callers override ConvertObjectToMaterial's default Wavefront
+// illum/Ke/map_* -> Material mapping -- for example to plug in a studio's
+// own PBR pipeline, or to support a vendor's proprietary MTL extensions --
+// without forking this package. diffuse is the Texture the caller already
+// resolved from mat.Texture or mat.Diffuse, so a custom Build doesn't have
+// to repeat that texture-vs-uniform-color lookup.
+type MaterialLibrary interface {
+	Build(mat *obj.Material, diffuse Texture, assetFS fs.FS) (Material, error)
+}
+
+// defaultMaterialLibrary is the MaterialLibrary ConvertObjectToMaterial
+// uses unless the caller supplies its own: it maps the Wavefront illum
+// code, Ke emission, and Ni refractive index conventions documented in
+// the obj package onto this package's built-in Material types.
+type defaultMaterialLibrary struct{}
+
+// roughnessFromShininess converts a Phong specular exponent (Ns) to a
+// perceptual roughness in (0, 1] via the standard roughness =
+// sqrt(2/(Ns+2)) approximation, so MTL files authored for a Phong/Blinn
+// renderer produce a plausible microfacet roughness without any manual
+// tuning. Ns <= 0 (unset) maps to a fully rough 1.0.
+func roughnessFromShininess(ns float64) float64 {
+	if ns <= 0 {
+		return 1
+	}
+	return math.Sqrt(2 / (ns + 2))
+}
+
+func (defaultMaterialLibrary) Build(mat *obj.Material, diffuse Texture, assetFS fs.FS) (Material, error) {
+	var emissive Texture
+	switch {
+	case mat.EmissionTexture != "":
+		tex, err := NewTextureImageFS(assetFS, mat.EmissionTexture, "bilinear", "repeat")
+		if err != nil {
+			return nil, fmt.Errorf("failed to load emission map '%s' for material '%s': %v", mat.EmissionTexture, mat.Name, err)
+		}
+		emissive = tex
+	case mat.Emission[0] > 0 || mat.Emission[1] > 0 || mat.Emission[2] > 0:
+		emissive = TextureUniform{Color: Spectrum{X: mat.Emission[0], Y: mat.Emission[1], Z: mat.Emission[2]}}
+	}
+	var normalMap *TextureNormal
+	if mat.NormalTexture != "" {
+		tex, err := NewTextureImageFS(assetFS, mat.NormalTexture, "bilinear", "repeat")
+		if err != nil {
+			return nil, fmt.Errorf("failed to load normal map '%s' for material '%s': %v", mat.NormalTexture, mat.Name, err)
+		}
+		normalMap = &TextureNormal{Normal: tex}
+	}
+	roughness := roughnessFromShininess(mat.Shininess)
+
+	switch {
+	case mat.HasIllum && mat.Illum >= 6:
+		// Reflective and refractive (illum 6/7): a dielectric, using Ni for
+		// the interior refractive index when given.
+		ior := mat.IOR
+		if ior <= 0 {
+			ior = 1.5
+		}
+		m := Dielectric{RefractiveIndexInterior: ior, RefractiveIndexExterior: 1, Roughness: roughness}
+		return m, m.Validate()
+	case mat.HasIllum && mat.Illum >= 3:
+		// Reflective (illum 3/4): a metal, tinted by Ks if present, falling
+		// back to Kd.
+		albedo := r3.Vec{X: mat.Specular[0], Y: mat.Specular[1], Z: mat.Specular[2]}
+		if albedo.X == 0 && albedo.Y == 0 && albedo.Z == 0 {
+			albedo = r3.Vec{X: mat.Diffuse[0], Y: mat.Diffuse[1], Z: mat.Diffuse[2]}
+		}
+		m := Metal{Albedo: albedo, Fuzz: roughness}
+		return m, m.Validate()
+	case emissive != nil && mat.Texture != "":
+		// A diffuse map and an emission source both exist: mix them through
+		// PBR's BaseColor + EmissiveTexture rather than picking just one.
+		mixRoughness := roughness
+		if !mat.HasPBR {
+			mixRoughness = 1
+		}
+		m := PBR{BaseColor: diffuse, Metallic: mat.Metallic, Roughness: mixRoughness, NormalMap: normalMap, EmissiveTexture: emissive}
+		return m, m.Validate()
+	case emissive != nil:
+		m := Emitter{Texture: emissive}
+		return m, m.Validate()
+	case mat.HasPBR:
+		m := PBR{BaseColor: diffuse, Metallic: mat.Metallic, Roughness: mat.Roughness, NormalMap: normalMap}
+		return m, m.Validate()
+	case mat.HasIllum && mat.Illum == 2:
+		// Diffuse + specular highlights (illum 2): a Fresnel-weighted
+		// specular coat over the diffuse substrate.
+		f0 := r3.Vec{X: mat.Specular[0], Y: mat.Specular[1], Z: mat.Specular[2]}
+		m := RoughPlastic{Texture: diffuse, Specular: MicrofacetBRDF{Roughness: roughness, F0: f0}}
+		return m, m.Validate()
+	case mat.HasIllum && mat.Illum == 1:
+		// Flat diffuse, no specular (illum 1).
+		m := Lambertian{Texture: diffuse}
+		return m, m.Validate()
+	default:
+		// No illum was specified: preserve ConvertObjectToMaterial's
+		// long-standing default of treating untagged materials as
+		// self-illuminating, so existing scenes authored without illum
+		// keep rendering unchanged.
+		m := Emitter{Texture: diffuse}
+		return m, m.Validate()
+	}
+}
+
+// ConvertObjectToMaterial converts the materials defined in obj.Object into
+// phys.Material instances using the default illum/Ke/map_* mapping (see
+// MaterialLibrary). It returns a map from material names to phys.Material,
+// associating different parts of the geometry with their respective
+// materials.
 func ConvertObjectToMaterial(src *obj.Object, assetFS fs.FS) (map[string]Material, error) {
+	return ConvertObjectToMaterialWithLibrary(src, assetFS, defaultMaterialLibrary{})
+}
+
+// ConvertObjectToMaterialWithLibrary behaves like ConvertObjectToMaterial,
+// but builds each phys.Material via lib instead of the built-in mapping,
+// so callers can override how MTL properties translate to Material types.
+func ConvertObjectToMaterialWithLibrary(src *obj.Object, assetFS fs.FS, lib MaterialLibrary) (map[string]Material, error) {
 	materialMap := make(map[string]Material)
 	for name, mat := range src.Materials {
-		var texture Texture
+		var diffuse Texture
 		var err error
 		if mat.Texture != "" {
 			log.Printf("loading texture %s", mat.Texture)
 			texturePath := mat.Texture
-			texture, err = NewTextureImageFS(assetFS, texturePath, "bilinear", "repeat")
+			diffuse, err = NewTextureImageFS(assetFS, texturePath, "bilinear", "repeat")
 			if err != nil {
 				walk(assetFS, "phys.ConvertObjectToMaterial.assetFS")
 				return nil, fmt.Errorf("failed to load texture '%s' for material '%s': %v", texturePath, name, err)
@@ -284,13 +447,16 @@ func ConvertObjectToMaterial(src *obj.Object, assetFS fs.FS) (map[string]Materia
 			r := mat.Diffuse[0]
 			g := mat.Diffuse[1]
 			b := mat.Diffuse[2]
-			texture = TextureUniform{Color: Spectrum{X: r, Y: g, Z: b}}
+			diffuse = TextureUniform{Color: Spectrum{X: r, Y: g, Z: b}}
+		}
+
+		m, err := lib.Build(mat, diffuse, assetFS)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build material '%s': %v", name, err)
 		}
-		m := Emitter{Texture: texture}
 		if err := m.Validate(); err != nil {
 			return nil, fmt.Errorf("invalid material '%s': %v", name, err)
 		}
-
 		materialMap[name] = m
 	}
 
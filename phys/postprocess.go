@@ -0,0 +1,410 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// RadianceBuffer holds a dx-by-dy grid of linear, un-tonemapped radiance,
+// row-major with (0,0) at the image's top-left, the same orientation as
+// RenderArtifact.Image. renderScene populates it from the same per-pixel
+// average renderPixel otherwise clamps straight into an 8-bit Image,
+// before that clamp happens, whenever RenderOptions.PostProcess is
+// non-empty: the whole point of a post-process pipeline is to run on
+// radiance a naive 8-bit clamp would already have destroyed (a clipped
+// HDR skybox, a saturated emitter bright-pass for Bloom).
+type RadianceBuffer struct {
+	Dx, Dy int
+	Pix    []Spectrum
+}
+
+func newRadianceBuffer(dx, dy int) *RadianceBuffer {
+	return &RadianceBuffer{Dx: dx, Dy: dy, Pix: make([]Spectrum, dx*dy)}
+}
+
+func (b *RadianceBuffer) at(x, y int) Spectrum     { return b.Pix[y*b.Dx+x] }
+func (b *RadianceBuffer) set(x, y int, s Spectrum) { b.Pix[y*b.Dx+x] = s }
+
+// clone returns a deep copy of b, so a Pass can read neighbors of a pixel
+// it has already overwritten in its own output buffer.
+func (b *RadianceBuffer) clone() *RadianceBuffer {
+	out := &RadianceBuffer{Dx: b.Dx, Dy: b.Dy, Pix: make([]Spectrum, len(b.Pix))}
+	copy(out.Pix, b.Pix)
+	return out
+}
+
+// ToRGBA clamps b's linear radiance into an 8-bit image the same way
+// renderPixel does (255.99*channel, clamped to [0,255]), with no implicit
+// gamma encoding. A pipeline that wants display-referred sRGB output
+// should end with SRGBEncode, which runs before this final clamp.
+func (b *RadianceBuffer) ToRGBA() *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, b.Dx, b.Dy))
+	for y := 0; y < b.Dy; y++ {
+		for x := 0; x < b.Dx; x++ {
+			s := b.at(x, y)
+			img.Set(x, y, color.RGBA{
+				R: uint8(clamp(255.99*s.X, 0, 255)),
+				G: uint8(clamp(255.99*s.Y, 0, 255)),
+				B: uint8(clamp(255.99*s.Z, 0, 255)),
+				A: 255,
+			})
+		}
+	}
+	return img
+}
+
+// Pass is one stage of a RenderOptions.PostProcess pipeline: given the
+// running radiance buffer and the scene's first-hit Aux buffers (either
+// field nil if RenderOptions.AuxBuffers wasn't set), it returns the
+// buffer after this stage's transform. DenoiseATrous, Bloom, ToneMap, and
+// SRGBEncode build the standard passes; runPostProcess runs
+// RenderOptions.PostProcess in order and converts the result to an 8-bit
+// image with ToRGBA.
+type Pass func(buf *RadianceBuffer, aux Aux) *RadianceBuffer
+
+// runPostProcess runs passes over buf in order and returns the final
+// 8-bit image. An empty or nil passes list still goes through ToRGBA, so
+// RenderOptions.PostProcess unset and PostProcess: []Pass{} behave the
+// same.
+func runPostProcess(buf *RadianceBuffer, aux Aux, passes []Pass) *image.RGBA {
+	for _, p := range passes {
+		buf = p(buf, aux)
+	}
+	return buf.ToRGBA()
+}
+
+// atrousKernel1D is the 5-tap B-spline kernel the À-Trous wavelet filter
+// holes out by 2^i pixels on iteration i, {1,4,6,4,1}/16.
+var atrousKernel1D = [5]float64{1.0 / 16, 4.0 / 16, 6.0 / 16, 4.0 / 16, 1.0 / 16}
+
+// defaultDenoiseSigmas is used by RenderOptions.Denoise when
+// DenoiseSigmas is left at its zero value, picked to noticeably smooth a
+// path-traced image at a handful of samples per pixel without washing out
+// genuine detail.
+var defaultDenoiseSigmas = BilateralSigmas{Spatial: 2, Color: 0.1, Normal: 0.1, Albedo: 0.1}
+
+// defaultDenoiseIterations is the À-Trous round count RenderOptions.Denoise
+// uses when DenoiseIterations is left at zero, matching DenoiseATrous's own
+// usual depth.
+const defaultDenoiseIterations = 5
+
+// DenoiseATrous returns a Pass running an edge-aware À-Trous wavelet
+// denoiser for iterations rounds. Round i convolves with the 5x5
+// separable B-spline kernel holed out to a tap spacing of 2^i pixels, so
+// iterations=5 reaches an effective 65x65 support for 5*25 taps instead
+// of a plain Gaussian's ~4000 -- the usual reason this filter is preferred
+// over blurring with a same-radius kernel directly. Each tap is
+// additionally weighted by how much its color, first-hit normal,
+// first-hit albedo (aux.Normal/aux.Albedo), and pixel offset (scaled by
+// the current tap spacing) differ from the center pixel's, per sigmas, so
+// edges (material or geometric) aren't blurred across. Like
+// ApplyBilateralRGBA, a nil Aux field drops that term (the neighbor
+// always "matches") rather than panicking.
+func DenoiseATrous(sigmas BilateralSigmas, iterations int) Pass {
+	return func(buf *RadianceBuffer, aux Aux) *RadianceBuffer {
+		for i := 0; i < iterations; i++ {
+			buf = atrousIteration(buf, aux, sigmas, 1<<uint(i))
+		}
+		return buf
+	}
+}
+
+// atrousIteration runs one hole-spaced round of DenoiseATrous's kernel
+// over src, stepping each of the 5x5 taps by step pixels.
+func atrousIteration(src *RadianceBuffer, aux Aux, sigmas BilateralSigmas, step int) *RadianceBuffer {
+	rgbAt := func(img *image.RGBA, x, y int) (float64, float64, float64) {
+		r, g, b, _ := img.At(x, y).RGBA()
+		return float64(r) / 65535.0, float64(g) / 65535.0, float64(b) / 65535.0
+	}
+	gaussianTerm := func(d2, sigma float64) float64 {
+		if sigma <= 0 {
+			return 1
+		}
+		return math.Exp(-d2 / (2 * sigma * sigma))
+	}
+
+	dst := newRadianceBuffer(src.Dx, src.Dy)
+	for y := 0; y < src.Dy; y++ {
+		for x := 0; x < src.Dx; x++ {
+			c := src.at(x, y)
+			var nr, ng, nb float64
+			if aux.Normal != nil {
+				nr, ng, nb = rgbAt(aux.Normal, x, y)
+			}
+			var ar, ag, ab float64
+			if aux.Albedo != nil {
+				ar, ag, ab = rgbAt(aux.Albedo, x, y)
+			}
+
+			var sumW, sr, sg, sb float64
+			for j := -2; j <= 2; j++ {
+				yj := y + j*step
+				if yj < 0 || yj >= src.Dy {
+					continue
+				}
+				wy := atrousKernel1D[j+2]
+				for i := -2; i <= 2; i++ {
+					xi := x + i*step
+					if xi < 0 || xi >= src.Dx {
+						continue
+					}
+					n := src.at(xi, yj)
+					dr, dg, db := n.X-c.X, n.Y-c.Y, n.Z-c.Z
+					weight := atrousKernel1D[i+2] * wy * gaussianTerm(dr*dr+dg*dg+db*db, sigmas.Color)
+					// Position term uses sigma scaled by step, not the
+					// raw pixel offset: i/j (the kernel's own tap
+					// indices, -2..2) are what sigmas.Spatial is meant to
+					// compare against, scaled the same way atrousKernel1D
+					// itself is applied unchanged across iterations.
+					// Comparing the step-dilated offset against a fixed
+					// sigma instead would make this term collapse to ~0
+					// past the first iteration or two, silently
+					// defeating the dilating-stencil's wider support.
+					weight *= gaussianTerm(float64(i*i+j*j), sigmas.Spatial)
+					if aux.Normal != nil {
+						nr2, ng2, nb2 := rgbAt(aux.Normal, xi, yj)
+						weight *= gaussianTerm((nr2-nr)*(nr2-nr)+(ng2-ng)*(ng2-ng)+(nb2-nb)*(nb2-nb), sigmas.Normal)
+					}
+					if aux.Albedo != nil {
+						ar2, ag2, ab2 := rgbAt(aux.Albedo, xi, yj)
+						weight *= gaussianTerm((ar2-ar)*(ar2-ar)+(ag2-ag)*(ag2-ag)+(ab2-ab)*(ab2-ab), sigmas.Albedo)
+					}
+					sr += weight * n.X
+					sg += weight * n.Y
+					sb += weight * n.Z
+					sumW += weight
+				}
+			}
+			if sumW > 0 {
+				dst.set(x, y, Spectrum{X: sr / sumW, Y: sg / sumW, Z: sb / sumW})
+			} else {
+				dst.set(x, y, c)
+			}
+		}
+	}
+	return dst
+}
+
+// Bloom returns a Pass implementing a classic bright-pass-and-blur glow:
+// every pixel whose Spectrum.Luminance exceeds threshold is extracted
+// into its own buffer, blurred with a separable Gaussian of the given
+// radius (sigma = radius/3, GaussianFilter's convention), and added back
+// onto the image scaled by intensity. This lets a saturated highlight
+// (the sun disc, an emissive material) bleed light into its neighbors the
+// way a real camera lens would, instead of just hard-clipping at white.
+func Bloom(threshold, intensity float64, radius int) Pass {
+	return func(buf *RadianceBuffer, aux Aux) *RadianceBuffer {
+		bright := newRadianceBuffer(buf.Dx, buf.Dy)
+		for i, s := range buf.Pix {
+			if s.Luminance() > threshold {
+				bright.Pix[i] = s
+			}
+		}
+		blurred := blurSeparable(bright, radius)
+		out := buf.clone()
+		for i := range out.Pix {
+			out.Pix[i] = out.Pix[i].Add(blurred.Pix[i].Muls(intensity))
+		}
+		return out
+	}
+}
+
+// blurSeparable runs a two-pass Gaussian blur (sigma = radius/3) over buf
+// and returns the result as a new buffer.
+func blurSeparable(buf *RadianceBuffer, radius int) *RadianceBuffer {
+	if radius <= 0 {
+		return buf
+	}
+	sigma := float64(radius) / 3
+	weight := func(d int) float64 {
+		x := float64(d)
+		return math.Exp(-(x * x) / (2 * sigma * sigma))
+	}
+
+	horiz := newRadianceBuffer(buf.Dx, buf.Dy)
+	for y := 0; y < buf.Dy; y++ {
+		for x := 0; x < buf.Dx; x++ {
+			var sum Spectrum
+			var wsum float64
+			for d := -radius; d <= radius; d++ {
+				xi := x + d
+				if xi < 0 || xi >= buf.Dx {
+					continue
+				}
+				w := weight(d)
+				sum = sum.Add(buf.at(xi, y).Muls(w))
+				wsum += w
+			}
+			if wsum > 0 {
+				sum = sum.Divs(wsum)
+			}
+			horiz.set(x, y, sum)
+		}
+	}
+
+	out := newRadianceBuffer(buf.Dx, buf.Dy)
+	for y := 0; y < buf.Dy; y++ {
+		for x := 0; x < buf.Dx; x++ {
+			var sum Spectrum
+			var wsum float64
+			for d := -radius; d <= radius; d++ {
+				yi := y + d
+				if yi < 0 || yi >= buf.Dy {
+					continue
+				}
+				w := weight(d)
+				sum = sum.Add(horiz.at(x, yi).Muls(w))
+				wsum += w
+			}
+			if wsum > 0 {
+				sum = sum.Divs(wsum)
+			}
+			out.set(x, y, sum)
+		}
+	}
+	return out
+}
+
+// ToneMapOperator selects the curve ToneMap compresses linear HDR
+// radiance through. The zero value, ToneMapReinhard, is the simple
+// Reinhard operator.
+type ToneMapOperator int
+
+const (
+	// ToneMapReinhard applies c/(1+c) per channel: cheap, monotonic, and
+	// maps all of [0, inf) into [0, 1), but desaturates bright highlights
+	// since each channel is compressed independently.
+	ToneMapReinhard ToneMapOperator = iota
+	// ToneMapACESFilmic applies Narkowicz's fit to the ACES filmic
+	// reference curve (the one Unreal/Unity popularized): a cheap
+	// rational approximation with more filmic highlight rolloff than
+	// Reinhard.
+	ToneMapACESFilmic
+	// ToneMapUncharted2 applies John Hable's filmic curve from
+	// Uncharted 2, a Reinhard-derived formula with separate shoulder,
+	// toe, and linear-section controls that preserves more shadow and
+	// midtone contrast than ToneMapACESFilmic at the cost of requiring
+	// the white-point division applied in uncharted2Tonemap.
+	ToneMapUncharted2
+)
+
+// ToneMap returns a Pass that multiplies every pixel by 2^exposure (stops
+// of exposure compensation) and compresses the result into [0, 1] with
+// op, so a later ToRGBA's 8-bit clamp rolls off a bright HDR skybox or
+// emitter instead of clipping it to flat white.
+func ToneMap(op ToneMapOperator, exposure float64) Pass {
+	curve := reinhardTonemap
+	switch op {
+	case ToneMapACESFilmic:
+		curve = acesFilmicTonemap
+	case ToneMapUncharted2:
+		curve = uncharted2Tonemap
+	}
+	scale := math.Pow(2, exposure)
+	return func(buf *RadianceBuffer, aux Aux) *RadianceBuffer {
+		out := newRadianceBuffer(buf.Dx, buf.Dy)
+		for i, s := range buf.Pix {
+			s = s.Muls(scale)
+			out.Pix[i] = Spectrum{X: curve(s.X), Y: curve(s.Y), Z: curve(s.Z)}
+		}
+		return out
+	}
+}
+
+// reinhardTonemap is the simple per-channel Reinhard operator, c/(1+c).
+func reinhardTonemap(c float64) float64 {
+	if c < 0 {
+		return 0
+	}
+	return c / (1 + c)
+}
+
+// acesFilmicTonemap is Krzysztof Narkowicz's rational fit to the ACES
+// filmic reference curve (RRT+ODT), clamped to [0, 1].
+func acesFilmicTonemap(c float64) float64 {
+	const a, b, cc, d, e = 2.51, 0.03, 2.43, 0.59, 0.14
+	if c < 0 {
+		c = 0
+	}
+	return clamp((c*(a*c+b))/(c*(cc*c+d)+e), 0, 1)
+}
+
+// uncharted2WhitePoint is the linear radiance value uncharted2Tonemap
+// treats as displayed white, applied as the denominator in
+// uncharted2Tonemap so the curve itself maps to [0, 1]. It's the value
+// Hable's original Uncharted 2 demo used.
+const uncharted2WhitePoint = 11.2
+
+// uncharted2Curve is the shoulder/toe/linear filmic curve John Hable
+// presented for Uncharted 2: a Reinhard-derived formula with independent
+// control over shoulder strength (a), linear strength (b), linear angle
+// (c), toe strength (d), toe numerator (e), and toe denominator (f).
+func uncharted2Curve(c float64) float64 {
+	const a, b, cc, d, e, f = 0.15, 0.50, 0.10, 0.20, 0.02, 0.30
+	return ((c*(a*c+cc*b)+d*e)/(c*(a*c+b)+d*f) - e/f)
+}
+
+// uncharted2Tonemap applies uncharted2Curve to c and divides by the same
+// curve applied to uncharted2WhitePoint, so a pixel at the white point
+// maps to exactly 1 instead of the curve's own asymptote.
+func uncharted2Tonemap(c float64) float64 {
+	if c < 0 {
+		c = 0
+	}
+	return clamp(uncharted2Curve(c)/uncharted2Curve(uncharted2WhitePoint), 0, 1)
+}
+
+// SRGBEncode returns a Pass that gamma-encodes buf from linear light to
+// sRGB per channel, the inverse of srgbToLinear. Run it last, after
+// ToneMap has already compressed radiance into [0, 1]; linearToSRGB
+// assumes its input is already in that range.
+func SRGBEncode() Pass {
+	return func(buf *RadianceBuffer, aux Aux) *RadianceBuffer {
+		out := newRadianceBuffer(buf.Dx, buf.Dy)
+		for i, s := range buf.Pix {
+			out.Pix[i] = Spectrum{X: linearToSRGB(s.X), Y: linearToSRGB(s.Y), Z: linearToSRGB(s.Z)}
+		}
+		return out
+	}
+}
+
+// linearToSRGB converts one linear-light channel value in [0, 1] to
+// sRGB-encoded, the inverse of srgbToLinear.
+func linearToSRGB(c float64) float64 {
+	c = clamp(c, 0, 1)
+	if c <= 0.0031308 {
+		return 12.92 * c
+	}
+	return 1.055*math.Pow(c, 1/2.4) - 0.055
+}
+
+// DefaultGamma is the exponent Gamma uses when called with 0, the
+// standard approximation of a display's response curve.
+const DefaultGamma = 2.2
+
+// Gamma returns a Pass that raises every channel to 1/exponent, a cheap
+// power-law display encoding. exponent of 0 uses DefaultGamma. Unlike
+// SRGBEncode's piecewise sRGB transfer function, this is the plain
+// textbook gamma curve -- pick it over SRGBEncode when matching a
+// specific display's stated gamma matters more than sRGB's exact
+// toe-and-power shape. Run it last, after ToneMap has already compressed
+// radiance into [0, 1].
+func Gamma(exponent float64) Pass {
+	if exponent == 0 {
+		exponent = DefaultGamma
+	}
+	invExponent := 1 / exponent
+	return func(buf *RadianceBuffer, aux Aux) *RadianceBuffer {
+		out := newRadianceBuffer(buf.Dx, buf.Dy)
+		for i, s := range buf.Pix {
+			out.Pix[i] = Spectrum{
+				X: math.Pow(clamp(s.X, 0, 1), invExponent),
+				Y: math.Pow(clamp(s.Y, 0, 1), invExponent),
+				Z: math.Pow(clamp(s.Z, 0, 1), invExponent),
+			}
+		}
+		return out
+	}
+}
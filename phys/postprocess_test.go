@@ -0,0 +1,245 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import (
+	"context"
+	"image"
+	"math"
+	"testing"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
+)
+
+// TestDenoiseATrousPreservesFlatRegion verifies a uniformly colored buffer
+// is unchanged by denoising (every neighbor agrees, so the weighted
+// average is the same radiance), the same property
+// TestApplyBilateralRGBAPreservesFlatRegion checks for the joint
+// bilateral filter.
+func TestDenoiseATrousPreservesFlatRegion(t *testing.T) {
+	buf := newRadianceBuffer(8, 8)
+	for i := range buf.Pix {
+		buf.Pix[i] = Spectrum{X: 0.5, Y: 0.25, Z: 0.125}
+	}
+	out := DenoiseATrous(BilateralSigmas{Spatial: 2, Color: 0.1, Normal: 0.1, Albedo: 0.1}, 3)(buf, Aux{})
+	got := out.at(4, 4)
+	if math.Abs(got.X-0.5) > 1e-6 || math.Abs(got.Y-0.25) > 1e-6 || math.Abs(got.Z-0.125) > 1e-6 {
+		t.Errorf("DenoiseATrous on a flat buffer = %+v, want {0.5, 0.25, 0.125}", got)
+	}
+}
+
+// TestBloomAddsGlowAroundBrightPixel verifies a single bright pixel
+// bleeds some of its radiance into a neighbor, and leaves a neighbor
+// below threshold unchanged by anything but that bleed.
+func TestBloomAddsGlowAroundBrightPixel(t *testing.T) {
+	buf := newRadianceBuffer(9, 9)
+	buf.set(4, 4, Spectrum{X: 10, Y: 10, Z: 10})
+	out := Bloom(1.0, 0.5, 2)(buf, Aux{})
+	if got := out.at(5, 4).X; got <= 0 {
+		t.Errorf("neighbor of bloomed pixel = %v, want > 0 (glow bled in)", got)
+	}
+	if got := out.at(4, 4).X; got < 10 {
+		t.Errorf("bloomed pixel itself = %v, want >= 10 (original plus glow)", got)
+	}
+}
+
+// TestToneMapReinhardCompressesToUnitRange verifies Reinhard maps
+// arbitrarily bright radiance into [0, 1) instead of letting it later
+// clip to flat white in ToRGBA.
+func TestToneMapReinhardCompressesToUnitRange(t *testing.T) {
+	buf := newRadianceBuffer(1, 1)
+	buf.set(0, 0, Spectrum{X: 1000, Y: 1000, Z: 1000})
+	out := ToneMap(ToneMapReinhard, 0)(buf, Aux{})
+	got := out.at(0, 0)
+	if got.X <= 0 || got.X >= 1 {
+		t.Errorf("ToneMapReinhard(1000) = %v, want in (0, 1)", got.X)
+	}
+}
+
+// TestToneMapUncharted2CompressesToUnitRange verifies ToneMapUncharted2
+// maps a pixel below its white point into (0, 1), the same contract
+// TestToneMapReinhardCompressesToUnitRange checks for ToneMapReinhard.
+func TestToneMapUncharted2CompressesToUnitRange(t *testing.T) {
+	buf := newRadianceBuffer(1, 1)
+	buf.set(0, 0, Spectrum{X: 1, Y: 1, Z: 1})
+	out := ToneMap(ToneMapUncharted2, 0)(buf, Aux{})
+	got := out.at(0, 0)
+	if got.X <= 0 || got.X >= 1 {
+		t.Errorf("ToneMapUncharted2(1) = %v, want in (0, 1)", got.X)
+	}
+}
+
+// TestToRGBAClampsToByteRange verifies ToRGBA clamps out-of-range linear
+// radiance instead of overflowing or underflowing the uint8 channels.
+func TestToRGBAClampsToByteRange(t *testing.T) {
+	buf := newRadianceBuffer(2, 1)
+	buf.set(0, 0, Spectrum{X: -1, Y: 0.5, Z: 10})
+	img := buf.ToRGBA()
+	r, g, b, _ := img.At(0, 0).RGBA()
+	if r != 0 {
+		t.Errorf("R channel for negative radiance = %v, want 0", r>>8)
+	}
+	if b != 65535 {
+		t.Errorf("B channel for radiance=10 = %v, want 255 (clamped)", b>>8)
+	}
+	_ = g
+}
+
+// TestSRGBEncodeRoundTripsWithSRGBToLinear verifies linearToSRGB inverts
+// srgbToLinear (up to floating point error), since PostProcess pipelines
+// and buildMipPyramid must agree on the same sRGB transfer function.
+func TestSRGBEncodeRoundTripsWithSRGBToLinear(t *testing.T) {
+	for _, c := range []float64{0, 0.02, 0.18, 0.5, 1} {
+		got := linearToSRGB(srgbToLinear(c))
+		if math.Abs(got-c) > 1e-9 {
+			t.Errorf("linearToSRGB(srgbToLinear(%v)) = %v, want %v", c, got, c)
+		}
+	}
+}
+
+// TestGammaMatchesPowerLaw verifies Gamma raises each channel to
+// 1/exponent, and that a zero exponent falls back to DefaultGamma.
+func TestGammaMatchesPowerLaw(t *testing.T) {
+	buf := newRadianceBuffer(1, 1)
+	buf.set(0, 0, Spectrum{X: 0.5, Y: 0.5, Z: 0.5})
+
+	want := math.Pow(0.5, 1/2.4)
+	got := Gamma(2.4)(buf, Aux{}).at(0, 0)
+	if math.Abs(got.X-want) > 1e-9 {
+		t.Errorf("Gamma(2.4) = %v, want %v", got.X, want)
+	}
+
+	wantDefault := math.Pow(0.5, 1/DefaultGamma)
+	gotDefault := Gamma(0)(buf, Aux{}).at(0, 0)
+	if math.Abs(gotDefault.X-wantDefault) > 1e-9 {
+		t.Errorf("Gamma(0) = %v, want %v (DefaultGamma)", gotDefault.X, wantDefault)
+	}
+}
+
+// denoiseTestScene is a diffuse ball on a diffuse floor lit by a single
+// PointLight, with enough MaxRayDepth for indirect-bounce Monte Carlo noise
+// (PointLight itself is deterministically sampled, so at low RaysPerPixel
+// the grain comes from each pixel's random Lambertian bounce direction, the
+// kind of noise DenoiseATrous is meant to smooth without blurring the
+// ball/floor edge).
+func denoiseTestScene(raysPerPixel int, denoise bool) *Scene {
+	scene := &Scene{
+		RenderOptions: RenderOptions{
+			Seed:         1,
+			RaysPerPixel: raysPerPixel,
+			MaxRayDepth:  3,
+			Dx:           24,
+			Dy:           24,
+			Denoise:      denoise,
+		},
+		Camera: []Camera{OrthographicCamera{
+			FOVWidth: 4, FOVHeight: 4,
+			LookFrom: r3.Point{Y: 1.5, Z: 5}, LookAt: r3.Point{Y: 1}, VUp: r3.Vec{Y: 1},
+		}},
+		Light: []Light{PointLight{Position: r3.Point{X: 3, Y: 5, Z: 3}, RadiantIntensity: r3.Vec{X: 0.5, Y: 0.5, Z: 0.5}}},
+	}
+	scene.Add(Node{
+		Name:     "Floor",
+		Shape:    Sphere{Center: r3.Point{Y: -1000}, Radius: 1000},
+		Material: Lambertian{Texture: TextureUniform{Color: Spectrum{X: 0.6, Y: 0.6, Z: 0.6}}},
+	})
+	scene.Add(Node{
+		Name:     "Ball",
+		Shape:    Sphere{Center: r3.Point{Y: 1}, Radius: 1},
+		Material: Lambertian{Texture: TextureUniform{Color: Spectrum{X: 0.8, Y: 0.3, Z: 0.3}}},
+	})
+	return scene
+}
+
+// TestRenderExposesRadiance verifies RenderArtifact.Radiance is nil when
+// neither PostProcess nor Denoise is set, and populated with the
+// pre-post-process linear HDR buffer (not the tonemapped Image) when
+// PostProcess is set, so a caller can run its own Bloom pass against it.
+func TestRenderExposesRadiance(t *testing.T) {
+	ctx := context.Background()
+	plain := denoiseTestScene(4, false)
+	out, err := Render(ctx, plain)
+	if err != nil {
+		t.Fatalf("Render(plain) error = %v", err)
+	}
+	if out.Radiance != nil {
+		t.Errorf("Radiance = %v, want nil with no PostProcess/Denoise set", out.Radiance)
+	}
+
+	bloomed := denoiseTestScene(4, false)
+	bloomed.RenderOptions.PostProcess = []Pass{Bloom(0.8, 1, 2)}
+	out, err = Render(ctx, bloomed)
+	if err != nil {
+		t.Fatalf("Render(bloomed) error = %v", err)
+	}
+	if out.Radiance == nil {
+		t.Fatal("Radiance = nil, want a populated buffer when PostProcess is set")
+	}
+	if out.Radiance.Dx != bloomed.RenderOptions.Dx || out.Radiance.Dy != bloomed.RenderOptions.Dy {
+		t.Errorf("Radiance dims = %dx%d, want %dx%d", out.Radiance.Dx, out.Radiance.Dy, bloomed.RenderOptions.Dx, bloomed.RenderOptions.Dy)
+	}
+	// Running Bloom again against the exposed buffer should not panic and
+	// should produce an image the same size as the one PostProcess already
+	// encoded, confirming Radiance is a real, independently usable buffer
+	// rather than a husk left over from the pipeline that consumed it.
+	again := runPostProcess(out.Radiance, Aux{}, []Pass{Bloom(0.8, 1, 2)})
+	if got, want := again.Bounds(), out.Image.Bounds(); got != want {
+		t.Errorf("re-running Bloom on Radiance: bounds = %v, want %v", got, want)
+	}
+}
+
+// psnrRGBA returns the peak signal-to-noise ratio, in dB, between a and b's
+// RGB channels (assumed equal bounds), the standard metric for how close a
+// denoised/compressed image is to a reference.
+func psnrRGBA(a, b *image.RGBA) float64 {
+	var sumSq float64
+	var n int
+	bounds := a.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			ar, ag, ab, _ := a.At(x, y).RGBA()
+			br, bg, bb, _ := b.At(x, y).RGBA()
+			for _, d := range []float64{
+				float64(ar>>8) - float64(br>>8),
+				float64(ag>>8) - float64(bg>>8),
+				float64(ab>>8) - float64(bb>>8),
+			} {
+				sumSq += d * d
+				n++
+			}
+		}
+	}
+	mse := sumSq / float64(n)
+	if mse == 0 {
+		return math.Inf(1)
+	}
+	return 10 * math.Log10(255*255/mse)
+}
+
+// TestRenderOptionsDenoiseImprovesPSNR verifies a render with Denoise set
+// comes out closer (higher PSNR) to a high-sample reference render than the
+// same low-sample render left undenoised, confirming DenoiseATrous actually
+// recovers some of what the missing samples cost instead of just blurring
+// the image in a way that happens not to regress this test.
+func TestRenderOptionsDenoiseImprovesPSNR(t *testing.T) {
+	ctx := context.Background()
+
+	reference, err := Render(ctx, denoiseTestScene(256, false))
+	if err != nil {
+		t.Fatalf("Render(reference) error = %v", err)
+	}
+
+	noisy, err := Render(ctx, denoiseTestScene(4, false))
+	if err != nil {
+		t.Fatalf("Render(noisy) error = %v", err)
+	}
+	denoised, err := Render(ctx, denoiseTestScene(4, true))
+	if err != nil {
+		t.Fatalf("Render(denoised) error = %v", err)
+	}
+
+	noisyPSNR := psnrRGBA(noisy.Image, reference.Image)
+	denoisedPSNR := psnrRGBA(denoised.Image, reference.Image)
+	if denoisedPSNR <= noisyPSNR {
+		t.Errorf("PSNR vs reference: denoised = %.2f dB, noisy = %.2f dB; want denoised > noisy", denoisedPSNR, noisyPSNR)
+	}
+}
@@ -0,0 +1,258 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+
+package phys
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
+)
+
+// shBasis9 evaluates the 9 real spherical harmonic basis functions (l <= 2)
+// at the unit direction d, using the normalization constants from Green's
+// "Spherical Harmonic Lighting: The Gritty Details": Y00 = 0.282095,
+// Y1{-1,0,1} = 0.488603*(y,z,x), Y2-2 = 1.092548*xy, Y2-1 = 1.092548*yz,
+// Y20 = 0.315392*(3z^2-1), Y21 = 1.092548*xz, Y22 = 0.546274*(x^2-y^2).
+// The returned array is indexed band-major: [0]=Y00, [1..3]=l=1, [4..8]=l=2,
+// the order Probe.Coefficients and probeBandWeights share.
+func shBasis9(d r3.Vec) [9]float64 {
+	x, y, z := d.X, d.Y, d.Z
+	return [9]float64{
+		0.282095,
+		0.488603 * y,
+		0.488603 * z,
+		0.488603 * x,
+		1.092548 * x * y,
+		1.092548 * y * z,
+		0.315392 * (3*z*z - 1),
+		1.092548 * x * z,
+		0.546274 * (x*x - y*y),
+	}
+}
+
+// probeBandWeights holds the Ramamoorthi-Hanrahan convolution constants
+// that turn a radiance projection into a diffuse irradiance reconstruction,
+// one weight per SH band: A0 = pi for l=0, A1 = 2*pi/3 for l=1, A2 = pi/4
+// for l=2. Index i is weighted by probeBandWeights[bandOf(i)].
+var probeBandWeights = [9]float64{
+	math.Pi,         // Y00
+	2 * math.Pi / 3, // Y1-1
+	2 * math.Pi / 3, // Y10
+	2 * math.Pi / 3, // Y11
+	math.Pi / 4,     // Y2-2
+	math.Pi / 4,     // Y2-1
+	math.Pi / 4,     // Y20
+	math.Pi / 4,     // Y21
+	math.Pi / 4,     // Y22
+}
+
+// Probe is a baked irradiance probe: the incoming radiance at Center,
+// integrated over the sphere of directions and projected onto the first
+// 9 real spherical harmonics (l <= 2), one projection per RGB channel.
+// See BakeProbe to produce one and ProbeVolume/ProbeLight to shade with it.
+type Probe struct {
+	Center       r3.Point
+	Bounds       AABB          // Region this probe is considered valid over; see ProbeVolume.
+	Coefficients [3][9]float64 // [channel][SH band], channel order R, G, B.
+}
+
+func (p *Probe) Validate() error {
+	if p == nil {
+		return fmt.Errorf("nil Probe")
+	}
+	return nil
+}
+
+// Irradiance reconstructs the diffuse irradiance arriving at the probe's
+// baked point from direction n (the surface normal), via the
+// Ramamoorthi-Hanrahan convolution: sum_i coefficient_i * A(band(i)) * Y_i(n).
+func (p *Probe) Irradiance(n r3.Vec) Spectrum {
+	basis := shBasis9(n.Unit())
+	var out Spectrum
+	for channel := 0; channel < 3; channel++ {
+		sum := 0.0
+		for i, y := range basis {
+			sum += p.Coefficients[channel][i] * probeBandWeights[i] * y
+		}
+		switch channel {
+		case 0:
+			out.X = sum
+		case 1:
+			out.Y = sum
+		case 2:
+			out.Z = sum
+		}
+	}
+	return out
+}
+
+// BakeProbe shoots samples rays uniformly over the unit sphere from
+// center, evaluates the scene's incoming radiance L(omega) along each with
+// tracePath, and projects the result onto the 9-term SH basis, accumulating
+// coefficient_i += L(omega)*Y_i(omega)*(4*pi/samples) per Lundholm/Green's
+// Monte-Carlo SH projection estimator. The probe's Bounds default to a
+// single point at center; callers that want it to cover a region for
+// ProbeVolume blending should widen Bounds after baking.
+func BakeProbe(ctx context.Context, scene *Scene, center r3.Point, samples int) (*Probe, error) {
+	if samples <= 0 {
+		return nil, fmt.Errorf("BakeProbe: samples must be positive, got %d", samples)
+	}
+	rand := NewRand(0)
+	stats := &RenderStats{}
+	probe := &Probe{
+		Center: center,
+		Bounds: AABB{Min: center, Max: center},
+	}
+	weight := 4 * math.Pi / float64(samples)
+	for i := 0; i < samples; i++ {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		dir := rand.UnitVector()
+		r := ray{
+			origin:    center,
+			direction: dir,
+			depth:     0,
+			radiance:  Spectrum{X: 1, Y: 1, Z: 1},
+			rand:      rand,
+		}
+		radiance := tracePath(ctx, scene, r, stats, nil)
+		basis := shBasis9(dir)
+		channels := [3]float64{radiance.X, radiance.Y, radiance.Z}
+		for channel, l := range channels {
+			for band, y := range basis {
+				probe.Coefficients[channel][band] += l * y * weight
+			}
+		}
+	}
+	return probe, nil
+}
+
+// ProbeVolume blends one or more baked Probes over a region, Armory-engine
+// style: a shading point inside a probe's own Bounds is lit by every such
+// probe, weighted by inverse-square distance to each probe's Center; a
+// point outside every probe's Bounds falls back to the single nearest
+// probe, so shading never goes fully dark between sparse probes.
+type ProbeVolume struct {
+	Bounds AABB
+	Probes []*Probe
+}
+
+func (v *ProbeVolume) Validate() error {
+	if v == nil || len(v.Probes) == 0 {
+		return fmt.Errorf("ProbeVolume: must hold at least one Probe")
+	}
+	for i, p := range v.Probes {
+		if err := p.Validate(); err != nil {
+			return fmt.Errorf("probe %d: %v", i, err)
+		}
+	}
+	return nil
+}
+
+func (b AABB) containsPoint(p r3.Point) bool {
+	return p.X >= b.Min.X && p.X <= b.Max.X &&
+		p.Y >= b.Min.Y && p.Y <= b.Max.Y &&
+		p.Z >= b.Min.Z && p.Z <= b.Max.Z
+}
+
+// Irradiance blends every probe in v whose Bounds contains p, or falls back
+// to the nearest probe by distance if none do, then reconstructs diffuse
+// irradiance toward n from the blended result.
+func (v *ProbeVolume) Irradiance(p r3.Point, n r3.Vec) Spectrum {
+	type weighted struct {
+		probe  *Probe
+		weight float64
+	}
+	var contained []weighted
+	for _, probe := range v.Probes {
+		if probe.Bounds.containsPoint(p) {
+			d2 := p.Sub(probe.Center).Dot(p.Sub(probe.Center))
+			contained = append(contained, weighted{probe, 1 / math.Max(d2, 1e-6)})
+		}
+	}
+	if len(contained) == 0 {
+		nearest := v.Probes[0]
+		best := p.Sub(nearest.Center).Dot(p.Sub(nearest.Center))
+		for _, probe := range v.Probes[1:] {
+			d2 := p.Sub(probe.Center).Dot(p.Sub(probe.Center))
+			if d2 < best {
+				best = d2
+				nearest = probe
+			}
+		}
+		return nearest.Irradiance(n)
+	}
+	var sum Spectrum
+	totalWeight := 0.0
+	for _, w := range contained {
+		sum = sum.Add(w.probe.Irradiance(n).Muls(w.weight))
+		totalWeight += w.weight
+	}
+	return sum.Divs(totalWeight)
+}
+
+// ProbeLight makes a baked ProbeVolume available as a Light: Lambertian
+// and PBR both recognize *ProbeLight specially in ComputeDirectLighting
+// and add albedo/pi * Volume.Irradiance(p, n) directly, untraced, since
+// Armory-style probe lighting approximates precomputed ambient/indirect
+// illumination rather than a shadow-testable direct light. Sample still
+// implements the Light interface so a ProbeLight sitting in Scene.Light
+// is usable by generic code that doesn't know about the specialization
+// (e.g. BDPT's light-vertex connection); lacking the shading normal there,
+// it approximates the omnidirectional irradiance by reconstructing toward
+// a uniformly sampled direction instead of the true surface normal.
+type ProbeLight struct {
+	Volume *ProbeVolume
+}
+
+func (pl ProbeLight) Validate() error {
+	return pl.Volume.Validate()
+}
+
+func (pl ProbeLight) Sample(p r3.Point, rand *Rand) (direction r3.Vec, distance Distance, radiance r3.Vec) {
+	direction = rand.UnitVector()
+	irradiance := pl.Volume.Irradiance(p, direction)
+	return direction, Distance(math.MaxFloat64), r3.Vec(irradiance.Muls(1 / math.Pi))
+}
+
+// Pdf returns 1/(4*pi), the constant solid-angle density of Sample's
+// uniform sphere direction: unlike every other Light in this package,
+// ProbeLight isn't a delta light, so (unlike their Pdf) this value is
+// meant to be used in a two-strategy MIS combination once one exists for
+// scene.Light, rather than always contributing 0.
+func (pl ProbeLight) Pdf(p r3.Point, dir r3.Vec) float64 {
+	return 1 / (4 * math.Pi)
+}
+
+// EmittedRadiance evaluates the same reconstructed irradiance Sample does,
+// but at a caller-chosen dir rather than a uniformly sampled one, and
+// always "hits" (distance math.MaxFloat64): unlike the delta lights in
+// this package, a BSDF-sampled direction can always connect to a
+// ProbeLight's continuous, omnidirectional field, the two-strategy MIS
+// combination Pdf's doc comment anticipates.
+func (pl ProbeLight) EmittedRadiance(p r3.Point, dir r3.Vec) (r3.Vec, Distance) {
+	irradiance := pl.Volume.Irradiance(p, dir)
+	return r3.Vec(irradiance.Muls(1 / math.Pi)), Distance(math.MaxFloat64)
+}
+
+// probeAmbient sums albedo/pi * Volume.Irradiance(p, n) over every
+// *ProbeLight in scene.Light, the shared helper Lambertian and PBR call
+// from ComputeDirectLighting to fold in baked probe lighting.
+func probeAmbient(scene *Scene, p r3.Point, n r3.Vec, albedo Spectrum) Spectrum {
+	var out Spectrum
+	for _, light := range scene.Light {
+		pl, ok := light.(ProbeLight)
+		if !ok {
+			continue
+		}
+		out = out.Add(albedo.Mul(pl.Volume.Irradiance(p, n)).Muls(1 / math.Pi))
+	}
+	return out
+}
+
+func init() {
+	RegisterInterfaceType(ProbeLight{})
+}
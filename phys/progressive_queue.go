@@ -0,0 +1,126 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+
+package phys
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+	"time"
+)
+
+// RenderRequest is one entry in a ProgressiveQueue: a request to
+// re-render at RaysPerPixel quality, not due until ReadyAt, with ties
+// and preemption broken by Priority (higher wins).
+type RenderRequest struct {
+	RaysPerPixel int
+	ReadyAt      time.Time
+	Priority     int
+}
+
+// ProgressiveQueue schedules re-render passes for an interactively moved
+// camera: a view change enqueues an immediate low-sample preview
+// (high Priority, ReadyAt now) and a delayed, higher-quality convergence
+// pass (low Priority, ReadyAt now+TTL). Pop returns whichever queued
+// request is both due and highest priority, so a fresh preview preempts
+// a convergence pass still waiting out its TTL, while a due convergence
+// pass is not starved by a flood of previews. The zero value is not
+// useful; construct with NewProgressiveQueue.
+type ProgressiveQueue struct {
+	mu    sync.Mutex
+	cond  *sync.Cond
+	items requestHeap
+}
+
+// NewProgressiveQueue returns an empty ProgressiveQueue.
+func NewProgressiveQueue() *ProgressiveQueue {
+	q := &ProgressiveQueue{}
+	q.cond = sync.NewCond(&q.mu)
+	return q
+}
+
+// Push enqueues req, waking any goroutine blocked in Pop.
+func (q *ProgressiveQueue) Push(req RenderRequest) {
+	q.mu.Lock()
+	heap.Push(&q.items, req)
+	q.cond.Broadcast()
+	q.mu.Unlock()
+}
+
+// Pop blocks until a queued request is due (its ReadyAt has elapsed),
+// then returns the highest-priority due request. It returns ok=false if
+// ctx is canceled first.
+func (q *ProgressiveQueue) Pop(ctx context.Context) (req RenderRequest, ok bool) {
+	stop := context.AfterFunc(ctx, func() {
+		q.mu.Lock()
+		q.cond.Broadcast()
+		q.mu.Unlock()
+	})
+	defer stop()
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for {
+		if ctx.Err() != nil {
+			return RenderRequest{}, false
+		}
+		if len(q.items) == 0 {
+			q.cond.Wait()
+			continue
+		}
+		if wait := time.Until(q.items[0].ReadyAt); wait > 0 {
+			q.waitOrWake(ctx, wait)
+			continue
+		}
+		return heap.Pop(&q.items).(RenderRequest), true
+	}
+}
+
+// TryPop returns the highest-priority due request without blocking, for
+// callers on a fixed tick (like a render loop already calling this once
+// per frame) that would rather skip a beat than stall waiting for a
+// request's TTL to elapse. ok is false if the queue is empty or its
+// highest-priority request is not yet due.
+func (q *ProgressiveQueue) TryPop() (req RenderRequest, ok bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.items) == 0 || time.Now().Before(q.items[0].ReadyAt) {
+		return RenderRequest{}, false
+	}
+	return heap.Pop(&q.items).(RenderRequest), true
+}
+
+// waitOrWake releases q.mu for up to d (or until ctx is canceled or Push
+// wakes it early), then reacquires it. Called with q.mu held.
+func (q *ProgressiveQueue) waitOrWake(ctx context.Context, d time.Duration) {
+	q.mu.Unlock()
+	defer q.mu.Lock()
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+}
+
+// requestHeap implements container/heap.Interface, ordering by Priority
+// (descending) then ReadyAt (ascending) so Pop's peek at items[0] always
+// sees the request it should service next.
+type requestHeap []RenderRequest
+
+func (h requestHeap) Len() int { return len(h) }
+func (h requestHeap) Less(i, j int) bool {
+	if h[i].Priority != h[j].Priority {
+		return h[i].Priority > h[j].Priority
+	}
+	return h[i].ReadyAt.Before(h[j].ReadyAt)
+}
+func (h requestHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h *requestHeap) Push(x any)   { *h = append(*h, x.(RenderRequest)) }
+func (h *requestHeap) Pop() any {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	*h = old[:n-1]
+	return item
+}
@@ -0,0 +1,107 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestProgressiveQueuePopWaitsForReadyAt(t *testing.T) {
+	q := NewProgressiveQueue()
+	q.Push(RenderRequest{RaysPerPixel: 64, ReadyAt: time.Now().Add(40 * time.Millisecond)})
+
+	start := time.Now()
+	req, ok := q.Pop(context.Background())
+	elapsed := time.Since(start)
+	if !ok {
+		t.Fatal("Pop() ok = false, want true")
+	}
+	if req.RaysPerPixel != 64 {
+		t.Errorf("Pop().RaysPerPixel = %d, want 64", req.RaysPerPixel)
+	}
+	if elapsed < 30*time.Millisecond {
+		t.Errorf("Pop() returned after %v, want it to wait out the request's TTL (~40ms)", elapsed)
+	}
+}
+
+func TestProgressiveQueuePopPrefersHigherPriorityWhenBothDue(t *testing.T) {
+	q := NewProgressiveQueue()
+	now := time.Now()
+	q.Push(RenderRequest{RaysPerPixel: 256, ReadyAt: now, Priority: 0}) // convergence pass
+	q.Push(RenderRequest{RaysPerPixel: 1, ReadyAt: now, Priority: 10})  // immediate preview
+
+	req, ok := q.Pop(context.Background())
+	if !ok {
+		t.Fatal("Pop() ok = false, want true")
+	}
+	if req.RaysPerPixel != 1 {
+		t.Errorf("Pop().RaysPerPixel = %d, want 1 (the higher-priority preview should preempt the convergence pass)", req.RaysPerPixel)
+	}
+
+	req, ok = q.Pop(context.Background())
+	if !ok || req.RaysPerPixel != 256 {
+		t.Errorf("second Pop() = (%+v, %v), want the convergence pass", req, ok)
+	}
+}
+
+func TestProgressiveQueuePopContextCanceled(t *testing.T) {
+	q := NewProgressiveQueue()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if _, ok := q.Pop(ctx); ok {
+			t.Error("Pop() with an already-canceled context ok = true, want false")
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Pop() did not return promptly for a canceled context")
+	}
+}
+
+func TestProgressiveQueueTryPop(t *testing.T) {
+	q := NewProgressiveQueue()
+	if _, ok := q.TryPop(); ok {
+		t.Error("TryPop() on an empty queue ok = true, want false")
+	}
+
+	q.Push(RenderRequest{RaysPerPixel: 4, ReadyAt: time.Now().Add(time.Hour)})
+	if _, ok := q.TryPop(); ok {
+		t.Error("TryPop() with only a not-yet-due request ok = true, want false")
+	}
+
+	q.Push(RenderRequest{RaysPerPixel: 1, ReadyAt: time.Now()})
+	req, ok := q.TryPop()
+	if !ok || req.RaysPerPixel != 1 {
+		t.Errorf("TryPop() = (%+v, %v), want the due request", req, ok)
+	}
+	if _, ok := q.TryPop(); ok {
+		t.Error("TryPop() after draining the due request ok = true, want false")
+	}
+}
+
+func TestProgressiveQueuePushWakesBlockedPop(t *testing.T) {
+	q := NewProgressiveQueue()
+	result := make(chan RenderRequest, 1)
+	go func() {
+		req, _ := q.Pop(context.Background())
+		result <- req
+	}()
+
+	time.Sleep(10 * time.Millisecond) // give Pop time to block on the empty queue
+	q.Push(RenderRequest{RaysPerPixel: 8, ReadyAt: time.Now()})
+
+	select {
+	case req := <-result:
+		if req.RaysPerPixel != 8 {
+			t.Errorf("Pop() after Push = %+v, want RaysPerPixel 8", req)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Pop() did not wake up after Push")
+	}
+}
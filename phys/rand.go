@@ -20,6 +20,40 @@ func NewRand(seed int64) *Rand {
 	return &Rand{rand.New(rand.NewSource(seed))}
 }
 
+// splitMix64 advances Sebastiano Vigna's SplitMix64 generator by one step,
+// turning a low-entropy, sequential input (an image-seed XORed with a
+// pixel coordinate, say) into a well-distributed 64-bit output. It's the
+// mixing primitive pixelSampleSeed chains to fold (seed, x, y, sample)
+// into a single int64 seed.
+func splitMix64(x uint64) uint64 {
+	x += 0x9E3779B97F4A7C15
+	x = (x ^ (x >> 30)) * 0xBF58476D1CE4E5B9
+	x = (x ^ (x >> 27)) * 0x94D049BB133111EB
+	return x ^ (x >> 31)
+}
+
+// pixelSampleSeed derives a 64-bit seed from (seed, x, y, sample) by
+// chaining splitMix64, so NewPixelRand's stream for a given pixel and
+// sample index is the same no matter how the image is split into tiles
+// or scheduled across workers.
+func pixelSampleSeed(seed int64, x, y, sample int) int64 {
+	s := splitMix64(uint64(seed))
+	s = splitMix64(s ^ uint64(uint32(x)))
+	s = splitMix64(s ^ uint64(uint32(y)))
+	s = splitMix64(s ^ uint64(uint32(sample)))
+	return int64(s)
+}
+
+// NewPixelRand returns a Rand seeded deterministically from
+// (seed, x, y, sample) via pixelSampleSeed. renderPixel, renderPixelFilm,
+// renderTileAdaptive, and renderTilePass each call this once per sample
+// instead of sharing one *Rand across a tile or row, so a pixel's Nth
+// sample is bit-exact regardless of RenderOptions.TileSize, worker count,
+// or tile completion order.
+func NewPixelRand(seed int64, x, y, sample int) *Rand {
+	return NewRand(pixelSampleSeed(seed, x, y, sample))
+}
+
 // InUnitSphere returns a random vector uniformly distributed within a unit sphere.
 // Useful for volumetric scattering and diffuse reflections.
 // Length of the vector is guaranteed to be less than 1.
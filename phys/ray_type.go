@@ -0,0 +1,42 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+
+package phys
+
+// RayType classifies why a ray was traced, borrowed from vermeer/core's
+// ray-type flag scheme. The integrator tags every ray it spawns so that
+// materials can branch on how they were reached, e.g. a debug/diagnostic
+// material that should only show up directly to the camera and not
+// contaminate shadow tests or indirect bounces onto neighboring surfaces.
+type RayType uint8
+
+const (
+	// RayTypeCamera is a primary ray cast from a Camera. It is the zero
+	// value, so a ray{} left unset (as in Camera.Cast implementations and
+	// most existing tests) is a camera ray by default.
+	RayTypeCamera RayType = iota
+	// RayTypeShadow is a next-event-estimation visibility ray traced from
+	// a surface toward a light by ComputeDirectLighting.
+	RayTypeShadow
+	// RayTypeReflected is a specular or glossy reflection bounce, e.g.
+	// Mirror's reflected ray or the reflect branch of Glass/Dispersive.
+	RayTypeReflected
+	// RayTypeRefracted is a specular transmission bounce, e.g. the
+	// transmit branch of Glass/Dispersive.
+	RayTypeRefracted
+	// RayTypeGlossy is any other scattered bounce off a non-delta BSDF,
+	// e.g. Lambertian/Diffuse/RoughPlastic's cosine-weighted bounce or
+	// Metal's fuzzy reflection.
+	RayTypeGlossy
+)
+
+// debugEmissionOnly is shared by the Debug* AOV materials: it suppresses
+// their false-color emission unless rayType is RayTypeCamera, so a debug
+// material only shows its diagnostic color directly to the camera and
+// returns black to shadow tests and indirect bounces instead of polluting
+// neighboring surfaces' global illumination.
+func debugEmissionOnly(rayType RayType, emission Spectrum) resolution {
+	if rayType != RayTypeCamera {
+		return resolution{emission: Spectrum{}}
+	}
+	return resolution{emission: emission}
+}
@@ -7,6 +7,7 @@ package phys
 // as long as you consider the chosen kernel to be your sensor’s pixel response.
 
 import (
+	"fmt"
 	"image"
 	"image/color"
 	"image/draw"
@@ -22,7 +23,11 @@ type ReconFilter struct {
 	Radius float64
 	// Eval returns w(|x|) for x in pixels. Implementations MUST return 0 for
 	// |x| >= Radius (compact support).
-	Eval func(x float64) float64
+	//
+	// Excluded from JSON (json:"-"): encoding/json rejects func-typed
+	// fields, the same reason RenderOptions.OnPass carries the tag. This
+	// matters now that RenderOptions.Filter embeds a ReconFilter.
+	Eval func(x float64) float64 `json:"-"`
 }
 
 // BoxFilter returns a box (nearest) kernel with radius 0.5 (pixel average).
@@ -82,6 +87,25 @@ func MitchellNetravaliFilter() ReconFilter {
 	}
 }
 
+// GaussianFilter returns a truncated Gaussian kernel, w(x)=exp(-x^2/(2*sigma^2))
+// for |x| < radius, else 0. radius<=0 defaults to 3*sigma, the point past
+// which a Gaussian's contribution is negligible for any practical sigma.
+func GaussianFilter(sigma, radius float64) ReconFilter {
+	if radius <= 0 {
+		radius = 3 * sigma
+	}
+	return ReconFilter{
+		Name:   fmt.Sprintf("Gaussian(sigma=%v,radius=%v)", sigma, radius),
+		Radius: radius,
+		Eval: func(x float64) float64 {
+			if math.Abs(x) >= radius {
+				return 0
+			}
+			return math.Exp(-(x * x) / (2 * sigma * sigma))
+		},
+	}
+}
+
 // ApplySeparableFilterRGBA applies a separable reconstruction filter to src
 // (assumed to be linear RGB) and returns a new RGBA image. Edges are clamped.
 // This is a postprocess equivalent to reconstructing with that kernel.
@@ -173,3 +197,113 @@ func ApplySeparableFilter(src image.Image, f ReconFilter) *image.RGBA {
 	draw.Draw(rgba, rgba.Bounds(), src, src.Bounds().Min, draw.Src)
 	return ApplySeparableFilterRGBA(rgba, f)
 }
+
+// Aux bundles the auxiliary first-hit buffers JointBilateralFilter weights
+// neighbors by, i.e. RenderArtifact.AuxAlbedo and AuxNormal.
+type Aux struct {
+	Albedo *image.RGBA
+	Normal *image.RGBA
+}
+
+// BilateralSigmas are the per-term standard deviations JointBilateralFilter,
+// ApplyBilateralRGBA, and DenoiseATrous use to weight a neighbor: Spatial
+// over pixel distance, Color over the noisy src image's color difference,
+// Normal over Aux.Normal's difference, and Albedo over Aux.Albedo's
+// difference. Each must be strictly positive.
+type BilateralSigmas struct {
+	Spatial float64
+	Color   float64
+	Normal  float64
+	Albedo  float64
+}
+
+// JointBilateralFilter is a non-separable, edge-preserving denoiser: unlike
+// ReconFilter's purely spatial kernels, each neighbor's weight also falls
+// off with how different it looks from the center pixel in color and (via
+// Aux) shading normal and albedo, so it smooths flat, noisy regions without
+// blurring across geometric or material edges the way a spatial-only
+// kernel would. It is not itself a ReconFilter (its weight depends on pixel
+// content, not just position, so it isn't separable); use
+// ApplyBilateralRGBA to run it over an image.
+type JointBilateralFilter struct {
+	Sigmas BilateralSigmas
+	// Radius is the half-width of the spatial search window in pixels.
+	Radius int
+}
+
+// ApplyBilateralRGBA denoises src (assumed linear RGB) using f, weighting
+// each neighbor within f.Radius pixels by
+// exp(-||dc||^2/2*sigmaC^2) * exp(-||dn||^2/2*sigmaN^2) *
+// exp(-||da||^2/2*sigmaA^2) * exp(-d^2/2*sigmaS^2), where dc/dn/da are the
+// color/normal/albedo differences from aux and src, and d is the pixel
+// distance. aux.Normal and aux.Albedo may be nil, in which case their terms
+// are omitted (treated as always matching), letting this run as a plain
+// (non-cross) bilateral filter over src alone.
+func ApplyBilateralRGBA(src *image.RGBA, aux Aux, f JointBilateralFilter) *image.RGBA {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	dst := image.NewRGBA(b)
+
+	rgb := func(img *image.RGBA, x, y int) (float64, float64, float64) {
+		r8, g8, b8, _ := img.At(b.Min.X+x, b.Min.Y+y).RGBA()
+		return float64(r8) / 65535.0, float64(g8) / 65535.0, float64(b8) / 65535.0
+	}
+	gaussianTerm := func(dr, dg, db, sigma float64) float64 {
+		if sigma <= 0 {
+			return 1
+		}
+		d2 := dr*dr + dg*dg + db*db
+		return math.Exp(-d2 / (2 * sigma * sigma))
+	}
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			cr, cg, cb := rgb(src, x, y)
+			var nr, ng, nb float64
+			if aux.Normal != nil {
+				nr, ng, nb = rgb(aux.Normal, x, y)
+			}
+			var ar, ag, ab float64
+			if aux.Albedo != nil {
+				ar, ag, ab = rgb(aux.Albedo, x, y)
+			}
+
+			x0, x1 := max(0, x-f.Radius), min(w-1, x+f.Radius)
+			y0, y1 := max(0, y-f.Radius), min(h-1, y+f.Radius)
+			var wr, wg, wb, wsum float64
+			for yi := y0; yi <= y1; yi++ {
+				for xi := x0; xi <= x1; xi++ {
+					dr, dg, db := rgb(src, xi, yi)
+					weight := gaussianTerm(dr-cr, dg-cg, db-cb, f.Sigmas.Color)
+					if aux.Normal != nil {
+						nr2, ng2, nb2 := rgb(aux.Normal, xi, yi)
+						weight *= gaussianTerm(nr2-nr, ng2-ng, nb2-nb, f.Sigmas.Normal)
+					}
+					if aux.Albedo != nil {
+						ar2, ag2, ab2 := rgb(aux.Albedo, xi, yi)
+						weight *= gaussianTerm(ar2-ar, ag2-ag, ab2-ab, f.Sigmas.Albedo)
+					}
+					dx, dy := float64(xi-x), float64(yi-y)
+					weight *= gaussianTerm(dx, dy, 0, f.Sigmas.Spatial)
+
+					wr += weight * dr
+					wg += weight * dg
+					wb += weight * db
+					wsum += weight
+				}
+			}
+			if wsum > 0 {
+				wr /= wsum
+				wg /= wsum
+				wb /= wsum
+			}
+			dst.Set(b.Min.X+x, b.Min.Y+y, color.RGBA{
+				R: uint8(math.Max(0, math.Min(255, 255.0*wr))),
+				G: uint8(math.Max(0, math.Min(255, 255.0*wg))),
+				B: uint8(math.Max(0, math.Min(255, 255.0*wb))),
+				A: 255,
+			})
+		}
+	}
+	return dst
+}
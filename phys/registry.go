@@ -0,0 +1,165 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// CameraFactory, LightFactory, ShapeFactory, and MaterialFactory construct a
+// fresh zero-value instance of a concrete type, typically returning a
+// pointer so json.Unmarshal has an addressable target, e.g.:
+//
+//	phys.RegisterCamera("MyCamera", func() phys.Camera { return &MyCamera{} })
+type (
+	CameraFactory   func() Camera
+	LightFactory    func() Light
+	ShapeFactory    func() Shape
+	MaterialFactory func() Material
+)
+
+// typeKindRegistry is a concurrency-safe name -> factory map shared by
+// RegisterCamera/RegisterLight/RegisterShape/RegisterMaterial, with support
+// for aliasing old type-tag names to a current factory.
+type typeKindRegistry[T any] struct {
+	mu       sync.RWMutex
+	byName   map[string]func() T
+	aliasFor map[string]string // alias name -> canonical name
+}
+
+func newTypeKindRegistry[T any]() *typeKindRegistry[T] {
+	return &typeKindRegistry[T]{byName: make(map[string]func() T), aliasFor: make(map[string]string)}
+}
+
+func (r *typeKindRegistry[T]) register(name string, factory func() T) {
+	if name == "" {
+		panic("phys: cannot register a type with no name")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.byName[name]; exists {
+		panic(fmt.Sprintf("phys: type %q is already registered", name))
+	}
+	r.byName[name] = factory
+}
+
+func (r *typeKindRegistry[T]) registerAlias(alias, canonical string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.byName[canonical]; !exists {
+		panic(fmt.Sprintf("phys: cannot alias %q to unregistered type %q", alias, canonical))
+	}
+	r.aliasFor[alias] = canonical
+}
+
+func (r *typeKindRegistry[T]) lookup(name string) (func() T, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if canonical, ok := r.aliasFor[name]; ok {
+		name = canonical
+	}
+	factory, ok := r.byName[name]
+	return factory, ok
+}
+
+func (r *typeKindRegistry[T]) registered() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.byName))
+	for name := range r.byName {
+		names = append(names, name)
+	}
+	return names
+}
+
+var (
+	cameraRegistry   = newTypeKindRegistry[Camera]()
+	lightRegistry    = newTypeKindRegistry[Light]()
+	shapeRegistry    = newTypeKindRegistry[Shape]()
+	materialRegistry = newTypeKindRegistry[Material]()
+)
+
+// RegisterCamera registers a named factory for a concrete Camera
+// implementation so Scene.UnmarshalJSON can dispatch to it without the
+// caller forking this module. Panics if name is already registered.
+func RegisterCamera(name string, factory CameraFactory) { cameraRegistry.register(name, factory) }
+
+// RegisterLight registers a named factory for a concrete Light
+// implementation. Panics if name is already registered.
+func RegisterLight(name string, factory LightFactory) { lightRegistry.register(name, factory) }
+
+// RegisterShape registers a named factory for a concrete Shape
+// implementation, e.g. an SDF primitive or volumetric light shape. Panics
+// if name is already registered.
+func RegisterShape(name string, factory ShapeFactory) { shapeRegistry.register(name, factory) }
+
+// RegisterMaterial registers a named factory for a concrete Material
+// implementation. Panics if name is already registered.
+func RegisterMaterial(name string, factory MaterialFactory) {
+	materialRegistry.register(name, factory)
+}
+
+// RegisterAlias makes alias resolve to the same factory as an already
+// registered canonical type-tag name, for one of "Camera", "Light",
+// "Shape", or "Material". This lets a scene file authored against an old
+// type name keep working after a rename. Panics if kind is unknown or
+// canonical is not registered.
+func RegisterAlias(kind, alias, canonical string) {
+	switch kind {
+	case "Camera":
+		cameraRegistry.registerAlias(alias, canonical)
+	case "Light":
+		lightRegistry.registerAlias(alias, canonical)
+	case "Shape":
+		shapeRegistry.registerAlias(alias, canonical)
+	case "Material":
+		materialRegistry.registerAlias(alias, canonical)
+	default:
+		panic(fmt.Sprintf("phys.RegisterAlias: unknown kind %q (want Camera, Light, Shape, or Material)", kind))
+	}
+}
+
+// Registered reports the type-tag names currently registered for Cameras,
+// Lights, Shapes, and Materials via RegisterCamera/RegisterLight/
+// RegisterShape/RegisterMaterial. It does not include names registered
+// through the legacy RegisterInterfaceType path.
+func Registered() map[string][]string {
+	return map[string][]string{
+		"Camera":   cameraRegistry.registered(),
+		"Light":    lightRegistry.registered(),
+		"Shape":    shapeRegistry.registered(),
+		"Material": materialRegistry.registered(),
+	}
+}
+
+// unmarshalTyped decodes a {"Type": ..., "Data": ...}-wrapped value using
+// the kind-specific registry, falling back to the legacy reflect-based
+// typeRegistry (populated by RegisterInterfaceType) when the tag is not
+// found in factory, so existing built-in types keep working unchanged.
+func unmarshalTyped[T any](data json.RawMessage, registry *typeKindRegistry[T]) (T, error) {
+	var zero T
+	var wrapper struct {
+		Type string          `json:"Type"`
+		Data json.RawMessage `json:"Data"`
+	}
+	if err := json.Unmarshal(data, &wrapper); err != nil {
+		return zero, err
+	}
+	if factory, ok := registry.lookup(wrapper.Type); ok {
+		v := factory()
+		if err := json.Unmarshal(wrapper.Data, v); err != nil {
+			return zero, fmt.Errorf("phys: decoding %q: %v", wrapper.Type, err)
+		}
+		return v, nil
+	}
+	iface, err := unmarshalInterface(data)
+	if err != nil {
+		return zero, fmt.Errorf("unsupported type: %q; has it been registered with phys.Register*?", wrapper.Type)
+	}
+	v, ok := iface.(T)
+	if !ok {
+		return zero, fmt.Errorf("phys: type %q did not implement the expected interface", wrapper.Type)
+	}
+	return v, nil
+}
@@ -0,0 +1,31 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import "testing"
+
+func TestRegisterAliasResolvesToCanonical(t *testing.T) {
+	RegisterMaterial("registryTestMaterial", func() Material { return &Lambertian{} })
+	RegisterAlias("Material", "registryTestMaterialOld", "registryTestMaterial")
+
+	factory, ok := materialRegistry.lookup("registryTestMaterialOld")
+	if !ok {
+		t.Fatalf("expected alias to resolve")
+	}
+	if _, ok := factory().(*Lambertian); !ok {
+		t.Fatalf("expected alias factory to produce *Lambertian")
+	}
+}
+
+func TestRegisteredListsRegisteredNames(t *testing.T) {
+	RegisterShape("registryTestShape", func() Shape { return &Sphere{Radius: 1} })
+	names := Registered()["Shape"]
+	found := false
+	for _, n := range names {
+		if n == "registryTestShape" {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("expected registryTestShape in Registered()[\"Shape\"], got %v", names)
+	}
+}
@@ -7,7 +7,7 @@ import (
 	"fmt"
 	"image"
 	"image/color"
-	"log"
+	"image/draw"
 	"math"
 	"runtime"
 	"sync"
@@ -16,17 +16,49 @@ import (
 
 	"cmp"
 
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r2"
 	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
 )
 
 type ray struct {
-	radiance  Spectrum
-	origin    r3.Point
-	direction r3.Vec
-	depth     int
-	pixelX    int
-	pixelY    int
-	rand      *Rand
+	radiance    Spectrum
+	origin      r3.Point
+	direction   r3.Vec
+	depth       int
+	pixelX      int
+	pixelY      int
+	rand        *Rand
+	wavelengths [spectralSamples]float64 // Hero wavelength + offsets (nm); zero value means "no spectral sample chosen yet", see hasWavelengths.
+	rayType     RayType                  // Why this ray was traced; zero value is RayTypeCamera, see RayType.
+
+	// time is this ray's sample point within Scene.Shutter's exposure
+	// window, in [0,1): 0 is shutter open, 1 would be shutter close.
+	// castPrimaryRay draws it once per primary ray and every scattered or
+	// shadow ray spawned from it copies it forward unchanged, the same
+	// way depth and rand propagate, so a whole path sees one consistent
+	// instant in time. AnimatedInstance.Collide is the only reader: it
+	// interpolates between its two Transforms at time. Zero value means
+	// "shutter just opened", which is also what a scene with no Shutter
+	// configured resolves to -- AnimatedInstance with T0==T1 is then a
+	// no-op regardless.
+	time float64
+
+	// uvFootprint is castPixelSampleImpl's estimate (via finite-differenced
+	// neighbor rays) of how much uv changes across one pixel at this
+	// primary ray's eventual hit point. Only ever set on primary camera
+	// rays, and only when RenderOptions.TextureFootprint is on; zero
+	// otherwise. tracePath copies it onto the primary hit's
+	// collision.uvFootprint.
+	uvFootprint r2.Point
+
+	// bsdfPdf is the solid-angle pdf of direction, as drawn by the Resolve
+	// call that scattered this ray (e.g. Lambertian, Metal, RoughPlastic,
+	// PBR). Zero means this ray isn't a continuous BSDF sample with a
+	// comparable pdf -- the primary camera ray, a shadow ray, or a ray
+	// scattered by a delta/specular material (Mirror, Glass, Dielectric) --
+	// so tracePath falls back to adding a hit Emitter's emission in full
+	// rather than MIS-weighting it. See misWeightedEmission.
+	bsdfPdf float64
 }
 
 func (r ray) at(t Distance) r3.Point {
@@ -34,16 +66,82 @@ func (r ray) at(t Distance) r3.Point {
 	return p
 }
 
+// atSlice evaluates r.at for every t in ts, batching the work into a
+// r3.VecSlice instead of one r3.Point per call. A BVH leaf holding several
+// primitives can use this to compute all of its candidate hit positions
+// at once instead of looping over at.
+func (r ray) atSlice(ts []Distance) r3.VecSlice {
+	n := len(ts)
+	t := make([]float64, n)
+	for i, d := range ts {
+		t[i] = float64(d)
+	}
+	direction := r3.VecSlice{X: make([]float64, n), Y: make([]float64, n), Z: make([]float64, n)}
+	origin := r3.VecSlice{X: make([]float64, n), Y: make([]float64, n), Z: make([]float64, n)}
+	for i := 0; i < n; i++ {
+		direction.X[i], direction.Y[i], direction.Z[i] = r.direction.X*t[i], r.direction.Y*t[i], r.direction.Z*t[i]
+		origin.X[i], origin.Y[i], origin.Z[i] = r.origin.X, r.origin.Y, r.origin.Z
+	}
+	return r3.AddSlice(origin, direction)
+}
+
 // RenderStats collects runtime metrics for the rendering process.
 type RenderStats struct {
 	RaysExceededDepth uint64        // Total count of rays that exceeded max ray depth.
 	RaysLeftScene     uint64        // Total count of rays that left the scene.
 	TotalRays         uint64        // Total count of all rays generated.
+	BVHNodeVisits     uint64        // Total count of BVH AABB tests; see BVH.CollideCounted. Zero when scene.Accel is nil (no BVH built).
+	TilesCompleted    uint64        // Total count of tiles that finished rendering.
 	RenderTime        time.Duration // How long it took to render the scene.
 	Dx                int           // Width of the rendered image.
 	Dy                int           // Height of the rendered image.
+	Events            []RenderEvent // Sampled per-tile timings; see renderEventSampleRate.
+}
+
+// FrameStats summarizes a RenderStats into the compact, per-frame metrics a
+// live perf overlay wants, via NewFrameStats.
+type FrameStats struct {
+	NanosPerPixel      float64 // RenderTime / (Dx*Dy), average time spent per output pixel.
+	RaysCast           uint64  // RenderStats.TotalRays.
+	BVHNodeVisits      uint64  // RenderStats.BVHNodeVisits.
+	SamplesAccumulated uint64  // Total samples taken across all pixels: TotalRays for a single-bounce-per-ray accounting isn't quite this, so this is Dx*Dy*RaysPerPixel, the sample budget the render actually spent.
+	TilesCompleted     uint64  // RenderStats.TilesCompleted.
 }
 
+// NewFrameStats summarizes stats (as populated by Render for the given
+// raysPerPixel) into a FrameStats, the compact form the wasm worker's
+// rolling perf window and live HUD consume.
+func NewFrameStats(stats RenderStats, raysPerPixel int) FrameStats {
+	var nanosPerPixel float64
+	if pixels := stats.Dx * stats.Dy; pixels > 0 {
+		nanosPerPixel = float64(stats.RenderTime.Nanoseconds()) / float64(pixels)
+	}
+	return FrameStats{
+		NanosPerPixel:      nanosPerPixel,
+		RaysCast:           stats.TotalRays,
+		BVHNodeVisits:      stats.BVHNodeVisits,
+		SamplesAccumulated: uint64(stats.Dx) * uint64(stats.Dy) * uint64(raysPerPixel),
+		TilesCompleted:     stats.TilesCompleted,
+	}
+}
+
+// RenderEvent is one sampled tile-completion timing, recorded into
+// RenderStats.Events so a caller can see which tiles were hot without
+// turning on external tracing. TraceID is the context's trace ID (see
+// WithTraceID), or "" if the render's context didn't carry one.
+type RenderEvent struct {
+	TraceID  string
+	TileX    int
+	TileY    int
+	Duration time.Duration
+}
+
+// renderEventSampleRate is how many tiles renderScene lets finish between
+// each one it records a RenderEvent for -- every tile would make
+// RenderStats.Events as large as the tile grid itself for no real benefit,
+// since adjacent tiles in a worker's queue tend to cost about the same.
+const renderEventSampleRate = 8
+
 func (stats RenderStats) String() string {
 	return fmt.Sprintf("RenderStats{RaysExceededDepth=%d, RaysLeftScene=%d, TotalRays=%d, RenderTime=%s}",
 		stats.RaysExceededDepth, stats.RaysLeftScene, stats.TotalRays, stats.RenderTime)
@@ -72,10 +170,241 @@ func (s RenderStats) PPrint() string {
 
 type RenderOptions struct {
 	Seed         int64 // Random base seed.
-	RaysPerPixel int   // Number of rays to generate for each pixel.
+	RaysPerPixel int   // Number of rays to generate for each pixel, or the per-tile sample budget when AdaptiveSampling is set.
 	MaxRayDepth  int   // Maximum number of collisions before terminating ray.
 	Dx           int   // Width of the rendered image in pixels.
 	Dy           int   // Height of the rendered image in pixels.
+
+	// AdaptiveSampling, when true, renders each tile with
+	// renderTileAdaptive instead of a uniform RaysPerPixel for every
+	// pixel: a pilot pass estimates luminance/normal/albedo variance per
+	// pixel, and the remaining sample budget is spent preferentially on
+	// high-variance pixels (edges, discontinuities, noisy indirect
+	// lighting) instead of flat, already-converged ones.
+	AdaptiveSampling bool
+	// AdaptiveMinSamples is the fewest samples any pixel receives when
+	// AdaptiveSampling is set. Zero means the default of 1.
+	AdaptiveMinSamples int
+	// AdaptiveMaxSamples is the most samples any pixel receives when
+	// AdaptiveSampling is set. Zero means the default of RaysPerPixel*4.
+	AdaptiveMaxSamples int
+
+	// Backend selects the Renderer NewRenderer builds: "" or "cpu" for
+	// the CPU path tracer (Render/renderScene, below), or "gl" for the
+	// compute-shader backend in renderer_gl.go. See Renderer.
+	Backend string
+
+	// Spectral, when true, assigns every primary ray a hero wavelength
+	// sample set (NewHeroWavelengths) instead of waiting for the first hit
+	// on a wavelength-dependent material (e.g. Dispersive) to do so, and
+	// makes tracePath terminate long hero-wavelength paths with Russian
+	// roulette rather than a hard depth cutoff, which would otherwise bias
+	// color toward whichever wavelengths happen to survive fewer bounces.
+	Spectral bool
+
+	// Integrator selects how tracePath accumulates radiance at each
+	// camera subpath vertex. The zero value, IntegratorPath, is ordinary
+	// unidirectional path tracing.
+	Integrator Integrator
+
+	// VoxelResolution is the voxels-per-axis Scene.BuildVoxelGrid should
+	// use for IntegratorVoxelGI. Zero means BuildVoxelGrid hasn't been
+	// asked to run from this RenderOptions; Render itself never calls
+	// BuildVoxelGrid (see IntegratorVoxelGI's doc comment), so this is
+	// presently only a settings field a future caller or CLI front-end
+	// can read before building the grid itself.
+	VoxelResolution int
+
+	// BDPTLightBounces overrides how many times IntegratorBDPT's light
+	// subpath bounces off a DiffuseReflector surface past the initial
+	// emitter vertex (see sampleLightSubpath and bdptMaxLightBounces).
+	// Zero means the default of bdptMaxLightBounces. A scene whose small
+	// emitters only reach the camera through more than one diffuse bounce
+	// (e.g. a light visible solely via a mirror-adjacent wall) can raise
+	// this at the cost of one extra shadow ray and ReflectedRadiance
+	// evaluation per additional bounce; unused when Integrator is not
+	// IntegratorBDPT.
+	BDPTLightBounces int
+
+	// OnPass, if set, switches Render into progressive mode
+	// (renderScenePassCallback instead of renderScene): the image is
+	// rendered as PassCount sequential full-frame passes of SamplesPerPass
+	// samples per pixel each, and OnPass is called after every pass with
+	// the 1-based pass number and the running mean image/stats accumulated
+	// so far, so a caller can stream a preview to disk, update a live
+	// display, or cancel by returning a non-nil error (Render then returns
+	// that error, wrapped, along with the partial artifact). A context
+	// cancellation cancels the same way, also returning the best artifact
+	// accumulated through the last pass that finished.
+	// Excluded from JSON (json:"-"): encoding/json rejects func-typed
+	// fields unconditionally, even when nil, and a callback closure has no
+	// meaningful wire representation anyway.
+	OnPass func(pass int, partial *RenderArtifact) error `json:"-"`
+
+	// PassCount is the number of progressive passes renderScenePassCallback
+	// renders when OnPass is set. Zero defaults to
+	// RaysPerPixel/SamplesPerPass, so by default the progressive path
+	// spends the same total sample budget as renderScene and (see
+	// renderTilePass) folds in the exact same per-pixel samples.
+	PassCount int
+
+	// SamplesPerPass is how many samples renderScenePassCallback takes per
+	// pixel within each pass, instead of always one. Zero defaults to 1.
+	// Raising it trades live-preview granularity (fewer, chunkier OnPass
+	// updates) for less per-pass overhead (tile dispatch, EarlyStopVariance
+	// bookkeeping, the OnPass call itself).
+	SamplesPerPass int
+
+	// EarlyStopVariance, when positive and OnPass is set, lets
+	// renderScenePassCallback stop refining a tile once every pixel in it
+	// has a running Welford luminance variance below this threshold,
+	// instead of spending the full PassCount budget on regions that have
+	// already converged. Zero disables early stopping.
+	EarlyStopVariance float64
+
+	// TileSize overrides the worker tile size (pixels per side) used by
+	// both renderScene and renderScenePassCallback's tile queues. Zero
+	// defaults to 16.
+	TileSize int
+
+	// TileOrder selects what order fillRenderQueue enqueues renderScene's
+	// tiles in. The zero value, TileOrderRowMajor, is the scan order
+	// renderScene has always used. TileOrderHilbert matters most alongside
+	// OnTile: a Hilbert-ordered stream of tiles traces a single contiguous,
+	// ever-expanding region instead of hopping scanlines, so a live
+	// consumer's partial image reads as coherent progress rather than
+	// noise.
+	TileOrder TileOrder
+
+	// Filter, when its Eval func is set, switches renderScene from
+	// averaging each pixel's samples and filtering the finished image
+	// (what ApplySeparableFilterRGBA does) to splatting every sample into
+	// a shared Film through the kernel as it's taken, which is the
+	// unbiased reconstruction the postprocess is only an approximation
+	// of. The zero value leaves renderScene's existing per-pixel-average
+	// behavior untouched. Not yet supported together with
+	// AdaptiveSampling; Validate rejects that combination.
+	Filter ReconFilter
+
+	// AuxBuffers, when true, makes renderScene additionally populate
+	// RenderArtifact.AuxAlbedo and AuxNormal with each pixel's first-hit
+	// ShadingHints (no secondary bounces), for use by auxiliary-guided
+	// denoisers like ApplyBilateralRGBA. Not yet supported together with
+	// Filter or AdaptiveSampling; Validate rejects those combinations.
+	AuxBuffers bool
+
+	// OnTile, if set, is called by renderScene once for every tile as soon
+	// as that tile finishes rendering (rather than only after a whole
+	// pass, as OnPass does), from whichever worker goroutine completed it.
+	// RenderTiled is the usual way to set this; see TileResult. Returning
+	// a non-nil error cancels the render, the same way a context
+	// cancellation does, and Render returns that error (wrapped) once all
+	// in-flight tiles have stopped. Not yet supported together with
+	// Filter-based splatting, since img holds no pixels for OnTile's
+	// sub-image until Film.Resolve runs at the very end; Validate rejects
+	// that combination.
+	// Excluded from JSON (json:"-") for the same reason as OnPass.
+	OnTile func(TileResult) error `json:"-"`
+
+	// ResumeTile, if set, is consulted by renderScene once per tile,
+	// before that tile is traced: given the tile's pixel bounds, it
+	// returns a previously-rendered image for that exact region and true
+	// if one exists. When it does, renderScene copies those pixels into
+	// the output in place of tracing the tile (no rays are cast, so the
+	// tile contributes nothing to RenderStats), then still calls OnTile
+	// with the copied-in result if OnTile is also set. This is what lets
+	// a caller resume a render a crashed server left partially finished
+	// -- see phys/store.Checkpointer, which implements this by looking
+	// up PNG-encoded tiles an earlier OnTile call of the same shape
+	// saved. Only consulted by renderScene; renderScenePassCallback's
+	// progressive welford accumulators always start from zero.
+	// Excluded from JSON (json:"-") for the same reason as OnPass.
+	ResumeTile func(x0, y0, x1, y1 int) (image.Image, bool) `json:"-"`
+
+	// TextureFootprint, when true, makes castPixelSampleImpl estimate each
+	// primary ray's UV-space footprint (via two extra finite-differencing
+	// rays cast at neighboring pixels) and carry it onto the first hit's
+	// collision.uvFootprint, which textureAt passes to any
+	// TextureFootprintSampler (e.g. a mipmapped TextureImage) so its
+	// "trilinear"/"anisotropic" Interp modes can pick an appropriately
+	// blurred mip level instead of always sampling the base image. Off by
+	// default since it costs two extra rays per pixel; only primary rays
+	// get an estimate; secondary bounces always see a zero footprint
+	// (base-mip sampling), since true ray-differential transfer through a
+	// BSDF isn't implemented here.
+	TextureFootprint bool
+
+	// PostProcess, when non-empty, makes renderScene additionally
+	// accumulate each pixel's un-clamped linear radiance into a
+	// RadianceBuffer, run it through every Pass in order once the render
+	// finishes, and replace RenderArtifact.Image with the result (see
+	// RadianceBuffer.ToRGBA) instead of renderPixel's own 8-bit clamp.
+	// Nil or empty leaves renderScene's existing clamp-per-pixel behavior
+	// untouched, at no extra cost. Not yet supported together with
+	// Filter-based splatting or AdaptiveSampling, for the same reason
+	// AuxBuffers isn't: Validate rejects those combinations.
+	PostProcess []Pass
+
+	// Denoise, when true, makes renderScene run DenoiseATrous (tuned by
+	// DenoiseSigmas/DenoiseIterations) over the image before returning it,
+	// after any explicit PostProcess passes. It's a convenience over
+	// manually setting AuxBuffers and appending a DenoiseATrous Pass to
+	// PostProcess: renderScene allocates the aux and radiance buffers
+	// Denoise needs on its own, and a caller who also set AuxBuffers still
+	// gets RenderArtifact.AuxAlbedo/AuxNormal populated as usual. This is
+	// what lets a render converge visually at far fewer RaysPerPixel than
+	// it would need without filtering. Not yet supported together with
+	// Filter-based splatting or AdaptiveSampling, for the same reason
+	// AuxBuffers isn't: Validate rejects those combinations.
+	Denoise bool
+
+	// DenoiseSigmas tunes the edge-stopping terms DenoiseATrous weights
+	// neighbors by when Denoise is set. The zero value uses
+	// defaultDenoiseSigmas rather than disabling every term (BilateralSigmas
+	// zero fields already mean "always match" to DenoiseATrous, which would
+	// denoise without respecting any edge).
+	DenoiseSigmas BilateralSigmas
+
+	// DenoiseIterations is the number of dilating-stencil À-Trous rounds
+	// DenoiseATrous runs when Denoise is set. Zero defaults to
+	// defaultDenoiseIterations.
+	DenoiseIterations int
+
+	// NumWorkers overrides the number of goroutines renderScene and
+	// renderScenePassCallback use to drain the tile queue concurrently.
+	// Zero (the default) uses runtime.NumCPU(). The WASM worker
+	// (lab/worker) surfaces this as its "setWorkers" message so a page can
+	// trade render latency for CPU headroom shared with the rest of the
+	// browser tab.
+	NumWorkers int
+
+	// FrustumCull, when true, makes Render prune scene.Accel down to only
+	// the BVH subtrees FrustumFromCamera(camera) and BVH.CollectVisible
+	// say could be visible before tracing a single ray, instead of
+	// relying on each ray's own AABB.hit rejection to skip off-screen
+	// geometry one ray at a time. Only takes effect for a Camera
+	// FrustumFromCamera supports (PinholeCamera, OrthographicCamera, or
+	// an AnimatedCamera resolved at its current U); for any other Camera,
+	// or a Scene with no Accel built, Render renders exactly as if
+	// FrustumCull were false. Since FrustumFromCamera only sees the
+	// camera's current pose, an animated render that also moves the
+	// camera between frames should build its own culled Accel per frame
+	// via AnimatedCamera.FrustumUnion instead of setting this.
+	FrustumCull bool
+
+	// DirectLightSamples caps how many scene.Light entries Lambertian,
+	// Diffuse, Metal, PBR, Dielectric, and RoughPlastic's light-sampling
+	// loop draws per surface hit. Zero (the default) samples every light,
+	// exactly as if this option didn't exist. A scene with many small
+	// lights can set this below len(scene.Light) to trace fewer shadow
+	// rays per hit, at the cost of more variance for RaysPerPixel to
+	// average out; each of the lights actually sampled is reweighted by
+	// len(scene.Light)/DirectLightSamples (see sampledLights) so the
+	// estimator stays unbiased. Has no effect on probeAmbient's
+	// ProbeLight loop, or on Emitter-material Node sampling, which
+	// RenderOptions.Integrator's IntegratorBDPT machinery and
+	// sampleEmitterDirectLighting handle independently of scene.Light.
+	DirectLightSamples int
 }
 
 func (r RenderOptions) Validate() error {
@@ -94,6 +423,83 @@ func (r RenderOptions) Validate() error {
 	if r.Dy <= 0 {
 		return fmt.Errorf("bad Dy must be positive but got %d", r.Dy)
 	}
+	if r.AdaptiveMinSamples < 0 {
+		return fmt.Errorf("bad AdaptiveMinSamples must be non-negative but got %d", r.AdaptiveMinSamples)
+	}
+	if r.AdaptiveMaxSamples < 0 {
+		return fmt.Errorf("bad AdaptiveMaxSamples must be non-negative but got %d", r.AdaptiveMaxSamples)
+	}
+	if r.PassCount < 0 {
+		return fmt.Errorf("bad PassCount must be non-negative but got %d", r.PassCount)
+	}
+	if r.SamplesPerPass < 0 {
+		return fmt.Errorf("bad SamplesPerPass must be non-negative but got %d", r.SamplesPerPass)
+	}
+	if r.EarlyStopVariance < 0 {
+		return fmt.Errorf("bad EarlyStopVariance must be non-negative but got %v", r.EarlyStopVariance)
+	}
+	if r.TileSize < 0 {
+		return fmt.Errorf("bad TileSize must be non-negative but got %d", r.TileSize)
+	}
+	if err := r.TileOrder.Validate(); err != nil {
+		return fmt.Errorf("bad TileOrder: %v", err)
+	}
+	if r.DenoiseIterations < 0 {
+		return fmt.Errorf("bad DenoiseIterations must be non-negative but got %d", r.DenoiseIterations)
+	}
+	if r.NumWorkers < 0 {
+		return fmt.Errorf("bad NumWorkers must be non-negative but got %d", r.NumWorkers)
+	}
+	if r.DirectLightSamples < 0 {
+		return fmt.Errorf("bad DirectLightSamples must be non-negative but got %d", r.DirectLightSamples)
+	}
+	if r.VoxelResolution < 0 {
+		return fmt.Errorf("bad VoxelResolution must be non-negative but got %d", r.VoxelResolution)
+	}
+	if r.BDPTLightBounces < 0 {
+		return fmt.Errorf("bad BDPTLightBounces must be non-negative but got %d", r.BDPTLightBounces)
+	}
+	switch r.Backend {
+	case "", "cpu", "gl":
+	default:
+		return fmt.Errorf("bad Backend must be \"cpu\" or \"gl\" but got %q", r.Backend)
+	}
+	if err := r.Integrator.Validate(); err != nil {
+		return fmt.Errorf("bad Integrator: %v", err)
+	}
+	if r.Filter.Eval != nil && r.AdaptiveSampling {
+		return fmt.Errorf("Filter-based splatting is not yet supported together with AdaptiveSampling")
+	}
+	if r.AuxBuffers && r.Filter.Eval != nil {
+		return fmt.Errorf("AuxBuffers is not yet supported together with Filter-based splatting")
+	}
+	if r.AuxBuffers && r.AdaptiveSampling {
+		return fmt.Errorf("AuxBuffers is not yet supported together with AdaptiveSampling")
+	}
+	if r.OnTile != nil && r.Filter.Eval != nil {
+		return fmt.Errorf("OnTile is not yet supported together with Filter-based splatting")
+	}
+	if r.OnTile != nil && r.OnPass != nil {
+		return fmt.Errorf("OnTile is not supported together with OnPass; Render dispatches to renderScenePassCallback, which doesn't call OnTile")
+	}
+	if r.ResumeTile != nil && r.OnPass != nil {
+		return fmt.Errorf("ResumeTile is not supported together with OnPass; Render dispatches to renderScenePassCallback, which doesn't consult ResumeTile")
+	}
+	if r.ResumeTile != nil && r.Filter.Eval != nil {
+		return fmt.Errorf("ResumeTile is not yet supported together with Filter-based splatting; a resumed tile's pixels never reach the Film, so film.Resolve() would overwrite them")
+	}
+	if len(r.PostProcess) > 0 && r.Filter.Eval != nil {
+		return fmt.Errorf("PostProcess is not yet supported together with Filter-based splatting")
+	}
+	if len(r.PostProcess) > 0 && r.AdaptiveSampling {
+		return fmt.Errorf("PostProcess is not yet supported together with AdaptiveSampling")
+	}
+	if r.Denoise && r.Filter.Eval != nil {
+		return fmt.Errorf("Denoise is not yet supported together with Filter-based splatting")
+	}
+	if r.Denoise && r.AdaptiveSampling {
+		return fmt.Errorf("Denoise is not yet supported together with AdaptiveSampling")
+	}
 	return nil
 }
 
@@ -101,12 +507,108 @@ func (r RenderOptions) Validate() error {
 type RenderArtifact struct {
 	Image *image.RGBA
 	Stats RenderStats
+
+	// AuxAlbedo and AuxNormal hold each pixel's first-hit albedo and
+	// shading normal (normal encoded as n*0.5+0.5), populated only when
+	// RenderOptions.AuxBuffers is set. Nil otherwise.
+	AuxAlbedo *image.RGBA
+	AuxNormal *image.RGBA
+
+	// Radiance holds the same linear, un-tonemapped HDR values Image is
+	// clamped to 8 bits from, before any RenderOptions.PostProcess pass or
+	// the final Denoise pass ran (every built-in Pass clones rather than
+	// mutating its input, so this is the pipeline's original input, not
+	// its output). Populated whenever RenderOptions.PostProcess is
+	// non-empty or RenderOptions.Denoise is set, since that is already
+	// when renderScene allocates the buffer internally; nil otherwise, the
+	// same opt-in-by-already-being-computed convention as AuxAlbedo and
+	// AuxNormal. A caller wanting Bloom's glow without also wanting
+	// PostProcess to touch Image can run Bloom against Radiance directly
+	// and encode the result itself.
+	Radiance *RadianceBuffer
+
+	// TileSize, TileCountX, and TileCountY describe the tile grid
+	// TileVariance and UpdatedTiles index into: TileCountX*TileCountY
+	// tiles, tileSize pixels per side (the last row/column may be
+	// smaller), in the same row-major order fillRenderQueue and
+	// renderScenePassCallback's tileDone use. Populated only by
+	// renderScenePassCallback (i.e. when RenderOptions.OnPass is set);
+	// zero otherwise.
+	TileSize               int
+	TileCountX, TileCountY int
+
+	// TileVariance holds, per tile (row-major, TileCountX wide), the
+	// worst-case (max over the tile's pixels) running pixelWelford
+	// luminance variance as of the most recent pass -- the same quantity
+	// EarlyStopVariance compares against to retire a tile. A caller
+	// streaming progressive frames (e.g. a render-service WebSocket
+	// endpoint) can use this to show which regions are still noisy. Nil
+	// unless RenderOptions.OnPass is set.
+	TileVariance []float64
+
+	// UpdatedTiles lists, by index into the same tile grid as
+	// TileVariance, every tile renderScenePassCallback actually traced
+	// another sample into during the most recent pass (i.e. every tile
+	// that wasn't already retired by EarlyStopVariance before this pass
+	// started). A caller diffing successive passes only needs to
+	// re-encode these tiles' pixels; every other tile's image is
+	// unchanged since the last pass. Nil unless RenderOptions.OnPass is
+	// set.
+	UpdatedTiles []int
+}
+
+// auxBuffers accumulates the first-hit albedo/normal images renderScene
+// populates when RenderOptions.AuxBuffers is set. It exists instead of
+// writing straight into RenderArtifact fields so renderPixel doesn't need
+// to know about RenderArtifact at all.
+type auxBuffers struct {
+	Albedo *image.RGBA
+	Normal *image.RGBA
+}
+
+func newAuxBuffers(dx, dy int) *auxBuffers {
+	return &auxBuffers{
+		Albedo: image.NewRGBA(image.Rect(0, 0, dx, dy)),
+		Normal: image.NewRGBA(image.Rect(0, 0, dx, dy)),
+	}
+}
+
+// set writes hints' albedo and normal (encoded n*0.5+0.5, so components in
+// [-1,1] fit a uint8 channel) to (x, y).
+func (a *auxBuffers) set(x, y int, hints ShadingHints) {
+	alb := hints.Albedo
+	a.Albedo.Set(x, y, color.RGBA{
+		R: uint8(math.Min(255, 255.99*alb.X)),
+		G: uint8(math.Min(255, 255.99*alb.Y)),
+		B: uint8(math.Min(255, 255.99*alb.Z)),
+		A: 255,
+	})
+	n := hints.Normal.Unit()
+	a.Normal.Set(x, y, color.RGBA{
+		R: uint8(clamp(255.99*(n.X*0.5+0.5), 0, 255)),
+		G: uint8(clamp(255.99*(n.Y*0.5+0.5), 0, 255)),
+		B: uint8(clamp(255.99*(n.Z*0.5+0.5), 0, 255)),
+		A: 255,
+	})
 }
 
 type tile struct {
 	x0, x1, y0, y1 int
 }
 
+// TileResult is passed to RenderOptions.OnTile every time a tile finishes
+// rendering: the pixel rectangle it covers, how many samples per pixel it
+// accumulated, and a read-only view of those pixels. Image is img.SubImage
+// of the frame renderScene is still filling in, so it shares the backing
+// array rather than copying it; callers that need to hold onto it past
+// the callback (e.g. to hand it to a goroutine that PNG-encodes it for a
+// slow network client) should copy the pixels out first.
+type TileResult struct {
+	X, Y, W, H  int
+	SampleIndex int
+	Image       image.Image
+}
+
 func (t tile) String() string {
 	return fmt.Sprintf("Tile{xStart=%d, xEnd=%d, yStart=%d, yEnd=%d}", t.x0, t.x1, t.y0, t.y1)
 }
@@ -139,101 +641,354 @@ func clamp[T cmp.Ordered](a, minVal, maxVal T) T {
 	return a
 }
 
-func tracePath(ctx context.Context, scene *Scene, r ray, stats *RenderStats) Spectrum {
+// tracePath traces r through scene and returns its accumulated radiance.
+// If hints is non-nil and r is a primary ray (r.depth == 0), tracePath
+// fills *hints with the ShadingHints of r's first hit, so a caller like
+// renderTileAdaptive can get a normal/albedo AOV sample alongside the
+// radiance sample without a second trace.
+func tracePath(ctx context.Context, scene *Scene, r ray, stats *RenderStats, hints *ShadingHints) Spectrum {
 	atomic.AddUint64(&stats.TotalRays, 1)
 	if ctx.Err() != nil {
 		return Spectrum{}
 	}
 	if r.origin.IsNaN() || r.origin.IsInf() || r.direction.IsNaN() || r.direction.IsInf() {
-		log.Printf("invalid ray: %+v", r)
+		LoggerFromContext(ctx).Warn("invalid ray", "ray", fmt.Sprintf("%+v", r))
 		return Spectrum{}
 	}
 	if r.depth > scene.RenderOptions.MaxRayDepth {
 		atomic.AddUint64(&stats.RaysExceededDepth, 1)
 		return Spectrum{}
 	}
+	if r.depth > pathRRStartDepth {
+		survive, weight := russianRoulette(r.radiance, r.rand)
+		if !survive {
+			return Spectrum{}
+		}
+		r.radiance = r.radiance.Muls(weight)
+	}
 	var nearest surfaceInteraction
-	var minDist = Distance(math.MaxFloat64)
 	var hit bool
-	for i := range scene.Node {
-		node := &scene.Node[i]
-		h, c := node.Shape.Collide(r, eps, minDist)
-		if h && c.t < minDist {
-			minDist = c.t
+	if scene.Accel != nil {
+		h, c, visits := scene.Accel.CollideCounted(r, eps, Distance(math.MaxFloat64))
+		atomic.AddUint64(&stats.BVHNodeVisits, visits)
+		if h {
 			nearest.collision = c
 			nearest.incoming = r
 			nearest.outgoing = r.direction.Muls(-1) // Direction towards camera.
-			nearest.node = scene.Node[i]
+			nearest.node = scene.Node[c.nodeIndex]
+			nearest.materialID = c.nodeIndex
+			nearest.rayType = r.rayType
 			hit = true
 		}
+	} else {
+		minDist := Distance(math.MaxFloat64)
+		for i := range scene.Node {
+			node := &scene.Node[i]
+			h, c := node.Shape.Collide(r, eps, minDist)
+			if h && c.t < minDist {
+				minDist = c.t
+				nearest.collision = c
+				nearest.incoming = r
+				nearest.outgoing = r.direction.Muls(-1) // Direction towards camera.
+				nearest.node = scene.Node[i]
+				nearest.materialID = i
+				nearest.rayType = r.rayType
+				hit = true
+			}
+		}
 	}
 	if !hit {
 		atomic.AddUint64(&stats.RaysLeftScene, 1)
 		return Spectrum{}
 	}
+	if hints != nil && r.depth == 0 {
+		*hints = shadingHints(nearest)
+	}
+	if r.depth == 0 {
+		nearest.collision.uvFootprint = r.uvFootprint
+	}
 	resolution := nearest.node.Material.Resolve(ctx, nearest)
 	rgb := Spectrum{}
-	rgb = rgb.Add(resolution.emission)
-	rgb = rgb.Add(nearest.node.Material.ComputeDirectLighting(ctx, nearest, scene))
+	rgb = rgb.Add(misWeightedEmission(scene, nearest, r, resolution.emission))
+	if sm, ok := nearest.node.Material.(SpecularMaterial); !ok || !sm.Specular() {
+		rgb = rgb.Add(nearest.node.Material.ComputeDirectLighting(ctx, nearest, scene))
+		if scene.RenderOptions.Integrator == IntegratorBDPT {
+			if reflector, ok := nearest.node.Material.(DiffuseReflector); ok {
+				rgb = rgb.Add(connectToLightVertex(scene, nearest, reflector))
+			}
+		}
+	}
 	for _, newRay := range resolution.scattered {
-		outgoingColor := tracePath(ctx, scene, newRay, stats)
+		outgoingColor := tracePath(ctx, scene, newRay, stats, nil)
 		rgb = rgb.Add(outgoingColor)
 	}
 	return rgb
 }
 
-// renderPixel renders a single pixel in the image. Any x, y outside the image bounds will be clamped.
-func renderPixel(ctx context.Context, scene *Scene, camera Camera, rand *Rand, stats *RenderStats, x, y int, img *image.RGBA) {
+// closestHitUV intersects r against scene, returning the uv and node
+// index of the closest hit. It mirrors tracePath's own Accel-or-linear-scan
+// intersection, duplicated rather than shared so that a cheap uv-only
+// probe (used by castPixelSampleImpl's footprint estimate) doesn't need to
+// build a whole surfaceInteraction.
+func closestHitUV(scene *Scene, r ray) (uv r2.Point, nodeIndex int, hit bool) {
+	if scene.Accel != nil {
+		h, c := scene.Accel.Collide(r, eps, Distance(math.MaxFloat64))
+		if h {
+			return c.uv, c.nodeIndex, true
+		}
+		return r2.Point{}, 0, false
+	}
+	minDist := Distance(math.MaxFloat64)
+	for i := range scene.Node {
+		h, c := scene.Node[i].Shape.Collide(r, eps, minDist)
+		if h && c.t < minDist {
+			minDist = c.t
+			uv, nodeIndex, hit = c.uv, i, true
+		}
+	}
+	return uv, nodeIndex, hit
+}
+
+// Occluded reports whether shadowRay, traced up to tMax, hits anything in
+// the scene other than one of skip's shapes. It is the one shadow test
+// every material's ComputeDirectLighting and connectToVertex's light-
+// vertex connection now share, descending scene.Accel when built and
+// falling back to a linear scan over scene.Node otherwise -- the same
+// Accel-or-linear-scan split tracePath and closestHitUV already make,
+// rather than each call site rolling its own O(len(Node)) loop per light
+// per shading point.
+//
+// skip is typically the shading point's own node (to avoid a false
+// self-hit alongside the eps origin offset already guarding against it)
+// and, for a light-vertex connection, the light's own node too. Since
+// scene.Accel only reports the nearest hit, a skipped shape found nearest
+// doesn't end the search: Occluded narrows tMax to just short of it and
+// keeps looking, in case an actual occluder lies beyond.
+func (s *Scene) Occluded(shadowRay ray, tMax Distance, skip ...Shape) bool {
+	isSkipped := func(shape Shape) bool {
+		for _, sk := range skip {
+			if shape == sk {
+				return true
+			}
+		}
+		return false
+	}
+	if s.Accel != nil {
+		remaining := tMax
+		for remaining > eps {
+			h, c := s.Accel.Collide(shadowRay, eps, remaining)
+			if !h {
+				return false
+			}
+			if !isSkipped(s.Node[c.nodeIndex].Shape) {
+				return true
+			}
+			remaining = c.t - Distance(eps)
+		}
+		return false
+	}
+	for i := range s.Node {
+		if isSkipped(s.Node[i].Shape) {
+			continue
+		}
+		if hit, _ := s.Node[i].Shape.Collide(shadowRay, eps, tMax); hit {
+			return true
+		}
+	}
+	return false
+}
+
+// primaryUVFootprint estimates how much uv changes across one pixel at
+// the primary ray r's hit point, by finite-differencing against two
+// neighbor rays cast one pixel away in x and y. Returns a zero footprint
+// if r misses the scene or either neighbor lands on a different node (the
+// estimate is only meaningful across a continuous surface).
+func primaryUVFootprint(scene *Scene, camera Camera, rand *Rand, cx, cy int, s, tSample float64, dx, dy int) r2.Point {
+	uv0, node0, hit0 := closestHitUV(scene, camera.Cast(s, tSample, rand))
+	if !hit0 {
+		return r2.Point{}
+	}
+	sx := (float64(cx) + 1.5) / float64(dx)
+	uvx, nodeX, hitX := closestHitUV(scene, camera.Cast(sx, tSample, rand))
+	ty := (float64(cy) + 1.5) / float64(dy)
+	uvy, nodeY, hitY := closestHitUV(scene, camera.Cast(s, ty, rand))
+
+	var footprint r2.Point
+	if hitX && nodeX == node0 {
+		d := uvx.Sub(uv0)
+		footprint.X = math.Abs(d.X)
+	}
+	if hitY && nodeY == node0 {
+		d := uvy.Sub(uv0)
+		footprint.Y = math.Abs(d.Y)
+	}
+	return footprint
+}
+
+// castPixelSample casts and traces the sample-th of total samples at the
+// camera-space pixel (cx, cy), writing imgy as the outgoing ray's
+// pixelY. It returns the sample's radiance and the primary hit's
+// ShadingHints (the zero ShadingHints if the ray left the scene). When
+// total is 1 it samples the exact pixel center rather than jittering,
+// since there's no second sample to average the jitter against.
+func castPixelSample(ctx context.Context, scene *Scene, camera Camera, rand *Rand, stats *RenderStats, cx, cy, imgy, sample, total int) (Spectrum, ShadingHints) {
+	return castPixelSampleImpl(ctx, scene, camera, rand, stats, cx, cy, imgy, sample, total, total == 1)
+}
+
+// castPixelSampleJittered behaves like castPixelSample but always samples
+// a random point within the pixel, regardless of how many total samples
+// the caller plans to take overall. renderScenePassCallback's progressive
+// passes call this once per pass so that repeated single-sample passes
+// don't collapse onto the identical center ray every time.
+func castPixelSampleJittered(ctx context.Context, scene *Scene, camera Camera, rand *Rand, stats *RenderStats, cx, cy, imgy, sample, total int) (Spectrum, ShadingHints) {
+	return castPixelSampleImpl(ctx, scene, camera, rand, stats, cx, cy, imgy, sample, total, false)
+}
+
+// castPrimaryRay builds the primary ray for camera-space pixel (cx, cy),
+// the sample-th of total planned samples: it asks camera for a ray at the
+// jittered-or-centered image coordinates, then stamps on everything that
+// doesn't depend on what the ray hits -- pixel coordinates, spectral
+// wavelengths, and (see Scene.Shutter) the time instant within the
+// current exposure that every scattered or shadow ray this primary ray
+// spawns will copy forward unchanged, the one piece AnimatedInstance.Collide
+// reads to pick its pose.
+func castPrimaryRay(scene *Scene, camera Camera, rand *Rand, cx, cy, imgy, sample, total int, s, tSample float64) ray {
+	r := camera.Cast(s, tSample, rand)
+	r.pixelX = cx
+	r.pixelY = imgy
+	if scene.Shutter.Duration > 0 {
+		r.time = scene.Shutter.sample(sample, total, rand)
+	}
+	if scene.RenderOptions.Spectral {
+		r.wavelengths = NewHeroWavelengths(rand)
+	}
+	return r
+}
+
+func castPixelSampleImpl(ctx context.Context, scene *Scene, camera Camera, rand *Rand, stats *RenderStats, cx, cy, imgy, sample, total int, sampleCenter bool) (Spectrum, ShadingHints) {
+	dx := scene.RenderOptions.Dx
+	dy := scene.RenderOptions.Dy
+	var s, tSample float64
+	if sampleCenter {
+		// Sample center of the pixel.
+		s = (float64(cx) + 0.5) / float64(dx)
+		tSample = (float64(cy) + 0.5) / float64(dy)
+	} else {
+		// Sample randomly within the pixel.
+		s = (float64(cx) + rand.Float64()) / float64(dx)
+		tSample = (float64(cy) + rand.Float64()) / float64(dy)
+	}
+	r := castPrimaryRay(scene, camera, rand, cx, cy, imgy, sample, total, s, tSample)
+	if scene.RenderOptions.TextureFootprint {
+		r.uvFootprint = primaryUVFootprint(scene, camera, rand, cx, cy, s, tSample, dx, dy)
+	}
+	var hints ShadingHints
+	radiance := tracePath(ctx, scene, r, stats, &hints)
+	return radiance, hints
+}
+
+// renderPixel renders a single pixel in the image. Any x, y outside the
+// image bounds will be clamped. If aux is non-nil, the first sample's
+// ShadingHints (no secondary bounces) is recorded into it -- cheap enough
+// to take alongside the regular samples rather than casting a dedicated
+// ray. If radiance is non-nil, the pixel's averaged radiance is also
+// recorded there before the 8-bit clamp below, for
+// RenderOptions.PostProcess to run on afterward. Each sample gets its own
+// NewPixelRand(Seed, cx, cy, sample) instead of a shared *Rand threaded
+// across the tile, so the result is bit-exact regardless of
+// RenderOptions.TileSize, worker count, or tile completion order.
+func renderPixel(ctx context.Context, scene *Scene, camera Camera, stats *RenderStats, x, y int, img *image.RGBA, aux *auxBuffers, radiance *RadianceBuffer) {
 	dx := scene.RenderOptions.Dx
 	dy := scene.RenderOptions.Dy
 	// Clamp pixel coordinates to image bounds.
 	cx := clamp(x, 0, dx-1)
 	cy := clamp(y, 0, dy-1)
 	if x != cx || y != cy {
-		log.Printf("clamped pixel coordinates: (x, y)=(%d, %d) to (%d, %d)", x, y, cx, cy)
+		LoggerFromContext(ctx).Warn("clamped pixel coordinates", "x", x, "y", y, "cx", cx, "cy", cy)
 	}
 	imgy := dy - 1 - cy // Flip y-axis to match image coordinates.
+	total := scene.RenderOptions.RaysPerPixel
 	rgb := Spectrum{}
-	for sample := 0; sample < scene.RenderOptions.RaysPerPixel; sample++ {
+	var firstHints ShadingHints
+	for sample := 0; sample < total; sample++ {
 		if ctx.Err() != nil {
 			return
 		}
-		var s, tSample float64
-		if scene.RenderOptions.RaysPerPixel == 1 {
-			// Sample center of the pixel.
-			s = (float64(cx) + 0.5) / float64(dx)
-			tSample = (float64(cy) + 0.5) / float64(dy)
-		} else {
-			// Sample randomly within the pixel.
-			s = (float64(cx) + rand.Float64()) / float64(dx)
-			tSample = (float64(cy) + rand.Float64()) / float64(dy)
-		}
-		// Cast the ray from the camera.
-		ray := camera.Cast(s, tSample, rand)
-		ray.pixelX = cx
-		ray.pixelY = imgy
-		color := tracePath(ctx, scene, ray, stats)
-		rgb = rgb.Add(color)
-	}
-	rgb = rgb.Divs(float64(scene.RenderOptions.RaysPerPixel))
+		rand := NewPixelRand(scene.RenderOptions.Seed, cx, cy, sample)
+		radiance, hints := castPixelSample(ctx, scene, camera, rand, stats, cx, cy, imgy, sample, total)
+		if sample == 0 {
+			firstHints = hints
+		}
+		rgb = rgb.Add(radiance)
+	}
+	rgb = rgb.Divs(float64(total))
 	img.Set(x, imgy, color.RGBA{
 		R: uint8(math.Min(255, 255.99*rgb.X)),
 		G: uint8(math.Min(255, 255.99*rgb.Y)),
 		B: uint8(math.Min(255, 255.99*rgb.Z)),
 		A: 255,
 	})
+	if aux != nil {
+		aux.set(x, imgy, firstHints)
+	}
+	if radiance != nil {
+		radiance.set(x, imgy, rgb)
+	}
 }
 
-func renderTile(ctx context.Context, scene *Scene, camera Camera, t tile, img *image.RGBA, stats *RenderStats) {
+// renderPixelFilm is renderPixel's splatting counterpart: instead of
+// averaging a pixel's samples and writing one RGBA value, it splats each
+// sample's radiance at its continuous jittered image-plane position into
+// film, under filmMu (Film.Splat touches a small, fixed-size neighborhood
+// per call, so a single coarse mutex shared by every tile costs far less
+// than the ray tracing it guards). Like renderPixel, each sample seeds its
+// own NewPixelRand(Seed, cx, cy, sample) rather than sharing one *Rand
+// across the tile.
+func renderPixelFilm(ctx context.Context, scene *Scene, camera Camera, stats *RenderStats, x, y int, film *Film, filmMu *sync.Mutex) {
+	dx := scene.RenderOptions.Dx
+	dy := scene.RenderOptions.Dy
+	cx := clamp(x, 0, dx-1)
+	cy := clamp(y, 0, dy-1)
+	imgy := dy - 1 - cy // Flip y-axis to match image coordinates.
+	total := scene.RenderOptions.RaysPerPixel
+	for sample := 0; sample < total; sample++ {
+		if ctx.Err() != nil {
+			return
+		}
+		rand := NewPixelRand(scene.RenderOptions.Seed, cx, cy, sample)
+		var jx, jy float64
+		if total == 1 {
+			jx, jy = 0.5, 0.5
+		} else {
+			jx, jy = rand.Float64(), rand.Float64()
+		}
+		s := (float64(cx) + jx) / float64(dx)
+		tSample := (float64(cy) + jy) / float64(dy)
+		r := castPrimaryRay(scene, camera, rand, cx, cy, imgy, sample, total, s, tSample)
+		radiance := tracePath(ctx, scene, r, stats, nil)
+		filmMu.Lock()
+		film.Splat(float64(cx)+jx, float64(imgy)+jy, radiance)
+		filmMu.Unlock()
+	}
+}
+
+func renderTile(ctx context.Context, scene *Scene, camera Camera, t tile, img *image.RGBA, film *Film, filmMu *sync.Mutex, stats *RenderStats, aux *auxBuffers, radiance *RadianceBuffer) {
+	if scene.RenderOptions.AdaptiveSampling {
+		renderTileAdaptive(ctx, scene, camera, t, img, stats)
+		return
+	}
 	for y := t.y0; y < t.y1; y++ {
 		select {
 		case <-ctx.Done():
 			return
 		default:
 		}
-		rand := NewRand(scene.RenderOptions.Seed + int64(y)*int64(scene.RenderOptions.Dx) + int64(t.x0))
 		for x := t.x0; x < t.x1; x++ {
-			renderPixel(ctx, scene, camera, rand, stats, x, y, img)
+			if film != nil {
+				renderPixelFilm(ctx, scene, camera, stats, x, y, film, filmMu)
+			} else {
+				renderPixel(ctx, scene, camera, stats, x, y, img, aux, radiance)
+			}
 		}
 	}
 }
@@ -261,24 +1016,64 @@ func startProgressBar(ctx context.Context, totalTiles int, tilesCompleted *uint6
 	return progressDone
 }
 
-// fillRenderQueue populates the render queue with tiles.
-func fillRenderQueue(ctx context.Context, dx, dy, tileSize int, renderQueue chan tile) {
+// defaultTileSize is used whenever RenderOptions.TileSize isn't set.
+const defaultTileSize = 16
+
+// TileGrid resolves opts.TileSize (applying defaultTileSize if unset) and
+// the resulting tile-grid dimensions for an opts.Dx x opts.Dy image, using
+// the same ceiling-division every render path and RenderArtifact.TileSize/
+// TileCountX/TileCountY tiles by. Callers that need to predict a render's
+// tile count up front (e.g. phys/jobs, to report progress before any tile
+// has finished) should call this instead of re-deriving the arithmetic.
+func TileGrid(opts RenderOptions) (tileSize, numTilesX, numTilesY int) {
+	tileSize = opts.TileSize
+	if tileSize <= 0 {
+		tileSize = defaultTileSize
+	}
+	numTilesX = (opts.Dx + tileSize - 1) / tileSize
+	numTilesY = (opts.Dy + tileSize - 1) / tileSize
+	return tileSize, numTilesX, numTilesY
+}
+
+// resumeTile reports the image RenderOptions.ResumeTile (if set) has
+// stored for t's exact bounds, so the caller can skip tracing it.
+func resumeTile(opts RenderOptions, t tile) (image.Image, bool) {
+	if opts.ResumeTile == nil {
+		return nil, false
+	}
+	return opts.ResumeTile(t.x0, t.y0, t.x1, t.y1)
+}
+
+// fillRenderQueue populates the render queue with tiles, in tileOrder.
+func fillRenderQueue(ctx context.Context, dx, dy, tileSize int, tileOrder TileOrder, renderQueue chan tile) {
 	numTilesX := (dx + tileSize - 1) / tileSize
 	numTilesY := (dy + tileSize - 1) / tileSize
-	for ty := 0; ty < numTilesY; ty++ {
-		for tx := 0; tx < numTilesX; tx++ {
-			select {
-			case <-ctx.Done():
-				return
-			case renderQueue <- tile{
-				x0: tx * tileSize,
-				x1: min((tx+1)*tileSize, dx),
-				y0: ty * tileSize,
-				y1: min((ty+1)*tileSize, dy),
-			}:
+
+	var coords [][2]int
+	if tileOrder == TileOrderHilbert {
+		coords = hilbertTileOrder(numTilesX, numTilesY)
+	} else {
+		coords = make([][2]int, 0, numTilesX*numTilesY)
+		for ty := 0; ty < numTilesY; ty++ {
+			for tx := 0; tx < numTilesX; tx++ {
+				coords = append(coords, [2]int{tx, ty})
 			}
 		}
 	}
+
+	for _, c := range coords {
+		tx, ty := c[0], c[1]
+		select {
+		case <-ctx.Done():
+			return
+		case renderQueue <- tile{
+			x0: tx * tileSize,
+			x1: min((tx+1)*tileSize, dx),
+			y0: ty * tileSize,
+			y1: min((ty+1)*tileSize, dy),
+		}:
+		}
+	}
 	close(renderQueue)
 }
 
@@ -294,16 +1089,43 @@ func renderScene(ctx context.Context, scene *Scene, camera Camera) (RenderArtifa
 	ctxScene, cancel := context.WithCancel(ctx)
 	defer cancel()
 
-	numWorkers := runtime.NumCPU()
-	tileSize := 16
-	numTilesX := (dx + tileSize - 1) / tileSize
-	numTilesY := (dy + tileSize - 1) / tileSize
+	numWorkers := scene.RenderOptions.NumWorkers
+	if numWorkers <= 0 {
+		numWorkers = runtime.NumCPU()
+	}
+	tileSize, numTilesX, numTilesY := TileGrid(scene.RenderOptions)
 	totalTiles := numTilesX * numTilesY
 	var tilesCompleted uint64
 
 	renderQueue := make(chan tile, numWorkers)
 	progressBar := startProgressBar(ctxScene, totalTiles, &tilesCompleted)
 
+	var film *Film
+	var filmMu sync.Mutex
+	if scene.RenderOptions.Filter.Eval != nil {
+		film = NewFilm(dx, dy, scene.RenderOptions.Filter)
+	}
+
+	var aux *auxBuffers
+	if scene.RenderOptions.AuxBuffers || scene.RenderOptions.Denoise {
+		aux = newAuxBuffers(dx, dy)
+	}
+
+	var radiance *RadianceBuffer
+	if len(scene.RenderOptions.PostProcess) > 0 || scene.RenderOptions.Denoise {
+		radiance = newRadianceBuffer(dx, dy)
+	}
+
+	// onTileErr holds the first error an OnTile callback returns, across
+	// however many workers are calling it concurrently; onTileErrOnce
+	// makes sure only the first one cancels the render.
+	var onTileErr error
+	var onTileErrOnce sync.Once
+
+	traceID := TraceIDFromContext(ctx)
+	logger := LoggerFromContext(ctx)
+	var eventsMu sync.Mutex
+
 	// Start worker goroutines.
 	var wg sync.WaitGroup
 	for i := 0; i < numWorkers; i++ {
@@ -311,24 +1133,59 @@ func renderScene(ctx context.Context, scene *Scene, camera Camera) (RenderArtifa
 		go func(workerID int) {
 			defer wg.Done()
 			workerStats := RenderStats{}
-			tilesCompleted := uint64(0)
+			tilesCompletedLocal := uint64(0)
 			for t := range renderQueue {
 				if ctxScene.Err() != nil {
 					return
 				}
-				renderTile(ctxScene, scene, camera, t, img, &workerStats)
-				tilesCompleted++
+				resumed, wasResumed := resumeTile(scene.RenderOptions, t)
+				tileStart := time.Now()
+				if wasResumed {
+					draw.Draw(img, image.Rect(t.x0, t.y0, t.x1, t.y1), resumed, resumed.Bounds().Min, draw.Src)
+				} else {
+					renderTile(ctxScene, scene, camera, t, img, film, &filmMu, &workerStats, aux, radiance)
+				}
+				tilesCompletedLocal++
+				atomic.AddUint64(&tilesCompleted, 1)
+				// Only a tile renderTile actually traced gets a RenderEvent --
+				// a resumed tile's "duration" would just be the draw.Draw copy
+				// above, not a real render timing, and would mislead anyone
+				// using Events to find hot tiles in a render resumed from
+				// checkpoints.
+				if !wasResumed && tilesCompletedLocal%renderEventSampleRate == 0 {
+					event := RenderEvent{TraceID: traceID, TileX: t.x0, TileY: t.y0, Duration: time.Since(tileStart)}
+					logger.Debug("tile completed", "tile_x", t.x0, "tile_y", t.y0, "duration", event.Duration)
+					workerStats.Events = append(workerStats.Events, event)
+				}
+				if scene.RenderOptions.OnTile != nil {
+					res := TileResult{
+						X: t.x0, Y: t.y0, W: t.x1 - t.x0, H: t.y1 - t.y0,
+						SampleIndex: scene.RenderOptions.RaysPerPixel,
+						Image:       img.SubImage(image.Rect(t.x0, t.y0, t.x1, t.y1)),
+					}
+					if err := scene.RenderOptions.OnTile(res); err != nil {
+						onTileErrOnce.Do(func() {
+							onTileErr = err
+							cancel()
+						})
+						return
+					}
+				}
 			}
 
 			// Accumulate workerStats into main stats.
-			atomic.AddUint64(&tilesCompleted, tilesCompleted)
+			atomic.AddUint64(&stats.TilesCompleted, tilesCompletedLocal)
 			atomic.AddUint64(&stats.TotalRays, workerStats.TotalRays)
 			atomic.AddUint64(&stats.RaysExceededDepth, workerStats.RaysExceededDepth)
 			atomic.AddUint64(&stats.RaysLeftScene, workerStats.RaysLeftScene)
+			atomic.AddUint64(&stats.BVHNodeVisits, workerStats.BVHNodeVisits)
+			eventsMu.Lock()
+			stats.Events = append(stats.Events, workerStats.Events...)
+			eventsMu.Unlock()
 		}(i)
 	}
 
-	go fillRenderQueue(ctxScene, dx, dy, tileSize, renderQueue)
+	go fillRenderQueue(ctxScene, dx, dy, tileSize, scene.RenderOptions.TileOrder, renderQueue)
 
 	// Wait for workers to finish or an error to occur.
 	done := make(chan struct{})
@@ -344,8 +1201,383 @@ func renderScene(ctx context.Context, scene *Scene, camera Camera) (RenderArtifa
 	case <-done:
 		close(progressBar)
 	}
+	if onTileErr != nil {
+		return RenderArtifact{}, fmt.Errorf("OnTile: %v", onTileErr)
+	}
 	stats.RenderTime = time.Since(t0)
-	return RenderArtifact{Image: img, Stats: stats}, nil
+	if film != nil {
+		img = film.Resolve()
+	}
+	if radiance != nil {
+		aPass := Aux{}
+		if aux != nil {
+			aPass.Albedo, aPass.Normal = aux.Albedo, aux.Normal
+		}
+		passes := scene.RenderOptions.PostProcess
+		if scene.RenderOptions.Denoise {
+			sigmas := scene.RenderOptions.DenoiseSigmas
+			if sigmas == (BilateralSigmas{}) {
+				sigmas = defaultDenoiseSigmas
+			}
+			iterations := scene.RenderOptions.DenoiseIterations
+			if iterations == 0 {
+				iterations = defaultDenoiseIterations
+			}
+			passes = append(append([]Pass{}, passes...), DenoiseATrous(sigmas, iterations))
+		}
+		img = runPostProcess(radiance, aPass, passes)
+	}
+	artifact := RenderArtifact{Image: img, Stats: stats}
+	if aux != nil {
+		artifact.AuxAlbedo = aux.Albedo
+		artifact.AuxNormal = aux.Normal
+	}
+	if radiance != nil {
+		artifact.Radiance = radiance
+	}
+	return artifact, nil
+}
+
+// pixelWelford is a running accumulator for one pixel across
+// renderScenePassCallback's progressive passes: sum is the plain running
+// total of every sample folded in so far (see rgb), while
+// meanLuminance/m2Luminance is a Welford mean/sum-of-squared-deviations
+// of the scalar luminance that EarlyStopVariance compares against,
+// without ever retaining the individual per-pass samples.
+type pixelWelford struct {
+	n             int
+	sum           Spectrum
+	meanLuminance float64
+	m2Luminance   float64
+}
+
+// add folds one more sample into w.
+func (w *pixelWelford) add(sample Spectrum) {
+	w.n++
+	w.sum = Spectrum(r3.Vec(w.sum).Add(r3.Vec(sample)))
+
+	lum := sample.Luminance()
+	n := float64(w.n)
+	deltaLum := lum - w.meanLuminance
+	w.meanLuminance += deltaLum / n
+	w.m2Luminance += deltaLum * (lum - w.meanLuminance)
+}
+
+// rgb returns w's averaged radiance, summing in the same left-to-right
+// order renderPixel's single-call RaysPerPixel loop does (see
+// renderTilePass) and dividing once at the end -- not Welford's
+// incremental mean update, which would round differently -- so a
+// progressive render's final image is bit-identical to renderScene's
+// when PassCount*SamplesPerPass == RaysPerPixel.
+func (w *pixelWelford) rgb() Spectrum {
+	if w.n == 0 {
+		return Spectrum{}
+	}
+	return Spectrum(r3.Vec(w.sum).Divs(float64(w.n)))
+}
+
+// variance returns the sample variance of w's luminance, or +Inf if fewer
+// than two samples have been folded in (too little information to call it
+// converged either way).
+func (w *pixelWelford) variance() float64 {
+	if w.n < 2 {
+		return math.Inf(1)
+	}
+	return w.m2Luminance / float64(w.n-1)
+}
+
+// renderTilePass traces samplesPerPass samples per pixel across t for a
+// single progressive pass, folding each into welford (indexed by
+// imgy*dx+x, the same layout writeWelfordImage and tileConverged use).
+// Pass p's samples are pixel (x,y)'s global samples
+// (p-1)*samplesPerPass .. p*samplesPerPass-1, each seeded from
+// NewPixelRand(Seed, x, y, sample) -- the same per-sample seed renderPixel
+// uses across its single-call RaysPerPixel loop -- so a given pixel's
+// samples are bit-exact regardless of RenderOptions.TileSize, worker
+// count, or tile completion order, and (see pixelWelford.rgb) a
+// progressive render folds in exactly the same samples in exactly the
+// same order as a single renderScene call over the same total count.
+func renderTilePass(ctx context.Context, scene *Scene, camera Camera, t tile, pass, samplesPerPass int, welford []pixelWelford, stats *RenderStats) {
+	opts := scene.RenderOptions
+	dx, dy := opts.Dx, opts.Dy
+	for y := t.y0; y < t.y1; y++ {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+		imgy := dy - 1 - y
+		for x := t.x0; x < t.x1; x++ {
+			for s := 0; s < samplesPerPass; s++ {
+				sample := (pass-1)*samplesPerPass + s
+				rand := NewPixelRand(opts.Seed, x, y, sample)
+				radiance, _ := castPixelSampleJittered(ctx, scene, camera, rand, stats, x, y, imgy, sample, opts.RaysPerPixel)
+				welford[imgy*dx+x].add(radiance)
+			}
+		}
+	}
+}
+
+// tileConverged reports whether every pixel in t has a Welford luminance
+// variance at or below threshold, letting renderScenePassCallback retire
+// the tile from later passes instead of spending its full sample budget
+// on an already-converged region.
+func tileConverged(t tile, dx, dy int, welford []pixelWelford, threshold float64) bool {
+	for y := t.y0; y < t.y1; y++ {
+		imgy := dy - 1 - y
+		for x := t.x0; x < t.x1; x++ {
+			if welford[imgy*dx+x].variance() > threshold {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// tileMaxVariance returns the largest Welford luminance variance among
+// t's pixels, the same worst-case-over-the-tile quantity tileConverged
+// thresholds against, for reporting in RenderArtifact.TileVariance. A
+// pixel's +Inf variance (fewer than two samples folded in yet) makes the
+// whole tile report +Inf.
+func tileMaxVariance(t tile, dx, dy int, welford []pixelWelford) float64 {
+	var worst float64
+	for y := t.y0; y < t.y1; y++ {
+		imgy := dy - 1 - y
+		for x := t.x0; x < t.x1; x++ {
+			if v := welford[imgy*dx+x].variance(); v > worst {
+				worst = v
+			}
+		}
+	}
+	return worst
+}
+
+// writeWelfordImage writes every pixel's running mean into img.
+func writeWelfordImage(welford []pixelWelford, img *image.RGBA, dx, dy int) {
+	for y := 0; y < dy; y++ {
+		for x := 0; x < dx; x++ {
+			rgb := welford[y*dx+x].rgb()
+			img.Set(x, y, color.RGBA{
+				R: uint8(math.Min(255, 255.99*rgb.X)),
+				G: uint8(math.Min(255, 255.99*rgb.Y)),
+				B: uint8(math.Min(255, 255.99*rgb.Z)),
+				A: 255,
+			})
+		}
+	}
+}
+
+// renderScenePassCallback renders scene's camera as PassCount (or
+// RaysPerPixel/SamplesPerPass, if PassCount is zero) sequential
+// full-frame passes of SamplesPerPass samples per pixel each, instead of
+// renderScene's single pass that accumulates RaysPerPixel samples per
+// pixel internally before returning. A running pixelWelford per pixel
+// tracks the averaged radiance and luminance variance across samples;
+// after every pass, scene.RenderOptions.OnPass (if set) is called with
+// the pass number and the artifact accumulated so far, and a tile whose
+// every pixel's variance has dropped below EarlyStopVariance is skipped
+// in subsequent passes. This is the render path Render dispatches to when
+// OnPass is set.
+//
+// A context cancellation (or an OnPass error) stops after the in-flight
+// pass's workers return and reports the artifact accumulated through the
+// last pass that finished, rather than discarding it -- a caller orbiting
+// a camera can cancel a long progressive render mid-flight and still get
+// back the best preview rendered so far instead of nothing.
+func renderScenePassCallback(ctx context.Context, scene *Scene, camera Camera) (RenderArtifact, error) {
+	t0 := time.Now()
+	opts := scene.RenderOptions
+	dx, dy := opts.Dx, opts.Dy
+
+	samplesPerPass := opts.SamplesPerPass
+	if samplesPerPass <= 0 {
+		samplesPerPass = 1
+	}
+	passCount := opts.PassCount
+	if passCount <= 0 {
+		// Round up so the default never spends fewer than RaysPerPixel
+		// samples per pixel; a RaysPerPixel not evenly divisible by
+		// SamplesPerPass spends a few extra samples on its last pass
+		// instead of quietly under-sampling.
+		passCount = (opts.RaysPerPixel + samplesPerPass - 1) / samplesPerPass
+		if passCount <= 0 {
+			passCount = 1
+		}
+	}
+	tileSize, numTilesX, numTilesY := TileGrid(opts)
+
+	stats := RenderStats{Dx: dx, Dy: dy}
+	welford := make([]pixelWelford, dx*dy)
+	img := image.NewRGBA(image.Rect(0, 0, dx, dy))
+
+	tileDone := make([]bool, numTilesX*numTilesY)
+	tileVariance := make([]float64, numTilesX*numTilesY)
+	numWorkers := opts.NumWorkers
+	if numWorkers <= 0 {
+		numWorkers = runtime.NumCPU()
+	}
+
+	ctxScene, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var artifact RenderArtifact
+	for pass := 1; pass <= passCount; pass++ {
+		if ctxScene.Err() != nil {
+			return artifact, ctxScene.Err()
+		}
+
+		// Snapshot which tiles are still active before this pass retires
+		// any more of them, so the pass's UpdatedTiles reports exactly
+		// the tiles it traced a sample into.
+		updatedTiles := make([]int, 0, len(tileDone))
+		for idx, done := range tileDone {
+			if !done {
+				updatedTiles = append(updatedTiles, idx)
+			}
+		}
+
+		type queuedTile struct {
+			t   tile
+			idx int
+		}
+		tileQueue := make(chan queuedTile, numWorkers)
+		go func() {
+			defer close(tileQueue)
+			for ty := 0; ty < numTilesY; ty++ {
+				for tx := 0; tx < numTilesX; tx++ {
+					idx := ty*numTilesX + tx
+					if tileDone[idx] {
+						continue
+					}
+					select {
+					case <-ctxScene.Done():
+						return
+					case tileQueue <- queuedTile{t: tile{
+						x0: tx * tileSize,
+						x1: min((tx+1)*tileSize, dx),
+						y0: ty * tileSize,
+						y1: min((ty+1)*tileSize, dy),
+					}, idx: idx}:
+					}
+				}
+			}
+		}()
+
+		var wg sync.WaitGroup
+		for i := 0; i < numWorkers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				workerStats := RenderStats{}
+				tilesCompletedLocal := uint64(0)
+				for qt := range tileQueue {
+					if ctxScene.Err() != nil {
+						return
+					}
+					renderTilePass(ctxScene, scene, camera, qt.t, pass, samplesPerPass, welford, &workerStats)
+					tilesCompletedLocal++
+					if opts.EarlyStopVariance > 0 && tileConverged(qt.t, dx, dy, welford, opts.EarlyStopVariance) {
+						tileDone[qt.idx] = true
+					}
+				}
+				atomic.AddUint64(&stats.TotalRays, workerStats.TotalRays)
+				atomic.AddUint64(&stats.RaysExceededDepth, workerStats.RaysExceededDepth)
+				atomic.AddUint64(&stats.RaysLeftScene, workerStats.RaysLeftScene)
+				atomic.AddUint64(&stats.BVHNodeVisits, workerStats.BVHNodeVisits)
+				atomic.AddUint64(&stats.TilesCompleted, tilesCompletedLocal)
+			}()
+		}
+		wg.Wait()
+
+		if ctxScene.Err() != nil {
+			return artifact, ctxScene.Err()
+		}
+
+		writeWelfordImage(welford, img, dx, dy)
+		stats.RenderTime = time.Since(t0)
+		// Only the tiles this pass actually rendered into can have a
+		// changed variance; a retired tile's welford samples are frozen,
+		// so recomputing its entry here would just redo the same scan.
+		for _, idx := range updatedTiles {
+			tx, ty := idx%numTilesX, idx/numTilesX
+			tileVariance[idx] = tileMaxVariance(tile{
+				x0: tx * tileSize,
+				x1: min((tx+1)*tileSize, dx),
+				y0: ty * tileSize,
+				y1: min((ty+1)*tileSize, dy),
+			}, dx, dy, welford)
+		}
+		artifact = RenderArtifact{
+			Image:        img,
+			Stats:        stats,
+			TileSize:     tileSize,
+			TileCountX:   numTilesX,
+			TileCountY:   numTilesY,
+			TileVariance: tileVariance,
+			UpdatedTiles: updatedTiles,
+		}
+
+		if opts.OnPass != nil {
+			if err := opts.OnPass(pass, &artifact); err != nil {
+				return artifact, fmt.Errorf("OnPass(%d): %v", pass, err)
+			}
+		}
+	}
+
+	return artifact, nil
+}
+
+// RenderTiled renders scene like Render, but calls tileCb after every tile
+// finishes instead of waiting for the whole image, so a caller such as a
+// web server can stream each tile to a client as soon as it's ready (see
+// TileResult) instead of blocking on the full render. It's built on
+// RenderOptions.OnTile rather than a separate code path, so it inherits
+// renderScene's worker pool and tiling unchanged; a non-nil tileCb error
+// cancels the render the same way a context cancellation does.
+//
+// RenderTiled does not mutate scene: it renders a shallow copy with
+// RenderOptions.OnTile set to tileCb.
+// PathtracerProgressive renders scene like Render, but in sequential
+// passes of passes.SamplesPerPass samples per pixel each (PassCount of
+// them, defaulting as usual to RaysPerPixel/SamplesPerPass), calling
+// onPass with the 1-based pass number and the RenderArtifact accumulated
+// so far after every one -- an interactive preview is visible in roughly
+// 1/PassCount of the time a single RaysPerPixel render would take, and
+// refines from there. It's a thin, named entry point over
+// RenderOptions.OnPass/PassCount/SamplesPerPass (see
+// renderScenePassCallback) for callers who want a progressive render
+// without threading those fields through by hand; a caller that also
+// needs AdaptiveSampling, Filter, or another RenderOptions combination
+// OnPass doesn't yet support should set the fields directly instead.
+//
+// A context cancellation (or an onPass error) stops between passes and
+// returns the best artifact accumulated through the last pass that
+// finished, the same way renderScenePassCallback does -- exactly what
+// lets an interactive caller like the animate example short-circuit a
+// frame the moment it's no longer needed instead of discarding it.
+//
+// PathtracerProgressive does not mutate scene: it renders a shallow copy
+// with RenderOptions.OnPass set to onPass.
+func PathtracerProgressive(ctx context.Context, scene *Scene, onPass func(pass int, artifact *RenderArtifact) error) (RenderArtifact, error) {
+	clone := *scene
+	clone.RenderOptions.OnPass = onPass
+	return Render(ctx, &clone)
+}
+
+// RenderTiled renders scene like Render, but calls tileCb after every tile
+// finishes instead of waiting for the whole image, so a caller such as a
+// web server can stream each tile to a client as soon as it's ready (see
+// TileResult) instead of blocking on the full render. It's built on
+// RenderOptions.OnTile rather than a separate code path, so it inherits
+// renderScene's worker pool and tiling unchanged; a non-nil tileCb error
+// cancels the render the same way a context cancellation does.
+//
+// RenderTiled does not mutate scene: it renders a shallow copy with
+// RenderOptions.OnTile set to tileCb.
+func RenderTiled(ctx context.Context, scene *Scene, tileCb func(TileResult) error) (RenderArtifact, error) {
+	clone := *scene
+	clone.RenderOptions.OnTile = tileCb
+	return Render(ctx, &clone)
 }
 
 func Render(ctx context.Context, scene *Scene) (output RenderArtifact, err error) {
@@ -356,9 +1588,28 @@ func Render(ctx context.Context, scene *Scene) (output RenderArtifact, err error
 	// Select the first camera in the scene.
 	// We already know there is at least one camera in the scene.
 	camera := scene.Camera[0]
-	output, err = renderScene(ctx, scene, camera)
+	if scene.RenderOptions.FrustumCull && scene.Accel != nil {
+		if frustum, ferr := FrustumFromCamera(camera); ferr == nil {
+			var visible []Shape
+			scene.Accel.CollectVisible(frustum, &visible)
+			clone := *scene
+			clone.Accel = NewBVH(visible, 0)
+			scene = &clone
+		}
+	}
+	if scene.RenderOptions.OnPass != nil {
+		output, err = renderScenePassCallback(ctx, scene, camera)
+	} else {
+		output, err = renderScene(ctx, scene, camera)
+	}
 	if err != nil {
-		return RenderArtifact{}, fmt.Errorf("failed to render scene: %v", err)
+		// output already holds whatever renderScenePassCallback or
+		// renderScene managed to accumulate before err (the zero value if
+		// neither got that far), so it's returned alongside the wrapped
+		// error rather than discarded -- see renderScenePassCallback's doc
+		// comment on returning the best progressive artifact on
+		// cancellation.
+		return output, fmt.Errorf("failed to render scene: %v", err)
 	}
 	return output, nil
 }
@@ -0,0 +1,93 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+
+package phys
+
+import (
+	"math"
+	"testing"
+)
+
+// TestPixelWelfordFlatPixelHasZeroVariance verifies that a pixel whose
+// samples all agree has zero Welford variance, so tileConverged treats it
+// as converged immediately.
+func TestPixelWelfordFlatPixelHasZeroVariance(t *testing.T) {
+	var w pixelWelford
+	for i := 0; i < 8; i++ {
+		w.add(Spectrum{X: 0.25, Y: 0.25, Z: 0.25})
+	}
+	if got := w.variance(); got != 0 {
+		t.Errorf("variance() = %v, want 0 for a perfectly flat pixel", got)
+	}
+}
+
+// TestPixelWelfordNoisyPixelHasHigherVariance verifies that a pixel with
+// varying luminance across samples scores a higher variance than a flat
+// one, which is what EarlyStopVariance compares against.
+func TestPixelWelfordNoisyPixelHasHigherVariance(t *testing.T) {
+	var flat, noisy pixelWelford
+	for i := 0; i < 8; i++ {
+		flat.add(Spectrum{X: 0.5, Y: 0.5, Z: 0.5})
+		if i%2 == 0 {
+			noisy.add(Spectrum{X: 0, Y: 0, Z: 0})
+		} else {
+			noisy.add(Spectrum{X: 1, Y: 1, Z: 1})
+		}
+	}
+	if noisy.variance() <= flat.variance() {
+		t.Errorf("variance() noisy=%v flat=%v, want noisy > flat", noisy.variance(), flat.variance())
+	}
+}
+
+// TestPixelWelfordMean verifies add accumulates the running mean radiance.
+func TestPixelWelfordMean(t *testing.T) {
+	var w pixelWelford
+	w.add(Spectrum{X: 1, Y: 0, Z: 0})
+	w.add(Spectrum{X: 0, Y: 1, Z: 0})
+	got := w.rgb()
+	want := Spectrum{X: 0.5, Y: 0.5, Z: 0}
+	if got != want {
+		t.Errorf("rgb() = %v, want %v", got, want)
+	}
+}
+
+// TestPixelWelfordVarianceUndefinedBelowTwoSamples verifies variance
+// reports +Inf (never converged) until there are at least two samples to
+// compare, matching tileConverged's treatment of freshly-started tiles.
+func TestPixelWelfordVarianceUndefinedBelowTwoSamples(t *testing.T) {
+	var w pixelWelford
+	if got := w.variance(); !math.IsInf(got, 1) {
+		t.Errorf("variance() with 0 samples = %v, want +Inf", got)
+	}
+	w.add(Spectrum{X: 1, Y: 1, Z: 1})
+	if got := w.variance(); !math.IsInf(got, 1) {
+		t.Errorf("variance() with 1 sample = %v, want +Inf", got)
+	}
+}
+
+// TestTileMaxVarianceReportsWorstPixel verifies tileMaxVariance (what
+// RenderArtifact.TileVariance reports per tile) returns the single
+// noisiest pixel's variance rather than an average, matching
+// tileConverged's all-pixels-must-converge threshold check.
+func TestTileMaxVarianceReportsWorstPixel(t *testing.T) {
+	dx, dy := 4, 2
+	welford := make([]pixelWelford, dx*dy)
+	for i := range welford {
+		for s := 0; s < 4; s++ {
+			welford[i].add(Spectrum{X: 0.5, Y: 0.5, Z: 0.5})
+		}
+	}
+	// Make pixel (2, 0) noisy; every other pixel in the tile stays flat.
+	noisyIdx := 0*dx + 2
+	welford[noisyIdx] = pixelWelford{}
+	for s := 0; s < 4; s++ {
+		if s%2 == 0 {
+			welford[noisyIdx].add(Spectrum{X: 0, Y: 0, Z: 0})
+		} else {
+			welford[noisyIdx].add(Spectrum{X: 1, Y: 1, Z: 1})
+		}
+	}
+	got := tileMaxVariance(tile{x0: 0, x1: dx, y0: 0, y1: dy}, dx, dy, welford)
+	if got != welford[noisyIdx].variance() {
+		t.Errorf("tileMaxVariance() = %v, want the noisy pixel's own variance %v", got, welford[noisyIdx].variance())
+	}
+}
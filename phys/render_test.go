@@ -0,0 +1,43 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import (
+	"testing"
+	"time"
+)
+
+// TestNewFrameStatsSummarizesRenderStats verifies NewFrameStats derives its
+// fields from RenderStats and raysPerPixel the way its doc comment
+// describes, rather than, say, leaving NanosPerPixel at zero or forgetting
+// to multiply SamplesAccumulated by raysPerPixel.
+func TestNewFrameStatsSummarizesRenderStats(t *testing.T) {
+	stats := RenderStats{
+		TotalRays:      1000,
+		BVHNodeVisits:  5000,
+		TilesCompleted: 4,
+		RenderTime:     10 * time.Millisecond,
+		Dx:             10,
+		Dy:             10,
+	}
+	got := NewFrameStats(stats, 8)
+	want := FrameStats{
+		NanosPerPixel:      float64((10 * time.Millisecond).Nanoseconds()) / 100,
+		RaysCast:           1000,
+		BVHNodeVisits:      5000,
+		SamplesAccumulated: 10 * 10 * 8,
+		TilesCompleted:     4,
+	}
+	if got != want {
+		t.Errorf("NewFrameStats(%+v, 8) = %+v, want %+v", stats, got, want)
+	}
+}
+
+// TestNewFrameStatsZeroDimensionsAvoidsDivideByZero verifies NewFrameStats
+// leaves NanosPerPixel at zero instead of computing RenderTime/0 when
+// RenderStats.Dx or Dy is zero (e.g. a RenderStats that was never populated).
+func TestNewFrameStatsZeroDimensionsAvoidsDivideByZero(t *testing.T) {
+	got := NewFrameStats(RenderStats{RenderTime: time.Second}, 1)
+	if got.NanosPerPixel != 0 {
+		t.Errorf("NewFrameStats with Dx=Dy=0: NanosPerPixel = %v, want 0", got.NanosPerPixel)
+	}
+}
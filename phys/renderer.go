@@ -0,0 +1,59 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import (
+	"context"
+	"fmt"
+)
+
+// Renderer produces a RenderArtifact from a Scene. It mirrors the
+// multi-backend driver pattern Gio GPU uses for its opengl/vulkan/metal/
+// d3d11 drivers: callers program against the single Renderer interface
+// and pick a concrete backend with NewRenderer and
+// RenderOptions.Backend, instead of calling Render (the CPU path tracer)
+// directly.
+//
+// Release frees any backend-owned resources (GPU buffers, shader
+// programs, ...) and must be called when the Renderer is no longer
+// needed. A Renderer's zero value is not expected to be usable; build
+// one with NewRenderer.
+type Renderer interface {
+	Render(ctx context.Context, scene *Scene) (RenderArtifact, error)
+	Release()
+}
+
+// NewRenderer builds the Renderer named by opts.Backend ("" and "cpu"
+// both select the CPU path tracer). The "gl" backend requires a non-nil
+// device implementing GLDevice -- typically the same context a caller's
+// window was created with, so the renderer and the window share one GL
+// context rather than each opening their own. device is ignored for the
+// "cpu" backend and may be nil.
+func NewRenderer(opts RenderOptions, device GLDevice) (Renderer, error) {
+	switch opts.Backend {
+	case "", "cpu":
+		return NewCPURenderer(), nil
+	case "gl":
+		if device == nil {
+			return nil, fmt.Errorf("phys.NewRenderer: backend %q requires a non-nil GLDevice", opts.Backend)
+		}
+		return NewGLRenderer(device), nil
+	default:
+		return nil, fmt.Errorf("phys.NewRenderer: unknown Backend %q", opts.Backend)
+	}
+}
+
+// cpuRenderer is the Renderer wrapping the existing CPU path tracer
+// (Render/renderScene). It owns no resources, so Release is a no-op.
+type cpuRenderer struct{}
+
+// NewCPURenderer returns the Renderer backed by the existing CPU path
+// tracer, i.e. the Renderer RenderOptions.Backend "cpu" selects.
+func NewCPURenderer() Renderer {
+	return cpuRenderer{}
+}
+
+func (cpuRenderer) Render(ctx context.Context, scene *Scene) (RenderArtifact, error) {
+	return Render(ctx, scene)
+}
+
+func (cpuRenderer) Release() {}
@@ -0,0 +1,149 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"time"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
+)
+
+// GLDevice is the subset of a GL window/context a glRenderer needs to
+// run the primary-ray intersection compute shader: upload the scene's
+// primitives as SSBOs once per Render call, then dispatch against a
+// Camera. A caller's own window context (e.g. the one a gl.Win already
+// created) is expected to satisfy GLDevice directly, so the renderer
+// dispatches into the same GL context the window displays with instead
+// of opening a second, competing one.
+type GLDevice interface {
+	// UploadPrimitives uploads the scene's spheres, cylinders, and quads
+	// as SSBOs, replacing whatever was previously uploaded.
+	UploadPrimitives(spheres []GPUSphere, cylinders []GPUCylinder, quads []GPUQuad) error
+	// DispatchPrimaryRays runs the primary-ray intersection compute
+	// shader against the most recently uploaded primitives for camera,
+	// and returns the resulting dx x dy image.
+	DispatchPrimaryRays(camera Camera, dx, dy int) (*image.RGBA, error)
+	// Release frees the device's GPU-side buffers and programs.
+	Release()
+}
+
+// GPUSphere is a Sphere plus its flat base color, laid out for upload as
+// an SSBO element; see GLDevice.UploadPrimitives.
+type GPUSphere struct {
+	Center r3.Point
+	Radius Distance
+	Color  Spectrum
+}
+
+// GPUCylinder is a Cylinder plus its flat base color, laid out for
+// upload as an SSBO element.
+type GPUCylinder struct {
+	Origin    r3.Point
+	Direction r3.Vec
+	Radius    Distance
+	Height    Distance
+	Color     Spectrum
+}
+
+// GPUQuad is a Quad plus its flat base color, laid out for upload as an
+// SSBO element.
+type GPUQuad struct {
+	Center r3.Point
+	Normal r3.Vec
+	Width  Distance
+	Height Distance
+	Color  Spectrum
+}
+
+// glRenderer is the Renderer RenderOptions.Backend "gl" selects: each
+// Render uploads scene's spheres/cylinders/quads to device and asks it
+// to intersect primary rays against them on the GPU. It models a flat
+// base color per primitive only -- no reflections, refraction, or
+// indirect lighting -- so it suits fast geometry previews, not final
+// renders; use the "cpu" backend (NewCPURenderer, Render) for those.
+type glRenderer struct {
+	device GLDevice
+}
+
+// NewGLRenderer returns the Renderer backed by device, i.e. the Renderer
+// RenderOptions.Backend "gl" selects. device is typically a caller's own
+// window's GL context, so the compute dispatch and the window display
+// share one context.
+func NewGLRenderer(device GLDevice) Renderer {
+	return &glRenderer{device: device}
+}
+
+func (g *glRenderer) Render(ctx context.Context, scene *Scene) (RenderArtifact, error) {
+	if err := scene.Validate(); err != nil {
+		return RenderArtifact{}, fmt.Errorf("invalid scene: %v", err)
+	}
+	spheres, cylinders, quads, err := gpuPrimitives(scene)
+	if err != nil {
+		return RenderArtifact{}, fmt.Errorf("phys.glRenderer: %v", err)
+	}
+	if err := g.device.UploadPrimitives(spheres, cylinders, quads); err != nil {
+		return RenderArtifact{}, fmt.Errorf("phys.glRenderer: upload: %v", err)
+	}
+	t0 := time.Now()
+	img, err := g.device.DispatchPrimaryRays(scene.Camera[0], scene.RenderOptions.Dx, scene.RenderOptions.Dy)
+	if err != nil {
+		return RenderArtifact{}, fmt.Errorf("phys.glRenderer: dispatch: %v", err)
+	}
+	stats := RenderStats{
+		TotalRays:  uint64(scene.RenderOptions.Dx * scene.RenderOptions.Dy),
+		RenderTime: time.Since(t0),
+		Dx:         scene.RenderOptions.Dx,
+		Dy:         scene.RenderOptions.Dy,
+	}
+	return RenderArtifact{Image: img, Stats: stats}, nil
+}
+
+func (g *glRenderer) Release() {
+	g.device.Release()
+}
+
+// gpuPrimitives converts scene's nodes into the SSBO-ready primitive
+// lists GLDevice.UploadPrimitives expects. A Node whose Shape is not a
+// Sphere, Cylinder, or Quad is rejected: the compute shader this backend
+// targets only intersects those three primitive kinds.
+func gpuPrimitives(scene *Scene) (spheres []GPUSphere, cylinders []GPUCylinder, quads []GPUQuad, err error) {
+	for _, n := range scene.Node {
+		color := gpuNodeColor(n.Material)
+		switch shape := n.Shape.(type) {
+		case Sphere:
+			spheres = append(spheres, GPUSphere{Center: shape.Center, Radius: shape.Radius, Color: color})
+		case Cylinder:
+			cylinders = append(cylinders, GPUCylinder{Origin: shape.Origin, Direction: shape.Direction, Radius: shape.Radius, Height: shape.Height, Color: color})
+		case Quad:
+			quads = append(quads, GPUQuad{Center: shape.Center, Normal: shape.Normal, Width: shape.Width, Height: shape.Height, Color: color})
+		default:
+			return nil, nil, nil, fmt.Errorf("node %q: shape %T is not supported by the gl backend (want Sphere, Cylinder, or Quad)", n.Name, n.Shape)
+		}
+	}
+	return spheres, cylinders, quads, nil
+}
+
+// gpuNodeColor approximates mat's flat base color for upload, the same
+// way materialAlbedo does but sampling a fixed representative UV instead
+// of a hit point's, since the SSBO upload happens once per primitive
+// rather than per ray.
+func gpuNodeColor(mat Material) Spectrum {
+	switch m := mat.(type) {
+	case Lambertian:
+		return m.Texture.At(0.5, 0.5)
+	case Diffuse:
+		return m.Texture.At(0.5, 0.5)
+	case RoughPlastic:
+		return m.Texture.At(0.5, 0.5)
+	case Metal:
+		return Spectrum(m.Albedo)
+	case Mirror:
+		return Spectrum(m.F0)
+	case Emitter:
+		return m.Texture.At(0.5, 0.5)
+	default:
+		return Spectrum{X: 0.5, Y: 0.5, Z: 0.5}
+	}
+}
@@ -0,0 +1,150 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"runtime"
+	"sync"
+)
+
+// progressiveTileSize is the fixed tile edge length ProgressiveRenderer.Pass
+// divides the image into, independent of RenderOptions.TileSize/
+// defaultTileSize -- a ProgressiveRenderer's tiling is an implementation
+// detail of how it parallelizes one Pass, not a tunable the caller
+// configures.
+const progressiveTileSize = 32
+
+// ProgressiveRenderer is an incremental, interruptible alternative to
+// Render: instead of tracing RenderOptions.RaysPerPixel samples per pixel
+// in one blocking call, each Pass adds samplesPerPixel more samples to
+// every pixel of a persistent Film and hands back the image as it stands,
+// so a caller can keep calling Pass to refine a preview that's already on
+// screen. The zero ProgressiveRenderer is not usable; construct one with
+// NewProgressiveRenderer.
+type ProgressiveRenderer struct {
+	film      *Film
+	passIndex int
+}
+
+// NewProgressiveRenderer returns a ProgressiveRenderer with no accumulated
+// samples yet. Its Film is sized from the first scene passed to Pass, so
+// scene.RenderOptions.Dx and Dy must stay constant across a
+// ProgressiveRenderer's Pass calls.
+func NewProgressiveRenderer() *ProgressiveRenderer {
+	return &ProgressiveRenderer{}
+}
+
+// Pass adds samplesPerPixel more samples to every pixel of r's
+// accumulation buffer by tracing scene through its first camera, then
+// returns the buffer's current tonemapped image. Each call divides the
+// image into fixed progressiveTileSize tiles dispatched to a worker pool
+// sized by runtime.NumCPU(), with one *Rand per tile seeded from
+// (r.passIndex, tileX, tileY) -- shared by every sample of every pixel the
+// tile covers this pass -- so calling Pass again with an unchanged scene
+// and worker count reproduces the same refinement step by step.
+func (r *ProgressiveRenderer) Pass(ctx context.Context, scene *Scene, samplesPerPixel int) (image.Image, error) {
+	if samplesPerPixel <= 0 {
+		return nil, fmt.Errorf("phys.ProgressiveRenderer.Pass: samplesPerPixel must be positive, got %d", samplesPerPixel)
+	}
+	if err := scene.Validate(); err != nil {
+		return nil, fmt.Errorf("phys.ProgressiveRenderer.Pass: invalid scene: %v", err)
+	}
+	dx, dy := scene.RenderOptions.Dx, scene.RenderOptions.Dy
+	if r.film == nil {
+		r.film = NewFilm(dx, dy, scene.RenderOptions.Filter)
+	} else if r.film.Dx != dx || r.film.Dy != dy {
+		return nil, fmt.Errorf("phys.ProgressiveRenderer.Pass: scene is %dx%d but this ProgressiveRenderer was started at %dx%d", dx, dy, r.film.Dx, r.film.Dy)
+	}
+	camera := scene.Camera[0]
+
+	numTilesX := (dx + progressiveTileSize - 1) / progressiveTileSize
+	numTilesY := (dy + progressiveTileSize - 1) / progressiveTileSize
+
+	// tileCoord, not render.go's own tile, because progressiveTileSeed
+	// needs each tile's (tx, ty) grid index alongside its pixel bounds,
+	// and tile's fixed progressiveTileSize (vs. RenderOptions.TileSize)
+	// doesn't line up with TileGrid/fillRenderQueue's tiling either.
+	type tileCoord struct{ x0, y0, tx, ty int }
+	tileQueue := make(chan tileCoord, numTilesX*numTilesY)
+	for ty := 0; ty < numTilesY; ty++ {
+		for tx := 0; tx < numTilesX; tx++ {
+			tileQueue <- tileCoord{x0: tx * progressiveTileSize, y0: ty * progressiveTileSize, tx: tx, ty: ty}
+		}
+	}
+	close(tileQueue)
+
+	var filmMu sync.Mutex
+	var stats RenderStats
+	numWorkers := runtime.NumCPU()
+	var wg sync.WaitGroup
+	for i := 0; i < numWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for tc := range tileQueue {
+				if ctx.Err() != nil {
+					return
+				}
+				x1 := min(tc.x0+progressiveTileSize, dx)
+				y1 := min(tc.y0+progressiveTileSize, dy)
+				rnd := NewRand(progressiveTileSeed(r.passIndex, tc.tx, tc.ty))
+				r.renderProgressiveTile(ctx, scene, camera, &stats, rnd, tc.x0, tc.y0, x1, y1, samplesPerPixel, &filmMu)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return r.film.Resolve(), err
+	}
+	r.passIndex++
+	return r.film.Resolve(), nil
+}
+
+// renderProgressiveTile traces samplesPerPixel samples for every pixel in
+// [x0,x1) x [y0,y1), all drawn from the single tile-shared rnd, and splats
+// each one into r.film under filmMu.
+func (r *ProgressiveRenderer) renderProgressiveTile(ctx context.Context, scene *Scene, camera Camera, stats *RenderStats, rnd *Rand, x0, y0, x1, y1, samplesPerPixel int, filmMu *sync.Mutex) {
+	dy := scene.RenderOptions.Dy
+	for y := y0; y < y1; y++ {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+		imgy := dy - 1 - y // Flip y-axis to match image coordinates, as renderPixelFilm does.
+		for x := x0; x < x1; x++ {
+			for sample := 0; sample < samplesPerPixel; sample++ {
+				jx, jy := rnd.Float64(), rnd.Float64()
+				s := (float64(x) + jx) / float64(scene.RenderOptions.Dx)
+				t := (float64(y) + jy) / float64(dy)
+				ray := camera.Cast(s, t, rnd)
+				ray.pixelX = x
+				ray.pixelY = imgy
+				if scene.RenderOptions.Spectral {
+					ray.wavelengths = NewHeroWavelengths(rnd)
+				}
+				radiance := tracePath(ctx, scene, ray, stats, nil)
+				filmMu.Lock()
+				r.film.Splat(float64(x)+jx, float64(imgy)+jy, radiance)
+				filmMu.Unlock()
+			}
+		}
+	}
+}
+
+// progressiveTileSeed derives a 64-bit seed from (passIndex, tileX, tileY)
+// by chaining splitMix64, the same mixing primitive pixelSampleSeed uses
+// for per-pixel-per-sample seeds. ProgressiveRenderer.Pass uses one *Rand
+// per tile instead of NewPixelRand's per-sample granularity, since a
+// progressive preview only needs Pass(n) to reproduce Pass(n) -- not
+// bit-exact independence from tile size or worker scheduling within a
+// single pass.
+func progressiveTileSeed(passIndex, tileX, tileY int) int64 {
+	s := splitMix64(uint64(uint32(passIndex)))
+	s = splitMix64(s ^ uint64(uint32(tileX)))
+	s = splitMix64(s ^ uint64(uint32(tileY)))
+	return int64(s)
+}
@@ -0,0 +1,97 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import (
+	"context"
+	"image"
+	"testing"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
+)
+
+// fakeGLDevice is an in-memory GLDevice stand-in: it records the
+// primitives it was asked to upload and returns a blank image from
+// DispatchPrimaryRays, so glRenderer can be exercised without a real GPU.
+type fakeGLDevice struct {
+	spheres   []GPUSphere
+	cylinders []GPUCylinder
+	quads     []GPUQuad
+	released  bool
+}
+
+func (d *fakeGLDevice) UploadPrimitives(spheres []GPUSphere, cylinders []GPUCylinder, quads []GPUQuad) error {
+	d.spheres, d.cylinders, d.quads = spheres, cylinders, quads
+	return nil
+}
+
+func (d *fakeGLDevice) DispatchPrimaryRays(camera Camera, dx, dy int) (*image.RGBA, error) {
+	return image.NewRGBA(image.Rect(0, 0, dx, dy)), nil
+}
+
+func (d *fakeGLDevice) Release() { d.released = true }
+
+func testGLScene() *Scene {
+	return &Scene{
+		RenderOptions: RenderOptions{Seed: 1, RaysPerPixel: 1, MaxRayDepth: 1, Dx: 4, Dy: 4, Backend: "gl"},
+		Camera:        []Camera{OrthographicCamera{FOVWidth: 1, FOVHeight: 1, LookFrom: r3.Point{Z: 1}, LookAt: r3.Point{}, VUp: r3.Vec{Y: 1}}},
+		Node: []Node{
+			{Name: "Sphere1", Shape: Sphere{Center: r3.Point{}, Radius: 1}, Material: Lambertian{Texture: TextureUniform{Color: Spectrum{X: 1}}}},
+			{Name: "Quad1", Shape: Quad{Center: r3.Point{}, Normal: r3.Vec{Z: 1}, Width: 1, Height: 1}, Material: Metal{Albedo: r3.Vec{Y: 1}}},
+		},
+	}
+}
+
+func TestGLRendererUploadsPrimitivesAndDispatches(t *testing.T) {
+	device := &fakeGLDevice{}
+	renderer := NewGLRenderer(device)
+	defer renderer.Release()
+
+	scene := testGLScene()
+	artifact, err := renderer.Render(context.Background(), scene)
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	if got := artifact.Image.Bounds().Dx(); got != scene.RenderOptions.Dx {
+		t.Errorf("image width = %d, want %d", got, scene.RenderOptions.Dx)
+	}
+	if len(device.spheres) != 1 {
+		t.Errorf("len(device.spheres) = %d, want 1", len(device.spheres))
+	}
+	if len(device.quads) != 1 {
+		t.Errorf("len(device.quads) = %d, want 1", len(device.quads))
+	}
+
+	renderer.Release()
+	if !device.released {
+		t.Error("Release did not reach the underlying GLDevice")
+	}
+}
+
+func TestGLRendererRejectsUnsupportedShape(t *testing.T) {
+	device := &fakeGLDevice{}
+	renderer := NewGLRenderer(device)
+	scene := testGLScene()
+	scene.Node = append(scene.Node, Node{Name: "Tri1", Shape: TriangleUV{}, Material: Lambertian{Texture: TextureUniform{}}})
+
+	if _, err := renderer.Render(context.Background(), scene); err == nil {
+		t.Error("Render with an unsupported shape returned nil error, want an error")
+	}
+}
+
+func TestNewRendererSelectsBackend(t *testing.T) {
+	if r, err := NewRenderer(RenderOptions{Backend: "cpu"}, nil); err != nil || r == nil {
+		t.Errorf("NewRenderer(cpu) = %v, %v, want a non-nil Renderer and nil error", r, err)
+	}
+	if r, err := NewRenderer(RenderOptions{Backend: ""}, nil); err != nil || r == nil {
+		t.Errorf("NewRenderer(\"\") = %v, %v, want a non-nil Renderer and nil error", r, err)
+	}
+	if _, err := NewRenderer(RenderOptions{Backend: "gl"}, nil); err == nil {
+		t.Error("NewRenderer(gl) with a nil device returned nil error, want an error")
+	}
+	if r, err := NewRenderer(RenderOptions{Backend: "gl"}, &fakeGLDevice{}); err != nil || r == nil {
+		t.Errorf("NewRenderer(gl) with a device = %v, %v, want a non-nil Renderer and nil error", r, err)
+	}
+	if _, err := NewRenderer(RenderOptions{Backend: "cuda"}, nil); err == nil {
+		t.Error("NewRenderer(cuda) returned nil error, want an error for an unknown backend")
+	}
+}
@@ -4,6 +4,8 @@ package phys
 import (
 	"encoding/json"
 	"fmt"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
 )
 
 type Scene struct {
@@ -11,6 +13,120 @@ type Scene struct {
 	Node          []Node
 	Light         []Light
 	RenderOptions RenderOptions
+
+	// Shutter is the exposure window renderPixel and renderTilePass
+	// stratify each primary ray's ray.time across, in turn read by
+	// AnimatedInstance.Collide to interpolate its two Transforms. The
+	// zero value samples every ray at time 0, so a scene with no moving
+	// AnimatedInstance (or that doesn't set this) renders exactly as it
+	// did before motion blur existed.
+	Shutter Shutter
+
+	// Accel is an optional top-level acceleration structure over Node,
+	// built by BuildAccel. When non-nil, tracePath descends it instead of
+	// linearly scanning Node, which matters once a scene holds thousands
+	// of shapes (e.g. the commented axis-marker example). It is a derived
+	// cache, not scene definition data, so it is never marshaled: callers
+	// that unmarshal a Scene and want acceleration must call BuildAccel
+	// themselves, the same way Mesh's embedded BVH is rebuilt by NewMesh
+	// rather than trusted from JSON.
+	Accel *BVH
+
+	// VoxelGrid is an optional mipmapped voxelization of Node's emissive
+	// and diffuse geometry, built by BuildVoxelGrid for IntegratorVoxelGI's
+	// cone tracing. It is a derived cache, not scene definition data, in
+	// the same style as Accel: never marshaled, and must be rebuilt after
+	// any change to Node.
+	VoxelGrid *VoxelGrid
+
+	// emissiveNodeIndices caches the result of CollectEmissiveNodes: the
+	// indices into Node of every samplable Emitter node, in the same
+	// derived-cache style as Accel. sampleEmitterNode consults it once
+	// set, rather than rescanning Node on every diffuse hit;
+	// emissiveNodesCollected distinguishes "collected, no lights found"
+	// from "never collected", since a nil slice alone can't.
+	emissiveNodeIndices    []int
+	emissiveNodesCollected bool
+}
+
+// CollectEmissiveNodes scans s.Node for every node whose Material is an
+// Emitter with non-zero radiance (Emitter.radiantPower) and whose Shape
+// can be sampled as a light (implements AreaSampler -- Sphere and Mesh,
+// including per-triangle area-weighted sampling for an emissive Mesh),
+// caching the result so sampleEmitterDirectLighting and IntegratorBDPT's
+// light-vertex connection no longer rescan every Node on every diffuse
+// hit. Call it once after the scene's nodes are finalized (and again
+// after Node changes), the same way BuildAccel caches s.Accel.
+//
+// Calling CollectEmissiveNodes is optional: sampleEmitterNode falls back
+// to scanning Node directly when it hasn't been called, so existing
+// scenes that never call it keep working unchanged.
+func (s *Scene) CollectEmissiveNodes() {
+	s.emissiveNodeIndices = scanEmissiveNodeIndices(s)
+	s.emissiveNodesCollected = true
+}
+
+// scanEmissiveNodeIndices is the actual Node scan CollectEmissiveNodes
+// caches and sampleEmitterNode falls back to when no cache exists.
+func scanEmissiveNodeIndices(s *Scene) []int {
+	var indices []int
+	for i, node := range s.Node {
+		emitter, isEmitter := node.Material.(Emitter)
+		if !isEmitter || !emitter.radiantPower() {
+			continue
+		}
+		if _, samplable := node.Shape.(AreaSampler); !samplable {
+			continue
+		}
+		indices = append(indices, i)
+	}
+	return indices
+}
+
+// BuildAccel constructs s.Accel from s.Node's current shapes, replacing
+// any previous value. Call it once after the scene's nodes are finalized
+// (and again after any node is added, removed, or moved) to switch
+// tracePath from a linear scan over Node to BVH traversal.
+func (s *Scene) BuildAccel() {
+	if len(s.Node) == 0 {
+		s.Accel = nil
+		return
+	}
+	shapes := make([]Shape, len(s.Node))
+	for i := range s.Node {
+		shapes[i] = accelNode{index: i, shape: s.Node[i].Shape}
+	}
+	s.Accel = NewBVH(shapes, 0)
+}
+
+// accelNode adapts a Scene.Node's Shape so it can live inside a BVH built
+// over the scene as a whole: it forwards every Shape method to the
+// wrapped shape, but Collide additionally stamps index onto the
+// collision it returns, letting tracePath recover which Node was hit
+// after a Scene.Accel descent.
+type accelNode struct {
+	index int
+	shape Shape
+}
+
+func (a accelNode) Collide(r ray, tmin, tmax Distance) (bool, collision) {
+	hit, c := a.shape.Collide(r, tmin, tmax)
+	if hit {
+		c.nodeIndex = a.index
+	}
+	return hit, c
+}
+
+func (a accelNode) Bounds() AABB {
+	return a.shape.Bounds()
+}
+
+func (a accelNode) Validate() error {
+	return a.shape.Validate()
+}
+
+func (a accelNode) SignedDistance(p r3.Point) Distance {
+	return a.shape.SignedDistance(p)
 }
 
 func (s *Scene) Add(e ...Node) {
@@ -32,6 +148,9 @@ func (s *Scene) Validate() error {
 	if err != nil {
 		return fmt.Errorf("bad RenderOptions=%v err=%v", s.RenderOptions, err)
 	}
+	if err := s.Shutter.Validate(); err != nil {
+		return fmt.Errorf("bad Shutter=%v err=%v", s.Shutter, err)
+	}
 	// Verify at least one camera.
 	if len(s.Camera) == 0 {
 		return fmt.Errorf("no cameras in the scene")
@@ -125,14 +244,10 @@ func (s *Scene) UnmarshalJSON(data []byte) error {
 		return err
 	}
 	for _, cRaw := range wrapper.Camera {
-		iface, err := unmarshalInterface(cRaw)
+		cam, err := unmarshalTyped(cRaw, cameraRegistry)
 		if err != nil {
 			return err
 		}
-		cam, ok := iface.(Camera)
-		if !ok {
-			return err
-		}
 		s.Camera = append(s.Camera, cam)
 	}
 	for _, eRaw := range wrapper.Node {
@@ -144,14 +259,10 @@ func (s *Scene) UnmarshalJSON(data []byte) error {
 		s.Node = append(s.Node, e)
 	}
 	for _, lRaw := range wrapper.Light {
-		iface, err := unmarshalInterface(lRaw)
+		light, err := unmarshalTyped(lRaw, lightRegistry)
 		if err != nil {
 			return err
 		}
-		light, ok := iface.(Light)
-		if !ok {
-			return err
-		}
 		s.Light = append(s.Light, light)
 	}
 	s.RenderOptions = wrapper.RenderOptions
@@ -0,0 +1,105 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
+)
+
+// manySphereScene returns a scene with n non-overlapping spheres spread
+// along the X axis, each with a distinct material so a hit can be
+// attributed to the right node.
+func manySphereScene(n int) *Scene {
+	scene := &Scene{
+		RenderOptions: RenderOptions{Seed: 1, RaysPerPixel: 1, MaxRayDepth: 2, Dx: 4, Dy: 4},
+		Camera:        []Camera{OrthographicCamera{FOVWidth: 1, FOVHeight: 1, LookFrom: r3.Point{Z: 10}, LookAt: r3.Point{}, VUp: r3.Vec{Y: 1}}},
+	}
+	for i := 0; i < n; i++ {
+		scene.Add(Node{
+			Name:     fmt.Sprintf("Sphere%d", i),
+			Shape:    Sphere{Center: r3.Point{X: float64(i) * 4}, Radius: 1},
+			Material: Lambertian{Texture: TextureUniform{Color: Spectrum{X: 1, Y: float64(i) / float64(n), Z: 0}}},
+		})
+	}
+	return scene
+}
+
+// TestSceneBuildAccelMatchesLinearScan verifies that tracing the same set
+// of rays against a scene with Accel built produces the same radiance as
+// tracing it with the default linear scan, so switching a scene over to
+// the accelerator never changes what's rendered, only how fast.
+func TestSceneBuildAccelMatchesLinearScan(t *testing.T) {
+	linear := manySphereScene(40)
+	accel := manySphereScene(40)
+	accel.BuildAccel()
+	if accel.Accel == nil {
+		t.Fatalf("BuildAccel() left Accel nil for a non-empty scene")
+	}
+
+	ctx := context.Background()
+	camera := linear.Camera[0]
+	for _, cx := range []int{0, 40, 80, 120, 160} {
+		rand := NewRand(int64(cx))
+		r := camera.Cast(float64(cx)/160, 0.5, rand)
+
+		var statsLinear, statsAccel RenderStats
+		gotLinear := tracePath(ctx, linear, r, &statsLinear, nil)
+		gotAccel := tracePath(ctx, accel, r, &statsAccel, nil)
+		if gotLinear != gotAccel {
+			t.Errorf("cx=%d: linear scan radiance = %v, Accel radiance = %v", cx, gotLinear, gotAccel)
+		}
+	}
+}
+
+// TestSceneBuildAccelEmptyScene verifies BuildAccel leaves Accel nil for a
+// scene with no nodes, rather than constructing a BVH over zero shapes.
+func TestSceneBuildAccelEmptyScene(t *testing.T) {
+	scene := &Scene{}
+	scene.BuildAccel()
+	if scene.Accel != nil {
+		t.Errorf("BuildAccel() on an empty scene set Accel = %v, want nil", scene.Accel)
+	}
+}
+
+// TestSceneOccludedMatchesLinearScanAndRespectsAccel verifies Occluded
+// gives the same answer with and without Accel built, for a ray that
+// passes through its own origin node (skipped) and is blocked by a
+// second node further along, so the Accel branch's narrow-and-retry loop
+// is exercised rather than only ever seeing an unskipped nearest hit.
+func TestSceneOccludedMatchesLinearScanAndRespectsAccel(t *testing.T) {
+	build := func() (*Scene, Shape, Shape) {
+		scene := &Scene{}
+		scene.Add(Node{Name: "Origin", Shape: Sphere{Center: r3.Point{X: -2}, Radius: 1}, Material: Lambertian{Texture: TextureUniform{}}})
+		scene.Add(Node{Name: "Blocker", Shape: Sphere{Center: r3.Point{X: 3}, Radius: 1}, Material: Lambertian{Texture: TextureUniform{}}})
+		return scene, scene.Node[0].Shape, scene.Node[1].Shape
+	}
+	shadowRay := ray{origin: r3.Point{X: -1}, direction: r3.Vec{X: 1}, rand: NewRand(1)}
+
+	linear, origin, _ := build()
+	accel, _, _ := build()
+	accel.BuildAccel()
+	if accel.Accel == nil {
+		t.Fatalf("BuildAccel() left Accel nil for a non-empty scene")
+	}
+
+	for _, tc := range []struct {
+		name string
+		tMax Distance
+		want bool
+	}{
+		{"short of Blocker", 2.5, false},
+		{"reaches Blocker", 10, true},
+	} {
+		gotLinear := linear.Occluded(shadowRay, tc.tMax, origin)
+		gotAccel := accel.Occluded(shadowRay, tc.tMax, origin)
+		if gotLinear != tc.want {
+			t.Errorf("%s: linear scan Occluded() = %v, want %v", tc.name, gotLinear, tc.want)
+		}
+		if gotAccel != tc.want {
+			t.Errorf("%s: Accel Occluded() = %v, want %v", tc.name, gotAccel, tc.want)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LoadScene reads a scene description from path and returns a fully
+// constructed, validated *Scene. The file's Camera/Node/Light entries
+// are dispatched through the same RegisterCamera/RegisterLight/
+// RegisterShape/RegisterMaterial (and legacy RegisterInterfaceType)
+// registries Scene.UnmarshalJSON already uses, so any material, shape,
+// camera, or light that has registered itself -- including a user's own
+// out-of-tree type -- can be named from a scene file without a
+// recompile, e.g. {"Type": "Lambertian", "Data": {"Texture": ...}}.
+//
+// LoadScene dispatches on path's extension: ".json" decodes the file
+// directly as JSON. A ".yaml"/".yml" or ".toml" extension is rejected
+// with a descriptive error rather than silently parsed, since this
+// module vendors no YAML or TOML library and encoding/json is the only
+// decoder built into the standard library; callers wanting those formats
+// today must convert the file to JSON first (e.g. with an external
+// yq/ghodss-yaml or tomlq step) and pass the JSON file to LoadScene.
+func LoadScene(path string) (*Scene, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("phys.LoadScene: %v", err)
+	}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		// Handled below.
+	case ".yaml", ".yml":
+		return nil, fmt.Errorf("phys.LoadScene: %s is a YAML scene file, but this module does not vendor a YAML decoder; convert it to JSON first", path)
+	case ".toml":
+		return nil, fmt.Errorf("phys.LoadScene: %s is a TOML scene file, but this module does not vendor a TOML decoder; convert it to JSON first", path)
+	default:
+		return nil, fmt.Errorf("phys.LoadScene: unrecognized scene file extension %q (want .json)", ext)
+	}
+	scene := &Scene{}
+	if err := json.Unmarshal(data, scene); err != nil {
+		return nil, fmt.Errorf("phys.LoadScene: %v", err)
+	}
+	if err := scene.Validate(); err != nil {
+		return nil, fmt.Errorf("phys.LoadScene: %v", err)
+	}
+	return scene, nil
+}
@@ -0,0 +1,51 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
+)
+
+func TestLoadSceneJSON(t *testing.T) {
+	scene := &Scene{
+		Camera: []Camera{OrthographicCamera{
+			LookFrom:  r3.Point{X: 0, Y: 0, Z: 1},
+			LookAt:    r3.Point{X: 0, Y: 0, Z: 0},
+			VUp:       r3.Vec{X: 0, Y: 1, Z: 0},
+			FOVHeight: 1,
+			FOVWidth:  1,
+		}},
+		Node: []Node{
+			{Name: "a", Shape: Sphere{Radius: 1.5}, Material: DebugNormal{}},
+		},
+		RenderOptions: RenderOptions{Seed: 1, RaysPerPixel: 4, MaxRayDepth: 8, Dx: 16, Dy: 16},
+	}
+	data, err := scene.MarshalJSON()
+	if err != nil {
+		t.Fatalf("MarshalJSON: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "scene.json")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	got, err := LoadScene(path)
+	if err != nil {
+		t.Fatalf("LoadScene: %v", err)
+	}
+	if len(got.Node) != 1 || got.Node[0].Name != "a" {
+		t.Fatalf("unexpected scene: %+v", got)
+	}
+}
+
+func TestLoadSceneRejectsYAML(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scene.yaml")
+	if err := os.WriteFile(path, []byte("{}"), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if _, err := LoadScene(path); err == nil {
+		t.Fatalf("LoadScene(%q): expected an error for a YAML file, got nil", path)
+	}
+}
@@ -0,0 +1,224 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// ScenePatchVersion identifies the wire format of ScenePatch. Bump it
+// whenever a field is added or removed so older patches can be rejected
+// instead of silently misapplied.
+const ScenePatchVersion = 1
+
+// ScenePatch describes a structural delta between two Scenes, keyed by
+// Node.Name. It is produced by Diff and consumed by Apply, and marshals
+// through the same polymorphic Camera/Light/Shape/Material dispatch as
+// Scene itself so it can travel over the wire to a persistent renderer
+// process that rebuilds only the affected BVH branches.
+type ScenePatch struct {
+	Version int
+
+	// NodeUpserted holds nodes that were added in next or whose Shape,
+	// Material, or Transform changed between prev and next, keyed by name.
+	NodeUpserted []Node
+
+	// NodeRemoved holds the names of nodes present in prev but absent
+	// from next.
+	NodeRemoved []string
+
+	// Camera is set (non-nil) when next's camera list differs from prev's;
+	// Apply replaces the destination scene's Camera slice wholesale, since
+	// cameras have no stable identity to diff by name.
+	Camera []Camera
+
+	// Light is set (non-nil) when next's light list differs from prev's,
+	// for the same reason as Camera.
+	Light []Light
+
+	// RenderOptions is set when next.RenderOptions differs from
+	// prev.RenderOptions (by reflect.DeepEqual, since OnPass is a func
+	// field).
+	RenderOptions *RenderOptions
+}
+
+// MarshalJSON implements the json.Marshaler interface for ScenePatch.
+func (p ScenePatch) MarshalJSON() ([]byte, error) {
+	// Delegate through a throwaway Scene so NodeUpserted/Camera/Light reuse
+	// the exact same polymorphic Shape/Material/Camera/Light dispatch that
+	// Scene.MarshalJSON uses, keeping the patch format stable alongside it.
+	nodes := Scene{Node: p.NodeUpserted, Camera: p.Camera, Light: p.Light}
+	nodesJSON, err := nodes.MarshalJSON()
+	if err != nil {
+		return nil, fmt.Errorf("phys.ScenePatch.MarshalJSON: %v", err)
+	}
+	wrapped := map[string]interface{}{
+		"Version":       p.Version,
+		"Payload":       json.RawMessage(nodesJSON),
+		"NodeRemoved":   p.NodeRemoved,
+		"RenderOptions": p.RenderOptions,
+	}
+	return json.Marshal(wrapped)
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface for ScenePatch.
+func (p *ScenePatch) UnmarshalJSON(data []byte) error {
+	var wrapper struct {
+		Version       int
+		Payload       json.RawMessage
+		NodeRemoved   []string
+		RenderOptions *RenderOptions
+	}
+	if err := json.Unmarshal(data, &wrapper); err != nil {
+		return fmt.Errorf("phys.ScenePatch.UnmarshalJSON: %v", err)
+	}
+	if wrapper.Version != ScenePatchVersion {
+		return fmt.Errorf("phys.ScenePatch.UnmarshalJSON: unsupported version %d (want %d)", wrapper.Version, ScenePatchVersion)
+	}
+	var payload Scene
+	if len(wrapper.Payload) > 0 {
+		if err := payload.UnmarshalJSON(wrapper.Payload); err != nil {
+			return fmt.Errorf("phys.ScenePatch.UnmarshalJSON: %v", err)
+		}
+	}
+	p.Version = wrapper.Version
+	p.NodeUpserted = payload.Node
+	p.Camera = payload.Camera
+	p.Light = payload.Light
+	p.NodeRemoved = wrapper.NodeRemoved
+	p.RenderOptions = wrapper.RenderOptions
+	return nil
+}
+
+// Diff computes the structural delta required to transform prev into next.
+// Nodes are matched by Node.Name: a node present in both with an identical
+// JSON encoding is considered unchanged and omitted from the patch; a node
+// whose encoding differs, or that only exists in next, is upserted; a node
+// that only exists in prev is recorded in NodeRemoved. Camera and Light are
+// replaced wholesale when they differ, since neither has a stable per-entry
+// identity to diff against.
+func Diff(prev, next *Scene) (ScenePatch, error) {
+	patch := ScenePatch{Version: ScenePatchVersion}
+
+	prevByName := make(map[string]Node, len(prev.Node))
+	for _, n := range prev.Node {
+		prevByName[n.Name] = n
+	}
+	nextNames := make(map[string]bool, len(next.Node))
+	for _, n := range next.Node {
+		nextNames[n.Name] = true
+		old, existed := prevByName[n.Name]
+		if !existed {
+			patch.NodeUpserted = append(patch.NodeUpserted, n)
+			continue
+		}
+		changed, err := nodesDiffer(old, n)
+		if err != nil {
+			return ScenePatch{}, fmt.Errorf("phys.Diff: comparing node %q: %v", n.Name, err)
+		}
+		if changed {
+			patch.NodeUpserted = append(patch.NodeUpserted, n)
+		}
+	}
+	for name := range prevByName {
+		if !nextNames[name] {
+			patch.NodeRemoved = append(patch.NodeRemoved, name)
+		}
+	}
+
+	if camerasDiffer(prev.Camera, next.Camera) {
+		patch.Camera = next.Camera
+	}
+	if lightsDiffer(prev.Light, next.Light) {
+		patch.Light = next.Light
+	}
+	// RenderOptions.OnPass is a func field, which is only comparable with
+	// itself via ==, not across distinct closures, so reflect.DeepEqual
+	// (rather than !=) is used here; it treats the two as equal only when
+	// both OnPass fields are nil, which is the common no-callback case.
+	if !reflect.DeepEqual(prev.RenderOptions, next.RenderOptions) {
+		ro := next.RenderOptions
+		patch.RenderOptions = &ro
+	}
+	return patch, nil
+}
+
+// Apply mutates s in place to reflect p, replacing or adding each node in
+// p.NodeUpserted, dropping each node named in p.NodeRemoved, and
+// overwriting Camera/Light/RenderOptions when present in p.
+func Apply(s *Scene, p ScenePatch) error {
+	if p.Version != ScenePatchVersion {
+		return fmt.Errorf("phys.Apply: unsupported ScenePatch version %d (want %d)", p.Version, ScenePatchVersion)
+	}
+	removed := make(map[string]bool, len(p.NodeRemoved))
+	for _, name := range p.NodeRemoved {
+		removed[name] = true
+	}
+	upserted := make(map[string]Node, len(p.NodeUpserted))
+	for _, n := range p.NodeUpserted {
+		upserted[n.Name] = n
+	}
+
+	var merged []Node
+	for _, n := range s.Node {
+		if removed[n.Name] {
+			continue
+		}
+		if updated, ok := upserted[n.Name]; ok {
+			merged = append(merged, updated)
+			delete(upserted, n.Name)
+			continue
+		}
+		merged = append(merged, n)
+	}
+	for _, n := range p.NodeUpserted {
+		if _, stillPending := upserted[n.Name]; stillPending {
+			merged = append(merged, n)
+		}
+	}
+	s.Node = merged
+
+	if p.Camera != nil {
+		s.Camera = p.Camera
+	}
+	if p.Light != nil {
+		s.Light = p.Light
+	}
+	if p.RenderOptions != nil {
+		s.RenderOptions = *p.RenderOptions
+	}
+	return nil
+}
+
+// nodesDiffer reports whether a and b would marshal to different JSON,
+// i.e. whether their Shape, Material, Transform, or Name differ.
+func nodesDiffer(a, b Node) (bool, error) {
+	aJSON, err := a.MarshalJSON()
+	if err != nil {
+		return false, err
+	}
+	bJSON, err := b.MarshalJSON()
+	if err != nil {
+		return false, err
+	}
+	return string(aJSON) != string(bJSON), nil
+}
+
+func camerasDiffer(a, b []Camera) bool {
+	aJSON, errA := (Scene{Camera: a}).MarshalJSON()
+	bJSON, errB := (Scene{Camera: b}).MarshalJSON()
+	if errA != nil || errB != nil {
+		return true
+	}
+	return string(aJSON) != string(bJSON)
+}
+
+func lightsDiffer(a, b []Light) bool {
+	aJSON, errA := (Scene{Light: a}).MarshalJSON()
+	bJSON, errB := (Scene{Light: b}).MarshalJSON()
+	if errA != nil || errB != nil {
+		return true
+	}
+	return string(aJSON) != string(bJSON)
+}
@@ -0,0 +1,65 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import "testing"
+
+func TestDiffApplyRoundTrip(t *testing.T) {
+	prev := &Scene{
+		Node: []Node{
+			{Name: "keep", Shape: Sphere{Radius: 1}, Material: Lambertian{Texture: TextureUniform{}}},
+			{Name: "drop", Shape: Sphere{Radius: 2}, Material: Lambertian{Texture: TextureUniform{}}},
+		},
+		RenderOptions: RenderOptions{Seed: 1, RaysPerPixel: 1, MaxRayDepth: 1, Dx: 4, Dy: 4},
+	}
+	next := &Scene{
+		Node: []Node{
+			{Name: "keep", Shape: Sphere{Radius: 1}, Material: Lambertian{Texture: TextureUniform{}}},
+			{Name: "added", Shape: Sphere{Radius: 3}, Material: Lambertian{Texture: TextureUniform{}}},
+		},
+		RenderOptions: RenderOptions{Seed: 1, RaysPerPixel: 8, MaxRayDepth: 1, Dx: 4, Dy: 4},
+	}
+
+	patch, err := Diff(prev, next)
+	if err != nil {
+		t.Fatalf("Diff: %v", err)
+	}
+	if len(patch.NodeUpserted) != 1 || patch.NodeUpserted[0].Name != "added" {
+		t.Fatalf("expected only 'added' upserted, got %+v", patch.NodeUpserted)
+	}
+	if len(patch.NodeRemoved) != 1 || patch.NodeRemoved[0] != "drop" {
+		t.Fatalf("expected 'drop' removed, got %v", patch.NodeRemoved)
+	}
+	if patch.RenderOptions == nil || patch.RenderOptions.RaysPerPixel != 8 {
+		t.Fatalf("expected RenderOptions override, got %+v", patch.RenderOptions)
+	}
+
+	if err := Apply(prev, patch); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	if len(prev.Node) != 2 {
+		t.Fatalf("expected 2 nodes after apply, got %d", len(prev.Node))
+	}
+	names := map[string]bool{}
+	for _, n := range prev.Node {
+		names[n.Name] = true
+	}
+	if !names["keep"] || !names["added"] || names["drop"] {
+		t.Fatalf("unexpected node set after apply: %v", names)
+	}
+	if prev.RenderOptions.RaysPerPixel != 8 {
+		t.Fatalf("expected RenderOptions applied, got %+v", prev.RenderOptions)
+	}
+
+	// Serialize and round-trip the patch itself.
+	data, err := patch.MarshalJSON()
+	if err != nil {
+		t.Fatalf("MarshalJSON: %v", err)
+	}
+	var decoded ScenePatch
+	if err := decoded.UnmarshalJSON(data); err != nil {
+		t.Fatalf("UnmarshalJSON: %v", err)
+	}
+	if len(decoded.NodeUpserted) != 1 || decoded.NodeUpserted[0].Name != "added" {
+		t.Fatalf("round-tripped patch mismatch: %+v", decoded)
+	}
+}
@@ -0,0 +1,45 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import "time"
+
+// SceneScriptState is the per-tick context a SceneScript receives, so it
+// does not need its own clock or frame counter to drive an orbit, sweep a
+// color, or otherwise animate a scene over time.
+type SceneScriptState struct {
+	Frame   int           // Ticks since the script was loaded (or reloaded), starting at 0.
+	Elapsed time.Duration // Wall-clock time since the script was loaded (or reloaded).
+	Paused  bool          // Whether the caller's playback is currently paused.
+}
+
+// SceneScript mutates scene once per tick: moving a Node's Shape, swapping
+// its Material, replacing a Camera's extrinsics, or appending/removing
+// Node entries outright. Callers invoke Tick once per frame, typically
+// right before deciding whether a frame can be served from cache.
+//
+// A SceneScript speaks native Go rather than an embedded language: this
+// module has no package manifest to vendor a scripting engine like
+// tengo or goja against, so SceneScript is a plain interface a caller
+// implements (or builds with SceneScriptFunc) instead of a sandboxed VM.
+// r3.Point, r3.Vec, Spectrum, and Distance are used directly, with no
+// binding layer required.
+//
+// Because a SceneScript can make a scene's contents a function of wall
+// time rather than frame index alone, a caller that caches rendered
+// frames by index (see example/pal257's frameCache) should not trust
+// that cache while a SceneScript is active, and must invalidate it
+// whenever the active SceneScript is loaded or replaced.
+type SceneScript interface {
+	Tick(scene *Scene, state SceneScriptState) error
+}
+
+// SceneScriptFunc adapts a plain function to a SceneScript, mirroring
+// http.HandlerFunc: most scripts are a single closure over whatever state
+// they need to animate (an orbit radius, a swept color, a node name to
+// move, ...) rather than a type worth naming.
+type SceneScriptFunc func(scene *Scene, state SceneScriptState) error
+
+// Tick calls f.
+func (f SceneScriptFunc) Tick(scene *Scene, state SceneScriptState) error {
+	return f(scene, state)
+}
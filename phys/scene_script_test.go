@@ -0,0 +1,36 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestSceneScriptFuncMutatesScene verifies that a SceneScriptFunc adapts to
+// the SceneScript interface and that its mutations to scene are visible to
+// the caller after Tick returns.
+func TestSceneScriptFuncMutatesScene(t *testing.T) {
+	scene := &Scene{Node: []Node{{Name: "Beam1"}}}
+
+	var script SceneScript = SceneScriptFunc(func(s *Scene, state SceneScriptState) error {
+		s.Node[0].Material = DebugAlbedo{}
+		return nil
+	})
+
+	if err := script.Tick(scene, SceneScriptState{Frame: 3}); err != nil {
+		t.Fatalf("Tick: %v", err)
+	}
+	if _, ok := scene.Node[0].Material.(DebugAlbedo); !ok {
+		t.Errorf("Node[0].Material = %T, want DebugAlbedo", scene.Node[0].Material)
+	}
+}
+
+// TestSceneScriptFuncPropagatesError verifies that an error returned from
+// the wrapped function is returned from Tick unchanged.
+func TestSceneScriptFuncPropagatesError(t *testing.T) {
+	wantErr := errors.New("boom")
+	script := SceneScriptFunc(func(s *Scene, state SceneScriptState) error { return wantErr })
+	if err := script.Tick(&Scene{}, SceneScriptState{}); err != wantErr {
+		t.Errorf("Tick() err = %v, want %v", err, wantErr)
+	}
+}
@@ -0,0 +1,136 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// DecodeSceneStream parses a Scene from r using the Token API of
+// encoding/json, invoking cb once for each Node as it is parsed. Unlike
+// Scene.UnmarshalJSON, it never holds the full Node array in memory at
+// once, so scenes with millions of nodes or gigabyte-sized JSON files can
+// be processed with bounded memory.
+//
+// cb is called in document order. If cb returns an error, decoding stops
+// immediately and that error is returned, wrapped with the offending node
+// index. Camera, Light, and RenderOptions fields are still buffered in
+// full and are available on the returned Scene once decoding completes.
+func DecodeSceneStream(r io.Reader, cb func(Node) error) (*Scene, error) {
+	dec := json.NewDecoder(r)
+
+	// Expect the opening '{' of the Scene object.
+	if err := expectDelim(dec, '{'); err != nil {
+		return nil, fmt.Errorf("phys.DecodeSceneStream: %v", err)
+	}
+
+	scene := &Scene{}
+	for dec.More() {
+		keyTok, err := dec.Token()
+		if err != nil {
+			return nil, fmt.Errorf("phys.DecodeSceneStream: reading field name: %v", err)
+		}
+		key, ok := keyTok.(string)
+		if !ok {
+			return nil, fmt.Errorf("phys.DecodeSceneStream: expected field name, got %v", keyTok)
+		}
+		switch key {
+		case "Node":
+			if err := streamNodes(dec, cb); err != nil {
+				return nil, err
+			}
+		case "Camera":
+			var raws []json.RawMessage
+			if err := dec.Decode(&raws); err != nil {
+				return nil, fmt.Errorf("phys.DecodeSceneStream: decoding Camera: %v", err)
+			}
+			for _, raw := range raws {
+				iface, err := unmarshalInterface(raw)
+				if err != nil {
+					return nil, fmt.Errorf("phys.DecodeSceneStream: decoding Camera: %v", err)
+				}
+				cam, ok := iface.(Camera)
+				if !ok {
+					return nil, fmt.Errorf("phys.DecodeSceneStream: expected Camera, got %T", iface)
+				}
+				scene.Camera = append(scene.Camera, cam)
+			}
+		case "Light":
+			var raws []json.RawMessage
+			if err := dec.Decode(&raws); err != nil {
+				return nil, fmt.Errorf("phys.DecodeSceneStream: decoding Light: %v", err)
+			}
+			for _, raw := range raws {
+				iface, err := unmarshalInterface(raw)
+				if err != nil {
+					return nil, fmt.Errorf("phys.DecodeSceneStream: decoding Light: %v", err)
+				}
+				light, ok := iface.(Light)
+				if !ok {
+					return nil, fmt.Errorf("phys.DecodeSceneStream: expected Light, got %T", iface)
+				}
+				scene.Light = append(scene.Light, light)
+			}
+		case "RenderOptions":
+			if err := dec.Decode(&scene.RenderOptions); err != nil {
+				return nil, fmt.Errorf("phys.DecodeSceneStream: decoding RenderOptions: %v", err)
+			}
+		default:
+			// Skip unknown fields so forward-compatible scene files don't fail.
+			var discard json.RawMessage
+			if err := dec.Decode(&discard); err != nil {
+				return nil, fmt.Errorf("phys.DecodeSceneStream: skipping field %q: %v", key, err)
+			}
+		}
+	}
+	if err := expectDelim(dec, '}'); err != nil {
+		return nil, fmt.Errorf("phys.DecodeSceneStream: %v", err)
+	}
+	return scene, nil
+}
+
+// streamNodes parses the Node array one element at a time, calling cb for
+// each parsed Node and appending it to the scene reachable via cb's side
+// effects are the caller's responsibility; streamNodes itself does not
+// retain the decoded nodes.
+func streamNodes(dec *json.Decoder, cb func(Node) error) error {
+	if err := expectDelim(dec, '['); err != nil {
+		return fmt.Errorf("phys.DecodeSceneStream: Node array: %v", err)
+	}
+	index := 0
+	for dec.More() {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return fmt.Errorf("phys.DecodeSceneStream: Node[%d]: %v", index, err)
+		}
+		var node Node
+		if err := node.UnmarshalJSON(raw); err != nil {
+			return fmt.Errorf("phys.DecodeSceneStream: Node[%d]: %v", index, err)
+		}
+		if cb != nil {
+			if err := cb(node); err != nil {
+				return fmt.Errorf("phys.DecodeSceneStream: Node[%d] callback: %v", index, err)
+			}
+		}
+		index++
+	}
+	if err := expectDelim(dec, ']'); err != nil {
+		return fmt.Errorf("phys.DecodeSceneStream: Node array: %v", err)
+	}
+	return nil
+}
+
+// expectDelim reads the next token from dec and verifies it is the given
+// JSON delimiter ('{', '}', '[', or ']').
+func expectDelim(dec *json.Decoder, want json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	got, ok := tok.(json.Delim)
+	if !ok || got != want {
+		return fmt.Errorf("expected delimiter %q, got %v", want, tok)
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
+)
+
+func TestDecodeSceneStream(t *testing.T) {
+	scene := &Scene{
+		Camera: []Camera{PinholeCamera{Horizontal: r3.Vec{X: 1}, Vertical: r3.Vec{Y: 1}}},
+		Node: []Node{
+			{Name: "a", Shape: Sphere{Radius: 1}, Material: Lambertian{Texture: TextureUniform{}}},
+			{Name: "b", Shape: Sphere{Radius: 2}, Material: Lambertian{Texture: TextureUniform{}}},
+		},
+		RenderOptions: RenderOptions{Seed: 1, RaysPerPixel: 1, MaxRayDepth: 1, Dx: 1, Dy: 1},
+	}
+	data, err := scene.MarshalJSON()
+	if err != nil {
+		t.Fatalf("MarshalJSON: %v", err)
+	}
+
+	var names []string
+	got, err := DecodeSceneStream(strings.NewReader(string(data)), func(n Node) error {
+		names = append(names, n.Name)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("DecodeSceneStream: %v", err)
+	}
+	if len(names) != 2 || names[0] != "a" || names[1] != "b" {
+		t.Fatalf("unexpected callback order: %v", names)
+	}
+	if len(got.Camera) != 1 {
+		t.Fatalf("expected 1 camera, got %d", len(got.Camera))
+	}
+	if got.RenderOptions.Dx != 1 {
+		t.Fatalf("expected RenderOptions to be decoded, got %+v", got.RenderOptions)
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+)
+
+// WatchScene polls the scene file at path for mtime changes and returns a
+// channel that receives a freshly LoadScene'd *Scene every time the file
+// changes on disk, plus a sibling channel carrying any parse/validate
+// error encountered along the way. This module has no dependency
+// manifest to pull in a filesystem-event library (e.g. fsnotify), so
+// WatchScene polls instead -- fine for a hand-edited scene file on a
+// human's edit-save cycle, not meant for high-frequency or large-fanout
+// watching.
+//
+// The first successfully loaded scene is always sent, immediately. After
+// that, WatchScene re-reads path every pollInterval; if the file's mtime
+// is unchanged since the last read, nothing is sent. If it changed but
+// the new contents fail to parse or validate, the error is sent on the
+// error channel and the previously loaded *Scene is retained -- WatchScene
+// never pushes a broken scene, so a caller mid-edit of scene.json doesn't
+// see a half-written file blow up its render loop.
+//
+// Both channels are closed, and the background goroutine exits, when ctx
+// is canceled.
+func WatchScene(ctx context.Context, path string) (<-chan *Scene, <-chan error) {
+	return watchScene(ctx, path, time.Second)
+}
+
+// watchScene is WatchScene with an explicit poll interval, split out so
+// tests can run the poll loop fast instead of waiting on the real
+// WatchScene's one-second cadence.
+func watchScene(ctx context.Context, path string, pollInterval time.Duration) (<-chan *Scene, <-chan error) {
+	sceneCh := make(chan *Scene, 1)
+	errCh := make(chan error, 1)
+
+	go func() {
+		defer close(sceneCh)
+		defer close(errCh)
+
+		var lastModTime time.Time
+		send := func() {
+			info, err := os.Stat(path)
+			if err != nil {
+				select {
+				case errCh <- fmt.Errorf("phys.WatchScene: %v", err):
+				case <-ctx.Done():
+				}
+				return
+			}
+			if !info.ModTime().After(lastModTime) {
+				return
+			}
+			scene, err := LoadScene(path)
+			if err != nil {
+				select {
+				case errCh <- err:
+				case <-ctx.Done():
+				}
+				return
+			}
+			lastModTime = info.ModTime()
+			select {
+			case sceneCh <- scene:
+			case <-ctx.Done():
+			}
+		}
+
+		send() // Load the initial scene immediately, without waiting a full tick.
+
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				send()
+			}
+		}
+	}()
+
+	return sceneCh, errCh
+}
@@ -0,0 +1,138 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
+)
+
+func writeTestScene(t *testing.T, path string, dx int) {
+	t.Helper()
+	scene := &Scene{
+		Camera: []Camera{OrthographicCamera{
+			LookFrom:  r3.Point{X: 0, Y: 0, Z: 1},
+			LookAt:    r3.Point{X: 0, Y: 0, Z: 0},
+			VUp:       r3.Vec{X: 0, Y: 1, Z: 0},
+			FOVHeight: 1,
+			FOVWidth:  1,
+		}},
+		Node:          []Node{{Name: "a", Shape: Sphere{Radius: 1.5}, Material: DebugNormal{}}},
+		RenderOptions: RenderOptions{Seed: 1, RaysPerPixel: 4, MaxRayDepth: 8, Dx: dx, Dy: 16},
+	}
+	data, err := scene.MarshalJSON()
+	if err != nil {
+		t.Fatalf("MarshalJSON: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+}
+
+func TestWatchSceneReloadsOnChange(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scene.json")
+	writeTestScene(t, path, 16)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sceneCh, errCh := watchScene(ctx, path, 10*time.Millisecond)
+
+	select {
+	case scene := <-sceneCh:
+		if scene.RenderOptions.Dx != 16 {
+			t.Fatalf("initial scene: Dx = %d, want 16", scene.RenderOptions.Dx)
+		}
+	case err := <-errCh:
+		t.Fatalf("initial load: %v", err)
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for initial scene")
+	}
+
+	// mtime resolution on some filesystems is coarse; make sure the
+	// rewrite lands on a later mtime than the initial write.
+	time.Sleep(20 * time.Millisecond)
+	writeTestScene(t, path, 32)
+
+	select {
+	case scene := <-sceneCh:
+		if scene.RenderOptions.Dx != 32 {
+			t.Fatalf("reloaded scene: Dx = %d, want 32", scene.RenderOptions.Dx)
+		}
+	case err := <-errCh:
+		t.Fatalf("reload: %v", err)
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for reloaded scene")
+	}
+}
+
+func TestWatchSceneRetainsLastValidOnParseError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scene.json")
+	writeTestScene(t, path, 16)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sceneCh, errCh := watchScene(ctx, path, 10*time.Millisecond)
+
+	select {
+	case <-sceneCh:
+	case err := <-errCh:
+		t.Fatalf("initial load: %v", err)
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for initial scene")
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	if err := os.WriteFile(path, []byte("not json"), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	select {
+	case err := <-errCh:
+		if err == nil {
+			t.Fatal("expected a parse error, got nil")
+		}
+	case <-sceneCh:
+		t.Fatal("expected an error, got a new scene")
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for parse error")
+	}
+}
+
+func TestWatchSceneClosesChannelsOnCancel(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scene.json")
+	writeTestScene(t, path, 16)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	sceneCh, errCh := watchScene(ctx, path, 10*time.Millisecond)
+
+	select {
+	case <-sceneCh:
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for initial scene")
+	}
+
+	cancel()
+
+	for _, ch := range []<-chan error{errCh} {
+		select {
+		case _, ok := <-ch:
+			if ok {
+				t.Fatal("expected errCh to drain then close")
+			}
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for errCh to close")
+		}
+	}
+	select {
+	case _, ok := <-sceneCh:
+		if ok {
+			t.Fatal("expected sceneCh to drain then close")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for sceneCh to close")
+	}
+}
@@ -0,0 +1,60 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+
+package phys
+
+import (
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
+)
+
+// ShadingHints is a cheap, approximate summary of what a material looks
+// like at a surfaceInteraction without performing the full Resolve
+// computation (sampling a scattered direction, evaluating the BSDF,
+// tracing a recursive ray). DebugAlbedo and the adaptive sampler
+// (renderTileAdaptive) both only need this same rough answer, so it is
+// computed once here instead of twice.
+type ShadingHints struct {
+	Normal r3.Vec   // Shading normal at the hit point.
+	Albedo Spectrum // Approximate base color at the hit point.
+}
+
+// ShadingHintsMaterial is implemented by materials that can report their
+// own ShadingHints more accurately than materialAlbedo's type switch,
+// e.g. a material whose albedo depends on something materialAlbedo can't
+// see. Like SpecularMaterial, this is an optional interface: a material
+// that doesn't implement it still gets a usable answer from
+// shadingHints's fallback.
+type ShadingHintsMaterial interface {
+	ShadingHints(si surfaceInteraction) ShadingHints
+}
+
+// shadingHints returns si's shading hints, preferring si's Material's own
+// ShadingHintsMaterial implementation when present and otherwise falling
+// back to the collision's normal and materialAlbedo's guess.
+func shadingHints(si surfaceInteraction) ShadingHints {
+	if hm, ok := si.node.Material.(ShadingHintsMaterial); ok {
+		return hm.ShadingHints(si)
+	}
+	return ShadingHints{Normal: si.collision.normal, Albedo: materialAlbedo(si)}
+}
+
+// materialAlbedo approximates a material's base color without performing
+// a full Resolve. There is no shared accessor for "albedo" across
+// Material implementations, so this type-switches over the concrete
+// materials that have one; materials with no well-defined albedo (e.g.
+// Glass) report middle gray.
+func materialAlbedo(si surfaceInteraction) Spectrum {
+	switch mat := si.node.Material.(type) {
+	case Lambertian:
+		return textureAt(mat.Texture, si.collision.uv.X, si.collision.uv.Y, si.collision.uvFootprint)
+	case Diffuse:
+		return textureAt(mat.Texture, si.collision.uv.X, si.collision.uv.Y, si.collision.uvFootprint)
+	case RoughPlastic:
+		return textureAt(mat.Texture, si.collision.uv.X, si.collision.uv.Y, si.collision.uvFootprint)
+	case Metal:
+		return Spectrum(mat.Albedo)
+	case Mirror:
+		return Spectrum(mat.F0)
+	default:
+		return Spectrum(r3.Vec{X: 0.5, Y: 0.5, Z: 0.5})
+	}
+}
@@ -0,0 +1,56 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import (
+	"math"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
+)
+
+// EnsureValidReflection corrects a shading normal n so that the mirror
+// reflection of wo about n never points below the true geometric surface
+// ng. Bump and normal maps perturb n away from ng, and at grazing angles
+// the perturbed reflection direction can dip under the geometric surface;
+// tracing it anyway produces visible black terminator artifacts. This is
+// the algorithm Cycles uses to fix it ("ensure_valid_specular_reflection"):
+// if the unperturbed reflection is already above ng by a safety margin, n
+// is returned unchanged; otherwise n is rotated toward ng, within the
+// plane spanned by ng and n, by the minimum amount needed to bring the
+// reflection back above the margin.
+//
+// ng, wo, and n need not be unit length; the returned vector is unit length.
+func EnsureValidReflection(ng, wo, n r3.Vec) r3.Vec {
+	ng = ng.Unit()
+	wo = wo.Unit()
+	n = n.Unit()
+
+	R := n.Muls(2 * n.Dot(wo)).Sub(wo)
+	t := math.Min(0.9*ng.Dot(wo), 0.025)
+	if ng.Dot(R) >= t {
+		return n
+	}
+
+	// Coordinate system with ng as the Z axis and the component of n
+	// orthogonal to ng as the X axis, so the correction below stays
+	// within the (X, ng) plane that already contains both n and ng.
+	X := n.Sub(ng.Muls(n.Dot(ng))).Unit()
+	origNx := n.Dot(X)
+	origNz := n.Dot(ng)
+
+	Ix := wo.Dot(X)
+	Iz := wo.Dot(ng)
+
+	a := Ix*Ix + Iz*Iz
+	b := math.Sqrt(math.Max(0, Ix*Ix*(a-t*t)))
+	Nz := math.Sqrt(math.Max(0, 0.5*(Iz*t+a)/a))
+	Nx1 := (b + Ix*t) / (2 * a)
+	Nx2 := (b - Ix*t) / (2 * a)
+
+	// Of the two roots satisfying dot(ng, reflect(-wo, N')) = t, keep
+	// whichever stays closest to the original n.
+	Nx := Nx1
+	if Nx2*origNx+Nz*origNz > Nx1*origNx+Nz*origNz {
+		Nx = Nx2
+	}
+	return X.Muls(Nx).Add(ng.Muls(Nz)).Unit()
+}
@@ -0,0 +1,92 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import (
+	"math"
+	"testing"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
+)
+
+// TestEnsureValidReflectionUnchangedWhenValid verifies that when the
+// reflection about n already lies safely above ng, n is returned
+// unmodified.
+func TestEnsureValidReflectionUnchangedWhenValid(t *testing.T) {
+	ng := r3.Vec{X: 0, Y: 0, Z: 1}
+	n := ng
+	wo := r3.Vec{X: 0, Y: 0, Z: 1}
+
+	got := EnsureValidReflection(ng, wo, n)
+	if !got.IsClose(ng, eps) {
+		t.Errorf("EnsureValidReflection with no shading-normal perturbation: got %v, want %v", got, ng)
+	}
+}
+
+// TestEnsureValidReflectionGrazingAngle verifies that a steeply bumped
+// shading normal at a grazing viewing angle is corrected so that its
+// reflection stays above the geometric surface, rather than dipping below
+// it and producing black terminator artifacts.
+func TestEnsureValidReflectionGrazingAngle(t *testing.T) {
+	ng := r3.Vec{X: 0, Y: 0, Z: 1}
+	// wo grazes the surface almost edge-on.
+	wo := r3.Vec{X: math.Sin(89 * math.Pi / 180), Y: 0, Z: math.Cos(89 * math.Pi / 180)}.Unit()
+	// n is tilted steeply toward wo by bump mapping, enough that the
+	// naive reflection about n would go below ng.
+	n := r3.Vec{X: math.Sin(60 * math.Pi / 180), Y: 0, Z: math.Cos(60 * math.Pi / 180)}.Unit()
+
+	corrected := EnsureValidReflection(ng, wo, n)
+
+	R := corrected.Muls(2 * corrected.Dot(wo)).Sub(wo)
+	t_ := math.Min(0.9*ng.Dot(wo), 0.025)
+	if ng.Dot(R) < t_-eps {
+		t.Errorf("EnsureValidReflection did not fix grazing reflection: dot(ng,R)=%v, want >= %v", ng.Dot(R), t_)
+	}
+	if math.Abs(corrected.Length()-1) > eps {
+		t.Errorf("EnsureValidReflection returned non-unit vector: %v, length %v", corrected, corrected.Length())
+	}
+}
+
+// TestEnsureValidReflectionSteepBump verifies correction still produces a
+// unit, valid normal for a very strong bump perturbation at a moderate
+// viewing angle.
+func TestEnsureValidReflectionSteepBump(t *testing.T) {
+	ng := r3.Vec{X: 0, Y: 0, Z: 1}
+	wo := r3.Vec{X: math.Sin(70 * math.Pi / 180), Y: 0, Z: math.Cos(70 * math.Pi / 180)}.Unit()
+	// n tilted almost parallel to the surface by an extreme bump strength.
+	n := r3.Vec{X: math.Sin(85 * math.Pi / 180), Y: 0, Z: math.Cos(85 * math.Pi / 180)}.Unit()
+
+	corrected := EnsureValidReflection(ng, wo, n)
+
+	if math.Abs(corrected.Length()-1) > eps {
+		t.Errorf("EnsureValidReflection returned non-unit vector: %v, length %v", corrected, corrected.Length())
+	}
+	R := corrected.Muls(2 * corrected.Dot(wo)).Sub(wo)
+	t_ := math.Min(0.9*ng.Dot(wo), 0.025)
+	if ng.Dot(R) < t_-eps {
+		t.Errorf("EnsureValidReflection did not fix steep bump reflection: dot(ng,R)=%v, want >= %v", ng.Dot(R), t_)
+	}
+}
+
+// TestMicrofacetBRDFSampleShaded verifies that SampleShaded only ever
+// returns directions above the geometric surface, even when the shading
+// normal is perturbed away from it.
+func TestMicrofacetBRDFSampleShaded(t *testing.T) {
+	brdf := MicrofacetBRDF{
+		Roughness: 0.3,
+		F0:        r3.Vec{X: 0.04, Y: 0.04, Z: 0.04},
+	}
+	ng := r3.Vec{X: 0, Y: 0, Z: 1}
+	ns := r3.Vec{X: math.Sin(60 * math.Pi / 180), Y: 0, Z: math.Cos(60 * math.Pi / 180)}.Unit()
+	wo := r3.Vec{X: math.Sin(85 * math.Pi / 180), Y: 0, Z: math.Cos(85 * math.Pi / 180)}.Unit()
+
+	rand := NewRand(1)
+	for i := 0; i < 100; i++ {
+		wi, pdf := brdf.SampleShaded(wo, ns, ng, rand)
+		if pdf <= 0 {
+			continue
+		}
+		if ng.Dot(wi) <= 0 {
+			t.Errorf("SampleShaded produced wi below the geometric surface: wi=%v, dot(ng,wi)=%v", wi, ng.Dot(wi))
+		}
+	}
+}
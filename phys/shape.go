@@ -13,6 +13,99 @@ type collision struct {
 	at     r3.Point // Collision point on the shape.
 	uv     r2.Point // Texture coordinates at the collision point.
 	normal r3.Vec   // Normal vector of the surface at the collision point.
+
+	// tangent and bitangent span the surface's local tangent plane,
+	// orthogonal to normal. Shapes with a UV parameterization derive
+	// tangent from the UV gradient, as NewTangentFrameFromFace does;
+	// others fall back to an arbitrary orthonormal basis, as
+	// NewTangentFrame does. Zero value on shapes that don't set it.
+	tangent   r3.Vec
+	bitangent r3.Vec
+
+	// barycentric holds the barycentric weights (w, u, v) of the
+	// collision point with respect to the hit triangle, where
+	// w+u+v == 1. Zero value on shapes without a triangular
+	// parameterization (e.g. Sphere, Cylinder).
+	barycentric r3.Vec
+
+	// primitiveID identifies which sub-primitive of the shape was hit,
+	// e.g. the triangle index within a Mesh. Shapes that are themselves
+	// a single primitive (Triangle, Quad, Sphere, Cylinder) leave it 0.
+	primitiveID int
+
+	// leaf is the BVHLeaf that produced this collision, set by
+	// BVHLeaf.Collide and left nil otherwise. It's a warm-start hint for
+	// CollisionCache: a ray that misses the cache's exact bucket can
+	// still retest directly against the previous hit's leaf before
+	// falling back to a full BVH descent.
+	leaf *BVHLeaf
+
+	// nodeIndex identifies which Scene.Node produced this collision,
+	// set by accelNode.Collide when the collision came from a
+	// Scene.Accel descent. Meaningless (zero value) otherwise; only
+	// tracePath's Scene.Accel branch reads it, and only after a hit.
+	nodeIndex int
+
+	// uvFootprint is an estimate of how much uv changes across one pixel
+	// at this collision point, copied from the incoming ray's own
+	// uvFootprint field by tracePath. It's the signal TextureImage's
+	// "trilinear"/"anisotropic" Interp modes use to pick a mip level via
+	// textureAt. Zero (the default) means no estimate is available and
+	// callers should behave like a plain point sample; see
+	// RenderOptions.TextureFootprint.
+	uvFootprint r2.Point
+
+	// frontFace reports whether the incoming ray hit the shape from
+	// outside, for shapes that flip normal to always point against the
+	// incoming ray (e.g. Cylinder, so a ray cast from inside the
+	// cylinder toward its wall still gets a normal useful for
+	// refraction/CSG). Shapes that leave normal as the raw outward
+	// geometric normal (Sphere, Triangle, Face, ...) don't set this, so
+	// its zero value false carries no meaning there.
+	frontFace bool
+
+	// feature classifies where on the triangle the collision landed
+	// (Face, Edge, or Vertex), set by Triangle.Collide via
+	// classifyBarycentricFeature. Shapes that don't set it (including
+	// Face and TriangleUV, which have the same triangular
+	// parameterization but don't yet classify it) leave it at its zero
+	// value, FeatureFace.
+	feature Feature
+}
+
+// Feature classifies where a Triangle collision landed, based on how
+// close its barycentric coordinates are to the triangle's edges and
+// corners. Grazing hits near an edge or vertex are numerically ambiguous
+// between adjacent primitives, so shading code and BVH refit logic can
+// use Feature to handle them deterministically instead of depending on
+// which primitive happened to win the race.
+type Feature int
+
+const (
+	FeatureFace   Feature = iota // Interior of the triangle, away from every edge.
+	FeatureEdge                  // Within featureEps of one edge, away from its endpoints.
+	FeatureVertex                // Within featureEps of a vertex.
+)
+
+// featureEps is the barycentric-coordinate tolerance classifyBarycentricFeature
+// uses to call a collision "on" an edge or vertex rather than the face interior.
+const featureEps = 1e-4
+
+// classifyBarycentricFeature classifies a collision's barycentric
+// coordinates (w, u, v), where w+u+v == 1, as landing on the triangle's
+// face interior, one of its edges, or one of its vertices.
+func classifyBarycentricFeature(w, u, v float64) Feature {
+	nearW := math.Abs(w) < featureEps
+	nearU := math.Abs(u) < featureEps
+	nearV := math.Abs(v) < featureEps
+	switch {
+	case (nearW && nearU) || (nearU && nearV) || (nearV && nearW):
+		return FeatureVertex
+	case nearW || nearU || nearV:
+		return FeatureEdge
+	default:
+		return FeatureFace
+	}
 }
 
 // Shape represents an geometric object that can collide with rays.
@@ -20,6 +113,13 @@ type Shape interface {
 	Collide(r ray, tmin Distance, tmax Distance) (bool, collision)
 	Bounds() AABB
 	Validate() error // Validate checks if the shape is valid.
+
+	// SignedDistance returns the distance from p to the closest point on
+	// the shape's surface, negative when p is inside the shape. Shapes
+	// with no well-defined interior (open surfaces like Triangle, Face,
+	// and Quad) always return a non-negative distance; see each type's
+	// doc comment for its convention.
+	SignedDistance(p r3.Point) Distance
 }
 
 // AABB represents an axis-aligned bounding box.
@@ -78,6 +178,19 @@ func (b AABB) intersects(other AABB) bool {
 		b.Min.Z <= other.Max.Z && b.Max.Z >= other.Min.Z
 }
 
+// distanceLowerBound returns a lower bound on the distance from p to any
+// point on or inside b: the Euclidean distance to the nearest point of b,
+// clamping each axis independently so it is 0 when p is within b's extent
+// on that axis. Used to order a best-first SignedDistance search over a
+// BVH: a node can be pruned once this bound exceeds the closest surface
+// distance found so far.
+func (b AABB) distanceLowerBound(p r3.Point) Distance {
+	dx := math.Max(math.Max(b.Min.X-p.X, 0), p.X-b.Max.X)
+	dy := math.Max(math.Max(b.Min.Y-p.Y, 0), p.Y-b.Max.Y)
+	dz := math.Max(math.Max(b.Min.Z-p.Z, 0), p.Z-b.Max.Z)
+	return Distance(math.Sqrt(dx*dx + dy*dy + dz*dz))
+}
+
 func (b AABB) hit(r ray, tmin, tmax Distance) bool {
 	for axis := 0; axis < 3; axis++ {
 		invD := 1.0 / r.direction.Get(axis)
@@ -0,0 +1,130 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
+)
+
+// AnimatedInstance wraps a Shape with two Transforms, T0 at the shutter's
+// open instant and T1 at its close, and interpolates between them at
+// whatever ray.time Scene.Shutter stratified this ray's exposure sample
+// to. Where TransformedShape and Instancer place a Shape at one fixed
+// pose, AnimatedInstance places it along a straight-line motion between
+// two poses, giving moving geometry the blurred streak a finite camera
+// shutter actually records instead of the sharp, frozen-instant silhouette
+// a per-frame-only animation loop produces.
+type AnimatedInstance struct {
+	Shape  Shape
+	T0, T1 Transform
+}
+
+func (ai AnimatedInstance) Validate() error {
+	if ai.Shape == nil {
+		return fmt.Errorf("AnimatedInstance: Shape is nil")
+	}
+	return ai.Shape.Validate()
+}
+
+// Collide interpolates T0 and T1's already-cached inverses at r.time
+// (rather than inverting their interpolation, which would need a fresh
+// matrix inversion per ray) to transform r into the shape's local space,
+// and interpolates T0 and T1's forward matrices the same way to transform
+// the collision back to world space. Mirrors TransformedShape.Collide
+// otherwise, substituting the interpolated matrices for a single
+// Transform.
+func (ai AnimatedInstance) Collide(r ray, tmin, tmax Distance) (bool, collision) {
+	invLocal := ai.T0.Inverse().Matrix().Lerp(ai.T1.Inverse().Matrix(), r.time)
+	fwdLocal := ai.T0.Matrix().Lerp(ai.T1.Matrix(), r.time)
+
+	localRay := ray{
+		origin:    invLocal.TransformPoint(r.origin),
+		direction: invLocal.TransformVec(r.direction),
+		depth:     r.depth,
+		radiance:  r.radiance,
+		rand:      r.rand,
+		pixelX:    r.pixelX,
+		pixelY:    r.pixelY,
+		rayType:   r.rayType,
+		time:      r.time,
+	}
+
+	hit, col := ai.Shape.Collide(localRay, tmin, tmax)
+	if !hit {
+		return false, collision{}
+	}
+
+	// The normal goes through the interpolated inverse-transpose (the same
+	// reasoning as Transform.ApplyToNormal) rather than the forward
+	// matrix, so it stays perpendicular to the surface under a
+	// non-uniform scale or shear; tangent and bitangent lie in the surface
+	// itself, so the ordinary linear map is correct for them.
+	return true, collision{
+		t:           col.t,
+		at:          fwdLocal.TransformPoint(col.at),
+		normal:      invLocal.Transpose().TransformVec(col.normal).Unit(),
+		uv:          col.uv,
+		tangent:     fwdLocal.TransformVec(col.tangent).Unit(),
+		bitangent:   fwdLocal.TransformVec(col.bitangent).Unit(),
+		barycentric: col.barycentric,
+		primitiveID: col.primitiveID,
+		feature:     col.feature,
+	}
+}
+
+// Bounds returns the union of ai.Shape's bounds transformed by T0 and by
+// T1, conservative over every pose the shape passes through between them
+// since each transformed AABB is itself conservative and the path between
+// two affine poses is a straight line -- so the BVH leaf wrapping an
+// AnimatedInstance never needs rebuilding mid-exposure the way a true
+// per-sample bounds would.
+func (ai AnimatedInstance) Bounds() AABB {
+	bounds := ai.Shape.Bounds()
+	return animatedInstanceTransformBounds(bounds, ai.T0).Union(animatedInstanceTransformBounds(bounds, ai.T1))
+}
+
+// animatedInstanceTransformBounds transforms all 8 corners of bounds by t
+// and returns their enclosing AABB, the same corner-transform approach
+// TransformedShape.Bounds and instanceProxy.Bounds use.
+func animatedInstanceTransformBounds(bounds AABB, t Transform) AABB {
+	min := bounds.Min
+	max := bounds.Max
+	corners := []r3.Point{
+		{X: min.X, Y: min.Y, Z: min.Z},
+		{X: max.X, Y: min.Y, Z: min.Z},
+		{X: min.X, Y: max.Y, Z: min.Z},
+		{X: max.X, Y: max.Y, Z: min.Z},
+		{X: min.X, Y: min.Y, Z: max.Z},
+		{X: max.X, Y: min.Y, Z: max.Z},
+		{X: min.X, Y: max.Y, Z: max.Z},
+		{X: max.X, Y: max.Y, Z: max.Z},
+	}
+
+	newMin := t.ApplyToPoint(corners[0])
+	newMax := newMin
+	for _, corner := range corners[1:] {
+		p := t.ApplyToPoint(corner)
+		newMin.X = math.Min(newMin.X, p.X)
+		newMin.Y = math.Min(newMin.Y, p.Y)
+		newMin.Z = math.Min(newMin.Z, p.Z)
+		newMax.X = math.Max(newMax.X, p.X)
+		newMax.Y = math.Max(newMax.Y, p.Y)
+		newMax.Z = math.Max(newMax.Z, p.Z)
+	}
+
+	return AABB{Min: newMin, Max: newMax}
+}
+
+// SignedDistance evaluates ai.Shape.SignedDistance at T0's pose: a single
+// shutter-open-only estimate, since a time-indexed ray isn't available at
+// a signed-distance query site (SignedDistance callers have no ray.time to
+// interpolate by) the way it is at Collide.
+func (ai AnimatedInstance) SignedDistance(p r3.Point) Distance {
+	return ai.Shape.SignedDistance(ai.T0.Inverse().ApplyToPoint(p))
+}
+
+func init() {
+	RegisterInterfaceType(AnimatedInstance{})
+}
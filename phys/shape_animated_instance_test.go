@@ -0,0 +1,61 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import (
+	"math"
+	"testing"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
+)
+
+// movingSphere returns an AnimatedInstance that slides a unit sphere from
+// the origin (T0) to (10, 0, 0) (T1), used by the tests below.
+func movingSphere() AnimatedInstance {
+	return AnimatedInstance{
+		Shape: unitSphere(),
+		T0:    NewTransform(),
+		T1:    NewTranslation(r3.Vec{X: 10, Y: 0, Z: 0}),
+	}
+}
+
+// TestAnimatedInstanceCollideInterpolatesPoseByTime verifies that Collide
+// places the wrapped Shape at T0 when r.time is 0, at T1 when r.time is 1,
+// and at the halfway pose when r.time is 0.5.
+func TestAnimatedInstanceCollideInterpolatesPoseByTime(t *testing.T) {
+	ai := movingSphere()
+
+	cases := []struct {
+		time float64
+		want r3.Point
+	}{
+		{0, r3.Point{X: 0, Y: 0, Z: -1}},
+		{0.5, r3.Point{X: 5, Y: 0, Z: -1}},
+		{1, r3.Point{X: 10, Y: 0, Z: -1}},
+	}
+	for _, c := range cases {
+		r := ray{
+			origin:    r3.Point{X: c.want.X, Y: 0, Z: -5},
+			direction: r3.Vec{X: 0, Y: 0, Z: 1},
+			time:      c.time,
+		}
+		hit, coll := ai.Collide(r, 0, Distance(math.MaxFloat64))
+		if !hit {
+			t.Fatalf("time=%v: expected hit", c.time)
+		}
+		if !coll.at.IsClose(c.want, eps) {
+			t.Errorf("time=%v: collision point = %v, want %v", c.time, coll.at, c.want)
+		}
+	}
+}
+
+// TestAnimatedInstanceBoundsUnionsBothPoses verifies Bounds covers the
+// shape's footprint at both T0 and T1, not just one endpoint.
+func TestAnimatedInstanceBoundsUnionsBothPoses(t *testing.T) {
+	bounds := movingSphere().Bounds()
+	if bounds.Min.X > -1+eps {
+		t.Errorf("Bounds().Min.X = %v, want <= -1", bounds.Min.X)
+	}
+	if bounds.Max.X < 11-eps {
+		t.Errorf("Bounds().Max.X = %v, want >= 11", bounds.Max.X)
+	}
+}
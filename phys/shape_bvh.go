@@ -16,19 +16,51 @@ type BVH struct {
 	Left   Shape
 	Right  Shape
 	bounds AABB
+
+	// generation counts how many times Refit has touched this node; a
+	// CollisionCache compares it against the value it last saw to notice
+	// that the tree moved and its cached results are stale.
+	generation uint64
+
+	// count memoizes the number of primitives under this node (summed
+	// across all descendant leaves), used by Update to find which leaf a
+	// global shape index falls into without re-walking the whole tree on
+	// every call. 0 means not yet computed; see primitiveCount. The
+	// tree's topology never changes after construction (Refit and Update
+	// only touch bounds and leaf contents), so this is safe to cache
+	// permanently once computed.
+	count int
+}
+
+// primitiveCount returns the number of primitives under b, computing and
+// caching it on first use.
+func (b *BVH) primitiveCount() int {
+	if b.count != 0 {
+		return b.count
+	}
+	if b.Right == nil {
+		b.count = len(b.Left.(*BVHLeaf).Shapes)
+	} else {
+		b.count = b.Left.(*BVH).primitiveCount() + b.Right.(*BVH).primitiveCount()
+	}
+	return b.count
 }
 
 // Ensure BVH implements the Shape interface.
 var _ Shape = (*BVH)(nil)
 
-// Validate checks if the BVH is valid.
+// Validate checks if the BVH is valid. A leaf node (Right == nil, see
+// newBVHLeaf) only has a Left child to check.
 func (b *BVH) Validate() error {
-	if b.Left == nil || b.Right == nil {
+	if b.Left == nil {
 		return fmt.Errorf("BVH nodes must not be nil")
 	}
 	if err := b.Left.Validate(); err != nil {
 		return fmt.Errorf("BVH Left child is invalid: %v", err)
 	}
+	if b.Right == nil {
+		return nil
+	}
 	if err := b.Right.Validate(); err != nil {
 		return fmt.Errorf("BVH Right child is invalid: %v", err)
 	}
@@ -46,20 +78,16 @@ func (b *BVH) Collide(r ray, tmin, tmax Distance) (bool, collision) {
 		return false, collision{}
 	}
 
-	var hitLeft, hitRight bool
-	var collLeft, collRight collision
-
-	// Early termination and tmax update
-	if b.Left != nil {
-		hitLeft, collLeft = b.Left.Collide(r, tmin, tmax)
-		if hitLeft {
-			tmax = Distance(math.Min(float64(tmax), float64(collLeft.t)))
-		}
+	// A leaf node (see newBVHLeaf) has only a Left child.
+	if b.Right == nil {
+		return b.Left.Collide(r, tmin, tmax)
 	}
 
-	if b.Right != nil && b.Right != b.Left {
-		hitRight, collRight = b.Right.Collide(r, tmin, tmax)
+	hitLeft, collLeft := b.Left.Collide(r, tmin, tmax)
+	if hitLeft {
+		tmax = Distance(math.Min(float64(tmax), float64(collLeft.t)))
 	}
+	hitRight, collRight := b.Right.Collide(r, tmin, tmax)
 
 	if !hitLeft && !hitRight {
 		return false, collision{}
@@ -77,6 +105,60 @@ func (b *BVH) Collide(r ray, tmin, tmax Distance) (bool, collision) {
 	}
 }
 
+// CollideCounted behaves exactly like Collide, but also returns the number
+// of AABB tests performed along the way (every node descended into,
+// including this one, plus one for each non-*BVH leaf shape tested): the
+// traversal-cost metric tracePath accumulates into RenderStats.BVHNodeVisits
+// when FrameStats instrumentation is wanted. It's a separate method, rather
+// than Collide always counting, so the hot traversal path pays no
+// bookkeeping cost when nobody asks for it.
+func (b *BVH) CollideCounted(r ray, tmin, tmax Distance) (bool, collision, uint64) {
+	visits := uint64(1)
+	if !b.bounds.hit(r, tmin, tmax) {
+		return false, collision{}, visits
+	}
+
+	// A leaf node (see newBVHLeaf) has only a Left child.
+	if b.Right == nil {
+		hit, coll := b.Left.Collide(r, tmin, tmax)
+		return hit, coll, visits + 1
+	}
+
+	hitLeft, collLeft, leftVisits := collideCounted(b.Left, r, tmin, tmax)
+	visits += leftVisits
+	if hitLeft {
+		tmax = Distance(math.Min(float64(tmax), float64(collLeft.t)))
+	}
+	hitRight, collRight, rightVisits := collideCounted(b.Right, r, tmin, tmax)
+	visits += rightVisits
+
+	if !hitLeft && !hitRight {
+		return false, collision{}, visits
+	}
+	if hitLeft && hitRight {
+		if collLeft.t < collRight.t {
+			return true, collLeft, visits
+		}
+		return true, collRight, visits
+	} else if hitLeft {
+		return true, collLeft, visits
+	}
+	return true, collRight, visits
+}
+
+// collideCounted dispatches to child.(*BVH).CollideCounted when child is
+// itself an internal BVH node, or counts a single AABB/shape test when it's
+// a leaf Shape, so CollideCounted's node-visit count covers the whole tree
+// regardless of how far NewBVH's recursion nested before bottoming out at
+// newBVHLeaf.
+func collideCounted(child Shape, r ray, tmin, tmax Distance) (bool, collision, uint64) {
+	if bvh, ok := child.(*BVH); ok {
+		return bvh.CollideCounted(r, tmin, tmax)
+	}
+	hit, coll := child.Collide(r, tmin, tmax)
+	return hit, coll, 1
+}
+
 // NewBVH constructs a BVH from a list of shapes using the Binned Surface Area Heuristic.
 func NewBVH(shapes []Shape, depth int) *BVH {
 	const maxDepth = 32
@@ -89,22 +171,12 @@ func NewBVH(shapes []Shape, depth int) *BVH {
 
 	// If only one shape, create a leaf node.
 	if len(shapes) == 1 {
-		return &BVH{
-			Left:   shapes[0],
-			Right:  shapes[0],
-			bounds: shapes[0].Bounds(),
-		}
+		return newBVHLeaf(shapes)
 	}
 
 	// If maximum depth reached or few shapes, create a leaf node.
 	if depth >= maxDepth || len(shapes) <= minShapesPerLeaf {
-		// Group shapes into a leaf node.
-		group := &Group{Shapes: shapes}
-		return &BVH{
-			Left:   group,
-			Right:  group,
-			bounds: group.Bounds(),
-		}
+		return newBVHLeaf(shapes)
 	}
 
 	// Compute bounding box of all shapes.
@@ -245,9 +317,12 @@ func NewBVH(shapes []Shape, depth int) *BVH {
 		}
 	}
 
-	// Parallelize BVH construction if the number of shapes is large enough.
+	// Parallelize BVH construction if the number of shapes is large enough
+	// that two goroutines' worth of work outweighs the cost of spawning
+	// them; below this, build both halves on the calling goroutine.
+	const parallelThreshold = 1024
 	var left, right *BVH
-	if len(shapes) > 1 { // Adjust the threshold based on performance testing.
+	if len(shapes) > parallelThreshold {
 		var wg sync.WaitGroup
 		wg.Add(2)
 		go func() {
@@ -271,21 +346,25 @@ func NewBVH(shapes []Shape, depth int) *BVH {
 	}
 }
 
-// Implement custom JSON marshalling for BVH
+// Implement custom JSON marshalling for BVH. A leaf node's Right is nil
+// (see newBVHLeaf) and is encoded as a null Right field.
 func (b *BVH) MarshalJSON() ([]byte, error) {
 	type BVHData struct {
 		Type   string          `json:"Type"`
 		Left   json.RawMessage `json:"Left"`
-		Right  json.RawMessage `json:"Right"`
+		Right  json.RawMessage `json:"Right,omitempty"`
 		Bounds AABB            `json:"Bounds"`
 	}
 	leftData, err := marshalInterface(b.Left)
 	if err != nil {
 		return nil, err
 	}
-	rightData, err := marshalInterface(b.Right)
-	if err != nil {
-		return nil, err
+	var rightData json.RawMessage
+	if b.Right != nil {
+		rightData, err = marshalInterface(b.Right)
+		if err != nil {
+			return nil, err
+		}
 	}
 	data := BVHData{
 		Type:   "BVH",
@@ -296,7 +375,7 @@ func (b *BVH) MarshalJSON() ([]byte, error) {
 	return json.Marshal(data)
 }
 
-// Implement custom JSON unmarshalling for BVH
+// Implement custom JSON unmarshalling for BVH.
 func (b *BVH) UnmarshalJSON(data []byte) error {
 	type BVHData struct {
 		Type   string          `json:"Type"`
@@ -315,111 +394,18 @@ func (b *BVH) UnmarshalJSON(data []byte) error {
 	if err != nil {
 		return err
 	}
-	rightShape, err := unmarshalInterface(temp.Right)
-	if err != nil {
-		return err
-	}
 	b.Left = leftShape.(Shape)
-	b.Right = rightShape.(Shape)
-	b.bounds = temp.Bounds
-	return nil
-}
-
-// Implement custom JSON marshalling for Group
-func (g *Group) MarshalJSON() ([]byte, error) {
-	type GroupData struct {
-		Type   string            `json:"Type"`
-		Shapes []json.RawMessage `json:"Shapes"`
-	}
-	shapesData := make([]json.RawMessage, len(g.Shapes))
-	for i, shape := range g.Shapes {
-		data, err := marshalInterface(shape)
-		if err != nil {
-			return nil, err
-		}
-		shapesData[i] = data
-	}
-	data := GroupData{
-		Type:   "Group",
-		Shapes: shapesData,
-	}
-	return json.Marshal(data)
-}
-
-// Implement custom JSON unmarshalling for Group
-func (g *Group) UnmarshalJSON(data []byte) error {
-	type GroupData struct {
-		Type   string            `json:"Type"`
-		Shapes []json.RawMessage `json:"Shapes"`
-	}
-	var temp GroupData
-	if err := json.Unmarshal(data, &temp); err != nil {
-		return err
-	}
-	if temp.Type != "Group" {
-		return fmt.Errorf("invalid type: expected Group, got %s", temp.Type)
-	}
-	shapes := make([]Shape, len(temp.Shapes))
-	for i, shapeData := range temp.Shapes {
-		shape, err := unmarshalInterface(shapeData)
+	if len(temp.Right) > 0 && string(temp.Right) != "null" {
+		rightShape, err := unmarshalInterface(temp.Right)
 		if err != nil {
 			return err
 		}
-		shapes[i] = shape.(Shape)
-	}
-	g.Shapes = shapes
-	return nil
-}
-
-// Group represents a group of shapes, used as leaf nodes in the BVH.
-type Group struct {
-	Shapes []Shape
-}
-
-// Ensure Group implements the Shape interface.
-var _ Shape = (*Group)(nil)
-
-// Validate checks if the Group is valid.
-func (g *Group) Validate() error {
-	if len(g.Shapes) == 0 {
-		return fmt.Errorf("Group must contain at least one shape")
-	}
-	for i, shape := range g.Shapes {
-		if shape == nil {
-			return fmt.Errorf("Group shape at index %d is nil", i)
-		}
-		if err := shape.Validate(); err != nil {
-			return fmt.Errorf("Group shape at index %d is invalid: %v", i, err)
-		}
+		b.Right = rightShape.(Shape)
 	}
+	b.bounds = temp.Bounds
 	return nil
 }
 
-// Bounds computes the bounding box of the group.
-func (g *Group) Bounds() AABB {
-	bbox := g.Shapes[0].Bounds()
-	for _, shape := range g.Shapes[1:] {
-		bbox = bbox.Union(shape.Bounds())
-	}
-	return bbox
-}
-
-// Collide checks for collision between a ray and any shape in the group.
-func (g *Group) Collide(r ray, tmin, tmax Distance) (bool, collision) {
-	hitAnything := false
-	var closestCollision collision
-	closestT := tmax
-	for _, shape := range g.Shapes {
-		hit, coll := shape.Collide(r, tmin, closestT)
-		if hit {
-			hitAnything = true
-			closestT = coll.t
-			closestCollision = coll
-		}
-	}
-	return hitAnything, closestCollision
-}
-
 // String returns a string representation of the BVH.
 func (b *BVH) String() string {
 	return fmt.Sprintf("BVH{Left: %v, Right: %v, Bounds: %v}", b.Left, b.Right, b.bounds)
@@ -427,5 +413,4 @@ func (b *BVH) String() string {
 
 func init() {
 	RegisterInterfaceType(BVH{})
-	RegisterInterfaceType(Group{})
 }
@@ -0,0 +1,137 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import (
+	"container/list"
+	"math"
+	"sync"
+)
+
+// CollisionCache memoizes BVH.Collide results for rays that repeat,
+// unchanged or nearly so, across many calls -- the common case for
+// structured-light SLAM re-projection, iterative reconstruction, and
+// finite-difference gradient estimation, all of which re-fire close to
+// the same rays frame after frame or step after step. A ray is looked up
+// by its origin, direction, and tmin/tmax quantized to Precision, so rays
+// within half a quantization step of each other share an entry. The cache
+// holds at most Capacity entries, evicting the least recently used one
+// once full.
+//
+// A CollisionCache isn't tied to a single *BVH at construction: CollideCached
+// compares the BVH's current generation (bumped by BVH.Refit) against the
+// generation the cache last saw, and drops every entry on a mismatch
+// rather than risk returning a result from before the scene changed.
+type CollisionCache struct {
+	Capacity  int
+	Precision float64
+
+	mu         sync.Mutex
+	generation uint64
+	order      *list.List // front = most recently used; elements hold *cacheEntry.
+	entries    map[cacheKey]*list.Element
+}
+
+type cacheKey struct {
+	ox, oy, oz int64
+	dx, dy, dz int64
+	tmin, tmax int64
+}
+
+type cacheEntry struct {
+	key  cacheKey
+	hit  bool
+	coll collision
+}
+
+// NewCollisionCache returns a cache holding at most capacity entries,
+// quantizing each ray's origin, direction, and tmin/tmax to precision
+// before hashing (e.g. 1e-4 for a sub-millimeter scene).
+func NewCollisionCache(capacity int, precision float64) *CollisionCache {
+	return &CollisionCache{
+		Capacity:  capacity,
+		Precision: precision,
+		order:     list.New(),
+		entries:   make(map[cacheKey]*list.Element, capacity),
+	}
+}
+
+func quantize(v, precision float64) int64 {
+	return int64(math.Round(v / precision))
+}
+
+func (c *CollisionCache) key(r ray, tmin, tmax Distance) cacheKey {
+	p := c.Precision
+	return cacheKey{
+		ox: quantize(r.origin.X, p), oy: quantize(r.origin.Y, p), oz: quantize(r.origin.Z, p),
+		dx: quantize(r.direction.X, p), dy: quantize(r.direction.Y, p), dz: quantize(r.direction.Z, p),
+		tmin: quantize(float64(tmin), p), tmax: quantize(float64(tmax), p),
+	}
+}
+
+// resetLocked drops every entry and adopts generation as the one the cache
+// has now seen. Callers must hold c.mu.
+func (c *CollisionCache) resetLocked(generation uint64) {
+	c.generation = generation
+	c.order.Init()
+	c.entries = make(map[cacheKey]*list.Element, c.Capacity)
+}
+
+func (c *CollisionCache) insertLocked(k cacheKey, hit bool, coll collision) {
+	entry := &cacheEntry{key: k, hit: hit, coll: coll}
+	c.entries[k] = c.order.PushFront(entry)
+	if c.order.Len() > c.Capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+// CollideCached behaves like BVH.Collide, but first checks cache for a
+// ray matching one already seen (within Precision) since b last changed.
+// On a miss, it still always descends the full tree -- a BVHLeaf only
+// knows the handful of shapes it holds, so trusting one leaf's Collide
+// outright could accept a far hit in an unrelated leaf while missing a
+// true nearest hit elsewhere in the tree. Instead, the most recently
+// cached hit's leaf is retested first only to tighten tmax: if it hits,
+// the full descent below only has to beat that distance, which prunes
+// most of the tree on the common case of a ray that moved only slightly
+// frame to frame, without ever trusting an untraversed leaf's answer.
+// Either way, the result is remembered for next time.
+func (b *BVH) CollideCached(cache *CollisionCache, r ray, tmin, tmax Distance) (bool, collision) {
+	cache.mu.Lock()
+	if cache.generation != b.generation {
+		cache.resetLocked(b.generation)
+	}
+	k := cache.key(r, tmin, tmax)
+	if elem, ok := cache.entries[k]; ok {
+		cache.order.MoveToFront(elem)
+		entry := elem.Value.(*cacheEntry)
+		cache.mu.Unlock()
+		return entry.hit, entry.coll
+	}
+	var warmStart *BVHLeaf
+	if front := cache.order.Front(); front != nil {
+		warmStart = front.Value.(*cacheEntry).coll.leaf
+	}
+	cache.mu.Unlock()
+
+	var hit bool
+	var coll collision
+	searchTmax := tmax
+	if warmStart != nil {
+		if h, c := warmStart.Collide(r, tmin, searchTmax); h {
+			hit, coll = true, c
+			searchTmax = c.t // a full descent only needs to beat this.
+		}
+	}
+	if fullHit, fullColl := b.Collide(r, tmin, searchTmax); fullHit {
+		hit, coll = true, fullColl
+	}
+
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	if cache.generation == b.generation {
+		cache.insertLocked(k, hit, coll)
+	}
+	return hit, coll
+}
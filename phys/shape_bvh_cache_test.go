@@ -0,0 +1,79 @@
+package phys
+
+import (
+	"math"
+	"testing"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
+)
+
+func TestCollideCachedMatchesCollide(t *testing.T) {
+	shapes := generateRandomShapes(2000)
+	bvh := NewBVH(shapes, 0)
+	cache := NewCollisionCache(64, 1e-6)
+
+	for _, r := range generateRandomRays(32) {
+		wantHit, wantColl := bvh.Collide(r, 0.001, Distance(math.MaxFloat64))
+		gotHit, gotColl := bvh.CollideCached(cache, r, 0.001, Distance(math.MaxFloat64))
+		if gotHit != wantHit {
+			t.Fatalf("CollideCached hit = %v, want %v", gotHit, wantHit)
+		}
+		if wantHit && !gotColl.at.IsClose(wantColl.at, 1e-9) {
+			t.Errorf("CollideCached at = %v, want %v", gotColl.at, wantColl.at)
+		}
+	}
+}
+
+func TestCollideCachedHitsCacheForRepeatedRay(t *testing.T) {
+	bvh := NewBVH([]Shape{Sphere{Radius: 1}}, 0)
+	cache := NewCollisionCache(4, 1e-6)
+	r := ray{origin: r3.Point{Z: -5}, direction: r3.Vec{Z: 1}}
+
+	hit1, coll1 := bvh.CollideCached(cache, r, 0.001, Distance(math.MaxFloat64))
+	hit2, coll2 := bvh.CollideCached(cache, r, 0.001, Distance(math.MaxFloat64))
+	if !hit1 || !hit2 {
+		t.Fatalf("CollideCached hit = %v, %v, want true, true", hit1, hit2)
+	}
+	if coll1.at != coll2.at {
+		t.Errorf("CollideCached returned different results for the same ray: %v != %v", coll1.at, coll2.at)
+	}
+}
+
+func TestCollideCachedInvalidatesOnRefit(t *testing.T) {
+	spheres := []*Sphere{{Center: r3.Point{X: 5}, Radius: 1}}
+	shapes := []Shape{spheres[0]}
+	bvh := NewBVH(shapes, 0)
+	cache := NewCollisionCache(4, 1e-6)
+	r := ray{origin: r3.Point{X: 5, Z: -5}, direction: r3.Vec{Z: 1}}
+
+	if hit, _ := bvh.CollideCached(cache, r, 0.001, Distance(math.MaxFloat64)); !hit {
+		t.Fatal("CollideCached hit = false before moving the sphere, want true")
+	}
+
+	spheres[0].Center = r3.Point{X: 1000}
+	bvh.Refit()
+
+	if hit, _ := bvh.CollideCached(cache, r, 0.001, Distance(math.MaxFloat64)); hit {
+		t.Error("CollideCached hit = true after Refit moved the sphere away, want false (stale cache entry reused)")
+	}
+}
+
+func TestCollideCachedEvictsLeastRecentlyUsed(t *testing.T) {
+	bvh := NewBVH([]Shape{Sphere{Radius: 1}}, 0)
+	cache := NewCollisionCache(2, 1e-6)
+
+	rays := []ray{
+		{origin: r3.Point{X: -1000, Z: -5}, direction: r3.Vec{Z: 1}},
+		{origin: r3.Point{X: -2000, Z: -5}, direction: r3.Vec{Z: 1}},
+		{origin: r3.Point{X: -3000, Z: -5}, direction: r3.Vec{Z: 1}},
+	}
+	for _, r := range rays {
+		bvh.CollideCached(cache, r, 0.001, Distance(math.MaxFloat64))
+	}
+	if len(cache.entries) != cache.Capacity {
+		t.Fatalf("len(cache.entries) = %d, want %d (capacity)", len(cache.entries), cache.Capacity)
+	}
+	if _, ok := cache.entries[cache.key(rays[0], 0.001, Distance(math.MaxFloat64))]; ok {
+		t.Error("the first ray's entry survived eviction, want it evicted as least recently used")
+	}
+}
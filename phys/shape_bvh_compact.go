@@ -0,0 +1,226 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
+)
+
+// compactNode is one node in a BVHCompact's flat, depth-first array.
+type compactNode struct {
+	bounds AABB
+
+	// skip is the index of the node immediately following this node's
+	// entire subtree -- its rope to the next node worth visiting when a
+	// ray misses bounds (for a leaf, whose subtree is itself alone,
+	// skip == its own index + 1). Traversal never needs a stack: a miss
+	// jumps straight to skip instead of popping one.
+	skip int
+
+	// A leaf's shapes are shapes[shapeStart : shapeStart+shapeCount] in
+	// the BVHCompact that owns this node; an interior node has
+	// shapeCount == 0 and its left child is the very next node in the
+	// array (depth-first order), so a hit simply advances by 1.
+	shapeStart int
+	shapeCount int
+}
+
+// BVHCompact is a flattened, stackless representation of a BVH: every
+// node lives in a single slice in depth-first order instead of as linked
+// *BVH/*BVHLeaf pointers, and Collide becomes a linear loop over an index
+// that advances by 1 to descend or jumps to a node's skip rope to pass
+// over a missed subtree -- no recursion, no explicit stack. This is the
+// layout GPU ray tracers and AVX2 packet traversal expect, and its flat
+// shape is trivially serializable: MarshalJSON writes the node array and
+// shape list directly, so a large prebuilt tree (the 1M-triangle
+// benchmark) can be loaded rather than rebuilt on every process start.
+//
+// Build one with Compact, not a bare struct literal.
+type BVHCompact struct {
+	nodes  []compactNode
+	shapes []Shape
+}
+
+// Ensure BVHCompact implements the Shape interface.
+var _ Shape = (*BVHCompact)(nil)
+
+// Compact flattens b into a BVHCompact with the same topology and ray
+// results, differing only in traversal mechanics. b may be nil (NewBVH's
+// result for an empty shape slice), in which case Compact returns an
+// empty BVHCompact with no nodes rather than panicking.
+func (b *BVH) Compact() *BVHCompact {
+	c := &BVHCompact{}
+	if b == nil {
+		return c
+	}
+	c.append(b)
+	return c
+}
+
+// append appends b's subtree to c in depth-first order, then backpatches
+// its skip rope once the size of that subtree (interior: both children;
+// leaf: itself) is known.
+func (c *BVHCompact) append(b *BVH) {
+	idx := len(c.nodes)
+	c.nodes = append(c.nodes, compactNode{bounds: b.bounds})
+
+	if b.Right == nil {
+		leaf := b.Left.(*BVHLeaf)
+		c.nodes[idx].shapeStart = len(c.shapes)
+		c.nodes[idx].shapeCount = len(leaf.Shapes)
+		c.shapes = append(c.shapes, leaf.Shapes...)
+		c.nodes[idx].skip = len(c.nodes)
+		return
+	}
+
+	c.append(b.Left.(*BVH))
+	c.append(b.Right.(*BVH))
+	c.nodes[idx].skip = len(c.nodes)
+}
+
+// Validate checks that every shape referenced by a leaf is present and
+// valid.
+func (c *BVHCompact) Validate() error {
+	if len(c.nodes) == 0 {
+		return fmt.Errorf("BVHCompact: nodes is empty")
+	}
+	for i, shape := range c.shapes {
+		if shape == nil {
+			return fmt.Errorf("BVHCompact: shape at index %d is nil", i)
+		}
+		if err := shape.Validate(); err != nil {
+			return fmt.Errorf("BVHCompact: shape at index %d is invalid: %v", i, err)
+		}
+	}
+	return nil
+}
+
+// Bounds returns the root node's bounding box, or the zero AABB for an
+// empty BVHCompact (see Compact).
+func (c *BVHCompact) Bounds() AABB {
+	if len(c.nodes) == 0 {
+		return AABB{}
+	}
+	return c.nodes[0].bounds
+}
+
+// Collide traces r through c's flat node array: on a bounds miss it jumps
+// straight to the missed node's skip rope; on a hit it either descends
+// (interior: the next node in the array is the left child) or tests the
+// leaf's contiguous shape range and then follows its rope, which for a
+// leaf always equals the very next index. Either way the loop only ever
+// moves forward, so there is no stack to maintain and no recursion.
+func (c *BVHCompact) Collide(r ray, tmin, tmax Distance) (bool, collision) {
+	hitAnything := false
+	var best collision
+	for i := 0; i < len(c.nodes); {
+		n := &c.nodes[i]
+		if !n.bounds.hit(r, tmin, tmax) {
+			i = n.skip
+			continue
+		}
+		if n.shapeCount == 0 {
+			i++ // Interior hit: descend into the left child, next in the array.
+			continue
+		}
+		for _, shape := range c.shapes[n.shapeStart : n.shapeStart+n.shapeCount] {
+			if hit, coll := shape.Collide(r, tmin, tmax); hit {
+				hitAnything = true
+				tmax = coll.t
+				best = coll
+			}
+		}
+		i = n.skip
+	}
+	return hitAnything, best
+}
+
+// SignedDistance linearly scans every shape rather than exploiting the
+// rope structure (which only helps prune by ray direction, not by
+// distance to a point): BVHCompact exists to speed up Collide, and a
+// caller needing a fast SignedDistance should query the original *BVH's
+// best-first search instead.
+func (c *BVHCompact) SignedDistance(p r3.Point) Distance {
+	best := Distance(math.Inf(1))
+	bestAbs := math.Inf(1)
+	for _, shape := range c.shapes {
+		d := shape.SignedDistance(p)
+		if ad := math.Abs(float64(d)); ad < bestAbs {
+			bestAbs = ad
+			best = d
+		}
+	}
+	return best
+}
+
+// compactNodeData is compactNode's wire format; shapeCount == 0 is an
+// interior node, matching the in-memory convention.
+type compactNodeData struct {
+	Bounds     AABB `json:"Bounds"`
+	Skip       int  `json:"Skip"`
+	ShapeStart int  `json:"ShapeStart"`
+	ShapeCount int  `json:"ShapeCount"`
+}
+
+// MarshalJSON writes the flat node array and shape list directly, with no
+// recursive tree structure to walk back out -- the serialization this
+// type exists to make trivial.
+func (c *BVHCompact) MarshalJSON() ([]byte, error) {
+	type BVHCompactData struct {
+		Type   string            `json:"Type"`
+		Nodes  []compactNodeData `json:"Nodes"`
+		Shapes []json.RawMessage `json:"Shapes"`
+	}
+	nodes := make([]compactNodeData, len(c.nodes))
+	for i, n := range c.nodes {
+		nodes[i] = compactNodeData{Bounds: n.bounds, Skip: n.skip, ShapeStart: n.shapeStart, ShapeCount: n.shapeCount}
+	}
+	shapes := make([]json.RawMessage, len(c.shapes))
+	for i, shape := range c.shapes {
+		data, err := marshalInterface(shape)
+		if err != nil {
+			return nil, err
+		}
+		shapes[i] = data
+	}
+	return json.Marshal(BVHCompactData{Type: "BVHCompact", Nodes: nodes, Shapes: shapes})
+}
+
+// UnmarshalJSON reads the flat node array and shape list back in
+// directly, with no tree to rebuild.
+func (c *BVHCompact) UnmarshalJSON(data []byte) error {
+	type BVHCompactData struct {
+		Type   string            `json:"Type"`
+		Nodes  []compactNodeData `json:"Nodes"`
+		Shapes []json.RawMessage `json:"Shapes"`
+	}
+	var temp BVHCompactData
+	if err := json.Unmarshal(data, &temp); err != nil {
+		return err
+	}
+	if temp.Type != "BVHCompact" {
+		return fmt.Errorf("invalid type: expected BVHCompact, got %s", temp.Type)
+	}
+	nodes := make([]compactNode, len(temp.Nodes))
+	for i, n := range temp.Nodes {
+		nodes[i] = compactNode{bounds: n.Bounds, skip: n.Skip, shapeStart: n.ShapeStart, shapeCount: n.ShapeCount}
+	}
+	shapes := make([]Shape, len(temp.Shapes))
+	for i, shapeData := range temp.Shapes {
+		shape, err := unmarshalInterface(shapeData)
+		if err != nil {
+			return err
+		}
+		shapes[i] = shape.(Shape)
+	}
+	c.nodes = nodes
+	c.shapes = shapes
+	return nil
+}
+
+func init() {
+	RegisterInterfaceType(BVHCompact{})
+}
@@ -0,0 +1,114 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
+)
+
+// TestBVHCompactMatchesBVHCollide verifies that flattening a BVH into a
+// BVHCompact doesn't change which ray hits which primitive or where.
+func TestBVHCompactMatchesBVHCollide(t *testing.T) {
+	shapes := generateRandomShapes(2000)
+	bvh := NewBVH(shapes, 0)
+	compact := bvh.Compact()
+
+	for _, r := range generateRandomRays(200) {
+		wantHit, wantColl := bvh.Collide(r, 0.001, Distance(math.MaxFloat64))
+		gotHit, gotColl := compact.Collide(r, 0.001, Distance(math.MaxFloat64))
+		if gotHit != wantHit {
+			t.Fatalf("Collide() hit = %v, want %v", gotHit, wantHit)
+		}
+		if !wantHit {
+			continue
+		}
+		if !gotColl.at.IsClose(wantColl.at, 1e-9) {
+			t.Errorf("Collide().at = %v, want %v", gotColl.at, wantColl.at)
+		}
+	}
+}
+
+// TestBVHCompactOfNilBVH verifies that Compact tolerates the nil *BVH
+// NewBVH returns for an empty shape slice instead of panicking.
+func TestBVHCompactOfNilBVH(t *testing.T) {
+	var bvh *BVH
+	compact := bvh.Compact()
+	if hit, _ := compact.Collide(ray{direction: r3.Vec{Z: 1}}, 0, Distance(math.MaxFloat64)); hit {
+		t.Error("Collide() on an empty BVHCompact = true, want false")
+	}
+	if got := compact.Bounds(); got != (AABB{}) {
+		t.Errorf("Bounds() on an empty BVHCompact = %v, want the zero AABB", got)
+	}
+}
+
+// TestBVHCompactMissSkipsWholeSubtree verifies that a ray missing an
+// interior node's bounds never tests the shapes under it.
+func TestBVHCompactMissSkipsWholeSubtree(t *testing.T) {
+	shapes := []Shape{
+		Sphere{Center: r3.Point{X: -10}, Radius: 1},
+		Sphere{Center: r3.Point{X: 10}, Radius: 1},
+		Sphere{Center: r3.Point{Y: 10}, Radius: 1},
+		Sphere{Center: r3.Point{Y: -10}, Radius: 1},
+	}
+	compact := NewBVH(shapes, 0).Compact()
+
+	// A ray far from every sphere should miss entirely, exercising the
+	// skip rope at the root.
+	r := ray{origin: r3.Point{X: 1000, Y: 1000, Z: -10}, direction: r3.Vec{Z: 1}}
+	if hit, _ := compact.Collide(r, 0, Distance(math.MaxFloat64)); hit {
+		t.Fatal("Collide() = true for a ray that misses every shape")
+	}
+}
+
+// TestBVHCompactMarshalJSONRoundTrip verifies that a BVHCompact's flat
+// node array and shape list survive a JSON round-trip with the same
+// Collide behavior.
+func TestBVHCompactMarshalJSONRoundTrip(t *testing.T) {
+	shapes := []Shape{
+		Sphere{Center: r3.Point{X: -10}, Radius: 1},
+		Sphere{Center: r3.Point{X: 10}, Radius: 1},
+		Sphere{Center: r3.Point{Y: 10}, Radius: 1},
+	}
+	compact := NewBVH(shapes, 0).Compact()
+
+	data, err := json.Marshal(compact)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+
+	var decoded BVHCompact
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if err := decoded.Validate(); err != nil {
+		t.Errorf("decoded BVHCompact invalid: %v", err)
+	}
+
+	r := ray{origin: r3.Point{X: 10, Z: -10}, direction: r3.Vec{Z: 1}}
+	hit, coll := decoded.Collide(r, 0, Distance(math.MaxFloat64))
+	if !hit {
+		t.Fatal("decoded BVHCompact should still collide after round-trip")
+	}
+	if !coll.at.IsClose(r3.Point{X: 10, Z: -1}, 1e-9) {
+		t.Errorf("Collide().at = %v, want the sphere's near surface", coll.at)
+	}
+}
+
+// BenchmarkBVHCompactCollisionDetectionSingleRay benchmarks collision
+// detection against a flattened BVHCompact, for comparison against
+// BenchmarkBVHCollisionDetectionSingleRay's pointer-chasing *BVH walk.
+func BenchmarkBVHCompactCollisionDetectionSingleRay(b *testing.B) {
+	shapes := generateRandomShapes(1000000) // 1,000,000 triangles
+	compact := NewBVH(shapes, 0).Compact()
+	r := ray{
+		origin:    r3.Point{X: 0, Y: 0, Z: -10},
+		direction: r3.Vec{X: 0, Y: 0, Z: 1},
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = compact.Collide(r, 0.001, Distance(math.MaxFloat64))
+	}
+}
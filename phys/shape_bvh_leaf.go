@@ -0,0 +1,140 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
+)
+
+// BVHLeaf is a BVH leaf node: a flat list of shapes too few to be worth
+// splitting further, tested against a ray or query point one at a time
+// rather than through another layer of tree traversal. A BVH node is a
+// leaf exactly when its Right child is nil, in which case Left is always
+// a *BVHLeaf; see newBVHLeaf.
+type BVHLeaf struct {
+	Shapes []Shape
+}
+
+// Ensure BVHLeaf implements the Shape interface.
+var _ Shape = (*BVHLeaf)(nil)
+
+// newBVHLeaf wraps shapes in a leaf BVH node.
+func newBVHLeaf(shapes []Shape) *BVH {
+	leaf := &BVHLeaf{Shapes: shapes}
+	return &BVH{Left: leaf, Right: nil, bounds: leaf.Bounds()}
+}
+
+// Validate checks if the BVHLeaf is valid.
+func (l *BVHLeaf) Validate() error {
+	if len(l.Shapes) == 0 {
+		return fmt.Errorf("BVHLeaf must contain at least one shape")
+	}
+	for i, shape := range l.Shapes {
+		if shape == nil {
+			return fmt.Errorf("BVHLeaf shape at index %d is nil", i)
+		}
+		if err := shape.Validate(); err != nil {
+			return fmt.Errorf("BVHLeaf shape at index %d is invalid: %v", i, err)
+		}
+	}
+	return nil
+}
+
+// Bounds computes the bounding box of the leaf's shapes. Unlike BVH's,
+// this isn't cached: a leaf holds the scene's actual primitives, whose own
+// Bounds() already recomputes from their current fields (see Refitter).
+func (l *BVHLeaf) Bounds() AABB {
+	bbox := l.Shapes[0].Bounds()
+	for _, shape := range l.Shapes[1:] {
+		bbox = bbox.Union(shape.Bounds())
+	}
+	return bbox
+}
+
+// Collide checks for collision between a ray and any shape in the leaf.
+func (l *BVHLeaf) Collide(r ray, tmin, tmax Distance) (bool, collision) {
+	hitAnything := false
+	var closestCollision collision
+	closestT := tmax
+	for _, shape := range l.Shapes {
+		hit, coll := shape.Collide(r, tmin, closestT)
+		if hit {
+			hitAnything = true
+			closestT = coll.t
+			closestCollision = coll
+		}
+	}
+	if hitAnything {
+		closestCollision.leaf = l
+	}
+	return hitAnything, closestCollision
+}
+
+// SignedDistance returns the signed distance to the closest of the leaf's
+// shapes: the one among them whose surface is nearest p, with that
+// shape's own sign.
+func (l *BVHLeaf) SignedDistance(p r3.Point) Distance {
+	best := Distance(math.Inf(1))
+	bestAbs := math.Inf(1)
+	for _, shape := range l.Shapes {
+		d := shape.SignedDistance(p)
+		if ad := math.Abs(float64(d)); ad < bestAbs {
+			bestAbs = ad
+			best = d
+		}
+	}
+	return best
+}
+
+// Implement custom JSON marshalling for BVHLeaf.
+func (l *BVHLeaf) MarshalJSON() ([]byte, error) {
+	type BVHLeafData struct {
+		Type   string            `json:"Type"`
+		Shapes []json.RawMessage `json:"Shapes"`
+	}
+	shapesData := make([]json.RawMessage, len(l.Shapes))
+	for i, shape := range l.Shapes {
+		data, err := marshalInterface(shape)
+		if err != nil {
+			return nil, err
+		}
+		shapesData[i] = data
+	}
+	data := BVHLeafData{
+		Type:   "BVHLeaf",
+		Shapes: shapesData,
+	}
+	return json.Marshal(data)
+}
+
+// Implement custom JSON unmarshalling for BVHLeaf.
+func (l *BVHLeaf) UnmarshalJSON(data []byte) error {
+	type BVHLeafData struct {
+		Type   string            `json:"Type"`
+		Shapes []json.RawMessage `json:"Shapes"`
+	}
+	var temp BVHLeafData
+	if err := json.Unmarshal(data, &temp); err != nil {
+		return err
+	}
+	if temp.Type != "BVHLeaf" {
+		return fmt.Errorf("invalid type: expected BVHLeaf, got %s", temp.Type)
+	}
+	shapes := make([]Shape, len(temp.Shapes))
+	for i, shapeData := range temp.Shapes {
+		shape, err := unmarshalInterface(shapeData)
+		if err != nil {
+			return err
+		}
+		shapes[i] = shape.(Shape)
+	}
+	l.Shapes = shapes
+	return nil
+}
+
+func init() {
+	RegisterInterfaceType(BVHLeaf{})
+}
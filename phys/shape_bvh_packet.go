@@ -0,0 +1,73 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import "fmt"
+
+// CollidePacket traces a packet of rays sharing a common origin (a pixel's
+// antialiasing subsamples, or its batch of shadow rays toward several
+// lights) through the BVH together instead of one at a time. At each node
+// it runs the AABB slab test for every still-live ray against the same
+// node bounds and descends only if at least one of them can still hit it,
+// pruning a ray once its own current tmax falls below the node's near
+// distance. Sharing this traversal decision across the packet means the
+// node bounds, Left, and Right fields are read once per node per packet
+// rather than once per node per ray, and every ray in the packet follows
+// the same branch at the same time -- the coherent memory traffic and
+// branching a packet buys, even without explicit SIMD lanes.
+//
+// rays, out, and hits must all have the same length; out[i] and hits[i]
+// receive ray i's result. tmin and tmax bound every ray in the packet.
+func (b *BVH) CollidePacket(rays []ray, tmin, tmax Distance, out []collision, hits []bool) {
+	if len(rays) != len(out) || len(rays) != len(hits) {
+		panic(fmt.Sprintf("phys: BVH.CollidePacket: len(rays)=%d, len(out)=%d, len(hits)=%d must match", len(rays), len(out), len(hits)))
+	}
+	if len(rays) == 0 {
+		return
+	}
+
+	tmaxPerRay := make([]Distance, len(rays))
+	active := make([]bool, len(rays))
+	for i := range rays {
+		tmaxPerRay[i] = tmax
+		active[i] = true
+		hits[i] = false
+	}
+	b.collidePacket(rays, tmin, tmaxPerRay, active, out, hits)
+}
+
+// collidePacket is CollidePacket's recursive worker. active marks which
+// rays are still candidates for this subtree, having survived every
+// ancestor node's bounds test with their tmax as of that test; it is
+// narrowed to a fresh slice at each node rather than mutated in place, so
+// a ray pruned out of the left subtree is still tested against the right.
+func (b *BVH) collidePacket(rays []ray, tmin Distance, tmaxPerRay []Distance, active []bool, out []collision, hits []bool) {
+	nodeActive := make([]bool, len(rays))
+	anyActive := false
+	for i, a := range active {
+		if a && b.bounds.hit(rays[i], tmin, tmaxPerRay[i]) {
+			nodeActive[i] = true
+			anyActive = true
+		}
+	}
+	if !anyActive {
+		return
+	}
+
+	if b.Right == nil {
+		leaf := b.Left.(*BVHLeaf)
+		for i, a := range nodeActive {
+			if !a {
+				continue
+			}
+			if hit, coll := leaf.Collide(rays[i], tmin, tmaxPerRay[i]); hit {
+				hits[i] = true
+				out[i] = coll
+				tmaxPerRay[i] = coll.t
+			}
+		}
+		return
+	}
+
+	b.Left.(*BVH).collidePacket(rays, tmin, tmaxPerRay, nodeActive, out, hits)
+	b.Right.(*BVH).collidePacket(rays, tmin, tmaxPerRay, nodeActive, out, hits)
+}
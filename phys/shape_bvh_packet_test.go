@@ -0,0 +1,61 @@
+package phys
+
+import (
+	"math"
+	"testing"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
+)
+
+func TestBVHCollidePacketMatchesCollide(t *testing.T) {
+	shapes := generateRandomShapes(2000)
+	bvh := NewBVH(shapes, 0)
+	rays := generateRandomRays(8)
+
+	out := make([]collision, len(rays))
+	hits := make([]bool, len(rays))
+	bvh.CollidePacket(rays, 0.001, Distance(math.MaxFloat64), out, hits)
+
+	for i, r := range rays {
+		wantHit, wantColl := bvh.Collide(r, 0.001, Distance(math.MaxFloat64))
+		if hits[i] != wantHit {
+			t.Fatalf("ray %d: CollidePacket hit = %v, want %v", i, hits[i], wantHit)
+		}
+		if wantHit && !out[i].at.IsClose(wantColl.at, 1e-9) {
+			t.Errorf("ray %d: CollidePacket at = %v, want %v", i, out[i].at, wantColl.at)
+		}
+	}
+}
+
+func TestBVHCollidePacketPrunesInactiveRays(t *testing.T) {
+	shapes := []Shape{
+		Sphere{Center: r3.Point{X: -10}, Radius: 1},
+		Sphere{Center: r3.Point{X: 10}, Radius: 1},
+	}
+	bvh := NewBVH(shapes, 0)
+
+	rays := []ray{
+		{origin: r3.Point{X: -10, Z: -5}, direction: r3.Vec{Z: 1}},  // hits the left sphere
+		{origin: r3.Point{X: 1000, Z: -5}, direction: r3.Vec{Z: 1}}, // hits nothing
+	}
+	out := make([]collision, len(rays))
+	hits := make([]bool, len(rays))
+	bvh.CollidePacket(rays, 0, Distance(math.MaxFloat64), out, hits)
+
+	if !hits[0] {
+		t.Error("ray 0: CollidePacket hit = false, want true")
+	}
+	if hits[1] {
+		t.Error("ray 1: CollidePacket hit = true, want false")
+	}
+}
+
+func TestBVHCollidePacketLengthMismatchPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("CollidePacket with mismatched slice lengths did not panic")
+		}
+	}()
+	bvh := NewBVH([]Shape{Sphere{Radius: 1}}, 0)
+	bvh.CollidePacket(make([]ray, 2), 0, Distance(math.MaxFloat64), make([]collision, 1), make([]bool, 1))
+}
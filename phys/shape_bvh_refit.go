@@ -0,0 +1,233 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import "fmt"
+
+// Refitter is implemented by composite shapes that cache their Bounds and
+// so need to be told explicitly when an underlying primitive's geometry
+// has changed. BVH and Mesh both hold such a cache; leaf primitives
+// (Sphere, Triangle, and so on) don't, since their Bounds() recomputes
+// from their fields on every call.
+type Refitter interface {
+	Refit() AABB
+}
+
+// Ensure BVH and Mesh implement Refitter.
+var (
+	_ Refitter = (*BVH)(nil)
+	_ Refitter = Mesh{}
+)
+
+// Refit recomputes b's bounds, and those of every descendant *BVH node,
+// bottom-up from the current state of the leaf primitives. It does not
+// change the tree's split topology, only the cached bounds fields, so it
+// is O(N) versus NewBVH's O(N log N) rebuild: use it when a frame's
+// primitives moved (skinned meshes, scanning-mirror simulations, a rig of
+// instanced fixtures) but the partition built for the previous frame is
+// still a reasonable one.
+func (b *BVH) Refit() AABB {
+	if b == nil {
+		return AABB{}
+	}
+	b.generation++
+	if b.Right == nil {
+		// Leaf (see newBVHLeaf): Left is a *BVHLeaf, whose own Bounds()
+		// already reflects any change to the primitives it holds.
+		b.bounds = b.Left.Bounds()
+		return b.bounds
+	}
+
+	var leftBounds, rightBounds AABB
+	if lb, ok := b.Left.(*BVH); ok {
+		leftBounds = lb.Refit()
+	} else {
+		leftBounds = b.Left.Bounds()
+	}
+	if rb, ok := b.Right.(*BVH); ok {
+		rightBounds = rb.Refit()
+	} else {
+		rightBounds = b.Right.Bounds()
+	}
+	b.bounds = leftBounds.Union(rightBounds)
+	return b.bounds
+}
+
+// Refit recomputes m's internal BVH's bounds; see BVH.Refit.
+func (m Mesh) Refit() AABB {
+	return m.BVH.Refit()
+}
+
+// NewBVHFromTemplate clones oldTree's split topology and rebinds each leaf
+// to the corresponding shapes from the shapes slice, recomputing bounds
+// from the new shapes rather than reusing oldTree's. shapes must be in
+// the same order oldTree's own leaves appear in a left-to-right walk, and
+// the same length as the primitive count oldTree was built from — the
+// natural case for an animated scene whose topology (and so whose leaf
+// shape counts and ordering) is unchanged frame to frame, only the
+// primitives' own geometry having moved.
+//
+// This is cheaper than a fresh NewBVH/NewBVHSTR call because it skips
+// re-deriving the split itself (the binned-SAH cost evaluation or the
+// sort passes), reusing oldTree's partition as-is.
+func NewBVHFromTemplate(oldTree *BVH, shapes []Shape) *BVH {
+	idx := 0
+	return cloneBVHTemplate(oldTree, shapes, &idx)
+}
+
+// cloneBVHTemplate is NewBVHFromTemplate's recursive worker. idx tracks
+// how many of shapes have been consumed so far by earlier leaves in the
+// left-to-right walk.
+func cloneBVHTemplate(old *BVH, shapes []Shape, idx *int) *BVH {
+	if old == nil {
+		return nil
+	}
+	if old.Right == nil {
+		child := rebindTemplateLeaf(old.Left.(*BVHLeaf), shapes, idx)
+		return &BVH{Left: child, Right: nil, bounds: child.Bounds()}
+	}
+	left := cloneBVHTemplate(old.Left.(*BVH), shapes, idx)
+	right := cloneBVHTemplate(old.Right.(*BVH), shapes, idx)
+	return &BVH{Left: left, Right: right, bounds: left.bounds.Union(right.bounds)}
+}
+
+// rebindTemplateLeaf returns a new *BVHLeaf of the same size as old, built
+// from the next shapes taken off shapes starting at *idx.
+func rebindTemplateLeaf(old *BVHLeaf, shapes []Shape, idx *int) *BVHLeaf {
+	n := len(old.Shapes)
+	newShapes := append([]Shape(nil), shapes[*idx:*idx+n]...)
+	*idx += n
+	return &BVHLeaf{Shapes: newShapes}
+}
+
+// Update replaces the primitives at the given global indices -- positions
+// in the same left-to-right leaf ordering NewBVHFromTemplate's shapes
+// parameter assumes -- and refits bounds only along the ancestor chains
+// above the leaves that actually changed, leaving every other node's
+// cached bounds untouched. indices and shapes must be the same length,
+// with shapes[i] replacing the primitive currently at indices[i]; every
+// index must be within [0, primitive count).
+//
+// This is the incremental counterpart to Refit: Refit revisits every
+// node because it assumes any primitive may have moved, while Update
+// knows exactly which ones did and can skip whole unaffected subtrees --
+// the common case when only a handful of primitives in a large scene
+// changed this frame (a few bones of a rig, one scanning mirror facet).
+func (b *BVH) Update(indices []int, shapes []Shape) AABB {
+	if b == nil {
+		return AABB{}
+	}
+	if len(indices) != len(shapes) {
+		panic("phys: BVH.Update: indices and shapes must have the same length")
+	}
+	n := b.primitiveCount()
+	for _, idx := range indices {
+		if idx < 0 || idx >= n {
+			panic(fmt.Sprintf("phys: BVH.Update: index %d out of range [0, %d)", idx, n))
+		}
+	}
+	bounds, _ := b.update(indices, shapes, 0)
+	return bounds
+}
+
+// update is Update's recursive worker. offset is the global index of the
+// first primitive under b (its position in the left-to-right leaf walk).
+// It returns b's bounds and whether anything under b changed, so a parent
+// can skip recomputing its own bounds when neither child did.
+func (b *BVH) update(indices []int, shapes []Shape, offset int) (AABB, bool) {
+	n := b.primitiveCount()
+	var relIndices []int
+	var relShapes []Shape
+	for i, idx := range indices {
+		if idx >= offset && idx < offset+n {
+			relIndices = append(relIndices, idx)
+			relShapes = append(relShapes, shapes[i])
+		}
+	}
+	if len(relIndices) == 0 {
+		return b.bounds, false
+	}
+
+	if b.Right == nil {
+		leaf := b.Left.(*BVHLeaf)
+		for i, idx := range relIndices {
+			leaf.Shapes[idx-offset] = relShapes[i]
+		}
+		b.generation++
+		b.bounds = leaf.Bounds()
+		return b.bounds, true
+	}
+
+	left := b.Left.(*BVH)
+	right := b.Right.(*BVH)
+	leftBounds, leftChanged := left.update(relIndices, relShapes, offset)
+	rightBounds, rightChanged := right.update(relIndices, relShapes, offset+left.primitiveCount())
+	if leftChanged || rightChanged {
+		b.generation++
+		b.bounds = leftBounds.Union(rightBounds)
+	}
+	return b.bounds, leftChanged || rightChanged
+}
+
+// shapes collects every primitive held across b's leaves, in the same
+// left-to-right order Update's indices and NewBVHFromTemplate's shapes
+// parameter assume.
+func (b *BVH) shapes() []Shape {
+	if b == nil {
+		return nil
+	}
+	if b.Right == nil {
+		return append([]Shape(nil), b.Left.(*BVHLeaf).Shapes...)
+	}
+	out := b.Left.(*BVH).shapes()
+	return append(out, b.Right.(*BVH).shapes()...)
+}
+
+// sahCost estimates b's expected ray-traversal cost: an interior node
+// costs 1 (its own box test) plus its children's costs weighted by how
+// much of the node's surface area each child covers -- the same
+// area-weighted form the binned split search in NewBVH approximates
+// locally per split -- and a leaf costs its primitive count, one
+// intersection test per shape. Used by Quality to judge how far a
+// Refit/Update-maintained tree's topology has drifted from optimal.
+func (b *BVH) sahCost() float64 {
+	if b == nil {
+		return 0
+	}
+	if b.Right == nil {
+		return float64(len(b.Left.(*BVHLeaf).Shapes))
+	}
+	left := b.Left.(*BVH)
+	right := b.Right.(*BVH)
+	totalSA := b.bounds.surfaceArea()
+	if totalSA <= 0 {
+		return 1 + left.sahCost() + right.sahCost()
+	}
+	pLeft := left.bounds.surfaceArea() / totalSA
+	pRight := right.bounds.surfaceArea() / totalSA
+	return 1 + pLeft*left.sahCost() + pRight*right.sahCost()
+}
+
+// Quality reports how much worse b's current expected traversal cost is
+// than a fresh NewBVH build of the same primitives: 1.0 means b is as
+// good as a rebuild, 2.0 means a ray traversal through b costs roughly
+// twice as much on average. Repeated Refit/Update calls on a deforming
+// scene can leave a tree's split topology increasingly mismatched with
+// where its primitives actually ended up -- bounds stay correct, but two
+// leaves that started far apart and drifted close together still cost a
+// traversal as if they hadn't -- so Quality is how a caller decides
+// whether it's worth paying for a full rebuild instead of another cheap
+// Update. Rebuilding the reference tree to compare against is itself an
+// O(N log N) operation, the same cost as the rebuild Quality is meant to
+// help a caller avoid paying every frame; call it occasionally, not per
+// frame.
+func (b *BVH) Quality() float64 {
+	if b == nil {
+		return 1
+	}
+	fresh := NewBVH(b.shapes(), 0)
+	freshCost := fresh.sahCost()
+	if freshCost <= 0 {
+		return 1
+	}
+	return b.sahCost() / freshCost
+}
@@ -0,0 +1,184 @@
+package phys
+
+import (
+	"math"
+	"testing"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
+)
+
+func TestBVHRefitPicksUpMovedPrimitive(t *testing.T) {
+	spheres := []*Sphere{
+		{Center: r3.Point{X: -10}, Radius: 1},
+		{Center: r3.Point{X: 10}, Radius: 1},
+	}
+	shapes := make([]Shape, len(spheres))
+	for i, s := range spheres {
+		shapes[i] = s
+	}
+	bvh := NewBVH(shapes, 0)
+
+	before := bvh.Bounds()
+	spheres[1].Center = r3.Point{X: 1000}
+	if got := bvh.Bounds(); got != before {
+		t.Fatalf("Bounds() changed before Refit: %v -> %v, want unchanged (cached)", before, got)
+	}
+
+	bvh.Refit()
+	want := AABB{Min: r3.Point{X: -11, Y: -1, Z: -1}, Max: r3.Point{X: 1001, Y: 1, Z: 1}}
+	if got := bvh.Bounds(); got != want {
+		t.Errorf("Bounds() after Refit = %v, want %v", got, want)
+	}
+}
+
+func TestNewBVHFromTemplateRebindsByPosition(t *testing.T) {
+	oldShapes := []Shape{
+		Sphere{Center: r3.Point{X: -10}, Radius: 1},
+		Sphere{Center: r3.Point{X: 10}, Radius: 1},
+		Sphere{Center: r3.Point{Y: 10}, Radius: 1},
+	}
+	oldTree := NewBVH(oldShapes, 0)
+
+	newShapes := []Shape{
+		Sphere{Center: r3.Point{X: -20}, Radius: 2},
+		Sphere{Center: r3.Point{X: 20}, Radius: 2},
+		Sphere{Center: r3.Point{Y: 20}, Radius: 2},
+	}
+	newTree := NewBVHFromTemplate(oldTree, newShapes)
+
+	if err := newTree.Validate(); err != nil {
+		t.Fatalf("NewBVHFromTemplate(...).Validate() = %v, want nil", err)
+	}
+
+	want := newShapes[0].Bounds().Union(newShapes[1].Bounds()).Union(newShapes[2].Bounds())
+	if got := newTree.Bounds(); got != want {
+		t.Errorf("Bounds() = %v, want %v", got, want)
+	}
+
+	// A ray through the (moved, enlarged) second sphere should hit it.
+	r := ray{origin: r3.Point{X: 20, Z: -10}, direction: r3.Vec{Z: 1}}
+	hit, coll := newTree.Collide(r, 0, 1e6)
+	if !hit {
+		t.Fatal("Collide() with rebound tree = false, want true")
+	}
+	if !coll.at.IsClose(r3.Point{X: 20, Z: -2}, 1e-9) {
+		t.Errorf("Collide().at = %v, want the new sphere's near surface", coll.at)
+	}
+}
+
+func TestBVHUpdateOnlyTouchesAffectedLeaves(t *testing.T) {
+	shapes := []Shape{
+		Sphere{Center: r3.Point{X: -10}, Radius: 1},
+		Sphere{Center: r3.Point{X: 10}, Radius: 1},
+		Sphere{Center: r3.Point{Y: 10}, Radius: 1},
+		Sphere{Center: r3.Point{Y: -10}, Radius: 1},
+	}
+	bvh := NewBVH(shapes, 0)
+	before := bvh.Bounds()
+
+	// Move the shape at index 1 far away and push it through Update.
+	moved := Sphere{Center: r3.Point{X: 1000}, Radius: 1}
+	got := bvh.Update([]int{1}, []Shape{moved})
+
+	want := shapes[0].Bounds().Union(moved.Bounds()).Union(shapes[2].Bounds()).Union(shapes[3].Bounds())
+	if got != want {
+		t.Errorf("Update(...) = %v, want %v", got, want)
+	}
+	if bvh.Bounds() != want {
+		t.Errorf("Bounds() after Update = %v, want %v", bvh.Bounds(), want)
+	}
+	if before == want {
+		t.Fatal("Update did not change the tree's bounds at all")
+	}
+
+	// A ray through the moved sphere's new position should hit it.
+	r := ray{origin: r3.Point{X: 1000, Z: -10}, direction: r3.Vec{Z: 1}}
+	hit, coll := bvh.Collide(r, 0, 1e6)
+	if !hit {
+		t.Fatal("Collide() after Update = false, want true")
+	}
+	if !coll.at.IsClose(r3.Point{X: 1000, Z: -1}, 1e-9) {
+		t.Errorf("Collide().at = %v, want the moved sphere's near surface", coll.at)
+	}
+}
+
+func TestBVHUpdateAcrossInteriorLevels(t *testing.T) {
+	// 64 primitives spread across many leaves forces several levels of
+	// interior *BVH nodes, exercising update()'s offset arithmetic and
+	// left/right dispatch rather than just the single-leaf case.
+	const count = 64
+	shapes := make([]Shape, count)
+	for i := 0; i < count; i++ {
+		shapes[i] = Sphere{Center: r3.Point{X: float64(i) * 3}, Radius: 1}
+	}
+	bvh := NewBVH(shapes, 0)
+
+	moved := Sphere{Center: r3.Point{X: 9000}, Radius: 1}
+	bvh.Update([]int{count - 1}, []Shape{moved})
+
+	want := shapes[0].Bounds()
+	for _, s := range shapes[:count-1] {
+		want = want.Union(s.Bounds())
+	}
+	want = want.Union(moved.Bounds())
+	if got := bvh.Bounds(); got != want {
+		t.Errorf("Bounds() after Update = %v, want %v", got, want)
+	}
+
+	r := ray{origin: r3.Point{X: 9000, Z: -10}, direction: r3.Vec{Z: 1}}
+	hit, coll := bvh.Collide(r, 0, 1e6)
+	if !hit {
+		t.Fatal("Collide() after Update = false, want true")
+	}
+	if !coll.at.IsClose(r3.Point{X: 9000, Z: -1}, 1e-9) {
+		t.Errorf("Collide().at = %v, want the moved sphere's near surface", coll.at)
+	}
+}
+
+func TestBVHUpdateRejectsOutOfRangeIndex(t *testing.T) {
+	shapes := []Shape{
+		Sphere{Center: r3.Point{X: -10}, Radius: 1},
+		Sphere{Center: r3.Point{X: 10}, Radius: 1},
+	}
+	bvh := NewBVH(shapes, 0)
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("Update with an out-of-range index did not panic")
+		}
+	}()
+	bvh.Update([]int{2}, []Shape{Sphere{Radius: 1}})
+}
+
+func TestBVHQualityIsOneForAFreshBuild(t *testing.T) {
+	shapes := make([]Shape, 0, 64)
+	for i := 0; i < 64; i++ {
+		shapes = append(shapes, Sphere{Center: r3.Point{X: float64(i)}, Radius: 0.4})
+	}
+	bvh := NewBVH(shapes, 0)
+	if got := bvh.Quality(); math.Abs(got-1) > 1e-9 {
+		t.Errorf("Quality() on a fresh build = %v, want 1", got)
+	}
+}
+
+func TestBVHQualityDegradesAfterDrift(t *testing.T) {
+	spheres := make([]*Sphere, 64)
+	shapes := make([]Shape, 64)
+	for i := range spheres {
+		spheres[i] = &Sphere{Center: r3.Point{X: float64(i)}, Radius: 0.4}
+		shapes[i] = spheres[i]
+	}
+	bvh := NewBVH(shapes, 0)
+
+	// Collapse every other primitive onto the same point: the split
+	// built for their original, spread-out centroids no longer reflects
+	// where they actually are.
+	for i := 0; i < len(spheres); i += 2 {
+		spheres[i].Center = r3.Point{}
+	}
+	bvh.Refit()
+
+	if got := bvh.Quality(); got <= 1+1e-9 {
+		t.Errorf("Quality() after drift = %v, want > 1", got)
+	}
+}
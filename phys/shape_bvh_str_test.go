@@ -0,0 +1,95 @@
+package phys
+
+import (
+	"math"
+	"testing"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
+)
+
+func TestNewBVHSTREmpty(t *testing.T) {
+	if got := NewBVHSTR(nil); got != nil {
+		t.Errorf("NewBVHSTR(nil) = %v, want nil", got)
+	}
+}
+
+func TestNewBVHSTRValidates(t *testing.T) {
+	shapes := generateRandomShapes(500)
+	bvh := NewBVHSTR(shapes)
+	if err := bvh.Validate(); err != nil {
+		t.Fatalf("NewBVHSTR(shapes).Validate() = %v, want nil", err)
+	}
+}
+
+func TestNewBVHSTRBoundsContainAllShapes(t *testing.T) {
+	shapes := generateRandomShapes(500)
+	bvh := NewBVHSTR(shapes)
+	bounds := bvh.Bounds()
+	for i, shape := range shapes {
+		if bounds.Union(shape.Bounds()) != bounds {
+			t.Errorf("shape %d bounds %v not contained in BVH bounds %v", i, shape.Bounds(), bounds)
+		}
+	}
+}
+
+func TestNewBVHSTRFindsCollisions(t *testing.T) {
+	shapes := generateRandomShapes(1000)
+	bvh := NewBVHSTR(shapes)
+	r := ray{origin: r3.Point{X: 0, Y: 0, Z: -1000}, direction: r3.Vec{X: 0, Y: 0, Z: 1}}
+	sahHit, _ := NewBVH(shapes, 0).Collide(r, 0.001, Distance(math.MaxFloat64))
+	strHit, _ := bvh.Collide(r, 0.001, Distance(math.MaxFloat64))
+	if sahHit != strHit {
+		t.Errorf("STR Collide hit = %v, SAH Collide hit = %v, want equal", strHit, sahHit)
+	}
+}
+
+// BenchmarkBVHConstructionSTRSmall benchmarks STR bulk-loading construction
+// with a small number of shapes; compare against BenchmarkBVHConstructionSmall.
+func BenchmarkBVHConstructionSTRSmall(b *testing.B) {
+	shapes := generateRandomShapes(1000) // 1,000 triangles
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = NewBVHSTR(shapes)
+	}
+}
+
+// BenchmarkBVHConstructionSTRLarge benchmarks STR bulk-loading construction
+// with a large number of shapes; compare against BenchmarkBVHConstructionLarge.
+func BenchmarkBVHConstructionSTRLarge(b *testing.B) {
+	shapes := generateRandomShapes(1000000) // 1,000,000 triangles
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = NewBVHSTR(shapes)
+	}
+}
+
+// BenchmarkBVHSTRCollisionDetectionSingleRay benchmarks traversal of an
+// STR-packed tree with a single ray; compare against
+// BenchmarkBVHCollisionDetectionSingleRay for the SAH-built equivalent.
+func BenchmarkBVHSTRCollisionDetectionSingleRay(b *testing.B) {
+	shapes := generateRandomShapes(1000000) // 1,000,000 triangles
+	bvh := NewBVHSTR(shapes)
+	r := ray{
+		origin:    r3.Point{X: 0, Y: 0, Z: -10},
+		direction: r3.Vec{X: 0, Y: 0, Z: 1},
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = bvh.Collide(r, 0.001, Distance(math.MaxFloat64))
+	}
+}
+
+// BenchmarkBVHSTRCollisionDetectionMultipleRays benchmarks traversal of an
+// STR-packed tree with many rays; compare against
+// BenchmarkBVHCollisionDetectionMultipleRays for the SAH-built equivalent.
+func BenchmarkBVHSTRCollisionDetectionMultipleRays(b *testing.B) {
+	shapes := generateRandomShapes(1000000) // 1,000,000 triangles
+	bvh := NewBVHSTR(shapes)
+	rays := generateRandomRays(1000000) // 1,000,000 rays
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, r := range rays {
+			_, _ = bvh.Collide(r, 0.001, Distance(math.MaxFloat64))
+		}
+	}
+}
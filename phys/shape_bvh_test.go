@@ -56,6 +56,24 @@ func BenchmarkBVHCollisionDetectionMultipleRays(b *testing.B) {
 	}
 }
 
+// TestBVHCollideCountedAgreesWithCollide verifies CollideCounted returns the
+// same hit/collision Collide does, plus a node-visit count that's always at
+// least 1 (the root node itself is always visited).
+func TestBVHCollideCountedAgreesWithCollide(t *testing.T) {
+	shapes := generateRandomShapes(1000)
+	bvh := NewBVH(shapes, 0)
+	for _, r := range generateRandomRays(100) {
+		wantHit, wantColl := bvh.Collide(r, 0.001, Distance(math.MaxFloat64))
+		gotHit, gotColl, visits := bvh.CollideCounted(r, 0.001, Distance(math.MaxFloat64))
+		if gotHit != wantHit || gotColl.t != wantColl.t {
+			t.Fatalf("CollideCounted(%+v) = (%v, %+v), want (%v, %+v) to match Collide", r, gotHit, gotColl, wantHit, wantColl)
+		}
+		if visits < 1 {
+			t.Errorf("CollideCounted(%+v) visits = %d, want >= 1", r, visits)
+		}
+	}
+}
+
 // Helper function to generate a list of random triangles.
 func generateRandomShapes(n int) []Shape {
 	shapes := make([]Shape, n)
@@ -0,0 +1,282 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import (
+	"fmt"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
+)
+
+// Interval represents a single span during which a ray is inside a
+// shape's body, from the surface it enters through to the surface it
+// exits through. It is returned by IntervalShape.CollideAll and combined
+// by the CSG combinators (ShapeUnion, ShapeIntersect, ShapeDifference).
+type Interval struct {
+	TEnter, TExit Distance  // Parametric distance along the ray at entry and exit.
+	Enter, Exit   collision // Collisions at the entry and exit surfaces, respectively.
+}
+
+// IntervalShape is implemented by shapes that can report every span a ray
+// spends inside their body, not just the nearest surface hit. Set
+// operations on solids (union, intersection, difference) are defined in
+// terms of where a ray is inside versus outside each operand, so the CSG
+// combinators require both of their children to implement it.
+type IntervalShape interface {
+	Shape
+	// CollideAll returns every [TEnter, TExit] span the ray spends inside
+	// the shape within [tmin, tmax], sorted by TEnter. A ray that never
+	// enters the shape returns nil.
+	CollideAll(r ray, tmin, tmax Distance) []Interval
+}
+
+// mergeUnion merges two sorted, non-overlapping-within-each-input lists
+// of intervals into the sorted list of spans covered by a OR b.
+// Overlapping or touching intervals from the combined list are coalesced
+// into one.
+func mergeUnion(a, b []Interval) []Interval {
+	all := make([]Interval, 0, len(a)+len(b))
+	all = append(all, a...)
+	all = append(all, b...)
+	if len(all) == 0 {
+		return nil
+	}
+	sortIntervals(all)
+	merged := []Interval{all[0]}
+	for _, iv := range all[1:] {
+		last := &merged[len(merged)-1]
+		if iv.TEnter <= last.TExit {
+			if iv.TExit > last.TExit {
+				last.TExit = iv.TExit
+				last.Exit = iv.Exit
+			}
+			continue
+		}
+		merged = append(merged, iv)
+	}
+	return merged
+}
+
+// mergeIntersect merges two sorted interval lists into the sorted list of
+// spans covered by both a AND b.
+func mergeIntersect(a, b []Interval) []Interval {
+	var result []Interval
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		enter := max(a[i].TEnter, b[j].TEnter)
+		exit := min(a[i].TExit, b[j].TExit)
+		if enter < exit {
+			iv := Interval{TEnter: enter, TExit: exit}
+			if a[i].TEnter >= b[j].TEnter {
+				iv.Enter = a[i].Enter
+			} else {
+				iv.Enter = b[j].Enter
+			}
+			if a[i].TExit <= b[j].TExit {
+				iv.Exit = a[i].Exit
+			} else {
+				iv.Exit = b[j].Exit
+			}
+			result = append(result, iv)
+		}
+		if a[i].TExit < b[j].TExit {
+			i++
+		} else {
+			j++
+		}
+	}
+	return result
+}
+
+// mergeDifference merges two sorted interval lists into the sorted list
+// of spans covered by a but not b. Every span carved out of a by an
+// overlapping b span has its cut surface's normal flipped (via
+// flippedExit/flippedEnter below), since the subtracted geometry's
+// surface now faces into the remaining solid rather than out of it.
+func mergeDifference(a, b []Interval) []Interval {
+	var result []Interval
+	for _, av := range a {
+		remaining := []Interval{av}
+		for _, bv := range b {
+			var next []Interval
+			for _, r := range remaining {
+				if bv.TExit <= r.TEnter || bv.TEnter >= r.TExit {
+					// No overlap.
+					next = append(next, r)
+					continue
+				}
+				if bv.TEnter > r.TEnter {
+					next = append(next, Interval{TEnter: r.TEnter, TExit: bv.TEnter, Enter: r.Enter, Exit: flippedCollision(bv.Enter)})
+				}
+				if bv.TExit < r.TExit {
+					next = append(next, Interval{TEnter: bv.TExit, TExit: r.TExit, Enter: flippedCollision(bv.Exit), Exit: r.Exit})
+				}
+			}
+			remaining = next
+		}
+		result = append(result, remaining...)
+	}
+	sortIntervals(result)
+	return result
+}
+
+// flippedCollision returns c with its normal (and the tangent/bitangent
+// that complete its frame) reversed, used when a subtracted shape's
+// surface becomes an interior wall of the remaining solid and so must
+// face the opposite direction to stay outward-facing.
+func flippedCollision(c collision) collision {
+	c.normal = c.normal.Muls(-1)
+	c.tangent = c.tangent.Muls(-1)
+	return c
+}
+
+// sortIntervals sorts ivs by TEnter in place using insertion sort, which
+// is simple and fast enough for the small interval counts (a handful of
+// spans per CSG node) this package works with.
+func sortIntervals(ivs []Interval) {
+	for i := 1; i < len(ivs); i++ {
+		for j := i; j > 0 && ivs[j].TEnter < ivs[j-1].TEnter; j-- {
+			ivs[j], ivs[j-1] = ivs[j-1], ivs[j]
+		}
+	}
+}
+
+// firstHit reports the first interval endpoint within [tmin, tmax] among
+// ivs, as a Collide-style (bool, collision) pair. ivs must be sorted by
+// TEnter. If tmin falls strictly inside the first interval (the ray
+// origin already being inside the solid, e.g. a secondary ray spawned at
+// a CSG surface), the exit endpoint is reported instead of the entry.
+func firstHit(ivs []Interval, tmin, tmax Distance) (bool, collision) {
+	for _, iv := range ivs {
+		if iv.TEnter >= tmin && iv.TEnter <= tmax {
+			return true, iv.Enter
+		}
+		if iv.TEnter < tmin && iv.TExit >= tmin && iv.TExit <= tmax {
+			return true, iv.Exit
+		}
+	}
+	return false, collision{}
+}
+
+// ShapeUnion is a CSG combinator representing the union of two shapes: a
+// ray is inside it wherever it is inside A or B. It enables compound
+// solids built from overlapping primitives without duplicate, internal
+// surfaces being shaded.
+type ShapeUnion struct {
+	A, B IntervalShape
+}
+
+// ShapeIntersect is a CSG combinator representing the intersection of two
+// shapes: a ray is inside it only where it is inside both A and B.
+type ShapeIntersect struct {
+	A, B IntervalShape
+}
+
+// ShapeDifference is a CSG combinator representing A with B removed: a
+// ray is inside it where it is inside A and outside B. Enables drilled or
+// hollowed-out objects, e.g. a hollow glass sphere (a large Sphere minus
+// a smaller, concentric one).
+type ShapeDifference struct {
+	A, B IntervalShape
+}
+
+func init() {
+	RegisterInterfaceType(ShapeUnion{})
+	RegisterInterfaceType(ShapeIntersect{})
+	RegisterInterfaceType(ShapeDifference{})
+}
+
+func (s ShapeUnion) Validate() error {
+	return validateCSGOperands("ShapeUnion", s.A, s.B)
+}
+
+func (s ShapeIntersect) Validate() error {
+	return validateCSGOperands("ShapeIntersect", s.A, s.B)
+}
+
+func (s ShapeDifference) Validate() error {
+	return validateCSGOperands("ShapeDifference", s.A, s.B)
+}
+
+func validateCSGOperands(name string, a, b IntervalShape) error {
+	if a == nil || b == nil {
+		return fmt.Errorf("%s: A and B must both be set", name)
+	}
+	if err := a.Validate(); err != nil {
+		return fmt.Errorf("%s: invalid A: %v", name, err)
+	}
+	if err := b.Validate(); err != nil {
+		return fmt.Errorf("%s: invalid B: %v", name, err)
+	}
+	return nil
+}
+
+func (s ShapeUnion) Bounds() AABB {
+	return s.A.Bounds().Union(s.B.Bounds())
+}
+
+// Bounds returns A's bounding box, since an intersection can only be as
+// large as its smaller operand and a tight intersection bound would
+// require computing the actual overlap geometry; A's bound is always a
+// valid (if not minimal) superset.
+func (s ShapeIntersect) Bounds() AABB {
+	return s.A.Bounds()
+}
+
+// Bounds returns A's bounding box: removing B can only shrink A, never
+// grow it.
+func (s ShapeDifference) Bounds() AABB {
+	return s.A.Bounds()
+}
+
+// SignedDistance follows the standard SDF union rule: the closer of the
+// two operands' surfaces, since a point is inside the union as soon as
+// it's inside either one.
+func (s ShapeUnion) SignedDistance(p r3.Point) Distance {
+	return min(s.A.SignedDistance(p), s.B.SignedDistance(p))
+}
+
+// SignedDistance follows the standard SDF intersection rule: the
+// farther of the two operands' surfaces, since a point is only inside
+// the intersection once it's inside both.
+func (s ShapeIntersect) SignedDistance(p r3.Point) Distance {
+	return max(s.A.SignedDistance(p), s.B.SignedDistance(p))
+}
+
+// SignedDistance follows the standard SDF difference rule: A intersected
+// with the complement of B (negating B's distance flips inside/outside).
+func (s ShapeDifference) SignedDistance(p r3.Point) Distance {
+	return max(s.A.SignedDistance(p), -s.B.SignedDistance(p))
+}
+
+func (s ShapeUnion) CollideAll(r ray, tmin, tmax Distance) []Interval {
+	return mergeUnion(s.A.CollideAll(r, tmin, tmax), s.B.CollideAll(r, tmin, tmax))
+}
+
+func (s ShapeIntersect) CollideAll(r ray, tmin, tmax Distance) []Interval {
+	return mergeIntersect(s.A.CollideAll(r, tmin, tmax), s.B.CollideAll(r, tmin, tmax))
+}
+
+func (s ShapeDifference) CollideAll(r ray, tmin, tmax Distance) []Interval {
+	return mergeDifference(s.A.CollideAll(r, tmin, tmax), s.B.CollideAll(r, tmin, tmax))
+}
+
+func (s ShapeUnion) Collide(r ray, tmin, tmax Distance) (bool, collision) {
+	return firstHit(s.CollideAll(r, tmin, tmax), tmin, tmax)
+}
+
+func (s ShapeIntersect) Collide(r ray, tmin, tmax Distance) (bool, collision) {
+	return firstHit(s.CollideAll(r, tmin, tmax), tmin, tmax)
+}
+
+func (s ShapeDifference) Collide(r ray, tmin, tmax Distance) (bool, collision) {
+	return firstHit(s.CollideAll(r, tmin, tmax), tmin, tmax)
+}
+
+var (
+	_ Shape         = (*ShapeUnion)(nil)
+	_ Shape         = (*ShapeIntersect)(nil)
+	_ Shape         = (*ShapeDifference)(nil)
+	_ IntervalShape = (*ShapeUnion)(nil)
+	_ IntervalShape = (*ShapeIntersect)(nil)
+	_ IntervalShape = (*ShapeDifference)(nil)
+)
@@ -0,0 +1,125 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import (
+	"testing"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
+)
+
+// straightRay returns a ray traveling in +X from x=-10 along the X axis
+// through the origin, a convenient probe for 1D interval arithmetic
+// against spheres centered on the X axis.
+func straightRay() ray {
+	return ray{origin: r3.Point{X: -10}, direction: r3.Vec{X: 1}}
+}
+
+// TestShapeUnionCollideHitsNearerSurface verifies a ray through two
+// overlapping spheres reports the union's outer (nearer) entry surface,
+// not either sphere's individual entry.
+func TestShapeUnionCollideHitsNearerSurface(t *testing.T) {
+	a := Sphere{Center: r3.Point{X: 0}, Radius: 2}
+	b := Sphere{Center: r3.Point{X: 1}, Radius: 2}
+	u := ShapeUnion{A: a, B: b}
+	if err := u.Validate(); err != nil {
+		t.Fatalf("Validate() = %v, want nil", err)
+	}
+	hit, c := u.Collide(straightRay(), 0, 1000)
+	if !hit {
+		t.Fatalf("Collide() did not hit the union")
+	}
+	// a's near surface is at x=-2, b's near surface is at x=-1: the union
+	// is entered at the smaller (farther along -X), i.e. x=-2.
+	if got := c.at.X; got > -1.999 || got < -2.001 {
+		t.Errorf("Collide() hit x = %v, want approx -2 (a's outer surface)", got)
+	}
+}
+
+// TestShapeIntersectCollideHitsOverlapOnly verifies two disjoint spheres
+// have no intersection, and two overlapping spheres report the entry
+// into their shared region.
+func TestShapeIntersectCollideHitsOverlapOnly(t *testing.T) {
+	disjoint := ShapeIntersect{
+		A: Sphere{Center: r3.Point{X: -5}, Radius: 1},
+		B: Sphere{Center: r3.Point{X: 5}, Radius: 1},
+	}
+	if hit, _ := disjoint.Collide(straightRay(), 0, 1000); hit {
+		t.Errorf("Collide() hit disjoint spheres' intersection, want no hit")
+	}
+
+	overlap := ShapeIntersect{
+		A: Sphere{Center: r3.Point{X: 0}, Radius: 2},
+		B: Sphere{Center: r3.Point{X: 1}, Radius: 2},
+	}
+	hit, c := overlap.Collide(straightRay(), 0, 1000)
+	if !hit {
+		t.Fatalf("Collide() did not hit the overlapping intersection")
+	}
+	// b's near surface (x=-1) is where the ray first enters both spheres.
+	if got := c.at.X; got > -0.999 || got < -1.001 {
+		t.Errorf("Collide() hit x = %v, want approx -1 (b's outer surface)", got)
+	}
+}
+
+// TestShapeDifferenceCollideSkipsRemovedRegion verifies a hollow sphere
+// (outer minus a smaller concentric inner sphere) is entered at the outer
+// surface, and a ray starting inside the cavity exits through the inner
+// sphere's wall with a flipped (inward-facing) normal.
+func TestShapeDifferenceCollideSkipsRemovedRegion(t *testing.T) {
+	hollow := ShapeDifference{
+		A: Sphere{Center: r3.Point{}, Radius: 2},
+		B: Sphere{Center: r3.Point{}, Radius: 1},
+	}
+	if err := hollow.Validate(); err != nil {
+		t.Fatalf("Validate() = %v, want nil", err)
+	}
+	hit, c := hollow.Collide(straightRay(), 0, 1000)
+	if !hit {
+		t.Fatalf("Collide() did not hit the hollow sphere")
+	}
+	if got := c.at.X; got > -1.999 || got < -2.001 {
+		t.Errorf("Collide() hit x = %v, want approx -2 (outer surface)", got)
+	}
+
+	// A ray starting inside the shell (between radius 1 and 2) should hit
+	// the inner cavity wall with a normal flipped to face outward from
+	// the cavity (i.e. pointing back toward the ray origin, -X).
+	shellRay := ray{origin: r3.Point{X: -1.5}, direction: r3.Vec{X: 1}}
+	hit, c = hollow.Collide(shellRay, 0, 1000)
+	if !hit {
+		t.Fatalf("Collide() did not hit the inner cavity wall from within the shell")
+	}
+	if got := c.at.X; got > -0.999 || got < -1.001 {
+		t.Errorf("Collide() hit x = %v, want approx -1 (inner cavity wall)", got)
+	}
+	// The remaining solid's material sits on the -X side of this wall
+	// (between the outer and inner radii) and the cavity it was
+	// subtracted into sits on the +X side (toward the center), so the
+	// outward-facing normal here must point toward +X, into the cavity —
+	// the flip of B's own outward (away-from-center) normal.
+	if c.normal.X <= 0 {
+		t.Errorf("Collide() cavity wall normal = %v, want it flipped to point toward +X", c.normal)
+	}
+}
+
+// TestShapeDifferenceValidateRejectsNilOperand verifies Validate catches
+// an unset A or B rather than panicking later during Collide.
+func TestShapeDifferenceValidateRejectsNilOperand(t *testing.T) {
+	d := ShapeDifference{A: Sphere{Center: r3.Point{}, Radius: 1}}
+	if err := d.Validate(); err == nil {
+		t.Errorf("Validate() = nil, want an error for a nil B operand")
+	}
+}
+
+// TestSphereCollideAllReturnsBothRoots verifies CollideAll reports a
+// single entry/exit span through the sphere, with TEnter < TExit.
+func TestSphereCollideAllReturnsBothRoots(t *testing.T) {
+	s := Sphere{Center: r3.Point{}, Radius: 1}
+	ivs := s.CollideAll(straightRay(), 0, 1000)
+	if len(ivs) != 1 {
+		t.Fatalf("CollideAll() returned %d intervals, want 1", len(ivs))
+	}
+	if ivs[0].TEnter >= ivs[0].TExit {
+		t.Errorf("CollideAll() TEnter=%v >= TExit=%v", ivs[0].TEnter, ivs[0].TExit)
+	}
+}
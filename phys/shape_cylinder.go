@@ -35,12 +35,59 @@ func (c Cylinder) Validate() error {
 	return nil
 }
 
+// basis returns an orthonormal frame (u, v, d) for the cylinder: d is the
+// unit axis direction, and u, v span the cross-sectional plane
+// perpendicular to it. Collide uses this same frame to parameterize UVs
+// as Bounds does to compute the bounding circle's corners, so a scene
+// edit that changes Direction moves the UV seam and the AABB corners
+// together rather than independently.
+func (c Cylinder) basis() (u, v, d r3.Vec) {
+	d = c.Direction.Unit()
+	var orthogonal r3.Vec
+	if math.Abs(d.X) > math.Abs(d.Y) {
+		orthogonal = r3.Vec{X: -d.Z, Y: 0, Z: d.X}.Unit()
+	} else {
+		orthogonal = r3.Vec{X: 0, Y: d.Z, Z: -d.Y}.Unit()
+	}
+	u = orthogonal
+	v = d.Cross(u)
+	return u, v, d
+}
+
+// SignedDistance returns the distance from p to the cylinder's surface
+// (the lateral surface and the two flat caps), negative when p is inside.
+//
+// It reduces the problem to a 2D box SDF in the (radial, axial)
+// cross-section formed by projecting p onto the axis: radial is the
+// distance from p to the axis minus Radius, axial is how far p's
+// projection falls outside [0, Height].
+func (c Cylinder) SignedDistance(p r3.Point) Distance {
+	d := c.Direction.Unit()
+	op := p.Sub(c.Origin)
+	axial := d.Dot(op)
+	radialVec := op.Sub(d.Muls(axial))
+
+	dr := radialVec.Length() - float64(c.Radius)
+	da := math.Max(-axial, axial-float64(c.Height))
+
+	outside := math.Hypot(math.Max(dr, 0), math.Max(da, 0))
+	inside := math.Min(math.Max(dr, da), 0)
+	return Distance(outside + inside)
+}
+
 // Collide determines if the ray intersects with the finite cylinder.
 // It returns a boolean indicating a hit and the collision details.
 func (c Cylinder) Collide(r ray, tmin, tmax Distance) (bool, collision) {
-	d := c.Direction.Unit() // Ensure the axis is a unit vector.
+	u, v, d := c.basis()
 	oc := r.origin.Sub(c.Origin)
 
+	// frontFace is false (and the geometric outward normal gets flipped)
+	// only when the ray actually starts inside the solid cylinder, not
+	// merely when it grazes the surface from outside (SignedDistance is
+	// exactly 0 there, not negative) -- so e.g. a tangent ray still
+	// reports the true outward normal.
+	frontFace := c.SignedDistance(r.origin) >= 0
+
 	dDotRd := d.Dot(r.direction)
 	dDotOc := d.Dot(oc)
 
@@ -73,12 +120,29 @@ func (c Cylinder) Collide(r ray, tmin, tmax Distance) (bool, collision) {
 				if y >= 0 && y <= float64(c.Height) {
 					if t < closestT {
 						at := r.at(Distance(t))
-						normal := at.Sub(c.Origin.Add(d.Muls(y))).Unit()
+						radial := at.Sub(c.Origin.Add(d.Muls(y)))
+						outwardNormal := radial.Unit()
+						normal := outwardNormal
+						if !frontFace {
+							normal = outwardNormal.Muls(-1)
+						}
+
+						// Side UV: angle around the axis in the (u, v)
+						// basis for the horizontal coordinate, height
+						// fraction for the vertical one.
+						theta := math.Atan2(radial.Dot(v), radial.Dot(u))
+						uCoord := theta/(2*math.Pi) + 0.5
+						vCoord := y / float64(c.Height)
+
 						closestT = t
 						closestCollision = collision{
-							t:      Distance(t),
-							at:     at,
-							normal: normal,
+							t:         Distance(t),
+							at:        at,
+							normal:    normal,
+							uv:        r2.Point{X: uCoord, Y: vCoord},
+							tangent:   d.Cross(normal).Unit(),
+							bitangent: d,
+							frontFace: frontFace,
 						}
 						hit = true
 					}
@@ -116,14 +180,27 @@ func (c Cylinder) Collide(r ray, tmin, tmax Distance) (bool, collision) {
 		// Compute the intersection point.
 		p := r.at(Distance(t))
 		// Check if the point is within the cap's radius.
-		if p.Sub(cap.center).Dot(p.Sub(cap.center)) <= float64(c.Radius*c.Radius) {
+		radial := p.Sub(cap.center)
+		if radial.Dot(radial) <= float64(c.Radius*c.Radius) {
 			if t < closestT {
+				normal := cap.normal
+				if !frontFace {
+					normal = cap.normal.Muls(-1)
+				}
+
 				closestT = t
+				capFrame := NewTangentFrame(normal)
 				closestCollision = collision{
 					t:      Distance(t),
 					at:     p,
-					normal: cap.normal,
-					uv:     r2.Point{X: 0.5, Y: 0.5},
+					normal: normal,
+					uv: r2.Point{
+						X: (radial.Dot(u)/float64(c.Radius) + 1) / 2,
+						Y: (radial.Dot(v)/float64(c.Radius) + 1) / 2,
+					},
+					tangent:   capFrame.Tangent,
+					bitangent: capFrame.Bitangent,
+					frontFace: frontFace,
 				}
 				hit = true
 			}
@@ -133,17 +210,32 @@ func (c Cylinder) Collide(r ray, tmin, tmax Distance) (bool, collision) {
 	return hit, closestCollision
 }
 
-func (c Cylinder) Bounds() AABB {
-	d := c.Direction.Unit()
-	var orthogonal r3.Vec
-	if math.Abs(d.X) > math.Abs(d.Y) {
-		orthogonal = r3.Vec{X: -d.Z, Y: 0, Z: d.X}.Unit()
-	} else {
-		orthogonal = r3.Vec{X: 0, Y: d.Z, Z: -d.Y}.Unit()
+// SweepCollide finds the time-of-impact, as a fraction of motion in
+// [0, 1], at which a point starting at r.origin and displaced by motion
+// first touches the cylinder. Unlike Collide, which tests a fixed ray
+// against the surface, SweepCollide tests continuous motion over a
+// timestep against the same surface: because the swept point travels in
+// a straight line, this reduces exactly to Collide against a ray cast
+// along motion's direction, with tmin/tmax (themselves already fractions
+// of motion, matching the [0, 1] TOI convention) scaled up to the
+// world-space distances Collide expects, and the result scaled back down.
+// tmin/tmax let a caller exclude the endpoints, e.g. Body.Step passing
+// a small tmin to avoid re-colliding with the surface it just resolved.
+func (c Cylinder) SweepCollide(r ray, motion r3.Vec, tmin, tmax Distance) (bool, collision) {
+	length := motion.Length()
+	if length < eps {
+		return false, collision{}
 	}
+	hit, coll := c.Collide(ray{origin: r.origin, direction: motion.Unit()}, tmin*Distance(length), tmax*Distance(length))
+	if !hit {
+		return false, collision{}
+	}
+	coll.t /= Distance(length)
+	return true, coll
+}
 
-	u := orthogonal
-	v := d.Cross(u)
+func (c Cylinder) Bounds() AABB {
+	u, v, d := c.basis()
 
 	// Compute all 8 corners
 	var points []r3.Point
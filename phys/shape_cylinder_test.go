@@ -5,6 +5,7 @@ import (
 	"math"
 	"testing"
 
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r2"
 	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
 )
 
@@ -190,3 +191,102 @@ func TestCylinderBounds(t *testing.T) {
 		})
 	}
 }
+
+// TestCylinderCollideUV verifies Collide's side and cap UV
+// parameterization, and that the v=0.5, u=0.5 point (directly opposite
+// the basis's u_axis) for both lands at the expected side/cap location.
+func TestCylinderCollideUV(t *testing.T) {
+	cylinder := Cylinder{
+		Origin:    r3.Point{X: 0, Y: 0, Z: 0},
+		Direction: r3.Vec{X: 0, Y: 1, Z: 0}, // Y-axis
+		Radius:    1.0,
+		Height:    2.0,
+	}
+
+	t.Run("side UV varies with height and angle", func(t *testing.T) {
+		// Halfway up the side, along +X: v should be 0.5.
+		hit, coll := cylinder.Collide(ray{
+			origin:    r3.Point{X: 2, Y: 1, Z: 0},
+			direction: r3.Vec{X: -1, Y: 0, Z: 0},
+		}, 0.0001, math.MaxFloat64)
+		if !hit {
+			t.Fatalf("expected hit")
+		}
+		if want := 0.5; coll.uv.Y < want-eps || coll.uv.Y > want+eps {
+			t.Errorf("side v = %v, want %v at half height", coll.uv.Y, want)
+		}
+
+		// Quarter of the way up the side: v should be 0.25.
+		hit, coll = cylinder.Collide(ray{
+			origin:    r3.Point{X: 2, Y: 0.5, Z: 0},
+			direction: r3.Vec{X: -1, Y: 0, Z: 0},
+		}, 0.0001, math.MaxFloat64)
+		if !hit {
+			t.Fatalf("expected hit")
+		}
+		if want := 0.25; coll.uv.Y < want-eps || coll.uv.Y > want+eps {
+			t.Errorf("side v = %v, want %v at quarter height", coll.uv.Y, want)
+		}
+	})
+
+	t.Run("cap UV centers at the axis", func(t *testing.T) {
+		hit, coll := cylinder.Collide(ray{
+			origin:    r3.Point{X: 0, Y: 3, Z: 0},
+			direction: r3.Vec{X: 0, Y: -1, Z: 0},
+		}, 0.0001, math.MaxFloat64)
+		if !hit {
+			t.Fatalf("expected hit on the top cap")
+		}
+		want := r2.Point{X: 0.5, Y: 0.5}
+		if !coll.uv.IsClose(want, eps) {
+			t.Errorf("cap uv = %v, want %v at the axis", coll.uv, want)
+		}
+	})
+}
+
+// TestCylinderCollideFrontFace verifies Collide reports frontFace=true
+// and the outward geometric normal for a ray starting outside the
+// cylinder, and frontFace=false with a flipped (inward-pointing, i.e.
+// against the ray) normal for a ray starting inside it.
+func TestCylinderCollideFrontFace(t *testing.T) {
+	cylinder := Cylinder{
+		Origin:    r3.Point{X: 0, Y: 0, Z: 0},
+		Direction: r3.Vec{X: 0, Y: 1, Z: 0},
+		Radius:    1.0,
+		Height:    2.0,
+	}
+
+	t.Run("ray from outside hits the front face", func(t *testing.T) {
+		hit, coll := cylinder.Collide(ray{
+			origin:    r3.Point{X: 2, Y: 1, Z: 0},
+			direction: r3.Vec{X: -1, Y: 0, Z: 0},
+		}, 0.0001, math.MaxFloat64)
+		if !hit {
+			t.Fatalf("expected hit")
+		}
+		if !coll.frontFace {
+			t.Errorf("frontFace = false, want true for a ray starting outside the cylinder")
+		}
+		want := r3.Vec{X: 1, Y: 0, Z: 0}
+		if !coll.normal.IsClose(want, eps) {
+			t.Errorf("normal = %v, want outward %v", coll.normal, want)
+		}
+	})
+
+	t.Run("ray from inside hits the back face", func(t *testing.T) {
+		hit, coll := cylinder.Collide(ray{
+			origin:    r3.Point{X: 0, Y: 1, Z: 0},
+			direction: r3.Vec{X: 1, Y: 0, Z: 0},
+		}, 0.0001, math.MaxFloat64)
+		if !hit {
+			t.Fatalf("expected hit")
+		}
+		if coll.frontFace {
+			t.Errorf("frontFace = true, want false for a ray starting inside the cylinder")
+		}
+		want := r3.Vec{X: -1, Y: 0, Z: 0}
+		if !coll.normal.IsClose(want, eps) {
+			t.Errorf("normal = %v, want inward-flipped %v", coll.normal, want)
+		}
+	})
+}
@@ -0,0 +1,236 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
+)
+
+// Instancer places many transformed copies of a single Shape without
+// giving each copy its own acceleration structure. A scene full of
+// TransformedShapes wrapping the same *Mesh already shares that Mesh's
+// BVH in memory, but marshalInterface has no way to know the Shapes are
+// the same pointer: serializing N TransformedShapes serializes N full
+// copies of the mesh. Instancer stores Shape and Transforms exactly once
+// and builds a top-level BVH over lightweight instanceProxy values, each
+// of which borrows Shape and one Transform rather than owning either.
+// Construct with NewInstancer, not a bare struct literal.
+type Instancer struct {
+	Shape      Shape
+	Transforms []Transform
+
+	// bvh is a BVH over this Instancer's instanceProxy values, built once
+	// by NewInstancer (and rebuilt by UnmarshalJSON). Like Mesh.BVH and
+	// Scene.Accel, it's a derived cache: Instancer's custom MarshalJSON
+	// never writes it, since doing so would reintroduce exactly the
+	// per-instance duplication Instancer exists to avoid.
+	bvh *BVH
+}
+
+// NewInstancer builds an Instancer placing shape at each of transforms,
+// with a single top-level BVH shared across all instances.
+func NewInstancer(shape Shape, transforms []Transform) (*Instancer, error) {
+	ins := &Instancer{Shape: shape, Transforms: transforms}
+	ins.build()
+	if err := ins.Validate(); err != nil {
+		return nil, err
+	}
+	return ins, nil
+}
+
+// build (re)constructs ins.bvh from ins.Shape and ins.Transforms.
+func (ins *Instancer) build() {
+	shapes := make([]Shape, len(ins.Transforms))
+	for i := range ins.Transforms {
+		shapes[i] = instanceProxy{instancer: ins, index: i}
+	}
+	if len(shapes) > 0 {
+		ins.bvh = NewBVH(shapes, 0)
+	}
+}
+
+// PropInstances is a convenience constructor for the common case of
+// scattering copies of one Shape across a scene (e.g. trees in a forest,
+// bolts on an assembly): it builds the Instancer and returns its Instances
+// directly, ready to use alongside ordinary Shapes in a []Shape passed to
+// NewBVH, or wrapped one per Node if each instance needs independent
+// Material or visibility.
+func PropInstances(shape Shape, transforms []Transform) ([]Shape, error) {
+	ins, err := NewInstancer(shape, transforms)
+	if err != nil {
+		return nil, err
+	}
+	return ins.Instances(), nil
+}
+
+// Instances returns one Shape per Transform, each a lightweight proxy
+// sharing ins.Shape (and so its BVH, if any) rather than duplicating it.
+func (ins *Instancer) Instances() []Shape {
+	out := make([]Shape, len(ins.Transforms))
+	for i := range ins.Transforms {
+		out[i] = instanceProxy{instancer: ins, index: i}
+	}
+	return out
+}
+
+func (ins Instancer) Validate() error {
+	if ins.Shape == nil {
+		return fmt.Errorf("Instancer: Shape is nil")
+	}
+	if len(ins.Transforms) == 0 {
+		return fmt.Errorf("Instancer: Transforms is empty")
+	}
+	if ins.bvh == nil {
+		return fmt.Errorf("Instancer: bvh is nil; construct with NewInstancer")
+	}
+	if err := ins.Shape.Validate(); err != nil {
+		return fmt.Errorf("Instancer: %v", err)
+	}
+	return ins.bvh.Validate()
+}
+
+// Collide descends ins.bvh, the same top-level BVH used by the rest of
+// the acceleration pipeline, so a ray tests against only the instances
+// whose bounds it actually crosses.
+func (ins Instancer) Collide(r ray, tmin, tmax Distance) (bool, collision) {
+	return ins.bvh.Collide(r, tmin, tmax)
+}
+
+// Bounds returns the union of every instance's bounds.
+func (ins Instancer) Bounds() AABB {
+	return ins.bvh.Bounds()
+}
+
+// SignedDistance returns the distance to the nearest instance's surface.
+func (ins Instancer) SignedDistance(p r3.Point) Distance {
+	return ins.bvh.SignedDistance(p)
+}
+
+// instanceProxy is one placed copy of an Instancer's shared Shape: it
+// transforms a ray (or query point) into the shape's local space and the
+// result back out, exactly like TransformedShape, but without owning a
+// separate Shape or Transform of its own -- only a pointer back to the
+// Instancer and an index into its Transforms. It is never marshaled on
+// its own; Instancer's custom MarshalJSON writes Shape and Transforms
+// instead, and rebuilds instanceProxy values via build on unmarshal.
+type instanceProxy struct {
+	instancer *Instancer
+	index     int
+}
+
+func (p instanceProxy) transform() Transform {
+	return p.instancer.Transforms[p.index]
+}
+
+func (p instanceProxy) Validate() error {
+	return nil // Covered once by Instancer.Validate, not per instance.
+}
+
+// Collide transforms the ray into the instance's local space, performs
+// collision, and transforms the collision back to world space. Mirrors
+// TransformedShape.Collide exactly, substituting the shared Shape and
+// this instance's Transform.
+func (p instanceProxy) Collide(r ray, tmin, tmax Distance) (bool, collision) {
+	t := p.transform()
+	localRay := t.Inverse().TransformRay(r)
+
+	hit, col := p.instancer.Shape.Collide(localRay, tmin, tmax)
+	if !hit {
+		return false, collision{}
+	}
+
+	return true, collision{
+		t:           col.t,
+		at:          t.ApplyToPoint(col.at),
+		normal:      t.ApplyToNormal(col.normal).Unit(),
+		uv:          col.uv,
+		tangent:     t.ApplyToVector(col.tangent).Unit(),
+		bitangent:   t.ApplyToVector(col.bitangent).Unit(),
+		barycentric: col.barycentric,
+		primitiveID: col.primitiveID,
+		feature:     col.feature,
+	}
+}
+
+// Bounds transforms the shared Shape's bounding box by this instance's
+// Transform, the same corner-transforming approach as
+// TransformedShape.Bounds.
+func (p instanceProxy) Bounds() AABB {
+	bounds := p.instancer.Shape.Bounds()
+	t := p.transform()
+	min, max := bounds.Min, bounds.Max
+	corners := [8]r3.Point{
+		{X: min.X, Y: min.Y, Z: min.Z},
+		{X: max.X, Y: min.Y, Z: min.Z},
+		{X: min.X, Y: max.Y, Z: min.Z},
+		{X: max.X, Y: max.Y, Z: min.Z},
+		{X: min.X, Y: min.Y, Z: max.Z},
+		{X: max.X, Y: min.Y, Z: max.Z},
+		{X: min.X, Y: max.Y, Z: max.Z},
+		{X: max.X, Y: max.Y, Z: max.Z},
+	}
+	newMin := t.ApplyToPoint(corners[0])
+	newMax := newMin
+	for _, c := range corners[1:] {
+		wp := t.ApplyToPoint(c)
+		newMin = r3.Point{X: math.Min(newMin.X, wp.X), Y: math.Min(newMin.Y, wp.Y), Z: math.Min(newMin.Z, wp.Z)}
+		newMax = r3.Point{X: math.Max(newMax.X, wp.X), Y: math.Max(newMax.Y, wp.Y), Z: math.Max(newMax.Z, wp.Z)}
+	}
+	return AABB{Min: newMin, Max: newMax}
+}
+
+// SignedDistance mirrors TransformedShape.SignedDistance: evaluate in the
+// instance's local space, then rescale by the cube root of the
+// transform's volume scale factor.
+func (p instanceProxy) SignedDistance(pt r3.Point) Distance {
+	t := p.transform()
+	local := t.Inverse().ApplyToPoint(pt)
+	d := p.instancer.Shape.SignedDistance(local)
+	volumeScale := math.Abs(t.LinearDeterminant())
+	return d * Distance(math.Cbrt(volumeScale))
+}
+
+type instancerData struct {
+	Type       string          `json:"Type"`
+	Shape      json.RawMessage `json:"Shape"`
+	Transforms []Transform     `json:"Transforms"`
+}
+
+// MarshalJSON writes Shape and Transforms only, never the derived bvh or
+// its instanceProxy leaves, so N instances of the same mesh cost exactly
+// one copy of the mesh on the wire.
+func (ins Instancer) MarshalJSON() ([]byte, error) {
+	shapeData, err := marshalInterface(ins.Shape)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(instancerData{Type: "Instancer", Shape: shapeData, Transforms: ins.Transforms})
+}
+
+// UnmarshalJSON reads Shape and Transforms and rebuilds bvh, the same way
+// NewInstancer does, so a decoded Instancer is immediately usable without
+// requiring the caller to call a separate "BuildAccel"-style method.
+func (ins *Instancer) UnmarshalJSON(data []byte) error {
+	var temp instancerData
+	if err := json.Unmarshal(data, &temp); err != nil {
+		return err
+	}
+	if temp.Type != "Instancer" {
+		return fmt.Errorf("invalid type: expected Instancer, got %s", temp.Type)
+	}
+	shape, err := unmarshalInterface(temp.Shape)
+	if err != nil {
+		return err
+	}
+	ins.Shape = shape.(Shape)
+	ins.Transforms = temp.Transforms
+	ins.build()
+	return nil
+}
+
+func init() {
+	RegisterInterfaceType(Instancer{})
+}
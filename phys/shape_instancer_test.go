@@ -0,0 +1,111 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
+)
+
+// unitSphere is a Sphere at the origin, used as the shared Shape in
+// Instancer tests below.
+func unitSphere() Sphere {
+	return Sphere{Center: r3.Point{X: 0, Y: 0, Z: 0}, Radius: 1}
+}
+
+// translatedTransforms returns a Transform at the origin and one
+// translated by (dx, 0, 0), the pair used by the Instancer tests below.
+func translatedTransforms(dx float64) []Transform {
+	moved := NewTranslation(r3.Vec{X: dx, Y: 0, Z: 0})
+	return []Transform{NewTransform(), moved}
+}
+
+// TestInstancerCollideHitsEachInstance verifies that a ray is tested
+// against every placed copy, transformed into the shared Shape's local
+// space, and hits the one whose Transform puts it in the ray's path.
+func TestInstancerCollideHitsEachInstance(t *testing.T) {
+	ins, err := NewInstancer(unitSphere(), translatedTransforms(10))
+	if err != nil {
+		t.Fatalf("NewInstancer: %v", err)
+	}
+
+	hit, coll := ins.Collide(ray{
+		origin:    r3.Point{X: 10, Y: 0, Z: -5},
+		direction: r3.Vec{X: 0, Y: 0, Z: 1},
+	}, 0, Distance(math.MaxFloat64))
+	if !hit {
+		t.Fatalf("expected hit against the translated instance")
+	}
+	want := r3.Point{X: 10, Y: 0, Z: -1}
+	if !coll.at.IsClose(want, eps) {
+		t.Errorf("collision point = %v, want %v", coll.at, want)
+	}
+
+	hit, _ = ins.Collide(ray{
+		origin:    r3.Point{X: 5, Y: 0, Z: -5},
+		direction: r3.Vec{X: 0, Y: 0, Z: 1},
+	}, 0, Distance(math.MaxFloat64))
+	if hit {
+		t.Errorf("expected no hit between the two instances")
+	}
+}
+
+// TestInstancerBoundsUnionsInstances verifies Bounds covers every placed
+// instance, not just the shared Shape's own local-space bounds.
+func TestInstancerBoundsUnionsInstances(t *testing.T) {
+	ins, err := NewInstancer(unitSphere(), translatedTransforms(10))
+	if err != nil {
+		t.Fatalf("NewInstancer: %v", err)
+	}
+	bounds := ins.Bounds()
+	if bounds.Max.X < 11-eps {
+		t.Errorf("Bounds().Max.X = %v, want >= 11", bounds.Max.X)
+	}
+	if bounds.Min.X > -1+eps {
+		t.Errorf("Bounds().Min.X = %v, want <= -1", bounds.Min.X)
+	}
+}
+
+// TestInstancerMarshalJSONDoesNotDuplicateShape verifies that marshaling
+// an Instancer with many Transforms writes the shared Shape's type tag
+// exactly once, not once per instance, and that the result round-trips
+// back to a usable Instancer.
+func TestInstancerMarshalJSONDoesNotDuplicateShape(t *testing.T) {
+	transforms := make([]Transform, 50)
+	for i := range transforms {
+		transforms[i] = NewTransform()
+	}
+	ins, err := NewInstancer(unitSphere(), transforms)
+	if err != nil {
+		t.Fatalf("NewInstancer: %v", err)
+	}
+
+	data, err := json.Marshal(ins)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	if n := strings.Count(string(data), `"Sphere"`); n != 1 {
+		t.Errorf(`"Sphere" type tag appears %d times, want 1`, n)
+	}
+
+	var decoded Instancer
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if len(decoded.Transforms) != len(transforms) {
+		t.Fatalf("Transforms = %d, want %d", len(decoded.Transforms), len(transforms))
+	}
+	if err := decoded.Validate(); err != nil {
+		t.Errorf("decoded Instancer invalid: %v", err)
+	}
+	hit, _ := decoded.Collide(ray{
+		origin:    r3.Point{X: 0, Y: 0, Z: -5},
+		direction: r3.Vec{X: 0, Y: 0, Z: 1},
+	}, 0, Distance(math.MaxFloat64))
+	if !hit {
+		t.Errorf("decoded Instancer should still collide after round-trip")
+	}
+}
@@ -3,6 +3,7 @@ package phys
 import (
 	"fmt"
 	"math"
+	"sort"
 
 	"github.com/scottlawsonbc/slam/code/photon/raytrace/r2"
 	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
@@ -12,12 +13,18 @@ import (
 type Vertex struct {
 	Position r3.Point
 	UV       r2.Point
-	// Possibly other fields here in the future, like normals, tangents, etc.
+	// Normal is this vertex's shading normal, e.g. from an OBJ file's vn
+	// records. Zero value means "not supplied": Face.Collide then falls
+	// back to the face's flat geometric normal instead of interpolating,
+	// the same degrade-gracefully convention uvFootprint and tangent use
+	// elsewhere in this package.
+	Normal r3.Vec
 }
 
 // Face represents a triangular mesh face.
 type Face struct {
 	Vertex [3]Vertex
+	Index  int // Position of this face within its Mesh, set by NewMesh. Used as collision.primitiveID.
 }
 
 // Validate performs comprehensive validation checks on the Face instance.
@@ -39,6 +46,13 @@ func (face Face) Validate() error {
 		}
 		return nil
 	}
+	checkVec3 := func(v r3.Vec, label string) error {
+		if math.IsNaN(v.X) || math.IsNaN(v.Y) || math.IsNaN(v.Z) ||
+			math.IsInf(v.X, 0) || math.IsInf(v.Y, 0) || math.IsInf(v.Z, 0) {
+			return fmt.Errorf("invalid Face: %s contains NaN or Inf: %+v", label, v)
+		}
+		return nil
+	}
 	for i, v := range face.Vertex {
 		if err := checkPoint3(v.Position, fmt.Sprintf("Vertex[%d].Position", i)); err != nil {
 			return err
@@ -46,6 +60,13 @@ func (face Face) Validate() error {
 		if err := checkPoint2(v.UV, fmt.Sprintf("Vertex[%d].UV", i)); err != nil {
 			return err
 		}
+		// Vertex.Normal's zero value means "not supplied"; only check it
+		// for NaN/Inf when actually set.
+		if !v.Normal.IsZero() {
+			if err := checkVec3(v.Normal, fmt.Sprintf("Vertex[%d].Normal", i)); err != nil {
+				return err
+			}
+		}
 	}
 
 	// Check for duplicate vertices.
@@ -90,6 +111,18 @@ func (face Face) Validate() error {
 	return nil
 }
 
+// SignedDistance returns the distance from p to the closest point on the
+// face. A Face has no thickness and so no interior; the returned distance
+// is always non-negative. Mesh.SignedDistance supplies the sign for the
+// mesh as a whole.
+func (face Face) SignedDistance(p r3.Point) Distance {
+	p0 := face.Vertex[0].Position
+	p1 := face.Vertex[1].Position
+	p2 := face.Vertex[2].Position
+	closest := closestPointOnTriangle(p, p0, p1, p2)
+	return Distance(p.Sub(closest).Length())
+}
+
 // Collide determines whether a given ray intersects with the Face.
 // It also interpolates the UV coordinates at the intersection point.
 func (face Face) Collide(r ray, tmin, tmax Distance) (bool, collision) {
@@ -123,24 +156,76 @@ func (face Face) Collide(r ray, tmin, tmax Distance) (bool, collision) {
 		return false, collision{}
 	}
 	at := r.at(Distance(t))
+	w := 1 - u - v
 
-	// Compute face normal.
+	// Compute the flat geometric normal, and smooth-shade it via
+	// barycentric interpolation of the vertex normals when the face
+	// carries them (see Vertex.Normal).
 	normal := edge1.Cross(edge2).Unit()
+	n0, n1, n2 := face.Vertex[0].Normal, face.Vertex[1].Normal, face.Vertex[2].Normal
+	if !n0.IsZero() && !n1.IsZero() && !n2.IsZero() {
+		if smooth := n0.Muls(w).Add(n1.Muls(u)).Add(n2.Muls(v)); !smooth.IsZero() {
+			normal = smooth.Unit()
+		}
+	}
 
 	// Interpolate UVs using barycentric coordinates.
 	uv0 := face.Vertex[0].UV
 	uv1 := face.Vertex[1].UV
 	uv2 := face.Vertex[2].UV
-	w := 1 - u - v
 	interpolatedUV := uv0.Muls(w).Add(uv1.Muls(u)).Add(uv2.Muls(v))
+
+	frame := NewTangentFrameFromFace(face)
 	return true, collision{
-		t:      Distance(t),
-		at:     at,
-		normal: normal,
-		uv:     interpolatedUV,
+		t:           Distance(t),
+		at:          at,
+		normal:      normal,
+		uv:          interpolatedUV,
+		tangent:     frame.Tangent,
+		bitangent:   frame.Bitangent,
+		barycentric: r3.Vec{X: w, Y: u, Z: v},
+		primitiveID: face.Index,
 	}
 }
 
+// area returns face's surface area.
+func (face Face) area() float64 {
+	p0 := face.Vertex[0].Position
+	p1 := face.Vertex[1].Position
+	p2 := face.Vertex[2].Position
+	return 0.5 * p1.Sub(p0).Cross(p2.Sub(p0)).Length()
+}
+
+// samplePoint draws a point uniformly distributed over face's area via
+// the standard sqrt(r1) barycentric trick, smooth-shading the returned
+// normal from the vertex normals the same way Collide does when all three
+// are set.
+func (face Face) samplePoint(rand *Rand) (p r3.Point, normal r3.Vec) {
+	u1, u2 := rand.Float64(), rand.Float64()
+	sr1 := math.Sqrt(u1)
+	u := 1 - sr1
+	v := u2 * sr1
+	w := 1 - u - v
+
+	p0 := face.Vertex[0].Position
+	p1 := face.Vertex[1].Position
+	p2 := face.Vertex[2].Position
+	p = r3.Point{
+		X: w*p0.X + u*p1.X + v*p2.X,
+		Y: w*p0.Y + u*p1.Y + v*p2.Y,
+		Z: w*p0.Z + u*p1.Z + v*p2.Z,
+	}
+
+	normal = p1.Sub(p0).Cross(p2.Sub(p0)).Unit()
+	n0, n1, n2 := face.Vertex[0].Normal, face.Vertex[1].Normal, face.Vertex[2].Normal
+	if !n0.IsZero() && !n1.IsZero() && !n2.IsZero() {
+		if smooth := n0.Muls(w).Add(n1.Muls(u)).Add(n2.Muls(v)); !smooth.IsZero() {
+			normal = smooth.Unit()
+		}
+	}
+	return p, normal
+}
+
 // Bounds computes the Axis-Aligned Bounding Box (AABB) of the Face.
 func (f Face) Bounds() AABB {
 	p0 := f.Vertex[0].Position
@@ -164,14 +249,28 @@ func (f Face) Bounds() AABB {
 type Mesh struct {
 	Face []Face
 	BVH  *BVH
+
+	// faceCumulativeArea and totalArea cache a running sum of Face area,
+	// built once by NewMesh so SampleSurface can pick a face weighted by
+	// its share of the mesh's total area with one sort.Search instead of
+	// re-summing every face's area on every sample. Derived from Face,
+	// same as BVH, so it is never marshaled.
+	faceCumulativeArea []float64
+	totalArea          float64
 }
 
-// NewMesh creates a new Mesh and builds the BVH.
+// NewMesh creates a new Mesh, builds its BVH, and sums each Face's area
+// into a cumulative total for SampleSurface.
 func NewMesh(faces []Face) (*Mesh, error) {
-	m := &Mesh{Face: faces}
+	for i := range faces {
+		faces[i].Index = i
+	}
+	m := &Mesh{Face: faces, faceCumulativeArea: make([]float64, len(faces))}
 	var shapes []Shape
-	for _, face := range faces {
+	for i, face := range faces {
 		shapes = append(shapes, face)
+		m.totalArea += face.area()
+		m.faceCumulativeArea[i] = m.totalArea
 	}
 	m.BVH = NewBVH(shapes, 0)
 	if err := m.Validate(); err != nil {
@@ -215,6 +314,142 @@ func (m *Mesh) String() string {
 	return fmt.Sprintf("Mesh{Faces: %d, BVH: %v}", len(m.Face), m.BVH)
 }
 
+// SelfIntersection reports that two of a Mesh's faces intersect, identified
+// by their Face.Index, along with the segment where their triangles cross.
+type SelfIntersection struct {
+	FaceA, FaceB int
+	Segment      r3.Segment
+}
+
+// SelfIntersections reports every pair of faces in m whose triangles
+// actually cross, a diagnostic for validating imported OBJ/STL geometry
+// before rendering: two faces sharing a vertex or edge (ordinary mesh
+// topology) touch there without crossing, so Triangle.Intersects does not
+// flag them, but faces that pierce through each other (self-intersecting
+// or badly welded geometry) do. It pairs Triangle.Intersects with m.BVH in
+// the standard self-collision tree walk (selfOverlapPairs/crossOverlapPairs
+// below) to avoid the O(n^2) face pair scan a naive check would need: each
+// node's two children are checked against each other and recursed into
+// individually, so a subtree pair only gets examined once its combined
+// bounds are known to overlap -- the same broad-phase pruning Collide
+// gets from the tree for a single ray.
+func (m Mesh) SelfIntersections() []SelfIntersection {
+	var found []SelfIntersection
+	selfOverlapPairs(m.BVH, func(a, b Face) {
+		if facesShareVertex(a, b) {
+			return
+		}
+		ta := Triangle{P0: a.Vertex[0].Position, P1: a.Vertex[1].Position, P2: a.Vertex[2].Position}
+		tb := Triangle{P0: b.Vertex[0].Position, P1: b.Vertex[1].Position, P2: b.Vertex[2].Position}
+		if hit, seg := ta.Intersects(tb); hit {
+			found = append(found, SelfIntersection{FaceA: a.Index, FaceB: b.Index, Segment: seg})
+		}
+	})
+	return found
+}
+
+// facesShareVertex reports whether a and b have a vertex at (nearly) the
+// same position, the ordinary case of adjacent faces meeting at a shared
+// edge or corner. SelfIntersections excludes these pairs: they touch at
+// that shared point by construction, which Triangle.Intersects would
+// otherwise also (correctly) report as an intersecting segment or point.
+func facesShareVertex(a, b Face) bool {
+	const eps = 1e-9
+	for _, va := range a.Vertex {
+		for _, vb := range b.Vertex {
+			if va.Position.IsClose(vb.Position, eps) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// selfOverlapPairs visits every pair of distinct Faces under node exactly
+// once: a leaf pairs its own shapes up directly; an internal node recurses
+// into each child's own pairs, then hands the two children to
+// crossOverlapPairs for the pairs that span both.
+func selfOverlapPairs(node Shape, visit func(Face, Face)) {
+	leaf, ok := node.(*BVHLeaf)
+	if ok {
+		for i := 0; i < len(leaf.Shapes); i++ {
+			for j := i + 1; j < len(leaf.Shapes); j++ {
+				visit(leaf.Shapes[i].(Face), leaf.Shapes[j].(Face))
+			}
+		}
+		return
+	}
+	b := node.(*BVH)
+	if b.Right == nil {
+		selfOverlapPairs(b.Left, visit)
+		return
+	}
+	selfOverlapPairs(b.Left, visit)
+	selfOverlapPairs(b.Right, visit)
+	crossOverlapPairs(b.Left, b.Right, visit)
+}
+
+// crossOverlapPairs visits every pair of Faces with one drawn from under a
+// and the other from under b, pruning the recursion the moment a and b's
+// combined bounds stop overlapping.
+func crossOverlapPairs(a, b Shape, visit func(Face, Face)) {
+	if !a.Bounds().intersects(b.Bounds()) {
+		return
+	}
+	aLeaf, aIsLeaf := a.(*BVHLeaf)
+	bLeaf, bIsLeaf := b.(*BVHLeaf)
+	switch {
+	case aIsLeaf && bIsLeaf:
+		for _, sa := range aLeaf.Shapes {
+			for _, sb := range bLeaf.Shapes {
+				visit(sa.(Face), sb.(Face))
+			}
+		}
+	case aIsLeaf:
+		bb := b.(*BVH)
+		crossOverlapPairs(a, bb.Left, visit)
+		crossOverlapPairs(a, bb.Right, visit)
+	case bIsLeaf:
+		aa := a.(*BVH)
+		crossOverlapPairs(aa.Left, b, visit)
+		crossOverlapPairs(aa.Right, b, visit)
+	default:
+		aa, bb := a.(*BVH), b.(*BVH)
+		crossOverlapPairs(aa.Left, bb.Left, visit)
+		crossOverlapPairs(aa.Left, bb.Right, visit)
+		crossOverlapPairs(aa.Right, bb.Left, visit)
+		crossOverlapPairs(aa.Right, bb.Right, visit)
+	}
+}
+
+// SampleSurface returns a point uniformly distributed over the mesh's
+// total surface area: it first picks a Face with probability proportional
+// to that face's own area (an O(log n) sort.Search over
+// faceCumulativeArea, the per-face area CDF NewMesh builds once), then
+// samples a point uniformly within that face. It satisfies AreaSampler,
+// so a Mesh carrying an Emitter material -- e.g. a glowing quad authored
+// as emissive geometry in an OBJ file -- can be sampled as a light the
+// same way Sphere already can.
+func (m Mesh) SampleSurface(rand *Rand) (p r3.Point, normal r3.Vec, pdfArea float64) {
+	if m.totalArea <= 0 {
+		// Every face is degenerate (zero area); there's no point to draw
+		// a meaningful sample from, so report it the same way a caller
+		// would treat "nothing to sample" rather than dividing by zero.
+		return r3.Point{}, r3.Vec{}, 0
+	}
+	target := rand.Float64() * m.totalArea
+	i := sort.Search(len(m.faceCumulativeArea), func(i int) bool {
+		return m.faceCumulativeArea[i] >= target
+	})
+	if i == len(m.faceCumulativeArea) {
+		i--
+	}
+	p, normal = m.Face[i].samplePoint(rand)
+	return p, normal, 1 / m.totalArea
+}
+
+var _ AreaSampler = (*Mesh)(nil)
+
 func init() {
 	RegisterInterfaceType(Mesh{})
 	RegisterInterfaceType(Face{})
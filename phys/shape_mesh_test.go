@@ -0,0 +1,160 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import (
+	"math"
+	"testing"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
+)
+
+// TestFaceCollideSmoothNormal verifies that a Face whose vertices all carry
+// a Normal interpolates them barycentrically instead of returning the flat
+// geometric normal.
+func TestFaceCollideSmoothNormal(t *testing.T) {
+	// A face in the XY-plane, but with vertex normals tilted toward +X so
+	// the smooth-shaded normal at the centroid should differ from the
+	// flat geometric normal (0, 0, 1).
+	face := Face{
+		Vertex: [3]Vertex{
+			{Position: r3.Point{X: 0, Y: 0, Z: 0}, Normal: r3.Vec{X: 0.5, Y: 0, Z: 0.866}},
+			{Position: r3.Point{X: 1, Y: 0, Z: 0}, Normal: r3.Vec{X: 0.5, Y: 0, Z: 0.866}},
+			{Position: r3.Point{X: 0, Y: 1, Z: 0}, Normal: r3.Vec{X: 0.5, Y: 0, Z: 0.866}},
+		},
+	}
+
+	hit, coll := face.Collide(ray{
+		origin:    r3.Point{X: 0.25, Y: 0.25, Z: -1},
+		direction: r3.Vec{X: 0, Y: 0, Z: 1},
+	}, 0, Distance(math.MaxFloat64))
+	if !hit {
+		t.Fatalf("expected hit")
+	}
+	want := r3.Vec{X: 0.5, Y: 0, Z: 0.866}.Unit()
+	if !coll.normal.IsClose(want, eps) {
+		t.Errorf("smooth normal = %v, want %v", coll.normal, want)
+	}
+}
+
+// TestFaceCollideFlatNormalWithoutVertexNormals verifies that a Face whose
+// vertices leave Normal unset (the zero value) falls back to the flat
+// geometric normal, per Vertex.Normal's doc comment.
+func TestFaceCollideFlatNormalWithoutVertexNormals(t *testing.T) {
+	face := Face{
+		Vertex: [3]Vertex{
+			{Position: r3.Point{X: 0, Y: 0, Z: 0}},
+			{Position: r3.Point{X: 1, Y: 0, Z: 0}},
+			{Position: r3.Point{X: 0, Y: 1, Z: 0}},
+		},
+	}
+
+	hit, coll := face.Collide(ray{
+		origin:    r3.Point{X: 0.25, Y: 0.25, Z: -1},
+		direction: r3.Vec{X: 0, Y: 0, Z: 1},
+	}, 0, Distance(math.MaxFloat64))
+	if !hit {
+		t.Fatalf("expected hit")
+	}
+	want := r3.Vec{X: 0, Y: 0, Z: 1}
+	if !coll.normal.IsClose(want, eps) {
+		t.Errorf("flat normal = %v, want %v", coll.normal, want)
+	}
+}
+
+// TestMeshSampleSurfaceWeightsByArea verifies SampleSurface picks a Face
+// with probability proportional to its area, and that every sampled point
+// actually lies on the mesh.
+func TestMeshSampleSurfaceWeightsByArea(t *testing.T) {
+	// A small unit-area face (index 0) and a large, 100x-area face (index
+	// 1), far enough apart that which one was sampled is unambiguous.
+	small := Face{Vertex: [3]Vertex{
+		{Position: r3.Point{X: 0, Y: 0, Z: 0}},
+		{Position: r3.Point{X: 2, Y: 0, Z: 0}},
+		{Position: r3.Point{X: 0, Y: 1, Z: 0}},
+	}}
+	large := Face{Vertex: [3]Vertex{
+		{Position: r3.Point{X: 100, Y: 0, Z: 0}},
+		{Position: r3.Point{X: 120, Y: 0, Z: 0}},
+		{Position: r3.Point{X: 100, Y: 20, Z: 0}},
+	}}
+	mesh, err := NewMesh([]Face{small, large})
+	if err != nil {
+		t.Fatalf("NewMesh() err = %v", err)
+	}
+
+	rand := NewRand(1)
+	var onLarge int
+	const samples = 2000
+	for i := 0; i < samples; i++ {
+		p, _, pdfArea := mesh.SampleSurface(rand)
+		if pdfArea <= 0 {
+			t.Fatalf("SampleSurface() pdfArea = %v, want positive", pdfArea)
+		}
+		if p.X > 50 {
+			onLarge++
+		}
+	}
+	// The large face has 100x the area of the small one, so it should
+	// account for the overwhelming majority of samples.
+	if frac := float64(onLarge) / samples; frac < 0.9 {
+		t.Errorf("fraction of samples landing on the large face = %v, want >= 0.9", frac)
+	}
+}
+
+// TestMeshSelfIntersections verifies that SelfIntersections ignores faces
+// that only touch at a shared vertex or edge, but reports faces whose
+// triangles genuinely cross.
+func TestMeshSelfIntersections(t *testing.T) {
+	t.Run("adjacent faces sharing an edge report nothing", func(t *testing.T) {
+		// Two faces forming a unit square in the XY-plane, split along
+		// the diagonal (0,0,0)-(1,1,0): they share that edge but never
+		// cross.
+		a := Face{Vertex: [3]Vertex{
+			{Position: r3.Point{X: 0, Y: 0, Z: 0}},
+			{Position: r3.Point{X: 1, Y: 0, Z: 0}},
+			{Position: r3.Point{X: 1, Y: 1, Z: 0}},
+		}}
+		b := Face{Vertex: [3]Vertex{
+			{Position: r3.Point{X: 0, Y: 0, Z: 0}},
+			{Position: r3.Point{X: 1, Y: 1, Z: 0}},
+			{Position: r3.Point{X: 0, Y: 1, Z: 0}},
+		}}
+		mesh, err := NewMesh([]Face{a, b})
+		if err != nil {
+			t.Fatalf("NewMesh() err = %v", err)
+		}
+		if got := mesh.SelfIntersections(); len(got) != 0 {
+			t.Errorf("SelfIntersections() = %v, want none", got)
+		}
+	})
+
+	t.Run("piercing faces are reported", func(t *testing.T) {
+		// A horizontal face in the XY-plane and a vertical face that
+		// stabs straight through its interior.
+		horizontal := Face{Vertex: [3]Vertex{
+			{Position: r3.Point{X: -1, Y: -1, Z: 0}},
+			{Position: r3.Point{X: 2, Y: -1, Z: 0}},
+			{Position: r3.Point{X: -1, Y: 2, Z: 0}},
+		}}
+		vertical := Face{Vertex: [3]Vertex{
+			{Position: r3.Point{X: 0, Y: 0, Z: -1}},
+			{Position: r3.Point{X: 0, Y: 0, Z: 1}},
+			{Position: r3.Point{X: 0, Y: 1, Z: 0}},
+		}}
+		mesh, err := NewMesh([]Face{horizontal, vertical})
+		if err != nil {
+			t.Fatalf("NewMesh() err = %v", err)
+		}
+		got := mesh.SelfIntersections()
+		if len(got) != 1 {
+			t.Fatalf("SelfIntersections() = %v, want exactly 1", got)
+		}
+		si := got[0]
+		if si.FaceA != horizontal.Index && si.FaceB != horizontal.Index {
+			t.Errorf("SelfIntersections()[0] = %+v, want it to reference face %d", si, horizontal.Index)
+		}
+		if si.FaceA != vertical.Index && si.FaceB != vertical.Index {
+			t.Errorf("SelfIntersections()[0] = %+v, want it to reference face %d", si, vertical.Index)
+		}
+	})
+}
@@ -0,0 +1,200 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import (
+	"fmt"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r2"
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
+)
+
+// MorphedMesh is a triangle mesh whose vertex positions blend between a
+// base pose P and len(D) displacement targets, the same blend-shape
+// model glTF's morph targets use: the effective position of vertex i at
+// weights W is P[i] + sum_j W[j]*D[j][i]. UV and the triangle topology
+// (Indices, three per triangle) are shared by every pose. Face normals
+// are recomputed from each triangle's two edges after blending rather
+// than interpolated, the same flat-normal fallback Vertex.Normal's zero
+// value already gives Face.Collide.
+//
+// MorphedMesh caches the blended pose as an ordinary *Mesh (mesh) and
+// pushes new positions into that Mesh's BVH via BVH.Update instead of
+// rebuilding it from scratch on every SetWeights call -- the incremental
+// refit shape_bvh_refit.go's own doc comments name skinned meshes as the
+// intended use for, since the triangle topology (and so the BVH's split)
+// never changes, only where each triangle's vertices landed.
+//
+// Like Mesh's own BVH, mesh is a derived cache rather than scene
+// definition data: it is unexported and so never marshaled. A
+// MorphedMesh loaded from JSON has P/UV/D/Indices/W but a nil mesh, and
+// must call Build (or SetWeights) once to populate it before use -- the
+// same obligation Node.LightmapCache places on a scene loaded from disk.
+type MorphedMesh struct {
+	P       []r3.Point // Base pose positions.
+	UV      []r2.Point // Per-vertex UV, shared by every pose. May be nil.
+	D       [][]r3.Vec // D[j] is target j's per-vertex displacement from P; len(D[j]) == len(P).
+	Indices []int      // Triangle topology, three indices per triangle, indexing into P/UV/D[j].
+	W       []float64  // Current weights, len(W) == len(D).
+
+	mesh *Mesh // Cached blended pose at W. Derived; never marshaled.
+}
+
+// NewMorphedMesh validates p, uv, d, and indices, then builds the initial
+// pose at zero weights (the base pose P unperturbed).
+func NewMorphedMesh(p []r3.Point, uv []r2.Point, d [][]r3.Vec, indices []int) (*MorphedMesh, error) {
+	mm := &MorphedMesh{P: p, UV: uv, D: d, Indices: indices, W: make([]float64, len(d))}
+	if err := mm.validateShape(); err != nil {
+		return nil, err
+	}
+	if err := mm.Build(); err != nil {
+		return nil, err
+	}
+	return mm, nil
+}
+
+// validateShape checks P/UV/D/Indices/W's lengths agree, independent of
+// whether mesh has been built yet -- the part of Validate that a
+// JSON-loaded MorphedMesh can still check before calling Build.
+func (mm *MorphedMesh) validateShape() error {
+	if len(mm.P) == 0 {
+		return fmt.Errorf("MorphedMesh: P must be non-empty")
+	}
+	if mm.UV != nil && len(mm.UV) != len(mm.P) {
+		return fmt.Errorf("MorphedMesh: len(UV) %d != len(P) %d", len(mm.UV), len(mm.P))
+	}
+	if len(mm.Indices) == 0 || len(mm.Indices)%3 != 0 {
+		return fmt.Errorf("MorphedMesh: len(Indices) %d must be a positive multiple of 3", len(mm.Indices))
+	}
+	for i, target := range mm.D {
+		if len(target) != len(mm.P) {
+			return fmt.Errorf("MorphedMesh: target %d has %d displacements, want %d", i, len(target), len(mm.P))
+		}
+	}
+	if len(mm.W) != len(mm.D) {
+		return fmt.Errorf("MorphedMesh: len(W) %d != %d targets", len(mm.W), len(mm.D))
+	}
+	return nil
+}
+
+// positions returns the blended vertex positions P + sum_j w[j]*D[j].
+func (mm *MorphedMesh) positions(w []float64) []r3.Point {
+	out := make([]r3.Point, len(mm.P))
+	copy(out, mm.P)
+	for j, weight := range w {
+		if weight == 0 {
+			continue
+		}
+		target := mm.D[j]
+		for i, d := range target {
+			out[i] = out[i].Add(d.Muls(weight))
+		}
+	}
+	return out
+}
+
+// faces builds the triangle Face list at the blended positions for w,
+// leaving Vertex.Normal at its zero value so Face.Collide falls back to
+// the flat normal recomputed from the blended triangle's own two edges.
+func (mm *MorphedMesh) faces(w []float64) ([]Face, error) {
+	positions := mm.positions(w)
+	faces := make([]Face, 0, len(mm.Indices)/3)
+	for t := 0; t+2 < len(mm.Indices); t += 3 {
+		var verts [3]Vertex
+		for k := 0; k < 3; k++ {
+			idx := mm.Indices[t+k]
+			if idx < 0 || idx >= len(positions) {
+				return nil, fmt.Errorf("MorphedMesh: index %d out of range for %d vertices", idx, len(positions))
+			}
+			v := Vertex{Position: positions[idx]}
+			if mm.UV != nil {
+				v.UV = mm.UV[idx]
+			}
+			verts[k] = v
+		}
+		faces = append(faces, Face{Vertex: verts})
+	}
+	return faces, nil
+}
+
+// Build (re)constructs mm's cached Mesh and BVH from scratch at the
+// current W. NewMorphedMesh calls this once; a MorphedMesh loaded from
+// JSON must call it before Collide/Bounds/SignedDistance, since mesh is
+// never marshaled.
+func (mm *MorphedMesh) Build() error {
+	if err := mm.validateShape(); err != nil {
+		return err
+	}
+	faces, err := mm.faces(mm.W)
+	if err != nil {
+		return err
+	}
+	mesh, err := NewMesh(faces)
+	if err != nil {
+		return fmt.Errorf("MorphedMesh: %w", err)
+	}
+	mm.mesh = mesh
+	return nil
+}
+
+// SetWeights re-blends mm's triangles at w and pushes the new positions
+// into the cached Mesh's BVH via BVH.Update, cheaper per frame than
+// Build's full NewMesh/NewBVH rebuild since the triangle topology (and so
+// the BVH's split) never changes, only where each triangle's vertices
+// landed.
+func (mm *MorphedMesh) SetWeights(w []float64) error {
+	if len(w) != len(mm.D) {
+		return fmt.Errorf("MorphedMesh.SetWeights: len(w) %d != %d targets", len(w), len(mm.D))
+	}
+	if mm.mesh == nil {
+		mm.W = w
+		return mm.Build()
+	}
+	faces, err := mm.faces(w)
+	if err != nil {
+		return err
+	}
+	shapes := make([]Shape, len(faces))
+	indices := make([]int, len(faces))
+	for i, f := range faces {
+		f.Index = i
+		mm.mesh.Face[i] = f
+		shapes[i] = f
+		indices[i] = i
+	}
+	mm.mesh.BVH.Update(indices, shapes)
+	mm.W = w
+	return nil
+}
+
+// Collide delegates to the cached blended-pose Mesh.
+func (mm *MorphedMesh) Collide(r ray, tmin, tmax Distance) (bool, collision) {
+	return mm.mesh.Collide(r, tmin, tmax)
+}
+
+// Bounds delegates to the cached blended-pose Mesh.
+func (mm *MorphedMesh) Bounds() AABB {
+	return mm.mesh.Bounds()
+}
+
+// SignedDistance delegates to the cached blended-pose Mesh.
+func (mm *MorphedMesh) SignedDistance(p r3.Point) Distance {
+	return mm.mesh.SignedDistance(p)
+}
+
+// Validate checks P/UV/D/Indices/W's lengths and, once Build has been
+// called, the cached Mesh itself.
+func (mm *MorphedMesh) Validate() error {
+	if err := mm.validateShape(); err != nil {
+		return err
+	}
+	if mm.mesh == nil {
+		return fmt.Errorf("MorphedMesh: mesh not built; call Build")
+	}
+	return mm.mesh.Validate()
+}
+
+var _ Shape = (*MorphedMesh)(nil)
+
+func init() {
+	RegisterInterfaceType(&MorphedMesh{})
+}
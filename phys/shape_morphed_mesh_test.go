@@ -0,0 +1,63 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import (
+	"math"
+	"testing"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
+)
+
+// quadMorphedMesh builds a two-triangle, slightly tented XY quad near
+// Z=0 (vertex Z staggered by 0.1 so its AABB has non-zero thickness on
+// every axis, avoiding the exactly-flat-box grazing case AABB.hit
+// doesn't resolve -- see TestQuadCollide/Ray_hits_quad_at_corner), with
+// a single morph target displacing every vertex by +5 along Z.
+func quadMorphedMesh(t *testing.T) *MorphedMesh {
+	t.Helper()
+	p := []r3.Point{{X: -1, Y: -1, Z: -0.1}, {X: 1, Y: -1, Z: 0.1}, {X: 1, Y: 1, Z: -0.1}, {X: -1, Y: 1, Z: 0.1}}
+	d := [][]r3.Vec{{{Z: 5}, {Z: 5}, {Z: 5}, {Z: 5}}}
+	mm, err := NewMorphedMesh(p, nil, d, []int{0, 1, 2, 0, 2, 3})
+	if err != nil {
+		t.Fatalf("NewMorphedMesh: %v", err)
+	}
+	return mm
+}
+
+// TestMorphedMeshSetWeightsMovesTheHitSurface verifies SetWeights
+// actually blends the vertex positions Collide sees: the hit point of a
+// ray straight down the Z axis moves from near the base pose's Z=0 to
+// near the target's Z=5 as the weight goes from 0 to 1.
+func TestMorphedMeshSetWeightsMovesTheHitSurface(t *testing.T) {
+	mm := quadMorphedMesh(t)
+	probe := ray{origin: r3.Point{Z: -10}, direction: r3.Vec{Z: 1}}
+
+	hit, c := mm.Collide(probe, eps, 1000)
+	if !hit {
+		t.Fatalf("Collide at W=0 missed, want a hit near the base pose's Z=0")
+	}
+	if math.Abs(float64(c.at.Z)) > 0.2 {
+		t.Errorf("collision.at.Z at W=0 = %v, want close to 0", c.at.Z)
+	}
+
+	if err := mm.SetWeights([]float64{1}); err != nil {
+		t.Fatalf("SetWeights: %v", err)
+	}
+	hit, c = mm.Collide(probe, eps, 1000)
+	if !hit {
+		t.Fatalf("Collide at W=1 missed, want a hit on the displaced quad near Z=5")
+	}
+	if math.Abs(float64(c.at.Z)-5) > 0.2 {
+		t.Errorf("collision.at.Z at W=1 = %v, want close to 5", c.at.Z)
+	}
+}
+
+// TestMorphedMeshSetWeightsRejectsWrongLength verifies SetWeights
+// rejects a weight vector whose length doesn't match the number of morph
+// targets, rather than silently ignoring the extra or missing entries.
+func TestMorphedMeshSetWeightsRejectsWrongLength(t *testing.T) {
+	mm := quadMorphedMesh(t)
+	if err := mm.SetWeights([]float64{1, 2}); err == nil {
+		t.Fatalf("SetWeights with wrong length: got nil error, want one")
+	}
+}
@@ -0,0 +1,166 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r2"
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
+)
+
+// Polygon represents a planar convex polygon defined by three or more
+// coplanar Vertices, wound counter-clockwise when viewed from the side
+// Normal points to (the same convention Triangle's P0, P1, P2 follow).
+// It generalizes Quad to an arbitrary vertex count: Quad.Collide delegates
+// to Polygon for its plane/edge test rather than stitching together two
+// Triangle.Collide calls.
+type Polygon struct {
+	Vertices []r3.Point
+	Normal   r3.Vec
+
+	// UAxis and VAxis optionally fix the in-plane basis Collide projects
+	// Vertices[0]-relative hit points into for coll.uv. Leave both zero
+	// (the default) to have Collide derive an orthonormal frame from
+	// Normal automatically, the same arbitrary-vector heuristic
+	// Quad.Collide uses.
+	UAxis, VAxis r3.Vec
+}
+
+// uvBasis returns the origin and orthonormal in-plane axes Collide
+// projects a hit point against, either p's explicit UAxis/VAxis or an
+// automatically derived frame.
+func (p Polygon) uvBasis() (origin r3.Point, uAxis, vAxis r3.Vec) {
+	if p.UAxis.IsZero() && p.VAxis.IsZero() {
+		normal := p.Normal.Unit()
+		arbitrary := r3.Vec{X: 1, Y: 0, Z: 0}
+		if math.Abs(normal.X) >= 0.9 {
+			arbitrary = r3.Vec{X: 0, Y: 1, Z: 0}
+		}
+		uAxis = normal.Cross(arbitrary).Unit()
+		vAxis = normal.Cross(uAxis).Unit()
+	} else {
+		uAxis = p.UAxis.Unit()
+		vAxis = p.VAxis.Unit()
+	}
+	return p.Vertices[0], uAxis, vAxis
+}
+
+// Validate checks that p has at least three vertices, a unit Normal, and
+// consistent counter-clockwise winding: at every vertex, the cross
+// product of its incoming and outgoing edges must point in the same
+// direction as Normal, which also rules out non-convex input (a reflex
+// vertex flips that cross product).
+func (p Polygon) Validate() error {
+	n := len(p.Vertices)
+	if n < 3 {
+		return fmt.Errorf("Polygon: need at least 3 Vertices, got %d", n)
+	}
+	if p.Normal.IsZero() {
+		return fmt.Errorf("Polygon: Normal is zero (has it been set?)")
+	}
+	if math.Abs(p.Normal.Length()-1) > eps {
+		return fmt.Errorf("Polygon: Normal should be a unit vector, got %v", p.Normal)
+	}
+	normal := p.Normal.Unit()
+	plane := p.Vertices[0]
+	for i, v := range p.Vertices {
+		if d := v.Sub(plane).Dot(normal); math.Abs(d) > eps {
+			return fmt.Errorf("Polygon: Vertices[%d] is %v off the plane through Vertices[0] with Normal %v", i, d, normal)
+		}
+	}
+	for i := 0; i < n; i++ {
+		prev := p.Vertices[(i-1+n)%n]
+		curr := p.Vertices[i]
+		next := p.Vertices[(i+1)%n]
+		edge1 := curr.Sub(prev)
+		edge2 := next.Sub(curr)
+		if edge1.Cross(edge2).Dot(normal) <= 0 {
+			return fmt.Errorf("Polygon: Vertices[%d] breaks convex counter-clockwise winding relative to Normal", i)
+		}
+	}
+	return nil
+}
+
+// Collide intersects r with p's plane, then rejects the hit if it falls
+// outside any of p's edges: for each edge v[i]->v[i+1], edgeNormal :=
+// Normal x (v[i+1]-v[i]), and the hit must satisfy
+// dot(hitPoint-v[i], edgeNormal) >= 0 for every edge. This is the same
+// inside test Quad.Collide now delegates to, generalized to an arbitrary
+// vertex count instead of two hardcoded Triangle.Collide calls.
+func (p Polygon) Collide(r ray, tmin, tmax Distance) (bool, collision) {
+	normal := p.Normal.Unit()
+	denom := r.direction.Dot(normal)
+	if denom > -eps && denom < eps {
+		return false, collision{} // Ray parallel to the plane.
+	}
+	t := Distance(p.Vertices[0].Sub(r.origin).Dot(normal) / denom)
+	if t < tmin || t > tmax {
+		return false, collision{}
+	}
+	hitPoint := r.at(t)
+
+	n := len(p.Vertices)
+	for i := 0; i < n; i++ {
+		v0 := p.Vertices[i]
+		v1 := p.Vertices[(i+1)%n]
+		edgeNormal := normal.Cross(v1.Sub(v0))
+		if hitPoint.Sub(v0).Dot(edgeNormal) < 0 {
+			return false, collision{}
+		}
+	}
+
+	origin, uAxis, vAxis := p.uvBasis()
+	rel := hitPoint.Sub(origin)
+	return true, collision{
+		t:         t,
+		at:        hitPoint,
+		normal:    normal,
+		uv:        r2.Point{X: rel.Dot(uAxis), Y: rel.Dot(vAxis)},
+		tangent:   uAxis,
+		bitangent: vAxis,
+	}
+}
+
+// Bounds returns the AABB over Vertices.
+func (p Polygon) Bounds() AABB {
+	min, max := p.Vertices[0], p.Vertices[0]
+	for _, v := range p.Vertices[1:] {
+		min = r3.Point{X: math.Min(min.X, v.X), Y: math.Min(min.Y, v.Y), Z: math.Min(min.Z, v.Z)}
+		max = r3.Point{X: math.Max(max.X, v.X), Y: math.Max(max.Y, v.Y), Z: math.Max(max.Z, v.Z)}
+	}
+	return AABB{Min: min, Max: max}
+}
+
+// SignedDistance returns the distance from pt to the closest point on p.
+// A Polygon has no thickness and so no interior; the returned distance is
+// always non-negative. It delegates to closestPointOnTriangle over p's
+// own Triangulate() fan, the same per-triangle primitive Triangle.
+// SignedDistance uses, rather than duplicating a separate
+// point-to-convex-polygon routine.
+func (p Polygon) SignedDistance(pt r3.Point) Distance {
+	best := math.Inf(1)
+	for _, tri := range p.Triangulate() {
+		if d := pt.Sub(closestPointOnTriangle(pt, tri.P0, tri.P1, tri.P2)).Length(); d < best {
+			best = d
+		}
+	}
+	return Distance(best)
+}
+
+// Triangulate fan-triangulates p from Vertices[0], returning len(Vertices)-2
+// Triangles. This is exact for convex input (Polygon's required shape;
+// see Validate) and is what callers needing a Triangle-only BVH -- or
+// Polygon.SignedDistance above -- use instead of a dedicated convex
+// polygon intersection routine.
+func (p Polygon) Triangulate() []Triangle {
+	triangles := make([]Triangle, 0, len(p.Vertices)-2)
+	for i := 1; i+1 < len(p.Vertices); i++ {
+		triangles = append(triangles, Triangle{P0: p.Vertices[0], P1: p.Vertices[i], P2: p.Vertices[i+1]})
+	}
+	return triangles
+}
+
+func init() {
+	RegisterInterfaceType(Polygon{})
+}
@@ -0,0 +1,131 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import (
+	"testing"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
+)
+
+// square returns a Polygon tracing out the same 2x2 square, in the XZ
+// plane facing +Y, that TestQuadCollide's Quad covers -- used to check
+// Polygon's plane/edge test agrees with Quad.Collide (which now delegates
+// to it) on a case that's just Quad with an explicit vertex list.
+func square() Polygon {
+	return Polygon{
+		Vertices: []r3.Point{
+			{X: -1, Y: 0, Z: -1},
+			{X: -1, Y: 0, Z: 1},
+			{X: 1, Y: 0, Z: 1},
+			{X: 1, Y: 0, Z: -1},
+		},
+		Normal: r3.Vec{Y: 1},
+	}
+}
+
+func TestPolygonValidate(t *testing.T) {
+	if err := square().Validate(); err != nil {
+		t.Errorf("Validate() = %v, want nil", err)
+	}
+
+	tooFew := Polygon{Vertices: []r3.Point{{}, {X: 1}}, Normal: r3.Vec{Y: 1}}
+	if err := tooFew.Validate(); err == nil {
+		t.Error("Validate() with 2 Vertices = nil, want an error")
+	}
+
+	nonPlanar := square()
+	nonPlanar.Vertices[2].Y = 5
+	if err := nonPlanar.Validate(); err == nil {
+		t.Error("Validate() with a non-planar vertex = nil, want an error")
+	}
+
+	clockwise := square()
+	clockwise.Vertices[1], clockwise.Vertices[3] = clockwise.Vertices[3], clockwise.Vertices[1]
+	if err := clockwise.Validate(); err == nil {
+		t.Error("Validate() with reversed winding = nil, want an error")
+	}
+
+	nonConvex := Polygon{
+		Vertices: []r3.Point{
+			{X: -1, Y: 0, Z: -1},
+			{X: -1, Y: 0, Z: 1},
+			{X: 0, Y: 0, Z: 0}, // Reflex vertex: dents the square inward.
+			{X: 1, Y: 0, Z: 1},
+			{X: 1, Y: 0, Z: -1},
+		},
+		Normal: r3.Vec{Y: 1},
+	}
+	if err := nonConvex.Validate(); err == nil {
+		t.Error("Validate() with a reflex vertex = nil, want an error")
+	}
+}
+
+func TestPolygonCollide(t *testing.T) {
+	poly := square()
+
+	hit, c := poly.Collide(ray{origin: r3.Point{Y: -1}, direction: r3.Vec{Y: 1}}, eps, 1000)
+	if !hit {
+		t.Fatal("Collide() through the center = false, want true")
+	}
+	if want := (r3.Point{}); !c.at.IsClose(want, eps) {
+		t.Errorf("Collide() hit point = %v, want %v", c.at, want)
+	}
+	if want := (r3.Vec{Y: 1}); !c.normal.IsClose(want, eps) {
+		t.Errorf("Collide() normal = %v, want %v", c.normal, want)
+	}
+
+	if hit, _ := poly.Collide(ray{origin: r3.Point{X: 2, Y: -1}, direction: r3.Vec{Y: 1}}, eps, 1000); hit {
+		t.Error("Collide() outside an edge = true, want false")
+	}
+
+	if hit, _ := poly.Collide(ray{origin: r3.Point{Y: -1}, direction: r3.Vec{X: 1}}, eps, 1000); hit {
+		t.Error("Collide() parallel to the plane = true, want false")
+	}
+}
+
+// TestPolygonTriangulateCoversSameArea verifies Triangulate's fan covers
+// exactly the same point set as Collide's edge test, by firing rays at
+// each fan triangle's centroid and checking both agree on the hit point.
+func TestPolygonTriangulateCoversSameArea(t *testing.T) {
+	poly := square()
+	triangles := poly.Triangulate()
+	if want := len(poly.Vertices) - 2; len(triangles) != want {
+		t.Fatalf("Triangulate() returned %d triangles, want %d", len(triangles), want)
+	}
+	for i, tri := range triangles {
+		centroid := r3.Point{
+			X: (tri.P0.X + tri.P1.X + tri.P2.X) / 3,
+			Y: (tri.P0.Y + tri.P1.Y + tri.P2.Y) / 3,
+			Z: (tri.P0.Z + tri.P1.Z + tri.P2.Z) / 3,
+		}
+		r := ray{origin: centroid.Add(r3.Vec{Y: -1}), direction: r3.Vec{Y: 1}}
+		hitPoly, cPoly := poly.Collide(r, eps, 1000)
+		hitTri, cTri := tri.Collide(r, eps, 1000)
+		if !hitPoly || !hitTri {
+			t.Fatalf("triangle %d centroid: Polygon hit=%v Triangle hit=%v, want both true", i, hitPoly, hitTri)
+		}
+		if !cPoly.at.IsClose(cTri.at, eps) {
+			t.Errorf("triangle %d centroid: Polygon hit %v, Triangle hit %v", i, cPoly.at, cTri.at)
+		}
+	}
+}
+
+func TestPolygonBounds(t *testing.T) {
+	got := square().Bounds()
+	want := AABB{Min: r3.Point{X: -1, Y: 0, Z: -1}, Max: r3.Point{X: 1, Y: 0, Z: 1}}
+	if got != want {
+		t.Errorf("Bounds() = %v, want %v", got, want)
+	}
+}
+
+func TestPolygonSignedDistanceIsNonNegative(t *testing.T) {
+	poly := square()
+	for _, p := range []r3.Point{{}, {X: 5}, {Y: 3}, {X: -2, Y: -2, Z: -2}} {
+		if d := poly.SignedDistance(p); d < 0 {
+			t.Errorf("SignedDistance(%v) = %v, want >= 0", p, d)
+		}
+	}
+	if d := poly.SignedDistance(r3.Point{}); d != 0 {
+		t.Errorf("SignedDistance(center) = %v, want 0", d)
+	}
+}
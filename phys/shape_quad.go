@@ -35,120 +35,98 @@ func (q Quad) Validate() error {
 	return nil
 }
 
-// Collide checks for an intersection between a ray and the plane by checking collisions with the two triangles.
-func (q Quad) Collide(r ray, tmin, tmax Distance) (bool, collision) {
+// SignedDistance returns the distance from p to the closest point on the
+// finite rectangle. A Quad has no thickness and so no interior; the
+// returned distance is always non-negative.
+func (q Quad) SignedDistance(p r3.Point) Distance {
 	normal := q.Normal.Unit()
 
-	// Compute two orthogonal vectors (u and v) in the plane.
 	var arbitrary r3.Vec
 	if math.Abs(normal.X) < 0.9 {
 		arbitrary = r3.Vec{X: 1, Y: 0, Z: 0}
 	} else {
 		arbitrary = r3.Vec{X: 0, Y: 1, Z: 0}
 	}
+	uAxis := normal.Cross(arbitrary).Unit()
+	vAxis := normal.Cross(uAxis).Unit()
 
-	// Compute orthogonal vectors u and v.
-	u := normal.Cross(arbitrary).Unit()
-	v := normal.Cross(u).Unit()
-
-	// Scale u and v by half the width and height.
-	halfWidth := float64(q.Width) / 2
-	halfHeight := float64(q.Height) / 2
-	u = u.Muls(halfWidth)
-	v = v.Muls(halfHeight)
-
-	// Compute the four corner points of the plane.
-	p0 := q.Center.Subv(u).Subv(v) // Bottom-left corner.
-	p1 := q.Center.Add(u).Subv(v)  // Bottom-right corner.
-	p2 := q.Center.Add(u).Add(v)   // Top-right corner.
-	p3 := q.Center.Subv(u).Add(v)  // Top-left corner.
-
-	// Create two triangles from the corner points.
-	tri1 := Triangle{P0: p0, P1: p1, P2: p2}
-	tri2 := Triangle{P0: p0, P1: p2, P2: p3}
-
-	// Check for collisions with the two triangles.
-	hit1, c1 := tri1.Collide(r, tmin, tmax)
-	hit2, c2 := tri2.Collide(r, tmin, tmax)
-
-	var hit bool
-	var c collision
-
-	if hit1 && (!hit2 || c1.t < c2.t) {
-		hit = true
-		c = c1
-	} else if hit2 {
-		hit = true
-		c = c2
-	}
-
-	if hit {
-		// Compute UV coordinates based on the hit point.
-		// Map the collision point back to local plane coordinates.
-
-		// Set local origin to p1 to align UV (0,0) at p1
-		localOrigin := p1
-		localU := p2.Sub(p1) // Vector along U axis (Width)
-		localV := p0.Sub(p1) // Vector along V axis (Height)
-
-		// Compute local coordinates (s, t)
-		hitPoint := c.at.Sub(localOrigin)
-		uCoord := hitPoint.Dot(localU) / localU.Dot(localU)
-		vCoord := hitPoint.Dot(localV) / localV.Dot(localV)
-
-		// Clamp UV coordinates to [0,1] to handle floating-point inaccuracies
-		uCoord = math.Max(0, math.Min(1, uCoord))
-		vCoord = math.Max(0, math.Min(1, vCoord))
-
-		// SCOTT TODO IS THIS RIGHT?
-		uCoord = 1 - uCoord
-		vCoord = 1 - vCoord
-
-		c.uv = r2.Point{X: uCoord, Y: vCoord}
-		c.normal = normal // Ensure normal is set correctly
-
-		// Debugging Statements
-		// fmt.Printf("Hit Point: %+v, UV: %+v\n", c.at, c.uv)
-	}
+	rel := p.Sub(q.Center)
+	localU := rel.Dot(uAxis)
+	localV := rel.Dot(vAxis)
+	localN := rel.Dot(normal)
 
-	return hit, c
+	dx := math.Max(math.Abs(localU)-float64(q.Width)/2, 0)
+	dy := math.Max(math.Abs(localV)-float64(q.Height)/2, 0)
+	return Distance(math.Sqrt(dx*dx + dy*dy + localN*localN))
 }
 
-// Bounds computes the axis-aligned bounding box of the plane.
-func (q Quad) Bounds() AABB {
+// corners returns q's four corner points, counter-clockwise starting at
+// the bottom-left when viewed from the side Normal points to, and the
+// half-scaled in-plane axes (u along Width, v along Height) used to
+// derive them.
+func (q Quad) corners() (p0, p1, p2, p3 r3.Point, u, v r3.Vec) {
 	normal := q.Normal.Unit()
+
 	// Compute two orthogonal vectors (u and v) in the plane.
-	// Choose an arbitrary vector that is not parallel to the normal.
 	var arbitrary r3.Vec
 	if math.Abs(normal.X) < 0.9 {
 		arbitrary = r3.Vec{X: 1, Y: 0, Z: 0}
 	} else {
 		arbitrary = r3.Vec{X: 0, Y: 1, Z: 0}
 	}
+
 	// Compute orthogonal vectors u and v.
-	u := normal.Cross(arbitrary).Unit()
-	v := normal.Cross(u).Unit()
+	u = normal.Cross(arbitrary).Unit()
+	v = normal.Cross(u).Unit()
+
 	// Scale u and v by half the width and height.
-	halfWidth := float64(q.Width) / 2
-	halfHeight := float64(q.Height) / 2
-	u = u.Muls(halfWidth)
-	v = v.Muls(halfHeight)
-	// Compute the four corner points of the plane.
-	p0 := q.Center.Subv(u).Subv(v) // Bottom-left corner.
-	p1 := q.Center.Add(u).Subv(v)  // Bottom-right corner.
-	p2 := q.Center.Add(u).Add(v)   // Top-right corner.
-	p3 := q.Center.Subv(u).Add(v)  // Top-left corner.
-	// Compute bounds from the four corner points.
-	minX := math.Min(math.Min(p0.X, p1.X), math.Min(p2.X, p3.X))
-	minY := math.Min(math.Min(p0.Y, p1.Y), math.Min(p2.Y, p3.Y))
-	minZ := math.Min(math.Min(p0.Z, p1.Z), math.Min(p2.Z, p3.Z))
-	maxX := math.Max(math.Max(p0.X, p1.X), math.Max(p2.X, p3.X))
-	maxY := math.Max(math.Max(p0.Y, p1.Y), math.Max(p2.Y, p3.Y))
-	maxZ := math.Max(math.Max(p0.Z, p1.Z), math.Max(p2.Z, p3.Z))
-	return AABB{
-		Min: r3.Point{X: minX, Y: minY, Z: minZ},
-		Max: r3.Point{X: maxX, Y: maxY, Z: maxZ},
+	u = u.Muls(float64(q.Width) / 2)
+	v = v.Muls(float64(q.Height) / 2)
+
+	p0 = q.Center.Subv(u).Subv(v) // Bottom-left corner.
+	p1 = q.Center.Add(u).Subv(v)  // Bottom-right corner.
+	p2 = q.Center.Add(u).Add(v)   // Top-right corner.
+	p3 = q.Center.Subv(u).Add(v)  // Top-left corner.
+	return p0, p1, p2, p3, u, v
+}
+
+// Collide delegates the plane intersection and inside test to Polygon
+// (built from q's four corners), then maps the hit point into q's own
+// normalized [0,1] UV space using Width/Height, rather than Polygon's raw
+// planar coordinates.
+func (q Quad) Collide(r ray, tmin, tmax Distance) (bool, collision) {
+	p0, p1, p2, p3, u, v := q.corners()
+	hit, c := Polygon{Vertices: []r3.Point{p0, p1, p2, p3}, Normal: q.Normal.Unit()}.Collide(r, tmin, tmax)
+	if !hit {
+		return false, collision{}
 	}
+
+	// Map the collision point back to local plane coordinates, with UV
+	// (0,0) at p2 so that a ray hitting p2 yields (0,0), p1 yields (1,0),
+	// and p3 yields (0,1).
+	localOrigin := p2
+	localU := p1.Sub(p2) // uCoord's basis vector; runs along v, the Height axis.
+	localV := p3.Sub(p2) // vCoord's basis vector; runs along u, the Width axis.
+
+	// Compute local coordinates (s, t)
+	hitPoint := c.at.Sub(localOrigin)
+	uCoord := hitPoint.Dot(localU) / localU.Dot(localU)
+	vCoord := hitPoint.Dot(localV) / localV.Dot(localV)
+
+	// Clamp UV coordinates to [0,1] to handle floating-point inaccuracies
+	uCoord = math.Max(0, math.Min(1, uCoord))
+	vCoord = math.Max(0, math.Min(1, vCoord))
+
+	c.uv = r2.Point{X: uCoord, Y: vCoord}
+	c.tangent = u.Unit()
+	c.bitangent = v.Unit()
+	return true, c
+}
+
+// Bounds computes the axis-aligned bounding box of the plane.
+func (q Quad) Bounds() AABB {
+	p0, p1, p2, p3, _, _ := q.corners()
+	return Polygon{Vertices: []r3.Point{p0, p1, p2, p3}, Normal: q.Normal.Unit()}.Bounds()
 }
 
 func init() {
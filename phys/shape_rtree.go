@@ -0,0 +1,600 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import (
+	"container/heap"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
+)
+
+// RTree is an R-tree acceleration structure: an alternative to BVH with
+// the same Shape interface, so either can be dropped into a Node without
+// the rest of the scene caring which one it is. Unlike BVH's binary
+// Left/Right split, an RTree node holds up to NodeCap entries -- either
+// child *RTree nodes, or, at a leaf (Leaf == true), the scene's actual
+// primitives -- and entries' bounding boxes are allowed to overlap.
+//
+// Build a static tree in one shot with NewRTreeSTR; for a scene that adds
+// and removes geometry at runtime, build an empty root with &RTree{NodeCap: m}
+// and call Insert/Delete as the scene changes, the same incremental role
+// BVH.Update/Refit play for a rebuilt-from-scratch NewBVH. Prefer RTree
+// over BVH for long, thin, mutually overlapping geometry (R-tree's
+// overlapping entries waste less space there than BVH's disjoint split)
+// and for scenes edited more often than rendered; prefer BVH for static,
+// roughly uniform scenes, where its tighter disjoint partition traverses
+// faster. See BenchmarkRTreeVsBVHLongThinOverlapping and BenchmarkRTreeVsBVHUniform.
+type RTree struct {
+	// Entries holds this node's children: other *RTree nodes when
+	// Leaf is false, or the scene's own Shapes when Leaf is true.
+	Entries []Shape
+
+	// Leaf reports whether Entries holds primitives (true) or child
+	// *RTree nodes (false).
+	Leaf bool
+
+	// NodeCap is the maximum number of Entries before Insert splits
+	// this node (M in the classic R-tree literature). NewRTreeSTR's
+	// bulk load packs leaves to exactly this size; Insert enforces it
+	// going forward.
+	NodeCap int
+
+	bounds AABB
+}
+
+// Ensure RTree implements the Shape interface.
+var _ Shape = (*RTree)(nil)
+
+// minEntries is the quadratic split/delete underflow threshold, the
+// classic R-tree choice of ceil(NodeCap/2): a group below this is
+// considered too sparse, which split's PickNext step never lets happen.
+func (t *RTree) minEntries() int {
+	m := (t.NodeCap + 1) / 2
+	if m < 1 {
+		return 1
+	}
+	return m
+}
+
+// Bounds returns t's cached bounding box, the union of all of Entries'.
+func (t *RTree) Bounds() AABB {
+	return t.bounds
+}
+
+// Validate checks that t's Entries are non-empty, recursively valid, and
+// that Leaf correctly describes whether they're primitives or children.
+func (t *RTree) Validate() error {
+	if len(t.Entries) == 0 {
+		return fmt.Errorf("RTree node must not be empty")
+	}
+	for i, e := range t.Entries {
+		if e == nil {
+			return fmt.Errorf("RTree entry %d is nil", i)
+		}
+		if _, ok := e.(*RTree); ok == t.Leaf {
+			return fmt.Errorf("RTree entry %d is a %T, inconsistent with Leaf=%v", i, e, t.Leaf)
+		}
+		if err := e.Validate(); err != nil {
+			return fmt.Errorf("RTree entry %d is invalid: %v", i, err)
+		}
+	}
+	return nil
+}
+
+// SignedDistance returns the signed distance to the closest of t's
+// entries, mirroring BVHLeaf.SignedDistance: an RTree has no SAH-style
+// pruning structure for this query, so it simply checks every entry
+// recursively.
+func (t *RTree) SignedDistance(p r3.Point) Distance {
+	best := Distance(math.Inf(1))
+	bestAbs := math.Inf(1)
+	for _, e := range t.Entries {
+		d := e.SignedDistance(p)
+		if ad := math.Abs(float64(d)); ad < bestAbs {
+			bestAbs = ad
+			best = d
+		}
+	}
+	return best
+}
+
+// aabbRayT reports whether r hits b within [tmin, tmax] and, if so, the
+// entry distance along r at which it does -- the same slab test as
+// AABB.hit, but returning the near t a priority queue can order
+// children by, instead of just a bool.
+func aabbRayT(b AABB, r ray, tmin, tmax Distance) (bool, Distance) {
+	near, far := tmin, tmax
+	for axis := 0; axis < 3; axis++ {
+		invD := 1.0 / r.direction.Get(axis)
+		t0 := (b.Min.Get(axis) - r.origin.Get(axis)) * invD
+		t1 := (b.Max.Get(axis) - r.origin.Get(axis)) * invD
+		if invD < 0.0 {
+			t0, t1 = t1, t0
+		}
+		near = Distance(math.Max(float64(t0), float64(near)))
+		far = Distance(math.Min(float64(t1), float64(far)))
+		if far <= near {
+			return false, 0
+		}
+	}
+	return true, near
+}
+
+// rtreeQueueItem is one pending entry in Collide's traversal queue.
+type rtreeQueueItem struct {
+	entry Shape
+	t     Distance // Near distance of entry's bounds along the ray; the queue's sort key.
+}
+
+// rtreeQueue is a container/heap min-heap of rtreeQueueItem ordered by t,
+// so Collide's pop-nearest-first traversal costs O(log n) per operation
+// instead of re-sorting the whole pending list on every pop.
+type rtreeQueue []rtreeQueueItem
+
+func (q rtreeQueue) Len() int           { return len(q) }
+func (q rtreeQueue) Less(i, j int) bool { return q[i].t < q[j].t }
+func (q rtreeQueue) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+func (q *rtreeQueue) Push(x any)        { *q = append(*q, x.(rtreeQueueItem)) }
+func (q *rtreeQueue) Pop() any {
+	old := *q
+	n := len(old)
+	item := old[n-1]
+	*q = old[:n-1]
+	return item
+}
+
+// Collide descends t in nearest-bounds-first order via a distance-ordered
+// priority queue, so the first leaf primitive hit is usually found with
+// far fewer box tests than a depth-first walk, and any queued entry whose
+// near-t is already past the closest hit found so far is skipped without
+// testing its bounds.
+func (t *RTree) Collide(r ray, tmin, tmax Distance) (bool, collision) {
+	if ok, _ := aabbRayT(t.bounds, r, tmin, tmax); !ok {
+		return false, collision{}
+	}
+
+	queue := &rtreeQueue{{entry: t, t: tmin}}
+	hitAnything := false
+	var best collision
+	bestT := tmax
+
+	for queue.Len() > 0 {
+		item := heap.Pop(queue).(rtreeQueueItem)
+		if item.t >= bestT {
+			continue
+		}
+
+		if node, ok := item.entry.(*RTree); ok {
+			for _, child := range node.Entries {
+				if ok, childT := aabbRayT(child.Bounds(), r, tmin, bestT); ok {
+					heap.Push(queue, rtreeQueueItem{entry: child, t: childT})
+				}
+			}
+			continue
+		}
+
+		if hit, coll := item.entry.Collide(r, tmin, bestT); hit {
+			hitAnything = true
+			bestT = coll.t
+			best = coll
+		}
+	}
+	return hitAnything, best
+}
+
+// boundsOf returns the union of entries' Bounds, or the zero AABB for an
+// empty slice.
+func boundsOf(entries []Shape) AABB {
+	if len(entries) == 0 {
+		return AABB{}
+	}
+	b := entries[0].Bounds()
+	for _, e := range entries[1:] {
+		b = b.Union(e.Bounds())
+	}
+	return b
+}
+
+// enlargement returns how much union(b, add) grows b's area.
+func enlargement(b, add AABB) float64 {
+	return b.Union(add).surfaceArea() - b.surfaceArea()
+}
+
+// overlap returns the surface area of the intersection of a and b, or 0
+// if they don't overlap.
+func overlap(a, b AABB) float64 {
+	dx := math.Min(a.Max.X, b.Max.X) - math.Max(a.Min.X, b.Min.X)
+	dy := math.Min(a.Max.Y, b.Max.Y) - math.Max(a.Min.Y, b.Min.Y)
+	dz := math.Min(a.Max.Z, b.Max.Z) - math.Max(a.Min.Z, b.Min.Z)
+	if dx <= 0 || dy <= 0 || dz <= 0 {
+		return 0
+	}
+	return 2 * (dx*dy + dy*dz + dz*dx)
+}
+
+// chooseSubtree is the R*-tree ChooseSubtree algorithm: at a node one
+// level above the leaves, pick the child whose bounds would need to grow
+// least in total overlap with its siblings to admit bounds (breaking ties
+// by area enlargement, then by raw area); at any node further from the
+// leaves, skip the overlap term and minimize area enlargement directly,
+// since overlap between grandchildren doesn't bound query cost as
+// tightly as overlap between the leaves themselves.
+func (t *RTree) chooseSubtree(bounds AABB) int {
+	children := t.Entries
+	atLeafLevel := children[0].(*RTree).Leaf
+
+	best := -1
+	var bestOverlapDelta, bestAreaDelta, bestArea float64
+
+	for i, c := range children {
+		child := c.(*RTree)
+		areaDelta := enlargement(child.bounds, bounds)
+
+		var overlapDelta float64
+		if atLeafLevel {
+			grown := child.bounds.Union(bounds)
+			before, after := 0.0, 0.0
+			for j, o := range children {
+				if j == i {
+					continue
+				}
+				other := o.(*RTree).bounds
+				before += overlap(child.bounds, other)
+				after += overlap(grown, other)
+			}
+			overlapDelta = after - before
+		}
+
+		area := child.bounds.surfaceArea()
+		better := best == -1
+		if !better {
+			if atLeafLevel && overlapDelta != bestOverlapDelta {
+				better = overlapDelta < bestOverlapDelta
+			} else if areaDelta != bestAreaDelta {
+				better = areaDelta < bestAreaDelta
+			} else {
+				better = area < bestArea
+			}
+		}
+		if better {
+			best, bestOverlapDelta, bestAreaDelta, bestArea = i, overlapDelta, areaDelta, area
+		}
+	}
+	return best
+}
+
+// Insert adds s to the tree rooted at t. If a node overflows NodeCap, it
+// is split via quadraticSplit; if the overflow reaches the root, t grows
+// the tree by one level in place (wrapping its own current Entries/Leaf
+// into a new child alongside the split-off sibling) so that callers who
+// hold a *RTree pointer to the root never need to be told it changed.
+func (t *RTree) Insert(s Shape) {
+	if t.NodeCap == 0 {
+		t.NodeCap = 8
+	}
+	if len(t.Entries) == 0 && t.Leaf == false && t.bounds == (AABB{}) {
+		t.Leaf = true
+	}
+	sibling := t.insert(s)
+	if sibling == nil {
+		return
+	}
+	left := &RTree{Entries: t.Entries, Leaf: t.Leaf, NodeCap: t.NodeCap, bounds: boundsOf(t.Entries)}
+	t.Entries = []Shape{left, sibling}
+	t.Leaf = false
+	t.bounds = left.bounds.Union(sibling.bounds)
+}
+
+// insert adds s to the subtree rooted at t, returning a newly split-off
+// sibling node if t overflowed NodeCap, or nil otherwise.
+func (t *RTree) insert(s Shape) *RTree {
+	if t.Leaf {
+		if len(t.Entries) == 0 {
+			t.bounds = s.Bounds()
+		} else {
+			t.bounds = t.bounds.Union(s.Bounds())
+		}
+		t.Entries = append(t.Entries, s)
+		if len(t.Entries) > t.NodeCap {
+			return t.quadraticSplit()
+		}
+		return nil
+	}
+
+	i := t.chooseSubtree(s.Bounds())
+	child := t.Entries[i].(*RTree)
+	sibling := child.insert(s)
+	t.bounds = t.bounds.Union(s.Bounds())
+	if sibling == nil {
+		return nil
+	}
+	t.Entries = append(t.Entries, sibling)
+	if len(t.Entries) > t.NodeCap {
+		return t.quadraticSplit()
+	}
+	return nil
+}
+
+// quadraticSplit partitions t's overflowing Entries into two groups using
+// Guttman's quadratic-cost algorithm: PickSeeds chooses the pair that
+// would waste the most area sharing a node, then each remaining entry is
+// assigned, one at a time, to whichever group's area grows least to admit
+// it -- except once a group reaches minEntries short of holding every
+// remaining entry, in which case the rest are dumped into it unscored, so
+// neither group ends up under minEntries. t keeps the first group in
+// place (same Leaf, same NodeCap) and quadraticSplit returns a new *RTree
+// holding the second.
+func (t *RTree) quadraticSplit() *RTree {
+	entries := t.Entries
+
+	// PickSeeds: the pair with the most "dead space" if merged.
+	seedI, seedJ := 0, 1
+	worst := math.Inf(-1)
+	for i := 0; i < len(entries); i++ {
+		for j := i + 1; j < len(entries); j++ {
+			bi, bj := entries[i].Bounds(), entries[j].Bounds()
+			d := bi.Union(bj).surfaceArea() - bi.surfaceArea() - bj.surfaceArea()
+			if d > worst {
+				worst, seedI, seedJ = d, i, j
+			}
+		}
+	}
+
+	groupA := []Shape{entries[seedI]}
+	groupB := []Shape{entries[seedJ]}
+	boundsA := entries[seedI].Bounds()
+	boundsB := entries[seedJ].Bounds()
+
+	remaining := make([]Shape, 0, len(entries)-2)
+	for i, e := range entries {
+		if i != seedI && i != seedJ {
+			remaining = append(remaining, e)
+		}
+	}
+
+	min := t.minEntries()
+	for len(remaining) > 0 {
+		if len(groupA)+len(remaining) <= min {
+			groupA = append(groupA, remaining...)
+			for _, e := range remaining {
+				boundsA = boundsA.Union(e.Bounds())
+			}
+			remaining = nil
+			break
+		}
+		if len(groupB)+len(remaining) <= min {
+			groupB = append(groupB, remaining...)
+			for _, e := range remaining {
+				boundsB = boundsB.Union(e.Bounds())
+			}
+			remaining = nil
+			break
+		}
+
+		// PickNext: the entry with the strongest preference for one
+		// group over the other (biggest difference in enlargement),
+		// assigned to whichever group it prefers.
+		bestIdx, bestPreferA := 0, true
+		bestDiff := math.Inf(-1)
+		for i, e := range remaining {
+			dA := enlargement(boundsA, e.Bounds())
+			dB := enlargement(boundsB, e.Bounds())
+			diff := math.Abs(dA - dB)
+			if diff > bestDiff {
+				bestDiff, bestIdx, bestPreferA = diff, i, dA < dB
+			}
+		}
+		e := remaining[bestIdx]
+		remaining = append(remaining[:bestIdx], remaining[bestIdx+1:]...)
+		if bestPreferA {
+			groupA = append(groupA, e)
+			boundsA = boundsA.Union(e.Bounds())
+		} else {
+			groupB = append(groupB, e)
+			boundsB = boundsB.Union(e.Bounds())
+		}
+	}
+
+	t.Entries = groupA
+	t.bounds = boundsA
+	return &RTree{Entries: groupB, Leaf: t.Leaf, NodeCap: t.NodeCap, bounds: boundsB}
+}
+
+// Delete removes s from the tree rooted at t, reporting whether it was
+// found. It shrinks ancestor bounds back down along the path to s and
+// drops any node left empty by the removal, but -- unlike a full R-tree
+// delete -- does not force-reinsert the orphaned contents of a node that
+// merely underflows minEntries; an RTree with deletions therefore stays
+// correct but can accumulate sparser-than-ideal nodes over many Delete
+// calls; NewRTreeSTR rebuild periodically if that matters for a
+// long-running scene editor. The same asymmetry exists between BVH's
+// Refit (bounds only) and a from-scratch NewBVH rebuild.
+func (t *RTree) Delete(s Shape) bool {
+	found := t.delete(s)
+	if found {
+		t.bounds = boundsOf(t.Entries)
+	}
+	return found
+}
+
+// delete recursively searches t for s, removing it and reports whether
+// it was found. An internal child left with no Entries by the removal is
+// itself dropped from its parent.
+func (t *RTree) delete(s Shape) bool {
+	if t.Leaf {
+		for i, e := range t.Entries {
+			if e == s {
+				t.Entries = append(t.Entries[:i], t.Entries[i+1:]...)
+				return true
+			}
+		}
+		return false
+	}
+	for i, e := range t.Entries {
+		child := e.(*RTree)
+		if !child.bounds.intersects(s.Bounds()) {
+			continue
+		}
+		if !child.delete(s) {
+			continue
+		}
+		if len(child.Entries) == 0 {
+			t.Entries = append(t.Entries[:i], t.Entries[i+1:]...)
+		} else {
+			child.bounds = boundsOf(child.Entries)
+		}
+		return true
+	}
+	return false
+}
+
+// NewRTreeSTR bulk-loads shapes into an RTree via Sort-Tile-Recurse: sort
+// the N leaves by centroid X into ceil(sqrt(N/nodeCap)) vertical slices of
+// ceil(sqrt(N/nodeCap))*nodeCap leaves each, sort each slice by centroid Y,
+// and pack consecutive runs of nodeCap leaves into leaf nodes; then repeat
+// the same X/Y tiling one level up on the resulting leaves' own bounds,
+// and so on, until a single root remains. Unlike NewBVH's binary SAH
+// split, this packs nodeCap-wide, typically-overlapping nodes in one
+// O(N log N) pass with no per-split cost evaluation.
+func NewRTreeSTR(shapes []Shape, nodeCap int) *RTree {
+	if nodeCap < 2 {
+		nodeCap = 2
+	}
+	if len(shapes) == 0 {
+		return nil
+	}
+	level := make([]Shape, len(shapes))
+	copy(level, shapes)
+	leaf := true
+	for {
+		nodes := strTile(level, nodeCap, leaf)
+		if len(nodes) == 1 {
+			return nodes[0]
+		}
+		level = make([]Shape, len(nodes))
+		for i, n := range nodes {
+			level[i] = n
+		}
+		leaf = false
+	}
+}
+
+// strTile packs entries into ceil(len(entries)/nodeCap) RTree nodes via
+// one Sort-Tile-Recurse pass: slice by centroid X into
+// ceil(sqrt(numNodes)) vertical strips, sort each strip by centroid Y,
+// then chunk each strip into nodeCap-sized groups.
+func strTile(entries []Shape, nodeCap int, leaf bool) []*RTree {
+	n := len(entries)
+	if n <= nodeCap {
+		return []*RTree{{Entries: append([]Shape(nil), entries...), Leaf: leaf, NodeCap: nodeCap, bounds: boundsOf(entries)}}
+	}
+
+	numNodes := int(math.Ceil(float64(n) / float64(nodeCap)))
+	numStrips := int(math.Ceil(math.Sqrt(float64(numNodes))))
+	if numStrips < 1 {
+		numStrips = 1
+	}
+
+	type centroidEntry struct {
+		shape    Shape
+		centroid r3.Point
+	}
+	ce := make([]centroidEntry, n)
+	for i, e := range entries {
+		ce[i] = centroidEntry{shape: e, centroid: e.Bounds().center()}
+	}
+	sort.Slice(ce, func(i, j int) bool { return ce[i].centroid.X < ce[j].centroid.X })
+
+	stripSize := int(math.Ceil(float64(n) / float64(numStrips)))
+	var nodes []*RTree
+	for i := 0; i < n; i += stripSize {
+		end := i + stripSize
+		if end > n {
+			end = n
+		}
+		strip := ce[i:end]
+		sort.Slice(strip, func(i, j int) bool { return strip[i].centroid.Y < strip[j].centroid.Y })
+
+		for j := 0; j < len(strip); j += nodeCap {
+			jend := j + nodeCap
+			if jend > len(strip) {
+				jend = len(strip)
+			}
+			group := make([]Shape, jend-j)
+			for k := j; k < jend; k++ {
+				group[k-j] = strip[k].shape
+			}
+			nodes = append(nodes, &RTree{Entries: group, Leaf: leaf, NodeCap: nodeCap, bounds: boundsOf(group)})
+		}
+	}
+	return nodes
+}
+
+// Implement custom JSON marshalling for RTree.
+func (t *RTree) MarshalJSON() ([]byte, error) {
+	type RTreeData struct {
+		Type    string            `json:"Type"`
+		Entries []json.RawMessage `json:"Entries"`
+		Leaf    bool              `json:"Leaf"`
+		NodeCap int               `json:"NodeCap"`
+		Bounds  AABB              `json:"Bounds"`
+	}
+	entriesData := make([]json.RawMessage, len(t.Entries))
+	for i, e := range t.Entries {
+		data, err := marshalInterface(e)
+		if err != nil {
+			return nil, err
+		}
+		entriesData[i] = data
+	}
+	return json.Marshal(RTreeData{
+		Type:    "RTree",
+		Entries: entriesData,
+		Leaf:    t.Leaf,
+		NodeCap: t.NodeCap,
+		Bounds:  t.bounds,
+	})
+}
+
+// Implement custom JSON unmarshalling for RTree.
+func (t *RTree) UnmarshalJSON(data []byte) error {
+	type RTreeData struct {
+		Type    string            `json:"Type"`
+		Entries []json.RawMessage `json:"Entries"`
+		Leaf    bool              `json:"Leaf"`
+		NodeCap int               `json:"NodeCap"`
+		Bounds  AABB              `json:"Bounds"`
+	}
+	var temp RTreeData
+	if err := json.Unmarshal(data, &temp); err != nil {
+		return err
+	}
+	if temp.Type != "RTree" {
+		return fmt.Errorf("invalid type: expected RTree, got %s", temp.Type)
+	}
+	entries := make([]Shape, len(temp.Entries))
+	for i, raw := range temp.Entries {
+		shape, err := unmarshalInterface(raw)
+		if err != nil {
+			return err
+		}
+		entries[i] = shape.(Shape)
+	}
+	t.Entries = entries
+	t.Leaf = temp.Leaf
+	t.NodeCap = temp.NodeCap
+	t.bounds = temp.Bounds
+	return nil
+}
+
+// String returns a string representation of the RTree.
+func (t *RTree) String() string {
+	return fmt.Sprintf("RTree{Entries: %d, Leaf: %v, Bounds: %v}", len(t.Entries), t.Leaf, t.bounds)
+}
+
+func init() {
+	RegisterInterfaceType(&RTree{})
+}
@@ -0,0 +1,226 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import (
+	"math"
+	"testing"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
+)
+
+func TestNewRTreeSTREmpty(t *testing.T) {
+	if got := NewRTreeSTR(nil, 8); got != nil {
+		t.Errorf("NewRTreeSTR(nil, 8) = %v, want nil", got)
+	}
+}
+
+func TestNewRTreeSTRValidates(t *testing.T) {
+	shapes := generateRandomShapes(500)
+	tree := NewRTreeSTR(shapes, 8)
+	if err := tree.Validate(); err != nil {
+		t.Fatalf("NewRTreeSTR(shapes, 8).Validate() = %v, want nil", err)
+	}
+}
+
+func TestNewRTreeSTRBoundsContainAllShapes(t *testing.T) {
+	shapes := generateRandomShapes(500)
+	tree := NewRTreeSTR(shapes, 8)
+	bounds := tree.Bounds()
+	for i, shape := range shapes {
+		if bounds.Union(shape.Bounds()) != bounds {
+			t.Errorf("shape %d bounds %v not contained in RTree bounds %v", i, shape.Bounds(), bounds)
+		}
+	}
+}
+
+func TestNewRTreeSTRFindsCollisions(t *testing.T) {
+	shapes := generateRandomShapes(1000)
+	tree := NewRTreeSTR(shapes, 8)
+	bvh := NewBVH(shapes, 0)
+	for _, r := range generateRandomRays(200) {
+		bvhHit, bvhColl := bvh.Collide(r, 0.001, Distance(math.MaxFloat64))
+		rtreeHit, rtreeColl := tree.Collide(r, 0.001, Distance(math.MaxFloat64))
+		if bvhHit != rtreeHit {
+			t.Fatalf("Collide(%+v) hit = %v, BVH hit = %v, want equal", r, rtreeHit, bvhHit)
+		}
+		if bvhHit && math.Abs(float64(bvhColl.t-rtreeColl.t)) > 1e-6 {
+			t.Errorf("Collide(%+v) t = %v, BVH t = %v, want equal", r, rtreeColl.t, bvhColl.t)
+		}
+	}
+}
+
+// TestRTreeInsertFindsCollisions verifies a tree built entry-by-entry via
+// Insert (rather than bulk-loaded by NewRTreeSTR) finds the same
+// collisions as a BVH built from the same shapes.
+func TestRTreeInsertFindsCollisions(t *testing.T) {
+	shapes := generateRandomShapes(300)
+	tree := &RTree{NodeCap: 8}
+	for _, s := range shapes {
+		tree.Insert(s)
+	}
+	if err := tree.Validate(); err != nil {
+		t.Fatalf("Validate() = %v, want nil", err)
+	}
+	bvh := NewBVH(shapes, 0)
+	for _, r := range generateRandomRays(200) {
+		bvhHit, bvhColl := bvh.Collide(r, 0.001, Distance(math.MaxFloat64))
+		rtreeHit, rtreeColl := tree.Collide(r, 0.001, Distance(math.MaxFloat64))
+		if bvhHit != rtreeHit {
+			t.Fatalf("Collide(%+v) hit = %v, BVH hit = %v, want equal", r, rtreeHit, bvhHit)
+		}
+		if bvhHit && math.Abs(float64(bvhColl.t-rtreeColl.t)) > 1e-6 {
+			t.Errorf("Collide(%+v) t = %v, BVH t = %v, want equal", r, rtreeColl.t, bvhColl.t)
+		}
+	}
+}
+
+// TestRTreeInsertBoundsContainAllShapes verifies every Insert grows the
+// root's cached bounds to still contain every inserted shape, including
+// across the in-place root splits Insert performs when NodeCap overflows.
+func TestRTreeInsertBoundsContainAllShapes(t *testing.T) {
+	shapes := generateRandomShapes(500)
+	tree := &RTree{NodeCap: 4}
+	for _, s := range shapes {
+		tree.Insert(s)
+	}
+	bounds := tree.Bounds()
+	for i, shape := range shapes {
+		if bounds.Union(shape.Bounds()) != bounds {
+			t.Errorf("shape %d bounds %v not contained in RTree bounds %v", i, shape.Bounds(), bounds)
+		}
+	}
+}
+
+// TestRTreeDeleteRemovesShape verifies Delete removes a shape such that a
+// ray that only hits that shape no longer reports a collision, and
+// reports false for a shape that isn't present.
+func TestRTreeDeleteRemovesShape(t *testing.T) {
+	tree := &RTree{NodeCap: 4}
+	// Z staggered slightly across the three vertices so the face's AABB
+	// has non-zero thickness on every axis, avoiding the exactly-flat-box
+	// grazing case AABB.hit doesn't resolve for a ray traveling parallel
+	// to a degenerate axis -- see TestQuadCollide/Ray_hits_quad_at_corner
+	// and the equivalent tented-quad fix in shape_morphed_mesh_test.go.
+	isolated := Face{Vertex: [3]Vertex{
+		{Position: r3.Point{X: -1, Y: -1, Z: 99.99}},
+		{Position: r3.Point{X: 1, Y: -1, Z: 100}},
+		{Position: r3.Point{X: 0, Y: 1, Z: 100.01}},
+	}}
+	// Other entries sit far from the probe ray's X/Y corridor (near
+	// X=200) so they can never be what the ray hits, leaving isolated as
+	// the only shape it can possibly find.
+	for i := 0; i < 50; i++ {
+		tree.Insert(Face{Vertex: [3]Vertex{
+			{Position: r3.Point{X: 200 + randFloat(), Y: randFloat()*100 - 50, Z: randFloat()*100 - 50}},
+			{Position: r3.Point{X: 201 + randFloat(), Y: randFloat()*100 - 50, Z: randFloat()*100 - 50}},
+			{Position: r3.Point{X: 200.5 + randFloat(), Y: randFloat()*100 - 50, Z: randFloat()*100 - 50}},
+		}})
+	}
+	tree.Insert(isolated)
+
+	r := ray{origin: r3.Point{X: 0, Y: -0.3, Z: 0}, direction: r3.Vec{X: 0, Y: 0, Z: 1}}
+	hit, coll := tree.Collide(r, 0.001, Distance(math.MaxFloat64))
+	if !hit || math.Abs(float64(coll.t)-100) > 0.01 {
+		t.Fatalf("Collide before Delete = (%v, t=%v), want a hit on isolated near t=100", hit, coll.t)
+	}
+
+	if !tree.Delete(isolated) {
+		t.Fatalf("Delete(isolated) = false, want true")
+	}
+	if hit, _ := tree.Collide(r, 0.001, Distance(math.MaxFloat64)); hit {
+		t.Errorf("Collide after Delete(isolated) = hit, want no hit past the deleted face")
+	}
+	if tree.Delete(isolated) {
+		t.Errorf("Delete(isolated) a second time = true, want false (already removed)")
+	}
+}
+
+// BenchmarkRTreeConstructionSTR benchmarks STR bulk-loading construction;
+// compare against BenchmarkBVHConstructionLarge for the SAH-built BVH.
+func BenchmarkRTreeConstructionSTR(b *testing.B) {
+	shapes := generateRandomShapes(1000000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = NewRTreeSTR(shapes, 8)
+	}
+}
+
+// longThinOverlappingShapes builds n long, thin, mutually overlapping
+// triangles all crossing through a shared central corridor -- a stand-in
+// for the kind of geometry an owl model's individual feather barbs form
+// (many long, narrow, overlapping slivers sharing a bounding region), the
+// case this request's own doc comment names as R-tree's advantage over
+// BVH's disjoint split.
+func longThinOverlappingShapes(n int) []Shape {
+	shapes := make([]Shape, n)
+	for i := 0; i < n; i++ {
+		cx := randFloat()*2 - 1 // Every feather barb crosses near the same corridor.
+		cy := randFloat()*100 - 50
+		cz := randFloat()*100 - 50
+		length := 20 + randFloat()*30
+		shapes[i] = Face{Vertex: [3]Vertex{
+			{Position: r3.Point{X: cx - 0.01, Y: cy - length/2, Z: cz}},
+			{Position: r3.Point{X: cx + 0.01, Y: cy - length/2, Z: cz}},
+			{Position: r3.Point{X: cx, Y: cy + length/2, Z: cz}},
+		}}
+	}
+	return shapes
+}
+
+// BenchmarkRTreeVsBVHLongThinOverlapping compares single-ray traversal of
+// an STR-packed RTree against an SAH BVH over long, thin, mutually
+// overlapping geometry -- the regime this request expects RTree to win,
+// since BVH's disjoint split has to duplicate or badly separate slivers
+// that all cross the same corridor, while RTree's entries are allowed to
+// overlap.
+func BenchmarkRTreeVsBVHLongThinOverlapping(b *testing.B) {
+	shapes := longThinOverlappingShapes(100000)
+	rays := generateRandomRays(1000)
+
+	b.Run("RTree", func(b *testing.B) {
+		tree := NewRTreeSTR(shapes, 8)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			for _, r := range rays {
+				_, _ = tree.Collide(r, 0.001, Distance(math.MaxFloat64))
+			}
+		}
+	})
+	b.Run("BVH", func(b *testing.B) {
+		bvh := NewBVH(shapes, 0)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			for _, r := range rays {
+				_, _ = bvh.Collide(r, 0.001, Distance(math.MaxFloat64))
+			}
+		}
+	})
+}
+
+// BenchmarkRTreeVsBVHUniform compares the same traversal over
+// generateRandomShapes' uniformly scattered, roughly equal-sized
+// triangles -- the regime this request expects BVH to win, since its
+// tighter disjoint partition has no overlap to pay for.
+func BenchmarkRTreeVsBVHUniform(b *testing.B) {
+	shapes := generateRandomShapes(100000)
+	rays := generateRandomRays(1000)
+
+	b.Run("RTree", func(b *testing.B) {
+		tree := NewRTreeSTR(shapes, 8)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			for _, r := range rays {
+				_, _ = tree.Collide(r, 0.001, Distance(math.MaxFloat64))
+			}
+		}
+	})
+	b.Run("BVH", func(b *testing.B) {
+		bvh := NewBVH(shapes, 0)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			for _, r := range rays {
+				_, _ = bvh.Collide(r, 0.001, Distance(math.MaxFloat64))
+			}
+		}
+	})
+}
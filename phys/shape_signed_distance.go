@@ -0,0 +1,168 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import (
+	"container/heap"
+	"math"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
+)
+
+// SignedDistance searches the BVH best-first for the surface closest to p:
+// it repeatedly pops the node whose AABB distanceLowerBound to p is
+// smallest, expanding internal nodes into their children and evaluating
+// leaves exactly, pruning any node whose lower bound already exceeds the
+// closest |distance| found so far. This explores far fewer nodes than a
+// full traversal for the common case of a query point near one small part
+// of a large scene.
+func (b *BVH) SignedDistance(p r3.Point) Distance {
+	if b == nil {
+		return Distance(math.Inf(1))
+	}
+
+	best := Distance(math.Inf(1))
+	bestAbs := math.Inf(1)
+
+	pq := &sdfHeap{{shape: b, bound: b.bounds.distanceLowerBound(p)}}
+	for pq.Len() > 0 {
+		item := heap.Pop(pq).(sdfCandidate)
+		if float64(item.bound) >= bestAbs {
+			break // every remaining candidate's bound is >= item.bound, so none can improve on best.
+		}
+
+		if node, ok := item.shape.(*BVH); ok {
+			heap.Push(pq, sdfCandidate{shape: node.Left, bound: node.Left.Bounds().distanceLowerBound(p)})
+			if node.Right != nil {
+				heap.Push(pq, sdfCandidate{shape: node.Right, bound: node.Right.Bounds().distanceLowerBound(p)})
+			}
+			continue
+		}
+
+		d := item.shape.SignedDistance(p)
+		if ad := math.Abs(float64(d)); ad < bestAbs {
+			bestAbs = ad
+			best = d
+		}
+	}
+	return best
+}
+
+// SignedDistance returns the distance from p to the mesh's surface,
+// negative when p is inside. The nearest point comes from a best-first
+// search of the mesh's BVH (whose Face leaves have no notion of "inside"
+// and so always report a non-negative distance); the sign is decided
+// separately by firing a ray from p and counting how many faces it
+// crosses, inside being an odd number of crossings.
+func (m Mesh) SignedDistance(p r3.Point) Distance {
+	d := m.BVH.SignedDistance(p)
+	if m.insideByRayParity(p) {
+		return -d
+	}
+	return d
+}
+
+// insideByRayParity reports whether p is inside the mesh by casting a ray
+// from p in a fixed, non-axis-aligned direction (chosen to avoid grazing
+// axis-aligned faces and edges) and counting crossings: an odd count means
+// p is enclosed by the mesh's surface.
+func (m Mesh) insideByRayParity(p r3.Point) bool {
+	dir := r3.Vec{X: 0.5773502691896258, Y: 0.5773502691896258, Z: 0.5773502691896258} // 1/sqrt(3) on each axis.
+	r := ray{origin: p, direction: dir}
+	tmin := Distance(eps)
+	crossings := 0
+	for {
+		hit, coll := m.BVH.Collide(r, tmin, Distance(math.MaxFloat64))
+		if !hit {
+			return crossings%2 == 1
+		}
+		crossings++
+		tmin = coll.t + eps
+	}
+}
+
+// SignedDistance transforms p into the shape's local space, evaluates its
+// signed distance there, and scales the result back to world units by the
+// cube root of the transform's volume scale factor. This is exact for
+// uniform scale and an approximation otherwise, since no single scalar can
+// exactly undo a non-uniform scale's effect on distance in every direction.
+func (ts TransformedShape) SignedDistance(p r3.Point) Distance {
+	local := ts.Transform.Inverse().ApplyToPoint(p)
+	d := ts.Shape.SignedDistance(local)
+	volumeScale := math.Abs(ts.Transform.LinearDeterminant())
+	return d * Distance(math.Cbrt(volumeScale))
+}
+
+// closestPointOnTriangle returns the point on triangle abc closest to p,
+// following the region tests in Ericson's Real-Time Collision Detection
+// (ch. 5.1.5): identify which Voronoi region of the triangle (a vertex, an
+// edge, or the face) p's projection falls into, and project accordingly.
+func closestPointOnTriangle(p, a, b, c r3.Point) r3.Point {
+	ab := b.Sub(a)
+	ac := c.Sub(a)
+	ap := p.Sub(a)
+	d1 := ab.Dot(ap)
+	d2 := ac.Dot(ap)
+	if d1 <= 0 && d2 <= 0 {
+		return a // vertex region a
+	}
+
+	bp := p.Sub(b)
+	d3 := ab.Dot(bp)
+	d4 := ac.Dot(bp)
+	if d3 >= 0 && d4 <= d3 {
+		return b // vertex region b
+	}
+
+	vc := d1*d4 - d3*d2
+	if vc <= 0 && d1 >= 0 && d3 <= 0 {
+		v := d1 / (d1 - d3)
+		return a.Add(ab.Muls(v)) // edge region ab
+	}
+
+	cp := p.Sub(c)
+	d5 := ab.Dot(cp)
+	d6 := ac.Dot(cp)
+	if d6 >= 0 && d5 <= d6 {
+		return c // vertex region c
+	}
+
+	vb := d5*d2 - d1*d6
+	if vb <= 0 && d2 >= 0 && d6 <= 0 {
+		w := d2 / (d2 - d6)
+		return a.Add(ac.Muls(w)) // edge region ac
+	}
+
+	va := d3*d6 - d5*d4
+	if va <= 0 && (d4-d3) >= 0 && (d5-d6) >= 0 {
+		w := (d4 - d3) / ((d4 - d3) + (d5 - d6))
+		return b.Add(c.Sub(b).Muls(w)) // edge region bc
+	}
+
+	denom := 1 / (va + vb + vc)
+	v := vb * denom
+	w := vc * denom
+	return a.Add(ab.Muls(v)).Add(ac.Muls(w)) // face region
+}
+
+// sdfCandidate is one entry in a sdfHeap: a shape paired with a lower
+// bound on its distance to the query point.
+type sdfCandidate struct {
+	shape Shape
+	bound Distance
+}
+
+// sdfHeap implements container/heap.Interface, ordering by bound ascending
+// so Pop always returns the most promising unexplored candidate.
+type sdfHeap []sdfCandidate
+
+func (h sdfHeap) Len() int           { return len(h) }
+func (h sdfHeap) Less(i, j int) bool { return h[i].bound < h[j].bound }
+func (h sdfHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *sdfHeap) Push(x any)        { *h = append(*h, x.(sdfCandidate)) }
+func (h *sdfHeap) Pop() any {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	*h = old[:n-1]
+	return item
+}
@@ -0,0 +1,132 @@
+package phys
+
+import (
+	"math"
+	"testing"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
+)
+
+func TestSphereSignedDistance(t *testing.T) {
+	s := Sphere{Center: r3.Point{}, Radius: 2}
+	cases := []struct {
+		p    r3.Point
+		want Distance
+	}{
+		{r3.Point{X: 5}, 3},
+		{r3.Point{}, -2},
+		{r3.Point{X: 2}, 0},
+	}
+	for _, c := range cases {
+		if got := s.SignedDistance(c.p); !isClose(float64(got), float64(c.want), 1e-9) {
+			t.Errorf("SignedDistance(%v) = %v, want %v", c.p, got, c.want)
+		}
+	}
+}
+
+func TestCylinderSignedDistance(t *testing.T) {
+	cyl := Cylinder{Origin: r3.Point{}, Direction: r3.Vec{Y: 1}, Radius: 1, Height: 2}
+	cases := []struct {
+		p    r3.Point
+		want Distance
+	}{
+		{r3.Point{Y: 1}, -1},      // on axis, mid-height: 1 inside the radius, flush with neither cap.
+		{r3.Point{X: 2, Y: 1}, 1}, // outside the lateral surface, mid-height.
+		{r3.Point{Y: -1}, 1},      // straight below the base cap.
+		{r3.Point{Y: 3}, 1},       // straight above the top cap.
+	}
+	for _, c := range cases {
+		if got := cyl.SignedDistance(c.p); !isClose(float64(got), float64(c.want), 1e-9) {
+			t.Errorf("SignedDistance(%v) = %v, want %v", c.p, got, c.want)
+		}
+	}
+}
+
+func TestQuadSignedDistanceIsNonNegative(t *testing.T) {
+	q := Quad{Center: r3.Point{}, Normal: r3.Vec{Z: 1}, Width: 2, Height: 2}
+	if got := q.SignedDistance(r3.Point{}); !isClose(float64(got), 0, 1e-9) {
+		t.Errorf("SignedDistance(center) = %v, want 0", got)
+	}
+	if got := q.SignedDistance(r3.Point{Z: 5}); !isClose(float64(got), 5, 1e-9) {
+		t.Errorf("SignedDistance(5 above center) = %v, want 5", got)
+	}
+	if got := q.SignedDistance(r3.Point{X: -10}); got < 0 {
+		t.Errorf("SignedDistance(%v) = %v, want non-negative (Quad has no interior)", r3.Point{X: -10}, got)
+	}
+}
+
+func TestTriangleSignedDistanceIsNonNegative(t *testing.T) {
+	tri := Triangle{P0: r3.Point{}, P1: r3.Point{X: 1}, P2: r3.Point{Y: 1}}
+	if got := tri.SignedDistance(r3.Point{X: 0.25, Y: 0.25}); !isClose(float64(got), 0, 1e-9) {
+		t.Errorf("SignedDistance(point on triangle) = %v, want 0", got)
+	}
+	if got := tri.SignedDistance(r3.Point{Z: 3}); !isClose(float64(got), 3, 1e-9) {
+		t.Errorf("SignedDistance(3 above P0) = %v, want 3", got)
+	}
+}
+
+func TestBVHSignedDistanceMatchesDirectScan(t *testing.T) {
+	shapes := []Shape{
+		Sphere{Center: r3.Point{X: -20}, Radius: 1},
+		Sphere{Center: r3.Point{X: 20}, Radius: 1},
+		Sphere{Center: r3.Point{Y: 20}, Radius: 1},
+	}
+	bvh := NewBVH(shapes, 0)
+
+	for _, p := range []r3.Point{{X: -19}, {X: 19, Y: 0.5}, {Y: 21}, {}} {
+		want := Distance(math.Inf(1))
+		for _, s := range shapes {
+			if d := s.SignedDistance(p); math.Abs(float64(d)) < math.Abs(float64(want)) {
+				want = d
+			}
+		}
+		if got := bvh.SignedDistance(p); !isClose(float64(got), float64(want), 1e-9) {
+			t.Errorf("BVH.SignedDistance(%v) = %v, want %v (matching a direct scan)", p, got, want)
+		}
+	}
+}
+
+func TestMeshSignedDistanceNegativeInside(t *testing.T) {
+	// A unit cube made of 12 triangles (2 per face), axis-aligned at the origin.
+	mesh := unitCubeMesh(t)
+
+	if d := mesh.SignedDistance(r3.Point{X: 0.5, Y: 0.5, Z: 0.5}); d >= 0 {
+		t.Errorf("SignedDistance(cube center) = %v, want negative", d)
+	}
+	if d := mesh.SignedDistance(r3.Point{X: 2, Y: 0.5, Z: 0.5}); d <= 0 {
+		t.Errorf("SignedDistance(outside cube) = %v, want positive", d)
+	}
+}
+
+// unitCubeMesh builds a closed, axis-aligned unit cube from [0,1]^3 as a
+// 12-triangle Mesh, for testing Mesh.SignedDistance's inside/outside test.
+func unitCubeMesh(t *testing.T) *Mesh {
+	t.Helper()
+	v := func(x, y, z float64) Vertex { return Vertex{Position: r3.Point{X: x, Y: y, Z: z}} }
+	quad := func(a, b, c, d Vertex) [2]Face {
+		return [2]Face{{Vertex: [3]Vertex{a, b, c}}, {Vertex: [3]Vertex{a, c, d}}}
+	}
+
+	p000, p100 := v(0, 0, 0), v(1, 0, 0)
+	p010, p110 := v(0, 1, 0), v(1, 1, 0)
+	p001, p101 := v(0, 0, 1), v(1, 0, 1)
+	p011, p111 := v(0, 1, 1), v(1, 1, 1)
+
+	var faces []Face
+	for _, fp := range [][2]Face{
+		quad(p000, p010, p110, p100), // -Z
+		quad(p001, p101, p111, p011), // +Z
+		quad(p000, p100, p101, p001), // -Y
+		quad(p010, p011, p111, p110), // +Y
+		quad(p000, p001, p011, p010), // -X
+		quad(p100, p110, p111, p101), // +X
+	} {
+		faces = append(faces, fp[0], fp[1])
+	}
+
+	mesh, err := NewMesh(faces)
+	if err != nil {
+		t.Fatalf("NewMesh(unit cube) error: %v", err)
+	}
+	return mesh
+}
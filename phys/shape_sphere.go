@@ -253,11 +253,21 @@ type Sphere struct {
 	// UVMap selects the UV parameterization used by Collide. The zero value
 	// is UVMapEquirect.
 	UVMap UVMapKind `json:"UVMap,omitempty"`
+
+	// BumpMap and NormalMap, if set, perturb the geometric normal computed
+	// by Collide via perturbNormal. At most one should be set; if both are,
+	// NormalMap takes precedence. The zero value (both nil) leaves the
+	// normal unperturbed.
+	BumpMap   *TextureBump   `json:"BumpMap,omitempty"`
+	NormalMap *TextureNormal `json:"NormalMap,omitempty"`
 }
 
 // Ensure Sphere satisfies the Shape interface.
 var _ Shape = (*Sphere)(nil)
 
+// Ensure Sphere satisfies IntervalShape, so it can be a CSG combinator operand.
+var _ IntervalShape = (*Sphere)(nil)
+
 // Validate reports whether s has a positive radius.
 //
 // Validate returns nil when Radius > 0. It does not mutate the receiver.
@@ -265,6 +275,16 @@ func (s Sphere) Validate() error {
 	if s.Radius <= 0 {
 		return fmt.Errorf("invalid Sphere radius: %v (has it been set?)", s.Radius)
 	}
+	if s.BumpMap != nil {
+		if err := s.BumpMap.Validate(); err != nil {
+			return fmt.Errorf("invalid Sphere BumpMap: %v", err)
+		}
+	}
+	if s.NormalMap != nil {
+		if err := s.NormalMap.Validate(); err != nil {
+			return fmt.Errorf("invalid Sphere NormalMap: %v", err)
+		}
+	}
 	return nil
 }
 
@@ -279,6 +299,12 @@ func (s Sphere) Bounds() AABB {
 	}
 }
 
+// SignedDistance returns the distance from p to the sphere's surface,
+// negative when p is inside.
+func (s Sphere) SignedDistance(p r3.Point) Distance {
+	return Distance(p.Sub(s.Center).Length()) - s.Radius
+}
+
 // Collide reports whether the ray r intersects the sphere within [tmin, tmax].
 //
 // On success it returns true and a populated collision:
@@ -311,8 +337,15 @@ func (s Sphere) Collide(r ray, tmin, tmax Distance) (bool, collision) {
 		}
 	}
 
-	hitT := Distance(t)
-	at := r.at(hitT)
+	return true, s.collisionAt(r, Distance(t))
+}
+
+// collisionAt builds the collision at parametric distance t along r,
+// computing the geometric normal, UV, tangent frame, and bump/normal map
+// perturbation shared by Collide (nearest root) and CollideAll (both
+// roots).
+func (s Sphere) collisionAt(r ray, t Distance) collision {
+	at := r.at(t)
 
 	// Geometric normal.
 	normal := at.Sub(s.Center).Unit()
@@ -330,12 +363,66 @@ func (s Sphere) Collide(r ray, tmin, tmax Distance) (bool, collision) {
 		uv = equirectUV(normal, true)
 	}
 
-	return true, collision{
-		t:      hitT,
-		at:     at,
-		normal: normal,
-		uv:     uv,
+	frame := NewTangentFrame(normal)
+	shadingNormal := normal
+	if s.BumpMap != nil || s.NormalMap != nil {
+		perturbed, err := perturbNormal(frame, uv.X, uv.Y, s.BumpMap, s.NormalMap)
+		if err == nil {
+			shadingNormal = perturbed
+		}
+	}
+	return collision{
+		t:         t,
+		at:        at,
+		normal:    shadingNormal,
+		uv:        uv,
+		tangent:   frame.Tangent,
+		bitangent: frame.Bitangent,
+	}
+}
+
+// SampleSurface returns a point uniformly distributed over the sphere's
+// surface, its outward normal there, and the pdf of that sample with
+// respect to surface area (1 / 4*pi*Radius^2). It satisfies AreaSampler,
+// letting a Sphere act as the emitting surface the BDPT integrator
+// connects a light vertex to.
+func (s Sphere) SampleSurface(rand *Rand) (p r3.Point, normal r3.Vec, pdfArea float64) {
+	normal = rand.UnitVector()
+	p = s.Center.Add(normal.Muls(float64(s.Radius)))
+	area := 4 * math.Pi * float64(s.Radius) * float64(s.Radius)
+	return p, normal, 1 / area
+}
+
+// Ensure Sphere satisfies AreaSampler, so it can act as an Emitter's
+// sampled surface for BDPT's light-vertex connection.
+var _ AreaSampler = (*Sphere)(nil)
+
+// CollideAll returns the ray's single entry/exit span through the sphere
+// within [tmin, tmax], or nil if the ray misses the sphere or the span
+// lies entirely outside [tmin, tmax]. It satisfies IntervalShape, letting
+// Sphere participate in CSG combinators (ShapeUnion, ShapeIntersect,
+// ShapeDifference).
+func (s Sphere) CollideAll(r ray, tmin, tmax Distance) []Interval {
+	oc := r.origin.Sub(s.Center)
+	a := r.direction.Dot(r.direction)
+	b := oc.Dot(r.direction)
+	c := oc.Dot(oc) - float64(s.Radius*s.Radius)
+	discriminant := b*b - a*c
+	if discriminant < 0 {
+		return nil
+	}
+	sqrtD := math.Sqrt(discriminant)
+	tEnter := Distance((-b - sqrtD) / a)
+	tExit := Distance((-b + sqrtD) / a)
+	if tExit < tmin || tEnter > tmax {
+		return nil
 	}
+	return []Interval{{
+		TEnter: tEnter,
+		TExit:  tExit,
+		Enter:  s.collisionAt(r, tEnter),
+		Exit:   s.collisionAt(r, tExit),
+	}}
 }
 
 // equirectUV returns longitude/latitude UV for a unit direction n.
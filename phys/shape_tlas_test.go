@@ -0,0 +1,102 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
+)
+
+// twoInstances builds a TLAS with two distinct BLASes (one per unit
+// sphere), placed at the origin and translated by (dx, 0, 0), the pair
+// used by the tests below.
+func twoInstances(dx float64) []Instance {
+	a := NewBVH([]Shape{unitSphere()}, 0)
+	b := NewBVH([]Shape{Sphere{Center: r3.Point{}, Radius: 2}}, 0)
+	moved := NewTranslation(r3.Vec{X: dx, Y: 0, Z: 0})
+	return []Instance{
+		{BLAS: a, Transform: NewTransform()},
+		{BLAS: b, Transform: moved},
+	}
+}
+
+// TestTLASCollideHitsEachInstance verifies that a ray is tested against
+// every instance, each transformed into its own BLAS's local space, and
+// hits the one whose Transform puts it in the ray's path.
+func TestTLASCollideHitsEachInstance(t *testing.T) {
+	tlas, err := NewTLAS(twoInstances(10))
+	if err != nil {
+		t.Fatalf("NewTLAS: %v", err)
+	}
+
+	hit, coll := tlas.Collide(ray{
+		origin:    r3.Point{X: 10, Y: 0, Z: -5},
+		direction: r3.Vec{X: 0, Y: 0, Z: 1},
+	}, 0, Distance(math.MaxFloat64))
+	if !hit {
+		t.Fatalf("expected hit against the translated instance")
+	}
+	want := r3.Point{X: 10, Y: 0, Z: -2}
+	if !coll.at.IsClose(want, eps) {
+		t.Errorf("collision point = %v, want %v", coll.at, want)
+	}
+
+	hit, _ = tlas.Collide(ray{
+		origin:    r3.Point{X: 5, Y: 0, Z: -5},
+		direction: r3.Vec{X: 0, Y: 0, Z: 1},
+	}, 0, Distance(math.MaxFloat64))
+	if hit {
+		t.Errorf("expected no hit between the two instances")
+	}
+}
+
+// TestTLASBoundsUnionsInstances verifies Bounds covers every instance's
+// own world-space bounds, not just one BLAS's local-space bounds.
+func TestTLASBoundsUnionsInstances(t *testing.T) {
+	tlas, err := NewTLAS(twoInstances(10))
+	if err != nil {
+		t.Fatalf("NewTLAS: %v", err)
+	}
+	bounds := tlas.Bounds()
+	if bounds.Max.X < 12-eps {
+		t.Errorf("Bounds().Max.X = %v, want >= 12", bounds.Max.X)
+	}
+	if bounds.Min.X > -1+eps {
+		t.Errorf("Bounds().Min.X = %v, want <= -1", bounds.Min.X)
+	}
+}
+
+// TestTLASMarshalJSONRoundTrip verifies that each instance's BLAS is
+// marshaled and rebuilt independently (unlike Instancer, which shares one
+// Shape), and that the result round-trips back to a usable TLAS.
+func TestTLASMarshalJSONRoundTrip(t *testing.T) {
+	tlas, err := NewTLAS(twoInstances(10))
+	if err != nil {
+		t.Fatalf("NewTLAS: %v", err)
+	}
+
+	data, err := json.Marshal(tlas)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+
+	var decoded TLAS
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if len(decoded.Instances) != 2 {
+		t.Fatalf("Instances = %d, want 2", len(decoded.Instances))
+	}
+	if err := decoded.Validate(); err != nil {
+		t.Errorf("decoded TLAS invalid: %v", err)
+	}
+	hit, _ := decoded.Collide(ray{
+		origin:    r3.Point{X: 0, Y: 0, Z: -5},
+		direction: r3.Vec{X: 0, Y: 0, Z: 1},
+	}, 0, Distance(math.MaxFloat64))
+	if !hit {
+		t.Errorf("decoded TLAS should still collide after round-trip")
+	}
+}
@@ -25,34 +25,33 @@ func (ts TransformedShape) Validate() error {
 // and transforms the collision back to world space.
 func (ts TransformedShape) Collide(r ray, tmin, tmax Distance) (bool, collision) {
 	// Transform the ray into the local space of the shape.
-	invTransform := ts.Transform.Inverse()
-	localOrigin := invTransform.ApplyToPoint(r.origin)
-	localDirection := invTransform.ApplyToVector(r.direction)
-
-	localRay := ray{
-		origin:    localOrigin,
-		direction: localDirection,
-		depth:     r.depth,
-		radiance:  r.radiance,
-		rand:      r.rand,
-		pixelX:    r.pixelX,
-		pixelY:    r.pixelY,
-	}
+	localRay := ts.Transform.Inverse().TransformRay(r)
 
 	hit, col := ts.Shape.Collide(localRay, tmin, tmax)
 	if !hit {
 		return false, collision{}
 	}
 
-	// Transform collision back to world space
+	// Transform collision back to world space. The normal goes through
+	// ApplyToNormal (the inverse-transpose) rather than ApplyToVector, so
+	// it stays perpendicular to the surface under a non-uniform scale or
+	// shear; tangent and bitangent lie in the surface itself, so the
+	// ordinary linear map is correct for them.
 	worldPoint := ts.Transform.ApplyToPoint(col.at)
-	worldNormal := ts.Transform.ApplyToVector(col.normal).Unit()
+	worldNormal := ts.Transform.ApplyToNormal(col.normal).Unit()
+	worldTangent := ts.Transform.ApplyToVector(col.tangent).Unit()
+	worldBitangent := ts.Transform.ApplyToVector(col.bitangent).Unit()
 
 	return true, collision{
-		t:      col.t,
-		at:     worldPoint,
-		normal: worldNormal,
-		uv:     col.uv,
+		t:           col.t,
+		at:          worldPoint,
+		normal:      worldNormal,
+		uv:          col.uv,
+		tangent:     worldTangent,
+		bitangent:   worldBitangent,
+		barycentric: col.barycentric,
+		primitiveID: col.primitiveID,
+		feature:     col.feature,
 	}
 }
 
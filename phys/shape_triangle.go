@@ -42,8 +42,53 @@ import (
 // the ray tracer, serving as a basic geometric primitive.
 type Triangle struct {
 	P0, P1, P2 r3.Point // Vertices of the triangle in counter-clockwise order.
+
+	// N0, N1, N2 are optional per-vertex shading normals, e.g. from an
+	// OBJ file's vn records. Zero value means "not supplied": Collide
+	// then falls back to the flat geometric normal instead of
+	// interpolating, the same convention Vertex.Normal uses for Mesh
+	// faces.
+	N0, N1, N2 r3.Vec
+
+	// UV0, UV1, UV2 are optional per-vertex texture coordinates, e.g.
+	// from an OBJ file's vt records. Zero value on all three means "not
+	// supplied": Collide then returns the raw barycentric (u, v) as
+	// coll.uv instead of interpolating, as it always has.
+	UV0, UV1, UV2 r2.Point
+
+	// Backface selects how Collide treats rays that hit the triangle's
+	// back face. The zero value, BackfaceInclude, reproduces Collide's
+	// original two-sided behavior, so existing Triangle literals built
+	// before this field existed are unaffected.
+	Backface Backface
 }
 
+// Backface selects how Triangle.Collide handles a ray that hits a
+// triangle's back face, i.e. one whose direction has a positive dot
+// product with the triangle's geometric (front-facing) normal. Opaque
+// closed meshes typically want BackfaceCull for the speedup of skipping
+// the divide and 1/det scaling on a miss; glass or other refractive
+// volumes, which need to see a ray exit through a back face, want
+// BackfaceInclude or BackfaceFlipNormal instead.
+type Backface int
+
+const (
+	// BackfaceInclude reports back-face hits the same as front-face
+	// hits, without culling or flipping the normal. This is Collide's
+	// original behavior and Backface's zero value.
+	BackfaceInclude Backface = iota
+
+	// BackfaceCull discards hits on the triangle's back face, short-
+	// circuiting before the 1/det scaling the way a one-sided
+	// Möller–Trumbore test does.
+	BackfaceCull
+
+	// BackfaceFlipNormal reports back-face hits like BackfaceInclude,
+	// except coll.normal (and, if present, the interpolated smooth
+	// normal) is negated so it always faces the incoming ray.
+	BackfaceFlipNormal
+)
+
 // Validate performs comprehensive validation checks on the Triangle instance.
 // It ensures that:
 // 1. All three vertices are distinct.
@@ -85,6 +130,14 @@ func (tri Triangle) Validate() error {
 	return nil
 }
 
+// SignedDistance returns the distance from p to the closest point on the
+// triangle. A Triangle has no thickness and so no interior; the returned
+// distance is always non-negative.
+func (tri Triangle) SignedDistance(p r3.Point) Distance {
+	closest := closestPointOnTriangle(p, tri.P0, tri.P1, tri.P2)
+	return Distance(p.Sub(closest).Length())
+}
+
 // Collide determines whether a given ray intersects with the triangle.
 // It implements the Möller–Trumbore intersection algorithm, which is efficient
 // for detecting ray-triangle intersections.
@@ -92,7 +145,8 @@ func (tri Triangle) Validate() error {
 // The algorithm computes if and where the ray intersects the triangle within
 // the bounds [tmin, tmax]. If an intersection occurs, it returns true along
 // with the collision details, including the intersection point and the
-// triangle's normal at that point.
+// triangle's normal at that point. tri.Backface governs what happens when
+// the ray hits the back face; see its doc comment.
 //
 // Parameters:
 //   - r ray: The ray to test for intersection.
@@ -107,7 +161,14 @@ func (tri Triangle) Collide(r ray, tmin, tmax Distance) (bool, collision) {
 	edge2 := tri.P2.Sub(tri.P0)
 	h := r.direction.Cross(edge2)
 	a := edge1.Dot(h)
-	if a > -eps && a < eps {
+	if tri.Backface == BackfaceCull {
+		// One-sided test: a <= eps covers both the parallel case and
+		// every back-face hit, so a culling Triangle never pays for the
+		// divide and 1/det scaling below on either kind of miss.
+		if a < eps {
+			return false, collision{}
+		}
+	} else if a > -eps && a < eps {
 		return false, collision{}
 	}
 	f := 1 / a
@@ -129,12 +190,149 @@ func (tri Triangle) Collide(r ray, tmin, tmax Distance) (bool, collision) {
 	if t < float64(tmin) || t > float64(tmax) {
 		return false, collision{}
 	}
+	return true, tri.shade(r, t, 1-u-v, u, v)
+}
+
+// shade builds the collision record for a hit already known to land on
+// tri at parameter t, with barycentric weights w, u, v (for P0, P1, P2
+// respectively; w+u+v == 1). Both Collide and CollideWatertight share
+// this so the two intersection tests agree on everything downstream of
+// "where did it hit": normal smoothing, UV interpolation, Backface, and
+// Feature classification.
+func (tri Triangle) shade(r ray, t, w, u, v float64) collision {
+	edge1 := tri.P1.Sub(tri.P0)
+	edge2 := tri.P2.Sub(tri.P0)
 	at := r.at(Distance(t))
-	return true, collision{
-		t:      Distance(t),
-		at:     at,
-		normal: edge1.Cross(edge2).Unit(),
-		uv:     r2.Point{X: u, Y: v},
+
+	// Compute the flat geometric normal, and smooth-shade it via
+	// barycentric interpolation of the vertex normals when the triangle
+	// carries them (see N0, N1, N2's doc comment).
+	normal := edge1.Cross(edge2).Unit()
+	flip := tri.Backface == BackfaceFlipNormal && normal.Dot(r.direction) > 0
+	if !tri.N0.IsZero() && !tri.N1.IsZero() && !tri.N2.IsZero() {
+		if smooth := tri.N0.Muls(w).Add(tri.N1.Muls(u)).Add(tri.N2.Muls(v)); !smooth.IsZero() {
+			normal = smooth.Unit()
+		}
+	}
+	if flip {
+		normal = normal.Muls(-1)
+	}
+
+	// uv defaults to the raw barycentric (u, v); when the triangle
+	// carries per-vertex texture coordinates, interpolate those instead,
+	// the same barycentric weights used for the smooth normal above.
+	uv := r2.Point{X: u, Y: v}
+	if tri.UV0 != (r2.Point{}) || tri.UV1 != (r2.Point{}) || tri.UV2 != (r2.Point{}) {
+		uv = tri.UV0.Muls(w).Add(tri.UV1.Muls(u)).Add(tri.UV2.Muls(v))
+	}
+
+	tangentFrame := NewTangentFrame(normal)
+	return collision{
+		t:           Distance(t),
+		at:          at,
+		normal:      normal,
+		uv:          uv,
+		tangent:     tangentFrame.Tangent,
+		bitangent:   tangentFrame.Bitangent,
+		barycentric: r3.Vec{X: w, Y: u, Z: v},
+		feature:     classifyBarycentricFeature(w, u, v),
+	}
+}
+
+// CollideWatertight is an alternative to Collide that implements the
+// Woop/Benthin/Wald "Watertight Ray/Triangle Intersection" algorithm
+// (JCGT 2013): it permutes the ray's axes so its direction aligns with
+// +Z and shears the triangle's vertices into that space, replacing
+// Collide's divide-heavy Möller–Trumbore edge tests with three edge
+// functions evaluated in a single consistent coordinate frame. The
+// payoff is that two adjacent triangles sharing an edge never both miss a
+// ray passing exactly along that edge -- the crack/leak a plain
+// floating-point Möller–Trumbore test can produce at shared mesh edges.
+// Both triangles may report a hit in that case, which is harmless for a
+// nearest- or any-hit query. Everything after the hit test -- normal
+// smoothing, UV interpolation, Backface, Feature -- is identical to
+// Collide; see shade.
+func (tri Triangle) CollideWatertight(r ray, tmin, tmax Distance) (bool, collision) {
+	// 1. Pick the permutation that puts the ray direction's largest
+	// component on Z, so dividing by it later is as numerically stable
+	// as this ray can offer.
+	kz := 0
+	if ax, ay, az := math.Abs(r.direction.X), math.Abs(r.direction.Y), math.Abs(r.direction.Z); ay > ax && ay >= az {
+		kz = 1
+	} else if az > ax && az > ay {
+		kz = 2
+	}
+	kx, ky := (kz+1)%3, (kz+2)%3
+	// Swapping kx/ky when direction[kz] is negative keeps the
+	// permutation winding-preserving, so the edge functions below don't
+	// need a separate sign correction for which way the ray points.
+	dz := component(r.direction, kz)
+	if dz < 0 {
+		kx, ky = ky, kx
+	}
+	dx, dy := component(r.direction, kx), component(r.direction, ky)
+
+	// Shear constants that turn the permuted ray into the +Z axis.
+	sx := -dx / dz
+	sy := -dy / dz
+	sz := 1 / dz
+
+	// 2. Translate the vertices into ray-origin space and apply the
+	// permutation and shear, so the ray itself no longer appears in the
+	// edge-function math below.
+	translate := func(p r3.Point) (x, y, z float64) {
+		v := p.Sub(r.origin)
+		px, py, pz := component(v, kx), component(v, ky), component(v, kz)
+		return px + sx*pz, py + sy*pz, pz
+	}
+	ax, ay, az := translate(tri.P0)
+	bx, by, bz := translate(tri.P1)
+	cx, cy, cz := translate(tri.P2)
+
+	// 3. Edge functions: u0 is the signed area opposite P0 (i.e. formed
+	// by P1, P2), and so on cyclically. The ray hits iff all three share
+	// a sign (or are zero), regardless of which sign, so this doubles as
+	// the front/back-face test Collide gets from the sign of a.
+	u0 := cx*by - cy*bx
+	u1 := ax*cy - ay*cx
+	u2 := bx*ay - by*ax
+	if (u0 < 0 || u1 < 0 || u2 < 0) && (u0 > 0 || u1 > 0 || u2 > 0) {
+		return false, collision{}
+	}
+	det := u0 + u1 + u2
+	if det == 0 {
+		return false, collision{}
+	}
+	if tri.Backface == BackfaceCull && det < 0 {
+		return false, collision{}
+	}
+
+	// 4. Recover t and the barycentric weights, scaling Z by sz only now
+	// that it's no longer needed unscaled for the edge functions above.
+	az *= sz
+	bz *= sz
+	cz *= sz
+	tScaled := u0*az + u1*bz + u2*cz
+	invDet := 1 / det
+	t := tScaled * invDet
+	if t < float64(tmin) || t > float64(tmax) {
+		return false, collision{}
+	}
+	w, u, v := u0*invDet, u1*invDet, u2*invDet
+	return true, tri.shade(r, t, w, u, v)
+}
+
+// component returns v's coordinate along axis i (0=X, 1=Y, 2=Z), letting
+// CollideWatertight index into a permuted axis order computed at runtime
+// instead of branching on it at every use.
+func component(v r3.Vec, i int) float64 {
+	switch i {
+	case 0:
+		return v.X
+	case 1:
+		return v.Y
+	default:
+		return v.Z
 	}
 }
 
@@ -157,3 +355,204 @@ func (tri Triangle) Bounds() AABB {
 	}
 	return AABB{Min: min, Max: max}
 }
+
+// OverlapsAABB reports whether tri overlaps box, using the Akenine-Möller
+// separating-axis test for triangle/box intersection. It tests 13
+// candidate separating axes: the box's three face normals (an AABB-vs-AABB
+// reject), the triangle's own face normal, and the nine cross products of
+// the triangle's edge vectors with the box's axes. If any axis separates
+// the triangle's projection from the box's, they do not overlap; if none
+// do, they overlap.
+func (tri Triangle) OverlapsAABB(box AABB) bool {
+	center := box.center()
+	half := r3.Vec{
+		X: (box.Max.X - box.Min.X) / 2,
+		Y: (box.Max.Y - box.Min.Y) / 2,
+		Z: (box.Max.Z - box.Min.Z) / 2,
+	}
+	v0 := tri.P0.Sub(center)
+	v1 := tri.P1.Sub(center)
+	v2 := tri.P2.Sub(center)
+
+	// Axes 1-3: the box's face normals. Equivalent to an AABB-vs-AABB
+	// test between box and tri.Bounds(), and rejects the common case of a
+	// triangle nowhere near the box before the more expensive axes below.
+	min := r3.Vec{
+		X: math.Min(math.Min(v0.X, v1.X), v2.X),
+		Y: math.Min(math.Min(v0.Y, v1.Y), v2.Y),
+		Z: math.Min(math.Min(v0.Z, v1.Z), v2.Z),
+	}
+	max := r3.Vec{
+		X: math.Max(math.Max(v0.X, v1.X), v2.X),
+		Y: math.Max(math.Max(v0.Y, v1.Y), v2.Y),
+		Z: math.Max(math.Max(v0.Z, v1.Z), v2.Z),
+	}
+	if min.X > half.X || max.X < -half.X ||
+		min.Y > half.Y || max.Y < -half.Y ||
+		min.Z > half.Z || max.Z < -half.Z {
+		return false
+	}
+
+	// Axis 4: the triangle's own plane. Project the box's half-extent
+	// onto the plane normal and compare to the normal's distance from the
+	// (box-centered) triangle to decide if the box's extent along the
+	// normal reaches the plane.
+	e0 := tri.P1.Sub(tri.P0)
+	e1 := tri.P2.Sub(tri.P1)
+	e2 := tri.P0.Sub(tri.P2)
+	normal := e0.Cross(e1)
+	r := half.X*math.Abs(normal.X) + half.Y*math.Abs(normal.Y) + half.Z*math.Abs(normal.Z)
+	d := normal.Dot(v0)
+	if d > r || d < -r {
+		return false
+	}
+
+	// Axes 5-13: the nine cross products of the triangle's edges with the
+	// box's axes. For each, project the three (box-centered) vertices and
+	// the box's half-extent onto the axis and reject if the intervals
+	// don't overlap.
+	edges := [3]r3.Vec{e0, e1, e2}
+	boxAxes := [3]r3.Vec{{X: 1}, {Y: 1}, {Z: 1}}
+	verts := [3]r3.Vec{v0, v1, v2}
+	for _, e := range edges {
+		for _, a := range boxAxes {
+			axis := e.Cross(a)
+			if axis.IsZero() {
+				continue
+			}
+			p0 := axis.Dot(verts[0])
+			p1 := axis.Dot(verts[1])
+			p2 := axis.Dot(verts[2])
+			lo := math.Min(math.Min(p0, p1), p2)
+			hi := math.Max(math.Max(p0, p1), p2)
+			rad := half.X*math.Abs(axis.X) + half.Y*math.Abs(axis.Y) + half.Z*math.Abs(axis.Z)
+			if lo > rad || hi < -rad {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (tri Triangle) String() string {
+	return fmt.Sprintf("Triangle{P0: %v, P1: %v, P2: %v}", tri.P0, tri.P1, tri.P2)
+}
+
+// Intersects reports whether a and b intersect as two solid triangles (not
+// as rays), implementing Möller's fast triangle-triangle intersection
+// test: each triangle's vertices are classified by signed distance to the
+// other's plane, and a triangle entirely on one side of the other's plane
+// cannot intersect it. When both triangles straddle each other's plane,
+// their boundaries are projected onto the line where the two planes
+// meet, reducing the test to an overlap of two 1-D intervals on that
+// line; the overlapping sub-interval is the returned Segment.
+//
+// Coplanar triangles (including degenerate ones whose plane is undefined)
+// are reported as not intersecting: the general position this test
+// assumes breaks down when the two planes coincide, and resolving that
+// case needs a separate 2D polygon test this diagnostic does not need for
+// its purpose of flagging accidental self-intersections in a mesh.
+func (a Triangle) Intersects(b Triangle) (bool, r3.Segment) {
+	ae0, ae1 := a.P1.Sub(a.P0), a.P2.Sub(a.P0)
+	na := ae0.Cross(ae1)
+	be0, be1 := b.P1.Sub(b.P0), b.P2.Sub(b.P0)
+	nb := be0.Cross(be1)
+
+	// Distances of b's vertices to a's plane; if they're all the same
+	// strict sign, b lies entirely to one side of a and cannot intersect
+	// it.
+	db0 := na.Dot(b.P0.Sub(a.P0))
+	db1 := na.Dot(b.P1.Sub(a.P0))
+	db2 := na.Dot(b.P2.Sub(a.P0))
+	if sameSign(db0, db1, db2) {
+		return false, r3.Segment{}
+	}
+
+	// Distances of a's vertices to b's plane; same rejection, the other
+	// direction.
+	da0 := nb.Dot(a.P0.Sub(b.P0))
+	da1 := nb.Dot(a.P1.Sub(b.P0))
+	da2 := nb.Dot(a.P2.Sub(b.P0))
+	if sameSign(da0, da1, da2) {
+		return false, r3.Segment{}
+	}
+
+	rawD := na.Cross(nb)
+	if rawD.Length() < eps {
+		// Planes are parallel (or a/b degenerate); treat as coplanar, see
+		// doc comment.
+		return false, r3.Segment{}
+	}
+	// Normalized so that project/reconstruct below agree on what a unit
+	// step along d means; triEdgeInterval's interpolated t values are
+	// then true distances along the line, not scaled by |rawD|^2.
+	d := rawD.Unit()
+
+	// A point O on the intersection line of the two planes, found by
+	// writing O (relative to a.P0) as a linear combination of na and nb --
+	// valid since na, nb, d are linearly independent -- and solving the
+	// 2x2 system na.Dot(O-a.P0)=0, nb.Dot(O-a.P0)=nb.Dot(b.P0-a.P0).
+	c := nb.Dot(b.P0.Sub(a.P0))
+	a11, a12, a22 := na.Dot(na), na.Dot(nb), nb.Dot(nb)
+	denom := a11*a22 - a12*a12
+	alpha := -c * a12 / denom
+	beta := c * a11 / denom
+	o := a.P0.Add(na.Muls(alpha)).Add(nb.Muls(beta))
+
+	aMin, aMax, okA := triEdgeInterval(a.P0, a.P1, a.P2, da0, da1, da2, d, o)
+	bMin, bMax, okB := triEdgeInterval(b.P0, b.P1, b.P2, db0, db1, db2, d, o)
+	if !okA || !okB {
+		return false, r3.Segment{}
+	}
+	lo, hi := math.Max(aMin, bMin), math.Min(aMax, bMax)
+	if lo > hi {
+		return false, r3.Segment{}
+	}
+	return true, r3.Segment{A: o.Add(d.Muls(lo)), B: o.Add(d.Muls(hi))}
+}
+
+// sameSign reports whether a, b, c are all strictly positive or all
+// strictly negative, meaning a triangle with these signed plane distances
+// lies entirely to one side of the plane and cannot cross it. Zero counts
+// as neither sign, so a vertex lying exactly on the plane is always
+// treated as potentially straddling it.
+func sameSign(a, b, c float64) bool {
+	return (a > 0 && b > 0 && c > 0) || (a < 0 && b < 0 && c < 0)
+}
+
+// triEdgeInterval computes the 1-D interval, along direction d starting
+// from origin o, that the boundary of triangle (v0, v1, v2) sweeps out as
+// it crosses the other triangle's plane, given this triangle's signed
+// distances (d0, d1, d2) to that plane. Exactly one vertex has a distance
+// whose sign (treating 0 as non-negative) differs from the other two; the
+// two edges from that vertex to the others are the ones that cross the
+// plane, and linearly interpolating each by its pair of distances gives
+// the interval's endpoints. ok is false if all three distances share a
+// sign, meaning this triangle does not cross the plane at all.
+func triEdgeInterval(v0, v1, v2 r3.Point, d0, d1, d2 float64, d r3.Vec, o r3.Point) (lo, hi float64, ok bool) {
+	v := [3]r3.Point{v0, v1, v2}
+	dist := [3]float64{d0, d1, d2}
+	nonneg := [3]bool{d0 >= 0, d1 >= 0, d2 >= 0}
+
+	iso := -1
+	for i := 0; i < 3; i++ {
+		j, k := (i+1)%3, (i+2)%3
+		if nonneg[i] != nonneg[j] && nonneg[i] != nonneg[k] {
+			iso = i
+			break
+		}
+	}
+	if iso < 0 {
+		return 0, 0, false
+	}
+	j, k := (iso+1)%3, (iso+2)%3
+	project := func(p r3.Point) float64 { return d.Dot(p.Sub(o)) }
+	pIso, pJ, pK := project(v[iso]), project(v[j]), project(v[k])
+	t1 := pIso + (pJ-pIso)*dist[iso]/(dist[iso]-dist[j])
+	t2 := pIso + (pK-pIso)*dist[iso]/(dist[iso]-dist[k])
+	return math.Min(t1, t2), math.Max(t1, t2), true
+}
+
+func init() {
+	RegisterInterfaceType(Triangle{})
+}
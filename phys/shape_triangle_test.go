@@ -5,6 +5,7 @@ import (
 	"math"
 	"testing"
 
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r2"
 	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
 )
 
@@ -389,6 +390,17 @@ func TestTriangleCollideEdgeCases(t *testing.T) {
 			expectPoint: r3.Point{X: 1, Y: 0, Z: 0},
 			expectNorm:  r3.Vec{X: 0, Y: 0, Z: 1},
 		},
+		// KNOWN FAILING, TRACKED: the next two cases assert that a ray
+		// whose origin already lies on the triangle (t == tmin == 0)
+		// reports a miss. That contradicts Collide's own documented
+		// [tmin, tmax] contract (inclusive, the same convention
+		// Sphere/Polygon/Cylinder's Collide share: a caller wanting to
+		// exclude the origin itself passes a small positive tmin, e.g.
+		// the eps-offset shadow ray origins ComputeDirectLighting
+		// builds), so "fixing" Collide to special-case t == tmin would
+		// make Triangle inconsistent with every other Shape instead.
+		// Left red rather than silently skipped or reinterpreted;
+		// revisit if a real self-intersection bug surfaces here.
 		{
 			name: "Ray grazes the triangle (t == tmin)",
 			ray: ray{
@@ -451,6 +463,130 @@ func TestTriangleBounds(t *testing.T) {
 	}
 }
 
+// TestTriangleOverlapsAABB exercises the separating-axis test against a
+// unit box centered at the origin: a triangle fully inside, one only
+// touching an edge or a corner, one that pierces the box without putting
+// any vertex inside it (the case the 13-axis test exists for, since the 3
+// box-face-normal axes and the triangle's own plane axis alone would both
+// report "no separation"), and one that is cleanly separated.
+func TestTriangleOverlapsAABB(t *testing.T) {
+	box := AABB{Min: r3.Point{X: -1, Y: -1, Z: -1}, Max: r3.Point{X: 1, Y: 1, Z: 1}}
+
+	tests := []struct {
+		name string
+		tri  Triangle
+		want bool
+	}{
+		{
+			name: "fully inside",
+			tri: Triangle{
+				P0: r3.Point{X: -0.1, Y: -0.1, Z: 0},
+				P1: r3.Point{X: 0.1, Y: -0.1, Z: 0},
+				P2: r3.Point{X: 0, Y: 0.1, Z: 0},
+			},
+			want: true,
+		},
+		{
+			name: "touches a box edge",
+			tri: Triangle{
+				P0: r3.Point{X: 1, Y: 1, Z: 0},
+				P1: r3.Point{X: 2, Y: 1, Z: 1},
+				P2: r3.Point{X: 2, Y: 1, Z: -1},
+			},
+			want: true,
+		},
+		{
+			name: "touches a box corner",
+			tri: Triangle{
+				P0: r3.Point{X: 1, Y: 1, Z: 1},
+				P1: r3.Point{X: 2, Y: 1, Z: 1},
+				P2: r3.Point{X: 1, Y: 2, Z: 1},
+			},
+			want: true,
+		},
+		{
+			name: "pierces the box with no vertex inside",
+			tri: Triangle{
+				P0: r3.Point{X: -2, Y: 0, Z: 0},
+				P1: r3.Point{X: 2, Y: 0.1, Z: 0},
+				P2: r3.Point{X: 2, Y: -0.1, Z: 0},
+			},
+			want: true,
+		},
+		{
+			name: "separated",
+			tri: Triangle{
+				P0: r3.Point{X: 5, Y: 5, Z: 5},
+				P1: r3.Point{X: 6, Y: 5, Z: 5},
+				P2: r3.Point{X: 5, Y: 6, Z: 5},
+			},
+			want: false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.tri.OverlapsAABB(box); got != tc.want {
+				t.Errorf("OverlapsAABB() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+// TestTriangleIntersects covers Intersects' three outcomes: two triangles
+// that don't overlap at all, two that cross each other's plane and share a
+// segment, and two coplanar triangles, which Intersects reports as not
+// intersecting (see its doc comment for why).
+func TestTriangleIntersects(t *testing.T) {
+	a := Triangle{
+		P0: r3.Point{X: 0, Y: 0, Z: 0},
+		P1: r3.Point{X: 2, Y: 0, Z: 0},
+		P2: r3.Point{X: 0, Y: 2, Z: 0},
+	}
+
+	t.Run("separated", func(t *testing.T) {
+		b := Triangle{
+			P0: r3.Point{X: 10, Y: 10, Z: 10},
+			P1: r3.Point{X: 12, Y: 10, Z: 10},
+			P2: r3.Point{X: 10, Y: 12, Z: 10},
+		}
+		if hit, _ := a.Intersects(b); hit {
+			t.Errorf("Intersects() = true, want false for separated triangles")
+		}
+	})
+
+	t.Run("piercing", func(t *testing.T) {
+		// b stabs through a's plane along a segment that lies inside a.
+		b := Triangle{
+			P0: r3.Point{X: 0.5, Y: 0.5, Z: -1},
+			P1: r3.Point{X: 0.5, Y: 0.5, Z: 1},
+			P2: r3.Point{X: 1, Y: 0, Z: 1},
+		}
+		hit, seg := a.Intersects(b)
+		if !hit {
+			t.Fatalf("Intersects() = false, want true")
+		}
+		wantA := r3.Point{X: 0.75, Y: 0.25, Z: 0}
+		wantB := r3.Point{X: 0.5, Y: 0.5, Z: 0}
+		gotMatches := (seg.A.IsClose(wantA, eps) && seg.B.IsClose(wantB, eps)) ||
+			(seg.A.IsClose(wantB, eps) && seg.B.IsClose(wantA, eps))
+		if !gotMatches {
+			t.Errorf("Intersects() segment = %v, want endpoints %v and %v in either order", seg, wantA, wantB)
+		}
+	})
+
+	t.Run("coplanar", func(t *testing.T) {
+		b := Triangle{
+			P0: r3.Point{X: 1, Y: 1, Z: 0},
+			P1: r3.Point{X: 3, Y: 1, Z: 0},
+			P2: r3.Point{X: 1, Y: 3, Z: 0},
+		}
+		if hit, _ := a.Intersects(b); hit {
+			t.Errorf("Intersects() = true, want false for coplanar triangles")
+		}
+	})
+}
+
 // TestTriangleNormals verifies that the computed normals are correct based on vertex order.
 func TestTriangleNormals(t *testing.T) {
 	// Triangle in the XY-plane with counter-clockwise winding.
@@ -722,3 +858,305 @@ func TestTriangleValidate(t *testing.T) {
 		})
 	}
 }
+
+// TestTriangleCollideSmoothNormal verifies that a Triangle whose vertices
+// all carry an N0/N1/N2 normal interpolates them barycentrically instead
+// of returning the flat geometric normal, matching Face.Collide's
+// smooth-shading convention.
+func TestTriangleCollideSmoothNormal(t *testing.T) {
+	triangle := Triangle{
+		P0: r3.Point{X: 0, Y: 0, Z: 0},
+		P1: r3.Point{X: 1, Y: 0, Z: 0},
+		P2: r3.Point{X: 0, Y: 1, Z: 0},
+		N0: r3.Vec{X: 0.5, Y: 0, Z: 0.866},
+		N1: r3.Vec{X: 0.5, Y: 0, Z: 0.866},
+		N2: r3.Vec{X: 0.5, Y: 0, Z: 0.866},
+	}
+
+	hit, coll := triangle.Collide(ray{
+		origin:    r3.Point{X: 0.25, Y: 0.25, Z: -1},
+		direction: r3.Vec{X: 0, Y: 0, Z: 1},
+	}, 0, Distance(math.MaxFloat64))
+	if !hit {
+		t.Fatalf("expected hit")
+	}
+	want := r3.Vec{X: 0.5, Y: 0, Z: 0.866}.Unit()
+	if !coll.normal.IsClose(want, eps) {
+		t.Errorf("smooth normal = %v, want %v", coll.normal, want)
+	}
+}
+
+// TestTriangleCollideSmoothNormalIsUnit verifies that interpolating
+// differing vertex normals (not just three copies of the same one) still
+// leaves coll.normal unit-length, since Collide normalizes the blend.
+func TestTriangleCollideSmoothNormalIsUnit(t *testing.T) {
+	triangle := Triangle{
+		P0: r3.Point{X: 0, Y: 0, Z: 0},
+		P1: r3.Point{X: 1, Y: 0, Z: 0},
+		P2: r3.Point{X: 0, Y: 1, Z: 0},
+		N0: r3.Vec{X: 0, Y: 0, Z: 1},
+		N1: r3.Vec{X: 1, Y: 0, Z: 1},
+		N2: r3.Vec{X: 0, Y: 1, Z: 1},
+	}
+
+	hit, coll := triangle.Collide(ray{
+		origin:    r3.Point{X: 0.25, Y: 0.25, Z: -1},
+		direction: r3.Vec{X: 0, Y: 0, Z: 1},
+	}, 0, Distance(math.MaxFloat64))
+	if !hit {
+		t.Fatalf("expected hit")
+	}
+	if length := coll.normal.Length(); math.Abs(length-1) > eps {
+		t.Errorf("interpolated normal length = %v, want 1", length)
+	}
+}
+
+// TestTriangleCollideSmoothUV verifies that a Triangle whose vertices all
+// carry a UV0/UV1/UV2 texture coordinate interpolates them barycentrically
+// into coll.uv, instead of returning the raw barycentric (u, v).
+func TestTriangleCollideSmoothUV(t *testing.T) {
+	triangle := Triangle{
+		P0:  r3.Point{X: 0, Y: 0, Z: 0},
+		P1:  r3.Point{X: 1, Y: 0, Z: 0},
+		P2:  r3.Point{X: 0, Y: 1, Z: 0},
+		UV0: r2.Point{X: 0, Y: 0},
+		UV1: r2.Point{X: 2, Y: 0},
+		UV2: r2.Point{X: 0, Y: 2},
+	}
+
+	hit, coll := triangle.Collide(ray{
+		origin:    r3.Point{X: 0.25, Y: 0.25, Z: -1},
+		direction: r3.Vec{X: 0, Y: 0, Z: 1},
+	}, 0, Distance(math.MaxFloat64))
+	if !hit {
+		t.Fatalf("expected hit")
+	}
+	// u=v=0.25, w=0.5, so the interpolated UV is 0.25*(2,0) + 0.25*(0,2),
+	// twice the raw barycentric coordinate since UV1/UV2 are scaled by 2.
+	want := r2.Point{X: 0.5, Y: 0.5}
+	if !coll.uv.IsClose(want, eps) {
+		t.Errorf("uv = %v, want %v", coll.uv, want)
+	}
+}
+
+// TestTriangleCollideFeature verifies Collide classifies a hit's collision
+// feature as FeatureFace, FeatureEdge, or FeatureVertex based on how close
+// its barycentric coordinates land to the triangle's edges and corners.
+func TestTriangleCollideFeature(t *testing.T) {
+	triangle := Triangle{
+		P0: r3.Point{X: 0, Y: 0, Z: 0},
+		P1: r3.Point{X: 1, Y: 0, Z: 0},
+		P2: r3.Point{X: 0, Y: 1, Z: 0},
+	}
+
+	cases := []struct {
+		name    string
+		ray     ray
+		feature Feature
+	}{
+		{
+			name:    "interior hit",
+			ray:     ray{origin: r3.Point{X: 0.25, Y: 0.25, Z: -1}, direction: r3.Vec{X: 0, Y: 0, Z: 1}},
+			feature: FeatureFace,
+		},
+		{
+			name:    "edge P1-P2 hit",
+			ray:     ray{origin: r3.Point{X: 0.5, Y: 0.5, Z: -1}, direction: r3.Vec{X: 0, Y: 0, Z: 1}},
+			feature: FeatureEdge,
+		},
+		{
+			name:    "vertex P0 hit",
+			ray:     ray{origin: r3.Point{X: 0, Y: 0, Z: -1}, direction: r3.Vec{X: 0, Y: 0, Z: 1}},
+			feature: FeatureVertex,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			hit, coll := triangle.Collide(c.ray, 0, Distance(math.MaxFloat64))
+			if !hit {
+				t.Fatalf("expected hit")
+			}
+			if coll.feature != c.feature {
+				t.Errorf("feature = %v, want %v", coll.feature, c.feature)
+			}
+		})
+	}
+}
+
+// TestTriangleCollideWatertightAgreesWithCollide spot-checks that
+// CollideWatertight and Collide agree on hit/miss and intersection point
+// across the same cases TestTriangleCollide and TestTriangleCollideFeature
+// already cover for the Möller–Trumbore path.
+func TestTriangleCollideWatertightAgreesWithCollide(t *testing.T) {
+	triangle := Triangle{
+		P0: r3.Point{X: 0, Y: 0, Z: 0},
+		P1: r3.Point{X: 1, Y: 0, Z: 0},
+		P2: r3.Point{X: 0, Y: 1, Z: 0},
+	}
+
+	cases := []struct {
+		name string
+		ray  ray
+	}{
+		{"interior hit", ray{origin: r3.Point{X: 0.25, Y: 0.25, Z: -1}, direction: r3.Vec{X: 0, Y: 0, Z: 1}}},
+		{"edge P1-P2 hit", ray{origin: r3.Point{X: 0.5, Y: 0.5, Z: -1}, direction: r3.Vec{X: 0, Y: 0, Z: 1}}},
+		{"vertex P0 hit", ray{origin: r3.Point{X: 0, Y: 0, Z: -1}, direction: r3.Vec{X: 0, Y: 0, Z: 1}}},
+		{"miss outside", ray{origin: r3.Point{X: 1, Y: 1, Z: -1}, direction: r3.Vec{X: 0, Y: 0, Z: 1}}},
+		{"hit from behind", ray{origin: r3.Point{X: 0.25, Y: 0.25, Z: 1}, direction: r3.Vec{X: 0, Y: 0, Z: -1}}},
+		{"diagonal direction", ray{origin: r3.Point{X: -1, Y: -1, Z: -1}, direction: r3.Vec{X: 1.25, Y: 1.25, Z: 1}}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			wantHit, want := triangle.Collide(c.ray, 0, Distance(math.MaxFloat64))
+			gotHit, got := triangle.CollideWatertight(c.ray, 0, Distance(math.MaxFloat64))
+			if gotHit != wantHit {
+				t.Fatalf("CollideWatertight hit = %v, want %v", gotHit, wantHit)
+			}
+			if !gotHit {
+				return
+			}
+			if !got.at.IsClose(want.at, eps) {
+				t.Errorf("at = %v, want %v", got.at, want.at)
+			}
+			if !got.normal.IsClose(want.normal, eps) {
+				t.Errorf("normal = %v, want %v", got.normal, want.normal)
+			}
+		})
+	}
+}
+
+// FuzzTriangleCollideWatertightSharedEdge verifies the watertight property
+// CollideWatertight exists for: tessellating a unit quad into two triangles
+// two different ways (split along either diagonal), a ray aimed anywhere
+// within the quad's footprint must hit at least one of the two triangles in
+// each tessellation. A ray landing exactly on the shared diagonal may hit
+// both (the edge test is inclusive on both sides, by design, so that two
+// adjacent triangles never both miss it), but it must never hit neither,
+// which is the crack a plain Möller–Trumbore test can produce.
+func FuzzTriangleCollideWatertightSharedEdge(f *testing.F) {
+	f.Add(0.5, 0.5)
+	f.Add(0.0, 0.0)
+	f.Add(1.0, 0.0)
+	f.Add(0.0, 1.0)
+	f.Add(0.3, 0.7)
+
+	f.Fuzz(func(t *testing.T, x, y float64) {
+		if math.IsNaN(x) || math.IsNaN(y) || math.IsInf(x, 0) || math.IsInf(y, 0) {
+			return
+		}
+		// Map into [0, 1] so the ray always aims somewhere over the quad.
+		x = math.Mod(math.Abs(x), 1)
+		y = math.Mod(math.Abs(y), 1)
+
+		// Unit quad (0,0)-(1,0)-(1,1)-(0,1) in the XY-plane, split along
+		// each diagonal in turn.
+		p00 := r3.Point{X: 0, Y: 0, Z: 0}
+		p10 := r3.Point{X: 1, Y: 0, Z: 0}
+		p11 := r3.Point{X: 1, Y: 1, Z: 0}
+		p01 := r3.Point{X: 0, Y: 1, Z: 0}
+
+		tessellations := [][2]Triangle{
+			{ // split along the (0,0)-(1,1) diagonal
+				{P0: p00, P1: p10, P2: p11},
+				{P0: p00, P1: p11, P2: p01},
+			},
+			{ // split along the (1,0)-(0,1) diagonal
+				{P0: p00, P1: p10, P2: p01},
+				{P0: p10, P1: p11, P2: p01},
+			},
+		}
+
+		r := ray{origin: r3.Point{X: x, Y: y, Z: -1}, direction: r3.Vec{X: 0, Y: 0, Z: 1}}
+		for _, pair := range tessellations {
+			hits := 0
+			for _, tri := range pair {
+				if hit, _ := tri.CollideWatertight(r, 0, Distance(math.MaxFloat64)); hit {
+					hits++
+				}
+			}
+			if hits == 0 {
+				t.Errorf("ray at (%v, %v) hit 0 of the 2 triangles in a tessellation, want at least 1", x, y)
+			}
+		}
+	})
+}
+
+// TestTriangleCollideBackface verifies the three Backface policies handle a
+// ray hitting the triangle's back face as documented: BackfaceInclude hits
+// it unchanged, BackfaceCull reports a miss, and BackfaceFlipNormal hits it
+// but negates the returned normal.
+func TestTriangleCollideBackface(t *testing.T) {
+	triangle := Triangle{
+		P0: r3.Point{X: 0, Y: 0, Z: 0},
+		P1: r3.Point{X: 1, Y: 0, Z: 0},
+		P2: r3.Point{X: 0, Y: 1, Z: 0},
+	}
+	// Geometric normal is (0, 0, 1). backRay's direction dots positively
+	// with it, the back-face case the Backface doc comment describes;
+	// frontRay's direction dots negatively with it, approaching from the
+	// side the normal points toward.
+	backRay := ray{
+		origin:    r3.Point{X: 0.25, Y: 0.25, Z: -1},
+		direction: r3.Vec{X: 0, Y: 0, Z: 1},
+	}
+	frontRay := ray{
+		origin:    r3.Point{X: 0.25, Y: 0.25, Z: 1},
+		direction: r3.Vec{X: 0, Y: 0, Z: -1},
+	}
+	frontNormal := r3.Vec{X: 0, Y: 0, Z: 1}
+
+	t.Run("BackfaceInclude hits the back face with the unflipped normal", func(t *testing.T) {
+		tri := triangle
+		tri.Backface = BackfaceInclude
+		hit, coll := tri.Collide(backRay, 0, Distance(math.MaxFloat64))
+		if !hit {
+			t.Fatalf("expected hit")
+		}
+		if !coll.normal.IsClose(frontNormal, eps) {
+			t.Errorf("normal = %v, want %v (unflipped)", coll.normal, frontNormal)
+		}
+	})
+
+	t.Run("BackfaceCull reports a miss", func(t *testing.T) {
+		tri := triangle
+		tri.Backface = BackfaceCull
+		hit, _ := tri.Collide(backRay, 0, Distance(math.MaxFloat64))
+		if hit {
+			t.Errorf("expected miss for a back-face hit under BackfaceCull")
+		}
+		// A front-face hit is unaffected by culling.
+		hit, coll := tri.Collide(frontRay, 0, Distance(math.MaxFloat64))
+		if !hit {
+			t.Fatalf("expected hit for a front-face ray under BackfaceCull")
+		}
+		if !coll.normal.IsClose(frontNormal, eps) {
+			t.Errorf("normal = %v, want %v", coll.normal, frontNormal)
+		}
+	})
+
+	t.Run("BackfaceFlipNormal hits the back face with a flipped normal", func(t *testing.T) {
+		tri := triangle
+		tri.Backface = BackfaceFlipNormal
+		hit, coll := tri.Collide(backRay, 0, Distance(math.MaxFloat64))
+		if !hit {
+			t.Fatalf("expected hit")
+		}
+		want := frontNormal.Muls(-1)
+		if !coll.normal.IsClose(want, eps) {
+			t.Errorf("normal = %v, want %v (flipped to face the incoming ray)", coll.normal, want)
+		}
+	})
+}
+
+// TestTriangleRegistered verifies Triangle is registered for polymorphic
+// Shape (de)serialization, matching TriangleUV's registration.
+func TestTriangleRegistered(t *testing.T) {
+	registryMutex.RLock()
+	_, ok := typeRegistry["Triangle"]
+	registryMutex.RUnlock()
+	if !ok {
+		t.Error(`typeRegistry["Triangle"] missing, want it registered via init()`)
+	}
+}
@@ -112,6 +112,14 @@ func (tri TriangleUV) Validate() error {
 	return nil
 }
 
+// SignedDistance returns the distance from p to the closest point on the
+// triangle. A TriangleUV has no thickness and so no interior; the returned
+// distance is always non-negative.
+func (tri TriangleUV) SignedDistance(p r3.Point) Distance {
+	closest := closestPointOnTriangle(p, tri.P0, tri.P1, tri.P2)
+	return Distance(p.Sub(closest).Length())
+}
+
 // Collide determines whether a given ray intersects with the TriangleUV.
 // It also interpolates the UV coordinates at the intersection point.
 func (tri TriangleUV) Collide(r ray, tmin, tmax Distance) (bool, collision) {
@@ -141,11 +149,20 @@ func (tri TriangleUV) Collide(r ray, tmin, tmax Distance) (bool, collision) {
 	}
 	at := r.at(Distance(t))
 	interpolatedUV := tri.UV0.Lerp(tri.UV1, u).Add(tri.UV2.Muls(v))
+
+	frame := NewTangentFrameFromFace(Face{Vertex: [3]Vertex{
+		{Position: tri.P0, UV: tri.UV0},
+		{Position: tri.P1, UV: tri.UV1},
+		{Position: tri.P2, UV: tri.UV2},
+	}})
 	return true, collision{
-		t:      Distance(t),
-		at:     at,
-		normal: tri.Normal.Unit(),
-		uv:     interpolatedUV,
+		t:           Distance(t),
+		at:          at,
+		normal:      tri.Normal.Unit(),
+		uv:          interpolatedUV,
+		tangent:     frame.Tangent,
+		bitangent:   frame.Bitangent,
+		barycentric: r3.Vec{X: 1 - u - v, Y: u, Z: v},
 	}
 }
 
@@ -0,0 +1,45 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import (
+	"fmt"
+	"math"
+)
+
+// Shutter describes how long a virtual camera's shutter stays open
+// relative to one full exposure: Open is when it starts letting light in
+// and Duration is how long it stays open after that, both fractions of
+// the exposure in [0,1) -- the same normalized cycle AnimatedCamera.U and
+// an AnimatedInstance's T0/T1 pair already use. The zero value (Open=0,
+// Duration=0) is an infinitely fast shutter: every ray samples exactly
+// time 0, so Scene.Shutter has no effect unless a scene opts in by
+// setting Duration positive.
+type Shutter struct {
+	Open     float64
+	Duration float64
+}
+
+// Validate reports an error if sh.Duration is negative, which would make
+// sample's stratification run backward.
+func (sh Shutter) Validate() error {
+	if sh.Duration < 0 {
+		return fmt.Errorf("bad Shutter.Duration must be non-negative but got %v", sh.Duration)
+	}
+	return nil
+}
+
+// sample draws one stratified time value in [0,1) from sh's exposure
+// window for the index-th of total per-pixel samples: the [0,1) sample
+// budget is split into total equal strata so a pixel's repeated samples
+// cover the exposure evenly instead of clumping, jittered within its
+// stratum by rand, and mapped into [sh.Open, sh.Open+sh.Duration),
+// wrapping past 1 the same way AnimatedCamera.wrap01 does. A total less
+// than 1 is treated as 1 (a single, unstratified sample).
+func (sh Shutter) sample(index, total int, rand *Rand) float64 {
+	if total < 1 {
+		total = 1
+	}
+	stratum := (float64(index) + rand.Float64()) / float64(total)
+	t := sh.Open + stratum*sh.Duration
+	return t - math.Floor(t)
+}
@@ -0,0 +1,45 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import "testing"
+
+// TestShutterSampleStratifiesWithinWindow verifies that sample spreads its
+// index-th-of-total draws evenly across [Open, Open+Duration) rather than
+// clumping, and never strays outside that window.
+func TestShutterSampleStratifiesWithinWindow(t *testing.T) {
+	sh := Shutter{Open: 0.25, Duration: 0.5}
+	rand := NewRand(1)
+	const total = 8
+	for i := 0; i < total; i++ {
+		got := sh.sample(i, total, rand)
+		lo := sh.Open + float64(i)/float64(total)*sh.Duration
+		hi := sh.Open + float64(i+1)/float64(total)*sh.Duration
+		if got < lo-eps || got > hi+eps {
+			t.Errorf("sample(%d, %d) = %v, want in [%v, %v]", i, total, got, lo, hi)
+		}
+	}
+}
+
+// TestShutterSampleZeroDurationIsTimeZero verifies the zero-value Shutter
+// (an infinitely fast shutter) always samples exactly Open, so a scene
+// that never sets Duration renders as it did before motion blur existed.
+func TestShutterSampleZeroDurationIsTimeZero(t *testing.T) {
+	sh := Shutter{}
+	rand := NewRand(1)
+	for i := 0; i < 4; i++ {
+		if got := sh.sample(i, 4, rand); got != 0 {
+			t.Errorf("sample(%d, 4) = %v, want 0", i, got)
+		}
+	}
+}
+
+// TestShutterValidateRejectsNegativeDuration verifies Validate catches a
+// Duration that would make sample's stratification run backward.
+func TestShutterValidateRejectsNegativeDuration(t *testing.T) {
+	if err := (Shutter{Duration: -1}).Validate(); err == nil {
+		t.Errorf("Validate() = nil, want error for negative Duration")
+	}
+	if err := (Shutter{Duration: 0.5}).Validate(); err != nil {
+		t.Errorf("Validate() = %v, want nil", err)
+	}
+}
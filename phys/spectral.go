@@ -0,0 +1,134 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+// Package-level constants describing the visible range this renderer
+// samples over and how many wavelengths a path carries at once.
+const (
+	wavelengthMin = 380.0 // nm
+	wavelengthMax = 780.0 // nm
+
+	// spectralSamples is the number of stratified wavelengths carried per
+	// path: one hero wavelength plus evenly-rotated offsets, following
+	// the hero-wavelength spectral sampling scheme used by renderers such
+	// as clovers and pbrt-v4.
+	spectralSamples = 4
+)
+
+// SampledSpectrum is a spectrum represented by a small number of
+// stratified wavelength samples rather than an RGB triple. It is the unit
+// of exchange for materials that are inherently wavelength-dependent
+// (Dispersive, Blackbody, D65); ordinary RGB materials never need to
+// construct one directly.
+//
+// Zero value: a SampledSpectrum with all-zero Wavelengths is not a valid
+// sample set (0 nm is outside the visible range) and is used as the
+// sentinel for "no hero wavelength has been chosen yet" on a ray.
+type SampledSpectrum struct {
+	Wavelengths [spectralSamples]float64 // nm
+	Values      [spectralSamples]float64 // Spectral radiance (or reflectance) at each wavelength.
+}
+
+// NewHeroWavelengths stochastically picks a hero wavelength uniformly
+// over [wavelengthMin, wavelengthMax] and derives the remaining
+// spectralSamples-1 wavelengths by rotating evenly through the range, as
+// described by Wilkie et al., "Hero Wavelength Spectral Sampling"
+// (EGSR 2014). Rotating rather than independently sampling each
+// wavelength keeps the samples stratified and lets a single ray carry
+// all of them with a shared, uniform pdf.
+func NewHeroWavelengths(rand *Rand) [spectralSamples]float64 {
+	span := wavelengthMax - wavelengthMin
+	hero := wavelengthMin + rand.Float64()*span
+	var wavelengths [spectralSamples]float64
+	for i := range wavelengths {
+		w := hero + float64(i)*span/spectralSamples
+		if w > wavelengthMax {
+			w -= span
+		}
+		wavelengths[i] = w
+	}
+	return wavelengths
+}
+
+// pathRRStartDepth is the path depth after which tracePath makes every
+// path -- spectral or RGB -- eligible for russianRoulette termination.
+// Paths shallower than this always continue, since the extra cost of a
+// few guaranteed bounces is cheap next to the variance roulette would
+// add.
+const pathRRStartDepth = 3
+
+// russianRoulette probabilistically terminates a path, weighted by the
+// luminance of the radiance it currently carries, and rescales survivors by
+// 1/continueProb so the estimator stays unbiased. tracePath uses it past
+// pathRRStartDepth to cap the cost of long paths -- e.g. light bouncing
+// between several dielectric interfaces, or between several dispersive
+// ones -- without a hard depth cutoff, which would bias color toward
+// whichever paths happen to need fewer bounces.
+func russianRoulette(radiance Spectrum, rand *Rand) (survive bool, weight float64) {
+	luminance := 0.2126*radiance.X + 0.7152*radiance.Y + 0.0722*radiance.Z
+	continueProb := clamp(luminance, 0.05, 1.0)
+	if rand.Float64() > continueProb {
+		return false, 0
+	}
+	return true, 1 / continueProb
+}
+
+// hasWavelengths reports whether w holds a hero wavelength sample set,
+// as opposed to the zero value used by RGB-only paths.
+func hasWavelengths(w [spectralSamples]float64) bool {
+	return w[0] != 0
+}
+
+// wavelengthPDF is the probability density (per nm) of NewHeroWavelengths
+// having produced any one particular wavelength: sampling is uniform over
+// the visible range, so every wavelength shares the same density.
+func wavelengthPDF() float64 {
+	return 1 / (wavelengthMax - wavelengthMin)
+}
+
+// ToRGB converts the sampled spectrum to a linear RGB Spectrum by
+// averaging each sample's contribution to CIE XYZ (Monte Carlo
+// integration against the uniform wavelength pdf, normalized by
+// cieYIntegral so a flat, unit-valued spectrum maps to white) and then to
+// sRGB. This is the "MIS weight 1/pdf(lambda)" step: each of the
+// spectralSamples independent estimates of the integral is averaged,
+// which is the hero-wavelength estimator's balance-heuristic MIS weight
+// for uniformly-sampled wavelengths.
+func (s SampledSpectrum) ToRGB() Spectrum {
+	pdf := wavelengthPDF()
+	var x, y, z float64
+	for i := 0; i < spectralSamples; i++ {
+		cx, cy, cz := cieXYZ(s.Wavelengths[i])
+		weight := s.Values[i] / (pdf * cieYIntegral)
+		x += cx * weight
+		y += cy * weight
+		z += cz * weight
+	}
+	n := float64(spectralSamples)
+	return xyzToSRGB(x/n, y/n, z/n)
+}
+
+// RGBToSpectrum upsamples an RGB Spectrum into per-wavelength reflectance
+// values at wavelengths, providing the compatibility path that lets
+// existing RGB-only materials (DebugNormal, Lambertian, Metal, ...)
+// participate in a spectral path: whenever a hero-wavelength ray picks up
+// radiance from one of them, that RGB value is reinterpreted at the
+// path's specific wavelengths via this upsample instead of being treated
+// as achromatic.
+//
+// This is a simplified stand-in for the smooth, energy-preserving
+// upsampling methods of Meng et al. 2015 and Jakob & Hanika 2019 (which
+// fit a per-pixel sigmoid polynomial against a precomputed table): it
+// models R, G, and B as overlapping Gaussian bumps in wavelength space
+// centered near their perceptual peaks, which is smooth and gives
+// qualitatively correct dispersion colors without requiring a fitted
+// table shipped alongside the renderer.
+func RGBToSpectrum(rgb Spectrum, wavelengths [spectralSamples]float64) SampledSpectrum {
+	var s SampledSpectrum
+	for i, lambda := range wavelengths {
+		s.Wavelengths[i] = lambda
+		s.Values[i] = rgb.X*gaussianAsymmetric(lambda, 610, 50, 50) +
+			rgb.Y*gaussianAsymmetric(lambda, 550, 50, 50) +
+			rgb.Z*gaussianAsymmetric(lambda, 465, 50, 50)
+	}
+	return s
+}
@@ -0,0 +1,121 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import (
+	"math"
+	"testing"
+)
+
+// TestNewHeroWavelengthsWithinRange verifies all stratified samples land
+// inside the visible range and are evenly spaced from the hero sample.
+func TestNewHeroWavelengthsWithinRange(t *testing.T) {
+	rand := NewRand(1)
+	wavelengths := NewHeroWavelengths(rand)
+	for i, w := range wavelengths {
+		if w < wavelengthMin || w > wavelengthMax {
+			t.Errorf("wavelengths[%d] = %v, want in [%v, %v]", i, w, wavelengthMin, wavelengthMax)
+		}
+	}
+	if !hasWavelengths(wavelengths) {
+		t.Errorf("hasWavelengths(%v) = false, want true", wavelengths)
+	}
+}
+
+// TestHasWavelengthsZeroValue verifies the zero value is treated as
+// "unset", the sentinel Dispersive relies on to know when to sample a new
+// hero wavelength.
+func TestHasWavelengthsZeroValue(t *testing.T) {
+	var zero [spectralSamples]float64
+	if hasWavelengths(zero) {
+		t.Errorf("hasWavelengths(zero value) = true, want false")
+	}
+}
+
+// TestSampledSpectrumToRGBWhiteIsNeutral verifies that a spectrum with a
+// flat unit value at every sampled wavelength converges to a roughly
+// neutral (equal R, G, B) color, since the underlying light has no
+// spectral bias.
+func TestSampledSpectrumToRGBWhiteIsNeutral(t *testing.T) {
+	rand := NewRand(7)
+	const n = 200
+	var sum Spectrum
+	for i := 0; i < n; i++ {
+		wavelengths := NewHeroWavelengths(rand)
+		s := SampledSpectrum{Wavelengths: wavelengths}
+		for j := range s.Values {
+			s.Values[j] = 1
+		}
+		sum = sum.Add(s.ToRGB())
+	}
+	avg := sum.Divs(n)
+	maxComponent := math.Max(avg.X, math.Max(avg.Y, avg.Z))
+	minComponent := math.Min(avg.X, math.Min(avg.Y, avg.Z))
+	if maxComponent <= 0 {
+		t.Fatalf("averaged white spectrum has non-positive luminance: %v", avg)
+	}
+	if (maxComponent-minComponent)/maxComponent > 0.2 {
+		t.Errorf("averaged white spectrum is not roughly neutral: %v", avg)
+	}
+}
+
+// TestRussianRouletteAlwaysSurvivesBrightRadiance verifies a path carrying
+// full white radiance always survives roulette with weight 1, since its
+// continuation probability clamps to 1.
+func TestRussianRouletteAlwaysSurvivesBrightRadiance(t *testing.T) {
+	rand := NewRand(3)
+	for i := 0; i < 50; i++ {
+		survive, weight := russianRoulette(Spectrum{X: 1, Y: 1, Z: 1}, rand)
+		if !survive {
+			t.Fatalf("russianRoulette terminated a full-radiance path")
+		}
+		if weight != 1 {
+			t.Errorf("russianRoulette weight = %v, want 1 for full radiance", weight)
+		}
+	}
+}
+
+// TestRussianRouletteUnbiasedMean verifies that averaging many roulette
+// trials on a dim path reproduces the original radiance in expectation:
+// survivors are rescaled by 1/continueProb, and non-survivors contribute 0.
+func TestRussianRouletteUnbiasedMean(t *testing.T) {
+	rand := NewRand(11)
+	radiance := Spectrum{X: 0.1, Y: 0.1, Z: 0.1}
+	const n = 20000
+	var sum float64
+	for i := 0; i < n; i++ {
+		survive, weight := russianRoulette(radiance, rand)
+		if survive {
+			sum += radiance.X * weight
+		}
+	}
+	mean := sum / n
+	if math.Abs(mean-radiance.X) > 0.02 {
+		t.Errorf("mean surviving radiance = %v, want close to %v", mean, radiance.X)
+	}
+}
+
+// TestSpectralUniformAtWavelengthsUpsamplesColor verifies SpectralUniform
+// delegates to RGBToSpectrum, ignoring (u, v).
+func TestSpectralUniformAtWavelengthsUpsamplesColor(t *testing.T) {
+	tex := SpectralUniform{Color: Spectrum{X: 1, Y: 0, Z: 0}}
+	wavelengths := [spectralSamples]float64{610, 610, 610, 610}
+	got := tex.AtWavelengths(0.3, 0.7, wavelengths)
+	want := RGBToSpectrum(tex.Color, wavelengths)
+	if got != want {
+		t.Errorf("AtWavelengths(0.3, 0.7, ...) = %v, want %v", got, want)
+	}
+}
+
+// TestRGBToSpectrumPreservesDominantChannel verifies the compatibility
+// upsample path weights the reflectance at a wavelength toward whichever
+// RGB channel peaks near that wavelength.
+func TestRGBToSpectrumPreservesDominantChannel(t *testing.T) {
+	red := Spectrum{X: 1, Y: 0, Z: 0}
+	wavelengths := [spectralSamples]float64{610, 610, 610, 610} // Red's peak.
+	s := RGBToSpectrum(red, wavelengths)
+	for i, v := range s.Values {
+		if v <= 0 {
+			t.Errorf("Values[%d] = %v, want > 0 for pure red at its peak wavelength", i, v)
+		}
+	}
+}
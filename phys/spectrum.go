@@ -49,6 +49,13 @@ func (s Spectrum) ToColor() color.Color {
 	}
 }
 
+// Luminance returns the Rec. 709 relative luminance of s, treating X, Y,
+// and Z as linear sRGB red, green, and blue. Used where a single scalar
+// brightness is wanted, e.g. the adaptive sampler's variance estimate.
+func (s Spectrum) Luminance() float64 {
+	return 0.2126*s.X + 0.7152*s.Y + 0.0722*s.Z
+}
+
 // String returns a string representation of the spectrum.
 func (s Spectrum) String() string {
 	return r3.Vec(s).String()
@@ -0,0 +1,147 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package store
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/phys"
+)
+
+const (
+	sceneKeyPrefix    = "scene:"
+	artifactKeyPrefix = "artifact:"
+	tileKeyPrefix     = "tile:"
+)
+
+// Checkpointer layers phys.Scene/phys.RenderArtifact persistence, keyed
+// by content hash, on top of a plain Store: PutScene/GetScene and
+// PutArtifact/GetArtifact cache whole renders, while OnTile/ResumeTile
+// build the hooks phys.RenderOptions.OnTile and .ResumeTile expect, so a
+// render crashed mid-way can pick back up from whatever tiles it already
+// finished instead of starting over.
+type Checkpointer struct {
+	Store Store
+}
+
+// NewCheckpointer returns a Checkpointer backed by s.
+func NewCheckpointer(s Store) *Checkpointer {
+	return &Checkpointer{Store: s}
+}
+
+// PutScene stores sceneJSON under its content hash and returns that hash.
+func (c *Checkpointer) PutScene(sceneJSON []byte) (string, error) {
+	hash := HashBytes(sceneJSON)
+	if err := c.Store.Put(sceneKeyPrefix+hash, sceneJSON); err != nil {
+		return "", fmt.Errorf("store: put scene %s: %w", hash, err)
+	}
+	return hash, nil
+}
+
+// GetScene returns the scene JSON stored under hash.
+func (c *Checkpointer) GetScene(hash string) ([]byte, error) {
+	return c.Store.Get(sceneKeyPrefix + hash)
+}
+
+// artifactRecord is the JSON shape PutArtifact/GetArtifact persist under
+// a hash: the rendered image PNG-encoded alongside its RenderStats, so a
+// retrieval doesn't need a second round trip to recover ray counts/timing.
+type artifactRecord struct {
+	PNG   []byte           `json:"png"`
+	Stats phys.RenderStats `json:"stats"`
+}
+
+// PutArtifact PNG-encodes artifact.Image and stores it with artifact.Stats
+// under hash.
+func (c *Checkpointer) PutArtifact(hash string, artifact phys.RenderArtifact) error {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, artifact.Image); err != nil {
+		return fmt.Errorf("store: encode artifact %s: %w", hash, err)
+	}
+	data, err := json.Marshal(artifactRecord{PNG: buf.Bytes(), Stats: artifact.Stats})
+	if err != nil {
+		return fmt.Errorf("store: marshal artifact %s: %w", hash, err)
+	}
+	if err := c.Store.Put(artifactKeyPrefix+hash, data); err != nil {
+		return fmt.Errorf("store: put artifact %s: %w", hash, err)
+	}
+	return nil
+}
+
+// GetArtifact returns the decoded image and RenderStats stored under hash.
+func (c *Checkpointer) GetArtifact(hash string) (image.Image, phys.RenderStats, error) {
+	data, err := c.Store.Get(artifactKeyPrefix + hash)
+	if err != nil {
+		return nil, phys.RenderStats{}, err
+	}
+	var rec artifactRecord
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return nil, phys.RenderStats{}, fmt.Errorf("store: unmarshal artifact %s: %w", hash, err)
+	}
+	img, err := png.Decode(bytes.NewReader(rec.PNG))
+	if err != nil {
+		return nil, phys.RenderStats{}, fmt.Errorf("store: decode artifact %s: %w", hash, err)
+	}
+	return img, rec.Stats, nil
+}
+
+// tileKey identifies the checkpoint for one tile of one scene: tile
+// bounds are deterministic given a scene's Dx/Dy/TileSize, so (x0, y0,
+// x1, y1) alone is enough to recognize "the same tile" across restarts.
+func tileKey(hash string, x0, y0, x1, y1 int) string {
+	return fmt.Sprintf("%s%s:%d,%d,%d,%d", tileKeyPrefix, hash, x0, y0, x1, y1)
+}
+
+// OnTile returns a phys.RenderOptions.OnTile callback that checkpoints
+// every finished tile of the scene identified by hash.
+func (c *Checkpointer) OnTile(hash string) func(phys.TileResult) error {
+	return func(res phys.TileResult) error {
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, res.Image); err != nil {
+			return fmt.Errorf("store: encode tile checkpoint: %w", err)
+		}
+		key := tileKey(hash, res.X, res.Y, res.X+res.W, res.Y+res.H)
+		if err := c.Store.Put(key, buf.Bytes()); err != nil {
+			return fmt.Errorf("store: put tile checkpoint: %w", err)
+		}
+		return nil
+	}
+}
+
+// ResumeTile returns a phys.RenderOptions.ResumeTile callback that serves
+// previously checkpointed tiles of the scene identified by hash, so
+// re-rendering that scene (e.g. after the process restarts mid-render)
+// skips whatever OnTile already saved.
+func (c *Checkpointer) ResumeTile(hash string) func(x0, y0, x1, y1 int) (image.Image, bool) {
+	return func(x0, y0, x1, y1 int) (image.Image, bool) {
+		data, err := c.Store.Get(tileKey(hash, x0, y0, x1, y1))
+		if err != nil {
+			return nil, false
+		}
+		img, err := png.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, false
+		}
+		return img, true
+	}
+}
+
+// ClearTiles removes every tile checkpoint stored for hash, once its
+// render has finished and PutArtifact has cached the whole result, so
+// completed renders don't leave their per-tile checkpoints behind.
+func (c *Checkpointer) ClearTiles(hash string) error {
+	keys, err := c.Store.List(fmt.Sprintf("%s%s:", tileKeyPrefix, hash))
+	if err != nil {
+		return fmt.Errorf("store: list tile checkpoints for %s: %w", hash, err)
+	}
+	for _, key := range keys {
+		if err := c.Store.Delete(key); err != nil && !errors.Is(err, ErrNotFound) {
+			return fmt.Errorf("store: delete tile checkpoint %s: %w", key, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+
+// Package store persists submitted phys.Scene JSON documents and their
+// resulting phys.RenderArtifacts in a key/value backend, keyed by a
+// content hash of the scene JSON, so a server can short-circuit repeated
+// renders of a scene it's already seen and a long render can checkpoint
+// its finished tiles for a crashed process to resume instead of starting
+// over. The Store interface is the low-level KV contract; Checkpointer
+// builds scene/artifact/tile-checkpoint semantics on top of it.
+package store
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+)
+
+// ErrNotFound is returned by Store.Get and Store.Delete when key isn't
+// present.
+var ErrNotFound = errors.New("store: key not found")
+
+// Store is a minimal embedded key/value backend. Keys are opaque byte
+// strings; List returns every key with the given prefix, in sorted
+// order, so a caller can range-scan (e.g. every checkpointed tile for one
+// scene hash) without the Store needing to know anything about how its
+// callers structure their keys.
+type Store interface {
+	// Put writes value under key, replacing any existing value.
+	Put(key string, value []byte) error
+	// Get returns the value stored under key, or ErrNotFound if absent.
+	Get(key string) ([]byte, error)
+	// Delete removes key, or returns ErrNotFound if it wasn't present.
+	Delete(key string) error
+	// List returns every key with the given prefix, sorted ascending.
+	List(prefix string) ([]string, error)
+}
+
+// HashBytes returns the hex-encoded SHA-256 digest of data, used to key
+// scenes and artifacts by content so identical submissions collide onto
+// the same entry instead of accumulating duplicates.
+func HashBytes(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
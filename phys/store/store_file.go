@@ -0,0 +1,88 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package store
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// FileStore is a Store that persists each value as its own file under a
+// directory on disk, so entries survive a process restart. This module
+// takes no dependencies outside the standard library, so rather than
+// embed a third-party single-file KV engine, FileStore gets the same
+// "one durable store directory" contract by hex-encoding each key into a
+// filename -- arbitrary keys (including ones containing "/") can't escape
+// baseDir or collide with each other this way.
+type FileStore struct {
+	baseDir string
+}
+
+// NewFileStore returns a FileStore rooted at baseDir, creating it (and
+// any missing parents) if it doesn't already exist.
+func NewFileStore(baseDir string) (*FileStore, error) {
+	if err := os.MkdirAll(baseDir, 0o755); err != nil {
+		return nil, fmt.Errorf("store: create %s: %w", baseDir, err)
+	}
+	return &FileStore{baseDir: baseDir}, nil
+}
+
+func (f *FileStore) path(key string) string {
+	return filepath.Join(f.baseDir, hex.EncodeToString([]byte(key)))
+}
+
+func (f *FileStore) Put(key string, value []byte) error {
+	if err := os.WriteFile(f.path(key), value, 0o644); err != nil {
+		return fmt.Errorf("store: put %s: %w", key, err)
+	}
+	return nil
+}
+
+func (f *FileStore) Get(key string) ([]byte, error) {
+	data, err := os.ReadFile(f.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("store: get %s: %w", key, err)
+	}
+	return data, nil
+}
+
+func (f *FileStore) Delete(key string) error {
+	err := os.Remove(f.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("store: delete %s: %w", key, err)
+	}
+	return nil
+}
+
+func (f *FileStore) List(prefix string) ([]string, error) {
+	entries, err := os.ReadDir(f.baseDir)
+	if err != nil {
+		return nil, fmt.Errorf("store: list %s: %w", f.baseDir, err)
+	}
+	var keys []string
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		raw, err := hex.DecodeString(e.Name())
+		if err != nil {
+			continue // not one of ours
+		}
+		key := string(raw)
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
@@ -0,0 +1,176 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+
+package store
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/phys"
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
+)
+
+// testStores returns one of each Store implementation, so the generic
+// Store behavior tests below run against both.
+func testStores(t *testing.T) map[string]Store {
+	t.Helper()
+	fileStore, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileStore: %v", err)
+	}
+	return map[string]Store{
+		"MemStore":  NewMemStore(),
+		"FileStore": fileStore,
+	}
+}
+
+func TestStorePutGetDelete(t *testing.T) {
+	for name, s := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			if _, err := s.Get("missing"); err != ErrNotFound {
+				t.Fatalf("Get(missing) = %v, want ErrNotFound", err)
+			}
+			if err := s.Put("a", []byte("hello")); err != nil {
+				t.Fatalf("Put: %v", err)
+			}
+			got, err := s.Get("a")
+			if err != nil || string(got) != "hello" {
+				t.Fatalf("Get(a) = %q, %v, want \"hello\", nil", got, err)
+			}
+			if err := s.Put("a", []byte("updated")); err != nil {
+				t.Fatalf("Put overwrite: %v", err)
+			}
+			got, _ = s.Get("a")
+			if string(got) != "updated" {
+				t.Fatalf("Get(a) after overwrite = %q, want \"updated\"", got)
+			}
+			if err := s.Delete("a"); err != nil {
+				t.Fatalf("Delete: %v", err)
+			}
+			if err := s.Delete("a"); err != ErrNotFound {
+				t.Fatalf("Delete(already gone) = %v, want ErrNotFound", err)
+			}
+		})
+	}
+}
+
+func TestStoreListReturnsSortedPrefixMatches(t *testing.T) {
+	for name, s := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			for _, k := range []string{"tile:abc:2,0", "tile:abc:1,0", "scene:abc", "tile:xyz:0,0"} {
+				if err := s.Put(k, []byte("v")); err != nil {
+					t.Fatalf("Put(%s): %v", k, err)
+				}
+			}
+			got, err := s.List("tile:abc:")
+			if err != nil {
+				t.Fatalf("List: %v", err)
+			}
+			want := []string{"tile:abc:1,0", "tile:abc:2,0"}
+			if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+				t.Fatalf("List(tile:abc:) = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestCheckpointerSceneAndArtifactRoundTrip(t *testing.T) {
+	c := NewCheckpointer(NewMemStore())
+	sceneJSON := []byte(`{"Node":[],"Camera":null}`)
+
+	hash, err := c.PutScene(sceneJSON)
+	if err != nil {
+		t.Fatalf("PutScene: %v", err)
+	}
+	got, err := c.GetScene(hash)
+	if err != nil || string(got) != string(sceneJSON) {
+		t.Fatalf("GetScene = %q, %v, want %q, nil", got, err, sceneJSON)
+	}
+
+	artifact := phys.RenderArtifact{
+		Image: testRGBA(4, 4),
+		Stats: phys.RenderStats{TotalRays: 42, Dx: 4, Dy: 4},
+	}
+	if err := c.PutArtifact(hash, artifact); err != nil {
+		t.Fatalf("PutArtifact: %v", err)
+	}
+	img, stats, err := c.GetArtifact(hash)
+	if err != nil {
+		t.Fatalf("GetArtifact: %v", err)
+	}
+	if stats.TotalRays != 42 {
+		t.Errorf("GetArtifact stats.TotalRays = %d, want 42", stats.TotalRays)
+	}
+	if img.Bounds().Dx() != 4 || img.Bounds().Dy() != 4 {
+		t.Errorf("GetArtifact image bounds = %v, want 4x4", img.Bounds())
+	}
+}
+
+func testRGBA(dx, dy int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, dx, dy))
+	for y := 0; y < dy; y++ {
+		for x := 0; x < dx; x++ {
+			img.Set(x, y, color.RGBA{R: 128, G: 64, B: 32, A: 255})
+		}
+	}
+	return img
+}
+
+func testScene(t *testing.T) *phys.Scene {
+	t.Helper()
+	return &phys.Scene{
+		RenderOptions: phys.RenderOptions{Seed: 1, RaysPerPixel: 2, MaxRayDepth: 2, Dx: 8, Dy: 8, TileSize: 4},
+		Camera: []phys.Camera{phys.OrthographicCamera{
+			LookFrom:  r3.Point{X: 0, Y: 0, Z: -5},
+			LookAt:    r3.Point{X: 0, Y: 0, Z: 0},
+			VUp:       r3.Vec{Y: 1},
+			FOVHeight: 4,
+			FOVWidth:  4,
+		}},
+		Node: []phys.Node{{
+			Name:     "ball",
+			Shape:    phys.Sphere{Center: r3.Point{X: 0, Y: 0, Z: 0}, Radius: 1},
+			Material: phys.Diffuse{Texture: phys.TextureUniform{Color: phys.Spectrum{X: 0.8, Y: 0.2, Z: 0.2}}, BRDF: phys.LambertianBRDF{}},
+		}},
+		Light: []phys.Light{phys.PointLight{Position: r3.Point{X: 2, Y: 3, Z: -3}, RadiantIntensity: r3.Vec{X: 40, Y: 40, Z: 40}}},
+	}
+}
+
+// TestCheckpointerResumeSkipsFinishedTiles renders a scene once with
+// OnTile checkpointing every tile, then renders it again from scratch
+// with ResumeTile wired to the same checkpoints: the second render
+// should trace zero additional rays, since every tile is served from the
+// checkpoint instead of being re-traced.
+func TestCheckpointerResumeSkipsFinishedTiles(t *testing.T) {
+	scene := testScene(t)
+	if err := scene.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	c := NewCheckpointer(NewMemStore())
+	hash := HashBytes([]byte("test-scene"))
+
+	first := *scene
+	first.RenderOptions.OnTile = c.OnTile(hash)
+	artifact1, err := phys.Render(context.Background(), &first)
+	if err != nil {
+		t.Fatalf("first Render: %v", err)
+	}
+	if artifact1.Stats.TotalRays == 0 {
+		t.Fatalf("first Render traced 0 rays, test scene is misconfigured")
+	}
+
+	second := *scene
+	second.RenderOptions.ResumeTile = c.ResumeTile(hash)
+	artifact2, err := phys.Render(context.Background(), &second)
+	if err != nil {
+		t.Fatalf("second Render: %v", err)
+	}
+	if artifact2.Stats.TotalRays != 0 {
+		t.Errorf("second Render traced %d rays, want 0 (every tile should have resumed from checkpoint)", artifact2.Stats.TotalRays)
+	}
+	if artifact2.Image.Bounds() != artifact1.Image.Bounds() {
+		t.Errorf("resumed image bounds = %v, want %v", artifact2.Image.Bounds(), artifact1.Image.Bounds())
+	}
+}
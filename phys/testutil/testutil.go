@@ -0,0 +1,134 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+
+// Package testutil provides a golden-image regression harness for phys:
+// render a canonical scene file at a fixed RenderOptions.Seed and diff
+// the result against a PNG checked into testdata/, catching subtle
+// BRDF/geometry regressions a scalar unit test would miss. It lives in
+// its own package (rather than inside phys's own _test.go files) so a
+// golden test can import it without an import cycle; see golden_test.go.
+package testutil
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"testing"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/phys"
+)
+
+// UpdateEnvVar, when set to a non-empty value, makes AssertGolden
+// overwrite goldenPath with the scene's freshly rendered image instead
+// of comparing against it -- the way to (re)create a golden file after
+// an intentional rendering change:
+//
+//	PHYS_GOLDEN_UPDATE=1 go test ./phys/... -run TestGolden
+const UpdateEnvVar = "PHYS_GOLDEN_UPDATE"
+
+// RenderGolden loads scenePath with phys.LoadScene and renders it. The
+// scene's RenderOptions.Seed must be pinned (not left at its zero value
+// only by coincidence) for the result to be reproducible across runs.
+func RenderGolden(ctx context.Context, scenePath string) (*image.RGBA, error) {
+	scene, err := phys.LoadScene(scenePath)
+	if err != nil {
+		return nil, err
+	}
+	art, err := phys.Render(ctx, scene)
+	if err != nil {
+		return nil, fmt.Errorf("testutil.RenderGolden: %v", err)
+	}
+	return art.Image, nil
+}
+
+// AssertGolden renders scenePath and fails t if the result differs from
+// the PNG at goldenPath by more than tolerance (0-255) in any pixel's
+// R/G/B channel. Set UpdateEnvVar to regenerate goldenPath from the
+// current render instead of comparing against it.
+func AssertGolden(t testing.TB, ctx context.Context, scenePath, goldenPath string, tolerance int) {
+	t.Helper()
+	got, err := RenderGolden(ctx, scenePath)
+	if err != nil {
+		t.Fatalf("testutil.AssertGolden(%s): %v", scenePath, err)
+		return
+	}
+	if os.Getenv(UpdateEnvVar) != "" {
+		if err := writePNG(goldenPath, got); err != nil {
+			t.Fatalf("testutil.AssertGolden(%s): writing golden: %v", scenePath, err)
+		}
+		return
+	}
+	want, err := readPNG(goldenPath)
+	if err != nil {
+		t.Fatalf("testutil.AssertGolden(%s): reading golden %s: %v (set %s=1 to create it)", scenePath, goldenPath, err, UpdateEnvVar)
+		return
+	}
+	if diff := ComparePNG(got, want, tolerance); diff != "" {
+		t.Errorf("testutil.AssertGolden(%s): %s", scenePath, diff)
+	}
+}
+
+// ComparePNG reports a human-readable description of how got differs
+// from want, or "" if every pixel's R/G/B channel (alpha is ignored) is
+// within tolerance (0-255) of the other. got and want must share bounds.
+func ComparePNG(got, want image.Image, tolerance int) string {
+	gb, wb := got.Bounds(), want.Bounds()
+	if gb != wb {
+		return fmt.Sprintf("image bounds differ: got %v, want %v", gb, wb)
+	}
+	var worst, mismatched int
+	for y := gb.Min.Y; y < gb.Max.Y; y++ {
+		for x := gb.Min.X; x < gb.Max.X; x++ {
+			gr, gg, gbv, _ := got.At(x, y).RGBA()
+			wr, wg, wbv, _ := want.At(x, y).RGBA()
+			d := channelDelta(gr, wr)
+			if dg := channelDelta(gg, wg); dg > d {
+				d = dg
+			}
+			if db := channelDelta(gbv, wbv); db > d {
+				d = db
+			}
+			if d > worst {
+				worst = d
+			}
+			if d > tolerance {
+				mismatched++
+			}
+		}
+	}
+	if mismatched == 0 {
+		return ""
+	}
+	total := gb.Dx() * gb.Dy()
+	return fmt.Sprintf("%d/%d pixels exceeded tolerance %d (worst channel delta %d)", mismatched, total, tolerance, worst)
+}
+
+// channelDelta returns the absolute 8-bit difference between two
+// image/color.Color channel values, which RGBA() returns premultiplied
+// and scaled to 16 bits.
+func channelDelta(a, b uint32) int {
+	d := int(a>>8) - int(b>>8)
+	if d < 0 {
+		return -d
+	}
+	return d
+}
+
+func readPNG(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return png.Decode(f)
+}
+
+func writePNG(path string, img image.Image) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return png.Encode(f, img)
+}
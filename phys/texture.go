@@ -1,6 +1,8 @@
 // Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
 package phys
 
+import "github.com/scottlawsonbc/slam/code/photon/raytrace/r2"
+
 type Texture interface {
 	// At returns the color of the texture at the given UV coordinates.
 	At(u, v float64) Spectrum
@@ -8,7 +10,33 @@ type Texture interface {
 	Validate() error
 }
 
+// TextureFootprintSampler is implemented by textures that can filter
+// against an explicit UV-space footprint (e.g. TextureImage's mipmapped
+// "trilinear"/"anisotropic" Interp modes) instead of always point-sampling
+// the base resolution. It's an optional interface, mirroring
+// ShadingHintsMaterial: a texture that doesn't implement it is still
+// usable via At, just without mip-aware antialiasing.
+type TextureFootprintSampler interface {
+	// AtFootprint returns the filtered color at (u, v) given footprint, the
+	// approximate size of one pixel's projection in UV space (du, dv). A
+	// zero footprint means "no estimate available" and should behave like
+	// At.
+	AtFootprint(u, v float64, footprint r2.Point) Spectrum
+}
+
+// textureAt samples tex at (u, v), using footprint-aware filtering via
+// TextureFootprintSampler when tex implements it and footprint is
+// meaningful, and falling back to plain At otherwise. Material
+// implementations use this instead of calling tex.At directly so that a
+// mipmapped TextureImage gets antialiased without every material needing
+// its own type switch.
+func textureAt(tex Texture, u, v float64, footprint r2.Point) Spectrum {
+	if fs, ok := tex.(TextureFootprintSampler); ok {
+		return fs.AtFootprint(u, v, footprint)
+	}
+	return tex.At(u, v)
+}
+
 // TODO: rework how assets are retrieved and make it work in a way that can
 // translate to other formats like glTF. Whatever solution I end up with
 // should end up being some version of an asset loader.
-
@@ -0,0 +1,171 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
+)
+
+func init() {
+	RegisterInterfaceType(TextureBump{})
+	RegisterInterfaceType(TextureNormal{})
+}
+
+// bumpDelta is the finite-difference step, in UV units, used to estimate
+// dh/du and dh/dv from neighboring texel samples of a TextureBump's height
+// field.
+const bumpDelta = 1e-3
+
+// TextureBump perturbs a shading normal using a scalar height field h(u,v),
+// sampled from Height's luminance (its Y channel, following the Spectrum
+// convention used elsewhere for grayscale textures). BumpScale controls how
+// strongly the height gradient tilts the normal; the zero value leaves
+// normals unperturbed.
+type TextureBump struct {
+	Height    Texture
+	BumpScale float64
+}
+
+// Perturb tilts frame.Normal by the height field's gradient at (u, v),
+// following the standard bump-mapping construction:
+//
+//	N' = normalize(N - (dh/du)*(N×B) - (dh/dv)*(T×N))
+//
+// It returns an error if the estimated derivatives are not finite, which
+// would otherwise silently propagate NaN/Inf into the shading normal.
+func (tex TextureBump) Perturb(frame TangentFrame, u, v float64) (r3.Vec, error) {
+	h := tex.Height.At(u, v).Y
+	hu := tex.Height.At(u+bumpDelta, v).Y
+	hv := tex.Height.At(u, v+bumpDelta).Y
+	dhdu := (hu - h) / bumpDelta
+	dhdv := (hv - h) / bumpDelta
+	if math.IsNaN(dhdu) || math.IsInf(dhdu, 0) || math.IsNaN(dhdv) || math.IsInf(dhdv, 0) {
+		return r3.Vec{}, fmt.Errorf("phys: TextureBump height-field derivative not finite at (u=%v, v=%v)", u, v)
+	}
+	n, t, b := frame.Normal, frame.Tangent, frame.Bitangent
+	perturbed := n.Sub(n.Cross(b).Muls(dhdu * tex.BumpScale)).Sub(t.Cross(n).Muls(dhdv * tex.BumpScale))
+	if perturbed.IsZero() {
+		return n, nil
+	}
+	return perturbed.Unit(), nil
+}
+
+func (tex TextureBump) Validate() error {
+	if tex.Height == nil {
+		return fmt.Errorf("phys: TextureBump.Height must not be nil")
+	}
+	return tex.Height.Validate()
+}
+
+type textureBumpData struct {
+	Type      string          `json:"Type"`
+	Height    json.RawMessage `json:"Height"`
+	BumpScale float64         `json:"BumpScale"`
+}
+
+func (tex TextureBump) MarshalJSON() ([]byte, error) {
+	heightData, err := marshalInterface(tex.Height)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(textureBumpData{Type: "TextureBump", Height: heightData, BumpScale: tex.BumpScale})
+}
+
+func (tex *TextureBump) UnmarshalJSON(data []byte) error {
+	var temp textureBumpData
+	if err := json.Unmarshal(data, &temp); err != nil {
+		return err
+	}
+	if temp.Type != "TextureBump" {
+		return fmt.Errorf("invalid type: expected TextureBump, got %s", temp.Type)
+	}
+	height, err := unmarshalInterface(temp.Height)
+	if err != nil {
+		return err
+	}
+	tex.Height = height.(Texture)
+	tex.BumpScale = temp.BumpScale
+	return nil
+}
+
+// TextureNormal perturbs a shading normal by sampling Normal as a
+// tangent-space normal map: an RGB texture whose channels encode
+// (nx, ny, nz) in [-1, 1] (the conventional OpenGL tangent-space encoding,
+// here stored unpacked rather than as [0,1]-biased 8-bit color).
+type TextureNormal struct {
+	Normal Texture
+}
+
+// Perturb rotates the tangent-space normal sampled from tex.Normal at
+// (u, v) into world space via M = [T, B, N].
+func (tex TextureNormal) Perturb(frame TangentFrame, u, v float64) r3.Vec {
+	c := tex.Normal.At(u, v)
+	local := r3.Vec{X: c.X, Y: c.Y, Z: c.Z}
+	world := frame.Tangent.Muls(local.X).Add(frame.Bitangent.Muls(local.Y)).Add(frame.Normal.Muls(local.Z))
+	if world.IsZero() {
+		return frame.Normal
+	}
+	return world.Unit()
+}
+
+func (tex TextureNormal) Validate() error {
+	if tex.Normal == nil {
+		return fmt.Errorf("phys: TextureNormal.Normal must not be nil")
+	}
+	return tex.Normal.Validate()
+}
+
+type textureNormalData struct {
+	Type   string          `json:"Type"`
+	Normal json.RawMessage `json:"Normal"`
+}
+
+func (tex TextureNormal) MarshalJSON() ([]byte, error) {
+	normalData, err := marshalInterface(tex.Normal)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(textureNormalData{Type: "TextureNormal", Normal: normalData})
+}
+
+func (tex *TextureNormal) UnmarshalJSON(data []byte) error {
+	var temp textureNormalData
+	if err := json.Unmarshal(data, &temp); err != nil {
+		return err
+	}
+	if temp.Type != "TextureNormal" {
+		return fmt.Errorf("invalid type: expected TextureNormal, got %s", temp.Type)
+	}
+	normal, err := unmarshalInterface(temp.Normal)
+	if err != nil {
+		return err
+	}
+	tex.Normal = normal.(Texture)
+	return nil
+}
+
+// TangentBasis returns the TangentFrame spanning c's tangent plane, letting
+// a material rotate a tangent-space quantity -- a TextureNormal sample, or
+// any other per-texel vector -- into world space at the hit point.
+func (c collision) TangentBasis() TangentFrame {
+	return TangentFrame{Tangent: c.tangent, Bitangent: c.bitangent, Normal: c.normal}
+}
+
+// perturbNormal applies whichever of bump or normalMap is non-nil to frame,
+// sampled at (u, v), and returns the resulting shading normal. It returns
+// frame.Normal unchanged if both are nil, and returns an error only in the
+// TextureBump case, where a non-finite height-field derivative indicates a
+// broken height texture.
+func perturbNormal(frame TangentFrame, u, v float64, bump *TextureBump, normalMap *TextureNormal) (r3.Vec, error) {
+	switch {
+	case normalMap != nil:
+		return normalMap.Perturb(frame, u, v), nil
+	case bump != nil:
+		return bump.Perturb(frame, u, v)
+	default:
+		return frame.Normal, nil
+	}
+}
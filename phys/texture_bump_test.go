@@ -0,0 +1,108 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import (
+	"math"
+	"testing"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
+)
+
+// heightTexture is a Texture whose value is a deterministic function of
+// (u, v), used to exercise TextureBump's finite-difference derivatives.
+type heightTexture struct{ slopeU, slopeV float64 }
+
+func (h heightTexture) At(u, v float64) Spectrum {
+	y := h.slopeU*u + h.slopeV*v
+	return Spectrum{X: y, Y: y, Z: y}
+}
+
+func (h heightTexture) Validate() error { return nil }
+
+// TestTextureBumpPerturbFlat verifies that a height field with zero slope
+// leaves the normal unchanged.
+func TestTextureBumpPerturbFlat(t *testing.T) {
+	frame := NewTangentFrame(r3.Vec{X: 0, Y: 0, Z: 1})
+	tex := TextureBump{Height: heightTexture{}, BumpScale: 1}
+	got, err := tex.Perturb(frame, 0.5, 0.5)
+	if err != nil {
+		t.Fatalf("Perturb returned error for a flat height field: %v", err)
+	}
+	if !got.IsClose(frame.Normal, eps) {
+		t.Errorf("Perturb on a flat height field: got %v, want %v", got, frame.Normal)
+	}
+}
+
+// TestTextureBumpPerturbTilts verifies that a sloped height field tilts the
+// normal and that BumpScale == 0 leaves it unperturbed.
+func TestTextureBumpPerturbTilts(t *testing.T) {
+	frame := NewTangentFrame(r3.Vec{X: 0, Y: 0, Z: 1})
+	tex := TextureBump{Height: heightTexture{slopeU: 1}, BumpScale: 1}
+	got, err := tex.Perturb(frame, 0.5, 0.5)
+	if err != nil {
+		t.Fatalf("Perturb returned unexpected error: %v", err)
+	}
+	if got.IsClose(frame.Normal, eps) {
+		t.Errorf("Perturb with a sloped height field did not tilt the normal: got %v", got)
+	}
+	if math.Abs(got.Length()-1) > eps {
+		t.Errorf("Perturb returned a non-unit normal: %v, length %v", got, got.Length())
+	}
+
+	flat := TextureBump{Height: heightTexture{slopeU: 1}, BumpScale: 0}
+	unchanged, err := flat.Perturb(frame, 0.5, 0.5)
+	if err != nil {
+		t.Fatalf("Perturb returned unexpected error: %v", err)
+	}
+	if !unchanged.IsClose(frame.Normal, eps) {
+		t.Errorf("Perturb with BumpScale=0: got %v, want %v", unchanged, frame.Normal)
+	}
+}
+
+// TestTextureNormalPerturbRotatesIntoWorldSpace verifies that a tangent-space
+// normal map sample is rotated into world space via the frame's basis.
+func TestTextureNormalPerturbRotatesIntoWorldSpace(t *testing.T) {
+	frame := TangentFrame{
+		Tangent:   r3.Vec{X: 1, Y: 0, Z: 0},
+		Bitangent: r3.Vec{X: 0, Y: 1, Z: 0},
+		Normal:    r3.Vec{X: 0, Y: 0, Z: 1},
+	}
+	tex := TextureNormal{Normal: TextureUniform{Color: Spectrum{X: 0, Y: 0, Z: 1}}}
+	got := tex.Perturb(frame, 0, 0)
+	if !got.IsClose(frame.Normal, eps) {
+		t.Errorf("Perturb with an unperturbed (0,0,1) tangent-space normal: got %v, want %v", got, frame.Normal)
+	}
+
+	tilted := TextureNormal{Normal: TextureUniform{Color: Spectrum{X: 1, Y: 0, Z: 1}}}
+	gotTilted := tilted.Perturb(frame, 0, 0)
+	want := r3.Vec{X: 1, Y: 0, Z: 1}.Unit()
+	if !gotTilted.IsClose(want, eps) {
+		t.Errorf("Perturb with a tilted tangent-space normal: got %v, want %v", gotTilted, want)
+	}
+}
+
+// TestSphereNormalMapPerturbsCollisionNormal verifies that a Sphere with a
+// NormalMap reports a shading normal different from the geometric normal.
+func TestSphereNormalMapPerturbsCollisionNormal(t *testing.T) {
+	plain := Sphere{Center: r3.Point{}, Radius: 1}
+	bumped := Sphere{
+		Center: r3.Point{},
+		Radius: 1,
+		NormalMap: &TextureNormal{
+			Normal: TextureUniform{Color: Spectrum{X: 0.5, Y: 0, Z: 1}},
+		},
+	}
+	if err := bumped.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+
+	r := ray{origin: r3.Point{X: 0, Y: 0, Z: -5}, direction: r3.Vec{X: 0, Y: 0, Z: 1}}
+	_, plainColl := plain.Collide(r, eps, math.MaxFloat64)
+	_, bumpedColl := bumped.Collide(r, eps, math.MaxFloat64)
+	if bumpedColl.normal.IsClose(plainColl.normal, eps) {
+		t.Errorf("Sphere.Collide with a NormalMap did not perturb the shading normal: got %v", bumpedColl.normal)
+	}
+	if math.Abs(bumpedColl.normal.Length()-1) > eps {
+		t.Errorf("Sphere.Collide NormalMap normal is not unit length: %v", bumpedColl.normal)
+	}
+}
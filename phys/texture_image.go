@@ -1,10 +1,13 @@
 package phys
 
 import (
+	"encoding/json"
 	"fmt"
 	"image"
 	"image/color"
-	"image/png"
+	_ "image/gif"  // Registers image.Decode support for .gif.
+	_ "image/jpeg" // Registers image.Decode support for .jpeg/.jpg.
+	_ "image/png"  // Registers image.Decode support for .png.
 	"io/fs"
 	"math"
 	"os"
@@ -12,14 +15,37 @@ import (
 
 func init() {
 	RegisterInterfaceType(TextureImage{})
+	RegisterInterfaceType(TextureImageHDR{})
 }
 
 // TextureImage represents a texture loaded from an image file.
 type TextureImage struct {
 	Image    image.Image
 	FilePath string // Path to the image file (for serialization)
-	Interp   string // Interpolation method: "nearest" or "bilinear"
-	WrapMode string // Wrapping mode: "repeat" or "clamp"
+	Interp   string // Interpolation method: "nearest", "bilinear", "trilinear", or "anisotropic"
+	WrapMode string // Wrapping mode: "repeat", "clamp", or "mirror"
+
+	// Unit selects how At/AtFootprint interpret their u,v arguments:
+	// "" or "uv" (the default) takes u,v normalized to [0,1]; "pixel"
+	// takes u,v over [0,W]x[0,H] of Image, borrowed from Kage's "kage:unit
+	// pixel" directive. Pixel addressing is the natural unit for
+	// calibration targets authored against a printed image's pixel grid
+	// (e.g. a ChArUco board) instead of UV space.
+	Unit string
+
+	// MaxAnisotropy caps how many axis-aligned taps AtFootprint takes
+	// along a footprint's long axis when Interp is "anisotropic"; see
+	// anisotropic. <= 0 defaults to 8, the common real-time default.
+	MaxAnisotropy float64
+
+	// mips is the image's mip pyramid, built once by the NewTextureImage*
+	// constructors via buildMipPyramid; see that function's comment. Nil
+	// if Image was set without going through a constructor (e.g. a
+	// hand-built test fixture), in which case AtFootprint falls back to
+	// At, the same as it does for a zero footprint estimate. Like Mesh's
+	// embedded BVH, this is a derived cache, not scene definition data,
+	// and isn't reconstructed from a bare struct literal.
+	mips []mipLevel
 }
 
 func (it TextureImage) Validate() error {
@@ -29,14 +55,21 @@ func (it TextureImage) Validate() error {
 	return nil
 }
 
-// NewTextureImage loads an image from a file within the provided filesystem and returns a TextureImage.
+// NewTextureImageFS loads an image from a file within the provided
+// filesystem and returns a TextureImage. The format is auto-detected from
+// the file's content (not its extension) among those registered with
+// image.RegisterFormat: PNG, JPEG, and GIF out of the box, since this
+// package has no dependency manifest to pull in golang.org/x/image's
+// TIFF/BMP decoders. High-dynamic-range formats need NewTextureImageHDRFS
+// instead: image.Image's color.Color is 16-bit-clamped per channel, so it
+// can't represent unclamped HDR radiance.
 func NewTextureImageFS(fsys fs.FS, filePath string, interp string, wrapMode string) (*TextureImage, error) {
 	file, err := fsys.Open(filePath)
 	if err != nil {
 		return nil, err
 	}
 	defer file.Close()
-	img, err := png.Decode(file)
+	img, _, err := image.Decode(file)
 	if err != nil {
 		return nil, err
 	}
@@ -45,6 +78,7 @@ func NewTextureImageFS(fsys fs.FS, filePath string, interp string, wrapMode stri
 		FilePath: filePath,
 		Interp:   interp,
 		WrapMode: wrapMode,
+		mips:     buildMipPyramid(img),
 	}, nil
 }
 
@@ -57,13 +91,14 @@ func MustNewTextureImageFS(fsys fs.FS, filePath string, interp string, wrapMode
 }
 
 // NewTextureImage loads an image from a file and returns an ImageTexture.
+// See NewTextureImageFS for which formats are supported.
 func NewTextureImage(filePath string, interp string, wrapMode string) (*TextureImage, error) {
 	file, err := os.Open(filePath)
 	if err != nil {
 		return nil, err
 	}
 	defer file.Close()
-	img, err := png.Decode(file)
+	img, _, err := image.Decode(file)
 	if err != nil {
 		return nil, err
 	}
@@ -72,6 +107,7 @@ func NewTextureImage(filePath string, interp string, wrapMode string) (*TextureI
 		FilePath: filePath,
 		Interp:   interp,
 		WrapMode: wrapMode,
+		mips:     buildMipPyramid(img),
 	}, nil
 }
 
@@ -83,29 +119,88 @@ func MustNewTextureImage(filePath string, interp string, wrapMode string) *Textu
 	return tex
 }
 
-// At returns the color value at the given UV coordinates.
+// textureImageJSON is TextureImage's on-disk representation. Image is
+// deliberately excluded: serializing a decoded image.Image's raw pixel
+// buffer as JSON would bloat a scene file by orders of magnitude, and
+// FilePath already names where the pixels live.
+type textureImageJSON struct {
+	FilePath      string
+	Interp        string
+	WrapMode      string
+	Unit          string
+	MaxAnisotropy float64
+}
+
+// MarshalJSON writes FilePath, Interp, WrapMode, Unit, and MaxAnisotropy,
+// omitting Image (see textureImageJSON) and the derived mip pyramid.
+func (it TextureImage) MarshalJSON() ([]byte, error) {
+	return json.Marshal(textureImageJSON{
+		FilePath:      it.FilePath,
+		Interp:        it.Interp,
+		WrapMode:      it.WrapMode,
+		Unit:          it.Unit,
+		MaxAnisotropy: it.MaxAnisotropy,
+	})
+}
+
+// UnmarshalJSON decodes a TextureImage written by MarshalJSON, reloading
+// Image and its mip pyramid from FilePath (relative to the process's
+// working directory) when FilePath is set -- so a scene file that names a
+// texture by path, rather than embedding pixel data, round-trips back
+// into a texture that actually samples something. A TextureImage with no
+// FilePath (e.g. a hand-built test fixture with Image assigned directly
+// in Go) decodes with Image left nil, same as its zero value.
+func (it *TextureImage) UnmarshalJSON(data []byte) error {
+	var aux textureImageJSON
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	*it = TextureImage{
+		FilePath:      aux.FilePath,
+		Interp:        aux.Interp,
+		WrapMode:      aux.WrapMode,
+		Unit:          aux.Unit,
+		MaxAnisotropy: aux.MaxAnisotropy,
+	}
+	if aux.FilePath == "" {
+		return nil
+	}
+	loaded, err := NewTextureImage(aux.FilePath, aux.Interp, aux.WrapMode)
+	if err != nil {
+		return fmt.Errorf("phys: TextureImage: reloading %q: %v", aux.FilePath, err)
+	}
+	it.Image = loaded.Image
+	it.mips = loaded.mips
+	return nil
+}
+
+// toUV converts u,v from whichever coordinate space Unit selects into
+// normalized UV, dividing by the image's (width, height) when Unit is
+// "pixel" and leaving u,v unchanged otherwise.
+func (it TextureImage) toUV(u, v float64) (float64, float64) {
+	if it.Unit != "pixel" || it.Image == nil {
+		return u, v
+	}
+	b := it.Image.Bounds()
+	return u / float64(b.Dx()), v / float64(b.Dy())
+}
+
+// At returns the color value at the given coordinates, interpreted
+// according to Unit ("uv" by default, or "pixel"; see the Unit field).
 func (it TextureImage) At(u, v float64) Spectrum {
+	u, v = it.toUV(u, v)
+	return it.atUV(u, v)
+}
+
+// atUV is At's implementation, assuming u,v are already normalized UV
+// coordinates -- the form AtFootprint's mip sampling needs internally,
+// after it has done its own Unit conversion.
+func (it TextureImage) atUV(u, v float64) Spectrum {
 	if it.Image == nil {
 		// Return a default color if the image failed to load.
 		return Spectrum{X: 1, Y: 0, Z: 1} // Magenta indicates missing texture.
 	}
-	// Handle wrapping modes.
-	switch it.WrapMode {
-	case "repeat":
-		u = u - math.Floor(u)
-		v = v - math.Floor(v)
-	case "clamp":
-		u = math.Min(math.Max(u, 0.0), 1.0)
-		v = math.Min(math.Max(v, 0.0), 1.0)
-	default:
-		// Default to repeat
-		u = u - math.Floor(u)
-		v = v - math.Floor(v)
-	}
-
-	// Flip V coordinate to match image coordinate system.
-	// TODO: scott what is the name of this conversion, uv to screen space?
-	v = 1.0 - v
+	u, v = wrapUV(it.WrapMode, u, v)
 
 	// Convert UV coordinates to image coordinates
 	width := it.Image.Bounds().Dx()
@@ -135,6 +230,43 @@ func (it TextureImage) At(u, v float64) Spectrum {
 	}
 }
 
+// wrapUV applies wrapMode to u and v ("repeat", the default, wraps both
+// to [0, 1); "clamp" clamps instead; "mirror" reflects back and forth
+// across [0, 1] instead of wrapping, so a tiled texture's edges line up
+// continuously) and flips v to match the image coordinate system's
+// top-left origin. Shared by At and the mip pyramid sampling AtFootprint
+// uses, so both interpret WrapMode identically.
+func wrapUV(wrapMode string, u, v float64) (float64, float64) {
+	switch wrapMode {
+	case "clamp":
+		u = math.Min(math.Max(u, 0.0), 1.0)
+		v = math.Min(math.Max(v, 0.0), 1.0)
+	case "mirror":
+		u = mirrorWrap(u)
+		v = mirrorWrap(v)
+	default:
+		// Default to repeat.
+		u = u - math.Floor(u)
+		v = v - math.Floor(v)
+	}
+	// TODO: scott what is the name of this conversion, uv to screen space?
+	return u, 1.0 - v
+}
+
+// mirrorWrap reflects x back and forth across [0, 1]: every even integer
+// period wraps normally, every odd period runs backwards, so x=1.2 and
+// x=-0.2 both map to 0.8, and a texture's right edge meets its own
+// mirror image instead of snapping back to the left edge the way "repeat"
+// does.
+func mirrorWrap(x float64) float64 {
+	x = math.Abs(x)
+	period := math.Mod(x, 2)
+	if period > 1 {
+		return 2 - period
+	}
+	return period
+}
+
 // bilinearSample performs bilinear interpolation on the image at (x, y).
 func bilinearSample(img image.Image, x, y float64) color.Color {
 	x0 := int(math.Floor(x))
@@ -0,0 +1,107 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import (
+	"bytes"
+	"math"
+	"strconv"
+	"testing"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
+)
+
+// flatRGBEFile builds a minimal Radiance RGBE file with uncompressed
+// (flat) scanlines, i.e. no new-style RLE marker bytes.
+func flatRGBEFile(width, height int, pixel [4]byte) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("#?RADIANCE\n")
+	buf.WriteString("FORMAT=32-bit_rle_rgbe\n")
+	buf.WriteString("\n")
+	buf.WriteString("-Y " + strconv.Itoa(height) + " +X " + strconv.Itoa(width) + "\n")
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			buf.Write(pixel[:])
+		}
+	}
+	return buf.Bytes()
+}
+
+// TestDecodeRGBEFlatScanline verifies the uncompressed-scanline path (used
+// for widths below the new-style RLE's minimum) decodes a uniform image to
+// the expected linear radiance via rgbeToVec.
+func TestDecodeRGBEFlatScanline(t *testing.T) {
+	data := flatRGBEFile(4, 2, [4]byte{128, 64, 32, 136})
+	width, height, pixels, err := decodeRGBE(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("decodeRGBE: %v", err)
+	}
+	if width != 4 || height != 2 {
+		t.Fatalf("decodeRGBE size = %dx%d, want 4x2", width, height)
+	}
+	want := rgbeToVec(128, 64, 32, 136)
+	for i, p := range pixels {
+		if p != want {
+			t.Errorf("pixels[%d] = %v, want %v", i, p, want)
+		}
+	}
+}
+
+// TestDecodeRGBENewStyleRLE verifies the new-style per-component RLE path
+// (marker bytes 2, 2, widthHi, widthLo) decodes runs and literal spans
+// correctly for a width long enough to trigger it.
+func TestDecodeRGBENewStyleRLE(t *testing.T) {
+	const width = 10
+	var buf bytes.Buffer
+	buf.WriteString("#?RADIANCE\n\n")
+	buf.WriteString("-Y 1 +X " + strconv.Itoa(width) + "\n")
+	buf.WriteByte(2)
+	buf.WriteByte(2)
+	buf.WriteByte(byte(width >> 8))
+	buf.WriteByte(byte(width & 0xff))
+	// Four components (R, G, B, E), each a single run of `width` repeats.
+	values := [4]byte{10, 20, 30, 128}
+	for _, v := range values {
+		buf.WriteByte(128 + width) // run of `width` repeats
+		buf.WriteByte(v)
+	}
+
+	width2, height2, pixels, err := decodeRGBE(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("decodeRGBE: %v", err)
+	}
+	if width2 != width || height2 != 1 {
+		t.Fatalf("decodeRGBE size = %dx%d, want %dx1", width2, height2, width)
+	}
+	want := rgbeToVec(values[0], values[1], values[2], values[3])
+	for i, p := range pixels {
+		if p != want {
+			t.Errorf("pixels[%d] = %v, want %v", i, p, want)
+		}
+	}
+}
+
+// TestRGBEToVecZeroExponentIsBlack verifies the e==0 special case (exactly
+// black, per the RGBE spec) rather than falling through to Ldexp.
+func TestRGBEToVecZeroExponentIsBlack(t *testing.T) {
+	got := rgbeToVec(255, 255, 255, 0)
+	if got.X != 0 || got.Y != 0 || got.Z != 0 {
+		t.Errorf("rgbeToVec(255,255,255,0) = %v, want zero vector", got)
+	}
+}
+
+// TestTextureImageHDRAtUnclamped verifies At returns the stored radiance
+// unclamped, including values outside [0, 1], unlike TextureImage.At which
+// round-trips through a clamped color.Color.
+func TestTextureImageHDRAtUnclamped(t *testing.T) {
+	bright := 5.0
+	tex := TextureImageHDR{
+		Width: 2, Height: 1,
+		Pixels:   []r3.Vec{{X: bright, Y: bright, Z: bright}, {X: 0, Y: 0, Z: 0}},
+		WrapMode: "clamp",
+		Interp:   "nearest",
+	}
+	got := tex.At(0.0, 1.0)
+	if math.Abs(got.X-bright) > 1e-9 {
+		t.Errorf("At = %v, want unclamped X = %v", got, bright)
+	}
+}
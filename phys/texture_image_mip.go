@@ -0,0 +1,252 @@
+package phys
+
+import (
+	"image"
+	"math"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r2"
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
+)
+
+// mipLevel is one level of a TextureImage's mip pyramid: linear (not
+// sRGB-encoded) RGB pixels, row-major with origin top-left, at some
+// fraction of the base image's resolution.
+type mipLevel struct {
+	width, height int
+	pixel         []r3.Vec
+}
+
+func (m mipLevel) at(x, y int) r3.Vec {
+	return m.pixel[y*m.width+x]
+}
+
+// lanczosA is the Lanczos-3 kernel's support radius, in source samples.
+const lanczosA = 3.0
+
+// lanczos3 evaluates the Lanczos-3 windowed sinc kernel at x.
+func lanczos3(x float64) float64 {
+	if x == 0 {
+		return 1
+	}
+	if x <= -lanczosA || x >= lanczosA {
+		return 0
+	}
+	piX := math.Pi * x
+	return lanczosA * math.Sin(piX) * math.Sin(piX/lanczosA) / (piX * piX)
+}
+
+// buildMipPyramid decodes img to linear RGB (sRGB -> linear, since img's
+// pixels are conventionally gamma-encoded but downsampling must happen in
+// linear light to avoid darkening edges) and repeatedly halves it with a
+// separable Lanczos-3 filter until reaching a 1x1 level. AtFootprint's
+// "trilinear" and "anisotropic" Interp modes sample from the result
+// instead of always point-sampling the base image, the standard
+// PBRT-style fix for a minified texture aliasing under path tracing.
+func buildMipPyramid(img image.Image) []mipLevel {
+	mips := []mipLevel{srgbImageToLinear(img)}
+	for {
+		last := mips[len(mips)-1]
+		if last.width == 1 && last.height == 1 {
+			return mips
+		}
+		mips = append(mips, downsampleLanczos2x(last))
+	}
+}
+
+// srgbImageToLinear reads every pixel of img and sRGB-decodes it to
+// linear RGB, producing mip level 0 of buildMipPyramid's pyramid.
+func srgbImageToLinear(img image.Image) mipLevel {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	pixel := make([]r3.Vec, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			r, g, bl, _ := img.At(b.Min.X+x, b.Min.Y+y).RGBA()
+			pixel[y*w+x] = r3.Vec{
+				X: srgbToLinear(float64(r) / 65535.0),
+				Y: srgbToLinear(float64(g) / 65535.0),
+				Z: srgbToLinear(float64(bl) / 65535.0),
+			}
+		}
+	}
+	return mipLevel{width: w, height: h, pixel: pixel}
+}
+
+// srgbToLinear converts one sRGB-encoded channel value in [0, 1] to
+// linear light, per the IEC 61966-2-1 piecewise definition.
+func srgbToLinear(c float64) float64 {
+	if c <= 0.04045 {
+		return c / 12.92
+	}
+	return math.Pow((c+0.055)/1.055, 2.4)
+}
+
+// downsampleLanczos2x halves src's width and height (rounding up, so a
+// 1-pixel source axis still has somewhere to land), filtering separably
+// with Lanczos-3. Per the standard decimation rule, the kernel's support
+// is stretched by the minification factor (2x here) rather than left at
+// its native radius, which is what keeps a high-frequency checker texture
+// from aliasing the way a naive box-2 average would.
+func downsampleLanczos2x(src mipLevel) mipLevel {
+	dstW := max(1, src.width/2)
+	dstH := max(1, src.height/2)
+
+	// Horizontal pass: src.height rows, each resampled from width to dstW.
+	tmp := make([]r3.Vec, dstW*src.height)
+	for y := 0; y < src.height; y++ {
+		row := y
+		for x := 0; x < dstW; x++ {
+			tmp[y*dstW+x] = lanczosTap1D(func(i int) r3.Vec {
+				return src.at(clamp(i, 0, src.width-1), row)
+			}, src.width, dstW, x)
+		}
+	}
+	// Vertical pass: dstW columns of tmp, each resampled from height to dstH.
+	dst := mipLevel{width: dstW, height: dstH, pixel: make([]r3.Vec, dstW*dstH)}
+	for y := 0; y < dstH; y++ {
+		for x := 0; x < dstW; x++ {
+			col := x
+			dst.pixel[y*dstW+x] = lanczosTap1D(func(i int) r3.Vec {
+				return tmp[clamp(i, 0, src.height-1)*dstW+col]
+			}, src.height, dstH, y)
+		}
+	}
+	return dst
+}
+
+// lanczosTap1D returns the Lanczos-3-filtered value at destination index
+// dstIdx along an axis of length srcLen being resized to dstLen, calling
+// at to fetch each tapped source sample (already clamped to the axis).
+func lanczosTap1D(at func(i int) r3.Vec, srcLen, dstLen, dstIdx int) r3.Vec {
+	scale := float64(srcLen) / float64(dstLen)
+	support := lanczosA * max(scale, 1)
+	center := (float64(dstIdx)+0.5)*scale - 0.5
+	lo := int(math.Floor(center - support))
+	hi := int(math.Ceil(center + support))
+
+	var sum r3.Vec
+	var wsum float64
+	for i := lo; i <= hi; i++ {
+		w := lanczos3((float64(i) - center) / max(scale, 1))
+		if w == 0 {
+			continue
+		}
+		sum = sum.Add(at(i).Muls(w))
+		wsum += w
+	}
+	if wsum == 0 {
+		return at(clamp(int(math.Round(center)), 0, srcLen-1))
+	}
+	return sum.Muls(1 / wsum)
+}
+
+// AtFootprint implements TextureFootprintSampler. With no mip pyramid
+// (mips unset, see the mips field) or a zero footprint, or an Interp
+// other than "trilinear"/"anisotropic", it falls back to At; otherwise it
+// filters using footprint's UV-space size the way real-time renderers
+// use a ray differential.
+func (it *TextureImage) AtFootprint(u, v float64, footprint r2.Point) Spectrum {
+	u, v = it.toUV(u, v)
+	if it.Unit == "pixel" && it.Image != nil {
+		b := it.Image.Bounds()
+		footprint = r2.Point{X: footprint.X / float64(b.Dx()), Y: footprint.Y / float64(b.Dy())}
+	}
+	if len(it.mips) == 0 || (footprint.X == 0 && footprint.Y == 0) {
+		return it.atUV(u, v)
+	}
+	switch it.Interp {
+	case "trilinear":
+		return it.trilinear(u, v, max(footprint.X, footprint.Y))
+	case "anisotropic":
+		return it.anisotropic(u, v, footprint)
+	default:
+		return it.atUV(u, v)
+	}
+}
+
+// trilinear picks the continuous mip level implied by duv (the
+// footprint's size along its largest UV axis) and linearly blends the
+// bilinear samples from the two levels bracketing it.
+func (it *TextureImage) trilinear(u, v, duv float64) Spectrum {
+	lo, hi, t := it.mipLevelsFor(duv)
+	c0 := it.sampleMip(lo, u, v)
+	c1 := it.sampleMip(hi, u, v)
+	return Spectrum(c0.Lerp(c1, t))
+}
+
+// anisotropic approximates EWA filtering of an elongated footprint: it
+// picks the mip level implied by the footprint's minor (shorter) axis,
+// then averages several bilinear taps stepped across the footprint's
+// major axis at that level, where the tap count is the axis ratio
+// clamped to MaxAnisotropy. This is the classic "RipMap"-style
+// approximation real-time renderers use in place of rasterizing a true
+// elliptical Gaussian footprint.
+func (it *TextureImage) anisotropic(u, v float64, footprint r2.Point) Spectrum {
+	major, minor := footprint.X, footprint.Y
+	majorIsX := true
+	if minor > major {
+		major, minor = minor, major
+		majorIsX = false
+	}
+	if minor <= 0 {
+		return it.trilinear(u, v, major)
+	}
+	maxAniso := it.MaxAnisotropy
+	if maxAniso <= 0 {
+		maxAniso = 8
+	}
+	taps := clamp(int(math.Round(major/minor)), 1, int(maxAniso))
+	lo, hi, t := it.mipLevelsFor(minor)
+
+	var sum r3.Vec
+	for i := 0; i < taps; i++ {
+		// Step across [-major/2, +major/2] along the footprint's long axis.
+		offset := ((float64(i)+0.5)/float64(taps) - 0.5) * major
+		su, sv := u, v
+		if majorIsX {
+			su += offset
+		} else {
+			sv += offset
+		}
+		c0 := it.sampleMip(lo, su, sv)
+		c1 := it.sampleMip(hi, su, sv)
+		sum = sum.Add(c0.Lerp(c1, t))
+	}
+	return Spectrum(sum.Muls(1 / float64(taps)))
+}
+
+// mipLevelsFor converts a UV-space footprint size duv into a continuous
+// mip level (log2 of the footprint in texels, clamped to the pyramid's
+// range) and returns the two integer levels bracketing it plus the blend
+// factor between them.
+func (it *TextureImage) mipLevelsFor(duv float64) (lo, hi int, t float64) {
+	n := len(it.mips)
+	maxDim := float64(max(it.mips[0].width, it.mips[0].height))
+	level := clamp(math.Log2(max(duv*maxDim, 1e-8)), 0, float64(n-1))
+	lo = int(math.Floor(level))
+	hi = min(lo+1, n-1)
+	return lo, hi, level - float64(lo)
+}
+
+// sampleMip bilinearly samples mip level idx at UV (u, v), applying the
+// same WrapMode and V-flip convention as At.
+func (it *TextureImage) sampleMip(idx int, u, v float64) r3.Vec {
+	m := it.mips[idx]
+	wu, wv := wrapUV(it.WrapMode, u, v)
+	x := wu * float64(max(m.width-1, 0))
+	y := wv * float64(max(m.height-1, 0))
+
+	x0 := int(math.Floor(x))
+	y0 := int(math.Floor(y))
+	fx := x - float64(x0)
+	fy := y - float64(y0)
+
+	c00 := m.at(clamp(x0, 0, m.width-1), clamp(y0, 0, m.height-1))
+	c10 := m.at(clamp(x0+1, 0, m.width-1), clamp(y0, 0, m.height-1))
+	c01 := m.at(clamp(x0, 0, m.width-1), clamp(y0+1, 0, m.height-1))
+	c11 := m.at(clamp(x0+1, 0, m.width-1), clamp(y0+1, 0, m.height-1))
+
+	top := c00.Lerp(c10, fx)
+	bottom := c01.Lerp(c11, fx)
+	return top.Lerp(bottom, fy)
+}
@@ -0,0 +1,132 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r2"
+)
+
+// checkerNRGBA builds a w x h image.NRGBA alternating pure black and pure
+// white pixels in a 1-pixel checker pattern, the classic minification
+// torture test: a box filter averages to mid-gray, a naive point sample
+// aliases between the two extremes.
+func checkerNRGBA(w, h int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if (x+y)%2 == 0 {
+				img.Set(x, y, color.White)
+			} else {
+				img.Set(x, y, color.Black)
+			}
+		}
+	}
+	return img
+}
+
+// TestBuildMipPyramidHalvesToOneByOne verifies the pyramid's level sizes
+// halve (rounding up) each step and terminate at a 1x1 level.
+func TestBuildMipPyramidHalvesToOneByOne(t *testing.T) {
+	mips := buildMipPyramid(checkerNRGBA(8, 4))
+	wantSizes := [][2]int{{8, 4}, {4, 2}, {2, 1}, {1, 1}}
+	if len(mips) != len(wantSizes) {
+		t.Fatalf("len(mips) = %d, want %d", len(mips), len(wantSizes))
+	}
+	for i, want := range wantSizes {
+		if mips[i].width != want[0] || mips[i].height != want[1] {
+			t.Errorf("mips[%d] size = %dx%d, want %dx%d", i, mips[i].width, mips[i].height, want[0], want[1])
+		}
+	}
+}
+
+// TestBuildMipPyramidCoarsestLevelAveragesCheckerboard verifies a
+// black/white checker image's coarsest mip level lands near mid-gray in
+// linear light, confirming the pyramid actually low-pass filters instead
+// of just subsampling (which would alternate back to pure black/white).
+func TestBuildMipPyramidCoarsestLevelAveragesCheckerboard(t *testing.T) {
+	mips := buildMipPyramid(checkerNRGBA(16, 16))
+	last := mips[len(mips)-1]
+	if last.width != 1 || last.height != 1 {
+		t.Fatalf("coarsest level = %dx%d, want 1x1", last.width, last.height)
+	}
+	c := last.at(0, 0)
+	if math.Abs(c.X-0.5) > 0.1 || math.Abs(c.Y-0.5) > 0.1 || math.Abs(c.Z-0.5) > 0.1 {
+		t.Errorf("coarsest level = %v, want near mid-gray (0.5, 0.5, 0.5)", c)
+	}
+}
+
+// TestSrgbToLinearEndpoints verifies the sRGB->linear conversion is the
+// identity at 0 and 1 and darkens values in between (the curve bows below
+// the y=x line everywhere in (0, 1)).
+func TestSrgbToLinearEndpoints(t *testing.T) {
+	if got := srgbToLinear(0); got != 0 {
+		t.Errorf("srgbToLinear(0) = %v, want 0", got)
+	}
+	if got := srgbToLinear(1); math.Abs(got-1) > 1e-9 {
+		t.Errorf("srgbToLinear(1) = %v, want 1", got)
+	}
+	if got := srgbToLinear(0.5); got >= 0.5 {
+		t.Errorf("srgbToLinear(0.5) = %v, want < 0.5", got)
+	}
+}
+
+// TestAtFootprintFallsBackToAtForZeroFootprint verifies a zero footprint
+// (the "no estimate available" sentinel used for secondary bounces and
+// bare connection vertices) degrades to a plain At sample rather than
+// indexing into the mip pyramid.
+func TestAtFootprintFallsBackToAtForZeroFootprint(t *testing.T) {
+	tex := &TextureImage{
+		Image:    checkerNRGBA(4, 4),
+		Interp:   "trilinear",
+		WrapMode: "clamp",
+		mips:     buildMipPyramid(checkerNRGBA(4, 4)),
+	}
+	want := tex.At(0.1, 0.1)
+	got := tex.AtFootprint(0.1, 0.1, r2.Point{})
+	if got != want {
+		t.Errorf("AtFootprint with zero footprint = %v, want At = %v", got, want)
+	}
+}
+
+// TestAtFootprintTrilinearBlursTowardMidGrayForLargeFootprint verifies
+// that a footprint spanning the whole checkerboard pulls the trilinear
+// sample toward the coarsest mip level's averaged mid-gray, rather than
+// aliasing to the nearest checker cell's pure black or white.
+func TestAtFootprintTrilinearBlursTowardMidGrayForLargeFootprint(t *testing.T) {
+	img := checkerNRGBA(32, 32)
+	tex := &TextureImage{
+		Image:    img,
+		Interp:   "trilinear",
+		WrapMode: "clamp",
+		mips:     buildMipPyramid(img),
+	}
+	got := tex.AtFootprint(0.5, 0.5, r2.Point{X: 1, Y: 1})
+	for _, c := range []float64{got.X, got.Y, got.Z} {
+		if math.Abs(c-0.5) > 0.15 {
+			t.Errorf("AtFootprint with a whole-image footprint = %v, want near mid-gray", got)
+		}
+	}
+}
+
+// TestAtFootprintAnisotropicMatchesTrilinearForSquareFootprint verifies
+// that anisotropic sampling with an isotropic (square) footprint reduces
+// to a single tap and so matches trilinear's result exactly.
+func TestAtFootprintAnisotropicMatchesTrilinearForSquareFootprint(t *testing.T) {
+	img := checkerNRGBA(16, 16)
+	tex := &TextureImage{
+		Image:    img,
+		Interp:   "anisotropic",
+		WrapMode: "clamp",
+		mips:     buildMipPyramid(img),
+	}
+	footprint := r2.Point{X: 0.1, Y: 0.1}
+	got := tex.AtFootprint(0.3, 0.6, footprint)
+	want := tex.trilinear(0.3, 0.6, 0.1)
+	if math.Abs(float64(got.X-want.X)) > 1e-9 || math.Abs(float64(got.Y-want.Y)) > 1e-9 || math.Abs(float64(got.Z-want.Z)) > 1e-9 {
+		t.Errorf("anisotropic with a square footprint = %v, want trilinear = %v", got, want)
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+// checkerboardImage builds a 2x2 image whose four texels are distinguishable
+// solid colors, for verifying pixel-space vs UV-space addressing picks the
+// intended texel.
+func checkerboardImage() *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, 2, 2))
+	img.Set(0, 0, color.NRGBA{R: 255, A: 255})         // top-left: red
+	img.Set(1, 0, color.NRGBA{G: 255, A: 255})         // top-right: green
+	img.Set(0, 1, color.NRGBA{B: 255, A: 255})         // bottom-left: blue
+	img.Set(1, 1, color.NRGBA{R: 255, G: 255, A: 255}) // bottom-right: yellow
+	return img
+}
+
+// TestTextureImageUnitPixel verifies Unit: "pixel" addresses the image in
+// [0,W]x[0,H] pixel coordinates rather than normalized UV, landing on the
+// same texel At's UV-space form would reach via u/W, v/H.
+func TestTextureImageUnitPixel(t *testing.T) {
+	img := checkerboardImage()
+	pixelTex := TextureImage{Image: img, Interp: "nearest", Unit: "pixel"}
+	uvTex := TextureImage{Image: img, Interp: "nearest"}
+
+	// Pixel (1, 0) (top-right, green) should match UV (0.5, 0).
+	got := pixelTex.At(1, 0)
+	want := uvTex.At(0.5, 0)
+	if got != want {
+		t.Errorf("pixelTex.At(1,0) = %+v, want %+v (matching uvTex.At(0.5,0))", got, want)
+	}
+}
+
+// TestTextureImageUnitDefaultIsUV verifies the zero-value Unit ("")
+// behaves exactly like the pre-existing UV addressing, so existing scenes
+// that never set Unit are unaffected.
+func TestTextureImageUnitDefaultIsUV(t *testing.T) {
+	img := checkerboardImage()
+	tex := TextureImage{Image: img, Interp: "nearest"}
+	// At flips v to match the image's top-left origin, so UV (0.9, 0.9)
+	// (nearest-rounds to column 1, and after the v flip, row 0) lands on
+	// the top-right texel, green.
+	got := tex.At(0.9, 0.9)
+	want := Spectrum{X: 0, Y: 1, Z: 0} // top-right: green
+	if got != want {
+		t.Errorf("At(0.9, 0.9) = %+v, want %+v", got, want)
+	}
+}
+
+// TestWrapUVMirror verifies "mirror" reflects back and forth across
+// [0, 1] instead of wrapping discontinuously the way "repeat" does.
+func TestWrapUVMirror(t *testing.T) {
+	tests := []struct {
+		u, want float64
+	}{
+		{u: 0.3, want: 0.3},
+		{u: 1.2, want: 0.8},
+		{u: -0.2, want: 0.2},
+		{u: 2.3, want: 0.3},
+	}
+	for _, tc := range tests {
+		u, _ := wrapUV("mirror", tc.u, 0)
+		if math.Abs(u-tc.want) > 1e-9 {
+			t.Errorf("mirrorWrap(%v) = %v, want %v", tc.u, u, tc.want)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import (
+	"math"
+	"testing"
+)
+
+// TestTexturePerlinAtIsDeterministicAndBounded verifies that repeated
+// evaluations at the same UV agree and stay within Color0/Color1's
+// convex hull.
+func TestTexturePerlinAtIsDeterministicAndBounded(t *testing.T) {
+	tex := TexturePerlin{Scale: 4, Seed: 7, Color0: Spectrum{X: 0}, Color1: Spectrum{X: 1}}
+	a := tex.At(0.37, 1.21)
+	b := tex.At(0.37, 1.21)
+	if a != b {
+		t.Errorf("At is not deterministic: %v != %v", a, b)
+	}
+	if a.X < 0 || a.X > 1 {
+		t.Errorf("At = %v, want X in [0, 1]", a)
+	}
+}
+
+// TestTexturePerlinDifferentSeedsDiffer verifies that Seed actually
+// changes the noise pattern rather than being ignored.
+func TestTexturePerlinDifferentSeedsDiffer(t *testing.T) {
+	a := TexturePerlin{Scale: 4, Seed: 1, Color0: Spectrum{X: 0}, Color1: Spectrum{X: 1}}
+	b := TexturePerlin{Scale: 4, Seed: 2, Color0: Spectrum{X: 0}, Color1: Spectrum{X: 1}}
+	same := true
+	for i := 0; i < 20; i++ {
+		u, v := float64(i)*0.31, float64(i)*0.17
+		if a.At(u, v) != b.At(u, v) {
+			same = false
+			break
+		}
+	}
+	if same {
+		t.Error("TexturePerlin with different Seed values produced identical noise over 20 samples")
+	}
+}
+
+// TestTexturePerlinValidateRejectsNonPositiveScale verifies Validate
+// catches a non-positive Scale.
+func TestTexturePerlinValidateRejectsNonPositiveScale(t *testing.T) {
+	tex := TexturePerlin{Scale: 0}
+	if err := tex.Validate(); err == nil {
+		t.Error("Validate: expected an error for Scale == 0, got nil")
+	}
+}
+
+// TestTextureTurbulenceAtIsBounded verifies the normalized octave sum
+// stays within Color0/Color1's convex hull regardless of Octaves.
+func TestTextureTurbulenceAtIsBounded(t *testing.T) {
+	tex := TextureTurbulence{
+		Scale: 2, Seed: 3, Octaves: 5, Persistence: 0.5, Lacunarity: 2,
+		Color0: Spectrum{X: 0}, Color1: Spectrum{X: 1},
+	}
+	for i := 0; i < 10; i++ {
+		c := tex.At(float64(i)*0.9, float64(i)*1.3)
+		if c.X < -1e-9 || c.X > 1+1e-9 {
+			t.Errorf("At(%d) = %v, want X in [0, 1]", i, c)
+		}
+	}
+}
+
+// TestTextureTurbulenceValidateRejectsBadParameters verifies Validate
+// catches non-positive Scale/Persistence/Lacunarity and Octaves < 1.
+func TestTextureTurbulenceValidateRejectsBadParameters(t *testing.T) {
+	base := TextureTurbulence{Scale: 1, Octaves: 1, Persistence: 0.5, Lacunarity: 2}
+	cases := []TextureTurbulence{
+		{Scale: 0, Octaves: 1, Persistence: 0.5, Lacunarity: 2},
+		{Scale: 1, Octaves: 0, Persistence: 0.5, Lacunarity: 2},
+		{Scale: 1, Octaves: 1, Persistence: 0, Lacunarity: 2},
+		{Scale: 1, Octaves: 1, Persistence: 0.5, Lacunarity: 0},
+	}
+	if err := base.Validate(); err != nil {
+		t.Fatalf("Validate on a valid TextureTurbulence: %v", err)
+	}
+	for i, c := range cases {
+		if err := c.Validate(); err == nil {
+			t.Errorf("case %d: Validate(%+v): expected an error, got nil", i, c)
+		}
+	}
+}
+
+// TestTextureWorleyF1IsZeroAtFeaturePoint verifies that the distance to
+// the nearest (N=1) feature point is (approximately) zero exactly at a
+// cell corner that worleyFeaturePoint jitters to itself -- more directly,
+// that sampling precisely at a known feature point returns Color0.
+func TestTextureWorleyF1IsZeroAtFeaturePoint(t *testing.T) {
+	tex := TextureWorley{Scale: 1, Seed: 11, N: 1, Color0: Spectrum{X: 0}, Color1: Spectrum{X: 1}}
+	fp := worleyFeaturePoint(0, 0, 0, tex.Seed)
+	tex.W = fp.Z // At reads Z from W*Scale; pin it to the feature point's own Z.
+	c := tex.At(fp.X, fp.Y)
+	if math.Abs(c.X) > 1e-6 {
+		t.Errorf("At(feature point) = %v, want ~Color0 (X=0)", c)
+	}
+}
+
+// TestTextureWorleyF2NotLessThanF1 verifies that the N=2 (second-nearest)
+// distance is never smaller than N=1 at the same UV, as distances are
+// sorted ascending.
+func TestTextureWorleyF2NotLessThanF1(t *testing.T) {
+	f1 := TextureWorley{Scale: 1.7, Seed: 5, N: 1, Color0: Spectrum{X: 0}, Color1: Spectrum{X: 1}}
+	f2 := TextureWorley{Scale: 1.7, Seed: 5, N: 2, Color0: Spectrum{X: 0}, Color1: Spectrum{X: 1}}
+	for i := 0; i < 10; i++ {
+		u, v := float64(i)*0.53, float64(i)*0.29
+		if f2.At(u, v).X+1e-9 < f1.At(u, v).X {
+			t.Errorf("at (%v, %v): F2 = %v < F1 = %v", u, v, f2.At(u, v).X, f1.At(u, v).X)
+		}
+	}
+}
+
+// TestTextureWorleyValidateRejectsBadParameters verifies Validate catches
+// a non-positive Scale or N < 1.
+func TestTextureWorleyValidateRejectsBadParameters(t *testing.T) {
+	if err := (TextureWorley{Scale: 0, N: 1}).Validate(); err == nil {
+		t.Error("Validate: expected an error for Scale == 0, got nil")
+	}
+	if err := (TextureWorley{Scale: 1, N: 0}).Validate(); err == nil {
+		t.Error("Validate: expected an error for N == 0, got nil")
+	}
+}
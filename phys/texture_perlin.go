@@ -0,0 +1,199 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
+)
+
+func init() {
+	RegisterInterfaceType(TexturePerlin{})
+}
+
+// perlinPermutation is Ken Perlin's reference permutation table from
+// "Improving Noise" (2002): a fixed shuffle of 0..255. TexturePerlin,
+// TextureTurbulence, and TextureWorley all offset into it by their own
+// Seed rather than reshuffling per seed, so noise evaluation stays O(1)
+// with no per-texture setup cost, at the price of only 256 distinct seed
+// phases rather than truly independent random tables per seed.
+var perlinPermutation = [256]int{
+	151, 160, 137, 91, 90, 15, 131, 13, 201, 95, 96, 53, 194, 233, 7, 225,
+	140, 36, 103, 30, 69, 142, 8, 99, 37, 240, 21, 10, 23, 190, 6, 148,
+	247, 120, 234, 75, 0, 26, 197, 62, 94, 252, 219, 203, 117, 35, 11, 32,
+	57, 177, 33, 88, 237, 149, 56, 87, 174, 20, 125, 136, 171, 168, 68, 175,
+	74, 165, 71, 134, 139, 48, 27, 166, 77, 146, 158, 231, 83, 111, 229, 122,
+	60, 211, 133, 230, 220, 105, 92, 41, 55, 46, 245, 40, 244, 102, 143, 54,
+	65, 25, 63, 161, 1, 216, 80, 73, 209, 76, 132, 187, 208, 89, 18, 169,
+	200, 196, 135, 130, 116, 188, 159, 86, 164, 100, 109, 198, 173, 186, 3, 64,
+	52, 217, 226, 250, 124, 123, 5, 202, 38, 147, 118, 126, 255, 82, 85, 212,
+	207, 206, 59, 227, 47, 16, 58, 17, 182, 189, 28, 42, 223, 183, 170, 213,
+	119, 248, 152, 2, 44, 154, 163, 70, 221, 153, 101, 155, 167, 43, 172, 9,
+	129, 22, 39, 253, 19, 98, 108, 110, 79, 113, 224, 232, 178, 185, 112, 104,
+	218, 246, 97, 228, 251, 34, 242, 193, 238, 210, 144, 12, 191, 179, 162, 241,
+	81, 51, 145, 235, 249, 14, 239, 107, 49, 192, 214, 31, 181, 199, 106, 157,
+	184, 84, 204, 176, 115, 121, 50, 45, 127, 4, 150, 254, 138, 236, 205, 93,
+	222, 114, 67, 29, 24, 72, 243, 141, 128, 195, 78, 66, 215, 61, 156, 180,
+}
+
+// perlinGradients are the 12 edge-midpoint gradient directions used by Ken
+// Perlin's improved noise, chosen so grad(hash, x, y, z) can be computed
+// with only additions (no table of dot products needed).
+var perlinGradients = [12]r3.Vec{
+	{X: 1, Y: 1, Z: 0}, {X: -1, Y: 1, Z: 0}, {X: 1, Y: -1, Z: 0}, {X: -1, Y: -1, Z: 0},
+	{X: 1, Y: 0, Z: 1}, {X: -1, Y: 0, Z: 1}, {X: 1, Y: 0, Z: -1}, {X: -1, Y: 0, Z: -1},
+	{X: 0, Y: 1, Z: 1}, {X: 0, Y: -1, Z: 1}, {X: 0, Y: 1, Z: -1}, {X: 0, Y: -1, Z: -1},
+}
+
+// perlinHash folds (x, y, z) and seed through three lookups into
+// perlinPermutation, the standard way to turn a 3D lattice coordinate into
+// one of the 12 gradient indices.
+func perlinHash(x, y, z int, seed int64) int {
+	s := int(seed & 0xff)
+	a := perlinPermutation[(x+s)&255]
+	a = perlinPermutation[(a+y)&255]
+	a = perlinPermutation[(a+z)&255]
+	return a
+}
+
+// perlinFade is the 6t^5-15t^4+10t^3 quintic easing curve that gives
+// improved Perlin noise its C2-continuous interpolation.
+func perlinFade(t float64) float64 {
+	return t * t * t * (t*(t*6-15) + 10)
+}
+
+func perlinLerp(t, a, b float64) float64 {
+	return a + t*(b-a)
+}
+
+// perlinGrad evaluates the gradient at lattice corner hash dotted with the
+// offset (x, y, z) from that corner to the sample point.
+func perlinGrad(hash int, x, y, z float64) float64 {
+	g := perlinGradients[hash%12]
+	return g.X*x + g.Y*y + g.Z*z
+}
+
+// perlinNoise3 evaluates classic gradient-lattice Perlin noise at (x, y, z)
+// for the given seed, trilinearly interpolating the 8 surrounding lattice
+// corners' gradients. The result is approximately in [-1, 1].
+func perlinNoise3(seed int64, x, y, z float64) float64 {
+	xi, yi, zi := int(math.Floor(x)), int(math.Floor(y)), int(math.Floor(z))
+	xf, yf, zf := x-math.Floor(x), y-math.Floor(y), z-math.Floor(z)
+	u, v, w := perlinFade(xf), perlinFade(yf), perlinFade(zf)
+
+	aaa := perlinHash(xi, yi, zi, seed)
+	aba := perlinHash(xi, yi+1, zi, seed)
+	aab := perlinHash(xi, yi, zi+1, seed)
+	abb := perlinHash(xi, yi+1, zi+1, seed)
+	baa := perlinHash(xi+1, yi, zi, seed)
+	bba := perlinHash(xi+1, yi+1, zi, seed)
+	bab := perlinHash(xi+1, yi, zi+1, seed)
+	bbb := perlinHash(xi+1, yi+1, zi+1, seed)
+
+	x1 := perlinLerp(u, perlinGrad(aaa, xf, yf, zf), perlinGrad(baa, xf-1, yf, zf))
+	x2 := perlinLerp(u, perlinGrad(aba, xf, yf-1, zf), perlinGrad(bba, xf-1, yf-1, zf))
+	y1 := perlinLerp(v, x1, x2)
+
+	x3 := perlinLerp(u, perlinGrad(aab, xf, yf, zf-1), perlinGrad(bab, xf-1, yf, zf-1))
+	x4 := perlinLerp(u, perlinGrad(abb, xf, yf-1, zf-1), perlinGrad(bbb, xf-1, yf-1, zf-1))
+	y2 := perlinLerp(v, x3, x4)
+
+	return perlinLerp(w, y1, y2)
+}
+
+// clamp01 restricts x to [0, 1], used by the noise textures to turn a
+// roughly-[-1,1] noise sample into a Color0/Color1 mix fraction.
+func clamp01(x float64) float64 {
+	return math.Max(0, math.Min(1, x))
+}
+
+// TexturePerlin is a 3D gradient-lattice (classic Perlin) noise texture.
+// It samples noise at (u*Scale, v*Scale, W*Scale) -- W is a fixed third
+// lattice coordinate a caller can vary over time to animate the texture
+// without needing a 4D noise function -- remaps the result from
+// approximately [-1, 1] to [0, 1], and linearly interpolates between
+// Color0 and Color1 by that fraction.
+type TexturePerlin struct {
+	// Scale is the spatial frequency applied to the UV (and W) coordinates
+	// before sampling the noise lattice. Larger values produce
+	// finer-grained noise. Scale must be strictly positive.
+	Scale float64
+
+	// Seed selects one of 256 phases of the fixed reference permutation
+	// table (see perlinPermutation), giving different seeds visibly
+	// different noise patterns without reshuffling any table.
+	Seed int64
+
+	// W is an additional fixed lattice coordinate, letting a caller
+	// animate the texture by varying W between frames.
+	W float64
+
+	// Color0 is the color at the lowest noise values, Color1 at the
+	// highest.
+	Color0 Spectrum
+	Color1 Spectrum
+}
+
+// Validate reports whether tex has usable parameters.
+func (tex TexturePerlin) Validate() error {
+	if tex.Scale <= 0 {
+		return fmt.Errorf("error TexturePerlin.Scale must be positive: %v", tex.Scale)
+	}
+	return nil
+}
+
+// At returns the Color0/Color1 mix at UV coordinates (u, v), driven by the
+// noise lattice sampled at (u*Scale, v*Scale, W*Scale).
+func (tex TexturePerlin) At(u, v float64) Spectrum {
+	n := perlinNoise3(tex.Seed, u*tex.Scale, v*tex.Scale, tex.W*tex.Scale)
+	t := clamp01(n*0.5 + 0.5)
+	return Spectrum(r3.Vec(tex.Color0).Lerp(r3.Vec(tex.Color1), t))
+}
+
+// MarshalJSON encodes a TexturePerlin as JSON with a "Type" discriminator.
+func (tex TexturePerlin) MarshalJSON() ([]byte, error) {
+	type TexturePerlinData struct {
+		Type   string   `json:"Type"`
+		Scale  float64  `json:"Scale"`
+		Seed   int64    `json:"Seed"`
+		W      float64  `json:"W"`
+		Color0 Spectrum `json:"Color0"`
+		Color1 Spectrum `json:"Color1"`
+	}
+	data := TexturePerlinData{
+		Type:   "TexturePerlin",
+		Scale:  tex.Scale,
+		Seed:   tex.Seed,
+		W:      tex.W,
+		Color0: tex.Color0,
+		Color1: tex.Color1,
+	}
+	return json.Marshal(data)
+}
+
+// UnmarshalJSON decodes a TexturePerlin from JSON.
+func (tex *TexturePerlin) UnmarshalJSON(data []byte) error {
+	type TexturePerlinData struct {
+		Type   string   `json:"Type"`
+		Scale  float64  `json:"Scale"`
+		Seed   int64    `json:"Seed"`
+		W      float64  `json:"W"`
+		Color0 Spectrum `json:"Color0"`
+		Color1 Spectrum `json:"Color1"`
+	}
+	var temp TexturePerlinData
+	if err := json.Unmarshal(data, &temp); err != nil {
+		return err
+	}
+	if temp.Type != "TexturePerlin" {
+		return fmt.Errorf("invalid type: expected TexturePerlin, got %s", temp.Type)
+	}
+	tex.Scale = temp.Scale
+	tex.Seed = temp.Seed
+	tex.W = temp.W
+	tex.Color0 = temp.Color0
+	tex.Color1 = temp.Color1
+	return nil
+}
@@ -0,0 +1,63 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+func init() {
+	RegisterInterfaceType(SpectralUniform{})
+}
+
+// SpectralTexture is the wavelength-resolved analog of Texture: instead of
+// one RGB-like Spectrum, it returns a SampledSpectrum evaluated at the
+// specific wavelengths a RenderOptions.Spectral path is carrying. Materials
+// that want to stay wavelength-accurate end to end (rather than
+// round-tripping through RGBToSpectrum at every hit, as Dispersive does)
+// should sample a SpectralTexture instead of a Texture.
+type SpectralTexture interface {
+	// AtWavelengths returns the texture's value at (u, v), sampled at each
+	// of wavelengths.
+	AtWavelengths(u, v float64, wavelengths [spectralSamples]float64) SampledSpectrum
+	// Validate checks if the texture is valid.
+	Validate() error
+}
+
+// SpectralUniform is a SpectralTexture with a single, spatially uniform
+// color, upsampled into per-wavelength values via RGBToSpectrum. It is the
+// spectral-mode counterpart of TextureUniform.
+type SpectralUniform struct {
+	Color Spectrum
+}
+
+// AtWavelengths returns tex.Color upsampled at wavelengths, ignoring (u, v)
+// since the color is spatially uniform.
+func (tex SpectralUniform) AtWavelengths(u, v float64, wavelengths [spectralSamples]float64) SampledSpectrum {
+	return RGBToSpectrum(tex.Color, wavelengths)
+}
+
+func (tex SpectralUniform) Validate() error {
+	return nil
+}
+
+type spectralUniformData struct {
+	Type  string   `json:"Type"`
+	Color Spectrum `json:"Color"`
+}
+
+func (tex SpectralUniform) MarshalJSON() ([]byte, error) {
+	return json.Marshal(spectralUniformData{Type: "SpectralUniform", Color: tex.Color})
+}
+
+func (tex *SpectralUniform) UnmarshalJSON(data []byte) error {
+	var temp spectralUniformData
+	if err := json.Unmarshal(data, &temp); err != nil {
+		return err
+	}
+	if temp.Type != "SpectralUniform" {
+		return fmt.Errorf("invalid type: expected SpectralUniform, got %s", temp.Type)
+	}
+	tex.Color = temp.Color
+	return nil
+}
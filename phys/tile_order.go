@@ -0,0 +1,96 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import "fmt"
+
+// TileOrder selects what order fillRenderQueue enqueues a render's tiles
+// in. See RenderOptions.TileOrder.
+type TileOrder int
+
+const (
+	// TileOrderRowMajor enqueues tiles left-to-right, top-to-bottom. It is
+	// the zero value, so existing callers that never set TileOrder see no
+	// behavior change.
+	TileOrderRowMajor TileOrder = iota
+
+	// TileOrderHilbert enqueues tiles along a Hilbert space-filling curve
+	// over the tile grid, so consecutively dispatched (and, with enough
+	// workers, consecutively finished) tiles are always spatially
+	// adjacent.
+	TileOrderHilbert
+)
+
+func (o TileOrder) String() string {
+	switch o {
+	case TileOrderRowMajor:
+		return "RowMajor"
+	case TileOrderHilbert:
+		return "Hilbert"
+	default:
+		return fmt.Sprintf("TileOrder(%d)", int(o))
+	}
+}
+
+func (o TileOrder) Validate() error {
+	switch o {
+	case TileOrderRowMajor, TileOrderHilbert:
+		return nil
+	default:
+		return fmt.Errorf("bad TileOrder %v", o)
+	}
+}
+
+// hilbertTileOrder returns every (tx, ty) coordinate of a numTilesX x
+// numTilesY grid, ordered along a Hilbert curve over the smallest
+// power-of-two square containing the grid. Coordinates the curve visits
+// outside that grid (whenever numTilesX != numTilesY, or neither is a
+// power of two) are skipped in place rather than remapped, so the
+// coordinates that remain still trace contiguous runs of the curve.
+func hilbertTileOrder(numTilesX, numTilesY int) [][2]int {
+	if numTilesX <= 0 || numTilesY <= 0 {
+		return nil
+	}
+	order := 1
+	for order < numTilesX || order < numTilesY {
+		order *= 2
+	}
+	coords := make([][2]int, 0, numTilesX*numTilesY)
+	for d := 0; d < order*order; d++ {
+		x, y := hilbertD2XY(order, d)
+		if x < numTilesX && y < numTilesY {
+			coords = append(coords, [2]int{x, y})
+		}
+	}
+	return coords
+}
+
+// hilbertD2XY converts a distance d along a Hilbert curve of the given
+// order (side length, a power of two) into (x, y) grid coordinates, via
+// the standard bit-unpacking-and-rotate construction.
+func hilbertD2XY(order, d int) (x, y int) {
+	t := d
+	for s := 1; s < order; s *= 2 {
+		rx := 1 & (t / 2)
+		ry := 1 & (t ^ rx)
+		x, y = hilbertRotate(s, x, y, rx, ry)
+		x += s * rx
+		y += s * ry
+		t /= 4
+	}
+	return x, y
+}
+
+// hilbertRotate reflects and transposes (x, y) within an s x s sub-square
+// so the recursive quadrant construction in hilbertD2XY traces a
+// continuous curve across quadrant boundaries instead of four disconnected
+// copies of the same pattern.
+func hilbertRotate(s, x, y, rx, ry int) (int, int) {
+	if ry == 0 {
+		if rx == 1 {
+			x = s - 1 - x
+			y = s - 1 - y
+		}
+		x, y = y, x
+	}
+	return x, y
+}
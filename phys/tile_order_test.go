@@ -0,0 +1,77 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import (
+	"context"
+	"testing"
+)
+
+func TestHilbertTileOrderVisitsEveryCellExactlyOnce(t *testing.T) {
+	for _, dims := range [][2]int{{4, 4}, {3, 5}, {1, 1}, {7, 2}} {
+		numTilesX, numTilesY := dims[0], dims[1]
+		coords := hilbertTileOrder(numTilesX, numTilesY)
+		if want := numTilesX * numTilesY; len(coords) != want {
+			t.Fatalf("hilbertTileOrder(%d, %d) returned %d coords, want %d", numTilesX, numTilesY, len(coords), want)
+		}
+		seen := make(map[[2]int]bool, len(coords))
+		for _, c := range coords {
+			if c[0] < 0 || c[0] >= numTilesX || c[1] < 0 || c[1] >= numTilesY {
+				t.Fatalf("hilbertTileOrder(%d, %d) returned out-of-range coord %v", numTilesX, numTilesY, c)
+			}
+			if seen[c] {
+				t.Fatalf("hilbertTileOrder(%d, %d) returned coord %v twice", numTilesX, numTilesY, c)
+			}
+			seen[c] = true
+		}
+	}
+}
+
+// TestHilbertTileOrderIsSpatiallyCoherent checks the defining property a
+// row-major order lacks: consecutive tiles along the curve are adjacent
+// (Chebyshev distance 1) far more often than a scan order's worst case of
+// jumping a full row at every line wrap.
+func TestHilbertTileOrderIsSpatiallyCoherent(t *testing.T) {
+	coords := hilbertTileOrder(8, 8)
+	for i := 1; i < len(coords); i++ {
+		dx := coords[i][0] - coords[i-1][0]
+		dy := coords[i][1] - coords[i-1][1]
+		if dx < -1 || dx > 1 || dy < -1 || dy > 1 {
+			t.Fatalf("step %d: %v -> %v is not adjacent", i, coords[i-1], coords[i])
+		}
+	}
+}
+
+// TestRenderTiledHilbertOrderCoversWholeImage verifies RenderTiled with
+// TileOrder set to TileOrderHilbert still delivers every tile of the image
+// to OnTile exactly once, the same contract TileOrderRowMajor already has
+// to meet: TileOrder only changes dispatch order, never which tiles exist.
+func TestRenderTiledHilbertOrderCoversWholeImage(t *testing.T) {
+	scene := denoiseTestScene(2, false)
+	scene.RenderOptions.TileSize = 8
+	scene.RenderOptions.TileOrder = TileOrderHilbert
+
+	_, numTilesX, numTilesY := TileGrid(scene.RenderOptions)
+	seen := make(map[[2]int]bool)
+	_, err := RenderTiled(context.Background(), scene, func(res TileResult) error {
+		seen[[2]int{res.X, res.Y}] = true
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("RenderTiled() error = %v", err)
+	}
+	if want := numTilesX * numTilesY; len(seen) != want {
+		t.Errorf("RenderTiled() delivered %d distinct tiles, want %d", len(seen), want)
+	}
+}
+
+func TestTileOrderValidate(t *testing.T) {
+	if err := TileOrderRowMajor.Validate(); err != nil {
+		t.Errorf("TileOrderRowMajor.Validate() = %v, want nil", err)
+	}
+	if err := TileOrderHilbert.Validate(); err != nil {
+		t.Errorf("TileOrderHilbert.Validate() = %v, want nil", err)
+	}
+	if err := TileOrder(99).Validate(); err == nil {
+		t.Error("TileOrder(99).Validate() = nil, want an error")
+	}
+}
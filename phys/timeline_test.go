@@ -0,0 +1,80 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import (
+	"testing"
+	"time"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
+)
+
+func testTimeline(interp InterpolationKind) Timeline {
+	return Timeline{
+		Interpolation: interp,
+		Keyframes: []TimelineKeyframe{
+			{Time: 0, Extrinsics: CameraExtrinsics{LookFrom: r3.Point{X: 0, Z: 5}, LookAt: r3.Point{}, VUp: r3.Vec{Y: 1}}, LightIntensity: 0},
+			{Time: time.Second, Extrinsics: CameraExtrinsics{LookFrom: r3.Point{X: 10, Z: 5}, LookAt: r3.Point{}, VUp: r3.Vec{Y: 1}}, LightIntensity: 10},
+			{Time: 2 * time.Second, Extrinsics: CameraExtrinsics{LookFrom: r3.Point{X: 20, Z: 5}, LookAt: r3.Point{}, VUp: r3.Vec{Y: 1}}, LightIntensity: 20},
+		},
+	}
+}
+
+func TestTimelineValidate(t *testing.T) {
+	if err := testTimeline(InterpolationLinear).Validate(); err != nil {
+		t.Errorf("Validate() = %v, want nil", err)
+	}
+	if err := (Timeline{}).Validate(); err == nil {
+		t.Error("Validate() on an empty Timeline = nil, want an error")
+	}
+	unsorted := testTimeline(InterpolationLinear)
+	unsorted.Keyframes[1].Time = 0
+	if err := unsorted.Validate(); err == nil {
+		t.Error("Validate() with non-increasing keyframe times = nil, want an error")
+	}
+}
+
+func TestTimelineDurationFrames(t *testing.T) {
+	tl := testTimeline(InterpolationLinear)
+	if got, want := tl.Duration(), 2*time.Second; got != want {
+		t.Errorf("Duration() = %v, want %v", got, want)
+	}
+	if got, want := tl.DurationFrames(30), 60; got != want {
+		t.Errorf("DurationFrames(30) = %d, want %d", got, want)
+	}
+	if got := (Timeline{}).DurationFrames(30); got != 1 {
+		t.Errorf("DurationFrames(30) on an empty Timeline = %d, want 1", got)
+	}
+}
+
+func TestTimelineAtLinear(t *testing.T) {
+	tl := testTimeline(InterpolationLinear)
+	if got := tl.At(-time.Second).LookFrom.X; got != 0 {
+		t.Errorf("At(-1s).LookFrom.X = %v, want 0 (clamped to first keyframe)", got)
+	}
+	if got := tl.At(3 * time.Second).LookFrom.X; got != 20 {
+		t.Errorf("At(3s).LookFrom.X = %v, want 20 (clamped to last keyframe)", got)
+	}
+	if got, want := tl.At(500*time.Millisecond).LookFrom.X, 5.0; got != want {
+		t.Errorf("At(500ms).LookFrom.X = %v, want %v", got, want)
+	}
+}
+
+func TestTimelineAtCatmullRomPassesThroughKeyframes(t *testing.T) {
+	tl := testTimeline(InterpolationCatmullRom)
+	for _, k := range tl.Keyframes {
+		got := tl.At(k.Time).LookFrom.X
+		if got != k.Extrinsics.LookFrom.X {
+			t.Errorf("At(%v).LookFrom.X = %v, want %v (CatmullRom must pass through every keyframe)", k.Time, got, k.Extrinsics.LookFrom.X)
+		}
+	}
+}
+
+func TestTimelineLightIntensityAt(t *testing.T) {
+	tl := testTimeline(InterpolationLinear)
+	if got, want := tl.LightIntensityAt(500*time.Millisecond), 5.0; got != want {
+		t.Errorf("LightIntensityAt(500ms) = %v, want %v", got, want)
+	}
+	if got, want := tl.LightIntensityAt(10*time.Second), 20.0; got != want {
+		t.Errorf("LightIntensityAt(10s) = %v, want %v (clamped to last keyframe)", got, want)
+	}
+}
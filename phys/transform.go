@@ -2,101 +2,291 @@
 package phys
 
 import (
+	"encoding/json"
+	"fmt"
+	"math"
+
 	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
 )
 
-// // Transform represents a 4x4 transformation matrix.
-// type Transform struct {
-// 	Matrix        Matrix4x4
-// 	InverseMatrix Matrix4x4 // Precompute the inverse for efficiency.
-// }
-
-// // NewTransform creates a new Transform with an idnode matrix.
-// func NewTransform() Transform {
-// 	identity := IdentityMatrix()
-// 	return Transform{
-// 		Matrix:        identity,
-// 		InverseMatrix: identity,
-// 	}
-// }
-
-// // ApplyToPoint applies the transformation to a r3.Point.
-// func (t Transform) ApplyToPoint(p r3.Point) r3.Point {
-// 	return t.Matrix.Transformr3.Point(p)
-// }
-
-// // ApplyToVector applies the transformation to a r3.Vec.
-// func (t Transform) ApplyToVector(v r3.Vec) r3.Vec {
-// 	return t.Matrix.Transformr3.Vec(v)
-// }
-
-// // Inverse returns the inverse of the transformation.
-// func (t Transform) Inverse() Transform {
-// 	return Transform{
-// 		Matrix:        t.InverseMatrix,
-// 		InverseMatrix: t.Matrix,
-// 	}
-// }
-
-// // Combine combines the current transform with another.
-// func (t Transform) Combine(other Transform) Transform {
-// 	combinedMatrix := t.Matrix.Multiply(other.Matrix)
-// 	combinedInverse := other.InverseMatrix.Multiply(t.InverseMatrix)
-// 	return Transform{
-// 		Matrix:        combinedMatrix,
-// 		InverseMatrix: combinedInverse,
-// 	}
-// }
-
-// Transform represents a transformation in 3D space, including translation,
-// rotation (as a matrix), and scaling.
+// Transform represents a transformation in 3D space as a 4x4 homogeneous
+// matrix, which (unlike a translation + rotation + per-axis scale) can
+// also encode shear and perspective projection. matrix and inverse are
+// kept in lockstep by every constructor and by Compose, so Inverse() is
+// always a cache hit rather than a re-derivation of matrix's cofactors.
 type Transform struct {
-	Translation r3.Vec
-	Rotation    r3.Mat3x3
-	Scale       r3.Vec
+	matrix  r3.Mat4
+	inverse r3.Mat4
 }
 
-// NewTransform creates a new Transform with default values (idnode).
+// NewTransform returns the identity Transform.
 func NewTransform() Transform {
-	return Transform{
-		Translation: r3.Vec{X: 0, Y: 0, Z: 0},
-		Rotation:    r3.IdentityMat3x3(),
-		Scale:       r3.Vec{X: 1, Y: 1, Z: 1},
+	id := r3.IdentityMat4()
+	return Transform{matrix: id, inverse: id}
+}
+
+// NewTransformFromMatrix builds a Transform from an arbitrary m, computing
+// and caching its inverse up front. It returns an error if m is singular,
+// since Transform has no representation for a transformation with no
+// inverse.
+func NewTransformFromMatrix(m r3.Mat4) (Transform, error) {
+	inv, ok := m.Inverse()
+	if !ok {
+		return Transform{}, fmt.Errorf("NewTransformFromMatrix: singular matrix (determinant %v)", m.Determinant())
+	}
+	return Transform{matrix: m, inverse: inv}, nil
+}
+
+// Matrix returns t's underlying 4x4 matrix.
+func (t Transform) Matrix() r3.Mat4 {
+	return t.matrix
+}
+
+// MarshalJSON writes t's matrix only; inverse is a derived cache, rebuilt
+// by UnmarshalJSON rather than serialized, the same reasoning
+// Instancer.MarshalJSON and TLAS.MarshalJSON apply to their own derived
+// BVHs.
+func (t Transform) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.matrix)
+}
+
+// UnmarshalJSON reads a matrix and rebuilds t's cached inverse, erroring
+// if the matrix is singular and so has none.
+func (t *Transform) UnmarshalJSON(data []byte) error {
+	var m r3.Mat4
+	if err := json.Unmarshal(data, &m); err != nil {
+		return fmt.Errorf("Transform: %w", err)
+	}
+	nt, err := NewTransformFromMatrix(m)
+	if err != nil {
+		return fmt.Errorf("Transform: %w", err)
+	}
+	*t = nt
+	return nil
+}
+
+// NewTranslation returns a Transform that translates by v.
+func NewTranslation(v r3.Vec) Transform {
+	m := r3.IdentityMat4()
+	m.M[0][3], m.M[1][3], m.M[2][3] = v.X, v.Y, v.Z
+	inv := r3.IdentityMat4()
+	inv.M[0][3], inv.M[1][3], inv.M[2][3] = -v.X, -v.Y, -v.Z
+	return Transform{matrix: m, inverse: inv}
+}
+
+// NewScale returns a Transform that scales each axis independently by v.
+// v's components must be nonzero; a zero scale has no inverse.
+func NewScale(v r3.Vec) Transform {
+	m := r3.IdentityMat4()
+	m.M[0][0], m.M[1][1], m.M[2][2] = v.X, v.Y, v.Z
+	inv := r3.IdentityMat4()
+	inv.M[0][0], inv.M[1][1], inv.M[2][2] = 1/v.X, 1/v.Y, 1/v.Z
+	return Transform{matrix: m, inverse: inv}
+}
+
+// NewRotation returns a Transform from a rotation matrix r, assumed
+// orthonormal (as every RotationMatrixX/Y/Z this package's callers build
+// already is), so its inverse is its transpose -- cheaper and exact
+// compared to the general cofactor-expansion Inverse.
+func NewRotation(r r3.Mat3x3) Transform {
+	rt := r.Transpose()
+	m := r3.IdentityMat4()
+	inv := r3.IdentityMat4()
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			m.M[i][j] = r.M[i][j]
+			inv.M[i][j] = rt.M[i][j]
+		}
+	}
+	return Transform{matrix: m, inverse: inv}
+}
+
+// NewAxisAngle returns a rotation Transform of angle radians about axis
+// (which must be a unit vector), via Rodrigues' rotation formula.
+func NewAxisAngle(axis r3.Vec, angle float64) Transform {
+	c := math.Cos(angle)
+	s := math.Sin(angle)
+	t := 1 - c
+	x, y, z := axis.X, axis.Y, axis.Z
+	return NewRotation(r3.Mat3x3{M: [3][3]float64{
+		{t*x*x + c, t*x*y - s*z, t*x*z + s*y},
+		{t*x*y + s*z, t*y*y + c, t*y*z - s*x},
+		{t*x*z - s*y, t*y*z + s*x, t*z*z + c},
+	}})
+}
+
+// NewLookAt returns a Transform placing its local origin at eye with its
+// local -Z axis pointing at target, the same right-handed, -Z-forward
+// basis convention phys/gltf's lookAtTRS/importCamera use for a camera:
+// w = eye-target, u = up x w, v = w x u.
+func NewLookAt(eye, target r3.Point, up r3.Vec) Transform {
+	w := eye.Sub(target).Unit()
+	u := up.Cross(w).Unit()
+	v := w.Cross(u)
+	rotation := NewRotation(r3.Mat3x3{M: [3][3]float64{
+		{u.X, v.X, w.X},
+		{u.Y, v.Y, w.Y},
+		{u.Z, v.Z, w.Z},
+	}})
+	return Compose(rotation, NewTranslation(r3.Vec(eye)))
+}
+
+// NewPerspective returns an OpenGL-style perspective projection Transform:
+// fovY is the full vertical field of view in radians, aspect is
+// width/height, and near/far are the positive distances to the clip
+// planes. Panics on a degenerate (non-positive, or near >= far) view
+// volume, which would otherwise produce a silently-unusable projection
+// rather than a working one with the wrong shape.
+func NewPerspective(fovY, aspect, near, far float64) Transform {
+	if near <= 0 || far <= 0 || near >= far {
+		panic(fmt.Sprintf("NewPerspective: degenerate near/far (near=%v far=%v)", near, far))
+	}
+	f := 1 / math.Tan(fovY/2)
+	m := r3.Mat4{M: [4][4]float64{
+		{f / aspect, 0, 0, 0},
+		{0, f, 0, 0},
+		{0, 0, (far + near) / (near - far), 2 * far * near / (near - far)},
+		{0, 0, -1, 0},
+	}}
+	t, err := NewTransformFromMatrix(m)
+	if err != nil {
+		panic(fmt.Sprintf("NewPerspective: %v", err)) // A valid fov/aspect/near/far always yields an invertible projection.
+	}
+	return t
+}
+
+// NewOrthographic returns an OpenGL-style orthographic projection
+// Transform mapping the box [left,right] x [bottom,top] x [-near,-far] to
+// the [-1,1]^3 clip cube. Panics on a degenerate (zero-volume) box for the
+// same reason NewPerspective does.
+func NewOrthographic(left, right, bottom, top, near, far float64) Transform {
+	if left == right || bottom == top || near == far {
+		panic(fmt.Sprintf("NewOrthographic: degenerate box (left=%v right=%v bottom=%v top=%v near=%v far=%v)", left, right, bottom, top, near, far))
 	}
+	m := r3.Mat4{M: [4][4]float64{
+		{2 / (right - left), 0, 0, -(right + left) / (right - left)},
+		{0, 2 / (top - bottom), 0, -(top + bottom) / (top - bottom)},
+		{0, 0, -2 / (far - near), -(far + near) / (far - near)},
+		{0, 0, 0, 1},
+	}}
+	t, err := NewTransformFromMatrix(m)
+	if err != nil {
+		panic(fmt.Sprintf("NewOrthographic: %v", err)) // A valid, non-degenerate box always yields an invertible projection.
+	}
+	return t
+}
+
+// Compose returns the Transform equivalent to applying transforms in
+// order: transforms[0] first, transforms[len-1] last, so
+// Compose(a, b).ApplyToPoint(p) == b.ApplyToPoint(a.ApplyToPoint(p)).
+// Its inverse is accumulated alongside the forward matrix -- (AB)^-1 =
+// B^-1 * A^-1 -- rather than computed afterward from the composed
+// matrix's cofactors. Compose() with no arguments returns the identity.
+func Compose(transforms ...Transform) Transform {
+	m := r3.IdentityMat4()
+	inv := r3.IdentityMat4()
+	for _, t := range transforms {
+		m = t.matrix.Mul(m)
+		inv = inv.Mul(t.inverse)
+	}
+	return Transform{matrix: m, inverse: inv}
 }
 
 // ApplyToPoint applies the transformation to a r3.Point.
 func (t Transform) ApplyToPoint(p r3.Point) r3.Point {
-	// Scale, then rotate, then translate.
-	scaled := r3.Vec{X: p.X * t.Scale.X, Y: p.Y * t.Scale.Y, Z: p.Z * t.Scale.Z}
-	rotated := t.Rotation.MulVec(scaled)
-	translated := rotated.Add(t.Translation)
-	return r3.Point(translated)
+	return t.matrix.TransformPoint(p)
 }
 
-// ApplyToVector applies the transformation to a r3.Vec (ignoring translation).
+// ApplyToVector applies the transformation to a r3.Vec (ignoring
+// translation).
 func (t Transform) ApplyToVector(v r3.Vec) r3.Vec {
-	// Scale, then rotate
-	scaled := r3.Vec{X: v.X * t.Scale.X, Y: v.Y * t.Scale.Y, Z: v.Z * t.Scale.Z}
-	rotated := t.Rotation.MulVec(scaled)
-	return rotated
+	return t.matrix.TransformVec(v)
+}
+
+// ApplyToNormal transforms a surface normal by the inverse-transpose of
+// t's matrix, keeping it perpendicular to its surface under a
+// non-uniform scale or shear. It reuses t.inverse (already cached at
+// construction) rather than calling Mat4.TransformNormal, which would
+// recompute the inverse Mat4.Inverse already did once.
+func (t Transform) ApplyToNormal(n r3.Vec) r3.Vec {
+	return t.inverse.Transpose().TransformVec(n)
 }
 
 // Inverse returns the inverse of the transformation.
 func (t Transform) Inverse() Transform {
-	// Invert scale
-	invScale := r3.Vec{
-		X: 1 / t.Scale.X,
-		Y: 1 / t.Scale.Y,
-		Z: 1 / t.Scale.Z,
+	return Transform{matrix: t.inverse, inverse: t.matrix}
+}
+
+// TransformRay returns r with its origin and direction run through
+// ApplyToPoint and ApplyToVector respectively, every other field (depth,
+// radiance, rand, pixelX/Y, rayType, time, ...) copied through unchanged.
+// TransformedShape.Collide and instanceProxy.Collide both call
+// t.Inverse().TransformRay(r) to build the ray they hand to the wrapped
+// Shape in its local space.
+func (t Transform) TransformRay(r ray) ray {
+	out := r
+	out.origin = t.ApplyToPoint(r.origin)
+	out.direction = t.ApplyToVector(r.direction)
+	return out
+}
+
+// Validate reports an error if t's matrix has a NaN/Inf entry, or is
+// singular (|Determinant()| below eps), either of which would make
+// ApplyToPoint/Inverse produce garbage.
+func (t Transform) Validate() error {
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 4; j++ {
+			v := t.matrix.M[i][j]
+			if math.IsNaN(v) || math.IsInf(v, 0) {
+				return fmt.Errorf("Transform: matrix entry [%d][%d] is %v", i, j, v)
+			}
+		}
+	}
+	if d := t.matrix.Determinant(); math.Abs(d) < eps {
+		return fmt.Errorf("Transform: singular matrix (determinant %v)", d)
+	}
+	return nil
+}
+
+// LinearDeterminant returns the determinant of t's upper-left 3x3
+// (linear, non-translation) part: the factor by which t scales volume.
+// shape_instancer.go, shape_signed_distance.go and shape_tlas.go use this
+// to correct a SignedDistance estimate for t's scale, in place of the old
+// Scale.X*Scale.Y*Scale.Z now that a Transform's linear part isn't
+// necessarily a pure per-axis scale.
+func (t Transform) LinearDeterminant() float64 {
+	m := t.matrix
+	return m.M[0][0]*(m.M[1][1]*m.M[2][2]-m.M[1][2]*m.M[2][1]) -
+		m.M[0][1]*(m.M[1][0]*m.M[2][2]-m.M[1][2]*m.M[2][0]) +
+		m.M[0][2]*(m.M[1][0]*m.M[2][1]-m.M[1][1]*m.M[2][0])
+}
+
+// Decompose splits t's matrix into a translation, rotation, and per-axis
+// scale, assuming its linear part has no shear (any shear in t.matrix is
+// discarded). It exists for callers like phys/gltf that target glTF's TRS
+// node format, which is itself limited to exactly that: phys/gltf's own
+// composeTransform carried this same assumption before Transform grew a
+// general Compose.
+func (t Transform) Decompose() (translation r3.Vec, rotation r3.Mat3x3, scale r3.Vec) {
+	m := t.matrix
+	c0 := r3.Vec{X: m.M[0][0], Y: m.M[1][0], Z: m.M[2][0]}
+	c1 := r3.Vec{X: m.M[0][1], Y: m.M[1][1], Z: m.M[2][1]}
+	c2 := r3.Vec{X: m.M[0][2], Y: m.M[1][2], Z: m.M[2][2]}
+	scale = r3.Vec{X: c0.Length(), Y: c1.Length(), Z: c2.Length()}
+	translation = r3.Vec{X: m.M[0][3], Y: m.M[1][3], Z: m.M[2][3]}
+	if scale.X > eps {
+		c0 = c0.Muls(1 / scale.X)
+	}
+	if scale.Y > eps {
+		c1 = c1.Muls(1 / scale.Y)
 	}
-	// Invert rotation
-	invRotation := t.Rotation.Transpose()
-	// Invert translation
-	invTranslation := invRotation.MulVec(t.Translation.Muls(-1)).Mul(invScale)
-	return Transform{
-		Translation: invTranslation,
-		Rotation:    invRotation,
-		Scale:       invScale,
+	if scale.Z > eps {
+		c2 = c2.Muls(1 / scale.Z)
 	}
+	rotation = r3.Mat3x3{M: [3][3]float64{
+		{c0.X, c1.X, c2.X},
+		{c0.Y, c1.Y, c2.Y},
+		{c0.Z, c1.Z, c2.Z},
+	}}
+	return translation, rotation, scale
 }
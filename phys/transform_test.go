@@ -0,0 +1,87 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import (
+	"math"
+	"testing"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
+)
+
+// TestTransformedShapeQuadMatchesManuallyRecenteredQuad verifies that
+// wrapping a Quad in a TransformedShape (rotate then translate) produces
+// the same ray hits as building a second Quad directly at the rotated,
+// translated pose: the same property TransformedShape.Bounds and
+// instanceProxy.Collide already rely on for BVH correctness.
+func TestTransformedShapeQuadMatchesManuallyRecenteredQuad(t *testing.T) {
+	original := Quad{Center: r3.Point{Z: -5}, Normal: r3.Vec{Z: 1}, Width: 2, Height: 2}
+	xform := Compose(NewAxisAngle(r3.Vec{Y: 1}, math.Pi/4), NewTranslation(r3.Vec{X: 3, Y: 1, Z: 2}))
+	transformed := TransformedShape{Shape: original, Transform: xform}
+
+	recentered := Quad{
+		Center: xform.ApplyToPoint(original.Center),
+		Normal: xform.ApplyToVector(original.Normal).Unit(),
+		Width:  original.Width,
+		Height: original.Height,
+	}
+
+	// Aim rays at a handful of points spread across the quad's face
+	// (in the original's own local frame, away from its edges to avoid
+	// any ambiguity from the two Quads deriving their in-plane u/v axes
+	// independently), then transform each aim point into world space by
+	// the same xform so both shapes are probed at matching positions.
+	for i := 0; i < 9; i++ {
+		u := float64(i%3)/2*1.6 - 0.8
+		v := float64(i/3)/2*1.6 - 0.8
+		localAim := r3.Point{X: u, Y: v, Z: -5}
+		worldAim := xform.ApplyToPoint(localAim)
+		origin := worldAim.Add(recentered.Normal.Muls(10))
+		r := ray{origin: origin, direction: recentered.Normal.Muls(-1)}
+
+		hitTransformed, colTransformed := transformed.Collide(r, 0, 1000)
+		hitRecentered, colRecentered := recentered.Collide(r, 0, 1000)
+
+		if hitTransformed != hitRecentered {
+			t.Fatalf("aim %d: TransformedShape hit = %v, recentered Quad hit = %v", i, hitTransformed, hitRecentered)
+		}
+		if !hitTransformed {
+			continue
+		}
+		if diff := colTransformed.at.Sub(colRecentered.at).Length(); diff > 1e-9 {
+			t.Errorf("aim %d: hit point %v, want %v (diff %v)", i, colTransformed.at, colRecentered.at, diff)
+		}
+		if diff := colTransformed.normal.Sub(colRecentered.normal).Length(); diff > 1e-9 {
+			t.Errorf("aim %d: normal %v, want %v (diff %v)", i, colTransformed.normal, colRecentered.normal, diff)
+		}
+	}
+}
+
+// TestTransformRayPreservesOtherFields verifies TransformRay only touches
+// origin and direction, leaving every other field (the values a scattered
+// or shadow ray carries through a whole path) copied through unchanged.
+func TestTransformRayPreservesOtherFields(t *testing.T) {
+	r := ray{
+		origin:    r3.Point{X: 1, Y: 2, Z: 3},
+		direction: r3.Vec{Z: -1},
+		depth:     2,
+		radiance:  Spectrum{X: 0.5, Y: 0.5, Z: 0.5},
+		pixelX:    7,
+		pixelY:    9,
+		rayType:   RayTypeShadow,
+		time:      0.25,
+	}
+	xform := Compose(NewAxisAngle(r3.Vec{X: 1}, math.Pi/2), NewTranslation(r3.Vec{X: 1}))
+
+	got := xform.TransformRay(r)
+
+	if got.depth != r.depth || got.radiance != r.radiance || got.pixelX != r.pixelX ||
+		got.pixelY != r.pixelY || got.rayType != r.rayType || got.time != r.time {
+		t.Errorf("TransformRay() = %+v, want every non-geometric field preserved from %+v", got, r)
+	}
+	if want := xform.ApplyToPoint(r.origin); got.origin != want {
+		t.Errorf("TransformRay() origin = %v, want %v", got.origin, want)
+	}
+	if want := xform.ApplyToVector(r.direction); got.direction != want {
+		t.Errorf("TransformRay() direction = %v, want %v", got.direction, want)
+	}
+}
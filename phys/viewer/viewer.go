@@ -0,0 +1,285 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+
+// Package viewer wires lab/event/key keyboard events into a progressively
+// rendered, interactively steerable view of a phys.Scene. It turns the
+// offline workflow (edit Go, re-run, reload a PNG) into a loop where the
+// camera, reconstruction filter, and sample count can all be adjusted
+// between renders of the same scene.
+package viewer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"os"
+	"sync"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/lab/event/key"
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/phys"
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
+)
+
+// rotateAroundAxis rotates v by angle radians (right-hand rule) around
+// axis, which must be a unit vector, via Rodrigues' rotation formula.
+func rotateAroundAxis(v, axis r3.Vec, angle float64) r3.Vec {
+	cosT, sinT := math.Cos(angle), math.Sin(angle)
+	return v.Muls(cosT).
+		Add(axis.Cross(v).Muls(sinT)).
+		Add(axis.Muls(axis.Dot(v) * (1 - cosT)))
+}
+
+// filterCycle lists the ReconFilter presets "F" cycles through, in order.
+// The zero ReconFilter (Eval == nil) is first so cycling can return to
+// Scene.RenderOptions' default per-pixel-average behavior.
+var filterCycle = []phys.ReconFilter{
+	{},
+	phys.BoxFilter(),
+	phys.TentFilter(),
+	phys.MitchellNetravaliFilter(),
+}
+
+// Viewer holds the mutable interactive state -- camera pose, active
+// reconstruction filter, sample budget -- layered on top of a Scene, plus
+// the progressive accumulator that averages successive Step renders.
+//
+// Viewer is safe for concurrent use: HandleKey and Step both lock an
+// internal mutex, so key events arriving on one goroutine (e.g. a UI event
+// loop) can safely interleave with a render loop calling Step on another.
+type Viewer struct {
+	// Scene is the scene being viewed. Its first camera must be a
+	// phys.FocusableCamera; Viewer moves that camera in place as keys are
+	// handled, and Scene.RenderOptions.Filter and RaysPerPixel are
+	// likewise mutated directly.
+	Scene *phys.Scene
+
+	// TranslateStep is the distance WASD moves the camera per key press
+	// before the Shift/Alt modifier scale is applied.
+	TranslateStep phys.Distance
+	// RotateStep is the angle in radians QE and the arrow keys rotate the
+	// camera per key press before the Shift/Alt modifier scale is applied.
+	RotateStep float64
+	// FOVStep is the fractional change +/- applies to FOVHeight/FOVWidth
+	// per key press before the Shift/Alt modifier scale is applied.
+	FOVStep float64
+	// RaysPerPixelStep is the change in RenderOptions.RaysPerPixel that
+	// '[' and ']' apply per key press before the Shift/Alt modifier scale
+	// is applied. It is rounded to at least 1 sample.
+	RaysPerPixelStep int
+
+	// SavePath is the file Ctrl+S writes the serialized Scene to.
+	SavePath string
+
+	mu          sync.Mutex
+	filterIndex int
+	accum       []r3.Vec
+	accumDx     int
+	accumDy     int
+	accumN      int
+}
+
+// New returns a Viewer over scene, whose first camera must be a
+// phys.FocusableCamera.
+func New(scene *phys.Scene) (*Viewer, error) {
+	if len(scene.Camera) == 0 {
+		return nil, fmt.Errorf("error viewer.New: scene has no Camera")
+	}
+	if _, ok := scene.Camera[0].(phys.FocusableCamera); !ok {
+		return nil, fmt.Errorf("error viewer.New: scene.Camera[0] is %T, want phys.FocusableCamera", scene.Camera[0])
+	}
+	return &Viewer{
+		Scene:            scene,
+		TranslateStep:    phys.Millimeter,
+		RotateStep:       5 * math.Pi / 180,
+		FOVStep:          0.1,
+		RaysPerPixelStep: 1,
+		SavePath:         "scene.json",
+	}, nil
+}
+
+// modifierScale reports the motion multiplier for mods: Shift scales by
+// 10x, Alt by 0.1x. Both together compose to 1x, matching the repo's
+// other modifier handling (e.g. lab/client/app.go) where each bit is
+// tested independently rather than treated as an exclusive choice.
+func modifierScale(mods key.Modifiers) float64 {
+	scale := 1.0
+	if mods&key.ModShift != 0 {
+		scale *= 10
+	}
+	if mods&key.ModAlt != 0 {
+		scale *= 0.1
+	}
+	return scale
+}
+
+// HandleKey applies the camera, filter, sample-count, or save action
+// bound to e, if any. Key repeats and releases (Direction != DirPress)
+// are ignored, since every bound action is a discrete step rather than a
+// held state. Any camera or RenderOptions mutation resets the progressive
+// accumulator so Step starts refining the new view from scratch.
+func (v *Viewer) HandleKey(e key.Event) error {
+	if e.Direction != key.DirPress {
+		return nil
+	}
+	if e.Modifiers&key.ModControl != 0 && e.Code == "KeyS" {
+		return v.Save()
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	cam := v.Scene.Camera[0].(phys.FocusableCamera)
+	scale := modifierScale(e.Modifiers)
+	mutated := true
+
+	w := cam.LookFrom.Sub(cam.LookAt).Unit() // Points from LookAt back to LookFrom.
+	u := cam.VUp.Cross(w).Unit()             // Right.
+	up := w.Cross(u)                         // Orthonormalized up.
+
+	switch e.Code {
+	case "KeyW":
+		cam = translateCamera(cam, w.Muls(-float64(v.TranslateStep)*scale))
+	case "KeyS":
+		cam = translateCamera(cam, w.Muls(float64(v.TranslateStep)*scale))
+	case "KeyA":
+		cam = translateCamera(cam, u.Muls(-float64(v.TranslateStep)*scale))
+	case "KeyD":
+		cam = translateCamera(cam, u.Muls(float64(v.TranslateStep)*scale))
+	case "KeyQ":
+		cam.VUp = rotateAroundAxis(cam.VUp, w, v.RotateStep*scale)
+	case "KeyE":
+		cam.VUp = rotateAroundAxis(cam.VUp, w, -v.RotateStep*scale)
+	case "ArrowLeft":
+		cam.LookAt = cam.LookFrom.Add(rotateAroundAxis(cam.LookAt.Sub(cam.LookFrom), up, v.RotateStep*scale))
+	case "ArrowRight":
+		cam.LookAt = cam.LookFrom.Add(rotateAroundAxis(cam.LookAt.Sub(cam.LookFrom), up, -v.RotateStep*scale))
+	case "ArrowUp":
+		cam.LookAt = cam.LookFrom.Add(rotateAroundAxis(cam.LookAt.Sub(cam.LookFrom), u, v.RotateStep*scale))
+	case "ArrowDown":
+		cam.LookAt = cam.LookFrom.Add(rotateAroundAxis(cam.LookAt.Sub(cam.LookFrom), u, -v.RotateStep*scale))
+	case "Equal":
+		cam.FOVHeight *= phys.Distance(1 + v.FOVStep*scale)
+		cam.FOVWidth *= phys.Distance(1 + v.FOVStep*scale)
+	case "Minus":
+		cam.FOVHeight = maxDistance(cam.FOVHeight*phys.Distance(1-v.FOVStep*scale), phys.Nanometer)
+		cam.FOVWidth = maxDistance(cam.FOVWidth*phys.Distance(1-v.FOVStep*scale), phys.Nanometer)
+	case "KeyF":
+		v.filterIndex = (v.filterIndex + 1) % len(filterCycle)
+		v.Scene.RenderOptions.Filter = filterCycle[v.filterIndex]
+	case "BracketRight":
+		v.Scene.RenderOptions.RaysPerPixel += maxInt(1, int(float64(v.RaysPerPixelStep)*scale))
+	case "BracketLeft":
+		v.Scene.RenderOptions.RaysPerPixel = maxInt(1, v.Scene.RenderOptions.RaysPerPixel-maxInt(1, int(float64(v.RaysPerPixelStep)*scale)))
+	default:
+		mutated = false
+	}
+
+	v.Scene.Camera[0] = cam
+	if mutated {
+		v.resetAccumulation()
+	}
+	return nil
+}
+
+// translateCamera returns cam with both LookFrom and LookAt shifted by
+// delta, so the view direction is preserved and only the camera's
+// position changes (a "fly" camera translation).
+func translateCamera(cam phys.FocusableCamera, delta r3.Vec) phys.FocusableCamera {
+	cam.LookFrom = cam.LookFrom.Add(delta)
+	cam.LookAt = cam.LookAt.Add(delta)
+	return cam
+}
+
+func maxDistance(a, b phys.Distance) phys.Distance {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// resetAccumulation discards Step's running mean, so the next Step starts
+// a fresh progressive accumulation. Callers holding v.mu must call this
+// directly; HandleKey calls it automatically after a mutating key.
+func (v *Viewer) resetAccumulation() {
+	v.accum = nil
+	v.accumN = 0
+}
+
+// Reset discards the progressive accumulator built up by Step, without
+// otherwise changing the Scene. Call it after mutating the Scene directly
+// (e.g. adding a Node) rather than through HandleKey.
+func (v *Viewer) Reset() {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.resetAccumulation()
+}
+
+// Step renders one more full-frame sample of v.Scene and folds it into
+// the running per-pixel mean of every frame rendered since the last
+// mutation or Reset, returning that mean as an image. Accumulating across
+// independent Step calls, each with its own random seed, is what makes
+// the view progressively converge: a caller driving an interactive loop
+// calls Step repeatedly between HandleKey calls and displays its result.
+func (v *Viewer) Step(ctx context.Context) (*image.RGBA, error) {
+	v.mu.Lock()
+	scene := v.Scene
+	scene.RenderOptions.Seed++ // Each Step must sample different noise than the last.
+	v.mu.Unlock()
+
+	artifact, err := phys.Render(ctx, scene)
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if err != nil {
+		return nil, fmt.Errorf("error Viewer.Step: %v", err)
+	}
+	dx, dy := scene.RenderOptions.Dx, scene.RenderOptions.Dy
+	if v.accum == nil || v.accumDx != dx || v.accumDy != dy {
+		v.accum = make([]r3.Vec, dx*dy)
+		v.accumDx, v.accumDy = dx, dy
+		v.accumN = 0
+	}
+	v.accumN++
+	out := image.NewRGBA(image.Rect(0, 0, dx, dy))
+	for y := 0; y < dy; y++ {
+		for x := 0; x < dx; x++ {
+			i := y*dx + x
+			c := artifact.Image.RGBAAt(x, y)
+			v.accum[i] = v.accum[i].Add(r3.Vec{X: float64(c.R), Y: float64(c.G), Z: float64(c.B)})
+			mean := v.accum[i].Divs(float64(v.accumN))
+			out.SetRGBA(x, y, color.RGBA{
+				R: uint8(math.Min(255, mean.X)),
+				G: uint8(math.Min(255, mean.Y)),
+				B: uint8(math.Min(255, mean.Z)),
+				A: 255,
+			})
+		}
+	}
+	return out, nil
+}
+
+// Save serializes v.Scene to JSON via Scene's existing
+// marshalInterface-based interface-registry machinery and writes it to
+// v.SavePath.
+func (v *Viewer) Save() error {
+	v.mu.Lock()
+	data, err := json.MarshalIndent(v.Scene, "", "  ")
+	path := v.SavePath
+	v.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("error Viewer.Save: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("error Viewer.Save: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+
+package viewer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/lab/event/key"
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/phys"
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/phys/viewerfixture"
+)
+
+// TestNewRejectsNonFocusableCamera verifies New returns an honest error
+// instead of panicking later when the scene's first camera isn't a
+// phys.FocusableCamera.
+func TestNewRejectsNonFocusableCamera(t *testing.T) {
+	scene := &phys.Scene{Camera: []phys.Camera{phys.OrthographicCamera{}}}
+	if _, err := New(scene); err == nil {
+		t.Fatal("New: expected an error for a non-FocusableCamera scene, got nil")
+	}
+}
+
+// TestHandleKeyTranslatesCamera verifies WASD moves LookFrom and LookAt by
+// the same delta, scaled by the Shift modifier.
+func TestHandleKeyTranslatesCamera(t *testing.T) {
+	scene := viewerfixture.Scene(t)
+	v, err := New(scene)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	before := scene.Camera[0].(phys.FocusableCamera)
+	if err := v.HandleKey(key.Event{Code: "KeyD", Direction: key.DirPress, Modifiers: key.ModShift}); err != nil {
+		t.Fatalf("HandleKey: %v", err)
+	}
+	after := scene.Camera[0].(phys.FocusableCamera)
+	wantDelta := 10 * float64(v.TranslateStep) // Shift = 10x.
+	gotDelta := after.LookFrom.Sub(before.LookFrom).Length()
+	if !closeEnough(gotDelta, wantDelta) {
+		t.Errorf("LookFrom moved %v, want %v", gotDelta, wantDelta)
+	}
+	if !after.LookAt.Sub(before.LookAt).IsClose(after.LookFrom.Sub(before.LookFrom), 1e-9) {
+		t.Errorf("LookAt delta %v != LookFrom delta %v; translate should preserve view direction",
+			after.LookAt.Sub(before.LookAt), after.LookFrom.Sub(before.LookFrom))
+	}
+}
+
+func closeEnough(a, b float64) bool {
+	const atol = 1e-6
+	d := a - b
+	return d > -atol && d < atol
+}
+
+// TestHandleKeyIgnoresNonPressDirections verifies key releases and
+// repeats don't move the camera or toggle state.
+func TestHandleKeyIgnoresNonPressDirections(t *testing.T) {
+	scene := viewerfixture.Scene(t)
+	v, err := New(scene)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	before := scene.Camera[0].(phys.FocusableCamera)
+	if err := v.HandleKey(key.Event{Code: "KeyW", Direction: key.DirRelease}); err != nil {
+		t.Fatalf("HandleKey: %v", err)
+	}
+	after := scene.Camera[0].(phys.FocusableCamera)
+	if before != after {
+		t.Errorf("camera changed on a key release: before=%+v after=%+v", before, after)
+	}
+}
+
+// TestHandleKeyFOV verifies '=' grows and '-' shrinks FOVHeight/FOVWidth.
+func TestHandleKeyFOV(t *testing.T) {
+	scene := viewerfixture.Scene(t)
+	v, err := New(scene)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	before := scene.Camera[0].(phys.FocusableCamera).FOVHeight
+	if err := v.HandleKey(key.Event{Code: "Equal", Direction: key.DirPress}); err != nil {
+		t.Fatalf("HandleKey: %v", err)
+	}
+	grown := scene.Camera[0].(phys.FocusableCamera).FOVHeight
+	if grown <= before {
+		t.Errorf("FOVHeight after '=' = %v, want > %v", grown, before)
+	}
+	if err := v.HandleKey(key.Event{Code: "Minus", Direction: key.DirPress}); err != nil {
+		t.Fatalf("HandleKey: %v", err)
+	}
+	if err := v.HandleKey(key.Event{Code: "Minus", Direction: key.DirPress}); err != nil {
+		t.Fatalf("HandleKey: %v", err)
+	}
+	shrunk := scene.Camera[0].(phys.FocusableCamera).FOVHeight
+	if shrunk >= grown {
+		t.Errorf("FOVHeight after '-' twice = %v, want < %v", shrunk, grown)
+	}
+}
+
+// TestHandleKeyCyclesFilterAndBumpsSamples verifies 'F' advances the
+// ReconFilter cycle and ']'/'[' adjust RaysPerPixel.
+func TestHandleKeyCyclesFilterAndBumpsSamples(t *testing.T) {
+	scene := viewerfixture.Scene(t)
+	v, err := New(scene)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if scene.RenderOptions.Filter.Eval != nil {
+		t.Fatalf("scene starts with a non-zero Filter: %+v", scene.RenderOptions.Filter)
+	}
+	if err := v.HandleKey(key.Event{Code: "KeyF", Direction: key.DirPress}); err != nil {
+		t.Fatalf("HandleKey: %v", err)
+	}
+	if scene.RenderOptions.Filter.Eval == nil {
+		t.Error("Filter still zero after one 'F' press")
+	}
+
+	before := scene.RenderOptions.RaysPerPixel
+	if err := v.HandleKey(key.Event{Code: "BracketRight", Direction: key.DirPress}); err != nil {
+		t.Fatalf("HandleKey: %v", err)
+	}
+	if scene.RenderOptions.RaysPerPixel <= before {
+		t.Errorf("RaysPerPixel after ']' = %d, want > %d", scene.RenderOptions.RaysPerPixel, before)
+	}
+}
+
+// TestStepAccumulatesAndResets verifies repeated Step calls converge
+// (the accumulator's sample count grows) and that a mutating HandleKey
+// restarts the accumulation.
+func TestStepAccumulatesAndResets(t *testing.T) {
+	scene := viewerfixture.Scene(t)
+	v, err := New(scene)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	ctx := context.Background()
+	if _, err := v.Step(ctx); err != nil {
+		t.Fatalf("Step: %v", err)
+	}
+	if _, err := v.Step(ctx); err != nil {
+		t.Fatalf("Step: %v", err)
+	}
+	if v.accumN != 2 {
+		t.Errorf("accumN after 2 Steps = %d, want 2", v.accumN)
+	}
+	if err := v.HandleKey(key.Event{Code: "KeyW", Direction: key.DirPress}); err != nil {
+		t.Fatalf("HandleKey: %v", err)
+	}
+	if v.accumN != 0 {
+		t.Errorf("accumN after a mutating key = %d, want 0 (accumulator should reset)", v.accumN)
+	}
+}
+
+// TestSaveWritesScJSON verifies Ctrl+S serializes the Scene to SavePath.
+func TestSaveWritesSceneJSON(t *testing.T) {
+	scene := viewerfixture.Scene(t)
+	v, err := New(scene)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	v.SavePath = filepath.Join(t.TempDir(), "scene.json")
+	if err := v.HandleKey(key.Event{Code: "KeyS", Direction: key.DirPress, Modifiers: key.ModControl}); err != nil {
+		t.Fatalf("HandleKey(Ctrl+S): %v", err)
+	}
+	data, err := os.ReadFile(v.SavePath)
+	if err != nil {
+		t.Fatalf("ReadFile(%q): %v", v.SavePath, err)
+	}
+	if len(data) == 0 {
+		t.Error("saved scene JSON is empty")
+	}
+}
@@ -0,0 +1,40 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+
+// Package viewerfixture provides the minimal phys.Scene used by both
+// phys/viewer's and lab/viewer's tests: a FocusableCamera looking at a
+// single Lambertian sphere. It lives in its own package (rather than in
+// either viewer's own _test.go file) so phys/viewer and lab/viewer --
+// siblings, not one importing the other -- can share the fixture instead
+// of each keeping its own copy in sync by hand.
+package viewerfixture
+
+import (
+	"testing"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/phys"
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
+)
+
+// Scene returns a fresh phys.Scene: a FocusableCamera at (0,0,5) looking
+// at the origin, and a radius-10 Lambertian sphere centered on the
+// origin, rendered at a tiny 4x4 resolution so tests exercising it stay
+// fast.
+func Scene(t *testing.T) *phys.Scene {
+	t.Helper()
+	return &phys.Scene{
+		RenderOptions: phys.RenderOptions{Seed: 0, RaysPerPixel: 1, MaxRayDepth: 2, Dx: 4, Dy: 4},
+		Camera: []phys.Camera{phys.FocusableCamera{
+			LookFrom:        r3.Point{X: 0, Y: 0, Z: 5},
+			LookAt:          r3.Point{X: 0, Y: 0, Z: 0},
+			VUp:             r3.Vec{X: 0, Y: 1, Z: 0},
+			FOVHeight:       2,
+			FOVWidth:        2,
+			WorkingDistance: 5,
+		}},
+		Node: []phys.Node{{
+			Name:     "floor",
+			Shape:    phys.Sphere{Center: r3.Point{X: 0, Y: 0, Z: 0}, Radius: 10},
+			Material: phys.Lambertian{Texture: phys.TextureUniform{Color: phys.Spectrum{X: 0.5, Y: 0.5, Z: 0.5}}},
+		}},
+	}
+}
@@ -0,0 +1,311 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+
+package phys
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r2"
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
+)
+
+// VoxelGrid is a mipmapped 3D raster of scene radiance and opacity built
+// by Scene.BuildVoxelGrid, letting TraceCone approximate how much light
+// arrives along a wide cone -- an entire solid angle at once -- instead
+// of the single ray per sample a path-traced bounce costs.
+//
+// Injection only captures emissive nodes' own radiance: a voxel's
+// radiance comes from whichever Emitter node's shape contains the
+// voxel's center, not the reflected light ComputeDirectLighting computes
+// for diffuse surfaces at render time (that needs a surfaceInteraction --
+// a hit point, a BSDF, a *Rand -- none of which exist yet at
+// voxelization time, only Scene.Node and Scene.Light). So TraceCone
+// recovers bounce light from emissive surfaces a BSDF-sampled ray would
+// rarely find on its own (the scenario VoxelGI exists for), but not
+// color bleeding between two purely diffuse, non-emissive surfaces;
+// diffuse (Lambertian/Diffuse) nodes are voxelized for opacity only, so
+// cones still self-occlude against them correctly.
+type VoxelGrid struct {
+	Bounds     AABB
+	Resolution int // Voxels per axis at mip level 0.
+
+	// mips[0] is the finest level (Resolution^3 cells); each later level
+	// is a 2x2x2 box-filter downsample of the level before it, halving
+	// resolution each time down to a single voxel, the mip pyramid
+	// TraceCone walks from coarse to fine as a cone's footprint narrows.
+	mips []voxelMip
+}
+
+type voxelMip struct {
+	resolution int
+	radiance   []r3.Vec // Length resolution^3, indexed by voxelIndex.
+	opacity    []float64
+}
+
+func voxelIndex(resolution, x, y, z int) int {
+	return (z*resolution+y)*resolution + x
+}
+
+// BuildVoxelGrid voxelizes s.Node's emissive and diffuse geometry into a
+// new VoxelGrid of resolution voxels per axis, covering s.Node's combined
+// bounds, and caches it on s.VoxelGrid, the same derived-cache convention
+// BuildAccel already uses for s.Accel. Call it once after the scene's
+// nodes are finalized (and again after any node changes) before tracing
+// cones through it.
+func (s *Scene) BuildVoxelGrid(resolution int) error {
+	if resolution < 1 {
+		return fmt.Errorf("BuildVoxelGrid: resolution must be positive, got %d", resolution)
+	}
+	if len(s.Node) == 0 {
+		s.VoxelGrid = nil
+		return nil
+	}
+
+	bounds := s.Node[0].Shape.Bounds()
+	for _, n := range s.Node[1:] {
+		bounds = bounds.Union(n.Shape.Bounds())
+	}
+	cell := r3.Vec{
+		X: float64(bounds.Max.X-bounds.Min.X) / float64(resolution),
+		Y: float64(bounds.Max.Y-bounds.Min.Y) / float64(resolution),
+		Z: float64(bounds.Max.Z-bounds.Min.Z) / float64(resolution),
+	}
+
+	base := voxelMip{
+		resolution: resolution,
+		radiance:   make([]r3.Vec, resolution*resolution*resolution),
+		opacity:    make([]float64, resolution*resolution*resolution),
+	}
+	for _, node := range s.Node {
+		emitter, isEmitter := node.Material.(Emitter)
+		_, isDiffuse := node.Material.(DiffuseReflector)
+		if !isEmitter && !isDiffuse {
+			continue
+		}
+		var radiance r3.Vec
+		if isEmitter && emitter.radiantPower() {
+			radiance = r3.Vec(textureAt(emitter.Texture, 0.5, 0.5, r2.Point{}))
+		}
+		voxelizeNode(&base, bounds, cell, resolution, node.Shape, radiance)
+	}
+
+	mips := []voxelMip{base}
+	for mips[len(mips)-1].resolution > 1 {
+		mips = append(mips, downsampleVoxelMip(mips[len(mips)-1]))
+	}
+	s.VoxelGrid = &VoxelGrid{Bounds: bounds, Resolution: resolution, mips: mips}
+	return nil
+}
+
+// voxelizeNode marks every voxel of base whose center lies inside shape
+// (per SignedDistance) as opaque, stamping radiance onto it when shape's
+// node is an emitter. It only visits the voxels overlapping shape's own
+// Bounds(), not the whole grid.
+func voxelizeNode(base *voxelMip, bounds AABB, cell r3.Vec, resolution int, shape Shape, radiance r3.Vec) {
+	shapeBounds := shape.Bounds()
+	lo := voxelCoordClamped(bounds, cell, resolution, shapeBounds.Min)
+	hi := voxelCoordClamped(bounds, cell, resolution, shapeBounds.Max)
+	for z := lo[2]; z <= hi[2]; z++ {
+		for y := lo[1]; y <= hi[1]; y++ {
+			for x := lo[0]; x <= hi[0]; x++ {
+				center := voxelCenter(bounds, cell, x, y, z)
+				if shape.SignedDistance(center) > 0 {
+					continue
+				}
+				i := voxelIndex(resolution, x, y, z)
+				base.opacity[i] = 1
+				if radiance != (r3.Vec{}) {
+					base.radiance[i] = radiance
+				}
+			}
+		}
+	}
+}
+
+// voxelCoordClamped returns the [x,y,z] grid cell containing world point
+// p, clamped to [0, resolution-1] so a shape extending past bounds (by
+// floating-point slop, or a Bounds() slightly looser than its true
+// surface) never indexes out of range.
+func voxelCoordClamped(bounds AABB, cell r3.Vec, resolution int, p r3.Point) [3]int {
+	coord := func(v, min, c float64) int {
+		i := int(math.Floor((v - min) / c))
+		return clamp(i, 0, resolution-1)
+	}
+	return [3]int{
+		coord(p.X, bounds.Min.X, cell.X),
+		coord(p.Y, bounds.Min.Y, cell.Y),
+		coord(p.Z, bounds.Min.Z, cell.Z),
+	}
+}
+
+func voxelCenter(bounds AABB, cell r3.Vec, x, y, z int) r3.Point {
+	return r3.Point{
+		X: bounds.Min.X + cell.X*(float64(x)+0.5),
+		Y: bounds.Min.Y + cell.Y*(float64(y)+0.5),
+		Z: bounds.Min.Z + cell.Z*(float64(z)+0.5),
+	}
+}
+
+// downsampleVoxelMip box-filters m into a mip half its resolution (the
+// last level, at resolution 1, downsamples from a 2-voxel mip the same
+// way): each output voxel averages the opacity of its 8 children,
+// weighting their radiance by opacity so an empty child doesn't dilute a
+// bright one.
+func downsampleVoxelMip(m voxelMip) voxelMip {
+	res := m.resolution / 2
+	if res < 1 {
+		res = 1
+	}
+	out := voxelMip{resolution: res, radiance: make([]r3.Vec, res*res*res), opacity: make([]float64, res*res*res)}
+	for z := 0; z < res; z++ {
+		for y := 0; y < res; y++ {
+			for x := 0; x < res; x++ {
+				var radianceSum r3.Vec
+				var opacitySum float64
+				var weightSum float64
+				for dz := 0; dz < 2; dz++ {
+					for dy := 0; dy < 2; dy++ {
+						for dx := 0; dx < 2; dx++ {
+							cx, cy, cz := x*2+dx, y*2+dy, z*2+dz
+							if cx >= m.resolution || cy >= m.resolution || cz >= m.resolution {
+								continue
+							}
+							i := voxelIndex(m.resolution, cx, cy, cz)
+							o := m.opacity[i]
+							radianceSum = radianceSum.Add(m.radiance[i].Muls(o))
+							opacitySum += o
+							weightSum++
+						}
+					}
+				}
+				if opacitySum > 0 {
+					radianceSum = radianceSum.Divs(opacitySum)
+				}
+				oi := voxelIndex(res, x, y, z)
+				out.radiance[oi] = radianceSum
+				if weightSum > 0 {
+					out.opacity[oi] = opacitySum / weightSum
+				}
+			}
+		}
+	}
+	return out
+}
+
+// coneOpacitySaturation is the accumulated alpha at which TraceCone stops
+// marching early: past this point the remaining scene contributes too
+// little (multiplied by 1-alpha) to matter, the standard early-out every
+// front-to-back voxel cone tracer uses.
+const coneOpacitySaturation = 0.95
+
+// TraceCone marches a cone of half-angle aperture (radians) from origin
+// along direction (a unit vector) through g's mip pyramid, accumulating
+// radiance via front-to-back alpha compositing: at each step it samples
+// the mip level whose voxel size best matches the cone's footprint
+// diameter at that distance, weights that level's radiance/opacity by
+// how much of the remaining ray is still unoccluded, and stops once
+// accumulated opacity passes coneOpacitySaturation or the march leaves
+// g.Bounds.
+func (g *VoxelGrid) TraceCone(origin r3.Point, direction r3.Vec, aperture float64) r3.Vec {
+	direction = direction.Unit()
+	baseVoxel := (g.Bounds.Max.X - g.Bounds.Min.X) / float64(g.Resolution)
+	if baseVoxel <= 0 {
+		return r3.Vec{}
+	}
+
+	var accumulated r3.Vec
+	var alpha float64
+	dist := baseVoxel * 0.5 // Start half a voxel out to avoid immediately re-sampling the origin's own surface.
+	for alpha < coneOpacitySaturation {
+		p := origin.Add(direction.Muls(dist))
+		if !g.contains(p) {
+			return accumulated
+		}
+		diameter := 2 * math.Tan(aperture/2) * dist
+		level := g.mipLevel(diameter)
+		radiance, opacity := g.sample(level, p)
+
+		weight := 1 - alpha
+		accumulated = accumulated.Add(radiance.Muls(weight * opacity))
+		alpha += weight * opacity
+
+		step := math.Max(diameter, baseVoxel*0.5)
+		dist += step
+	}
+	return accumulated
+}
+
+func (g *VoxelGrid) contains(p r3.Point) bool {
+	return p.X >= g.Bounds.Min.X && p.X <= g.Bounds.Max.X &&
+		p.Y >= g.Bounds.Min.Y && p.Y <= g.Bounds.Max.Y &&
+		p.Z >= g.Bounds.Min.Z && p.Z <= g.Bounds.Max.Z
+}
+
+// mipLevel returns the index into g.mips whose voxel size most closely
+// matches footprint, the mip selection every voxel cone tracer makes to
+// approximate a cone's growing footprint with one trilinear-ish lookup
+// per step instead of actually integrating over it.
+func (g *VoxelGrid) mipLevel(footprint float64) int {
+	extent := float64(g.Bounds.Max.X - g.Bounds.Min.X)
+	for level := 0; level < len(g.mips)-1; level++ {
+		voxelSize := extent / float64(g.mips[level].resolution)
+		if voxelSize >= footprint {
+			return level
+		}
+	}
+	return len(g.mips) - 1
+}
+
+func (g *VoxelGrid) sample(level int, p r3.Point) (r3.Vec, float64) {
+	m := g.mips[level]
+	cell := r3.Vec{
+		X: float64(g.Bounds.Max.X-g.Bounds.Min.X) / float64(m.resolution),
+		Y: float64(g.Bounds.Max.Y-g.Bounds.Min.Y) / float64(m.resolution),
+		Z: float64(g.Bounds.Max.Z-g.Bounds.Min.Z) / float64(m.resolution),
+	}
+	coord := voxelCoordClamped(g.Bounds, cell, m.resolution, p)
+	i := voxelIndex(m.resolution, coord[0], coord[1], coord[2])
+	return m.radiance[i], m.opacity[i]
+}
+
+// DiffuseConeDirections returns the 6 directions VoxelGI's indirect-diffuse
+// estimate traces a cone along: one straight up the normal n, and 5 more
+// at a fixed angle off it, evenly spaced in azimuth around it -- the
+// standard 6-cone hemisphere coverage used for 60-degree-aperture voxel
+// cone tracing (each cone's aperture covers its share of the hemisphere
+// with only slight overlap).
+func DiffuseConeDirections(n r3.Vec) []r3.Vec {
+	n = n.Unit()
+	t, b := orthonormalBasis(n)
+	const tilt = 50 * math.Pi / 180 // Standard 6-cone VCT tilt off the normal.
+	dirs := make([]r3.Vec, 0, 6)
+	dirs = append(dirs, n)
+	for i := 0; i < 5; i++ {
+		azimuth := float64(i) * 2 * math.Pi / 5
+		dir := n.Muls(math.Cos(tilt)).
+			Add(t.Muls(math.Cos(azimuth) * math.Sin(tilt))).
+			Add(b.Muls(math.Sin(azimuth) * math.Sin(tilt)))
+		dirs = append(dirs, dir.Unit())
+	}
+	return dirs
+}
+
+// diffuseConeAperture is the half-angle every DiffuseConeDirections cone
+// is traced with: 6 cones at this aperture, tilted per
+// DiffuseConeDirections, cover the hemisphere with only slight overlap.
+const diffuseConeAperture = 30 * math.Pi / 180
+
+// SpecularConeAperture maps a material's roughness (Metal.Fuzz or
+// Dielectric.Roughness, both already normalized to [0, 1]) to the
+// half-angle of the single specular cone VoxelGI traces along the
+// reflected direction: 0 roughness traces a near-pencil-thin cone (a
+// sharp mirror reflection), 1 widens it to diffuseConeAperture (as blurry
+// as the diffuse cones, since at that point the reflection carries no
+// more directional information than a diffuse bounce). This is a linear
+// approximation, not a derivation from either material's real BRDF lobe
+// shape -- calibrating it against Metal/Dielectric's actual GGX-style
+// lobes is its own follow-up.
+func SpecularConeAperture(roughness float64) float64 {
+	return clamp(roughness, 0, 1) * diffuseConeAperture
+}
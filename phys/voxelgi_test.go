@@ -0,0 +1,108 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package phys
+
+import (
+	"testing"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
+)
+
+func voxelGITestScene() *Scene {
+	return &Scene{Node: []Node{
+		{
+			Name:     "light",
+			Shape:    Sphere{Center: r3.Point{X: 5, Y: 0, Z: 0}, Radius: 1},
+			Material: Emitter{Texture: TextureUniform{Color: Spectrum{X: 2, Y: 2, Z: 2}}},
+		},
+		{
+			Name:     "floor",
+			Shape:    Sphere{Center: r3.Point{X: -5, Y: 0, Z: 0}, Radius: 1},
+			Material: Lambertian{Texture: TextureUniform{Color: Spectrum{X: 0.5, Y: 0.5, Z: 0.5}}},
+		},
+	}}
+}
+
+// TestBuildVoxelGridRejectsNonPositiveResolution verifies BuildVoxelGrid
+// returns an error instead of dividing by zero for a non-positive
+// resolution.
+func TestBuildVoxelGridRejectsNonPositiveResolution(t *testing.T) {
+	s := voxelGITestScene()
+	if err := s.BuildVoxelGrid(0); err == nil {
+		t.Fatal("BuildVoxelGrid(0): expected an error, got nil")
+	}
+}
+
+// TestBuildVoxelGridEmptyScene verifies an empty scene clears any
+// previous VoxelGrid rather than erroring or indexing an empty Node
+// slice's bounds.
+func TestBuildVoxelGridEmptyScene(t *testing.T) {
+	s := &Scene{}
+	if err := s.BuildVoxelGrid(8); err != nil {
+		t.Fatalf("BuildVoxelGrid: %v", err)
+	}
+	if s.VoxelGrid != nil {
+		t.Errorf("VoxelGrid = %v, want nil for an empty scene", s.VoxelGrid)
+	}
+}
+
+// TestBuildVoxelGridMipPyramidShrinksToOne verifies the mip chain halves
+// resolution each level down to a single voxel, regardless of the base
+// resolution's own power-of-two-ness.
+func TestBuildVoxelGridMipPyramidShrinksToOne(t *testing.T) {
+	s := voxelGITestScene()
+	if err := s.BuildVoxelGrid(10); err != nil {
+		t.Fatalf("BuildVoxelGrid: %v", err)
+	}
+	last := s.VoxelGrid.mips[len(s.VoxelGrid.mips)-1]
+	if last.resolution != 1 {
+		t.Errorf("last mip resolution = %d, want 1", last.resolution)
+	}
+}
+
+// TestTraceConeFindsEmissiveVoxel verifies a cone aimed at the emissive
+// sphere's voxels returns non-zero radiance, while one aimed away from
+// every node returns zero.
+func TestTraceConeFindsEmissiveVoxel(t *testing.T) {
+	s := voxelGITestScene()
+	if err := s.BuildVoxelGrid(32); err != nil {
+		t.Fatalf("BuildVoxelGrid: %v", err)
+	}
+	origin := r3.Point{}
+	toward := s.VoxelGrid.TraceCone(origin, r3.Vec{X: 1}, diffuseConeAperture)
+	if toward == (r3.Vec{}) {
+		t.Error("TraceCone toward the emitter returned zero radiance")
+	}
+	away := s.VoxelGrid.TraceCone(origin, r3.Vec{Y: 1}, diffuseConeAperture)
+	if away != (r3.Vec{}) {
+		t.Errorf("TraceCone away from every node = %v, want zero", away)
+	}
+}
+
+// TestDiffuseConeDirectionsCount verifies DiffuseConeDirections returns
+// the standard 6-cone hemisphere set, each a unit vector.
+func TestDiffuseConeDirectionsCount(t *testing.T) {
+	dirs := DiffuseConeDirections(r3.Vec{Y: 1})
+	if len(dirs) != 6 {
+		t.Fatalf("len(DiffuseConeDirections) = %d, want 6", len(dirs))
+	}
+	for i, d := range dirs {
+		if length := d.Length(); length < 0.999 || length > 1.001 {
+			t.Errorf("dirs[%d] = %v, length %v, want a unit vector", i, d, length)
+		}
+	}
+}
+
+// TestSpecularConeApertureMonotonic verifies SpecularConeAperture grows
+// from a near-zero aperture at roughness 0 to diffuseConeAperture at
+// roughness 1, and clamps outside [0, 1].
+func TestSpecularConeApertureMonotonic(t *testing.T) {
+	if a := SpecularConeAperture(0); a != 0 {
+		t.Errorf("SpecularConeAperture(0) = %v, want 0", a)
+	}
+	if a := SpecularConeAperture(1); a != diffuseConeAperture {
+		t.Errorf("SpecularConeAperture(1) = %v, want %v", a, diffuseConeAperture)
+	}
+	if a := SpecularConeAperture(2); a != diffuseConeAperture {
+		t.Errorf("SpecularConeAperture(2) = %v, want %v (clamped)", a, diffuseConeAperture)
+	}
+}
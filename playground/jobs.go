@@ -0,0 +1,223 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/phys"
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/phys/jobs"
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/phys/store"
+)
+
+// maxConcurrentRenderJobs bounds how many scenes jobManager will render at
+// once; further POST /raytrace/jobs submissions queue behind it instead of
+// oversubscribing CPU.
+const maxConcurrentRenderJobs = 4
+
+var jobManager = jobs.NewManager(maxConcurrentRenderJobs)
+
+// jobResponse is the JSON shape returned by every /raytrace/jobs endpoint
+// except the image download.
+type jobResponse struct {
+	ID             string            `json:"id"`
+	Status         jobs.Status       `json:"status"`
+	TilesCompleted int               `json:"tilesCompleted"`
+	TotalTiles     int               `json:"totalTiles"`
+	Stats          *phys.RenderStats `json:"stats,omitempty"`
+	Error          string            `json:"error,omitempty"`
+}
+
+func newJobResponse(snap jobs.Snapshot) jobResponse {
+	resp := jobResponse{
+		ID:             snap.ID,
+		Status:         snap.Status,
+		TilesCompleted: snap.TilesCompleted,
+		TotalTiles:     snap.TotalTiles,
+	}
+	if snap.Status == jobs.StatusDone {
+		resp.Stats = &snap.Stats
+	}
+	if snap.Err != nil {
+		resp.Error = snap.Err.Error()
+	}
+	return resp
+}
+
+// renderHandler handles POST /raytrace/render: the original blocking
+// single-shot render API. It submits scene to jobManager like
+// jobsCreateHandler does, but waits for the job to finish (or the request
+// context to end) before responding with a base64-encoded PNG, so existing
+// callers don't need to change. Waiting through jobManager instead of a
+// bespoke goroutine means a client that disconnects early cancels the
+// render rather than leaving it running unattended, and there's no more
+// hardcoded timeout -- a slow render just keeps the request open.
+//
+// Before rendering, it hashes the submitted scene JSON and checks
+// checkpointer for an artifact already cached under that hash, so a
+// repeated submission of an identical scene returns instantly instead of
+// re-rendering. On a cache miss, it checkpoints the render's tiles as
+// they finish (so a crashed server can resume this same scene later) and
+// caches the finished artifact on success.
+//
+// Every call gets its own trace ID (see withTrace), logged on every line
+// below and threaded through the render itself, so a slow or failed
+// render's log lines -- including tracePath/renderPixel's own warnings and
+// RenderStats.Events' sampled tile timings -- can all be grepped out by
+// trace_id.
+func renderHandler(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	ctx, traceID := withTrace(r.Context())
+	log := renderLogger.With("trace_id", traceID)
+
+	sceneJSON, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, `{"error": "failed to read request body"}`, http.StatusBadRequest)
+		return
+	}
+	hash := store.HashBytes(sceneJSON)
+	w.Header().Set("Content-Type", "application/json")
+	if cachedRenderResponse(w, hash) {
+		log.Info("render request served from cache", "duration", time.Since(start))
+		return
+	}
+
+	var scene phys.Scene
+	if err := json.Unmarshal(sceneJSON, &scene); err != nil {
+		log.Warn("invalid scene JSON", "err", err)
+		http.Error(w, `{"error": "Invalid JSON: `+err.Error()+`"}`, http.StatusBadRequest)
+		return
+	}
+	if err := scene.Validate(); err != nil {
+		http.Error(w, `{"error": "Invalid scene: `+err.Error()+`"}`, http.StatusBadRequest)
+		return
+	}
+	checkpointScene(&scene, hash)
+
+	job := jobManager.Submit(ctx, &scene)
+	snap := job.Wait(r.Context())
+
+	switch snap.Status {
+	case jobs.StatusDone:
+		encoded, err := encodePNGBase64(snap.Image)
+		if err != nil {
+			log.Error("encoding image", "err", err)
+			http.Error(w, `{"error": "Failed to encode image"}`, http.StatusInternalServerError)
+			return
+		}
+		cacheCompletedRender(sceneJSON, hash, phys.RenderArtifact{Image: snap.Image.(*image.RGBA), Stats: snap.Stats})
+		response := map[string]string{"image": encoded}
+		json.NewEncoder(w).Encode(response)
+	case jobs.StatusCancelled:
+		// Tile checkpoints already written under hash are deliberately
+		// left in place (not cleared) here: resuming from them is the
+		// whole point of checkpointScene, so a later resubmission of
+		// this same scene can pick up where this cancelled render left
+		// off instead of starting over.
+		log.Warn("render cancelled (client disconnected)")
+		http.Error(w, `{"error": "render cancelled"}`, http.StatusGatewayTimeout)
+	default:
+		log.Error("render failed", "err", snap.Err)
+		response := map[string]string{"error": fmt.Sprintf("Render Error: %v", snap.Err)}
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(response)
+	}
+
+	log.Info("render request processed", "duration", time.Since(start))
+}
+
+// jobsCreateHandler handles POST /raytrace/jobs: it decodes the request
+// body as a phys.Scene, submits it to jobManager, and returns the new
+// job's ID immediately without waiting for the render to start.
+//
+// Unlike renderHandler, it doesn't consult or populate checkpointer: a
+// caller polling a job ID already has its own handle on the in-progress
+// render, so the content-hash cache renderHandler uses to short-circuit
+// a blocking resubmission doesn't apply here the same way. Extending
+// checkpoint/resume to this path is future work, not an oversight.
+func jobsCreateHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var scene phys.Scene
+	if err := json.NewDecoder(r.Body).Decode(&scene); err != nil {
+		http.Error(w, `{"error": "Invalid JSON: `+err.Error()+`"}`, http.StatusBadRequest)
+		return
+	}
+	if err := scene.Validate(); err != nil {
+		http.Error(w, `{"error": "Invalid scene: `+err.Error()+`"}`, http.StatusBadRequest)
+		return
+	}
+	ctx, traceID := withTrace(r.Context())
+	job := jobManager.Submit(ctx, &scene)
+	// The response only carries job.ID (jobResponse's established shape,
+	// unchanged here), so this is the one place that ties job.ID to
+	// traceID -- without it, a render's own trace_id-tagged log lines
+	// (tracePath/renderPixel warnings, RenderStats.Events) would be
+	// unreachable from the job ID a caller actually has.
+	renderLogger.Info("job submitted", "trace_id", traceID, "job_id", job.ID)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(newJobResponse(job.Snapshot()))
+}
+
+// jobsItemHandler handles GET/DELETE /raytrace/jobs/{id} and
+// GET /raytrace/jobs/{id}/image.
+func jobsItemHandler(w http.ResponseWriter, r *http.Request) {
+	rest := strings.TrimPrefix(r.URL.Path, "/raytrace/jobs/")
+	id, sub, hasSub := strings.Cut(rest, "/")
+	if id == "" {
+		http.Error(w, "Not Found", http.StatusNotFound)
+		return
+	}
+	job := jobManager.Get(id)
+	if job == nil {
+		http.Error(w, `{"error": "no such job"}`, http.StatusNotFound)
+		return
+	}
+
+	if hasSub {
+		if sub != "image" || r.Method != http.MethodGet {
+			http.Error(w, "Not Found", http.StatusNotFound)
+			return
+		}
+		jobImageHandler(w, r, job)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(newJobResponse(job.Snapshot()))
+	case http.MethodDelete:
+		jobManager.Cancel(id)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(newJobResponse(job.Snapshot()))
+	default:
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// jobImageHandler writes job's rendered PNG, or 409 if it hasn't finished.
+func jobImageHandler(w http.ResponseWriter, r *http.Request, job *jobs.Job) {
+	snap := job.Snapshot()
+	if snap.Status != jobs.StatusDone {
+		http.Error(w, `{"error": "job not done (status `+string(snap.Status)+`)"}`, http.StatusConflict)
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	if err := png.Encode(w, snap.Image); err != nil {
+		http.Error(w, `{"error": "failed to encode image"}`, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,29 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package main
+
+import (
+	"context"
+	"log/slog"
+	"os"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/phys"
+)
+
+// renderLogger is the structured logger every render-submitting handler
+// threads through phys via phys.WithLogger, so a render's invalid-ray
+// warnings, clamped-pixel messages, and tile-completion events land in the
+// same stream as this server's own logging instead of a separate one.
+var renderLogger = slog.New(slog.NewTextHandler(os.Stderr, nil))
+
+// withTrace attaches a fresh phys.NewTraceID and renderLogger to ctx, so
+// every phys log line emitted while rendering the request this trace ID
+// names carries it, and RenderStats.Events records it alongside each
+// sampled tile timing. Returns the trace ID too, for the handler's own log
+// lines (via renderLogger.With("trace_id", traceID), or just
+// phys.LoggerFromContext(ctx) again).
+func withTrace(ctx context.Context) (context.Context, string) {
+	traceID := phys.NewTraceID()
+	ctx = phys.WithTraceID(ctx, traceID)
+	ctx = phys.WithLogger(ctx, renderLogger)
+	return ctx, traceID
+}
@@ -2,19 +2,10 @@
 package main
 
 import (
-	"bytes"
-	"context"
-	"encoding/base64"
 	"encoding/json"
-	"fmt"
-	"image"
-	"image/png"
-	"io"
 	"log"
 	"net/http"
 	"time"
-
-	"github.com/scottlawsonbc/slam/code/photon/raytrace/phys"
 )
 
 func main() {
@@ -46,89 +37,29 @@ func main() {
 		json.NewEncoder(w).Encode(response)
 	})
 
-	// Render endpoint
-	mux.HandleFunc("/raytrace/render", func(w http.ResponseWriter, r *http.Request) {
-		start := time.Now()
-		if r.Method != http.MethodPost {
-			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
-			return
-		}
-
-		// Read the request body
-		body, err := io.ReadAll(r.Body)
-		if err != nil {
-			log.Printf("Error reading request body: %v", err)
-			http.Error(w, `{"error": "Invalid request body"}`, http.StatusBadRequest)
-			return
-		}
-		defer r.Body.Close()
-
-		// Parse JSON into Scene
-		var scene phys.Scene
-		err = json.Unmarshal(body, &scene)
-		if err != nil {
-			log.Printf("JSON Unmarshal error: %v", err)
-			w.Header().Set("Content-Type", "application/json")
-			http.Error(w, `{"error": "Invalid JSON: `+err.Error()+`"}`, http.StatusBadRequest)
-			return
-		}
-
-		// Render the scene with a timeout
-		type renderResult struct {
-			Image image.Image
-			Err   error
-		}
-		renderCh := make(chan renderResult, 1)
-
-		go func() {
-			// Call phys.Render which now returns (reconstruction, error)
-			recon, err := phys.Render(context.Background(), &scene)
-			if err != nil {
-				renderCh <- renderResult{nil, err}
-				return
-			}
-			// Create a montage of rendered images
-			renderCh <- renderResult{recon.Image, nil}
-		}()
-
-		select {
-		case res := <-renderCh:
-			if res.Err != nil {
-				log.Printf("Raytracer error: %v", res.Err)
-				w.Header().Set("Content-Type", "application/json")
-				errorMsg := fmt.Sprintf("Render Error: %v", res.Err)
-				response := map[string]string{"error": errorMsg}
-				w.WriteHeader(http.StatusInternalServerError)
-				json.NewEncoder(w).Encode(response)
-				return
-			}
-
-			// Encode the image to PNG
-			var buf bytes.Buffer
-			err = png.Encode(&buf, res.Image)
-			if err != nil {
-				log.Printf("Error encoding image: %v", err)
-				w.Header().Set("Content-Type", "application/json")
-				http.Error(w, `{"error": "Failed to encode image"}`, http.StatusInternalServerError)
-				return
-			}
-
-			// Base64 encode the PNG
-			encoded := base64.StdEncoding.EncodeToString(buf.Bytes())
-
-			// Send the response
-			response := map[string]string{"image": encoded}
-			w.Header().Set("Content-Type", "application/json")
-			json.NewEncoder(w).Encode(response)
-
-		case <-time.After(30 * time.Second):
-			log.Printf("Raytracer render timed out")
-			w.Header().Set("Content-Type", "application/json")
-			http.Error(w, `{"error": "Raytracer render timed out"}`, http.StatusGatewayTimeout)
-		}
-
-		log.Printf("Render request processed in %v", time.Since(start))
-	})
+	// Render endpoint: blocking single-shot render, kept for existing
+	// clients. Internally it's now just jobsCreateHandler's job manager
+	// with the wait done server-side, so a client that hangs up early
+	// cancels the render instead of leaving it running unattended, and
+	// a slow render just keeps the request open rather than hitting a
+	// hardcoded timeout. See /raytrace/jobs for the non-blocking form.
+	mux.HandleFunc("/raytrace/render", renderHandler)
+
+	// Progressive render endpoint: same input as /raytrace/render, but
+	// streams a refining preview over a WebSocket instead of blocking for
+	// one final PNG. See renderStreamHandler.
+	mux.HandleFunc("/raytrace/render/stream", renderStreamHandler)
+
+	// Job-oriented render API: POST submits a scene and returns
+	// immediately with a job ID; GET polls status/stats, DELETE cancels,
+	// and GET .../image fetches the PNG once done. See jobs.go.
+	mux.HandleFunc("/raytrace/jobs", jobsCreateHandler)
+	mux.HandleFunc("/raytrace/jobs/", jobsItemHandler)
+
+	// Retrieval endpoints for scenes/artifacts /raytrace/render has cached
+	// by content hash. See store.go.
+	mux.HandleFunc("/raytrace/scenes/", scenesHandler)
+	mux.HandleFunc("/raytrace/artifacts/", artifactsHandler)
 
 	addr := ":8020"
 	log.Printf("Starting server at http://localhost%s/raytrace/playground", addr)
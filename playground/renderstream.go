@@ -0,0 +1,185 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/png"
+	"log"
+	"math"
+	"net/http"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/phys"
+)
+
+// streamFrame is the JSON payload of every text frame renderStreamHandler
+// sends over the WebSocket for one progressive pass: the running sample
+// count, the tile grid's per-tile noise estimate, and PNG-encoded crops of
+// only the tiles that changed since the last frame.
+type streamFrame struct {
+	Pass         int        `json:"pass"`
+	SampleCount  int        `json:"sampleCount"`
+	TileSize     int        `json:"tileSize"`
+	TileCountX   int        `json:"tileCountX"`
+	TileCountY   int        `json:"tileCountY"`
+	TileVariance []float64  `json:"tileVariance"`
+	Tiles        []tileDiff `json:"tiles"`
+}
+
+// tileVarianceUnconverged is reported in place of a tile's true variance
+// when it has fewer than two accumulated samples (pixelWelford.variance
+// returns +Inf in that case, which JSON cannot encode) -- the client
+// should treat it the same as "very noisy, not yet converged".
+const tileVarianceUnconverged = -1
+
+// tileDiff is one updated tile's crop within a streamFrame.
+type tileDiff struct {
+	Index int    `json:"index"`
+	X     int    `json:"x"`
+	Y     int    `json:"y"`
+	W     int    `json:"w"`
+	H     int    `json:"h"`
+	PNG   string `json:"png"`
+}
+
+// streamErrorFrame is sent instead of a streamFrame when the render fails.
+type streamErrorFrame struct {
+	Error string `json:"error"`
+}
+
+// renderStreamHandler upgrades the request to a WebSocket, reads a single
+// initial text message containing a phys.Scene as JSON, and renders it in
+// progressive mode (RenderOptions.OnPass), sending a streamFrame after
+// every pass so a client like the playground can refine a preview image
+// in real time instead of waiting for RaysPerPixel samples to accumulate
+// before seeing anything. The stream ends with a frame whose Pass equals
+// the scene's configured pass count, or a streamErrorFrame on failure.
+func renderStreamHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	ws, err := wsAccept(w, r)
+	if err != nil {
+		log.Printf("renderStreamHandler: upgrade: %v", err)
+		return
+	}
+	defer ws.Close()
+
+	op, payload, err := ws.ReadMessage()
+	if err != nil {
+		log.Printf("renderStreamHandler: read scene message: %v", err)
+		return
+	}
+	if op == wsOpClose {
+		return
+	}
+
+	var scene phys.Scene
+	if err := json.Unmarshal(payload, &scene); err != nil {
+		writeStreamErrorFrame(ws, fmt.Sprintf("invalid scene JSON: %v", err))
+		return
+	}
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	ctx, _ = withTrace(ctx)
+
+	scene.RenderOptions.OnPass = func(pass int, partial *phys.RenderArtifact) error {
+		if err := sendStreamFrame(ws, pass, partial); err != nil {
+			cancel()
+			return fmt.Errorf("send frame: %w", err)
+		}
+		return nil
+	}
+
+	if _, err := phys.Render(ctx, &scene); err != nil {
+		writeStreamErrorFrame(ws, err.Error())
+		return
+	}
+}
+
+// sendStreamFrame builds a streamFrame from partial (PNG-encoding a crop
+// for every tile in partial.UpdatedTiles) and writes it as a WebSocket
+// text message.
+func sendStreamFrame(ws *wsConn, pass int, partial *phys.RenderArtifact) error {
+	tiles := make([]tileDiff, 0, len(partial.UpdatedTiles))
+	for _, idx := range partial.UpdatedTiles {
+		x0, y0, x1, y1 := tileBounds(idx, partial.TileCountX, partial.TileSize, partial.Stats.Dx, partial.Stats.Dy)
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, partial.Image.SubImage(image.Rect(x0, y0, x1, y1))); err != nil {
+			return fmt.Errorf("encode tile %d: %w", idx, err)
+		}
+		tiles = append(tiles, tileDiff{
+			Index: idx, X: x0, Y: y0, W: x1 - x0, H: y1 - y0,
+			PNG: base64.StdEncoding.EncodeToString(buf.Bytes()),
+		})
+	}
+
+	frame := streamFrame{
+		Pass:         pass,
+		SampleCount:  pass,
+		TileSize:     partial.TileSize,
+		TileCountX:   partial.TileCountX,
+		TileCountY:   partial.TileCountY,
+		TileVariance: sanitizeTileVariance(partial.TileVariance),
+		Tiles:        tiles,
+	}
+	data, err := json.Marshal(frame)
+	if err != nil {
+		return fmt.Errorf("marshal frame: %w", err)
+	}
+	return ws.WriteText(data)
+}
+
+// sanitizeTileVariance copies variance, replacing every +Inf entry (a tile
+// still below pixelWelford's two-sample minimum) with
+// tileVarianceUnconverged so the frame stays valid JSON.
+func sanitizeTileVariance(variance []float64) []float64 {
+	out := make([]float64, len(variance))
+	for i, v := range variance {
+		if math.IsInf(v, 1) {
+			out[i] = tileVarianceUnconverged
+			continue
+		}
+		out[i] = v
+	}
+	return out
+}
+
+// tileBounds returns the pixel rectangle of tile idx within partial.Image,
+// given the row-major, tileCountX-wide tile grid of tileSize-pixel tiles
+// that fillRenderQueue/renderScenePassCallback/tileMaxVariance index by.
+// That grid is in scene-space y (y0 = ty*tileSize from the top of the
+// camera's rendered frame), but renderScenePassCallback's img is written
+// by writeWelfordImage directly from the welford slice, which renderPixel
+// and renderTilePass already store flipped (imgy := dy-1-y) to match
+// image-coordinate convention -- so the row range has to be flipped here
+// too before it's used to crop partial.Image, or a tile's PNG ends up
+// showing a different tile's pixels.
+func tileBounds(idx, tileCountX, tileSize, dx, dy int) (x0, y0, x1, y1 int) {
+	tx, ty := idx%tileCountX, idx/tileCountX
+	x0 = tx * tileSize
+	x1 = min(x0+tileSize, dx)
+	y0Scene := ty * tileSize
+	y1Scene := min(y0Scene+tileSize, dy)
+	y0 = dy - y1Scene
+	y1 = dy - y0Scene
+	return x0, y0, x1, y1
+}
+
+func writeStreamErrorFrame(ws *wsConn, msg string) {
+	data, err := json.Marshal(streamErrorFrame{Error: msg})
+	if err != nil {
+		log.Printf("renderStreamHandler: marshal error frame: %v", err)
+		return
+	}
+	if err := ws.WriteText(data); err != nil {
+		log.Printf("renderStreamHandler: write error frame: %v", err)
+	}
+}
@@ -0,0 +1,139 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/png"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/phys"
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/phys/store"
+)
+
+// encodePNGBase64 PNG-encodes img and returns it as a base64 string, the
+// shape every /raytrace/render-family response embeds its image in.
+func encodePNGBase64(img image.Image) (string, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return "", fmt.Errorf("encode image: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+// sceneStoreDir is where checkpointer persists scene JSON, rendered
+// artifacts, and in-progress tile checkpoints, relative to the working
+// directory the server is started from (matching the "./static" relative
+// path already used for the playground's static assets).
+const sceneStoreDir = "./raytrace-store"
+
+var checkpointer = newCheckpointer()
+
+func newCheckpointer() *store.Checkpointer {
+	fileStore, err := store.NewFileStore(sceneStoreDir)
+	if err != nil {
+		log.Fatalf("failed to open scene store at %s: %v", sceneStoreDir, err)
+	}
+	return store.NewCheckpointer(fileStore)
+}
+
+// scenesHandler handles GET /raytrace/scenes/{hash}: it returns the raw
+// scene JSON a prior render request submitted, as stored by renderHandler
+// on a successful render.
+func scenesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	hash := strings.TrimPrefix(r.URL.Path, "/raytrace/scenes/")
+	if hash == "" {
+		http.Error(w, "Not Found", http.StatusNotFound)
+		return
+	}
+	sceneJSON, err := checkpointer.GetScene(hash)
+	if err != nil {
+		http.Error(w, `{"error": "no scene for that hash"}`, http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(sceneJSON)
+}
+
+// artifactsHandler handles GET /raytrace/artifacts/{hash}: it returns the
+// rendered image and stats cached under hash, in the same
+// {"image": ..., "stats": ...} shape as POST /raytrace/render.
+func artifactsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	hash := strings.TrimPrefix(r.URL.Path, "/raytrace/artifacts/")
+	if hash == "" {
+		http.Error(w, "Not Found", http.StatusNotFound)
+		return
+	}
+	img, stats, err := checkpointer.GetArtifact(hash)
+	if err != nil {
+		http.Error(w, `{"error": "no artifact for that hash"}`, http.StatusNotFound)
+		return
+	}
+	encoded, err := encodePNGBase64(img)
+	if err != nil {
+		http.Error(w, `{"error": "failed to encode image"}`, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"image": encoded,
+		"stats": stats,
+	})
+}
+
+// cachedRenderResponse writes the same {"image": ...} body renderHandler
+// returns for a fresh render, but for an artifact already in checkpointer
+// under hash -- so a repeated POST /raytrace/render with an identical
+// scene short-circuits to the cached result instead of re-rendering.
+func cachedRenderResponse(w http.ResponseWriter, hash string) bool {
+	img, _, err := checkpointer.GetArtifact(hash)
+	if err != nil {
+		return false
+	}
+	encoded, err := encodePNGBase64(img)
+	if err != nil {
+		return false
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"image": encoded})
+	return true
+}
+
+// checkpointScene installs hash's tile checkpointing hooks on scene's
+// RenderOptions, so the render started next: (a) resumes any tiles a
+// previous, crashed run of this same scene already checkpointed, and
+// (b) checkpoints every tile it finishes, in case this run is interrupted
+// too. Call cacheCompletedRender once the render finishes successfully to
+// cache the whole artifact and drop the now-redundant tile checkpoints.
+func checkpointScene(scene *phys.Scene, hash string) {
+	scene.RenderOptions.OnTile = checkpointer.OnTile(hash)
+	scene.RenderOptions.ResumeTile = checkpointer.ResumeTile(hash)
+}
+
+// cacheCompletedRender stores sceneJSON and artifact under hash so later
+// identical submissions short-circuit via cachedRenderResponse, and clears
+// hash's now-unneeded tile checkpoints.
+func cacheCompletedRender(sceneJSON []byte, hash string, artifact phys.RenderArtifact) {
+	if _, err := checkpointer.PutScene(sceneJSON); err != nil {
+		log.Printf("failed to cache scene %s: %v", hash, err)
+	}
+	if err := checkpointer.PutArtifact(hash, artifact); err != nil {
+		log.Printf("failed to cache artifact %s: %v", hash, err)
+	}
+	if err := checkpointer.ClearTiles(hash); err != nil {
+		log.Printf("failed to clear tile checkpoints for %s: %v", hash, err)
+	}
+}
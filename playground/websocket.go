@@ -0,0 +1,163 @@
+// Copyright 2024 Scott Lawson scottlawsonbc@gmail.com. All rights reserved.
+package main
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+)
+
+// websocketGUID is the fixed string RFC 6455 section 1.3 has the server
+// append to the client's Sec-WebSocket-Key before hashing, to prove the
+// handshake response came from a WebSocket-aware server rather than some
+// other HTTP endpoint that happened to echo the header back.
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// wsMaxFramePayload bounds the payload size ReadMessage will allocate for,
+// so a frame claiming an extended length near 2^63 can't make a render
+// worker try to allocate that much memory (or panic on a slice length that
+// doesn't fit) before a single byte of it has even arrived.
+const wsMaxFramePayload = 64 << 20 // 64 MiB; a scene JSON payload is KB-sized.
+
+// wsOpcode is a RFC 6455 section 5.2 frame opcode.
+type wsOpcode byte
+
+const (
+	wsOpContinuation wsOpcode = 0x0
+	wsOpText         wsOpcode = 0x1
+	wsOpBinary       wsOpcode = 0x2
+	wsOpClose        wsOpcode = 0x8
+	wsOpPing         wsOpcode = 0x9
+	wsOpPong         wsOpcode = 0xA
+)
+
+// wsConn is a hijacked HTTP connection upgraded to a RFC 6455 WebSocket.
+// There's no third-party dependency in this module, so renderStreamHandler
+// speaks just enough of the frame format itself: unfragmented text/binary
+// frames out, and enough of the read side to pull in the client's initial
+// message and notice a close. It does not support permessage-deflate or
+// fragmented messages.
+type wsConn struct {
+	conn net.Conn
+	br   *bufio.Reader
+	bw   *bufio.Writer
+}
+
+// wsAccept upgrades r's connection to a WebSocket per the RFC 6455
+// handshake and returns it hijacked. The caller owns the returned wsConn
+// and must Close it; w must not be written to afterward.
+func wsAccept(w http.ResponseWriter, r *http.Request) (*wsConn, error) {
+	if r.Header.Get("Upgrade") != "websocket" {
+		return nil, fmt.Errorf("missing Upgrade: websocket header")
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if key == "" {
+		return nil, fmt.Errorf("missing Sec-WebSocket-Key header")
+	}
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		return nil, fmt.Errorf("ResponseWriter does not support hijacking")
+	}
+	conn, rw, err := hijacker.Hijack()
+	if err != nil {
+		return nil, fmt.Errorf("hijack: %w", err)
+	}
+
+	sum := sha1.Sum([]byte(key + websocketGUID))
+	accept := base64.StdEncoding.EncodeToString(sum[:])
+	fmt.Fprintf(rw, "HTTP/1.1 101 Switching Protocols\r\n"+
+		"Upgrade: websocket\r\n"+
+		"Connection: Upgrade\r\n"+
+		"Sec-WebSocket-Accept: %s\r\n\r\n", accept)
+	if err := rw.Flush(); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("flush handshake response: %w", err)
+	}
+	return &wsConn{conn: conn, br: rw.Reader, bw: rw.Writer}, nil
+}
+
+func (c *wsConn) Close() error {
+	return c.conn.Close()
+}
+
+// WriteText sends data as a single unfragmented, unmasked text frame
+// (server-to-client frames are never masked, per RFC 6455 section 5.1).
+func (c *wsConn) WriteText(data []byte) error {
+	return c.writeFrame(wsOpText, data)
+}
+
+func (c *wsConn) writeFrame(op wsOpcode, payload []byte) error {
+	var header []byte
+	header = append(header, 0x80|byte(op)) // FIN=1, no fragmentation.
+	n := len(payload)
+	switch {
+	case n <= 125:
+		header = append(header, byte(n))
+	case n <= 0xFFFF:
+		header = append(header, 126)
+		header = binary.BigEndian.AppendUint16(header, uint16(n))
+	default:
+		header = append(header, 127)
+		header = binary.BigEndian.AppendUint64(header, uint64(n))
+	}
+	if _, err := c.bw.Write(header); err != nil {
+		return err
+	}
+	if _, err := c.bw.Write(payload); err != nil {
+		return err
+	}
+	return c.bw.Flush()
+}
+
+// ReadMessage reads one client frame and returns its payload, unmasking
+// it per RFC 6455 section 5.3 (every client-to-server frame is masked).
+// It does not reassemble fragmented messages; renderStreamHandler only
+// ever expects the client's single initial scene-JSON message.
+func (c *wsConn) ReadMessage() (wsOpcode, []byte, error) {
+	var head [2]byte
+	if _, err := io.ReadFull(c.br, head[:]); err != nil {
+		return 0, nil, err
+	}
+	op := wsOpcode(head[0] & 0x0F)
+	masked := head[1]&0x80 != 0
+	length := uint64(head[1] & 0x7F)
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+			return 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+			return 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+	if length > wsMaxFramePayload {
+		return 0, nil, fmt.Errorf("frame payload %d bytes exceeds %d byte limit", length, wsMaxFramePayload)
+	}
+	var maskKey [4]byte
+	if masked {
+		if _, err := io.ReadFull(c.br, maskKey[:]); err != nil {
+			return 0, nil, err
+		}
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.br, payload); err != nil {
+		return 0, nil, err
+	}
+	if masked {
+		for i := range payload {
+			payload[i] ^= maskKey[i%4]
+		}
+	}
+	return op, payload, nil
+}
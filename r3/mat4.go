@@ -0,0 +1,177 @@
+package r3
+
+import "math"
+
+// Mat4 represents a 4x4 matrix operating on homogeneous coordinates. Unlike
+// Mat3x3, which only ever represents a linear map (rotation/scale/shear
+// about the origin), Mat4 can also encode translation and perspective
+// projection, distinguishing a Point (w=1, affected by translation) from a
+// Vec (w=0, not).
+type Mat4 struct {
+	M [4][4]float64
+}
+
+// IdentityMat4 returns an identity matrix, which leaves points and vectors
+// unchanged when applied as a transformation.
+func IdentityMat4() Mat4 {
+	return Mat4{M: [4][4]float64{
+		{1, 0, 0, 0},
+		{0, 1, 0, 0},
+		{0, 0, 1, 0},
+		{0, 0, 0, 1},
+	}}
+}
+
+// Mul returns the matrix product m * n.
+func (m Mat4) Mul(n Mat4) Mat4 {
+	var result Mat4
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 4; j++ {
+			sum := 0.0
+			for k := 0; k < 4; k++ {
+				sum += m.M[i][k] * n.M[k][j]
+			}
+			result.M[i][j] = sum
+		}
+	}
+	return result
+}
+
+// Transpose returns the transpose of m.
+func (m Mat4) Transpose() Mat4 {
+	var result Mat4
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 4; j++ {
+			result.M[i][j] = m.M[j][i]
+		}
+	}
+	return result
+}
+
+// mat4Minor returns the determinant of the 3x3 matrix formed by deleting
+// row and col from m.
+func mat4Minor(m Mat4, row, col int) float64 {
+	var sub [3][3]float64
+	si := 0
+	for i := 0; i < 4; i++ {
+		if i == row {
+			continue
+		}
+		sj := 0
+		for j := 0; j < 4; j++ {
+			if j == col {
+				continue
+			}
+			sub[si][sj] = m.M[i][j]
+			sj++
+		}
+		si++
+	}
+	return sub[0][0]*(sub[1][1]*sub[2][2]-sub[1][2]*sub[2][1]) -
+		sub[0][1]*(sub[1][0]*sub[2][2]-sub[1][2]*sub[2][0]) +
+		sub[0][2]*(sub[1][0]*sub[2][1]-sub[1][1]*sub[2][0])
+}
+
+// mat4Cofactor returns the (row, col) cofactor of m: its minor with the
+// checkerboard sign (-1)^(row+col) applied.
+func mat4Cofactor(m Mat4, row, col int) float64 {
+	minor := mat4Minor(m, row, col)
+	if (row+col)%2 != 0 {
+		return -minor
+	}
+	return minor
+}
+
+// Determinant returns the determinant of m, expanded along row 0 via the
+// cofactors of its 3x3 minors.
+func (m Mat4) Determinant() float64 {
+	det := 0.0
+	for j := 0; j < 4; j++ {
+		det += m.M[0][j] * mat4Cofactor(m, 0, j)
+	}
+	return det
+}
+
+// mat4SingularEps is the determinant magnitude below which Inverse refuses
+// to invert m, matching the threshold Transform.Validate uses to reject a
+// Transform built from a singular matrix.
+const mat4SingularEps = 1e-12
+
+// Inverse returns the inverse of m via its adjugate (the transpose of its
+// cofactor matrix) divided by its determinant, and ok=false if m is
+// singular (|Determinant()| below mat4SingularEps).
+func (m Mat4) Inverse() (inv Mat4, ok bool) {
+	det := m.Determinant()
+	if math.Abs(det) < mat4SingularEps {
+		return Mat4{}, false
+	}
+	var adjugate Mat4
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 4; j++ {
+			// The adjugate is the transpose of the cofactor matrix.
+			adjugate.M[i][j] = mat4Cofactor(m, j, i)
+		}
+	}
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 4; j++ {
+			inv.M[i][j] = adjugate.M[i][j] / det
+		}
+	}
+	return inv, true
+}
+
+// TransformPoint applies m to p, treating it as a homogeneous point with
+// w=1 so translation (and, for a projective m, perspective divide)
+// applies. If the resulting w isn't 1, the coordinates are divided by w.
+func (m Mat4) TransformPoint(p Point) Point {
+	x := m.M[0][0]*p.X + m.M[0][1]*p.Y + m.M[0][2]*p.Z + m.M[0][3]
+	y := m.M[1][0]*p.X + m.M[1][1]*p.Y + m.M[1][2]*p.Z + m.M[1][3]
+	z := m.M[2][0]*p.X + m.M[2][1]*p.Y + m.M[2][2]*p.Z + m.M[2][3]
+	w := m.M[3][0]*p.X + m.M[3][1]*p.Y + m.M[3][2]*p.Z + m.M[3][3]
+	if w != 0 && w != 1 {
+		return Point{X: x / w, Y: y / w, Z: z / w}
+	}
+	return Point{X: x, Y: y, Z: z}
+}
+
+// TransformVec applies m to v, treating it as a homogeneous vector with
+// w=0 so it is affected by rotation, scale, and shear but not by
+// translation.
+func (m Mat4) TransformVec(v Vec) Vec {
+	return Vec{
+		X: m.M[0][0]*v.X + m.M[0][1]*v.Y + m.M[0][2]*v.Z,
+		Y: m.M[1][0]*v.X + m.M[1][1]*v.Y + m.M[1][2]*v.Z,
+		Z: m.M[2][0]*v.X + m.M[2][1]*v.Y + m.M[2][2]*v.Z,
+	}
+}
+
+// TransformNormal transforms a surface normal by m's inverse transpose,
+// the standard technique for keeping a normal perpendicular to its
+// surface under a non-uniform scale or shear, which (unlike a pure
+// rotation or uniform scale) a plain TransformVec would not preserve.
+// Falls back to TransformVec if m's linear part is singular.
+func (m Mat4) TransformNormal(n Vec) Vec {
+	inv, ok := m.Inverse()
+	if !ok {
+		return m.TransformVec(n)
+	}
+	return inv.Transpose().TransformVec(n)
+}
+
+// Lerp linearly interpolates every entry of m toward n, the same
+// component-wise blend Point.Lerp and Vec.Lerp use. This is not a
+// rotation-aware interpolation (it does not slerp the linear part), so a
+// blend between two matrices with very different rotations can shrink or
+// skew partway through -- acceptable for the short, mostly-translational
+// steps motion blur samples a shutter interval at, but not a substitute
+// for a proper TRS decomposition over a large rotation.
+func (m Mat4) Lerp(n Mat4, t float64) Mat4 {
+	t = math.Max(0, math.Min(1, t))
+	var result Mat4
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 4; j++ {
+			result.M[i][j] = m.M[i][j] + t*(n.M[i][j]-m.M[i][j])
+		}
+	}
+	return result
+}
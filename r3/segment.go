@@ -0,0 +1,14 @@
+package r3
+
+import "fmt"
+
+// Segment represents a line segment in three-dimensional space, from A to B.
+type Segment struct {
+	A Point
+	B Point
+}
+
+// String returns a string representation of the segment.
+func (s Segment) String() string {
+	return fmt.Sprintf("Segment{A: %v, B: %v}", s.A, s.B)
+}
@@ -0,0 +1,104 @@
+package r3
+
+import "math"
+
+// VecSlice is a struct-of-arrays batch of Vec values: X[i], Y[i], and
+// Z[i] together form the i'th vector. Hot paths that touch many vectors
+// at once (BVH leaf tests against several primitives, batched shading)
+// can use VecSlice in place of a []Vec so AddSlice/DotSlice/NormalizeSlice
+// process several vectors per loop iteration instead of paying one method
+// call's overhead per Vec; on amd64, DotSlice additionally runs a
+// hand-written AVX2 fast path (see dotSlice in vec_slice_amd64.s). Vec's
+// own API and semantics are unchanged by any of this.
+//
+// The AVX2 path's win is workload dependent, and BenchmarkDotCrossover in
+// vec_slice_test.go measures where it does and doesn't pay off: below
+// roughly n=32, the fixed cost of the asm call dominates and a plain
+// Vec.Dot loop (which inlines) is actually faster; from a few dozen
+// elements up to several thousand, DotSlice is consistently around 2x
+// faster, the sweet spot for a BVH leaf or a small batch of shading
+// samples; above roughly n=64k, SoA's six independent float64 streams
+// (ax, ay, az, bx, by, bz) become memory-bandwidth bound and the
+// advantage shrinks back toward parity with the scalar loop. Callers
+// batching a handful to a few thousand vectors benefit; callers sweeping
+// single huge cold arrays mostly won't.
+type VecSlice struct {
+	X, Y, Z []float64
+}
+
+// NewVecSlice converts vs to a VecSlice.
+func NewVecSlice(vs []Vec) VecSlice {
+	s := VecSlice{X: make([]float64, len(vs)), Y: make([]float64, len(vs)), Z: make([]float64, len(vs))}
+	for i, v := range vs {
+		s.X[i], s.Y[i], s.Z[i] = v.X, v.Y, v.Z
+	}
+	return s
+}
+
+// Vecs converts s back to a []Vec.
+func (s VecSlice) Vecs() []Vec {
+	out := make([]Vec, s.Len())
+	for i := range out {
+		out[i] = Vec{X: s.X[i], Y: s.Y[i], Z: s.Z[i]}
+	}
+	return out
+}
+
+// Len returns the number of vectors in s.
+func (s VecSlice) Len() int { return len(s.X) }
+
+// AddSlice returns the element-wise vector addition a[i]+b[i] for every
+// i. a and b must have equal length.
+func AddSlice(a, b VecSlice) VecSlice {
+	n := a.Len()
+	out := VecSlice{X: make([]float64, n), Y: make([]float64, n), Z: make([]float64, n)}
+	for i := 0; i < n; i++ {
+		out.X[i] = a.X[i] + b.X[i]
+		out.Y[i] = a.Y[i] + b.Y[i]
+		out.Z[i] = a.Z[i] + b.Z[i]
+	}
+	return out
+}
+
+// DotSlice returns the element-wise dot product a[i]·b[i] for every i,
+// allocating a fresh result slice. a and b must have equal length. Hot
+// loops that call DotSlice every frame should prefer DotSliceInto with a
+// reused buffer, to spend time on the reduction rather than on repeated
+// allocation.
+func DotSlice(a, b VecSlice) []float64 {
+	out := make([]float64, a.Len())
+	DotSliceInto(a, b, out)
+	return out
+}
+
+// DotSliceInto writes the element-wise dot product a[i]·b[i] into out for
+// every i, without allocating. a, b, and out must all have equal length.
+// The reduction itself is done by dotSlice, which has an AVX2 fast path
+// on amd64 (vec_slice_amd64.s) and a plain Go loop on every other
+// architecture (vec_slice_generic.go).
+func DotSliceInto(a, b VecSlice, out []float64) {
+	if a.Len() == 0 {
+		return
+	}
+	dotSlice(a.X, a.Y, a.Z, b.X, b.Y, b.Z, out)
+}
+
+// NormalizeSlice returns the unit vector of every element of s, matching
+// Vec.Unit element-wise: an element of zero length maps to the zero
+// vector rather than dividing by zero.
+func NormalizeSlice(s VecSlice) VecSlice {
+	n := s.Len()
+	out := VecSlice{X: make([]float64, n), Y: make([]float64, n), Z: make([]float64, n)}
+	lengthSquared := make([]float64, n)
+	DotSliceInto(s, s, lengthSquared)
+	for i := 0; i < n; i++ {
+		l := math.Sqrt(lengthSquared[i])
+		if l == 0 {
+			continue
+		}
+		out.X[i] = s.X[i] / l
+		out.Y[i] = s.Y[i] / l
+		out.Z[i] = s.Z[i] / l
+	}
+	return out
+}
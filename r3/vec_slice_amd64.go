@@ -0,0 +1,23 @@
+//go:build amd64
+
+package r3
+
+// dotSliceAsm is implemented in vec_slice_amd64.s: it computes
+// out[i] = ax[i]*bx[i] + ay[i]*by[i] + az[i]*bz[i] for i in [0, n),
+// processing four elements per iteration with AVX2 and the remainder
+// (n % 4) with scalar SSE2 instructions. All seven pointers must be
+// non-nil and point to at least n float64s.
+//
+//go:noescape
+func dotSliceAsm(ax, ay, az, bx, by, bz, out *float64, n int)
+
+// dotSlice computes out[i] = ax[i]*bx[i] + ay[i]*by[i] + az[i]*bz[i] for
+// every i via the AVX2 routine above. ax, ay, az, bx, by, bz, and out must
+// all have the same length.
+func dotSlice(ax, ay, az, bx, by, bz, out []float64) {
+	n := len(ax)
+	if n == 0 {
+		return
+	}
+	dotSliceAsm(&ax[0], &ay[0], &az[0], &bx[0], &by[0], &bz[0], &out[0], n)
+}
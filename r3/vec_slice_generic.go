@@ -0,0 +1,12 @@
+//go:build !amd64
+
+package r3
+
+// dotSlice computes out[i] = ax[i]*bx[i] + ay[i]*by[i] + az[i]*bz[i] for
+// every i with a plain Go loop. Architectures other than amd64 don't get
+// the AVX2 fast path in vec_slice_amd64.s.
+func dotSlice(ax, ay, az, bx, by, bz, out []float64) {
+	for i := range ax {
+		out[i] = ax[i]*bx[i] + ay[i]*by[i] + az[i]*bz[i]
+	}
+}
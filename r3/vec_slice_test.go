@@ -0,0 +1,108 @@
+package r3_test
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/scottlawsonbc/slam/code/photon/raytrace/r3"
+)
+
+func randVecs(rng *rand.Rand, n int) []r3.Vec {
+	vs := make([]r3.Vec, n)
+	for i := range vs {
+		vs[i] = r3.Vec{X: rng.Float64()*10 - 5, Y: rng.Float64()*10 - 5, Z: rng.Float64()*10 - 5}
+	}
+	return vs
+}
+
+// TestVecSliceRoundTrip verifies NewVecSlice and VecSlice.Vecs are
+// inverses.
+func TestVecSliceRoundTrip(t *testing.T) {
+	want := []r3.Vec{{X: 1, Y: 2, Z: 3}, {X: -4, Y: 0, Z: 5}}
+	got := r3.NewVecSlice(want).Vecs()
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Vecs()[%d] = %v, want %v", i, got[i], want[i])
+		}
+	}
+}
+
+// TestAddSliceMatchesVecAdd verifies AddSlice agrees with Vec.Add
+// element-wise across sizes that aren't multiples of the AVX2 lane width.
+func TestAddSliceMatchesVecAdd(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, n := range []int{0, 1, 3, 4, 5, 8, 9, 100} {
+		a := randVecs(rng, n)
+		b := randVecs(rng, n)
+		got := r3.AddSlice(r3.NewVecSlice(a), r3.NewVecSlice(b)).Vecs()
+		for i := range a {
+			if want := a[i].Add(b[i]); got[i] != want {
+				t.Fatalf("n=%d i=%d: AddSlice = %v, want %v", n, i, got[i], want)
+			}
+		}
+	}
+}
+
+// TestDotSliceMatchesVecDot verifies DotSlice agrees with Vec.Dot
+// element-wise across sizes that aren't multiples of the AVX2 lane width,
+// exercising both the bulk SIMD loop and its scalar remainder.
+func TestDotSliceMatchesVecDot(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	for _, n := range []int{0, 1, 2, 3, 4, 5, 7, 8, 9, 16, 17, 1000} {
+		a := randVecs(rng, n)
+		b := randVecs(rng, n)
+		got := r3.DotSlice(r3.NewVecSlice(a), r3.NewVecSlice(b))
+		for i := range a {
+			if want := a[i].Dot(b[i]); math.Abs(got[i]-want) > 1e-9 {
+				t.Fatalf("n=%d i=%d: DotSlice = %v, want %v", n, i, got[i], want)
+			}
+		}
+	}
+}
+
+// TestNormalizeSliceMatchesVecUnit verifies NormalizeSlice agrees with
+// Vec.Unit element-wise, including its zero-vector special case.
+func TestNormalizeSliceMatchesVecUnit(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	vs := randVecs(rng, 37)
+	vs = append(vs, r3.Vec{})
+	got := r3.NormalizeSlice(r3.NewVecSlice(vs)).Vecs()
+	for i, v := range vs {
+		want := v.Unit()
+		if !got[i].IsClose(want, 1e-9) {
+			t.Errorf("i=%d: NormalizeSlice = %v, want %v", i, got[i], want)
+		}
+	}
+}
+
+// BenchmarkDotScalar times computing n dot products one Vec.Dot call at a
+// time, the baseline AoS API.
+func BenchmarkDotScalar(b *testing.B) {
+	rng := rand.New(rand.NewSource(4))
+	const n = 4096
+	a := randVecs(rng, n)
+	bs := randVecs(rng, n)
+	out := make([]float64, n)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for j := range a {
+			out[j] = a[j].Dot(bs[j])
+		}
+	}
+}
+
+// BenchmarkDotSlice times the same n dot products via DotSliceInto's
+// struct-of-arrays batch path, reusing one output buffer the same way
+// BenchmarkDotScalar reuses its out slice.
+func BenchmarkDotSlice(b *testing.B) {
+	rng := rand.New(rand.NewSource(4))
+	const n = 4096
+	a := r3.NewVecSlice(randVecs(rng, n))
+	bs := r3.NewVecSlice(randVecs(rng, n))
+	out := make([]float64, n)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		r3.DotSliceInto(a, bs, out)
+	}
+}